@@ -0,0 +1,37 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+ALTER TABLE tuple ADD COLUMN user_object_type VARCHAR(128) NOT NULL DEFAULT '';
+ALTER TABLE tuple ADD COLUMN user_relation VARCHAR(50) NOT NULL DEFAULT '';
+
+-- backfill the tuples in ranges of 10000 ULIDs, each committed on its own, so that large tables
+-- are not locked and rewritten in a single transaction
+-- +goose StatementBegin
+CREATE PROCEDURE backfill_userset_type()
+BEGIN
+    DECLARE last_ulid CHAR(26) DEFAULT '';
+    DECLARE batch_end CHAR(26);
+
+    backfill: LOOP
+        SELECT MAX(ulid) INTO batch_end FROM (
+            SELECT ulid FROM tuple WHERE ulid > last_ulid ORDER BY ulid LIMIT 10000
+        ) AS batch;
+        IF batch_end IS NULL THEN
+            LEAVE backfill;
+        END IF;
+
+        UPDATE tuple SET user_object_type = SUBSTRING_INDEX(_user, ':', 1), user_relation = IF(LOCATE('#', _user) > 0, SUBSTRING_INDEX(_user, '#', -1), '')
+        WHERE ulid > last_ulid AND ulid <= batch_end;
+
+        SET last_ulid = batch_end;
+    END LOOP;
+END;
+-- +goose StatementEnd
+CALL backfill_userset_type();
+DROP PROCEDURE backfill_userset_type;
+
+CREATE INDEX idx_tuple_userset_type ON tuple (store, object_type, object_id, relation, user_type, user_object_type, user_relation);
+
+-- +goose Down
+DROP INDEX idx_tuple_userset_type ON tuple;
+ALTER TABLE tuple DROP COLUMN user_relation;
+ALTER TABLE tuple DROP COLUMN user_object_type;
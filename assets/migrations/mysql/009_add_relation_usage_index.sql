@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX idx_tuple_relation_usage ON tuple (store, object_type, relation);
+
+-- +goose Down
+DROP INDEX idx_tuple_relation_usage ON tuple;
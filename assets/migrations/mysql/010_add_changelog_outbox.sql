@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE changelog_outbox (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    store CHAR(26) NOT NULL,
+    object_type VARCHAR(256) NOT NULL,
+    object_id VARCHAR(256) NOT NULL,
+    relation VARCHAR(50) NOT NULL,
+    _user VARCHAR(512) NOT NULL,
+    operation INTEGER NOT NULL,
+    condition_name VARCHAR(256),
+    condition_context LONGBLOB,
+    PRIMARY KEY (id)
+);
+
+-- +goose Down
+DROP TABLE changelog_outbox;
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE changelog_trim (
+    store CHAR(26) NOT NULL,
+    ulid CHAR(26) NOT NULL,
+    PRIMARY KEY (store)
+);
+
+-- +goose Down
+DROP TABLE changelog_trim;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX idx_tuple_read_order ON tuple (store, (CAST(object_type AS BINARY)), (CAST(object_id AS BINARY)), (CAST(relation AS BINARY)), (CAST(_user AS BINARY)));
+
+-- +goose Down
+DROP INDEX idx_tuple_read_order ON tuple;
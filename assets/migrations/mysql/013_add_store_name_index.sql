@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX idx_store_name ON store (name, deleted_at);
+
+-- +goose Down
+DROP INDEX idx_store_name ON store;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX idx_store_deleted_at ON store (deleted_at);
+
+-- +goose Down
+DROP INDEX idx_store_deleted_at ON store;
@@ -0,0 +1,6 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_tuple_read_order ON tuple (store, object_type COLLATE "C", object_id COLLATE "C", relation COLLATE "C", _user COLLATE "C");
+
+-- +goose Down
+DROP INDEX CONCURRENTLY IF EXISTS idx_tuple_read_order;
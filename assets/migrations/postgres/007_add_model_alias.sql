@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE model_alias (
+	store TEXT NOT NULL,
+	alias TEXT NOT NULL,
+	authorization_model_id TEXT NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (store, alias)
+);
+
+-- +goose Down
+DROP TABLE model_alias;
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE tuple_count (
+	store TEXT NOT NULL,
+	object_type TEXT NOT NULL,
+	tuples BIGINT NOT NULL,
+	PRIMARY KEY (store, object_type)
+);
+
+-- +goose Down
+DROP TABLE tuple_count;
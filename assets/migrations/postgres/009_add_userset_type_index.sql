@@ -0,0 +1,35 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+ALTER TABLE tuple ADD COLUMN user_object_type TEXT NOT NULL DEFAULT '';
+ALTER TABLE tuple ADD COLUMN user_relation TEXT NOT NULL DEFAULT '';
+
+-- backfill the tuples in ranges of 10000 ULIDs, each committed on its own, so that large tables
+-- are not locked and rewritten in a single transaction
+-- +goose StatementBegin
+DO $$
+DECLARE
+    last_ulid TEXT := '';
+    batch_end TEXT;
+BEGIN
+    LOOP
+        SELECT MAX(ulid) INTO batch_end FROM (
+            SELECT ulid FROM tuple WHERE ulid > last_ulid ORDER BY ulid LIMIT 10000
+        ) AS batch;
+        EXIT WHEN batch_end IS NULL;
+
+        UPDATE tuple SET user_object_type = split_part(_user, ':', 1), user_relation = split_part(_user, '#', 2)
+        WHERE ulid > last_ulid AND ulid <= batch_end;
+
+        last_ulid := batch_end;
+        COMMIT;
+    END LOOP;
+END
+$$;
+-- +goose StatementEnd
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_tuple_userset_type ON tuple (store, object_type, object_id, relation, user_object_type, user_relation) WHERE user_type = 'userset';
+
+-- +goose Down
+DROP INDEX CONCURRENTLY IF EXISTS idx_tuple_userset_type;
+ALTER TABLE tuple DROP COLUMN user_relation;
+ALTER TABLE tuple DROP COLUMN user_object_type;
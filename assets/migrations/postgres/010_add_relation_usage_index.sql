@@ -0,0 +1,6 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_tuple_relation_usage ON tuple (store, object_type, relation);
+
+-- +goose Down
+DROP INDEX CONCURRENTLY IF EXISTS idx_tuple_relation_usage;
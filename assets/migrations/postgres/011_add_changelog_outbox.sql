@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE changelog_outbox (
+	id BIGSERIAL PRIMARY KEY,
+	store TEXT NOT NULL,
+	object_type TEXT NOT NULL,
+	object_id TEXT NOT NULL,
+	relation TEXT NOT NULL,
+	_user TEXT NOT NULL,
+	operation INTEGER NOT NULL,
+	condition_name TEXT,
+	condition_context BYTEA
+);
+
+-- +goose Down
+DROP TABLE changelog_outbox;
@@ -0,0 +1,8 @@
+-- +goose Up
+CREATE TABLE changelog_trim (
+	store TEXT PRIMARY KEY,
+	ulid TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE changelog_trim;
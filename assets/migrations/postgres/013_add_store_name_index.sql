@@ -0,0 +1,6 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_store_name ON store (name) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX CONCURRENTLY IF EXISTS idx_store_name;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE model_alias (
+    store CHAR(26) NOT NULL,
+    alias VARCHAR(64) NOT NULL,
+    authorization_model_id CHAR(26) NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (store, alias)
+);
+
+-- +goose Down
+DROP TABLE model_alias;
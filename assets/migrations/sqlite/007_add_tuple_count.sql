@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE tuple_count (
+    store CHAR(26) NOT NULL,
+    object_type VARCHAR(128) NOT NULL,
+    tuples BIGINT NOT NULL,
+    PRIMARY KEY (store, object_type)
+);
+
+-- +goose Down
+DROP TABLE tuple_count;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE changelog_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    store CHAR(26) NOT NULL,
+    object_type VARCHAR(256) NOT NULL,
+    object_id VARCHAR(256) NOT NULL,
+    relation VARCHAR(50) NOT NULL,
+    user_object_type VARCHAR(128) NOT NULL,
+    user_object_id VARCHAR(128) NOT NULL,
+    user_relation VARCHAR(50) NOT NULL,
+    operation INTEGER NOT NULL,
+    condition_name VARCHAR(256),
+    condition_context LONGBLOB
+);
+
+-- +goose Down
+DROP TABLE changelog_outbox;
@@ -0,0 +1,8 @@
+-- +goose Up
+CREATE TABLE changelog_trim (
+    store CHAR(26) PRIMARY KEY,
+    ulid CHAR(26) NOT NULL
+);
+
+-- +goose Down
+DROP TABLE changelog_trim;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX idx_tuple_read_order ON tuple (store, object_type, object_id, relation, (user_object_type || ':' || user_object_id || CASE WHEN user_relation = '' THEN '' ELSE '#' || user_relation END));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tuple_read_order;
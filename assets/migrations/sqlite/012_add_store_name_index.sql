@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX idx_store_name ON store (name) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_store_name;
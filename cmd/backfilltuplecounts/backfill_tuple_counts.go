@@ -0,0 +1,136 @@
+// Package backfilltuplecounts contains the command to recompute the tuple counters of the stores.
+package backfilltuplecounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/mysql"
+	"github.com/openfga/openfga/pkg/storage/postgres"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+	"github.com/openfga/openfga/pkg/storage/sqlite"
+)
+
+const (
+	datastoreEngineFlag = "datastore-engine"
+	datastoreURIFlag    = "datastore-uri"
+	storeIDFlag         = "store-id"
+)
+
+func NewBackfillTupleCountsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backfill-tuple-counts",
+		Short: "Recompute the tuple counters of the stores from their tuples",
+		Long: `With the datastore-tuple-counts setting, the datastores count the tuples of each store and object
+type as they are written, to enforce the tuple quotas cheaply. The backfill-tuple-counts command
+recomputes the counters from the tuples, e.g. to count the tuples written before the counters were
+enabled. Run it once after enabling them. Each store is recounted in one transaction, during which
+the writes of its tuples wait.`,
+		RunE: runBackfillTupleCounts,
+		Args: cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+	flags.String(datastoreEngineFlag, "", "the datastore engine")
+	flags.String(datastoreURIFlag, "", "the connection uri to the datastore")
+	flags.String(storeIDFlag, "", "the ID of the store to recount (if omitted every store is recounted)")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindRunFlagsFunc(flags)
+
+	return cmd
+}
+
+func runBackfillTupleCounts(_ *cobra.Command, _ []string) error {
+	engine := viper.GetString(datastoreEngineFlag)
+	uri := viper.GetString(datastoreURIFlag)
+	storeID := viper.GetString(storeIDFlag)
+
+	var (
+		db  storage.OpenFGADatastore
+		err error
+	)
+	cfg := sqlcommon.NewConfig(sqlcommon.WithTupleCounts(true))
+	switch engine {
+	case "mysql":
+		db, err = mysql.New(uri, cfg)
+	case "postgres":
+		db, err = postgres.New(uri, cfg)
+	case "sqlite":
+		db, err = sqlite.New(uri, cfg)
+	case "":
+		return fmt.Errorf("missing datastore engine type")
+	case "memory":
+		fallthrough
+	default:
+		return fmt.Errorf("storage engine '%s' is unsupported", engine)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to open a connection to the datastore: %v", err)
+	}
+	defer db.Close()
+
+	counter, ok := db.(storage.TupleCounter)
+	if !ok {
+		return fmt.Errorf("storage engine '%s' does not support counting tuples", engine)
+	}
+
+	return BackfillTupleCounts(context.Background(), db, counter, storeID)
+}
+
+// BackfillTupleCounts recomputes the tuple counters of the given store, or of every store if
+// storeID is empty, logging the progress.
+func BackfillTupleCounts(ctx context.Context, stores storage.StoresBackend, counter storage.TupleCounter, storeID string) error {
+	if storeID != "" {
+		if _, err := stores.GetStore(ctx, storeID); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return fmt.Errorf("store '%s' does not exist", storeID)
+			}
+			return fmt.Errorf("error reading store '%s': %w", storeID, err)
+		}
+		return backfillStore(ctx, counter, storeID)
+	}
+
+	var continuationToken string
+	for {
+		page, token, err := stores.ListStores(ctx, storage.ListStoresOptions{
+			Pagination: storage.NewPaginationOptions(storage.DefaultPageSize, continuationToken),
+		})
+		if err != nil {
+			return fmt.Errorf("error listing stores: %w", err)
+		}
+
+		for _, store := range page {
+			if err := backfillStore(ctx, counter, store.GetId()); err != nil {
+				return err
+			}
+		}
+
+		if len(token) == 0 {
+			return nil
+		}
+		continuationToken = string(token)
+	}
+}
+
+func backfillStore(ctx context.Context, counter storage.TupleCounter, storeID string) error {
+	if err := counter.BackfillTupleCounts(ctx, storeID); err != nil {
+		return fmt.Errorf("error counting the tuples of store '%s': %w", storeID, err)
+	}
+
+	counts, err := counter.ReadTupleCounts(ctx, storeID)
+	if err != nil {
+		return fmt.Errorf("error reading the tuple counters of store '%s': %w", storeID, err)
+	}
+	log.Printf("store '%s': %d tuples", storeID, counts.Total)
+
+	return nil
+}
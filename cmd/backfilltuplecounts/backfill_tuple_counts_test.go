@@ -0,0 +1,83 @@
+package backfilltuplecounts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/cmd/util"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+	"github.com/openfga/openfga/pkg/storage/sqlite"
+	storagetest "github.com/openfga/openfga/pkg/storage/test"
+)
+
+func TestBackfillTupleCounts(t *testing.T) {
+	_, _, uri := util.MustBootstrapDatastore(t, "sqlite")
+	ds, err := sqlite.New(uri, sqlcommon.NewConfig(sqlcommon.WithTupleCounts(true)))
+	require.NoError(t, err)
+	t.Cleanup(ds.Close)
+	counter := storage.TupleCounter(ds)
+
+	ctx := context.Background()
+
+	storeID, _ := storagetest.BootstrapFGAStore(t, ds, `
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`,
+		[]string{"document:1#viewer@user:anne", "document:2#viewer@user:anne"},
+	)
+	_, err = ds.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: "backfill"})
+	require.NoError(t, err)
+
+	t.Run("unknown_store", func(t *testing.T) {
+		err := BackfillTupleCounts(ctx, ds, counter, ulid.Make().String())
+		require.ErrorContains(t, err, "does not exist")
+	})
+
+	t.Run("every_store", func(t *testing.T) {
+		require.NoError(t, BackfillTupleCounts(ctx, ds, counter, ""))
+
+		counts, err := counter.ReadTupleCounts(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), counts.Total)
+		require.Equal(t, map[string]int64{"document": 2}, counts.ByObjectType)
+	})
+
+	t.Run("one_store", func(t *testing.T) {
+		require.NoError(t, BackfillTupleCounts(ctx, ds, counter, storeID))
+
+		counts, err := counter.ReadTupleCounts(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), counts.Total)
+	})
+}
+
+func TestBackfillTupleCountsCommandWhenInvalidEngine(t *testing.T) {
+	for _, tc := range []struct {
+		engine        string
+		errorExpected string
+	}{
+		{
+			engine:        "memory",
+			errorExpected: "storage engine 'memory' is unsupported",
+		},
+		{
+			engine:        "",
+			errorExpected: "missing datastore engine type",
+		},
+	} {
+		t.Run(tc.engine, func(t *testing.T) {
+			backfillCommand := NewBackfillTupleCountsCommand()
+			backfillCommand.SetArgs([]string{"--datastore-engine", tc.engine, "--datastore-uri", ""})
+			err := backfillCommand.Execute()
+			require.ErrorContains(t, err, tc.errorExpected)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Package indexadvice contains the command to print the indexes recommended by a running server.
+package indexadvice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/server/indexadvice"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+const (
+	grpcAddrFlag = "grpc-addr"
+	ddlFlag      = "ddl"
+	timeoutFlag  = "timeout"
+)
+
+func NewIndexAdviceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index-advice",
+		Short: "Print the indexes recommended for the query shapes sampled by a running server",
+		Long: `With the datastore-query-shape-sampling setting, the SQL datastores sample the shapes of their
+tuple queries. The index-advice command reads the sampled shapes and the indexes of the tuple table
+that they call for from a running server over gRPC. The server only answers callers connecting from
+a loopback address, so run it on the host of the server.`,
+		RunE: runIndexAdvice,
+		Args: cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+	flags.String(grpcAddrFlag, "127.0.0.1:8081", "the gRPC address of the server")
+	flags.Bool(ddlFlag, false, "print only the DDL of the recommended indexes that the migrations do not create, one statement per line")
+	flags.Duration(timeoutFlag, 10*time.Second, "the timeout of the request to the server")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindRunFlagsFunc(flags)
+
+	return cmd
+}
+
+func runIndexAdvice(_ *cobra.Command, _ []string) error {
+	addr := viper.GetString(grpcAddrFlag)
+	ddl := viper.GetBool(ddlFlag)
+	timeout := viper.GetDuration(timeoutFlag)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("failed to connect to '%s': %w", addr, err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return PrintIndexAdvice(ctx, conn, os.Stdout, ddl)
+}
+
+// PrintIndexAdvice reads the index advice of the server of conn with the GetIndexAdvice method of
+// the [indexadvice.ServiceDesc] service, and writes it to w as indented JSON, or only the DDL of
+// the recommended indexes that the migrations of the datastore do not create if ddl is true.
+func PrintIndexAdvice(ctx context.Context, conn grpc.ClientConnInterface, w io.Writer, ddl bool) error {
+	resp := &structpb.Struct{}
+	if err := conn.Invoke(ctx, indexadvice.GetIndexAdviceFullMethodName, &emptypb.Empty{}, resp); err != nil {
+		return fmt.Errorf("failed to get the index advice: %w", err)
+	}
+
+	b, err := protojson.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to decode the index advice: %w", err)
+	}
+	var advice storage.IndexAdvice
+	if err := json.Unmarshal(b, &advice); err != nil {
+		return fmt.Errorf("failed to decode the index advice: %w", err)
+	}
+
+	if ddl {
+		for _, recommendation := range advice.Recommendations {
+			if !recommendation.Default {
+				if _, err := fmt.Fprintln(w, recommendation.DDL); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(advice)
+}
@@ -0,0 +1,100 @@
+package indexadvice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/cmd/util"
+	"github.com/openfga/openfga/pkg/gateway"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+	"github.com/openfga/openfga/pkg/storage/sqlite"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// serve serves the gRPC services of a server on ds on a loopback address, and returns a client
+// connection to it.
+func serve(t *testing.T, ds storage.OpenFGADatastore) *grpc.ClientConn {
+	s := server.MustNewServerWithOpts(server.WithDatastore(ds), server.WithTransport(gateway.NewRPCTransport(logger.NewNoopLogger())))
+	t.Cleanup(s.Close)
+
+	grpcServer := grpc.NewServer()
+	server.RegisterGRPC(grpcServer, s)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+func TestPrintIndexAdvice(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("datastore_without_advisor", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+		conn := serve(t, ds)
+
+		err := PrintIndexAdvice(ctx, conn, &bytes.Buffer{}, false)
+		require.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+
+	_, _, uri := util.MustBootstrapDatastore(t, "sqlite")
+	ds, err := sqlite.New(uri, sqlcommon.NewConfig(sqlcommon.WithQueryShapeSampling(true)))
+	require.NoError(t, err)
+	t.Cleanup(ds.Close)
+	conn := serve(t, ds)
+
+	for i := 0; i < sqlcommon.MinIndexAdviceQueries; i++ {
+		iter, err := ds.ReadUsersetTuples(ctx, ulid.Make().String(), storage.ReadUsersetTuplesFilter{
+			Object:                      "document:1",
+			Relation:                    "viewer",
+			AllowedUserTypeRestrictions: []*openfgav1.RelationReference{typesystem.DirectRelationReference("group", "member")},
+		}, storage.ReadUsersetTuplesOptions{})
+		require.NoError(t, err)
+		iter.Stop()
+	}
+
+	advice, err := ds.AdviseIndexes(ctx)
+	require.NoError(t, err)
+	require.Len(t, advice.Recommendations, 1)
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, PrintIndexAdvice(ctx, conn, &out, false))
+
+		var printed storage.IndexAdvice
+		require.NoError(t, json.Unmarshal(out.Bytes(), &printed))
+		require.Equal(t, advice.Recommendations, printed.Recommendations)
+		require.Equal(t, advice.Shapes, printed.Shapes)
+	})
+
+	t.Run("ddl", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, PrintIndexAdvice(ctx, conn, &out, true))
+		require.Equal(t, advice.Recommendations[0].DDL+"\n", out.String())
+	})
+}
@@ -5,7 +5,10 @@ import (
 	"os"
 
 	"github.com/openfga/openfga/cmd"
+	"github.com/openfga/openfga/cmd/backfilltuplecounts"
+	"github.com/openfga/openfga/cmd/indexadvice"
 	"github.com/openfga/openfga/cmd/migrate"
+	"github.com/openfga/openfga/cmd/purgestore"
 	"github.com/openfga/openfga/cmd/run"
 	"github.com/openfga/openfga/cmd/validatemodels"
 )
@@ -22,6 +25,15 @@ func main() {
 	validateModelsCmd := validatemodels.NewValidateCommand()
 	rootCmd.AddCommand(validateModelsCmd)
 
+	purgeStoreCmd := purgestore.NewPurgeStoreCommand()
+	rootCmd.AddCommand(purgeStoreCmd)
+
+	backfillTupleCountsCmd := backfilltuplecounts.NewBackfillTupleCountsCommand()
+	rootCmd.AddCommand(backfillTupleCountsCmd)
+
+	indexAdviceCmd := indexadvice.NewIndexAdviceCommand()
+	rootCmd.AddCommand(indexAdviceCmd)
+
 	versionCmd := cmd.NewVersionCommand()
 	rootCmd.AddCommand(versionCmd)
 
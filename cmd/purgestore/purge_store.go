@@ -0,0 +1,199 @@
+// Package purgestore contains the command to delete the data of deleted stores.
+package purgestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/mysql"
+	"github.com/openfga/openfga/pkg/storage/postgres"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+	"github.com/openfga/openfga/pkg/storage/sqlite"
+)
+
+const (
+	datastoreEngineFlag = "datastore-engine"
+	datastoreURIFlag    = "datastore-uri"
+	storeIDFlag         = "store-id"
+	batchSizeFlag       = "batch-size"
+	batchIntervalFlag   = "batch-interval"
+	metricsAddrFlag     = "metrics-addr"
+)
+
+var (
+	purgeBatchesCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "purge_store_batches_count",
+		Help:      "The total number of batches of rows of deleted stores deleted by purge-store.",
+	})
+
+	purgeBatchErrorsCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "purge_store_batch_errors_count",
+		Help:      "The total number of batches of purge-store that failed.",
+	})
+
+	purgeRowsDeletedCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "purge_store_rows_deleted_count",
+		Help:      "The total number of rows of deleted stores deleted by purge-store.",
+	})
+
+	purgeStoresPurgedCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "purge_store_stores_purged_count",
+		Help:      "The total number of deleted stores whose data purge-store deleted entirely.",
+	})
+
+	purgeBatchDurationHistogram = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace:                       build.ProjectName,
+		Name:                            "purge_store_batch_duration_ms",
+		Help:                            "The time it takes purge-store to delete a batch of rows of a deleted store.",
+		Buckets:                         []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 5000, 30000},
+		NativeHistogramBucketFactor:     1.1,
+		NativeHistogramMaxBucketNumber:  100,
+		NativeHistogramMinResetDuration: time.Hour,
+	})
+)
+
+func NewPurgeStoreCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "purge-store",
+		Short: "Delete the tuples, changelog and authorization models of deleted stores in batches",
+		Long: `DeleteStore only marks a store as deleted, so that its APIs fail right away, and keeps its data.
+The purge-store command deletes the data of deleted stores in bounded batches, so that large stores
+do not hold a long transaction. It can be stopped at any time and run again to resume the purge.`,
+		RunE: runPurgeStore,
+		Args: cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+	flags.String(datastoreEngineFlag, "", "the datastore engine")
+	flags.String(datastoreURIFlag, "", "the connection uri to the datastore")
+	flags.String(storeIDFlag, "", "the ID of the deleted store to purge (if omitted every deleted store is purged)")
+	flags.Int(batchSizeFlag, 1000, "the maximum number of rows deleted per batch")
+	flags.Duration(batchIntervalFlag, 0, "the pause between batches, e.g. to let the database reclaim the deleted rows")
+	flags.String(metricsAddrFlag, "", "the address to serve the prometheus metrics of the purge on, e.g. '0.0.0.0:2112' (if omitted the metrics are not served)")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindRunFlagsFunc(flags)
+
+	return cmd
+}
+
+func runPurgeStore(_ *cobra.Command, _ []string) error {
+	engine := viper.GetString(datastoreEngineFlag)
+	uri := viper.GetString(datastoreURIFlag)
+	storeID := viper.GetString(storeIDFlag)
+	batchSize := viper.GetInt(batchSizeFlag)
+	batchInterval := viper.GetDuration(batchIntervalFlag)
+	metricsAddr := viper.GetString(metricsAddrFlag)
+
+	if batchSize <= 0 {
+		return fmt.Errorf("'%s' must be greater than 0", batchSizeFlag)
+	}
+
+	var (
+		db  storage.OpenFGADatastore
+		err error
+	)
+	switch engine {
+	case "mysql":
+		db, err = mysql.New(uri, sqlcommon.NewConfig())
+	case "postgres":
+		db, err = postgres.New(uri, sqlcommon.NewConfig())
+	case "sqlite":
+		db, err = sqlite.New(uri, sqlcommon.NewConfig())
+	case "":
+		return fmt.Errorf("missing datastore engine type")
+	case "memory":
+		fallthrough
+	default:
+		return fmt.Errorf("storage engine '%s' is unsupported", engine)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to open a connection to the datastore: %v", err)
+	}
+	defer db.Close()
+
+	purger, ok := db.(storage.StorePurger)
+	if !ok {
+		return fmt.Errorf("storage engine '%s' does not support purging stores", engine)
+	}
+
+	if metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+
+		metricsServer := &http.Server{Addr: metricsAddr, Handler: mux}
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("failed to serve the metrics on '%s': %v", metricsAddr, err)
+			}
+		}()
+		defer metricsServer.Close()
+	}
+
+	return PurgeStores(context.Background(), purger, storeID, batchSize, batchInterval)
+}
+
+// PurgeStores purges the given deleted store, or every deleted store if storeID is empty,
+// in batches of at most batchSize rows, logging the progress of each store. The batches, their
+// duration and the rows deleted are counted by the purge_store_* metrics.
+func PurgeStores(ctx context.Context, purger storage.StorePurger, storeID string, batchSize int, batchInterval time.Duration) error {
+	stores := []string{storeID}
+	if storeID == "" {
+		var err error
+		stores, err = purger.ListDeletedStores(ctx)
+		if err != nil {
+			return fmt.Errorf("error listing deleted stores: %w", err)
+		}
+		log.Printf("%d deleted stores to purge", len(stores))
+	}
+
+	for _, store := range stores {
+		var total int64
+		for {
+			start := time.Now()
+			deleted, err := purger.PurgeStore(ctx, store, batchSize)
+			purgeBatchDurationHistogram.Observe(float64(time.Since(start).Milliseconds()))
+			purgeBatchesCounter.Inc()
+			if err != nil {
+				purgeBatchErrorsCounter.Inc()
+				if errors.Is(err, storage.ErrNotFound) {
+					return fmt.Errorf("store '%s' does not exist or is not deleted", store)
+				}
+				return fmt.Errorf("error purging store '%s' after %d rows: %w", store, total, err)
+			}
+			if deleted == 0 {
+				break
+			}
+
+			total += deleted
+			purgeRowsDeletedCounter.Add(float64(deleted))
+			log.Printf("store '%s': %d rows deleted", store, total)
+
+			if batchInterval > 0 {
+				time.Sleep(batchInterval)
+			}
+		}
+		purgeStoresPurgedCounter.Inc()
+		log.Printf("store '%s' purged", store)
+	}
+
+	return nil
+}
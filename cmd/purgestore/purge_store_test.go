@@ -0,0 +1,95 @@
+package purgestore
+
+import (
+	"context"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/cmd/util"
+	"github.com/openfga/openfga/pkg/storage"
+	storagetest "github.com/openfga/openfga/pkg/storage/test"
+)
+
+func TestPurgeStores(t *testing.T) {
+	_, ds, _ := util.MustBootstrapDatastore(t, "sqlite")
+	purger := ds.(storage.StorePurger)
+
+	ctx := context.Background()
+
+	var storeIDs []string
+	for i := 0; i < 2; i++ {
+		storeID, _ := storagetest.BootstrapFGAStore(t, ds, `
+			model
+				schema 1.1
+			type user
+			type document
+				relations
+					define viewer: [user]`,
+			[]string{"document:1#viewer@user:anne", "document:2#viewer@user:anne"},
+		)
+		_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: "purge"})
+		require.NoError(t, err)
+		storeIDs = append(storeIDs, storeID)
+	}
+
+	t.Run("store_not_deleted", func(t *testing.T) {
+		initialErrors := promtestutil.ToFloat64(purgeBatchErrorsCounter)
+
+		err := PurgeStores(ctx, purger, storeIDs[0], 1, 0)
+		require.ErrorContains(t, err, "does not exist or is not deleted")
+		require.InDelta(t, initialErrors+1, promtestutil.ToFloat64(purgeBatchErrorsCounter), 0)
+	})
+
+	t.Run("every_deleted_store", func(t *testing.T) {
+		require.NoError(t, ds.DeleteStore(ctx, storeIDs[0]))
+
+		initialBatches := promtestutil.ToFloat64(purgeBatchesCounter)
+		initialRows := promtestutil.ToFloat64(purgeRowsDeletedCounter)
+		initialStores := promtestutil.ToFloat64(purgeStoresPurgedCounter)
+
+		require.NoError(t, PurgeStores(ctx, purger, "", 1, 0))
+
+		// batches of one row, and a last batch that finds no rows left
+		rows := promtestutil.ToFloat64(purgeRowsDeletedCounter) - initialRows
+		require.Greater(t, rows, float64(0))
+		require.InDelta(t, rows+1, promtestutil.ToFloat64(purgeBatchesCounter)-initialBatches, 0)
+		require.InDelta(t, initialStores+1, promtestutil.ToFloat64(purgeStoresPurgedCounter), 0)
+		require.Equal(t, 1, promtestutil.CollectAndCount(purgeBatchDurationHistogram))
+
+		deletedStores, err := purger.ListDeletedStores(ctx)
+		require.NoError(t, err)
+		require.Empty(t, deletedStores)
+
+		_, err = ds.FindLatestAuthorizationModel(ctx, storeIDs[0])
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		_, err = ds.FindLatestAuthorizationModel(ctx, storeIDs[1])
+		require.NoError(t, err)
+	})
+}
+
+func TestPurgeStoreCommandWhenInvalidEngine(t *testing.T) {
+	for _, tc := range []struct {
+		engine        string
+		errorExpected string
+	}{
+		{
+			engine:        "memory",
+			errorExpected: "storage engine 'memory' is unsupported",
+		},
+		{
+			engine:        "",
+			errorExpected: "missing datastore engine type",
+		},
+	} {
+		t.Run(tc.engine, func(t *testing.T) {
+			purgeStoreCommand := NewPurgeStoreCommand()
+			purgeStoreCommand.SetArgs([]string{"--datastore-engine", tc.engine, "--datastore-uri", ""})
+			err := purgeStoreCommand.Execute()
+			require.ErrorContains(t, err, tc.errorExpected)
+		})
+	}
+}
@@ -14,6 +14,27 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("experimentals", flags.Lookup("experimentals"))
 		util.MustBindEnv("experimentals", "OPENFGA_EXPERIMENTALS")
 
+		util.MustBindPFlag("readOnly", flags.Lookup("read-only"))
+		util.MustBindEnv("readOnly", "OPENFGA_READ_ONLY", "OPENFGA_READONLY")
+
+		util.MustBindPFlag("listStoresExactTotalCount", flags.Lookup("list-stores-exact-total-count"))
+		util.MustBindEnv("listStoresExactTotalCount", "OPENFGA_LIST_STORES_EXACT_TOTAL_COUNT", "OPENFGA_LISTSTORESEXACTTOTALCOUNT")
+
+		util.MustBindPFlag("uniqueStoreNames", flags.Lookup("unique-store-names"))
+		util.MustBindEnv("uniqueStoreNames", "OPENFGA_UNIQUE_STORE_NAMES", "OPENFGA_UNIQUESTORENAMES")
+
+		util.MustBindPFlag("rejectWritesOnStaleModel", flags.Lookup("reject-writes-on-stale-model"))
+		util.MustBindEnv("rejectWritesOnStaleModel", "OPENFGA_REJECT_WRITES_ON_STALE_MODEL", "OPENFGA_REJECTWRITESONSTALEMODEL")
+
+		util.MustBindPFlag("readChangesHorizonHeaders", flags.Lookup("read-changes-horizon-headers"))
+		util.MustBindEnv("readChangesHorizonHeaders", "OPENFGA_READ_CHANGES_HORIZON_HEADERS", "OPENFGA_READCHANGESHORIZONHEADERS")
+
+		util.MustBindPFlag("checkResponseMetadataHeaders", flags.Lookup("check-response-metadata-headers"))
+		util.MustBindEnv("checkResponseMetadataHeaders", "OPENFGA_CHECK_RESPONSE_METADATA_HEADERS", "OPENFGA_CHECKRESPONSEMETADATAHEADERS")
+
+		util.MustBindPFlag("checkDeduplicationEnabled", flags.Lookup("check-deduplication-enabled"))
+		util.MustBindEnv("checkDeduplicationEnabled", "OPENFGA_CHECK_DEDUPLICATION_ENABLED", "OPENFGA_CHECKDEDUPLICATIONENABLED")
+
 		util.MustBindPFlag("grpc.addr", flags.Lookup("grpc-addr"))
 		util.MustBindEnv("grpc.addr", "OPENFGA_GRPC_ADDR")
 
@@ -54,6 +75,15 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("http.corsAllowedHeaders", flags.Lookup("http-cors-allowed-headers"))
 		util.MustBindEnv("http.corsAllowedHeaders", "OPENFGA_HTTP_CORS_ALLOWED_HEADERS", "OPENFGA_HTTP_CORSALLOWEDHEADERS")
 
+		util.MustBindPFlag("http.maxBodySizeInBytes", flags.Lookup("http-max-body-size-in-bytes"))
+		util.MustBindEnv("http.maxBodySizeInBytes", "OPENFGA_HTTP_MAX_BODY_SIZE_IN_BYTES", "OPENFGA_HTTP_MAXBODYSIZEINBYTES")
+
+		util.MustBindPFlag("http.routeMaxBodySizesInBytes", flags.Lookup("http-route-max-body-sizes-in-bytes"))
+		util.MustBindEnv("http.routeMaxBodySizesInBytes", "OPENFGA_HTTP_ROUTE_MAX_BODY_SIZES_IN_BYTES", "OPENFGA_HTTP_ROUTEMAXBODYSIZESINBYTES")
+
+		util.MustBindPFlag("http.strictJSON", flags.Lookup("http-strict-json"))
+		util.MustBindEnv("http.strictJSON", "OPENFGA_HTTP_STRICT_JSON", "OPENFGA_HTTP_STRICTJSON")
+
 		util.MustBindPFlag("authn.method", flags.Lookup("authn-method"))
 		util.MustBindEnv("authn.method", "OPENFGA_AUTHN_METHOD")
 
@@ -99,9 +129,30 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("datastore.connMaxLifetime", flags.Lookup("datastore-conn-max-lifetime"))
 		util.MustBindEnv("datastore.connMaxLifetime", "OPENFGA_DATASTORE_CONN_MAX_LIFETIME", "OPENFGA_DATASTORE_CONNMAXLIFETIME")
 
+		util.MustBindPFlag("datastore.changelogMode", flags.Lookup("datastore-changelog-mode"))
+		util.MustBindEnv("datastore.changelogMode", "OPENFGA_DATASTORE_CHANGELOG_MODE", "OPENFGA_DATASTORE_CHANGELOGMODE")
+
+		util.MustBindPFlag("datastore.queryShapeSampling", flags.Lookup("datastore-query-shape-sampling"))
+		util.MustBindEnv("datastore.queryShapeSampling", "OPENFGA_DATASTORE_QUERY_SHAPE_SAMPLING", "OPENFGA_DATASTORE_QUERYSHAPESAMPLING")
+
+		util.MustBindPFlag("datastore.tupleCounts", flags.Lookup("datastore-tuple-counts"))
+		util.MustBindEnv("datastore.tupleCounts", "OPENFGA_DATASTORE_TUPLE_COUNTS", "OPENFGA_DATASTORE_TUPLECOUNTS")
+
 		util.MustBindPFlag("datastore.metrics.enabled", flags.Lookup("datastore-metrics-enabled"))
 		util.MustBindEnv("datastore.metrics.enabled", "OPENFGA_DATASTORE_METRICS_ENABLED")
 
+		util.MustBindPFlag("datastore.hedging.enabled", flags.Lookup("datastore-hedging-enabled"))
+		util.MustBindEnv("datastore.hedging.enabled", "OPENFGA_DATASTORE_HEDGING_ENABLED")
+
+		util.MustBindPFlag("datastore.hedging.minDelay", flags.Lookup("datastore-hedging-min-delay"))
+		util.MustBindEnv("datastore.hedging.minDelay", "OPENFGA_DATASTORE_HEDGING_MIN_DELAY")
+
+		util.MustBindPFlag("datastore.hedging.percentile", flags.Lookup("datastore-hedging-percentile"))
+		util.MustBindEnv("datastore.hedging.percentile", "OPENFGA_DATASTORE_HEDGING_PERCENTILE")
+
+		util.MustBindPFlag("datastore.hedging.maxPerRequest", flags.Lookup("datastore-hedging-max-per-request"))
+		util.MustBindEnv("datastore.hedging.maxPerRequest", "OPENFGA_DATASTORE_HEDGING_MAX_PER_REQUEST")
+
 		util.MustBindPFlag("playground.enabled", flags.Lookup("playground-enabled"))
 		util.MustBindEnv("playground.enabled", "OPENFGA_PLAYGROUND_ENABLED")
 
@@ -114,6 +165,15 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("profiler.addr", flags.Lookup("profiler-addr"))
 		util.MustBindEnv("profiler.addr", "OPENFGA_PROFILER_ADDRESS")
 
+		util.MustBindPFlag("admin.enabled", flags.Lookup("admin-enabled"))
+		util.MustBindEnv("admin.enabled", "OPENFGA_ADMIN_ENABLED")
+
+		util.MustBindPFlag("admin.addr", flags.Lookup("admin-addr"))
+		util.MustBindEnv("admin.addr", "OPENFGA_ADMIN_ADDR")
+
+		util.MustBindPFlag("admin.token", flags.Lookup("admin-token"))
+		util.MustBindEnv("admin.token", "OPENFGA_ADMIN_TOKEN")
+
 		util.MustBindPFlag("log.format", flags.Lookup("log-format"))
 		util.MustBindEnv("log.format", "OPENFGA_LOG_FORMAT")
 
@@ -147,6 +207,21 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("metrics.enableRPCHistograms", flags.Lookup("metrics-enable-rpc-histograms"))
 		util.MustBindEnv("metrics.enableRPCHistograms", "OPENFGA_METRICS_ENABLE_RPC_HISTOGRAMS")
 
+		util.MustBindPFlag("metrics.perStoreWriteAllowlist", flags.Lookup("metrics-per-store-write-allowlist"))
+		util.MustBindEnv("metrics.perStoreWriteAllowlist", "OPENFGA_METRICS_PER_STORE_WRITE_ALLOWLIST")
+
+		util.MustBindPFlag("metrics.perStoreModelComplexityAllowlist", flags.Lookup("metrics-per-store-model-complexity-allowlist"))
+		util.MustBindEnv("metrics.perStoreModelComplexityAllowlist", "OPENFGA_METRICS_PER_STORE_MODEL_COMPLEXITY_ALLOWLIST")
+
+		util.MustBindPFlag("metrics.changelogInterval", flags.Lookup("metrics-changelog-interval"))
+		util.MustBindEnv("metrics.changelogInterval", "OPENFGA_METRICS_CHANGELOG_INTERVAL")
+
+		util.MustBindPFlag("metrics.perStoreChangelogAllowlist", flags.Lookup("metrics-per-store-changelog-allowlist"))
+		util.MustBindEnv("metrics.perStoreChangelogAllowlist", "OPENFGA_METRICS_PER_STORE_CHANGELOG_ALLOWLIST")
+
+		util.MustBindPFlag("metrics.perStoreReadWaitAllowlist", flags.Lookup("metrics-per-store-read-wait-allowlist"))
+		util.MustBindEnv("metrics.perStoreReadWaitAllowlist", "OPENFGA_METRICS_PER_STORE_READ_WAIT_ALLOWLIST")
+
 		util.MustBindPFlag("maxTuplesPerWrite", flags.Lookup("max-tuples-per-write"))
 		util.MustBindEnv("maxTuplesPerWrite", "OPENFGA_MAX_TUPLES_PER_WRITE", "OPENFGA_MAXTUPLESPERWRITE")
 
@@ -156,6 +231,27 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("maxAuthorizationModelSizeInBytes", flags.Lookup("max-authorization-model-size-in-bytes"))
 		util.MustBindEnv("maxAuthorizationModelSizeInBytes", "OPENFGA_MAX_AUTHORIZATION_MODEL_SIZE_IN_BYTES", "OPENFGA_MAXAUTHORIZATIONMODELSIZEINBYTES")
 
+		util.MustBindPFlag("maxAuthorizationModelComplexity", flags.Lookup("max-authorization-model-complexity"))
+		util.MustBindEnv("maxAuthorizationModelComplexity", "OPENFGA_MAX_AUTHORIZATION_MODEL_COMPLEXITY", "OPENFGA_MAXAUTHORIZATIONMODELCOMPLEXITY")
+
+		util.MustBindPFlag("maxAuthorizationModelTypes", flags.Lookup("max-authorization-model-types"))
+		util.MustBindEnv("maxAuthorizationModelTypes", "OPENFGA_MAX_AUTHORIZATION_MODEL_TYPES", "OPENFGA_MAXAUTHORIZATIONMODELTYPES")
+
+		util.MustBindPFlag("maxAuthorizationModelRelationsPerType", flags.Lookup("max-authorization-model-relations-per-type"))
+		util.MustBindEnv("maxAuthorizationModelRelationsPerType", "OPENFGA_MAX_AUTHORIZATION_MODEL_RELATIONS_PER_TYPE", "OPENFGA_MAXAUTHORIZATIONMODELRELATIONSPERTYPE")
+
+		util.MustBindPFlag("maxAuthorizationModelRewriteDepth", flags.Lookup("max-authorization-model-rewrite-depth"))
+		util.MustBindEnv("maxAuthorizationModelRewriteDepth", "OPENFGA_MAX_AUTHORIZATION_MODEL_REWRITE_DEPTH", "OPENFGA_MAXAUTHORIZATIONMODELREWRITEDEPTH")
+
+		util.MustBindPFlag("authorizationModelValidationTimeout", flags.Lookup("authorization-model-validation-timeout"))
+		util.MustBindEnv("authorizationModelValidationTimeout", "OPENFGA_AUTHORIZATION_MODEL_VALIDATION_TIMEOUT", "OPENFGA_AUTHORIZATIONMODELVALIDATIONTIMEOUT")
+
+		util.MustBindPFlag("authorizationModelValidationMaxVisits", flags.Lookup("authorization-model-validation-max-visits"))
+		util.MustBindEnv("authorizationModelValidationMaxVisits", "OPENFGA_AUTHORIZATION_MODEL_VALIDATION_MAX_VISITS", "OPENFGA_AUTHORIZATIONMODELVALIDATIONMAXVISITS")
+
+		util.MustBindPFlag("modelImpactAnalysisMaxProbes", flags.Lookup("model-impact-analysis-max-probes"))
+		util.MustBindEnv("modelImpactAnalysisMaxProbes", "OPENFGA_MODEL_IMPACT_ANALYSIS_MAX_PROBES")
+
 		util.MustBindPFlag("maxConcurrentReadsForListObjects", flags.Lookup("max-concurrent-reads-for-list-objects"))
 		util.MustBindEnv("maxConcurrentReadsForListObjects", "OPENFGA_MAX_CONCURRENT_READS_FOR_LIST_OBJECTS", "OPENFGA_MAXCONCURRENTREADSFORLISTOBJECTS")
 
@@ -165,24 +261,108 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("maxConcurrentReadsForCheck", flags.Lookup("max-concurrent-reads-for-check"))
 		util.MustBindEnv("maxConcurrentReadsForCheck", "OPENFGA_MAX_CONCURRENT_READS_FOR_CHECK", "OPENFGA_MAXCONCURRENTREADSFORCHECK")
 
+		util.MustBindPFlag("snapshotReadsForCheck", flags.Lookup("snapshot-reads-for-check"))
+		util.MustBindEnv("snapshotReadsForCheck", "OPENFGA_SNAPSHOT_READS_FOR_CHECK")
+
+		util.MustBindPFlag("maxChecksPerBatchCheck", flags.Lookup("max-checks-per-batch-check"))
+		util.MustBindEnv("maxChecksPerBatchCheck", "OPENFGA_MAX_CHECKS_PER_BATCH_CHECK")
+
+		util.MustBindPFlag("maxConcurrentReadsForExpand", flags.Lookup("max-concurrent-reads-for-expand"))
+		util.MustBindEnv("maxConcurrentReadsForExpand", "OPENFGA_MAX_CONCURRENT_READS_FOR_EXPAND", "OPENFGA_MAXCONCURRENTREADSFOREXPAND")
+
+		util.MustBindPFlag("maxConcurrentReadsForRead", flags.Lookup("max-concurrent-reads-for-read"))
+		util.MustBindEnv("maxConcurrentReadsForRead", "OPENFGA_MAX_CONCURRENT_READS_FOR_READ", "OPENFGA_MAXCONCURRENTREADSFORREAD")
+
+		util.MustBindPFlag("maxReadResponseSizeInBytes", flags.Lookup("max-read-response-size-in-bytes"))
+		util.MustBindEnv("maxReadResponseSizeInBytes", "OPENFGA_MAX_READ_RESPONSE_SIZE_IN_BYTES", "OPENFGA_MAXREADRESPONSESIZEINBYTES")
+
 		util.MustBindPFlag("maxConditionEvaluationCost", flags.Lookup("max-condition-evaluation-cost"))
 		util.MustBindEnv("maxConditionEvaluationCost", "OPENFGA_MAX_CONDITION_EVALUATION_COST", "OPENFGA_MAXCONDITIONEVALUATIONCOST")
 
 		util.MustBindPFlag("changelogHorizonOffset", flags.Lookup("changelog-horizon-offset"))
 		util.MustBindEnv("changelogHorizonOffset", "OPENFGA_CHANGELOG_HORIZON_OFFSET", "OPENFGA_CHANGELOGHORIZONOFFSET")
 
+		util.MustBindPFlag("readChangesWatchPollInterval", flags.Lookup("read-changes-watch-poll-interval"))
+		util.MustBindEnv("readChangesWatchPollInterval", "OPENFGA_READ_CHANGES_WATCH_POLL_INTERVAL", "OPENFGA_READCHANGESWATCHPOLLINTERVAL")
+
+		util.MustBindPFlag("readChangesWatchHeartbeatInterval", flags.Lookup("read-changes-watch-heartbeat-interval"))
+		util.MustBindEnv("readChangesWatchHeartbeatInterval", "OPENFGA_READ_CHANGES_WATCH_HEARTBEAT_INTERVAL", "OPENFGA_READCHANGESWATCHHEARTBEATINTERVAL")
+
+		util.MustBindPFlag("readChangesWatchMaxStreams", flags.Lookup("read-changes-watch-max-streams"))
+		util.MustBindEnv("readChangesWatchMaxStreams", "OPENFGA_READ_CHANGES_WATCH_MAX_STREAMS", "OPENFGA_READCHANGESWATCHMAXSTREAMS")
+
 		util.MustBindPFlag("resolveNodeLimit", flags.Lookup("resolve-node-limit"))
 		util.MustBindEnv("resolveNodeLimit", "OPENFGA_RESOLVE_NODE_LIMIT", "OPENFGA_RESOLVENODELIMIT")
 
 		util.MustBindPFlag("resolveNodeBreadthLimit", flags.Lookup("resolve-node-breadth-limit"))
 		util.MustBindEnv("resolveNodeBreadthLimit", "OPENFGA_RESOLVE_NODE_BREADTH_LIMIT", "OPENFGA_RESOLVENODEBREADTHLIMIT")
 
+		util.MustBindPFlag("resolveNodeLimitShadow", flags.Lookup("resolve-node-limit-shadow"))
+		util.MustBindEnv("resolveNodeLimitShadow", "OPENFGA_RESOLVE_NODE_LIMIT_SHADOW", "OPENFGA_RESOLVENODELIMITSHADOW")
+
+		util.MustBindPFlag("resolveNodeBreadthLimitShadow", flags.Lookup("resolve-node-breadth-limit-shadow"))
+		util.MustBindEnv("resolveNodeBreadthLimitShadow", "OPENFGA_RESOLVE_NODE_BREADTH_LIMIT_SHADOW", "OPENFGA_RESOLVENODEBREADTHLIMITSHADOW")
+
+		util.MustBindPFlag("resolverWorkerPoolEnabled", flags.Lookup("resolver-worker-pool-enabled"))
+		util.MustBindEnv("resolverWorkerPoolEnabled", "OPENFGA_RESOLVER_WORKER_POOL_ENABLED", "OPENFGA_RESOLVERWORKERPOOLENABLED")
+
+		util.MustBindPFlag("resolverWorkerPoolSize", flags.Lookup("resolver-worker-pool-size"))
+		util.MustBindEnv("resolverWorkerPoolSize", "OPENFGA_RESOLVER_WORKER_POOL_SIZE", "OPENFGA_RESOLVERWORKERPOOLSIZE")
+
 		util.MustBindPFlag("listObjectsDeadline", flags.Lookup("listObjects-deadline"))
 		util.MustBindEnv("listObjectsDeadline", "OPENFGA_LIST_OBJECTS_DEADLINE", "OPENFGA_LISTOBJECTSDEADLINE")
 
 		util.MustBindPFlag("listObjectsMaxResults", flags.Lookup("listObjects-max-results"))
 		util.MustBindEnv("listObjectsMaxResults", "OPENFGA_LIST_OBJECTS_MAX_RESULTS", "OPENFGA_LISTOBJECTSMAXRESULTS")
 
+		util.MustBindPFlag("listObjectsMaxCandidates", flags.Lookup("listObjects-max-candidates"))
+		util.MustBindEnv("listObjectsMaxCandidates", "OPENFGA_LIST_OBJECTS_MAX_CANDIDATES", "OPENFGA_LISTOBJECTSMAXCANDIDATES")
+
+		util.MustBindPFlag("listObjectsStrategy", flags.Lookup("listObjects-strategy"))
+		util.MustBindEnv("listObjectsStrategy", "OPENFGA_LIST_OBJECTS_STRATEGY", "OPENFGA_LISTOBJECTSSTRATEGY")
+
+		util.MustBindPFlag("listObjectsStreamMaxModelLag", flags.Lookup("listObjects-stream-max-model-lag"))
+		util.MustBindEnv("listObjectsStreamMaxModelLag", "OPENFGA_LIST_OBJECTS_STREAM_MAX_MODEL_LAG", "OPENFGA_LISTOBJECTSSTREAMMAXMODELLAG")
+
+		util.MustBindPFlag("listObjectsStreamModelCheckInterval", flags.Lookup("listObjects-stream-model-check-interval"))
+		util.MustBindEnv("listObjectsStreamModelCheckInterval", "OPENFGA_LIST_OBJECTS_STREAM_MODEL_CHECK_INTERVAL", "OPENFGA_LISTOBJECTSSTREAMMODELCHECKINTERVAL")
+
+		util.MustBindPFlag("maxStreamDuration", flags.Lookup("max-stream-duration"))
+		util.MustBindEnv("maxStreamDuration", "OPENFGA_MAX_STREAM_DURATION", "OPENFGA_MAXSTREAMDURATION")
+
+		util.MustBindPFlag("expandMaxLeafUsers", flags.Lookup("expand-max-leaf-users"))
+		util.MustBindEnv("expandMaxLeafUsers", "OPENFGA_EXPAND_MAX_LEAF_USERS", "OPENFGA_EXPANDMAXLEAFUSERS")
+
+		util.MustBindPFlag("expandMaxNodes", flags.Lookup("expand-max-nodes"))
+		util.MustBindEnv("expandMaxNodes", "OPENFGA_EXPAND_MAX_NODES", "OPENFGA_EXPANDMAXNODES")
+
+		util.MustBindPFlag("expandStrictResultLimit", flags.Lookup("expand-strict-result-limit"))
+		util.MustBindEnv("expandStrictResultLimit", "OPENFGA_EXPAND_STRICT_RESULT_LIMIT", "OPENFGA_EXPANDSTRICTRESULTLIMIT")
+
+		util.MustBindPFlag("relationStatisticsMaxSampleSize", flags.Lookup("relation-statistics-max-sample-size"))
+		util.MustBindEnv("relationStatisticsMaxSampleSize", "OPENFGA_RELATION_STATISTICS_MAX_SAMPLE_SIZE", "OPENFGA_RELATIONSTATISTICSMAXSAMPLESIZE")
+
+		util.MustBindPFlag("relationStatisticsDeadline", flags.Lookup("relation-statistics-deadline"))
+		util.MustBindEnv("relationStatisticsDeadline", "OPENFGA_RELATION_STATISTICS_DEADLINE", "OPENFGA_RELATIONSTATISTICSDEADLINE")
+
+		util.MustBindPFlag("relationUsageDeadline", flags.Lookup("relation-usage-deadline"))
+		util.MustBindEnv("relationUsageDeadline", "OPENFGA_RELATION_USAGE_DEADLINE", "OPENFGA_RELATIONUSAGEDEADLINE")
+
+		util.MustBindPFlag("deleteTuplesByFilterMaxTuples", flags.Lookup("delete-tuples-by-filter-max-tuples"))
+		util.MustBindEnv("deleteTuplesByFilterMaxTuples", "OPENFGA_DELETE_TUPLES_BY_FILTER_MAX_TUPLES", "OPENFGA_DELETETUPLESBYFILTERMAXTUPLES")
+
+		util.MustBindPFlag("deleteTuplesByFilterMaxScanned", flags.Lookup("delete-tuples-by-filter-max-scanned"))
+		util.MustBindEnv("deleteTuplesByFilterMaxScanned", "OPENFGA_DELETE_TUPLES_BY_FILTER_MAX_SCANNED", "OPENFGA_DELETETUPLESBYFILTERMAXSCANNED")
+
+		util.MustBindPFlag("pointInTimeCheckHorizon", flags.Lookup("point-in-time-check-horizon"))
+		util.MustBindEnv("pointInTimeCheckHorizon", "OPENFGA_POINT_IN_TIME_CHECK_HORIZON", "OPENFGA_POINTINTIMECHECKHORIZON")
+
+		util.MustBindPFlag("pointInTimeCheckMaxChanges", flags.Lookup("point-in-time-check-max-changes"))
+		util.MustBindEnv("pointInTimeCheckMaxChanges", "OPENFGA_POINT_IN_TIME_CHECK_MAX_CHANGES", "OPENFGA_POINTINTIMECHECKMAXCHANGES")
+
+		util.MustBindPFlag("errorReasonDetails", flags.Lookup("error-reason-details"))
+		util.MustBindEnv("errorReasonDetails", "OPENFGA_ERROR_REASON_DETAILS", "OPENFGA_ERRORREASONDETAILS")
+
 		util.MustBindPFlag("listUsersDeadline", flags.Lookup("listUsers-deadline"))
 		util.MustBindEnv("listUsersDeadline", "OPENFGA_LIST_USERS_DEADLINE", "OPENFGA_LISTUSERSDEADLINE")
 
@@ -205,6 +385,9 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("checkQueryCache.ttl", flags.Lookup("check-query-cache-ttl"))
 		util.MustBindEnv("checkQueryCache.ttl", "OPENFGA_CHECK_QUERY_CACHE_TTL")
 
+		util.MustBindPFlag("checkQueryCache.responseHeader", flags.Lookup("check-query-cache-response-header"))
+		util.MustBindEnv("checkQueryCache.responseHeader", "OPENFGA_CHECK_QUERY_CACHE_RESPONSE_HEADER")
+
 		util.MustBindPFlag("requestDurationDatastoreQueryCountBuckets", flags.Lookup("request-duration-datastore-query-count-buckets"))
 		util.MustBindEnv("requestDurationDatastoreQueryCountBuckets", "OPENFGA_REQUEST_DURATION_DATASTORE_QUERY_COUNT_BUCKETS")
 
@@ -265,5 +448,59 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 
 		util.MustBindPFlag("requestTimeout", flags.Lookup("request-timeout"))
 		util.MustBindEnv("requestTimeout", "OPENFGA_REQUEST_TIMEOUT")
+
+		util.MustBindPFlag("requestPriority.enabled", flags.Lookup("request-priority-enabled"))
+		util.MustBindEnv("requestPriority.enabled", "OPENFGA_REQUEST_PRIORITY_ENABLED")
+
+		util.MustBindPFlag("requestPriority.maxDeprioritization", flags.Lookup("request-priority-max-deprioritization"))
+		util.MustBindEnv("requestPriority.maxDeprioritization", "OPENFGA_REQUEST_PRIORITY_MAX_DEPRIORITIZATION")
+
+		util.MustBindPFlag("sessionAffinity.enabled", flags.Lookup("session-affinity-enabled"))
+		util.MustBindEnv("sessionAffinity.enabled", "OPENFGA_SESSION_AFFINITY_ENABLED")
+
+		util.MustBindPFlag("sessionAffinity.window", flags.Lookup("session-affinity-window"))
+		util.MustBindEnv("sessionAffinity.window", "OPENFGA_SESSION_AFFINITY_WINDOW")
+
+		util.MustBindPFlag("sessionAffinity.maxSessions", flags.Lookup("session-affinity-max-sessions"))
+		util.MustBindEnv("sessionAffinity.maxSessions", "OPENFGA_SESSION_AFFINITY_MAX_SESSIONS")
+
+		util.MustBindPFlag("membershipIndex.relations", flags.Lookup("membership-index-relations"))
+		util.MustBindEnv("membershipIndex.relations", "OPENFGA_MEMBERSHIP_INDEX_RELATIONS")
+
+		util.MustBindPFlag("membershipIndex.pollInterval", flags.Lookup("membership-index-poll-interval"))
+		util.MustBindEnv("membershipIndex.pollInterval", "OPENFGA_MEMBERSHIP_INDEX_POLL_INTERVAL")
+
+		util.MustBindPFlag("membershipIndex.maxStaleness", flags.Lookup("membership-index-max-staleness"))
+		util.MustBindEnv("membershipIndex.maxStaleness", "OPENFGA_MEMBERSHIP_INDEX_MAX_STALENESS")
+
+		util.MustBindPFlag("membershipIndex.maxStores", flags.Lookup("membership-index-max-stores"))
+		util.MustBindEnv("membershipIndex.maxStores", "OPENFGA_MEMBERSHIP_INDEX_MAX_STORES")
+
+		util.MustBindPFlag("membershipIndex.idleTimeout", flags.Lookup("membership-index-idle-timeout"))
+		util.MustBindEnv("membershipIndex.idleTimeout", "OPENFGA_MEMBERSHIP_INDEX_IDLE_TIMEOUT")
+
+		util.MustBindPFlag("checkFairReadSharing.enabled", flags.Lookup("check-fair-read-sharing-enabled"))
+		util.MustBindEnv("checkFairReadSharing.enabled", "OPENFGA_CHECK_FAIR_READ_SHARING_ENABLED")
+
+		util.MustBindPFlag("checkFairReadSharing.storeWeights", flags.Lookup("check-fair-read-sharing-store-weights"))
+		util.MustBindEnv("checkFairReadSharing.storeWeights", "OPENFGA_CHECK_FAIR_READ_SHARING_STORE_WEIGHTS")
+
+		util.MustBindPFlag("tupleQuota.maxTuples", flags.Lookup("tuple-quota-max-tuples"))
+		util.MustBindEnv("tupleQuota.maxTuples", "OPENFGA_TUPLE_QUOTA_MAX_TUPLES")
+
+		util.MustBindPFlag("tupleQuota.maxTuplesPerObjectType", flags.Lookup("tuple-quota-max-tuples-per-object-type"))
+		util.MustBindEnv("tupleQuota.maxTuplesPerObjectType", "OPENFGA_TUPLE_QUOTA_MAX_TUPLES_PER_OBJECT_TYPE")
+
+		util.MustBindPFlag("tupleQuota.maxTuplesByObjectType", flags.Lookup("tuple-quota-max-tuples-by-object-type"))
+		util.MustBindEnv("tupleQuota.maxTuplesByObjectType", "OPENFGA_TUPLE_QUOTA_MAX_TUPLES_BY_OBJECT_TYPE")
+
+		util.MustBindPFlag("tupleQuota.storeMaxTuples", flags.Lookup("tuple-quota-store-max-tuples"))
+		util.MustBindEnv("tupleQuota.storeMaxTuples", "OPENFGA_TUPLE_QUOTA_STORE_MAX_TUPLES")
+
+		util.MustBindPFlag("requestOptions.strict", flags.Lookup("request-options-strict"))
+		util.MustBindEnv("requestOptions.strict", "OPENFGA_REQUEST_OPTIONS_STRICT")
+
+		util.MustBindPFlag("requestOptions.privilegedSubjects", flags.Lookup("request-options-privileged-subjects"))
+		util.MustBindEnv("requestOptions.privilegedSubjects", "OPENFGA_REQUEST_OPTIONS_PRIVILEGED_SUBJECTS")
 	}
 }
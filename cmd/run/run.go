@@ -11,7 +11,9 @@ import (
 	"net/http/pprof"
 	"os"
 	"os/signal"
+	"reflect"
 	goruntime "runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
@@ -23,7 +25,7 @@ import (
 	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	grpc_prometheus "github.com/jon-whit/go-grpc-prometheus"
-	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/mitchellh/mapstructure"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
 	"github.com/spf13/cobra"
@@ -37,9 +39,6 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
-	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
-	"google.golang.org/grpc/reflection"
-	"google.golang.org/grpc/status"
 
 	"github.com/openfga/openfga/pkg/gateway"
 
@@ -52,15 +51,17 @@ import (
 	serverconfig "github.com/openfga/openfga/internal/server/config"
 	"github.com/openfga/openfga/pkg/logger"
 	"github.com/openfga/openfga/pkg/middleware"
-	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
+	"github.com/openfga/openfga/pkg/middleware/errorreason"
 	"github.com/openfga/openfga/pkg/middleware/logging"
+	"github.com/openfga/openfga/pkg/middleware/msgsize"
 	"github.com/openfga/openfga/pkg/middleware/recovery"
 	"github.com/openfga/openfga/pkg/middleware/requestid"
+	"github.com/openfga/openfga/pkg/middleware/requestoptions"
 	"github.com/openfga/openfga/pkg/middleware/storeid"
 	"github.com/openfga/openfga/pkg/middleware/validator"
+	"github.com/openfga/openfga/pkg/requestcontext"
 	"github.com/openfga/openfga/pkg/server"
-	serverErrors "github.com/openfga/openfga/pkg/server/errors"
-	"github.com/openfga/openfga/pkg/server/health"
+	"github.com/openfga/openfga/pkg/server/commands"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/memory"
 	"github.com/openfga/openfga/pkg/storage/mysql"
@@ -89,6 +90,20 @@ func NewRunCommand() *cobra.Command {
 
 	flags.StringSlice("experimentals", defaultConfig.Experimentals, "a list of experimental features to enable.")
 
+	flags.Bool("read-only", defaultConfig.ReadOnly, "serve only the read APIs, e.g. when the datastore is a read replica. Write, WriteAuthorizationModel, WriteAssertions, CreateStore and DeleteStore fail with a failed precondition error")
+
+	flags.Bool("list-stores-exact-total-count", defaultConfig.ListStoresExactTotalCount, "return the exact number of stores to the ListStores requests that ask for it with the Openfga-ListStores-Include-Total-Count header. Otherwise the MySQL and Postgres datastores return an estimate, which is cheaper with many stores but lags behind the stores created and deleted recently")
+
+	flags.Bool("unique-store-names", defaultConfig.UniqueStoreNames, "make CreateStore fail with an already exists error, with the ID of the conflicting store, when another store already has the name of the new store")
+
+	flags.Bool("reject-writes-on-stale-model", defaultConfig.RejectWritesOnStaleModel, "make Write fail with a failed precondition error, carrying both model IDs, when it is evaluated with an authorization model other than the latest of the store, unless the request sets the Openfga-Write-Allow-Stale-Model header to true")
+
+	flags.Bool("read-changes-horizon-headers", defaultConfig.ReadChangesHorizonHeaders, "return the changelog horizon and the time of the newest change withheld by it in the Openfga-ReadChanges-Horizon and Openfga-ReadChanges-Newest-Withheld-Change headers of ReadChanges, so consumers can tell no changes from changes pending behind the horizon")
+
+	flags.Bool("check-response-metadata-headers", defaultConfig.CheckResponseMetadataHeaders, "return the number of dispatches and datastore queries of Check and ListObjects in the Openfga-Dispatch-Count and Openfga-Datastore-Query-Count response headers (trailers of StreamedListObjects)")
+
+	flags.Bool("check-deduplication-enabled", defaultConfig.CheckDeduplicationEnabled, "make identical concurrent Check requests (same store, model, tuple key, contextual tuples, context and consistency preference) share a single evaluation instead of each being evaluated")
+
 	flags.String("grpc-addr", defaultConfig.GRPC.Addr, "the host:port address to serve the grpc server on")
 
 	flags.Bool("grpc-tls-enabled", defaultConfig.GRPC.TLS.Enabled, "enable/disable transport layer security (TLS)")
@@ -117,6 +132,12 @@ func NewRunCommand() *cobra.Command {
 
 	flags.StringSlice("http-cors-allowed-headers", defaultConfig.HTTP.CORSAllowedHeaders, "specifies the CORS allowed headers")
 
+	flags.Int("http-max-body-size-in-bytes", defaultConfig.HTTP.MaxBodySizeInBytes, "the maximum size of the request bodies accepted by the HTTP server. If 0, the size is not limited")
+
+	flags.StringToInt("http-route-max-body-sizes-in-bytes", defaultConfig.HTTP.RouteMaxBodySizesInBytes, "the maximum size of the request bodies accepted by the HTTP server per route, e.g. 'write=1048576,check=65536'. Routes are named by the last literal segment of their path")
+
+	flags.Bool("http-strict-json", defaultConfig.HTTP.StrictJSON, "reject the bodies of Write, WriteAuthorizationModel and WriteAssertions requests that have unknown fields, instead of ignoring the fields")
+
 	flags.String("authn-method", defaultConfig.Authn.Method, "the authentication method to use")
 
 	flags.StringSlice("authn-preshared-keys", defaultConfig.Authn.Keys, "one or more preshared keys to use for authentication")
@@ -147,8 +168,22 @@ func NewRunCommand() *cobra.Command {
 
 	flags.Duration("datastore-conn-max-lifetime", defaultConfig.Datastore.ConnMaxLifetime, "the maximum amount of time a connection to the datastore may be reused")
 
+	flags.String("datastore-changelog-mode", defaultConfig.Datastore.ChangelogMode, "how SQL datastores write changelog entries: 'sync' in the write transaction, 'async' in an outbox table moved to the changelog in batches (ReadChanges lags behind writes), or 'disabled' (ReadChanges returns an error)")
+
+	flags.Bool("datastore-query-shape-sampling", defaultConfig.Datastore.QueryShapeSampling, "enable counting the tuple queries of SQL datastores by method and filtered columns, without their values, to recommend indexes through the GET /admin/index-advice endpoint")
+
+	flags.Bool("datastore-tuple-counts", defaultConfig.Datastore.TupleCounts, "enable maintaining the number of tuples of each store and object type in every write to SQL datastores, which the store statistics and tuple quotas require. Backfill the counters of existing tuples with the backfill-tuple-counts command")
+
 	flags.Bool("datastore-metrics-enabled", defaultConfig.Datastore.Metrics.Enabled, "enable/disable sql metrics")
 
+	flags.Bool("datastore-hedging-enabled", defaultConfig.Datastore.Hedging.Enabled, "enable hedging the datastore reads of Check, ListObjects and ListUsers: a read that has not returned within the hedging delay is issued again, and the first result is used. Reads with HIGHER_CONSISTENCY are never hedged")
+
+	flags.Duration("datastore-hedging-min-delay", defaultConfig.Datastore.Hedging.MinDelay, "if datastore hedging is enabled, the minimum hedging delay, which applies until enough reads were observed to compute the percentile")
+
+	flags.Float64("datastore-hedging-percentile", defaultConfig.Datastore.Hedging.Percentile, "if datastore hedging is enabled, the percentile of the latency of the latest datastore reads used as the hedging delay")
+
+	flags.Uint32("datastore-hedging-max-per-request", defaultConfig.Datastore.Hedging.MaxPerRequest, "if datastore hedging is enabled, the maximum number of hedged reads per request")
+
 	flags.Bool("playground-enabled", defaultConfig.Playground.Enabled, "enable/disable the OpenFGA Playground")
 
 	flags.Int("playground-port", defaultConfig.Playground.Port, "the port to serve the local OpenFGA Playground on")
@@ -157,6 +192,12 @@ func NewRunCommand() *cobra.Command {
 
 	flags.String("profiler-addr", defaultConfig.Profiler.Addr, "the host:port address to serve the pprof profiler server on")
 
+	flags.Bool("admin-enabled", defaultConfig.Admin.Enabled, "enable/disable the unauthenticated administration endpoints, e.g. to refresh the cached models of a store after writing models to the datastore directly")
+
+	flags.String("admin-addr", defaultConfig.Admin.Addr, "the host:port address to serve the administration endpoints on, which must not be reachable by the clients of the API")
+
+	flags.String("admin-token", defaultConfig.Admin.Token, "the bearer token required by the administration endpoints that delete data, e.g. to delete the tuples of a store by filter. Without it, those endpoints are disabled")
+
 	flags.String("log-format", defaultConfig.Log.Format, "the log format to output logs in")
 
 	flags.String("log-level", defaultConfig.Log.Level, "the log level to use")
@@ -179,11 +220,35 @@ func NewRunCommand() *cobra.Command {
 
 	flags.Bool("metrics-enable-rpc-histograms", defaultConfig.Metrics.EnableRPCHistograms, "enables prometheus histogram metrics for RPC latency distributions")
 
+	flags.StringSlice("metrics-per-store-write-allowlist", defaultConfig.Metrics.PerStoreWriteAllowlist, "a list of store IDs whose tuple write and delete counts are reported with their own store_id label. Writes to other stores are reported under 'other'")
+
+	flags.StringSlice("metrics-per-store-model-complexity-allowlist", defaultConfig.Metrics.PerStoreModelComplexityAllowlist, "a list of store IDs whose authorization model complexity score is reported by the authorization_model_complexity_score gauge. The scores of other stores are only logged")
+
+	flags.Duration("metrics-changelog-interval", defaultConfig.Metrics.ChangelogInterval, "how often the changelog_rows and changelog_newest_change_age_seconds gauges are computed from the datastore. If 0 (the default), they are disabled")
+
+	flags.StringSlice("metrics-per-store-changelog-allowlist", defaultConfig.Metrics.PerStoreChangelogAllowlist, "a list of store IDs whose newest change age is reported by the changelog_newest_change_age_seconds gauge")
+
+	flags.StringSlice("metrics-per-store-read-wait-allowlist", defaultConfig.Metrics.PerStoreReadWaitAllowlist, "a list of store IDs whose Check reads waiting for their share of the concurrent reads are reported by the datastore_store_read_wait_ms histogram with their own store_id label. The other stores are reported under 'other'")
+
 	flags.Int("max-tuples-per-write", defaultConfig.MaxTuplesPerWrite, "the maximum allowed number of tuples per Write transaction")
 
 	flags.Int("max-types-per-authorization-model", defaultConfig.MaxTypesPerAuthorizationModel, "the maximum allowed number of type definitions per authorization model")
 
-	flags.Int("max-authorization-model-size-in-bytes", defaultConfig.MaxAuthorizationModelSizeInBytes, "the maximum size in bytes allowed for persisting an Authorization Model.")
+	flags.Int("max-authorization-model-size-in-bytes", defaultConfig.MaxAuthorizationModelSizeInBytes, "the maximum size in bytes allowed for persisting an Authorization Model, checked against both the size of the WriteAuthorizationModel request and the size of the model as stored.")
+
+	flags.Int("max-authorization-model-complexity", defaultConfig.MaxAuthorizationModelComplexity, "the maximum complexity score of the authorization models accepted by WriteAuthorizationModel, computed from the number of types, relations and conditions and from the depth and width of the relation rewrites. 0 means no limit.")
+
+	flags.Int("max-authorization-model-types", defaultConfig.MaxAuthorizationModelTypes, "the maximum number of types of the authorization models accepted by WriteAuthorizationModel, in addition to the max-types-per-authorization-model limit of the datastore. 0 means no limit.")
+
+	flags.Int("max-authorization-model-relations-per-type", defaultConfig.MaxAuthorizationModelRelationsPerType, "the maximum number of relations of each type of the authorization models accepted by WriteAuthorizationModel. 0 means no limit.")
+
+	flags.Int("max-authorization-model-rewrite-depth", defaultConfig.MaxAuthorizationModelRewriteDepth, "the maximum depth of the nested rewrites (unions, intersections and exclusions) of each relation of the authorization models accepted by WriteAuthorizationModel, 1 for a rewrite without them. 0 means no limit.")
+
+	flags.Duration("authorization-model-validation-timeout", defaultConfig.AuthorizationModelValidationTimeout, "the maximum time that WriteAuthorizationModel spends validating a model before rejecting it as too complex to validate. 0 means no limit.")
+
+	flags.Int("authorization-model-validation-max-visits", defaultConfig.AuthorizationModelValidationMaxVisits, "the maximum number of rewrite nodes that WriteAuthorizationModel visits validating a model before rejecting it as too complex to validate. Models already written are loaded without the limit. 0 means no limit.")
+
+	flags.Int("model-impact-analysis-max-probes", defaultConfig.ModelImpactAnalysisMaxProbes, "the maximum number of tuple reads of the impact analysis that WriteAuthorizationModel runs before writing a model when the 'Openfga-Write-Model-Impact-Analysis' header is set, i.e. the number of changed relations whose tuples are probed.")
 
 	flags.Uint32("max-concurrent-reads-for-list-users", defaultConfig.MaxConcurrentReadsForListUsers, "the maximum allowed number of concurrent datastore reads in a single ListUsers query. A high number will consume more connections from the datastore pool and will attempt to prioritize performance for the request at the expense of other queries performance.")
 
@@ -191,18 +256,74 @@ func NewRunCommand() *cobra.Command {
 
 	flags.Uint32("max-concurrent-reads-for-check", defaultConfig.MaxConcurrentReadsForCheck, "the maximum allowed number of concurrent datastore reads in a single Check query. A high number will consume more connections from the datastore pool and will attempt to prioritize performance for the request at the expense of other queries performance.")
 
+	flags.Bool("snapshot-reads-for-check", defaultConfig.SnapshotReadsForCheck, "evaluate each Check request against one consistent snapshot of the tuples, so that the tuples written while it is evaluated are not read by some of its reads and not by others. The SQL datastores hold read-only transactions, and so connections, for each Check request. PostgreSQL shares the snapshot between up to maxConcurrentReadsForCheck transactions, while MySQL and SQLite run the reads of a request one at a time.")
+
+	flags.Uint32("max-checks-per-batch-check", defaultConfig.MaxChecksPerBatchCheck, "the maximum number of checks of a BatchCheck request. Larger batches are rejected.")
+
+	flags.Uint32("max-concurrent-reads-for-expand", defaultConfig.MaxConcurrentReadsForExpand, "the maximum allowed number of concurrent datastore reads for all the Expand queries of the server together. The limit is shared by the queries, so that a burst of Expand queries cannot take all the connections of the datastore pool needed by other APIs.")
+
+	flags.Uint32("max-concurrent-reads-for-read", defaultConfig.MaxConcurrentReadsForRead, "the maximum allowed number of concurrent datastore reads for all the Read queries of the server together. The limit is shared by the queries, so that a burst of Read queries cannot take all the connections of the datastore pool needed by other APIs.")
+
+	flags.Int("max-read-response-size-in-bytes", defaultConfig.MaxReadResponseSizeInBytes, "the approximate maximum size in bytes of the responses of Read and ReadChanges. A page that would exceed it ends early, with a continuation token at the first tuple or change left out. 0 disables the limit.")
+
 	flags.Uint64("max-condition-evaluation-cost", defaultConfig.MaxConditionEvaluationCost, "the maximum cost for CEL condition evaluation before a request returns an error")
 
 	flags.Int("changelog-horizon-offset", defaultConfig.ChangelogHorizonOffset, "the offset (in minutes) from the current time. Changes that occur after this offset will not be included in the response of ReadChanges")
 
+	flags.Duration("read-changes-watch-poll-interval", defaultConfig.ReadChangesWatchPollInterval, "how often the streams of StreamedReadChanges read the changelog for new changes once they have read all of them")
+
+	flags.Duration("read-changes-watch-heartbeat-interval", defaultConfig.ReadChangesWatchHeartbeatInterval, "how long the streams of StreamedReadChanges can go without sending a response before they send an empty one, with the continuation token of the stream, so that proxies do not close idle streams. If 0, no heartbeats are sent")
+
+	flags.Uint32("read-changes-watch-max-streams", defaultConfig.ReadChangesWatchMaxStreams, "the maximum number of streams of StreamedReadChanges the server serves at once. Streams over the limit are rejected. If 0, there is no limit")
+
 	flags.Uint32("resolve-node-limit", defaultConfig.ResolveNodeLimit, "maximum resolution depth to attempt before throwing an error (defines how deeply nested an authorization model can be before a query errors out).")
 
 	flags.Uint32("resolve-node-breadth-limit", defaultConfig.ResolveNodeBreadthLimit, "defines how many nodes on a given level can be evaluated concurrently in a Check resolution tree")
 
-	flags.Duration("listObjects-deadline", defaultConfig.ListObjectsDeadline, "the timeout deadline for serving ListObjects and StreamedListObjects requests")
+	flags.Uint32("resolve-node-limit-shadow", defaultConfig.ResolveNodeLimitShadow, "a candidate resolve-node-limit that is measured but not enforced: Check resolutions deeper than it are counted by a metric and a sample of them is logged. If 0, disabled")
+
+	flags.Uint32("resolve-node-breadth-limit-shadow", defaultConfig.ResolveNodeBreadthLimitShadow, "a candidate resolve-node-breadth-limit that is measured but not enforced: Check resolutions in which a node evaluates more subproblems than it are counted by a metric and a sample of them is logged. If 0, disabled")
+
+	flags.Bool("resolver-worker-pool-enabled", defaultConfig.ResolverWorkerPoolEnabled, "run the concurrent subproblems of Check and ListObjects on a pool of long-lived goroutines instead of starting a goroutine per subproblem")
+
+	flags.Int("resolver-worker-pool-size", defaultConfig.ResolverWorkerPoolSize, "the number of goroutines of the resolver worker pool, if enabled. If 0, GOMAXPROCS times resolve-node-breadth-limit")
+
+	flags.Duration("listObjects-deadline", defaultConfig.ListObjectsDeadline, "the timeout deadline for serving ListObjects and StreamedListObjects requests. If 0, there is no deadline")
 
 	flags.Uint32("listObjects-max-results", defaultConfig.ListObjectsMaxResults, "the maximum results to return in non-streaming ListObjects API responses. If 0, all results can be returned")
 
+	flags.Uint32("listObjects-max-candidates", defaultConfig.ListObjectsMaxCandidates, "the maximum number of distinct candidate objects a ListObjects request may hold in memory before it fails with a resource exhausted error. If 0, there is no limit")
+
+	flags.String("listObjects-strategy", defaultConfig.ListObjectsStrategy, "how ListObjects and StreamedListObjects find objects: 'auto' picks the strategy from the shape of the requested relation, 'direct', 'reverse_expand', 'check_candidates' or 'filtered_base' force it ('direct' and 'filtered_base' fall back to 'reverse_expand' for relations they do not apply to)")
+
+	flags.Uint32("listObjects-stream-max-model-lag", defaultConfig.ListObjectsStreamMaxModelLag, "the number of authorization models that can be written during a StreamedListObjects stream of the latest model before the stream is aborted. If 0, streams are not aborted")
+
+	flags.Duration("listObjects-stream-model-check-interval", defaultConfig.ListObjectsStreamModelCheckInterval, "how often StreamedListObjects streams check the latest authorization model of the store when listObjects-stream-max-model-lag is set")
+
+	flags.Duration("max-stream-duration", defaultConfig.MaxStreamDuration, "the maximum duration of the streams of StreamedListObjects and StreamedReadChanges, after which they end gracefully with the Openfga-Stream-Truncated trailer. If 0, there is no limit")
+
+	flags.Uint32("expand-max-leaf-users", defaultConfig.ExpandMaxLeafUsers, "the maximum number of leaf users in the tree returned by Expand. If 0, there is no limit")
+
+	flags.Uint32("expand-max-nodes", defaultConfig.ExpandMaxNodes, "the maximum number of nodes in the tree returned by Expand. If 0, there is no limit")
+
+	flags.Bool("expand-strict-result-limit", defaultConfig.ExpandStrictResultLimit, "fail Expand requests that exceed expand-max-leaf-users or expand-max-nodes with a resource exhausted error instead of truncating the tree")
+
+	flags.Uint32("relation-statistics-max-sample-size", defaultConfig.RelationStatisticsMaxSampleSize, "the maximum number of tuples read by a single GetRelationStatistics call")
+
+	flags.Duration("relation-statistics-deadline", defaultConfig.RelationStatisticsDeadline, "the timeout deadline for GetRelationStatistics calls")
+
+	flags.Duration("relation-usage-deadline", defaultConfig.RelationUsageDeadline, "the timeout deadline for GetRelationUsage calls. Past it, datastores that cannot count the tuples of a store in one query report the counts of the tuples read so far")
+
+	flags.Uint32("delete-tuples-by-filter-max-tuples", defaultConfig.DeleteTuplesByFilterMaxTuples, "the maximum number of tuples deleted by a single DeleteTuplesByFilter call. Past it, the call reports that more tuples are selected by the filter. If 0, there is no limit")
+
+	flags.Uint32("delete-tuples-by-filter-max-scanned", defaultConfig.DeleteTuplesByFilterMaxScanned, "the maximum number of tuples read by a single DeleteTuplesByFilter call to find the ones selected by its filter. Past it, the call returns a continuation token to resume the scan. If 0, there is no limit")
+
+	flags.Duration("point-in-time-check-horizon", defaultConfig.PointInTimeCheckHorizon, "how far back a point-in-time Check can be evaluated (requires the 'enable-point-in-time-check' experimental flag). If 0, there is no limit")
+
+	flags.Uint32("point-in-time-check-max-changes", defaultConfig.PointInTimeCheckMaxChanges, "the maximum number of changelog entries read to evaluate a single point-in-time Check. If 0, there is no limit")
+
+	flags.Bool("error-reason-details", defaultConfig.ErrorReasonDetails, "add to the gRPC errors of Write, Check, ListObjects and ListUsers an ErrorInfo detail with the reason MODEL_INVALID, MODEL_NOT_FOUND, TUPLE_INVALID, CONDITION_CONTEXT_INVALID or LIMIT_EXCEEDED")
+
 	flags.Duration("listUsers-deadline", defaultConfig.ListUsersDeadline, "the timeout deadline for serving ListUsers requests. If 0, there is no deadline")
 
 	flags.Uint32("listUsers-max-results", defaultConfig.ListUsersMaxResults, "the maximum results to return in ListUsers API responses. If 0, all results can be returned")
@@ -217,6 +338,8 @@ func NewRunCommand() *cobra.Command {
 
 	flags.Duration("check-query-cache-ttl", defaultConfig.CheckQueryCache.TTL, "if caching of Check and ListObjects is enabled, this is the TTL of each value")
 
+	flags.Bool("check-query-cache-response-header", defaultConfig.CheckQueryCache.ResponseHeader, "if caching of Check is enabled, return whether the result of a Check was served from the cache, and how old the cached result was, in the Openfga-Check-Cache response header, e.g. 'hit; age=1.2s' or 'miss'")
+
 	// Unfortunately UintSlice/IntSlice does not work well when used as environment variable, we need to stick with string slice and convert back to integer
 	flags.StringSlice("request-duration-datastore-query-count-buckets", defaultConfig.RequestDurationDatastoreQueryCountBuckets, "datastore query count buckets used in labelling request_duration_ms.")
 
@@ -262,6 +385,42 @@ func NewRunCommand() *cobra.Command {
 
 	Define the maximum dispatch threshold beyond which requests will be throttled. 0 will use the 'dispatch-throttling-threshold' value as maximum`)
 
+	flags.Bool("request-priority-enabled", defaultConfig.RequestPriority.Enabled, "enable request priority classes. Requests with the 'Openfga-Request-Priority: low' header are throttled with lower dispatch thresholds and their datastore reads wait for the reads of normal priority requests.")
+
+	flags.Uint32("request-priority-max-deprioritization", defaultConfig.RequestPriority.MaxDeprioritization, "the percentage (0-100) by which dispatch throttling thresholds are lowered for low priority requests.")
+
+	flags.Bool("request-options-strict", defaultConfig.RequestOptions.Strict, "reject requests with 'Openfga-*' request headers unknown to the server, e.g. a misspelled request option.")
+
+	flags.Bool("session-affinity-enabled", defaultConfig.SessionAffinity.Enabled, "upgrade the Check, Read and ListObjects requests of a client that wrote tuples to a store to HIGHER_CONSISTENCY for the session affinity window, so that it reads its writes even when the datastore reads from replicas. Clients are identified by their authenticated subject, or else by their connection.")
+
+	flags.Duration("session-affinity-window", defaultConfig.SessionAffinity.Window, "if session affinity is enabled, how long after a write the reads of the client are upgraded to HIGHER_CONSISTENCY. It should exceed the replication delay of the datastore.")
+
+	flags.Uint32("session-affinity-max-sessions", defaultConfig.SessionAffinity.MaxSessions, "if session affinity is enabled, the maximum number of pairs of client and store whose writes are remembered. The least recently written are forgotten first.")
+
+	flags.StringSlice("membership-index-relations", defaultConfig.MembershipIndex.Relations, "the relations, as 'objectType#relation', whose members are indexed from the changelog so that Check answers them without resolving them, e.g. 'group#member'. Check only consults the index for relations that the model defines as directly related user types without conditions, and not for HIGHER_CONSISTENCY requests, as the index is eventually consistent.")
+
+	flags.Duration("membership-index-poll-interval", defaultConfig.MembershipIndex.PollInterval, "if the membership index is enabled, how often it reads the new changes of the indexed stores.")
+
+	flags.Duration("membership-index-max-staleness", defaultConfig.MembershipIndex.MaxStaleness, "if the membership index is enabled, how long after it last caught up with the changelog of a store it still answers the Check requests of the store. It must exceed the changelog horizon offset.")
+
+	flags.Int("membership-index-max-stores", defaultConfig.MembershipIndex.MaxStores, "if the membership index is enabled, how many stores it keeps. The least recently looked up store is dropped to index another one.")
+
+	flags.Duration("membership-index-idle-timeout", defaultConfig.MembershipIndex.IdleTimeout, "if the membership index is enabled, how long after the last Check that consulted it for a store it drops the store.")
+
+	flags.Bool("check-fair-read-sharing-enabled", defaultConfig.CheckFairReadSharing.Enabled, "make maxConcurrentReadsForCheck a limit of the concurrent reads of all the Check requests, instead of each request, shared fairly among the stores: a store may use the reads that other stores leave unused, but a burst of Check requests on one store does not hold back the reads of the other stores.")
+
+	flags.StringToInt("check-fair-read-sharing-store-weights", defaultConfig.CheckFairReadSharing.StoreWeights, "if fair read sharing for Check is enabled, the weights of stores, e.g. 'storeID=3,storeID=2'. Each store that is reading gets a share of the reads proportional to its weight. Stores without a weight have a weight of 1.")
+
+	flags.Int("tuple-quota-max-tuples", defaultConfig.TupleQuota.MaxTuples, "the maximum number of tuples of each store. Writes that would add tuples beyond it are rejected with a ResourceExhausted error. 0 means no limit")
+
+	flags.Int("tuple-quota-max-tuples-per-object-type", defaultConfig.TupleQuota.MaxTuplesPerObjectType, "the maximum number of tuples of each object type of each store, unless --tuple-quota-max-tuples-by-object-type sets another for the type. 0 means no limit")
+
+	flags.StringToInt("tuple-quota-max-tuples-by-object-type", defaultConfig.TupleQuota.MaxTuplesByObjectType, "the maximum number of tuples of the given object types of each store, e.g. 'document=100000,folder=1000'")
+
+	flags.StringToInt("tuple-quota-store-max-tuples", defaultConfig.TupleQuota.StoreMaxTuples, "the maximum number of tuples of the given stores, instead of --tuple-quota-max-tuples, e.g. 'storeID=1000000'")
+
+	flags.StringSlice("request-options-privileged-subjects", defaultConfig.RequestOptions.PrivilegedSubjects, "the authenticated subjects allowed to set privileged request options, e.g. the 'Openfga-No-Model-Cache' header, or '*' for every client.")
+
 	flags.Duration("request-timeout", defaultConfig.RequestTimeout, "configures request timeout.  If both HTTP upstream timeout and request timeout are specified, request timeout will be used.")
 
 	// NOTE: if you add a new flag here, update the function below, too
@@ -285,13 +444,46 @@ func ReadConfig() (*serverconfig.Config, error) {
 		}
 	}
 
-	if err := viper.Unmarshal(config); err != nil {
+	if err := viper.Unmarshal(config, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		stringToIntMapHookFunc(),
+	))); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal server config: %w", err)
 	}
 
 	return config, nil
 }
 
+// stringToIntMapHookFunc decodes the 'key=value,key=value' form of the map configs that are set
+// with environment variables, such as 'http.routeMaxBodySizesInBytes'.
+func stringToIntMapHookFunc() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(map[string]int{}) {
+			return data, nil
+		}
+
+		result := map[string]int{}
+		value := strings.Trim(data.(string), "[]")
+		if value == "" {
+			return result, nil
+		}
+
+		for _, pair := range strings.Split(value, ",") {
+			key, size, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid 'key=value' pair '%s'", pair)
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(size))
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of key '%s': %w", key, err)
+			}
+			result[strings.TrimSpace(key)] = n
+		}
+		return result, nil
+	}
+}
+
 func run(_ *cobra.Command, _ []string) {
 	config, err := ReadConfig()
 	if err != nil {
@@ -372,6 +564,9 @@ func (s *ServerContext) datastoreConfig(config *serverconfig.Config) (storage.Op
 		sqlcommon.WithMaxIdleConns(config.Datastore.MaxIdleConns),
 		sqlcommon.WithConnMaxIdleTime(config.Datastore.ConnMaxIdleTime),
 		sqlcommon.WithConnMaxLifetime(config.Datastore.ConnMaxLifetime),
+		sqlcommon.WithChangelogMode(sqlcommon.ChangelogMode(config.Datastore.ChangelogMode)),
+		sqlcommon.WithQueryShapeSampling(config.Datastore.QueryShapeSampling),
+		sqlcommon.WithTupleCounts(config.Datastore.TupleCounts),
 	}
 
 	if config.Datastore.Metrics.Enabled {
@@ -463,8 +658,10 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		return err
 	}
 
+	msgSizeLimits := grpcMessageSizeLimits(config)
+
 	serverOpts := []grpc.ServerOption{
-		grpc.MaxRecvMsgSize(serverconfig.DefaultMaxRPCMessageSizeInBytes),
+		grpc.MaxRecvMsgSize(msgSizeLimits.Max()),
 		grpc.ChainUnaryInterceptor(
 			[]grpc.UnaryServerInterceptor{
 				grpc_recovery.UnaryServerInterceptor( // panic middleware must be 1st in chain
@@ -472,8 +669,11 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 						recovery.PanicRecoveryHandler(s.Logger),
 					),
 				),
+				// the errors of the following interceptors need a reason too
+				errorreason.NewUnaryInterceptor(config.ErrorReasonDetails),
 				grpc_ctxtags.UnaryServerInterceptor(), // needed for logging
 				requestid.NewUnaryInterceptor(),       // add request_id to ctxtags
+				msgsize.NewUnaryInterceptor(msgSizeLimits),
 			}...,
 		),
 		grpc.ChainStreamInterceptor(
@@ -483,8 +683,10 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 						recovery.PanicRecoveryHandler(s.Logger),
 					),
 				),
+				errorreason.NewStreamingInterceptor(config.ErrorReasonDetails),
 				grpc_ctxtags.StreamServerInterceptor(), // needed for logging
 				requestid.NewStreamingInterceptor(),    // add request_id to ctxtags
+				msgsize.NewStreamingInterceptor(msgSizeLimits),
 			}...,
 		),
 	}
@@ -525,13 +727,24 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		serverOpts = append(serverOpts, grpc.StatsHandler(otelgrpc.NewServerHandler()))
 	}
 
+	requestOptions := []requestoptions.Option{
+		requestoptions.WithRequestPriority(config.RequestPriority.Enabled),
+		requestoptions.WithPrivilegedSubjects(config.RequestOptions.PrivilegedSubjects...),
+	}
+	if config.RequestOptions.Strict {
+		requestOptions = append(requestOptions, requestoptions.WithStrictHeaders(server.RequestHeaders...))
+	}
+
 	serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(
 		[]grpc.UnaryServerInterceptor{
 			grpcauth.UnaryServerInterceptor(authnmw.AuthFunc(authenticator)),
+			// the privileged request options depend on the authenticated subject
+			requestoptions.NewUnaryInterceptor(requestOptions...),
 		}...),
 		grpc.ChainStreamInterceptor(
 			[]grpc.StreamServerInterceptor{
 				grpcauth.StreamServerInterceptor(authnmw.AuthFunc(authenticator)),
+				requestoptions.NewStreamingInterceptor(requestOptions...),
 				// The following interceptors wrap the server stream with our own
 				// wrapper and must come last.
 				storeid.NewStreamingInterceptor(),
@@ -606,22 +819,53 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		server.WithTransport(gateway.NewRPCTransport(s.Logger)),
 		server.WithResolveNodeLimit(config.ResolveNodeLimit),
 		server.WithResolveNodeBreadthLimit(config.ResolveNodeBreadthLimit),
+		server.WithResolveNodeLimitShadow(config.ResolveNodeLimitShadow),
+		server.WithResolveNodeBreadthLimitShadow(config.ResolveNodeBreadthLimitShadow),
+		server.WithResolverWorkerPoolEnabled(config.ResolverWorkerPoolEnabled),
+		server.WithResolverWorkerPoolSize(config.ResolverWorkerPoolSize),
 		server.WithChangelogHorizonOffset(config.ChangelogHorizonOffset),
+		server.WithReadChangesWatchPollInterval(config.ReadChangesWatchPollInterval),
+		server.WithReadChangesWatchHeartbeatInterval(config.ReadChangesWatchHeartbeatInterval),
+		server.WithReadChangesWatchMaxStreams(config.ReadChangesWatchMaxStreams),
 		server.WithListObjectsDeadline(config.ListObjectsDeadline),
 		server.WithListObjectsMaxResults(config.ListObjectsMaxResults),
+		server.WithListObjectsMaxCandidates(config.ListObjectsMaxCandidates),
+		server.WithListObjectsStrategy(commands.ListObjectsStrategy(config.ListObjectsStrategy)),
+		server.WithStreamedListObjectsMaxModelLag(config.ListObjectsStreamMaxModelLag, config.ListObjectsStreamModelCheckInterval),
+		server.WithMaxStreamDuration(config.MaxStreamDuration),
+		server.WithExpandResultLimit(config.ExpandMaxLeafUsers, config.ExpandMaxNodes, config.ExpandStrictResultLimit),
+		server.WithRelationStatisticsLimits(config.RelationStatisticsMaxSampleSize, config.RelationStatisticsDeadline),
+		server.WithRelationUsageDeadline(config.RelationUsageDeadline),
+		server.WithDeleteTuplesByFilterMaxTuples(config.DeleteTuplesByFilterMaxTuples),
+		server.WithDeleteTuplesByFilterMaxScanned(config.DeleteTuplesByFilterMaxScanned),
+		server.WithPointInTimeCheckLimits(config.PointInTimeCheckHorizon, config.PointInTimeCheckMaxChanges),
+		server.WithErrorReasonDetails(config.ErrorReasonDetails),
 		server.WithListUsersDeadline(config.ListUsersDeadline),
 		server.WithListUsersMaxResults(config.ListUsersMaxResults),
 		server.WithMaxConcurrentReadsForListObjects(config.MaxConcurrentReadsForListObjects),
 		server.WithMaxConcurrentReadsForCheck(config.MaxConcurrentReadsForCheck),
+		server.WithSnapshotReadsForCheck(config.SnapshotReadsForCheck),
+		server.WithMaxChecksPerBatchCheck(config.MaxChecksPerBatchCheck),
 		server.WithMaxConcurrentReadsForListUsers(config.MaxConcurrentReadsForListUsers),
+		server.WithMaxConcurrentReadsForExpand(config.MaxConcurrentReadsForExpand),
+		server.WithMaxConcurrentReadsForRead(config.MaxConcurrentReadsForRead),
+		server.WithMaxReadResponseSizeInBytes(config.MaxReadResponseSizeInBytes),
 		server.WithCacheLimit(config.Cache.Limit),
 		server.WithCheckIteratorCacheEnabled(config.CheckIteratorCache.Enabled),
 		server.WithCheckIteratorCacheMaxResults(config.CheckIteratorCache.MaxResults),
 		server.WithCheckQueryCacheEnabled(config.CheckQueryCache.Enabled),
 		server.WithCheckQueryCacheTTL(config.CheckQueryCache.TTL),
+		server.WithCheckQueryCacheResponseHeader(config.CheckQueryCache.ResponseHeader),
 		server.WithRequestDurationByQueryHistogramBuckets(convertStringArrayToUintArray(config.RequestDurationDatastoreQueryCountBuckets)),
 		server.WithRequestDurationByDispatchCountHistogramBuckets(convertStringArrayToUintArray(config.RequestDurationDispatchCountBuckets)),
 		server.WithMaxAuthorizationModelSizeInBytes(config.MaxAuthorizationModelSizeInBytes),
+		server.WithMaxAuthorizationModelComplexity(config.MaxAuthorizationModelComplexity),
+		server.WithMaxAuthorizationModelTypes(config.MaxAuthorizationModelTypes),
+		server.WithMaxAuthorizationModelRelationsPerType(config.MaxAuthorizationModelRelationsPerType),
+		server.WithMaxAuthorizationModelRewriteDepth(config.MaxAuthorizationModelRewriteDepth),
+		server.WithAuthorizationModelValidationTimeout(config.AuthorizationModelValidationTimeout),
+		server.WithAuthorizationModelValidationMaxVisits(config.AuthorizationModelValidationMaxVisits),
+		server.WithModelImpactAnalysisMaxProbes(config.ModelImpactAnalysisMaxProbes),
 		server.WithDispatchThrottlingCheckResolverEnabled(checkDispatchThrottlingConfig.Enabled),
 		server.WithDispatchThrottlingCheckResolverFrequency(checkDispatchThrottlingConfig.Frequency),
 		server.WithDispatchThrottlingCheckResolverThreshold(checkDispatchThrottlingConfig.Threshold),
@@ -634,7 +878,37 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		server.WithListUsersDispatchThrottlingFrequency(config.ListUsersDispatchThrottling.Frequency),
 		server.WithListUsersDispatchThrottlingThreshold(config.ListUsersDispatchThrottling.Threshold),
 		server.WithListUsersDispatchThrottlingMaxThreshold(config.ListUsersDispatchThrottling.MaxThreshold),
+		server.WithRequestPriorityEnabled(config.RequestPriority.Enabled),
+		server.WithRequestPriorityMaxDeprioritization(config.RequestPriority.MaxDeprioritization),
+		server.WithPerStoreWriteMetrics(config.Metrics.PerStoreWriteAllowlist),
+		server.WithPerStoreModelComplexityMetrics(config.Metrics.PerStoreModelComplexityAllowlist),
+		server.WithChangelogMetricsInterval(config.Metrics.ChangelogInterval),
+		server.WithPerStoreChangelogMetrics(config.Metrics.PerStoreChangelogAllowlist),
 		server.WithExperimentals(experimentals...),
+		server.WithReadOnlyMode(config.ReadOnly),
+		server.WithSessionAffinityEnabled(config.SessionAffinity.Enabled),
+		server.WithSessionAffinityWindow(config.SessionAffinity.Window),
+		server.WithSessionAffinityMaxSessions(config.SessionAffinity.MaxSessions),
+		server.WithDatastoreHedgingEnabled(config.Datastore.Hedging.Enabled),
+		server.WithDatastoreHedgingMinDelay(config.Datastore.Hedging.MinDelay),
+		server.WithDatastoreHedgingPercentile(config.Datastore.Hedging.Percentile),
+		server.WithDatastoreHedgingMaxPerRequest(config.Datastore.Hedging.MaxPerRequest),
+		server.WithMembershipIndexRelations(config.MembershipIndex.Relations),
+		server.WithMembershipIndexPollInterval(config.MembershipIndex.PollInterval),
+		server.WithMembershipIndexMaxStaleness(config.MembershipIndex.MaxStaleness),
+		server.WithMembershipIndexMaxStores(config.MembershipIndex.MaxStores),
+		server.WithMembershipIndexIdleTimeout(config.MembershipIndex.IdleTimeout),
+		server.WithCheckFairReadSharing(config.CheckFairReadSharing.Enabled),
+		server.WithCheckStoreReadWeights(storeReadWeights(config.CheckFairReadSharing.StoreWeights)),
+		server.WithTupleQuota(tupleQuota(config.TupleQuota)),
+		server.WithTupleQuotaOverrides(tupleQuotaOverrides(config.TupleQuota)),
+		server.WithPerStoreReadWaitMetrics(config.Metrics.PerStoreReadWaitAllowlist),
+		server.WithListStoresExactTotalCount(config.ListStoresExactTotalCount),
+		server.WithUniqueStoreNames(config.UniqueStoreNames),
+		server.WithRejectWritesOnStaleModel(config.RejectWritesOnStaleModel),
+		server.WithReadChangesHorizonHeaders(config.ReadChangesHorizonHeaders),
+		server.WithCheckResponseMetadataHeaders(config.CheckResponseMetadataHeaders),
+		server.WithCheckDeduplicationEnabled(config.CheckDeduplicationEnabled),
 		server.WithContext(ctx),
 	)
 
@@ -647,12 +921,24 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		zap.Any("config", config),
 	)
 
+	var adminServer *http.Server
+	if config.Admin.Enabled {
+		adminServer = &http.Server{Addr: config.Admin.Addr, Handler: server.NewAdminHandler(svr, server.WithAdminToken(config.Admin.Token))}
+
+		go func() {
+			s.Logger.Info(fmt.Sprintf("🔧 starting admin server on '%s'", config.Admin.Addr))
+			if err := adminServer.ListenAndServe(); err != nil {
+				if err != http.ErrServerClosed {
+					s.Logger.Fatal("failed to start admin server", zap.Error(err))
+				}
+			}
+			s.Logger.Info("admin server shut down.")
+		}()
+	}
+
 	// nosemgrep: grpc-server-insecure-connection
 	grpcServer := grpc.NewServer(serverOpts...)
-	openfgav1.RegisterOpenFGAServiceServer(grpcServer, svr)
-	healthServer := &health.Checker{TargetService: svr, TargetServiceName: openfgav1.OpenFGAService_ServiceDesc.ServiceName}
-	healthv1pb.RegisterHealthServer(grpcServer, healthServer)
-	reflection.Register(grpcServer)
+	server.RegisterGRPC(grpcServer, svr, server.WithReflection(true))
 
 	lis, err := net.Listen("tcp", config.GRPC.Addr)
 	if err != nil {
@@ -700,22 +986,17 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		}
 		defer conn.Close()
 
-		muxOpts := []runtime.ServeMuxOption{
-			runtime.WithForwardResponseOption(httpmiddleware.HTTPResponseModifier),
-			runtime.WithErrorHandler(func(c context.Context, sr *runtime.ServeMux, mm runtime.Marshaler, w http.ResponseWriter, r *http.Request, e error) {
-				intCode := serverErrors.ConvertToEncodedErrorCode(status.Convert(e))
-				httpmiddleware.CustomHTTPErrorHandler(c, w, r, serverErrors.NewEncodedError(intCode, e.Error()))
-			}),
-			runtime.WithStreamErrorHandler(func(ctx context.Context, e error) *status.Status {
-				intCode := serverErrors.ConvertToEncodedErrorCode(status.Convert(e))
-				encodedErr := serverErrors.NewEncodedError(intCode, e.Error())
-				return status.Convert(encodedErr)
-			}),
-			runtime.WithHealthzEndpoint(healthv1pb.NewHealthClient(conn)),
-			runtime.WithOutgoingHeaderMatcher(func(s string) (string, bool) { return s, true }),
-		}
-		mux := runtime.NewServeMux(muxOpts...)
-		if err := openfgav1.RegisterOpenFGAServiceHandler(ctx, mux, conn); err != nil {
+		mux, err := server.NewGatewayMux(ctx, conn,
+			server.WithGatewayIncomingHeaders(
+				append(slices.Clone(requestcontext.Headers), server.RequestHeaders...)...,
+			),
+			server.WithGatewayMaxBodySize(
+				int64(config.HTTP.MaxBodySizeInBytes),
+				routeMaxBodySizes(config.HTTP.RouteMaxBodySizesInBytes),
+			),
+			server.WithGatewayStrictJSON(config.HTTP.StrictJSON),
+		)
+		if err != nil {
 			return err
 		}
 		handler := http.Handler(mux)
@@ -857,6 +1138,12 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		}
 	}
 
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctx); err != nil {
+			s.Logger.Info("failed to shutdown the admin server", zap.Error(err))
+		}
+	}
+
 	if metricsServer != nil {
 		if err := metricsServer.Shutdown(ctx); err != nil {
 			s.Logger.Info("failed to shutdown the prometheus metrics server", zap.Error(err))
@@ -877,3 +1164,67 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 
 	return nil
 }
+
+// grpcMessageSizeLimits returns the maximum sizes of the request messages of the gRPC methods.
+// The gateway forwards the bodies of its routes as messages, so a method accepts the messages
+// as large as the bodies of its route. The other methods are limited to the default message
+// size, or to the default body size of the gateway if it is larger.
+func grpcMessageSizeLimits(config *serverconfig.Config) msgsize.Limits {
+	limits := msgsize.Limits{Default: serverconfig.DefaultMaxRPCMessageSizeInBytes}
+	if !config.HTTP.Enabled {
+		return limits
+	}
+
+	limits.Default = max(limits.Default, config.HTTP.MaxBodySizeInBytes)
+	limits.Methods = make(map[string]int, len(config.HTTP.RouteMaxBodySizesInBytes))
+	for route, size := range config.HTTP.RouteMaxBodySizesInBytes {
+		if method, ok := server.GatewayRouteMethods[route]; ok {
+			limits.Methods[method] = max(serverconfig.DefaultMaxRPCMessageSizeInBytes, size)
+		}
+	}
+	return limits
+}
+
+func routeMaxBodySizes(sizes map[string]int) map[string]int64 {
+	result := make(map[string]int64, len(sizes))
+	for route, size := range sizes {
+		result[route] = int64(size)
+	}
+	return result
+}
+
+// storeReadWeights converts the weights of the config, which are verified to fit in a uint32.
+func storeReadWeights(weights map[string]int) map[string]uint32 {
+	result := make(map[string]uint32, len(weights))
+	for storeID, weight := range weights {
+		result[storeID] = uint32(weight)
+	}
+	return result
+}
+
+// tupleQuota returns the quota of every store of the config.
+func tupleQuota(config serverconfig.TupleQuotaConfig) commands.TupleQuota {
+	quota := commands.TupleQuota{
+		MaxTuples:              int64(config.MaxTuples),
+		MaxTuplesPerObjectType: int64(config.MaxTuplesPerObjectType),
+	}
+	if len(config.MaxTuplesByObjectType) > 0 {
+		quota.MaxTuplesByObjectType = make(map[string]int64, len(config.MaxTuplesByObjectType))
+		for objectType, maxTuples := range config.MaxTuplesByObjectType {
+			quota.MaxTuplesByObjectType[objectType] = int64(maxTuples)
+		}
+	}
+	return quota
+}
+
+// tupleQuotaOverrides returns the quotas of the stores of the config with their own maximum
+// number of tuples, which keep the other limits of the quota of every store.
+func tupleQuotaOverrides(config serverconfig.TupleQuotaConfig) map[string]commands.TupleQuota {
+	overrides := make(map[string]commands.TupleQuota, len(config.StoreMaxTuples))
+	for storeID, maxTuples := range config.StoreMaxTuples {
+		quota := tupleQuota(config)
+		quota.MaxTuples = int64(maxTuples)
+		overrides[storeID] = quota
+	}
+	return overrides
+}
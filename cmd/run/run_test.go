@@ -28,10 +28,14 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"go.uber.org/goleak"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/openfga/openfga/pkg/middleware/requestid"
 	"github.com/openfga/openfga/pkg/middleware/storeid"
 	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/server/commands"
 	"github.com/openfga/openfga/pkg/testutils"
 
 	"github.com/openfga/openfga/cmd"
@@ -996,6 +1000,38 @@ func TestHTTPServerEnabled(t *testing.T) {
 	testutils.EnsureServiceHealthy(t, cfg.GRPC.Addr, cfg.HTTP.Addr, nil)
 }
 
+func TestStartupLogOmitsAdminToken(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	cfg := testutils.MustDefaultConfigWithRandomPorts()
+	cfg.Admin.Token = "secret-admin-token"
+
+	observerLogger, logs := observer.New(zap.InfoLevel)
+	serverCtx := &ServerContext{Logger: &logger.ZapLogger{Logger: zap.New(observerLogger)}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		if err := serverCtx.Run(ctx, cfg); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	testutils.EnsureServiceHealthy(t, cfg.GRPC.Addr, cfg.HTTP.Addr, nil)
+
+	startLogs := logs.FilterMessage("starting openfga service...").All()
+	require.Len(t, startLogs, 1)
+
+	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	buf, err := encoder.EncodeEntry(startLogs[0].Entry, startLogs[0].Context)
+	require.NoError(t, err)
+	defer buf.Free()
+	require.Contains(t, buf.String(), `"config":`)
+	require.NotContains(t, buf.String(), cfg.Admin.Token)
+}
+
 func TestDefaultConfig(t *testing.T) {
 	cfg, err := ReadConfig()
 	require.NoError(t, err)
@@ -1028,10 +1064,38 @@ func TestDefaultConfig(t *testing.T) {
 	val = res.Get("properties.datastore.properties.connMaxLifetime.default")
 	require.True(t, val.Exists())
 
+	val = res.Get("properties.datastore.properties.changelogMode.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Datastore.ChangelogMode)
+
+	val = res.Get("properties.datastore.properties.queryShapeSampling.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.Datastore.QueryShapeSampling)
+
+	val = res.Get("properties.datastore.properties.tupleCounts.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.Datastore.TupleCounts)
+
 	val = res.Get("properties.datastore.properties.metrics.properties.enabled.default")
 	require.True(t, val.Exists())
 	require.False(t, val.Bool())
 
+	val = res.Get("properties.datastore.properties.hedging.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.Datastore.Hedging.Enabled)
+
+	val = res.Get("properties.datastore.properties.hedging.properties.minDelay.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Datastore.Hedging.MinDelay.String())
+
+	val = res.Get("properties.datastore.properties.hedging.properties.percentile.default")
+	require.True(t, val.Exists())
+	require.InDelta(t, val.Float(), cfg.Datastore.Hedging.Percentile, 0)
+
+	val = res.Get("properties.datastore.properties.hedging.properties.maxPerRequest.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.Datastore.Hedging.MaxPerRequest)
+
 	val = res.Get("properties.grpc.properties.addr.default")
 	require.True(t, val.Exists())
 	require.Equal(t, val.String(), cfg.GRPC.Addr)
@@ -1044,6 +1108,22 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.Equal(t, val.String(), cfg.HTTP.Addr)
 
+	val = res.Get("properties.http.properties.maxBodySizeInBytes.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.HTTP.MaxBodySizeInBytes)
+
+	val = res.Get("properties.http.properties.routeMaxBodySizesInBytes.default")
+	require.True(t, val.Exists())
+	routeMaxBodySizes := map[string]int{}
+	for route, size := range val.Map() {
+		routeMaxBodySizes[route] = int(size.Int())
+	}
+	require.Equal(t, routeMaxBodySizes, cfg.HTTP.RouteMaxBodySizesInBytes)
+
+	val = res.Get("properties.http.properties.strictJSON.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.HTTP.StrictJSON)
+
 	val = res.Get("properties.playground.properties.enabled.default")
 	require.True(t, val.Exists())
 	require.Equal(t, val.Bool(), cfg.Playground.Enabled)
@@ -1060,6 +1140,18 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.Equal(t, val.String(), cfg.Profiler.Addr)
 
+	val = res.Get("properties.admin.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.Admin.Enabled)
+
+	val = res.Get("properties.admin.properties.addr.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Admin.Addr)
+
+	val = res.Get("properties.admin.properties.token.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Admin.Token)
+
 	val = res.Get("properties.authn.properties.method.default")
 	require.True(t, val.Exists())
 	require.Equal(t, val.String(), cfg.Authn.Method)
@@ -1076,6 +1168,34 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.EqualValues(t, val.Int(), cfg.MaxTypesPerAuthorizationModel)
 
+	val = res.Get("properties.maxAuthorizationModelComplexity.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.MaxAuthorizationModelComplexity)
+
+	val = res.Get("properties.maxAuthorizationModelTypes.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.MaxAuthorizationModelTypes)
+
+	val = res.Get("properties.maxAuthorizationModelRelationsPerType.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.MaxAuthorizationModelRelationsPerType)
+
+	val = res.Get("properties.maxAuthorizationModelRewriteDepth.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.MaxAuthorizationModelRewriteDepth)
+
+	val = res.Get("properties.authorizationModelValidationTimeout.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.AuthorizationModelValidationTimeout.String())
+
+	val = res.Get("properties.authorizationModelValidationMaxVisits.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.AuthorizationModelValidationMaxVisits)
+
+	val = res.Get("properties.modelImpactAnalysisMaxProbes.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.ModelImpactAnalysisMaxProbes)
+
 	val = res.Get("properties.maxConcurrentReadsForListObjects.default")
 	require.True(t, val.Exists())
 	require.EqualValues(t, val.Int(), cfg.MaxConcurrentReadsForListObjects)
@@ -1084,6 +1204,14 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.EqualValues(t, val.Int(), cfg.MaxConcurrentReadsForCheck)
 
+	val = res.Get("properties.snapshotReadsForCheck.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.SnapshotReadsForCheck)
+
+	val = res.Get("properties.maxChecksPerBatchCheck.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.MaxChecksPerBatchCheck)
+
 	val = res.Get("properties.maxConditionEvaluationCost.default")
 	require.True(t, val.Exists())
 	require.EqualValues(t, val.Uint(), cfg.MaxConditionEvaluationCost)
@@ -1092,14 +1220,54 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.EqualValues(t, val.Int(), cfg.MaxConcurrentReadsForListUsers)
 
+	val = res.Get("properties.maxConcurrentReadsForExpand.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.MaxConcurrentReadsForExpand)
+
+	val = res.Get("properties.maxConcurrentReadsForRead.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.MaxConcurrentReadsForRead)
+
+	val = res.Get("properties.maxReadResponseSizeInBytes.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.MaxReadResponseSizeInBytes)
+
 	val = res.Get("properties.changelogHorizonOffset.default")
 	require.True(t, val.Exists())
 	require.EqualValues(t, val.Int(), cfg.ChangelogHorizonOffset)
 
+	val = res.Get("properties.readChangesWatchPollInterval.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.ReadChangesWatchPollInterval.String())
+
+	val = res.Get("properties.readChangesWatchHeartbeatInterval.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.ReadChangesWatchHeartbeatInterval.String())
+
+	val = res.Get("properties.readChangesWatchMaxStreams.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.ReadChangesWatchMaxStreams)
+
 	val = res.Get("properties.resolveNodeBreadthLimit.default")
 	require.True(t, val.Exists())
 	require.EqualValues(t, val.Int(), cfg.ResolveNodeBreadthLimit)
 
+	val = res.Get("properties.resolveNodeLimitShadow.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.ResolveNodeLimitShadow)
+
+	val = res.Get("properties.resolveNodeBreadthLimitShadow.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.ResolveNodeBreadthLimitShadow)
+
+	val = res.Get("properties.resolverWorkerPoolEnabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.ResolverWorkerPoolEnabled)
+
+	val = res.Get("properties.resolverWorkerPoolSize.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.ResolverWorkerPoolSize)
+
 	val = res.Get("properties.resolveNodeLimit.default")
 	require.True(t, val.Exists())
 	require.EqualValues(t, val.Int(), cfg.ResolveNodeLimit)
@@ -1120,6 +1288,70 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.EqualValues(t, val.Int(), cfg.ListObjectsMaxResults)
 
+	val = res.Get("properties.listObjectsMaxCandidates.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.ListObjectsMaxCandidates)
+
+	val = res.Get("properties.listObjectsStrategy.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.ListObjectsStrategy)
+
+	val = res.Get("properties.listObjectsStreamMaxModelLag.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.ListObjectsStreamMaxModelLag)
+
+	val = res.Get("properties.listObjectsStreamModelCheckInterval.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.ListObjectsStreamModelCheckInterval.String())
+
+	val = res.Get("properties.maxStreamDuration.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.MaxStreamDuration.String())
+
+	val = res.Get("properties.expandMaxLeafUsers.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.ExpandMaxLeafUsers)
+
+	val = res.Get("properties.expandMaxNodes.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.ExpandMaxNodes)
+
+	val = res.Get("properties.expandStrictResultLimit.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.ExpandStrictResultLimit)
+
+	val = res.Get("properties.relationStatisticsMaxSampleSize.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.RelationStatisticsMaxSampleSize)
+
+	val = res.Get("properties.relationStatisticsDeadline.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.RelationStatisticsDeadline.String())
+
+	val = res.Get("properties.relationUsageDeadline.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.RelationUsageDeadline.String())
+
+	val = res.Get("properties.deleteTuplesByFilterMaxTuples.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.DeleteTuplesByFilterMaxTuples)
+
+	val = res.Get("properties.deleteTuplesByFilterMaxScanned.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.DeleteTuplesByFilterMaxScanned)
+
+	val = res.Get("properties.pointInTimeCheckHorizon.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.PointInTimeCheckHorizon.String())
+
+	val = res.Get("properties.pointInTimeCheckMaxChanges.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.PointInTimeCheckMaxChanges)
+
+	val = res.Get("properties.errorReasonDetails.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.ErrorReasonDetails)
+
 	val = res.Get("properties.listUsersDeadline.default")
 	require.True(t, val.Exists())
 	require.Equal(t, val.String(), cfg.ListUsersDeadline.String())
@@ -1132,6 +1364,34 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.Equal(t, len(val.Array()), len(cfg.Experimentals))
 
+	val = res.Get("properties.readOnly.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.ReadOnly)
+
+	val = res.Get("properties.listStoresExactTotalCount.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.ListStoresExactTotalCount)
+
+	val = res.Get("properties.uniqueStoreNames.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.UniqueStoreNames)
+
+	val = res.Get("properties.rejectWritesOnStaleModel.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.RejectWritesOnStaleModel)
+
+	val = res.Get("properties.readChangesHorizonHeaders.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.ReadChangesHorizonHeaders)
+
+	val = res.Get("properties.checkResponseMetadataHeaders.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.CheckResponseMetadataHeaders)
+
+	val = res.Get("properties.checkDeduplicationEnabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.CheckDeduplicationEnabled)
+
 	val = res.Get("properties.metrics.properties.enabled.default")
 	require.True(t, val.Exists())
 	require.Equal(t, val.Bool(), cfg.Metrics.Enabled)
@@ -1144,6 +1404,10 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.Equal(t, val.Bool(), cfg.Metrics.EnableRPCHistograms)
 
+	val = res.Get("properties.metrics.properties.changelogInterval.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.Metrics.ChangelogInterval.String())
+
 	val = res.Get("properties.trace.properties.serviceName.default")
 	require.True(t, val.Exists())
 	require.Equal(t, val.String(), cfg.Trace.ServiceName)
@@ -1168,6 +1432,10 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, val.Exists())
 	require.Equal(t, val.String(), cfg.CheckQueryCache.TTL.String())
 
+	val = res.Get("properties.checkQueryCache.properties.responseHeader.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.CheckQueryCache.ResponseHeader)
+
 	val = res.Get("properties.requestDurationDatastoreQueryCountBuckets.default")
 	require.True(t, val.Exists())
 	require.Equal(t, len(val.Array()), len(cfg.RequestDurationDatastoreQueryCountBuckets))
@@ -1245,6 +1513,78 @@ func TestDefaultConfig(t *testing.T) {
 	val = res.Get("properties.requestTimeout.default")
 	require.True(t, val.Exists())
 	require.EqualValues(t, val.String(), cfg.RequestTimeout.String())
+
+	val = res.Get("properties.requestPriority.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.RequestPriority.Enabled)
+
+	val = res.Get("properties.requestPriority.properties.maxDeprioritization.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.RequestPriority.MaxDeprioritization)
+
+	val = res.Get("properties.sessionAffinity.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.SessionAffinity.Enabled)
+
+	val = res.Get("properties.sessionAffinity.properties.window.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.SessionAffinity.Window.String())
+
+	val = res.Get("properties.sessionAffinity.properties.maxSessions.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.SessionAffinity.MaxSessions)
+
+	val = res.Get("properties.membershipIndex.properties.pollInterval.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.MembershipIndex.PollInterval.String())
+
+	val = res.Get("properties.membershipIndex.properties.maxStaleness.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.String(), cfg.MembershipIndex.MaxStaleness.String())
+
+	val = res.Get("properties.membershipIndex.properties.maxStores.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.MembershipIndex.MaxStores)
+
+	val = res.Get("properties.membershipIndex.properties.idleTimeout.default")
+	require.True(t, val.Exists())
+	require.Equal(t, "10m", val.String())
+	require.Equal(t, 10*time.Minute, cfg.MembershipIndex.IdleTimeout)
+
+	val = res.Get("properties.checkFairReadSharing.properties.enabled.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.CheckFairReadSharing.Enabled)
+
+	val = res.Get("properties.checkFairReadSharing.properties.storeWeights.default")
+	require.True(t, val.Exists())
+	require.Empty(t, val.Map())
+	require.Empty(t, cfg.CheckFairReadSharing.StoreWeights)
+
+	val = res.Get("properties.tupleQuota.properties.maxTuples.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.TupleQuota.MaxTuples)
+
+	val = res.Get("properties.tupleQuota.properties.maxTuplesPerObjectType.default")
+	require.True(t, val.Exists())
+	require.EqualValues(t, val.Int(), cfg.TupleQuota.MaxTuplesPerObjectType)
+
+	val = res.Get("properties.tupleQuota.properties.maxTuplesByObjectType.default")
+	require.True(t, val.Exists())
+	require.Empty(t, val.Map())
+	require.Empty(t, cfg.TupleQuota.MaxTuplesByObjectType)
+
+	val = res.Get("properties.tupleQuota.properties.storeMaxTuples.default")
+	require.True(t, val.Exists())
+	require.Empty(t, val.Map())
+	require.Empty(t, cfg.TupleQuota.StoreMaxTuples)
+
+	val = res.Get("properties.requestOptions.properties.strict.default")
+	require.True(t, val.Exists())
+	require.Equal(t, val.Bool(), cfg.RequestOptions.Strict)
+
+	val = res.Get("properties.requestOptions.properties.privilegedSubjects.default")
+	require.True(t, val.Exists())
+	require.Equal(t, len(val.Array()), len(cfg.RequestOptions.PrivilegedSubjects))
 }
 
 func TestRunCommandNoConfigDefaultValues(t *testing.T) {
@@ -1312,6 +1652,68 @@ requestDurationDispatchCountBuckets: [32,42]
 	require.Equal(t, []string{"32", "42"}, cfg.RequestDurationDispatchCountBuckets)
 }
 
+func TestParseRouteMaxBodySizesFromEnv(t *testing.T) {
+	util.PrepareTempConfigFile(t, "")
+
+	t.Setenv("OPENFGA_HTTP_ROUTE_MAX_BODY_SIZES_IN_BYTES", "write=2097152,check=65536")
+
+	runCmd := NewRunCommand()
+	runCmd.RunE = func(cmd *cobra.Command, _ []string) error {
+		return nil
+	}
+	rootCmd := cmd.NewRootCommand()
+	rootCmd.AddCommand(runCmd)
+	rootCmd.SetArgs([]string{"run"})
+	require.NoError(t, rootCmd.Execute())
+
+	cfg, err := ReadConfig()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"write": 2097152, "check": 65536}, cfg.HTTP.RouteMaxBodySizesInBytes)
+}
+
+func TestTupleQuotaFromEnv(t *testing.T) {
+	util.PrepareTempConfigFile(t, "")
+
+	t.Setenv("OPENFGA_TUPLE_QUOTA_MAX_TUPLES", "1000")
+	t.Setenv("OPENFGA_TUPLE_QUOTA_MAX_TUPLES_BY_OBJECT_TYPE", "document=100")
+	t.Setenv("OPENFGA_TUPLE_QUOTA_STORE_MAX_TUPLES", "01JCZ6AN3K4V7RDRG9XW8PQ5MN=5000")
+
+	runCmd := NewRunCommand()
+	runCmd.RunE = func(cmd *cobra.Command, _ []string) error {
+		return nil
+	}
+	rootCmd := cmd.NewRootCommand()
+	rootCmd.AddCommand(runCmd)
+	rootCmd.SetArgs([]string{"run"})
+	require.NoError(t, rootCmd.Execute())
+
+	cfg, err := ReadConfig()
+	require.NoError(t, err)
+
+	quota := commands.TupleQuota{MaxTuples: 1000, MaxTuplesByObjectType: map[string]int64{"document": 100}}
+	require.Equal(t, quota, tupleQuota(cfg.TupleQuota))
+
+	quota.MaxTuples = 5000
+	require.Equal(t, map[string]commands.TupleQuota{"01JCZ6AN3K4V7RDRG9XW8PQ5MN": quota}, tupleQuotaOverrides(cfg.TupleQuota))
+}
+
+func TestGRPCMessageSizeLimits(t *testing.T) {
+	cfg := serverconfig.DefaultConfig()
+	cfg.HTTP.MaxBodySizeInBytes = 1 << 20
+	cfg.HTTP.RouteMaxBodySizesInBytes = map[string]int{"write": 64 << 20, "check": 1 << 10, "unknown": 128 << 20}
+
+	limits := grpcMessageSizeLimits(cfg)
+	require.Equal(t, 1<<20, limits.Default)
+	require.Equal(t, map[string]int{
+		openfgav1.OpenFGAService_Write_FullMethodName: 64 << 20,
+		openfgav1.OpenFGAService_Check_FullMethodName: serverconfig.DefaultMaxRPCMessageSizeInBytes,
+	}, limits.Methods)
+	require.Equal(t, 64<<20, limits.Max())
+
+	cfg.HTTP.Enabled = false
+	require.Equal(t, serverconfig.DefaultMaxRPCMessageSizeInBytes, grpcMessageSizeLimits(cfg).Max())
+}
+
 func TestRunCommandConfigIsMerged(t *testing.T) {
 	config := `datastore:
     engine: postgres
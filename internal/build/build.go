@@ -17,5 +17,10 @@ var (
 	// this specific build of OpenFGA. Refer to the `assets/migrations` artifacts for more information.
 	MinimumSupportedDatastoreSchemaRevision int64 = 4
 
+	// AuthzModelSchemaVersion is the version of the schema of the root authorization model that the
+	// FGA-on-FGA access control of this build expects, so that the builds expecting different
+	// relations can be told apart, e.g. during rolling deploys.
+	AuthzModelSchemaVersion = "1"
+
 	ProjectName = "openfga"
 )
@@ -3,7 +3,6 @@ package checkutil
 import (
 	"context"
 	"errors"
-	"fmt"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"google.golang.org/protobuf/types/known/structpb"
@@ -27,9 +26,10 @@ func BuildTupleKeyConditionFilter(ctx context.Context, reqCtx *structpb.Struct,
 		if len(condEvalResult.MissingParameters) > 0 {
 			return false, condition.NewEvaluationError(
 				t.GetCondition().GetName(),
-				fmt.Errorf("tuple '%s' is missing context parameters '%v'",
-					tuple.TupleKeyToString(t),
-					condEvalResult.MissingParameters),
+				&condition.MissingParametersError{
+					Tuple:      tuple.TupleKeyToString(t),
+					Parameters: condEvalResult.MissingParameters,
+				},
 			)
 		}
 
@@ -125,8 +125,10 @@ func TestBuildTupleKeyConditionFilter(t *testing.T) {
 `),
 			context:      map[string]interface{}{"x": 5},
 			conditionMet: false,
-			expectedErr: condition.NewEvaluationError("x_y",
-				fmt.Errorf("tuple 'document:1#can_view@user:maria' is missing context parameters '[y]'")),
+			expectedErr: condition.NewEvaluationError("x_y", &condition.MissingParametersError{
+				Tuple:      "document:1#can_view@user:maria",
+				Parameters: []string{"y"},
+			}),
 		},
 	}
 
@@ -2,6 +2,7 @@ package concurrency
 
 import (
 	"context"
+	"sync"
 
 	"github.com/sourcegraph/conc/pool"
 )
@@ -24,3 +25,92 @@ func TrySendThroughChannel[T any](ctx context.Context, msg T, channel chan<- T)
 	case channel <- msg:
 	}
 }
+
+// WorkerPool runs tasks on a fixed set of long-lived goroutines, so that hot fan-out paths,
+// such as the resolution of Check subproblems, do not start a goroutine per task.
+type WorkerPool struct {
+	tasks     chan func()
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewWorkerPool starts a WorkerPool of the given number of workers, and returns once they
+// are all running. Close must be called to stop them.
+func NewWorkerPool(size int) *WorkerPool {
+	p := &WorkerPool{
+		tasks: make(chan func()),
+		done:  make(chan struct{}),
+	}
+
+	// without waiting, the first tasks would find no idle worker and run inline
+	var started sync.WaitGroup
+	started.Add(size)
+	p.wg.Add(size)
+	for i := 0; i < size; i++ {
+		go p.work(&started)
+	}
+	started.Wait()
+	return p
+}
+
+func (p *WorkerPool) work(started *sync.WaitGroup) {
+	defer p.wg.Done()
+	started.Done()
+	for {
+		select {
+		case task := <-p.tasks:
+			task()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// TryGo hands the task to an idle worker and reports whether one was available.
+// It never blocks, and always returns false once the pool is closed.
+func (p *WorkerPool) TryGo(task func()) bool {
+	select {
+	case p.tasks <- task:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops the workers once they finish their current task, and waits for them.
+func (p *WorkerPool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+	})
+	p.wg.Wait()
+}
+
+type workerPoolContextKey struct{}
+
+// ContextWithWorkerPool returns a context whose tasks started with Go run on the given pool.
+func ContextWithWorkerPool(ctx context.Context, p *WorkerPool) context.Context {
+	return context.WithValue(ctx, workerPoolContextKey{}, p)
+}
+
+// WorkerPoolFromContext returns the pool set by ContextWithWorkerPool, or nil.
+func WorkerPoolFromContext(ctx context.Context) *WorkerPool {
+	p, _ := ctx.Value(workerPoolContextKey{}).(*WorkerPool)
+	return p
+}
+
+// Go runs the task concurrently. If the context has a WorkerPool, the task runs on one of its
+// idle workers or, when every worker is busy, inline in the calling goroutine: waiting for a
+// worker instead could deadlock, since tasks running on the workers submit nested tasks.
+// Without a pool, the task runs in a new goroutine.
+func Go(ctx context.Context, task func()) {
+	p := WorkerPoolFromContext(ctx)
+	if p == nil {
+		go task()
+		return
+	}
+
+	if !p.TryGo(task) {
+		task()
+	}
+}
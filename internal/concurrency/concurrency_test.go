@@ -3,8 +3,10 @@ package concurrency
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
 )
 
 func TestTrySendThroughChannel(t *testing.T) {
@@ -48,3 +50,53 @@ func TestTrySendThroughChannel(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkerPool(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	t.Run("runs_tasks_on_idle_workers", func(t *testing.T) {
+		pool := NewWorkerPool(1)
+		t.Cleanup(pool.Close)
+
+		ran := make(chan struct{})
+		require.Eventually(t, func() bool {
+			return pool.TryGo(func() { close(ran) })
+		}, time.Second, time.Millisecond)
+		<-ran
+	})
+
+	t.Run("runs_inline_when_saturated", func(t *testing.T) {
+		pool := NewWorkerPool(1)
+		t.Cleanup(pool.Close)
+		ctx := ContextWithWorkerPool(context.Background(), pool)
+
+		release := make(chan struct{})
+		require.Eventually(t, func() bool {
+			return pool.TryGo(func() { <-release })
+		}, time.Second, time.Millisecond)
+
+		// the only worker is busy, so nested tasks must not wait for it
+		inline := false
+		Go(ctx, func() {
+			Go(ctx, func() { inline = true })
+		})
+		require.True(t, inline)
+		close(release)
+	})
+
+	t.Run("without_pool", func(t *testing.T) {
+		done := make(chan struct{})
+		Go(context.Background(), func() { close(done) })
+		<-done
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		pool := NewWorkerPool(4)
+		pool.Close()
+		pool.Close()
+
+		require.False(t, pool.TryGo(func() {}))
+	})
+}
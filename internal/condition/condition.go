@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/google/cel-go/cel"
 	"github.com/google/cel-go/common"
+	celast "github.com/google/cel-go/common/ast"
 	celtypes "github.com/google/cel-go/common/types"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"go.opentelemetry.io/otel"
@@ -56,6 +58,7 @@ type EvaluableCondition struct {
 
 	celProgramOpts []cel.ProgramOption
 	celEnv         *cel.Env
+	celAst         *cel.Ast
 	celProgram     cel.Program
 	compileOnce    sync.Once
 }
@@ -141,10 +144,79 @@ func (e *EvaluableCondition) compile() error {
 	}
 
 	e.celEnv = env
+	e.celAst = ast
 	e.celProgram = prg
 	return nil
 }
 
+// UnusedParameters compiles the condition and returns the sorted names of the
+// declared parameters that the checked expression never references. Parameters
+// referenced in the expression but not declared fail the compilation.
+func (e *EvaluableCondition) UnusedParameters() ([]string, error) {
+	if err := e.Compile(); err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]struct{}, len(e.GetParameters()))
+	collectReferencedIdents(e.celAst.NativeRep().Expr(), map[string]int{}, referenced)
+
+	var unused []string
+	for paramName := range e.GetParameters() {
+		if _, ok := referenced[paramName]; !ok {
+			unused = append(unused, paramName)
+		}
+	}
+	sort.Strings(unused)
+
+	return unused, nil
+}
+
+// collectReferencedIdents adds to referenced the identifiers of expr that are not
+// shadowed by the iteration or accumulator variable of an enclosing comprehension.
+func collectReferencedIdents(expr celast.Expr, shadowed map[string]int, referenced map[string]struct{}) {
+	switch expr.Kind() {
+	case celast.IdentKind:
+		if shadowed[expr.AsIdent()] == 0 {
+			referenced[expr.AsIdent()] = struct{}{}
+		}
+	case celast.SelectKind:
+		collectReferencedIdents(expr.AsSelect().Operand(), shadowed, referenced)
+	case celast.CallKind:
+		call := expr.AsCall()
+		if call.IsMemberFunction() {
+			collectReferencedIdents(call.Target(), shadowed, referenced)
+		}
+		for _, arg := range call.Args() {
+			collectReferencedIdents(arg, shadowed, referenced)
+		}
+	case celast.ListKind:
+		for _, elem := range expr.AsList().Elements() {
+			collectReferencedIdents(elem, shadowed, referenced)
+		}
+	case celast.MapKind:
+		for _, entry := range expr.AsMap().Entries() {
+			collectReferencedIdents(entry.AsMapEntry().Key(), shadowed, referenced)
+			collectReferencedIdents(entry.AsMapEntry().Value(), shadowed, referenced)
+		}
+	case celast.StructKind:
+		for _, field := range expr.AsStruct().Fields() {
+			collectReferencedIdents(field.AsStructField().Value(), shadowed, referenced)
+		}
+	case celast.ComprehensionKind:
+		comp := expr.AsComprehension()
+		collectReferencedIdents(comp.IterRange(), shadowed, referenced)
+		collectReferencedIdents(comp.AccuInit(), shadowed, referenced)
+
+		shadowed[comp.IterVar()]++
+		shadowed[comp.AccuVar()]++
+		collectReferencedIdents(comp.LoopCondition(), shadowed, referenced)
+		collectReferencedIdents(comp.LoopStep(), shadowed, referenced)
+		collectReferencedIdents(comp.Result(), shadowed, referenced)
+		shadowed[comp.IterVar()]--
+		shadowed[comp.AccuVar()]--
+	}
+}
+
 // CastContextToTypedParameters converts the provided context to typed condition
 // parameters and returns an error if any additional context fields are provided
 // that are not defined by the evaluable condition.
@@ -182,7 +254,12 @@ func (e *EvaluableCondition) CastContextToTypedParameters(contextMap map[string]
 		if err != nil {
 			return nil, &ParameterTypeError{
 				Condition: e.Name,
-				Cause:     fmt.Errorf("failed to convert context parameter '%s': %w", parameterKey, err),
+				Cause: &ParameterValueError{
+					Parameter:    parameterKey,
+					ExpectedType: varType.String(),
+					ActualType:   contextValueTypeName(contextValue),
+					Cause:        err,
+				},
 			}
 		}
 
@@ -192,6 +269,26 @@ func (e *EvaluableCondition) CastContextToTypedParameters(contextMap map[string]
 	return converted, nil
 }
 
+// contextValueTypeName returns a human-readable name of the JSON type of the provided context value.
+func contextValueTypeName(value *structpb.Value) string {
+	switch value.GetKind().(type) {
+	case *structpb.Value_NullValue:
+		return "null"
+	case *structpb.Value_NumberValue:
+		return "number"
+	case *structpb.Value_StringValue:
+		return "string"
+	case *structpb.Value_BoolValue:
+		return "bool"
+	case *structpb.Value_StructValue:
+		return "map"
+	case *structpb.Value_ListValue:
+		return "list"
+	default:
+		return "unknown"
+	}
+}
+
 // Evaluate evaluates the provided CEL condition expression with a CEL environment
 // constructed from the condition's parameter type definitions and using the context maps provided.
 // If more than one source map of context is provided, and if the keys provided in those map
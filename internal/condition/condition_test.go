@@ -9,6 +9,7 @@ import (
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/openfga/openfga/internal/condition"
@@ -109,6 +110,92 @@ func TestNewCompiled(t *testing.T) {
 	}
 }
 
+func TestUnusedParameters(t *testing.T) {
+	stringParam := &openfgav1.ConditionParamTypeRef{
+		TypeName: openfgav1.ConditionParamTypeRef_TYPE_NAME_STRING,
+	}
+	listParam := &openfgav1.ConditionParamTypeRef{
+		TypeName:     openfgav1.ConditionParamTypeRef_TYPE_NAME_LIST,
+		GenericTypes: []*openfgav1.ConditionParamTypeRef{stringParam},
+	}
+
+	var tests = []struct {
+		name           string
+		expression     string
+		parameters     map[string]*openfgav1.ConditionParamTypeRef
+		expectedUnused []string
+		expectedErr    string
+	}{
+		{
+			name:       "all_parameters_used",
+			expression: "param1 == param2",
+			parameters: map[string]*openfgav1.ConditionParamTypeRef{
+				"param1": stringParam,
+				"param2": stringParam,
+			},
+		},
+		{
+			name:       "unused_parameters",
+			expression: "param2 == 'ok'",
+			parameters: map[string]*openfgav1.ConditionParamTypeRef{
+				"param1": stringParam,
+				"param2": stringParam,
+				"param3": stringParam,
+			},
+			expectedUnused: []string{"param1", "param3"},
+		},
+		{
+			name:       "parameter_used_in_comprehension",
+			expression: "allowed.exists(x, x == param1)",
+			parameters: map[string]*openfgav1.ConditionParamTypeRef{
+				"param1":  stringParam,
+				"allowed": listParam,
+			},
+		},
+		{
+			name:       "comprehension_variable_shadows_parameter",
+			expression: "allowed.exists(param1, param1 == 'ok')",
+			parameters: map[string]*openfgav1.ConditionParamTypeRef{
+				"param1":  stringParam,
+				"allowed": listParam,
+			},
+			expectedUnused: []string{"param1"},
+		},
+		{
+			name:           "no_parameters",
+			expression:     "true",
+			expectedUnused: nil,
+		},
+		{
+			name:       "undeclared_parameter",
+			expression: "param1 == param2",
+			parameters: map[string]*openfgav1.ConditionParamTypeRef{
+				"param1": stringParam,
+			},
+			expectedErr: "failed to compile expression on condition 'condition1' - ERROR: condition1:1:11: undeclared reference to 'param2' (in container '')\n | param1 == param2\n | ..........^",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := condition.NewUncompiled(&openfgav1.Condition{
+				Name:       "condition1",
+				Expression: test.expression,
+				Parameters: test.parameters,
+			})
+
+			unused, err := c.UnusedParameters()
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expectedUnused, unused)
+		})
+	}
+}
+
 func TestEvaluate(t *testing.T) {
 	var tests = []struct {
 		name      string
@@ -548,6 +635,54 @@ func TestCastContextToTypedParameters(t *testing.T) {
 	}
 }
 
+func FuzzCastContextToTypedParameters(f *testing.F) {
+	f.Add([]byte(`{"s": "ok", "i": 1, "u": 1, "d": 1.5, "b": true}`))
+	f.Add([]byte(`{"dur": "1h", "ts": "2023-01-01T00:00:00Z", "ip": "192.168.0.1"}`))
+	f.Add([]byte(`{"list": ["a", "b"], "map": {"a": 1}}`))
+	f.Add([]byte(`{"i": "1", "u": -1, "d": "x", "b": null}`))
+	f.Add([]byte(`{"i": 1e300, "u": 1.5, "dur": "1x", "ts": "yesterday", "ip": "::g"}`))
+	f.Add([]byte(`{"list": [1, null], "map": {"a": "b"}, "unknown": {}}`))
+
+	c := condition.NewUncompiled(&openfgav1.Condition{
+		Name:       "condition1",
+		Expression: "s == 'ok'",
+		Parameters: map[string]*openfgav1.ConditionParamTypeRef{
+			"s":    {TypeName: openfgav1.ConditionParamTypeRef_TYPE_NAME_STRING},
+			"i":    {TypeName: openfgav1.ConditionParamTypeRef_TYPE_NAME_INT},
+			"u":    {TypeName: openfgav1.ConditionParamTypeRef_TYPE_NAME_UINT},
+			"d":    {TypeName: openfgav1.ConditionParamTypeRef_TYPE_NAME_DOUBLE},
+			"b":    {TypeName: openfgav1.ConditionParamTypeRef_TYPE_NAME_BOOL},
+			"dur":  {TypeName: openfgav1.ConditionParamTypeRef_TYPE_NAME_DURATION},
+			"ts":   {TypeName: openfgav1.ConditionParamTypeRef_TYPE_NAME_TIMESTAMP},
+			"ip":   {TypeName: openfgav1.ConditionParamTypeRef_TYPE_NAME_IPADDRESS},
+			"any":  {TypeName: openfgav1.ConditionParamTypeRef_TYPE_NAME_ANY},
+			"list": {TypeName: openfgav1.ConditionParamTypeRef_TYPE_NAME_LIST, GenericTypes: []*openfgav1.ConditionParamTypeRef{{TypeName: openfgav1.ConditionParamTypeRef_TYPE_NAME_STRING}}},
+			"map":  {TypeName: openfgav1.ConditionParamTypeRef_TYPE_NAME_MAP, GenericTypes: []*openfgav1.ConditionParamTypeRef{{TypeName: openfgav1.ConditionParamTypeRef_TYPE_NAME_INT}}},
+		},
+	})
+
+	f.Fuzz(func(t *testing.T, contextJSON []byte) {
+		contextStruct := &structpb.Struct{}
+		if err := protojson.Unmarshal(contextJSON, contextStruct); err != nil {
+			t.Skip()
+		}
+
+		typedParams, err := c.CastContextToTypedParameters(contextStruct.GetFields())
+		if err != nil {
+			var parameterTypeError *condition.ParameterTypeError
+			require.ErrorAs(t, err, &parameterTypeError)
+			require.Nil(t, typedParams)
+			return
+		}
+
+		// only the parameters of the condition are converted
+		for name := range typedParams {
+			require.Contains(t, contextStruct.GetFields(), name)
+			require.Contains(t, c.GetParameters(), name)
+		}
+	})
+}
+
 func TestEvaluateWithInterruptCheckFrequency(t *testing.T) {
 	makeItems := func(size int) []interface{} {
 		items := make([]interface{}, size)
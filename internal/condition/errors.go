@@ -45,6 +45,17 @@ func (e *EvaluationError) Unwrap() error {
 	return e.Cause
 }
 
+// MissingParametersError is the cause of the [EvaluationError] of a condition whose parameters
+// are missing from the context of the request.
+type MissingParametersError struct {
+	Tuple      string
+	Parameters []string
+}
+
+func (e *MissingParametersError) Error() string {
+	return fmt.Sprintf("tuple '%s' is missing context parameters '%v'", e.Tuple, e.Parameters)
+}
+
 type ParameterTypeError struct {
 	Condition string
 	Cause     error
@@ -57,3 +68,20 @@ func (e *ParameterTypeError) Error() string {
 func (e *ParameterTypeError) Unwrap() error {
 	return e.Cause
 }
+
+// ParameterValueError is returned if a context value provided for a condition
+// parameter cannot be converted to the parameter's declared type.
+type ParameterValueError struct {
+	Parameter    string
+	ExpectedType string
+	ActualType   string
+	Cause        error
+}
+
+func (e *ParameterValueError) Error() string {
+	return fmt.Sprintf("failed to convert context parameter '%s': %v", e.Parameter, e.Cause)
+}
+
+func (e *ParameterValueError) Unwrap() error {
+	return e.Cause
+}
@@ -144,9 +144,13 @@ func (pt ParameterType) String() string {
 		}
 
 		// e.g. map<int>
-		return fmt.Sprintf("%s<%s>", pt.name, strings.Join(genericTypeStrings, ", "))
+		return fmt.Sprintf("%s<%s>", pt.baseTypeString(), strings.Join(genericTypeStrings, ", "))
 	}
 
+	return pt.baseTypeString()
+}
+
+func (pt ParameterType) baseTypeString() string {
 	str, ok := paramTypeString[pt.name]
 	if !ok {
 		return "unknown"
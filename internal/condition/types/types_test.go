@@ -196,7 +196,7 @@ func TestPrimitives(t *testing.T) {
 			paramType: mustMapParamType(StringParamType),
 			input:     map[string]any{"hello": "world"},
 			output:    map[string]any{"hello": "world"},
-			repr:      "TYPE_NAME_MAP<string>",
+			repr:      "map<string>",
 		},
 		{
 			name:      "invalid_map",
@@ -205,7 +205,7 @@ func TestPrimitives(t *testing.T) {
 			expectedError: fmt.Errorf(
 				"map requires a map, found: map[int]interface {}",
 			),
-			repr: "TYPE_NAME_MAP<string>",
+			repr: "map<string>",
 		},
 		{
 			name:      "invalid_map_string",
@@ -214,14 +214,14 @@ func TestPrimitives(t *testing.T) {
 			expectedError: fmt.Errorf(
 				"found an invalid value for key 'hello': expected type value 'string', but found 'int'",
 			),
-			repr: "TYPE_NAME_MAP<string>",
+			repr: "map<string>",
 		},
 		{
 			name:      "valid_list_string",
 			paramType: mustListParamType(StringParamType),
 			input:     []any{"hello", "world"},
 			output:    []any{"hello", "world"},
-			repr:      "TYPE_NAME_LIST<string>",
+			repr:      "list<string>",
 		},
 		{
 			name:      "invalid_list",
@@ -230,7 +230,7 @@ func TestPrimitives(t *testing.T) {
 			expectedError: fmt.Errorf(
 				"list requires a list, found: string",
 			),
-			repr: "TYPE_NAME_LIST<string>",
+			repr: "list<string>",
 		},
 		{
 			name:      "invalid_list_string",
@@ -239,7 +239,7 @@ func TestPrimitives(t *testing.T) {
 			expectedError: fmt.Errorf(
 				"found an invalid list item at index `1`: expected type value 'string', but found 'int'",
 			),
-			repr: "TYPE_NAME_LIST<string>",
+			repr: "list<string>",
 		},
 	}
 
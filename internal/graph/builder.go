@@ -2,9 +2,12 @@ package graph
 
 type CheckResolverOrderedBuilder struct {
 	resolvers                              []CheckResolver
+	membershipIndexCheckResolverEnabled    bool
+	membershipIndexCheckResolverOptions    []MembershipIndexCheckResolverOpt
 	localCheckerOptions                    []LocalCheckerOption
 	cachedCheckResolverEnabled             bool
 	cachedCheckResolverOptions             []CachedCheckResolverOpt
+	singleflightCheckResolverEnabled       bool
 	dispatchThrottlingCheckResolverEnabled bool
 	dispatchThrottlingCheckResolverOptions []DispatchThrottlingCheckResolverOpt
 }
@@ -18,6 +21,14 @@ func WithLocalCheckerOpts(opts ...LocalCheckerOption) CheckResolverOrderedBuilde
 	}
 }
 
+// WithMembershipIndexCheckResolverOpts sets the opts to be used to build MembershipIndexCheckResolver.
+func WithMembershipIndexCheckResolverOpts(enabled bool, opts ...MembershipIndexCheckResolverOpt) CheckResolverOrderedBuilderOpt {
+	return func(r *CheckResolverOrderedBuilder) {
+		r.membershipIndexCheckResolverEnabled = enabled
+		r.membershipIndexCheckResolverOptions = opts
+	}
+}
+
 // WithCachedCheckResolverOpts sets the opts to be used to build CachedCheckResolver.
 func WithCachedCheckResolverOpts(enabled bool, opts ...CachedCheckResolverOpt) CheckResolverOrderedBuilderOpt {
 	return func(r *CheckResolverOrderedBuilder) {
@@ -26,6 +37,14 @@ func WithCachedCheckResolverOpts(enabled bool, opts ...CachedCheckResolverOpt) C
 	}
 }
 
+// WithSingleflightCheckResolverEnabled sets whether the identical concurrent checks are
+// deduplicated by a SingleflightCheckResolver.
+func WithSingleflightCheckResolverEnabled(enabled bool) CheckResolverOrderedBuilderOpt {
+	return func(r *CheckResolverOrderedBuilder) {
+		r.singleflightCheckResolverEnabled = enabled
+	}
+}
+
 // WithDispatchThrottlingCheckResolverOpts sets the opts to be used to build DispatchThrottlingCheckResolver.
 func WithDispatchThrottlingCheckResolverOpts(enabled bool, opts ...DispatchThrottlingCheckResolverOpt) CheckResolverOrderedBuilderOpt {
 	return func(r *CheckResolverOrderedBuilder) {
@@ -52,10 +71,19 @@ func NewOrderedCheckResolvers(opts ...CheckResolverOrderedBuilderOpt) *CheckReso
 func (c *CheckResolverOrderedBuilder) Build() (CheckResolver, CheckResolverCloser) {
 	c.resolvers = []CheckResolver{}
 
+	if c.membershipIndexCheckResolverEnabled {
+		c.resolvers = append(c.resolvers, NewMembershipIndexCheckResolver(c.membershipIndexCheckResolverOptions...))
+	}
+
 	if c.cachedCheckResolverEnabled {
 		c.resolvers = append(c.resolvers, NewCachedCheckResolver(c.cachedCheckResolverOptions...))
 	}
 
+	// after the cache, so that the checks answered from the cache are not deduplicated
+	if c.singleflightCheckResolverEnabled {
+		c.resolvers = append(c.resolvers, NewSingleflightCheckResolver())
+	}
+
 	if c.dispatchThrottlingCheckResolverEnabled {
 		c.resolvers = append(c.resolvers, NewDispatchThrottlingCheckResolver(c.dispatchThrottlingCheckResolverOptions...))
 	}
@@ -10,7 +10,9 @@ import (
 func TestNewOrderedCheckResolverBuilder(t *testing.T) {
 	type Test struct {
 		name                                   string
+		MembershipIndexCheckResolverEnabled    bool
 		CachedCheckResolverEnabled             bool
+		SingleflightCheckResolverEnabled       bool
 		DispatchThrottlingCheckResolverEnabled bool
 		expectedResolverOrder                  []CheckResolver
 	}
@@ -36,17 +38,34 @@ func TestNewOrderedCheckResolverBuilder(t *testing.T) {
 			DispatchThrottlingCheckResolverEnabled: true,
 			expectedResolverOrder:                  []CheckResolver{&CachedCheckResolver{}, &DispatchThrottlingCheckResolver{}, &LocalChecker{}},
 		},
+		{
+			name:                                   "when_singleflight_is_enabled",
+			CachedCheckResolverEnabled:             true,
+			SingleflightCheckResolverEnabled:       true,
+			DispatchThrottlingCheckResolverEnabled: true,
+			expectedResolverOrder:                  []CheckResolver{&CachedCheckResolver{}, &SingleflightCheckResolver{}, &DispatchThrottlingCheckResolver{}, &LocalChecker{}},
+		},
+		{
+			name:                                   "when_membership_index_is_enabled",
+			MembershipIndexCheckResolverEnabled:    true,
+			CachedCheckResolverEnabled:             true,
+			DispatchThrottlingCheckResolverEnabled: true,
+			expectedResolverOrder:                  []CheckResolver{&MembershipIndexCheckResolver{}, &CachedCheckResolver{}, &DispatchThrottlingCheckResolver{}, &LocalChecker{}},
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			builder := NewOrderedCheckResolvers([]CheckResolverOrderedBuilderOpt{
+				WithMembershipIndexCheckResolverOpts(test.MembershipIndexCheckResolverEnabled),
 				WithCachedCheckResolverOpts(test.CachedCheckResolverEnabled),
+				WithSingleflightCheckResolverEnabled(test.SingleflightCheckResolverEnabled),
 				WithDispatchThrottlingCheckResolverOpts(test.DispatchThrottlingCheckResolverEnabled),
 			}...)
 			_, checkResolverCloser := builder.Build()
 			t.Cleanup(checkResolverCloser)
 
+			require.Len(t, builder.resolvers, len(test.expectedResolverOrder))
 			for i, resolver := range builder.resolvers {
 				require.Equal(t, reflect.TypeOf(test.expectedResolverOrder[i]), reflect.TypeOf(resolver))
 			}
@@ -0,0 +1,238 @@
+package graph
+
+import (
+	"context"
+	"sync"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/internal/concurrency"
+	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// BulkCheckRequest is the check of one relation of one user on many objects, which share the
+// store, the model, the contextual tuples and the context of the request.
+type BulkCheckRequest struct {
+	StoreID              string
+	AuthorizationModelID string
+	Relation             string
+	User                 string
+	ContextualTuples     []*openfgav1.TupleKey
+	Context              *structpb.Struct
+	Consistency          openfgav1.ConsistencyPreference
+}
+
+// BulkChecker resolves the checks of a BulkCheckRequest on many objects, e.g. the candidate
+// objects of ListObjects. Unlike separate calls to ResolveCheck, the checks share one
+// ResolutionMetadata and one memo of the outcomes of the subproblems they dispatch, so that a
+// subproblem reached from many objects, e.g. the parent folder of many documents, is resolved
+// once per request. The typesystem and the tuple reader, with its index of the contextual
+// tuples, are read from the context as for ResolveCheck, and are shared as well.
+//
+// The memo is also shared by the successive or concurrent Execute calls of a BulkChecker, e.g.
+// for the checks of several relations of the objects of one ListObjects request, so a BulkChecker
+// must only be used for requests that share their user, contextual tuples and context.
+type BulkChecker struct {
+	resolver         CheckResolver
+	resolveNodeLimit uint32
+	breadthLimit     uint32
+	memo             *subproblemMemo
+}
+
+type BulkCheckerOption func(*BulkChecker)
+
+// WithBulkCheckResolveNodeLimit sets the maximum depth of the resolution of each check.
+func WithBulkCheckResolveNodeLimit(limit uint32) BulkCheckerOption {
+	return func(b *BulkChecker) {
+		b.resolveNodeLimit = limit
+	}
+}
+
+// WithBulkCheckBreadthLimit sets the maximum number of checks resolved concurrently.
+func WithBulkCheckBreadthLimit(limit uint32) BulkCheckerOption {
+	return func(b *BulkChecker) {
+		b.breadthLimit = limit
+	}
+}
+
+// NewBulkChecker creates a BulkChecker that resolves each check with the given resolver.
+func NewBulkChecker(resolver CheckResolver, opts ...BulkCheckerOption) *BulkChecker {
+	b := &BulkChecker{
+		resolver:         resolver,
+		resolveNodeLimit: serverconfig.DefaultResolveNodeLimit,
+		breadthLimit:     serverconfig.DefaultResolveNodeBreadthLimit,
+		memo:             &subproblemMemo{},
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Execute checks the relation of the user on each object received from objects, at most
+// breadth limit at a time, until objects is closed. allowed is called, never concurrently, with
+// each object on which the relation is allowed; Execute stops early, cancelling the checks in
+// flight, as soon as allowed returns false, e.g. once the maximum number of results of ListObjects
+// is reached. It also stops if the context is done, or at the first check that fails, whose
+// error it returns. Objects are not read anymore once Execute has returned.
+//
+// The returned ResolutionMetadata counts the work done by all the checks.
+func (b *BulkChecker) Execute(
+	ctx context.Context,
+	req *BulkCheckRequest,
+	objects <-chan string,
+	allowed func(object string) bool,
+) (*ResolutionMetadata, error) {
+	return b.ExecuteWithOutcomes(ctx, req, objects, func(object string, isAllowed bool) bool {
+		return !isAllowed || allowed(object)
+	})
+}
+
+// ExecuteWithOutcomes is Execute with the outcome of every check: outcome is called, never
+// concurrently, with each object and whether the relation is allowed on it, and Execute stops
+// early as soon as it returns false.
+func (b *BulkChecker) ExecuteWithOutcomes(
+	ctx context.Context,
+	req *BulkCheckRequest,
+	objects <-chan string,
+	outcome func(object string, allowed bool) bool,
+) (*ResolutionMetadata, error) {
+	ctx, span := tracer.Start(ctx, "BulkCheck", trace.WithAttributes(
+		attribute.String("relation", req.Relation),
+		attribute.String("user", req.User),
+	))
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	resolutionMetadata := NewResolutionMetadata()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		stopped bool
+		err     error
+		checks  int
+	)
+	// stop records the first error, and cancels the checks in flight
+	stop := func(checkErr error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !stopped {
+			stopped = true
+			err = checkErr
+		}
+		cancel()
+	}
+
+	limiter := make(chan struct{}, max(b.breadthLimit, 1))
+
+ReadLoop:
+	for {
+		var object string
+		select {
+		case <-ctx.Done():
+			break ReadLoop
+		case o, ok := <-objects:
+			if !ok {
+				break ReadLoop
+			}
+			object = o
+		}
+
+		select {
+		case <-ctx.Done():
+			break ReadLoop
+		case limiter <- struct{}{}:
+		}
+
+		checks++
+		wg.Add(1)
+		concurrency.Go(ctx, func() {
+			defer func() {
+				<-limiter
+				wg.Done()
+			}()
+
+			resp, checkErr := b.resolver.ResolveCheck(ctx, &ResolveCheckRequest{
+				StoreID:              req.StoreID,
+				AuthorizationModelID: req.AuthorizationModelID,
+				TupleKey:             tuple.NewTupleKey(object, req.Relation, req.User),
+				ContextualTuples:     req.ContextualTuples,
+				Context:              req.Context,
+				RequestMetadata: &ResolveCheckRequestMetadata{
+					Depth:              b.resolveNodeLimit,
+					maxDepth:           b.resolveNodeLimit,
+					ResolutionMetadata: resolutionMetadata,
+					subproblems:        b.memo,
+				},
+				Consistency: req.Consistency,
+			})
+			if checkErr != nil {
+				if ctx.Err() == nil {
+					stop(checkErr)
+				}
+				return
+			}
+			resolutionMetadata.DatastoreQueryCount.Add(resp.GetResolutionMetadata().DatastoreQueryCount)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if stopped {
+				return
+			}
+			if !outcome(object, resp.GetAllowed()) {
+				stopped = true
+				cancel()
+			}
+		})
+	}
+
+	wg.Wait()
+
+	span.SetAttributes(attribute.Int("checks", checks))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err == nil && !stopped && ctx.Err() != nil {
+		// the parent context is done, not the bulk check
+		return resolutionMetadata, ctx.Err()
+	}
+	return resolutionMetadata, err
+}
+
+// subproblemMemo memoizes the outcomes of the subproblems dispatched by the checks of one
+// BulkChecker request, keyed by their tuple key alone since they share the rest of the request.
+// Outcomes that detected a cycle are not memoized, as they depend on the path that led to them.
+// A nil memo memoizes nothing.
+type subproblemMemo struct {
+	outcomes sync.Map
+}
+
+func (m *subproblemMemo) get(tk *openfgav1.TupleKey) (*ResolveCheckResponse, bool) {
+	if m == nil {
+		return nil, false
+	}
+	resp, ok := m.outcomes.Load(tuple.CanonicalTupleKey(tk))
+	if !ok {
+		return nil, false
+	}
+	// return a copy to avoid races across goroutines
+	return resp.(*ResolveCheckResponse).clone(), true
+}
+
+func (m *subproblemMemo) set(tk *openfgav1.TupleKey, resp *ResolveCheckResponse) {
+	if m == nil || resp.GetCycleDetected() {
+		return
+	}
+	// as for the check cache, the datastore queries of the outcome are only counted once
+	memoized := resp.clone()
+	memoized.ResolutionMetadata.DatastoreQueryCount = 0
+	m.outcomes.Store(tuple.CanonicalTupleKey(tk), memoized)
+}
@@ -0,0 +1,238 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	storagetest "github.com/openfga/openfga/pkg/storage/test"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// bulkCheckTestModel has documents whose viewers are inherited from a few parent folders, so that
+// the checks of many documents share the subproblems of their folders.
+const bulkCheckTestModel = `
+	model
+		schema 1.1
+	type user
+	type group
+		relations
+			define member: [user]
+	type folder
+		relations
+			define viewer: [group#member]
+	type doc
+		relations
+			define parent: [folder]
+			define viewer: [user] or viewer from parent`
+
+// bootstrapBulkCheckStore writes n documents, spread over 10 folders that are viewed by the
+// members of a group, and returns a context with the typesystem and datastore of the store.
+func bootstrapBulkCheckStore(tb testing.TB, n int) (context.Context, string, *openfgav1.AuthorizationModel) {
+	ds := memory.New()
+	tb.Cleanup(ds.Close)
+
+	tuples := []string{"group:eng#member@user:anne"}
+	for i := 0; i < 10; i++ {
+		tuples = append(tuples, fmt.Sprintf("folder:%d#viewer@group:eng#member", i))
+	}
+	for i := 0; i < n; i++ {
+		tuples = append(tuples, fmt.Sprintf("doc:%d#parent@folder:%d", i, i%10))
+	}
+	storeID, model := storagetest.BootstrapFGAStore(tb, ds, bulkCheckTestModel, tuples)
+
+	ts, err := typesystem.New(model)
+	require.NoError(tb, err)
+	ctx := typesystem.ContextWithTypesystem(
+		storage.ContextWithRelationshipTupleReader(context.Background(), ds),
+		ts,
+	)
+	return ctx, storeID, model
+}
+
+func sendObjects(objects []string) <-chan string {
+	ch := make(chan string, len(objects))
+	for _, object := range objects {
+		ch <- object
+	}
+	close(ch)
+	return ch
+}
+
+func TestBulkChecker(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx, storeID, model := bootstrapBulkCheckStore(t, 100)
+
+	objects := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		objects = append(objects, fmt.Sprintf("doc:%d", i))
+	}
+	req := func(user string) *BulkCheckRequest {
+		return &BulkCheckRequest{
+			StoreID:              storeID,
+			AuthorizationModelID: model.GetId(),
+			Relation:             "viewer",
+			User:                 user,
+		}
+	}
+
+	checker := NewLocalChecker()
+	t.Cleanup(checker.Close)
+
+	t.Run("allows_the_same_objects_as_separate_checks", func(t *testing.T) {
+		var allowed []string
+		metadata, err := NewBulkChecker(checker).Execute(ctx, req("user:anne"), sendObjects(objects), func(object string) bool {
+			allowed = append(allowed, object)
+			return true
+		})
+		require.NoError(t, err)
+
+		sort.Strings(allowed)
+		expected := append([]string(nil), objects...)
+		sort.Strings(expected)
+		require.Equal(t, expected, allowed)
+
+		// the subproblems of the 10 folders are shared by the 100 documents
+		require.Positive(t, metadata.CacheHitCount.Load())
+		require.Positive(t, metadata.DatastoreQueryCount.Load())
+	})
+
+	t.Run("denies_other_users", func(t *testing.T) {
+		var allowed []string
+		_, err := NewBulkChecker(checker).Execute(ctx, req("user:bob"), sendObjects(objects), func(object string) bool {
+			allowed = append(allowed, object)
+			return true
+		})
+		require.NoError(t, err)
+		require.Empty(t, allowed)
+	})
+
+	t.Run("stops_when_allowed_returns_false", func(t *testing.T) {
+		var allowed []string
+		_, err := NewBulkChecker(checker, WithBulkCheckBreadthLimit(2)).Execute(ctx, req("user:anne"), sendObjects(objects), func(object string) bool {
+			allowed = append(allowed, object)
+			return len(allowed) < 5
+		})
+		require.NoError(t, err)
+		require.Len(t, allowed, 5)
+	})
+
+	t.Run("returns_the_first_error", func(t *testing.T) {
+		_, err := NewBulkChecker(checker, WithBulkCheckResolveNodeLimit(1)).Execute(ctx, req("user:anne"), sendObjects(objects), func(string) bool {
+			return true
+		})
+		require.ErrorIs(t, err, ErrResolutionDepthExceeded)
+	})
+
+	t.Run("returns_the_error_of_the_context", func(t *testing.T) {
+		cancelCtx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := NewBulkChecker(checker).Execute(cancelCtx, req("user:anne"), make(chan string), func(string) bool {
+			return true
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestSubproblemMemo(t *testing.T) {
+	tk := tuple.NewTupleKey("folder:1", "viewer", "user:anne")
+
+	t.Run("nil_memo_memoizes_nothing", func(t *testing.T) {
+		var memo *subproblemMemo
+		memo.set(tk, &ResolveCheckResponse{Allowed: true, ResolutionMetadata: &ResolveCheckResponseMetadata{}})
+		_, ok := memo.get(tk)
+		require.False(t, ok)
+	})
+
+	t.Run("outcomes_with_cycles_are_not_memoized", func(t *testing.T) {
+		memo := &subproblemMemo{}
+		memo.set(tk, &ResolveCheckResponse{ResolutionMetadata: &ResolveCheckResponseMetadata{CycleDetected: true}})
+		_, ok := memo.get(tk)
+		require.False(t, ok)
+	})
+
+	t.Run("datastore_queries_are_counted_once", func(t *testing.T) {
+		memo := &subproblemMemo{}
+		memo.set(tk, &ResolveCheckResponse{Allowed: true, ResolutionMetadata: &ResolveCheckResponseMetadata{DatastoreQueryCount: 3}})
+		resp, ok := memo.get(tk)
+		require.True(t, ok)
+		require.True(t, resp.GetAllowed())
+		require.Zero(t, resp.GetResolutionMetadata().DatastoreQueryCount)
+	})
+}
+
+// BenchmarkBulkChecker compares the checks of 1k and 10k candidate objects with a BulkChecker and
+// with a ResolveCheck per object, as ListObjects did before.
+func BenchmarkBulkChecker(b *testing.B) {
+	for _, n := range []int{1_000, 10_000} {
+		ctx, storeID, model := bootstrapBulkCheckStore(b, n)
+
+		objects := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			objects = append(objects, fmt.Sprintf("doc:%d", i))
+		}
+
+		checker := NewLocalChecker()
+		b.Cleanup(checker.Close)
+
+		b.Run(fmt.Sprintf("bulk_%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				allowed := 0
+				_, err := NewBulkChecker(checker).Execute(ctx, &BulkCheckRequest{
+					StoreID:              storeID,
+					AuthorizationModelID: model.GetId(),
+					Relation:             "viewer",
+					User:                 "user:anne",
+				}, sendObjects(objects), func(string) bool {
+					allowed++
+					return true
+				})
+				if err != nil || allowed != n {
+					b.Fatal("unexpected bulk check result", err, allowed)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("resolve_check_per_object_%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var wg sync.WaitGroup
+				limiter := make(chan struct{}, 100)
+				for _, object := range objects {
+					wg.Add(1)
+					limiter <- struct{}{}
+					go func() {
+						defer func() {
+							<-limiter
+							wg.Done()
+						}()
+						resp, err := checker.ResolveCheck(ctx, &ResolveCheckRequest{
+							StoreID:              storeID,
+							AuthorizationModelID: model.GetId(),
+							TupleKey:             tuple.NewTupleKey(object, "viewer", "user:anne"),
+							RequestMetadata:      NewCheckRequestMetadata(defaultResolveNodeLimit),
+						})
+						if err != nil || !resp.GetAllowed() {
+							b.Error("unexpected check result", err)
+						}
+					}()
+				}
+				wg.Wait()
+			}
+		})
+	}
+}
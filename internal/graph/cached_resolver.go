@@ -2,7 +2,6 @@ package graph
 
 import (
 	"context"
-	"fmt"
 	"strconv"
 	"time"
 
@@ -163,9 +162,19 @@ func (c *CachedCheckResolver) ResolveCheck(
 		span.SetAttributes(attribute.Bool("is_cached", isCached))
 		if isCached {
 			checkCacheHitCounter.Inc()
+			if reqMetadata := req.GetRequestMetadata(); reqMetadata != nil {
+				reqMetadata.ResolutionMetadata.CacheHitCount.Add(1)
+			}
 
 			// return a copy to avoid races across goroutines
-			return cachedResp.Value.(*ResolveCheckResponse).clone(), nil
+			cached := cachedResp.Value.(*ResolveCheckResponse)
+			resp := cached.clone()
+			resp.ResolutionMetadata.CacheHit = true
+			if metadata := cached.GetResolutionMetadata(); metadata != nil && !metadata.cachedAt.IsZero() {
+				resp.ResolutionMetadata.CacheEntryAge = time.Since(metadata.cachedAt)
+			}
+			span.SetAttributes(attribute.Int64("cache_entry_age_ms", resp.ResolutionMetadata.CacheEntryAge.Milliseconds()))
+			return resp, nil
 		}
 	}
 
@@ -181,8 +190,15 @@ func (c *CachedCheckResolver) ResolveCheck(
 	// to 0 so it doesn't bias the resolution metadata negatively
 	clonedResp := resp.clone()
 	clonedResp.ResolutionMetadata.DatastoreQueryCount = 0
+	clonedResp.ResolutionMetadata.cachedAt = time.Now()
 
 	c.cache.Set(cacheKey, clonedResp, c.cacheTTL)
+
+	// the delegate may return the cached response of a subproblem as is, e.g. for a computed
+	// relation, but this response was not served from the cache
+	if metadata := resp.GetResolutionMetadata(); metadata != nil && metadata.CacheHit {
+		resp = resp.clone()
+	}
 	return resp, nil
 }
 
@@ -191,21 +207,23 @@ func (c *CachedCheckResolver) ResolveCheck(
 //
 // For one store and model ID, the same tuple provided with the same contextual tuples and context
 // should produce the same cache key. Contextual tuple order and context parameter order is ignored,
-// only the contents are compared.
+// only the contents are compared. Every user-controlled string is length-prefixed or JSON-encoded,
+// so requests that differ in their contextual tuples (including their conditions), in their
+// context or in their point in time never produce the same key.
 func CheckRequestCacheKey(req *ResolveCheckRequest) (string, error) {
 	hasher := keys.NewCacheKeyHasher(xxhash.New())
 
 	tupleKey := req.GetTupleKey()
-	key := fmt.Sprintf("%s/%s/%s#%s@%s",
+	for _, field := range []string{
 		req.GetStoreID(),
 		req.GetAuthorizationModelID(),
 		tupleKey.GetObject(),
 		tupleKey.GetRelation(),
 		tupleKey.GetUser(),
-	)
-
-	if err := hasher.WriteString(key); err != nil {
-		return "", err
+	} {
+		if err := hasher.WriteField(field); err != nil {
+			return "", err
+		}
 	}
 
 	// here, and for context below, avoid hashing if we don't need to
@@ -216,7 +234,20 @@ func CheckRequestCacheKey(req *ResolveCheckRequest) (string, error) {
 		}
 	}
 
+	if pointInTime := req.GetPointInTime(); !pointInTime.IsZero() {
+		if err := hasher.WriteString("/at"); err != nil {
+			return "", err
+		}
+		if err := hasher.WriteField(strconv.FormatInt(pointInTime.UnixNano(), 10)); err != nil {
+			return "", err
+		}
+	}
+
 	if req.GetContext() != nil {
+		if err := hasher.WriteString("/context"); err != nil {
+			return "", err
+		}
+
 		err := keys.NewContextHasher(req.GetContext()).Append(hasher)
 		if err != nil {
 			return "", err
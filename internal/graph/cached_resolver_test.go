@@ -622,6 +622,81 @@ func TestCachedCheckResolver_FieldsInResponse(t *testing.T) {
 	require.True(t, resp.GetResolutionMetadata().CycleDetected)
 }
 
+func TestCachedCheckResolverCountsCacheHits(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	cachedCheckResolver := NewCachedCheckResolver()
+	defer cachedCheckResolver.Close()
+
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	mockCheckResolver := NewMockCheckResolver(mockCtrl)
+	cachedCheckResolver.SetDelegate(mockCheckResolver)
+
+	mockCheckResolver.EXPECT().
+		ResolveCheck(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(&ResolveCheckResponse{Allowed: true}, nil)
+
+	req := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+		RequestMetadata:      NewCheckRequestMetadata(20),
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := cachedCheckResolver.ResolveCheck(context.Background(), req)
+		require.NoError(t, err)
+	}
+	require.Equal(t, uint32(2), req.GetRequestMetadata().ResolutionMetadata.CacheHitCount.Load())
+}
+
+func TestCachedCheckResolverReportsCacheHits(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	cachedCheckResolver := NewCachedCheckResolver()
+	defer cachedCheckResolver.Close()
+
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	mockCheckResolver := NewMockCheckResolver(mockCtrl)
+	cachedCheckResolver.SetDelegate(mockCheckResolver)
+
+	// the delegate returns the cached response of a subproblem as is
+	mockCheckResolver.EXPECT().
+		ResolveCheck(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(&ResolveCheckResponse{
+			Allowed:            true,
+			ResolutionMetadata: &ResolveCheckResponseMetadata{CacheHit: true, CacheEntryAge: time.Minute},
+		}, nil)
+
+	req := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+		RequestMetadata:      NewCheckRequestMetadata(20),
+	}
+
+	resp, err := cachedCheckResolver.ResolveCheck(context.Background(), req)
+	require.NoError(t, err)
+	require.False(t, resp.GetResolutionMetadata().CacheHit)
+	require.Zero(t, resp.GetResolutionMetadata().CacheEntryAge)
+
+	time.Sleep(10 * time.Millisecond)
+
+	resp, err = cachedCheckResolver.ResolveCheck(context.Background(), req)
+	require.NoError(t, err)
+	require.True(t, resp.GetResolutionMetadata().CacheHit)
+	require.GreaterOrEqual(t, resp.GetResolutionMetadata().CacheEntryAge, 10*time.Millisecond)
+	require.Less(t, resp.GetResolutionMetadata().CacheEntryAge, defaultCacheTTL)
+}
+
 func TestCachedCheckDatastoreQueryCount(t *testing.T) {
 	t.Parallel()
 
@@ -1003,3 +1078,163 @@ func BenchmarkCheckRequestCacheKeyWithContext(b *testing.B) {
 		require.NoError(b, err)
 	}
 }
+
+func TestCheckCacheKeyDistinguishesContextualTuplesAndContext(t *testing.T) {
+	storeID := ulid.Make().String()
+	modelID := ulid.Make().String()
+
+	tests := map[string]struct {
+		req1 *ResolveCheckRequest
+		req2 *ResolveCheckRequest
+	}{
+		`contextual_tuple_condition`: {
+			req1: &ResolveCheckRequest{
+				TupleKey: tuple.NewTupleKey("document:x", "viewer", "user:jon"),
+				ContextualTuples: []*openfgav1.TupleKey{
+					tuple.NewTupleKey("document:x", "viewer", "user:jon"),
+				},
+			},
+			req2: &ResolveCheckRequest{
+				TupleKey: tuple.NewTupleKey("document:x", "viewer", "user:jon"),
+				ContextualTuples: []*openfgav1.TupleKey{
+					tuple.NewTupleKeyWithCondition("document:x", "viewer", "user:jon", "condX", nil),
+				},
+			},
+		},
+		`contextual_tuple_condition_context`: {
+			req1: &ResolveCheckRequest{
+				TupleKey: tuple.NewTupleKey("document:x", "viewer", "user:jon"),
+				ContextualTuples: []*openfgav1.TupleKey{
+					tuple.NewTupleKeyWithCondition("document:x", "viewer", "user:jon", "condX",
+						testutils.MustNewStruct(t, map[string]interface{}{"x": 1})),
+				},
+			},
+			req2: &ResolveCheckRequest{
+				TupleKey: tuple.NewTupleKey("document:x", "viewer", "user:jon"),
+				ContextualTuples: []*openfgav1.TupleKey{
+					tuple.NewTupleKeyWithCondition("document:x", "viewer", "user:jon", "condX",
+						testutils.MustNewStruct(t, map[string]interface{}{"x": 2})),
+				},
+			},
+		},
+		`context_injected_into_user`: {
+			req1: &ResolveCheckRequest{
+				TupleKey: tuple.NewTupleKey("document:x", "viewer", "user:jon'key1:'true,"),
+			},
+			req2: &ResolveCheckRequest{
+				TupleKey: tuple.NewTupleKey("document:x", "viewer", "user:jon"),
+				Context:  testutils.MustNewStruct(t, map[string]interface{}{"key1": true}),
+			},
+		},
+		`contextual_tuple_injected_into_user`: {
+			req1: &ResolveCheckRequest{
+				TupleKey: tuple.NewTupleKey("document:x", "viewer", "user:jon/document:1#viewer@user:jon"),
+			},
+			req2: &ResolveCheckRequest{
+				TupleKey: tuple.NewTupleKey("document:x", "viewer", "user:jon"),
+				ContextualTuples: []*openfgav1.TupleKey{
+					tuple.NewTupleKey("document:1", "viewer", "user:jon"),
+				},
+			},
+		},
+		`context_value_types`: {
+			req1: &ResolveCheckRequest{
+				TupleKey: tuple.NewTupleKey("document:x", "viewer", "user:jon"),
+				Context:  testutils.MustNewStruct(t, map[string]interface{}{"x": 1}),
+			},
+			req2: &ResolveCheckRequest{
+				TupleKey: tuple.NewTupleKey("document:x", "viewer", "user:jon"),
+				Context:  testutils.MustNewStruct(t, map[string]interface{}{"x": "1"}),
+			},
+		},
+		`point_in_time`: {
+			req1: &ResolveCheckRequest{
+				TupleKey: tuple.NewTupleKey("document:x", "viewer", "user:jon"),
+			},
+			req2: &ResolveCheckRequest{
+				TupleKey:    tuple.NewTupleKey("document:x", "viewer", "user:jon"),
+				PointInTime: time.Unix(1700000000, 0),
+			},
+		},
+		`points_in_time`: {
+			req1: &ResolveCheckRequest{
+				TupleKey:    tuple.NewTupleKey("document:x", "viewer", "user:jon"),
+				PointInTime: time.Unix(1700000000, 0),
+			},
+			req2: &ResolveCheckRequest{
+				TupleKey:    tuple.NewTupleKey("document:x", "viewer", "user:jon"),
+				PointInTime: time.Unix(1700000000, 1),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, req := range []*ResolveCheckRequest{test.req1, test.req2} {
+				req.StoreID = storeID
+				req.AuthorizationModelID = modelID
+				req.RequestMetadata = NewCheckRequestMetadata(25)
+			}
+
+			key1, err := CheckRequestCacheKey(test.req1)
+			require.NoError(t, err)
+
+			key2, err := CheckRequestCacheKey(test.req2)
+			require.NoError(t, err)
+
+			require.NotEqual(t, key1, key2)
+		})
+	}
+}
+
+func FuzzCheckRequestCacheKey(f *testing.F) {
+	f.Add("user:jon", "document:1", "key1", "true", "user:jon", "document:1", "key1", "true")
+	f.Add("user:jon'key1:'true,", "", "", "", "user:jon", "", "key1", "true")
+	f.Add("user:jon/document:1#viewer@user:jon", "", "", "", "user:jon", "document:1", "", "")
+
+	f.Fuzz(func(t *testing.T,
+		user1, contextualObject1, contextKey1, contextValue1,
+		user2, contextualObject2, contextKey2, contextValue2 string,
+	) {
+		key := func(user, contextualObject, contextKey, contextValue string) string {
+			req := &ResolveCheckRequest{
+				StoreID:              "01JA9X9Z3J7Q2WJ7N0J0MSDW7S",
+				AuthorizationModelID: "01JA9X9Z3J7Q2WJ7N0J0MSDW7T",
+				TupleKey:             tuple.NewTupleKey("document:x", "viewer", user),
+				RequestMetadata:      NewCheckRequestMetadata(25),
+			}
+
+			if contextualObject != "" {
+				req.ContextualTuples = []*openfgav1.TupleKey{
+					tuple.NewTupleKey(contextualObject, "viewer", "user:jon"),
+				}
+			}
+
+			if contextKey != "" {
+				var err error
+				req.Context, err = structpb.NewStruct(map[string]interface{}{contextKey: contextValue})
+				if err != nil {
+					t.Skip("invalid UTF-8 in context")
+				}
+			}
+
+			k, err := CheckRequestCacheKey(req)
+			require.NoError(t, err)
+			return k
+		}
+
+		if contextKey1 == "" {
+			contextValue1 = ""
+		}
+		if contextKey2 == "" {
+			contextValue2 = ""
+		}
+
+		key1 := key(user1, contextualObject1, contextKey1, contextValue1)
+		key2 := key(user2, contextualObject2, contextKey2, contextValue2)
+
+		sameInput := user1 == user2 && contextualObject1 == contextualObject2 &&
+			contextKey1 == contextKey2 && contextValue1 == contextValue2
+		require.Equal(t, sameInput, key1 == key2)
+	})
+}
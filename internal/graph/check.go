@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"go.opentelemetry.io/otel"
@@ -44,6 +45,13 @@ type LocalChecker struct {
 	maxConcurrentReads uint32
 	usersetBatchSize   int
 	logger             logger.Logger
+	workerPool         *concurrency.WorkerPool
+
+	// breadthLimitShadow and depthLimitShadow are candidate limits that are measured but not
+	// enforced, see observeBreadth and observeDepth. 0 disables them.
+	breadthLimitShadow       uint32
+	depthLimitShadow         uint32
+	shadowLimitExceededCount atomic.Uint64
 }
 
 type LocalCheckerOption func(d *LocalChecker)
@@ -70,6 +78,13 @@ func WithMaxConcurrentReads(limit uint32) LocalCheckerOption {
 	}
 }
 
+// WithResolverWorkerPool see server.WithResolverWorkerPoolSize.
+func WithResolverWorkerPool(pool *concurrency.WorkerPool) LocalCheckerOption {
+	return func(d *LocalChecker) {
+		d.workerPool = pool
+	}
+}
+
 func WithLocalCheckerLogger(logger logger.Logger) LocalCheckerOption {
 	return func(d *LocalChecker) {
 		d.logger = logger
@@ -139,10 +154,10 @@ func resolver(ctx context.Context, concurrencyLimit uint32, resultChan chan<- ch
 			return
 		}
 
-		go func() {
+		concurrency.Go(ctx, func() {
 			resp, err := fn(ctx)
 			resolved <- checkOutcome{resp, err}
-		}()
+		})
 
 		select {
 		case <-ctx.Done():
@@ -161,7 +176,7 @@ func resolver(ctx context.Context, concurrencyLimit uint32, resultChan chan<- ch
 			select {
 			case limiter <- struct{}{}:
 				wg.Add(1)
-				go checker(fn)
+				concurrency.Go(ctx, func() { checker(fn) })
 			case <-ctx.Done():
 				break outer
 			}
@@ -192,7 +207,7 @@ func union(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHandle
 
 	var dbReads uint32
 	var err error
-	var cycleDetected bool
+	var cycleDetected, conditionNotMet bool
 	for i := 0; i < len(handlers); i++ {
 		select {
 		case result := <-resultChan:
@@ -204,6 +219,9 @@ func union(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHandle
 			if result.resp.GetCycleDetected() {
 				cycleDetected = true
 			}
+			if result.resp.GetResolutionMetadata().ConditionNotMet {
+				conditionNotMet = true
+			}
 
 			dbReads += result.resp.GetResolutionMetadata().DatastoreQueryCount
 
@@ -225,6 +243,7 @@ func union(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHandle
 		ResolutionMetadata: &ResolveCheckResponseMetadata{
 			DatastoreQueryCount: dbReads,
 			CycleDetected:       cycleDetected,
+			ConditionNotMet:     conditionNotMet,
 		},
 	}, nil
 }
@@ -317,21 +336,21 @@ func exclusion(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHa
 
 	limiter <- struct{}{}
 	wg.Add(1)
-	go func() {
+	concurrency.Go(ctx, func() {
 		resp, err := baseHandler(ctx)
 		baseChan <- checkOutcome{resp, err}
 		<-limiter
 		wg.Done()
-	}()
+	})
 
 	limiter <- struct{}{}
 	wg.Add(1)
-	go func() {
+	concurrency.Go(ctx, func() {
 		resp, err := subHandler(ctx)
 		subChan <- checkOutcome{resp, err}
 		<-limiter
 		wg.Done()
-	}()
+	})
 
 	response := &ResolveCheckResponse{
 		Allowed: false,
@@ -367,6 +386,7 @@ func exclusion(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHa
 
 			if !baseResult.resp.GetAllowed() {
 				response.GetResolutionMetadata().DatastoreQueryCount = dbReads
+				response.GetResolutionMetadata().ConditionNotMet = baseResult.resp.GetResolutionMetadata().ConditionNotMet
 				return response, nil
 			}
 
@@ -420,18 +440,26 @@ func (c *LocalChecker) Close() {
 }
 
 // dispatch clones the parent request, modifies its metadata and tupleKey, and dispatches the new request
-// to the CheckResolver this LocalChecker was constructed with.
+// to the CheckResolver this LocalChecker was constructed with, unless the subproblem was already resolved
+// by another check of the same BulkChecker request.
 func (c *LocalChecker) dispatch(_ context.Context, parentReq *ResolveCheckRequest, tk *openfgav1.TupleKey) CheckHandlerFunc {
 	return func(ctx context.Context) (*ResolveCheckResponse, error) {
-		parentReq.GetRequestMetadata().DispatchCounter.Add(1)
+		parentReq.GetRequestMetadata().ResolutionMetadata.DispatchCount.Add(1)
 		childRequest := parentReq.clone()
 		childRequest.TupleKey = tk
 		childRequest.GetRequestMetadata().Depth--
 
+		memo := childRequest.GetRequestMetadata().subproblems
+		if resp, ok := memo.get(tk); ok {
+			parentReq.GetRequestMetadata().ResolutionMetadata.CacheHitCount.Add(1)
+			return resp, nil
+		}
+
 		resp, err := c.delegate.ResolveCheck(ctx, childRequest)
 		if err != nil {
 			return nil, err
 		}
+		memo.set(tk, resp)
 		return resp, nil
 	}
 }
@@ -457,6 +485,11 @@ func (c *LocalChecker) ResolveCheck(
 	if req.GetRequestMetadata().Depth == 0 {
 		return nil, ErrResolutionDepthExceeded
 	}
+	c.observeDepth(ctx, req)
+
+	if c.workerPool != nil {
+		ctx = concurrency.ContextWithWorkerPool(ctx, c.workerPool)
+	}
 
 	cycle := c.hasCycle(req)
 	if cycle {
@@ -511,7 +544,7 @@ func (c *LocalChecker) ResolveCheck(
 
 // hasCycle returns true if a cycle has been found. It modifies the request object.
 func (c *LocalChecker) hasCycle(req *ResolveCheckRequest) bool {
-	key := tuple.TupleKeyToString(req.GetTupleKey())
+	key := tuple.CanonicalTupleKey(req.GetTupleKey())
 	if req.VisitedPaths == nil {
 		req.VisitedPaths = map[string]struct{}{}
 	}
@@ -545,13 +578,26 @@ func checkAssociatedObjects(ctx context.Context, req *ResolveCheckRequest, objec
 	}
 
 	reqContext := req.GetContext()
+	conditionFilter := checkutil.BuildTupleKeyConditionFilter(ctx, reqContext, typesys)
+
+	// conditionNotMet records whether a tuple of one of the objects was dropped by its condition
+	var conditionNotMet bool
+
 	// filter out invalid tuples yielded by the database iterator
 	filteredIter := storage.NewConditionsFilteredTupleKeyIterator(
 		storage.NewFilteredTupleKeyIterator(
 			storage.NewTupleKeyIteratorFromTupleIterator(i),
 			validation.FilterInvalidTuples(typesys),
 		),
-		checkutil.BuildTupleKeyConditionFilter(ctx, reqContext, typesys),
+		func(t *openfgav1.TupleKey) (bool, error) {
+			conditionMet, err := conditionFilter(t)
+			if err == nil && !conditionMet {
+				if _, objectID := tuple.SplitObject(t.GetObject()); objectIDs.Exists(objectID) {
+					conditionNotMet = true
+				}
+			}
+			return conditionMet, err
+		},
 	)
 	defer filteredIter.Stop()
 
@@ -568,6 +614,7 @@ func checkAssociatedObjects(ctx context.Context, req *ResolveCheckRequest, objec
 		Allowed: allowed,
 		ResolutionMetadata: &ResolveCheckResponseMetadata{
 			DatastoreQueryCount: 1,
+			ConditionNotMet:     !allowed && conditionNotMet,
 		},
 	}, nil
 }
@@ -624,10 +671,14 @@ func (c *LocalChecker) processDispatches(ctx context.Context, limit uint32, disp
 	dispatchPool := concurrency.NewPool(ctx, int(limit))
 
 	go func() {
+		// breadth counts the subproblems dispatched for parentReq at this level.
+		var parentReq *ResolveCheckRequest
+		var breadth int
 		defer func() {
 			// We need to wait always to avoid a goroutine leak.
 			_ = dispatchPool.Wait()
 			close(outcomes)
+			c.observeBreadth(ctx, parentReq, breadth)
 		}()
 
 		for {
@@ -654,6 +705,8 @@ func (c *LocalChecker) processDispatches(ctx context.Context, limit uint32, disp
 				}
 
 				if msg.dispatchParams != nil {
+					parentReq = msg.dispatchParams.parentReq
+					breadth++
 					dispatchPool.Go(func(ctx context.Context) error {
 						resp, err := c.dispatch(ctx, msg.dispatchParams.parentReq, msg.dispatchParams.tk)(ctx)
 						concurrency.TrySendThroughChannel(ctx, checkOutcome{resp: resp, err: err}, outcomes)
@@ -696,6 +749,9 @@ ConsumerLoop:
 			if outcome.resp.GetResolutionMetadata().CycleDetected {
 				finalResult.ResolutionMetadata.CycleDetected = true
 			}
+			if outcome.resp.GetResolutionMetadata().ConditionNotMet {
+				finalResult.ResolutionMetadata.ConditionNotMet = true
+			}
 
 			finalResult.ResolutionMetadata.DatastoreQueryCount += outcome.resp.GetResolutionMetadata().DatastoreQueryCount
 
@@ -908,6 +964,9 @@ ConsumerLoop:
 			if outcome.resp.GetResolutionMetadata().CycleDetected {
 				finalResult.ResolutionMetadata.CycleDetected = true
 			}
+			if outcome.resp.GetResolutionMetadata().ConditionNotMet {
+				finalResult.ResolutionMetadata.ConditionNotMet = true
+			}
 
 			finalResult.ResolutionMetadata.DatastoreQueryCount += outcome.resp.GetResolutionMetadata().DatastoreQueryCount
 
@@ -1053,6 +1112,7 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 				response.Allowed = true
 				return response, nil
 			}
+			response.ResolutionMetadata.ConditionNotMet = true
 			return response, nil
 		}
 
@@ -1348,6 +1408,7 @@ func (c *LocalChecker) checkSetOperation(
 			span.End()
 		}()
 
+		c.observeBreadth(ctx, req, len(handlers))
 		resp, err = reducer(ctx, c.concurrencyLimit, handlers...)
 		return resp, err
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
@@ -18,6 +19,7 @@ import (
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	parser "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 	"go.uber.org/mock/gomock"
@@ -28,6 +30,7 @@ import (
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/memory"
 	"github.com/openfga/openfga/pkg/storage/storagewrappers"
+	storagetest "github.com/openfga/openfga/pkg/storage/test"
 	"github.com/openfga/openfga/pkg/testutils"
 	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
@@ -1530,7 +1533,7 @@ func TestCheckDispatchCount(t *testing.T) {
 		require.NoError(t, err)
 		require.True(t, resp.Allowed)
 
-		require.Equal(t, uint32(3), checkRequestMetadata.DispatchCounter.Load())
+		require.Equal(t, uint32(3), checkRequestMetadata.ResolutionMetadata.DispatchCount.Load())
 
 		t.Run("direct_lookup_requires_no_dispatch", func(t *testing.T) {
 			checkRequestMetadata := NewCheckRequestMetadata(5)
@@ -1544,7 +1547,7 @@ func TestCheckDispatchCount(t *testing.T) {
 			require.NoError(t, err)
 			require.True(t, resp.Allowed)
 
-			require.Zero(t, checkRequestMetadata.DispatchCounter.Load())
+			require.Zero(t, checkRequestMetadata.ResolutionMetadata.DispatchCount.Load())
 		})
 	})
 
@@ -1595,8 +1598,8 @@ func TestCheckDispatchCount(t *testing.T) {
 		require.NoError(t, err)
 		require.True(t, resp.Allowed)
 
-		require.GreaterOrEqual(t, checkRequestMetadata.DispatchCounter.Load(), uint32(2))
-		require.LessOrEqual(t, checkRequestMetadata.DispatchCounter.Load(), uint32(4))
+		require.GreaterOrEqual(t, checkRequestMetadata.ResolutionMetadata.DispatchCount.Load(), uint32(2))
+		require.LessOrEqual(t, checkRequestMetadata.ResolutionMetadata.DispatchCount.Load(), uint32(4))
 
 		checkRequestMetadata = NewCheckRequestMetadata(5)
 
@@ -1609,7 +1612,7 @@ func TestCheckDispatchCount(t *testing.T) {
 		require.NoError(t, err)
 		require.False(t, resp.Allowed)
 
-		require.Equal(t, uint32(4), checkRequestMetadata.DispatchCounter.Load())
+		require.Equal(t, uint32(4), checkRequestMetadata.ResolutionMetadata.DispatchCount.Load())
 	})
 
 	t.Run("dispatch_count_computed_userset_lookups", func(t *testing.T) {
@@ -1651,7 +1654,7 @@ func TestCheckDispatchCount(t *testing.T) {
 		require.NoError(t, err)
 		require.True(t, resp.Allowed)
 
-		require.Zero(t, checkRequestMetadata.DispatchCounter.Load())
+		require.Zero(t, checkRequestMetadata.ResolutionMetadata.DispatchCount.Load())
 
 		checkRequestMetadata = NewCheckRequestMetadata(5)
 
@@ -1664,8 +1667,8 @@ func TestCheckDispatchCount(t *testing.T) {
 		require.NoError(t, err)
 		require.True(t, resp.Allowed)
 
-		require.LessOrEqual(t, checkRequestMetadata.DispatchCounter.Load(), uint32(1))
-		require.GreaterOrEqual(t, checkRequestMetadata.DispatchCounter.Load(), uint32(0))
+		require.LessOrEqual(t, checkRequestMetadata.ResolutionMetadata.DispatchCount.Load(), uint32(1))
+		require.GreaterOrEqual(t, checkRequestMetadata.ResolutionMetadata.DispatchCount.Load(), uint32(0))
 
 		checkRequestMetadata = NewCheckRequestMetadata(5)
 		resp, err = checker.ResolveCheck(ctx, &ResolveCheckRequest{
@@ -1676,7 +1679,7 @@ func TestCheckDispatchCount(t *testing.T) {
 		})
 		require.NoError(t, err)
 		require.False(t, resp.Allowed)
-		require.Equal(t, uint32(0), checkRequestMetadata.DispatchCounter.Load())
+		require.Equal(t, uint32(0), checkRequestMetadata.ResolutionMetadata.DispatchCount.Load())
 	})
 }
 
@@ -2159,7 +2162,7 @@ func TestCycleDetection(t *testing.T) {
 			TupleKey:        cyclicalTuple, // here
 			RequestMetadata: NewCheckRequestMetadata(defaultResolveNodeLimit),
 			VisitedPaths: map[string]struct{}{
-				tuple.TupleKeyToString(cyclicalTuple): {}, // and here
+				tuple.CanonicalTupleKey(cyclicalTuple): {}, // and here
 			},
 		})
 
@@ -2671,6 +2674,7 @@ func TestCheckAssociatedObjects(t *testing.T) {
 				Allowed: false,
 				ResolutionMetadata: &ResolveCheckResponseMetadata{
 					DatastoreQueryCount: 1,
+					ConditionNotMet:     true,
 				},
 			},
 			expectedError: false,
@@ -3137,7 +3141,7 @@ func TestDispatch(t *testing.T) {
 		func(_ context.Context, req *ResolveCheckRequest) (*ResolveCheckResponse, error) {
 			require.Equal(t, expectedReq.GetTupleKey(), req.GetTupleKey())
 			require.Equal(t, expectedReq.GetRequestMetadata().Depth, req.GetRequestMetadata().Depth)
-			require.Equal(t, uint32(1), req.GetRequestMetadata().DispatchCounter.Load())
+			require.Equal(t, uint32(1), req.GetRequestMetadata().ResolutionMetadata.DispatchCount.Load())
 			return nil, nil
 		})
 	dispatch := checker.dispatch(context.Background(), parentReq, tk)
@@ -4101,3 +4105,149 @@ func TestCheckTTUSlowPath(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveCheckWithWorkerPool(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+	storeID, model := storagetest.BootstrapFGAStore(t, ds, `
+		model
+			schema 1.1
+		type user
+		type group
+			relations
+				define member: [user, group#member]
+		type folder
+			relations
+				define viewer: [user, group#member]
+		type doc
+			relations
+				define parent: [folder]
+				define blocked: [user]
+				define editor: [user, group#member]
+				define viewer: ([user, group#member] or editor or viewer from parent) but not blocked`,
+		[]string{
+			"group:1#member@group:2#member",
+			"group:2#member@user:anne",
+			"folder:x#viewer@group:3#member",
+			"group:3#member@user:bob",
+			"doc:1#parent@folder:x",
+			"doc:1#editor@group:1#member",
+			"doc:1#blocked@user:charlie",
+			"doc:1#viewer@user:charlie",
+		})
+
+	pool := concurrency.NewWorkerPool(2)
+	t.Cleanup(pool.Close)
+
+	checker := NewLocalChecker(WithResolverWorkerPool(pool))
+	t.Cleanup(checker.Close)
+
+	ts, err := typesystem.New(model)
+	require.NoError(t, err)
+	ctx := typesystem.ContextWithTypesystem(
+		storage.ContextWithRelationshipTupleReader(context.Background(), ds),
+		ts,
+	)
+
+	tests := map[string]bool{
+		"user:anne":    true,
+		"user:bob":     true,
+		"user:charlie": false,
+		"user:dave":    false,
+	}
+
+	// more concurrent checks than workers, so that some subproblems run inline
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for user, expected := range tests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := checker.ResolveCheck(ctx, &ResolveCheckRequest{
+					StoreID:              storeID,
+					AuthorizationModelID: model.GetId(),
+					TupleKey:             tuple.NewTupleKey("doc:1", "viewer", user),
+					RequestMetadata:      NewCheckRequestMetadata(defaultResolveNodeLimit),
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, expected, resp.GetAllowed(), user)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// BenchmarkCheckWorkerPool compares the resolution of a wide Check, whose subproblems all run
+// because the user is denied, with a goroutine per subproblem and with a worker pool.
+func BenchmarkCheckWorkerPool(b *testing.B) {
+	ds := memory.New()
+	b.Cleanup(ds.Close)
+
+	tuples := []string{"doc:1#parent@folder:1"}
+	for i := 0; i < 50; i++ {
+		tuples = append(tuples,
+			fmt.Sprintf("doc:1#viewer@group:%d#member", i),
+			fmt.Sprintf("folder:1#viewer@group:%d#member", i),
+			fmt.Sprintf("group:%d#member@user:%d", i, i),
+		)
+	}
+	storeID, model := storagetest.BootstrapFGAStore(b, ds, `
+		model
+			schema 1.1
+		type user
+		type group
+			relations
+				define member: [user]
+		type folder
+			relations
+				define viewer: [group#member]
+		type doc
+			relations
+				define parent: [folder]
+				define editor: [group#member]
+				define viewer: [group#member] or editor or viewer from parent`, tuples)
+
+	ts, err := typesystem.New(model)
+	require.NoError(b, err)
+	ctx := typesystem.ContextWithTypesystem(
+		storage.ContextWithRelationshipTupleReader(context.Background(), ds),
+		ts,
+	)
+
+	benchmarks := map[string]int{
+		"goroutine_per_subproblem": 0,
+		"worker_pool":              runtime.GOMAXPROCS(0) * serverconfig.DefaultResolveNodeBreadthLimit,
+	}
+	for name, poolSize := range benchmarks {
+		b.Run(name, func(b *testing.B) {
+			var opts []LocalCheckerOption
+			if poolSize > 0 {
+				pool := concurrency.NewWorkerPool(poolSize)
+				b.Cleanup(pool.Close)
+				opts = append(opts, WithResolverWorkerPool(pool))
+			}
+			checker := NewLocalChecker(opts...)
+			b.Cleanup(checker.Close)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					resp, err := checker.ResolveCheck(ctx, &ResolveCheckRequest{
+						StoreID:              storeID,
+						AuthorizationModelID: model.GetId(),
+						TupleKey:             tuple.NewTupleKey("doc:1", "viewer", "user:denied"),
+						RequestMetadata:      NewCheckRequestMetadata(defaultResolveNodeLimit),
+					})
+					if err != nil || resp.GetAllowed() {
+						b.Fatal("unexpected check result", err)
+					}
+				}
+			})
+		})
+	}
+}
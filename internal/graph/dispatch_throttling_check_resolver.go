@@ -15,6 +15,9 @@ import (
 type DispatchThrottlingCheckResolverConfig struct {
 	DefaultThreshold uint32
 	MaxThreshold     uint32
+
+	// LowPriorityDeprioritization is the percentage by which DefaultThreshold is lowered for low priority requests.
+	LowPriorityDeprioritization uint32
 }
 
 // DispatchThrottlingCheckResolver will prioritize requests with fewer dispatches over
@@ -82,13 +85,14 @@ func (r *DispatchThrottlingCheckResolver) ResolveCheck(ctx context.Context,
 ) (*ResolveCheckResponse, error) {
 	span := trace.SpanFromContext(ctx)
 
-	currentNumDispatch := req.GetRequestMetadata().DispatchCounter.Load()
+	currentNumDispatch := req.GetRequestMetadata().ResolutionMetadata.DispatchCount.Load()
 
 	shouldThrottle := threshold.ShouldThrottle(
 		ctx,
 		currentNumDispatch,
 		r.config.DefaultThreshold,
 		r.config.MaxThreshold,
+		r.config.LowPriorityDeprioritization,
 	)
 
 	span.SetAttributes(
@@ -96,7 +100,7 @@ func (r *DispatchThrottlingCheckResolver) ResolveCheck(ctx context.Context,
 		attribute.Bool("is_throttled", shouldThrottle))
 
 	if shouldThrottle {
-		req.GetRequestMetadata().WasThrottled.Store(true)
+		req.GetRequestMetadata().ResolutionMetadata.ThrottleCount.Add(1)
 		r.throttler.Throttle(ctx)
 	}
 	return r.delegate.ResolveCheck(ctx, req)
@@ -42,14 +42,14 @@ func TestDispatchThrottlingCheckResolver(t *testing.T) {
 		mockThrottler.EXPECT().Throttle(gomock.Any()).Times(0)
 
 		req := &ResolveCheckRequest{RequestMetadata: NewCheckRequestMetadata(10)}
-		req.GetRequestMetadata().DispatchCounter.Store(190)
+		req.GetRequestMetadata().ResolutionMetadata.DispatchCount.Store(190)
 
 		ctx := context.Background()
 
 		_, err := dut.ResolveCheck(ctx, req)
 		require.NoError(t, err)
 
-		require.False(t, req.GetRequestMetadata().WasThrottled.Load())
+		require.False(t, req.GetRequestMetadata().ResolutionMetadata.WasThrottled())
 	})
 
 	t.Run("above_threshold_should_call_throttle", func(t *testing.T) {
@@ -78,14 +78,14 @@ func TestDispatchThrottlingCheckResolver(t *testing.T) {
 		mockThrottler.EXPECT().Throttle(gomock.Any()).Times(1)
 
 		req := &ResolveCheckRequest{RequestMetadata: NewCheckRequestMetadata(10)}
-		req.GetRequestMetadata().DispatchCounter.Store(201)
+		req.GetRequestMetadata().ResolutionMetadata.DispatchCount.Store(201)
 
 		ctx := context.Background()
 
 		_, err := dut.ResolveCheck(ctx, req)
 		require.NoError(t, err)
 
-		require.True(t, req.GetRequestMetadata().WasThrottled.Load())
+		require.True(t, req.GetRequestMetadata().ResolutionMetadata.WasThrottled())
 	})
 
 	t.Run("zero_max_should_interpret_as_default", func(t *testing.T) {
@@ -114,14 +114,14 @@ func TestDispatchThrottlingCheckResolver(t *testing.T) {
 		mockThrottler.EXPECT().Throttle(gomock.Any()).Times(0)
 
 		req := &ResolveCheckRequest{RequestMetadata: NewCheckRequestMetadata(10)}
-		req.GetRequestMetadata().DispatchCounter.Store(190)
+		req.GetRequestMetadata().ResolutionMetadata.DispatchCount.Store(190)
 
 		ctx := context.Background()
 
 		_, err := dut.ResolveCheck(ctx, req)
 		require.NoError(t, err)
 
-		require.False(t, req.GetRequestMetadata().WasThrottled.Load())
+		require.False(t, req.GetRequestMetadata().ResolutionMetadata.WasThrottled())
 	})
 
 	t.Run("dispatch_should_use_request_threshold_if_available", func(t *testing.T) {
@@ -150,7 +150,7 @@ func TestDispatchThrottlingCheckResolver(t *testing.T) {
 		mockThrottler.EXPECT().Throttle(gomock.Any()).Times(1)
 
 		req := &ResolveCheckRequest{RequestMetadata: NewCheckRequestMetadata(10)}
-		req.GetRequestMetadata().DispatchCounter.Store(201)
+		req.GetRequestMetadata().ResolutionMetadata.DispatchCount.Store(201)
 
 		ctx := context.Background()
 		ctx = dispatch.ContextWithThrottlingThreshold(ctx, 200)
@@ -158,7 +158,7 @@ func TestDispatchThrottlingCheckResolver(t *testing.T) {
 		_, err := dut.ResolveCheck(ctx, req)
 		require.NoError(t, err)
 
-		require.True(t, req.GetRequestMetadata().WasThrottled.Load())
+		require.True(t, req.GetRequestMetadata().ResolutionMetadata.WasThrottled())
 	})
 
 	t.Run("should_respect_max_threshold", func(t *testing.T) {
@@ -190,11 +190,11 @@ func TestDispatchThrottlingCheckResolver(t *testing.T) {
 		ctx = dispatch.ContextWithThrottlingThreshold(ctx, 1000)
 
 		req := &ResolveCheckRequest{RequestMetadata: NewCheckRequestMetadata(10)}
-		req.GetRequestMetadata().DispatchCounter.Store(301)
+		req.GetRequestMetadata().ResolutionMetadata.DispatchCount.Store(301)
 
 		_, err := dut.ResolveCheck(ctx, req)
 		require.NoError(t, err)
 
-		require.True(t, req.GetRequestMetadata().WasThrottled.Load())
+		require.True(t, req.GetRequestMetadata().ResolutionMetadata.WasThrottled())
 	})
 }
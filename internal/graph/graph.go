@@ -6,7 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
-	"sync/atomic"
+	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 
@@ -51,22 +51,27 @@ type ResolveCheckRequestMetadata struct {
 	// Number of calls to ReadUserTuple + ReadUsersetTuples + Read accumulated so far, before this request is solved.
 	DatastoreQueryCount uint32
 
-	// DispatchCounter is the address to a shared counter that keeps track of how many calls to ResolveCheck we had to do
-	// to solve the root/parent problem.
-	// The contents of this counter will be written by concurrent goroutines.
-	// After the root problem has been solved, this value can be read.
-	DispatchCounter *atomic.Uint32
+	// ResolutionMetadata is shared by all the subproblems of the root/parent problem, e.g. to count
+	// how many calls to ResolveCheck we had to do to solve it.
+	// It will be written by concurrent goroutines.
+	// After the root problem has been solved, it can be read.
+	ResolutionMetadata *ResolutionMetadata
 
-	// WasThrottled indicates whether the request was throttled
-	WasThrottled *atomic.Bool
+	// maxDepth is the Depth of the root problem, i.e. the resolve node limit of the request. It is
+	// 0 if unknown.
+	maxDepth uint32
+
+	// subproblems memoizes the outcomes of the subproblems of the checks of a BulkChecker, nil
+	// for the other checks.
+	subproblems *subproblemMemo
 }
 
 func NewCheckRequestMetadata(maxDepth uint32) *ResolveCheckRequestMetadata {
 	return &ResolveCheckRequestMetadata{
 		Depth:               maxDepth,
+		maxDepth:            maxDepth,
 		DatastoreQueryCount: 0,
-		DispatchCounter:     new(atomic.Uint32),
-		WasThrottled:        new(atomic.Bool),
+		ResolutionMetadata:  NewResolutionMetadata(),
 	}
 }
 
@@ -81,6 +86,19 @@ type ResolveCheckResponseMetadata struct {
 	// Indicates if the ResolveCheck subproblem that was evaluated involved
 	// a cycle in the evaluation.
 	CycleDetected bool
+
+	// ConditionNotMet is true if the request is not allowed and a relationship that would have
+	// allowed it was found, directly or in one of the subproblems it was resolved with, but its
+	// condition was not met by the context of the request.
+	ConditionNotMet bool
+
+	// CacheHit is true if the response was served from the check query cache, and CacheEntryAge
+	// is then the time since it was cached.
+	CacheHit      bool
+	CacheEntryAge time.Duration
+
+	// cachedAt is when the response was cached, for the responses in the check query cache.
+	cachedAt time.Time
 }
 
 type RelationshipEdgeType int
@@ -0,0 +1,196 @@
+package graph
+
+import (
+	"context"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// The reasons for which a Check sub-problem on an indexed relation is not answered by the
+// membership index.
+const (
+	membershipIndexFallbackConsistency      = "higher_consistency"
+	membershipIndexFallbackContextualTuples = "contextual_tuples"
+	membershipIndexFallbackPointInTime      = "point_in_time"
+	membershipIndexFallbackUserset          = "userset_user"
+	membershipIndexFallbackModel            = "model"
+	membershipIndexFallbackIndex            = "index"
+)
+
+var (
+	membershipIndexHitCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "membership_index_hit_count",
+		Help:      "The total number of Check sub-problems answered by the membership index.",
+	})
+
+	membershipIndexFallbackCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "membership_index_fallback_count",
+		Help:      "The total number of Check sub-problems on indexed relations that were not answered by the membership index, by reason: higher_consistency, contextual_tuples, point_in_time, userset_user, model (the model does not define the relation as indexable) or index (the index could not answer, e.g. it is not caught up).",
+	}, []string{"reason"})
+)
+
+// MembershipIndexCheckResolver answers the Check sub-problems on the relations of a
+// [storage.MembershipIndex] from the index, and delegates the others, and those the index
+// cannot answer, to the next CheckResolver.
+//
+// A relation is only answered from the index if, under the model of the request, it and the
+// relations of its userset type restrictions are indexed, are defined as directly related user
+// types, and have no conditions, so that the index knows all the tuples its answer depends on.
+// As the index may lag behind the writes, it is bypassed for HIGHER_CONSISTENCY requests and for
+// point in time requests, as well as when contextual tuples are written to indexed relations.
+type MembershipIndexCheckResolver struct {
+	delegate  CheckResolver
+	index     storage.MembershipIndex
+	relations map[string]struct{}
+}
+
+var _ CheckResolver = (*MembershipIndexCheckResolver)(nil)
+
+// MembershipIndexCheckResolverOpt defines an option that can be used to change the behavior of
+// MembershipIndexCheckResolver instance.
+type MembershipIndexCheckResolverOpt func(*MembershipIndexCheckResolver)
+
+// WithMembershipIndex sets the index consulted by the MembershipIndexCheckResolver.
+func WithMembershipIndex(index storage.MembershipIndex) MembershipIndexCheckResolverOpt {
+	return func(r *MembershipIndexCheckResolver) {
+		r.index = index
+	}
+}
+
+// NewMembershipIndexCheckResolver constructs a MembershipIndexCheckResolver. Without an index,
+// it delegates every sub-problem.
+func NewMembershipIndexCheckResolver(opts ...MembershipIndexCheckResolverOpt) *MembershipIndexCheckResolver {
+	r := &MembershipIndexCheckResolver{relations: map[string]struct{}{}}
+	r.delegate = r
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.index != nil {
+		for _, relation := range r.index.Relations() {
+			r.relations[relation] = struct{}{}
+		}
+	}
+	return r
+}
+
+// SetDelegate sets this MembershipIndexCheckResolver's dispatch delegate.
+func (r *MembershipIndexCheckResolver) SetDelegate(delegate CheckResolver) {
+	r.delegate = delegate
+}
+
+// GetDelegate returns this MembershipIndexCheckResolver's dispatch delegate.
+func (r *MembershipIndexCheckResolver) GetDelegate() CheckResolver {
+	return r.delegate
+}
+
+// Close does not close the index, which is owned by its creator.
+func (r *MembershipIndexCheckResolver) Close() {}
+
+func (r *MembershipIndexCheckResolver) ResolveCheck(
+	ctx context.Context,
+	req *ResolveCheckRequest,
+) (*ResolveCheckResponse, error) {
+	tupleKey := req.GetTupleKey()
+	objectType := tuple.GetType(tupleKey.GetObject())
+	if _, ok := r.relations[tuple.ToObjectRelationString(objectType, tupleKey.GetRelation())]; !ok {
+		return r.delegate.ResolveCheck(ctx, req)
+	}
+
+	reason := r.fallbackReason(ctx, req)
+	if reason == "" {
+		member, ok := r.index.Lookup(ctx, req.GetStoreID(), tupleKey.GetObject(), tupleKey.GetRelation(), tupleKey.GetUser())
+		if ok {
+			membershipIndexHitCounter.Inc()
+			trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("membership_index_hit", true))
+			return &ResolveCheckResponse{
+				Allowed:            member,
+				ResolutionMetadata: &ResolveCheckResponseMetadata{},
+			}, nil
+		}
+		reason = membershipIndexFallbackIndex
+	}
+
+	membershipIndexFallbackCounter.WithLabelValues(reason).Inc()
+	return r.delegate.ResolveCheck(ctx, req)
+}
+
+// fallbackReason returns why the sub-problem cannot be answered from the index, if it cannot.
+func (r *MembershipIndexCheckResolver) fallbackReason(ctx context.Context, req *ResolveCheckRequest) string {
+	if req.GetConsistency() == openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY {
+		return membershipIndexFallbackConsistency
+	}
+	if !req.GetPointInTime().IsZero() {
+		return membershipIndexFallbackPointInTime
+	}
+	for _, contextualTuple := range req.GetContextualTuples() {
+		objectType := tuple.GetType(contextualTuple.GetObject())
+		if _, ok := r.relations[tuple.ToObjectRelationString(objectType, contextualTuple.GetRelation())]; ok {
+			return membershipIndexFallbackContextualTuples
+		}
+	}
+
+	tupleKey := req.GetTupleKey()
+	if tuple.IsObjectRelation(tupleKey.GetUser()) {
+		return membershipIndexFallbackUserset
+	}
+
+	typesys, ok := typesystem.TypesystemFromContext(ctx)
+	if !ok || !r.indexable(typesys, tuple.GetType(tupleKey.GetObject()), tupleKey.GetRelation()) {
+		return membershipIndexFallbackModel
+	}
+	return ""
+}
+
+// indexable returns true if the relation and the relations of its userset type restrictions,
+// recursively, are indexed, and are defined by the model as directly related user types without
+// conditions.
+func (r *MembershipIndexCheckResolver) indexable(typesys *typesystem.TypeSystem, objectType, relation string) bool {
+	start := tuple.ToObjectRelationString(objectType, relation)
+	visited := map[string]struct{}{start: {}}
+	for queue := []string{start}; len(queue) > 0; queue = queue[1:] {
+		if _, ok := r.relations[queue[0]]; !ok {
+			return false
+		}
+
+		objectType, relation := tuple.SplitObjectRelation(queue[0])
+		rel, err := typesys.GetRelation(objectType, relation)
+		if err != nil {
+			return false
+		}
+		if _, ok := rel.GetRewrite().GetUserset().(*openfgav1.Userset_This); !ok {
+			return false
+		}
+
+		directlyRelated, err := typesys.GetDirectlyRelatedUserTypes(objectType, relation)
+		if err != nil {
+			return false
+		}
+		for _, ref := range directlyRelated {
+			if ref.GetCondition() != "" {
+				return false
+			}
+			if ref.GetRelation() == "" {
+				continue
+			}
+			userset := tuple.ToObjectRelationString(ref.GetType(), ref.GetRelation())
+			if _, ok := visited[userset]; !ok {
+				visited[userset] = struct{}{}
+				queue = append(queue, userset)
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,191 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// staticMembershipIndex is a membership index of the relations of its members, by
+// `object#relation`, that cannot answer for the usersets it has no members for.
+type staticMembershipIndex struct {
+	relations []string
+	members   map[string][]string
+}
+
+func (s *staticMembershipIndex) Relations() []string {
+	return s.relations
+}
+
+func (s *staticMembershipIndex) Lookup(_ context.Context, _, object, relation, user string) (bool, bool) {
+	members, ok := s.members[tuple.ToObjectRelationString(object, relation)]
+	if !ok {
+		return false, false
+	}
+	for _, member := range members {
+		if member == user {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+func (s *staticMembershipIndex) Close() {}
+
+func TestMembershipIndexCheckResolver(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type team
+			relations
+				define member: [user]
+		type group
+			relations
+				define member: [user, user:*, group#member]
+				define owner: [user]
+				define admin: [user, team#member]
+				define manager: [user with in_office_hours]
+				define viewer: member
+		condition in_office_hours(hour: int) {
+			hour >= 9 && hour <= 17
+		}`)
+	typesys, err := typesystem.New(model)
+	require.NoError(t, err)
+
+	index := &staticMembershipIndex{
+		relations: []string{"group#member", "group#admin", "group#manager", "group#viewer"},
+		members: map[string][]string{
+			"group:eng#member":  {"user:anne"},
+			"group:eng#admin":   {"user:anne"},
+			"group:eng#manager": {"user:anne"},
+			"group:eng#viewer":  {"user:anne"},
+		},
+	}
+
+	tests := map[string]struct {
+		req     *ResolveCheckRequest
+		allowed bool
+		// delegated is true if the sub-problem is not on an indexed relation
+		delegated bool
+		fallback  string
+	}{
+		`member_from_the_index`: {
+			req:     &ResolveCheckRequest{TupleKey: tuple.NewTupleKey("group:eng", "member", "user:anne")},
+			allowed: true,
+		},
+		`not_a_member_from_the_index`: {
+			req: &ResolveCheckRequest{TupleKey: tuple.NewTupleKey("group:eng", "member", "user:bob")},
+		},
+		`relation_not_indexed`: {
+			req:       &ResolveCheckRequest{TupleKey: tuple.NewTupleKey("group:eng", "owner", "user:anne")},
+			delegated: true,
+		},
+		`higher_consistency`: {
+			req: &ResolveCheckRequest{
+				TupleKey:    tuple.NewTupleKey("group:eng", "member", "user:anne"),
+				Consistency: openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY,
+			},
+			fallback: membershipIndexFallbackConsistency,
+		},
+		`point_in_time`: {
+			req: &ResolveCheckRequest{
+				TupleKey:    tuple.NewTupleKey("group:eng", "member", "user:anne"),
+				PointInTime: time.Now(),
+			},
+			fallback: membershipIndexFallbackPointInTime,
+		},
+		`contextual_tuples_of_an_indexed_relation`: {
+			req: &ResolveCheckRequest{
+				TupleKey:         tuple.NewTupleKey("group:eng", "member", "user:anne"),
+				ContextualTuples: []*openfgav1.TupleKey{tuple.NewTupleKey("group:eng", "member", "user:bob")},
+			},
+			fallback: membershipIndexFallbackContextualTuples,
+		},
+		`contextual_tuples_of_other_relations`: {
+			req: &ResolveCheckRequest{
+				TupleKey:         tuple.NewTupleKey("group:eng", "member", "user:anne"),
+				ContextualTuples: []*openfgav1.TupleKey{tuple.NewTupleKey("group:eng", "owner", "user:bob")},
+			},
+			allowed: true,
+		},
+		`userset_user`: {
+			req:      &ResolveCheckRequest{TupleKey: tuple.NewTupleKey("group:eng", "member", "group:sre#member")},
+			fallback: membershipIndexFallbackUserset,
+		},
+		`userset_of_a_relation_not_indexed`: {
+			req:      &ResolveCheckRequest{TupleKey: tuple.NewTupleKey("group:eng", "admin", "user:anne")},
+			fallback: membershipIndexFallbackModel,
+		},
+		`conditional_type_restriction`: {
+			req:      &ResolveCheckRequest{TupleKey: tuple.NewTupleKey("group:eng", "manager", "user:anne")},
+			fallback: membershipIndexFallbackModel,
+		},
+		`rewritten_relation`: {
+			req:      &ResolveCheckRequest{TupleKey: tuple.NewTupleKey("group:eng", "viewer", "user:anne")},
+			fallback: membershipIndexFallbackModel,
+		},
+		`index_cannot_answer`: {
+			req:      &ResolveCheckRequest{TupleKey: tuple.NewTupleKey("group:sre", "member", "user:anne")},
+			fallback: membershipIndexFallbackIndex,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dut := NewMembershipIndexCheckResolver(WithMembershipIndex(index))
+			t.Cleanup(dut.Close)
+
+			mockCheckResolver := NewMockCheckResolver(ctrl)
+			dut.SetDelegate(mockCheckResolver)
+
+			delegated := test.delegated || test.fallback != ""
+			if delegated {
+				mockCheckResolver.EXPECT().ResolveCheck(gomock.Any(), gomock.Any()).
+					Return(&ResolveCheckResponse{Allowed: true, ResolutionMetadata: &ResolveCheckResponseMetadata{}}, nil).
+					Times(1)
+			} else {
+				mockCheckResolver.EXPECT().ResolveCheck(gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			hits := testutil.ToFloat64(membershipIndexHitCounter)
+			var fallbacks float64
+			if test.fallback != "" {
+				fallbacks = testutil.ToFloat64(membershipIndexFallbackCounter.WithLabelValues(test.fallback))
+			}
+
+			ctx := typesystem.ContextWithTypesystem(context.Background(), typesys)
+			resp, err := dut.ResolveCheck(ctx, test.req)
+			require.NoError(t, err)
+
+			switch {
+			case test.fallback != "":
+				require.True(t, resp.GetAllowed())
+				require.InDelta(t, fallbacks+1, testutil.ToFloat64(membershipIndexFallbackCounter.WithLabelValues(test.fallback)), 0)
+			case delegated:
+				require.True(t, resp.GetAllowed())
+				require.InDelta(t, hits, testutil.ToFloat64(membershipIndexHitCounter), 0)
+			default:
+				require.Equal(t, test.allowed, resp.GetAllowed())
+				require.InDelta(t, hits+1, testutil.ToFloat64(membershipIndexHitCounter), 0)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"sync/atomic"
+
+	"github.com/openfga/openfga/pkg/storage/storagewrappers"
+)
+
+var _ storagewrappers.QueryCounter = (*ResolutionMetadata)(nil)
+
+// ResolutionMetadata counts the work done to resolve one Check, ListObjects or ListUsers request.
+// It is shared by all the subproblems of the request, which update it concurrently, and read once
+// the request has been resolved.
+type ResolutionMetadata struct {
+	// DatastoreQueryCount is the number of datastore queries made to resolve the request.
+	DatastoreQueryCount atomic.Uint32
+
+	// DispatchCount is the number of subproblems dispatched to resolve the request.
+	DispatchCount atomic.Uint32
+
+	// ThrottleCount is the number of dispatches of the request that were throttled.
+	ThrottleCount atomic.Uint32
+
+	// CacheHitCount is the number of subproblems resolved from the check cache.
+	CacheHitCount atomic.Uint32
+
+	// ConditionalExclusionCount is the number of tuples that were not followed because their
+	// condition was not met.
+	ConditionalExclusionCount atomic.Uint32
+
+	// queryCounts are the numbers of tuple queries made to resolve the request, indexed by
+	// [storagewrappers.QueryKind]. Unlike DatastoreQueryCount, they are counted by the
+	// [storagewrappers.QueryCountingTupleReader] of the request.
+	queryCounts [len(storagewrappers.QueryKinds)]atomic.Uint32
+
+	// breadthShadowLimitExceeded and depthShadowLimitExceeded are set once the request exceeded
+	// the shadow resolve node limits of the LocalChecker, so that it is recorded only once.
+	breadthShadowLimitExceeded atomic.Bool
+	depthShadowLimitExceeded   atomic.Bool
+}
+
+func NewResolutionMetadata() *ResolutionMetadata {
+	return &ResolutionMetadata{}
+}
+
+// WasThrottled returns true if at least one dispatch of the request was throttled.
+func (m *ResolutionMetadata) WasThrottled() bool {
+	return m.ThrottleCount.Load() > 0
+}
+
+// AddQuery counts a tuple query of the given kind.
+func (m *ResolutionMetadata) AddQuery(kind storagewrappers.QueryKind) {
+	m.queryCounts[kind].Add(1)
+}
+
+// QueryCount returns the number of tuple queries of the given kind made to resolve the request.
+func (m *ResolutionMetadata) QueryCount(kind storagewrappers.QueryKind) uint32 {
+	return m.queryCounts[kind].Load()
+}
+
+// Merge adds the counters of other, e.g. the metadata of a subrequest resolved separately, to m.
+func (m *ResolutionMetadata) Merge(other *ResolutionMetadata) {
+	m.DatastoreQueryCount.Add(other.DatastoreQueryCount.Load())
+	m.DispatchCount.Add(other.DispatchCount.Load())
+	m.ThrottleCount.Add(other.ThrottleCount.Load())
+	m.CacheHitCount.Add(other.CacheHitCount.Load())
+	m.ConditionalExclusionCount.Add(other.ConditionalExclusionCount.Load())
+	for i := range m.queryCounts {
+		m.queryCounts[i].Add(other.queryCounts[i].Load())
+	}
+}
@@ -0,0 +1,37 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolutionMetadata(t *testing.T) {
+	t.Run("not_throttled_by_default", func(t *testing.T) {
+		require.False(t, NewResolutionMetadata().WasThrottled())
+	})
+
+	t.Run("merge_adds_the_counters", func(t *testing.T) {
+		m := NewResolutionMetadata()
+		m.DatastoreQueryCount.Add(1)
+		m.DispatchCount.Add(2)
+
+		other := NewResolutionMetadata()
+		other.DatastoreQueryCount.Add(3)
+		other.DispatchCount.Add(4)
+		other.ThrottleCount.Add(1)
+		other.CacheHitCount.Add(5)
+		other.ConditionalExclusionCount.Add(6)
+
+		m.Merge(other)
+		require.Equal(t, uint32(4), m.DatastoreQueryCount.Load())
+		require.Equal(t, uint32(6), m.DispatchCount.Load())
+		require.Equal(t, uint32(1), m.ThrottleCount.Load())
+		require.Equal(t, uint32(5), m.CacheHitCount.Load())
+		require.Equal(t, uint32(6), m.ConditionalExclusionCount.Load())
+		require.True(t, m.WasThrottled())
+
+		// other is left unchanged
+		require.Equal(t, uint32(3), other.DatastoreQueryCount.Load())
+	})
+}
@@ -1,6 +1,8 @@
 package graph
 
 import (
+	"time"
+
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"golang.org/x/exp/maps"
 	"google.golang.org/protobuf/types/known/structpb"
@@ -15,6 +17,9 @@ type ResolveCheckRequest struct {
 	RequestMetadata      *ResolveCheckRequestMetadata
 	VisitedPaths         map[string]struct{}
 	Consistency          openfgav1.ConsistencyPreference
+	// PointInTime, if set, is the point in time at which the tuples are read, e.g. by a
+	// storagewrappers.PointInTimeTupleReader. It is part of the cache key of the request.
+	PointInTime time.Time
 }
 
 func (r *ResolveCheckRequest) clone() *ResolveCheckRequest {
@@ -22,10 +27,11 @@ func (r *ResolveCheckRequest) clone() *ResolveCheckRequest {
 	origRequestMetadata := r.GetRequestMetadata()
 	if origRequestMetadata != nil {
 		requestMetadata = &ResolveCheckRequestMetadata{
-			DispatchCounter:     origRequestMetadata.DispatchCounter,
 			Depth:               origRequestMetadata.Depth,
 			DatastoreQueryCount: origRequestMetadata.DatastoreQueryCount,
-			WasThrottled:        origRequestMetadata.WasThrottled,
+			ResolutionMetadata:  origRequestMetadata.ResolutionMetadata,
+			maxDepth:            origRequestMetadata.maxDepth,
+			subproblems:         origRequestMetadata.subproblems,
 		}
 	}
 
@@ -38,6 +44,7 @@ func (r *ResolveCheckRequest) clone() *ResolveCheckRequest {
 		RequestMetadata:      requestMetadata,
 		VisitedPaths:         maps.Clone(r.GetVistedPaths()),
 		Consistency:          r.GetConsistency(),
+		PointInTime:          r.GetPointInTime(),
 	}
 }
 
@@ -90,6 +97,13 @@ func (r *ResolveCheckRequest) GetConsistency() openfgav1.ConsistencyPreference {
 	return r.Consistency
 }
 
+func (r *ResolveCheckRequest) GetPointInTime() time.Time {
+	if r == nil {
+		return time.Time{}
+	}
+	return r.PointInTime
+}
+
 func (r *ResolveCheckRequest) GetVistedPaths() map[string]struct{} {
 	if r == nil {
 		return map[string]struct{}{}
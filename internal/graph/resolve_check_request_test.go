@@ -2,6 +2,7 @@ package graph
 
 import (
 	"testing"
+	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/stretchr/testify/require"
@@ -27,9 +28,10 @@ func TestCloneResolveCheckRequest(t *testing.T) {
 				"abc": {},
 			},
 			Consistency: openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY,
+			PointInTime: time.Unix(1700000000, 0),
 		}
 		orig.GetRequestMetadata().DatastoreQueryCount++
-		orig.GetRequestMetadata().DispatchCounter.Add(2)
+		orig.GetRequestMetadata().ResolutionMetadata.DispatchCount.Add(2)
 
 		// First, assert the values of the orig
 		require.Equal(t, "12", orig.GetStoreID())
@@ -44,8 +46,8 @@ func TestCloneResolveCheckRequest(t *testing.T) {
 		require.Equal(t, contextStruct, orig.GetContext())
 		require.Equal(t, uint32(1), orig.GetRequestMetadata().DatastoreQueryCount)
 		require.Equal(t, uint32(20), orig.GetRequestMetadata().Depth)
-		require.Equal(t, uint32(2), orig.GetRequestMetadata().DispatchCounter.Load())
-		require.False(t, orig.GetRequestMetadata().WasThrottled.Load())
+		require.Equal(t, uint32(2), orig.GetRequestMetadata().ResolutionMetadata.DispatchCount.Load())
+		require.False(t, orig.GetRequestMetadata().ResolutionMetadata.WasThrottled())
 		require.Equal(t, map[string]struct{}{
 			"abc": {},
 		}, orig.VisitedPaths)
@@ -53,12 +55,12 @@ func TestCloneResolveCheckRequest(t *testing.T) {
 		// now, clone the orig and update the orig
 		cloned := orig.clone()
 		orig.GetRequestMetadata().DatastoreQueryCount++
-		orig.GetRequestMetadata().DispatchCounter.Add(5)
+		orig.GetRequestMetadata().ResolutionMetadata.DispatchCount.Add(5)
 		orig.VisitedPaths = map[string]struct{}{
 			"abc": {},
 			"xyz": {},
 		}
-		orig.GetRequestMetadata().WasThrottled.Store(true)
+		orig.GetRequestMetadata().ResolutionMetadata.ThrottleCount.Add(1)
 
 		// Assert the new values of the orig
 		require.Equal(t, "12", orig.GetStoreID())
@@ -73,8 +75,8 @@ func TestCloneResolveCheckRequest(t *testing.T) {
 		require.Equal(t, contextStruct, orig.GetContext())
 		require.Equal(t, uint32(2), orig.GetRequestMetadata().DatastoreQueryCount)
 		require.Equal(t, uint32(20), orig.GetRequestMetadata().Depth)
-		require.Equal(t, uint32(7), orig.GetRequestMetadata().DispatchCounter.Load())
-		require.True(t, orig.GetRequestMetadata().WasThrottled.Load())
+		require.Equal(t, uint32(7), orig.GetRequestMetadata().ResolutionMetadata.DispatchCount.Load())
+		require.True(t, orig.GetRequestMetadata().ResolutionMetadata.WasThrottled())
 		require.Equal(t, map[string]struct{}{
 			"abc": {},
 			"xyz": {},
@@ -92,11 +94,12 @@ func TestCloneResolveCheckRequest(t *testing.T) {
 		require.Equal(t, contextStruct, cloned.GetContext())
 		require.Equal(t, uint32(1), cloned.GetRequestMetadata().DatastoreQueryCount)
 		require.Equal(t, uint32(20), cloned.GetRequestMetadata().Depth)
-		require.Equal(t, uint32(7), cloned.GetRequestMetadata().DispatchCounter.Load()) // note that it is intended to have the request metadata share the same dispatch counter
-		require.True(t, cloned.GetRequestMetadata().WasThrottled.Load())                // it is intended to share the same was throttled state
+		require.Equal(t, uint32(7), cloned.GetRequestMetadata().ResolutionMetadata.DispatchCount.Load()) // note that it is intended to have the request metadata share the same dispatch counter
+		require.True(t, cloned.GetRequestMetadata().ResolutionMetadata.WasThrottled())                   // it is intended to share the same was throttled state
 		require.Equal(t, map[string]struct{}{
 			"abc": {},
 		}, cloned.VisitedPaths)
+		require.Equal(t, orig.GetPointInTime(), cloned.GetPointInTime())
 	})
 	t.Run("empty_clone", func(t *testing.T) {
 		var orig *ResolveCheckRequest
@@ -13,6 +13,7 @@ func (r *ResolveCheckResponse) clone() *ResolveCheckResponse {
 	if r.GetResolutionMetadata() != nil {
 		resolutionMetadata.DatastoreQueryCount = r.GetResolutionMetadata().DatastoreQueryCount
 		resolutionMetadata.CycleDetected = r.GetResolutionMetadata().CycleDetected
+		resolutionMetadata.ConditionNotMet = r.GetResolutionMetadata().ConditionNotMet
 	}
 
 	return &ResolveCheckResponse{
@@ -0,0 +1,98 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+const (
+	breadthShadowLimit = "breadth"
+	depthShadowLimit   = "depth"
+
+	// shadowLimitLogSampling logs one in this many requests exceeding a shadow limit, starting
+	// with the first one.
+	shadowLimitLogSampling = 100
+)
+
+var shadowLimitExceededCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "check_resolve_node_shadow_limit_exceeded_count",
+	Help:      "The total number of Check resolutions that would have exceeded the shadow resolve node breadth or depth limit, labeled by limit.",
+}, []string{"limit"})
+
+// WithResolveNodeBreadthLimitShadow see server.WithResolveNodeBreadthLimitShadow.
+func WithResolveNodeBreadthLimitShadow(limit uint32) LocalCheckerOption {
+	return func(d *LocalChecker) {
+		d.breadthLimitShadow = limit
+	}
+}
+
+// WithResolveNodeLimitShadow see server.WithResolveNodeLimitShadow.
+func WithResolveNodeLimitShadow(limit uint32) LocalCheckerOption {
+	return func(d *LocalChecker) {
+		d.depthLimitShadow = limit
+	}
+}
+
+// observeBreadth records the resolution of req if the breadth of one of its levels, the number of
+// subproblems evaluated for a single node, exceeds the shadow breadth limit. It never changes the
+// outcome of the resolution, and records each resolution at most once.
+func (c *LocalChecker) observeBreadth(ctx context.Context, req *ResolveCheckRequest, breadth int) {
+	if c.breadthLimitShadow == 0 || breadth <= int(c.breadthLimitShadow) {
+		return
+	}
+
+	requestMetadata := req.GetRequestMetadata()
+	if requestMetadata == nil || requestMetadata.ResolutionMetadata == nil ||
+		!requestMetadata.ResolutionMetadata.breadthShadowLimitExceeded.CompareAndSwap(false, true) {
+		return
+	}
+
+	c.recordShadowLimitExceeded(ctx, req, breadthShadowLimit, c.breadthLimitShadow, uint32(breadth))
+}
+
+// observeDepth records the resolution of req if it reaches a level deeper than the shadow depth
+// limit. Like observeBreadth, it records each resolution at most once.
+func (c *LocalChecker) observeDepth(ctx context.Context, req *ResolveCheckRequest) {
+	requestMetadata := req.GetRequestMetadata()
+	if c.depthLimitShadow == 0 || requestMetadata == nil || requestMetadata.maxDepth == 0 {
+		return
+	}
+
+	// the root of the resolution is at level 1
+	level := requestMetadata.maxDepth - requestMetadata.Depth + 1
+	if level <= c.depthLimitShadow {
+		return
+	}
+
+	if requestMetadata.ResolutionMetadata == nil ||
+		!requestMetadata.ResolutionMetadata.depthShadowLimitExceeded.CompareAndSwap(false, true) {
+		return
+	}
+
+	c.recordShadowLimitExceeded(ctx, req, depthShadowLimit, c.depthLimitShadow, level)
+}
+
+func (c *LocalChecker) recordShadowLimitExceeded(ctx context.Context, req *ResolveCheckRequest, limit string, shadow, observed uint32) {
+	shadowLimitExceededCounter.WithLabelValues(limit).Inc()
+
+	if c.shadowLimitExceededCount.Add(1)%shadowLimitLogSampling != 1 {
+		return
+	}
+
+	objectType, _ := tuple.SplitObject(req.GetTupleKey().GetObject())
+	c.logger.InfoWithContext(ctx, "check resolution exceeded a shadow resolve node limit",
+		zap.String("limit", limit),
+		zap.Uint32("shadow_limit", shadow),
+		zap.Uint32("observed", observed),
+		zap.String("store_id", req.GetStoreID()),
+		zap.String("object_type", objectType),
+		zap.String("relation", req.GetTupleKey().GetRelation()),
+	)
+}
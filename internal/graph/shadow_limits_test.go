@@ -0,0 +1,121 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+func TestResolveNodeShadowLimits(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+	err := ds.Write(context.Background(), storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+		tuple.NewTupleKey("document:1", "viewer", "group:fga#member"),
+		tuple.NewTupleKey("document:1", "viewer", "group:ops#member"),
+		tuple.NewTupleKey("group:ops", "member", "group:sre#member"),
+		tuple.NewTupleKey("group:sre", "member", "user:jon"),
+	})
+	require.NoError(t, err)
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+
+		type user
+
+		type group
+			relations
+				define member: [user, group#member]
+
+		type document
+			relations
+				define owner: [user]
+				define editor: [user]
+				define viewer: [group#member] or owner or editor`)
+	ts, err := typesystem.New(model)
+	require.NoError(t, err)
+
+	ctx := typesystem.ContextWithTypesystem(context.Background(), ts)
+	ctx = storage.ContextWithRelationshipTupleReader(ctx, ds)
+
+	check := func(t *testing.T, opts ...LocalCheckerOption) []observer.LoggedEntry {
+		observerLogger, logs := observer.New(zap.InfoLevel)
+		opts = append(opts, WithLocalCheckerLogger(&logger.ZapLogger{Logger: zap.New(observerLogger)}))
+		checker := NewLocalChecker(opts...)
+		t.Cleanup(checker.Close)
+
+		resp, err := checker.ResolveCheck(ctx, &ResolveCheckRequest{
+			StoreID:              storeID,
+			AuthorizationModelID: model.GetId(),
+			TupleKey:             tuple.NewTupleKey("document:1", "viewer", "user:jon"),
+			RequestMetadata:      NewCheckRequestMetadata(25),
+		})
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+
+		return logs.All()
+	}
+
+	t.Run("breadth_above_the_shadow_limit_is_recorded_once", func(t *testing.T) {
+		before := testutil.ToFloat64(shadowLimitExceededCounter.WithLabelValues(breadthShadowLimit))
+
+		// the union of viewer has 3 operands and the userset of viewer 3 members
+		logs := check(t, WithResolveNodeBreadthLimitShadow(2))
+
+		require.InDelta(t, before+1, testutil.ToFloat64(shadowLimitExceededCounter.WithLabelValues(breadthShadowLimit)), 0)
+		require.Len(t, logs, 1)
+		fields := logs[0].ContextMap()
+		require.Equal(t, breadthShadowLimit, fields["limit"])
+		require.Equal(t, storeID, fields["store_id"])
+		require.Equal(t, "document", fields["object_type"])
+		require.EqualValues(t, 3, fields["observed"])
+	})
+
+	t.Run("breadth_within_the_shadow_limit_is_not_recorded", func(t *testing.T) {
+		before := testutil.ToFloat64(shadowLimitExceededCounter.WithLabelValues(breadthShadowLimit))
+
+		logs := check(t, WithResolveNodeBreadthLimitShadow(3))
+
+		require.InDelta(t, before, testutil.ToFloat64(shadowLimitExceededCounter.WithLabelValues(breadthShadowLimit)), 0)
+		require.Empty(t, logs)
+	})
+
+	t.Run("depth_above_the_shadow_limit_is_recorded", func(t *testing.T) {
+		before := testutil.ToFloat64(shadowLimitExceededCounter.WithLabelValues(depthShadowLimit))
+
+		// group:ops#member is resolved at level 2
+		logs := check(t, WithResolveNodeLimitShadow(1))
+
+		require.InDelta(t, before+1, testutil.ToFloat64(shadowLimitExceededCounter.WithLabelValues(depthShadowLimit)), 0)
+		require.Len(t, logs, 1)
+		fields := logs[0].ContextMap()
+		require.Equal(t, depthShadowLimit, fields["limit"])
+		require.Equal(t, "member", fields["relation"])
+		require.EqualValues(t, 2, fields["observed"])
+	})
+
+	t.Run("disabled_by_default", func(t *testing.T) {
+		before := testutil.ToFloat64(shadowLimitExceededCounter.WithLabelValues(depthShadowLimit))
+
+		logs := check(t)
+
+		require.InDelta(t, before, testutil.ToFloat64(shadowLimitExceededCounter.WithLabelValues(depthShadowLimit)), 0)
+		require.Empty(t, logs)
+	})
+}
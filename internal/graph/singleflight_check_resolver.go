@@ -0,0 +1,169 @@
+package graph
+
+import (
+	"context"
+	"sync"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+var checkDeduplicatedCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "check_deduplicated_count",
+	Help:      "The total number of Check requests that shared the evaluation of an identical concurrent request instead of being evaluated.",
+})
+
+// singleflightContextKey marks the context of the evaluations shared by a
+// SingleflightCheckResolver, so that their subproblems are not deduplicated.
+type singleflightContextKey struct{}
+
+// requestScopedTupleReaderContextKey marks the context of a request whose tuples are read through
+// a reader released when the request completes, e.g. a snapshot.
+type requestScopedTupleReaderContextKey struct{}
+
+// ContextWithRequestScopedTupleReader returns a context marking that the tuples of its requests
+// are read through a reader released when the request completes, such as the transaction of a
+// snapshot, so that a SingleflightCheckResolver evaluates them on their own instead of sharing
+// an evaluation that may outlive the reader.
+func ContextWithRequestScopedTupleReader(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestScopedTupleReaderContextKey{}, struct{}{})
+}
+
+// SingleflightCheckResolver deduplicates identical concurrent Check requests: a request with the
+// same cache key (see [CheckRequestCacheKey]) and consistency preference as a request being
+// evaluated waits for its evaluation and gets a copy of its response, instead of being evaluated.
+//
+// Only the requests that enter the resolver are deduplicated, not the subproblems dispatched
+// to evaluate them, as a subproblem waiting for an evaluation that depends on it would never
+// complete. Point in time requests and requests whose context is marked by
+// [ContextWithRequestScopedTupleReader] are not deduplicated either, as the tuples they read are
+// released when their request completes, which would fail the requests waiting for it. Nor are
+// HIGHER_CONSISTENCY requests, which must see the tuples written before they were received,
+// while an evaluation in flight may have read the tuples before that.
+//
+// The shared evaluation is not canceled with the request that started it, but once every
+// request waiting for it is canceled.
+type SingleflightCheckResolver struct {
+	delegate CheckResolver
+
+	mu      sync.Mutex
+	flights map[string]*checkFlight
+}
+
+var _ CheckResolver = (*SingleflightCheckResolver)(nil)
+
+// checkFlight is an evaluation shared by the requests waiting for it.
+type checkFlight struct {
+	done chan struct{}
+	resp *ResolveCheckResponse
+	err  error
+
+	// waiters is the number of requests waiting for the evaluation, guarded by the mutex of the
+	// SingleflightCheckResolver. The evaluation is canceled when it drops to 0.
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// NewSingleflightCheckResolver constructs a SingleflightCheckResolver.
+func NewSingleflightCheckResolver() *SingleflightCheckResolver {
+	r := &SingleflightCheckResolver{flights: map[string]*checkFlight{}}
+	r.delegate = r
+	return r
+}
+
+// SetDelegate sets this SingleflightCheckResolver's dispatch delegate.
+func (r *SingleflightCheckResolver) SetDelegate(delegate CheckResolver) {
+	r.delegate = delegate
+}
+
+// GetDelegate returns this SingleflightCheckResolver's dispatch delegate.
+func (r *SingleflightCheckResolver) GetDelegate() CheckResolver {
+	return r.delegate
+}
+
+// Close is a no-op, the evaluations in flight complete with their requests.
+func (r *SingleflightCheckResolver) Close() {}
+
+func (r *SingleflightCheckResolver) ResolveCheck(
+	ctx context.Context,
+	req *ResolveCheckRequest,
+) (*ResolveCheckResponse, error) {
+	if ctx.Value(singleflightContextKey{}) != nil || ctx.Value(requestScopedTupleReaderContextKey{}) != nil || !req.GetPointInTime().IsZero() ||
+		req.GetConsistency() == openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY {
+		return r.delegate.ResolveCheck(ctx, req)
+	}
+
+	cacheKey, err := CheckRequestCacheKey(req)
+	if err != nil {
+		telemetry.TraceError(trace.SpanFromContext(ctx), err)
+		return nil, err
+	}
+	key := cacheKey + "/" + req.GetConsistency().String()
+
+	r.mu.Lock()
+	if flight, ok := r.flights[key]; ok {
+		flight.waiters++
+		r.mu.Unlock()
+
+		checkDeduplicatedCounter.Inc()
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("check_deduplicated", true))
+		resp, err := r.wait(ctx, key, flight)
+		if err != nil {
+			return nil, err
+		}
+		// the datastore queries were made by the request that started the evaluation
+		resp.ResolutionMetadata.DatastoreQueryCount = 0
+		return resp, nil
+	}
+
+	flightCtx, cancel := context.WithCancel(context.WithValue(context.WithoutCancel(ctx), singleflightContextKey{}, struct{}{}))
+	flight := &checkFlight{done: make(chan struct{}), waiters: 1, cancel: cancel}
+	r.flights[key] = flight
+	r.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		flight.resp, flight.err = r.delegate.ResolveCheck(flightCtx, req)
+
+		r.mu.Lock()
+		if r.flights[key] == flight {
+			delete(r.flights, key)
+		}
+		r.mu.Unlock()
+		close(flight.done)
+	}()
+
+	return r.wait(ctx, key, flight)
+}
+
+// wait returns a copy of the response of the evaluation, or the error of the context if it is
+// canceled first, in which case the evaluation is canceled if no other request waits for it.
+func (r *SingleflightCheckResolver) wait(ctx context.Context, key string, flight *checkFlight) (*ResolveCheckResponse, error) {
+	select {
+	case <-flight.done:
+		if flight.err != nil {
+			return nil, flight.err
+		}
+		// every request gets its own copy, as callers modify the metadata of the response
+		return flight.resp.clone(), nil
+	case <-ctx.Done():
+		r.mu.Lock()
+		flight.waiters--
+		if flight.waiters == 0 {
+			flight.cancel()
+			// the next identical request starts a new evaluation instead of waiting for this one
+			if r.flights[key] == flight {
+				delete(r.flights, key)
+			}
+		}
+		r.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,242 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestSingleflightCheckResolver(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	newRequest := func() *ResolveCheckRequest {
+		return &ResolveCheckRequest{
+			StoreID:              "store",
+			AuthorizationModelID: "model",
+			TupleKey:             tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+			RequestMetadata:      NewCheckRequestMetadata(10),
+		}
+	}
+
+	// setup returns a resolver whose delegate blocks until release is closed, and signals each
+	// evaluation on started
+	setup := func(t *testing.T) (*SingleflightCheckResolver, *MockCheckResolver, chan context.Context, chan struct{}) {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+
+		started := make(chan context.Context, 100)
+		release := make(chan struct{})
+
+		delegate := NewMockCheckResolver(ctrl)
+		delegate.EXPECT().ResolveCheck(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, req *ResolveCheckRequest) (*ResolveCheckResponse, error) {
+				started <- ctx
+				select {
+				case <-release:
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				return &ResolveCheckResponse{
+					Allowed:            true,
+					ResolutionMetadata: &ResolveCheckResponseMetadata{DatastoreQueryCount: 3},
+				}, nil
+			}).AnyTimes()
+
+		r := NewSingleflightCheckResolver()
+		r.SetDelegate(delegate)
+		t.Cleanup(r.Close)
+		return r, delegate, started, release
+	}
+
+	type result struct {
+		resp *ResolveCheckResponse
+		err  error
+	}
+	resolve := func(ctx context.Context, r *SingleflightCheckResolver, req *ResolveCheckRequest) chan result {
+		done := make(chan result, 1)
+		go func() {
+			resp, err := r.ResolveCheck(ctx, req)
+			done <- result{resp, err}
+		}()
+		return done
+	}
+
+	// waitForWaiters waits until n requests wait for the only evaluation in flight
+	waitForWaiters := func(r *SingleflightCheckResolver, n int) {
+		for {
+			r.mu.Lock()
+			waiters := 0
+			for _, flight := range r.flights {
+				waiters = flight.waiters
+			}
+			r.mu.Unlock()
+			if waiters == n {
+				return
+			}
+		}
+	}
+
+	t.Run("identical_requests_share_an_evaluation", func(t *testing.T) {
+		r, _, started, release := setup(t)
+
+		first := resolve(context.Background(), r, newRequest())
+		<-started
+
+		var results []chan result
+		for i := 0; i < 5; i++ {
+			results = append(results, resolve(context.Background(), r, newRequest()))
+		}
+		waitForWaiters(r, 6)
+		close(release)
+
+		res := <-first
+		require.NoError(t, res.err)
+		require.True(t, res.resp.GetAllowed())
+		require.Equal(t, uint32(3), res.resp.GetResolutionMetadata().DatastoreQueryCount)
+
+		for _, done := range results {
+			res := <-done
+			require.NoError(t, res.err)
+			require.True(t, res.resp.GetAllowed())
+			require.Zero(t, res.resp.GetResolutionMetadata().DatastoreQueryCount)
+		}
+		require.Empty(t, started)
+	})
+
+	t.Run("different_requests_are_not_deduplicated", func(t *testing.T) {
+		r, _, started, release := setup(t)
+
+		higherConsistency := newRequest()
+		higherConsistency.Consistency = openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY
+
+		withContextualTuples := newRequest()
+		withContextualTuples.ContextualTuples = []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:bob")}
+
+		var results []chan result
+		for _, req := range []*ResolveCheckRequest{newRequest(), higherConsistency, withContextualTuples} {
+			results = append(results, resolve(context.Background(), r, req))
+			<-started
+		}
+		close(release)
+
+		for _, done := range results {
+			require.NoError(t, (<-done).err)
+		}
+	})
+
+	t.Run("subproblems_are_not_deduplicated", func(t *testing.T) {
+		r, _, started, release := setup(t)
+
+		ctx := context.WithValue(context.Background(), singleflightContextKey{}, struct{}{})
+		first := resolve(ctx, r, newRequest())
+		second := resolve(ctx, r, newRequest())
+		<-started
+		<-started
+		close(release)
+
+		require.NoError(t, (<-first).err)
+		require.NoError(t, (<-second).err)
+		require.Empty(t, r.flights)
+	})
+
+	t.Run("requests_with_request_scoped_readers_are_not_deduplicated", func(t *testing.T) {
+		r, _, started, release := setup(t)
+
+		ctx := ContextWithRequestScopedTupleReader(context.Background())
+		first := resolve(ctx, r, newRequest())
+		second := resolve(ctx, r, newRequest())
+		<-started
+		<-started
+		close(release)
+
+		require.NoError(t, (<-first).err)
+		require.NoError(t, (<-second).err)
+		require.Empty(t, r.flights)
+	})
+
+	t.Run("higher_consistency_requests_are_not_deduplicated", func(t *testing.T) {
+		r, _, started, release := setup(t)
+
+		req := newRequest()
+		req.Consistency = openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY
+		first := resolve(context.Background(), r, req)
+		second := resolve(context.Background(), r, req)
+		<-started
+		<-started
+		close(release)
+
+		require.NoError(t, (<-first).err)
+		require.NoError(t, (<-second).err)
+		require.Empty(t, r.flights)
+	})
+
+	t.Run("canceled_waiter_does_not_cancel_the_evaluation", func(t *testing.T) {
+		r, _, started, release := setup(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		first := resolve(ctx, r, newRequest())
+		evaluationCtx := <-started
+
+		second := resolve(context.Background(), r, newRequest())
+		waitForWaiters(r, 2)
+
+		cancel()
+		require.ErrorIs(t, (<-first).err, context.Canceled)
+		require.NoError(t, evaluationCtx.Err())
+
+		close(release)
+		res := <-second
+		require.NoError(t, res.err)
+		require.True(t, res.resp.GetAllowed())
+	})
+
+	t.Run("evaluation_canceled_with_the_last_waiter", func(t *testing.T) {
+		r, _, started, _ := setup(t)
+
+		ctx1, cancel1 := context.WithCancel(context.Background())
+		ctx2, cancel2 := context.WithCancel(context.Background())
+		first := resolve(ctx1, r, newRequest())
+		evaluationCtx := <-started
+		second := resolve(ctx2, r, newRequest())
+		waitForWaiters(r, 2)
+
+		cancel1()
+		require.ErrorIs(t, (<-first).err, context.Canceled)
+		cancel2()
+		require.ErrorIs(t, (<-second).err, context.Canceled)
+
+		<-evaluationCtx.Done()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		require.Empty(t, r.flights)
+	})
+
+	t.Run("concurrent_requests", func(t *testing.T) {
+		r, _, _, release := setup(t)
+		close(release)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := r.ResolveCheck(context.Background(), newRequest())
+				if assert.NoError(t, err) {
+					assert.True(t, resp.GetAllowed())
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
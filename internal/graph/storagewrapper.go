@@ -40,6 +40,18 @@ var (
 		Help:      "The total number of cache hits from cached iterator instances.",
 	})
 
+	tuplesCacheMissCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "tuples_cache_miss_count",
+		Help:      "The total number of cache misses from cached iterator instances.",
+	})
+
+	tuplesCacheInvalidationCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "tuples_cache_invalidation_count",
+		Help:      "The total number of object#relation pairs whose cached usersets were invalidated by a write.",
+	})
+
 	tuplesCacheDiscardCounter = promauto.NewCounter(prometheus.CounterOpts{
 		Namespace: build.ProjectName,
 		Name:      "tuples_cache_discard_count",
@@ -72,6 +84,20 @@ type CachedDatastore struct {
 	maxResultSize int
 	ttl           time.Duration
 	sf            *singleflight.Group
+
+	// invalidationsMu protects invalidations and lastPrune.
+	invalidationsMu sync.RWMutex
+	// invalidations maps store/object#relation to the generation of the last write that touched it.
+	// The generation is part of the ReadUsersetTuples cache key, so entries cached before that
+	// write are no longer reachable and simply expire.
+	invalidations  map[string]invalidation
+	lastPrune      time.Time
+	lastGeneration uint64
+}
+
+type invalidation struct {
+	generation uint64
+	at         time.Time
 }
 
 // NewCachedDatastore returns a wrapper over a datastore that caches iterators in memory.
@@ -87,7 +113,75 @@ func NewCachedDatastore(
 		maxResultSize:    maxSize,
 		ttl:              ttl,
 		sf:               &singleflight.Group{},
+		invalidations:    make(map[string]invalidation),
+	}
+}
+
+// Write see [storage.RelationshipTupleWriter].Write. After a successful write, the cached
+// ReadUsersetTuples results of every object#relation touched by the write are invalidated.
+func (c *CachedDatastore) Write(
+	ctx context.Context,
+	store string,
+	deletes storage.Deletes,
+	writes storage.Writes,
+) error {
+	if err := c.OpenFGADatastore.Write(ctx, store, deletes, writes); err != nil {
+		return err
+	}
+
+	objectRelations := make([]string, 0, len(deletes)+len(writes))
+	for _, tk := range deletes {
+		objectRelations = append(objectRelations, invalidationKey(store, tk.GetObject(), tk.GetRelation()))
+	}
+	for _, tk := range writes {
+		objectRelations = append(objectRelations, invalidationKey(store, tk.GetObject(), tk.GetRelation()))
+	}
+
+	c.invalidate(objectRelations...)
+
+	return nil
+}
+
+// invalidate bumps the generation of the given store/object#relation keys.
+func (c *CachedDatastore) invalidate(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	now := time.Now()
+
+	c.invalidationsMu.Lock()
+	defer c.invalidationsMu.Unlock()
+
+	c.lastGeneration++
+	for _, key := range keys {
+		c.invalidations[key] = invalidation{generation: c.lastGeneration, at: now}
+	}
+	tuplesCacheInvalidationCounter.Add(float64(len(keys)))
+
+	// entries cached before now-ttl have expired anyway, so their invalidations are no longer needed
+	if now.Sub(c.lastPrune) < c.ttl {
+		return
+	}
+	for key, inv := range c.invalidations {
+		if now.Sub(inv.at) > c.ttl {
+			delete(c.invalidations, key)
+		}
 	}
+	c.lastPrune = now
+}
+
+// generation returns the generation of the last write to the store/object#relation key, if any.
+func (c *CachedDatastore) generation(key string) (uint64, bool) {
+	c.invalidationsMu.RLock()
+	defer c.invalidationsMu.RUnlock()
+
+	inv, ok := c.invalidations[key]
+	return inv.generation, ok
+}
+
+func invalidationKey(store, object, relation string) string {
+	return tuple.CanonicalKey(store, object, relation)
 }
 
 // ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
@@ -113,19 +207,26 @@ func (c *CachedDatastore) ReadUsersetTuples(
 	}
 
 	var b strings.Builder
-	b.WriteString(
-		fmt.Sprintf("%srut/%s/%s#%s", QueryCachePrefix, store, filter.Object, filter.Relation),
-	)
+	b.WriteString(QueryCachePrefix + "rut/")
+	tuple.AppendCanonicalField(&b, store)
+	tuple.AppendCanonicalField(&b, filter.Object)
+	tuple.AppendCanonicalField(&b, filter.Relation)
+	if generation, ok := c.generation(invalidationKey(store, filter.Object, filter.Relation)); ok {
+		b.WriteString(fmt.Sprintf("/g%d", generation))
+	}
 
 	var rb strings.Builder
 	var wb strings.Builder
 
 	for _, userset := range filter.AllowedUserTypeRestrictions {
 		if _, ok := userset.GetRelationOrWildcard().(*openfgav1.RelationReference_Relation); ok {
-			rb.WriteString(fmt.Sprintf("/%s#%s", userset.GetType(), userset.GetRelation()))
+			rb.WriteString("/")
+			tuple.AppendCanonicalField(&rb, userset.GetType())
+			tuple.AppendCanonicalField(&rb, userset.GetRelation())
 		}
 		if _, ok := userset.GetRelationOrWildcard().(*openfgav1.RelationReference_Wildcard); ok {
-			wb.WriteString(fmt.Sprintf("/%s:*", userset.GetType()))
+			wb.WriteString("/*")
+			tuple.AppendCanonicalField(&wb, userset.GetType())
 		}
 	}
 
@@ -163,11 +264,8 @@ func (c *CachedDatastore) Read(
 		return iter(ctx)
 	}
 
-	var b strings.Builder
-	b.WriteString(
-		fmt.Sprintf("%sr%s/%s", QueryCachePrefix, store, tuple.TupleKeyToString(tupleKey)),
-	)
-	return c.newCachedIterator(ctx, iter, b.String())
+	key := QueryCachePrefix + "r/" + tuple.CanonicalKey(store) + tuple.CanonicalTupleKey(tupleKey)
+	return c.newCachedIterator(ctx, iter, key)
 }
 
 // newCachedIterator either returns a cached static iterator for a cache hit, or
@@ -195,6 +293,7 @@ func (c *CachedDatastore) newCachedIterator(
 		span.SetAttributes(attribute.Bool("cached", true))
 		return storage.NewStaticTupleIterator(cachedResp.Value.([]*openfgav1.Tuple)), nil
 	}
+	tuplesCacheMissCounter.Inc()
 
 	iter, err := dsIterFunc(ctx)
 	if err != nil {
@@ -3,7 +3,9 @@ package graph
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -19,6 +21,7 @@ import (
 
 	"github.com/openfga/openfga/internal/mocks"
 	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
 	"github.com/openfga/openfga/pkg/testutils"
 	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
@@ -196,6 +199,166 @@ func TestReadUsersetTuples(t *testing.T) {
 	})
 }
 
+// readUsersetTuplesCountingDatastore counts the ReadUsersetTuples queries reaching the datastore.
+type readUsersetTuplesCountingDatastore struct {
+	storage.OpenFGADatastore
+	queries atomic.Int64
+}
+
+func (c *readUsersetTuplesCountingDatastore) ReadUsersetTuples(
+	ctx context.Context,
+	store string,
+	filter storage.ReadUsersetTuplesFilter,
+	options storage.ReadUsersetTuplesOptions,
+) (storage.TupleIterator, error) {
+	c.queries.Add(1)
+	return c.OpenFGADatastore.ReadUsersetTuples(ctx, store, filter, options)
+}
+
+func drainIterator(ctx context.Context, t testing.TB, iter storage.TupleIterator) []*openfgav1.Tuple {
+	defer iter.Stop()
+
+	var tuples []*openfgav1.Tuple
+	for {
+		tup, err := iter.Next(ctx)
+		if err != nil {
+			require.ErrorIs(t, err, storage.ErrIteratorDone)
+			return tuples
+		}
+		tuples = append(tuples, tup)
+	}
+}
+
+func TestReadUsersetTuplesWriteInvalidation(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	inner := &readUsersetTuplesCountingDatastore{OpenFGADatastore: memory.New()}
+	cache := storage.NewInMemoryLRUCache[any]()
+	t.Cleanup(cache.Stop)
+
+	ds := NewCachedDatastore(inner, cache, 100, 5*time.Hour)
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+	filter := storage.ReadUsersetTuplesFilter{
+		Object:   "document:1",
+		Relation: "viewer",
+		AllowedUserTypeRestrictions: []*openfgav1.RelationReference{
+			typesystem.DirectRelationReference("group", "member"),
+		},
+	}
+
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "group:1#member"),
+	})
+	require.NoError(t, err)
+
+	iter, err := ds.ReadUsersetTuples(ctx, storeID, filter, storage.ReadUsersetTuplesOptions{})
+	require.NoError(t, err)
+	require.Len(t, drainIterator(ctx, t, iter), 1)
+
+	iter, err = ds.ReadUsersetTuples(ctx, storeID, filter, storage.ReadUsersetTuplesOptions{})
+	require.NoError(t, err)
+	require.Len(t, drainIterator(ctx, t, iter), 1)
+	require.Equal(t, int64(1), inner.queries.Load())
+
+	t.Run("write_to_other_object_relation_keeps_cache", func(t *testing.T) {
+		err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:2", "viewer", "group:1#member"),
+			tuple.NewTupleKey("document:1", "editor", "group:1#member"),
+		})
+		require.NoError(t, err)
+
+		iter, err := ds.ReadUsersetTuples(ctx, storeID, filter, storage.ReadUsersetTuplesOptions{})
+		require.NoError(t, err)
+		require.Len(t, drainIterator(ctx, t, iter), 1)
+		require.Equal(t, int64(1), inner.queries.Load())
+	})
+
+	t.Run("write_to_object_relation_invalidates_cache", func(t *testing.T) {
+		err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "viewer", "group:2#member"),
+		})
+		require.NoError(t, err)
+
+		iter, err := ds.ReadUsersetTuples(ctx, storeID, filter, storage.ReadUsersetTuplesOptions{})
+		require.NoError(t, err)
+		require.Len(t, drainIterator(ctx, t, iter), 2)
+		require.Equal(t, int64(2), inner.queries.Load())
+	})
+
+	t.Run("delete_from_object_relation_invalidates_cache", func(t *testing.T) {
+		err := ds.Write(ctx, storeID, []*openfgav1.TupleKeyWithoutCondition{
+			tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("document:1", "viewer", "group:1#member")),
+		}, nil)
+		require.NoError(t, err)
+
+		iter, err := ds.ReadUsersetTuples(ctx, storeID, filter, storage.ReadUsersetTuplesOptions{})
+		require.NoError(t, err)
+		require.Len(t, drainIterator(ctx, t, iter), 1)
+		require.Equal(t, int64(3), inner.queries.Load())
+	})
+
+	t.Run("higher_consistency_bypasses_cache", func(t *testing.T) {
+		options := storage.ReadUsersetTuplesOptions{
+			Consistency: storage.ConsistencyOptions{Preference: openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY},
+		}
+		iter, err := ds.ReadUsersetTuples(ctx, storeID, filter, options)
+		require.NoError(t, err)
+		require.Len(t, drainIterator(ctx, t, iter), 1)
+		require.Equal(t, int64(4), inner.queries.Load())
+	})
+}
+
+func BenchmarkReadUsersetTuplesHotObject(b *testing.B) {
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+	filter := storage.ReadUsersetTuplesFilter{
+		Object:   "document:hot",
+		Relation: "viewer",
+		AllowedUserTypeRestrictions: []*openfgav1.RelationReference{
+			typesystem.DirectRelationReference("group", "member"),
+		},
+	}
+
+	var tks []*openfgav1.TupleKey
+	for i := 0; i < 20; i++ {
+		tks = append(tks, tuple.NewTupleKey("document:hot", "viewer", fmt.Sprintf("group:%d#member", i)))
+	}
+
+	benchmarks := map[string]func(inner storage.OpenFGADatastore) storage.OpenFGADatastore{
+		"uncached": func(inner storage.OpenFGADatastore) storage.OpenFGADatastore {
+			return inner
+		},
+		"cached": func(inner storage.OpenFGADatastore) storage.OpenFGADatastore {
+			cache := storage.NewInMemoryLRUCache[any]()
+			b.Cleanup(cache.Stop)
+			return NewCachedDatastore(inner, cache, 100, time.Minute)
+		},
+	}
+
+	for name, wrap := range benchmarks {
+		b.Run(name, func(b *testing.B) {
+			inner := &readUsersetTuplesCountingDatastore{OpenFGADatastore: memory.New()}
+			ds := wrap(inner)
+			b.Cleanup(ds.Close)
+
+			require.NoError(b, ds.Write(ctx, storeID, nil, tks))
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				iter, err := ds.ReadUsersetTuples(ctx, storeID, filter, storage.ReadUsersetTuplesOptions{})
+				require.NoError(b, err)
+				drainIterator(ctx, b, iter)
+			}
+			b.ReportMetric(float64(inner.queries.Load())/float64(b.N), "datastore_queries/op")
+		})
+	}
+}
+
 func TestRead(t *testing.T) {
 	ctx := context.Background()
 	t.Cleanup(func() {
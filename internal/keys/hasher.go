@@ -1,6 +1,7 @@
 package keys
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
@@ -8,6 +9,8 @@ import (
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"golang.org/x/exp/maps"
 	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/tuple"
 )
 
 type hasher interface {
@@ -27,9 +30,25 @@ func (s stringHasher) Append(h hasher) error {
 	return h.WriteString(string(s))
 }
 
+// writeField writes the value prefixed by its length (see [tuple.CanonicalKey]), so that the
+// boundary between consecutive fields is unambiguous no matter which delimiters the value contains.
+func writeField(h hasher, value string) error {
+	return h.WriteString(tuple.CanonicalKey(value))
+}
+
+// writeJSONString writes the value as a quoted and escaped JSON string.
+func writeJSONString(h hasher, value string) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return h.WriteString(string(b))
+}
+
 // NewTupleKeysHasher returns a hasher for an array of *openfgav1.TupleKey.
 // It sorts the tuples first to guarantee that two arrays that are identical except for the ordering
-// return the same hash.
+// return the same hash. The condition name and context of each tuple are part of the hash.
 func NewTupleKeysHasher(tupleKeys ...*openfgav1.TupleKey) *tupleKeysHasher {
 	return &tupleKeysHasher{tupleKeys}
 }
@@ -57,29 +76,29 @@ func (t tupleKeysHasher) Append(h hasher) error {
 			return sortedTupleKeys[i].GetUser() < sortedTupleKeys[j].GetUser()
 		}
 
-		return true
+		return sortedTupleKeys[i].GetCondition().GetName() < sortedTupleKeys[j].GetCondition().GetName()
 	})
 
-	// prefix to avoid overlap with previous strings written
-	if err := h.WriteString("/"); err != nil {
+	// prefix with the number of tuples to avoid overlap with previous and next strings written
+	if err := h.WriteString(fmt.Sprintf("/tuples[%d]", len(sortedTupleKeys))); err != nil {
 		return err
 	}
 
-	n := 0
 	for _, tupleKey := range sortedTupleKeys {
-		key := fmt.Sprintf("%s#%s@%s", tupleKey.GetObject(), tupleKey.GetRelation(), tupleKey.GetUser())
-
-		if err := h.WriteString(key); err != nil {
-			return err
-		}
-
-		if n < len(t.tupleKeys)-1 {
-			if err := h.WriteString(","); err != nil {
+		for _, field := range []string{
+			tupleKey.GetObject(),
+			tupleKey.GetRelation(),
+			tupleKey.GetUser(),
+			tupleKey.GetCondition().GetName(),
+		} {
+			if err := writeField(h, field); err != nil {
 				return err
 			}
 		}
 
-		n++
+		if err := NewContextHasher(tupleKey.GetCondition().GetContext()).Append(h); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -87,10 +106,11 @@ func (t tupleKeysHasher) Append(h hasher) error {
 
 // contextHasher represents a hashable protobuf Struct.
 //
-// The contextHasher can be used to generate a stable hash of a protobuf Struct. The fields
-// of the struct are ordered to produce a stable hash, and the values for each struct key
-// are produced using the structValueHasher, which produces a stable hash value for the Struct
-// value.
+// The contextHasher can be used to generate a stable hash of a protobuf Struct. The struct is
+// written as canonical JSON: the fields are ordered by key, strings are quoted and escaped, and
+// numbers are written with the shortest representation that preserves their value. Two structs
+// produce the same hash if and only if they hold the same values of the same kinds. A nil
+// Struct is written as null.
 type contextHasher struct {
 	*structpb.Struct
 }
@@ -105,29 +125,39 @@ var _ hashableValue = (*contextHasher)(nil)
 
 func (c contextHasher) Append(h hasher) error {
 	if c.Struct == nil {
-		return nil
+		return h.WriteString("null")
 	}
 
 	fields := c.GetFields()
 	keys := maps.Keys(fields)
 	sort.Strings(keys)
 
-	for _, key := range keys {
-		if err := h.WriteString(fmt.Sprintf("'%s:'", key)); err != nil {
+	if err := h.WriteString("{"); err != nil {
+		return err
+	}
+
+	for i, key := range keys {
+		if i > 0 {
+			if err := h.WriteString(","); err != nil {
+				return err
+			}
+		}
+
+		if err := writeJSONString(h, key); err != nil {
 			return err
 		}
 
-		valueHasher := structValueHasher{fields[key]}
-		if err := valueHasher.Append(h); err != nil {
+		if err := h.WriteString(":"); err != nil {
 			return err
 		}
 
-		if err := h.WriteString(","); err != nil {
+		valueHasher := structValueHasher{fields[key]}
+		if err := valueHasher.Append(h); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return h.WriteString("}")
 }
 
 // structValueHasher represents a hashable protobuf Struct value.
@@ -140,38 +170,37 @@ type structValueHasher struct {
 var _ hashableValue = (*structValueHasher)(nil)
 
 func (s structValueHasher) Append(h hasher) error {
-	switch val := s.Kind.(type) {
+	switch val := s.GetKind().(type) {
 	case *structpb.Value_BoolValue:
-		return h.WriteString(fmt.Sprintf("%v", val.BoolValue))
-	case *structpb.Value_NullValue:
+		return h.WriteString(strconv.FormatBool(val.BoolValue))
+	case nil, *structpb.Value_NullValue:
 		return h.WriteString("null")
 	case *structpb.Value_StringValue:
-		return h.WriteString(val.StringValue)
+		return writeJSONString(h, val.StringValue)
 	case *structpb.Value_NumberValue:
 		return h.WriteString(strconv.FormatFloat(val.NumberValue, 'f', -1, 64)) // -1 precision ensures we represent the 64-bit value with the maximum precision needed to represent it, see strconv#FormatFloat for more info.
 	case *structpb.Value_ListValue:
-		n := 0
-		values := val.ListValue.GetValues()
-
-		for _, v := range values {
-			valueHasher := structValueHasher{v}
-			if err := valueHasher.Append(h); err != nil {
-				return err
-			}
+		if err := h.WriteString("["); err != nil {
+			return err
+		}
 
-			if n < len(values)-1 {
+		for i, v := range val.ListValue.GetValues() {
+			if i > 0 {
 				if err := h.WriteString(","); err != nil {
 					return err
 				}
 			}
 
-			n++
+			valueHasher := structValueHasher{v}
+			if err := valueHasher.Append(h); err != nil {
+				return err
+			}
 		}
+
+		return h.WriteString("]")
 	case *structpb.Value_StructValue:
 		return contextHasher{val.StructValue}.Append(h)
 	default:
 		panic("unexpected structpb value encountered")
 	}
-
-	return nil
 }
@@ -1,11 +1,13 @@
 package keys
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/cespare/xxhash/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/openfga/openfga/pkg/testutils"
 	"github.com/openfga/openfga/pkg/tuple"
@@ -107,13 +109,14 @@ func TestContextHasher(t *testing.T) {
 			equal: false,
 		},
 		{
+			name: "number_and_string_differ",
 			context1: map[string]any{
 				"x": []any{1},
 			},
 			context2: map[string]any{
 				"x": []any{"1"},
 			},
-			equal: true,
+			equal: false,
 		},
 		{
 			context1: map[string]any{
@@ -169,6 +172,47 @@ func TestContextHasher(t *testing.T) {
 			},
 			equal: false,
 		},
+		{
+			name: "delimiter_in_key",
+			context1: map[string]any{
+				"x": "1",
+				"y": "2",
+			},
+			context2: map[string]any{
+				"x\":\"1\",\"y": "2",
+			},
+			equal: false,
+		},
+		{
+			name: "delimiter_in_list_value",
+			context1: map[string]any{
+				"x": []any{"a,b"},
+			},
+			context2: map[string]any{
+				"x": []any{"a", "b"},
+			},
+			equal: false,
+		},
+		{
+			name: "nested_struct_and_string",
+			context1: map[string]any{
+				"x": map[string]any{"y": "z"},
+			},
+			context2: map[string]any{
+				"x": `{"y":"z"}`,
+			},
+			equal: false,
+		},
+		{
+			name: "bool_and_string",
+			context1: map[string]any{
+				"x": true,
+			},
+			context2: map[string]any{
+				"x": "true",
+			},
+			equal: false,
+		},
 	}
 
 	for _, test := range testCases {
@@ -194,3 +238,105 @@ func TestContextHasher(t *testing.T) {
 		})
 	}
 }
+
+func TestTupleKeysHasherIncludesConditions(t *testing.T) {
+	var testCases = map[string]struct {
+		tuples1 []*openfgav1.TupleKey
+		tuples2 []*openfgav1.TupleKey
+	}{
+		`with_and_without_condition`: {
+			tuples1: []*openfgav1.TupleKey{tuple.NewTupleKey("document:A", "viewer", "user:A")},
+			tuples2: []*openfgav1.TupleKey{tuple.NewTupleKeyWithCondition("document:A", "viewer", "user:A", "cond", nil)},
+		},
+		`different_condition_names`: {
+			tuples1: []*openfgav1.TupleKey{tuple.NewTupleKeyWithCondition("document:A", "viewer", "user:A", "cond1", nil)},
+			tuples2: []*openfgav1.TupleKey{tuple.NewTupleKeyWithCondition("document:A", "viewer", "user:A", "cond2", nil)},
+		},
+		`different_condition_contexts`: {
+			tuples1: []*openfgav1.TupleKey{tuple.NewTupleKeyWithCondition("document:A", "viewer", "user:A", "cond",
+				testutils.MustNewStruct(t, map[string]any{"x": 1}))},
+			tuples2: []*openfgav1.TupleKey{tuple.NewTupleKeyWithCondition("document:A", "viewer", "user:A", "cond",
+				testutils.MustNewStruct(t, map[string]any{"x": 2}))},
+		},
+		`delimiter_in_user`: {
+			tuples1: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:A", "viewer", "user:A"),
+				tuple.NewTupleKey("document:B", "viewer", "user:B"),
+			},
+			tuples2: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:A", "viewer", "user:A,document:B#viewer@user:B"),
+			},
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			hasher1 := NewCacheKeyHasher(xxhash.New())
+			require.NoError(t, NewTupleKeysHasher(test.tuples1...).Append(hasher1))
+
+			hasher2 := NewCacheKeyHasher(xxhash.New())
+			require.NoError(t, NewTupleKeysHasher(test.tuples2...).Append(hasher2))
+
+			require.NotEqual(t, hasher1.Key().ToUInt64(), hasher2.Key().ToUInt64())
+		})
+	}
+}
+
+// recordingHasher records the canonical encoding instead of hashing it, so that
+// collisions of the encoding itself can be detected.
+type recordingHasher struct {
+	strings.Builder
+}
+
+func (r *recordingHasher) WriteString(value string) error {
+	_, err := r.Builder.WriteString(value)
+	return err
+}
+
+func FuzzTupleKeysHasher(f *testing.F) {
+	f.Add("document:1", "viewer", "user:jon", "cond", "x", "1", "document:1", "viewer", "user:jon", "cond", "x", "1")
+	f.Add("document:1", "viewer", "user:jon", "", "", "", "document:1", "viewer#user", "jon", "", "", "")
+	f.Add("document:1", "viewer", "user:jon", "cond", "x", "1,y:2", "document:1", "viewer", "user:jon", "cond", "x\":\"1\",\"y", "2")
+
+	f.Fuzz(func(t *testing.T,
+		object1, relation1, user1, condition1, contextKey1, contextValue1,
+		object2, relation2, user2, condition2, contextKey2, contextValue2 string,
+	) {
+		encode := func(object, relation, user, condition, contextKey, contextValue string) string {
+			var conditionContext *structpb.Struct
+			if contextKey != "" {
+				var err error
+				conditionContext, err = structpb.NewStruct(map[string]any{contextKey: contextValue})
+				if err != nil {
+					t.Skip("invalid UTF-8 in context")
+				}
+			}
+
+			h := &recordingHasher{}
+			tk := tuple.NewTupleKeyWithCondition(object, relation, user, condition, conditionContext)
+			require.NoError(t, NewTupleKeysHasher(tk).Append(h))
+			return h.String()
+		}
+
+		// a context is only recorded for a named condition, and a value only for a named key
+		if condition1 == "" {
+			contextKey1 = ""
+		}
+		if contextKey1 == "" {
+			contextValue1 = ""
+		}
+		if condition2 == "" {
+			contextKey2 = ""
+		}
+		if contextKey2 == "" {
+			contextValue2 = ""
+		}
+
+		encoded1 := encode(object1, relation1, user1, condition1, contextKey1, contextValue1)
+		encoded2 := encode(object2, relation2, user2, condition2, contextKey2, contextValue2)
+
+		sameInput := object1 == object2 && relation1 == relation2 && user1 == user2 &&
+			condition1 == condition2 && contextKey1 == contextKey2 && contextValue1 == contextValue2
+		require.Equal(t, sameInput, encoded1 == encoded2)
+	})
+}
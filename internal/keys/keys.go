@@ -24,6 +24,12 @@ func (c *cacheKeyHasher) WriteString(value string) error {
 	return nil
 }
 
+// WriteField writes the provided string to the hash prefixed by its length, so that
+// consecutive fields cannot be confused with each other whatever characters they contain.
+func (c *cacheKeyHasher) WriteField(value string) error {
+	return writeField(c, value)
+}
+
 // Key returns the stableCacheKey that this key hash defines.
 func (c cacheKeyHasher) Key() stableCacheKey {
 	return stableCacheKey{
@@ -619,18 +619,19 @@ func (m *MockAssertionsBackend) EXPECT() *MockAssertionsBackendMockRecorder {
 }
 
 // ReadAssertions mocks base method.
-func (m *MockAssertionsBackend) ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error) {
+func (m *MockAssertionsBackend) ReadAssertions(ctx context.Context, store, modelID string, options storage.ReadAssertionsOptions) ([]*openfgav1.Assertion, []byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadAssertions", ctx, store, modelID)
+	ret := m.ctrl.Call(m, "ReadAssertions", ctx, store, modelID, options)
 	ret0, _ := ret[0].([]*openfgav1.Assertion)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].([]byte)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // ReadAssertions indicates an expected call of ReadAssertions.
-func (mr *MockAssertionsBackendMockRecorder) ReadAssertions(ctx, store, modelID any) *gomock.Call {
+func (mr *MockAssertionsBackendMockRecorder) ReadAssertions(ctx, store, modelID, options any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadAssertions", reflect.TypeOf((*MockAssertionsBackend)(nil).ReadAssertions), ctx, store, modelID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadAssertions", reflect.TypeOf((*MockAssertionsBackend)(nil).ReadAssertions), ctx, store, modelID, options)
 }
 
 // WriteAssertions mocks base method.
@@ -855,18 +856,19 @@ func (mr *MockOpenFGADatastoreMockRecorder) Read(ctx, store, tupleKey, options a
 }
 
 // ReadAssertions mocks base method.
-func (m *MockOpenFGADatastore) ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error) {
+func (m *MockOpenFGADatastore) ReadAssertions(ctx context.Context, store, modelID string, options storage.ReadAssertionsOptions) ([]*openfgav1.Assertion, []byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadAssertions", ctx, store, modelID)
+	ret := m.ctrl.Call(m, "ReadAssertions", ctx, store, modelID, options)
 	ret0, _ := ret[0].([]*openfgav1.Assertion)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].([]byte)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // ReadAssertions indicates an expected call of ReadAssertions.
-func (mr *MockOpenFGADatastoreMockRecorder) ReadAssertions(ctx, store, modelID any) *gomock.Call {
+func (mr *MockOpenFGADatastoreMockRecorder) ReadAssertions(ctx, store, modelID, options any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadAssertions", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadAssertions), ctx, store, modelID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadAssertions", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadAssertions), ctx, store, modelID, options)
 }
 
 // ReadAuthorizationModel mocks base method.
@@ -15,29 +15,65 @@ import (
 )
 
 const (
-	DefaultMaxRPCMessageSizeInBytes         = 512 * 1_204 // 512 KB
-	DefaultMaxTuplesPerWrite                = 100
-	DefaultMaxTypesPerAuthorizationModel    = 100
-	DefaultMaxAuthorizationModelSizeInBytes = 256 * 1_024
-	DefaultMaxAuthorizationModelCacheSize   = 100000
-	DefaultChangelogHorizonOffset           = 0
-	DefaultResolveNodeLimit                 = 25
-	DefaultResolveNodeBreadthLimit          = 100
-	DefaultUsersetBatchSize                 = 1000
-	DefaultListObjectsDeadline              = 3 * time.Second
-	DefaultListObjectsMaxResults            = 1000
-	DefaultMaxConcurrentReadsForCheck       = math.MaxUint32
-	DefaultMaxConcurrentReadsForListObjects = math.MaxUint32
-	DefaultListUsersDeadline                = 3 * time.Second
-	DefaultListUsersMaxResults              = 1000
-	DefaultMaxConcurrentReadsForListUsers   = math.MaxUint32
+	DefaultMaxRPCMessageSizeInBytes            = 512 * 1_204 // 512 KB
+	DefaultMaxTuplesPerWrite                   = 100
+	DefaultMaxTypesPerAuthorizationModel       = 100
+	DefaultMaxAuthorizationModelSizeInBytes    = 256 * 1_024
+	DefaultMaxReadResponseSizeInBytes          = 3 * 1_024 * 1_024 // 3 MB, below the 4 MB default limit of gRPC clients
+	DefaultMaxAuthorizationModelComplexity     = 0
+	DefaultMaxAuthorizationModelTypes          = 0
+	DefaultMaxAuthorizationModelRelations      = 0
+	DefaultMaxAuthorizationModelRewriteDepth   = 0
+	DefaultAuthorizationModelValidationTimeout = time.Second
+	// DefaultAuthorizationModelValidationMaxVisits is well above the few hundred rewrite nodes
+	// that the validation of real-world models visits.
+	DefaultAuthorizationModelValidationMaxVisits = 100_000
+	DefaultModelImpactAnalysisMaxProbes          = 100
+	DefaultMaxAuthorizationModelCacheSize        = 100000
+	DefaultChangelogHorizonOffset                = 0
+	DefaultReadChangesWatchPollInterval          = time.Second
+	DefaultReadChangesWatchHeartbeatInterval     = 30 * time.Second
+	DefaultReadChangesWatchMaxStreams            = 100
+	DefaultResolveNodeLimit                      = 25
+	DefaultResolveNodeBreadthLimit               = 100
+	DefaultResolverWorkerPoolEnabled             = false
+	DefaultResolverWorkerPoolSize                = 0
+	DefaultUsersetBatchSize                      = 1000
+	DefaultListObjectsDeadline                   = 3 * time.Second
+	DefaultListObjectsMaxResults                 = 1000
+	DefaultListObjectsMaxCandidates              = 0
+	DefaultListObjectsStrategy                   = "auto"
+	DefaultListObjectsStreamMaxModelLag          = 0
+	DefaultListObjectsStreamModelCheckInterval   = time.Second
+	DefaultMaxStreamDuration                     = 0
+	DefaultExpandMaxLeafUsers                    = 0
+	DefaultExpandMaxNodes                        = 0
+	DefaultRelationStatisticsMaxSampleSize       = 10000
+	DefaultRelationStatisticsDeadline            = 2 * time.Second
+	DefaultRelationUsageDeadline                 = 10 * time.Second
+	DefaultDeleteTuplesByFilterMaxTuples         = 10000
+	DefaultDeleteTuplesByFilterMaxScanned        = 100000
+	DefaultPointInTimeCheckHorizon               = 7 * 24 * time.Hour
+	DefaultPointInTimeCheckMaxChanges            = 100000
+	DefaultErrorReasonDetails                    = false
+	DefaultMaxConcurrentReadsForCheck            = math.MaxUint32
+	DefaultSnapshotReadsForCheck                 = false
+	DefaultMaxChecksPerBatchCheck                = 50
+	DefaultMaxConcurrentReadsForListObjects      = math.MaxUint32
+	DefaultListUsersDeadline                     = 3 * time.Second
+	DefaultListUsersMaxResults                   = 1000
+	DefaultMaxConcurrentReadsForListUsers        = math.MaxUint32
+	DefaultMaxConcurrentReadsForExpand           = math.MaxUint32
+	DefaultMaxConcurrentReadsForRead             = math.MaxUint32
+	DefaultHTTPMaxBodySizeInBytes                = DefaultMaxRPCMessageSizeInBytes
 
 	DefaultWriteContextByteLimit = 32 * 1_024 // 32KB
 
 	DefaultCacheLimit = 10000
 
-	DefaultCheckQueryCacheEnabled = false
-	DefaultCheckQueryCacheTTL     = 10 * time.Second
+	DefaultCheckQueryCacheEnabled        = false
+	DefaultCheckQueryCacheTTL            = 10 * time.Second
+	DefaultCheckQueryCacheResponseHeader = false
 
 	DefaultCheckIteratorCacheEnabled    = false
 	DefaultCheckIteratorCacheMaxResults = 10000
@@ -61,6 +97,29 @@ const (
 	DefaultListUsersDispatchThrottlingDefaultThreshold = 100
 	DefaultListUsersDispatchThrottlingMaxThreshold     = 0 // 0 means use the default threshold as max
 
+	DefaultRequestPriorityEnabled             = false
+	DefaultRequestPriorityMaxDeprioritization = 50
+
+	DefaultRequestOptionsStrict = false
+
+	DefaultSessionAffinityEnabled     = false
+	DefaultSessionAffinityWindow      = 5 * time.Second
+	DefaultSessionAffinityMaxSessions = 10000
+
+	DefaultMembershipIndexPollInterval = time.Second
+	DefaultMembershipIndexMaxStaleness = 10 * time.Second
+	DefaultMembershipIndexMaxStores    = 1000
+	DefaultMembershipIndexIdleTimeout  = 10 * time.Minute
+
+	DefaultCheckFairReadSharingEnabled = false
+
+	DefaultDatastoreHedgingEnabled       = false
+	DefaultDatastoreHedgingMinDelay      = 5 * time.Millisecond
+	DefaultDatastoreHedgingPercentile    = 95
+	DefaultDatastoreHedgingMaxPerRequest = 10
+
+	DefaultChangelogMetricsInterval = 0
+
 	DefaultRequestTimeout     = 3 * time.Second
 	additionalUpstreamTimeout = 3 * time.Second
 )
@@ -70,6 +129,19 @@ type DatastoreMetricsConfig struct {
 	Enabled bool
 }
 
+// DatastoreHedgingConfig defines configuration for hedging the datastore reads of Check,
+// ListObjects and ListUsers.
+type DatastoreHedgingConfig struct {
+	// Enabled issues a second read when a read has not returned within the hedging delay, which is
+	// the Percentile of the latency of the latest reads, and never less than MinDelay.
+	Enabled    bool
+	MinDelay   time.Duration
+	Percentile float64
+
+	// MaxPerRequest bounds the number of hedged reads of a request.
+	MaxPerRequest uint32
+}
+
 // DatastoreConfig defines OpenFGA server configurations for datastore specific settings.
 type DatastoreConfig struct {
 	// Engine is the datastore engine to use (e.g. 'memory', 'postgres', 'mysql', 'sqlite')
@@ -94,8 +166,22 @@ type DatastoreConfig struct {
 	// ConnMaxLifetime is the maximum amount of time a connection to the datastore may be reused.
 	ConnMaxLifetime time.Duration
 
+	// ChangelogMode controls how SQL datastores write changelog entries: 'sync', 'async' or 'disabled'.
+	ChangelogMode string
+
+	// QueryShapeSampling makes SQL datastores count their tuple queries by method and filtered
+	// columns, to recommend indexes through the index advice admin endpoint.
+	QueryShapeSampling bool
+
+	// TupleCounts makes SQL datastores maintain the number of tuples of each store and object type
+	// in every Write, which the store statistics and tuple quotas read.
+	TupleCounts bool
+
 	// Metrics is configuration for the Datastore metrics.
 	Metrics DatastoreMetricsConfig
+
+	// Hedging is configuration for hedging the datastore reads.
+	Hedging DatastoreHedgingConfig
 }
 
 // GRPCConfig defines OpenFGA server configurations for grpc server specific settings.
@@ -116,6 +202,16 @@ type HTTPConfig struct {
 
 	CORSAllowedOrigins []string
 	CORSAllowedHeaders []string
+
+	// MaxBodySizeInBytes is the maximum size of the request bodies accepted by the HTTP server.
+	// RouteMaxBodySizesInBytes overrides it per route, keyed by the last literal segment of the
+	// route path, e.g. "check" or "write". 0 disables the limit.
+	MaxBodySizeInBytes       int
+	RouteMaxBodySizesInBytes map[string]int
+
+	// StrictJSON rejects the bodies of Write, WriteAuthorizationModel and WriteAssertions requests
+	// that have unknown fields, instead of ignoring the fields.
+	StrictJSON bool
 }
 
 // TLSConfig defines configuration specific to Transport Layer Security (TLS) settings.
@@ -190,17 +286,116 @@ type ProfilerConfig struct {
 	Addr    string
 }
 
+// AdminConfig defines server configurations specific to the administration endpoints, e.g. the
+// refresh of the cached models of a store. All the endpoints but the reachable relations, which
+// every client of the API can read, are only served to loopback callers, and the endpoints that
+// delete data also require the Token as a bearer token.
+type AdminConfig struct {
+	Enabled bool
+	Addr    string
+	Token   string `json:"-"` // private field, won't be logged
+}
+
 // MetricConfig defines configurations for serving custom metrics from OpenFGA.
 type MetricConfig struct {
 	Enabled             bool
 	Addr                string
 	EnableRPCHistograms bool
+
+	// PerStoreWriteAllowlist is the list of store IDs whose tuple writes and deletes are
+	// counted under their own store_id label. All other stores are counted under "other".
+	PerStoreWriteAllowlist []string
+
+	// PerStoreModelComplexityAllowlist is the list of store IDs whose authorization model
+	// complexity score is reported by a gauge labeled with the store ID.
+	PerStoreModelComplexityAllowlist []string
+
+	// ChangelogInterval is how often the changelog gauges are computed from the datastore. If 0,
+	// the default, they are disabled.
+	ChangelogInterval time.Duration
+
+	// PerStoreChangelogAllowlist is the list of store IDs whose newest change age is reported by
+	// a gauge labeled with the store ID.
+	PerStoreChangelogAllowlist []string
+
+	// PerStoreReadWaitAllowlist is the list of store IDs whose Check reads waiting for their share
+	// of the concurrent reads are reported under their own store_id label. All other stores are
+	// reported under "other".
+	PerStoreReadWaitAllowlist []string
 }
 
 // CheckQueryCache defines configuration for caching when resolving check.
 type CheckQueryCache struct {
 	Enabled bool
 	TTL     time.Duration
+	// ResponseHeader makes Check report whether its result was served from the cache, and how
+	// old the cached result was, in a response header.
+	ResponseHeader bool
+}
+
+// SessionAffinityConfig defines configuration for the read-your-writes consistency of the clients
+// that wrote to a store.
+type SessionAffinityConfig struct {
+	// Enabled upgrades the Check, Read and ListObjects requests, and the datastore reads, of a
+	// client that wrote tuples to a store to HIGHER_CONSISTENCY within the window.
+	Enabled bool
+	Window  time.Duration
+
+	// MaxSessions bounds the number of pairs of client and store whose writes are remembered.
+	MaxSessions uint32
+}
+
+// CheckFairReadSharingConfig defines configuration for sharing the concurrent reads of Check
+// fairly among the stores.
+type CheckFairReadSharingConfig struct {
+	// Enabled makes MaxConcurrentReadsForCheck a limit of the concurrent reads of all the Check
+	// requests, shared among the stores by weight, instead of a limit of each request.
+	Enabled bool
+
+	// StoreWeights are the weights of the stores, by store ID. Stores without a weight have a
+	// weight of 1.
+	StoreWeights map[string]int
+}
+
+// TupleQuotaConfig defines configuration for limiting the number of tuples of the stores. 0
+// means no limit.
+type TupleQuotaConfig struct {
+	// MaxTuples is the maximum number of tuples of each store.
+	MaxTuples int
+
+	// MaxTuplesPerObjectType is the maximum number of tuples of each object type of each store,
+	// unless MaxTuplesByObjectType sets another for the type.
+	MaxTuplesPerObjectType int
+
+	// MaxTuplesByObjectType is the maximum number of tuples of the given object types of each
+	// store.
+	MaxTuplesByObjectType map[string]int
+
+	// StoreMaxTuples overrides MaxTuples for the given store IDs.
+	StoreMaxTuples map[string]int
+}
+
+// MembershipIndexConfig defines configuration for the membership index that Check consults for
+// the members of some relations before resolving them.
+type MembershipIndexConfig struct {
+	// Relations are the indexed relations, as `objectType#relation`. The index is disabled if
+	// there are none.
+	Relations []string
+
+	// PollInterval is how often the index reads the new changes of the indexed stores.
+	PollInterval time.Duration
+
+	// MaxStaleness is how long after it last caught up with the changelog of a store the index
+	// still answers the Check sub-problems of the store.
+	MaxStaleness time.Duration
+
+	// MaxStores is how many stores the index keeps. The least recently looked up store is
+	// dropped to index another one.
+	MaxStores int
+
+	// IdleTimeout is how long after the last Check that consulted the index for a store the
+	// index drops the store.
+	IdleTimeout time.Duration
 }
 
 type CacheConfig struct {
@@ -220,6 +415,28 @@ type DispatchThrottlingConfig struct {
 	MaxThreshold uint32
 }
 
+// RequestPriorityConfig defines configurations for request priority classes.
+type RequestPriorityConfig struct {
+	// Enabled enables reading the priority of each request from the 'Openfga-Request-Priority' header.
+	Enabled bool
+
+	// MaxDeprioritization is the percentage (0-100) by which dispatch throttling thresholds are
+	// lowered for low priority requests.
+	MaxDeprioritization uint32
+}
+
+// RequestOptionsConfig defines configurations for the options that clients set on requests with
+// 'Openfga-*' request headers.
+type RequestOptionsConfig struct {
+	// Strict rejects the requests with 'Openfga-*' request headers unknown to the server, e.g. a
+	// misspelled option.
+	Strict bool
+
+	// PrivilegedSubjects are the authenticated subjects allowed to set the privileged options, e.g.
+	// 'Openfga-No-Model-Cache', or '*' for every client.
+	PrivilegedSubjects []string
+}
+
 type Config struct {
 	// If you change any of these settings, please update the documentation at
 	// https://github.com/openfga/openfga.dev/blob/main/docs/content/intro/setup-openfga.mdx
@@ -234,6 +451,61 @@ type Config struct {
 	// This is to protect the server from misuse of the ListObjects endpoints.
 	ListObjectsMaxResults uint32
 
+	// ListObjectsMaxCandidates defines the maximum number of distinct candidate objects that
+	// a single ListObjects or StreamedListObjects request may hold in memory while expanding
+	// the relationship graph. If exceeded, the request fails with a ResourceExhausted error.
+	// 0 means no limit.
+	ListObjectsMaxCandidates uint32
+
+	// ListObjectsStrategy is the way ListObjects finds objects: 'auto' picks it from the shape of
+	// the requested relation, 'direct', 'reverse_expand', 'check_candidates' or 'filtered_base'
+	// force it. 'direct' only applies to directly assigned relations and 'filtered_base' to
+	// exclusions and intersections of computed relations, and both fall back to 'reverse_expand'.
+	ListObjectsStrategy string
+
+	// ListObjectsStreamMaxModelLag is the number of authorization models that can be written
+	// during a StreamedListObjects stream of the latest model before the stream is aborted, as
+	// checked every ListObjectsStreamModelCheckInterval. 0 disables the check.
+	ListObjectsStreamMaxModelLag        uint32
+	ListObjectsStreamModelCheckInterval time.Duration
+
+	// MaxStreamDuration is the maximum duration of the streams of the streaming APIs, after which
+	// they end gracefully with a truncation indicator in their trailers. 0 means no limit.
+	MaxStreamDuration time.Duration
+
+	// ExpandMaxLeafUsers and ExpandMaxNodes define the maximum number of leaf users and of
+	// nodes in the tree returned by Expand. When exceeded, the tree is truncated, or the
+	// request fails with a ResourceExhausted error if ExpandStrictResultLimit is set.
+	// 0 means no limit.
+	ExpandMaxLeafUsers      uint32
+	ExpandMaxNodes          uint32
+	ExpandStrictResultLimit bool
+
+	// RelationStatisticsMaxSampleSize and RelationStatisticsDeadline bound the datastore reads
+	// of a single GetRelationStatistics call.
+	RelationStatisticsMaxSampleSize uint32
+	RelationStatisticsDeadline      time.Duration
+
+	// RelationUsageDeadline is the timeout deadline of a single GetRelationUsage call.
+	RelationUsageDeadline time.Duration
+
+	// DeleteTuplesByFilterMaxTuples is the maximum number of tuples deleted by a single
+	// DeleteTuplesByFilter call. 0 means no limit.
+	DeleteTuplesByFilterMaxTuples uint32
+
+	// DeleteTuplesByFilterMaxScanned is the maximum number of tuples read by a single
+	// DeleteTuplesByFilter call to find the ones selected by its filter. 0 means no limit.
+	DeleteTuplesByFilterMaxScanned uint32
+
+	// PointInTimeCheckHorizon and PointInTimeCheckMaxChanges bound how far back a point-in-time
+	// Check can be evaluated, and the number of changelog entries read to evaluate it.
+	PointInTimeCheckHorizon    time.Duration
+	PointInTimeCheckMaxChanges uint32
+
+	// ErrorReasonDetails adds to the errors of the Write, Check, ListObjects and ListUsers APIs an
+	// ErrorInfo detail whose reason tells model, tuple, condition context and limit errors apart.
+	ErrorReasonDetails bool
+
 	// ListUsersDeadline defines the maximum amount of time to accumulate ListUsers results
 	// before the server will respond. This is to protect the server from misuse of the
 	// ListUsers endpoints. It cannot be larger than the configured server's request timeout (RequestTimeout or HTTPConfig.UpstreamTimeout).
@@ -252,9 +524,35 @@ type Config struct {
 	MaxTypesPerAuthorizationModel int
 
 	// MaxAuthorizationModelSizeInBytes defines the maximum size in bytes allowed for
-	// persisting an Authorization Model.
+	// persisting an Authorization Model, both in the request and as stored.
 	MaxAuthorizationModelSizeInBytes int
 
+	// MaxAuthorizationModelComplexity defines the maximum complexity score of the
+	// authorization models accepted by WriteAuthorizationModel. 0 means no limit.
+	MaxAuthorizationModelComplexity int
+
+	// MaxAuthorizationModelTypes, MaxAuthorizationModelRelationsPerType and
+	// MaxAuthorizationModelRewriteDepth define the maximum number of types, of relations of a
+	// type and the maximum depth of the rewrite of a relation of the authorization models
+	// accepted by WriteAuthorizationModel. 0 means no limit.
+	MaxAuthorizationModelTypes            int
+	MaxAuthorizationModelRelationsPerType int
+	MaxAuthorizationModelRewriteDepth     int
+
+	// AuthorizationModelValidationTimeout defines the maximum time that WriteAuthorizationModel
+	// spends validating a model before rejecting it as too complex to validate. 0 means no limit.
+	AuthorizationModelValidationTimeout time.Duration
+
+	// AuthorizationModelValidationMaxVisits defines the maximum number of rewrite nodes that the
+	// validation of a model by WriteAuthorizationModel visits before rejecting it as too complex
+	// to validate. 0 means no limit. Models already written are loaded without limits.
+	AuthorizationModelValidationMaxVisits int
+
+	// ModelImpactAnalysisMaxProbes defines the maximum number of tuple reads of the impact
+	// analysis that WriteAuthorizationModel runs on request, before writing a model, to find the
+	// existing tuples that the model makes invalid.
+	ModelImpactAnalysisMaxProbes int
+
 	// MaxConcurrentReadsForListObjects defines the maximum number of concurrent database reads
 	// allowed in ListObjects queries
 	MaxConcurrentReadsForListObjects uint32
@@ -263,10 +561,31 @@ type Config struct {
 	// Check queries
 	MaxConcurrentReadsForCheck uint32
 
+	// SnapshotReadsForCheck evaluates each Check request against one consistent snapshot of the
+	// tuples. The SQL datastores hold up to MaxConcurrentReadsForCheck read-only transactions for
+	// each Check request.
+	SnapshotReadsForCheck bool
+
+	// MaxChecksPerBatchCheck defines the maximum number of checks of a BatchCheck request
+	MaxChecksPerBatchCheck uint32
+
 	// MaxConcurrentReadsForListUsers defines the maximum number of concurrent database reads
 	// allowed in ListUsers queries
 	MaxConcurrentReadsForListUsers uint32
 
+	// MaxConcurrentReadsForExpand defines the maximum number of concurrent database reads
+	// allowed for all the Expand queries of the server together
+	MaxConcurrentReadsForExpand uint32
+
+	// MaxConcurrentReadsForRead defines the maximum number of concurrent database reads
+	// allowed for all the Read queries of the server together
+	MaxConcurrentReadsForRead uint32
+
+	// MaxReadResponseSizeInBytes is the approximate maximum size of the responses of Read and
+	// ReadChanges. A page that would exceed it ends early, with a continuation token at the first
+	// tuple or change left out. 0 disables the limit.
+	MaxReadResponseSizeInBytes int
+
 	// MaxConditionEvaluationCost defines the maximum cost for CEL condition evaluation before a request returns an error
 	MaxConditionEvaluationCost uint64
 
@@ -274,9 +593,47 @@ type Config struct {
 	// after this offset will not be included in the response of ReadChanges.
 	ChangelogHorizonOffset int
 
+	// ReadChangesWatchPollInterval is how often the streams of StreamedReadChanges read the
+	// changelog for new changes once they have read all of them, and
+	// ReadChangesWatchHeartbeatInterval how long they can go without sending a response before
+	// they send an empty one. 0 disables the heartbeats.
+	ReadChangesWatchPollInterval      time.Duration
+	ReadChangesWatchHeartbeatInterval time.Duration
+
+	// ReadChangesWatchMaxStreams is the maximum number of streams of StreamedReadChanges the
+	// server serves at once. 0 means no limit.
+	ReadChangesWatchMaxStreams uint32
+
 	// Experimentals is a list of the experimental features to enable in the OpenFGA server.
 	Experimentals []string
 
+	// ReadOnly makes the server serve only the read APIs, e.g. when the datastore is a read replica.
+	ReadOnly bool
+
+	// ListStoresExactTotalCount makes ListStores count the stores exactly instead of with an
+	// estimate when a request asks for the number of stores.
+	ListStoresExactTotalCount bool
+
+	// UniqueStoreNames makes CreateStore reject the names that another store already has.
+	UniqueStoreNames bool
+
+	// RejectWritesOnStaleModel makes Write reject the requests evaluated with an authorization
+	// model other than the latest of the store.
+	RejectWritesOnStaleModel bool
+
+	// ReadChangesHorizonHeaders makes ReadChanges return the changelog horizon and the time of
+	// the newest change withheld by it in response headers.
+	ReadChangesHorizonHeaders bool
+
+	// CheckResponseMetadataHeaders makes Check and ListObjects return the number of dispatches
+	// and datastore queries of the request, and Check whether its result was a cache hit, in
+	// response headers.
+	CheckResponseMetadataHeaders bool
+
+	// CheckDeduplicationEnabled makes identical concurrent Check requests share a single
+	// evaluation instead of each being evaluated.
+	CheckDeduplicationEnabled bool
+
 	// ResolveNodeLimit indicates how deeply nested an authorization model can be before a query
 	// errors out.
 	ResolveNodeLimit uint32
@@ -285,6 +642,20 @@ type Config struct {
 	// concurrently in a query
 	ResolveNodeBreadthLimit uint32
 
+	// ResolveNodeLimitShadow and ResolveNodeBreadthLimitShadow are candidate values of
+	// ResolveNodeLimit and ResolveNodeBreadthLimit that are measured but not enforced. 0 disables
+	// them.
+	ResolveNodeLimitShadow        uint32
+	ResolveNodeBreadthLimitShadow uint32
+
+	// ResolverWorkerPoolEnabled makes the concurrent subproblems of Check and ListObjects run on a
+	// pool of long-lived goroutines instead of a goroutine per subproblem.
+	ResolverWorkerPoolEnabled bool
+
+	// ResolverWorkerPoolSize is the number of goroutines of the resolver worker pool. 0 sizes the
+	// pool to GOMAXPROCS times ResolveNodeBreadthLimit.
+	ResolverWorkerPoolSize int
+
 	// RequestTimeout configures request timeout.  If both HTTP upstream timeout and request timeout are specified,
 	// request timeout will be prioritized
 	RequestTimeout time.Duration
@@ -297,6 +668,7 @@ type Config struct {
 	Trace                         TraceConfig
 	Playground                    PlaygroundConfig
 	Profiler                      ProfilerConfig
+	Admin                         AdminConfig
 	Metrics                       MetricConfig
 	Cache                         CacheConfig
 	CheckIteratorCache            CheckIteratorCacheConfig
@@ -305,6 +677,12 @@ type Config struct {
 	CheckDispatchThrottling       DispatchThrottlingConfig
 	ListObjectsDispatchThrottling DispatchThrottlingConfig
 	ListUsersDispatchThrottling   DispatchThrottlingConfig
+	RequestPriority               RequestPriorityConfig
+	RequestOptions                RequestOptionsConfig
+	SessionAffinity               SessionAffinityConfig
+	MembershipIndex               MembershipIndexConfig
+	CheckFairReadSharing          CheckFairReadSharingConfig
+	TupleQuota                    TupleQuotaConfig
 
 	RequestDurationDatastoreQueryCountBuckets []string
 	RequestDurationDispatchCountBuckets       []string
@@ -328,10 +706,86 @@ func (cfg *Config) Verify() error {
 		)
 	}
 
+	if cfg.MaxAuthorizationModelComplexity < 0 {
+		return fmt.Errorf("config 'maxAuthorizationModelComplexity' cannot be negative")
+	}
+
+	if cfg.MaxAuthorizationModelTypes < 0 {
+		return fmt.Errorf("config 'maxAuthorizationModelTypes' cannot be negative")
+	}
+
+	if cfg.MaxAuthorizationModelRelationsPerType < 0 {
+		return fmt.Errorf("config 'maxAuthorizationModelRelationsPerType' cannot be negative")
+	}
+
+	if cfg.MaxAuthorizationModelRewriteDepth < 0 {
+		return fmt.Errorf("config 'maxAuthorizationModelRewriteDepth' cannot be negative")
+	}
+
+	if cfg.AuthorizationModelValidationTimeout < 0 {
+		return fmt.Errorf("config 'authorizationModelValidationTimeout' cannot be negative")
+	}
+
+	if cfg.AuthorizationModelValidationMaxVisits < 0 {
+		return fmt.Errorf("config 'authorizationModelValidationMaxVisits' cannot be negative")
+	}
+
+	if cfg.ModelImpactAnalysisMaxProbes <= 0 {
+		return fmt.Errorf("config 'modelImpactAnalysisMaxProbes' must be greater than 0")
+	}
+
+	if cfg.HTTP.MaxBodySizeInBytes < 0 {
+		return fmt.Errorf("config 'http.maxBodySizeInBytes' cannot be negative")
+	}
+	for route, size := range cfg.HTTP.RouteMaxBodySizesInBytes {
+		if size < 0 {
+			return fmt.Errorf("config 'http.routeMaxBodySizesInBytes' of route '%s' cannot be negative", route)
+		}
+	}
+
+	if cfg.TupleQuota.MaxTuples < 0 {
+		return fmt.Errorf("config 'tupleQuota.maxTuples' cannot be negative")
+	}
+	if cfg.TupleQuota.MaxTuplesPerObjectType < 0 {
+		return fmt.Errorf("config 'tupleQuota.maxTuplesPerObjectType' cannot be negative")
+	}
+	for objectType, maxTuples := range cfg.TupleQuota.MaxTuplesByObjectType {
+		if maxTuples < 0 {
+			return fmt.Errorf("config 'tupleQuota.maxTuplesByObjectType' of object type '%s' cannot be negative", objectType)
+		}
+	}
+	for storeID, maxTuples := range cfg.TupleQuota.StoreMaxTuples {
+		if maxTuples < 0 {
+			return fmt.Errorf("config 'tupleQuota.storeMaxTuples' of store '%s' cannot be negative", storeID)
+		}
+	}
+
+	for storeID, weight := range cfg.CheckFairReadSharing.StoreWeights {
+		if weight <= 0 || weight > math.MaxUint32 {
+			return fmt.Errorf("config 'checkFairReadSharing.storeWeights' of store '%s' must be between 1 and %d", storeID, uint32(math.MaxUint32))
+		}
+	}
+
 	if cfg.MaxConcurrentReadsForListUsers == 0 {
 		return fmt.Errorf("config 'maxConcurrentReadsForListUsers' cannot be 0")
 	}
 
+	if cfg.MaxConcurrentReadsForExpand == 0 {
+		return fmt.Errorf("config 'maxConcurrentReadsForExpand' cannot be 0")
+	}
+
+	if cfg.MaxConcurrentReadsForRead == 0 {
+		return fmt.Errorf("config 'maxConcurrentReadsForRead' cannot be 0")
+	}
+
+	if cfg.ResolverWorkerPoolSize < 0 {
+		return fmt.Errorf("config 'resolverWorkerPoolSize' cannot be negative")
+	}
+
+	if cfg.MaxReadResponseSizeInBytes < 0 {
+		return fmt.Errorf("config 'maxReadResponseSizeInBytes' cannot be negative")
+	}
+
 	if cfg.Log.Format != "text" && cfg.Log.Format != "json" {
 		return fmt.Errorf("config 'log.format' must be one of ['text', 'json']")
 	}
@@ -348,6 +802,20 @@ func (cfg *Config) Verify() error {
 		)
 	}
 
+	if cfg.ListObjectsStrategy != "auto" &&
+		cfg.ListObjectsStrategy != "direct" &&
+		cfg.ListObjectsStrategy != "reverse_expand" &&
+		cfg.ListObjectsStrategy != "check_candidates" &&
+		cfg.ListObjectsStrategy != "filtered_base" {
+		return fmt.Errorf("config 'listObjectsStrategy' must be one of ['auto', 'direct', 'reverse_expand', 'check_candidates', 'filtered_base']")
+	}
+
+	if cfg.Datastore.ChangelogMode != "sync" &&
+		cfg.Datastore.ChangelogMode != "async" &&
+		cfg.Datastore.ChangelogMode != "disabled" {
+		return fmt.Errorf("config 'datastore.changelogMode' must be one of ['sync', 'async', 'disabled']")
+	}
+
 	if cfg.Log.Level == "none" {
 		fmt.Println("WARNING: Logging is not enabled. It is highly recommended to enable logging in production environments to avoid masking attacker operations.")
 	}
@@ -432,6 +900,10 @@ func (cfg *Config) Verify() error {
 		}
 	}
 
+	if cfg.RequestPriority.MaxDeprioritization > 100 {
+		return errors.New("'requestPriority.maxDeprioritization' must be a percentage between 0 and 100")
+	}
+
 	if cfg.RequestTimeout < 0 {
 		return errors.New("requestTimeout must be a non-negative time duration")
 	}
@@ -444,6 +916,18 @@ func (cfg *Config) Verify() error {
 		return errors.New("listObjectsDeadline must be non-negative time duration")
 	}
 
+	if cfg.MaxStreamDuration < 0 {
+		return errors.New("maxStreamDuration must be non-negative time duration")
+	}
+
+	if cfg.ReadChangesWatchPollInterval <= 0 {
+		return errors.New("readChangesWatchPollInterval must be a positive time duration")
+	}
+
+	if cfg.ReadChangesWatchHeartbeatInterval < 0 {
+		return errors.New("readChangesWatchHeartbeatInterval must be a non-negative time duration")
+	}
+
 	if cfg.ListUsersDeadline < 0 {
 		return errors.New("listUsersDeadline must be non-negative time duration")
 	}
@@ -539,37 +1023,79 @@ func DefaultConfig() *Config {
 		MaxTuplesPerWrite:                         DefaultMaxTuplesPerWrite,
 		MaxTypesPerAuthorizationModel:             DefaultMaxTypesPerAuthorizationModel,
 		MaxAuthorizationModelSizeInBytes:          DefaultMaxAuthorizationModelSizeInBytes,
+		MaxReadResponseSizeInBytes:                DefaultMaxReadResponseSizeInBytes,
+		MaxAuthorizationModelComplexity:           DefaultMaxAuthorizationModelComplexity,
+		MaxAuthorizationModelTypes:                DefaultMaxAuthorizationModelTypes,
+		MaxAuthorizationModelRelationsPerType:     DefaultMaxAuthorizationModelRelations,
+		MaxAuthorizationModelRewriteDepth:         DefaultMaxAuthorizationModelRewriteDepth,
+		AuthorizationModelValidationTimeout:       DefaultAuthorizationModelValidationTimeout,
+		AuthorizationModelValidationMaxVisits:     DefaultAuthorizationModelValidationMaxVisits,
+		ModelImpactAnalysisMaxProbes:              DefaultModelImpactAnalysisMaxProbes,
 		MaxConcurrentReadsForCheck:                DefaultMaxConcurrentReadsForCheck,
+		SnapshotReadsForCheck:                     DefaultSnapshotReadsForCheck,
+		MaxChecksPerBatchCheck:                    DefaultMaxChecksPerBatchCheck,
 		MaxConcurrentReadsForListObjects:          DefaultMaxConcurrentReadsForListObjects,
 		MaxConcurrentReadsForListUsers:            DefaultMaxConcurrentReadsForListUsers,
+		MaxConcurrentReadsForExpand:               DefaultMaxConcurrentReadsForExpand,
+		MaxConcurrentReadsForRead:                 DefaultMaxConcurrentReadsForRead,
 		MaxConditionEvaluationCost:                DefaultMaxConditionEvaluationCost,
 		ChangelogHorizonOffset:                    DefaultChangelogHorizonOffset,
+		ReadChangesWatchPollInterval:              DefaultReadChangesWatchPollInterval,
+		ReadChangesWatchHeartbeatInterval:         DefaultReadChangesWatchHeartbeatInterval,
+		ReadChangesWatchMaxStreams:                DefaultReadChangesWatchMaxStreams,
 		ResolveNodeLimit:                          DefaultResolveNodeLimit,
 		ResolveNodeBreadthLimit:                   DefaultResolveNodeBreadthLimit,
+		ResolverWorkerPoolEnabled:                 DefaultResolverWorkerPoolEnabled,
+		ResolverWorkerPoolSize:                    DefaultResolverWorkerPoolSize,
 		Experimentals:                             []string{},
 		ListObjectsDeadline:                       DefaultListObjectsDeadline,
 		ListObjectsMaxResults:                     DefaultListObjectsMaxResults,
+		ListObjectsMaxCandidates:                  DefaultListObjectsMaxCandidates,
+		ListObjectsStrategy:                       DefaultListObjectsStrategy,
+		ListObjectsStreamMaxModelLag:              DefaultListObjectsStreamMaxModelLag,
+		ListObjectsStreamModelCheckInterval:       DefaultListObjectsStreamModelCheckInterval,
+		MaxStreamDuration:                         DefaultMaxStreamDuration,
+		ExpandMaxLeafUsers:                        DefaultExpandMaxLeafUsers,
+		ExpandMaxNodes:                            DefaultExpandMaxNodes,
+		RelationStatisticsMaxSampleSize:           DefaultRelationStatisticsMaxSampleSize,
+		RelationStatisticsDeadline:                DefaultRelationStatisticsDeadline,
+		RelationUsageDeadline:                     DefaultRelationUsageDeadline,
+		DeleteTuplesByFilterMaxTuples:             DefaultDeleteTuplesByFilterMaxTuples,
+		DeleteTuplesByFilterMaxScanned:            DefaultDeleteTuplesByFilterMaxScanned,
+		PointInTimeCheckHorizon:                   DefaultPointInTimeCheckHorizon,
+		PointInTimeCheckMaxChanges:                DefaultPointInTimeCheckMaxChanges,
+		ErrorReasonDetails:                        DefaultErrorReasonDetails,
 		ListUsersMaxResults:                       DefaultListUsersMaxResults,
 		ListUsersDeadline:                         DefaultListUsersDeadline,
 		RequestDurationDatastoreQueryCountBuckets: []string{"50", "200"},
 		RequestDurationDispatchCountBuckets:       []string{"50", "200"},
 		Datastore: DatastoreConfig{
-			Engine:       "memory",
-			MaxCacheSize: DefaultMaxAuthorizationModelCacheSize,
-			MaxIdleConns: 10,
-			MaxOpenConns: 30,
+			Engine:        "memory",
+			MaxCacheSize:  DefaultMaxAuthorizationModelCacheSize,
+			MaxIdleConns:  10,
+			MaxOpenConns:  30,
+			ChangelogMode: "sync",
+			Hedging: DatastoreHedgingConfig{
+				Enabled:       DefaultDatastoreHedgingEnabled,
+				MinDelay:      DefaultDatastoreHedgingMinDelay,
+				Percentile:    DefaultDatastoreHedgingPercentile,
+				MaxPerRequest: DefaultDatastoreHedgingMaxPerRequest,
+			},
 		},
 		GRPC: GRPCConfig{
 			Addr: "0.0.0.0:8081",
 			TLS:  &TLSConfig{Enabled: false},
 		},
 		HTTP: HTTPConfig{
-			Enabled:            true,
-			Addr:               "0.0.0.0:8080",
-			TLS:                &TLSConfig{Enabled: false},
-			UpstreamTimeout:    5 * time.Second,
-			CORSAllowedOrigins: []string{"*"},
-			CORSAllowedHeaders: []string{"*"},
+			Enabled:                  true,
+			Addr:                     "0.0.0.0:8080",
+			TLS:                      &TLSConfig{Enabled: false},
+			UpstreamTimeout:          5 * time.Second,
+			CORSAllowedOrigins:       []string{"*"},
+			CORSAllowedHeaders:       []string{"*"},
+			MaxBodySizeInBytes:       DefaultHTTPMaxBodySizeInBytes,
+			RouteMaxBodySizesInBytes: map[string]int{},
+			StrictJSON:               false,
 		},
 		Authn: AuthnConfig{
 			Method:                  "none",
@@ -600,18 +1126,24 @@ func DefaultConfig() *Config {
 			Enabled: false,
 			Addr:    ":3001",
 		},
+		Admin: AdminConfig{
+			Enabled: false,
+			Addr:    "127.0.0.1:3002",
+		},
 		Metrics: MetricConfig{
 			Enabled:             true,
 			Addr:                "0.0.0.0:2112",
 			EnableRPCHistograms: false,
+			ChangelogInterval:   DefaultChangelogMetricsInterval,
 		},
 		CheckIteratorCache: CheckIteratorCacheConfig{
 			Enabled:    DefaultCheckIteratorCacheEnabled,
 			MaxResults: DefaultCheckIteratorCacheMaxResults,
 		},
 		CheckQueryCache: CheckQueryCache{
-			Enabled: DefaultCheckQueryCacheEnabled,
-			TTL:     DefaultCheckQueryCacheTTL,
+			Enabled:        DefaultCheckQueryCacheEnabled,
+			TTL:            DefaultCheckQueryCacheTTL,
+			ResponseHeader: DefaultCheckQueryCacheResponseHeader,
 		},
 		Cache: CacheConfig{
 			Limit: DefaultCacheLimit,
@@ -640,6 +1172,34 @@ func DefaultConfig() *Config {
 			Threshold:    DefaultListUsersDispatchThrottlingDefaultThreshold,
 			MaxThreshold: DefaultListUsersDispatchThrottlingMaxThreshold,
 		},
+		RequestPriority: RequestPriorityConfig{
+			Enabled:             DefaultRequestPriorityEnabled,
+			MaxDeprioritization: DefaultRequestPriorityMaxDeprioritization,
+		},
+		RequestOptions: RequestOptionsConfig{
+			Strict:             DefaultRequestOptionsStrict,
+			PrivilegedSubjects: []string{},
+		},
+		SessionAffinity: SessionAffinityConfig{
+			Enabled:     DefaultSessionAffinityEnabled,
+			Window:      DefaultSessionAffinityWindow,
+			MaxSessions: DefaultSessionAffinityMaxSessions,
+		},
+		MembershipIndex: MembershipIndexConfig{
+			Relations:    []string{},
+			PollInterval: DefaultMembershipIndexPollInterval,
+			MaxStaleness: DefaultMembershipIndexMaxStaleness,
+			MaxStores:    DefaultMembershipIndexMaxStores,
+			IdleTimeout:  DefaultMembershipIndexIdleTimeout,
+		},
+		CheckFairReadSharing: CheckFairReadSharingConfig{
+			Enabled:      DefaultCheckFairReadSharingEnabled,
+			StoreWeights: map[string]int{},
+		},
+		TupleQuota: TupleQuotaConfig{
+			MaxTuplesByObjectType: map[string]int{},
+			StoreMaxTuples:        map[string]int{},
+		},
 		RequestTimeout: DefaultRequestTimeout,
 	}
 }
@@ -41,6 +41,60 @@ func TestVerifyConfig(t *testing.T) {
 		require.EqualError(t, err, "config 'maxConcurrentReadsForListUsers' cannot be 0")
 	})
 
+	t.Run("maxConcurrentReadsForExpand_not_zero", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.MaxConcurrentReadsForExpand = 0
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "config 'maxConcurrentReadsForExpand' cannot be 0")
+	})
+
+	t.Run("maxConcurrentReadsForRead_not_zero", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.MaxConcurrentReadsForRead = 0
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "config 'maxConcurrentReadsForRead' cannot be 0")
+	})
+
+	t.Run("resolverWorkerPoolSize_not_negative", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.ResolverWorkerPoolSize = -1
+
+		err := cfg.Verify()
+		require.EqualError(t, err, "config 'resolverWorkerPoolSize' cannot be negative")
+	})
+
+	t.Run("authorization_model_limits_not_negative", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.MaxAuthorizationModelTypes = -1
+		require.EqualError(t, cfg.Verify(), "config 'maxAuthorizationModelTypes' cannot be negative")
+
+		cfg = DefaultConfig()
+		cfg.MaxAuthorizationModelRelationsPerType = -1
+		require.EqualError(t, cfg.Verify(), "config 'maxAuthorizationModelRelationsPerType' cannot be negative")
+
+		cfg = DefaultConfig()
+		cfg.MaxAuthorizationModelRewriteDepth = -1
+		require.EqualError(t, cfg.Verify(), "config 'maxAuthorizationModelRewriteDepth' cannot be negative")
+	})
+
+	t.Run("check_fair_read_sharing_store_weights_positive", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.CheckFairReadSharing.StoreWeights = map[string]int{"01JCZ6AN3K4V7RDRG9XW8PQ5MN": 0}
+		require.EqualError(t, cfg.Verify(), "config 'checkFairReadSharing.storeWeights' of store '01JCZ6AN3K4V7RDRG9XW8PQ5MN' must be between 1 and 4294967295")
+	})
+
+	t.Run("tuple_quota_not_negative", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.TupleQuota.MaxTuples = -1
+		require.EqualError(t, cfg.Verify(), "config 'tupleQuota.maxTuples' cannot be negative")
+
+		cfg = DefaultConfig()
+		cfg.TupleQuota.StoreMaxTuples = map[string]int{"01JCZ6AN3K4V7RDRG9XW8PQ5MN": -1}
+		require.EqualError(t, cfg.Verify(), "config 'tupleQuota.storeMaxTuples' of store '01JCZ6AN3K4V7RDRG9XW8PQ5MN' cannot be negative")
+	})
+
 	t.Run("failing_to_set_http_cert_path_will_not_allow_server_to_start", func(t *testing.T) {
 		cfg := DefaultConfig()
 		cfg.HTTP.TLS = &TLSConfig{
@@ -265,6 +319,14 @@ func TestVerifyConfig(t *testing.T) {
 		require.ErrorContains(t, err, "'listUsersDispatchThrottling.threshold' must be less than or equal to 'listUsersDispatchThrottling.maxThreshold'")
 	})
 
+	t.Run("request_priority_max_deprioritization_larger_than_100", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.RequestPriority.MaxDeprioritization = 101
+
+		err := cfg.Verify()
+		require.ErrorContains(t, err, "'requestPriority.maxDeprioritization' must be a percentage between 0 and 100")
+	})
+
 	t.Run("negative_request_timeout_duration", func(t *testing.T) {
 		cfg := DefaultConfig()
 		cfg.RequestTimeout = -2 * time.Second
@@ -12,9 +12,13 @@ type Config struct {
 	Throttler    throttler.Throttler
 	Threshold    uint32
 	MaxThreshold uint32
+
+	// LowPriorityDeprioritization is the percentage (0-100) by which the threshold is lowered
+	// for requests whose priority is dispatch.RequestPriorityLow. 0 disables deprioritization.
+	LowPriorityDeprioritization uint32
 }
 
-func ShouldThrottle(ctx context.Context, currentCount uint32, defaultThreshold uint32, maxThreshold uint32) bool {
+func ShouldThrottle(ctx context.Context, currentCount uint32, defaultThreshold uint32, maxThreshold uint32, lowPriorityDeprioritization uint32) bool {
 	threshold := defaultThreshold
 
 	if maxThreshold == 0 {
@@ -27,5 +31,9 @@ func ShouldThrottle(ctx context.Context, currentCount uint32, defaultThreshold u
 		threshold = min(thresholdInCtx, maxThreshold)
 	}
 
+	if dispatch.RequestPriorityFromContext(ctx) == dispatch.RequestPriorityLow {
+		threshold -= uint32(uint64(threshold) * uint64(min(lowPriorityDeprioritization, 100)) / 100)
+	}
+
 	return currentCount > threshold
 }
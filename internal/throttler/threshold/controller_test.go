@@ -16,9 +16,9 @@ func TestShouldThrottle(t *testing.T) {
 	})
 	t.Run("expect_should_throttle_logic_to_work", func(t *testing.T) {
 		ctx := context.Background()
-		require.False(t, ShouldThrottle(ctx, 190, 200, 200))
-		require.True(t, ShouldThrottle(ctx, 201, 200, 200))
-		require.False(t, ShouldThrottle(ctx, 190, 200, 0))
+		require.False(t, ShouldThrottle(ctx, 190, 200, 200, 0))
+		require.True(t, ShouldThrottle(ctx, 201, 200, 200, 0))
+		require.False(t, ShouldThrottle(ctx, 190, 200, 0, 0))
 	})
 
 	type Tests struct {
@@ -60,7 +60,35 @@ func TestShouldThrottle(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			ctx := context.Background()
 			ctx = dispatch.ContextWithThrottlingThreshold(ctx, test.thresholdInCtx)
-			require.Equal(t, test.expectedResult, ShouldThrottle(ctx, test.currentCount, test.defaultThreshold, test.maxThreshold))
+			require.Equal(t, test.expectedResult, ShouldThrottle(ctx, test.currentCount, test.defaultThreshold, test.maxThreshold, 0))
 		})
 	}
 }
+
+func TestShouldThrottleWithRequestPriority(t *testing.T) {
+	lowCtx := dispatch.ContextWithRequestPriority(context.Background(), dispatch.RequestPriorityLow)
+	normalCtx := dispatch.ContextWithRequestPriority(context.Background(), dispatch.RequestPriorityNormal)
+
+	t.Run("normal_priority_is_not_deprioritized", func(t *testing.T) {
+		require.False(t, ShouldThrottle(normalCtx, 150, 200, 0, 50))
+	})
+
+	t.Run("low_priority_gets_lower_threshold", func(t *testing.T) {
+		require.False(t, ShouldThrottle(lowCtx, 100, 200, 0, 50))
+		require.True(t, ShouldThrottle(lowCtx, 101, 200, 0, 50))
+	})
+
+	t.Run("low_priority_without_deprioritization", func(t *testing.T) {
+		require.False(t, ShouldThrottle(lowCtx, 150, 200, 0, 0))
+	})
+
+	t.Run("deprioritization_is_capped_at_100_percent", func(t *testing.T) {
+		require.True(t, ShouldThrottle(lowCtx, 1, 200, 0, 250))
+	})
+
+	t.Run("deprioritization_applies_to_threshold_in_context", func(t *testing.T) {
+		ctx := dispatch.ContextWithThrottlingThreshold(lowCtx, 300)
+		require.False(t, ShouldThrottle(ctx, 150, 100, 400, 50))
+		require.True(t, ShouldThrottle(ctx, 151, 100, 400, 50))
+	})
+}
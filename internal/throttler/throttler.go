@@ -4,6 +4,7 @@ package throttler
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -42,6 +43,15 @@ func (r *noopThrottler) Close() {
 
 func NewNoopThrottler() Throttler { return &noopThrottler{} }
 
+// QueueDepth returns the number of goroutines waiting in the Throttle of t, or 0 if t doesn't
+// queue them.
+func QueueDepth(t Throttler) int64 {
+	if q, ok := t.(interface{ QueueDepth() int64 }); ok {
+		return q.QueueDepth()
+	}
+	return 0
+}
+
 // constantRateThrottler implements a throttling mechanism that can be used to control the rate of recursive resource consumption.
 // Throttling will release the goroutines from the throttlingQueue based on the configured ticker.
 type constantRateThrottler struct {
@@ -49,6 +59,9 @@ type constantRateThrottler struct {
 	ticker          *time.Ticker
 	throttlingQueue chan struct{}
 	done            chan struct{}
+
+	// queueDepth is the number of goroutines waiting in Throttle.
+	queueDepth atomic.Int64
 }
 
 // NewConstantRateThrottler constructs a constantRateThrottler which can be used to control the rate of recursive resource consumption.
@@ -100,7 +113,9 @@ func (r *constantRateThrottler) Close() {
 // which is produced by periodically sending a value on the channel based on the configured ticker frequency.
 func (r *constantRateThrottler) Throttle(ctx context.Context) {
 	start := time.Now()
+	r.queueDepth.Add(1)
 	<-r.throttlingQueue
+	r.queueDepth.Add(-1)
 	end := time.Now()
 	timeWaiting := end.Sub(start).Milliseconds()
 
@@ -111,3 +126,8 @@ func (r *constantRateThrottler) Throttle(ctx context.Context) {
 		r.name,
 	).Observe(float64(timeWaiting))
 }
+
+// QueueDepth returns the number of goroutines waiting in Throttle.
+func (r *constantRateThrottler) QueueDepth() int64 {
+	return r.queueDepth.Load()
+}
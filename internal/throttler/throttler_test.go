@@ -46,3 +46,40 @@ func TestConstantRateThrottler(t *testing.T) {
 		require.Equal(t, 1, counter)
 	})
 }
+
+func TestQueueDepth(t *testing.T) {
+	testThrottler := newConstantRateThrottler(1*time.Hour, "test")
+	t.Cleanup(func() {
+		testThrottler.Close()
+		goleak.VerifyNone(t)
+	})
+
+	var done sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			testThrottler.Throttle(context.Background())
+		}()
+	}
+
+	require.Eventually(t, func() bool {
+		return QueueDepth(testThrottler) == 2
+	}, time.Second, time.Millisecond)
+
+	// a send is dropped if no goroutine is receiving yet, so it is retried until one is released
+	require.Eventually(t, func() bool {
+		if QueueDepth(testThrottler) == 2 {
+			testThrottler.nonBlockingSend(testThrottler.throttlingQueue)
+		}
+		return QueueDepth(testThrottler) == 1
+	}, time.Second, time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		testThrottler.nonBlockingSend(testThrottler.throttlingQueue)
+		return QueueDepth(testThrottler) == 0
+	}, time.Second, time.Millisecond)
+	done.Wait()
+
+	require.Zero(t, QueueDepth(NewNoopThrottler()))
+}
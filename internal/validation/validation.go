@@ -120,6 +120,7 @@ func validateTuplesetRestrictions(typesys *typesystem.TypeSystem, tk *openfgav1.
 }
 
 // validateTypeRestrictions makes sure the type restrictions are enforced.
+// 0. If 'doc#reader' has no type restrictions (e.g. `define reader: writer`), no user can be assigned to it.
 // 1. If the tuple is of the form doc:budget#reader@person:bob, then 'doc#reader' must allow type 'person'.
 // 2. If the tuple is of the form doc:budget#reader@group:abc#member, then 'doc#reader' must allow 'group#member'.
 // 3. If the tuple is of the form doc:budget#reader@person:*, we allow it only if 'doc#reader' allows the typed wildcard 'person:*'.
@@ -137,6 +138,11 @@ func validateTypeRestrictions(typesys *typesystem.TypeSystem, tk *openfgav1.Tupl
 
 	relationInformation := relationsForObject[tk.GetRelation()]
 
+	if len(relationInformation.GetDirectlyRelatedUserTypes()) == 0 {
+		// case 0 documented above
+		return &tuple.RelationNotAssignableError{TypeName: objectType, Relation: tk.GetRelation()}
+	}
+
 	user := tk.GetUser()
 
 	if tuple.IsObjectRelation(user) {
@@ -147,7 +153,7 @@ func validateTypeRestrictions(typesys *typesystem.TypeSystem, tk *openfgav1.Tupl
 			}
 		}
 
-		return fmt.Errorf("'%s#%s' is not an allowed type restriction for '%s#%s'", userType, userRel, objectType, tk.GetRelation())
+		return &tuple.UserTypeNotAllowedError{TypeName: objectType, Relation: tk.GetRelation(), UserType: userType, UserRelation: userRel}
 	}
 
 	if tuple.IsTypedWildcard(user) {
@@ -158,7 +164,7 @@ func validateTypeRestrictions(typesys *typesystem.TypeSystem, tk *openfgav1.Tupl
 			}
 		}
 
-		return fmt.Errorf("the typed wildcard '%s' is not an allowed type restriction for '%s#%s'", user, objectType, tk.GetRelation())
+		return &tuple.WildcardNotAllowedError{TypeName: objectType, Relation: tk.GetRelation(), Wildcard: user}
 	}
 
 	// the user must be an object (case 1), so check directly against the objectType
@@ -168,7 +174,7 @@ func validateTypeRestrictions(typesys *typesystem.TypeSystem, tk *openfgav1.Tupl
 		}
 	}
 
-	return fmt.Errorf("type '%s' is not an allowed type restriction for '%s#%s'", userType, objectType, tk.GetRelation())
+	return &tuple.UserTypeNotAllowedError{TypeName: objectType, Relation: tk.GetRelation(), UserType: userType}
 }
 
 // validateCondition returns an error if the condition of the tuple is required but not present,
@@ -1,12 +1,16 @@
 package validation
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
 
+	"github.com/openfga/openfga/pkg/testutils"
 	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
 )
@@ -1205,3 +1209,120 @@ func BenchmarkValidateTupleForRead(b *testing.B) {
 		require.NoError(b, err)
 	}
 }
+
+func TestValidateTupleForWriteTypeRestrictions(t *testing.T) {
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type group
+			relations
+				define member: [user]
+		type document
+			relations
+				define owner: [user]
+				define editor: [group#member]
+				define viewer: [user:*]
+				define can_view: owner or viewer`)
+	ts, err := typesystem.New(model)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		tuple         *openfgav1.TupleKey
+		expectedCause error
+		expectedError string
+	}{
+		{
+			name:          "relation_not_found",
+			tuple:         tuple.NewTupleKey("document:1", "unknown", "user:jon"),
+			expectedCause: &tuple.RelationNotFoundError{TypeName: "document", Relation: "unknown"},
+			expectedError: "relation 'document#unknown' not found",
+		},
+		{
+			name:          "relation_not_assignable",
+			tuple:         tuple.NewTupleKey("document:1", "can_view", "user:jon"),
+			expectedCause: &tuple.RelationNotAssignableError{TypeName: "document", Relation: "can_view"},
+			expectedError: "relation 'document#can_view' is not assignable: it has no type restrictions, so it accepts no direct users",
+		},
+		{
+			name:          "user_type_not_allowed",
+			tuple:         tuple.NewTupleKey("document:1", "owner", "group:eng#member"),
+			expectedCause: &tuple.UserTypeNotAllowedError{TypeName: "document", Relation: "owner", UserType: "group", UserRelation: "member"},
+			expectedError: "'group#member' is not an allowed type restriction for 'document#owner'",
+		},
+		{
+			name:          "wildcard_not_allowed",
+			tuple:         tuple.NewTupleKey("document:1", "owner", "user:*"),
+			expectedCause: &tuple.WildcardNotAllowedError{TypeName: "document", Relation: "owner", Wildcard: "user:*"},
+			expectedError: "the typed wildcard 'user:*' is not an allowed type restriction for 'document#owner'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateTupleForWrite(ts, test.tuple)
+			require.ErrorIs(t, err, test.expectedCause)
+			require.ErrorContains(t, err, test.expectedError)
+		})
+	}
+}
+
+func FuzzValidateTupleForWrite(f *testing.F) {
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type group
+			relations
+				define member: [user, user:*, group#member]
+		type folder
+			relations
+				define viewer: [user]
+		type document
+			relations
+				define parent: [folder]
+				define owner: [user, user with in_office_hours]
+				define viewer: [user, group#member] or viewer from parent
+		condition in_office_hours(hour: int) {
+			hour >= 9 && hour <= 17
+		}`)
+	ts, err := typesystem.New(model)
+	require.NoError(f, err)
+
+	f.Add("document:1", "viewer", "user:jon", "", []byte(nil))
+	f.Add("document:1", "viewer", "group:eng#member", "", []byte(nil))
+	f.Add("document:1", "viewer", "group:eng#", "", []byte(nil))
+	f.Add("document:1", "viewer", "group:#member", "", []byte(nil))
+	f.Add("document:1", "viewer", "#member", "", []byte(nil))
+	f.Add("document:1", "parent", "folder:x#viewer", "", []byte(nil))
+	f.Add("document:1", "parent", "folder:*", "", []byte(nil))
+	f.Add("group:eng", "member", "user:*", "", []byte(nil))
+	f.Add("document:*", "owner", "user:jon", "", []byte(nil))
+	f.Add("document:1", "owner", "user:jon", "in_office_hours", []byte(`{"hour": 10}`))
+	f.Add("document:1", "owner", "user:jon", "in_office_hours", []byte(`{"hour": "ten", "day": 1}`))
+	f.Add("document:1", "owner", "user:jon", "unknown", []byte(`{}`))
+
+	f.Fuzz(func(t *testing.T, object, relation, user, conditionName string, conditionContext []byte) {
+		tk := tuple.NewTupleKey(object, relation, user)
+		if conditionName != "" {
+			contextStruct := &structpb.Struct{}
+			if err := protojson.Unmarshal(conditionContext, contextStruct); err != nil {
+				contextStruct = nil
+			}
+			tk.Condition = tuple.NewRelationshipCondition(conditionName, contextStruct)
+		}
+
+		err := ValidateTupleForWrite(ts, tk)
+		if err == nil {
+			return
+		}
+
+		// the errors of invalid tuples are mapped to validation errors by the server
+		var invalidTupleError *tuple.InvalidTupleError
+		var invalidConditionalTupleError *tuple.InvalidConditionalTupleError
+		if !errors.As(err, &invalidTupleError) && !errors.As(err, &invalidConditionalTupleError) {
+			t.Fatalf("unexpected error type %T: %v", err, err)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package dispatch
+
+import (
+	"context"
+	"strings"
+)
+
+// RequestPriority is the priority class of a request. Under load, requests with a
+// lower priority are deprioritized in favor of requests with a higher priority.
+type RequestPriority uint8
+
+const (
+	// RequestPriorityNormal is the priority of all requests that don't specify one.
+	RequestPriorityNormal RequestPriority = iota
+
+	// RequestPriorityLow is meant for batch or background traffic.
+	RequestPriorityLow
+)
+
+type requestPriorityType uint8
+
+const (
+	requestPriority requestPriorityType = iota
+)
+
+// String returns the name of the priority, as used in the request header and in metric labels.
+func (p RequestPriority) String() string {
+	switch p {
+	case RequestPriorityLow:
+		return "low"
+	default:
+		return "normal"
+	}
+}
+
+// ParseRequestPriority parses the priority name (case-insensitive).
+// It returns false if the name is not a known priority.
+func ParseRequestPriority(name string) (RequestPriority, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "normal":
+		return RequestPriorityNormal, true
+	case "low":
+		return RequestPriorityLow, true
+	default:
+		return RequestPriorityNormal, false
+	}
+}
+
+// ContextWithRequestPriority will save the request priority in context.
+// This can be used to set per request priority when OpenFGA is used as library in another Go project.
+func ContextWithRequestPriority(ctx context.Context, priority RequestPriority) context.Context {
+	return context.WithValue(ctx, requestPriority, priority)
+}
+
+// RequestPriorityFromContext returns the request priority saved in context.
+// Return RequestPriorityNormal if not found.
+func RequestPriorityFromContext(ctx context.Context) RequestPriority {
+	priority, ok := ctx.Value(requestPriority).(RequestPriority)
+	if !ok {
+		return RequestPriorityNormal
+	}
+	return priority
+}
@@ -22,3 +22,27 @@ func TestThrottlingThresholdFromContext(t *testing.T) {
 	thresholdInContext = ThrottlingThresholdFromContext(ctx)
 	require.Equal(t, uint32(20), thresholdInContext)
 }
+
+func TestRequestPriorityFromContext(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, RequestPriorityNormal, RequestPriorityFromContext(ctx))
+
+	ctx = ContextWithRequestPriority(ctx, RequestPriorityLow)
+	require.Equal(t, RequestPriorityLow, RequestPriorityFromContext(ctx))
+}
+
+func TestParseRequestPriority(t *testing.T) {
+	priority, ok := ParseRequestPriority("LOW")
+	require.True(t, ok)
+	require.Equal(t, RequestPriorityLow, priority)
+	require.Equal(t, "low", priority.String())
+
+	priority, ok = ParseRequestPriority("normal")
+	require.True(t, ok)
+	require.Equal(t, RequestPriorityNormal, priority)
+	require.Equal(t, "normal", priority.String())
+
+	priority, ok = ParseRequestPriority("urgent")
+	require.False(t, ok)
+	require.Equal(t, RequestPriorityNormal, priority)
+}
@@ -0,0 +1,38 @@
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/encrypter"
+)
+
+func FuzzTokenEncoderDecode(f *testing.F) {
+	gcm, err := encrypter.NewGCMEncrypter("key")
+	require.NoError(f, err)
+	tokenEncoder := NewTokenEncoder(gcm, NewBase64Encoder())
+
+	token, err := tokenEncoder.Encode([]byte(`{"ulid":"01HVMMBCMGZNCSVY6Y0RDQ2Z4H","ObjectType":"document"}`))
+	require.NoError(f, err)
+	f.Add(token)
+	f.Add(token[:len(token)-1])
+	f.Add("")
+	f.Add("dGhlIHR2IHNob3cgJ3NjaGl0dCdzIGNyZWVrJyBpcyBncmVhdCBmdW4=")
+	f.Add("not base64!")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		decoded, err := tokenEncoder.Decode(s)
+		if err != nil {
+			require.Nil(t, decoded)
+			return
+		}
+
+		// only the tokens encoded with the key decode
+		encoded, err := tokenEncoder.Encode(decoded)
+		require.NoError(t, err)
+		redecoded, err := tokenEncoder.Decode(encoded)
+		require.NoError(t, err)
+		require.Equal(t, decoded, redecoded)
+	})
+}
@@ -0,0 +1,2 @@
+// Package id contains the generators of store and authorization model IDs.
+package id
@@ -0,0 +1,50 @@
+package id
+
+import (
+	"fmt"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Ensure ULIDGenerator implements the Generator interface.
+var _ Generator = (*ULIDGenerator)(nil)
+
+// Generator is an interface that defines methods for generating the IDs of new stores
+// and authorization models.
+//
+// Generated IDs must be valid ULIDs (see Validate):
+//   - The API validates store IDs against the ULID alphabet and length.
+//   - Authorization model IDs are sorted to find the latest model of a store, so they must
+//     be monotonically increasing ULIDs.
+//
+// Custom data, such as a region prefix, may be embedded in the entropy part of the ULID.
+type Generator interface {
+	NewStoreID() string
+	NewModelID() string
+}
+
+// ULIDGenerator is an implementation of the Generator interface that generates random ULIDs.
+type ULIDGenerator struct{}
+
+// NewULIDGenerator creates a new instance of ULIDGenerator.
+func NewULIDGenerator() *ULIDGenerator {
+	return &ULIDGenerator{}
+}
+
+// NewStoreID returns a new random ULID.
+func (g *ULIDGenerator) NewStoreID() string {
+	return ulid.Make().String()
+}
+
+// NewModelID returns a new random ULID.
+func (g *ULIDGenerator) NewModelID() string {
+	return ulid.Make().String()
+}
+
+// Validate returns an error if the generated ID is not a valid ULID.
+func Validate(id string) error {
+	if _, err := ulid.ParseStrict(id); err != nil {
+		return fmt.Errorf("generated id '%s' is not a valid ULID: %w", id, err)
+	}
+	return nil
+}
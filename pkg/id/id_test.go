@@ -0,0 +1,25 @@
+package id
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestULIDGenerator(t *testing.T) {
+	generator := NewULIDGenerator()
+
+	storeID := generator.NewStoreID()
+	require.NoError(t, Validate(storeID))
+
+	modelID := generator.NewModelID()
+	require.NoError(t, Validate(modelID))
+
+	require.NotEqual(t, storeID, modelID)
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate("01JA5V4BZX3NNBSY9S3KCS2GXR"))
+	require.ErrorContains(t, Validate("us-east-1-01JA5V4BZX3NNBSY9S3KCS2GXR"), "is not a valid ULID")
+	require.Error(t, Validate(""))
+}
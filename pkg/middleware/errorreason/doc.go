@@ -0,0 +1,3 @@
+// Package errorreason contains middleware that adds their reason to the errors of the Write,
+// Check, ListObjects and ListUsers APIs, including the errors of the other interceptors.
+package errorreason
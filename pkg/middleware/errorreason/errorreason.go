@@ -0,0 +1,44 @@
+package errorreason
+
+import (
+	"context"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+// methods are the gRPC methods whose errors carry a reason, see [serverErrors.WithErrorReason].
+var methods = map[string]struct{}{
+	openfgav1.OpenFGAService_Write_FullMethodName:               {},
+	openfgav1.OpenFGAService_Check_FullMethodName:               {},
+	openfgav1.OpenFGAService_ListObjects_FullMethodName:         {},
+	openfgav1.OpenFGAService_StreamedListObjects_FullMethodName: {},
+	openfgav1.OpenFGAService_ListUsers_FullMethodName:           {},
+}
+
+// NewUnaryInterceptor returns a grpc.UnaryServerInterceptor that adds their reason to the errors
+// of the Write, Check, ListObjects and ListUsers APIs, if enabled. It must come before the
+// interceptors whose errors need a reason, e.g. the message size and validator interceptors.
+func NewUnaryInterceptor(enabled bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if _, ok := methods[info.FullMethod]; enabled && ok && err != nil {
+			return resp, serverErrors.WithErrorReason(err)
+		}
+		return resp, err
+	}
+}
+
+// NewStreamingInterceptor returns a grpc.StreamServerInterceptor that adds their reason to the
+// errors of the StreamedListObjects API, if enabled. See NewUnaryInterceptor.
+func NewStreamingInterceptor(enabled bool) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, stream)
+		if _, ok := methods[info.FullMethod]; enabled && ok && err != nil {
+			return serverErrors.WithErrorReason(err)
+		}
+		return err
+	}
+}
@@ -0,0 +1,66 @@
+package errorreason
+
+import (
+	"context"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+func TestUnaryInterceptor(t *testing.T) {
+	interceptorErr := serverErrors.MessageSizeExceeded(openfgav1.OpenFGAService_Write_FullMethodName, 2048, 1024)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, interceptorErr
+	}
+
+	t.Run("adds_the_reason", func(t *testing.T) {
+		_, err := NewUnaryInterceptor(true)(context.Background(), &openfgav1.WriteRequest{},
+			&grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_Write_FullMethodName}, handler)
+		require.Error(t, err)
+		require.Equal(t, serverErrors.WithErrorReason(interceptorErr), err)
+		require.Equal(t, serverErrors.ErrorReasonLimitExceeded, serverErrors.ErrorReasonOf(err))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		_, err := NewUnaryInterceptor(false)(context.Background(), &openfgav1.WriteRequest{},
+			&grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_Write_FullMethodName}, handler)
+		require.Equal(t, interceptorErr, err)
+	})
+
+	t.Run("other_method", func(t *testing.T) {
+		_, err := NewUnaryInterceptor(true)(context.Background(), &openfgav1.ReadRequest{},
+			&grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_Read_FullMethodName}, handler)
+		require.Equal(t, interceptorErr, err)
+	})
+
+	t.Run("no_error", func(t *testing.T) {
+		resp, err := NewUnaryInterceptor(true)(context.Background(), &openfgav1.WriteRequest{},
+			&grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_Write_FullMethodName},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "ok", nil
+			})
+		require.NoError(t, err)
+		require.Equal(t, "ok", resp)
+	})
+}
+
+func TestStreamingInterceptor(t *testing.T) {
+	interceptorErr := serverErrors.InvalidRequestField("StoreId", "value length must be 26 runes")
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return interceptorErr
+	}
+
+	err := NewStreamingInterceptor(true)(nil, nil,
+		&grpc.StreamServerInfo{FullMethod: openfgav1.OpenFGAService_StreamedListObjects_FullMethodName}, handler)
+	require.Error(t, err)
+	require.NotEqual(t, interceptorErr, err)
+	require.Equal(t, serverErrors.ErrorReasonTupleInvalid, serverErrors.ErrorReasonOf(err))
+
+	err = NewStreamingInterceptor(false)(nil, nil,
+		&grpc.StreamServerInfo{FullMethod: openfgav1.OpenFGAService_StreamedListObjects_FullMethodName}, handler)
+	require.Equal(t, interceptorErr, err)
+}
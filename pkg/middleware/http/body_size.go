@@ -0,0 +1,76 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+
+	"github.com/openfga/openfga/pkg/server/errors"
+)
+
+// MaxBodySizeMiddleware returns a gateway middleware that rejects requests whose body is larger
+// than the limit of their route with a 413 status and a JSON error body. Routes are named by the
+// last literal segment of their path pattern (see [RouteName]), and routes without an entry in
+// routeLimits are limited to defaultLimit. A limit of 0 disables the check.
+func MaxBodySizeMiddleware(defaultLimit int64, routeLimits map[string]int64) runtime.Middleware {
+	return func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+			var route string
+			if pattern, ok := runtime.HTTPPattern(r.Context()); ok {
+				route = RouteName(pattern.String())
+			}
+
+			limit := defaultLimit
+			if routeLimit, ok := routeLimits[route]; ok {
+				limit = routeLimit
+			}
+
+			if limit <= 0 || r.Body == nil || r.Body == http.NoBody {
+				next(w, r, pathParams)
+				return
+			}
+
+			if r.ContentLength > limit {
+				CustomHTTPErrorHandler(r.Context(), w, r, errors.NewRequestBodyTooLargeError(limit))
+				return
+			}
+
+			// the body is buffered so that chunked bodies are rejected with the same error
+			body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+			if err != nil {
+				// let the gateway report the read error
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+				next(w, r, pathParams)
+				return
+			}
+
+			if int64(len(body)) > limit {
+				CustomHTTPErrorHandler(r.Context(), w, r, errors.NewRequestBodyTooLargeError(limit))
+				return
+			}
+
+			r.Body = readCloser{bytes.NewReader(body), r.Body}
+			next(w, r, pathParams)
+		}
+	}
+}
+
+// RouteName returns the last literal segment of a gateway path pattern, e.g. "check" for
+// "/stores/{store_id}/check" and "assertions" for "/stores/{store_id}/assertions/{authorization_model_id}".
+func RouteName(pattern string) string {
+	segments := strings.Split(strings.Trim(pattern, "/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if !strings.HasPrefix(segments[i], "{") {
+			return segments[i]
+		}
+	}
+	return ""
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
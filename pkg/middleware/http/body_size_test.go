@@ -0,0 +1,44 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteName(t *testing.T) {
+	require.Equal(t, "check", RouteName("/stores/{store_id=*}/check"))
+	require.Equal(t, "assertions", RouteName("/stores/{store_id=*}/assertions/{authorization_model_id=*}"))
+	require.Equal(t, "stores", RouteName("/stores"))
+}
+
+func TestMaxBodySizeMiddleware(t *testing.T) {
+	var received string
+	handler := MaxBodySizeMiddleware(8, map[string]int64{"write": 16})(func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = string(body)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("within_the_limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/check", strings.NewReader("12345678")), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "12345678", received)
+	})
+
+	t.Run("chunked_body_over_the_limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/check", strings.NewReader("123456789"))
+		req.ContentLength = -1
+
+		w := httptest.NewRecorder()
+		handler(w, req, nil)
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		require.JSONEq(t, `{"code":"request_body_too_large","message":"the request body exceeds the limit of 8 bytes"}`, w.Body.String())
+	})
+}
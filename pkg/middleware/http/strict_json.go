@@ -0,0 +1,137 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// NewStrictJSONMarshaler returns the default JSON marshaler of the gateway, except that it rejects
+// the request bodies of the given messages that have fields unknown to them, e.g. "delete" instead
+// of "deletes" in a WriteRequest. The error names the first unknown field and its JSON path, e.g.
+// "writes.tuple_keys[0].conditon", and is reported by the gateway with a 400 status. The bodies of
+// other messages ignore unknown fields, so that clients can send fields of newer API versions.
+func NewStrictJSONMarshaler(messages ...proto.Message) runtime.Marshaler {
+	strict := make(map[protoreflect.FullName]bool, len(messages))
+	for _, message := range messages {
+		strict[message.ProtoReflect().Descriptor().FullName()] = true
+	}
+
+	return &runtime.HTTPBodyMarshaler{
+		Marshaler: &strictJSONMarshaler{
+			JSONPb: &runtime.JSONPb{
+				MarshalOptions: protojson.MarshalOptions{
+					EmitUnpopulated: true,
+				},
+				UnmarshalOptions: protojson.UnmarshalOptions{
+					DiscardUnknown: true,
+				},
+			},
+			strict: strict,
+		},
+	}
+}
+
+type strictJSONMarshaler struct {
+	*runtime.JSONPb
+	strict map[protoreflect.FullName]bool
+}
+
+func (m *strictJSONMarshaler) NewDecoder(r io.Reader) runtime.Decoder {
+	decoder := m.JSONPb.NewDecoder(r).(runtime.DecoderWrapper)
+	return runtime.DecoderFunc(func(v interface{}) error {
+		message, ok := v.(proto.Message)
+		if !ok || !m.strict[message.ProtoReflect().Descriptor().FullName()] {
+			return decoder.Decode(v)
+		}
+
+		var body json.RawMessage
+		if err := decoder.Decoder.Decode(&body); err != nil {
+			return err
+		}
+
+		var value interface{}
+		d := json.NewDecoder(bytes.NewReader(body))
+		d.UseNumber()
+		if err := d.Decode(&value); err == nil {
+			if field, path := unknownField(value, message.ProtoReflect().Descriptor(), ""); field != "" {
+				return fmt.Errorf("unknown field %q at %q", field, path)
+			}
+		}
+
+		// invalid bodies are reported by protojson
+		return m.UnmarshalOptions.Unmarshal(body, message)
+	})
+}
+
+// unknownField returns the first field of the JSON value that is unknown to the message md, and
+// its path in the value, or empty strings if there are none. Fields may be named by their JSON or
+// their proto name, as protojson accepts both. The content of well-known types is not checked.
+// Fields are checked in the order of their names, so that the same field is reported every time.
+func unknownField(value interface{}, md protoreflect.MessageDescriptor, path string) (string, string) {
+	object, ok := value.(map[string]interface{})
+	if !ok || md.FullName().Parent() == "google.protobuf" {
+		return "", ""
+	}
+
+	for _, name := range sortedKeys(object) {
+		fieldValue := object[name]
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+
+		fd := md.Fields().ByJSONName(name)
+		if fd == nil {
+			fd = md.Fields().ByTextName(name)
+		}
+		if fd == nil {
+			return name, fieldPath
+		}
+
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() == nil {
+				continue
+			}
+			entries, _ := fieldValue.(map[string]interface{})
+			for _, key := range sortedKeys(entries) {
+				if field, path := unknownField(entries[key], fd.MapValue().Message(), fieldPath+"."+key); field != "" {
+					return field, path
+				}
+			}
+		case fd.Message() == nil:
+			continue
+		case fd.IsList():
+			elements, _ := fieldValue.([]interface{})
+			for i, element := range elements {
+				if field, path := unknownField(element, fd.Message(), fieldPath+"["+strconv.Itoa(i)+"]"); field != "" {
+					return field, path
+				}
+			}
+		default:
+			if field, path := unknownField(fieldValue, fd.Message(), fieldPath); field != "" {
+				return field, path
+			}
+		}
+	}
+
+	return "", ""
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"strings"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrictJSONMarshaler(t *testing.T) {
+	marshaler := NewStrictJSONMarshaler(&openfgav1.WriteAuthorizationModelRequest{})
+
+	t.Run("accepts_known_fields", func(t *testing.T) {
+		var req openfgav1.WriteAuthorizationModelRequest
+		body := `{"schema_version": "1.1", "type_definitions": [{"type": "document", "relations": {"viewer": {"this": {}}}}]}`
+		require.NoError(t, marshaler.NewDecoder(strings.NewReader(body)).Decode(&req))
+		require.Equal(t, "1.1", req.GetSchemaVersion())
+	})
+
+	t.Run("checks_the_values_of_maps", func(t *testing.T) {
+		var req openfgav1.WriteAuthorizationModelRequest
+		body := `{"type_definitions": [{"type": "document", "relations": {"viewer": {"thiss": {}}}}]}`
+		err := marshaler.NewDecoder(strings.NewReader(body)).Decode(&req)
+		require.EqualError(t, err, `unknown field "thiss" at "type_definitions[0].relations.viewer.thiss"`)
+	})
+
+	t.Run("does_not_check_well_known_types", func(t *testing.T) {
+		var req openfgav1.WriteAuthorizationModelRequest
+		body := `{"conditions": {"c": {"name": "c", "expression": "x < 100", "parameters": {"x": {"type_name": "TYPE_NAME_INT"}}, "metadata": {"module": "m"}}}}`
+		require.NoError(t, marshaler.NewDecoder(strings.NewReader(body)).Decode(&req))
+	})
+
+	t.Run("ignores_unknown_fields_of_other_messages", func(t *testing.T) {
+		var req openfgav1.CheckRequest
+		body := `{"tuple_key": {"object": "document:1"}, "consistensy": "HIGHER_CONSISTENCY"}`
+		require.NoError(t, marshaler.NewDecoder(strings.NewReader(body)).Decode(&req))
+		require.Equal(t, "document:1", req.GetTupleKey().GetObject())
+	})
+}
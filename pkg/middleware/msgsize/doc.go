@@ -0,0 +1,2 @@
+// Package msgsize contains middleware that limits the size of the request messages per gRPC method.
+package msgsize
@@ -0,0 +1,87 @@
+package msgsize
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+// Limits are the maximum sizes in bytes of the request messages of the gRPC methods, by full
+// method name (e.g. "/openfga.v1.OpenFGAService/Write"). The methods without an entry are
+// limited to Default.
+//
+// The transport accepts messages up to the largest limit (see grpc.MaxRecvMsgSize), so that the
+// methods with a larger limit than the others, e.g. Write for bulk imports, do not raise the
+// limit of every method.
+type Limits struct {
+	Default int
+	Methods map[string]int
+}
+
+// Max returns the largest limit, which the transport must accept.
+func (l Limits) Max() int {
+	largest := l.Default
+	for _, limit := range l.Methods {
+		largest = max(largest, limit)
+	}
+	return largest
+}
+
+func (l Limits) limit(method string) int {
+	if limit, ok := l.Methods[method]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// check returns a ResourceExhausted error, like the one of the transport, if the message is
+// larger than the limit of the method.
+func (l Limits) check(method string, msg interface{}) error {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return nil
+	}
+	limit := l.limit(method)
+	if limit <= 0 {
+		return nil
+	}
+	if size := proto.Size(m); size > limit {
+		return serverErrors.MessageSizeExceeded(method, size, limit)
+	}
+	return nil
+}
+
+// NewUnaryInterceptor returns a grpc.UnaryServerInterceptor that rejects the requests larger
+// than the limit of their method.
+func NewUnaryInterceptor(limits Limits) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := limits.check(info.FullMethod, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// NewStreamingInterceptor returns a grpc.StreamServerInterceptor that rejects the messages
+// received on a stream that are larger than the limit of its method.
+func NewStreamingInterceptor(limits Limits) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &limitedStream{ServerStream: stream, limits: limits, method: info.FullMethod})
+	}
+}
+
+type limitedStream struct {
+	grpc.ServerStream
+	limits Limits
+	method string
+}
+
+func (s *limitedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.limits.check(s.method, m)
+}
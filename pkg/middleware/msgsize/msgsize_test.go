@@ -0,0 +1,60 @@
+package msgsize
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestUnaryInterceptor(t *testing.T) {
+	req := &openfgav1.WriteRequest{StoreId: strings.Repeat("a", 100)}
+	size := proto.Size(req)
+
+	interceptor := NewUnaryInterceptor(Limits{
+		Default: size - 1,
+		Methods: map[string]int{openfgav1.OpenFGAService_Write_FullMethodName: size},
+	})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	resp, err := interceptor(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_Write_FullMethodName}, handler)
+	require.NoError(t, err)
+	require.Equal(t, "ok", resp)
+
+	_, err = interceptor(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_Check_FullMethodName}, handler)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+type recvStream struct {
+	grpc.ServerStream
+	msg proto.Message
+}
+
+func (s *recvStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(proto.Message), s.msg)
+	return nil
+}
+
+func TestStreamingInterceptor(t *testing.T) {
+	req := &openfgav1.StreamedListObjectsRequest{StoreId: strings.Repeat("a", 100)}
+
+	interceptor := NewStreamingInterceptor(Limits{Default: proto.Size(req) - 1})
+	err := interceptor(nil, &recvStream{msg: req}, &grpc.StreamServerInfo{FullMethod: openfgav1.OpenFGAService_StreamedListObjects_FullMethodName},
+		func(srv interface{}, stream grpc.ServerStream) error {
+			return stream.RecvMsg(&openfgav1.StreamedListObjectsRequest{})
+		})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestLimitsMax(t *testing.T) {
+	require.Equal(t, 10, Limits{Default: 5, Methods: map[string]int{"a": 10, "b": 1}}.Max())
+	require.Equal(t, 5, Limits{Default: 5}.Max())
+}
@@ -8,17 +8,28 @@ import (
 	"runtime/debug"
 
 	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
-
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 
+	"github.com/openfga/openfga/internal/build"
 	"github.com/openfga/openfga/pkg/logger"
 	"github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/telemetry"
 )
 
+var panicCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "panic_total",
+	Help:      "The total number of panics recovered while serving requests, labeled by method.",
+}, []string{"grpc_method"})
+
 // HTTPPanicRecoveryHandler recover from panic for http services.
 func HTTPPanicRecoveryHandler(next http.Handler, logger logger.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -53,11 +64,28 @@ func HTTPPanicRecoveryHandler(next http.Handler, logger logger.Logger) http.Hand
 // PanicRecoveryHandler recovers from panics for unary/stream services.
 func PanicRecoveryHandler(logger logger.Logger) grpc_recovery.RecoveryHandlerFuncContext {
 	return func(ctx context.Context, p any) error {
-		logger.Error("PanicRecoveryHandler has recovered a panic",
-			zap.Error(fmt.Errorf("%v", p)),
+		method, _ := grpc.Method(ctx)
+		return RecoverPanic(ctx, logger, method, p)
+	}
+}
+
+// RecoverPanic handles the value p recovered from a panic while serving the given method.
+// It logs p with the stack trace and the fields that describe the request, increments the
+// panic_total counter, marks the span of ctx with the error and returns the Internal error
+// to send to the client. It must be called from the deferred function that recovered p.
+func RecoverPanic(ctx context.Context, logger logger.Logger, method string, p any, fields ...zap.Field) error {
+	panicErr := fmt.Errorf("panic: %v", p)
+
+	logger.ErrorWithContext(ctx, "recovered a panic",
+		append([]zap.Field{
+			zap.String("method", method),
+			zap.Error(panicErr),
 			zap.ByteString("stacktrace", debug.Stack()),
-		)
+		}, fields...)...,
+	)
 
-		return status.Errorf(codes.Internal, errors.InternalServerErrorMsg)
-	}
+	panicCounter.WithLabelValues(method).Inc()
+	telemetry.TraceError(trace.SpanFromContext(ctx), panicErr)
+
+	return status.Errorf(codes.Internal, errors.InternalServerErrorMsg)
 }
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 	"google.golang.org/grpc"
@@ -163,3 +164,12 @@ func (unimplementedOpenFGAServiceServer) StreamedListObjects(m *openfgav1.Stream
 
 	panic("Unexpected error!")
 }
+
+func TestRecoverPanic(t *testing.T) {
+	const method = "/openfga.v1.OpenFGAService/Check"
+	before := testutil.ToFloat64(panicCounter.WithLabelValues(method))
+
+	err := RecoverPanic(context.Background(), logger.NewNoopLogger(), method, "injected panic")
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.InDelta(t, before+1, testutil.ToFloat64(panicCounter.WithLabelValues(method)), 0)
+}
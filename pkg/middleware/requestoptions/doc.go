@@ -0,0 +1,3 @@
+// Package requestoptions contains middleware to parse the options of a request from its metadata
+// into a [requestcontext.Options].
+package requestoptions
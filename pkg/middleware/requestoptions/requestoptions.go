@@ -0,0 +1,161 @@
+package requestoptions
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/internal/authn"
+	"github.com/openfga/openfga/pkg/dispatch"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+const requestPriorityKey = "request_priority"
+
+// AllSubjects allows every client to set the privileged options (see [WithPrivilegedSubjects]).
+const AllSubjects = "*"
+
+type config struct {
+	priority           bool
+	strict             bool
+	knownHeaders       []string
+	privilegedSubjects []string
+}
+
+// Option configures the interceptors of the package.
+type Option func(*config)
+
+// WithRequestPriority honors the priority option of the requests. Otherwise, all requests have the
+// normal priority, and the priority header is not validated. It is disabled by default.
+func WithRequestPriority(enabled bool) Option {
+	return func(c *config) {
+		c.priority = enabled
+	}
+}
+
+// WithStrictHeaders rejects the requests with a request header of OpenFGA that is neither an option
+// nor one of knownHeaders, e.g. a misspelled option, with an InvalidArgument error. Otherwise, such
+// headers are ignored.
+func WithStrictHeaders(knownHeaders ...string) Option {
+	return func(c *config) {
+		c.strict = true
+		c.knownHeaders = knownHeaders
+	}
+}
+
+// WithPrivilegedSubjects sets the authenticated subjects allowed to set the privileged options
+// (see [requestcontext.Options.Privileged]), or [AllSubjects]. Requests of other subjects with
+// privileged options fail with a PermissionDenied error, which is also the default for every
+// subject. The interceptors must run after the authentication interceptor.
+func WithPrivilegedSubjects(subjects ...string) Option {
+	return func(c *config) {
+		c.privilegedSubjects = subjects
+	}
+}
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor which parses the options of the request
+// from the incoming metadata, and saves them in the RPC context (see [requestcontext.FromContext]).
+// Requests with invalid options fail with an InvalidArgument error.
+func NewUnaryInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	c := newConfig(opts...)
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := c.contextWithOptions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor which parses the options of the
+// request from the incoming metadata, and saves them in the RPC context (see
+// [requestcontext.FromContext]). Requests with invalid options fail with an InvalidArgument error.
+func NewStreamingInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	c := newConfig(opts...)
+	return func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := c.contextWithOptions(stream.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func newConfig(opts ...Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *config) contextWithOptions(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if c.strict {
+		if unknown := requestcontext.UnknownHeaders(md, c.knownHeaders...); len(unknown) > 0 {
+			slices.Sort(unknown)
+			return nil, serverErrors.UnknownRequestHeaders(unknown)
+		}
+	}
+
+	if !c.priority && len(md.Get(requestcontext.PriorityHeader)) > 0 {
+		// the priority of the requests is not honored, so neither is its header validated
+		md = md.Copy()
+		delete(md, strings.ToLower(requestcontext.PriorityHeader))
+	}
+
+	opts, err := requestcontext.Parse(md)
+	if err != nil {
+		var headerErr *requestcontext.HeaderError
+		if errors.As(err, &headerErr) {
+			return nil, serverErrors.InvalidHeaderValue(headerErr.Header, headerErr.Value, err)
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if opts.Privileged() && !c.privileged(ctx) {
+		return nil, serverErrors.HeaderNotAllowed(requestcontext.NoModelCacheHeader)
+	}
+
+	if !c.priority {
+		opts.Priority = dispatch.RequestPriorityNormal
+	} else {
+		grpc_ctxtags.Extract(ctx).Set(requestPriorityKey, opts.Priority.String())
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String(requestPriorityKey, opts.Priority.String()))
+	}
+
+	return requestcontext.ContextWithOptions(ctx, opts), nil
+}
+
+func (c *config) privileged(ctx context.Context) bool {
+	if slices.Contains(c.privilegedSubjects, AllSubjects) {
+		return true
+	}
+
+	claims, ok := authn.AuthClaimsFromContext(ctx)
+	if !ok || claims.Subject == "" {
+		return false
+	}
+	return slices.Contains(c.privilegedSubjects, claims.Subject)
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the stream, with the options of the request.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
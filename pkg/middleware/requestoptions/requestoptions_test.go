@@ -0,0 +1,182 @@
+package requestoptions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/internal/authn"
+	"github.com/openfga/openfga/pkg/dispatch"
+	"github.com/openfga/openfga/pkg/requestcontext"
+)
+
+func TestUnaryInterceptor(t *testing.T) {
+	tests := map[string]struct {
+		opts           []Option
+		md             metadata.MD
+		subject        string
+		expected       requestcontext.Options
+		expectedCode   codes.Code
+		expectedError  string
+		expectedReason string
+	}{
+		`no_metadata`: {
+			expected: requestcontext.Options{},
+		},
+		`low_priority`: {
+			opts:     []Option{WithRequestPriority(true)},
+			md:       metadata.Pairs(requestcontext.PriorityHeader, "low"),
+			expected: requestcontext.Options{Priority: dispatch.RequestPriorityLow},
+		},
+		`low_priority_ignored_if_priority_disabled`: {
+			md:       metadata.Pairs(requestcontext.PriorityHeader, "low"),
+			expected: requestcontext.Options{Priority: dispatch.RequestPriorityNormal},
+		},
+		`invalid_priority_ignored_if_priority_disabled`: {
+			md:       metadata.Pairs(requestcontext.PriorityHeader, "urgent"),
+			expected: requestcontext.Options{Priority: dispatch.RequestPriorityNormal},
+		},
+		`invalid_priority`: {
+			opts:           []Option{WithRequestPriority(true)},
+			md:             metadata.Pairs(requestcontext.PriorityHeader, "urgent"),
+			expectedCode:   codes.InvalidArgument,
+			expectedError:  "invalid 'Openfga-Request-Priority' header value 'urgent': expected 'normal' or 'low'",
+			expectedReason: "INVALID_HEADER_VALUE",
+		},
+		`resolve_node_limit`: {
+			md:       metadata.Pairs(requestcontext.ResolveNodeLimitHeader, "10"),
+			expected: requestcontext.Options{ResolveNodeLimit: 10},
+		},
+		`invalid_resolve_node_limit`: {
+			md:             metadata.Pairs(requestcontext.ResolveNodeLimitHeader, "0"),
+			expectedCode:   codes.InvalidArgument,
+			expectedError:  "invalid 'Openfga-Resolve-Node-Limit' header value '0': expected a positive integer",
+			expectedReason: "INVALID_HEADER_VALUE",
+		},
+		`header_set_twice`: {
+			md:             metadata.Pairs(requestcontext.ResolveNodeLimitHeader, "10", requestcontext.ResolveNodeLimitHeader, "20"),
+			expectedCode:   codes.InvalidArgument,
+			expectedError:  "header 'Openfga-Resolve-Node-Limit' must be set once",
+			expectedReason: "INVALID_HEADER_VALUE",
+		},
+		`no_model_cache_without_privileged_subjects`: {
+			md:             metadata.Pairs(requestcontext.NoModelCacheHeader, "true"),
+			subject:        "client",
+			expectedCode:   codes.PermissionDenied,
+			expectedError:  "the 'Openfga-No-Model-Cache' header is not allowed for this client",
+			expectedReason: "HEADER_NOT_ALLOWED",
+		},
+		`no_model_cache_of_other_subject`: {
+			opts:           []Option{WithPrivilegedSubjects("admin")},
+			md:             metadata.Pairs(requestcontext.NoModelCacheHeader, "true"),
+			subject:        "client",
+			expectedCode:   codes.PermissionDenied,
+			expectedError:  "the 'Openfga-No-Model-Cache' header is not allowed for this client",
+			expectedReason: "HEADER_NOT_ALLOWED",
+		},
+		`no_model_cache_of_privileged_subject`: {
+			opts:     []Option{WithPrivilegedSubjects("admin")},
+			md:       metadata.Pairs(requestcontext.NoModelCacheHeader, "true"),
+			subject:  "admin",
+			expected: requestcontext.Options{NoModelCache: true},
+		},
+		`no_model_cache_of_all_subjects`: {
+			opts:     []Option{WithPrivilegedSubjects(AllSubjects)},
+			md:       metadata.Pairs(requestcontext.NoModelCacheHeader, "true"),
+			expected: requestcontext.Options{NoModelCache: true},
+		},
+		`no_model_cache_false_is_not_privileged`: {
+			md:       metadata.Pairs(requestcontext.NoModelCacheHeader, "false"),
+			expected: requestcontext.Options{},
+		},
+		`unknown_headers_ignored`: {
+			md:       metadata.Pairs("Openfga-No-Modle-Cache", "true", "Openfga-Check-At", "2024-01-01T00:00:00Z"),
+			expected: requestcontext.Options{},
+		},
+		`unknown_headers_rejected_if_strict`: {
+			opts:           []Option{WithStrictHeaders("Openfga-Check-At")},
+			md:             metadata.Pairs("Openfga-No-Modle-Cache", "true", "Openfga-Resolve-Limit", "1", "Openfga-Check-At", "2024-01-01T00:00:00Z"),
+			expectedCode:   codes.InvalidArgument,
+			expectedError:  "unknown request headers: openfga-no-modle-cache, openfga-resolve-limit",
+			expectedReason: "UNKNOWN_REQUEST_HEADERS",
+		},
+		`known_headers_allowed_if_strict`: {
+			opts:     []Option{WithStrictHeaders("Openfga-Check-At")},
+			md:       metadata.Pairs(requestcontext.ResolveNodeLimitHeader, "10", "Openfga-Check-At", "2024-01-01T00:00:00Z", "Authorization", "Bearer key"),
+			expected: requestcontext.Options{ResolveNodeLimit: 10},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, test.md)
+			}
+			if test.subject != "" {
+				ctx = authn.ContextWithAuthClaims(ctx, &authn.AuthClaims{Subject: test.subject})
+			}
+
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				require.Equal(t, test.expected, requestcontext.FromContext(ctx))
+				require.Equal(t, test.expected.Priority, dispatch.RequestPriorityFromContext(ctx))
+				return nil, nil
+			}
+
+			_, err := NewUnaryInterceptor(test.opts...)(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+			if test.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Equal(t, test.expectedCode, status.Code(err))
+			require.Equal(t, test.expectedError, status.Convert(err).Message())
+			require.Len(t, status.Convert(err).Details(), 1)
+			require.Equal(t, test.expectedReason, status.Convert(err).Details()[0].(*errdetails.ErrorInfo).GetReason())
+		})
+	}
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *mockServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamingInterceptor(t *testing.T) {
+	t.Run("saves_the_options_in_the_stream_context", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			requestcontext.PriorityHeader, "low",
+			requestcontext.ResolveNodeLimitHeader, "5",
+		))
+
+		handler := func(srv interface{}, stream grpc.ServerStream) error {
+			require.Equal(t, requestcontext.Options{Priority: dispatch.RequestPriorityLow, ResolveNodeLimit: 5}, requestcontext.FromContext(stream.Context()))
+			require.Equal(t, dispatch.RequestPriorityLow, dispatch.RequestPriorityFromContext(stream.Context()))
+			return nil
+		}
+
+		err := NewStreamingInterceptor(WithRequestPriority(true))(nil, &mockServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, handler)
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects_invalid_options", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestcontext.NoModelCacheHeader, "yes"))
+
+		handler := func(srv interface{}, stream grpc.ServerStream) error {
+			require.Fail(t, "handler must not be called")
+			return nil
+		}
+
+		err := NewStreamingInterceptor()(nil, &mockServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, handler)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
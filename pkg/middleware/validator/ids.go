@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"regexp"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+// ulidPattern matches the upper case Crockford base32 ULIDs used as store and authorization
+// model IDs, like the pattern of the ID fields of the API.
+var ulidPattern = regexp.MustCompile(`^[0-9ABCDEFGHJKMNPQRSTVWXYZ]{26}$`)
+
+// ValidateIDs checks the shape of the store ID and authorization model ID of a request, if it
+// has them, so that malformed IDs fail with the same InvalidArgument error in every API
+// instead of reaching the datastore. The authorization model ID is optional.
+func ValidateIDs(req interface{}) error {
+	if r, ok := req.(interface{ GetStoreId() string }); ok {
+		if err := ValidateStoreID(r.GetStoreId()); err != nil {
+			return err
+		}
+	}
+
+	modelID := ""
+	switch r := req.(type) {
+	case *openfgav1.ReadAuthorizationModelRequest:
+		modelID = r.GetId()
+	case interface{ GetAuthorizationModelId() string }:
+		modelID = r.GetAuthorizationModelId()
+	}
+	if modelID != "" {
+		return ValidateAuthorizationModelID(modelID)
+	}
+
+	return nil
+}
+
+// ValidateStoreID returns [serverErrors.InvalidStoreID] if id is not a ULID.
+func ValidateStoreID(id string) error {
+	if !ulidPattern.MatchString(id) {
+		return serverErrors.InvalidStoreID
+	}
+	return nil
+}
+
+// ValidateAuthorizationModelID returns [serverErrors.InvalidAuthorizationModelID] if id is not a ULID.
+func ValidateAuthorizationModelID(id string) error {
+	if !ulidPattern.MatchString(id) {
+		return serverErrors.InvalidAuthorizationModelID
+	}
+	return nil
+}
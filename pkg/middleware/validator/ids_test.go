@@ -0,0 +1,91 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+func TestValidateIDs(t *testing.T) {
+	storeID := ulid.Make().String()
+	modelID := ulid.Make().String()
+
+	tests := map[string]struct {
+		req         interface{}
+		expectedErr error
+	}{
+		`valid_ids`: {
+			req: &openfgav1.CheckRequest{StoreId: storeID, AuthorizationModelId: modelID},
+		},
+		`optional_model_id`: {
+			req: &openfgav1.CheckRequest{StoreId: storeID},
+		},
+		`lowercase_store_id`: {
+			req:         &openfgav1.ReadRequest{StoreId: "01hvmmbcmgzncsvy6y0rdq2z4h"},
+			expectedErr: serverErrors.InvalidStoreID,
+		},
+		`uuid_store_id`: {
+			req:         &openfgav1.WriteRequest{StoreId: "5c3a0b5c-9a1b-4e0e-8d8c-1f2b3c4d5e6f"},
+			expectedErr: serverErrors.InvalidStoreID,
+		},
+		`invalid_model_id`: {
+			req:         &openfgav1.WriteRequest{StoreId: storeID, AuthorizationModelId: modelID + "A"},
+			expectedErr: serverErrors.InvalidAuthorizationModelID,
+		},
+		`invalid_read_authorization_model_id`: {
+			req:         &openfgav1.ReadAuthorizationModelRequest{StoreId: storeID, Id: "01HVMMBCMGZNCSVY6Y0RDQ2Z4I"},
+			expectedErr: serverErrors.InvalidAuthorizationModelID,
+		},
+		`without_ids`: {
+			req: &openfgav1.ListStoresRequest{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expectedErr, ValidateIDs(test.req))
+		})
+	}
+}
+
+func FuzzValidateIDs(f *testing.F) {
+	f.Add(ulid.Make().String(), ulid.Make().String())
+	f.Add("01hvmmbcmgzncsvy6y0rdq2z4h", "")
+	f.Add("01HVMMBCMGZNCSVY6Y0RDQ2Z4", "01HVMMBCMGZNCSVY6Y0RDQ2Z4HH")
+	f.Add("01HVMMBCMGZNCSVY6Y0RDQ2ZIL", "01HVMMBCMGZNCSVY6Y0RDQ2ZOU")
+	f.Add("5c3a0b5c-9a1b-4e0e-8d8c-1f2b3c4d5e6f", "\x00")
+
+	// isULID checks the shape of an ID character by character, like the pattern of the API.
+	isULID := func(id string) bool {
+		if len(id) != ulid.EncodedSize {
+			return false
+		}
+		for _, c := range id {
+			if !strings.ContainsRune("0123456789ABCDEFGHJKMNPQRSTVWXYZ", c) {
+				return false
+			}
+		}
+		return true
+	}
+
+	f.Fuzz(func(t *testing.T, storeID, modelID string) {
+		err := ValidateIDs(&openfgav1.CheckRequest{StoreId: storeID, AuthorizationModelId: modelID})
+		require.NotEqual(t, codes.Internal, status.Code(err))
+
+		switch {
+		case !isULID(storeID):
+			require.ErrorIs(t, err, serverErrors.InvalidStoreID)
+		case modelID != "" && !isULID(modelID):
+			require.ErrorIs(t, err, serverErrors.InvalidAuthorizationModelID)
+		default:
+			require.NoError(t, err)
+		}
+	})
+}
@@ -3,8 +3,9 @@ package validator
 import (
 	"context"
 
-	grpcvalidator "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
 	"google.golang.org/grpc"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 )
 
 type ctxKey string
@@ -26,31 +27,59 @@ func RequestIsValidatedFromContext(ctx context.Context) bool {
 
 // UnaryServerInterceptor returns a new unary server interceptor that runs request validations
 // and injects a bool in the context indicating that validation has been run.
+// Requests that have already been validated by another instance of the interceptor are not validated again.
 func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
-	validator := grpcvalidator.UnaryServerInterceptor()
-
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		return validator(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
-			return handler(contextWithRequestIsValidated(ctx), req)
-		})
+		if RequestIsValidatedFromContext(ctx) {
+			return handler(ctx, req)
+		}
+
+		if err := ValidateIDs(req); err != nil {
+			return nil, err
+		}
+		if err := ValidateFields(req); err != nil {
+			return nil, err
+		}
+
+		return handler(contextWithRequestIsValidated(ctx), req)
 	}
 }
 
 // StreamServerInterceptor returns a new streaming server interceptor that runs request validations
 // and injects a bool in the context indicating that validation has been run.
+// Streams that have already been validated by another instance of the interceptor are not validated again.
 func StreamServerInterceptor() grpc.StreamServerInterceptor {
-	validator := grpcvalidator.StreamServerInterceptor()
-
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		return validator(srv, stream, info, func(srv interface{}, ss grpc.ServerStream) error {
-			return handler(srv, &recvWrapper{
-				ctx:          contextWithRequestIsValidated(stream.Context()),
-				ServerStream: ss,
-			})
+		if RequestIsValidatedFromContext(stream.Context()) {
+			return handler(srv, stream)
+		}
+
+		return handler(srv, &recvWrapper{
+			ctx:          contextWithRequestIsValidated(stream.Context()),
+			ServerStream: &validatingStream{stream},
 		})
 	}
 }
 
+// ValidateFields runs the validations of the fields of the API on a request, if it has them,
+// and returns their errors as a [serverErrors.RequestValidationError], whose details tell the
+// invalid field. Like the validator of go-grpc-middleware, it reports all the invalid fields.
+func ValidateFields(req interface{}) error {
+	var err error
+	switch r := req.(type) {
+	case interface{ ValidateAll() error }:
+		err = r.ValidateAll()
+	case interface{ Validate(all bool) error }:
+		err = r.Validate(true)
+	case interface{ Validate() error }:
+		err = r.Validate()
+	}
+	if err != nil {
+		return serverErrors.RequestValidationError(err)
+	}
+	return nil
+}
+
 type recvWrapper struct {
 	ctx context.Context
 	grpc.ServerStream
@@ -60,3 +89,18 @@ type recvWrapper struct {
 func (r *recvWrapper) Context() context.Context {
 	return r.ctx
 }
+
+// validatingStream runs ValidateIDs and then ValidateFields on the received messages.
+type validatingStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if err := ValidateIDs(m); err != nil {
+		return err
+	}
+	return ValidateFields(m)
+}
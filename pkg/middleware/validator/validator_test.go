@@ -5,9 +5,13 @@ import (
 	"testing"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type pingService struct {
@@ -52,3 +56,36 @@ func (s *ValidatorTestSuite) TestStreamingPing() {
 	_, err := s.Client.PingStream(s.SimpleCtx())
 	s.Require().NoError(err)
 }
+
+func TestUnaryServerInterceptorSkipsValidatedRequests(t *testing.T) {
+	interceptor := UnaryServerInterceptor()
+
+	// an invalid request would be rejected if it was validated again
+	invalid := &testpb.PingRequest{SleepTimeMs: 20000}
+
+	_, err := interceptor(context.Background(), invalid, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	require.Error(t, err)
+
+	ctx := contextWithRequestIsValidated(context.Background())
+	_, err = interceptor(ctx, invalid, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		require.True(t, RequestIsValidatedFromContext(ctx))
+		return nil, nil
+	})
+	require.NoError(t, err)
+}
+
+func TestUnaryServerInterceptorReportsTheInvalidField(t *testing.T) {
+	_, err := UnaryServerInterceptor()(context.Background(), &openfgav1.CreateStoreRequest{}, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	info, ok := details[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	require.Equal(t, "INVALID_REQUEST_FIELD", info.GetReason())
+	require.Equal(t, "Name", info.GetMetadata()["field"])
+}
@@ -0,0 +1,168 @@
+// Package requestcontext holds the options that clients set on a request with `Openfga-*` request
+// headers (or gRPC metadata keys). The options are parsed and validated once per request by the
+// requestoptions middleware, and read from the context by the handlers and the resolvers.
+package requestcontext
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/pkg/dispatch"
+)
+
+const (
+	// HeaderPrefix is the prefix of the request headers of OpenFGA.
+	HeaderPrefix = "Openfga-"
+
+	// PriorityHeader sets the priority of a request, "normal" or "low" (see [dispatch.RequestPriority]).
+	PriorityHeader = "Openfga-Request-Priority"
+
+	// NoModelCacheHeader makes a request read its authorization model from the datastore, bypassing
+	// the model and type system caches, if set to "true". It is a privileged option.
+	NoModelCacheHeader = "Openfga-No-Model-Cache"
+
+	// ResolveNodeLimitHeader lowers the resolve node limit of a Check, ListObjects or ListUsers
+	// request to the given positive number. Limits above the limit of the server are ignored.
+	ResolveNodeLimitHeader = "Openfga-Resolve-Node-Limit"
+)
+
+// Headers are the request headers parsed into [Options].
+var Headers = []string{PriorityHeader, NoModelCacheHeader, ResolveNodeLimitHeader}
+
+// Options are the options of a request. The zero value is the behavior of requests without
+// option headers.
+type Options struct {
+	Priority dispatch.RequestPriority
+
+	// NoModelCache bypasses the caches of authorization models and type systems.
+	NoModelCache bool
+
+	// ResolveNodeLimit overrides the resolve node limit of the server if it is lower. 0 keeps the
+	// limit of the server.
+	ResolveNodeLimit uint32
+}
+
+// Privileged reports whether the options include one that only allowed clients may set, because
+// it makes the request more expensive for the server.
+func (o Options) Privileged() bool {
+	return o.NoModelCache
+}
+
+// EffectiveResolveNodeLimit returns the resolve node limit of the request given the limit of the
+// server: the override of the request if it is lower, and the limit of the server otherwise.
+func (o Options) EffectiveResolveNodeLimit(limit uint32) uint32 {
+	if o.ResolveNodeLimit > 0 && o.ResolveNodeLimit < limit {
+		return o.ResolveNodeLimit
+	}
+	return limit
+}
+
+// HeaderError is the error of [Parse] for an option header that has an invalid value or is set
+// more than once.
+type HeaderError struct {
+	Header string
+	// Value is the value of the header, or "" if the header is set more than once.
+	Value   string
+	message string
+}
+
+func (e *HeaderError) Error() string {
+	return e.message
+}
+
+// Parse returns the options set in the metadata of a request. It fails if an option header has an
+// invalid value or is set more than once. Other headers are ignored.
+func Parse(md metadata.MD) (Options, error) {
+	var opts Options
+
+	value, ok, err := headerValue(md, PriorityHeader)
+	if err != nil {
+		return Options{}, err
+	}
+	if ok {
+		priority, valid := dispatch.ParseRequestPriority(value)
+		if !valid {
+			return Options{}, &HeaderError{Header: PriorityHeader, Value: value, message: fmt.Sprintf("invalid '%s' header value '%s': expected 'normal' or 'low'", PriorityHeader, value)}
+		}
+		opts.Priority = priority
+	}
+
+	value, ok, err = headerValue(md, NoModelCacheHeader)
+	if err != nil {
+		return Options{}, err
+	}
+	if ok {
+		opts.NoModelCache, err = strconv.ParseBool(value)
+		if err != nil {
+			return Options{}, &HeaderError{Header: NoModelCacheHeader, Value: value, message: fmt.Sprintf("invalid '%s' header value '%s': expected 'true' or 'false'", NoModelCacheHeader, value)}
+		}
+	}
+
+	value, ok, err = headerValue(md, ResolveNodeLimitHeader)
+	if err != nil {
+		return Options{}, err
+	}
+	if ok {
+		limit, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || limit == 0 {
+			return Options{}, &HeaderError{Header: ResolveNodeLimitHeader, Value: value, message: fmt.Sprintf("invalid '%s' header value '%s': expected a positive integer", ResolveNodeLimitHeader, value)}
+		}
+		opts.ResolveNodeLimit = uint32(limit)
+	}
+
+	return opts, nil
+}
+
+// UnknownHeaders returns the keys of the metadata of a request that have the [HeaderPrefix] but are
+// neither option headers nor one of the known headers, in lowercase.
+func UnknownHeaders(md metadata.MD, known ...string) []string {
+	var unknown []string
+	for key := range md {
+		if !strings.HasPrefix(key, strings.ToLower(HeaderPrefix)) || isHeader(key, Headers) || isHeader(key, known) {
+			continue
+		}
+		unknown = append(unknown, key)
+	}
+	return unknown
+}
+
+func isHeader(key string, headers []string) bool {
+	for _, header := range headers {
+		if strings.EqualFold(key, header) {
+			return true
+		}
+	}
+	return false
+}
+
+func headerValue(md metadata.MD, header string) (string, bool, error) {
+	values := md.Get(header)
+	switch len(values) {
+	case 0:
+		return "", false, nil
+	case 1:
+		return strings.TrimSpace(values[0]), true, nil
+	default:
+		return "", false, &HeaderError{Header: header, message: fmt.Sprintf("header '%s' must be set once", header)}
+	}
+}
+
+type optionsContextKey struct{}
+
+// ContextWithOptions returns a context holding the options of a request. It also holds their
+// priority for [dispatch.RequestPriorityFromContext].
+func ContextWithOptions(ctx context.Context, opts Options) context.Context {
+	ctx = dispatch.ContextWithRequestPriority(ctx, opts.Priority)
+	return context.WithValue(ctx, optionsContextKey{}, opts)
+}
+
+// FromContext returns the options of the request of the context, or the zero options if the
+// context has none, e.g. if the requestoptions middleware is not installed.
+func FromContext(ctx context.Context) Options {
+	opts, _ := ctx.Value(optionsContextKey{}).(Options)
+	return opts
+}
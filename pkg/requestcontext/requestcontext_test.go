@@ -0,0 +1,63 @@
+package requestcontext
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/pkg/dispatch"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("parses_all_options", func(t *testing.T) {
+		opts, err := Parse(metadata.Pairs(
+			PriorityHeader, "low",
+			NoModelCacheHeader, "true",
+			ResolveNodeLimitHeader, " 7 ",
+		))
+		require.NoError(t, err)
+		require.Equal(t, Options{Priority: dispatch.RequestPriorityLow, NoModelCache: true, ResolveNodeLimit: 7}, opts)
+		require.True(t, opts.Privileged())
+	})
+
+	t.Run("no_options", func(t *testing.T) {
+		opts, err := Parse(nil)
+		require.NoError(t, err)
+		require.Equal(t, Options{}, opts)
+		require.False(t, opts.Privileged())
+	})
+
+	t.Run("rejects_invalid_values", func(t *testing.T) {
+		_, err := Parse(metadata.Pairs(NoModelCacheHeader, "yes"))
+		require.EqualError(t, err, "invalid 'Openfga-No-Model-Cache' header value 'yes': expected 'true' or 'false'")
+
+		_, err = Parse(metadata.Pairs(ResolveNodeLimitHeader, "-1"))
+		require.EqualError(t, err, "invalid 'Openfga-Resolve-Node-Limit' header value '-1': expected a positive integer")
+	})
+}
+
+func TestUnknownHeaders(t *testing.T) {
+	md := metadata.Pairs(
+		PriorityHeader, "low",
+		"Openfga-Check-At", "2024-01-01T00:00:00Z",
+		"Openfga-Priority", "low",
+		"Authorization", "Bearer key",
+	)
+	require.Equal(t, []string{"openfga-priority"}, UnknownHeaders(md, "Openfga-Check-At"))
+}
+
+func TestEffectiveResolveNodeLimit(t *testing.T) {
+	require.Equal(t, uint32(25), Options{}.EffectiveResolveNodeLimit(25))
+	require.Equal(t, uint32(10), Options{ResolveNodeLimit: 10}.EffectiveResolveNodeLimit(25))
+	require.Equal(t, uint32(25), Options{ResolveNodeLimit: 100}.EffectiveResolveNodeLimit(25))
+}
+
+func TestFromContext(t *testing.T) {
+	require.Equal(t, Options{}, FromContext(context.Background()))
+
+	ctx := ContextWithOptions(context.Background(), Options{Priority: dispatch.RequestPriorityLow, ResolveNodeLimit: 3})
+	require.Equal(t, Options{Priority: dispatch.RequestPriorityLow, ResolveNodeLimit: 3}, FromContext(ctx))
+	require.Equal(t, dispatch.RequestPriorityLow, dispatch.RequestPriorityFromContext(ctx))
+}
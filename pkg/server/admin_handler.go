@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
+	"github.com/openfga/openfga/pkg/server/commands"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+// refreshStoreResponse is the response of the refresh endpoint of [NewAdminHandler].
+type refreshStoreResponse struct {
+	AuthorizationModelID string `json:"authorization_model_id"`
+}
+
+// NewAdminHandler returns an HTTP handler for the administration endpoints of the server:
+//
+//   - POST /admin/stores/{store_id}/refresh calls [Server.RefreshStore] and responds with the
+//     ID of the latest model of the store, as {"authorization_model_id": "..."}.
+//   - GET /admin/stores/{store_id}/statistics calls [Server.GetStoreStatistics] and responds with
+//     the tuple counts and the quota of the store as JSON.
+//   - GET /admin/stores/{store_id}/relation-usage calls [Server.GetRelationUsage] and responds
+//     with the report as JSON.
+//   - GET /admin/stores/{store_id}/relation-statistics?object_type=document&relation=viewer calls
+//     [Server.GetRelationStatistics] and responds with the statistics as JSON.
+//   - GET /admin/stores/{store_id}/reachable-relations?user_type=user calls
+//     [Server.GetReachableRelations] with the optional authorization_model_id query parameter
+//     and responds with the relations as JSON.
+//   - POST /admin/stores/{store_id}/delete-tuples calls [Server.DeleteTuplesByFilter] with the
+//     [commands.DeleteTuplesByFilterRequest] of the JSON body, e.g.
+//     {"tuple_key": {"relation": "editor"}, "user_prefix": "service:old-bot", "dry_run": true},
+//     and responds with the counts as JSON. It requires the token set by [WithAdminToken] as a
+//     bearer token, and is disabled without one.
+//   - POST /admin/stores/{store_id}/migrate-model-schema calls
+//     [Server.MigrateAuthorizationModelSchema] with the
+//     [commands.MigrateAuthorizationModelSchemaRequest] of the JSON body, e.g. {"dry_run": true},
+//     and responds with the report of the migration as JSON.
+//   - GET /admin/index-advice calls [Server.AdviseIndexes] and responds with the query shapes
+//     and the recommended indexes as JSON.
+//
+// Apart from the bearer token of the endpoints that delete data, the endpoints are not
+// authenticated, so the handler must only be served on an address that is not reachable by the
+// clients of the API. The methods are called with the remote address of the HTTP request as
+// their peer, so the ones that only allow loopback callers (see [isLoopbackCaller]) check the
+// HTTP client.
+func NewAdminHandler(s *Server, opts ...AdminHandlerOption) http.Handler {
+	var cfg adminHandlerConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /admin/stores/{store_id}/refresh", func(w http.ResponseWriter, r *http.Request) {
+		modelID, err := s.RefreshStore(remotePeerContext(r), r.PathValue("store_id"))
+		if err != nil {
+			writeAdminError(w, r, err)
+			return
+		}
+		s.writeAdminResponse(w, refreshStoreResponse{AuthorizationModelID: modelID}, "the response of the refresh of a store")
+	})
+	mux.HandleFunc("GET /admin/stores/{store_id}/statistics", func(w http.ResponseWriter, r *http.Request) {
+		stats, err := s.GetStoreStatistics(remotePeerContext(r), r.PathValue("store_id"))
+		if err != nil {
+			writeAdminError(w, r, err)
+			return
+		}
+		s.writeAdminResponse(w, stats, "the statistics of a store")
+	})
+	mux.HandleFunc("GET /admin/stores/{store_id}/relation-usage", func(w http.ResponseWriter, r *http.Request) {
+		report, err := s.GetRelationUsage(remotePeerContext(r), r.PathValue("store_id"))
+		if err != nil {
+			writeAdminError(w, r, err)
+			return
+		}
+		s.writeAdminResponse(w, report, "the relation usage of a store")
+	})
+	mux.HandleFunc("GET /admin/stores/{store_id}/relation-statistics", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		stats, err := s.GetRelationStatistics(remotePeerContext(r), &commands.RelationStatisticsRequest{
+			StoreID:    r.PathValue("store_id"),
+			ObjectType: query.Get("object_type"),
+			Relation:   query.Get("relation"),
+		})
+		if err != nil {
+			writeAdminError(w, r, err)
+			return
+		}
+		s.writeAdminResponse(w, stats, "the statistics of a relation")
+	})
+	mux.HandleFunc("GET /admin/stores/{store_id}/reachable-relations", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		relations, err := s.GetReachableRelations(remotePeerContext(r), r.PathValue("store_id"), query.Get("authorization_model_id"), query.Get("user_type"))
+		if err != nil {
+			writeAdminError(w, r, err)
+			return
+		}
+		s.writeAdminResponse(w, relations, "the reachable relations of a user type")
+	})
+	mux.HandleFunc("POST /admin/stores/{store_id}/delete-tuples", func(w http.ResponseWriter, r *http.Request) {
+		if !cfg.authorized(r) {
+			writeAdminError(w, r, serverErrors.AdminTokenRequired)
+			return
+		}
+
+		var req commands.DeleteTuplesByFilterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAdminError(w, r, serverErrors.ValidationError(fmt.Errorf("invalid request body: %w", err)))
+			return
+		}
+		req.StoreID = r.PathValue("store_id")
+
+		resp, err := s.DeleteTuplesByFilter(remotePeerContext(r), &req)
+		if err != nil {
+			writeAdminError(w, r, err)
+			return
+		}
+		s.writeAdminResponse(w, resp, "the response of the deletion of tuples by filter")
+	})
+	mux.HandleFunc("POST /admin/stores/{store_id}/migrate-model-schema", func(w http.ResponseWriter, r *http.Request) {
+		var req commands.MigrateAuthorizationModelSchemaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAdminError(w, r, serverErrors.ValidationError(fmt.Errorf("invalid request body: %w", err)))
+			return
+		}
+		req.StoreID = r.PathValue("store_id")
+
+		resp, err := s.MigrateAuthorizationModelSchema(remotePeerContext(r), &req)
+		if err != nil {
+			writeAdminError(w, r, err)
+			return
+		}
+		s.writeAdminResponse(w, resp, "the response of the migration of a model schema")
+	})
+	mux.HandleFunc("GET /admin/index-advice", func(w http.ResponseWriter, r *http.Request) {
+		advice, err := s.AdviseIndexes(remotePeerContext(r))
+		if err != nil {
+			writeAdminError(w, r, err)
+			return
+		}
+		s.writeAdminResponse(w, advice, "the index advice of the datastore")
+	})
+	return mux
+}
+
+// writeAdminError writes err to w like the HTTP gateway writes the errors of the API.
+func writeAdminError(w http.ResponseWriter, r *http.Request, err error) {
+	intCode := serverErrors.ConvertToEncodedErrorCode(status.Convert(err))
+	httpmiddleware.CustomHTTPErrorHandler(r.Context(), w, r, serverErrors.NewEncodedError(intCode, err.Error()))
+}
+
+// writeAdminResponse writes resp to w as JSON, and logs a failure to write what it describes.
+func (s *Server) writeAdminResponse(w http.ResponseWriter, resp any, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.logger.Warn("failed to write "+description, zap.Error(err))
+	}
+}
+
+// AdminHandlerOption configures the handler returned by [NewAdminHandler].
+type AdminHandlerOption func(*adminHandlerConfig)
+
+type adminHandlerConfig struct {
+	token string
+}
+
+// WithAdminToken sets the bearer token required by the administration endpoints that delete
+// data. Without a token, those endpoints respond with PermissionDenied.
+func WithAdminToken(token string) AdminHandlerOption {
+	return func(cfg *adminHandlerConfig) {
+		cfg.token = token
+	}
+}
+
+// authorized reports whether the request carries the admin token as a bearer token.
+func (cfg *adminHandlerConfig) authorized(r *http.Request) bool {
+	if cfg.token == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(cfg.token)) == 1
+}
+
+// remotePeerContext returns the context of the request with the remote address of the request
+// as its peer, so that [isLoopbackCaller] checks the HTTP client rather than seeing an
+// in-process caller.
+func remotePeerContext(r *http.Request) context.Context {
+	addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr)
+	if err != nil {
+		// an address that cannot be parsed is not a loopback address
+		return peer.NewContext(r.Context(), &peer.Peer{Addr: &net.IPAddr{}})
+	}
+	return peer.NewContext(r.Context(), &peer.Peer{Addr: addr})
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/pkg/dispatch"
+	"github.com/openfga/openfga/pkg/middleware/validator"
+	"github.com/openfga/openfga/pkg/server/commands"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage/storagewrappers"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+// BatchCheckItem is one check of a [BatchCheckRequest]. Its correlation ID identifies its
+// result in the [BatchCheckResponse].
+type BatchCheckItem struct {
+	CorrelationID    string
+	TupleKey         *openfgav1.CheckRequestTupleKey
+	ContextualTuples []*openfgav1.TupleKey
+	Context          *structpb.Struct
+}
+
+// BatchCheckRequest is a list of checks on one store and authorization model, or its latest
+// model if AuthorizationModelID is empty, with one consistency preference.
+type BatchCheckRequest struct {
+	StoreID              string
+	AuthorizationModelID string
+	Checks               []*BatchCheckItem
+	Consistency          openfgav1.ConsistencyPreference
+}
+
+// BatchCheckResult is the result of one check of a batch: whether it is allowed, or the error
+// that failed it, e.g. a validation error of its tuple key.
+type BatchCheckResult struct {
+	Allowed bool
+	Error   error
+}
+
+// BatchCheckResponse are the results of the checks of a batch by correlation ID.
+type BatchCheckResponse struct {
+	AuthorizationModelID string
+	Results              map[string]*BatchCheckResult
+}
+
+// BatchCheck resolves the checks of a batch, e.g. the permissions needed to render a page, in
+// one call. The model is resolved, and the reader of the tuples is set up, once for the batch;
+// then the checks are resolved concurrently, with the check resolver of Check, within one limit
+// of concurrent datastore reads (see [WithMaxConcurrentReadsForCheck]) for the whole batch.
+//
+// A check that fails, e.g. because its tuple key is not valid under the model, has an error in
+// its result and does not fail the other checks. The batch itself fails if it is empty, has more
+// checks than the limit set by [WithMaxChecksPerBatchCheck], or has a missing or duplicate
+// correlation ID.
+func (s *Server) BatchCheck(ctx context.Context, req *BatchCheckRequest) (_ *BatchCheckResponse, err error) {
+	start := time.Now()
+
+	ctx, span := tracer.Start(ctx, "BatchCheck", trace.WithAttributes(
+		attribute.String("store_id", req.StoreID),
+		attribute.Int("checks", len(req.Checks)),
+		attribute.String("consistency", req.Consistency.String()),
+	))
+	defer span.End()
+	defer s.setErrorReason(&err)
+	defer s.inFlightRequests.start("BatchCheck")()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, "BatchCheck", req.StoreID, &err)
+
+	if err := s.validateBatchCheckRequest(req); err != nil {
+		return nil, err
+	}
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "BatchCheck",
+	})
+
+	typesys, err := s.resolveTypesystem(ctx, req.StoreID, req.AuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, checkDatastore, pointInTime, release, err := s.checkTupleReader(ctx, req.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// the checks of the batch share the limit of concurrent reads of one Check
+	checkDatastore = storagewrappers.NewBoundedConcurrencyTupleReader(checkDatastore, s.maxConcurrentReadsForCheck)
+
+	consistency := s.sessionConsistency(ctx, req.StoreID, req.Consistency)
+
+	const methodName = "batchcheck"
+	resolutionMetadata := graph.NewResolutionMetadata()
+	results := make(map[string]*BatchCheckResult, len(req.Checks))
+	var mu sync.Mutex
+
+	grp := &errgroup.Group{}
+	grp.SetLimit(int(s.resolveNodeBreadthLimit))
+	for _, item := range req.Checks {
+		grp.Go(func() error {
+			resp, metadata, err := commands.NewCheckCommand(
+				checkDatastore,
+				s.checkResolver,
+				typesys,
+				commands.WithCheckCommandLogger(s.logger),
+				commands.WithCheckCommandDatastoreHedging(s.datastoreHedgingPolicy),
+				commands.WithCheckCommandResolveNodeLimit(s.resolveNodeLimit),
+				commands.WithCheckCommandPointInTime(pointInTime),
+			).Execute(ctx, &openfgav1.CheckRequest{
+				StoreId:              req.StoreID,
+				AuthorizationModelId: typesys.GetAuthorizationModelID(),
+				TupleKey:             item.TupleKey,
+				ContextualTuples:     &openfgav1.ContextualTupleKeys{TupleKeys: item.ContextualTuples},
+				Context:              item.Context,
+				Consistency:          consistency,
+			})
+
+			result := &BatchCheckResult{}
+			if err != nil {
+				if errors.Is(err, serverErrors.ThrottledTimeout) {
+					throttledRequestCounter.WithLabelValues(s.serviceName, methodName, dispatch.RequestPriorityFromContext(ctx).String()).Inc()
+				}
+				s.setErrorReason(&err)
+				s.localizeError(ctx, &err)
+				result.Error = err
+			} else {
+				result.Allowed = resp.GetAllowed()
+				resolutionMetadata.Merge(metadata)
+				s.observeCheckCache(ctx, resp)
+				checkResultCounter.With(prometheus.Labels{allowedLabel: strconv.FormatBool(resp.GetAllowed())}).Inc()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[item.CorrelationID] = result
+			return nil
+		})
+	}
+	_ = grp.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	s.observeResolutionMetadata(ctx, methodName, consistency, start, resolutionMetadata)
+
+	return &BatchCheckResponse{
+		AuthorizationModelID: typesys.GetAuthorizationModelID(),
+		Results:              results,
+	}, nil
+}
+
+// validateBatchCheckRequest validates the fields of the batch, the checks themselves are
+// validated one by one.
+func (s *Server) validateBatchCheckRequest(req *BatchCheckRequest) error {
+	if err := validator.ValidateStoreID(req.StoreID); err != nil {
+		return err
+	}
+	if req.AuthorizationModelID != "" {
+		if err := validator.ValidateAuthorizationModelID(req.AuthorizationModelID); err != nil {
+			return err
+		}
+	}
+
+	if len(req.Checks) == 0 {
+		return serverErrors.BatchCheckEmpty
+	}
+	if len(req.Checks) > int(s.maxChecksPerBatchCheck) {
+		return serverErrors.BatchCheckSizeExceeded(len(req.Checks), int(s.maxChecksPerBatchCheck))
+	}
+
+	correlationIDs := make(map[string]struct{}, len(req.Checks))
+	for _, item := range req.Checks {
+		if item == nil || item.CorrelationID == "" {
+			return serverErrors.BatchCheckCorrelationIDMissing
+		}
+		if _, ok := correlationIDs[item.CorrelationID]; ok {
+			return serverErrors.BatchCheckDuplicateCorrelationID(item.CorrelationID)
+		}
+		correlationIDs[item.CorrelationID] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+var (
+	changelogNewestChangeAgeGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "changelog_newest_change_age_seconds",
+		Help:      "The age of the newest change in the changelog of each store allowlisted by WithPerStoreChangelogMetrics, as of the last run of the changelog metrics job. Stores without changes are absent.",
+	}, []string{"store_id"})
+
+	changelogRowsGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "changelog_rows",
+		Help:      "The number of changes in the changelog of all stores, as of the last run of the changelog metrics job. SQL datastores report the estimate of the database when it has one.",
+	})
+
+	changelogHorizonOffsetGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "changelog_horizon_offset_seconds",
+		Help:      "The changelog horizon offset of the server: ReadChanges does not return the changes more recent than it.",
+	})
+)
+
+// changelogMetricsReporter periodically sets the changelog gauges from the datastore, until the
+// server context is done or the reporter is closed. Each run reads the newest change of every
+// allowlisted store and the number of changes, so that it stays cheap for large changelogs.
+type changelogMetricsReporter struct {
+	server *Server
+	reader storage.ChangelogStatsReader
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newChangelogMetricsReporter(s *Server, reader storage.ChangelogStatsReader, interval time.Duration) *changelogMetricsReporter {
+	parent := s.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+
+	r := &changelogMetricsReporter{
+		server: s,
+		reader: reader,
+		cancel: cancel,
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			r.report(ctx, interval)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return r
+}
+
+// report sets the gauges once. A run is bounded by the interval, so that a slow datastore does
+// not stack up runs.
+func (r *changelogMetricsReporter) report(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for storeID := range r.server.perStoreChangelogMetricsAllowlist {
+		latest, err := r.reader.GetLatestChange(ctx, storeID)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			changelogNewestChangeAgeGauge.DeleteLabelValues(storeID)
+		case err != nil:
+			if ctx.Err() != nil {
+				return
+			}
+			r.server.logger.Warn("failed to read the newest change of a store for the changelog metrics", zap.String("store_id", storeID), zap.Error(err))
+		default:
+			changelogNewestChangeAgeGauge.WithLabelValues(storeID).Set(time.Since(latest).Seconds())
+		}
+	}
+
+	count, err := r.reader.CountChanges(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		r.server.logger.Warn("failed to count the changes for the changelog metrics", zap.Error(err))
+		return
+	}
+	changelogRowsGauge.Set(float64(count.Count))
+}
+
+// Close stops the reporter, waits for its last run to end and removes the series of its stores,
+// leaving the series of the other servers of the process.
+func (r *changelogMetricsReporter) Close() {
+	r.cancel()
+	r.wg.Wait()
+
+	for storeID := range r.server.perStoreChangelogMetricsAllowlist {
+		changelogNewestChangeAgeGauge.DeleteLabelValues(storeID)
+	}
+}
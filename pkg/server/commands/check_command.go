@@ -4,15 +4,15 @@ import (
 	"context"
 	"errors"
 	"math"
+	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 
 	"github.com/openfga/openfga/internal/condition"
 	"github.com/openfga/openfga/internal/graph"
 	"github.com/openfga/openfga/internal/validation"
 	"github.com/openfga/openfga/pkg/middleware/validator"
+	"github.com/openfga/openfga/pkg/requestcontext"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/storagewrappers"
@@ -35,6 +35,10 @@ type CheckQuery struct {
 
 	resolveNodeLimit   uint32
 	maxConcurrentReads uint32
+
+	hedgingPolicy *storagewrappers.HedgingPolicy
+
+	pointInTime time.Time
 }
 
 type CheckQueryOption func(*CheckQuery)
@@ -51,6 +55,23 @@ func WithCheckCommandMaxConcurrentReads(m uint32) CheckQueryOption {
 	}
 }
 
+// WithCheckCommandDatastoreHedging see server.WithDatastoreHedgingEnabled.
+func WithCheckCommandDatastoreHedging(policy *storagewrappers.HedgingPolicy) CheckQueryOption {
+	return func(c *CheckQuery) {
+		c.hedgingPolicy = policy
+	}
+}
+
+// WithCheckCommandPointInTime marks the Check as evaluated against the tuples as they were at
+// the given point in time, so that its results are cached apart from the results of the current
+// tuples. The datastore of the command must read the tuples as they were then, see
+// storagewrappers.NewPointInTimeTupleReader.
+func WithCheckCommandPointInTime(at time.Time) CheckQueryOption {
+	return func(c *CheckQuery) {
+		c.pointInTime = at
+	}
+}
+
 func WithCheckCommandLogger(l logger.Logger) CheckQueryOption {
 	return func(c *CheckQuery) {
 		c.logger = l
@@ -73,7 +94,9 @@ func NewCheckCommand(datastore storage.RelationshipTupleReader, checkResolver gr
 	return cmd
 }
 
-func (c *CheckQuery) Execute(ctx context.Context, req *openfgav1.CheckRequest) (*graph.ResolveCheckResponse, *graph.ResolveCheckRequestMetadata, error) {
+// Execute resolves the Check request, returning the metadata counting the work done to resolve it
+// along with the response.
+func (c *CheckQuery) Execute(ctx context.Context, req *openfgav1.CheckRequest) (*graph.ResolveCheckResponse, *graph.ResolutionMetadata, error) {
 	err := validateCheckRequest(ctx, req, c.typesys)
 	if err != nil {
 		return nil, nil, err
@@ -86,23 +109,32 @@ func (c *CheckQuery) Execute(ctx context.Context, req *openfgav1.CheckRequest) (
 		ContextualTuples:     req.GetContextualTuples().GetTupleKeys(),
 		Context:              req.GetContext(),
 		VisitedPaths:         make(map[string]struct{}),
-		RequestMetadata:      graph.NewCheckRequestMetadata(c.resolveNodeLimit),
+		RequestMetadata:      graph.NewCheckRequestMetadata(requestcontext.FromContext(ctx).EffectiveResolveNodeLimit(c.resolveNodeLimit)),
 		Consistency:          req.GetConsistency(),
+		PointInTime:          c.pointInTime,
 	}
 
-	ctx = buildCheckContext(ctx, c.typesys, c.datastore, c.maxConcurrentReads, resolveCheckRequest.GetContextualTuples())
+	resolutionMetadata := resolveCheckRequest.GetRequestMetadata().ResolutionMetadata
+
+	datastore := storagewrappers.NewQueryCountingTupleReader(c.datastore, resolutionMetadata)
+	ctx = buildCheckContext(ctx, c.typesys, datastore, c.maxConcurrentReads, c.hedgingPolicy, resolveCheckRequest.GetContextualTuples())
 
 	resp, err := c.checkResolver.ResolveCheck(ctx, &resolveCheckRequest)
 	if err != nil {
-		return nil, nil, translateError(resolveCheckRequest.GetRequestMetadata(), err)
+		return nil, nil, translateError(resolutionMetadata, err)
 	}
-	return resp, resolveCheckRequest.GetRequestMetadata(), nil
+
+	// the datastore queries are accumulated along the evaluated paths, so the count is known once resolved
+	if resp.GetResolutionMetadata() != nil {
+		resolutionMetadata.DatastoreQueryCount.Store(resp.GetResolutionMetadata().DatastoreQueryCount)
+	}
+	return resp, resolutionMetadata, nil
 }
 
 func validateCheckRequest(ctx context.Context, req *openfgav1.CheckRequest, typesys *typesystem.TypeSystem) error {
 	if !validator.RequestIsValidatedFromContext(ctx) {
 		if err := req.Validate(); err != nil {
-			return status.Error(codes.InvalidArgument, err.Error())
+			return serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -120,23 +152,27 @@ func validateCheckRequest(ctx context.Context, req *openfgav1.CheckRequest, type
 	return nil
 }
 
-func buildCheckContext(ctx context.Context, typesys *typesystem.TypeSystem, datastore storage.RelationshipTupleReader, maxconcurrentreads uint32, contextualTuples []*openfgav1.TupleKey) context.Context {
+// buildCheckContext returns the context carrying the typesystem and the tuple reader of a Check. If
+// hedging is not nil, the reads are hedged around the bound on the concurrent reads, so that the
+// hedged reads take their share of the concurrent reads too.
+func buildCheckContext(ctx context.Context, typesys *typesystem.TypeSystem, datastore storage.RelationshipTupleReader, maxconcurrentreads uint32, hedging *storagewrappers.HedgingPolicy, contextualTuples []*openfgav1.TupleKey) context.Context {
 	ctx = typesystem.ContextWithTypesystem(ctx, typesys)
 
 	// TODO the order is wrong, see https://github.com/openfga/openfga/issues/1394
-	ctx = storage.ContextWithRelationshipTupleReader(ctx,
-		storagewrappers.NewBoundedConcurrencyTupleReader(
-			storagewrappers.NewCombinedTupleReader(
-				datastore,
-				contextualTuples,
-			),
-			maxconcurrentreads,
+	var reader storage.RelationshipTupleReader = storagewrappers.NewBoundedConcurrencyTupleReader(
+		storagewrappers.NewCombinedTupleReader(
+			datastore,
+			contextualTuples,
 		),
+		maxconcurrentreads,
 	)
-	return ctx
+	if hedging != nil {
+		reader = storagewrappers.NewHedgedTupleReader(reader, hedging)
+	}
+	return storage.ContextWithRelationshipTupleReader(ctx, reader)
 }
 
-func translateError(reqMetadata *graph.ResolveCheckRequestMetadata, err error) error {
+func translateError(resolutionMetadata *graph.ResolutionMetadata, err error) error {
 	if errors.Is(err, graph.ErrResolutionDepthExceeded) {
 		return serverErrors.AuthorizationModelResolutionTooComplex
 	}
@@ -145,7 +181,7 @@ func translateError(reqMetadata *graph.ResolveCheckRequestMetadata, err error) e
 		return serverErrors.ValidationError(err)
 	}
 
-	if errors.Is(err, context.DeadlineExceeded) && reqMetadata.WasThrottled.Load() {
+	if errors.Is(err, context.DeadlineExceeded) && resolutionMetadata.WasThrottled() {
 		return serverErrors.ThrottledTimeout
 	}
 
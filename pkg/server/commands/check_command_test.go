@@ -2,7 +2,6 @@ package commands
 
 import (
 	"context"
-	"sync/atomic"
 	"testing"
 
 	"github.com/oklog/ulid/v2"
@@ -134,7 +133,7 @@ type doc
 				},
 			},
 		})
-		require.ErrorContains(t, err, "type 'user' is not an allowed type restriction for 'doc#viewer_computed'")
+		require.ErrorContains(t, err, "relation 'doc#viewer_computed' is not assignable")
 	})
 
 	t.Run("no_validation_error_and_call_to_resolver_goes_through", func(t *testing.T) {
@@ -160,6 +159,29 @@ type doc
 		})
 		require.ErrorIs(t, err, errors.ErrUnknown)
 	})
+
+	t.Run("counts_the_datastore_queries_by_kind", func(t *testing.T) {
+		cmd := NewCheckCommand(mockDatastore, mockCheckResolver, ts)
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(nil, storage.ErrNotFound)
+		mockCheckResolver.EXPECT().ResolveCheck(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(ctx context.Context, req *graph.ResolveCheckRequest) (*graph.ResolveCheckResponse, error) {
+				ds, _ := storage.RelationshipTupleReaderFromContext(ctx)
+				_, err := ds.ReadUserTuple(ctx, req.GetStoreID(), req.GetTupleKey(), storage.ReadUserTupleOptions{})
+				require.ErrorIs(t, err, storage.ErrNotFound)
+				return &graph.ResolveCheckResponse{ResolutionMetadata: &graph.ResolveCheckResponseMetadata{DatastoreQueryCount: 1}}, nil
+			})
+		_, metadata, err := cmd.Execute(context.Background(), &openfgav1.CheckRequest{
+			StoreId:              ulid.Make().String(),
+			AuthorizationModelId: ulid.Make().String(),
+			TupleKey:             tuple.NewCheckRequestTupleKey("doc:1", "viewer", "user:1"),
+		})
+		require.NoError(t, err)
+		require.Equal(t, uint32(1), metadata.QueryCount(storagewrappers.ReadUserTupleQuery))
+		require.Zero(t, metadata.QueryCount(storagewrappers.ReadQuery))
+	})
 }
 
 func TestBuildCheckContext(t *testing.T) {
@@ -180,7 +202,7 @@ type doc
 	ctx := context.Background()
 
 	// act
-	actualContext := buildCheckContext(ctx, ts, mockDatastore, 1, contextualTuples)
+	actualContext := buildCheckContext(ctx, ts, mockDatastore, 1, nil, contextualTuples)
 
 	// assert
 	tsFromContext, ok := typesystem.TypesystemFromContext(actualContext)
@@ -199,19 +221,14 @@ type doc
 }
 
 func TestTranslateError(t *testing.T) {
-	throttledRequestMetadata := &graph.ResolveCheckRequestMetadata{
-		WasThrottled: &atomic.Bool{},
-	}
-	throttledRequestMetadata.WasThrottled.Store(true)
+	throttledRequestMetadata := graph.NewResolutionMetadata()
+	throttledRequestMetadata.ThrottleCount.Add(1)
 
-	nonThrottledRequestMedata := &graph.ResolveCheckRequestMetadata{
-		WasThrottled: &atomic.Bool{},
-	}
-	nonThrottledRequestMedata.WasThrottled.Store(false)
+	nonThrottledRequestMedata := graph.NewResolutionMetadata()
 
 	testcases := map[string]struct {
 		inputError    error
-		reqMetadata   *graph.ResolveCheckRequestMetadata
+		reqMetadata   *graph.ResolutionMetadata
 		expectedError error
 	}{
 		`1`: {
@@ -2,10 +2,11 @@ package commands
 
 import (
 	"context"
+	"errors"
 
-	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 
+	"github.com/openfga/openfga/pkg/id"
 	"github.com/openfga/openfga/pkg/logger"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
@@ -14,6 +15,8 @@ import (
 type CreateStoreCommand struct {
 	storesBackend storage.StoresBackend
 	logger        logger.Logger
+	idGenerator   id.Generator
+	uniqueNames   storage.UniqueStoreNamesBackend
 }
 
 type CreateStoreCmdOption func(*CreateStoreCommand)
@@ -24,6 +27,21 @@ func WithCreateStoreCmdLogger(l logger.Logger) CreateStoreCmdOption {
 	}
 }
 
+// WithCreateStoreCmdIDGenerator sets the generator of new store IDs.
+func WithCreateStoreCmdIDGenerator(g id.Generator) CreateStoreCmdOption {
+	return func(c *CreateStoreCommand) {
+		c.idGenerator = g
+	}
+}
+
+// WithCreateStoreCmdUniqueNames makes the command create the store with the backend, which
+// rejects the names that another store already has.
+func WithCreateStoreCmdUniqueNames(backend storage.UniqueStoreNamesBackend) CreateStoreCmdOption {
+	return func(c *CreateStoreCommand) {
+		c.uniqueNames = backend
+	}
+}
+
 func NewCreateStoreCommand(
 	storesBackend storage.StoresBackend,
 	opts ...CreateStoreCmdOption,
@@ -31,6 +49,7 @@ func NewCreateStoreCommand(
 	cmd := &CreateStoreCommand{
 		storesBackend: storesBackend,
 		logger:        logger.NewNoopLogger(),
+		idGenerator:   id.NewULIDGenerator(),
 	}
 
 	for _, opt := range opts {
@@ -40,11 +59,28 @@ func NewCreateStoreCommand(
 }
 
 func (s *CreateStoreCommand) Execute(ctx context.Context, req *openfgav1.CreateStoreRequest) (*openfgav1.CreateStoreResponse, error) {
-	store, err := s.storesBackend.CreateStore(ctx, &openfgav1.Store{
-		Id:   ulid.Make().String(),
+	storeID := s.idGenerator.NewStoreID()
+	if err := id.Validate(storeID); err != nil {
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	newStore := &openfgav1.Store{
+		Id:   storeID,
 		Name: req.GetName(),
-	})
+	}
+
+	var store *openfgav1.Store
+	var err error
+	if s.uniqueNames != nil {
+		store, err = s.uniqueNames.CreateStoreWithUniqueName(ctx, newStore)
+	} else {
+		store, err = s.storesBackend.CreateStore(ctx, newStore)
+	}
 	if err != nil {
+		var conflict *storage.StoreNameConflictError
+		if errors.As(err, &conflict) {
+			return nil, serverErrors.StoreNameAlreadyExists(conflict.Name, conflict.StoreID)
+		}
 		return nil, serverErrors.HandleError("", err)
 	}
 
@@ -0,0 +1,239 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	tupleUtils "github.com/openfga/openfga/pkg/tuple"
+)
+
+// DeleteTuplesByFilterRequest selects the tuples of a store to delete.
+type DeleteTuplesByFilterRequest struct {
+	StoreID string
+	// TupleKey selects the tuples as the tuple key of a Read request does, except that the object
+	// type is optional: without it, every tuple of the store is read and matched against the
+	// relation and the user, up to the limit of tuples scanned by the command.
+	TupleKey *openfgav1.ReadRequestTupleKey `json:"tuple_key,omitempty"`
+	// UserPrefix, if set, only selects the tuples whose user starts with it, e.g.
+	// "service:old-bot".
+	UserPrefix string `json:"user_prefix,omitempty"`
+	// DryRun counts the tuples that would be deleted without deleting them.
+	DryRun bool `json:"dry_run,omitempty"`
+	// ContinuationToken resumes the scan of a previous execution that stopped at the limit of
+	// tuples scanned, see [DeleteTuplesByFilterResponse].
+	ContinuationToken string `json:"continuation_token,omitempty"`
+	// Progress, if set, is called after each batch of tuples is deleted (or counted, for a dry
+	// run) with the totals so far.
+	Progress func(DeleteTuplesByFilterResponse) `json:"-"`
+}
+
+// DeleteTuplesByFilterResponse reports the tuples deleted by a DeleteTuplesByFilterCommand.
+type DeleteTuplesByFilterResponse struct {
+	// Matched is the number of tuples selected by the filter, up to the limit of the command.
+	Matched int `json:"matched"`
+	// Deleted is the number of tuples deleted, Matched unless the request is a dry run.
+	Deleted int `json:"deleted"`
+	// Scanned is the number of tuples read to find the ones selected by the filter.
+	Scanned int `json:"scanned"`
+	// Truncated is true if more tuples are selected than the limit of the command, or more tuples
+	// are left to scan than its limit of tuples scanned, in which case the command can be executed
+	// again to delete the next ones.
+	Truncated bool `json:"truncated"`
+	// ContinuationToken is set if the execution stopped at the limit of tuples scanned, to resume
+	// the scan where it stopped.
+	ContinuationToken string `json:"continuation_token,omitempty"`
+}
+
+// DeleteTuplesByFilterCommand deletes the tuples of a store selected by a filter, in batches
+// written like the deletes of a Write request, so that they are recorded in the changelog.
+type DeleteTuplesByFilterCommand struct {
+	datastore  storage.OpenFGADatastore
+	logger     logger.Logger
+	maxTuples  int
+	maxScanned int
+	writeOpts  []WriteCommandOption
+}
+
+type DeleteTuplesByFilterOption func(*DeleteTuplesByFilterCommand)
+
+func WithDeleteTuplesByFilterLogger(l logger.Logger) DeleteTuplesByFilterOption {
+	return func(c *DeleteTuplesByFilterCommand) {
+		c.logger = l
+	}
+}
+
+// WithDeleteTuplesByFilterMaxTuples sets the maximum number of tuples deleted by an execution of
+// the command. 0 means no limit.
+func WithDeleteTuplesByFilterMaxTuples(limit uint32) DeleteTuplesByFilterOption {
+	return func(c *DeleteTuplesByFilterCommand) {
+		c.maxTuples = int(limit)
+	}
+}
+
+// WithDeleteTuplesByFilterMaxScannedTuples sets the maximum number of tuples read by an execution
+// of the command to find the ones selected by the filter. It is checked after each page, so an
+// execution reads at most a page more. 0 means no limit.
+func WithDeleteTuplesByFilterMaxScannedTuples(limit uint32) DeleteTuplesByFilterOption {
+	return func(c *DeleteTuplesByFilterCommand) {
+		c.maxScanned = int(limit)
+	}
+}
+
+// WithDeleteTuplesByFilterWriteOptions sets the options of the command deleting the batches of
+// tuples.
+func WithDeleteTuplesByFilterWriteOptions(opts ...WriteCommandOption) DeleteTuplesByFilterOption {
+	return func(c *DeleteTuplesByFilterCommand) {
+		c.writeOpts = opts
+	}
+}
+
+func NewDeleteTuplesByFilterCommand(datastore storage.OpenFGADatastore, opts ...DeleteTuplesByFilterOption) *DeleteTuplesByFilterCommand {
+	c := &DeleteTuplesByFilterCommand{
+		datastore: datastore,
+		logger:    logger.NewNoopLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Execute reads the tuples of the store page by page, only those of the object type of the filter
+// if it has one, and deletes the tuples that match the filter in batches of the maximum number of
+// tuples per write of the datastore, until the limits of the command are reached. A failed batch
+// fails the execution, but the batches deleted before it, as reported to the Progress callback of
+// the request, stay deleted.
+func (c *DeleteTuplesByFilterCommand) Execute(ctx context.Context, req *DeleteTuplesByFilterRequest) (*DeleteTuplesByFilterResponse, error) {
+	tk := req.TupleKey
+	if tk.GetObject() == "" && tk.GetRelation() == "" && tk.GetUser() == "" && req.UserPrefix == "" {
+		return nil, serverErrors.ValidationError(
+			fmt.Errorf("the filter must select the tuples by object, relation or user, use DeleteStore to delete every tuple of a store"),
+		)
+	}
+
+	objectType, _ := tupleUtils.SplitObject(tk.GetObject())
+	if tk.GetObject() != "" && objectType == "" {
+		return nil, serverErrors.ValidationError(
+			fmt.Errorf("the object of the filter must be an object type, e.g. 'document:', or an object, e.g. 'document:1'"),
+		)
+	}
+
+	// the datastores require an object type to filter by relation or user, so the filters without
+	// one scan the tuples of the whole store
+	readKey := &openfgav1.TupleKey{}
+	if objectType != "" {
+		readKey = tupleUtils.ConvertReadRequestTupleKeyToTupleKey(tk)
+	}
+
+	batchSize := c.datastore.MaxTuplesPerWrite()
+	writer := NewWriteCommand(c.datastore, c.writeOpts...)
+
+	resp := &DeleteTuplesByFilterResponse{}
+	batch := make([]*openfgav1.TupleKeyWithoutCondition, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if !req.DryRun {
+			_, err := writer.Execute(ctx, &openfgav1.WriteRequest{
+				StoreId: req.StoreID,
+				Deletes: &openfgav1.WriteRequestDeletes{TupleKeys: batch},
+			})
+			if err != nil {
+				return err
+			}
+			resp.Deleted += len(batch)
+		}
+		batch = batch[:0]
+
+		if req.Progress != nil {
+			req.Progress(*resp)
+		}
+		return nil
+	}
+
+	opts := storage.ReadPageOptions{
+		Pagination: storage.NewPaginationOptions(int32(batchSize), req.ContinuationToken),
+		// the tuples read from a replica that lags behind may no longer exist
+		Consistency: storage.ConsistencyOptions{Preference: openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY},
+	}
+	for {
+		tuples, contToken, err := c.datastore.ReadPage(ctx, req.StoreID, readKey, opts)
+		if err != nil {
+			return nil, serverErrors.HandleError("", err)
+		}
+		resp.Scanned += len(tuples)
+
+		for _, t := range tuples {
+			if !matchesDeleteFilter(t.GetKey(), tk, req.UserPrefix) {
+				continue
+			}
+			if c.maxTuples > 0 && resp.Matched == c.maxTuples {
+				resp.Truncated = true
+				break
+			}
+
+			resp.Matched++
+			batch = append(batch, tupleUtils.TupleKeyToTupleKeyWithoutCondition(t.GetKey()))
+			if len(batch) == batchSize {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// the pages resume after the last tuple read, so the deleted tuples do not shift them
+		if resp.Truncated || len(contToken) == 0 {
+			break
+		}
+		if c.maxScanned > 0 && resp.Scanned >= c.maxScanned {
+			resp.Truncated = true
+			resp.ContinuationToken = string(contToken)
+			break
+		}
+		opts.Pagination.From = string(contToken)
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	c.logger.InfoWithContext(ctx, "deleted the tuples of a store selected by a filter",
+		zap.String("store_id", req.StoreID),
+		zap.Int("matched", resp.Matched),
+		zap.Int("deleted", resp.Deleted),
+		zap.Int("scanned", resp.Scanned),
+		zap.Bool("truncated", resp.Truncated),
+	)
+	return resp, nil
+}
+
+// matchesDeleteFilter reports whether the tuple key is selected by the filter of a
+// DeleteTuplesByFilterRequest.
+func matchesDeleteFilter(key *openfgav1.TupleKey, filter *openfgav1.ReadRequestTupleKey, userPrefix string) bool {
+	if object := filter.GetObject(); object != "" {
+		objectType, objectID := tupleUtils.SplitObject(object)
+		if objectID == "" {
+			if tupleUtils.GetType(key.GetObject()) != objectType {
+				return false
+			}
+		} else if key.GetObject() != object {
+			return false
+		}
+	}
+	if relation := filter.GetRelation(); relation != "" && key.GetRelation() != relation {
+		return false
+	}
+	if user := filter.GetUser(); user != "" && key.GetUser() != user {
+		return false
+	}
+	return strings.HasPrefix(key.GetUser(), userPrefix)
+}
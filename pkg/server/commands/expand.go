@@ -3,6 +3,8 @@ package commands
 import (
 	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"golang.org/x/sync/errgroup"
@@ -15,10 +17,31 @@ import (
 	"github.com/openfga/openfga/pkg/typesystem"
 )
 
+// ExpandTruncatedNodeName is the name of the marker node that replaces the part of an
+// Expand tree that was cut because it exceeded the result limit.
+const ExpandTruncatedNodeName = "truncated"
+
+// ExpandTruncationCause is the reason why an Expand tree is incomplete.
+type ExpandTruncationCause string
+
+const (
+	// ExpandTruncationCauseNone means that the tree is complete.
+	ExpandTruncationCauseNone ExpandTruncationCause = ""
+	// ExpandTruncationCauseMaxLeafUsers means that the leaves of the tree held more users
+	// (or tupleset computed usersets) than allowed.
+	ExpandTruncationCauseMaxLeafUsers ExpandTruncationCause = "max_leaf_users"
+	// ExpandTruncationCauseMaxNodes means that the tree had more nodes than allowed.
+	ExpandTruncationCauseMaxNodes ExpandTruncationCause = "max_nodes"
+)
+
 // ExpandQuery resolves a target TupleKey into a UsersetTree by expanding type definitions.
 type ExpandQuery struct {
-	logger    logger.Logger
-	datastore storage.OpenFGADatastore
+	logger       logger.Logger
+	datastore    storage.OpenFGADatastore
+	tupleReader  storage.RelationshipTupleReader
+	maxLeafUsers uint32
+	maxNodes     uint32
+	strictLimit  bool
 }
 
 type ExpandQueryOption func(*ExpandQuery)
@@ -29,11 +52,29 @@ func WithExpandQueryLogger(l logger.Logger) ExpandQueryOption {
 	}
 }
 
+// WithExpandResultLimit see server.WithExpandResultLimit.
+func WithExpandResultLimit(maxLeafUsers, maxNodes uint32, strict bool) ExpandQueryOption {
+	return func(eq *ExpandQuery) {
+		eq.maxLeafUsers = maxLeafUsers
+		eq.maxNodes = maxNodes
+		eq.strictLimit = strict
+	}
+}
+
+// WithExpandQueryTupleReader reads the tuples with the given reader instead of the datastore,
+// e.g. a storagewrappers.BoundedConcurrencyTupleReader shared by the Expand queries of a server.
+func WithExpandQueryTupleReader(r storage.RelationshipTupleReader) ExpandQueryOption {
+	return func(eq *ExpandQuery) {
+		eq.tupleReader = r
+	}
+}
+
 // NewExpandQuery creates a new ExpandQuery using the supplied backends for retrieving data.
 func NewExpandQuery(datastore storage.OpenFGADatastore, opts ...ExpandQueryOption) *ExpandQuery {
 	eq := &ExpandQuery{
-		datastore: datastore,
-		logger:    logger.NewNoopLogger(),
+		datastore:   datastore,
+		tupleReader: datastore,
+		logger:      logger.NewNoopLogger(),
 	}
 
 	for _, opt := range opts {
@@ -43,6 +84,13 @@ func NewExpandQuery(datastore storage.OpenFGADatastore, opts ...ExpandQueryOptio
 }
 
 func (q *ExpandQuery) Execute(ctx context.Context, req *openfgav1.ExpandRequest) (*openfgav1.ExpandResponse, error) {
+	resp, _, err := q.ExecuteWithTruncationCause(ctx, req)
+	return resp, err
+}
+
+// ExecuteWithTruncationCause is like Execute, but it also reports whether, and why, the
+// tree was truncated by the result limit.
+func (q *ExpandQuery) ExecuteWithTruncationCause(ctx context.Context, req *openfgav1.ExpandRequest) (*openfgav1.ExpandResponse, ExpandTruncationCause, error) {
 	store := req.GetStoreId()
 	modelID := req.GetAuthorizationModelId()
 	tupleKey := req.GetTupleKey()
@@ -50,7 +98,7 @@ func (q *ExpandQuery) Execute(ctx context.Context, req *openfgav1.ExpandRequest)
 	relation := tupleKey.GetRelation()
 
 	if object == "" || relation == "" {
-		return nil, serverErrors.InvalidExpandInput
+		return nil, ExpandTruncationCauseNone, serverErrors.InvalidExpandInput
 	}
 
 	tk := tupleUtils.NewTupleKey(object, relation, "")
@@ -58,56 +106,62 @@ func (q *ExpandQuery) Execute(ctx context.Context, req *openfgav1.ExpandRequest)
 	model, err := q.datastore.ReadAuthorizationModel(ctx, store, modelID)
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
-			return nil, serverErrors.AuthorizationModelNotFound(modelID)
+			return nil, ExpandTruncationCauseNone, serverErrors.AuthorizationModelNotFound(modelID)
 		}
 
-		return nil, serverErrors.HandleError("", err)
+		return nil, ExpandTruncationCauseNone, serverErrors.HandleError("", err)
 	}
 
 	if !typesystem.IsSchemaVersionSupported(model.GetSchemaVersion()) {
-		return nil, serverErrors.ValidationError(typesystem.ErrInvalidSchemaVersion)
+		return nil, ExpandTruncationCauseNone, serverErrors.ValidationError(typesystem.ErrInvalidSchemaVersion)
 	}
 
 	typesys, err := typesystem.NewAndValidate(ctx, model)
 	if err != nil {
-		return nil, serverErrors.ValidationError(typesystem.ErrInvalidModel)
+		return nil, ExpandTruncationCauseNone, serverErrors.ValidationError(typesystem.ErrInvalidModel)
 	}
 
 	if err = validation.ValidateObject(typesys, tk); err != nil {
-		return nil, serverErrors.ValidationError(err)
+		return nil, ExpandTruncationCauseNone, serverErrors.ValidationError(err)
 	}
 
 	err = validation.ValidateRelation(typesys, tk)
 	if err != nil {
-		return nil, serverErrors.ValidationError(err)
+		return nil, ExpandTruncationCauseNone, serverErrors.ValidationError(err)
 	}
 
 	objectType := tupleUtils.GetType(object)
 	rel, err := typesys.GetRelation(objectType, relation)
 	if err != nil {
 		if errors.Is(err, typesystem.ErrObjectTypeUndefined) {
-			return nil, serverErrors.TypeNotFound(objectType)
+			return nil, ExpandTruncationCauseNone, serverErrors.TypeNotFound(objectType)
 		}
 
 		if errors.Is(err, typesystem.ErrRelationUndefined) {
-			return nil, serverErrors.RelationNotFound(relation, objectType, tk)
+			return nil, ExpandTruncationCauseNone, serverErrors.RelationNotFound(relation, objectType, tk)
 		}
 
-		return nil, serverErrors.HandleError("", err)
+		return nil, ExpandTruncationCauseNone, serverErrors.HandleError("", err)
 	}
 
 	userset := rel.GetRewrite()
 
-	root, err := q.resolveUserset(ctx, store, userset, tk, typesys, req.GetConsistency())
+	budget := &expandBudget{
+		maxLeafUsers: q.maxLeafUsers,
+		maxNodes:     q.maxNodes,
+		strict:       q.strictLimit,
+	}
+
+	root, err := q.resolveUserset(ctx, store, userset, tk, typesys, req.GetConsistency(), budget)
 	if err != nil {
-		return nil, err
+		return nil, ExpandTruncationCauseNone, err
 	}
 
 	return &openfgav1.ExpandResponse{
 		Tree: &openfgav1.UsersetTree{
 			Root: root,
 		},
-	}, nil
+	}, budget.truncationCause(), nil
 }
 
 func (q *ExpandQuery) resolveUserset(
@@ -117,30 +171,40 @@ func (q *ExpandQuery) resolveUserset(
 	tk *openfgav1.TupleKey,
 	typesys *typesystem.TypeSystem,
 	consistency openfgav1.ConsistencyPreference,
+	budget *expandBudget,
 ) (*openfgav1.UsersetTree_Node, error) {
 	ctx, span := tracer.Start(ctx, "resolveUserset")
 	defer span.End()
 
+	// Nodes are counted before they are resolved, so that a subtree over the limit is not read at all.
+	ok, err := budget.takeNode()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return truncatedNode(), nil
+	}
+
 	switch us := userset.GetUserset().(type) {
 	case nil, *openfgav1.Userset_This:
-		return q.resolveThis(ctx, store, tk, typesys, consistency)
+		return q.resolveThis(ctx, store, tk, typesys, consistency, budget)
 	case *openfgav1.Userset_ComputedUserset:
 		return q.resolveComputedUserset(ctx, us.ComputedUserset, tk)
 	case *openfgav1.Userset_TupleToUserset:
-		return q.resolveTupleToUserset(ctx, store, us.TupleToUserset, tk, typesys, consistency)
+		return q.resolveTupleToUserset(ctx, store, us.TupleToUserset, tk, typesys, consistency, budget)
 	case *openfgav1.Userset_Union:
-		return q.resolveUnionUserset(ctx, store, us.Union, tk, typesys, consistency)
+		return q.resolveUnionUserset(ctx, store, us.Union, tk, typesys, consistency, budget)
 	case *openfgav1.Userset_Difference:
-		return q.resolveDifferenceUserset(ctx, store, us.Difference, tk, typesys, consistency)
+		return q.resolveDifferenceUserset(ctx, store, us.Difference, tk, typesys, consistency, budget)
 	case *openfgav1.Userset_Intersection:
-		return q.resolveIntersectionUserset(ctx, store, us.Intersection, tk, typesys, consistency)
+		return q.resolveIntersectionUserset(ctx, store, us.Intersection, tk, typesys, consistency, budget)
 	default:
 		return nil, serverErrors.UnsupportedUserSet
 	}
 }
 
 // resolveThis resolves a DirectUserset into a leaf node containing a distinct set of users with that relation.
-func (q *ExpandQuery) resolveThis(ctx context.Context, store string, tk *openfgav1.TupleKey, typesys *typesystem.TypeSystem, consistency openfgav1.ConsistencyPreference) (*openfgav1.UsersetTree_Node, error) {
+func (q *ExpandQuery) resolveThis(ctx context.Context, store string, tk *openfgav1.TupleKey, typesys *typesystem.TypeSystem, consistency openfgav1.ConsistencyPreference, budget *expandBudget) (*openfgav1.UsersetTree_Node, error) {
 	ctx, span := tracer.Start(ctx, "resolveThis")
 	defer span.End()
 
@@ -149,7 +213,7 @@ func (q *ExpandQuery) resolveThis(ctx context.Context, store string, tk *openfga
 			Preference: consistency,
 		},
 	}
-	tupleIter, err := q.datastore.Read(ctx, store, tk, opts)
+	tupleIter, err := q.tupleReader.Read(ctx, store, tk, opts)
 	if err != nil {
 		return nil, serverErrors.HandleError("", err)
 	}
@@ -161,7 +225,8 @@ func (q *ExpandQuery) resolveThis(ctx context.Context, store string, tk *openfga
 	defer filteredIter.Stop()
 
 	distinctUsers := make(map[string]bool)
-	for {
+	truncated := false
+	for !truncated {
 		tk, err := filteredIter.Next(ctx)
 		if err != nil {
 			if err == storage.ErrIteratorDone {
@@ -169,6 +234,17 @@ func (q *ExpandQuery) resolveThis(ctx context.Context, store string, tk *openfga
 			}
 			return nil, serverErrors.HandleError("", err)
 		}
+		if distinctUsers[tk.GetUser()] {
+			continue
+		}
+		ok, err := budget.takeLeafUser()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			truncated = true
+			break
+		}
 		distinctUsers[tk.GetUser()] = true
 	}
 
@@ -177,7 +253,7 @@ func (q *ExpandQuery) resolveThis(ctx context.Context, store string, tk *openfga
 		users = append(users, u)
 	}
 
-	return &openfgav1.UsersetTree_Node{
+	node := &openfgav1.UsersetTree_Node{
 		Name: toObjectRelation(tk),
 		Value: &openfgav1.UsersetTree_Node_Leaf{
 			Leaf: &openfgav1.UsersetTree_Leaf{
@@ -188,7 +264,11 @@ func (q *ExpandQuery) resolveThis(ctx context.Context, store string, tk *openfga
 				},
 			},
 		},
-	}, nil
+	}
+	if truncated {
+		return withTruncatedMarker(node), nil
+	}
+	return node, nil
 }
 
 // resolveComputedUserset builds a leaf node containing the result of resolving a ComputedUserset rewrite.
@@ -231,6 +311,7 @@ func (q *ExpandQuery) resolveTupleToUserset(
 	tk *openfgav1.TupleKey,
 	typesys *typesystem.TypeSystem,
 	consistency openfgav1.ConsistencyPreference,
+	budget *expandBudget,
 ) (*openfgav1.UsersetTree_Node, error) {
 	ctx, span := tracer.Start(ctx, "resolveTupleToUserset")
 	defer span.End()
@@ -265,7 +346,7 @@ func (q *ExpandQuery) resolveTupleToUserset(
 			Preference: consistency,
 		},
 	}
-	tupleIter, err := q.datastore.Read(ctx, store, tsKey, opts)
+	tupleIter, err := q.tupleReader.Read(ctx, store, tsKey, opts)
 	if err != nil {
 		return nil, serverErrors.HandleError("", err)
 	}
@@ -278,7 +359,8 @@ func (q *ExpandQuery) resolveTupleToUserset(
 
 	var computed []*openfgav1.UsersetTree_Computed
 	seen := make(map[string]bool)
-	for {
+	truncated := false
+	for !truncated {
 		tk, err := filteredIter.Next(ctx)
 		if err != nil {
 			if err == storage.ErrIteratorDone {
@@ -302,12 +384,20 @@ func (q *ExpandQuery) resolveTupleToUserset(
 
 		computedRelation := toObjectRelation(cs)
 		if !seen[computedRelation] {
+			ok, err := budget.takeLeafUser()
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				truncated = true
+				break
+			}
 			computed = append(computed, &openfgav1.UsersetTree_Computed{Userset: computedRelation})
 			seen[computedRelation] = true
 		}
 	}
 
-	return &openfgav1.UsersetTree_Node{
+	node := &openfgav1.UsersetTree_Node{
 		Name: toObjectRelation(tk),
 		Value: &openfgav1.UsersetTree_Node_Leaf{
 			Leaf: &openfgav1.UsersetTree_Leaf{
@@ -319,7 +409,11 @@ func (q *ExpandQuery) resolveTupleToUserset(
 				},
 			},
 		},
-	}, nil
+	}
+	if truncated {
+		return withTruncatedMarker(node), nil
+	}
+	return node, nil
 }
 
 // resolveUnionUserset creates an intermediate Usertree node containing the union of its children.
@@ -330,11 +424,12 @@ func (q *ExpandQuery) resolveUnionUserset(
 	tk *openfgav1.TupleKey,
 	typesys *typesystem.TypeSystem,
 	consistency openfgav1.ConsistencyPreference,
+	budget *expandBudget,
 ) (*openfgav1.UsersetTree_Node, error) {
 	ctx, span := tracer.Start(ctx, "resolveUnionUserset")
 	defer span.End()
 
-	nodes, err := q.resolveUsersets(ctx, store, usersets.GetChild(), tk, typesys, consistency)
+	nodes, err := q.resolveUsersets(ctx, store, usersets.GetChild(), tk, typesys, consistency, budget)
 	if err != nil {
 		return nil, err
 	}
@@ -356,11 +451,12 @@ func (q *ExpandQuery) resolveIntersectionUserset(
 	tk *openfgav1.TupleKey,
 	typesys *typesystem.TypeSystem,
 	consistency openfgav1.ConsistencyPreference,
+	budget *expandBudget,
 ) (*openfgav1.UsersetTree_Node, error) {
 	ctx, span := tracer.Start(ctx, "resolveIntersectionUserset")
 	defer span.End()
 
-	nodes, err := q.resolveUsersets(ctx, store, usersets.GetChild(), tk, typesys, consistency)
+	nodes, err := q.resolveUsersets(ctx, store, usersets.GetChild(), tk, typesys, consistency, budget)
 	if err != nil {
 		return nil, err
 	}
@@ -382,11 +478,12 @@ func (q *ExpandQuery) resolveDifferenceUserset(
 	tk *openfgav1.TupleKey,
 	typesys *typesystem.TypeSystem,
 	consistency openfgav1.ConsistencyPreference,
+	budget *expandBudget,
 ) (*openfgav1.UsersetTree_Node, error) {
 	ctx, span := tracer.Start(ctx, "resolveDifferenceUserset")
 	defer span.End()
 
-	nodes, err := q.resolveUsersets(ctx, store, []*openfgav1.Userset{userset.GetBase(), userset.GetSubtract()}, tk, typesys, consistency)
+	nodes, err := q.resolveUsersets(ctx, store, []*openfgav1.Userset{userset.GetBase(), userset.GetSubtract()}, tk, typesys, consistency, budget)
 	if err != nil {
 		return nil, err
 	}
@@ -411,6 +508,7 @@ func (q *ExpandQuery) resolveUsersets(
 	tk *openfgav1.TupleKey,
 	typesys *typesystem.TypeSystem,
 	consistency openfgav1.ConsistencyPreference,
+	budget *expandBudget,
 ) ([]*openfgav1.UsersetTree_Node, error) {
 	ctx, span := tracer.Start(ctx, "resolveUsersets")
 	defer span.End()
@@ -421,7 +519,7 @@ func (q *ExpandQuery) resolveUsersets(
 		// https://golang.org/doc/faq#closures_and_goroutines
 		i, us := i, us
 		grp.Go(func() error {
-			node, err := q.resolveUserset(ctx, store, us, tk, typesys, consistency)
+			node, err := q.resolveUserset(ctx, store, us, tk, typesys, consistency, budget)
 			if err != nil {
 				return err
 			}
@@ -438,3 +536,85 @@ func (q *ExpandQuery) resolveUsersets(
 func toObjectRelation(tk *openfgav1.TupleKey) string {
 	return tupleUtils.ToObjectRelationString(tk.GetObject(), tk.GetRelation())
 }
+
+// expandBudget counts the leaf users and nodes of an Expand tree against the result limit.
+// It is shared by the goroutines that resolve the children of a node.
+type expandBudget struct {
+	maxLeafUsers uint32
+	maxNodes     uint32
+	strict       bool
+
+	leafUsers atomic.Uint32
+	nodes     atomic.Uint32
+
+	mu    sync.Mutex
+	cause ExpandTruncationCause
+}
+
+// takeLeafUser counts one more leaf user. It returns false once the limit is exceeded,
+// with a ResourceExhausted error in strict mode.
+func (b *expandBudget) takeLeafUser() (bool, error) {
+	if b.maxLeafUsers == 0 || b.leafUsers.Add(1) <= b.maxLeafUsers {
+		return true, nil
+	}
+	if b.strict {
+		return false, serverErrors.ExpandResultLimitExceeded("leaf users", b.maxLeafUsers)
+	}
+	b.truncate(ExpandTruncationCauseMaxLeafUsers)
+	return false, nil
+}
+
+// takeNode counts one more node. It returns false once the limit is exceeded,
+// with a ResourceExhausted error in strict mode.
+func (b *expandBudget) takeNode() (bool, error) {
+	if b.maxNodes == 0 || b.nodes.Add(1) <= b.maxNodes {
+		return true, nil
+	}
+	if b.strict {
+		return false, serverErrors.ExpandResultLimitExceeded("nodes", b.maxNodes)
+	}
+	b.truncate(ExpandTruncationCauseMaxNodes)
+	return false, nil
+}
+
+// truncate records the first cause of truncation.
+func (b *expandBudget) truncate(cause ExpandTruncationCause) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cause == ExpandTruncationCauseNone {
+		b.cause = cause
+	}
+}
+
+func (b *expandBudget) truncationCause() ExpandTruncationCause {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cause
+}
+
+// truncatedNode returns the marker node of a part of the tree that was cut.
+// It is an empty leaf, so it does not add users to the tree.
+func truncatedNode() *openfgav1.UsersetTree_Node {
+	return &openfgav1.UsersetTree_Node{
+		Name: ExpandTruncatedNodeName,
+		Value: &openfgav1.UsersetTree_Node_Leaf{
+			Leaf: &openfgav1.UsersetTree_Leaf{
+				Value: &openfgav1.UsersetTree_Leaf_Users{
+					Users: &openfgav1.UsersetTree_Users{},
+				},
+			},
+		},
+	}
+}
+
+// withTruncatedMarker returns the union of a partial leaf and the truncated marker node.
+func withTruncatedMarker(leaf *openfgav1.UsersetTree_Node) *openfgav1.UsersetTree_Node {
+	return &openfgav1.UsersetTree_Node{
+		Name: leaf.GetName(),
+		Value: &openfgav1.UsersetTree_Node_Union{
+			Union: &openfgav1.UsersetTree_Nodes{
+				Nodes: []*openfgav1.UsersetTree_Node{leaf, truncatedNode()},
+			},
+		},
+	}
+}
@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"fmt"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+)
+
+// CompactUsersetNodeKind is the kind of a node of a [CompactUsersetTree], after the kind of
+// the UsersetTree node it stands for.
+type CompactUsersetNodeKind string
+
+const (
+	// CompactUsersetNodeUsers is a leaf of users, see [CompactUsersetNode].Users.
+	CompactUsersetNodeUsers CompactUsersetNodeKind = "users"
+	// CompactUsersetNodeComputed is a leaf of a computed userset, see [CompactUsersetNode].Computed.
+	CompactUsersetNodeComputed CompactUsersetNodeKind = "computed"
+	// CompactUsersetNodeTupleToUserset is a leaf of a tuple to userset rewrite, see
+	// [CompactUsersetNode].Tupleset and [CompactUsersetNode].TuplesetComputed.
+	CompactUsersetNodeTupleToUserset CompactUsersetNodeKind = "tuple_to_userset"
+	// CompactUsersetNodeUnion is the union of the targets of its edges.
+	CompactUsersetNodeUnion CompactUsersetNodeKind = "union"
+	// CompactUsersetNodeIntersection is the intersection of the targets of its edges.
+	CompactUsersetNodeIntersection CompactUsersetNodeKind = "intersection"
+	// CompactUsersetNodeDifference is the target of its CompactUsersetEdgeBase edge minus the
+	// target of its CompactUsersetEdgeSubtract edge.
+	CompactUsersetNodeDifference CompactUsersetNodeKind = "difference"
+)
+
+const (
+	// CompactUsersetEdgeBase labels the edge of a difference node to its base.
+	CompactUsersetEdgeBase = "base"
+	// CompactUsersetEdgeSubtract labels the edge of a difference node to the userset it subtracts.
+	CompactUsersetEdgeSubtract = "subtract"
+)
+
+// CompactUsersetTree is an adjacency list representation of the UsersetTree of an Expand
+// response, easier to render as a graph than the nested tree and smaller for wide usersets: the
+// nodes of the tree are listed in pre-order, so the root is the first node, and the children of
+// the union, intersection and difference nodes are the targets of their edges, in the order of
+// the edges. The users of the leaves are deduplicated into Users, and referenced by index.
+//
+// It holds all the information of the tree, see [CompactUsersetTree.UsersetTree].
+type CompactUsersetTree struct {
+	Nodes []CompactUsersetNode `json:"nodes"`
+	Edges []CompactUsersetEdge `json:"edges"`
+	Users []string             `json:"users"`
+}
+
+// CompactUsersetNode is a node of a [CompactUsersetTree].
+type CompactUsersetNode struct {
+	Name string                 `json:"name"`
+	Kind CompactUsersetNodeKind `json:"kind"`
+	// Users are the indexes of the users of a users leaf in [CompactUsersetTree].Users.
+	Users []int `json:"users,omitempty"`
+	// Computed is the userset of a computed leaf.
+	Computed string `json:"computed,omitempty"`
+	// Tupleset and TuplesetComputed are the tupleset and the computed usersets of a tuple to
+	// userset leaf.
+	Tupleset         string   `json:"tupleset,omitempty"`
+	TuplesetComputed []string `json:"tupleset_computed,omitempty"`
+}
+
+// CompactUsersetEdge is an edge of a [CompactUsersetTree] from a node to one of its children, by
+// their indexes in [CompactUsersetTree].Nodes. The edges of a difference node are labeled with
+// CompactUsersetEdgeBase or CompactUsersetEdgeSubtract.
+type CompactUsersetEdge struct {
+	From  int    `json:"from"`
+	To    int    `json:"to"`
+	Label string `json:"label,omitempty"`
+}
+
+// NewCompactUsersetTree returns the compact representation of the tree of an Expand response.
+func NewCompactUsersetTree(tree *openfgav1.UsersetTree) *CompactUsersetTree {
+	c := &CompactUsersetTree{
+		Nodes: []CompactUsersetNode{},
+		Edges: []CompactUsersetEdge{},
+		Users: []string{},
+	}
+	if tree.GetRoot() == nil {
+		return c
+	}
+
+	userIndexes := map[string]int{}
+	var add func(node *openfgav1.UsersetTree_Node) int
+	add = func(node *openfgav1.UsersetTree_Node) int {
+		// the node is listed before its children, and filled in after them
+		index := len(c.Nodes)
+		c.Nodes = append(c.Nodes, CompactUsersetNode{})
+		compact := CompactUsersetNode{Name: node.GetName()}
+
+		switch value := node.GetValue().(type) {
+		case *openfgav1.UsersetTree_Node_Leaf:
+			switch leaf := value.Leaf.GetValue().(type) {
+			case *openfgav1.UsersetTree_Leaf_Users:
+				compact.Kind = CompactUsersetNodeUsers
+				for _, user := range leaf.Users.GetUsers() {
+					userIndex, ok := userIndexes[user]
+					if !ok {
+						userIndex = len(c.Users)
+						userIndexes[user] = userIndex
+						c.Users = append(c.Users, user)
+					}
+					compact.Users = append(compact.Users, userIndex)
+				}
+			case *openfgav1.UsersetTree_Leaf_Computed:
+				compact.Kind = CompactUsersetNodeComputed
+				compact.Computed = leaf.Computed.GetUserset()
+			case *openfgav1.UsersetTree_Leaf_TupleToUserset:
+				compact.Kind = CompactUsersetNodeTupleToUserset
+				compact.Tupleset = leaf.TupleToUserset.GetTupleset()
+				for _, computed := range leaf.TupleToUserset.GetComputed() {
+					compact.TuplesetComputed = append(compact.TuplesetComputed, computed.GetUserset())
+				}
+			}
+		case *openfgav1.UsersetTree_Node_Union:
+			compact.Kind = CompactUsersetNodeUnion
+			for _, child := range value.Union.GetNodes() {
+				c.Edges = append(c.Edges, CompactUsersetEdge{From: index, To: add(child)})
+			}
+		case *openfgav1.UsersetTree_Node_Intersection:
+			compact.Kind = CompactUsersetNodeIntersection
+			for _, child := range value.Intersection.GetNodes() {
+				c.Edges = append(c.Edges, CompactUsersetEdge{From: index, To: add(child)})
+			}
+		case *openfgav1.UsersetTree_Node_Difference:
+			compact.Kind = CompactUsersetNodeDifference
+			c.Edges = append(c.Edges, CompactUsersetEdge{From: index, To: add(value.Difference.GetBase()), Label: CompactUsersetEdgeBase})
+			c.Edges = append(c.Edges, CompactUsersetEdge{From: index, To: add(value.Difference.GetSubtract()), Label: CompactUsersetEdgeSubtract})
+		}
+
+		c.Nodes[index] = compact
+		return index
+	}
+	add(tree.GetRoot())
+
+	return c
+}
+
+// UsersetTree returns the UsersetTree that the compact representation stands for. It fails if
+// the representation is not one of a tree, e.g. if an index is out of range, or a node has two
+// parents or is not reachable from the root.
+func (c *CompactUsersetTree) UsersetTree() (*openfgav1.UsersetTree, error) {
+	if len(c.Nodes) == 0 {
+		return &openfgav1.UsersetTree{}, nil
+	}
+
+	children := make([][]CompactUsersetEdge, len(c.Nodes))
+	hasParent := make([]bool, len(c.Nodes))
+	for _, edge := range c.Edges {
+		if edge.From < 0 || edge.From >= len(c.Nodes) || edge.To <= 0 || edge.To >= len(c.Nodes) {
+			return nil, fmt.Errorf("the edge from %d to %d is out of the range of the %d nodes", edge.From, edge.To, len(c.Nodes))
+		}
+		if hasParent[edge.To] {
+			return nil, fmt.Errorf("node %d has more than one parent", edge.To)
+		}
+		hasParent[edge.To] = true
+		children[edge.From] = append(children[edge.From], edge)
+	}
+
+	built := 0
+	var build func(index int) (*openfgav1.UsersetTree_Node, error)
+	build = func(index int) (*openfgav1.UsersetTree_Node, error) {
+		built++
+		compact := c.Nodes[index]
+		node := &openfgav1.UsersetTree_Node{Name: compact.Name}
+
+		switch compact.Kind {
+		case CompactUsersetNodeUsers, CompactUsersetNodeComputed, CompactUsersetNodeTupleToUserset:
+			if len(children[index]) > 0 {
+				return nil, fmt.Errorf("leaf node %d has edges", index)
+			}
+		}
+
+		childNodes := make([]*openfgav1.UsersetTree_Node, 0, len(children[index]))
+		for _, edge := range children[index] {
+			child, err := build(edge.To)
+			if err != nil {
+				return nil, err
+			}
+			childNodes = append(childNodes, child)
+		}
+
+		switch compact.Kind {
+		case CompactUsersetNodeUsers:
+			users := make([]string, 0, len(compact.Users))
+			for _, userIndex := range compact.Users {
+				if userIndex < 0 || userIndex >= len(c.Users) {
+					return nil, fmt.Errorf("user %d of node %d is out of the range of the %d users", userIndex, index, len(c.Users))
+				}
+				users = append(users, c.Users[userIndex])
+			}
+			node.Value = &openfgav1.UsersetTree_Node_Leaf{Leaf: &openfgav1.UsersetTree_Leaf{
+				Value: &openfgav1.UsersetTree_Leaf_Users{Users: &openfgav1.UsersetTree_Users{Users: users}},
+			}}
+		case CompactUsersetNodeComputed:
+			node.Value = &openfgav1.UsersetTree_Node_Leaf{Leaf: &openfgav1.UsersetTree_Leaf{
+				Value: &openfgav1.UsersetTree_Leaf_Computed{Computed: &openfgav1.UsersetTree_Computed{Userset: compact.Computed}},
+			}}
+		case CompactUsersetNodeTupleToUserset:
+			computed := make([]*openfgav1.UsersetTree_Computed, 0, len(compact.TuplesetComputed))
+			for _, userset := range compact.TuplesetComputed {
+				computed = append(computed, &openfgav1.UsersetTree_Computed{Userset: userset})
+			}
+			node.Value = &openfgav1.UsersetTree_Node_Leaf{Leaf: &openfgav1.UsersetTree_Leaf{
+				Value: &openfgav1.UsersetTree_Leaf_TupleToUserset{TupleToUserset: &openfgav1.UsersetTree_TupleToUserset{
+					Tupleset: compact.Tupleset,
+					Computed: computed,
+				}},
+			}}
+		case CompactUsersetNodeUnion:
+			node.Value = &openfgav1.UsersetTree_Node_Union{Union: &openfgav1.UsersetTree_Nodes{Nodes: childNodes}}
+		case CompactUsersetNodeIntersection:
+			node.Value = &openfgav1.UsersetTree_Node_Intersection{Intersection: &openfgav1.UsersetTree_Nodes{Nodes: childNodes}}
+		case CompactUsersetNodeDifference:
+			edges := children[index]
+			if len(edges) != 2 || edges[0].Label != CompactUsersetEdgeBase || edges[1].Label != CompactUsersetEdgeSubtract {
+				return nil, fmt.Errorf("difference node %d must have a '%s' edge and a '%s' edge", index, CompactUsersetEdgeBase, CompactUsersetEdgeSubtract)
+			}
+			node.Value = &openfgav1.UsersetTree_Node_Difference{Difference: &openfgav1.UsersetTree_Difference{
+				Base:     childNodes[0],
+				Subtract: childNodes[1],
+			}}
+		default:
+			return nil, fmt.Errorf("node %d has unknown kind '%s'", index, compact.Kind)
+		}
+		return node, nil
+	}
+
+	root, err := build(0)
+	if err != nil {
+		return nil, err
+	}
+	if built != len(c.Nodes) {
+		return nil, fmt.Errorf("%d of the %d nodes are not reachable from the root", len(c.Nodes)-built, len(c.Nodes))
+	}
+	return &openfgav1.UsersetTree{Root: root}, nil
+}
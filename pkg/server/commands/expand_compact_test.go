@@ -0,0 +1,210 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func usersLeaf(name string, users ...string) *openfgav1.UsersetTree_Node {
+	return &openfgav1.UsersetTree_Node{
+		Name: name,
+		Value: &openfgav1.UsersetTree_Node_Leaf{Leaf: &openfgav1.UsersetTree_Leaf{
+			Value: &openfgav1.UsersetTree_Leaf_Users{Users: &openfgav1.UsersetTree_Users{Users: users}},
+		}},
+	}
+}
+
+func TestCompactUsersetTree(t *testing.T) {
+	tree := &openfgav1.UsersetTree{Root: &openfgav1.UsersetTree_Node{
+		Name: "document:1#viewer",
+		Value: &openfgav1.UsersetTree_Node_Union{Union: &openfgav1.UsersetTree_Nodes{Nodes: []*openfgav1.UsersetTree_Node{
+			usersLeaf("document:1#viewer", "user:anne", "user:bob", "group:eng#member"),
+			{
+				Name: "document:1#viewer",
+				Value: &openfgav1.UsersetTree_Node_Leaf{Leaf: &openfgav1.UsersetTree_Leaf{
+					Value: &openfgav1.UsersetTree_Leaf_Computed{Computed: &openfgav1.UsersetTree_Computed{Userset: "document:1#editor"}},
+				}},
+			},
+			{
+				Name: "document:1#viewer",
+				Value: &openfgav1.UsersetTree_Node_Leaf{Leaf: &openfgav1.UsersetTree_Leaf{
+					Value: &openfgav1.UsersetTree_Leaf_TupleToUserset{TupleToUserset: &openfgav1.UsersetTree_TupleToUserset{
+						Tupleset: "document:1#parent",
+						Computed: []*openfgav1.UsersetTree_Computed{{Userset: "folder:1#viewer"}, {Userset: "folder:2#viewer"}},
+					}},
+				}},
+			},
+			{
+				Name: "document:1#viewer",
+				Value: &openfgav1.UsersetTree_Node_Difference{Difference: &openfgav1.UsersetTree_Difference{
+					Base: &openfgav1.UsersetTree_Node{
+						Name: "document:1#viewer",
+						Value: &openfgav1.UsersetTree_Node_Intersection{Intersection: &openfgav1.UsersetTree_Nodes{Nodes: []*openfgav1.UsersetTree_Node{
+							usersLeaf("document:1#allowed", "user:bob", "user:anne"),
+							usersLeaf("document:1#member", "user:bob"),
+						}}},
+					},
+					Subtract: usersLeaf("document:1#blocked", "user:anne"),
+				}},
+			},
+			usersLeaf("document:1#viewer"),
+		}}},
+	}}
+
+	compact := NewCompactUsersetTree(tree)
+
+	t.Run("adjacency_list", func(t *testing.T) {
+		require.Len(t, compact.Nodes, 10)
+		require.Len(t, compact.Edges, 9)
+		require.Equal(t, CompactUsersetNodeUnion, compact.Nodes[0].Kind)
+
+		// the users are listed once, in the order they are first seen
+		require.Equal(t, []string{"user:anne", "user:bob", "group:eng#member"}, compact.Users)
+		require.Equal(t, []int{0, 1, 2}, compact.Nodes[1].Users)
+		require.Equal(t, []int{1, 0}, compact.Nodes[6].Users)
+
+		require.Equal(t, CompactUsersetNodeDifference, compact.Nodes[4].Kind)
+		require.Equal(t, []CompactUsersetEdge{
+			{From: 0, To: 1},
+			{From: 0, To: 2},
+			{From: 0, To: 3},
+			{From: 5, To: 6},
+			{From: 5, To: 7},
+			{From: 4, To: 5, Label: CompactUsersetEdgeBase},
+			{From: 4, To: 8, Label: CompactUsersetEdgeSubtract},
+			{From: 0, To: 4},
+			{From: 0, To: 9},
+		}, compact.Edges)
+	})
+
+	t.Run("round_trip", func(t *testing.T) {
+		roundTrip, err := compact.UsersetTree()
+		require.NoError(t, err)
+		require.True(t, proto.Equal(tree, roundTrip), "%v != %v", tree, roundTrip)
+	})
+
+	t.Run("json_round_trip", func(t *testing.T) {
+		data, err := json.Marshal(compact)
+		require.NoError(t, err)
+
+		var decoded CompactUsersetTree
+		require.NoError(t, json.Unmarshal(data, &decoded))
+
+		roundTrip, err := decoded.UsersetTree()
+		require.NoError(t, err)
+		require.True(t, proto.Equal(tree, roundTrip), "%v != %v", tree, roundTrip)
+	})
+
+	t.Run("empty_tree", func(t *testing.T) {
+		roundTrip, err := NewCompactUsersetTree(&openfgav1.UsersetTree{}).UsersetTree()
+		require.NoError(t, err)
+		require.True(t, proto.Equal(&openfgav1.UsersetTree{}, roundTrip))
+	})
+
+	t.Run("not_a_tree", func(t *testing.T) {
+		leaf := CompactUsersetNode{Name: "document:1#viewer", Kind: CompactUsersetNodeUsers}
+		union := CompactUsersetNode{Name: "document:1#viewer", Kind: CompactUsersetNodeUnion}
+		tests := map[string]struct {
+			compact CompactUsersetTree
+			err     string
+		}{
+			`edge_out_of_range`: {
+				compact: CompactUsersetTree{Nodes: []CompactUsersetNode{union}, Edges: []CompactUsersetEdge{{From: 0, To: 1}}},
+				err:     "the edge from 0 to 1 is out of the range of the 1 nodes",
+			},
+			`two_parents`: {
+				compact: CompactUsersetTree{
+					Nodes: []CompactUsersetNode{union, union, leaf},
+					Edges: []CompactUsersetEdge{{From: 0, To: 1}, {From: 0, To: 2}, {From: 1, To: 2}},
+				},
+				err: "node 2 has more than one parent",
+			},
+			`unreachable_node`: {
+				compact: CompactUsersetTree{Nodes: []CompactUsersetNode{union, leaf}},
+				err:     "1 of the 2 nodes are not reachable from the root",
+			},
+			`user_out_of_range`: {
+				compact: CompactUsersetTree{Nodes: []CompactUsersetNode{{Kind: CompactUsersetNodeUsers, Users: []int{0}}}},
+				err:     "user 0 of node 0 is out of the range of the 0 users",
+			},
+			`leaf_with_edges`: {
+				compact: CompactUsersetTree{Nodes: []CompactUsersetNode{leaf, leaf}, Edges: []CompactUsersetEdge{{From: 0, To: 1}}},
+				err:     "leaf node 0 has edges",
+			},
+			`difference_without_labels`: {
+				compact: CompactUsersetTree{
+					Nodes: []CompactUsersetNode{{Kind: CompactUsersetNodeDifference}, leaf, leaf},
+					Edges: []CompactUsersetEdge{{From: 0, To: 1}, {From: 0, To: 2}},
+				},
+				err: "difference node 0 must have a 'base' edge and a 'subtract' edge",
+			},
+			`unknown_kind`: {
+				compact: CompactUsersetTree{Nodes: []CompactUsersetNode{{Kind: "other"}}},
+				err:     "node 0 has unknown kind 'other'",
+			},
+		}
+
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := test.compact.UsersetTree()
+				require.EqualError(t, err, test.err)
+			})
+		}
+	})
+}
+
+func TestCompactUsersetTreeOfExpand(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type group
+			relations
+				define member: [user]
+		type folder
+			relations
+				define viewer: [user, group#member]
+		type document
+			relations
+				define parent: [folder]
+				define blocked: [user]
+				define editor: [user]
+				define viewer: ([user, group#member] or editor or viewer from parent) but not blocked`)
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+		tuple.NewTupleKey("document:1", "editor", "user:anne"),
+		tuple.NewTupleKey("document:1", "blocked", "user:bob"),
+		tuple.NewTupleKey("document:1", "parent", "folder:1"),
+	}))
+
+	resp, err := NewExpandQuery(ds).Execute(ctx, &openfgav1.ExpandRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: model.GetId(),
+		TupleKey:             tuple.NewExpandRequestTupleKey("document:1", "viewer"),
+	})
+	require.NoError(t, err)
+
+	compact := NewCompactUsersetTree(resp.GetTree())
+	require.Equal(t, CompactUsersetNodeDifference, compact.Nodes[0].Kind)
+
+	roundTrip, err := compact.UsersetTree()
+	require.NoError(t, err)
+	require.True(t, proto.Equal(resp.GetTree(), roundTrip), "%v != %v", resp.GetTree(), roundTrip)
+}
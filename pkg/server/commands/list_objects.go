@@ -18,6 +18,7 @@ import (
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 
 	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/internal/concurrency"
 	"github.com/openfga/openfga/internal/condition"
 	"github.com/openfga/openfga/internal/graph"
 	serverconfig "github.com/openfga/openfga/internal/server/config"
@@ -25,6 +26,7 @@ import (
 	"github.com/openfga/openfga/internal/throttler/threshold"
 	"github.com/openfga/openfga/internal/validation"
 	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/requestcontext"
 	"github.com/openfga/openfga/pkg/server/commands/reverseexpand"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
@@ -49,6 +51,10 @@ var (
 	})
 )
 
+// ErrStreamMaxDurationReached is returned by ExecuteStreamed, with the resolution metadata of the
+// results sent so far, when the stream ends at the limit of WithStreamedListObjectsMaxDuration.
+var ErrStreamMaxDurationReached = errors.New("the stream reached its maximum duration")
+
 type ListObjectsQuery struct {
 	datastore               storage.RelationshipTupleReader
 	logger                  logger.Logger
@@ -57,38 +63,34 @@ type ListObjectsQuery struct {
 	resolveNodeLimit        uint32
 	resolveNodeBreadthLimit uint32
 	maxConcurrentReads      uint32
+	maxCandidates           uint32
+	strategy                ListObjectsStrategy
+
+	hedgingPolicy *storagewrappers.HedgingPolicy
 
 	dispatchThrottlerConfig threshold.Config
 
 	checkResolver graph.CheckResolver
-}
-
-type ListObjectsResolutionMetadata struct {
-	// The total number of database reads from reverse_expand and Check (if any) to complete the ListObjects request
-	DatastoreQueryCount *uint32
-
-	// The total number of dispatches aggregated from reverse_expand and check resolutions (if any) to complete the ListObjects request
-	DispatchCounter *atomic.Uint32
-
-	// WasThrottled indicates whether the request was throttled
-	WasThrottled *atomic.Bool
-}
+	workerPool    *concurrency.WorkerPool
 
-func NewListObjectsResolutionMetadata() *ListObjectsResolutionMetadata {
-	return &ListObjectsResolutionMetadata{
-		DatastoreQueryCount: new(uint32),
-		DispatchCounter:     new(atomic.Uint32),
-		WasThrottled:        new(atomic.Bool),
-	}
+	streamCheck         func(context.Context) error
+	streamCheckInterval time.Duration
+	streamMaxDuration   time.Duration
 }
 
 type ListObjectsResponse struct {
-	Objects            []string
-	ResolutionMetadata ListObjectsResolutionMetadata
+	Objects []string
+
+	// ResolutionMetadata aggregates the work of reverse_expand and Check (if any) to complete the
+	// ListObjects request.
+	ResolutionMetadata *graph.ResolutionMetadata
 }
 
 type ListObjectsQueryOption func(d *ListObjectsQuery)
 
+// WithListObjectsDeadline sets the maximum time spent gathering the results of a request, after
+// which the results gathered so far are returned. 0 disables the deadline, and negative deadlines
+// are rejected by NewListObjectsQuery.
 func WithListObjectsDeadline(deadline time.Duration) ListObjectsQueryOption {
 	return func(d *ListObjectsQuery) {
 		d.listObjectsDeadline = deadline
@@ -101,6 +103,8 @@ func WithDispatchThrottlerConfig(config threshold.Config) ListObjectsQueryOption
 	}
 }
 
+// WithListObjectsMaxResults sets the maximum number of results returned by Execute. 0 returns all
+// the results. ExecuteStreamed ignores it.
 func WithListObjectsMaxResults(max uint32) ListObjectsQueryOption {
 	return func(d *ListObjectsQuery) {
 		d.listObjectsMaxResults = max
@@ -134,6 +138,53 @@ func WithMaxConcurrentReads(limit uint32) ListObjectsQueryOption {
 	}
 }
 
+// WithDatastoreHedging see server.WithDatastoreHedgingEnabled.
+func WithDatastoreHedging(policy *storagewrappers.HedgingPolicy) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.hedgingPolicy = policy
+	}
+}
+
+// WithListObjectsMaxCandidates see server.WithListObjectsMaxCandidates.
+func WithListObjectsMaxCandidates(limit uint32) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.maxCandidates = limit
+	}
+}
+
+// WithListObjectsStrategy see server.WithListObjectsStrategy.
+func WithListObjectsStrategy(strategy ListObjectsStrategy) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.strategy = strategy
+	}
+}
+
+// WithStreamedListObjectsCheck calls check every interval while ExecuteStreamed streams the
+// results, and ends the stream with the error it returns, if any. See also ExecuteStreamedWithCheck.
+func WithStreamedListObjectsCheck(interval time.Duration, check func(context.Context) error) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.streamCheckInterval = interval
+		d.streamCheck = check
+	}
+}
+
+// WithStreamedListObjectsMaxDuration ends the streams of ExecuteStreamed that last longer than
+// maxDuration with ErrStreamMaxDurationReached, between two objects, so that no object is cut
+// mid-message. 0 disables the limit, and negative limits are rejected by NewListObjectsQuery.
+func WithStreamedListObjectsMaxDuration(maxDuration time.Duration) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.streamMaxDuration = maxDuration
+	}
+}
+
+// WithListObjectsWorkerPool runs the checks of the objects found by the reverse expansion on
+// the given pool. See server.WithResolverWorkerPoolSize.
+func WithListObjectsWorkerPool(pool *concurrency.WorkerPool) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.workerPool = pool
+	}
+}
+
 func NewListObjectsQuery(
 	ds storage.RelationshipTupleReader,
 	checkResolver graph.CheckResolver,
@@ -155,6 +206,8 @@ func NewListObjectsQuery(
 		resolveNodeLimit:        serverconfig.DefaultResolveNodeLimit,
 		resolveNodeBreadthLimit: serverconfig.DefaultResolveNodeBreadthLimit,
 		maxConcurrentReads:      serverconfig.DefaultMaxConcurrentReadsForListObjects,
+		maxCandidates:           serverconfig.DefaultListObjectsMaxCandidates,
+		strategy:                ListObjectsStrategy(serverconfig.DefaultListObjectsStrategy),
 		dispatchThrottlerConfig: threshold.Config{
 			Throttler:    throttler.NewNoopThrottler(),
 			Enabled:      serverconfig.DefaultListObjectsDispatchThrottlingEnabled,
@@ -168,11 +221,29 @@ func NewListObjectsQuery(
 		opt(query)
 	}
 
-	query.datastore = storagewrappers.NewBoundedConcurrencyTupleReader(query.datastore, query.maxConcurrentReads)
+	if query.listObjectsDeadline < 0 {
+		return nil, fmt.Errorf("the ListObjects deadline must not be negative, use 0 to disable it")
+	}
+
+	if query.streamMaxDuration < 0 {
+		return nil, fmt.Errorf("the maximum duration of the streams must not be negative, use 0 to disable it")
+	}
 
 	return query, nil
 }
 
+// requestTupleReader wraps the datastore for one request. The wrappers are created per request
+// because the hedges and the concurrent reads are bounded per request, which is also what lets
+// a ListObjectsQuery be shared by concurrent requests. The hedged reads take their share of the
+// concurrent reads too.
+func (q *ListObjectsQuery) requestTupleReader() storage.RelationshipTupleReader {
+	var ds storage.RelationshipTupleReader = storagewrappers.NewBoundedConcurrencyTupleReader(q.datastore, q.maxConcurrentReads)
+	if q.hedgingPolicy != nil {
+		ds = storagewrappers.NewHedgedTupleReader(ds, q.hedgingPolicy)
+	}
+	return ds
+}
+
 type ListObjectsResult struct {
 	ObjectID string
 	Err      error
@@ -193,9 +264,10 @@ type listObjectsRequest interface {
 }
 
 // evaluate fires of evaluation of the ListObjects query by delegating to
-// [[reverseexpand.ReverseExpand#Execute]] and resolving the results yielded
-// from it. If any results yielded by reverse expansion require further eval,
-// then these results get dispatched to Check to resolve the residual outcome.
+// [[reverseexpand.ReverseExpand#Execute]], or to the enumeration of the strategy
+// planned for the relation, and resolving the results yielded from it. If any
+// results yielded require further eval, then these results get dispatched to
+// Check to resolve the residual outcome.
 //
 // The resultsChan is **always** closed by evaluate when it is done with its work,
 // which is either when all results have been yielded, the deadline has been met,
@@ -205,7 +277,7 @@ func (q *ListObjectsQuery) evaluate(
 	req listObjectsRequest,
 	resultsChan chan<- ListObjectsResult,
 	maxResults uint32,
-	resolutionMetadata *ListObjectsResolutionMetadata,
+	resolutionMetadata *graph.ResolutionMetadata,
 ) error {
 	targetObjectType := req.GetType()
 	targetRelation := req.GetRelation()
@@ -242,46 +314,58 @@ func (q *ListObjectsQuery) evaluate(
 		return serverErrors.ValidationError(fmt.Errorf("invalid 'user' value: %s", err))
 	}
 
-	handler := func() {
-		userObj, userRel := tuple.SplitObjectRelation(req.GetUser())
-		userObjType, userObjID := tuple.SplitObject(userObj)
-
-		var sourceUserRef reverseexpand.IsUserRef
-		sourceUserRef = &reverseexpand.UserRefObject{
-			Object: &openfgav1.Object{
-				Type: userObjType,
-				Id:   userObjID,
+	userObj, userRel := tuple.SplitObjectRelation(req.GetUser())
+	userObjType, userObjID := tuple.SplitObject(userObj)
+
+	var sourceUserRef reverseexpand.IsUserRef
+	sourceUserRef = &reverseexpand.UserRefObject{
+		Object: &openfgav1.Object{
+			Type: userObjType,
+			Id:   userObjID,
+		},
+	}
+
+	if tuple.IsTypedWildcard(userObj) {
+		sourceUserRef = &reverseexpand.UserRefTypedWildcard{Type: tuple.GetType(userObj)}
+	}
+
+	if userRel != "" {
+		sourceUserRef = &reverseexpand.UserRefObjectRelation{
+			ObjectRelation: &openfgav1.ObjectRelation{
+				Object:   userObj,
+				Relation: userRel,
 			},
 		}
+	}
 
-		if tuple.IsTypedWildcard(userObj) {
-			sourceUserRef = &reverseexpand.UserRefTypedWildcard{Type: tuple.GetType(userObj)}
-		}
+	shape, err := newRelationShape(typesys, targetObjectType, targetRelation)
+	if err != nil {
+		return serverErrors.HandleError("", err)
+	}
+	plan := newFilteredBasePlan(typesys, targetObjectType, targetRelation)
+	strategy := planListObjectsStrategy(q.strategy, shape, plan, sourceUserRef)
+	recordListObjectsStrategy(ctx, strategy, plan)
 
-		if userRel != "" {
-			sourceUserRef = &reverseexpand.UserRefObjectRelation{
-				ObjectRelation: &openfgav1.ObjectRelation{
-					Object:   userObj,
-					Relation: userRel,
-				},
-			}
-		}
+	resolveNodeLimit := requestcontext.FromContext(ctx).EffectiveResolveNodeLimit(q.resolveNodeLimit)
 
+	handler := func() {
 		reverseExpandResultsChan := make(chan *reverseexpand.ReverseExpandResult, 1)
 		objectsFound := atomic.Uint32{}
 
-		ds := storagewrappers.NewCombinedTupleReader(
-			q.datastore,
-			req.GetContextualTuples().GetTupleKeys(),
-		)
+		// the contextual tuples are indexed once, and shared by the reverse expansion and the
+		// checks of the candidate objects
+		contextualTuples := storagewrappers.NewContextualTupleOverlay(req.GetContextualTuples().GetTupleKeys())
+		datastore := storagewrappers.NewQueryCountingTupleReader(q.requestTupleReader(), resolutionMetadata)
+		ds := storagewrappers.NewCombinedTupleReaderWithOverlay(datastore, contextualTuples)
 
 		reverseExpandQuery := reverseexpand.NewReverseExpandQuery(
-			ds,
+			datastore,
 			typesys,
-			reverseexpand.WithResolveNodeLimit(q.resolveNodeLimit),
+			reverseexpand.WithResolveNodeLimit(resolveNodeLimit),
 			reverseexpand.WithDispatchThrottlerConfig(q.dispatchThrottlerConfig),
 			reverseexpand.WithResolveNodeBreadthLimit(q.resolveNodeBreadthLimit),
 			reverseexpand.WithLogger(q.logger),
+			reverseexpand.WithMaxCandidates(q.maxCandidates),
 		)
 
 		cancelCtx, cancel := context.WithCancel(ctx)
@@ -290,39 +374,147 @@ func (q *ListObjectsQuery) evaluate(
 
 		errChan := make(chan error, 1)
 
-		reverseExpandResolutionMetadata := reverseexpand.NewResolutionMetadata()
+		reverseExpandResolutionMetadata := graph.NewResolutionMetadata()
 
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 
-			err := reverseExpandQuery.Execute(cancelCtx, &reverseexpand.ReverseExpandRequest{
-				StoreID:          req.GetStoreId(),
-				ObjectType:       targetObjectType,
-				Relation:         targetRelation,
-				User:             sourceUserRef,
-				ContextualTuples: req.GetContextualTuples().GetTupleKeys(),
-				Context:          req.GetContext(),
-				Consistency:      req.GetConsistency(),
-			}, reverseExpandResultsChan, reverseExpandResolutionMetadata)
+			var err error
+			switch strategy {
+			case ListObjectsStrategyDirect, ListObjectsStrategyCheckCandidates:
+				enumerate := q.enumerateDirect
+				if strategy == ListObjectsStrategyCheckCandidates {
+					enumerate = q.enumerateCandidates
+				}
+				err = enumerate(cancelCtx, &listObjectsEnumerateRequest{
+					req:        req,
+					typesys:    typesys,
+					shape:      shape,
+					user:       sourceUserRef,
+					datastore:  ds,
+					resultChan: reverseExpandResultsChan,
+					metadata:   reverseExpandResolutionMetadata,
+				})
+			case ListObjectsStrategyFilteredBase:
+				if plan.baseRelation == "" {
+					err = q.enumerateDirect(cancelCtx, &listObjectsEnumerateRequest{
+						req:        req,
+						typesys:    typesys,
+						shape:      &relationShape{directRelations: []string{plan.relation}},
+						user:       sourceUserRef,
+						datastore:  ds,
+						resultChan: reverseExpandResultsChan,
+						metadata:   reverseExpandResolutionMetadata,
+					})
+					break
+				}
+				err = reverseExpandQuery.Execute(cancelCtx, &reverseexpand.ReverseExpandRequest{
+					StoreID:                req.GetStoreId(),
+					ObjectType:             targetObjectType,
+					Relation:               plan.baseRelation,
+					User:                   sourceUserRef,
+					ContextualTuples:       req.GetContextualTuples().GetTupleKeys(),
+					ContextualTupleOverlay: contextualTuples,
+					Context:                req.GetContext(),
+					Consistency:            req.GetConsistency(),
+				}, reverseExpandResultsChan, reverseExpandResolutionMetadata)
+			default:
+				err = reverseExpandQuery.Execute(cancelCtx, &reverseexpand.ReverseExpandRequest{
+					StoreID:                req.GetStoreId(),
+					ObjectType:             targetObjectType,
+					Relation:               targetRelation,
+					User:                   sourceUserRef,
+					ContextualTuples:       req.GetContextualTuples().GetTupleKeys(),
+					ContextualTupleOverlay: contextualTuples,
+					Context:                req.GetContext(),
+					Consistency:            req.GetConsistency(),
+				}, reverseExpandResultsChan, reverseExpandResolutionMetadata)
+			}
 			if err != nil {
 				errChan <- err
 			}
-			atomic.AddUint32(resolutionMetadata.DatastoreQueryCount, *reverseExpandResolutionMetadata.DatastoreQueryCount)
-			resolutionMetadata.DispatchCounter.Add(reverseExpandResolutionMetadata.DispatchCounter.Load())
-			resolutionMetadata.WasThrottled.Store(reverseExpandResolutionMetadata.WasThrottled.Load())
+			resolutionMetadata.Merge(reverseExpandResolutionMetadata)
 		}()
 
 		ctx = typesystem.ContextWithTypesystem(ctx, typesys)
 		ctx := storage.ContextWithRelationshipTupleReader(ctx, ds)
+		if q.workerPool != nil {
+			ctx = concurrency.ContextWithWorkerPool(ctx, q.workerPool)
+		}
 
-		concurrencyLimiterCh := make(chan struct{}, q.resolveNodeBreadthLimit)
+		// the objects that require further evaluation are checked in bulk, sharing the outcomes of
+		// their subproblems, until the maximum number of results is reached
+		bulkChecker := graph.NewBulkChecker(q.checkResolver,
+			graph.WithBulkCheckResolveNodeLimit(resolveNodeLimit),
+			graph.WithBulkCheckBreadthLimit(q.resolveNodeBreadthLimit),
+		)
+		bulkCheckRequest := &graph.BulkCheckRequest{
+			StoreID:              req.GetStoreId(),
+			AuthorizationModelID: req.GetAuthorizationModelId(),
+			Relation:             req.GetRelation(),
+			User:                 req.GetUser(),
+			ContextualTuples:     req.GetContextualTuples().GetTupleKeys(),
+			Context:              req.GetContext(),
+			Consistency:          req.GetConsistency(),
+		}
+
+		checkObjects := make(chan string)
+		checkDone := make(chan struct{})
+		wg.Add(1)
+		go func() {
+			defer func() {
+				close(checkDone)
+				wg.Done()
+			}()
+
+			checkResolutionMetadata, err := bulkChecker.Execute(ctx, bulkCheckRequest, checkObjects, func(object string) bool {
+				trySendObject(object, &objectsFound, maxResults, resultsChan)
+				return maxResults == 0 || objectsFound.Load() < maxResults
+			})
+			resolutionMetadata.Merge(checkResolutionMetadata)
+			if err != nil && ctx.Err() == nil {
+				if errors.Is(err, graph.ErrResolutionDepthExceeded) {
+					err = serverErrors.AuthorizationModelResolutionTooComplex
+				}
+				resultsChan <- ListObjectsResult{Err: err}
+			}
+		}()
+
+		// with ListObjectsStrategyFilteredBase, the objects of the base are only checked for the
+		// filters of the plan, sharing the memo of the checks of the objects above
+		var filterObjects chan string
+		filterDone := make(chan struct{})
+		if strategy == ListObjectsStrategyFilteredBase {
+			filterObjects = make(chan string)
+			wg.Add(1)
+			go func() {
+				defer func() {
+					close(filterDone)
+					wg.Done()
+				}()
+
+				filterResolutionMetadata, err := q.filterBase(ctx, bulkChecker, bulkCheckRequest, plan, filterObjects, func(object string) bool {
+					trySendObject(object, &objectsFound, maxResults, resultsChan)
+					return maxResults == 0 || objectsFound.Load() < maxResults
+				})
+				resolutionMetadata.Merge(filterResolutionMetadata)
+				if err != nil && ctx.Err() == nil {
+					if errors.Is(err, graph.ErrResolutionDepthExceeded) {
+						err = serverErrors.AuthorizationModelResolutionTooComplex
+					}
+					resultsChan <- ListObjectsResult{Err: err}
+				}
+			}()
+		}
 
 	ConsumerReadLoop:
 		for {
 			select {
 			case <-ctx.Done():
 				break ConsumerReadLoop
+			case <-checkDone:
+				break ConsumerReadLoop
 			case res, channelOpen := <-reverseExpandResultsChan:
 				if !channelOpen {
 					break ConsumerReadLoop
@@ -332,6 +524,19 @@ func (q *ListObjectsQuery) evaluate(
 					break ConsumerReadLoop
 				}
 
+				if res.ResultStatus == reverseexpand.NoFurtherEvalStatus && filterObjects != nil {
+					furtherEvalRequiredCounter.Inc()
+
+					select {
+					case filterObjects <- res.Object:
+					case <-filterDone:
+						break ConsumerReadLoop
+					case <-ctx.Done():
+						break ConsumerReadLoop
+					}
+					continue
+				}
+
 				if res.ResultStatus == reverseexpand.NoFurtherEvalStatus {
 					noFurtherEvalRequiredCounter.Inc()
 					trySendObject(res.Object, &objectsFound, maxResults, resultsChan)
@@ -340,42 +545,13 @@ func (q *ListObjectsQuery) evaluate(
 
 				furtherEvalRequiredCounter.Inc()
 
-				wg.Add(1)
-				go func(res *reverseexpand.ReverseExpandResult) {
-					defer func() {
-						<-concurrencyLimiterCh
-						wg.Done()
-					}()
-
-					concurrencyLimiterCh <- struct{}{}
-					checkRequestMetadata := graph.NewCheckRequestMetadata(q.resolveNodeLimit)
-
-					resp, err := q.checkResolver.ResolveCheck(ctx, &graph.ResolveCheckRequest{
-						StoreID:              req.GetStoreId(),
-						AuthorizationModelID: req.GetAuthorizationModelId(),
-						TupleKey:             tuple.NewTupleKey(res.Object, req.GetRelation(), req.GetUser()),
-						ContextualTuples:     req.GetContextualTuples().GetTupleKeys(),
-						Context:              req.GetContext(),
-						RequestMetadata:      checkRequestMetadata,
-						Consistency:          req.GetConsistency(),
-					})
-					if err != nil {
-						if errors.Is(err, graph.ErrResolutionDepthExceeded) {
-							resultsChan <- ListObjectsResult{Err: serverErrors.AuthorizationModelResolutionTooComplex}
-							return
-						}
-
-						resultsChan <- ListObjectsResult{Err: err}
-						return
-					}
-					atomic.AddUint32(resolutionMetadata.DatastoreQueryCount, resp.GetResolutionMetadata().DatastoreQueryCount)
-					resolutionMetadata.DispatchCounter.Add(reverseExpandResolutionMetadata.DispatchCounter.Load())
-					resolutionMetadata.WasThrottled.Store(reverseExpandResolutionMetadata.WasThrottled.Load())
-
-					if resp.Allowed {
-						trySendObject(res.Object, &objectsFound, maxResults, resultsChan)
-					}
-				}(res)
+				select {
+				case checkObjects <- res.Object:
+				case <-checkDone:
+					break ConsumerReadLoop
+				case <-ctx.Done():
+					break ConsumerReadLoop
+				}
 
 			case err := <-errChan:
 				if errors.Is(err, graph.ErrResolutionDepthExceeded) {
@@ -387,6 +563,10 @@ func (q *ListObjectsQuery) evaluate(
 			}
 		}
 
+		close(checkObjects)
+		if filterObjects != nil {
+			close(filterObjects)
+		}
 		cancel()
 		wg.Wait()
 		close(resultsChan)
@@ -419,13 +599,13 @@ func (q *ListObjectsQuery) Execute(
 	}
 
 	timeoutCtx := ctx
-	if q.listObjectsDeadline != 0 {
+	if q.listObjectsDeadline > 0 {
 		var cancel context.CancelFunc
 		timeoutCtx, cancel = context.WithTimeout(ctx, q.listObjectsDeadline)
 		defer cancel()
 	}
 
-	resolutionMetadata := NewListObjectsResolutionMetadata()
+	resolutionMetadata := graph.NewResolutionMetadata()
 
 	err := q.evaluate(timeoutCtx, req, resultsChan, maxResults, resolutionMetadata)
 	if err != nil {
@@ -442,6 +622,10 @@ func (q *ListObjectsQuery) Execute(
 				return nil, result.Err
 			}
 
+			if errors.Is(result.Err, reverseexpand.ErrMaxCandidatesExceeded) {
+				return nil, serverErrors.ListObjectsMaxCandidatesExceeded(q.maxCandidates)
+			}
+
 			if errors.Is(result.Err, condition.ErrEvaluationFailed) {
 				errs = errors.Join(errs, result.Err)
 				continue
@@ -463,38 +647,93 @@ func (q *ListObjectsQuery) Execute(
 
 	return &ListObjectsResponse{
 		Objects:            objects,
-		ResolutionMetadata: *resolutionMetadata,
+		ResolutionMetadata: resolutionMetadata,
 	}, nil
 }
 
 // ExecuteStreamed executes the ListObjectsQuery, returning a stream of object IDs.
 // It ignores the value of q.listObjectsMaxResults and returns all available results
-// until q.listObjectsDeadline is hit.
-func (q *ListObjectsQuery) ExecuteStreamed(ctx context.Context, req *openfgav1.StreamedListObjectsRequest, srv openfgav1.OpenFGAService_StreamedListObjectsServer) (*ListObjectsResolutionMetadata, error) {
+// until q.listObjectsDeadline is hit. Objects are sent to the client as soon as they
+// are resolved, so only the set of candidates seen so far (bounded by q.maxCandidates)
+// is held in memory. A stream that lasts longer than q.streamMaxDuration ends with
+// ErrStreamMaxDurationReached.
+func (q *ListObjectsQuery) ExecuteStreamed(ctx context.Context, req *openfgav1.StreamedListObjectsRequest, srv openfgav1.OpenFGAService_StreamedListObjectsServer) (*graph.ResolutionMetadata, error) {
+	return q.ExecuteStreamedWithCheck(ctx, req, srv, q.streamCheckInterval, q.streamCheck)
+}
+
+// ExecuteStreamedWithCheck is ExecuteStreamed with the stream check of the request instead of the
+// one of WithStreamedListObjectsCheck, so that a ListObjectsQuery can be shared by concurrent
+// requests. A nil check or a zero interval disables the check.
+func (q *ListObjectsQuery) ExecuteStreamedWithCheck(
+	ctx context.Context,
+	req *openfgav1.StreamedListObjectsRequest,
+	srv openfgav1.OpenFGAService_StreamedListObjectsServer,
+	streamCheckInterval time.Duration,
+	streamCheck func(context.Context) error,
+) (*graph.ResolutionMetadata, error) {
 	maxResults := uint32(math.MaxUint32)
 	// make a buffered channel so that writer goroutines aren't blocked when attempting to send a result
 	resultsChan := make(chan ListObjectsResult, streamedBufferSize)
 
 	timeoutCtx := ctx
-	if q.listObjectsDeadline != 0 {
+	if q.listObjectsDeadline > 0 {
 		var cancel context.CancelFunc
 		timeoutCtx, cancel = context.WithTimeout(ctx, q.listObjectsDeadline)
 		defer cancel()
 	}
 
-	resolutionMetadata := NewListObjectsResolutionMetadata()
+	// stops the evaluation if the stream ends before every result is sent
+	timeoutCtx, cancel := context.WithCancel(timeoutCtx)
+	defer cancel()
+
+	resolutionMetadata := graph.NewResolutionMetadata()
 
 	err := q.evaluate(timeoutCtx, req, resultsChan, maxResults, resolutionMetadata)
 	if err != nil {
 		return nil, err
 	}
 
-	for result := range resultsChan {
+	var streamCheckTick <-chan time.Time
+	if streamCheck != nil && streamCheckInterval > 0 {
+		ticker := time.NewTicker(streamCheckInterval)
+		defer ticker.Stop()
+		streamCheckTick = ticker.C
+	}
+
+	var maxDurationReached <-chan time.Time
+	if q.streamMaxDuration > 0 {
+		timer := time.NewTimer(q.streamMaxDuration)
+		defer timer.Stop()
+		maxDurationReached = timer.C
+	}
+
+	for {
+		var result ListObjectsResult
+		select {
+		case <-maxDurationReached:
+			// the deferred cancel stops the evaluation of the results that are not sent
+			return resolutionMetadata, ErrStreamMaxDurationReached
+		case <-streamCheckTick:
+			if err := streamCheck(timeoutCtx); err != nil {
+				return nil, err
+			}
+			continue
+		case res, ok := <-resultsChan:
+			if !ok {
+				return resolutionMetadata, nil
+			}
+			result = res
+		}
+
 		if result.Err != nil {
 			if errors.Is(result.Err, serverErrors.AuthorizationModelResolutionTooComplex) {
 				return nil, result.Err
 			}
 
+			if errors.Is(result.Err, reverseexpand.ErrMaxCandidatesExceeded) {
+				return nil, serverErrors.ListObjectsMaxCandidatesExceeded(q.maxCandidates)
+			}
+
 			if errors.Is(result.Err, condition.ErrEvaluationFailed) {
 				return nil, serverErrors.ValidationError(result.Err)
 			}
@@ -508,6 +747,4 @@ func (q *ListObjectsQuery) ExecuteStreamed(ctx context.Context, req *openfgav1.S
 			return nil, serverErrors.HandleError("", err)
 		}
 	}
-
-	return resolutionMetadata, nil
 }
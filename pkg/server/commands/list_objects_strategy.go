@@ -0,0 +1,735 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/internal/condition"
+	"github.com/openfga/openfga/internal/condition/eval"
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/server/commands/reverseexpand"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// ListObjectsStrategy is the way ListObjects finds the objects of a request.
+type ListObjectsStrategy string
+
+const (
+	// ListObjectsStrategyAuto picks the strategy from the shape of the requested relation.
+	ListObjectsStrategyAuto ListObjectsStrategy = "auto"
+
+	// ListObjectsStrategyDirect reads the tuples of the user with ReadStartingWithUser. It only
+	// applies to relations that are directly assigned, possibly through computed relations of
+	// the same type, without usersets or tuple to usersets. For other relations, the reverse
+	// expansion is used instead.
+	ListObjectsStrategyDirect ListObjectsStrategy = "direct"
+
+	// ListObjectsStrategyReverseExpand expands the relation from the user to the objects, and
+	// checks the objects reached through an intersection or exclusion.
+	ListObjectsStrategyReverseExpand ListObjectsStrategy = "reverse_expand"
+
+	// ListObjectsStrategyCheckCandidates reads the objects of the requested type that have a
+	// tuple, limited to the tuplesets of the relation if it is only made of tuple to usersets,
+	// and checks each of them. Unlike the reverse expansion, it evaluates the conditions of
+	// tuples that are not related to the user, whose evaluation errors fail the request.
+	ListObjectsStrategyCheckCandidates ListObjectsStrategy = "check_candidates"
+
+	// ListObjectsStrategyFilteredBase applies to relations defined with an exclusion or an
+	// intersection, e.g. `[user] but not blocked`. It finds the objects of the base of the
+	// relation, e.g. its directly assigned objects, and checks in bulk only the excluded or
+	// intersected relations on them, instead of the whole relation. The base must be directly
+	// assigned or a computed relation, and the other operands computed relations. For other
+	// relations, the reverse expansion is used instead.
+	ListObjectsStrategyFilteredBase ListObjectsStrategy = "filtered_base"
+)
+
+// listObjectsCheckCandidatesMinTuplesets is the number of tuple to usersets from which a relation
+// that is only made of tuple to usersets is resolved with ListObjectsStrategyCheckCandidates:
+// the reverse expansion reads the tuples of every tupleset for every object it reaches.
+const listObjectsCheckCandidatesMinTuplesets = 2
+
+var listObjectsStrategyCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "list_objects_strategy_count",
+	Help:      "The number of ListObjects and StreamedListObjects requests, labeled by the strategy used to find their objects.",
+}, []string{"strategy"})
+
+// relationShape summarizes the rewrite of a relation, following the computed relations of the
+// same type it refers to.
+type relationShape struct {
+	// directRelations are the directly assignable relations.
+	directRelations []string
+	// tuplesetRelations are the tupleset relations of the tuple to usersets.
+	tuplesetRelations []string
+	// usersetTypes is true if a userset, e.g. group#member, is assignable to a direct relation.
+	usersetTypes bool
+	// complex is true if the rewrite has an intersection or exclusion, or refers to a relation
+	// more than once.
+	complex bool
+	// conditional is true if the model defines conditions.
+	conditional bool
+}
+
+func newRelationShape(typesys *typesystem.TypeSystem, objectType, relation string) (*relationShape, error) {
+	shape := &relationShape{conditional: len(typesys.GetConditions()) > 0}
+	visited := map[string]struct{}{}
+
+	var visitRelation func(relation string) error
+	var visitRewrite func(rewrite *openfgav1.Userset, relation string) error
+
+	visitRelation = func(relation string) error {
+		if _, ok := visited[relation]; ok {
+			shape.complex = true
+			return nil
+		}
+		visited[relation] = struct{}{}
+
+		rel, err := typesys.GetRelation(objectType, relation)
+		if err != nil {
+			return err
+		}
+		return visitRewrite(rel.GetRewrite(), relation)
+	}
+
+	visitRewrite = func(rewrite *openfgav1.Userset, relation string) error {
+		switch rw := rewrite.GetUserset().(type) {
+		case *openfgav1.Userset_This:
+			shape.directRelations = append(shape.directRelations, relation)
+
+			directlyRelatedTypes, err := typesys.GetDirectlyRelatedUserTypes(objectType, relation)
+			if err != nil {
+				return err
+			}
+			for _, ref := range directlyRelatedTypes {
+				if ref.GetRelation() != "" {
+					shape.usersetTypes = true
+				}
+			}
+		case *openfgav1.Userset_ComputedUserset:
+			return visitRelation(rw.ComputedUserset.GetRelation())
+		case *openfgav1.Userset_TupleToUserset:
+			tupleset := rw.TupleToUserset.GetTupleset().GetRelation()
+			if !slices.Contains(shape.tuplesetRelations, tupleset) {
+				shape.tuplesetRelations = append(shape.tuplesetRelations, tupleset)
+			}
+		case *openfgav1.Userset_Union:
+			for _, child := range rw.Union.GetChild() {
+				if err := visitRewrite(child, relation); err != nil {
+					return err
+				}
+			}
+		default:
+			shape.complex = true
+		}
+		return nil
+	}
+
+	if err := visitRelation(relation); err != nil {
+		return nil, err
+	}
+	return shape, nil
+}
+
+// isDirect reports whether the objects of the relation are those of the tuples of the user on
+// its direct relations.
+func (s *relationShape) isDirect() bool {
+	return !s.complex && !s.usersetTypes && len(s.tuplesetRelations) == 0 && len(s.directRelations) > 0
+}
+
+// isTuplesetsOnly reports whether every object of the relation is the object of a tuple on one
+// of its tupleset relations.
+func (s *relationShape) isTuplesetsOnly() bool {
+	return !s.complex && len(s.directRelations) == 0 && len(s.tuplesetRelations) > 0
+}
+
+// filteredBasePlan is how ListObjectsStrategyFilteredBase resolves a relation defined with an
+// exclusion or an intersection.
+type filteredBasePlan struct {
+	// relation defines the exclusion or intersection. It is the requested relation, or a
+	// relation of the same type that the requested relation is computed from.
+	relation string
+	// baseRelation is the relation whose objects are filtered, or "" for the objects directly
+	// assigned to relation.
+	baseRelation string
+	// usersetTypes is true if a userset is assignable to relation. The directly assigned objects
+	// are read like for ListObjectsStrategyDirect, so they cannot be the base then.
+	usersetTypes bool
+	// filters are checked in order on the objects of the base.
+	filters []baseFilter
+}
+
+// baseFilter is a relation checked on the objects of the base of a filteredBasePlan.
+type baseFilter struct {
+	relation string
+	// exclude drops the objects on which the relation is allowed, instead of those on which it
+	// is not.
+	exclude bool
+}
+
+// newFilteredBasePlan returns the plan of ListObjectsStrategyFilteredBase for the relation, or nil
+// if the strategy does not apply to it.
+func newFilteredBasePlan(typesys *typesystem.TypeSystem, objectType, relation string) *filteredBasePlan {
+	visited := map[string]struct{}{}
+	for {
+		if _, ok := visited[relation]; ok {
+			return nil
+		}
+		visited[relation] = struct{}{}
+
+		rel, err := typesys.GetRelation(objectType, relation)
+		if err != nil {
+			return nil
+		}
+
+		var base *openfgav1.Userset
+		var filters []baseFilter
+		switch rw := rel.GetRewrite().GetUserset().(type) {
+		case *openfgav1.Userset_ComputedUserset:
+			relation = rw.ComputedUserset.GetRelation()
+			continue
+		case *openfgav1.Userset_Difference:
+			subtract := rw.Difference.GetSubtract().GetComputedUserset().GetRelation()
+			if subtract == "" {
+				return nil
+			}
+			base = rw.Difference.GetBase()
+			filters = []baseFilter{{relation: subtract, exclude: true}}
+		case *openfgav1.Userset_Intersection:
+			children := rw.Intersection.GetChild()
+			// the directly assigned objects are the cheapest base to read
+			baseIndex := slices.IndexFunc(children, func(child *openfgav1.Userset) bool {
+				_, ok := child.GetUserset().(*openfgav1.Userset_This)
+				return ok
+			})
+			if baseIndex < 0 {
+				baseIndex = slices.IndexFunc(children, func(child *openfgav1.Userset) bool {
+					return child.GetComputedUserset() != nil
+				})
+			}
+			if baseIndex < 0 {
+				return nil
+			}
+			base = children[baseIndex]
+			for i, child := range children {
+				if i == baseIndex {
+					continue
+				}
+				intersected := child.GetComputedUserset().GetRelation()
+				if intersected == "" {
+					return nil
+				}
+				filters = append(filters, baseFilter{relation: intersected})
+			}
+		default:
+			return nil
+		}
+
+		plan := &filteredBasePlan{relation: relation, filters: filters}
+		switch base.GetUserset().(type) {
+		case *openfgav1.Userset_This:
+			directlyRelatedTypes, err := typesys.GetDirectlyRelatedUserTypes(objectType, relation)
+			if err != nil {
+				return nil
+			}
+			for _, ref := range directlyRelatedTypes {
+				if ref.GetRelation() != "" {
+					plan.usersetTypes = true
+				}
+			}
+		case *openfgav1.Userset_ComputedUserset:
+			plan.baseRelation = base.GetComputedUserset().GetRelation()
+		default:
+			return nil
+		}
+
+		// a filter or base that depends on the relation itself is resolved differently on its
+		// own, e.g. a cycle through the subtracted relation is not allowed when checking the
+		// relation but is when checking the subtracted relation
+		operands := []string{plan.baseRelation}
+		for _, filter := range filters {
+			operands = append(operands, filter.relation)
+		}
+		for _, operand := range operands {
+			if operand != "" && relationReaches(typesys, objectType, operand, visited) {
+				return nil
+			}
+		}
+		return plan
+	}
+}
+
+// relationReaches reports whether the relation of the object type depends on one of the
+// relations of the same type, through computed relations, tuple to usersets or assignable
+// usersets. It conservatively reports true if the model cannot be walked.
+func relationReaches(typesys *typesystem.TypeSystem, targetType, relation string, targets map[string]struct{}) bool {
+	visited := map[string]struct{}{}
+
+	var reaches func(objectType, relation string) bool
+	var visitRelation func(objectType, relation string) bool
+	var visitRewrite func(rewrite *openfgav1.Userset, objectType, relation string) bool
+
+	visitRelation = func(objectType, relation string) bool {
+		key := tuple.ToObjectRelationString(objectType, relation)
+		if _, ok := visited[key]; ok {
+			return false
+		}
+		visited[key] = struct{}{}
+
+		rel, err := typesys.GetRelation(objectType, relation)
+		if err != nil {
+			return true
+		}
+		return visitRewrite(rel.GetRewrite(), objectType, relation)
+	}
+
+	visitRewrite = func(rewrite *openfgav1.Userset, objectType, relation string) bool {
+		var children []*openfgav1.Userset
+		switch rw := rewrite.GetUserset().(type) {
+		case *openfgav1.Userset_This:
+			directlyRelatedTypes, err := typesys.GetDirectlyRelatedUserTypes(objectType, relation)
+			if err != nil {
+				return true
+			}
+			for _, ref := range directlyRelatedTypes {
+				if ref.GetRelation() != "" && reaches(ref.GetType(), ref.GetRelation()) {
+					return true
+				}
+			}
+			return false
+		case *openfgav1.Userset_ComputedUserset:
+			return reaches(objectType, rw.ComputedUserset.GetRelation())
+		case *openfgav1.Userset_TupleToUserset:
+			directlyRelatedTypes, err := typesys.GetDirectlyRelatedUserTypes(objectType, rw.TupleToUserset.GetTupleset().GetRelation())
+			if err != nil {
+				return true
+			}
+			for _, ref := range directlyRelatedTypes {
+				computed := rw.TupleToUserset.GetComputedUserset().GetRelation()
+				if _, err := typesys.GetRelation(ref.GetType(), computed); err != nil {
+					continue
+				}
+				if reaches(ref.GetType(), computed) {
+					return true
+				}
+			}
+			return false
+		case *openfgav1.Userset_Union:
+			children = rw.Union.GetChild()
+		case *openfgav1.Userset_Intersection:
+			children = rw.Intersection.GetChild()
+		case *openfgav1.Userset_Difference:
+			children = []*openfgav1.Userset{rw.Difference.GetBase(), rw.Difference.GetSubtract()}
+		}
+		for _, child := range children {
+			if visitRewrite(child, objectType, relation) {
+				return true
+			}
+		}
+		return false
+	}
+
+	reaches = func(objectType, relation string) bool {
+		if _, ok := targets[relation]; ok && objectType == targetType {
+			return true
+		}
+		return visitRelation(objectType, relation)
+	}
+
+	return reaches(targetType, relation)
+}
+
+// appliesTo reports whether the plan can find the objects of the user.
+func (p *filteredBasePlan) appliesTo(user reverseexpand.IsUserRef) bool {
+	if p == nil {
+		return false
+	}
+	if p.baseRelation != "" {
+		return true
+	}
+	_, isUsersetUser := user.(*reverseexpand.UserRefObjectRelation)
+	return !p.usersetTypes && !isUsersetUser
+}
+
+// planListObjectsStrategy returns the strategy that ListObjects uses for the relation and user.
+// A ListObjectsStrategyDirect or ListObjectsStrategyFilteredBase that does not apply to the
+// relation is replaced by ListObjectsStrategyReverseExpand. ListObjectsStrategyCheckCandidates
+// is not picked for models with conditions, since it may fail on the conditions of tuples
+// unrelated to the user.
+func planListObjectsStrategy(strategy ListObjectsStrategy, shape *relationShape, plan *filteredBasePlan, user reverseexpand.IsUserRef) ListObjectsStrategy {
+	_, isUsersetUser := user.(*reverseexpand.UserRefObjectRelation)
+	canReadDirectly := shape.isDirect() && !isUsersetUser
+
+	switch strategy {
+	case ListObjectsStrategyDirect:
+		if canReadDirectly {
+			return ListObjectsStrategyDirect
+		}
+		return ListObjectsStrategyReverseExpand
+	case ListObjectsStrategyFilteredBase:
+		if plan.appliesTo(user) {
+			return ListObjectsStrategyFilteredBase
+		}
+		return ListObjectsStrategyReverseExpand
+	case ListObjectsStrategyReverseExpand, ListObjectsStrategyCheckCandidates:
+		return strategy
+	}
+
+	switch {
+	case canReadDirectly:
+		return ListObjectsStrategyDirect
+	case plan.appliesTo(user):
+		return ListObjectsStrategyFilteredBase
+	case shape.isTuplesetsOnly() && len(shape.tuplesetRelations) >= listObjectsCheckCandidatesMinTuplesets && !shape.conditional:
+		return ListObjectsStrategyCheckCandidates
+	default:
+		return ListObjectsStrategyReverseExpand
+	}
+}
+
+// recordListObjectsStrategy sets the strategy as a span attribute and counts it. For
+// ListObjectsStrategyFilteredBase, the base and the filters of the plan are span attributes too.
+func recordListObjectsStrategy(ctx context.Context, strategy ListObjectsStrategy, plan *filteredBasePlan) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("list_objects_strategy", string(strategy)))
+	if strategy == ListObjectsStrategyFilteredBase {
+		base := plan.baseRelation
+		if base == "" {
+			base = plan.relation + " (direct)"
+		}
+		filters := make([]string, 0, len(plan.filters))
+		for _, filter := range plan.filters {
+			if filter.exclude {
+				filters = append(filters, "but not "+filter.relation)
+			} else {
+				filters = append(filters, "and "+filter.relation)
+			}
+		}
+		span.SetAttributes(
+			attribute.String("list_objects_filtered_base", base),
+			attribute.StringSlice("list_objects_filters", filters),
+		)
+	}
+	listObjectsStrategyCounter.WithLabelValues(string(strategy)).Inc()
+}
+
+// candidateSet deduplicates the objects yielded by the ListObjectsStrategyDirect and
+// ListObjectsStrategyCheckCandidates enumerations, up to maxCandidates objects (0 means no limit).
+type candidateSet struct {
+	seen          map[string]struct{}
+	maxCandidates uint32
+}
+
+func newCandidateSet(maxCandidates uint32) *candidateSet {
+	return &candidateSet{seen: map[string]struct{}{}, maxCandidates: maxCandidates}
+}
+
+// send sends the object on resultChan unless it was already sent.
+func (s *candidateSet) send(ctx context.Context, object string, status reverseexpand.ConditionalResultStatus, resultChan chan<- *reverseexpand.ReverseExpandResult) error {
+	if _, ok := s.seen[object]; ok {
+		return nil
+	}
+	s.seen[object] = struct{}{}
+	if s.maxCandidates != 0 && uint32(len(s.seen)) > s.maxCandidates {
+		return reverseexpand.ErrMaxCandidatesExceeded
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case resultChan <- &reverseexpand.ReverseExpandResult{Object: object, ResultStatus: status}:
+		return nil
+	}
+}
+
+// listObjectsEnumerateRequest is the input of the ListObjectsStrategyDirect and
+// ListObjectsStrategyCheckCandidates enumerations.
+type listObjectsEnumerateRequest struct {
+	req        listObjectsRequest
+	typesys    *typesystem.TypeSystem
+	shape      *relationShape
+	user       reverseexpand.IsUserRef
+	datastore  storage.RelationshipTupleReader
+	resultChan chan<- *reverseexpand.ReverseExpandResult
+	metadata   *graph.ResolutionMetadata
+}
+
+// enumerateDirect yields the objects of the tuples of the user, or of its type wildcard, on the
+// direct relations of the shape, whose condition is met. Like [reverseexpand.ReverseExpandQuery.Execute],
+// it closes the result channel if it does not return an error.
+func (q *ListObjectsQuery) enumerateDirect(ctx context.Context, e *listObjectsEnumerateRequest) error {
+	objectType := e.req.GetType()
+	userType := e.user.GetObjectType()
+	candidates := newCandidateSet(q.maxCandidates)
+
+	var errs error
+	for _, relation := range e.shape.directRelations {
+		var userFilter []*openfgav1.ObjectRelation
+
+		publiclyAssignable, err := e.typesys.IsPubliclyAssignable(typesystem.DirectRelationReference(objectType, relation), userType)
+		if err != nil {
+			return err
+		}
+		if publiclyAssignable {
+			userFilter = append(userFilter, &openfgav1.ObjectRelation{Object: tuple.TypedPublicWildcard(userType)})
+		}
+		if user, ok := e.user.(*reverseexpand.UserRefObject); ok {
+			userFilter = append(userFilter, &openfgav1.ObjectRelation{
+				Object: tuple.BuildObject(user.Object.GetType(), user.Object.GetId()),
+			})
+		}
+		if len(userFilter) == 0 {
+			continue
+		}
+
+		iter, err := e.datastore.ReadStartingWithUser(ctx, e.req.GetStoreId(), storage.ReadStartingWithUserFilter{
+			ObjectType: objectType,
+			Relation:   relation,
+			UserFilter: userFilter,
+		}, storage.ReadStartingWithUserOptions{
+			Consistency: storage.ConsistencyOptions{
+				Preference: e.req.GetConsistency(),
+			},
+		})
+		e.metadata.DatastoreQueryCount.Add(1)
+		if err != nil {
+			return err
+		}
+
+		err = q.sendTupleObjects(ctx, e, iter, candidates, reverseexpand.NoFurtherEvalStatus, func(tk *openfgav1.TupleKey) bool {
+			condEvalResult, err := eval.EvaluateTupleCondition(ctx, tk, e.typesys, e.req.GetContext())
+			if err != nil {
+				errs = errors.Join(errs, err)
+				return false
+			}
+
+			if !condEvalResult.ConditionMet {
+				e.metadata.ConditionalExclusionCount.Add(1)
+				if len(condEvalResult.MissingParameters) > 0 {
+					errs = errors.Join(errs, condition.NewEvaluationError(
+						tk.GetCondition().GetName(),
+						fmt.Errorf("tuple '%s' is missing context parameters '%v'",
+							tuple.TupleKeyToString(tk),
+							condEvalResult.MissingParameters),
+					))
+				}
+				return false
+			}
+
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if errs != nil {
+		return errs
+	}
+
+	close(e.resultChan)
+	return nil
+}
+
+// enumerateCandidates yields the objects of the requested type that have a tuple on one of the
+// tupleset relations of the shape, or on any relation if the relation is not only made of tuple
+// to usersets, for the caller to check. Like [reverseexpand.ReverseExpandQuery.Execute], it
+// closes the result channel if it does not return an error.
+func (q *ListObjectsQuery) enumerateCandidates(ctx context.Context, e *listObjectsEnumerateRequest) error {
+	objectType := e.req.GetType()
+	candidates := newCandidateSet(q.maxCandidates)
+
+	// a userset of an object of the requested type, e.g. document:1#editor, may be related to
+	// the object without any tuple
+	if user, ok := e.user.(*reverseexpand.UserRefObjectRelation); ok && tuple.GetType(user.ObjectRelation.GetObject()) == objectType {
+		if err := candidates.send(ctx, user.ObjectRelation.GetObject(), reverseexpand.RequiresFurtherEvalStatus, e.resultChan); err != nil {
+			return err
+		}
+	}
+
+	relations := []string{""}
+	if e.shape.isTuplesetsOnly() {
+		relations = e.shape.tuplesetRelations
+	}
+
+	for _, relation := range relations {
+		// contextual tuples are only matched by object ID, so they are read separately
+		var contextualTuples []*openfgav1.Tuple
+		for _, tk := range e.req.GetContextualTuples().GetTupleKeys() {
+			if tuple.GetType(tk.GetObject()) == objectType && (relation == "" || tk.GetRelation() == relation) {
+				contextualTuples = append(contextualTuples, &openfgav1.Tuple{Key: tk})
+			}
+		}
+
+		iter, err := e.datastore.Read(ctx, e.req.GetStoreId(), tuple.NewTupleKey(tuple.BuildObject(objectType, ""), relation, ""), storage.ReadOptions{
+			Consistency: storage.ConsistencyOptions{
+				Preference: e.req.GetConsistency(),
+			},
+		})
+		e.metadata.DatastoreQueryCount.Add(1)
+		if err != nil {
+			return err
+		}
+
+		iter = storage.NewCombinedIterator(storage.NewStaticTupleIterator(contextualTuples), iter)
+		err = q.sendTupleObjects(ctx, e, iter, candidates, reverseexpand.RequiresFurtherEvalStatus, func(*openfgav1.TupleKey) bool {
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	close(e.resultChan)
+	return nil
+}
+
+// sendTupleObjects sends the objects of the valid tuples of the iterator for which include
+// returns true, with the given status.
+func (q *ListObjectsQuery) sendTupleObjects(
+	ctx context.Context,
+	e *listObjectsEnumerateRequest,
+	iter storage.TupleIterator,
+	candidates *candidateSet,
+	status reverseexpand.ConditionalResultStatus,
+	include func(*openfgav1.TupleKey) bool,
+) error {
+	filteredIter := storage.NewFilteredTupleKeyIterator(
+		storage.NewTupleKeyIteratorFromTupleIterator(iter),
+		validation.FilterInvalidTuples(e.typesys),
+	)
+	defer filteredIter.Stop()
+
+	for {
+		tk, err := filteredIter.Next(ctx)
+		if err != nil {
+			if errors.Is(err, storage.ErrIteratorDone) {
+				return nil
+			}
+			return err
+		}
+
+		if !include(tk) {
+			continue
+		}
+
+		if err := candidates.send(ctx, tk.GetObject(), status, e.resultChan); err != nil {
+			return err
+		}
+	}
+}
+
+// filterBase checks the filters of the plan on the objects of the base received from objects, in
+// a pipeline of bulk checks, one per filter, that share the memo of the bulk checker. An object
+// leaves the pipeline as soon as a filter drops it, and send is called, never concurrently, with
+// the objects that pass every filter, until it returns false. The numbers of objects of the base
+// and of objects dropped are span attributes.
+func (q *ListObjectsQuery) filterBase(
+	ctx context.Context,
+	bulkChecker *graph.BulkChecker,
+	req *graph.BulkCheckRequest,
+	plan *filteredBasePlan,
+	objects <-chan string,
+	send func(object string) bool,
+) (*graph.ResolutionMetadata, error) {
+	ctx, span := tracer.Start(ctx, "filterBase")
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		stopped  atomic.Bool
+		received atomic.Int64
+		dropped  atomic.Int64
+	)
+	resolutionMetadata := graph.NewResolutionMetadata()
+	errs := make([]error, len(plan.filters))
+
+	base := make(chan string)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(base)
+		for object := range objects {
+			received.Add(1)
+			select {
+			case base <- object:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var in <-chan string = base
+	for i, filter := range plan.filters {
+		stageIn := in
+		out := make(chan string)
+		last := i == len(plan.filters)-1
+		filterReq := *req
+		filterReq.Relation = filter.relation
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(out)
+
+			metadata, err := bulkChecker.ExecuteWithOutcomes(ctx, &filterReq, stageIn, func(object string, allowed bool) bool {
+				if allowed == filter.exclude {
+					dropped.Add(1)
+					return true
+				}
+				if last {
+					if !send(object) {
+						stopped.Store(true)
+						return false
+					}
+					return true
+				}
+				select {
+				case out <- object:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			})
+			resolutionMetadata.Merge(metadata)
+			if err != nil || stopped.Load() {
+				errs[i] = err
+				cancel()
+			}
+		}()
+		in = out
+	}
+
+	wg.Wait()
+
+	span.SetAttributes(
+		attribute.Int64("base_objects", received.Load()),
+		attribute.Int64("dropped_objects", dropped.Load()),
+	)
+
+	if stopped.Load() {
+		return resolutionMetadata, nil
+	}
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return resolutionMetadata, err
+		}
+	}
+	return resolutionMetadata, nil
+}
@@ -2,20 +2,31 @@ package commands
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 
 	"github.com/openfga/openfga/internal/graph"
 	"github.com/openfga/openfga/internal/mocks"
 	"github.com/openfga/openfga/internal/throttler/threshold"
+	"github.com/openfga/openfga/pkg/server/commands/reverseexpand"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+	"github.com/openfga/openfga/pkg/storage/sqlite"
+	"github.com/openfga/openfga/pkg/storage/storagewrappers"
 	storagetest "github.com/openfga/openfga/pkg/storage/test"
+	storagefixtures "github.com/openfga/openfga/pkg/testfixtures/storage"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
 )
 
@@ -34,6 +45,20 @@ func TestNewListObjectsQuery(t *testing.T) {
 		require.Error(t, err)
 	})
 
+	t.Run("negative_deadline", func(t *testing.T) {
+		checkResolver := graph.NewLocalChecker()
+		q, err := NewListObjectsQuery(memory.New(), checkResolver, WithListObjectsDeadline(-time.Second))
+		require.Nil(t, q)
+		require.EqualError(t, err, "the ListObjects deadline must not be negative, use 0 to disable it")
+	})
+
+	t.Run("negative_stream_max_duration", func(t *testing.T) {
+		checkResolver := graph.NewLocalChecker()
+		q, err := NewListObjectsQuery(memory.New(), checkResolver, WithStreamedListObjectsMaxDuration(-time.Second))
+		require.Nil(t, q)
+		require.EqualError(t, err, "the maximum duration of the streams must not be negative, use 0 to disable it")
+	})
+
 	t.Run("empty_typesystem_in_context", func(t *testing.T) {
 		checkResolver := graph.NewLocalChecker()
 		q, err := NewListObjectsQuery(memory.New(), checkResolver)
@@ -44,6 +69,273 @@ func TestNewListObjectsQuery(t *testing.T) {
 	})
 }
 
+func TestListObjectsMaxCandidates(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID, model := storagetest.BootstrapFGAStore(t, ds, `
+		model
+			schema 1.1
+
+		type user
+
+		type folder
+			relations
+				define viewer: [user]`, []string{
+		"folder:A#viewer@user:jon",
+		"folder:B#viewer@user:jon",
+		"folder:C#viewer@user:jon",
+	})
+	ts, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+	ctx := typesystem.ContextWithTypesystem(context.Background(), ts)
+
+	checker, checkResolverCloser := graph.NewOrderedCheckResolvers().Build()
+	t.Cleanup(checkResolverCloser)
+
+	req := &openfgav1.ListObjectsRequest{
+		StoreId:  storeID,
+		Type:     "folder",
+		Relation: "viewer",
+		User:     "user:jon",
+	}
+
+	t.Run("within_limit", func(t *testing.T) {
+		q, err := NewListObjectsQuery(ds, checker, WithListObjectsMaxCandidates(3))
+		require.NoError(t, err)
+
+		resp, err := q.Execute(ctx, req)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"folder:A", "folder:B", "folder:C"}, resp.Objects)
+	})
+
+	t.Run("exceeds_limit", func(t *testing.T) {
+		q, err := NewListObjectsQuery(ds, checker, WithListObjectsMaxCandidates(2))
+		require.NoError(t, err)
+
+		_, err = q.Execute(ctx, req)
+		require.Error(t, err)
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+		require.ErrorContains(t, err, "maximum of 2 candidate objects")
+	})
+}
+
+func TestListObjectsZeroDeadlineAndMaxResults(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID, model := storagetest.BootstrapFGAStore(t, ds, `
+		model
+			schema 1.1
+
+		type user
+
+		type folder
+			relations
+				define viewer: [user]`, []string{
+		"folder:1#viewer@user:jon",
+		"folder:2#viewer@user:jon",
+		"folder:3#viewer@user:jon",
+	})
+	ts, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+	ctx := typesystem.ContextWithTypesystem(context.Background(), ts)
+
+	checkResolver, checkResolverCloser := graph.NewOrderedCheckResolvers().Build()
+	t.Cleanup(checkResolverCloser)
+
+	req := &openfgav1.ListObjectsRequest{
+		StoreId:  storeID,
+		Type:     "folder",
+		Relation: "viewer",
+		User:     "user:jon",
+	}
+
+	tests := map[string]struct {
+		deadline        time.Duration
+		maxResults      uint32
+		expectedObjects int
+	}{
+		`zero_deadline_waits_for_every_result`: {
+			deadline:        0,
+			maxResults:      100,
+			expectedObjects: 3,
+		},
+		`zero_max_results_returns_every_result`: {
+			deadline:        10 * time.Second,
+			maxResults:      0,
+			expectedObjects: 3,
+		},
+		`zero_deadline_and_max_results`: {
+			deadline:        0,
+			maxResults:      0,
+			expectedObjects: 3,
+		},
+		`max_results_less_than_actual_results`: {
+			deadline:        10 * time.Second,
+			maxResults:      2,
+			expectedObjects: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// the reads are slower than any deadline that would expire immediately
+			q, err := NewListObjectsQuery(mocks.NewMockSlowDataStorage(ds, 20*time.Millisecond), checkResolver,
+				WithListObjectsDeadline(test.deadline),
+				WithListObjectsMaxResults(test.maxResults),
+			)
+			require.NoError(t, err)
+
+			resp, err := q.Execute(ctx, req)
+			require.NoError(t, err)
+			require.Len(t, resp.Objects, test.expectedObjects)
+		})
+	}
+}
+
+func TestListObjectsQuerySharedByConcurrentRequests(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	const users = 10
+	var tuples []string
+	for i := 0; i < users; i++ {
+		tuples = append(tuples,
+			fmt.Sprintf("folder:%d#viewer@user:%d", i, i),
+			fmt.Sprintf("document:%d#parent@folder:%d", i, i),
+			fmt.Sprintf("document:%d#allowed@user:%d", i, i),
+			fmt.Sprintf("document:direct-%d#viewer@user:%d", i, i),
+		)
+	}
+	storeID, model := storagetest.BootstrapFGAStore(t, ds, `
+		model
+			schema 1.1
+
+		type user
+
+		type folder
+			relations
+				define viewer: [user]
+
+		type document
+			relations
+				define parent: [folder]
+				define allowed: [user]
+				define viewer: [user] or (viewer from parent and allowed)`, tuples)
+	ts, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+	ctx := typesystem.ContextWithTypesystem(context.Background(), ts)
+
+	checker, checkResolverCloser := graph.NewOrderedCheckResolvers().Build()
+	t.Cleanup(checkResolverCloser)
+
+	// the hedges and the concurrent reads are bounded per request, so one read at a time must not
+	// serialize the requests sharing the query
+	q, err := NewListObjectsQuery(ds, checker,
+		WithMaxConcurrentReads(1),
+		WithDatastoreHedging(storagewrappers.NewHedgingPolicy(time.Millisecond, 95, 1)),
+	)
+	require.NoError(t, err)
+
+	const repeats = 20
+	type result struct {
+		user    int
+		objects []string
+		err     error
+	}
+	results := make(chan result, repeats*users)
+	var wg sync.WaitGroup
+	for i := 0; i < repeats*users; i++ {
+		wg.Add(1)
+		go func(user int) {
+			defer wg.Done()
+			resp, err := q.Execute(ctx, &openfgav1.ListObjectsRequest{
+				StoreId:  storeID,
+				Type:     "document",
+				Relation: "viewer",
+				User:     fmt.Sprintf("user:%d", user),
+			})
+			if err != nil {
+				results <- result{user: user, err: err}
+				return
+			}
+			results <- result{user: user, objects: resp.Objects}
+		}(i % users)
+	}
+	wg.Wait()
+	close(results)
+
+	for res := range results {
+		require.NoError(t, res.err)
+		require.ElementsMatch(t, []string{fmt.Sprintf("document:%d", res.user), fmt.Sprintf("document:direct-%d", res.user)}, res.objects)
+	}
+}
+
+// BenchmarkListObjectsQuery compares constructing a ListObjectsQuery for each request, as the
+// server used to, with sharing one query between the requests.
+func BenchmarkListObjectsQuery(b *testing.B) {
+	ds := memory.New()
+	b.Cleanup(ds.Close)
+
+	storeID, model := storagetest.BootstrapFGAStore(b, ds, `
+		model
+			schema 1.1
+
+		type user
+
+		type folder
+			relations
+				define viewer: [user]`, []string{
+		"folder:A#viewer@user:jon",
+	})
+	ts, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(b, err)
+	ctx := typesystem.ContextWithTypesystem(context.Background(), ts)
+
+	checker, checkResolverCloser := graph.NewOrderedCheckResolvers().Build()
+	b.Cleanup(checkResolverCloser)
+
+	opts := []ListObjectsQueryOption{
+		WithListObjectsDeadline(time.Second),
+		WithListObjectsMaxResults(100),
+		WithDispatchThrottlerConfig(threshold.Config{Threshold: 100}),
+		WithResolveNodeLimit(25),
+		WithResolveNodeBreadthLimit(100),
+		WithMaxConcurrentReads(30),
+		WithDatastoreHedging(storagewrappers.NewHedgingPolicy(time.Second, 95, 1)),
+		WithListObjectsMaxCandidates(1000),
+	}
+	req := &openfgav1.ListObjectsRequest{
+		StoreId:  storeID,
+		Type:     "folder",
+		Relation: "viewer",
+		User:     "user:jon",
+	}
+
+	b.Run("new_query_per_request", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			q, err := NewListObjectsQuery(ds, checker, opts...)
+			require.NoError(b, err)
+			_, err = q.Execute(ctx, req)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("shared_query", func(b *testing.B) {
+		q, err := NewListObjectsQuery(ds, checker, opts...)
+		require.NoError(b, err)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, err = q.Execute(ctx, req)
+			require.NoError(b, err)
+		}
+	})
+}
+
 func TestListObjectsDispatchCount(t *testing.T) {
 	ds := memory.New()
 	t.Cleanup(ds.Close)
@@ -129,7 +421,7 @@ func TestListObjectsDispatchCount(t *testing.T) {
 			objectType:              "folder",
 			relation:                "can_delete",
 			user:                    "user:jon",
-			expectedDispatchCount:   2,
+			expectedDispatchCount:   1,
 			expectedThrottlingValue: 0,
 		},
 		{
@@ -211,9 +503,11 @@ func TestListObjectsDispatchCount(t *testing.T) {
 				graph.WithLocalCheckerOpts(graph.WithMaxConcurrentReads(1))).Build()
 			t.Cleanup(checkResolverCloser)
 
+			// the dispatches counted are those of the reverse expansion
 			q, _ := NewListObjectsQuery(
 				ds,
 				checker,
+				WithListObjectsStrategy(ListObjectsStrategyReverseExpand),
 				WithDispatchThrottlerConfig(threshold.Config{
 					Throttler:    mockThrottler,
 					Enabled:      true,
@@ -232,8 +526,8 @@ func TestListObjectsDispatchCount(t *testing.T) {
 
 			require.NoError(t, err)
 
-			require.Equal(t, test.expectedDispatchCount, resp.ResolutionMetadata.DispatchCounter.Load())
-			require.Equal(t, test.expectedThrottlingValue > 0, resp.ResolutionMetadata.WasThrottled.Load())
+			require.Equal(t, test.expectedDispatchCount, resp.ResolutionMetadata.DispatchCount.Load())
+			require.Equal(t, test.expectedThrottlingValue > 0, resp.ResolutionMetadata.WasThrottled())
 		})
 	}
 }
@@ -295,9 +589,12 @@ func TestDoesNotUseCacheWhenHigherConsistencyEnabled(t *testing.T) {
 	}...).Build()
 	t.Cleanup(checkResolverCloser)
 
+	// the reverse expansion checks the viewer relation on each object, so its cached outcomes
+	// apply, whereas filtered_base would only check the blocked relation
 	q, _ := NewListObjectsQuery(
 		ds,
 		checkResolver,
+		WithListObjectsStrategy(ListObjectsStrategyReverseExpand),
 	)
 
 	// Run a check with MINIMIZE_LATENCY that will use the cache we added with 2 tuples
@@ -352,3 +649,379 @@ func TestDoesNotUseCacheWhenHigherConsistencyEnabled(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, resp.Objects, 2)
 }
+
+func TestPlanListObjectsStrategy(t *testing.T) {
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+
+		type user
+
+		type group
+			relations
+				define member: [user]
+
+		type folder
+			relations
+				define viewer: [user]
+
+		type document
+			relations
+				define parent: [folder]
+				define owner: [folder]
+				define editor: [user, user:*]
+				define viewer: [user] or editor
+				define grouped: [user, group#member]
+				define inherited: viewer from parent or viewer from owner
+				define parent_viewer: viewer from parent
+				define mixed: [user] or viewer from parent
+				define restricted: viewer but not editor
+				define blocked: [user]
+				define allowed: [user] but not blocked
+				define grouped_allowed: [user, group#member] but not blocked
+				define shared: grouped and viewer
+				define computed_allowed: allowed
+				define parent_restricted: viewer from parent but not blocked
+				define cyclic_blocked: [user, document#cyclic]
+				define cyclic: [user] but not cyclic_blocked`)
+	ts, err := typesystem.New(model)
+	require.NoError(t, err)
+
+	user := &reverseexpand.UserRefObject{Object: &openfgav1.Object{Type: "user", Id: "jon"}}
+	userset := &reverseexpand.UserRefObjectRelation{ObjectRelation: &openfgav1.ObjectRelation{Object: "group:eng", Relation: "member"}}
+
+	tests := []struct {
+		relation string
+		user     reverseexpand.IsUserRef
+		strategy ListObjectsStrategy
+		expected ListObjectsStrategy
+	}{
+		{relation: "editor", user: user, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyDirect},
+		{relation: "viewer", user: user, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyDirect},
+		{relation: "viewer", user: userset, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyReverseExpand},
+		{relation: "grouped", user: user, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyReverseExpand},
+		{relation: "inherited", user: user, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyCheckCandidates},
+		{relation: "parent_viewer", user: user, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyReverseExpand},
+		{relation: "mixed", user: user, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyReverseExpand},
+		{relation: "restricted", user: user, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyFilteredBase},
+		{relation: "restricted", user: userset, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyFilteredBase},
+		{relation: "restricted", user: user, strategy: ListObjectsStrategyDirect, expected: ListObjectsStrategyReverseExpand},
+		{relation: "allowed", user: user, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyFilteredBase},
+		{relation: "allowed", user: userset, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyReverseExpand},
+		{relation: "grouped_allowed", user: user, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyReverseExpand},
+		{relation: "shared", user: user, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyFilteredBase},
+		{relation: "computed_allowed", user: user, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyFilteredBase},
+		{relation: "parent_restricted", user: user, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyReverseExpand},
+		{relation: "parent_restricted", user: user, strategy: ListObjectsStrategyFilteredBase, expected: ListObjectsStrategyReverseExpand},
+		{relation: "cyclic", user: user, strategy: ListObjectsStrategyAuto, expected: ListObjectsStrategyReverseExpand},
+		{relation: "viewer", user: user, strategy: ListObjectsStrategyFilteredBase, expected: ListObjectsStrategyReverseExpand},
+		{relation: "viewer", user: user, strategy: ListObjectsStrategyReverseExpand, expected: ListObjectsStrategyReverseExpand},
+		{relation: "viewer", user: user, strategy: ListObjectsStrategyCheckCandidates, expected: ListObjectsStrategyCheckCandidates},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.strategy)+"_"+test.relation+"_"+test.user.String(), func(t *testing.T) {
+			shape, err := newRelationShape(ts, "document", test.relation)
+			require.NoError(t, err)
+			plan := newFilteredBasePlan(ts, "document", test.relation)
+			require.Equal(t, test.expected, planListObjectsStrategy(test.strategy, shape, plan, test.user))
+		})
+	}
+
+	t.Run("conditional_model", func(t *testing.T) {
+		model := testutils.MustTransformDSLToProtoWithID(`
+			model
+				schema 1.1
+
+			type user
+
+			type folder
+				relations
+					define viewer: [user with x_less_than]
+
+			type document
+				relations
+					define parent: [folder]
+					define owner: [folder]
+					define inherited: viewer from parent or viewer from owner
+
+			condition x_less_than(x: int) {
+				x < 100
+			}`)
+		ts, err := typesystem.New(model)
+		require.NoError(t, err)
+
+		shape, err := newRelationShape(ts, "document", "inherited")
+		require.NoError(t, err)
+		require.Equal(t, ListObjectsStrategyReverseExpand, planListObjectsStrategy(ListObjectsStrategyAuto, shape, nil, user))
+	})
+}
+
+func TestListObjectsFilteredBase(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID, model := storagetest.BootstrapFGAStore(t, ds, `
+		model
+			schema 1.1
+
+		type user
+
+		type group
+			relations
+				define member: [user]
+
+		type folder
+			relations
+				define viewer: [user]
+
+		type document
+			relations
+				define parent: [folder]
+				define blocked: [user, group#member]
+				define approved: [user]
+				define editor: [user]
+				define viewer: [user] or viewer from parent
+				define allowed: [user] but not blocked
+				define visible: viewer but not blocked
+				define approved_editor: editor and approved and viewer
+				define approved_allowed: allowed`, []string{
+		"folder:1#viewer@user:jon",
+		"group:banned#member@user:jon",
+		"document:1#parent@folder:1",
+		"document:2#parent@folder:1",
+		"document:3#viewer@user:jon",
+		"document:4#viewer@user:jon",
+		"document:2#blocked@user:jon",
+		"document:4#blocked@group:banned#member",
+		"document:1#allowed@user:jon",
+		"document:2#allowed@user:jon",
+		"document:3#allowed@user:jon",
+		"document:4#allowed@user:jon",
+		"document:1#editor@user:jon",
+		"document:2#editor@user:jon",
+		"document:3#editor@user:jon",
+		"document:1#approved@user:jon",
+		"document:3#approved@user:jon",
+		"document:4#approved@user:jon",
+	})
+	ts, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+	ctx := typesystem.ContextWithTypesystem(context.Background(), ts)
+
+	checker, checkResolverCloser := graph.NewOrderedCheckResolvers().Build()
+	t.Cleanup(checkResolverCloser)
+
+	tests := []struct {
+		relation string
+		user     string
+		expected []string
+	}{
+		{relation: "allowed", user: "user:jon", expected: []string{"document:1", "document:3"}},
+		{relation: "approved_allowed", user: "user:jon", expected: []string{"document:1", "document:3"}},
+		{relation: "visible", user: "user:jon", expected: []string{"document:1", "document:3"}},
+		{relation: "approved_editor", user: "user:jon", expected: []string{"document:1", "document:3"}},
+		{relation: "allowed", user: "user:maria", expected: nil},
+	}
+
+	for _, strategy := range []ListObjectsStrategy{ListObjectsStrategyReverseExpand, ListObjectsStrategyFilteredBase} {
+		q, err := NewListObjectsQuery(ds, checker, WithListObjectsStrategy(strategy))
+		require.NoError(t, err)
+
+		for _, test := range tests {
+			t.Run(string(strategy)+"_"+test.relation+"_"+test.user, func(t *testing.T) {
+				resp, err := q.Execute(ctx, &openfgav1.ListObjectsRequest{
+					StoreId:  storeID,
+					Type:     "document",
+					Relation: test.relation,
+					User:     test.user,
+				})
+				require.NoError(t, err)
+				require.ElementsMatch(t, test.expected, resp.Objects)
+			})
+		}
+	}
+
+	t.Run("max_results", func(t *testing.T) {
+		q, err := NewListObjectsQuery(ds, checker,
+			WithListObjectsStrategy(ListObjectsStrategyFilteredBase),
+			WithListObjectsMaxResults(1),
+		)
+		require.NoError(t, err)
+
+		resp, err := q.Execute(ctx, &openfgav1.ListObjectsRequest{
+			StoreId:  storeID,
+			Type:     "document",
+			Relation: "allowed",
+			User:     "user:jon",
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Objects, 1)
+		require.Subset(t, []string{"document:1", "document:3"}, resp.Objects)
+	})
+}
+
+// BenchmarkListObjectsFilteredBase compares the reverse expansion of an exclusion, which checks
+// the whole relation on every candidate, with checking only the excluded relation on them. The
+// tuples are in SQLite, since the memory datastore scans all the tuples of the store per read.
+func BenchmarkListObjectsFilteredBase(b *testing.B) {
+	testDatastore := storagefixtures.RunDatastoreTestContainer(b, "sqlite")
+	ds, err := sqlite.New(testDatastore.GetConnectionURI(true), sqlcommon.NewConfig())
+	require.NoError(b, err)
+	b.Cleanup(ds.Close)
+
+	const documents = 100_000
+	tuples := make([]string, 0, documents+documents/10)
+	for i := 0; i < documents; i++ {
+		tuples = append(tuples, fmt.Sprintf("document:%d#viewer@user:jon", i))
+		if i%10 == 0 {
+			tuples = append(tuples, fmt.Sprintf("document:%d#blocked@user:jon", i))
+		}
+	}
+	storeID, model := storagetest.BootstrapFGAStore(b, ds, `
+		model
+			schema 1.1
+
+		type user
+
+		type folder
+			relations
+				define viewer: [user]
+
+		type document
+			relations
+				define parent: [folder]
+				define blocked: [user] or viewer from parent
+				define viewer: [user] or viewer from parent
+				define visible: viewer but not blocked`, tuples)
+	ts, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(b, err)
+	ctx := typesystem.ContextWithTypesystem(context.Background(), ts)
+
+	checker, checkResolverCloser := graph.NewOrderedCheckResolvers().Build()
+	b.Cleanup(checkResolverCloser)
+
+	req := &openfgav1.ListObjectsRequest{
+		StoreId:  storeID,
+		Type:     "document",
+		Relation: "visible",
+		User:     "user:jon",
+	}
+
+	for _, strategy := range []ListObjectsStrategy{ListObjectsStrategyReverseExpand, ListObjectsStrategyFilteredBase} {
+		b.Run(string(strategy), func(b *testing.B) {
+			q, err := NewListObjectsQuery(ds, checker,
+				WithListObjectsStrategy(strategy),
+				WithListObjectsMaxResults(0),
+				WithListObjectsDeadline(0),
+			)
+			require.NoError(b, err)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				resp, err := q.Execute(ctx, req)
+				require.NoError(b, err)
+				require.Len(b, resp.Objects, documents-documents/10)
+			}
+		})
+	}
+}
+
+func TestListObjectsContextualTuples(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID, model := storagetest.BootstrapFGAStore(t, ds, `
+		model
+			schema 1.1
+
+		type user
+
+		type group
+			relations
+				define member: [user, group#member]
+
+		type folder
+			relations
+				define viewer: [user, user:*, group#member]
+
+		type document
+			relations
+				define parent: [folder]
+				define blocked: [user, group#member]
+				define editor: [user, user:*]
+				define viewer: [user, group#member] or editor or viewer from parent
+				define visible: viewer but not blocked
+				define inherited: viewer from parent
+				define grouped: [group#member]`, []string{
+		"document:1#editor@user:jon",
+		"folder:1#viewer@group:stored#member",
+		"group:stored#member@user:jon",
+	})
+	ts, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+	ctx := typesystem.ContextWithTypesystem(context.Background(), ts)
+
+	contextualTuples := []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:2", "viewer", "user:jon"),
+		tuple.NewTupleKey("document:3", "parent", "folder:1"),
+		tuple.NewTupleKey("document:4", "parent", "folder:2"),
+		tuple.NewTupleKey("folder:2", "viewer", "group:ctx#member"),
+		tuple.NewTupleKey("group:ctx", "member", "group:nested#member"),
+		tuple.NewTupleKey("group:nested", "member", "user:jon"),
+		tuple.NewTupleKey("document:5", "grouped", "group:nested#member"),
+		tuple.NewTupleKey("document:6", "editor", "user:*"),
+		tuple.NewTupleKey("document:4", "blocked", "group:nested#member"),
+		tuple.NewTupleKey("document:1", "blocked", "user:jon"),
+	}
+
+	checker, checkResolverCloser := graph.NewOrderedCheckResolvers().Build()
+	t.Cleanup(checkResolverCloser)
+
+	relations := []string{"viewer", "visible", "inherited", "grouped", "editor", "blocked"}
+	users := []string{"user:jon", "group:nested#member", "user:maria"}
+	strategies := []ListObjectsStrategy{
+		ListObjectsStrategyAuto,
+		ListObjectsStrategyDirect,
+		ListObjectsStrategyReverseExpand,
+		ListObjectsStrategyCheckCandidates,
+		ListObjectsStrategyFilteredBase,
+	}
+
+	for _, relation := range relations {
+		for _, user := range users {
+			// the expected objects are the ones that Check allows
+			var expected []string
+			for i := 1; i <= 6; i++ {
+				object := fmt.Sprintf("document:%d", i)
+				resp, _, err := NewCheckCommand(ds, checker, ts).Execute(ctx, &openfgav1.CheckRequest{
+					StoreId:          storeID,
+					TupleKey:         tuple.NewCheckRequestTupleKey(object, relation, user),
+					ContextualTuples: &openfgav1.ContextualTupleKeys{TupleKeys: contextualTuples},
+				})
+				require.NoError(t, err)
+				if resp.GetAllowed() {
+					expected = append(expected, object)
+				}
+			}
+
+			for _, strategy := range strategies {
+				t.Run(string(strategy)+"_"+relation+"_"+user, func(t *testing.T) {
+					q, err := NewListObjectsQuery(ds, checker, WithListObjectsStrategy(strategy))
+					require.NoError(t, err)
+
+					resp, err := q.Execute(ctx, &openfgav1.ListObjectsRequest{
+						StoreId:          storeID,
+						Type:             "document",
+						Relation:         relation,
+						User:             user,
+						ContextualTuples: &openfgav1.ContextualTupleKeys{TupleKeys: contextualTuples},
+					})
+					require.NoError(t, err)
+					require.ElementsMatch(t, expected, resp.Objects)
+				})
+			}
+		}
+	}
+}
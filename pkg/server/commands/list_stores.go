@@ -15,6 +15,9 @@ type ListStoresQuery struct {
 	storesBackend storage.StoresBackend
 	logger        logger.Logger
 	encoder       encoder.Encoder
+	storeCounter  storage.StoreCounter
+	exactCount    bool
+	name          string
 }
 
 type ListStoresQueryOption func(*ListStoresQuery)
@@ -31,6 +34,22 @@ func WithListStoresQueryEncoder(e encoder.Encoder) ListStoresQueryOption {
 	}
 }
 
+// WithListStoresQueryTotalCount makes the query count the stores with the counter, exactly or
+// with an estimate if the counter has one, see [ListStoresQuery.ExecuteWithTotalCount].
+func WithListStoresQueryTotalCount(counter storage.StoreCounter, exact bool) ListStoresQueryOption {
+	return func(q *ListStoresQuery) {
+		q.storeCounter = counter
+		q.exactCount = exact
+	}
+}
+
+// WithListStoresQueryName makes the query list only the stores with exactly the name.
+func WithListStoresQueryName(name string) ListStoresQueryOption {
+	return func(q *ListStoresQuery) {
+		q.name = name
+	}
+}
+
 func NewListStoresQuery(storesBackend storage.StoresBackend, opts ...ListStoresQueryOption) *ListStoresQuery {
 	q := &ListStoresQuery{
 		storesBackend: storesBackend,
@@ -45,22 +64,39 @@ func NewListStoresQuery(storesBackend storage.StoresBackend, opts ...ListStoresQ
 }
 
 func (q *ListStoresQuery) Execute(ctx context.Context, req *openfgav1.ListStoresRequest) (*openfgav1.ListStoresResponse, error) {
+	resp, _, err := q.ExecuteWithTotalCount(ctx, req)
+	return resp, err
+}
+
+// ExecuteWithTotalCount is like Execute, but it also returns the number of stores, or nil if
+// the query was not built with WithListStoresQueryTotalCount.
+func (q *ListStoresQuery) ExecuteWithTotalCount(ctx context.Context, req *openfgav1.ListStoresRequest) (*openfgav1.ListStoresResponse, *storage.StoreCount, error) {
 	decodedContToken, err := q.encoder.Decode(req.GetContinuationToken())
 	if err != nil {
-		return nil, serverErrors.InvalidContinuationToken
+		return nil, nil, serverErrors.InvalidContinuationToken
 	}
 
 	opts := storage.ListStoresOptions{
 		Pagination: storage.NewPaginationOptions(req.GetPageSize().GetValue(), string(decodedContToken)),
+		Name:       q.name,
 	}
 	stores, continuationToken, err := q.storesBackend.ListStores(ctx, opts)
 	if err != nil {
-		return nil, serverErrors.HandleError("", err)
+		return nil, nil, serverErrors.HandleError("", err)
+	}
+
+	var totalCount *storage.StoreCount
+	if q.storeCounter != nil {
+		count, err := q.storeCounter.CountStores(ctx, storage.CountStoresOptions{Exact: q.exactCount, Name: q.name})
+		if err != nil {
+			return nil, nil, serverErrors.HandleError("", err)
+		}
+		totalCount = &count
 	}
 
 	encodedToken, err := q.encoder.Encode(continuationToken)
 	if err != nil {
-		return nil, serverErrors.HandleError("", err)
+		return nil, nil, serverErrors.HandleError("", err)
 	}
 
 	resp := &openfgav1.ListStoresResponse{
@@ -68,5 +104,5 @@ func (q *ListStoresQuery) Execute(ctx context.Context, req *openfgav1.ListStores
 		ContinuationToken: encodedToken,
 	}
 
-	return resp, nil
+	return resp, totalCount, nil
 }
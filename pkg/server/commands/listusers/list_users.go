@@ -2,10 +2,11 @@ package listusers
 
 import (
 	"maps"
-	"sync/atomic"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/internal/graph"
 )
 
 type listUsersRequest interface {
@@ -32,9 +33,8 @@ type internalListUsersRequest struct {
 	// or endless cycle of recursion.
 	depth uint32
 
-	datastoreQueryCount *atomic.Uint32
-
-	dispatchCount *atomic.Uint32
+	// resolutionMetadata is shared by all the subrequests of the ListUsers request.
+	resolutionMetadata *graph.ResolutionMetadata
 }
 
 var _ listUsersRequest = (*internalListUsersRequest)(nil)
@@ -87,14 +87,14 @@ func (r *internalListUsersRequest) GetDatastoreQueryCount() uint32 {
 	if r == nil {
 		return uint32(0)
 	}
-	return r.datastoreQueryCount.Load()
+	return r.resolutionMetadata.DatastoreQueryCount.Load()
 }
 
 func (r *internalListUsersRequest) GetDispatchCount() uint32 {
 	if r == nil {
 		return uint32(0)
 	}
-	return r.dispatchCount.Load()
+	return r.resolutionMetadata.DispatchCount.Load()
 }
 
 func (r *internalListUsersRequest) GetContext() *structpb.Struct {
@@ -104,20 +104,30 @@ func (r *internalListUsersRequest) GetContext() *structpb.Struct {
 	return r.Context
 }
 
+// TruncationCause is the reason why the enumeration of users stopped before it was complete.
+type TruncationCause string
+
+const (
+	// TruncationCauseNone means that all users were enumerated.
+	TruncationCauseNone TruncationCause = ""
+	// TruncationCauseDeadline means that the deadline was reached before all users were enumerated.
+	TruncationCauseDeadline TruncationCause = "deadline"
+	// TruncationCauseMaxResults means that the maximum number of results was reached.
+	TruncationCauseMaxResults TruncationCause = "max_results"
+)
+
 type listUsersResponse struct {
 	Users    []*openfgav1.User
 	Metadata listUsersResponseMetadata
 }
 
 type listUsersResponseMetadata struct {
-	DatastoreQueryCount uint32
-
-	// The number of times we are recursively expanding to find users.
-	// Atomic is used to be consistent with the Check and ListObjects.
-	DispatchCounter *atomic.Uint32
+	// ResolutionMetadata counts the work done to resolve the request, e.g. the number of times we
+	// recursively expanded to find users.
+	ResolutionMetadata *graph.ResolutionMetadata
 
-	// WasThrottled indicates whether the request was throttled
-	WasThrottled *atomic.Bool
+	// TruncationCause indicates whether, and why, the users are a partial result.
+	TruncationCause TruncationCause
 }
 
 func (r *listUsersResponse) GetUsers() []*openfgav1.User {
@@ -134,12 +144,9 @@ func (r *listUsersResponse) GetMetadata() listUsersResponseMetadata {
 	return r.Metadata
 }
 
-func fromListUsersRequest(o listUsersRequest, datastoreQueryCount *atomic.Uint32, dispatchCount *atomic.Uint32) *internalListUsersRequest {
-	if datastoreQueryCount == nil {
-		datastoreQueryCount = new(atomic.Uint32)
-	}
-	if dispatchCount == nil {
-		dispatchCount = new(atomic.Uint32)
+func fromListUsersRequest(o listUsersRequest, resolutionMetadata *graph.ResolutionMetadata) *internalListUsersRequest {
+	if resolutionMetadata == nil {
+		resolutionMetadata = graph.NewResolutionMetadata()
 	}
 	return &internalListUsersRequest{
 		ListUsersRequest: &openfgav1.ListUsersRequest{
@@ -152,16 +159,15 @@ func fromListUsersRequest(o listUsersRequest, datastoreQueryCount *atomic.Uint32
 			Context:              o.GetContext(),
 			Consistency:          o.GetConsistency(),
 		},
-		visitedUsersetsMap:  make(map[string]struct{}),
-		depth:               0,
-		datastoreQueryCount: datastoreQueryCount,
-		dispatchCount:       dispatchCount,
+		visitedUsersetsMap: make(map[string]struct{}),
+		depth:              0,
+		resolutionMetadata: resolutionMetadata,
 	}
 }
 
 // clone creates a copy of the request. Note that some fields are not deep-cloned.
 func (r *internalListUsersRequest) clone() *internalListUsersRequest {
-	v := fromListUsersRequest(r, r.datastoreQueryCount, r.dispatchCount)
+	v := fromListUsersRequest(r, r.resolutionMetadata)
 	v.visitedUsersetsMap = maps.Clone(r.visitedUsersetsMap)
 	v.depth = r.depth
 	return v
@@ -21,6 +21,7 @@ import (
 	serverconfig "github.com/openfga/openfga/internal/server/config"
 	"github.com/openfga/openfga/internal/throttler/threshold"
 
+	"github.com/openfga/openfga/pkg/requestcontext"
 	"github.com/openfga/openfga/pkg/telemetry"
 
 	"github.com/openfga/openfga/pkg/logger"
@@ -47,7 +48,8 @@ type listUsersQuery struct {
 	maxConcurrentReads      uint32
 	deadline                time.Duration
 	dispatchThrottlerConfig threshold.Config
-	wasThrottled            *atomic.Bool
+
+	hedgingPolicy *storagewrappers.HedgingPolicy
 }
 
 type expandResponse struct {
@@ -90,14 +92,14 @@ func WithListUsersQueryLogger(l logger.Logger) ListUsersQueryOption {
 	}
 }
 
-// WithListUsersMaxResults see server.WithListUsersMaxResults.
+// WithListUsersMaxResults see server.WithListUsersMaxResults. 0 returns all the results.
 func WithListUsersMaxResults(max uint32) ListUsersQueryOption {
 	return func(d *listUsersQuery) {
 		d.maxResults = max
 	}
 }
 
-// WithListUsersDeadline see server.WithListUsersDeadline.
+// WithListUsersDeadline see server.WithListUsersDeadline. 0 disables the deadline.
 func WithListUsersDeadline(t time.Duration) ListUsersQueryOption {
 	return func(d *listUsersQuery) {
 		d.deadline = t
@@ -118,6 +120,13 @@ func WithResolveNodeBreadthLimit(limit uint32) ListUsersQueryOption {
 	}
 }
 
+// WithListUsersDatastoreHedging see server.WithDatastoreHedgingEnabled.
+func WithListUsersDatastoreHedging(policy *storagewrappers.HedgingPolicy) ListUsersQueryOption {
+	return func(d *listUsersQuery) {
+		d.hedgingPolicy = policy
+	}
+}
+
 // WithListUsersMaxConcurrentReads see server.WithMaxConcurrentReadsForListUsers.
 func WithListUsersMaxConcurrentReads(limit uint32) ListUsersQueryOption {
 	return func(d *listUsersQuery) {
@@ -125,7 +134,7 @@ func WithListUsersMaxConcurrentReads(limit uint32) ListUsersQueryOption {
 	}
 }
 
-func (l *listUsersQuery) throttle(ctx context.Context, currentNumDispatch uint32) {
+func (l *listUsersQuery) throttle(ctx context.Context, currentNumDispatch uint32, metadata *graph.ResolutionMetadata) {
 	span := trace.SpanFromContext(ctx)
 
 	shouldThrottle := threshold.ShouldThrottle(
@@ -133,6 +142,7 @@ func (l *listUsersQuery) throttle(ctx context.Context, currentNumDispatch uint32
 		currentNumDispatch,
 		l.dispatchThrottlerConfig.Threshold,
 		l.dispatchThrottlerConfig.MaxThreshold,
+		l.dispatchThrottlerConfig.LowPriorityDeprioritization,
 	)
 
 	span.SetAttributes(
@@ -140,7 +150,7 @@ func (l *listUsersQuery) throttle(ctx context.Context, currentNumDispatch uint32
 		attribute.Bool("is_throttled", shouldThrottle))
 
 	if shouldThrottle {
-		l.wasThrottled.Store(true)
+		metadata.ThrottleCount.Add(1)
 		l.dispatchThrottlerConfig.Throttler.Throttle(ctx)
 	}
 }
@@ -161,7 +171,6 @@ func NewListUsersQuery(ds storage.RelationshipTupleReader, opts ...ListUsersQuer
 		deadline:                serverconfig.DefaultListUsersDeadline,
 		maxResults:              serverconfig.DefaultListUsersMaxResults,
 		maxConcurrentReads:      serverconfig.DefaultMaxConcurrentReadsForListUsers,
-		wasThrottled:            new(atomic.Bool),
 	}
 
 	for _, opt := range opts {
@@ -181,17 +190,27 @@ func (l *listUsersQuery) ListUsers(
 	))
 	defer span.End()
 
-	cancellableCtx, cancelCtx := context.WithCancel(ctx)
-	if l.deadline != 0 {
-		cancellableCtx, cancelCtx = context.WithTimeout(cancellableCtx, l.deadline)
-		defer cancelCtx()
+	// a deadline of 0 is disabled, like the ListObjects deadline
+	var cancellableCtx context.Context
+	var cancelCtx context.CancelFunc
+	if l.deadline > 0 {
+		cancellableCtx, cancelCtx = context.WithTimeout(ctx, l.deadline)
+	} else {
+		cancellableCtx, cancelCtx = context.WithCancel(ctx)
 	}
 	defer cancelCtx()
 
-	l.ds = storagewrappers.NewCombinedTupleReader(
-		storagewrappers.NewBoundedConcurrencyTupleReader(l.ds, l.maxConcurrentReads),
-		req.GetContextualTuples(),
+	resolutionMetadata := graph.NewResolutionMetadata()
+
+	l.ds = storagewrappers.NewBoundedConcurrencyTupleReader(
+		storagewrappers.NewQueryCountingTupleReader(l.ds, resolutionMetadata),
+		l.maxConcurrentReads,
 	)
+	// the hedged reads take their share of the concurrent reads too
+	if l.hedgingPolicy != nil {
+		l.ds = storagewrappers.NewHedgedTupleReader(l.ds, l.hedgingPolicy)
+	}
+	l.ds = storagewrappers.NewCombinedTupleReader(l.ds, req.GetContextualTuples())
 	typesys, ok := typesystem.TypesystemFromContext(cancellableCtx)
 	if !ok {
 		return nil, fmt.Errorf("%w: typesystem missing in context", openfgaErrors.ErrUnknown)
@@ -210,40 +229,37 @@ func (l *listUsersQuery) ListUsers(
 			return &listUsersResponse{
 				Users: []*openfgav1.User{},
 				Metadata: listUsersResponseMetadata{
-					DatastoreQueryCount: 0,
-					DispatchCounter:     new(atomic.Uint32),
-					WasThrottled:        new(atomic.Bool),
+					ResolutionMetadata: resolutionMetadata,
 				},
 			}, nil
 		}
 	}
 
-	datastoreQueryCount := atomic.Uint32{}
-	dispatchCount := atomic.Uint32{}
-
 	foundUsersCh := l.buildResultsChannel()
 	expandErrCh := make(chan error, 1)
 
 	foundUsersUnique := make(map[tuple.UserString]foundUser, 1000)
 
+	// maxResultsFound is only read after doneWithFoundUsersCh is received from
+	maxResultsFound := false
 	doneWithFoundUsersCh := make(chan struct{}, 1)
 	go func() {
 		for foundUser := range foundUsersCh {
-			foundUsersUnique[tuple.UserProtoToString(foundUser.user)] = foundUser
-
-			if l.maxResults > 0 {
-				if uint32(len(foundUsersUnique)) >= l.maxResults {
-					span.SetAttributes(attribute.Bool("max_results_found", true))
-					break
-				}
+			key := tuple.UserProtoToString(foundUser.user)
+			if _, ok := foundUsersUnique[key]; !ok && l.maxResults > 0 && uint32(len(foundUsersUnique)) >= l.maxResults {
+				// the results are only truncated if a user beyond the maximum is found
+				span.SetAttributes(attribute.Bool("max_results_found", true))
+				maxResultsFound = true
+				break
 			}
+			foundUsersUnique[key] = foundUser
 		}
 
 		doneWithFoundUsersCh <- struct{}{}
 	}()
 
 	go func() {
-		internalRequest := fromListUsersRequest(req, &datastoreQueryCount, &dispatchCount)
+		internalRequest := fromListUsersRequest(req, resolutionMetadata)
 		resp := l.expand(cancellableCtx, internalRequest, foundUsersCh)
 		if resp.err != nil {
 			expandErrCh <- resp.err
@@ -257,16 +273,21 @@ func (l *listUsersQuery) ListUsers(
 	case <-doneWithFoundUsersCh:
 		break
 	case <-cancellableCtx.Done():
-		deadlineExceeded = true
 		// to avoid a race on the 'foundUsersUnique' map below, wait for the range over the channel to close
 		<-doneWithFoundUsersCh
-		break
+		if errors.Is(ctx.Err(), context.Canceled) {
+			// the client canceled the request, there is no one to send partial results to
+			telemetry.TraceError(span, ctx.Err())
+			return nil, ctx.Err()
+		}
+		deadlineExceeded = true
 	}
 
 	select {
 	case err := <-expandErrCh:
 		if deadlineExceeded || errors.Is(err, context.DeadlineExceeded) {
-			// We skip the error because we want to send at least partial results to the user (but we should probably set response headers)
+			// We skip the error because we want to send at least partial results to the user
+			deadlineExceeded = true
 			break
 		}
 		telemetry.TraceError(span, err)
@@ -275,6 +296,17 @@ func (l *listUsersQuery) ListUsers(
 		break
 	}
 
+	truncationCause := TruncationCauseNone
+	switch {
+	case maxResultsFound:
+		truncationCause = TruncationCauseMaxResults
+	case deadlineExceeded:
+		truncationCause = TruncationCauseDeadline
+	}
+	if truncationCause != TruncationCauseNone {
+		span.SetAttributes(attribute.String("truncation_cause", string(truncationCause)))
+	}
+
 	cancelCtx()
 
 	foundUsers := make([]*openfgav1.User, 0, len(foundUsersUnique))
@@ -291,9 +323,8 @@ func (l *listUsersQuery) ListUsers(
 	return &listUsersResponse{
 		Users: foundUsers,
 		Metadata: listUsersResponseMetadata{
-			DatastoreQueryCount: datastoreQueryCount.Load(),
-			DispatchCounter:     &dispatchCount,
-			WasThrottled:        l.wasThrottled,
+			ResolutionMetadata: resolutionMetadata,
+			TruncationCause:    truncationCause,
 		},
 	}, nil
 }
@@ -319,9 +350,9 @@ func (l *listUsersQuery) dispatch(
 	req *internalListUsersRequest,
 	foundUsersChan chan<- foundUser,
 ) expandResponse {
-	newcount := req.dispatchCount.Add(1)
+	newcount := req.resolutionMetadata.DispatchCount.Add(1)
 	if l.dispatchThrottlerConfig.Enabled {
-		l.throttle(ctx, newcount)
+		l.throttle(ctx, newcount, req.resolutionMetadata)
 	}
 
 	return l.expand(ctx, req, foundUsersChan)
@@ -335,7 +366,7 @@ func (l *listUsersQuery) expand(
 	ctx, span := tracer.Start(ctx, "expand")
 	defer span.End()
 	span.SetAttributes(attribute.Int("depth", int(req.depth)))
-	if req.depth >= l.resolveNodeLimit {
+	if req.depth >= requestcontext.FromContext(ctx).EffectiveResolveNodeLimit(l.resolveNodeLimit) {
 		return expandResponse{
 			err: graph.ErrResolutionDepthExceeded,
 		}
@@ -453,7 +484,7 @@ func (l *listUsersQuery) expandDirect(
 		}
 	}
 	defer iter.Stop()
-	req.datastoreQueryCount.Add(1)
+	req.resolutionMetadata.DatastoreQueryCount.Add(1)
 
 	filteredIter := storage.NewFilteredTupleKeyIterator(
 		storage.NewTupleKeyIteratorFromTupleIterator(iter),
@@ -874,7 +905,7 @@ func (l *listUsersQuery) expandTTU(
 		}
 	}
 	defer iter.Stop()
-	req.datastoreQueryCount.Add(1)
+	req.resolutionMetadata.DatastoreQueryCount.Add(1)
 
 	filteredIter := storage.NewFilteredTupleKeyIterator(
 		storage.NewTupleKeyIteratorFromTupleIterator(iter),
@@ -932,7 +963,7 @@ LoopOnIterator:
 }
 
 func enteredCycle(req *internalListUsersRequest) bool {
-	key := fmt.Sprintf("%s#%s", tuple.ObjectKey(req.GetObject()), req.Relation)
+	key := tuple.CanonicalKey(tuple.ObjectKey(req.GetObject()), req.Relation)
 	if _, loaded := req.visitedUsersetsMap[key]; loaded {
 		return true
 	}
@@ -3,7 +3,6 @@ package listusers
 import (
 	"context"
 	"fmt"
-	"sync/atomic"
 	"testing"
 	"time"
 
@@ -1161,6 +1160,132 @@ func TestListUsersConditions(t *testing.T) {
 	tests.runListUsersTestCases(t)
 }
 
+func TestListUsersContextualTuples(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	model := `
+		model
+			schema 1.1
+		type user
+
+		type group
+			relations
+				define member: [user, user with isTrue]
+
+		type document
+			relations
+				define viewer: [user, user with isTrue, group#member]
+				define editor: [user with inRegion]
+				define can_view: viewer or editor
+
+		condition isTrue(param: bool) {
+			param
+		}
+
+		condition inRegion(region: string, allowed: list<string>) {
+			region in allowed
+		}`
+
+	tests := ListUsersTests{
+		{
+			name: "users_granted_only_by_contextual_tuples",
+			req: &openfgav1.ListUsersRequest{
+				Object:      &openfgav1.Object{Type: "document", Id: "1"},
+				Relation:    "can_view",
+				UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+				ContextualTuples: []*openfgav1.TupleKey{
+					tuple.NewTupleKey("document:1", "viewer", "user:jon"),
+					tuple.NewTupleKey("document:2", "viewer", "user:maria"),
+				},
+			},
+			model: model,
+			tuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "viewer", "user:will"),
+			},
+			expectedUsers: []string{"user:will", "user:jon"},
+		},
+		{
+			name: "users_granted_through_a_contextual_group_membership",
+			req: &openfgav1.ListUsersRequest{
+				Object:      &openfgav1.Object{Type: "document", Id: "1"},
+				Relation:    "viewer",
+				UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+				ContextualTuples: []*openfgav1.TupleKey{
+					tuple.NewTupleKey("group:eng", "member", "user:jon"),
+				},
+			},
+			model: model,
+			tuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+			},
+			expectedUsers: []string{"user:jon"},
+		},
+		{
+			name: "conditional_contextual_tuples_evaluated_with_the_request_context",
+			req: &openfgav1.ListUsersRequest{
+				Object:      &openfgav1.Object{Type: "document", Id: "1"},
+				Relation:    "viewer",
+				UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+				ContextualTuples: []*openfgav1.TupleKey{
+					tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:jon", "isTrue", nil),
+				},
+				Context: testutils.MustNewStruct(t, map[string]interface{}{"param": true}),
+			},
+			model:         model,
+			expectedUsers: []string{"user:jon"},
+		},
+		{
+			name: "conditional_contextual_tuples_not_met",
+			req: &openfgav1.ListUsersRequest{
+				Object:      &openfgav1.Object{Type: "document", Id: "1"},
+				Relation:    "viewer",
+				UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+				ContextualTuples: []*openfgav1.TupleKey{
+					tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:jon", "isTrue", nil),
+				},
+				Context: testutils.MustNewStruct(t, map[string]interface{}{"param": false}),
+			},
+			model:         model,
+			expectedUsers: []string{},
+		},
+		{
+			name: "conditional_contextual_tuples_with_their_own_context",
+			req: &openfgav1.ListUsersRequest{
+				Object:      &openfgav1.Object{Type: "document", Id: "1"},
+				Relation:    "can_view",
+				UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+				ContextualTuples: []*openfgav1.TupleKey{
+					tuple.NewTupleKeyWithCondition("document:1", "editor", "user:jon", "inRegion",
+						testutils.MustNewStruct(t, map[string]interface{}{"allowed": []interface{}{"eu"}})),
+					tuple.NewTupleKeyWithCondition("document:1", "editor", "user:maria", "inRegion",
+						testutils.MustNewStruct(t, map[string]interface{}{"allowed": []interface{}{"us"}})),
+				},
+				Context: testutils.MustNewStruct(t, map[string]interface{}{"region": "eu"}),
+			},
+			model:         model,
+			expectedUsers: []string{"user:jon"},
+		},
+		{
+			name: "conditional_contextual_tuples_with_missing_context",
+			req: &openfgav1.ListUsersRequest{
+				Object:      &openfgav1.Object{Type: "document", Id: "1"},
+				Relation:    "viewer",
+				UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+				ContextualTuples: []*openfgav1.TupleKey{
+					tuple.NewTupleKeyWithCondition("group:eng", "member", "user:jon", "isTrue", nil),
+				},
+			},
+			model: model,
+			tuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+			},
+			expectedErrorMsg: "failed to evaluate relationship condition: 'isTrue' - tuple 'group:eng#member@user:jon' is missing context parameters '[param]'",
+		},
+	}
+	tests.runListUsersTestCases(t)
+}
+
 func TestListUsersIntersection(t *testing.T) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
@@ -2571,7 +2696,7 @@ func TestListUsersCycleDetection(t *testing.T) {
 			Id:       "1",
 			Relation: "viewer",
 		}
-		visitedUsersetKey := fmt.Sprintf("%s:%s#%s", visitedUserset.GetType(), visitedUserset.GetId(), visitedUserset.GetRelation())
+		visitedUsersetKey := tuple.CanonicalKey(visitedUserset.GetType()+":"+visitedUserset.GetId(), visitedUserset.GetRelation())
 		visitedUsersets := make(map[string]struct{})
 		visitedUsersets[visitedUsersetKey] = struct{}{}
 
@@ -3364,8 +3489,8 @@ func TestListUsersDatastoreQueryCountAndDispatchCount(t *testing.T) {
 				ContextualTuples: test.contextualTuples,
 			})
 			require.NoError(t, err)
-			require.Equal(t, test.dbReads, resp.GetMetadata().DatastoreQueryCount)
-			require.Equal(t, test.dispatches, resp.GetMetadata().DispatchCounter.Load())
+			require.Equal(t, test.dbReads, resp.GetMetadata().ResolutionMetadata.DatastoreQueryCount.Load())
+			require.Equal(t, test.dispatches, resp.GetMetadata().ResolutionMetadata.DispatchCount.Load())
 		})
 	}
 }
@@ -3385,6 +3510,7 @@ func TestListUsersConfig_MaxResults(t *testing.T) {
 		inputConfigMaxResults uint32
 		allResults            []*openfgav1.User // all the results. the server may return less
 		expectMinResults      uint32
+		expectTruncation      TruncationCause
 	}{
 		`max_results_infinite`: {
 			inputModel: `
@@ -3441,6 +3567,31 @@ func TestListUsersConfig_MaxResults(t *testing.T) {
 				{User: &openfgav1.User_Object{Object: &openfgav1.Object{Type: "user", Id: "3"}}},
 			},
 			expectMinResults: 2,
+			expectTruncation: TruncationCauseMaxResults,
+		},
+		`max_results_equal_to_actual_results`: {
+			inputModel: `
+				model
+					schema 1.1
+				type user
+				type repo
+					relations
+						define admin: [user]`,
+			inputTuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("repo:target", "admin", "user:1"),
+				tuple.NewTupleKey("repo:target", "admin", "user:2"),
+			},
+			inputRequest: &openfgav1.ListUsersRequest{
+				Object:      &openfgav1.Object{Type: "repo", Id: "target"},
+				Relation:    "admin",
+				UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+			},
+			inputConfigMaxResults: 2,
+			allResults: []*openfgav1.User{
+				{User: &openfgav1.User_Object{Object: &openfgav1.Object{Type: "user", Id: "1"}}},
+				{User: &openfgav1.User_Object{Object: &openfgav1.Object{Type: "user", Id: "2"}}},
+			},
+			expectMinResults: 2,
 		},
 		`max_results_more_than_actual_results`: {
 			inputModel: `
@@ -3499,6 +3650,7 @@ func TestListUsersConfig_MaxResults(t *testing.T) {
 			}
 			require.GreaterOrEqual(t, len(res.GetUsers()), int(test.expectMinResults))
 			require.Subset(t, test.allResults, res.GetUsers())
+			require.Equal(t, test.expectTruncation, res.GetMetadata().TruncationCause)
 		})
 	}
 }
@@ -3520,6 +3672,7 @@ func TestListUsersConfig_Deadline(t *testing.T) {
 		allResults          []*openfgav1.User // all the results. the server may return less
 		expectMinResults    uint32
 		expectError         string
+		expectTruncation    TruncationCause
 	}{
 		`infinite_deadline_does_not_block_return_of_errors`: {
 			inputModel: `
@@ -3544,6 +3697,30 @@ func TestListUsersConfig_Deadline(t *testing.T) {
 			inputReadDelay:      50 * time.Millisecond,
 			expectError:         "failed to evaluate relationship condition: 'condX' - tuple 'repo:target#admin@user:1' is missing context parameters '[x]",
 		},
+		`deadline_zero_waits_for_every_result`: {
+			inputModel: `
+				model
+					schema 1.1
+				type user
+				type repo
+					relations
+						define admin: [user]`,
+			inputTuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("repo:target", "admin", "user:1"),
+			},
+			inputRequest: &openfgav1.ListUsersRequest{
+				Object:      &openfgav1.Object{Type: "repo", Id: "target"},
+				Relation:    "admin",
+				UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+			},
+			inputConfigDeadline: 0, // disabled
+			inputReadDelay:      50 * time.Millisecond,
+			allResults: []*openfgav1.User{
+				{User: &openfgav1.User_Object{Object: &openfgav1.Object{Type: "user", Id: "1"}}},
+			},
+			expectMinResults: 1,
+			expectTruncation: TruncationCauseNone,
+		},
 		`deadline_very_small_returns_nothing`: {
 			inputModel: `
 				model
@@ -3565,6 +3742,7 @@ func TestListUsersConfig_Deadline(t *testing.T) {
 			allResults: []*openfgav1.User{
 				{User: &openfgav1.User_Object{Object: &openfgav1.Object{Type: "user", Id: "1"}}},
 			},
+			expectTruncation: TruncationCauseDeadline,
 		},
 		`deadline_very_high_returns_everything`: {
 			inputModel: `
@@ -3624,12 +3802,55 @@ func TestListUsersConfig_Deadline(t *testing.T) {
 					require.NoError(t, err)
 					require.GreaterOrEqual(t, len(res.GetUsers()), int(test.expectMinResults))
 					require.Subset(t, test.allResults, res.GetUsers())
+					require.Equal(t, test.expectTruncation, res.GetMetadata().TruncationCause)
 				}
 			})
 		})
 	}
 }
 
+func TestListUsersClientCancellation(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type repo
+			relations
+				define admin: [user]`)
+	storeID := ulid.Make().String()
+	err := ds.WriteAuthorizationModel(context.Background(), storeID, model)
+	require.NoError(t, err)
+	err = ds.Write(context.Background(), storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("repo:target", "admin", "user:1"),
+	})
+	require.NoError(t, err)
+
+	typesys, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(typesystem.ContextWithTypesystem(context.Background(), typesys))
+	defer cancel()
+	time.AfterFunc(5*time.Millisecond, cancel)
+
+	// the request is canceled by the client before the deadline of ListUsers
+	_, err = NewListUsersQuery(
+		mocks.NewMockSlowDataStorage(ds, 50*time.Millisecond),
+		WithListUsersDeadline(time.Second),
+	).ListUsers(ctx, &openfgav1.ListUsersRequest{
+		StoreId:     storeID,
+		Object:      &openfgav1.Object{Type: "repo", Id: "target"},
+		Relation:    "admin",
+		UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
 func TestListUsersConfig_MaxConcurrency(t *testing.T) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
@@ -3792,8 +4013,8 @@ func TestListUsers_ExpandExclusionHandler(t *testing.T) {
 						Type: "user",
 					}},
 				},
-				visitedUsersetsMap:  map[string]struct{}{},
-				datastoreQueryCount: new(atomic.Uint32),
+				visitedUsersetsMap: map[string]struct{}{},
+				resolutionMetadata: graph.NewResolutionMetadata(),
 			}, rewrite, channelWithResults)
 			if resp.err != nil {
 				channelWithError <- resp.err
@@ -3867,8 +4088,9 @@ func TestListUsersThrottle(t *testing.T) {
 		)
 		mockThrottler.EXPECT().Throttle(gomock.Any()).Times(0)
 
-		q.throttle(ctx, uint32(190))
-		require.False(t, q.wasThrottled.Load())
+		metadata := graph.NewResolutionMetadata()
+		q.throttle(ctx, uint32(190), metadata)
+		require.False(t, metadata.WasThrottled())
 	})
 
 	t.Run("above_threshold_should_call_throttle", func(t *testing.T) {
@@ -3883,8 +4105,9 @@ func TestListUsersThrottle(t *testing.T) {
 		)
 		mockThrottler.EXPECT().Throttle(gomock.Any()).Times(1)
 
-		q.throttle(ctx, uint32(201))
-		require.True(t, q.wasThrottled.Load())
+		metadata := graph.NewResolutionMetadata()
+		q.throttle(ctx, uint32(201), metadata)
+		require.True(t, metadata.WasThrottled())
 	})
 
 	t.Run("zero_max_should_interpret_as_default", func(t *testing.T) {
@@ -3899,8 +4122,9 @@ func TestListUsersThrottle(t *testing.T) {
 		)
 		mockThrottler.EXPECT().Throttle(gomock.Any()).Times(0)
 
-		q.throttle(ctx, uint32(190))
-		require.False(t, q.wasThrottled.Load())
+		metadata := graph.NewResolutionMetadata()
+		q.throttle(ctx, uint32(190), metadata)
+		require.False(t, metadata.WasThrottled())
 	})
 
 	t.Run("dispatch_should_use_request_threshold_if_available", func(t *testing.T) {
@@ -3918,8 +4142,9 @@ func TestListUsersThrottle(t *testing.T) {
 		ctx := context.Background()
 		ctx = dispatch.ContextWithThrottlingThreshold(ctx, 200)
 
-		q.throttle(ctx, dispatchCountValue)
-		require.True(t, q.wasThrottled.Load())
+		metadata := graph.NewResolutionMetadata()
+		q.throttle(ctx, dispatchCountValue, metadata)
+		require.True(t, metadata.WasThrottled())
 	})
 
 	t.Run("should_respect_max_threshold", func(t *testing.T) {
@@ -3937,8 +4162,9 @@ func TestListUsersThrottle(t *testing.T) {
 		ctx := context.Background()
 		ctx = dispatch.ContextWithThrottlingThreshold(ctx, 1000)
 
-		q.throttle(ctx, dispatchCountValue)
-		require.True(t, q.wasThrottled.Load())
+		metadata := graph.NewResolutionMetadata()
+		q.throttle(ctx, dispatchCountValue, metadata)
+		require.True(t, metadata.WasThrottled())
 	})
 }
 
@@ -0,0 +1,163 @@
+package commands
+
+import (
+	"context"
+	"errors"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+const migrateModelSchemaPageSize = 100
+
+// MigrateAuthorizationModelSchemaRequest selects the schema 1.0 model of a store to migrate.
+type MigrateAuthorizationModelSchemaRequest struct {
+	StoreID string
+	// AuthorizationModelID is the model to migrate, the latest model of the store if empty.
+	AuthorizationModelID string `json:"authorization_model_id,omitempty"`
+	// DryRun reports the migration without writing the migrated model.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// MigrateAuthorizationModelSchemaResponse reports the migration of a model to schema 1.1.
+type MigrateAuthorizationModelSchemaResponse struct {
+	SourceAuthorizationModelID string `json:"source_authorization_model_id"`
+	// AuthorizationModelID is the ID of the migrated model, empty if it was not written, because
+	// of a dry run or of relations that need attention.
+	AuthorizationModelID string                           `json:"authorization_model_id,omitempty"`
+	TuplesRead           int                              `json:"tuples_read"`
+	Transformations      []typesystem.SchemaMigrationNote `json:"transformations"`
+	NeedsAttention       []typesystem.SchemaMigrationNote `json:"needs_attention,omitempty"`
+}
+
+// MigrateAuthorizationModelSchemaCommand migrates a model of schema version 1.0 to schema
+// version 1.1, synthesizing the type restrictions of its relations from the tuples of the store,
+// and writes the result as a new model.
+type MigrateAuthorizationModelSchemaCommand struct {
+	datastore storage.OpenFGADatastore
+	logger    logger.Logger
+	writeOpts []WriteAuthModelOption
+}
+
+type MigrateAuthorizationModelSchemaOption func(*MigrateAuthorizationModelSchemaCommand)
+
+func WithMigrateModelSchemaLogger(l logger.Logger) MigrateAuthorizationModelSchemaOption {
+	return func(c *MigrateAuthorizationModelSchemaCommand) {
+		c.logger = l
+	}
+}
+
+// WithMigrateModelSchemaWriteOptions sets the options of the command writing the migrated model,
+// e.g. its size and complexity limits.
+func WithMigrateModelSchemaWriteOptions(opts ...WriteAuthModelOption) MigrateAuthorizationModelSchemaOption {
+	return func(c *MigrateAuthorizationModelSchemaCommand) {
+		c.writeOpts = opts
+	}
+}
+
+func NewMigrateAuthorizationModelSchemaCommand(datastore storage.OpenFGADatastore, opts ...MigrateAuthorizationModelSchemaOption) *MigrateAuthorizationModelSchemaCommand {
+	c := &MigrateAuthorizationModelSchemaCommand{
+		datastore: datastore,
+		logger:    logger.NewNoopLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Execute reads the model and every tuple of the store, and migrates the model with
+// [typesystem.MigrateSchema1_0To1_1]. The migrated model is written, with the same validations
+// as WriteAuthorizationModel, unless the request is a dry run or some relations need attention.
+func (c *MigrateAuthorizationModelSchemaCommand) Execute(ctx context.Context, req *MigrateAuthorizationModelSchemaRequest) (*MigrateAuthorizationModelSchemaResponse, error) {
+	model, err := c.readModel(ctx, req.StoreID, req.AuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if model.GetSchemaVersion() != typesystem.SchemaVersion1_0 {
+		return nil, serverErrors.AuthorizationModelSchemaNotMigratable(model.GetId(), model.GetSchemaVersion(), typesystem.SchemaVersion1_0)
+	}
+
+	usage := typesystem.NewSchemaUsage()
+	tuplesRead := 0
+	opts := storage.ReadPageOptions{Pagination: storage.PaginationOptions{PageSize: migrateModelSchemaPageSize}}
+	for {
+		tuples, contToken, err := c.datastore.ReadPage(ctx, req.StoreID, &openfgav1.TupleKey{}, opts)
+		if err != nil {
+			return nil, serverErrors.HandleError("", err)
+		}
+
+		for _, t := range tuples {
+			usage.AddTuple(t.GetKey())
+		}
+		tuplesRead += len(tuples)
+
+		if len(contToken) == 0 {
+			break
+		}
+		opts.Pagination.From = string(contToken)
+	}
+
+	migration, err := typesystem.MigrateSchema1_0To1_1(model, usage)
+	if err != nil {
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	resp := &MigrateAuthorizationModelSchemaResponse{
+		SourceAuthorizationModelID: model.GetId(),
+		TuplesRead:                 tuplesRead,
+		Transformations:            migration.Transformations,
+		NeedsAttention:             migration.NeedsAttention,
+	}
+	if req.DryRun || len(migration.NeedsAttention) > 0 {
+		return resp, nil
+	}
+
+	written, err := NewWriteAuthorizationModelCommand(c.datastore, c.writeOpts...).Execute(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         req.StoreID,
+		TypeDefinitions: migration.Model.GetTypeDefinitions(),
+		SchemaVersion:   migration.Model.GetSchemaVersion(),
+		Conditions:      migration.Model.GetConditions(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp.AuthorizationModelID = written.GetAuthorizationModelId()
+
+	c.logger.InfoWithContext(ctx, "authorization model migrated to schema version 1.1",
+		zap.String("store_id", req.StoreID),
+		zap.String("source_authorization_model_id", resp.SourceAuthorizationModelID),
+		zap.String("authorization_model_id", resp.AuthorizationModelID),
+	)
+
+	return resp, nil
+}
+
+func (c *MigrateAuthorizationModelSchemaCommand) readModel(ctx context.Context, storeID, modelID string) (*openfgav1.AuthorizationModel, error) {
+	if modelID == "" {
+		model, err := c.datastore.FindLatestAuthorizationModel(ctx, storeID)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, serverErrors.LatestAuthorizationModelNotFound(storeID)
+			}
+			return nil, serverErrors.HandleError("", err)
+		}
+		return model, nil
+	}
+
+	model, err := c.datastore.ReadAuthorizationModel(ctx, storeID, modelID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, serverErrors.AuthorizationModelNotFound(modelID)
+		}
+		return nil, serverErrors.HandleError("", err)
+	}
+	return model, nil
+}
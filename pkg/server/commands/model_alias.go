@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// modelAliasPattern only allows lowercase names, so that an alias can never be mistaken
+// for an authorization model ID.
+var modelAliasPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,63}$`)
+
+// ModelAlias is a named alias of an authorization model of a store, e.g. "production".
+type ModelAlias struct {
+	StoreID              string `json:"store_id"`
+	Alias                string `json:"alias"`
+	AuthorizationModelID string `json:"authorization_model_id"`
+}
+
+// ValidateModelAlias returns a validation error if the alias name is not valid.
+func ValidateModelAlias(alias string) error {
+	if !modelAliasPattern.MatchString(alias) {
+		return serverErrors.ValidationError(fmt.Errorf("invalid authorization model alias '%s': must match %s", alias, modelAliasPattern))
+	}
+	return nil
+}
+
+// WriteModelAliasCommand points an alias at an existing authorization model of the store.
+type WriteModelAliasCommand struct {
+	backend storage.ModelAliasBackend
+	models  storage.AuthorizationModelReadBackend
+	logger  logger.Logger
+}
+
+type WriteModelAliasCommandOption func(*WriteModelAliasCommand)
+
+func WithWriteModelAliasCmdLogger(l logger.Logger) WriteModelAliasCommandOption {
+	return func(c *WriteModelAliasCommand) {
+		c.logger = l
+	}
+}
+
+// NewWriteModelAliasCommand creates a WriteModelAliasCommand that stores the alias in the given
+// backend, after checking that the model exists in the given model backend.
+func NewWriteModelAliasCommand(backend storage.ModelAliasBackend, models storage.AuthorizationModelReadBackend, opts ...WriteModelAliasCommandOption) *WriteModelAliasCommand {
+	c := &WriteModelAliasCommand{
+		backend: backend,
+		models:  models,
+		logger:  logger.NewNoopLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *WriteModelAliasCommand) Execute(ctx context.Context, req *ModelAlias) error {
+	if err := ValidateModelAlias(req.Alias); err != nil {
+		return err
+	}
+
+	if _, err := c.models.ReadAuthorizationModel(ctx, req.StoreID, req.AuthorizationModelID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return serverErrors.AuthorizationModelNotFound(req.AuthorizationModelID)
+		}
+		return serverErrors.HandleError("", err)
+	}
+
+	if err := c.backend.WriteModelAlias(ctx, req.StoreID, req.Alias, req.AuthorizationModelID); err != nil {
+		return serverErrors.HandleError("", err)
+	}
+
+	return nil
+}
+
+// ReadModelAliasQuery returns the authorization model an alias points at.
+type ReadModelAliasQuery struct {
+	backend storage.ModelAliasBackend
+	logger  logger.Logger
+}
+
+type ReadModelAliasQueryOption func(*ReadModelAliasQuery)
+
+func WithReadModelAliasQueryLogger(l logger.Logger) ReadModelAliasQueryOption {
+	return func(q *ReadModelAliasQuery) {
+		q.logger = l
+	}
+}
+
+// NewReadModelAliasQuery creates a ReadModelAliasQuery that reads the aliases of the given backend.
+func NewReadModelAliasQuery(backend storage.ModelAliasBackend, opts ...ReadModelAliasQueryOption) *ReadModelAliasQuery {
+	q := &ReadModelAliasQuery{
+		backend: backend,
+		logger:  logger.NewNoopLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+func (q *ReadModelAliasQuery) Execute(ctx context.Context, storeID, alias string) (*ModelAlias, error) {
+	if err := ValidateModelAlias(alias); err != nil {
+		return nil, err
+	}
+
+	modelID, err := q.backend.ReadModelAlias(ctx, storeID, alias)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, serverErrors.AuthorizationModelAliasNotFound(alias)
+		}
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	return &ModelAlias{
+		StoreID:              storeID,
+		Alias:                alias,
+		AuthorizationModelID: modelID,
+	}, nil
+}
@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/storage"
+	tupleUtils "github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// ModelImpactReader reads the latest authorization model and the tuples of a store for the
+// impact analysis of a new model.
+type ModelImpactReader interface {
+	storage.AuthorizationModelReadBackend
+	storage.RelationshipTupleReader
+}
+
+// ModelImpactFinding is a relation of the latest model of a store with a tuple that a new model
+// makes invalid.
+type ModelImpactFinding struct {
+	ObjectType string
+	Relation   string
+	// Reason is how the new model changes the relation, e.g. "the relation was removed".
+	Reason string
+	// Tuple is a tuple of the relation that is invalid with the new model.
+	Tuple *openfgav1.TupleKey
+}
+
+func (f ModelImpactFinding) String() string {
+	return fmt.Sprintf("%s#%s: %s, but the store has tuples of it, e.g. '%s'",
+		f.ObjectType, f.Relation, f.Reason, tupleUtils.TupleKeyToString(f.Tuple))
+}
+
+// ModelImpact is the result of the impact analysis of a new model.
+type ModelImpact struct {
+	Findings []ModelImpactFinding
+	// Unprobed are the relations, as "type#relation", that the new model changes but were not
+	// probed because the analysis ran out of probes.
+	Unprobed []string
+}
+
+// modelImpactProbe is a read of at most one tuple of a relation changed by a new model.
+type modelImpactProbe struct {
+	objectType string
+	relation   string
+	// user restricts the probe to the tuples of the user, e.g. of a wildcard removed from the
+	// directly related user types of the relation.
+	user   string
+	reason string
+}
+
+// AnalyzeModelImpact compares the new model of a store with its latest model and looks for the
+// existing tuples that the new model makes invalid. For every directly assignable relation of
+// the latest model that the new model removes, makes not directly assignable, or restricts to
+// fewer directly related user types, it reads one tuple of the relation, with at most maxProbes
+// reads in total. A relation that loses a directly related type other than a wildcard is probed
+// with any one of its tuples, so the analysis may miss its tuples of the lost type.
+//
+// Stores without a model have nothing to analyze.
+func AnalyzeModelImpact(ctx context.Context, reader ModelImpactReader, storeID string, model *typesystem.TypeSystem, maxProbes int) (ModelImpact, error) {
+	latestModel, err := reader.FindLatestAuthorizationModel(ctx, storeID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return ModelImpact{}, nil
+		}
+		return ModelImpact{}, err
+	}
+	latest, err := typesystem.New(latestModel)
+	if err != nil {
+		return ModelImpact{}, err
+	}
+
+	var impact ModelImpact
+	for i, probe := range modelImpactProbes(latest, model) {
+		if i >= maxProbes {
+			impact.Unprobed = append(impact.Unprobed, probe.objectType+"#"+probe.relation)
+			continue
+		}
+
+		tuples, _, err := reader.ReadPage(ctx, storeID, tupleUtils.NewTupleKey(probe.objectType+":", probe.relation, probe.user), storage.ReadPageOptions{
+			Pagination: storage.NewPaginationOptions(1, ""),
+		})
+		if err != nil {
+			return ModelImpact{}, err
+		}
+		if len(tuples) == 0 {
+			continue
+		}
+
+		tk := tuples[0].GetKey()
+		if validation.ValidateTupleForWrite(model, tk) == nil {
+			continue
+		}
+		impact.Findings = append(impact.Findings, ModelImpactFinding{
+			ObjectType: probe.objectType,
+			Relation:   probe.relation,
+			Reason:     probe.reason,
+			Tuple:      tk,
+		})
+	}
+
+	return impact, nil
+}
+
+// modelImpactProbes returns the probes of the relations of the latest model that the new model
+// changes, in the order of their types and relations.
+func modelImpactProbes(latest, model *typesystem.TypeSystem) []modelImpactProbe {
+	var probes []modelImpactProbe
+	for objectType, relations := range latest.GetAllRelations() {
+		_, typeKept := model.GetTypeDefinition(objectType)
+
+		for name, relation := range relations {
+			if !latest.IsDirectlyAssignable(relation) {
+				continue
+			}
+			probe := modelImpactProbe{objectType: objectType, relation: name}
+
+			newRelation, err := model.GetRelation(objectType, name)
+			switch {
+			case !typeKept:
+				probe.reason = "the type was removed"
+			case err != nil:
+				probe.reason = "the relation was removed"
+			case !model.IsDirectlyAssignable(newRelation):
+				probe.reason = "the relation is no longer directly assignable"
+			default:
+				allowed := make(map[string]struct{})
+				for _, rr := range newRelation.GetTypeInfo().GetDirectlyRelatedUserTypes() {
+					allowed[relationReferenceKey(rr)] = struct{}{}
+				}
+
+				var lost []string
+				for _, rr := range relation.GetTypeInfo().GetDirectlyRelatedUserTypes() {
+					if _, ok := allowed[relationReferenceKey(rr)]; ok {
+						continue
+					}
+					// the tuples of a wildcard can be probed exactly
+					if rr.GetWildcard() != nil && rr.GetCondition() == "" {
+						probes = append(probes, modelImpactProbe{
+							objectType: objectType,
+							relation:   name,
+							user:       tupleUtils.TypedPublicWildcard(rr.GetType()),
+							reason:     fmt.Sprintf("the relation no longer allows '%s'", relationReferenceKey(rr)),
+						})
+						continue
+					}
+					lost = append(lost, relationReferenceKey(rr))
+				}
+				if len(lost) == 0 {
+					continue
+				}
+				sort.Strings(lost)
+				probe.reason = fmt.Sprintf("the relation no longer allows '%s'", strings.Join(lost, "', '"))
+			}
+
+			probes = append(probes, probe)
+		}
+	}
+
+	sort.Slice(probes, func(i, j int) bool {
+		if probes[i].objectType != probes[j].objectType {
+			return probes[i].objectType < probes[j].objectType
+		}
+		if probes[i].relation != probes[j].relation {
+			return probes[i].relation < probes[j].relation
+		}
+		return probes[i].user < probes[j].user
+	})
+	return probes
+}
+
+// relationReferenceKey returns the directly related user type of a relation reference as it is
+// written in the DSL, e.g. "user", "user:*", "group#member" or "user with ip_check".
+func relationReferenceKey(rr *openfgav1.RelationReference) string {
+	key := rr.GetType()
+	switch {
+	case rr.GetWildcard() != nil:
+		key = tupleUtils.TypedPublicWildcard(rr.GetType())
+	case rr.GetRelation() != "":
+		key = rr.GetType() + "#" + rr.GetRelation()
+	}
+	if rr.GetCondition() != "" {
+		key += " with " + rr.GetCondition()
+	}
+	return key
+}
@@ -2,10 +2,12 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 
+	serverconfig "github.com/openfga/openfga/internal/server/config"
 	"github.com/openfga/openfga/pkg/encoder"
 	"github.com/openfga/openfga/pkg/logger"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
@@ -19,9 +21,12 @@ import (
 // a given object ID or userset in a type, optionally
 // constrained by a relation name.
 type ReadQuery struct {
-	datastore storage.OpenFGADatastore
-	logger    logger.Logger
-	encoder   encoder.Encoder
+	datastore       storage.OpenFGADatastore
+	tupleReader     storage.RelationshipTupleReader
+	logger          logger.Logger
+	encoder         encoder.Encoder
+	conditionFilter storage.ReadConditionFilter
+	maxResponseSize int
 }
 
 type ReadQueryOption func(*ReadQuery)
@@ -38,12 +43,40 @@ func WithReadQueryEncoder(e encoder.Encoder) ReadQueryOption {
 	}
 }
 
+// WithReadQueryConditionFilter only returns the tuples that pass the filter.
+// The filter is embedded in the continuation tokens, which cannot be used to
+// continue a Read with a different filter. See also ExecuteWithConditionFilter.
+func WithReadQueryConditionFilter(f storage.ReadConditionFilter) ReadQueryOption {
+	return func(rq *ReadQuery) {
+		rq.conditionFilter = f
+	}
+}
+
+// WithReadQueryMaxResponseSizeInBytes sets the approximate maximum size of a response. A page
+// that would exceed it ends early, with a continuation token at the first tuple left out, but
+// always has at least one tuple. 0 disables the limit.
+func WithReadQueryMaxResponseSizeInBytes(size int) ReadQueryOption {
+	return func(rq *ReadQuery) {
+		rq.maxResponseSize = size
+	}
+}
+
+// WithReadQueryTupleReader reads the tuples with the given reader instead of the datastore,
+// e.g. a storagewrappers.BoundedConcurrencyTupleReader shared by the Read queries of a server.
+func WithReadQueryTupleReader(r storage.RelationshipTupleReader) ReadQueryOption {
+	return func(rq *ReadQuery) {
+		rq.tupleReader = r
+	}
+}
+
 // NewReadQuery creates a ReadQuery using the provided OpenFGA datastore implementation.
 func NewReadQuery(datastore storage.OpenFGADatastore, opts ...ReadQueryOption) *ReadQuery {
 	rq := &ReadQuery{
-		datastore: datastore,
-		logger:    logger.NewNoopLogger(),
-		encoder:   encoder.NewBase64Encoder(),
+		datastore:       datastore,
+		tupleReader:     datastore,
+		logger:          logger.NewNoopLogger(),
+		encoder:         encoder.NewBase64Encoder(),
+		maxResponseSize: serverconfig.DefaultMaxReadResponseSizeInBytes,
 	}
 
 	for _, opt := range opts {
@@ -55,6 +88,16 @@ func NewReadQuery(datastore storage.OpenFGADatastore, opts ...ReadQueryOption) *
 // Execute the ReadQuery, returning paginated `openfga.Tuple`(s) that match the tuple. Return all tuples if the tuple is
 // nil or empty.
 func (q *ReadQuery) Execute(ctx context.Context, req *openfgav1.ReadRequest) (*openfgav1.ReadResponse, error) {
+	return q.ExecuteWithConditionFilter(ctx, req, q.conditionFilter)
+}
+
+// ExecuteWithConditionFilter is Execute with the condition filter of the request instead of the one
+// of WithReadQueryConditionFilter, so that a ReadQuery can be shared by concurrent requests.
+func (q *ReadQuery) ExecuteWithConditionFilter(
+	ctx context.Context,
+	req *openfgav1.ReadRequest,
+	conditionFilter storage.ReadConditionFilter,
+) (*openfgav1.ReadResponse, error) {
 	store := req.GetStoreId()
 	tk := req.GetTupleKey()
 
@@ -68,15 +111,49 @@ func (q *ReadQuery) Execute(ctx context.Context, req *openfgav1.ReadRequest) (*o
 		}
 	}
 
+	if conditionFilter.Name != "" && conditionFilter.HasCondition != nil && !*conditionFilter.HasCondition {
+		return nil, serverErrors.ValidationError(
+			fmt.Errorf("a condition name cannot be combined with a filter on tuples without a condition"),
+		)
+	}
+
 	decodedContToken, err := q.encoder.Decode(req.GetContinuationToken())
 	if err != nil {
 		return nil, serverErrors.InvalidContinuationToken
 	}
 
+	from, err := unwrapContinuationToken(decodedContToken, conditionFilter)
+	if err != nil {
+		return nil, serverErrors.InvalidContinuationToken
+	}
+
 	opts := storage.ReadPageOptions{
-		Pagination: storage.NewPaginationOptions(req.GetPageSize().GetValue(), string(decodedContToken)),
+		Pagination:  storage.NewPaginationOptions(req.GetPageSize().GetValue(), from),
+		Consistency: storage.ConsistencyOptions{Preference: req.GetConsistency()},
+		Condition:   conditionFilter,
+	}
+	tuples, contToken, err := q.tupleReader.ReadPage(ctx, store, tupleUtils.ConvertReadRequestTupleKeyToTupleKey(tk), opts)
+	if err != nil {
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	if n := fitResponseSize(tuples, q.maxResponseSize); n < len(tuples) {
+		// the page resumes at the first tuple left out, see storage.ReadCursor
+		key := tuples[n].GetKey()
+		objectType, objectID := tupleUtils.SplitObject(key.GetObject())
+		contToken, err = storage.ReadCursor{
+			ObjectType: objectType,
+			ObjectID:   objectID,
+			Relation:   key.GetRelation(),
+			User:       key.GetUser(),
+		}.Marshal()
+		if err != nil {
+			return nil, serverErrors.HandleError("", err)
+		}
+		tuples = tuples[:n]
 	}
-	tuples, contToken, err := q.datastore.ReadPage(ctx, store, tupleUtils.ConvertReadRequestTupleKeyToTupleKey(tk), opts)
+
+	contToken, err = wrapContinuationToken(contToken, conditionFilter)
 	if err != nil {
 		return nil, serverErrors.HandleError("", err)
 	}
@@ -91,3 +168,48 @@ func (q *ReadQuery) Execute(ctx context.Context, req *openfgav1.ReadRequest) (*o
 		ContinuationToken: encodedContToken,
 	}, nil
 }
+
+// filteredContinuationToken is the continuation token of a Read with a condition filter.
+type filteredContinuationToken struct {
+	ConditionFilter string `json:"condition_filter"`
+	From            string `json:"from"`
+}
+
+// wrapContinuationToken embeds the condition filter in the datastore continuation token.
+// Tokens of unfiltered reads are returned as is.
+func wrapContinuationToken(contToken []byte, conditionFilter storage.ReadConditionFilter) ([]byte, error) {
+	if conditionFilter.IsEmpty() || len(contToken) == 0 {
+		return contToken, nil
+	}
+
+	return json.Marshal(filteredContinuationToken{
+		ConditionFilter: conditionFilter.String(),
+		From:            string(contToken),
+	})
+}
+
+// unwrapContinuationToken returns the datastore continuation token embedded by
+// wrapContinuationToken, and fails if it was issued for a different filter.
+func unwrapContinuationToken(contToken []byte, conditionFilter storage.ReadConditionFilter) (string, error) {
+	if len(contToken) == 0 {
+		return "", nil
+	}
+
+	var token filteredContinuationToken
+	if conditionFilter.IsEmpty() {
+		if err := json.Unmarshal(contToken, &token); err == nil && token.ConditionFilter != "" {
+			return "", fmt.Errorf("continuation token was issued for the condition filter '%s'", token.ConditionFilter)
+		}
+		return string(contToken), nil
+	}
+
+	if err := json.Unmarshal(contToken, &token); err != nil {
+		return "", err
+	}
+
+	if token.ConditionFilter != conditionFilter.String() {
+		return "", fmt.Errorf("continuation token was issued for the condition filter '%s'", token.ConditionFilter)
+	}
+
+	return token.From, nil
+}
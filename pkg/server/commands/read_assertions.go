@@ -5,14 +5,37 @@ import (
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 
+	"github.com/openfga/openfga/pkg/encoder"
 	"github.com/openfga/openfga/pkg/logger"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
 )
 
+// ReadAssertionsRequest selects the assertions of an authorization model. ObjectType and
+// Relation, when not empty, only select the assertions whose tuple key has them. A PageSize
+// above zero returns the assertions in pages of that size, continued with ContinuationToken;
+// otherwise every selected assertion is returned at once.
+type ReadAssertionsRequest struct {
+	StoreID              string
+	AuthorizationModelID string
+	ObjectType           string
+	Relation             string
+	PageSize             int32
+	ContinuationToken    string
+}
+
+// ReadAssertionsResponse is a page of the assertions of an authorization model. The
+// ContinuationToken is empty on the last page.
+type ReadAssertionsResponse struct {
+	AuthorizationModelID string
+	Assertions           []*openfgav1.Assertion
+	ContinuationToken    string
+}
+
 type ReadAssertionsQuery struct {
 	backend storage.AssertionsBackend
 	logger  logger.Logger
+	encoder encoder.Encoder
 }
 
 type ReadAssertionsQueryOption func(*ReadAssertionsQuery)
@@ -23,10 +46,17 @@ func WithReadAssertionsQueryLogger(l logger.Logger) ReadAssertionsQueryOption {
 	}
 }
 
+func WithReadAssertionsQueryEncoder(e encoder.Encoder) ReadAssertionsQueryOption {
+	return func(rq *ReadAssertionsQuery) {
+		rq.encoder = e
+	}
+}
+
 func NewReadAssertionsQuery(backend storage.AssertionsBackend, opts ...ReadAssertionsQueryOption) *ReadAssertionsQuery {
 	rq := &ReadAssertionsQuery{
 		backend: backend,
 		logger:  logger.NewNoopLogger(),
+		encoder: encoder.NewBase64Encoder(),
 	}
 
 	for _, opt := range opts {
@@ -35,13 +65,35 @@ func NewReadAssertionsQuery(backend storage.AssertionsBackend, opts ...ReadAsser
 	return rq
 }
 
-func (q *ReadAssertionsQuery) Execute(ctx context.Context, store, authorizationModelID string) (*openfgav1.ReadAssertionsResponse, error) {
-	assertions, err := q.backend.ReadAssertions(ctx, store, authorizationModelID)
+func (q *ReadAssertionsQuery) Execute(ctx context.Context, req *ReadAssertionsRequest) (*ReadAssertionsResponse, error) {
+	decodedContToken, err := q.encoder.Decode(req.ContinuationToken)
+	if err != nil {
+		return nil, serverErrors.InvalidContinuationToken
+	}
+
+	opts := storage.ReadAssertionsOptions{
+		Filter: storage.ReadAssertionsFilter{
+			ObjectType: req.ObjectType,
+			Relation:   req.Relation,
+		},
+		Pagination: storage.PaginationOptions{
+			PageSize: int(max(req.PageSize, 0)),
+			From:     string(decodedContToken),
+		},
+	}
+	assertions, contToken, err := q.backend.ReadAssertions(ctx, req.StoreID, req.AuthorizationModelID, opts)
+	if err != nil {
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	encodedContToken, err := q.encoder.Encode(contToken)
 	if err != nil {
 		return nil, serverErrors.HandleError("", err)
 	}
-	return &openfgav1.ReadAssertionsResponse{
-		AuthorizationModelId: authorizationModelID,
+
+	return &ReadAssertionsResponse{
+		AuthorizationModelID: req.AuthorizationModelID,
 		Assertions:           assertions,
+		ContinuationToken:    encodedContToken,
 	}, nil
 }
@@ -19,6 +19,20 @@ type ReadChangesQuery struct {
 	logger        logger.Logger
 	encoder       encoder.Encoder
 	horizonOffset time.Duration
+	horizonInfo   bool
+	startTime     time.Time
+
+	maxResponseSize int
+}
+
+// ReadChangesHorizon tells the consumers of ReadChanges whether changes are withheld by the
+// changelog horizon, to distinguish "no changes" from "changes pending behind the horizon".
+type ReadChangesHorizon struct {
+	// Horizon is the time before which the changes are returned.
+	Horizon time.Time
+	// NewestWithheldChange is the time of the newest change withheld by the horizon, or the
+	// zero time if none is.
+	NewestWithheldChange time.Time
 }
 
 type ReadChangesQueryOption func(*ReadChangesQuery)
@@ -42,6 +56,35 @@ func WithReadChangeQueryHorizonOffset(horizonOffset int) ReadChangesQueryOption
 	}
 }
 
+// WithReadChangesQueryHorizonInfo makes the query compute the [ReadChangesHorizon] of the
+// changes of the request, see [ReadChangesQuery.ExecuteWithHorizon]. If the horizon offset is not 0, it
+// costs one more read of the changelog, of its newest change.
+func WithReadChangesQueryHorizonInfo(enabled bool) ReadChangesQueryOption {
+	return func(rq *ReadChangesQuery) {
+		rq.horizonInfo = enabled
+	}
+}
+
+// WithReadChangesQueryMaxResponseSizeInBytes sets the approximate maximum size of a response. A
+// page that would exceed it ends early, with a continuation token at the first change left out,
+// but always has at least one change. 0 disables the limit.
+func WithReadChangesQueryMaxResponseSizeInBytes(size int) ReadChangesQueryOption {
+	return func(rq *ReadChangesQuery) {
+		rq.maxResponseSize = size
+	}
+}
+
+// WithReadChangesQueryStartTime makes the query start at the first change that occurred at or
+// after the start time, e.g. to resume reading the changes since a point in time without
+// reading the changelog from the beginning. The changes are still withheld by the horizon offset.
+// The start time cannot be combined with a continuation token, the continuation tokens of the
+// responses already start after it.
+func WithReadChangesQueryStartTime(startTime time.Time) ReadChangesQueryOption {
+	return func(rq *ReadChangesQuery) {
+		rq.startTime = startTime
+	}
+}
+
 // NewReadChangesQuery creates a ReadChangesQuery with specified `ChangelogBackend`.
 func NewReadChangesQuery(backend storage.ChangelogBackend, opts ...ReadChangesQueryOption) *ReadChangesQuery {
 	rq := &ReadChangesQuery{
@@ -49,6 +92,8 @@ func NewReadChangesQuery(backend storage.ChangelogBackend, opts ...ReadChangesQu
 		logger:        logger.NewNoopLogger(),
 		encoder:       encoder.NewBase64Encoder(),
 		horizonOffset: time.Duration(serverconfig.DefaultChangelogHorizonOffset) * time.Minute,
+
+		maxResponseSize: serverconfig.DefaultMaxReadResponseSizeInBytes,
 	}
 
 	for _, opt := range opts {
@@ -59,9 +104,20 @@ func NewReadChangesQuery(backend storage.ChangelogBackend, opts ...ReadChangesQu
 
 // Execute the ReadChangesQuery, returning paginated `openfga.TupleChange`(s) and a possibly non-empty continuation token.
 func (q *ReadChangesQuery) Execute(ctx context.Context, req *openfgav1.ReadChangesRequest) (*openfgav1.ReadChangesResponse, error) {
+	resp, _, err := q.ExecuteWithHorizon(ctx, req)
+	return resp, err
+}
+
+// ExecuteWithHorizon is like Execute, but it also returns the horizon of the changes, or nil if
+// the query was not built with WithReadChangesQueryHorizonInfo.
+func (q *ReadChangesQuery) ExecuteWithHorizon(ctx context.Context, req *openfgav1.ReadChangesRequest) (*openfgav1.ReadChangesResponse, *ReadChangesHorizon, error) {
+	if !q.startTime.IsZero() && req.GetContinuationToken() != "" {
+		return nil, nil, serverErrors.ReadChangesStartTimeWithContinuationToken
+	}
+
 	decodedContToken, err := q.encoder.Decode(req.GetContinuationToken())
 	if err != nil {
-		return nil, serverErrors.InvalidContinuationToken
+		return nil, nil, serverErrors.InvalidContinuationToken
 	}
 	opts := storage.ReadChangesOptions{
 		Pagination: storage.NewPaginationOptions(req.GetPageSize().GetValue(), string(decodedContToken)),
@@ -69,24 +125,78 @@ func (q *ReadChangesQuery) Execute(ctx context.Context, req *openfgav1.ReadChang
 	filter := storage.ReadChangesFilter{
 		ObjectType:    req.GetType(),
 		HorizonOffset: q.horizonOffset,
+		StartTime:     q.startTime,
 	}
+	horizon := time.Now().Add(-q.horizonOffset)
 	changes, contToken, err := q.backend.ReadChanges(ctx, req.GetStoreId(), filter, opts)
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
+			info, err := q.readHorizon(ctx, req, horizon)
+			if err != nil {
+				return nil, nil, err
+			}
 			return &openfgav1.ReadChangesResponse{
 				ContinuationToken: req.GetContinuationToken(),
-			}, nil
+			}, info, nil
 		}
-		return nil, serverErrors.HandleError("", err)
+		return nil, nil, serverErrors.HandleError("", err)
+	}
+
+	if n := fitResponseSize(changes, q.maxResponseSize); n < len(changes) {
+		// the continuation tokens of the datastore are opaque, so the shorter page is read again
+		// to get the token of its last change
+		opts.Pagination.PageSize = n
+		changes, contToken, err = q.backend.ReadChanges(ctx, req.GetStoreId(), filter, opts)
+		if err != nil {
+			return nil, nil, serverErrors.HandleError("", err)
+		}
+	}
+
+	info, err := q.readHorizon(ctx, req, horizon)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	encodedContToken, err := q.encoder.Encode(contToken)
 	if err != nil {
-		return nil, serverErrors.HandleError("", err)
+		return nil, nil, serverErrors.HandleError("", err)
 	}
 
 	return &openfgav1.ReadChangesResponse{
 		Changes:           changes,
 		ContinuationToken: encodedContToken,
-	}, nil
+	}, info, nil
+}
+
+// readHorizon returns the horizon of the request if the query was built with
+// WithReadChangesQueryHorizonInfo, or nil. The newest change of the type of the request is read
+// with a descending read of one change, backed by the index on the ULIDs of the changelog, and
+// withheld if it is newer than the horizon.
+func (q *ReadChangesQuery) readHorizon(ctx context.Context, req *openfgav1.ReadChangesRequest, horizon time.Time) (*ReadChangesHorizon, error) {
+	if !q.horizonInfo {
+		return nil, nil
+	}
+
+	info := &ReadChangesHorizon{Horizon: horizon}
+	if q.horizonOffset == 0 {
+		return info, nil
+	}
+
+	newest, _, err := q.backend.ReadChanges(ctx, req.GetStoreId(),
+		storage.ReadChangesFilter{ObjectType: req.GetType()},
+		storage.ReadChangesOptions{Pagination: storage.NewPaginationOptions(1, ""), SortDesc: true},
+	)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return info, nil
+		}
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	if len(newest) > 0 {
+		if timestamp := newest[0].GetTimestamp().AsTime(); !timestamp.Before(horizon) {
+			info.NewestWithheldChange = timestamp
+		}
+	}
+	return info, nil
 }
@@ -11,11 +11,16 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"github.com/openfga/openfga/internal/mocks"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
 )
 
 func TestReadChangesQuery(t *testing.T) {
@@ -154,4 +159,169 @@ func TestReadChangesQuery(t *testing.T) {
 		require.Empty(t, resp.GetChanges())
 		require.Equal(t, reqToken, resp.GetContinuationToken())
 	})
+
+	t.Run("returns_failed_precondition_if_continuation_token_expired", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		reqToken := "token"
+		mockEncoder := mocks.NewMockEncoder(mockController)
+		mockEncoder.EXPECT().Decode(reqToken).Return([]byte("expired"), nil).Times(1)
+
+		mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().ReadChanges(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, nil, storage.ErrContinuationTokenExpired)
+
+		cmd := NewReadChangesQuery(mockDatastore, WithReadChangesQueryEncoder(mockEncoder))
+		resp, err := cmd.Execute(context.Background(), &openfgav1.ReadChangesRequest{
+			StoreId:           ulid.Make().String(),
+			ContinuationToken: reqToken,
+		})
+		require.Nil(t, resp)
+		require.ErrorIs(t, err, serverErrors.ContinuationTokenExpired)
+	})
+
+	t.Run("passes_the_start_time_to_storage", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		storeID := ulid.Make().String()
+		startTime := time.Now().Add(-time.Hour)
+
+		mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+		opts := storage.ReadChangesOptions{
+			Pagination: storage.NewPaginationOptions(0, ""),
+		}
+		filter := storage.ReadChangesFilter{StartTime: startTime}
+		mockDatastore.EXPECT().ReadChanges(gomock.Any(), storeID, filter, opts).Times(1)
+
+		cmd := NewReadChangesQuery(mockDatastore, WithReadChangesQueryStartTime(startTime))
+		_, err := cmd.Execute(context.Background(), &openfgav1.ReadChangesRequest{StoreId: storeID})
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects_a_start_time_with_a_continuation_token", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+
+		cmd := NewReadChangesQuery(mockDatastore, WithReadChangesQueryStartTime(time.Now()))
+		resp, err := cmd.Execute(context.Background(), &openfgav1.ReadChangesRequest{
+			StoreId:           ulid.Make().String(),
+			ContinuationToken: "token",
+		})
+		require.Nil(t, resp)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestReadChangesQueryStartTime(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")}))
+	time.Sleep(10 * time.Millisecond)
+	startTime := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:bob")}))
+
+	t.Run("reads_from_the_start_time", func(t *testing.T) {
+		cmd := NewReadChangesQuery(ds, WithReadChangesQueryStartTime(startTime))
+		resp, err := cmd.Execute(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, resp.GetChanges(), 1)
+		require.Equal(t, "user:bob", resp.GetChanges()[0].GetTupleKey().GetUser())
+	})
+
+	t.Run("honors_the_horizon_offset", func(t *testing.T) {
+		cmd := NewReadChangesQuery(ds, WithReadChangesQueryStartTime(startTime), WithReadChangeQueryHorizonOffset(1))
+		resp, err := cmd.Execute(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Empty(t, resp.GetChanges())
+	})
+}
+
+func TestReadChangesQueryHorizon(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+	before := time.Now()
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+	}))
+
+	t.Run("zero_offset_withholds_nothing", func(t *testing.T) {
+		cmd := NewReadChangesQuery(ds, WithReadChangesQueryHorizonInfo(true))
+		resp, horizon, err := cmd.ExecuteWithHorizon(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, resp.GetChanges(), 2)
+		require.NotNil(t, horizon)
+		require.False(t, horizon.Horizon.Before(before))
+		require.True(t, horizon.NewestWithheldChange.IsZero())
+	})
+
+	t.Run("offset_withholds_the_new_changes", func(t *testing.T) {
+		cmd := NewReadChangesQuery(ds, WithReadChangeQueryHorizonOffset(5), WithReadChangesQueryHorizonInfo(true))
+		resp, horizon, err := cmd.ExecuteWithHorizon(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Empty(t, resp.GetChanges())
+		require.NotNil(t, horizon)
+		require.True(t, horizon.Horizon.Before(before.Add(-4*time.Minute)))
+		require.False(t, horizon.NewestWithheldChange.Before(before))
+	})
+
+	t.Run("offset_with_type_without_changes", func(t *testing.T) {
+		cmd := NewReadChangesQuery(ds, WithReadChangeQueryHorizonOffset(5), WithReadChangesQueryHorizonInfo(true))
+		_, horizon, err := cmd.ExecuteWithHorizon(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID, Type: "group"})
+		require.NoError(t, err)
+		require.NotNil(t, horizon)
+		require.True(t, horizon.NewestWithheldChange.IsZero())
+	})
+
+	t.Run("disabled_by_default", func(t *testing.T) {
+		cmd := NewReadChangesQuery(ds, WithReadChangeQueryHorizonOffset(5))
+		_, horizon, err := cmd.ExecuteWithHorizon(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Nil(t, horizon)
+	})
+}
+
+func TestReadChangesQueryMaxResponseSize(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+	tuples := tuplesWithLargeContexts(t, 10, 4*1_024)
+	require.NoError(t, ds.Write(ctx, storeID, nil, tuples))
+
+	cmd := NewReadChangesQuery(ds, WithReadChangesQueryMaxResponseSizeInBytes(10*1_024))
+
+	var read []string
+	var continuationToken string
+	for {
+		resp, err := cmd.Execute(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID, ContinuationToken: continuationToken})
+		require.NoError(t, err)
+		if len(resp.GetChanges()) == 0 {
+			break
+		}
+		require.LessOrEqual(t, len(resp.GetChanges()), 2)
+		require.LessOrEqual(t, proto.Size(resp), 10*1_024+1_024)
+
+		for _, change := range resp.GetChanges() {
+			read = append(read, change.GetTupleKey().GetUser())
+		}
+		continuationToken = resp.GetContinuationToken()
+	}
+
+	expected := make([]string, 0, len(tuples))
+	for _, tk := range tuples {
+		expected = append(expected, tk.GetUser())
+	}
+	require.Equal(t, expected, read)
 }
@@ -3,12 +3,18 @@ package commands
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"github.com/openfga/openfga/internal/mocks"
@@ -188,4 +194,230 @@ func TestReadCommand(t *testing.T) {
 		require.Equal(t, "admin_old", resp.GetTuples()[0].GetKey().GetRelation())
 		require.Equal(t, "user_old:maria", resp.GetTuples()[0].GetKey().GetUser())
 	})
+	t.Run("filters_by_condition", func(t *testing.T) {
+		datastore := memory.New()
+		t.Cleanup(datastore.Close)
+
+		ctx := context.Background()
+		storeID := ulid.Make().String()
+
+		err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+			tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:bob", "ip_allowlist", nil),
+			tuple.NewTupleKeyWithCondition("document:1", "editor", "user:bob", "in_region", nil),
+			tuple.NewTupleKeyWithCondition("document:2", "viewer", "user:bob", "ip_allowlist", nil),
+		})
+		require.NoError(t, err)
+
+		hasCondition := true
+		hasNoCondition := false
+
+		testCases := map[string]struct {
+			tupleKey *openfgav1.ReadRequestTupleKey
+			filter   storage.ReadConditionFilter
+			expected []string
+		}{
+			`condition_name`: {
+				filter:   storage.ReadConditionFilter{Name: "ip_allowlist"},
+				expected: []string{"document:1#viewer@user:bob", "document:2#viewer@user:bob"},
+			},
+			`condition_name_and_object`: {
+				tupleKey: &openfgav1.ReadRequestTupleKey{Object: "document:1"},
+				filter:   storage.ReadConditionFilter{Name: "ip_allowlist"},
+				expected: []string{"document:1#viewer@user:bob"},
+			},
+			`condition_name_and_object_and_relation`: {
+				tupleKey: &openfgav1.ReadRequestTupleKey{Object: "document:1", Relation: "editor"},
+				filter:   storage.ReadConditionFilter{Name: "ip_allowlist"},
+			},
+			`has_condition_and_user`: {
+				tupleKey: &openfgav1.ReadRequestTupleKey{Object: "document:", User: "user:bob"},
+				filter:   storage.ReadConditionFilter{HasCondition: &hasCondition},
+				expected: []string{"document:1#editor@user:bob", "document:1#viewer@user:bob", "document:2#viewer@user:bob"},
+			},
+			`has_no_condition_and_object_and_relation`: {
+				tupleKey: &openfgav1.ReadRequestTupleKey{Object: "document:1", Relation: "viewer"},
+				filter:   storage.ReadConditionFilter{HasCondition: &hasNoCondition},
+				expected: []string{"document:1#viewer@user:anne"},
+			},
+		}
+
+		for name, test := range testCases {
+			t.Run(name, func(t *testing.T) {
+				cmd := NewReadQuery(datastore, WithReadQueryConditionFilter(test.filter))
+
+				var got []string
+				var contToken string
+				for {
+					resp, err := cmd.Execute(ctx, &openfgav1.ReadRequest{
+						StoreId:           storeID,
+						TupleKey:          test.tupleKey,
+						PageSize:          wrapperspb.Int32(1),
+						ContinuationToken: contToken,
+					})
+					require.NoError(t, err)
+					for _, tp := range resp.GetTuples() {
+						got = append(got, tuple.TupleKeyToString(tp.GetKey()))
+					}
+					if resp.GetContinuationToken() == "" {
+						break
+					}
+					contToken = resp.GetContinuationToken()
+				}
+				require.Equal(t, test.expected, got)
+			})
+		}
+
+		t.Run("shared_by_concurrent_requests_with_different_filters", func(t *testing.T) {
+			cmd := NewReadQuery(datastore)
+
+			read := func(tupleKey *openfgav1.ReadRequestTupleKey, filter storage.ReadConditionFilter) ([]string, error) {
+				var got []string
+				var contToken string
+				for {
+					resp, err := cmd.ExecuteWithConditionFilter(ctx, &openfgav1.ReadRequest{
+						StoreId:           storeID,
+						TupleKey:          tupleKey,
+						PageSize:          wrapperspb.Int32(1),
+						ContinuationToken: contToken,
+					}, filter)
+					if err != nil {
+						return nil, err
+					}
+					for _, tp := range resp.GetTuples() {
+						got = append(got, tuple.TupleKeyToString(tp.GetKey()))
+					}
+					if resp.GetContinuationToken() == "" {
+						return got, nil
+					}
+					contToken = resp.GetContinuationToken()
+				}
+			}
+
+			const repeats = 20
+			type result struct {
+				name string
+				got  []string
+				err  error
+			}
+			results := make(chan result, repeats*len(testCases))
+			var wg sync.WaitGroup
+			for i := 0; i < repeats; i++ {
+				for name, test := range testCases {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						got, err := read(test.tupleKey, test.filter)
+						results <- result{name: name, got: got, err: err}
+					}()
+				}
+			}
+			wg.Wait()
+			close(results)
+
+			for res := range results {
+				require.NoError(t, res.err, res.name)
+				require.Equal(t, testCases[res.name].expected, res.got, res.name)
+			}
+		})
+
+		t.Run("continuation_token_is_bound_to_the_filter", func(t *testing.T) {
+			cmd := NewReadQuery(datastore, WithReadQueryConditionFilter(storage.ReadConditionFilter{Name: "ip_allowlist"}))
+			resp, err := cmd.Execute(ctx, &openfgav1.ReadRequest{
+				StoreId:  storeID,
+				PageSize: wrapperspb.Int32(1),
+			})
+			require.NoError(t, err)
+			require.NotEmpty(t, resp.GetContinuationToken())
+
+			for name, other := range map[string]*ReadQuery{
+				"different_filter": NewReadQuery(datastore, WithReadQueryConditionFilter(storage.ReadConditionFilter{Name: "in_region"})),
+				"no_filter":        NewReadQuery(datastore),
+			} {
+				t.Run(name, func(t *testing.T) {
+					_, err := other.Execute(ctx, &openfgav1.ReadRequest{
+						StoreId:           storeID,
+						PageSize:          wrapperspb.Int32(1),
+						ContinuationToken: resp.GetContinuationToken(),
+					})
+					require.ErrorIs(t, err, serverErrors.InvalidContinuationToken)
+				})
+			}
+		})
+
+		t.Run("rejects_condition_name_without_condition", func(t *testing.T) {
+			cmd := NewReadQuery(datastore, WithReadQueryConditionFilter(storage.ReadConditionFilter{
+				Name:         "ip_allowlist",
+				HasCondition: &hasNoCondition,
+			}))
+			_, err := cmd.Execute(ctx, &openfgav1.ReadRequest{StoreId: storeID})
+			require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+		})
+	})
+}
+
+// tuplesWithLargeContexts returns n tuples of document:1#viewer whose condition contexts have
+// about size bytes each.
+func tuplesWithLargeContexts(t *testing.T, n, size int) []*openfgav1.TupleKey {
+	conditionContext, err := structpb.NewStruct(map[string]any{"x": strings.Repeat("x", size)})
+	require.NoError(t, err)
+
+	tuples := make([]*openfgav1.TupleKey, 0, n)
+	for i := 0; i < n; i++ {
+		tuples = append(tuples, tuple.NewTupleKeyWithCondition("document:1", "viewer", fmt.Sprintf("user:%03d", i), "condition", conditionContext))
+	}
+	return tuples
+}
+
+func TestReadQueryMaxResponseSize(t *testing.T) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+	tuples := tuplesWithLargeContexts(t, 10, 4*1_024)
+	require.NoError(t, ds.Write(ctx, storeID, nil, tuples))
+
+	t.Run("ends_the_page_early", func(t *testing.T) {
+		cmd := NewReadQuery(ds, WithReadQueryMaxResponseSizeInBytes(10*1_024))
+
+		var read []string
+		var continuationToken string
+		for {
+			resp, err := cmd.Execute(ctx, &openfgav1.ReadRequest{StoreId: storeID, ContinuationToken: continuationToken})
+			require.NoError(t, err)
+			require.NotEmpty(t, resp.GetTuples())
+			require.LessOrEqual(t, len(resp.GetTuples()), 2)
+			require.LessOrEqual(t, proto.Size(resp), 10*1_024+1_024)
+
+			for _, t := range resp.GetTuples() {
+				read = append(read, t.GetKey().GetUser())
+			}
+			continuationToken = resp.GetContinuationToken()
+			if continuationToken == "" {
+				break
+			}
+		}
+
+		expected := make([]string, 0, len(tuples))
+		for _, tk := range tuples {
+			expected = append(expected, tk.GetUser())
+		}
+		require.Equal(t, expected, read)
+	})
+
+	t.Run("returns_a_tuple_larger_than_the_limit", func(t *testing.T) {
+		cmd := NewReadQuery(ds, WithReadQueryMaxResponseSizeInBytes(1_024))
+		resp, err := cmd.Execute(ctx, &openfgav1.ReadRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, resp.GetTuples(), 1)
+		require.NotEmpty(t, resp.GetContinuationToken())
+	})
+
+	t.Run("no_limit", func(t *testing.T) {
+		cmd := NewReadQuery(ds, WithReadQueryMaxResponseSizeInBytes(0))
+		resp, err := cmd.Execute(ctx, &openfgav1.ReadRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, resp.GetTuples(), len(tuples))
+	})
 }
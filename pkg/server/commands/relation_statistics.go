@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"math/bits"
+
+	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	tupleUtils "github.com/openfga/openfga/pkg/tuple"
+)
+
+const relationStatisticsPageSize = 100
+
+// RelationStatisticsRequest selects the tuples of one object type and relation in a store.
+type RelationStatisticsRequest struct {
+	StoreID    string
+	ObjectType string
+	Relation   string
+}
+
+// UsersPerObjectBucket is a bucket of the users-per-object histogram. Min and Max are the
+// inclusive bounds of the number of users per object, and Objects the number of sampled
+// objects that fall in the bucket.
+type UsersPerObjectBucket struct {
+	Min     int   `json:"min"`
+	Max     int   `json:"max"`
+	Objects int64 `json:"objects"`
+}
+
+// RelationStatistics are estimates of the size and shape of a relation, e.g. to decide on
+// the userset batch size or the dispatch throttling thresholds.
+type RelationStatistics struct {
+	TupleCount      int64 `json:"tuple_count"`
+	DistinctObjects int64 `json:"distinct_objects"`
+	DistinctUsers   int64 `json:"distinct_users"`
+	// Exact is true if the counts above are exact, either because the datastore counted them
+	// or because the sample covers every tuple of the relation. Otherwise they are the counts
+	// of the sample, i.e. lower bounds.
+	Exact bool `json:"exact"`
+	// UsersPerObject is the histogram of the number of users per object in the sample,
+	// in buckets of powers of two.
+	UsersPerObject []UsersPerObjectBucket `json:"users_per_object"`
+	// SampleSize is the number of tuples read to build the histogram.
+	SampleSize int `json:"sample_size"`
+}
+
+// RelationStatisticsQuery samples the tuples of a relation with bounded reads.
+type RelationStatisticsQuery struct {
+	datastore     storage.RelationshipTupleReader
+	counter       storage.RelationCounter
+	logger        logger.Logger
+	maxSampleSize int
+}
+
+type RelationStatisticsQueryOption func(*RelationStatisticsQuery)
+
+func WithRelationStatisticsQueryLogger(l logger.Logger) RelationStatisticsQueryOption {
+	return func(q *RelationStatisticsQuery) {
+		q.logger = l
+	}
+}
+
+// WithRelationStatisticsCounter counts the tuples of the relation with the given counter,
+// making the counts exact regardless of the sample size.
+func WithRelationStatisticsCounter(c storage.RelationCounter) RelationStatisticsQueryOption {
+	return func(q *RelationStatisticsQuery) {
+		q.counter = c
+	}
+}
+
+// WithRelationStatisticsMaxSampleSize sets the maximum number of tuples read per query.
+func WithRelationStatisticsMaxSampleSize(size uint32) RelationStatisticsQueryOption {
+	return func(q *RelationStatisticsQuery) {
+		q.maxSampleSize = int(size)
+	}
+}
+
+// NewRelationStatisticsQuery creates a RelationStatisticsQuery that samples the given datastore.
+func NewRelationStatisticsQuery(datastore storage.RelationshipTupleReader, opts ...RelationStatisticsQueryOption) *RelationStatisticsQuery {
+	q := &RelationStatisticsQuery{
+		datastore:     datastore,
+		logger:        logger.NewNoopLogger(),
+		maxSampleSize: serverconfig.DefaultRelationStatisticsMaxSampleSize,
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+type relationCountsResult struct {
+	counts storage.RelationCounts
+	err    error
+}
+
+// relationSample is the sample of the tuples of a relation read by RelationStatisticsQuery.
+type relationSample struct {
+	usersPerObject map[string]int
+	users          map[string]struct{}
+	size           int
+	// complete is true if the sample holds every tuple of the relation.
+	complete bool
+}
+
+// Execute counts the tuples of the relation with the counter, if any, while it samples up to
+// the maximum sample size of tuples for the histogram, both within the deadline of the context.
+// If the counter times out, the counts of the sample are returned instead, and if the sampling
+// times out before reading any tuple, the counts are returned without a histogram.
+func (q *RelationStatisticsQuery) Execute(ctx context.Context, req *RelationStatisticsRequest) (*RelationStatistics, error) {
+	var countsCh chan relationCountsResult
+	if q.counter != nil {
+		countsCh = make(chan relationCountsResult, 1)
+		go func() {
+			counts, err := q.counter.CountRelation(ctx, req.StoreID, req.ObjectType, req.Relation)
+			countsCh <- relationCountsResult{counts: counts, err: err}
+		}()
+	}
+
+	sample, sampleErr := q.sample(ctx, req)
+
+	var counts *storage.RelationCounts
+	if countsCh != nil {
+		res := <-countsCh
+		switch {
+		case res.err == nil:
+			counts = &res.counts
+		case errors.Is(res.err, context.DeadlineExceeded) && sampleErr == nil:
+			q.logger.WarnWithContext(ctx, "relation statistics counting stopped at the deadline, returning the counts of the sample")
+		case !errors.Is(res.err, storage.ErrUnimplemented) && !errors.Is(res.err, context.DeadlineExceeded):
+			return nil, serverErrors.HandleError("", res.err)
+		}
+	}
+
+	if sampleErr != nil {
+		if counts == nil || !errors.Is(sampleErr, context.DeadlineExceeded) {
+			return nil, serverErrors.HandleError("", sampleErr)
+		}
+		q.logger.WarnWithContext(ctx, "relation statistics sampling stopped at the deadline, returning the counts without a histogram")
+		sample = &relationSample{}
+	}
+
+	stats := &RelationStatistics{
+		TupleCount:      int64(sample.size),
+		DistinctObjects: int64(len(sample.usersPerObject)),
+		DistinctUsers:   int64(len(sample.users)),
+		Exact:           sample.complete,
+		UsersPerObject:  usersPerObjectHistogram(sample.usersPerObject),
+		SampleSize:      sample.size,
+	}
+	if counts != nil {
+		stats.TupleCount = counts.Tuples
+		stats.DistinctObjects = counts.DistinctObjects
+		stats.DistinctUsers = counts.DistinctUsers
+		stats.Exact = true
+	}
+
+	return stats, nil
+}
+
+// sample reads up to the maximum sample size of tuples of the relation, in pages, from the
+// first tuple in ReadPage order. If the deadline of the context is exceeded after the first
+// page, the tuples read so far are returned.
+func (q *RelationStatisticsQuery) sample(ctx context.Context, req *RelationStatisticsRequest) (*relationSample, error) {
+	sample := &relationSample{
+		usersPerObject: map[string]int{},
+		users:          map[string]struct{}{},
+	}
+
+	tk := tupleUtils.NewTupleKey(tupleUtils.BuildObject(req.ObjectType, ""), req.Relation, "")
+	var opts storage.ReadPageOptions
+	for sample.size < q.maxSampleSize {
+		opts.Pagination.PageSize = min(relationStatisticsPageSize, q.maxSampleSize-sample.size)
+		tuples, contToken, err := q.datastore.ReadPage(ctx, req.StoreID, tk, opts)
+		if err != nil {
+			if sample.size > 0 && errors.Is(err, context.DeadlineExceeded) {
+				q.logger.WarnWithContext(ctx, "relation statistics sampling stopped at the deadline")
+				return sample, nil
+			}
+			return nil, err
+		}
+
+		for _, t := range tuples {
+			sample.usersPerObject[t.GetKey().GetObject()]++
+			sample.users[t.GetKey().GetUser()] = struct{}{}
+			sample.size++
+		}
+
+		if len(contToken) == 0 {
+			sample.complete = true
+			return sample, nil
+		}
+		opts.Pagination.From = string(contToken)
+	}
+
+	return sample, nil
+}
+
+// usersPerObjectHistogram buckets the number of users per object in powers of two,
+// i.e. [1, 1], [2, 3], [4, 7], etc. up to the bucket of the largest count.
+func usersPerObjectHistogram(usersPerObject map[string]int) []UsersPerObjectBucket {
+	var histogram []UsersPerObjectBucket
+	for _, n := range usersPerObject {
+		i := bits.Len(uint(n)) - 1
+		for len(histogram) <= i {
+			lower := 1 << len(histogram)
+			histogram = append(histogram, UsersPerObjectBucket{Min: lower, Max: 2*lower - 1})
+		}
+		histogram[i].Objects++
+	}
+	return histogram
+}
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+type blockingRelationCounter struct{}
+
+func (blockingRelationCounter) CountRelation(ctx context.Context, _, _, _ string) (storage.RelationCounts, error) {
+	<-ctx.Done()
+	return storage.RelationCounts{}, ctx.Err()
+}
+
+type fixedRelationCounter struct {
+	counts storage.RelationCounts
+}
+
+func (c fixedRelationCounter) CountRelation(context.Context, string, string, string) (storage.RelationCounts, error) {
+	return c.counts, nil
+}
+
+type timingOutTupleReader struct {
+	storage.RelationshipTupleReader
+}
+
+func (timingOutTupleReader) ReadPage(context.Context, string, *openfgav1.TupleKey, storage.ReadPageOptions) ([]*openfgav1.Tuple, []byte, error) {
+	return nil, nil, context.DeadlineExceeded
+}
+
+func TestRelationStatisticsQuery(t *testing.T) {
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	// document:doc-000 to document:doc-099 have one viewer each
+	storeID := "01JBN9P3QFFBW06F2EBKAJWQXD"
+	var tuples []*openfgav1.TupleKey
+	for i := 0; i < 100; i++ {
+		tuples = append(tuples, tuple.NewTupleKey(fmt.Sprintf("document:doc-%03d", i), "viewer", "user:anne"))
+	}
+	require.NoError(t, ds.Write(ctx, storeID, nil, tuples))
+
+	req := &RelationStatisticsRequest{StoreID: storeID, ObjectType: "document", Relation: "viewer"}
+
+	t.Run("sample_is_bounded", func(t *testing.T) {
+		q := NewRelationStatisticsQuery(ds, WithRelationStatisticsMaxSampleSize(3))
+
+		sample, err := q.sample(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, 3, sample.size)
+		require.Equal(t, map[string]int{"document:doc-000": 1, "document:doc-001": 1, "document:doc-002": 1}, sample.usersPerObject)
+		require.False(t, sample.complete)
+	})
+
+	t.Run("sample_of_every_tuple_is_exact", func(t *testing.T) {
+		q := NewRelationStatisticsQuery(ds, WithRelationStatisticsMaxSampleSize(1000))
+
+		stats, err := q.Execute(ctx, req)
+		require.NoError(t, err)
+		require.True(t, stats.Exact)
+		require.EqualValues(t, 100, stats.TupleCount)
+		require.EqualValues(t, 100, stats.DistinctObjects)
+		require.Equal(t, 100, stats.SampleSize)
+	})
+
+	t.Run("counter_timeout_falls_back_to_the_sample", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		q := NewRelationStatisticsQuery(ds,
+			WithRelationStatisticsMaxSampleSize(10),
+			WithRelationStatisticsCounter(blockingRelationCounter{}),
+		)
+		stats, err := q.Execute(ctx, req)
+		require.NoError(t, err)
+		require.False(t, stats.Exact)
+		require.EqualValues(t, 10, stats.TupleCount)
+		require.Equal(t, []UsersPerObjectBucket{{Min: 1, Max: 1, Objects: 10}}, stats.UsersPerObject)
+	})
+
+	t.Run("sampling_timeout_falls_back_to_the_counts", func(t *testing.T) {
+		counts := storage.RelationCounts{Tuples: 100, DistinctObjects: 100, DistinctUsers: 1}
+		q := NewRelationStatisticsQuery(timingOutTupleReader{ds},
+			WithRelationStatisticsCounter(fixedRelationCounter{counts: counts}),
+		)
+		stats, err := q.Execute(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, &RelationStatistics{
+			TupleCount:      100,
+			DistinctObjects: 100,
+			DistinctUsers:   1,
+			Exact:           true,
+		}, stats)
+	})
+
+	t.Run("counter_and_sampling_timeouts", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		q := NewRelationStatisticsQuery(timingOutTupleReader{ds},
+			WithRelationStatisticsCounter(blockingRelationCounter{}),
+		)
+		_, err := q.Execute(ctx, req)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	tupleUtils "github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+const relationUsagePageSize = 100
+
+// RelationUsage is the number of tuples of one object type and relation.
+type RelationUsage struct {
+	ObjectType string `json:"object_type"`
+	Relation   string `json:"relation"`
+	HasTuples  bool   `json:"has_tuples"`
+	TupleCount int64  `json:"tuple_count"`
+}
+
+// RelationUsageReport is the usage of the relations of a store by its tuples, relative to an
+// authorization model, e.g. to decide whether a relation can be removed from the model.
+type RelationUsageReport struct {
+	AuthorizationModelID string `json:"authorization_model_id"`
+	// Relations are the relations of the model, with or without tuples, sorted by object type
+	// and relation.
+	Relations []RelationUsage `json:"relations"`
+	// Orphans are the relations with tuples that the model does not define, either because
+	// their object type or because their relation was removed from it, sorted by object type
+	// and relation.
+	Orphans []RelationUsage `json:"orphans"`
+	// Exact is true if the counts are exact, either because the datastore counted them or
+	// because every tuple of the store was read. Otherwise they are the counts of the tuples
+	// read before the deadline, i.e. lower bounds, and a relation without tuples may have some.
+	Exact bool `json:"exact"`
+}
+
+// RelationUsageQuery counts the tuples of each relation of a store.
+type RelationUsageQuery struct {
+	datastore storage.RelationshipTupleReader
+	counter   storage.RelationUsageCounter
+	logger    logger.Logger
+}
+
+type RelationUsageQueryOption func(*RelationUsageQuery)
+
+func WithRelationUsageQueryLogger(l logger.Logger) RelationUsageQueryOption {
+	return func(q *RelationUsageQuery) {
+		q.logger = l
+	}
+}
+
+// WithRelationUsageCounter counts the tuples with the given counter instead of reading them.
+func WithRelationUsageCounter(c storage.RelationUsageCounter) RelationUsageQueryOption {
+	return func(q *RelationUsageQuery) {
+		q.counter = c
+	}
+}
+
+// NewRelationUsageQuery creates a RelationUsageQuery that counts the tuples of the given datastore.
+func NewRelationUsageQuery(datastore storage.RelationshipTupleReader, opts ...RelationUsageQueryOption) *RelationUsageQuery {
+	q := &RelationUsageQuery{
+		datastore: datastore,
+		logger:    logger.NewNoopLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// Execute counts the tuples of each relation of the store and compares them to the relations
+// of the model. Without a counter, it reads every tuple of the store in ReadPage order, and if
+// the deadline of the context is exceeded after the first page, the report of the tuples read
+// so far is returned.
+func (q *RelationUsageQuery) Execute(ctx context.Context, storeID string, typesys *typesystem.TypeSystem) (*RelationUsageReport, error) {
+	usage, exact, err := q.count(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]map[string]int64, len(usage))
+	for _, u := range usage {
+		if counts[u.ObjectType] == nil {
+			counts[u.ObjectType] = map[string]int64{}
+		}
+		counts[u.ObjectType][u.Relation] += u.Tuples
+	}
+
+	report := &RelationUsageReport{
+		AuthorizationModelID: typesys.GetAuthorizationModelID(),
+		Relations:            []RelationUsage{},
+		Orphans:              []RelationUsage{},
+		Exact:                exact,
+	}
+	for objectType, relations := range typesys.GetAllRelations() {
+		for relation := range relations {
+			n := counts[objectType][relation]
+			report.Relations = append(report.Relations, RelationUsage{
+				ObjectType: objectType,
+				Relation:   relation,
+				HasTuples:  n > 0,
+				TupleCount: n,
+			})
+		}
+	}
+	for objectType, relations := range counts {
+		for relation, n := range relations {
+			if _, err := typesys.GetRelation(objectType, relation); err == nil {
+				continue
+			}
+			report.Orphans = append(report.Orphans, RelationUsage{
+				ObjectType: objectType,
+				Relation:   relation,
+				HasTuples:  true,
+				TupleCount: n,
+			})
+		}
+	}
+	sortRelationUsage(report.Relations)
+	sortRelationUsage(report.Orphans)
+
+	return report, nil
+}
+
+// count returns the counts of the relations with tuples, and whether they are exact.
+func (q *RelationUsageQuery) count(ctx context.Context, storeID string) ([]storage.RelationUsage, bool, error) {
+	if q.counter != nil {
+		usage, err := q.counter.CountRelationUsage(ctx, storeID)
+		switch {
+		case err == nil:
+			return usage, true, nil
+		case !errors.Is(err, storage.ErrUnimplemented):
+			return nil, false, serverErrors.HandleError("", err)
+		}
+		// e.g. the partition of the store does not count the tuples, so they are read
+	}
+
+	var usage []storage.RelationUsage
+	index := map[[2]string]int{}
+	read := 0
+
+	opts := storage.ReadPageOptions{Pagination: storage.NewPaginationOptions(relationUsagePageSize, "")}
+	for {
+		tuples, contToken, err := q.datastore.ReadPage(ctx, storeID, &openfgav1.TupleKey{}, opts)
+		if err != nil {
+			if read > 0 && errors.Is(err, context.DeadlineExceeded) {
+				q.logger.WarnWithContext(ctx, "relation usage count stopped at the deadline")
+				return usage, false, nil
+			}
+			return nil, false, serverErrors.HandleError("", err)
+		}
+
+		for _, t := range tuples {
+			k := [2]string{tupleUtils.GetType(t.GetKey().GetObject()), t.GetKey().GetRelation()}
+			i, ok := index[k]
+			if !ok {
+				i = len(usage)
+				index[k] = i
+				usage = append(usage, storage.RelationUsage{ObjectType: k[0], Relation: k[1]})
+			}
+			usage[i].Tuples++
+			read++
+		}
+
+		if len(contToken) == 0 {
+			return usage, true, nil
+		}
+		opts.Pagination.From = string(contToken)
+	}
+}
+
+func sortRelationUsage(usage []RelationUsage) {
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].ObjectType != usage[j].ObjectType {
+			return usage[i].ObjectType < usage[j].ObjectType
+		}
+		return usage[i].Relation < usage[j].Relation
+	})
+}
@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// fitResponseSize returns how many of the messages of a repeated field of a response, from the
+// first, fit in maxSize bytes, approximating the size of the response by the size of the field.
+// The first message always fits, so that a page is never empty, and a maxSize of 0 fits them all.
+func fitResponseSize[T proto.Message](messages []T, maxSize int) int {
+	if maxSize <= 0 {
+		return len(messages)
+	}
+
+	size := 0
+	for i, message := range messages {
+		// the tag of the field and the length prefix of the message
+		size += 1 + protowire.SizeBytes(proto.Size(message))
+		if size > maxSize && i > 0 {
+			return i
+		}
+	}
+	return len(messages)
+}
@@ -9,11 +9,14 @@ import (
 	"sync/atomic"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/types/known/structpb"
 
+	"github.com/openfga/openfga/internal/build"
 	"github.com/openfga/openfga/internal/concurrency"
 
 	"github.com/openfga/openfga/internal/condition"
@@ -33,6 +36,16 @@ import (
 
 var tracer = otel.Tracer("openfga/pkg/server/commands/reverse_expand")
 
+// ErrMaxCandidatesExceeded is returned when the number of distinct candidate objects
+// held in memory by a ReverseExpandQuery exceeds the configured limit.
+var ErrMaxCandidatesExceeded = errors.New("reverse expansion exceeded the maximum number of candidate objects")
+
+var candidateSetSizeGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: build.ProjectName,
+	Name:      "list_objects_candidate_set_size",
+	Help:      "The number of candidate objects currently held in memory by in-flight ListObjects requests.",
+})
+
 type ReverseExpandRequest struct {
 	StoreID          string
 	ObjectType       string
@@ -42,6 +55,10 @@ type ReverseExpandRequest struct {
 	Context          *structpb.Struct
 	Consistency      openfgav1.ConsistencyPreference
 
+	// ContextualTupleOverlay is the index of ContextualTuples, if the caller built it already to
+	// share it with its other readers. Execute builds it otherwise.
+	ContextualTupleOverlay *storagewrappers.ContextualTupleOverlay
+
 	edge *graph.RelationshipEdge
 }
 
@@ -123,6 +140,10 @@ type ReverseExpandQuery struct {
 	visitedUsersetsMap *sync.Map
 	// candidateObjectsMap map prevents returning the same object twice
 	candidateObjectsMap *sync.Map
+	// candidateCount is the number of entries in candidateObjectsMap
+	candidateCount atomic.Uint32
+	// maxCandidates is the maximum size of candidateObjectsMap. 0 means no limit.
+	maxCandidates uint32
 }
 
 type ReverseExpandQueryOption func(d *ReverseExpandQuery)
@@ -145,6 +166,14 @@ func WithResolveNodeBreadthLimit(limit uint32) ReverseExpandQueryOption {
 	}
 }
 
+// WithMaxCandidates sets the maximum number of distinct candidate objects that the query
+// holds in memory. If exceeded, Execute returns ErrMaxCandidatesExceeded. 0 means no limit.
+func WithMaxCandidates(limit uint32) ReverseExpandQueryOption {
+	return func(d *ReverseExpandQuery) {
+		d.maxCandidates = limit
+	}
+}
+
 func NewReverseExpandQuery(ds storage.RelationshipTupleReader, ts *typesystem.TypeSystem, opts ...ReverseExpandQueryOption) *ReverseExpandQuery {
 	query := &ReverseExpandQuery{
 		logger:                  logger.NewNoopLogger(),
@@ -181,24 +210,6 @@ type ReverseExpandResult struct {
 	ResultStatus ConditionalResultStatus
 }
 
-type ResolutionMetadata struct {
-	DatastoreQueryCount *uint32
-
-	// The number of times we are expanding from each node to find set of objects
-	DispatchCounter *atomic.Uint32
-
-	// WasThrottled indicates whether the request was throttled
-	WasThrottled *atomic.Bool
-}
-
-func NewResolutionMetadata() *ResolutionMetadata {
-	return &ResolutionMetadata{
-		DatastoreQueryCount: new(uint32),
-		DispatchCounter:     new(atomic.Uint32),
-		WasThrottled:        new(atomic.Bool),
-	}
-}
-
 func WithLogger(logger logger.Logger) ReverseExpandQueryOption {
 	return func(d *ReverseExpandQuery) {
 		d.logger = logger
@@ -221,8 +232,19 @@ func (c *ReverseExpandQuery) Execute(
 	ctx context.Context,
 	req *ReverseExpandRequest,
 	resultChan chan<- *ReverseExpandResult,
-	resolutionMetadata *ResolutionMetadata,
+	resolutionMetadata *graph.ResolutionMetadata,
 ) error {
+	defer func() {
+		candidateSetSizeGauge.Sub(float64(c.candidateCount.Swap(0)))
+	}()
+
+	// the contextual tuples are indexed once, and shared by all the dispatches
+	if req.ContextualTupleOverlay == nil {
+		indexed := *req
+		indexed.ContextualTupleOverlay = storagewrappers.NewContextualTupleOverlay(req.ContextualTuples)
+		req = &indexed
+	}
+
 	err := c.execute(ctx, req, resultChan, false, resolutionMetadata)
 	if err != nil {
 		return err
@@ -237,9 +259,9 @@ func (c *ReverseExpandQuery) dispatch(
 	req *ReverseExpandRequest,
 	resultChan chan<- *ReverseExpandResult,
 	intersectionOrExclusionInPreviousEdges bool,
-	resolutionMetadata *ResolutionMetadata,
+	resolutionMetadata *graph.ResolutionMetadata,
 ) error {
-	newcount := resolutionMetadata.DispatchCounter.Add(1)
+	newcount := resolutionMetadata.DispatchCount.Add(1)
 	if c.dispatchThrottlerConfig.Enabled {
 		c.throttle(ctx, newcount, resolutionMetadata)
 	}
@@ -251,7 +273,7 @@ func (c *ReverseExpandQuery) execute(
 	req *ReverseExpandRequest,
 	resultChan chan<- *ReverseExpandResult,
 	intersectionOrExclusionInPreviousEdges bool,
-	resolutionMetadata *ResolutionMetadata,
+	resolutionMetadata *graph.ResolutionMetadata,
 ) error {
 	if ctx.Err() != nil {
 		return ctx.Err()
@@ -302,7 +324,7 @@ func (c *ReverseExpandQuery) execute(
 		sourceUserRef = typesystem.DirectRelationReference(sourceUserType, val.ObjectRelation.GetRelation())
 
 		if req.edge != nil {
-			key := fmt.Sprintf("%s#%s", sourceUserObj, req.edge.String())
+			key := tuple.CanonicalKey(sourceUserObj, req.edge.String())
 			if _, loaded := c.visitedUsersetsMap.LoadOrStore(key, struct{}{}); loaded {
 				// we've already visited this userset through this edge, exit to avoid an infinite cycle
 				return nil
@@ -337,13 +359,14 @@ LoopOnEdges:
 		innerLoopEdge := edge
 		intersectionOrExclusionInPreviousEdges := intersectionOrExclusionInPreviousEdges || innerLoopEdge.TargetReferenceInvolvesIntersectionOrExclusion
 		r := &ReverseExpandRequest{
-			StoreID:          req.StoreID,
-			ObjectType:       req.ObjectType,
-			Relation:         req.Relation,
-			User:             req.User,
-			ContextualTuples: req.ContextualTuples,
-			Context:          req.Context,
-			edge:             innerLoopEdge,
+			StoreID:                req.StoreID,
+			ObjectType:             req.ObjectType,
+			Relation:               req.Relation,
+			User:                   req.User,
+			ContextualTuples:       req.ContextualTuples,
+			ContextualTupleOverlay: req.ContextualTupleOverlay,
+			Context:                req.Context,
+			edge:                   innerLoopEdge,
 		}
 		switch innerLoopEdge.Type {
 		case graph.DirectEdge:
@@ -386,7 +409,7 @@ func (c *ReverseExpandQuery) reverseExpandTupleToUserset(
 	req *ReverseExpandRequest,
 	resultChan chan<- *ReverseExpandResult,
 	intersectionOrExclusionInPreviousEdges bool,
-	resolutionMetadata *ResolutionMetadata,
+	resolutionMetadata *graph.ResolutionMetadata,
 ) error {
 	ctx, span := tracer.Start(ctx, "reverseExpandTupleToUserset", trace.WithAttributes(
 		attribute.String("edge", req.edge.String()),
@@ -409,7 +432,7 @@ func (c *ReverseExpandQuery) reverseExpandDirect(
 	req *ReverseExpandRequest,
 	resultChan chan<- *ReverseExpandResult,
 	intersectionOrExclusionInPreviousEdges bool,
-	resolutionMetadata *ResolutionMetadata,
+	resolutionMetadata *graph.ResolutionMetadata,
 ) error {
 	ctx, span := tracer.Start(ctx, "reverseExpandDirect", trace.WithAttributes(
 		attribute.String("edge", req.edge.String()),
@@ -432,7 +455,7 @@ func (c *ReverseExpandQuery) readTuplesAndExecute(
 	req *ReverseExpandRequest,
 	resultChan chan<- *ReverseExpandResult,
 	intersectionOrExclusionInPreviousEdges bool,
-	resolutionMetadata *ResolutionMetadata,
+	resolutionMetadata *graph.ResolutionMetadata,
 ) error {
 	if ctx.Err() != nil {
 		return ctx.Err()
@@ -487,7 +510,7 @@ func (c *ReverseExpandQuery) readTuplesAndExecute(
 		panic("unsupported edge type")
 	}
 
-	combinedTupleReader := storagewrappers.NewCombinedTupleReader(c.datastore, req.ContextualTuples)
+	combinedTupleReader := storagewrappers.NewCombinedTupleReaderWithOverlay(c.datastore, req.ContextualTupleOverlay)
 
 	// find all tuples of the form req.edge.TargetReference.Type:...#relationFilter@userFilter
 	iter, err := combinedTupleReader.ReadStartingWithUser(ctx, req.StoreID, storage.ReadStartingWithUserFilter{
@@ -499,7 +522,7 @@ func (c *ReverseExpandQuery) readTuplesAndExecute(
 			Preference: req.Consistency,
 		},
 	})
-	atomic.AddUint32(resolutionMetadata.DatastoreQueryCount, 1)
+	resolutionMetadata.DatastoreQueryCount.Add(1)
 	if err != nil {
 		return err
 	}
@@ -533,6 +556,7 @@ LoopOnIterator:
 		}
 
 		if !condEvalResult.ConditionMet {
+			resolutionMetadata.ConditionalExclusionCount.Add(1)
 			if len(condEvalResult.MissingParameters) > 0 {
 				errs = errors.Join(errs, condition.NewEvaluationError(
 					tk.GetCondition().GetName(),
@@ -569,9 +593,10 @@ LoopOnIterator:
 					},
 					Condition: tk.GetCondition(),
 				},
-				ContextualTuples: req.ContextualTuples,
-				Context:          req.Context,
-				edge:             req.edge,
+				ContextualTuples:       req.ContextualTuples,
+				ContextualTupleOverlay: req.ContextualTupleOverlay,
+				Context:                req.Context,
+				edge:                   req.edge,
 			}, resultChan, intersectionOrExclusionInPreviousEdges, resolutionMetadata)
 		})
 	}
@@ -593,6 +618,12 @@ func (c *ReverseExpandQuery) trySendCandidate(ctx context.Context, intersectionO
 	defer span.End()
 
 	if _, ok := c.candidateObjectsMap.LoadOrStore(candidateObject, struct{}{}); !ok {
+		candidateSetSizeGauge.Inc()
+		if count := c.candidateCount.Add(1); c.maxCandidates != 0 && count > c.maxCandidates {
+			span.SetAttributes(attribute.Int("candidate_count", int(count)))
+			return ErrMaxCandidatesExceeded
+		}
+
 		resultStatus := NoFurtherEvalStatus
 		if intersectionOrExclusionInPreviousEdges {
 			span.SetAttributes(attribute.Bool("requires_further_eval", true))
@@ -613,7 +644,7 @@ func (c *ReverseExpandQuery) trySendCandidate(ctx context.Context, intersectionO
 	return nil
 }
 
-func (c *ReverseExpandQuery) throttle(ctx context.Context, currentNumDispatch uint32, metadata *ResolutionMetadata) {
+func (c *ReverseExpandQuery) throttle(ctx context.Context, currentNumDispatch uint32, metadata *graph.ResolutionMetadata) {
 	span := trace.SpanFromContext(ctx)
 
 	shouldThrottle := threshold.ShouldThrottle(
@@ -621,6 +652,7 @@ func (c *ReverseExpandQuery) throttle(ctx context.Context, currentNumDispatch ui
 		currentNumDispatch,
 		c.dispatchThrottlerConfig.Threshold,
 		c.dispatchThrottlerConfig.MaxThreshold,
+		c.dispatchThrottlerConfig.LowPriorityDeprioritization,
 	)
 
 	span.SetAttributes(
@@ -628,7 +660,7 @@ func (c *ReverseExpandQuery) throttle(ctx context.Context, currentNumDispatch ui
 		attribute.Bool("is_throttled", shouldThrottle))
 
 	if shouldThrottle {
-		metadata.WasThrottled.Store(true)
+		metadata.ThrottleCount.Add(1)
 		c.dispatchThrottlerConfig.Throttler.Throttle(ctx)
 	}
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/oklog/ulid/v2"
 	"go.uber.org/goleak"
 
+	"github.com/openfga/openfga/internal/graph"
 	"github.com/openfga/openfga/internal/throttler/threshold"
 	"github.com/openfga/openfga/pkg/testutils"
 	"github.com/openfga/openfga/pkg/tuple"
@@ -77,7 +78,7 @@ func TestReverseExpandResultChannelClosed(t *testing.T) {
 				},
 			},
 			ContextualTuples: []*openfgav1.TupleKey{},
-		}, resultChan, NewResolutionMetadata())
+		}, resultChan, graph.NewResolutionMetadata())
 		t.Logf("after execute reverse expand")
 
 		if err != nil {
@@ -151,7 +152,7 @@ func TestReverseExpandRespectsContextCancellation(t *testing.T) {
 				},
 			},
 			ContextualTuples: []*openfgav1.TupleKey{},
-		}, resultChan, NewResolutionMetadata())
+		}, resultChan, graph.NewResolutionMetadata())
 		t.Logf("after execute reverse expand")
 
 		if err != nil {
@@ -224,7 +225,7 @@ func TestReverseExpandRespectsContextTimeout(t *testing.T) {
 				},
 			},
 			ContextualTuples: []*openfgav1.TupleKey{},
-		}, resultChan, NewResolutionMetadata())
+		}, resultChan, graph.NewResolutionMetadata())
 
 		if err != nil {
 			errChan <- err
@@ -294,7 +295,7 @@ func TestReverseExpandErrorInTuples(t *testing.T) {
 				},
 			},
 			ContextualTuples: []*openfgav1.TupleKey{},
-		}, resultChan, NewResolutionMetadata())
+		}, resultChan, graph.NewResolutionMetadata())
 		if err != nil {
 			errChan <- err
 		}
@@ -361,7 +362,7 @@ func TestReverseExpandSendsAllErrorsThroughChannel(t *testing.T) {
 					},
 				},
 				ContextualTuples: []*openfgav1.TupleKey{},
-			}, resultChan, NewResolutionMetadata())
+			}, resultChan, graph.NewResolutionMetadata())
 			t.Logf("after produce")
 
 			if err != nil {
@@ -450,7 +451,7 @@ func TestReverseExpandIgnoresInvalidTuples(t *testing.T) {
 			Relation:         "member",
 			User:             &UserRefObject{Object: &openfgav1.Object{Type: "user", Id: "anne"}},
 			ContextualTuples: []*openfgav1.TupleKey{},
-		}, resultChan, NewResolutionMetadata())
+		}, resultChan, graph.NewResolutionMetadata())
 
 		if err != nil {
 			errChan <- err
@@ -511,11 +512,11 @@ func TestReverseExpandThrottle(t *testing.T) {
 		)
 		mockThrottler.EXPECT().Throttle(gomock.Any()).Times(0)
 		dispatchCountValue := uint32(190)
-		metadata := NewResolutionMetadata()
-		metadata.DispatchCounter.Store(dispatchCountValue)
+		metadata := graph.NewResolutionMetadata()
+		metadata.DispatchCount.Store(dispatchCountValue)
 
 		reverseExpandQuery.throttle(ctx, dispatchCountValue, metadata)
-		require.False(t, metadata.WasThrottled.Load())
+		require.False(t, metadata.WasThrottled())
 	})
 
 	t.Run("above_threshold_should_call_throttle", func(t *testing.T) {
@@ -531,11 +532,11 @@ func TestReverseExpandThrottle(t *testing.T) {
 		)
 		mockThrottler.EXPECT().Throttle(gomock.Any()).Times(1)
 		dispatchCountValue := uint32(201)
-		metadata := NewResolutionMetadata()
-		metadata.DispatchCounter.Store(dispatchCountValue)
+		metadata := graph.NewResolutionMetadata()
+		metadata.DispatchCount.Store(dispatchCountValue)
 
 		reverseExpandQuery.throttle(ctx, dispatchCountValue, metadata)
-		require.True(t, metadata.WasThrottled.Load())
+		require.True(t, metadata.WasThrottled())
 	})
 
 	t.Run("zero_max_should_interpret_as_default", func(t *testing.T) {
@@ -551,11 +552,11 @@ func TestReverseExpandThrottle(t *testing.T) {
 		)
 		mockThrottler.EXPECT().Throttle(gomock.Any()).Times(0)
 		dispatchCountValue := uint32(190)
-		metadata := NewResolutionMetadata()
-		metadata.DispatchCounter.Store(dispatchCountValue)
+		metadata := graph.NewResolutionMetadata()
+		metadata.DispatchCount.Store(dispatchCountValue)
 
 		reverseExpandQuery.throttle(ctx, dispatchCountValue, metadata)
-		require.False(t, metadata.WasThrottled.Load())
+		require.False(t, metadata.WasThrottled())
 	})
 
 	t.Run("dispatch_should_use_request_threshold_if_available", func(t *testing.T) {
@@ -573,11 +574,11 @@ func TestReverseExpandThrottle(t *testing.T) {
 		dispatchCountValue := uint32(201)
 		ctx := context.Background()
 		ctx = dispatch.ContextWithThrottlingThreshold(ctx, 200)
-		metadata := NewResolutionMetadata()
-		metadata.DispatchCounter.Store(dispatchCountValue)
+		metadata := graph.NewResolutionMetadata()
+		metadata.DispatchCount.Store(dispatchCountValue)
 
 		reverseExpandQuery.throttle(ctx, dispatchCountValue, metadata)
-		require.True(t, metadata.WasThrottled.Load())
+		require.True(t, metadata.WasThrottled())
 	})
 
 	t.Run("should_respect_max_threshold", func(t *testing.T) {
@@ -595,10 +596,10 @@ func TestReverseExpandThrottle(t *testing.T) {
 		dispatchCountValue := uint32(301)
 		ctx := context.Background()
 		ctx = dispatch.ContextWithThrottlingThreshold(ctx, 1000)
-		metadata := NewResolutionMetadata()
+		metadata := graph.NewResolutionMetadata()
 
 		reverseExpandQuery.throttle(ctx, dispatchCountValue, metadata)
-		require.True(t, metadata.WasThrottled.Load())
+		require.True(t, metadata.WasThrottled())
 	})
 }
 
@@ -707,7 +708,7 @@ func TestReverseExpandDispatchCount(t *testing.T) {
 			ctx := storage.ContextWithRelationshipTupleReader(context.Background(), ds)
 			ctrl := gomock.NewController(t)
 			ctx = typesystem.ContextWithTypesystem(ctx, typesys)
-			resolutionMetadata := NewResolutionMetadata()
+			resolutionMetadata := graph.NewResolutionMetadata()
 
 			mockThrottler := mocks.NewMockThrottler(ctrl)
 			t.Cleanup(ctrl.Finish)
@@ -751,8 +752,8 @@ func TestReverseExpandDispatchCount(t *testing.T) {
 					break ConsumerLoop
 				}
 			}
-			require.Equal(t, test.expectedDispatchCount, resolutionMetadata.DispatchCounter.Load())
-			require.Equal(t, test.expectedWasThrottled, resolutionMetadata.WasThrottled.Load())
+			require.Equal(t, test.expectedDispatchCount, resolutionMetadata.DispatchCount.Load())
+			require.Equal(t, test.expectedWasThrottled, resolutionMetadata.WasThrottled())
 		})
 	}
 }
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"context"
+	"errors"
+
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// StoreStatistics are the number of tuples of a store, from the counters maintained by the
+// datastore, and the quota that limits them.
+type StoreStatistics struct {
+	StoreID                string           `json:"store_id"`
+	Name                   string           `json:"name"`
+	TupleCount             int64            `json:"tuple_count"`
+	TupleCountByObjectType map[string]int64 `json:"tuple_count_by_object_type"`
+	TupleQuota             TupleQuota       `json:"tuple_quota"`
+}
+
+// StoreStatisticsQuery reads the tuple counters of a store.
+type StoreStatisticsQuery struct {
+	storesBackend storage.StoresBackend
+	counter       storage.TupleCounter
+	logger        logger.Logger
+	tupleQuota    TupleQuota
+}
+
+type StoreStatisticsQueryOption func(*StoreStatisticsQuery)
+
+func WithStoreStatisticsQueryLogger(l logger.Logger) StoreStatisticsQueryOption {
+	return func(q *StoreStatisticsQuery) {
+		q.logger = l
+	}
+}
+
+// WithStoreStatisticsTupleQuota sets the quota reported with the statistics.
+func WithStoreStatisticsTupleQuota(quota TupleQuota) StoreStatisticsQueryOption {
+	return func(q *StoreStatisticsQuery) {
+		q.tupleQuota = quota
+	}
+}
+
+// NewStoreStatisticsQuery creates a StoreStatisticsQuery that reads the stores from storesBackend
+// and their tuple counters from counter.
+func NewStoreStatisticsQuery(storesBackend storage.StoresBackend, counter storage.TupleCounter, opts ...StoreStatisticsQueryOption) *StoreStatisticsQuery {
+	q := &StoreStatisticsQuery{
+		storesBackend: storesBackend,
+		counter:       counter,
+		logger:        logger.NewNoopLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// Execute returns the statistics of the store.
+func (q *StoreStatisticsQuery) Execute(ctx context.Context, storeID string) (*StoreStatistics, error) {
+	store, err := q.storesBackend.GetStore(ctx, storeID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, serverErrors.StoreIDNotFound
+		}
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	counts, err := q.counter.ReadTupleCounts(ctx, storeID)
+	if err != nil {
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	return &StoreStatistics{
+		StoreID:                store.GetId(),
+		Name:                   store.GetName(),
+		TupleCount:             counts.Total,
+		TupleCountByObjectType: counts.ByObjectType,
+		TupleQuota:             q.tupleQuota,
+	}, nil
+}
@@ -4,10 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/internal/condition"
 	"github.com/openfga/openfga/internal/server/config"
 	"github.com/openfga/openfga/internal/validation"
 	"github.com/openfga/openfga/pkg/logger"
@@ -17,11 +23,47 @@ import (
 	"github.com/openfga/openfga/pkg/typesystem"
 )
 
+var referentialCheckRejectionsCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "write_referential_check_rejections_count",
+	Help:      "The total number of Write requests rejected because a tupleset relation referenced an object without any tuples.",
+})
+
 // WriteCommand is used to Write and Delete tuples. Instances may be safely shared by multiple goroutines.
 type WriteCommand struct {
 	logger                    logger.Logger
 	datastore                 storage.OpenFGADatastore
 	conditionContextByteLimit int
+	referentialCheckEnabled   bool
+	tupleCounter              storage.TupleCounter
+	tupleQuota                TupleQuota
+}
+
+// TupleQuota limits the number of tuples of a store. Only writes that add tuples are rejected,
+// so that deletes can always bring a store back under its quota. 0 means no limit.
+type TupleQuota struct {
+	// MaxTuples is the maximum number of tuples of the store.
+	MaxTuples int64 `json:"max_tuples"`
+
+	// MaxTuplesPerObjectType is the maximum number of tuples of each object type of the store,
+	// unless MaxTuplesByObjectType sets another for the type.
+	MaxTuplesPerObjectType int64 `json:"max_tuples_per_object_type"`
+
+	// MaxTuplesByObjectType is the maximum number of tuples of the given object types of the store.
+	MaxTuplesByObjectType map[string]int64 `json:"max_tuples_by_object_type,omitempty"`
+}
+
+// IsZero returns true if the quota does not limit the number of tuples.
+func (q TupleQuota) IsZero() bool {
+	return q.MaxTuples == 0 && q.MaxTuplesPerObjectType == 0 && len(q.MaxTuplesByObjectType) == 0
+}
+
+// MaxTuplesOfObjectType returns the maximum number of tuples of the object type, 0 if there is no limit.
+func (q TupleQuota) MaxTuplesOfObjectType(objectType string) int64 {
+	if maxTuples, ok := q.MaxTuplesByObjectType[objectType]; ok {
+		return maxTuples
+	}
+	return q.MaxTuplesPerObjectType
 }
 
 type WriteCommandOption func(*WriteCommand)
@@ -38,6 +80,22 @@ func WithConditionContextByteLimit(limit int) WriteCommandOption {
 	}
 }
 
+// WithReferentialCheck see server.WithWriteReferentialCheck.
+func WithReferentialCheck(enabled bool) WriteCommandOption {
+	return func(wc *WriteCommand) {
+		wc.referentialCheckEnabled = enabled
+	}
+}
+
+// WithTupleQuota see server.WithTupleQuota. The quota is enforced with the counters read
+// from counter.
+func WithTupleQuota(counter storage.TupleCounter, quota TupleQuota) WriteCommandOption {
+	return func(wc *WriteCommand) {
+		wc.tupleCounter = counter
+		wc.tupleQuota = quota
+	}
+}
+
 // NewWriteCommand creates a WriteCommand with specified storage.OpenFGADatastore to use for storage.
 func NewWriteCommand(datastore storage.OpenFGADatastore, opts ...WriteCommandOption) *WriteCommand {
 	cmd := &WriteCommand{
@@ -58,6 +116,10 @@ func (c *WriteCommand) Execute(ctx context.Context, req *openfgav1.WriteRequest)
 		return nil, err
 	}
 
+	if err := c.checkTupleQuota(ctx, req); err != nil {
+		return nil, err
+	}
+
 	err := c.datastore.Write(
 		ctx,
 		req.GetStoreId(),
@@ -71,6 +133,53 @@ func (c *WriteCommand) Execute(ctx context.Context, req *openfgav1.WriteRequest)
 	return &openfgav1.WriteResponse{}, nil
 }
 
+// checkTupleQuota returns a TupleQuotaExceeded error if the request adds tuples to a store or
+// object type that would then exceed its quota. The counters are read before the write, so
+// concurrent writes can exceed a quota by the tuples they add together.
+func (c *WriteCommand) checkTupleQuota(ctx context.Context, req *openfgav1.WriteRequest) error {
+	if c.tupleCounter == nil || c.tupleQuota.IsZero() {
+		return nil
+	}
+
+	deltas := storage.TupleCountDeltas(req.GetDeletes().GetTupleKeys(), req.GetWrites().GetTupleKeys())
+
+	var added int64
+	objectTypes := make([]string, 0, len(deltas))
+	for objectType, delta := range deltas {
+		added += delta
+		if delta > 0 {
+			objectTypes = append(objectTypes, objectType)
+		}
+	}
+	if len(objectTypes) == 0 {
+		return nil
+	}
+	sort.Strings(objectTypes)
+
+	ctx, span := tracer.Start(ctx, "checkTupleQuota")
+	defer span.End()
+
+	counts, err := c.tupleCounter.ReadTupleCounts(ctx, req.GetStoreId())
+	if err != nil {
+		return serverErrors.HandleError("", err)
+	}
+
+	if quota := c.tupleQuota.MaxTuples; quota > 0 && added > 0 && counts.Total+added > quota {
+		span.SetAttributes(attribute.Bool("tuple_quota_exceeded", true))
+		return serverErrors.TupleQuotaExceeded("", quota, counts.Total)
+	}
+
+	for _, objectType := range objectTypes {
+		usage := counts.ByObjectType[objectType]
+		if quota := c.tupleQuota.MaxTuplesOfObjectType(objectType); quota > 0 && usage+deltas[objectType] > quota {
+			span.SetAttributes(attribute.Bool("tuple_quota_exceeded", true))
+			return serverErrors.TupleQuotaExceeded(objectType, quota, usage)
+		}
+	}
+
+	return nil
+}
+
 func (c *WriteCommand) validateWriteRequest(ctx context.Context, req *openfgav1.WriteRequest) error {
 	ctx, span := tracer.Start(ctx, "validateWriteRequest")
 	defer span.End()
@@ -84,6 +193,12 @@ func (c *WriteCommand) validateWriteRequest(ctx context.Context, req *openfgav1.
 		return serverErrors.InvalidWriteInput
 	}
 
+	// conflicting tuples are rejected before anything is read from storage, so that every
+	// backend reports them the same way
+	if err := c.validateNoDuplicatesAndCorrectSize(deletes, writes); err != nil {
+		return err
+	}
+
 	if len(writes) > 0 {
 		authModel, err := c.datastore.ReadAuthorizationModel(ctx, store, modelID)
 		if err != nil {
@@ -102,9 +217,18 @@ func (c *WriteCommand) validateWriteRequest(ctx context.Context, req *openfgav1.
 			return err
 		}
 
-		for _, tk := range writes {
+		for i, tk := range writes {
 			err := validation.ValidateTupleForWrite(typesys, tk)
 			if err != nil {
+				var paramErr *condition.ParameterValueError
+				if errors.As(err, &paramErr) {
+					return serverErrors.ValidationError(&tupleUtils.InvalidConditionalTupleError{
+						Cause: fmt.Errorf("context parameter '%s' of the tuple at index %d expects type '%s', but found '%s': %v",
+							paramErr.Parameter, i, paramErr.ExpectedType, paramErr.ActualType, paramErr.Cause),
+						TupleKey: tk,
+					})
+				}
+
 				return serverErrors.ValidationError(err)
 			}
 
@@ -121,6 +245,12 @@ func (c *WriteCommand) validateWriteRequest(ctx context.Context, req *openfgav1.
 				})
 			}
 		}
+
+		if c.referentialCheckEnabled {
+			if err := c.validateReferencedObjectsExist(ctx, store, typesys, writes, deletes); err != nil {
+				return err
+			}
+		}
 	}
 
 	for _, tk := range deletes {
@@ -134,39 +264,119 @@ func (c *WriteCommand) validateWriteRequest(ctx context.Context, req *openfgav1.
 		}
 	}
 
-	if err := c.validateNoDuplicatesAndCorrectSize(deletes, writes); err != nil {
-		return err
-	}
-
 	return nil
 }
 
 // validateNoDuplicatesAndCorrectSize ensures the deletes and writes contain no duplicates and length fits.
+// A tuple is a duplicate if it appears twice in the deletes, twice in the writes, or in both.
 func (c *WriteCommand) validateNoDuplicatesAndCorrectSize(
 	deletes []*openfgav1.TupleKeyWithoutCondition,
 	writes []*openfgav1.TupleKey,
 ) error {
-	tuples := map[string]struct{}{}
+	deleteIndices := make(map[string]int, len(deletes))
+	for i, tk := range deletes {
+		key := tupleUtils.CanonicalTupleKey(tk)
+		if j, ok := deleteIndices[key]; ok {
+			return serverErrors.DuplicateTupleInDeletes(tk, j, i)
+		}
+		deleteIndices[key] = i
+	}
 
-	for _, tk := range deletes {
-		key := tupleUtils.TupleKeyToString(tk)
-		if _, ok := tuples[key]; ok {
-			return serverErrors.DuplicateTupleInWrite(tk)
+	writeIndices := make(map[string]int, len(writes))
+	for i, tk := range writes {
+		key := tupleUtils.CanonicalTupleKey(tk)
+		if j, ok := writeIndices[key]; ok {
+			return serverErrors.DuplicateTupleInWrites(tk, j, i)
+		}
+		if j, ok := deleteIndices[key]; ok {
+			return serverErrors.TupleInWritesAndDeletes(tk, i, j)
 		}
-		tuples[key] = struct{}{}
+		writeIndices[key] = i
 	}
 
+	if len(deleteIndices)+len(writeIndices) > c.datastore.MaxTuplesPerWrite() {
+		return serverErrors.ExceededEntityLimit("write operations", c.datastore.MaxTuplesPerWrite())
+	}
+	return nil
+}
+
+// validateReferencedObjectsExist ensures that the object referenced by each written tuple of a
+// tupleset relation (e.g. folder:x in `document:1#parent@folder:x`) is the object of at least one
+// tuple, either written in the same request or stored and not deleted by the same request. It
+// issues at most one read per distinct referenced object, of one tuple more than the request
+// deletes of the object.
+func (c *WriteCommand) validateReferencedObjectsExist(
+	ctx context.Context,
+	store string,
+	typesys *typesystem.TypeSystem,
+	writes []*openfgav1.TupleKey,
+	deletes []*openfgav1.TupleKeyWithoutCondition,
+) error {
+	ctx, span := tracer.Start(ctx, "validateReferencedObjectsExist")
+	defer span.End()
+
+	writtenObjects := make(map[string]struct{}, len(writes))
 	for _, tk := range writes {
-		key := tupleUtils.TupleKeyToString(tk)
-		if _, ok := tuples[key]; ok {
-			return serverErrors.DuplicateTupleInWrite(tk)
+		writtenObjects[tk.GetObject()] = struct{}{}
+	}
+
+	deletedTuples := make(map[string]map[string]struct{}, len(deletes))
+	for _, tk := range deletes {
+		if _, ok := deletedTuples[tk.GetObject()]; !ok {
+			deletedTuples[tk.GetObject()] = map[string]struct{}{}
 		}
-		tuples[key] = struct{}{}
+		deletedTuples[tk.GetObject()][tupleUtils.TupleKeyToString(tk)] = struct{}{}
 	}
 
-	if len(tuples) > c.datastore.MaxTuplesPerWrite() {
-		return serverErrors.ExceededEntityLimit("write operations", c.datastore.MaxTuplesPerWrite())
+	checkedObjects := map[string]struct{}{}
+	for i, tk := range writes {
+		isTupleset, err := typesys.IsTuplesetRelation(tupleUtils.GetType(tk.GetObject()), tk.GetRelation())
+		if err != nil || !isTupleset {
+			// undefined types and relations are reported by the tuple validation
+			continue
+		}
+
+		referencedObject := tk.GetUser()
+		if _, ok := writtenObjects[referencedObject]; ok {
+			continue
+		}
+		if _, ok := checkedObjects[referencedObject]; ok {
+			continue
+		}
+		checkedObjects[referencedObject] = struct{}{}
+
+		// the tuples of the object that the request deletes do not count
+		deleted := deletedTuples[referencedObject]
+		tuples, _, err := c.datastore.ReadPage(ctx, store, &openfgav1.TupleKey{Object: referencedObject}, storage.ReadPageOptions{
+			Pagination: storage.NewPaginationOptions(int32(len(deleted)+1), ""),
+		})
+		if err != nil {
+			return serverErrors.HandleError("", err)
+		}
+
+		remaining := 0
+		for _, t := range tuples {
+			if _, ok := deleted[tupleUtils.TupleKeyToString(t.GetKey())]; !ok {
+				remaining++
+			}
+		}
+
+		if remaining == 0 {
+			referentialCheckRejectionsCounter.Inc()
+			cause := fmt.Errorf("the tuple at index %d references '%s' through the tupleset relation '%s', but '%s' is not the object of any tuple",
+				i, referencedObject, tk.GetRelation(), referencedObject)
+			if len(tuples) > 0 {
+				cause = fmt.Errorf("the tuple at index %d references '%s' through the tupleset relation '%s', but the request deletes all the tuples of '%s'",
+					i, referencedObject, tk.GetRelation(), referencedObject)
+			}
+			return serverErrors.ValidationError(&tupleUtils.InvalidTupleError{
+				Cause:    cause,
+				TupleKey: tk,
+			})
+		}
 	}
+
+	span.SetAttributes(attribute.Int("referenced_objects_read", len(checkedObjects)))
 	return nil
 }
 
@@ -2,26 +2,54 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"golang.org/x/exp/maps"
 	"google.golang.org/protobuf/proto"
 
 	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/id"
 	"github.com/openfga/openfga/pkg/logger"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
 )
 
+const maxTypesHint = "Split the model across several stores, or merge types that only differ by name"
+
 // WriteAuthorizationModelCommand performs updates of the store authorization model.
 type WriteAuthorizationModelCommand struct {
 	backend                          storage.TypeDefinitionWriteBackend
 	logger                           logger.Logger
 	maxAuthorizationModelSizeInBytes int
+	idGenerator                      id.Generator
+	strictConditionParameters        bool
+	maxComplexity                    int
+	maxTypes                         int
+	maxRelationsPerType              int
+	maxRewriteDepth                  int
+	validationMaxVisits              int
+	validationTimeout                time.Duration
+	impactReader                     ModelImpactReader
+	impactStrict                     bool
+	impactMaxProbes                  int
+}
+
+// WriteAuthorizationModelDetails are the details of a model written by
+// WriteAuthorizationModelCommand.
+type WriteAuthorizationModelDetails struct {
+	// Warnings are the warnings about the model, of its unused condition parameters and of
+	// the impact analysis.
+	Warnings   []string
+	Complexity typesystem.Complexity
+	// StoredSize is the size in bytes of the model as the datastores serialize it.
+	StoredSize int
 }
 
 type WriteAuthModelOption func(*WriteAuthorizationModelCommand)
@@ -38,11 +66,91 @@ func WithWriteAuthModelMaxSizeInBytes(size int) WriteAuthModelOption {
 	}
 }
 
+// WithWriteAuthModelIDGenerator sets the generator of new authorization model IDs.
+func WithWriteAuthModelIDGenerator(g id.Generator) WriteAuthModelOption {
+	return func(m *WriteAuthorizationModelCommand) {
+		m.idGenerator = g
+	}
+}
+
+// WithWriteAuthModelStrictConditionParameters rejects models with conditions that
+// declare parameters their expression does not use, instead of reporting a warning.
+func WithWriteAuthModelStrictConditionParameters(strict bool) WriteAuthModelOption {
+	return func(m *WriteAuthorizationModelCommand) {
+		m.strictConditionParameters = strict
+	}
+}
+
+// WithWriteAuthModelMaxComplexity rejects models whose complexity score (see
+// [typesystem.TypeSystem.Complexity]) is above the given score. 0 accepts every model.
+func WithWriteAuthModelMaxComplexity(score int) WriteAuthModelOption {
+	return func(m *WriteAuthorizationModelCommand) {
+		m.maxComplexity = score
+	}
+}
+
+// WithWriteAuthModelMaxTypes rejects models with more than the given number of types. 0 accepts
+// every model.
+func WithWriteAuthModelMaxTypes(limit int) WriteAuthModelOption {
+	return func(m *WriteAuthorizationModelCommand) {
+		m.maxTypes = limit
+	}
+}
+
+// WithWriteAuthModelMaxRelationsPerType rejects models with a type of more than the given number
+// of relations. 0 accepts every model.
+func WithWriteAuthModelMaxRelationsPerType(limit int) WriteAuthModelOption {
+	return func(m *WriteAuthorizationModelCommand) {
+		m.maxRelationsPerType = limit
+	}
+}
+
+// WithWriteAuthModelMaxRewriteDepth rejects models with a relation whose rewrite tree is deeper
+// than the given depth (see [typesystem.RewriteDepth]). 0 accepts every model.
+func WithWriteAuthModelMaxRewriteDepth(limit int) WriteAuthModelOption {
+	return func(m *WriteAuthorizationModelCommand) {
+		m.maxRewriteDepth = limit
+	}
+}
+
+// WithWriteAuthModelValidationMaxVisits rejects models whose validation visits more than the
+// given number of rewrite nodes as too complex to validate (see
+// [typesystem.WithValidationMaxVisits]). 0 disables the limit. Defaults to
+// serverconfig.DefaultAuthorizationModelValidationMaxVisits.
+func WithWriteAuthModelValidationMaxVisits(maxVisits int) WriteAuthModelOption {
+	return func(m *WriteAuthorizationModelCommand) {
+		m.validationMaxVisits = maxVisits
+	}
+}
+
+// WithWriteAuthModelValidationTimeout rejects models whose validation takes longer than the
+// given time as too complex to validate (see [typesystem.WithValidationTimeout]). 0 disables the
+// limit.
+func WithWriteAuthModelValidationTimeout(timeout time.Duration) WriteAuthModelOption {
+	return func(m *WriteAuthorizationModelCommand) {
+		m.validationTimeout = timeout
+	}
+}
+
+// WithWriteAuthModelImpactAnalysis compares the model with the latest model of the store before
+// writing it, and probes the tuples of the relations it changes with at most maxProbes reads (see
+// [AnalyzeModelImpact]). The tuples it makes invalid are reported as warnings or, if strict, reject
+// the model with a FailedPrecondition error.
+func WithWriteAuthModelImpactAnalysis(reader ModelImpactReader, strict bool, maxProbes int) WriteAuthModelOption {
+	return func(m *WriteAuthorizationModelCommand) {
+		m.impactReader = reader
+		m.impactStrict = strict
+		m.impactMaxProbes = maxProbes
+	}
+}
+
 func NewWriteAuthorizationModelCommand(backend storage.TypeDefinitionWriteBackend, opts ...WriteAuthModelOption) *WriteAuthorizationModelCommand {
 	model := &WriteAuthorizationModelCommand{
 		backend:                          backend,
 		logger:                           logger.NewNoopLogger(),
 		maxAuthorizationModelSizeInBytes: serverconfig.DefaultMaxAuthorizationModelSizeInBytes,
+		idGenerator:                      id.NewULIDGenerator(),
+		validationMaxVisits:              serverconfig.DefaultAuthorizationModelValidationMaxVisits,
 	}
 
 	for _, opt := range opts {
@@ -53,44 +161,151 @@ func NewWriteAuthorizationModelCommand(backend storage.TypeDefinitionWriteBacken
 
 // Execute the command using the supplied request.
 func (w *WriteAuthorizationModelCommand) Execute(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (*openfgav1.WriteAuthorizationModelResponse, error) {
+	resp, _, err := w.ExecuteWithDetails(ctx, req)
+	return resp, err
+}
+
+// ExecuteWithDetails is like Execute, but it also returns the details of the written model.
+func (w *WriteAuthorizationModelCommand) ExecuteWithDetails(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (*openfgav1.WriteAuthorizationModelResponse, *WriteAuthorizationModelDetails, error) {
 	// Until this is solved: https://github.com/envoyproxy/protoc-gen-validate/issues/74
-	if len(req.GetTypeDefinitions()) > w.backend.MaxTypesPerAuthorizationModel() {
-		return nil, serverErrors.ExceededEntityLimit("type definitions in an authorization model", w.backend.MaxTypesPerAuthorizationModel())
+	if types, limit := len(req.GetTypeDefinitions()), w.backend.MaxTypesPerAuthorizationModel(); types > limit {
+		return nil, nil, serverErrors.AuthorizationModelLimitExceeded("types", limit, types, "", maxTypesHint)
 	}
 
+	// the size of the request as received, before the schema version is filled in
+	requestSize := proto.Size(req)
+
 	// Fill in the schema version for old requests, which don't contain it, while we migrate to the new schema version.
 	if req.GetSchemaVersion() == "" {
 		req.SchemaVersion = typesystem.SchemaVersion1_1
 	}
 
+	modelID := w.idGenerator.NewModelID()
+	if err := id.Validate(modelID); err != nil {
+		return nil, nil, serverErrors.HandleError("", err)
+	}
+
 	model := &openfgav1.AuthorizationModel{
-		Id:              ulid.Make().String(),
+		Id:              modelID,
 		SchemaVersion:   req.GetSchemaVersion(),
 		TypeDefinitions: req.GetTypeDefinitions(),
 		Conditions:      req.GetConditions(),
 	}
 
-	// Validate the size in bytes of the wire-format encoding of the authorization model.
-	modelSize := proto.Size(model)
-	if modelSize > w.maxAuthorizationModelSizeInBytes {
-		return nil, status.Error(
-			codes.Code(openfgav1.ErrorCode_exceeded_entity_limit),
-			fmt.Sprintf("model exceeds size limit: %d bytes vs %d bytes", modelSize, w.maxAuthorizationModelSizeInBytes),
-		)
+	// Validate the size in bytes of both the request and the model as the datastores serialize it,
+	// with the generated model ID and the filled in schema version, so that a request within the
+	// limit cannot store a model over it.
+	storedSize := proto.Size(model)
+	if requestSize > w.maxAuthorizationModelSizeInBytes || storedSize > w.maxAuthorizationModelSizeInBytes {
+		return nil, nil, serverErrors.AuthorizationModelSizeExceeded(requestSize, storedSize, w.maxAuthorizationModelSizeInBytes)
+	}
+
+	if err := w.validateLimits(model); err != nil {
+		return nil, nil, err
 	}
 
-	_, err := typesystem.NewAndValidate(ctx, model)
+	typesys, err := typesystem.NewAndValidate(ctx, model,
+		typesystem.WithValidationMaxVisits(w.validationMaxVisits),
+		typesystem.WithValidationTimeout(w.validationTimeout),
+	)
 	if err != nil {
-		return nil, serverErrors.InvalidAuthorizationModelInput(err)
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, nil, serverErrors.HandleError("", err)
+		}
+		return nil, nil, serverErrors.InvalidAuthorizationModelInput(err)
+	}
+
+	warnings := typesys.ConditionParameterWarnings()
+	if w.strictConditionParameters && len(warnings) > 0 {
+		return nil, nil, serverErrors.InvalidAuthorizationModelInput(errors.New(strings.Join(warnings, "; ")))
+	}
+
+	complexity := typesys.Complexity()
+	if w.maxComplexity > 0 && complexity.Score > w.maxComplexity {
+		contributors := make([]string, 0, len(complexity.TopContributors))
+		for _, contributor := range complexity.TopContributors {
+			contributors = append(contributors, contributor.String())
+		}
+		return nil, nil, serverErrors.InvalidAuthorizationModelInput(fmt.Errorf(
+			"the complexity score of the model is %d, above the limit of %d; the relations contributing the most are %s",
+			complexity.Score, w.maxComplexity, strings.Join(contributors, ", "),
+		))
+	}
+
+	if w.impactReader != nil {
+		impactWarnings, err := w.analyzeImpact(ctx, req.GetStoreId(), typesys)
+		if err != nil {
+			return nil, nil, err
+		}
+		warnings = append(warnings, impactWarnings...)
 	}
 
 	err = w.backend.WriteAuthorizationModel(ctx, req.GetStoreId(), model)
 	if err != nil {
-		return nil, serverErrors.
+		return nil, nil, serverErrors.
 			HandleError("Error writing authorization model configuration", err)
 	}
 
 	return &openfgav1.WriteAuthorizationModelResponse{
 		AuthorizationModelId: model.GetId(),
+	}, &WriteAuthorizationModelDetails{
+		Warnings:   warnings,
+		Complexity: complexity,
+		StoredSize: storedSize,
 	}, nil
 }
+
+// analyzeImpact returns the warnings about the tuples of the store that the model makes invalid,
+// or an error if the analysis is strict and finds any.
+func (w *WriteAuthorizationModelCommand) analyzeImpact(ctx context.Context, storeID string, typesys *typesystem.TypeSystem) ([]string, error) {
+	impact, err := AnalyzeModelImpact(ctx, w.impactReader, storeID, typesys, w.impactMaxProbes)
+	if err != nil {
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	findings := make([]string, 0, len(impact.Findings))
+	for _, finding := range impact.Findings {
+		findings = append(findings, finding.String())
+	}
+	if w.impactStrict && len(findings) > 0 {
+		return nil, serverErrors.AuthorizationModelInvalidatesTuples(findings)
+	}
+
+	if len(impact.Unprobed) > 0 {
+		findings = append(findings, fmt.Sprintf("the impact analysis ran out of probes and did not check the tuples of %s", strings.Join(impact.Unprobed, ", ")))
+	}
+	return findings, nil
+}
+
+// validateLimits returns an error naming the first type or relation of the model, in the order
+// of the model, that exceeds the limits on the number of types, of relations per type and on
+// the depth of the rewrites.
+func (w *WriteAuthorizationModelCommand) validateLimits(model *openfgav1.AuthorizationModel) error {
+	typeDefinitions := model.GetTypeDefinitions()
+	if w.maxTypes > 0 && len(typeDefinitions) > w.maxTypes {
+		return serverErrors.AuthorizationModelLimitExceeded("types", w.maxTypes, len(typeDefinitions), "", maxTypesHint)
+	}
+
+	for _, typeDefinition := range typeDefinitions {
+		relations := typeDefinition.GetRelations()
+		if w.maxRelationsPerType > 0 && len(relations) > w.maxRelationsPerType {
+			return serverErrors.AuthorizationModelLimitExceeded("relations", w.maxRelationsPerType, len(relations), typeDefinition.GetType(),
+				"Move some of its relations to a new type related to it, e.g. through a tuple to userset")
+		}
+
+		if w.maxRewriteDepth == 0 {
+			continue
+		}
+		names := maps.Keys(relations)
+		sort.Strings(names)
+		for _, relation := range names {
+			if depth := typesystem.RewriteDepth(relations[relation]); depth > w.maxRewriteDepth {
+				return serverErrors.AuthorizationModelLimitExceeded("levels of nested rewrites", w.maxRewriteDepth, depth,
+					tuple.ToObjectRelationString(typeDefinition.GetType(), relation),
+					"Define some of its nested operands as relations of their own, and refer to them by name")
+			}
+		}
+	}
+
+	return nil
+}
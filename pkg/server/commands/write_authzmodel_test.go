@@ -2,15 +2,27 @@ package commands
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 	"go.uber.org/mock/gomock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	mockstorage "github.com/openfga/openfga/internal/mocks"
+	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
 )
 
@@ -57,3 +69,482 @@ func TestWriteAuthorizationModel(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteAuthorizationModelConditionParameterWarnings(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user with in_region]
+
+		condition in_region(region: string, leftover: string) {
+			region == "eu"
+		}`)
+	req := &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+		Conditions:      model.GetConditions(),
+	}
+
+	t.Run("warns_about_unused_parameters", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(100)
+		mockDatastore.EXPECT().WriteAuthorizationModel(gomock.Any(), storeID, gomock.Any()).Return(nil)
+
+		cmd := NewWriteAuthorizationModelCommand(mockDatastore)
+		_, details, err := cmd.ExecuteWithDetails(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, []string{"condition 'in_region' declares parameter 'leftover' which its expression does not use"}, details.Warnings)
+	})
+
+	t.Run("strict_mode_rejects_unused_parameters", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(100)
+
+		cmd := NewWriteAuthorizationModelCommand(mockDatastore, WithWriteAuthModelStrictConditionParameters(true))
+		_, err := cmd.Execute(ctx, req)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_authorization_model), status.Code(err))
+		require.ErrorContains(t, err, "condition 'in_region' declares parameter 'leftover' which its expression does not use")
+	})
+
+	t.Run("undeclared_parameters_are_rejected", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(100)
+
+		undeclared := proto.Clone(req).(*openfgav1.WriteAuthorizationModelRequest)
+		undeclared.GetConditions()["in_region"].Expression = "region == other"
+
+		cmd := NewWriteAuthorizationModelCommand(mockDatastore)
+		_, err := cmd.Execute(ctx, undeclared)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_authorization_model), status.Code(err))
+		require.ErrorContains(t, err, "undeclared reference to 'other'")
+	})
+}
+
+func TestWriteAuthorizationModelMaxComplexity(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	// The score is 3 types + folder#viewer 1 + parent 1 + owner 1 + viewer (2 + 2*1 + 2*3) = 16.
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type folder
+			relations
+				define viewer: [user]
+		type document
+			relations
+				define parent: [folder]
+				define owner: [user]
+				define viewer: [user] or viewer from parent`)
+	req := &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	}
+
+	t.Run("accepted_at_the_limit", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(100)
+		mockDatastore.EXPECT().WriteAuthorizationModel(gomock.Any(), storeID, gomock.Any()).Return(nil)
+
+		cmd := NewWriteAuthorizationModelCommand(mockDatastore, WithWriteAuthModelMaxComplexity(16))
+		_, details, err := cmd.ExecuteWithDetails(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, 16, details.Complexity.Score)
+		require.Equal(t, 1, details.Complexity.TupleToUsersets)
+	})
+
+	t.Run("rejected_above_the_limit", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(100)
+
+		cmd := NewWriteAuthorizationModelCommand(mockDatastore, WithWriteAuthModelMaxComplexity(15))
+		_, err := cmd.Execute(ctx, req)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_authorization_model), status.Code(err))
+		require.ErrorContains(t, err, "the complexity score of the model is 16, above the limit of 15; the relations contributing the most are document#viewer (10), document#owner (1), document#parent (1), folder#viewer (1)")
+	})
+}
+
+// limitsTestModel returns a model of the given number of types, each of the given number of
+// relations, whose first relation 'r0' has a rewrite of the given depth.
+func limitsTestModel(storeID string, types, relations, depth int) *openfgav1.WriteAuthorizationModelRequest {
+	req := &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+		TypeDefinitions: []*openfgav1.TypeDefinition{{Type: "user"}},
+	}
+
+	rewrite := typesystem.This()
+	for i := 1; i < depth; i++ {
+		rewrite = typesystem.Union(typesystem.ComputedUserset("r1"), rewrite)
+	}
+
+	for i := 1; i < types; i++ {
+		typeDefinition := &openfgav1.TypeDefinition{
+			Type:      fmt.Sprintf("type%d", i-1),
+			Relations: map[string]*openfgav1.Userset{},
+			Metadata:  &openfgav1.Metadata{Relations: map[string]*openfgav1.RelationMetadata{}},
+		}
+		for j := 0; j < relations; j++ {
+			relation := fmt.Sprintf("r%d", j)
+			typeDefinition.Relations[relation] = typesystem.This()
+			if j == 0 {
+				typeDefinition.Relations[relation] = rewrite
+			}
+			typeDefinition.Metadata.Relations[relation] = &openfgav1.RelationMetadata{
+				DirectlyRelatedUserTypes: []*openfgav1.RelationReference{typesystem.DirectRelationReference("user", "")},
+			}
+		}
+		req.TypeDefinitions = append(req.TypeDefinitions, typeDefinition)
+	}
+	return req
+}
+
+func TestWriteAuthorizationModelLimits(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	limits := []WriteAuthModelOption{
+		WithWriteAuthModelMaxTypes(3),
+		WithWriteAuthModelMaxRelationsPerType(3),
+		WithWriteAuthModelMaxRewriteDepth(3),
+	}
+
+	t.Run("accepted_at_the_limits", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(100)
+		mockDatastore.EXPECT().WriteAuthorizationModel(gomock.Any(), storeID, gomock.Any()).Return(nil)
+
+		_, err := NewWriteAuthorizationModelCommand(mockDatastore, limits...).Execute(ctx, limitsTestModel(storeID, 3, 3, 3))
+		require.NoError(t, err)
+	})
+
+	tests := map[string]struct {
+		req              *openfgav1.WriteAuthorizationModelRequest
+		maxTypes         int
+		expectedMessage  string
+		expectedMetadata map[string]string
+	}{
+		`types`: {
+			req:              limitsTestModel(storeID, 4, 3, 3),
+			maxTypes:         100,
+			expectedMessage:  "the authorization model has 4 types, above the limit of 3. Split the model across several stores, or merge types that only differ by name",
+			expectedMetadata: map[string]string{"entity": "types", "limit": "3", "value": "4"},
+		},
+		`types_of_the_datastore`: {
+			req:              limitsTestModel(storeID, 3, 1, 1),
+			maxTypes:         2,
+			expectedMessage:  "the authorization model has 3 types, above the limit of 2. Split the model across several stores, or merge types that only differ by name",
+			expectedMetadata: map[string]string{"entity": "types", "limit": "2", "value": "3"},
+		},
+		`relations_per_type`: {
+			req:              limitsTestModel(storeID, 3, 4, 3),
+			maxTypes:         100,
+			expectedMessage:  "'type0' has 4 relations, above the limit of 3. Move some of its relations to a new type related to it, e.g. through a tuple to userset",
+			expectedMetadata: map[string]string{"entity": "relations", "limit": "3", "value": "4", "offender": "type0"},
+		},
+		`rewrite_depth`: {
+			req:              limitsTestModel(storeID, 3, 3, 4),
+			maxTypes:         100,
+			expectedMessage:  "'type0#r0' has 4 levels of nested rewrites, above the limit of 3. Define some of its nested operands as relations of their own, and refer to them by name",
+			expectedMetadata: map[string]string{"entity": "levels of nested rewrites", "limit": "3", "value": "4", "offender": "type0#r0"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			defer mockController.Finish()
+
+			mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+			mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(test.maxTypes)
+
+			_, err := NewWriteAuthorizationModelCommand(mockDatastore, limits...).Execute(ctx, test.req)
+			st := status.Convert(err)
+			require.Equal(t, codes.Code(openfgav1.ErrorCode_exceeded_entity_limit), st.Code())
+			require.Equal(t, test.expectedMessage, st.Message())
+
+			require.Len(t, st.Details(), 1)
+			info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			require.Equal(t, "AUTHORIZATION_MODEL_LIMIT_EXCEEDED", info.GetReason())
+			require.Equal(t, test.expectedMetadata, info.GetMetadata())
+		})
+	}
+
+	t.Run("no_limits", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(100)
+		mockDatastore.EXPECT().WriteAuthorizationModel(gomock.Any(), storeID, gomock.Any()).Return(nil)
+
+		_, err := NewWriteAuthorizationModelCommand(mockDatastore).Execute(ctx, limitsTestModel(storeID, 10, 10, 10))
+		require.NoError(t, err)
+	})
+}
+
+func TestWriteAuthorizationModelSize(t *testing.T) {
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	// without a schema version, the model as stored is larger than the request, as the schema
+	// version is filled in
+	newRequest := func() *openfgav1.WriteAuthorizationModelRequest {
+		return &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			TypeDefinitions: []*openfgav1.TypeDefinition{{Type: "user"}, {Type: "document"}},
+		}
+	}
+	requestSize := proto.Size(newRequest())
+	storedSize := requestSize + proto.Size(&openfgav1.AuthorizationModel{SchemaVersion: typesystem.SchemaVersion1_1})
+
+	t.Run("request_within_the_limit_stored_over_it", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(100)
+
+		_, err := NewWriteAuthorizationModelCommand(mockDatastore, WithWriteAuthModelMaxSizeInBytes(requestSize)).Execute(ctx, newRequest())
+		st := status.Convert(err)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_exceeded_entity_limit), st.Code())
+		require.Equal(t, fmt.Sprintf("the authorization model is %d bytes in the request and %d bytes as stored, above the limit of %d bytes. "+
+			"Split the model across several stores, or raise the limit with the max-authorization-model-size-in-bytes setting", requestSize, storedSize, requestSize), st.Message())
+
+		require.Len(t, st.Details(), 1)
+		info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		require.Equal(t, "AUTHORIZATION_MODEL_SIZE_EXCEEDED", info.GetReason())
+		require.Equal(t, map[string]string{
+			"request_size": fmt.Sprint(requestSize),
+			"stored_size":  fmt.Sprint(storedSize),
+			"limit":        fmt.Sprint(requestSize),
+		}, info.GetMetadata())
+	})
+
+	t.Run("stored_within_the_limit", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		var written *openfgav1.AuthorizationModel
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(100)
+		mockDatastore.EXPECT().WriteAuthorizationModel(gomock.Any(), storeID, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, model *openfgav1.AuthorizationModel) error {
+				written = model
+				return nil
+			})
+
+		cmd := NewWriteAuthorizationModelCommand(mockDatastore, WithWriteAuthModelMaxSizeInBytes(storedSize))
+		_, details, err := cmd.ExecuteWithDetails(ctx, newRequest())
+		require.NoError(t, err)
+
+		serialized, err := proto.Marshal(written)
+		require.NoError(t, err)
+		require.Equal(t, storedSize, details.StoredSize)
+		require.Len(t, serialized, details.StoredSize)
+	})
+}
+
+func TestWriteAuthorizationModelTooComplexToValidate(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	// each relation rewrites to the next one twice, so that the validation walks 2^24 paths
+	var dsl strings.Builder
+	dsl.WriteString("model\n  schema 1.1\ntype user\ntype document\n  relations\n")
+	for i := 0; i < 24; i++ {
+		fmt.Fprintf(&dsl, "    define r%d: r%d or r%d\n", i, i+1, i+1)
+	}
+	dsl.WriteString("    define r24: [user]\n")
+	model := testutils.MustTransformDSLToProtoWithID(dsl.String())
+
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(100)
+
+	write := func(opts ...WriteAuthModelOption) error {
+		_, err := NewWriteAuthorizationModelCommand(mockDatastore, opts...).
+			Execute(context.Background(), &openfgav1.WriteAuthorizationModelRequest{
+				StoreId:         ulid.Make().String(),
+				SchemaVersion:   model.GetSchemaVersion(),
+				TypeDefinitions: model.GetTypeDefinitions(),
+			})
+		return err
+	}
+
+	t.Run("default_max_visits", func(t *testing.T) {
+		start := time.Now()
+		err := write()
+		require.Less(t, time.Since(start), 5*time.Second)
+
+		st := status.Convert(err)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_authorization_model), st.Code())
+		require.Contains(t, st.Message(), typesystem.ErrModelTooComplex.Error())
+		require.Contains(t, st.Message(), fmt.Sprintf("more than %d rewrite nodes", serverconfig.DefaultAuthorizationModelValidationMaxVisits))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		start := time.Now()
+		err := write(WithWriteAuthModelValidationMaxVisits(0), WithWriteAuthModelValidationTimeout(50*time.Millisecond))
+		require.Less(t, time.Since(start), 5*time.Second)
+
+		st := status.Convert(err)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_authorization_model), st.Code())
+		require.Contains(t, st.Message(), "its validation took longer than 50ms")
+	})
+}
+
+func TestWriteAuthorizationModelImpactAnalysis(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+	latest := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type group
+			relations
+				define member: [user]
+		type folder
+			relations
+				define viewer: [user]
+		type document
+			relations
+				define owner: [user]
+				define editor: [user, group#member]
+				define viewer: [user, user:*]
+				define archived: [user]`)
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, latest))
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "owner", "user:anne"),
+		tuple.NewTupleKey("document:1", "editor", "group:eng#member"),
+		tuple.NewTupleKey("document:1", "viewer", "user:*"),
+	}))
+
+	// removes folder, owner (with tuples) and archived (without tuples), retypes editor and viewer
+	request := func() *openfgav1.WriteAuthorizationModelRequest {
+		model := testutils.MustTransformDSLToProtoWithID(`
+			model
+				schema 1.1
+			type user
+			type group
+				relations
+					define member: [user]
+			type document
+				relations
+					define owner: editor
+					define editor: [user]
+					define viewer: [user]`)
+		return &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		}
+	}
+
+	t.Run("warnings", func(t *testing.T) {
+		cmd := NewWriteAuthorizationModelCommand(ds, WithWriteAuthModelImpactAnalysis(ds, false, 10))
+		_, details, err := cmd.ExecuteWithDetails(ctx, request())
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"document#editor: the relation no longer allows 'group#member', but the store has tuples of it, e.g. 'document:1#editor@group:eng#member'",
+			"document#owner: the relation is no longer directly assignable, but the store has tuples of it, e.g. 'document:1#owner@user:anne'",
+			"document#viewer: the relation no longer allows 'user:*', but the store has tuples of it, e.g. 'document:1#viewer@user:*'",
+			"folder#viewer: the type was removed, but the store has tuples of it, e.g. 'folder:1#viewer@user:anne'",
+		}, details.Warnings)
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		// the previous test wrote the new model to the first store
+		otherStoreID := ulid.Make().String()
+		require.NoError(t, ds.WriteAuthorizationModel(ctx, otherStoreID, latest))
+		require.NoError(t, ds.Write(ctx, otherStoreID, nil, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+		}))
+
+		req := request()
+		req.StoreId = otherStoreID
+		cmd := NewWriteAuthorizationModelCommand(ds, WithWriteAuthModelImpactAnalysis(ds, true, 10))
+		_, err := cmd.Execute(ctx, req)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+		require.ErrorContains(t, err, "folder#viewer: the type was removed, but the store has tuples of it, e.g. 'folder:1#viewer@user:anne'")
+
+		models, _, err := ds.ReadAuthorizationModels(ctx, otherStoreID, storage.ReadAuthorizationModelsOptions{})
+		require.NoError(t, err)
+		require.Len(t, models, 1)
+	})
+
+	t.Run("probe_budget", func(t *testing.T) {
+		otherStoreID := ulid.Make().String()
+		require.NoError(t, ds.WriteAuthorizationModel(ctx, otherStoreID, latest))
+
+		req := request()
+		req.StoreId = otherStoreID
+		cmd := NewWriteAuthorizationModelCommand(ds, WithWriteAuthModelImpactAnalysis(ds, true, 2))
+		_, details, err := cmd.ExecuteWithDetails(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"the impact analysis ran out of probes and did not check the tuples of document#owner, document#viewer, folder#viewer",
+		}, details.Warnings)
+	})
+
+	t.Run("store_without_model", func(t *testing.T) {
+		req := request()
+		req.StoreId = ulid.Make().String()
+		cmd := NewWriteAuthorizationModelCommand(ds, WithWriteAuthModelImpactAnalysis(ds, true, 10))
+		_, details, err := cmd.ExecuteWithDetails(ctx, req)
+		require.NoError(t, err)
+		require.Empty(t, details.Warnings)
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	parser "github.com/openfga/language/pkg/go/transformer"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	"google.golang.org/grpc/codes"
@@ -18,6 +19,8 @@ import (
 	"github.com/openfga/openfga/internal/server/config"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	storagetest "github.com/openfga/openfga/pkg/storage/test"
 	"github.com/openfga/openfga/pkg/testutils"
 	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
@@ -67,7 +70,7 @@ func TestValidateNoDuplicatesAndCorrectSize(t *testing.T) {
 			name:          "duplicate_deletes",
 			deletes:       []*openfgav1.TupleKeyWithoutCondition{items[0], items[1], items[0]},
 			writes:        []*openfgav1.TupleKey{},
-			expectedError: serverErrors.DuplicateTupleInWrite(items[0]),
+			expectedError: serverErrors.DuplicateTupleInDeletes(items[0], 0, 2),
 		},
 		{
 			name:    "duplicate_writes",
@@ -77,7 +80,7 @@ func TestValidateNoDuplicatesAndCorrectSize(t *testing.T) {
 				tuple.TupleKeyWithoutConditionToTupleKey(items[1]),
 				tuple.TupleKeyWithoutConditionToTupleKey(items[0]),
 			},
-			expectedError: serverErrors.DuplicateTupleInWrite(items[0]),
+			expectedError: serverErrors.DuplicateTupleInWrites(items[0], 0, 2),
 		},
 		{
 			name:    "same_item_appeared_in_writes_and_deletes",
@@ -86,7 +89,7 @@ func TestValidateNoDuplicatesAndCorrectSize(t *testing.T) {
 				tuple.TupleKeyWithoutConditionToTupleKey(items[0]),
 				tuple.TupleKeyWithoutConditionToTupleKey(items[1]),
 			},
-			expectedError: serverErrors.DuplicateTupleInWrite(items[1]),
+			expectedError: serverErrors.TupleInWritesAndDeletes(items[1], 1, 1),
 		},
 		{
 			name:          "too_many_items_writes_and_deletes",
@@ -268,7 +271,8 @@ func TestTransactionalWriteFailedError(t *testing.T) {
 		},
 	})
 
-	require.ErrorIs(t, err, status.Error(codes.Aborted, storage.ErrTransactionalWriteFailed.Error()))
+	require.ErrorIs(t, err, serverErrors.HandleError("", storage.ErrTransactionalWriteFailed))
+	require.Equal(t, codes.Aborted, status.Code(err))
 	require.Nil(t, resp)
 }
 
@@ -531,3 +535,370 @@ func TestValidateConditionsInTuples(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateConditionContextTypes(t *testing.T) {
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user with int_cond, user with list_cond, user with map_cond, user with timestamp_cond, user with duration_cond]
+
+		condition int_cond(x: int) {
+			x < 100
+		}
+		condition list_cond(xs: list<string>) {
+			"a" in xs
+		}
+		condition map_cond(m: map<int>) {
+			m["a"] == 1
+		}
+		condition timestamp_cond(ts: timestamp) {
+			ts < timestamp("2030-01-01T00:00:00Z")
+		}
+		condition duration_cond(d: duration) {
+			d < duration("1h")
+		}`)
+
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().MaxTuplesPerWrite().AnyTimes().Return(10)
+	mockDatastore.EXPECT().
+		ReadAuthorizationModel(gomock.Any(), gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(model, nil)
+
+	cmd := NewWriteCommand(mockDatastore)
+
+	tests := []struct {
+		name          string
+		condition     string
+		context       map[string]interface{}
+		expectedError string
+	}{
+		{
+			name:      "valid_int",
+			condition: "int_cond",
+			context:   map[string]interface{}{"x": 10},
+		},
+		{
+			name:      "valid_int_from_numeric_string",
+			condition: "int_cond",
+			context:   map[string]interface{}{"x": "10"},
+		},
+		{
+			name:          "invalid_int_from_string",
+			condition:     "int_cond",
+			context:       map[string]interface{}{"x": "ten"},
+			expectedError: "context parameter 'x' of the tuple at index 1 expects type 'int', but found 'string'",
+		},
+		{
+			name:          "invalid_int_from_bool",
+			condition:     "int_cond",
+			context:       map[string]interface{}{"x": true},
+			expectedError: "context parameter 'x' of the tuple at index 1 expects type 'int', but found 'bool'",
+		},
+		{
+			name:      "valid_list",
+			condition: "list_cond",
+			context:   map[string]interface{}{"xs": []interface{}{"a", "b"}},
+		},
+		{
+			name:          "invalid_list_from_string",
+			condition:     "list_cond",
+			context:       map[string]interface{}{"xs": "a"},
+			expectedError: "context parameter 'xs' of the tuple at index 1 expects type 'list<string>', but found 'string'",
+		},
+		{
+			name:          "invalid_list_item",
+			condition:     "list_cond",
+			context:       map[string]interface{}{"xs": []interface{}{"a", 1}},
+			expectedError: "context parameter 'xs' of the tuple at index 1 expects type 'list<string>', but found 'list'",
+		},
+		{
+			name:      "valid_map",
+			condition: "map_cond",
+			context:   map[string]interface{}{"m": map[string]interface{}{"a": 1}},
+		},
+		{
+			name:          "invalid_map_from_list",
+			condition:     "map_cond",
+			context:       map[string]interface{}{"m": []interface{}{1}},
+			expectedError: "context parameter 'm' of the tuple at index 1 expects type 'map<int>', but found 'list'",
+		},
+		{
+			name:          "invalid_map_value",
+			condition:     "map_cond",
+			context:       map[string]interface{}{"m": map[string]interface{}{"a": "one"}},
+			expectedError: "context parameter 'm' of the tuple at index 1 expects type 'map<int>', but found 'map'",
+		},
+		{
+			name:      "valid_timestamp",
+			condition: "timestamp_cond",
+			context:   map[string]interface{}{"ts": "2024-01-01T00:00:00Z"},
+		},
+		{
+			name:          "invalid_timestamp_format",
+			condition:     "timestamp_cond",
+			context:       map[string]interface{}{"ts": "2024-01-01"},
+			expectedError: "context parameter 'ts' of the tuple at index 1 expects type 'timestamp', but found 'string'",
+		},
+		{
+			name:          "invalid_timestamp_from_number",
+			condition:     "timestamp_cond",
+			context:       map[string]interface{}{"ts": 1704067200},
+			expectedError: "context parameter 'ts' of the tuple at index 1 expects type 'timestamp', but found 'number'",
+		},
+		{
+			name:      "valid_duration",
+			condition: "duration_cond",
+			context:   map[string]interface{}{"d": "10m"},
+		},
+		{
+			name:          "invalid_duration_from_number",
+			condition:     "duration_cond",
+			context:       map[string]interface{}{"d": 600},
+			expectedError: "context parameter 'd' of the tuple at index 1 expects type 'duration', but found 'number'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := cmd.validateWriteRequest(context.Background(), &openfgav1.WriteRequest{
+				StoreId:              ulid.Make().String(),
+				AuthorizationModelId: model.GetId(),
+				Writes: &openfgav1.WriteRequestWrites{
+					TupleKeys: []*openfgav1.TupleKey{
+						tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:jon", "int_cond", testutils.MustNewStruct(t, map[string]interface{}{"x": 1})),
+						tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:maria", test.condition, testutils.MustNewStruct(t, test.context)),
+					},
+				},
+			})
+
+			if test.expectedError != "" {
+				require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+				require.ErrorContains(t, err, test.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWriteReferentialCheck(t *testing.T) {
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID, model := storagetest.BootstrapFGAStore(t, ds, `
+		model
+			schema 1.1
+		type user
+		type folder
+			relations
+				define viewer: [user]
+		type document
+			relations
+				define parent: [folder]
+				define viewer: [user] or viewer from parent`, []string{
+		"folder:existing#viewer@user:anne",
+		"folder:shared#viewer@user:anne",
+		"folder:shared#viewer@user:bob",
+		"folder:single#viewer@user:anne",
+	})
+
+	tests := []struct {
+		name          string
+		enabled       bool
+		deletes       []*openfgav1.TupleKeyWithoutCondition
+		writes        []*openfgav1.TupleKey
+		expectedError string
+	}{
+		{
+			name:    "disabled_allows_dangling_reference",
+			enabled: false,
+			writes:  []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "parent", "folder:missing")},
+		},
+		{
+			name:    "existing_reference",
+			enabled: true,
+			writes:  []*openfgav1.TupleKey{tuple.NewTupleKey("document:2", "parent", "folder:existing")},
+		},
+		{
+			name:    "reference_written_in_same_request",
+			enabled: true,
+			writes: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:3", "parent", "folder:new"),
+				tuple.NewTupleKey("folder:new", "viewer", "user:anne"),
+			},
+		},
+		{
+			name:    "non_tupleset_relations_are_not_checked",
+			enabled: true,
+			writes:  []*openfgav1.TupleKey{tuple.NewTupleKey("document:4", "viewer", "user:missing")},
+		},
+		{
+			name:    "dangling_reference",
+			enabled: true,
+			writes: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:5", "viewer", "user:anne"),
+				tuple.NewTupleKey("document:5", "parent", "folder:missing"),
+			},
+			expectedError: "the tuple at index 1 references 'folder:missing' through the tupleset relation 'parent', but 'folder:missing' is not the object of any tuple",
+		},
+		{
+			name:    "reference_with_some_tuples_deleted_in_same_request",
+			enabled: true,
+			deletes: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("folder:shared", "viewer", "user:anne"))},
+			writes:  []*openfgav1.TupleKey{tuple.NewTupleKey("document:6", "parent", "folder:shared")},
+		},
+		{
+			name:          "reference_with_all_tuples_deleted_in_same_request",
+			enabled:       true,
+			deletes:       []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("folder:single", "viewer", "user:anne"))},
+			writes:        []*openfgav1.TupleKey{tuple.NewTupleKey("document:7", "parent", "folder:single")},
+			expectedError: "the tuple at index 0 references 'folder:single' through the tupleset relation 'parent', but the request deletes all the tuples of 'folder:single'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			initialRejections := promtestutil.ToFloat64(referentialCheckRejectionsCounter)
+
+			cmd := NewWriteCommand(ds, WithReferentialCheck(test.enabled))
+			req := &openfgav1.WriteRequest{
+				StoreId:              storeID,
+				AuthorizationModelId: model.GetId(),
+				Writes:               &openfgav1.WriteRequestWrites{TupleKeys: test.writes},
+			}
+			if len(test.deletes) > 0 {
+				req.Deletes = &openfgav1.WriteRequestDeletes{TupleKeys: test.deletes}
+			}
+			_, err := cmd.Execute(ctx, req)
+
+			if test.expectedError != "" {
+				require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+				require.ErrorContains(t, err, test.expectedError)
+				require.InDelta(t, initialRejections+1, promtestutil.ToFloat64(referentialCheckRejectionsCounter), 0)
+			} else {
+				require.NoError(t, err)
+				require.InDelta(t, initialRejections, promtestutil.ToFloat64(referentialCheckRejectionsCounter), 0)
+			}
+		})
+	}
+}
+
+func TestWriteTupleQuota(t *testing.T) {
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+	counter := ds.(storage.TupleCounter)
+
+	tests := []struct {
+		name          string
+		quota         TupleQuota
+		deletes       []*openfgav1.TupleKeyWithoutCondition
+		writes        []*openfgav1.TupleKey
+		expectedError string
+	}{
+		{
+			name:   "no_quota",
+			writes: []*openfgav1.TupleKey{tuple.NewTupleKey("document:3", "viewer", "user:anne")},
+		},
+		{
+			name:   "under_store_quota",
+			quota:  TupleQuota{MaxTuples: 4},
+			writes: []*openfgav1.TupleKey{tuple.NewTupleKey("document:3", "viewer", "user:anne")},
+		},
+		{
+			name:  "over_store_quota",
+			quota: TupleQuota{MaxTuples: 4},
+			writes: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:3", "viewer", "user:anne"),
+				tuple.NewTupleKey("folder:2", "viewer", "user:anne"),
+			},
+			expectedError: "the write would exceed the quota of 4 tuples of the store, which has 3 tuples",
+		},
+		{
+			name:    "replacing_tuples_at_store_quota",
+			quota:   TupleQuota{MaxTuples: 3},
+			deletes: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("document:1", "viewer", "user:anne"))},
+			writes:  []*openfgav1.TupleKey{tuple.NewTupleKey("document:3", "viewer", "user:anne")},
+		},
+		{
+			name:    "deleting_tuples_over_store_quota",
+			quota:   TupleQuota{MaxTuples: 1},
+			deletes: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("document:1", "viewer", "user:anne"))},
+		},
+		{
+			name:          "over_object_type_quota",
+			quota:         TupleQuota{MaxTuplesPerObjectType: 2},
+			writes:        []*openfgav1.TupleKey{tuple.NewTupleKey("document:3", "viewer", "user:anne")},
+			expectedError: "the write would exceed the quota of 2 tuples of the object type 'document', which has 2 tuples",
+		},
+		{
+			name:   "under_object_type_quota",
+			quota:  TupleQuota{MaxTuplesPerObjectType: 2},
+			writes: []*openfgav1.TupleKey{tuple.NewTupleKey("folder:2", "viewer", "user:anne")},
+		},
+		{
+			name:   "object_type_quota_override",
+			quota:  TupleQuota{MaxTuplesPerObjectType: 2, MaxTuplesByObjectType: map[string]int64{"document": 3}},
+			writes: []*openfgav1.TupleKey{tuple.NewTupleKey("document:3", "viewer", "user:anne")},
+		},
+		{
+			name:          "over_object_type_quota_override",
+			quota:         TupleQuota{MaxTuplesPerObjectType: 2, MaxTuplesByObjectType: map[string]int64{"folder": 1}},
+			writes:        []*openfgav1.TupleKey{tuple.NewTupleKey("folder:2", "viewer", "user:anne")},
+			expectedError: "the write would exceed the quota of 1 tuples of the object type 'folder', which has 1 tuples",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storeID, model := storagetest.BootstrapFGAStore(t, ds, `
+				model
+					schema 1.1
+				type user
+				type folder
+					relations
+						define viewer: [user]
+				type document
+					relations
+						define viewer: [user]`, []string{
+				"document:1#viewer@user:anne",
+				"document:2#viewer@user:anne",
+				"folder:1#viewer@user:anne",
+			})
+
+			req := &openfgav1.WriteRequest{
+				StoreId:              storeID,
+				AuthorizationModelId: model.GetId(),
+			}
+			if len(test.writes) > 0 {
+				req.Writes = &openfgav1.WriteRequestWrites{TupleKeys: test.writes}
+			}
+			if len(test.deletes) > 0 {
+				req.Deletes = &openfgav1.WriteRequestDeletes{TupleKeys: test.deletes}
+			}
+
+			_, err := NewWriteCommand(ds, WithTupleQuota(counter, test.quota)).Execute(ctx, req)
+			if test.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Equal(t, codes.ResourceExhausted, status.Code(err))
+			require.ErrorContains(t, err, test.expectedError)
+
+			counts, err := counter.ReadTupleCounts(ctx, storeID)
+			require.NoError(t, err)
+			require.Equal(t, int64(3), counts.Total)
+		})
+	}
+}
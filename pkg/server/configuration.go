@@ -0,0 +1,322 @@
+package server
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+// DispatchThrottlingConfiguration describes the effective dispatch throttling settings of one API.
+type DispatchThrottlingConfiguration struct {
+	Enabled          bool          `json:"enabled"`
+	Frequency        time.Duration `json:"frequency"`
+	DefaultThreshold uint32        `json:"default_threshold"`
+	// MaxThreshold is the effective maximum threshold. If no maximum was configured, it is the default threshold.
+	MaxThreshold uint32 `json:"max_threshold"`
+}
+
+// Configuration is a snapshot of the non-secret runtime configuration of a Server,
+// as assembled by NewServerWithOpts.
+//
+// Only plain values are copied into this struct. It must never reference the datastore,
+// the encoder or any other component that may hold credentials or keys.
+type Configuration struct {
+	ResolveNodeLimit                 uint32        `json:"resolve_node_limit"`
+	ResolveNodeBreadthLimit          uint32        `json:"resolve_node_breadth_limit"`
+	ResolveNodeLimitShadow           uint32        `json:"resolve_node_limit_shadow"`
+	ResolveNodeBreadthLimitShadow    uint32        `json:"resolve_node_breadth_limit_shadow"`
+	ResolverWorkerPoolEnabled        bool          `json:"resolver_worker_pool_enabled"`
+	ResolverWorkerPoolSize           int           `json:"resolver_worker_pool_size"`
+	UsersetBatchSize                 uint32        `json:"userset_batch_size"`
+	ChangelogHorizonOffset           int           `json:"changelog_horizon_offset"`
+	ListObjectsDeadline              time.Duration `json:"list_objects_deadline"`
+	ListObjectsMaxResults            uint32        `json:"list_objects_max_results"`
+	ListObjectsMaxCandidates         uint32        `json:"list_objects_max_candidates"`
+	ListObjectsStrategy              string        `json:"list_objects_strategy"`
+	ListObjectsStreamMaxModelLag     uint32        `json:"list_objects_stream_max_model_lag"`
+	ListObjectsStreamCheckInterval   time.Duration `json:"list_objects_stream_check_interval"`
+	MaxStreamDuration                time.Duration `json:"max_stream_duration"`
+	ExpandMaxLeafUsers               uint32        `json:"expand_max_leaf_users"`
+	ExpandMaxNodes                   uint32        `json:"expand_max_nodes"`
+	ExpandStrictResultLimit          bool          `json:"expand_strict_result_limit"`
+	RelationStatisticsMaxSampleSize  uint32        `json:"relation_statistics_max_sample_size"`
+	RelationStatisticsDeadline       time.Duration `json:"relation_statistics_deadline"`
+	RelationUsageDeadline            time.Duration `json:"relation_usage_deadline"`
+	DeleteTuplesByFilterMaxTuples    uint32        `json:"delete_tuples_by_filter_max_tuples"`
+	DeleteTuplesByFilterMaxScanned   uint32        `json:"delete_tuples_by_filter_max_scanned"`
+	PointInTimeCheckHorizon          time.Duration `json:"point_in_time_check_horizon"`
+	PointInTimeCheckMaxChanges       uint32        `json:"point_in_time_check_max_changes"`
+	ErrorReasonDetails               bool          `json:"error_reason_details"`
+	ListUsersDeadline                time.Duration `json:"list_users_deadline"`
+	ListUsersMaxResults              uint32        `json:"list_users_max_results"`
+	MaxConcurrentReadsForCheck       uint32        `json:"max_concurrent_reads_for_check"`
+	SnapshotReadsForCheck            bool          `json:"snapshot_reads_for_check"`
+	MaxChecksPerBatchCheck           uint32        `json:"max_checks_per_batch_check"`
+	MaxConcurrentReadsForListObjects uint32        `json:"max_concurrent_reads_for_list_objects"`
+	MaxConcurrentReadsForListUsers   uint32        `json:"max_concurrent_reads_for_list_users"`
+	MaxConcurrentReadsForExpand      uint32        `json:"max_concurrent_reads_for_expand"`
+	MaxConcurrentReadsForRead        uint32        `json:"max_concurrent_reads_for_read"`
+	MaxReadResponseSizeInBytes       int           `json:"max_read_response_size_in_bytes"`
+	MaxAuthorizationModelCacheSize   int           `json:"max_authorization_model_cache_size"`
+	MaxAuthorizationModelSizeInBytes int           `json:"max_authorization_model_size_in_bytes"`
+
+	CacheLimit                    uint32        `json:"cache_limit"`
+	CheckQueryCacheEnabled        bool          `json:"check_query_cache_enabled"`
+	CheckQueryCacheTTL            time.Duration `json:"check_query_cache_ttl"`
+	CheckQueryCacheResponseHeader bool          `json:"check_query_cache_response_header"`
+	CheckIteratorCacheEnabled     bool          `json:"check_iterator_cache_enabled"`
+	CheckIteratorCacheMaxResults  uint32        `json:"check_iterator_cache_max_results"`
+
+	CheckDispatchThrottling       DispatchThrottlingConfiguration `json:"check_dispatch_throttling"`
+	ListObjectsDispatchThrottling DispatchThrottlingConfiguration `json:"list_objects_dispatch_throttling"`
+	ListUsersDispatchThrottling   DispatchThrottlingConfiguration `json:"list_users_dispatch_throttling"`
+
+	RequestPriorityEnabled bool `json:"request_priority_enabled"`
+	// LowPriorityDeprioritization is the effective percentage by which thresholds are lowered for low priority requests.
+	LowPriorityDeprioritization uint32 `json:"low_priority_deprioritization"`
+
+	ShadowModelEvaluationSampleRate float64 `json:"shadow_model_evaluation_sample_rate"`
+
+	WriteReferentialCheckEnabled bool `json:"write_referential_check_enabled"`
+
+	StrictConditionParameters bool `json:"strict_condition_parameters"`
+
+	MaxAuthorizationModelComplexity       int `json:"max_authorization_model_complexity"`
+	MaxAuthorizationModelTypes            int `json:"max_authorization_model_types"`
+	MaxAuthorizationModelRelationsPerType int `json:"max_authorization_model_relations_per_type"`
+	MaxAuthorizationModelRewriteDepth     int `json:"max_authorization_model_rewrite_depth"`
+
+	AuthorizationModelValidationTimeout   time.Duration `json:"authorization_model_validation_timeout"`
+	AuthorizationModelValidationMaxVisits int           `json:"authorization_model_validation_max_visits"`
+	ModelImpactAnalysisMaxProbes          int           `json:"model_impact_analysis_max_probes"`
+
+	ReadOnly bool `json:"read_only"`
+
+	SessionAffinityEnabled     bool          `json:"session_affinity_enabled"`
+	SessionAffinityWindow      time.Duration `json:"session_affinity_window"`
+	SessionAffinityMaxSessions uint32        `json:"session_affinity_max_sessions"`
+
+	MembershipIndexRelations    []string      `json:"membership_index_relations"`
+	MembershipIndexPollInterval time.Duration `json:"membership_index_poll_interval"`
+	MembershipIndexMaxStaleness time.Duration `json:"membership_index_max_staleness"`
+	MembershipIndexMaxStores    int           `json:"membership_index_max_stores"`
+	MembershipIndexIdleTimeout  time.Duration `json:"membership_index_idle_timeout"`
+
+	CheckFairReadSharing bool `json:"check_fair_read_sharing"`
+
+	ChangelogMetricsInterval time.Duration `json:"changelog_metrics_interval"`
+
+	ListStoresExactTotalCount bool `json:"list_stores_exact_total_count"`
+	UniqueStoreNames          bool `json:"unique_store_names"`
+	RejectWritesOnStaleModel  bool `json:"reject_writes_on_stale_model"`
+	ReadChangesHorizonHeaders bool `json:"read_changes_horizon_headers"`
+
+	ReadChangesWatchPollInterval      time.Duration `json:"read_changes_watch_poll_interval"`
+	ReadChangesWatchHeartbeatInterval time.Duration `json:"read_changes_watch_heartbeat_interval"`
+	ReadChangesWatchMaxStreams        uint32        `json:"read_changes_watch_max_streams"`
+
+	CheckResponseMetadataHeaders bool `json:"check_response_metadata_headers"`
+	CheckDeduplicationEnabled    bool `json:"check_deduplication_enabled"`
+
+	Experimentals []string `json:"experimentals"`
+}
+
+// GetConfiguration returns the effective runtime configuration of the server, including
+// derived values such as the effective maximum dispatch thresholds. Secrets are never included.
+//
+// Only loopback callers are allowed, see [isLoopbackCaller].
+func (s *Server) GetConfiguration(ctx context.Context) (*Configuration, error) {
+	ctx, span := tracer.Start(ctx, "GetConfiguration")
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "GetConfiguration",
+	})
+
+	if !isLoopbackCaller(ctx) {
+		span.SetAttributes(attribute.Bool("permission_denied", true))
+		return nil, serverErrors.LoopbackCallerRequired("GetConfiguration", "the configuration can only be read from a loopback address")
+	}
+
+	experimentals := make([]string, 0, len(s.experimentals))
+	for _, flag := range s.experimentals {
+		experimentals = append(experimentals, string(flag))
+	}
+
+	return &Configuration{
+		ResolveNodeLimit:                 s.resolveNodeLimit,
+		ResolveNodeBreadthLimit:          s.resolveNodeBreadthLimit,
+		ResolveNodeLimitShadow:           s.resolveNodeLimitShadow,
+		ResolveNodeBreadthLimitShadow:    s.resolveNodeBreadthLimitShadow,
+		ResolverWorkerPoolEnabled:        s.resolverWorkerPoolEnabled,
+		ResolverWorkerPoolSize:           s.resolverWorkerPoolSize,
+		UsersetBatchSize:                 s.usersetBatchSize,
+		ChangelogHorizonOffset:           s.changelogHorizonOffset,
+		ListObjectsDeadline:              s.listObjectsDeadline,
+		ListObjectsMaxResults:            s.listObjectsMaxResults,
+		ListObjectsMaxCandidates:         s.listObjectsMaxCandidates,
+		ListObjectsStrategy:              string(s.listObjectsStrategy),
+		ListObjectsStreamMaxModelLag:     s.listObjectsStreamMaxModelLag,
+		ListObjectsStreamCheckInterval:   s.listObjectsStreamCheckInterval,
+		MaxStreamDuration:                s.maxStreamDuration,
+		ExpandMaxLeafUsers:               s.expandMaxLeafUsers,
+		ExpandMaxNodes:                   s.expandMaxNodes,
+		ExpandStrictResultLimit:          s.expandStrictResultLimit,
+		RelationStatisticsMaxSampleSize:  s.relationStatisticsMaxSampleSize,
+		RelationStatisticsDeadline:       s.relationStatisticsDeadline,
+		RelationUsageDeadline:            s.relationUsageDeadline,
+		DeleteTuplesByFilterMaxTuples:    s.deleteTuplesByFilterMaxTuples,
+		DeleteTuplesByFilterMaxScanned:   s.deleteTuplesByFilterMaxScanned,
+		PointInTimeCheckHorizon:          s.pointInTimeCheckHorizon,
+		PointInTimeCheckMaxChanges:       s.pointInTimeCheckMaxChanges,
+		ErrorReasonDetails:               s.errorReasonDetails,
+		ListUsersDeadline:                s.listUsersDeadline,
+		ListUsersMaxResults:              s.listUsersMaxResults,
+		MaxConcurrentReadsForCheck:       s.maxConcurrentReadsForCheck,
+		SnapshotReadsForCheck:            s.snapshotReadsForCheck,
+		MaxChecksPerBatchCheck:           s.maxChecksPerBatchCheck,
+		MaxConcurrentReadsForListObjects: s.maxConcurrentReadsForListObjects,
+		MaxConcurrentReadsForListUsers:   s.maxConcurrentReadsForListUsers,
+		MaxConcurrentReadsForExpand:      s.maxConcurrentReadsForExpand,
+		MaxConcurrentReadsForRead:        s.maxConcurrentReadsForRead,
+		MaxReadResponseSizeInBytes:       s.maxReadResponseSizeInBytes,
+		MaxAuthorizationModelCacheSize:   s.maxAuthorizationModelCacheSize,
+		MaxAuthorizationModelSizeInBytes: s.maxAuthorizationModelSizeInBytes,
+
+		CacheLimit:                    s.cacheLimit,
+		CheckQueryCacheEnabled:        s.checkQueryCacheEnabled,
+		CheckQueryCacheTTL:            s.checkQueryCacheTTL,
+		CheckQueryCacheResponseHeader: s.checkQueryCacheResponseHeader,
+		CheckIteratorCacheEnabled:     s.checkIteratorCacheEnabled,
+		CheckIteratorCacheMaxResults:  s.checkIteratorCacheMaxResults,
+
+		CheckDispatchThrottling: newDispatchThrottlingConfiguration(
+			s.checkDispatchThrottlingEnabled,
+			s.checkDispatchThrottlingFrequency,
+			s.checkDispatchThrottlingDefaultThreshold,
+			s.checkDispatchThrottlingMaxThreshold,
+		),
+		ListObjectsDispatchThrottling: newDispatchThrottlingConfiguration(
+			s.listObjectsDispatchThrottlingEnabled,
+			s.listObjectsDispatchThrottlingFrequency,
+			s.listObjectsDispatchDefaultThreshold,
+			s.listObjectsDispatchThrottlingMaxThreshold,
+		),
+		ListUsersDispatchThrottling: newDispatchThrottlingConfiguration(
+			s.listUsersDispatchThrottlingEnabled,
+			s.listUsersDispatchThrottlingFrequency,
+			s.listUsersDispatchDefaultThreshold,
+			s.listUsersDispatchThrottlingMaxThreshold,
+		),
+
+		RequestPriorityEnabled:      s.requestPriorityEnabled,
+		LowPriorityDeprioritization: s.lowPriorityDeprioritization(),
+
+		ShadowModelEvaluationSampleRate: s.shadowModelEvaluationSampleRate,
+
+		WriteReferentialCheckEnabled: s.writeReferentialCheckEnabled,
+
+		StrictConditionParameters: s.strictConditionParameters,
+
+		MaxAuthorizationModelComplexity:       s.maxAuthorizationModelComplexity,
+		MaxAuthorizationModelTypes:            s.maxAuthorizationModelTypes,
+		MaxAuthorizationModelRelationsPerType: s.maxAuthorizationModelRelationsPerType,
+		MaxAuthorizationModelRewriteDepth:     s.maxAuthorizationModelRewriteDepth,
+		AuthorizationModelValidationTimeout:   s.authorizationModelValidationTimeout,
+		AuthorizationModelValidationMaxVisits: s.authorizationModelValidationMaxVisits,
+		ModelImpactAnalysisMaxProbes:          s.modelImpactAnalysisMaxProbes,
+
+		ReadOnly: s.readOnly,
+
+		SessionAffinityEnabled:     s.sessionAffinityEnabled,
+		SessionAffinityWindow:      s.sessionAffinityWindow,
+		SessionAffinityMaxSessions: s.sessionAffinityMaxSessions,
+
+		MembershipIndexRelations:    s.membershipIndexRelations,
+		MembershipIndexPollInterval: s.membershipIndexPollInterval,
+		MembershipIndexMaxStaleness: s.membershipIndexMaxStaleness,
+		MembershipIndexMaxStores:    s.membershipIndexMaxStores,
+		MembershipIndexIdleTimeout:  s.membershipIndexIdleTimeout,
+
+		CheckFairReadSharing: s.checkFairReadSharing,
+
+		ChangelogMetricsInterval: s.changelogMetricsInterval,
+
+		ListStoresExactTotalCount: s.listStoresExactTotalCount,
+		UniqueStoreNames:          s.uniqueStoreNamesEnabled,
+		RejectWritesOnStaleModel:  s.rejectWritesOnStaleModel,
+		ReadChangesHorizonHeaders: s.readChangesHorizonHeaders,
+
+		ReadChangesWatchPollInterval:      s.readChangesWatchPollInterval,
+		ReadChangesWatchHeartbeatInterval: s.readChangesWatchHeartbeatInterval,
+		ReadChangesWatchMaxStreams:        s.readChangesWatchMaxStreams,
+
+		CheckResponseMetadataHeaders: s.checkResponseMetadataHeaders,
+		CheckDeduplicationEnabled:    s.checkDeduplicationEnabled,
+
+		Experimentals: experimentals,
+	}, nil
+}
+
+// newDispatchThrottlingConfiguration mirrors the clamping done in threshold.ShouldThrottle,
+// where a max threshold of zero means "use the default threshold as max".
+func newDispatchThrottlingConfiguration(enabled bool, frequency time.Duration, defaultThreshold, maxThreshold uint32) DispatchThrottlingConfiguration {
+	if maxThreshold == 0 {
+		maxThreshold = defaultThreshold
+	}
+
+	return DispatchThrottlingConfiguration{
+		Enabled:          enabled,
+		Frequency:        frequency,
+		DefaultThreshold: defaultThreshold,
+		MaxThreshold:     maxThreshold,
+	}
+}
+
+// forwardedForMetadataKey is the gRPC metadata key in which the HTTP gateway forwards the
+// remote address of an HTTP request, after the addresses of its X-Forwarded-For header (see
+// AnnotateContext of the grpc-gateway runtime).
+const forwardedForMetadataKey = "x-forwarded-for"
+
+// isLoopbackCaller reports whether the caller is either in-process or connected from a loopback address.
+//
+// It is the policy of the administration methods of the server, such as GetConfiguration,
+// GetStoreStatistics and DeleteTuplesByFilter, which have no other authorization: when called
+// over the network, only callers connecting from a loopback address are allowed, and in-process
+// callers (i.e. without peer information in the context) are always allowed. Callers are
+// rejected with serverErrors.LoopbackCallerRequired.
+//
+// The HTTP gateway calls the server from a loopback address, so for the calls it forwards, the
+// caller is the remote address of the HTTP request, i.e. the last address of the
+// forwardedForMetadataKey metadata. Only a caller connected from a loopback address can set it.
+func isLoopbackCaller(ctx context.Context) bool {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil && !isLoopbackAddress(p.Addr.String()) {
+		return false
+	}
+
+	if values := metadata.ValueFromIncomingContext(ctx, forwardedForMetadataKey); len(values) > 0 {
+		addrs := strings.Split(values[len(values)-1], ",")
+		return isLoopbackAddress(strings.TrimSpace(addrs[len(addrs)-1]))
+	}
+	return true
+}
+
+// isLoopbackAddress reports whether addr, with or without a port, is a loopback IP address.
+func isLoopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
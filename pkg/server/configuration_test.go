@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/storage/memory"
+)
+
+func TestGetConfiguration(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	t.Run("returns_effective_values", func(t *testing.T) {
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithResolveNodeLimit(10),
+			WithListObjectsDeadline(5*time.Second),
+			WithDispatchThrottlingCheckResolverEnabled(true),
+			WithDispatchThrottlingCheckResolverThreshold(50),
+			WithListObjectsDispatchThrottlingThreshold(20),
+			WithListObjectsDispatchThrottlingMaxThreshold(200),
+			WithExperimentals(ExperimentalFeatureFlag("some-flag")),
+		)
+		t.Cleanup(s.Close)
+
+		cfg, err := s.GetConfiguration(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, uint32(10), cfg.ResolveNodeLimit)
+		require.Equal(t, uint32(serverconfig.DefaultResolveNodeBreadthLimit), cfg.ResolveNodeBreadthLimit)
+		require.Equal(t, 5*time.Second, cfg.ListObjectsDeadline)
+		require.True(t, cfg.CheckDispatchThrottling.Enabled)
+		require.Equal(t, uint32(50), cfg.CheckDispatchThrottling.DefaultThreshold)
+		require.Equal(t, uint32(50), cfg.CheckDispatchThrottling.MaxThreshold)
+		require.Equal(t, uint32(20), cfg.ListObjectsDispatchThrottling.DefaultThreshold)
+		require.Equal(t, uint32(200), cfg.ListObjectsDispatchThrottling.MaxThreshold)
+		require.Equal(t, []string{"some-flag"}, cfg.Experimentals)
+	})
+
+	t.Run("allows_loopback_peers", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(s.Close)
+
+		ctx := peer.NewContext(context.Background(), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8081},
+		})
+		_, err := s.GetConfiguration(ctx)
+		require.NoError(t, err)
+
+		ctx = peer.NewContext(context.Background(), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.ParseIP("::1"), Port: 8081},
+		})
+		_, err = s.GetConfiguration(ctx)
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects_remote_peers", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(s.Close)
+
+		ctx := peer.NewContext(context.Background(), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.12"), Port: 8081},
+		})
+		_, err := s.GetConfiguration(ctx)
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
+
+func TestConfigurationExcludesSecrets(t *testing.T) {
+	// The configuration must only ever contain plain values, so that no datastore,
+	// encoder or credential can leak through it, even if new fields are added later.
+	forbiddenNames := []string{"uri", "password", "secret", "key", "token", "credential"}
+
+	var walk func(typ reflect.Type, path string)
+	walk = func(typ reflect.Type, path string) {
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			fieldPath := path + "." + field.Name
+
+			for _, forbidden := range forbiddenNames {
+				require.NotContains(t, strings.ToLower(field.Name), forbidden, fieldPath)
+			}
+
+			switch field.Type.Kind() {
+			case reflect.Struct:
+				walk(field.Type, fieldPath)
+			case reflect.Slice:
+				require.Equal(t, reflect.String, field.Type.Elem().Kind(), fieldPath)
+			case reflect.Bool, reflect.Int, reflect.Int64, reflect.Uint32, reflect.Float64, reflect.String:
+			default:
+				require.Failf(t, "unexpected field kind", "%s has kind %s", fieldPath, field.Type.Kind())
+			}
+		}
+	}
+
+	walk(reflect.TypeOf(Configuration{}), "Configuration")
+}
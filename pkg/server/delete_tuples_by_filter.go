@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/openfga/openfga/pkg/middleware/validator"
+	"github.com/openfga/openfga/pkg/server/commands"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+// DeleteTuplesByFilter deletes the tuples of a store selected by a filter, e.g. every tuple
+// with the editor relation and a user starting with "service:old-bot", to clean up after a bad
+// integration without paging through Read and writing the deletes. The tuples are deleted in
+// batches through the write path, so they are recorded in the changelog and invalidate the cached
+// results that depend on them. At most the limit set by [WithDeleteTuplesByFilterMaxTuples] are
+// deleted per call, and the response reports if more are left. A dry run only counts them.
+//
+// Only loopback callers are allowed, see [isLoopbackCaller], as the server has no admin
+// authorization model to check callers against.
+func (s *Server) DeleteTuplesByFilter(ctx context.Context, req *commands.DeleteTuplesByFilterRequest) (_ *commands.DeleteTuplesByFilterResponse, err error) {
+	ctx, span := tracer.Start(ctx, "DeleteTuplesByFilter", trace.WithAttributes(
+		attribute.String("store_id", req.StoreID),
+		attribute.String("object", req.TupleKey.GetObject()),
+		attribute.String("relation", req.TupleKey.GetRelation()),
+		attribute.String("user", req.TupleKey.GetUser()),
+		attribute.String("user_prefix", req.UserPrefix),
+		attribute.Bool("dry_run", req.DryRun),
+	))
+	defer span.End()
+	defer s.inFlightRequests.start("DeleteTuplesByFilter")()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, "DeleteTuplesByFilter", req, &err)
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "DeleteTuplesByFilter",
+	})
+
+	if !isLoopbackCaller(ctx) {
+		span.SetAttributes(attribute.Bool("permission_denied", true))
+		return nil, serverErrors.LoopbackCallerRequired("DeleteTuplesByFilter", "tuples can only be deleted by filter from a loopback address")
+	}
+
+	if err := validator.ValidateStoreID(req.StoreID); err != nil {
+		return nil, err
+	}
+
+	if s.readOnly && !req.DryRun {
+		return nil, serverErrors.ServerReadOnly
+	}
+
+	// the deletes go through the check datastore so that they invalidate the cached usersets they touch
+	resp, err := commands.NewDeleteTuplesByFilterCommand(s.checkDatastore,
+		commands.WithDeleteTuplesByFilterLogger(s.logger),
+		commands.WithDeleteTuplesByFilterMaxTuples(s.deleteTuplesByFilterMaxTuples),
+		commands.WithDeleteTuplesByFilterMaxScannedTuples(s.deleteTuplesByFilterMaxScanned),
+		commands.WithDeleteTuplesByFilterWriteOptions(
+			commands.WithWriteCmdLogger(s.logger),
+			commands.WithTupleQuota(s.tupleCounter, s.tupleQuotaForStore(req.StoreID)),
+		),
+	).Execute(ctx, req)
+	if err != nil {
+		telemetry.TraceError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.Int("matched", resp.Matched),
+		attribute.Int("tuples_deleted_count", resp.Deleted),
+		attribute.Bool("truncated", resp.Truncated),
+	)
+	tuplesDeletedCounter.WithLabelValues(s.perStoreWriteMetricsLabel(req.StoreID)).Add(float64(resp.Deleted))
+
+	return resp, nil
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/openfga/openfga/internal/graph"
+)
+
+// DenialReason describes why a Check request was not allowed.
+type DenialReason string
+
+const (
+	// DenialReasonDenied means no relationship grants the requested relation.
+	DenialReasonDenied DenialReason = "denied"
+
+	// DenialReasonConditionFalse means a matching relationship exists but its
+	// condition evaluated to false for the request context.
+	DenialReasonConditionFalse DenialReason = "denied_condition_false"
+
+	// DenialReasonConditionMissingContext means a matching relationship exists but
+	// its condition could not be evaluated because parameters were missing from
+	// the request context.
+	DenialReasonConditionMissingContext DenialReason = "denied_condition_missing_context"
+)
+
+// checkDenialReason classifies a denied Check from the metadata recorded while resolving it: it
+// was denied by a condition if a relationship that would have allowed it, directly or through
+// the subproblems it was resolved with, has a condition that the request context does not meet.
+// A Check failing because condition parameters are missing from the request context is reported
+// as DenialReasonConditionMissingContext from its error instead.
+func checkDenialReason(resp *graph.ResolveCheckResponse) DenialReason {
+	if resp.GetResolutionMetadata().ConditionNotMet {
+		return DenialReasonConditionFalse
+	}
+	return DenialReasonDenied
+}
+
+// setCheckDenialHeaders sets the denial reason response headers for a Check.
+func (s *Server) setCheckDenialHeaders(ctx context.Context, reason DenialReason, missing []string) {
+	s.transport.SetHeader(ctx, CheckDenialReasonHeader, string(reason))
+	if len(missing) > 0 {
+		s.transport.SetHeader(ctx, CheckMissingContextHeader, strings.Join(missing, ","))
+	}
+}
+
+// setListObjectsConditionalExclusionsHeader reports how many candidate objects
+// were dropped because their condition was not met.
+func (s *Server) setListObjectsConditionalExclusionsHeader(ctx context.Context, exclusions uint32) {
+	s.transport.SetHeader(ctx, ListObjectsConditionalExclusionsHeader, strconv.FormatUint(uint64(exclusions), 10))
+}
@@ -0,0 +1,73 @@
+package errors
+
+import (
+	"maps"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// LocaleParam is the param of a [MessageCatalog] with the locale of the request, e.g. the value
+// of its Accept-Language header. It is not part of the ErrorInfo details of the error.
+const LocaleParam = "locale"
+
+// MessageCatalog returns the human-readable message of the error with the given reason code and
+// params, i.e. the reason and metadata of the ErrorInfo details of the error, e.g. in the
+// language of the [LocaleParam]. It returns "" to keep the default message.
+type MessageCatalog func(code string, params map[string]string) string
+
+// Localize returns the error with its message replaced by the message of the catalog. The code
+// and details of the error are unchanged, so clients matching them are not affected by the
+// catalog. If the reason was replaced by an [ErrorReason] (see [WithErrorReason]), the catalog
+// is given the original reason. Errors without ErrorInfo details, e.g. the errors of the gRPC
+// transport, keep their message.
+func Localize(err error, catalog MessageCatalog, locale string) error {
+	if err == nil || catalog == nil {
+		return err
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	var info *errdetails.ErrorInfo
+	for _, detail := range st.Details() {
+		if i, ok := detail.(*errdetails.ErrorInfo); ok && i.GetDomain() == errorInfoDomain {
+			info = i
+			break
+		}
+	}
+	if info == nil {
+		return err
+	}
+
+	code := info.GetReason()
+	params := maps.Clone(info.GetMetadata())
+	if params == nil {
+		params = map[string]string{}
+	}
+	if detailReason, ok := params["detail_reason"]; ok {
+		code = detailReason
+		delete(params, "detail_reason")
+	}
+	if locale != "" {
+		params[LocaleParam] = locale
+	}
+
+	message := catalog(code, params)
+	if message == "" || message == st.Message() {
+		return err
+	}
+
+	stProto := st.Proto()
+	stProto.Message = message
+	localized := status.FromProto(stProto).Err()
+
+	// internal errors keep their cause for the logs
+	if internalErr, ok := err.(InternalError); ok {
+		internalErr.public = localized
+		return internalErr
+	}
+	return localized
+}
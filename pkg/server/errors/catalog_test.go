@@ -0,0 +1,310 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	errors2 "github.com/openfga/openfga/internal/errors"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// errorDetailsTests pins the reason and params of the ErrorInfo details of the errors, so that
+// a refactoring cannot change the codes that clients and message catalogs rely on.
+var errorDetailsTests = map[string]struct {
+	err            error
+	expectedReason string
+	expectedParams map[string]string
+}{
+	"store_id_not_found": {
+		err:            StoreIDNotFound,
+		expectedReason: "STORE_ID_NOT_FOUND",
+	},
+	"invalid_store_id": {
+		err:            InvalidStoreID,
+		expectedReason: "INVALID_STORE_ID",
+	},
+	"internal": {
+		err:            NewInternalError("", fmt.Errorf("boom")),
+		expectedReason: "INTERNAL_ERROR",
+	},
+	"authorization_model_not_found": {
+		err:            AuthorizationModelNotFound("01HVMMBCMGZNT3SED4Z17ECXCA"),
+		expectedReason: "AUTHORIZATION_MODEL_NOT_FOUND",
+		expectedParams: map[string]string{"authorization_model_id": "01HVMMBCMGZNT3SED4Z17ECXCA"},
+	},
+	"latest_authorization_model_not_found": {
+		err:            LatestAuthorizationModelNotFound("01HVMMBCMGZNT3SED4Z17ECXCA"),
+		expectedReason: "LATEST_AUTHORIZATION_MODEL_NOT_FOUND",
+		expectedParams: map[string]string{"store_id": "01HVMMBCMGZNT3SED4Z17ECXCA"},
+	},
+	"type_not_found": {
+		err:            TypeNotFound("folder"),
+		expectedReason: "TYPE_NOT_FOUND",
+		expectedParams: map[string]string{"object_type": "folder"},
+	},
+	"relation_not_found": {
+		err:            RelationNotFound("editor", "document", tuple.NewTupleKey("document:1", "editor", "user:jon")),
+		expectedReason: "RELATION_NOT_FOUND",
+		expectedParams: map[string]string{"object_type": "document", "relation": "editor", "tuple": "document:1#editor@user:jon"},
+	},
+	"exceeded_entity_limit": {
+		err:            ExceededEntityLimit("write operations", 100),
+		expectedReason: "EXCEEDED_ENTITY_LIMIT",
+		expectedParams: map[string]string{"entity": "write operations", "limit": "100"},
+	},
+	"list_objects_max_candidates": {
+		err:            ListObjectsMaxCandidatesExceeded(1000),
+		expectedReason: "LIST_OBJECTS_MAX_CANDIDATES_EXCEEDED",
+		expectedParams: map[string]string{"limit": "1000"},
+	},
+	"duplicate_tuple_in_writes": {
+		err:            DuplicateTupleInWrites(tuple.NewTupleKey("document:1", "viewer", "user:jon"), 0, 2),
+		expectedReason: "DUPLICATE_TUPLE_IN_WRITES",
+		expectedParams: map[string]string{"object": "document:1", "relation": "viewer", "user": "user:jon", "first_index": "0", "second_index": "2"},
+	},
+	"duplicate_tuple_in_write": {
+		err:            DuplicateTupleInWrite(tuple.NewTupleKey("document:1", "viewer", "user:jon")),
+		expectedReason: "DUPLICATE_TUPLE_IN_WRITE",
+		expectedParams: map[string]string{"object": "document:1", "relation": "viewer", "user": "user:jon"},
+	},
+	"invalid_tuple": {
+		err:            HandleTupleValidateError(&tuple.InvalidTupleError{Cause: fmt.Errorf("invalid"), TupleKey: tuple.NewTupleKey("document:1", "viewer", "user:jon")}),
+		expectedReason: "INVALID_TUPLE",
+		expectedParams: map[string]string{"tuple": "document:1#viewer@user:jon"},
+	},
+	"validation_error": {
+		err:            ValidationError(&tuple.RelationNotAssignableError{TypeName: "document", Relation: "viewer"}),
+		expectedReason: "RELATION_NOT_ASSIGNABLE",
+		expectedParams: map[string]string{"object_type": "document", "relation": "viewer"},
+	},
+	"authorization_model_size": {
+		err:            AuthorizationModelSizeExceeded(1030, 1035, 1032),
+		expectedReason: "AUTHORIZATION_MODEL_SIZE_EXCEEDED",
+		expectedParams: map[string]string{"request_size": "1030", "stored_size": "1035", "limit": "1032"},
+	},
+	"tuple_quota": {
+		err:            TupleQuotaExceeded("document", 10, 10),
+		expectedReason: "TUPLE_QUOTA_EXCEEDED",
+		expectedParams: map[string]string{"object_type": "document", "quota": "10", "usage": "10"},
+	},
+	"request_validation_error": {
+		err:            RequestValidationError((&openfgav1.CheckRequest{StoreId: "01HVMMBCMGZNT3SED4Z17ECXCA", TupleKey: &openfgav1.CheckRequestTupleKey{Object: "document:1", Relation: "viewer"}}).ValidateAll()),
+		expectedReason: "INVALID_REQUEST_FIELD",
+		expectedParams: map[string]string{"field": "TupleKey.User", "field_reason": `value does not match regex pattern "^[^\\s]{2,512}$"`},
+	},
+	"invalid_request_field": {
+		err:            InvalidRequestField("usertype", "unknown field"),
+		expectedReason: "INVALID_REQUEST_FIELD",
+		expectedParams: map[string]string{"field": "usertype", "field_reason": "unknown field"},
+	},
+	"required_fields_missing": {
+		err:            RequiredFieldsMissing("the store ID and authorization model ID are required", "store_id", "authorization_model_id"),
+		expectedReason: "REQUIRED_FIELDS_MISSING",
+		expectedParams: map[string]string{"fields": "store_id,authorization_model_id"},
+	},
+	"invalid_header_value": {
+		err:            InvalidHeaderValue("Openfga-Expand-Format", "tree", fmt.Errorf("invalid")),
+		expectedReason: "INVALID_HEADER_VALUE",
+		expectedParams: map[string]string{"header": "Openfga-Expand-Format", "value": "tree"},
+	},
+	"unknown_request_headers": {
+		err:            UnknownRequestHeaders([]string{"openfga-resolve-limit", "openfga-no-modle-cache"}),
+		expectedReason: "UNKNOWN_REQUEST_HEADERS",
+		expectedParams: map[string]string{"headers": "openfga-resolve-limit,openfga-no-modle-cache"},
+	},
+	"header_not_allowed": {
+		err:            HeaderNotAllowed("Openfga-No-Model-Cache"),
+		expectedReason: "HEADER_NOT_ALLOWED",
+		expectedParams: map[string]string{"header": "Openfga-No-Model-Cache"},
+	},
+	"experimental_flag_required": {
+		err:            ExperimentalFlagRequired("Openfga-Check-At", "enable-point-in-time-check"),
+		expectedReason: "EXPERIMENTAL_FLAG_REQUIRED",
+		expectedParams: map[string]string{"header": "Openfga-Check-At", "flag": "enable-point-in-time-check"},
+	},
+	"loopback_caller_required": {
+		err:            LoopbackCallerRequired("GetRelationUsage", "relation usage can only be read from a loopback address"),
+		expectedReason: "LOOPBACK_CALLER_REQUIRED",
+		expectedParams: map[string]string{"method": "GetRelationUsage"},
+	},
+	"admin_token_required": {
+		err:            AdminTokenRequired,
+		expectedReason: "ADMIN_TOKEN_REQUIRED",
+	},
+	"message_size_exceeded": {
+		err:            MessageSizeExceeded("/openfga.v1.OpenFGAService/Check", 8192, 4096),
+		expectedReason: "MESSAGE_SIZE_EXCEEDED",
+		expectedParams: map[string]string{"method": "/openfga.v1.OpenFGAService/Check", "size": "8192", "limit": "4096"},
+	},
+	"batch_check_empty": {
+		err:            BatchCheckEmpty,
+		expectedReason: "BATCH_CHECK_EMPTY",
+	},
+	"batch_check_size_exceeded": {
+		err:            BatchCheckSizeExceeded(60, 50),
+		expectedReason: "BATCH_CHECK_SIZE_EXCEEDED",
+		expectedParams: map[string]string{"checks": "60", "limit": "50"},
+	},
+	"batch_check_correlation_id_missing": {
+		err:            BatchCheckCorrelationIDMissing,
+		expectedReason: "BATCH_CHECK_CORRELATION_ID_MISSING",
+	},
+	"batch_check_duplicate_correlation_id": {
+		err:            BatchCheckDuplicateCorrelationID("a"),
+		expectedReason: "BATCH_CHECK_DUPLICATE_CORRELATION_ID",
+		expectedParams: map[string]string{"correlation_id": "a"},
+	},
+	"point_in_time_in_future": {
+		err:            PointInTimeInFuture(time.Date(2100, 1, 2, 3, 4, 5, 0, time.UTC)),
+		expectedReason: "POINT_IN_TIME_IN_FUTURE",
+		expectedParams: map[string]string{"point_in_time": "2100-01-02T03:04:05Z"},
+	},
+	"point_in_time_beyond_horizon": {
+		err:            PointInTimeBeyondHorizon(time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC), time.Hour),
+		expectedReason: "POINT_IN_TIME_BEYOND_HORIZON",
+		expectedParams: map[string]string{"point_in_time": "2000-01-02T03:04:05Z", "horizon": "1h0m0s"},
+	},
+	"point_in_time_unavailable": {
+		err:            PointInTimeUnavailable(fmt.Errorf("the changelog does not go back to the point in time")),
+		expectedReason: "POINT_IN_TIME_UNAVAILABLE",
+	},
+	"start_time_with_continuation_token": {
+		err:            ReadChangesStartTimeWithContinuationToken,
+		expectedReason: "START_TIME_WITH_CONTINUATION_TOKEN",
+	},
+	"query_shape_sampling_unsupported": {
+		err:            QueryShapeSamplingUnsupported,
+		expectedReason: "QUERY_SHAPE_SAMPLING_UNSUPPORTED",
+	},
+	"query_shape_sampling_disabled": {
+		err:            QueryShapeSamplingDisabled(fmt.Errorf("query shape sampling is disabled")),
+		expectedReason: "QUERY_SHAPE_SAMPLING_DISABLED",
+	},
+	"tuple_counts_unsupported": {
+		err:            TupleCountsUnsupported,
+		expectedReason: "TUPLE_COUNTS_UNSUPPORTED",
+	},
+	"store_counts_unsupported": {
+		err:            StoreCountsUnsupported,
+		expectedReason: "STORE_COUNTS_UNSUPPORTED",
+	},
+	"authorization_model_schema_not_migratable": {
+		err:            AuthorizationModelSchemaNotMigratable("01HVMMBCMGZNT3SED4Z17ECXCA", "1.1", "1.0"),
+		expectedReason: "AUTHORIZATION_MODEL_SCHEMA_NOT_MIGRATABLE",
+		expectedParams: map[string]string{"authorization_model_id": "01HVMMBCMGZNT3SED4Z17ECXCA", "schema_version": "1.1"},
+	},
+	"stale_authorization_model": {
+		err:            WriteOnStaleAuthorizationModel("01HVMMBCMGZNT3SED4Z17ECXCA", "01HVMMBCMGZNT3SED4Z17ECXCB"),
+		expectedReason: "STALE_AUTHORIZATION_MODEL",
+		expectedParams: map[string]string{"authorization_model_id": "01HVMMBCMGZNT3SED4Z17ECXCA", "latest_authorization_model_id": "01HVMMBCMGZNT3SED4Z17ECXCB"},
+	},
+}
+
+func TestErrorDetails(t *testing.T) {
+	for name, test := range errorDetailsTests {
+		t.Run(name, func(t *testing.T) {
+			st, ok := status.FromError(test.err)
+			require.True(t, ok)
+			require.Len(t, st.Details(), 1)
+
+			info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			require.Equal(t, test.expectedReason, info.GetReason())
+			require.Equal(t, "openfga.dev", info.GetDomain())
+			if test.expectedParams == nil {
+				require.Empty(t, info.GetMetadata())
+			} else {
+				require.Equal(t, test.expectedParams, info.GetMetadata())
+			}
+		})
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	for name, test := range errorDetailsTests {
+		t.Run(name, func(t *testing.T) {
+			var gotCode string
+			var gotParams map[string]string
+			catalog := func(code string, params map[string]string) string {
+				gotCode, gotParams = code, params
+				return "エラー: " + code
+			}
+
+			localized := Localize(test.err, catalog, "ja")
+			require.Equal(t, test.expectedReason, gotCode)
+			require.Equal(t, "ja", gotParams[LocaleParam])
+			delete(gotParams, LocaleParam)
+			if test.expectedParams == nil {
+				require.Empty(t, gotParams)
+			} else {
+				require.Equal(t, test.expectedParams, gotParams)
+			}
+
+			// only the message changes
+			expected := status.Convert(test.err)
+			actual := status.Convert(localized)
+			require.Equal(t, "エラー: "+test.expectedReason, actual.Message())
+			require.Equal(t, expected.Code(), actual.Code())
+			require.Empty(t, cmpDetails(expected, actual))
+		})
+	}
+
+	t.Run("keeps_the_message_without_catalog_message", func(t *testing.T) {
+		err := TypeNotFound("folder")
+		require.Equal(t, err, Localize(err, nil, "ja"))
+		require.Equal(t, err, Localize(err, func(string, map[string]string) string { return "" }, "ja"))
+	})
+
+	t.Run("keeps_errors_without_details", func(t *testing.T) {
+		err := status.Error(codes.InvalidArgument, "invalid")
+		require.Equal(t, err, Localize(err, func(string, map[string]string) string { return "無効" }, "ja"))
+
+		plain := fmt.Errorf("boom")
+		require.Equal(t, plain, Localize(plain, func(string, map[string]string) string { return "無効" }, "ja"))
+	})
+
+	t.Run("catalog_params_do_not_change_the_details", func(t *testing.T) {
+		err := TypeNotFound("folder")
+		localized := Localize(err, func(_ string, params map[string]string) string {
+			params["object_type"] = "changed"
+			return "型が見つかりません"
+		}, "ja")
+		require.Empty(t, cmpDetails(status.Convert(err), status.Convert(localized)))
+	})
+
+	t.Run("original_reason_of_error_reason", func(t *testing.T) {
+		err := WithErrorReason(TypeNotFound("folder"))
+
+		var gotCode string
+		var gotParams map[string]string
+		localized := Localize(err, func(code string, params map[string]string) string {
+			gotCode, gotParams = code, params
+			return "型が見つかりません"
+		}, "")
+		require.Equal(t, "TYPE_NOT_FOUND", gotCode)
+		require.Equal(t, map[string]string{"object_type": "folder"}, gotParams)
+		require.Equal(t, ErrorReasonTupleInvalid, ErrorReasonOf(localized))
+		require.Empty(t, cmpDetails(status.Convert(err), status.Convert(localized)))
+	})
+
+	t.Run("internal_error_keeps_its_cause", func(t *testing.T) {
+		err := NewInternalError("", errors2.ErrUnknown)
+		localized := Localize(err, func(string, map[string]string) string { return "内部エラー" }, "ja")
+		require.ErrorIs(t, localized, errors2.ErrUnknown)
+		require.Equal(t, "内部エラー", status.Convert(localized).Message())
+	})
+}
+
+// cmpDetails returns the difference between the details of two statuses.
+func cmpDetails(expected, actual *status.Status) string {
+	return cmp.Diff(expected.Proto().GetDetails(), actual.Proto().GetDetails(), protocmp.Transform())
+}
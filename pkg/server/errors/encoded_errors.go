@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"fmt"
 	"net/http"
 	"regexp"
 	"strings"
@@ -80,6 +81,17 @@ func NewEncodedError(errorCode int32, message string) *EncodedError {
 				},
 			}
 		}
+		if errorCode == int32(codes.PermissionDenied) {
+			return &EncodedError{
+				HTTPStatusCode: http.StatusForbidden,
+				GRPCStatusCode: codes.PermissionDenied,
+				ActualError: ErrorResponse{
+					Code:    codes.PermissionDenied.String(),
+					Message: sanitizedMessage(message),
+					codeInt: errorCode,
+				},
+			}
+		}
 		return &EncodedError{
 			HTTPStatusCode: http.StatusInternalServerError,
 			GRPCStatusCode: codes.Internal,
@@ -91,6 +103,18 @@ func NewEncodedError(errorCode int32, message string) *EncodedError {
 		}
 	}
 
+	if errorCode == int32(openfgav1.InternalErrorCode_already_exists) {
+		return &EncodedError{
+			HTTPStatusCode: http.StatusConflict,
+			GRPCStatusCode: codes.AlreadyExists,
+			ActualError: ErrorResponse{
+				Code:    openfgav1.InternalErrorCode_already_exists.String(),
+				Message: sanitizedMessage(message),
+				codeInt: errorCode,
+			},
+		}
+	}
+
 	var httpStatusCode int
 	var grpcStatusCode codes.Code
 	var code string
@@ -129,6 +153,19 @@ func NewEncodedError(errorCode int32, message string) *EncodedError {
 	}
 }
 
+// NewRequestBodyTooLargeError returns the error of HTTP requests whose body is larger than the
+// limit of their route.
+func NewRequestBodyTooLargeError(limit int64) *EncodedError {
+	return &EncodedError{
+		HTTPStatusCode: http.StatusRequestEntityTooLarge,
+		GRPCStatusCode: codes.ResourceExhausted,
+		ActualError: ErrorResponse{
+			Code:    "request_body_too_large",
+			Message: fmt.Sprintf("the request body exceeds the limit of %d bytes", limit),
+		},
+	}
+}
+
 // IsValidEncodedError returns whether the error code is a valid encoded error.
 func IsValidEncodedError(errorCode int32) bool {
 	return errorCode >= cFirstAuthenticationErrorCode
@@ -245,6 +282,8 @@ func ConvertToEncodedErrorCode(statusError *status.Status) int32 {
 		return int32(openfgav1.InternalErrorCode_failed_precondition)
 	case codes.Aborted:
 		return int32(codes.Aborted)
+	case codes.PermissionDenied:
+		return int32(codes.PermissionDenied)
 	case codes.OutOfRange:
 		return int32(openfgav1.InternalErrorCode_out_of_range)
 	case codes.Unimplemented:
@@ -28,6 +28,22 @@ func TestEncodedError(t *testing.T) {
 			expectedCode:           int(codes.Aborted),
 			expectedCodeString:     "Aborted",
 		},
+		{
+			_name:                  "permission_denied_error",
+			errorCode:              int32(codes.PermissionDenied),
+			message:                "error message",
+			expectedHTTPStatusCode: http.StatusForbidden,
+			expectedCode:           int(codes.PermissionDenied),
+			expectedCodeString:     "PermissionDenied",
+		},
+		{
+			_name:                  "already_exists_error",
+			errorCode:              int32(openfgav1.InternalErrorCode_already_exists),
+			message:                "error message",
+			expectedHTTPStatusCode: http.StatusConflict,
+			expectedCode:           int(openfgav1.InternalErrorCode_already_exists),
+			expectedCodeString:     "already_exists",
+		},
 		{
 			_name:                  "invalid_error",
 			errorCode:              20,
@@ -193,6 +209,11 @@ func TestConvertToEncodedErrorCode(t *testing.T) {
 			status:            status.New(codes.Aborted, "other error"),
 			expectedErrorCode: int32(codes.Aborted),
 		},
+		{
+			_name:             "permission_denied",
+			status:            status.New(codes.PermissionDenied, "other error"),
+			expectedErrorCode: int32(codes.PermissionDenied),
+		},
 		{
 			_name:             "out_of_range",
 			status:            status.New(codes.OutOfRange, "other error"),
@@ -5,11 +5,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
 
+	"github.com/openfga/openfga/internal/condition"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/tuple"
 )
@@ -18,18 +24,45 @@ const InternalServerErrorMsg = "Internal Server Error"
 
 var (
 	// AuthorizationModelResolutionTooComplex is used to avoid stack overflows.
-	AuthorizationModelResolutionTooComplex = status.Error(codes.Code(openfgav1.ErrorCode_authorization_model_resolution_too_complex), "Authorization Model resolution required too many rewrite rules to be resolved. Check your authorization model for infinite recursion or too much nesting")
-	InvalidWriteInput                      = status.Error(codes.Code(openfgav1.ErrorCode_invalid_write_input), "Invalid input. Make sure you provide at least one write, or at least one delete")
-	InvalidContinuationToken               = status.Error(codes.Code(openfgav1.ErrorCode_invalid_continuation_token), "Invalid continuation token")
-	InvalidExpandInput                     = status.Error(codes.Code(openfgav1.ErrorCode_invalid_expand_input), "Invalid input. Make sure you provide an object and a relation")
-	UnsupportedUserSet                     = status.Error(codes.Code(openfgav1.ErrorCode_unsupported_user_set), "Userset is not supported (right now)")
-	StoreIDNotFound                        = status.Error(codes.Code(openfgav1.NotFoundErrorCode_store_id_not_found), "Store ID not found")
-	MismatchObjectType                     = status.Error(codes.Code(openfgav1.ErrorCode_query_string_type_continuation_token_mismatch), "The type in the querystring and the continuation token don't match")
-	RequestCancelled                       = status.Error(codes.Code(openfgav1.ErrorCode_cancelled), "Request Cancelled")
-	RequestDeadlineExceeded                = status.Error(codes.Code(openfgav1.InternalErrorCode_deadline_exceeded), "Request Deadline Exceeded")
-	ThrottledTimeout                       = status.Error(codes.Code(openfgav1.UnprocessableContentErrorCode_throttled_timeout_error), "timeout due to throttling on complex request")
+	AuthorizationModelResolutionTooComplex = newError(codes.Code(openfgav1.ErrorCode_authorization_model_resolution_too_complex), "AUTHORIZATION_MODEL_RESOLUTION_TOO_COMPLEX", nil, "Authorization Model resolution required too many rewrite rules to be resolved. Check your authorization model for infinite recursion or too much nesting")
+	InvalidWriteInput                      = newError(codes.Code(openfgav1.ErrorCode_invalid_write_input), "INVALID_WRITE_INPUT", nil, "Invalid input. Make sure you provide at least one write, or at least one delete")
+	InvalidContinuationToken               = newError(codes.Code(openfgav1.ErrorCode_invalid_continuation_token), "INVALID_CONTINUATION_TOKEN", nil, "Invalid continuation token")
+	InvalidExpandInput                     = newError(codes.Code(openfgav1.ErrorCode_invalid_expand_input), "INVALID_EXPAND_INPUT", nil, "Invalid input. Make sure you provide an object and a relation")
+	UnsupportedUserSet                     = newError(codes.Code(openfgav1.ErrorCode_unsupported_user_set), "UNSUPPORTED_USER_SET", nil, "Userset is not supported (right now)")
+	StoreIDNotFound                        = newError(codes.Code(openfgav1.NotFoundErrorCode_store_id_not_found), "STORE_ID_NOT_FOUND", nil, "Store ID not found")
+	MismatchObjectType                     = newError(codes.Code(openfgav1.ErrorCode_query_string_type_continuation_token_mismatch), "QUERY_STRING_TYPE_CONTINUATION_TOKEN_MISMATCH", nil, "The type in the querystring and the continuation token don't match")
+	RequestCancelled                       = newError(codes.Code(openfgav1.ErrorCode_cancelled), "CANCELLED", nil, "Request Cancelled")
+	RequestDeadlineExceeded                = newError(codes.Code(openfgav1.InternalErrorCode_deadline_exceeded), "DEADLINE_EXCEEDED", nil, "Request Deadline Exceeded")
+	ThrottledTimeout                       = newError(codes.Code(openfgav1.UnprocessableContentErrorCode_throttled_timeout_error), "THROTTLED_TIMEOUT", nil, "timeout due to throttling on complex request")
+	ServerReadOnly                         = newError(codes.FailedPrecondition, "SERVER_READ_ONLY", nil, "server is read-only: send writes to a server that is not in read-only mode")
+	ModelAliasesUnsupported                = newError(codes.Unimplemented, "MODEL_ALIASES_UNSUPPORTED", nil, "the datastore does not support authorization model aliases")
+	InvalidStoreID                         = newError(codes.InvalidArgument, "INVALID_STORE_ID", nil, "store ID must be a valid ULID")
+	InvalidAuthorizationModelID            = newError(codes.InvalidArgument, "INVALID_AUTHORIZATION_MODEL_ID", nil, "authorization model ID must be a valid ULID")
+	ContinuationTokenExpired               = newError(codes.FailedPrecondition, "CONTINUATION_TOKEN_EXPIRED", nil, "continuation token expired: the changes it points to were trimmed from the changelog, restart reading from the beginning or from a start time")
 )
 
+// errorInfoDomain is the domain of the ErrorInfo details of the errors.
+const errorInfoDomain = "openfga.dev"
+
+// newError returns a status error with the code and message, and an ErrorInfo detail with the
+// reason and the params of the message. The reason is stable and tells the errors apart, and the
+// params are the values that the message is built from, e.g. limits, field names or tuples, so
+// that clients and message catalogs (see [Localize]) never have to parse the message.
+func newError(code codes.Code, reason string, params map[string]string, message string) error {
+	st := status.New(code, message)
+
+	// the details are marshalled deterministically, so that errors.Is holds between two
+	// errors with the same params despite the metadata map
+	detail := &anypb.Any{}
+	info := &errdetails.ErrorInfo{Reason: reason, Domain: errorInfoDomain, Metadata: params}
+	if err := anypb.MarshalFrom(detail, info, proto.MarshalOptions{Deterministic: true}); err != nil {
+		return st.Err()
+	}
+	stProto := st.Proto()
+	stProto.Details = append(stProto.Details, detail)
+	return status.FromProto(stProto).Err()
+}
+
 type InternalError struct {
 	public   error
 	internal error
@@ -61,58 +94,334 @@ func NewInternalError(public string, internal error) InternalError {
 	}
 
 	return InternalError{
-		public:   status.Error(codes.Code(openfgav1.InternalErrorCode_internal_error), public),
+		public:   newError(codes.Code(openfgav1.InternalErrorCode_internal_error), "INTERNAL_ERROR", nil, public),
 		internal: internal,
 	}
 }
 
+// ValidationError is returned when a request is invalid according to the authorization model.
+// If the cause is, or wraps, an error telling why a tuple does not fit the type restrictions of
+// a relation, the ErrorInfo details of the status carry a reason distinguishing the relations
+// that do not exist (RELATION_NOT_FOUND), the relations without type restrictions
+// (RELATION_NOT_ASSIGNABLE), the user types (USER_TYPE_NOT_ALLOWED) and the typed wildcards
+// (WILDCARD_NOT_ALLOWED) the relation does not allow, and the types that do not exist
+// (TYPE_NOT_FOUND). If the cause is a condition that cannot be evaluated with the context of the
+// request, the reason is CONDITION_CONTEXT_INVALID, with the parameters missing from the context,
+// if any, in the 'missing_parameters' metadata. Otherwise it is VALIDATION_ERROR. If the
+// cause is an invalid tuple, the details also carry the tuple.
 func ValidationError(cause error) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_validation_error), cause.Error())
+	reason, params := validationErrorReason(cause)
+	return newError(codes.Code(openfgav1.ErrorCode_validation_error), reason, params, cause.Error())
+}
+
+// missingParametersMetadataKey is the key of the ErrorInfo metadata of a ValidationError that
+// lists the condition parameters missing from the context of the request.
+const missingParametersMetadataKey = "missing_parameters"
+
+// MissingConditionParameters returns the condition parameters missing from the context of the
+// request that failed with the ValidationError err, or nil if none are missing.
+func MissingConditionParameters(err error) []string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == errorInfoDomain {
+			if missing := info.GetMetadata()[missingParametersMetadataKey]; missing != "" {
+				return strings.Split(missing, ",")
+			}
+		}
+	}
+	return nil
+}
+
+// validationErrorReason returns the reason and params of the ErrorInfo details of a
+// ValidationError.
+func validationErrorReason(cause error) (string, map[string]string) {
+	var (
+		invalidTuple          *tuple.InvalidTupleError
+		typeNotFound          *tuple.TypeNotFoundError
+		relationNotFound      *tuple.RelationNotFoundError
+		relationNotAssignable *tuple.RelationNotAssignableError
+		userTypeNotAllowed    *tuple.UserTypeNotAllowedError
+		wildcardNotAllowed    *tuple.WildcardNotAllowedError
+		parameterType         *condition.ParameterTypeError
+		parameterValue        *condition.ParameterValueError
+		missingParameters     *condition.MissingParametersError
+		evaluation            *condition.EvaluationError
+
+		reason   string
+		metadata map[string]string
+	)
+	switch {
+	case errors.As(cause, &typeNotFound):
+		reason = "TYPE_NOT_FOUND"
+		metadata = map[string]string{"object_type": typeNotFound.TypeName}
+	case errors.As(cause, &relationNotFound):
+		reason = "RELATION_NOT_FOUND"
+		metadata = map[string]string{"object_type": relationNotFound.TypeName, "relation": relationNotFound.Relation}
+	case errors.As(cause, &relationNotAssignable):
+		reason = "RELATION_NOT_ASSIGNABLE"
+		metadata = map[string]string{"object_type": relationNotAssignable.TypeName, "relation": relationNotAssignable.Relation}
+	case errors.As(cause, &userTypeNotAllowed):
+		userType := userTypeNotAllowed.UserType
+		if userTypeNotAllowed.UserRelation != "" {
+			userType = tuple.ToObjectRelationString(userType, userTypeNotAllowed.UserRelation)
+		}
+		reason = "USER_TYPE_NOT_ALLOWED"
+		metadata = map[string]string{"object_type": userTypeNotAllowed.TypeName, "relation": userTypeNotAllowed.Relation, "user_type": userType}
+	case errors.As(cause, &wildcardNotAllowed):
+		reason = "WILDCARD_NOT_ALLOWED"
+		metadata = map[string]string{"object_type": wildcardNotAllowed.TypeName, "relation": wildcardNotAllowed.Relation, "wildcard": wildcardNotAllowed.Wildcard}
+	case errors.As(cause, &parameterType):
+		reason = string(ErrorReasonConditionContextInvalid)
+		metadata = map[string]string{"condition": parameterType.Condition}
+	case errors.As(cause, &parameterValue):
+		reason = string(ErrorReasonConditionContextInvalid)
+		metadata = map[string]string{"parameter": parameterValue.Parameter}
+	case errors.As(cause, &missingParameters):
+		reason = string(ErrorReasonConditionContextInvalid)
+		metadata = map[string]string{missingParametersMetadataKey: strings.Join(missingParameters.Parameters, ",")}
+		if errors.As(cause, &evaluation) {
+			metadata["condition"] = evaluation.Condition
+		}
+	case errors.Is(cause, condition.ErrEvaluationFailed):
+		reason = string(ErrorReasonConditionContextInvalid)
+	default:
+		reason = "VALIDATION_ERROR"
+	}
+
+	if errors.As(cause, &invalidTuple) && invalidTuple.TupleKey != nil {
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+		metadata["tuple"] = tuple.TupleKeyToString(invalidTuple.TupleKey)
+	}
+
+	return reason, metadata
 }
 
 func AssertionsNotForAuthorizationModelFound(modelID string) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_authorization_model_assertions_not_found), fmt.Sprintf("No assertions found for authorization model '%s'", modelID))
+	return newError(codes.Code(openfgav1.ErrorCode_authorization_model_assertions_not_found), "AUTHORIZATION_MODEL_ASSERTIONS_NOT_FOUND",
+		map[string]string{"authorization_model_id": modelID},
+		fmt.Sprintf("No assertions found for authorization model '%s'", modelID))
 }
 
 func AuthorizationModelNotFound(modelID string) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_authorization_model_not_found), fmt.Sprintf("Authorization Model '%s' not found", modelID))
+	return newError(codes.Code(openfgav1.ErrorCode_authorization_model_not_found), "AUTHORIZATION_MODEL_NOT_FOUND",
+		map[string]string{"authorization_model_id": modelID},
+		fmt.Sprintf("Authorization Model '%s' not found", modelID))
+}
+
+// StoreNameAlreadyExists is returned by CreateStore when the server enforces unique store names
+// and the store with the given ID already has the name.
+func StoreNameAlreadyExists(name, storeID string) error {
+	return newError(codes.AlreadyExists, "STORE_NAME_ALREADY_EXISTS",
+		map[string]string{"store_name": name, "store_id": storeID},
+		fmt.Sprintf("a store named '%s' already exists: '%s'", name, storeID))
+}
+
+func AuthorizationModelAliasNotFound(alias string) error {
+	return newError(codes.NotFound, "AUTHORIZATION_MODEL_ALIAS_NOT_FOUND",
+		map[string]string{"alias": alias},
+		fmt.Sprintf("Authorization Model alias '%s' not found", alias))
 }
 
 func LatestAuthorizationModelNotFound(store string) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_latest_authorization_model_not_found), fmt.Sprintf("No authorization models found for store '%s'", store))
+	return newError(codes.Code(openfgav1.ErrorCode_latest_authorization_model_not_found), "LATEST_AUTHORIZATION_MODEL_NOT_FOUND",
+		map[string]string{"store_id": store},
+		fmt.Sprintf("No authorization models found for store '%s'", store))
 }
 
 func TypeNotFound(objectType string) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_type_not_found), fmt.Sprintf("type '%s' not found", objectType))
+	return newError(codes.Code(openfgav1.ErrorCode_type_not_found), "TYPE_NOT_FOUND",
+		map[string]string{"object_type": objectType},
+		fmt.Sprintf("type '%s' not found", objectType))
 }
 
 func RelationNotFound(relation string, objectType string, tk *openfgav1.TupleKey) error {
 	msg := fmt.Sprintf("relation '%s#%s' not found", objectType, relation)
+	params := map[string]string{"object_type": objectType, "relation": relation}
 	if tk != nil {
 		msg += fmt.Sprintf(" for tuple '%s'", tuple.TupleKeyToString(tk))
+		params["tuple"] = tuple.TupleKeyToString(tk)
 	}
 
-	return status.Error(codes.Code(openfgav1.ErrorCode_relation_not_found), msg)
+	return newError(codes.Code(openfgav1.ErrorCode_relation_not_found), "RELATION_NOT_FOUND", params, msg)
 }
 
 func ExceededEntityLimit(entity string, limit int) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_exceeded_entity_limit),
+	return newError(codes.Code(openfgav1.ErrorCode_exceeded_entity_limit), "EXCEEDED_ENTITY_LIMIT",
+		map[string]string{"entity": entity, "limit": strconv.Itoa(limit)},
 		fmt.Sprintf("The number of %s exceeds the allowed limit of %d", entity, limit))
 }
 
+// ListObjectsMaxCandidatesExceeded is returned when a ListObjects request has to consider
+// more candidate objects than the server allows.
+func ListObjectsMaxCandidatesExceeded(limit uint32) error {
+	return newError(codes.ResourceExhausted, "LIST_OBJECTS_MAX_CANDIDATES_EXCEEDED",
+		map[string]string{"limit": strconv.FormatUint(uint64(limit), 10)},
+		fmt.Sprintf("ListObjects exceeded the maximum of %d candidate objects. Narrow the request (e.g. query a more specific user or relation) or raise the limit with the listObjects-max-candidates setting", limit))
+}
+
+// StreamedListObjectsModelChanged is returned when StreamedListObjects ends a stream because
+// the latest authorization model of the store is more than the allowed number of versions
+// newer than the model the stream is evaluated with.
+func StreamedListObjectsModelChanged(modelID, latestModelID string, versions int) error {
+	return newError(codes.Aborted, "AUTHORIZATION_MODEL_CHANGED",
+		map[string]string{
+			"authorization_model_id":        modelID,
+			"latest_authorization_model_id": latestModelID,
+			"versions":                      strconv.Itoa(versions),
+		},
+		fmt.Sprintf("the latest authorization model of the store changed to '%s', %d versions after the model '%s' the stream was evaluated with. Restart the stream to list objects with the latest model", latestModelID, versions, modelID))
+}
+
+// WriteOnStaleAuthorizationModel is returned when Write rejects a request evaluated with an
+// authorization model that is not the latest of the store. The ErrorInfo details of the status
+// carry both model IDs.
+func WriteOnStaleAuthorizationModel(modelID, latestModelID string) error {
+	return newError(codes.FailedPrecondition, "STALE_AUTHORIZATION_MODEL",
+		map[string]string{
+			"authorization_model_id":        modelID,
+			"latest_authorization_model_id": latestModelID,
+		},
+		fmt.Sprintf("the authorization model '%s' is not the latest authorization model '%s' of the store. Write with the latest model, or set the 'Openfga-Write-Allow-Stale-Model' header to 'true' to write with an older one", modelID, latestModelID))
+}
+
+// AuthorizationModelInvalidatesTuples is returned when WriteAuthorizationModel rejects a model in
+// strict impact analysis because it makes existing tuples of the store invalid, described by the
+// findings, which the ErrorInfo details of the status carry separated by newlines.
+func AuthorizationModelInvalidatesTuples(findings []string) error {
+	return newError(codes.FailedPrecondition, "AUTHORIZATION_MODEL_INVALIDATES_TUPLES",
+		map[string]string{"findings": strings.Join(findings, "\n")},
+		fmt.Sprintf("the authorization model makes existing tuples of the store invalid: %s. Delete the tuples first, or write the model without strict impact analysis", strings.Join(findings, "; ")))
+}
+
+// TupleQuotaExceeded is returned when a Write would bring the number of tuples of a store, or of
+// one of its object types if objectType is set, over its quota. The ErrorInfo details of the
+// status carry the quota and the current usage.
+func TupleQuotaExceeded(objectType string, quota, usage int64) error {
+	scope := "the store"
+	if objectType != "" {
+		scope = fmt.Sprintf("the object type '%s'", objectType)
+	}
+	metadata := map[string]string{
+		"quota": strconv.FormatInt(quota, 10),
+		"usage": strconv.FormatInt(usage, 10),
+	}
+	if objectType != "" {
+		metadata["object_type"] = objectType
+	}
+	return newError(codes.ResourceExhausted, "TUPLE_QUOTA_EXCEEDED", metadata,
+		fmt.Sprintf("the write would exceed the quota of %d tuples of %s, which has %d tuples. Delete tuples or raise the quota", quota, scope, usage))
+}
+
+// AuthorizationModelLimitExceeded is returned when WriteAuthorizationModel rejects a model with
+// more of the given entity than the server allows, in the whole model or, if offender is set, in
+// one of its types or relations. The message ends with the hint, and the ErrorInfo details of
+// the status carry the limit, the observed value and the offender.
+func AuthorizationModelLimitExceeded(entity string, limit, value int, offender, hint string) error {
+	scope := "the authorization model"
+	if offender != "" {
+		scope = fmt.Sprintf("'%s'", offender)
+	}
+	metadata := map[string]string{
+		"entity": entity,
+		"limit":  strconv.Itoa(limit),
+		"value":  strconv.Itoa(value),
+	}
+	if offender != "" {
+		metadata["offender"] = offender
+	}
+	return newError(codes.Code(openfgav1.ErrorCode_exceeded_entity_limit), "AUTHORIZATION_MODEL_LIMIT_EXCEEDED", metadata,
+		fmt.Sprintf("%s has %d %s, above the limit of %d. %s", scope, value, entity, limit, hint))
+}
+
+// AuthorizationModelSizeExceeded is returned when WriteAuthorizationModel rejects a model whose
+// size in the request or as stored by the datastore, which includes the generated model ID and the
+// defaulted schema version, is above the limit. The ErrorInfo details of the status carry both
+// sizes and the limit, in bytes.
+func AuthorizationModelSizeExceeded(requestSize, storedSize, limit int) error {
+	return newError(codes.Code(openfgav1.ErrorCode_exceeded_entity_limit), "AUTHORIZATION_MODEL_SIZE_EXCEEDED",
+		map[string]string{
+			"request_size": strconv.Itoa(requestSize),
+			"stored_size":  strconv.Itoa(storedSize),
+			"limit":        strconv.Itoa(limit),
+		},
+		fmt.Sprintf("the authorization model is %d bytes in the request and %d bytes as stored, above the limit of %d bytes. Split the model across several stores, or raise the limit with the max-authorization-model-size-in-bytes setting",
+			requestSize, storedSize, limit))
+}
+
+// ExpandResultLimitExceeded is returned in strict mode when the tree of an Expand request
+// has more of the given entity than the server allows.
+func ExpandResultLimitExceeded(entity string, limit uint32) error {
+	return newError(codes.ResourceExhausted, "EXPAND_RESULT_LIMIT_EXCEEDED",
+		map[string]string{"entity": entity, "limit": strconv.FormatUint(uint64(limit), 10)},
+		fmt.Sprintf("Expand exceeded the maximum of %d %s. Expand a more specific relation or raise the limit with the expand-max-leaf-users and expand-max-nodes settings", limit, entity))
+}
+
+// ReadChangesWatchMaxStreamsExceeded is returned when StreamedReadChanges rejects a stream
+// because the server already serves the maximum number of them.
+func ReadChangesWatchMaxStreamsExceeded(limit uint32) error {
+	return newError(codes.ResourceExhausted, "READ_CHANGES_WATCH_MAX_STREAMS_EXCEEDED",
+		map[string]string{"limit": strconv.FormatUint(uint64(limit), 10)},
+		fmt.Sprintf("the server already serves the maximum of %d StreamedReadChanges streams. Retry later, or raise the limit with the read-changes-watch-max-streams setting", limit))
+}
+
+// DuplicateTupleInWrite is returned when the same tuple appears twice in the writes of a Write
+// request. It has the code of [DuplicateTupleInWrites] and its former message, without the
+// indices of the tuples.
+//
+// Deprecated: Use DuplicateTupleInWrites, which reports the indices of the tuples.
 func DuplicateTupleInWrite(tk tuple.TupleWithoutCondition) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_cannot_allow_duplicate_tuples_in_one_request), fmt.Sprintf("duplicate tuple in write: user: '%s', relation: '%s', object: '%s'", tk.GetUser(), tk.GetRelation(), tk.GetObject()))
+	return newError(codes.Code(openfgav1.ErrorCode_cannot_allow_duplicate_tuples_in_one_request), "DUPLICATE_TUPLE_IN_WRITE",
+		tupleParams(tk, nil),
+		fmt.Sprintf("duplicate tuple in write: user: '%s', relation: '%s', object: '%s'", tk.GetUser(), tk.GetRelation(), tk.GetObject()))
+}
+
+// DuplicateTupleInWrites is returned when the same tuple appears twice in the writes of a Write request.
+func DuplicateTupleInWrites(tk tuple.TupleWithoutCondition, firstIndex, secondIndex int) error {
+	return newError(codes.Code(openfgav1.ErrorCode_cannot_allow_duplicate_tuples_in_one_request), "DUPLICATE_TUPLE_IN_WRITES",
+		tupleParams(tk, map[string]string{"first_index": strconv.Itoa(firstIndex), "second_index": strconv.Itoa(secondIndex)}),
+		fmt.Sprintf("duplicate tuple in writes at indices %d and %d: user: '%s', relation: '%s', object: '%s'", firstIndex, secondIndex, tk.GetUser(), tk.GetRelation(), tk.GetObject()))
+}
+
+// DuplicateTupleInDeletes is returned when the same tuple appears twice in the deletes of a Write request.
+func DuplicateTupleInDeletes(tk tuple.TupleWithoutCondition, firstIndex, secondIndex int) error {
+	return newError(codes.Code(openfgav1.ErrorCode_cannot_allow_duplicate_tuples_in_one_request), "DUPLICATE_TUPLE_IN_DELETES",
+		tupleParams(tk, map[string]string{"first_index": strconv.Itoa(firstIndex), "second_index": strconv.Itoa(secondIndex)}),
+		fmt.Sprintf("duplicate tuple in deletes at indices %d and %d: user: '%s', relation: '%s', object: '%s'", firstIndex, secondIndex, tk.GetUser(), tk.GetRelation(), tk.GetObject()))
+}
+
+// TupleInWritesAndDeletes is returned when a Write request both writes and deletes the same tuple.
+func TupleInWritesAndDeletes(tk tuple.TupleWithoutCondition, writeIndex, deleteIndex int) error {
+	return newError(codes.Code(openfgav1.ErrorCode_cannot_allow_duplicate_tuples_in_one_request), "TUPLE_IN_WRITES_AND_DELETES",
+		tupleParams(tk, map[string]string{"write_index": strconv.Itoa(writeIndex), "delete_index": strconv.Itoa(deleteIndex)}),
+		fmt.Sprintf("tuple is both written at index %d and deleted at index %d: user: '%s', relation: '%s', object: '%s'", writeIndex, deleteIndex, tk.GetUser(), tk.GetRelation(), tk.GetObject()))
+}
+
+// tupleParams returns the params of the ErrorInfo details of an error about a tuple, with the
+// given params.
+func tupleParams(tk tuple.TupleWithoutCondition, params map[string]string) map[string]string {
+	if params == nil {
+		params = map[string]string{}
+	}
+	params["user"] = tk.GetUser()
+	params["relation"] = tk.GetRelation()
+	params["object"] = tk.GetObject()
+	return params
 }
 
 func WriteFailedDueToInvalidInput(err error) error {
 	if err != nil {
-		return status.Error(codes.Code(openfgav1.ErrorCode_write_failed_due_to_invalid_input), err.Error())
+		return newError(codes.Code(openfgav1.ErrorCode_write_failed_due_to_invalid_input), "WRITE_FAILED_DUE_TO_INVALID_INPUT", nil, err.Error())
 	}
-	return status.Error(codes.Code(openfgav1.ErrorCode_write_failed_due_to_invalid_input), "Write failed due to invalid input")
+	return newError(codes.Code(openfgav1.ErrorCode_write_failed_due_to_invalid_input), "WRITE_FAILED_DUE_TO_INVALID_INPUT", nil, "Write failed due to invalid input")
 }
 
 func InvalidAuthorizationModelInput(err error) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_invalid_authorization_model), err.Error())
+	return newError(codes.Code(openfgav1.ErrorCode_invalid_authorization_model), "INVALID_AUTHORIZATION_MODEL", nil, err.Error())
 }
 
 // HandleError is used to surface some errors, and hide others.
@@ -120,13 +429,23 @@ func InvalidAuthorizationModelInput(err error) error {
 func HandleError(public string, err error) error {
 	switch {
 	case errors.Is(err, storage.ErrTransactionalWriteFailed):
-		return status.Error(codes.Aborted, err.Error())
+		return newError(codes.Aborted, "TRANSACTIONAL_WRITE_FAILED", nil, err.Error())
 	case errors.Is(err, storage.ErrInvalidWriteInput):
 		return WriteFailedDueToInvalidInput(err)
 	case errors.Is(err, storage.ErrInvalidContinuationToken):
 		return InvalidContinuationToken
 	case errors.Is(err, storage.ErrMismatchObjectType):
 		return MismatchObjectType
+	case errors.Is(err, storage.ErrContinuationTokenExpired):
+		return ContinuationTokenExpired
+	case errors.Is(err, storage.ErrChangelogDisabled):
+		return newError(codes.FailedPrecondition, "CHANGELOG_DISABLED", nil, err.Error())
+	case errors.Is(err, storage.ErrTupleCountsDisabled):
+		return newError(codes.FailedPrecondition, "TUPLE_COUNTS_DISABLED", nil, err.Error())
+	case errors.Is(err, storage.ErrUnimplemented):
+		return newError(codes.Unimplemented, "UNIMPLEMENTED", nil, err.Error())
+	case errors.Is(err, storage.ErrReadOnly):
+		return ServerReadOnly
 	case errors.Is(err, context.Canceled):
 		// cancel by a client is not an "internal server error"
 		return RequestCancelled
@@ -141,8 +460,10 @@ func HandleError(public string, err error) error {
 func HandleTupleValidateError(err error) error {
 	switch t := err.(type) {
 	case *tuple.InvalidTupleError:
-		return status.Error(
+		return newError(
 			codes.Code(openfgav1.ErrorCode_invalid_tuple),
+			"INVALID_TUPLE",
+			tupleKeyParams(t.TupleKey),
 			fmt.Sprintf("Invalid tuple '%s'. Reason: %s", t.TupleKey, t.Cause.Error()),
 		)
 	case *tuple.TypeNotFoundError:
@@ -150,11 +471,21 @@ func HandleTupleValidateError(err error) error {
 	case *tuple.RelationNotFoundError:
 		return RelationNotFound(t.Relation, t.TypeName, t.TupleKey)
 	case *tuple.InvalidConditionalTupleError:
-		return status.Error(
+		return newError(
 			codes.Code(openfgav1.ErrorCode_validation_error),
+			"INVALID_CONDITIONAL_TUPLE",
+			tupleKeyParams(t.TupleKey),
 			err.Error(),
 		)
 	}
 
 	return HandleError("", err)
 }
+
+// tupleKeyParams returns the params of the ErrorInfo details of an error about a tuple key.
+func tupleKeyParams(tk tuple.TupleWithoutCondition) map[string]string {
+	if tk == nil {
+		return nil
+	}
+	return map[string]string{"tuple": tuple.TupleKeyToString(tk)}
+}
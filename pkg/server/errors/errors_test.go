@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"testing"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/openfga/openfga/internal/condition"
 	errors2 "github.com/openfga/openfga/internal/errors"
 
 	"github.com/openfga/openfga/pkg/storage"
@@ -64,6 +67,10 @@ func TestHandleErrors(t *testing.T) {
 			storageErr:              storage.ErrMismatchObjectType,
 			expectedTranslatedError: MismatchObjectType,
 		},
+		`expired_token_for_read_changes_api`: {
+			storageErr:              storage.ErrContinuationTokenExpired,
+			expectedTranslatedError: ContinuationTokenExpired,
+		},
 		`context_cancelled`: {
 			storageErr:              context.Canceled,
 			expectedTranslatedError: RequestCancelled,
@@ -78,7 +85,7 @@ func TestHandleErrors(t *testing.T) {
 		},
 		`transaction_failed`: {
 			storageErr:              storage.ErrTransactionalWriteFailed,
-			expectedTranslatedError: status.Error(codes.Aborted, storage.ErrTransactionalWriteFailed.Error()),
+			expectedTranslatedError: newError(codes.Aborted, "TRANSACTIONAL_WRITE_FAILED", nil, storage.ErrTransactionalWriteFailed.Error()),
 		},
 	}
 	for testName, test := range tests {
@@ -146,3 +153,83 @@ func TestHandleTupleValidateError(t *testing.T) {
 		})
 	}
 }
+
+func TestValidationErrorDetails(t *testing.T) {
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:jon")
+
+	tests := map[string]struct {
+		cause            error
+		expectedReason   string
+		expectedMetadata map[string]string
+	}{
+		"type_not_found": {
+			cause:            &tuple.TypeNotFoundError{TypeName: "folder"},
+			expectedReason:   "TYPE_NOT_FOUND",
+			expectedMetadata: map[string]string{"object_type": "folder"},
+		},
+		"relation_not_found": {
+			cause:            &tuple.RelationNotFoundError{TypeName: "document", Relation: "viewer"},
+			expectedReason:   "RELATION_NOT_FOUND",
+			expectedMetadata: map[string]string{"object_type": "document", "relation": "viewer"},
+		},
+		"relation_not_assignable": {
+			cause:            &tuple.RelationNotAssignableError{TypeName: "document", Relation: "viewer"},
+			expectedReason:   "RELATION_NOT_ASSIGNABLE",
+			expectedMetadata: map[string]string{"object_type": "document", "relation": "viewer"},
+		},
+		"user_type_not_allowed": {
+			cause:            &tuple.UserTypeNotAllowedError{TypeName: "document", Relation: "viewer", UserType: "group", UserRelation: "member"},
+			expectedReason:   "USER_TYPE_NOT_ALLOWED",
+			expectedMetadata: map[string]string{"object_type": "document", "relation": "viewer", "user_type": "group#member"},
+		},
+		"wildcard_not_allowed": {
+			cause:            &tuple.WildcardNotAllowedError{TypeName: "document", Relation: "viewer", Wildcard: "user:*"},
+			expectedReason:   "WILDCARD_NOT_ALLOWED",
+			expectedMetadata: map[string]string{"object_type": "document", "relation": "viewer", "wildcard": "user:*"},
+		},
+		"condition_parameter_type": {
+			cause:            &condition.ParameterTypeError{Condition: "in_office", Cause: fmt.Errorf("unexpected type")},
+			expectedReason:   "CONDITION_CONTEXT_INVALID",
+			expectedMetadata: map[string]string{"condition": "in_office"},
+		},
+		"condition_parameter_value": {
+			cause:            &condition.ParameterValueError{Parameter: "ip", ExpectedType: "ipaddress", ActualType: "int", Cause: fmt.Errorf("invalid")},
+			expectedReason:   "CONDITION_CONTEXT_INVALID",
+			expectedMetadata: map[string]string{"parameter": "ip"},
+		},
+		"condition_evaluation": {
+			cause:          condition.NewEvaluationError("in_office", fmt.Errorf("context is missing parameters")),
+			expectedReason: "CONDITION_CONTEXT_INVALID",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidationError(&tuple.InvalidTupleError{Cause: test.cause, TupleKey: tk})
+			require.ErrorIs(t, err, ValidationError(&tuple.InvalidTupleError{Cause: test.cause, TupleKey: tk}))
+
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), st.Code())
+			require.Len(t, st.Details(), 1)
+
+			info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			require.Equal(t, test.expectedReason, info.GetReason())
+			// the details also carry the invalid tuple
+			expectedMetadata := map[string]string{"tuple": tuple.TupleKeyToString(tk)}
+			maps.Copy(expectedMetadata, test.expectedMetadata)
+			require.Equal(t, expectedMetadata, info.GetMetadata())
+		})
+	}
+
+	t.Run("other_causes", func(t *testing.T) {
+		st, ok := status.FromError(ValidationError(fmt.Errorf("invalid")))
+		require.True(t, ok)
+		require.Len(t, st.Details(), 1)
+
+		info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		require.Equal(t, "VALIDATION_ERROR", info.GetReason())
+		require.Empty(t, info.GetMetadata())
+	})
+}
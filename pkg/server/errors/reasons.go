@@ -0,0 +1,144 @@
+package errors
+
+import (
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ErrorReason classifies the errors of the Write, Check, ListObjects and ListUsers APIs, so that
+// clients can tell the errors worth retrying once their input is fixed from the permanent ones
+// without matching the error messages. See [WithErrorReason].
+type ErrorReason string
+
+const (
+	// ErrorReasonModelInvalid is the reason of the errors caused by an authorization model that
+	// cannot be used, e.g. of an unsupported schema version.
+	ErrorReasonModelInvalid ErrorReason = "MODEL_INVALID"
+	// ErrorReasonModelNotFound is the reason of the errors caused by a reference to an
+	// authorization model that does not exist, or by a store without authorization model.
+	ErrorReasonModelNotFound ErrorReason = "MODEL_NOT_FOUND"
+	// ErrorReasonTupleInvalid is the reason of the errors caused by a tuple, tuple key or
+	// contextual tuple of the request that the authorization model does not allow, or by a field
+	// of the request that fails the validations of the API.
+	ErrorReasonTupleInvalid ErrorReason = "TUPLE_INVALID"
+	// ErrorReasonConditionContextInvalid is the reason of the errors caused by a condition that
+	// cannot be evaluated with the context of the request, e.g. a missing or mistyped parameter.
+	ErrorReasonConditionContextInvalid ErrorReason = "CONDITION_CONTEXT_INVALID"
+	// ErrorReasonLimitExceeded is the reason of the errors caused by a request exceeding a limit
+	// of the server, e.g. the number of tuples of a Write or the resolution depth of a Check.
+	ErrorReasonLimitExceeded ErrorReason = "LIMIT_EXCEEDED"
+)
+
+// errorReasonsByDetail maps the reasons of the ErrorInfo details that some errors carry to the
+// reasons of [WithErrorReason]. They take precedence over errorReasonsByCode. The reasons of
+// [WithErrorReason] map to themselves, so that it can be applied more than once, e.g. by the
+// server and by the interceptors.
+var errorReasonsByDetail = map[string]ErrorReason{
+	string(ErrorReasonModelInvalid):            ErrorReasonModelInvalid,
+	string(ErrorReasonModelNotFound):           ErrorReasonModelNotFound,
+	string(ErrorReasonTupleInvalid):            ErrorReasonTupleInvalid,
+	string(ErrorReasonLimitExceeded):           ErrorReasonLimitExceeded,
+	"TYPE_NOT_FOUND":                           ErrorReasonTupleInvalid,
+	"RELATION_NOT_FOUND":                       ErrorReasonTupleInvalid,
+	"RELATION_NOT_ASSIGNABLE":                  ErrorReasonTupleInvalid,
+	"USER_TYPE_NOT_ALLOWED":                    ErrorReasonTupleInvalid,
+	"WILDCARD_NOT_ALLOWED":                     ErrorReasonTupleInvalid,
+	"INVALID_REQUEST_FIELD":                    ErrorReasonTupleInvalid,
+	string(ErrorReasonConditionContextInvalid): ErrorReasonConditionContextInvalid,
+	"TUPLE_QUOTA_EXCEEDED":                     ErrorReasonLimitExceeded,
+	"AUTHORIZATION_MODEL_LIMIT_EXCEEDED":       ErrorReasonLimitExceeded,
+	"AUTHORIZATION_MODEL_SIZE_EXCEEDED":        ErrorReasonLimitExceeded,
+	"MESSAGE_SIZE_EXCEEDED":                    ErrorReasonLimitExceeded,
+}
+
+// errorReasonsByCode maps the codes of the errors to the reasons of [WithErrorReason].
+var errorReasonsByCode = map[codes.Code]ErrorReason{
+	codes.Code(openfgav1.ErrorCode_invalid_authorization_model): ErrorReasonModelInvalid,
+	codes.Code(openfgav1.ErrorCode_unsupported_schema_version):  ErrorReasonModelInvalid,
+
+	codes.Code(openfgav1.ErrorCode_authorization_model_not_found):        ErrorReasonModelNotFound,
+	codes.Code(openfgav1.ErrorCode_latest_authorization_model_not_found): ErrorReasonModelNotFound,
+
+	codes.Code(openfgav1.ErrorCode_validation_error):                             ErrorReasonTupleInvalid,
+	codes.Code(openfgav1.ErrorCode_invalid_write_input):                          ErrorReasonTupleInvalid,
+	codes.Code(openfgav1.ErrorCode_cannot_allow_duplicate_tuples_in_one_request): ErrorReasonTupleInvalid,
+	codes.Code(openfgav1.ErrorCode_invalid_check_input):                          ErrorReasonTupleInvalid,
+	codes.Code(openfgav1.ErrorCode_invalid_object_format):                        ErrorReasonTupleInvalid,
+	codes.Code(openfgav1.ErrorCode_write_failed_due_to_invalid_input):            ErrorReasonTupleInvalid,
+	codes.Code(openfgav1.ErrorCode_type_not_found):                               ErrorReasonTupleInvalid,
+	codes.Code(openfgav1.ErrorCode_relation_not_found):                           ErrorReasonTupleInvalid,
+	codes.Code(openfgav1.ErrorCode_invalid_user):                                 ErrorReasonTupleInvalid,
+	codes.Code(openfgav1.ErrorCode_invalid_tuple):                                ErrorReasonTupleInvalid,
+	codes.Code(openfgav1.ErrorCode_unknown_relation):                             ErrorReasonTupleInvalid,
+	codes.Code(openfgav1.ErrorCode_invalid_contextual_tuple):                     ErrorReasonTupleInvalid,
+	codes.Code(openfgav1.ErrorCode_duplicate_contextual_tuple):                   ErrorReasonTupleInvalid,
+
+	codes.Code(openfgav1.ErrorCode_exceeded_entity_limit):                      ErrorReasonLimitExceeded,
+	codes.Code(openfgav1.ErrorCode_authorization_model_resolution_too_complex): ErrorReasonLimitExceeded,
+	codes.ResourceExhausted: ErrorReasonLimitExceeded,
+}
+
+// ErrorReasonOf returns the reason of the error, or "" if it has none, see [WithErrorReason].
+func ErrorReasonOf(err error) ErrorReason {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.OK {
+		return ""
+	}
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			if reason, ok := errorReasonsByDetail[info.GetReason()]; ok {
+				return reason
+			}
+		}
+	}
+	return errorReasonsByCode[st.Code()]
+}
+
+// WithErrorReason returns the error with an ErrorInfo detail whose reason is the
+// [ErrorReason] of the error, or the error unchanged if it has no reason. If the error already
+// carries an ErrorInfo detail, e.g. a [ValidationError], its reason is replaced and kept in the
+// 'detail_reason' metadata. Only gRPC clients receive the details: HTTP responses carry the
+// code and message of the error.
+func WithErrorReason(err error) error {
+	reason := ErrorReasonOf(err)
+	if reason == "" {
+		return err
+	}
+
+	stProto := status.Convert(err).Proto()
+	info := &errdetails.ErrorInfo{Reason: string(reason), Domain: "openfga.dev"}
+	index := len(stProto.GetDetails())
+	for i, detail := range stProto.GetDetails() {
+		existing := &errdetails.ErrorInfo{}
+		if detail.MessageIs(existing) && detail.UnmarshalTo(existing) == nil {
+			info = existing
+			index = i
+			break
+		}
+	}
+
+	if info.GetReason() != string(reason) {
+		if info.Metadata == nil {
+			info.Metadata = map[string]string{}
+		}
+		info.Metadata["detail_reason"] = info.GetReason()
+		info.Reason = string(reason)
+	}
+
+	// the details are marshalled deterministically, see ValidationError
+	detail := &anypb.Any{}
+	if marshalErr := anypb.MarshalFrom(detail, info, proto.MarshalOptions{Deterministic: true}); marshalErr != nil {
+		return err
+	}
+	if index == len(stProto.GetDetails()) {
+		stProto.Details = append(stProto.Details, detail)
+	} else {
+		stProto.Details[index] = detail
+	}
+	return status.FromProto(stProto).Err()
+}
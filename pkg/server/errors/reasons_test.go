@@ -0,0 +1,179 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/internal/condition"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// TestErrorReasons pins the reason of the errors of the Write, Check, ListObjects and ListUsers
+// APIs, so that a refactoring cannot change the reason clients rely on.
+func TestErrorReasons(t *testing.T) {
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:jon")
+
+	tests := map[string]struct {
+		err            error
+		expectedReason ErrorReason
+	}{
+		"invalid_authorization_model": {
+			err:            InvalidAuthorizationModelInput(fmt.Errorf("invalid model")),
+			expectedReason: ErrorReasonModelInvalid,
+		},
+		"unsupported_schema_version": {
+			err:            status.Error(codes.Code(openfgav1.ErrorCode_unsupported_schema_version), "unsupported schema version"),
+			expectedReason: ErrorReasonModelInvalid,
+		},
+		"authorization_model_not_found": {
+			err:            AuthorizationModelNotFound("01HVMMBCMGZNT3SED4Z17ECXCA"),
+			expectedReason: ErrorReasonModelNotFound,
+		},
+		"latest_authorization_model_not_found": {
+			err:            LatestAuthorizationModelNotFound("01HVMMBCMGZNT3SED4Z17ECXCA"),
+			expectedReason: ErrorReasonModelNotFound,
+		},
+		"validation_error": {
+			err:            ValidationError(fmt.Errorf("invalid")),
+			expectedReason: ErrorReasonTupleInvalid,
+		},
+		"validation_error_with_details": {
+			err:            ValidationError(&tuple.InvalidTupleError{Cause: &tuple.RelationNotAssignableError{TypeName: "document", Relation: "viewer"}, TupleKey: tk}),
+			expectedReason: ErrorReasonTupleInvalid,
+		},
+		"invalid_tuple": {
+			err:            HandleTupleValidateError(&tuple.InvalidTupleError{Cause: fmt.Errorf("invalid"), TupleKey: tk}),
+			expectedReason: ErrorReasonTupleInvalid,
+		},
+		"invalid_conditional_tuple": {
+			err:            HandleTupleValidateError(&tuple.InvalidConditionalTupleError{Cause: fmt.Errorf("invalid"), TupleKey: tk}),
+			expectedReason: ErrorReasonTupleInvalid,
+		},
+		"type_not_found": {
+			err:            TypeNotFound("folder"),
+			expectedReason: ErrorReasonTupleInvalid,
+		},
+		"relation_not_found": {
+			err:            RelationNotFound("editor", "document", tk),
+			expectedReason: ErrorReasonTupleInvalid,
+		},
+		"invalid_write_input": {
+			err:            InvalidWriteInput,
+			expectedReason: ErrorReasonTupleInvalid,
+		},
+		"write_failed_due_to_invalid_input": {
+			err:            WriteFailedDueToInvalidInput(nil),
+			expectedReason: ErrorReasonTupleInvalid,
+		},
+		"duplicate_tuple_in_writes": {
+			err:            DuplicateTupleInWrites(tk, 0, 1),
+			expectedReason: ErrorReasonTupleInvalid,
+		},
+		"tuple_in_writes_and_deletes": {
+			err:            TupleInWritesAndDeletes(tk, 0, 0),
+			expectedReason: ErrorReasonTupleInvalid,
+		},
+		"invalid_request_field": {
+			err:            InvalidRequestField("TupleKey.Object", "value does not match regex pattern"),
+			expectedReason: ErrorReasonTupleInvalid,
+		},
+		"condition_context": {
+			err:            ValidationError(condition.NewEvaluationError("in_office", fmt.Errorf("context is missing parameters"))),
+			expectedReason: ErrorReasonConditionContextInvalid,
+		},
+		"exceeded_entity_limit": {
+			err:            ExceededEntityLimit("write operations", 100),
+			expectedReason: ErrorReasonLimitExceeded,
+		},
+		"resolution_too_complex": {
+			err:            AuthorizationModelResolutionTooComplex,
+			expectedReason: ErrorReasonLimitExceeded,
+		},
+		"list_objects_max_candidates": {
+			err:            ListObjectsMaxCandidatesExceeded(1000),
+			expectedReason: ErrorReasonLimitExceeded,
+		},
+		"authorization_model_limit": {
+			err:            AuthorizationModelLimitExceeded("relations", 10, 11, "document", "Split it"),
+			expectedReason: ErrorReasonLimitExceeded,
+		},
+		"authorization_model_size": {
+			err:            AuthorizationModelSizeExceeded(1030, 1035, 1032),
+			expectedReason: ErrorReasonLimitExceeded,
+		},
+		"message_size": {
+			err:            MessageSizeExceeded(openfgav1.OpenFGAService_Write_FullMethodName, 2048, 1024),
+			expectedReason: ErrorReasonLimitExceeded,
+		},
+		"tuple_quota": {
+			err:            TupleQuotaExceeded("document", 10, 10),
+			expectedReason: ErrorReasonLimitExceeded,
+		},
+		"stale_authorization_model": {
+			err: WriteOnStaleAuthorizationModel("01HVMMBCMGZNT3SED4Z17ECXCA", "01HVMMBCMGZNT3SED4Z17ECXCB"),
+		},
+		"throttled": {
+			err: ThrottledTimeout,
+		},
+		"internal": {
+			err: HandleError("", fmt.Errorf("boom")),
+		},
+		"cancelled": {
+			err: HandleError("", context.Canceled),
+		},
+		"not_a_status": {
+			err: fmt.Errorf("boom"),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expectedReason, ErrorReasonOf(test.err))
+
+			err := WithErrorReason(test.err)
+			if test.expectedReason == "" {
+				require.Equal(t, test.err, err)
+				return
+			}
+
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			require.Equal(t, status.Code(test.err), st.Code())
+			require.Equal(t, status.Convert(test.err).Message(), st.Message())
+
+			var infos []*errdetails.ErrorInfo
+			for _, detail := range st.Details() {
+				if info, ok := detail.(*errdetails.ErrorInfo); ok {
+					infos = append(infos, info)
+				}
+			}
+			require.Len(t, infos, 1)
+			require.Equal(t, string(test.expectedReason), infos[0].GetReason())
+			require.Equal(t, "openfga.dev", infos[0].GetDomain())
+
+			// the reason is stable
+			require.Equal(t, test.expectedReason, ErrorReasonOf(err))
+			require.Equal(t, err, WithErrorReason(err))
+		})
+	}
+
+	t.Run("detail_reason_is_kept", func(t *testing.T) {
+		err := WithErrorReason(TupleQuotaExceeded("document", 10, 10))
+
+		info, ok := status.Convert(err).Details()[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		require.Equal(t, "LIMIT_EXCEEDED", info.GetReason())
+		require.Equal(t, map[string]string{
+			"detail_reason": "TUPLE_QUOTA_EXCEEDED",
+			"object_type":   "document",
+			"quota":         "10",
+			"usage":         "10",
+		}, info.GetMetadata())
+	})
+}
@@ -0,0 +1,189 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// The errors of the requests that the server rejects before reading the model, e.g. for their
+// fields, headers or caller. Like the other errors, they carry an ErrorInfo detail with a stable
+// reason and the params of their message, see newError.
+var (
+	BatchCheckEmpty                           = newError(codes.InvalidArgument, "BATCH_CHECK_EMPTY", nil, "the batch must have at least one check")
+	BatchCheckCorrelationIDMissing            = newError(codes.InvalidArgument, "BATCH_CHECK_CORRELATION_ID_MISSING", nil, "each check of the batch must have a correlation ID")
+	ReadChangesStartTimeWithContinuationToken = newError(codes.InvalidArgument, "START_TIME_WITH_CONTINUATION_TOKEN", nil, "the start time cannot be combined with a continuation token")
+	AdminTokenRequired                        = newError(codes.PermissionDenied, "ADMIN_TOKEN_REQUIRED", nil, "deleting tuples by filter requires the admin token as a bearer token, see the admin-token setting")
+	QueryShapeSamplingUnsupported             = newError(codes.Unimplemented, "QUERY_SHAPE_SAMPLING_UNSUPPORTED", nil, "the datastore does not sample the shapes of its queries")
+	TupleCountsUnsupported                    = newError(codes.Unimplemented, "TUPLE_COUNTS_UNSUPPORTED", nil, "the datastore does not count the tuples of the stores")
+	StoreCountsUnsupported                    = newError(codes.Unimplemented, "STORE_COUNTS_UNSUPPORTED", nil, "the datastore cannot count the stores")
+)
+
+// fieldValidationError is implemented by the errors of the Validate methods of the requests of
+// the API.
+type fieldValidationError interface {
+	error
+	Field() string
+	Reason() string
+	Cause() error
+}
+
+// RequestValidationError is returned when the fields of a request fail the validations of the
+// API, with err the error of the Validate, ValidateAll or Validate(all) method of the request.
+// The reason is INVALID_REQUEST_FIELD, with the path of the first invalid field, e.g.
+// 'TupleKey.Object', in the 'field' param and why it is invalid in the 'field_reason' param.
+func RequestValidationError(err error) error {
+	fieldErr, ok := firstFieldValidationError(err)
+	if !ok {
+		return newError(codes.InvalidArgument, "INVALID_REQUEST_FIELD", nil, err.Error())
+	}
+
+	path := []string{fieldErr.Field()}
+	reason := fieldErr.Reason()
+	for {
+		cause, ok := firstFieldValidationError(fieldErr.Cause())
+		if !ok {
+			break
+		}
+		fieldErr = cause
+		path = append(path, fieldErr.Field())
+		reason = fieldErr.Reason()
+	}
+	return newError(codes.InvalidArgument, "INVALID_REQUEST_FIELD",
+		map[string]string{"field": strings.Join(path, "."), "field_reason": reason}, err.Error())
+}
+
+// firstFieldValidationError returns the field validation error of err, or the first one if err
+// lists the errors of several fields, like the errors of the ValidateAll methods.
+func firstFieldValidationError(err error) (fieldValidationError, bool) {
+	if multi, ok := err.(interface{ AllErrors() []error }); ok {
+		if len(multi.AllErrors()) == 0 {
+			return nil, false
+		}
+		err = multi.AllErrors()[0]
+	}
+	var fieldErr fieldValidationError
+	if err == nil || !errors.As(err, &fieldErr) {
+		return nil, false
+	}
+	return fieldErr, true
+}
+
+// InvalidRequestField is returned when a field of a request that the API does not validate is
+// invalid, e.g. of the requests of the services that are not part of the OpenFGA API.
+func InvalidRequestField(field, reason string) error {
+	return newError(codes.InvalidArgument, "INVALID_REQUEST_FIELD",
+		map[string]string{"field": field, "field_reason": reason},
+		fmt.Sprintf("invalid field '%s': %s", field, reason))
+}
+
+// RequiredFieldsMissing is returned when a request misses fields that it requires, e.g. the user
+// type of GetReachableRelations. The fields are in the 'fields' param, separated by commas.
+func RequiredFieldsMissing(message string, fields ...string) error {
+	return newError(codes.InvalidArgument, "REQUIRED_FIELDS_MISSING",
+		map[string]string{"fields": strings.Join(fields, ",")}, message)
+}
+
+// InvalidHeaderValue is returned when a request header has an invalid value, or is set more
+// than once, with cause the error telling why.
+func InvalidHeaderValue(header, value string, cause error) error {
+	return newError(codes.InvalidArgument, "INVALID_HEADER_VALUE",
+		map[string]string{"header": header, "value": value}, cause.Error())
+}
+
+// UnknownRequestHeaders is returned in strict mode when a request has headers with the
+// requestcontext.HeaderPrefix that the server does not know.
+func UnknownRequestHeaders(headers []string) error {
+	return newError(codes.InvalidArgument, "UNKNOWN_REQUEST_HEADERS",
+		map[string]string{"headers": strings.Join(headers, ",")},
+		fmt.Sprintf("unknown request headers: %s", strings.Join(headers, ", ")))
+}
+
+// HeaderNotAllowed is returned when the client of a request is not allowed to set one of its
+// headers.
+func HeaderNotAllowed(header string) error {
+	return newError(codes.PermissionDenied, "HEADER_NOT_ALLOWED",
+		map[string]string{"header": header},
+		fmt.Sprintf("the '%s' header is not allowed for this client", header))
+}
+
+// ExperimentalFlagRequired is returned when a request header needs an experimental flag that
+// the server does not enable.
+func ExperimentalFlagRequired(header, flag string) error {
+	return newError(codes.FailedPrecondition, "EXPERIMENTAL_FLAG_REQUIRED",
+		map[string]string{"header": header, "flag": flag},
+		fmt.Sprintf("the '%s' header requires the '%s' experimental flag", header, flag))
+}
+
+// LoopbackCallerRequired is returned when a method that is only allowed for in-process and
+// loopback callers is called from another address.
+func LoopbackCallerRequired(method, message string) error {
+	return newError(codes.PermissionDenied, "LOOPBACK_CALLER_REQUIRED",
+		map[string]string{"method": method}, message)
+}
+
+// MessageSizeExceeded is returned when a request message is larger than the limit of its method,
+// like the error of the gRPC transport for the messages larger than the limit of the server.
+func MessageSizeExceeded(method string, size, limit int) error {
+	return newError(codes.ResourceExhausted, "MESSAGE_SIZE_EXCEEDED",
+		map[string]string{"method": method, "size": strconv.Itoa(size), "limit": strconv.Itoa(limit)},
+		fmt.Sprintf("grpc: received message larger than max (%d vs. %d)", size, limit))
+}
+
+// BatchCheckSizeExceeded is returned when a batch has more checks than the server allows.
+func BatchCheckSizeExceeded(checks, limit int) error {
+	return newError(codes.InvalidArgument, "BATCH_CHECK_SIZE_EXCEEDED",
+		map[string]string{"checks": strconv.Itoa(checks), "limit": strconv.Itoa(limit)},
+		fmt.Sprintf("the batch has %d checks, more than the maximum of %d", checks, limit))
+}
+
+// BatchCheckDuplicateCorrelationID is returned when several checks of a batch have the same
+// correlation ID.
+func BatchCheckDuplicateCorrelationID(correlationID string) error {
+	return newError(codes.InvalidArgument, "BATCH_CHECK_DUPLICATE_CORRELATION_ID",
+		map[string]string{"correlation_id": correlationID},
+		fmt.Sprintf("the correlation ID '%s' is used by more than one check of the batch", correlationID))
+}
+
+// PointInTimeInFuture is returned when the point in time of a Check is in the future.
+func PointInTimeInFuture(at time.Time) error {
+	pointInTime := at.Format(time.RFC3339Nano)
+	return newError(codes.InvalidArgument, "POINT_IN_TIME_IN_FUTURE",
+		map[string]string{"point_in_time": pointInTime},
+		fmt.Sprintf("the point in time '%s' is in the future", pointInTime))
+}
+
+// PointInTimeBeyondHorizon is returned when the point in time of a Check is older than the
+// horizon of the server.
+func PointInTimeBeyondHorizon(at time.Time, horizon time.Duration) error {
+	pointInTime := at.Format(time.RFC3339Nano)
+	return newError(codes.InvalidArgument, "POINT_IN_TIME_BEYOND_HORIZON",
+		map[string]string{"point_in_time": pointInTime, "horizon": horizon.String()},
+		fmt.Sprintf("the point in time '%s' is older than the horizon of %s", pointInTime, horizon))
+}
+
+// PointInTimeUnavailable is returned when the tuples of a store cannot be rebuilt at the point
+// in time of a Check, with cause the error telling why.
+func PointInTimeUnavailable(cause error) error {
+	return newError(codes.FailedPrecondition, "POINT_IN_TIME_UNAVAILABLE", nil, cause.Error())
+}
+
+// QueryShapeSamplingDisabled is returned when the index advice is read while the datastore does
+// not sample the shapes of its queries.
+func QueryShapeSamplingDisabled(cause error) error {
+	return newError(codes.FailedPrecondition, "QUERY_SHAPE_SAMPLING_DISABLED", nil,
+		cause.Error()+"; enable it with the datastore-query-shape-sampling setting")
+}
+
+// AuthorizationModelSchemaNotMigratable is returned when the schema of an authorization model
+// cannot be migrated, i.e. it is not of the given schema version.
+func AuthorizationModelSchemaNotMigratable(modelID, schemaVersion, migratableSchemaVersion string) error {
+	return newError(codes.FailedPrecondition, "AUTHORIZATION_MODEL_SCHEMA_NOT_MIGRATABLE",
+		map[string]string{"authorization_model_id": modelID, "schema_version": schemaVersion},
+		fmt.Sprintf("authorization model '%s' has schema version %s: only models of schema version %s can be migrated",
+			modelID, schemaVersion, migratableSchemaVersion))
+}
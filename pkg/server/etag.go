@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+
+	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
+)
+
+// modelETag returns the entity tag of the responses with the authorization model of the given ID.
+// Models are immutable, so their ID identifies the content of the response.
+func modelETag(modelID string) string {
+	return strconv.Quote(modelID)
+}
+
+// modelsPageETag returns the entity tag of a page of ReadAuthorizationModels. Models are
+// immutable and listed from the latest, so the first model of a page, the page size and the
+// continuation token the page was read from identify its content.
+func modelsPageETag(firstModelID string, pageSize int32, continuationToken string) string {
+	hash := sha256.Sum256([]byte(firstModelID + "\x00" + strconv.Itoa(int(pageSize)) + "\x00" + continuationToken))
+	return strconv.Quote(hex.EncodeToString(hash[:16]))
+}
+
+// ifNoneMatch reports whether the IfNoneMatchHeader of the request matches the entity tag, i.e.
+// whether the client already has the content of the response. Entity tags are compared weakly,
+// as required for If-None-Match. The "*" wildcard, which matches any existing content, is only
+// matched if wildcard is true, i.e. once the content is known to exist.
+func ifNoneMatch(ctx context.Context, etag string, wildcard bool) bool {
+	for _, value := range metadata.ValueFromIncomingContext(ctx, IfNoneMatchHeader) {
+		for _, candidate := range strings.Split(value, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if (wildcard && candidate == "*") || candidate == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setNotModified marks the response as not modified: it has no content, and the gateway responds
+// with a 304 status.
+func (s *Server) setNotModified(ctx context.Context, etag string) {
+	s.transport.SetHeader(ctx, ETagHeader, etag)
+	s.transport.SetHeader(ctx, httpmiddleware.XHttpCode, strconv.Itoa(http.StatusNotModified))
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+// ExperimentalFeatureStage is the stage of the lifecycle of an experimental feature.
+type ExperimentalFeatureStage string
+
+const (
+	// ExperimentalStageExperimental features may change or be removed in any release.
+	ExperimentalStageExperimental ExperimentalFeatureStage = "experimental"
+	// ExperimentalStageBeta features are expected to graduate without breaking changes.
+	ExperimentalStageBeta ExperimentalFeatureStage = "beta"
+	// ExperimentalStageDeprecated features are going to be removed, see
+	// [ExperimentalFeature.RemovalVersion]. The requests that depend on them get the
+	// DeprecationHeader and SunsetHeader response headers.
+	ExperimentalStageDeprecated ExperimentalFeatureStage = "deprecated"
+)
+
+const (
+	// DeprecationHeader is the response header of the requests that depend on a deprecated
+	// experimental flag, with the time it was deprecated as in RFC 9745, e.g. "@1735689600".
+	DeprecationHeader = "Deprecation"
+	// SunsetHeader is the response header of the requests that depend on a deprecated
+	// experimental flag with a sunset date, after which the flag may be removed, as an HTTP date
+	// (RFC 8594).
+	SunsetHeader = "Sunset"
+
+	// deprecatedExperimentalLogInterval bounds how often the use of each deprecated experimental
+	// flag is logged.
+	deprecatedExperimentalLogInterval = time.Minute
+)
+
+// ExperimentalFeature is the lifecycle metadata of an experimental flag.
+type ExperimentalFeature struct {
+	Flag  ExperimentalFeatureFlag
+	Stage ExperimentalFeatureStage
+	// DeprecatedAt is when a deprecated flag was deprecated, and RemovalVersion the version of
+	// the server that removes it.
+	DeprecatedAt   time.Time
+	RemovalVersion string
+	// Sunset is the date after which a deprecated flag may be removed, if known.
+	Sunset time.Time
+}
+
+// defaultExperimentalFeatures is the registry of the experimental flags known to the server. It is
+// never modified: each server has its own copy, see [WithExperimentalFeatures].
+var defaultExperimentalFeatures = map[ExperimentalFeatureFlag]ExperimentalFeature{
+	ExperimentalCheckDenialReasons: {
+		Flag:  ExperimentalCheckDenialReasons,
+		Stage: ExperimentalStageExperimental,
+	},
+	ExperimentalPointInTimeCheck: {
+		Flag:  ExperimentalPointInTimeCheck,
+		Stage: ExperimentalStageExperimental,
+	},
+}
+
+var deprecatedExperimentalUseCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "deprecated_experimental_flag_use_count",
+	Help:      "The total number of requests that depended on a deprecated experimental flag, to find its remaining users before it is removed.",
+}, []string{"flag", "grpc_service", "grpc_method"})
+
+// LookupExperimentalFeature returns the lifecycle metadata of an experimental flag, or false if
+// the server does not know the flag.
+func LookupExperimentalFeature(flag ExperimentalFeatureFlag) (ExperimentalFeature, bool) {
+	feature, ok := defaultExperimentalFeatures[flag]
+	return feature, ok
+}
+
+// ExperimentalFeatures returns the lifecycle metadata of the experimental flags known to the
+// server, sorted by flag.
+func ExperimentalFeatures() []ExperimentalFeature {
+	features := make([]ExperimentalFeature, 0, len(defaultExperimentalFeatures))
+	for _, feature := range defaultExperimentalFeatures {
+		features = append(features, feature)
+	}
+	slices.SortFunc(features, func(a, b ExperimentalFeature) int {
+		return cmp.Compare(a.Flag, b.Flag)
+	})
+	return features
+}
+
+// warnAboutExperimentals logs a warning for each enabled flag that the server does not know, e.g.
+// because it graduated or was removed, and for each deprecated one.
+func (s *Server) warnAboutExperimentals() {
+	for _, flag := range s.experimentals {
+		feature, ok := s.experimentalFeatures[flag]
+		switch {
+		case !ok:
+			s.logger.Warn("unknown experimental flag, it has no effect", zap.String("flag", string(flag)))
+		case feature.Stage == ExperimentalStageDeprecated:
+			s.logger.Warn("deprecated experimental flag enabled", deprecatedExperimentalFields(feature)...)
+		}
+	}
+}
+
+// experimentallyEnabled is [Server.IsExperimentallyEnabled] for the handlers of requests whose
+// behavior depends on the flag. When the flag is deprecated and enabled, it also sets the
+// DeprecationHeader and SunsetHeader response headers, counts the use of the flag and logs a
+// warning, at most once per deprecatedExperimentalLogInterval per flag.
+func (s *Server) experimentallyEnabled(ctx context.Context, flag ExperimentalFeatureFlag) bool {
+	if !s.IsExperimentallyEnabled(flag) {
+		return false
+	}
+
+	feature, ok := s.experimentalFeatures[flag]
+	if !ok || feature.Stage != ExperimentalStageDeprecated {
+		return true
+	}
+
+	s.transport.SetHeader(ctx, DeprecationHeader, "@"+strconv.FormatInt(feature.DeprecatedAt.Unix(), 10))
+	if !feature.Sunset.IsZero() {
+		s.transport.SetHeader(ctx, SunsetHeader, feature.Sunset.UTC().Format(http.TimeFormat))
+	}
+
+	rpcInfo := telemetry.RPCInfoFromContext(ctx)
+	deprecatedExperimentalUseCounter.WithLabelValues(string(flag), rpcInfo.Service, rpcInfo.Method).Inc()
+
+	if s.deprecatedExperimentalLogs.allow(flag, time.Now()) {
+		fields := append(deprecatedExperimentalFields(feature), zap.String("grpc_method", rpcInfo.Method))
+		s.logger.WarnWithContext(ctx, "a request depended on a deprecated experimental flag", fields...)
+	}
+
+	return true
+}
+
+func deprecatedExperimentalFields(feature ExperimentalFeature) []zap.Field {
+	fields := []zap.Field{
+		zap.String("flag", string(feature.Flag)),
+		zap.String("removal_version", feature.RemovalVersion),
+	}
+	if !feature.Sunset.IsZero() {
+		fields = append(fields, zap.String("sunset", feature.Sunset.UTC().Format(time.DateOnly)))
+	}
+	return fields
+}
+
+// experimentalLogLimiter limits the logs of the use of each deprecated experimental flag to one
+// per deprecatedExperimentalLogInterval. Its zero value is ready to use.
+type experimentalLogLimiter struct {
+	mu     sync.Mutex
+	logged map[ExperimentalFeatureFlag]time.Time
+}
+
+// allow reports whether the use of the flag at now should be logged.
+func (l *experimentalLogLimiter) allow(flag ExperimentalFeatureFlag, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if last, ok := l.logged[flag]; ok && now.Sub(last) < deprecatedExperimentalLogInterval {
+		return false
+	}
+	if l.logged == nil {
+		l.logged = make(map[ExperimentalFeatureFlag]time.Time)
+	}
+	l.logged[flag] = now
+	return true
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+// AdviseIndexes reports the distribution of the shapes of the tuple queries of the datastore,
+// i.e. how many queries of each method filtered on which columns and how many rows they
+// returned, and the indexes of the tuple table that they call for, with the DDL that creates
+// them. No identifier is recorded, only the columns filtered on.
+//
+// It requires a datastore that implements [storage.IndexAdvisor], as the SQL datastores do when
+// their query shape sampling is enabled (the datastore-query-shape-sampling setting). Otherwise
+// it fails with Unimplemented or FailedPrecondition respectively.
+//
+// Only loopback callers are allowed, see [isLoopbackCaller].
+func (s *Server) AdviseIndexes(ctx context.Context) (_ *storage.IndexAdvice, err error) {
+	ctx, span := tracer.Start(ctx, "AdviseIndexes")
+	defer span.End()
+	defer s.inFlightRequests.start("AdviseIndexes")()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, "AdviseIndexes", nil, &err)
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "AdviseIndexes",
+	})
+
+	if !isLoopbackCaller(ctx) {
+		span.SetAttributes(attribute.Bool("permission_denied", true))
+		return nil, serverErrors.LoopbackCallerRequired("AdviseIndexes", "index advice can only be read from a loopback address")
+	}
+
+	if s.indexAdvisor == nil {
+		return nil, serverErrors.QueryShapeSamplingUnsupported
+	}
+
+	advice, err := s.indexAdvisor.AdviseIndexes(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrQueryShapeSamplingDisabled) {
+			return nil, serverErrors.QueryShapeSamplingDisabled(err)
+		}
+		telemetry.TraceError(span, err)
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("shapes", len(advice.Shapes)),
+		attribute.Int("recommendations", len(advice.Recommendations)),
+	)
+
+	return &advice, nil
+}
+
+// GetIndexAdvice serves [Server.AdviseIndexes] as the method of the [indexadvice.ServiceDesc]
+// gRPC service, e.g. for the index-advice command.
+func (s *Server) GetIndexAdvice(ctx context.Context, req *emptypb.Empty) (_ *structpb.Struct, err error) {
+	defer s.recoverFromPanic(ctx, "GetIndexAdvice", req, &err)
+
+	advice, err := s.AdviseIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonStruct(advice, "the index advice")
+}
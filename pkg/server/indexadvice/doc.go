@@ -0,0 +1,3 @@
+// Package indexadvice describes the gRPC service that reads the indexes recommended for the
+// query shapes sampled by the datastore, which the OpenFGA API does not define.
+package indexadvice
@@ -0,0 +1,55 @@
+package indexadvice
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// ServiceName is the name of the gRPC service of GetIndexAdvice. It is not part of the
+	// openfga.v1 package of the OpenFGA API.
+	ServiceName = "openfga.server.indexadvice.v1.IndexAdviceService"
+
+	// GetIndexAdviceFullMethodName is the full gRPC method name of GetIndexAdvice.
+	GetIndexAdviceFullMethodName = "/" + ServiceName + "/GetIndexAdvice"
+)
+
+// ServiceDesc describes the gRPC service of GetIndexAdvice. As the OpenFGA API has no messages
+// for it, the request is Empty and the response is a Struct with the fields of the JSON form of
+// the advice, e.g. {"datastore": "postgres", "shapes": [...], "recommendations": [...]}.
+var ServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*IndexAdviceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetIndexAdvice",
+			Handler:    getIndexAdviceHandler,
+		},
+	},
+}
+
+// IndexAdviceServer is the server API of the service of ServiceDesc.
+type IndexAdviceServer interface {
+	GetIndexAdvice(context.Context, *emptypb.Empty) (*structpb.Struct, error)
+}
+
+func getIndexAdviceHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexAdviceServer).GetIndexAdvice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetIndexAdviceFullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexAdviceServer).GetIndexAdvice(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
@@ -7,14 +7,11 @@ import (
 	"time"
 
 	"github.com/openfga/openfga/internal/throttler/threshold"
-	"github.com/openfga/openfga/internal/utils"
 
 	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 
 	"github.com/openfga/openfga/internal/condition"
 	"github.com/openfga/openfga/internal/graph"
@@ -32,7 +29,7 @@ import (
 func (s *Server) ListUsers(
 	ctx context.Context,
 	req *openfgav1.ListUsersRequest,
-) (*openfgav1.ListUsersResponse, error) {
+) (_ *openfgav1.ListUsersResponse, err error) {
 	start := time.Now()
 	ctx, span := tracer.Start(ctx, "ListUsers", trace.WithAttributes(
 		attribute.String("store_id", req.GetStoreId()),
@@ -42,10 +39,17 @@ func (s *Server) ListUsers(
 		attribute.String("consistency", req.GetConsistency().String()),
 	))
 	defer span.End()
+	defer s.setErrorReason(&err)
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_ListUsers_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_ListUsers_FullMethodName, req, &err)
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return nil, err
+		}
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -70,11 +74,13 @@ func (s *Server) ListUsers(
 		listusers.WithListUsersMaxResults(s.listUsersMaxResults),
 		listusers.WithListUsersDeadline(s.listUsersDeadline),
 		listusers.WithListUsersMaxConcurrentReads(s.maxConcurrentReadsForListUsers),
+		listusers.WithListUsersDatastoreHedging(s.datastoreHedgingPolicy),
 		listusers.WithDispatchThrottlerConfig(threshold.Config{
-			Throttler:    s.listUsersDispatchThrottler,
-			Enabled:      s.listUsersDispatchThrottlingEnabled,
-			Threshold:    s.listUsersDispatchDefaultThreshold,
-			MaxThreshold: s.listUsersDispatchThrottlingMaxThreshold,
+			Throttler:                   s.listUsersDispatchThrottler,
+			Enabled:                     s.listUsersDispatchThrottlingEnabled,
+			Threshold:                   s.listUsersDispatchDefaultThreshold,
+			MaxThreshold:                s.listUsersDispatchThrottlingMaxThreshold,
+			LowPriorityDeprioritization: s.lowPriorityDeprioritization(),
 		}),
 	)
 
@@ -92,34 +98,13 @@ func (s *Server) ListUsers(
 		}
 	}
 
-	datastoreQueryCount := float64(resp.Metadata.DatastoreQueryCount)
-
-	grpc_ctxtags.Extract(ctx).Set(datastoreQueryCountHistogramName, datastoreQueryCount)
-	span.SetAttributes(attribute.Float64(datastoreQueryCountHistogramName, datastoreQueryCount))
-	datastoreQueryCountHistogram.WithLabelValues(
-		s.serviceName,
-		methodName,
-	).Observe(datastoreQueryCount)
-
-	dispatchCount := float64(resp.Metadata.DispatchCounter.Load())
-	grpc_ctxtags.Extract(ctx).Set(dispatchCountHistogramName, dispatchCount)
-	span.SetAttributes(attribute.Float64(dispatchCountHistogramName, dispatchCount))
-	dispatchCountHistogram.WithLabelValues(
-		s.serviceName,
-		methodName,
-	).Observe(dispatchCount)
-
-	requestDurationHistogram.WithLabelValues(
-		s.serviceName,
-		methodName,
-		utils.Bucketize(uint(datastoreQueryCount), s.requestDurationByQueryHistogramBuckets),
-		utils.Bucketize(uint(dispatchCount), s.requestDurationByDispatchCountHistogramBuckets),
-		req.GetConsistency().String(),
-	).Observe(float64(time.Since(start).Milliseconds()))
-
-	wasRequestThrottled := resp.GetMetadata().WasThrottled.Load()
-	if wasRequestThrottled {
-		throttledRequestCounter.WithLabelValues(s.serviceName, methodName).Inc()
+	s.observeResolutionMetadata(ctx, methodName, req.GetConsistency(), start, resp.GetMetadata().ResolutionMetadata)
+
+	if truncationCause := resp.GetMetadata().TruncationCause; truncationCause != listusers.TruncationCauseNone {
+		grpc_ctxtags.Extract(ctx).Set("truncation_cause", string(truncationCause))
+		span.SetAttributes(attribute.String("truncation_cause", string(truncationCause)))
+		truncatedRequestCounter.WithLabelValues(s.serviceName, methodName, string(truncationCause)).Inc()
+		s.transport.SetHeader(ctx, ListUsersTruncatedHeader, string(truncationCause))
 	}
 
 	return &openfgav1.ListUsersResponse{
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -39,6 +40,20 @@ func TestListUsersValidation(t *testing.T) {
 			relations
 				define viewer: [user]`
 
+	conditionalModel := `
+		model
+			schema 1.1
+		type user
+
+		type document
+			relations
+				define viewer: [user with isTrue]
+				define can_view: viewer
+
+		condition isTrue(param: bool) {
+			param
+		}`
+
 	tests := []struct {
 		name              string
 		req               *openfgav1.ListUsersRequest
@@ -144,6 +159,45 @@ func TestListUsersValidation(t *testing.T) {
 			model:             model,
 			expectedErrorCode: codes.Code(2027),
 		},
+		{
+			name: "contextual_tuple_relation_not_assignable",
+			req: &openfgav1.ListUsersRequest{
+				Object:      &openfgav1.Object{Type: "document", Id: "1"},
+				Relation:    "viewer",
+				UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+				ContextualTuples: []*openfgav1.TupleKey{
+					tuple.NewTupleKey("document:1", "can_view", "user:will"),
+				},
+			},
+			model:             conditionalModel,
+			expectedErrorCode: codes.Code(2027),
+		},
+		{
+			name: "contextual_tuple_undefined_condition",
+			req: &openfgav1.ListUsersRequest{
+				Object:      &openfgav1.Object{Type: "document", Id: "1"},
+				Relation:    "viewer",
+				UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+				ContextualTuples: []*openfgav1.TupleKey{
+					tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:will", "undefined", nil),
+				},
+			},
+			model:             conditionalModel,
+			expectedErrorCode: codes.Code(2000),
+		},
+		{
+			name: "contextual_tuple_missing_required_condition",
+			req: &openfgav1.ListUsersRequest{
+				Object:      &openfgav1.Object{Type: "document", Id: "1"},
+				Relation:    "viewer",
+				UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+				ContextualTuples: []*openfgav1.TupleKey{
+					tuple.NewTupleKey("document:1", "viewer", "user:will"),
+				},
+			},
+			model:             conditionalModel,
+			expectedErrorCode: codes.Code(2000),
+		},
 	}
 
 	storeID := ulid.Make().String()
@@ -342,6 +396,28 @@ func TestListUsers_ErrorCases(t *testing.T) {
 	})
 }
 
+// headerRecordingTransport records the response headers set by the server.
+type headerRecordingTransport struct {
+	mu      sync.Mutex
+	headers map[string]string
+}
+
+func (h *headerRecordingTransport) SetHeader(_ context.Context, key, value string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.headers == nil {
+		h.headers = make(map[string]string)
+	}
+	h.headers[key] = value
+}
+
+func (h *headerRecordingTransport) header(key string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	value, ok := h.headers[key]
+	return value, ok
+}
+
 func TestListUsers_Deadline(t *testing.T) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
@@ -377,8 +453,11 @@ func TestListUsers_Deadline(t *testing.T) {
 		ds = mockstorage.NewMockSlowDataStorage(ds, 20*time.Millisecond)
 		t.Cleanup(ds.Close)
 
+		transport := &headerRecordingTransport{}
+
 		s := MustNewServerWithOpts(
 			WithDatastore(ds),
+			WithTransport(transport),
 			WithListUsersDeadline(30*time.Millisecond), // 30ms is enough for first read, but not others
 		)
 		t.Cleanup(s.Close)
@@ -398,6 +477,51 @@ func TestListUsers_Deadline(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, resp)
 		require.Len(t, resp.GetUsers(), 1)
+
+		truncated, ok := transport.header(ListUsersTruncatedHeader)
+		require.True(t, ok)
+		require.Equal(t, "deadline", truncated)
+	})
+
+	t.Run("no_truncation_header_for_complete_results", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		storeID, model := test.BootstrapFGAStore(t, ds, `
+			model
+				schema 1.1
+			type user
+
+			type document
+			relations
+				define viewer: [user]`, []string{"document:1#viewer@user:jon"})
+
+		transport := &headerRecordingTransport{}
+
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithTransport(transport),
+			WithListUsersDeadline(1*time.Minute),
+		)
+		t.Cleanup(s.Close)
+
+		resp, err := s.ListUsers(ctx, &openfgav1.ListUsersRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: model.GetId(),
+			Object: &openfgav1.Object{
+				Type: "document",
+				Id:   "1",
+			},
+			Relation: "viewer",
+			UserFilters: []*openfgav1.UserTypeFilter{
+				{Type: "user"},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.GetUsers(), 1)
+
+		_, ok := transport.header(ListUsersTruncatedHeader)
+		require.False(t, ok)
 	})
 
 	t.Run("return_no_error_and_partial_results_if_throttled_until_deadline", func(t *testing.T) {
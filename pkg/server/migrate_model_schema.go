@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/openfga/openfga/pkg/middleware/validator"
+	"github.com/openfga/openfga/pkg/server/commands"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+// MigrateAuthorizationModelSchema migrates a model of schema version 1.0, by default the latest
+// model of the store, to schema version 1.1 (see [typesystem.MigrateSchema1_0To1_1]). The type
+// restrictions of its directly assignable relations are synthesized from the users of the tuples
+// of the store, all of which are read. The migrated model is written as a new model, with the
+// same validations and limits as WriteAuthorizationModel, unless the request is a dry run or some
+// relations need attention, which the response reports along with the transformations applied.
+//
+// Only loopback callers are allowed, see [isLoopbackCaller].
+func (s *Server) MigrateAuthorizationModelSchema(ctx context.Context, req *commands.MigrateAuthorizationModelSchemaRequest) (_ *commands.MigrateAuthorizationModelSchemaResponse, err error) {
+	ctx, span := tracer.Start(ctx, "MigrateAuthorizationModelSchema", trace.WithAttributes(
+		attribute.String("store_id", req.StoreID),
+		attribute.String("authorization_model_id", req.AuthorizationModelID),
+		attribute.Bool("dry_run", req.DryRun),
+	))
+	defer span.End()
+	defer s.inFlightRequests.start("MigrateAuthorizationModelSchema")()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, "MigrateAuthorizationModelSchema", req, &err)
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "MigrateAuthorizationModelSchema",
+	})
+
+	if !isLoopbackCaller(ctx) {
+		span.SetAttributes(attribute.Bool("permission_denied", true))
+		return nil, serverErrors.LoopbackCallerRequired("MigrateAuthorizationModelSchema", "authorization models can only be migrated from a loopback address")
+	}
+
+	if err := validator.ValidateStoreID(req.StoreID); err != nil {
+		return nil, err
+	}
+	if req.AuthorizationModelID != "" {
+		if err := validator.ValidateAuthorizationModelID(req.AuthorizationModelID); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.readOnly && !req.DryRun {
+		return nil, serverErrors.ServerReadOnly
+	}
+
+	resp, err := commands.NewMigrateAuthorizationModelSchemaCommand(s.datastore,
+		commands.WithMigrateModelSchemaLogger(s.logger),
+		commands.WithMigrateModelSchemaWriteOptions(
+			commands.WithWriteAuthModelLogger(s.logger),
+			commands.WithWriteAuthModelMaxSizeInBytes(s.maxAuthorizationModelSizeInBytes),
+			commands.WithWriteAuthModelIDGenerator(s.idGenerator),
+			commands.WithWriteAuthModelStrictConditionParameters(s.strictConditionParameters),
+			commands.WithWriteAuthModelMaxComplexity(s.maxAuthorizationModelComplexity),
+			commands.WithWriteAuthModelMaxTypes(s.maxAuthorizationModelTypes),
+			commands.WithWriteAuthModelMaxRelationsPerType(s.maxAuthorizationModelRelationsPerType),
+			commands.WithWriteAuthModelMaxRewriteDepth(s.maxAuthorizationModelRewriteDepth),
+		),
+	).Execute(ctx, req)
+	if err != nil {
+		telemetry.TraceError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.String("migrated_authorization_model_id", resp.AuthorizationModelID),
+		attribute.Int("tuples_read", resp.TuplesRead),
+		attribute.Int("needs_attention", len(resp.NeedsAttention)),
+	)
+
+	return resp, nil
+}
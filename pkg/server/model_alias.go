@@ -0,0 +1,266 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/middleware/validator"
+	"github.com/openfga/openfga/pkg/server/commands"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/server/modelalias"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+// modelAliasCacheTTL bounds how long other servers keep resolving an alias to its previous
+// model after it is updated. The server that updates an alias invalidates its entry immediately.
+const modelAliasCacheTTL = 10 * time.Second
+
+// modelAliasCacheMaxEntries bounds the number of aliases in the cache. Beyond it, the least
+// recently used entry is evicted.
+const modelAliasCacheMaxEntries = 10000
+
+type modelAliasCacheEntry struct {
+	key       string // store id | alias
+	modelID   string
+	expiresAt time.Time
+}
+
+// modelAliasCache caches the model IDs of the aliases resolved by resolveTypesystem. It is an LRU
+// cache whose entries also expire after modelAliasCacheTTL.
+type modelAliasCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element // of *modelAliasCacheEntry
+	lru        *list.List               // most recently used first
+}
+
+func newModelAliasCache() *modelAliasCache {
+	return &modelAliasCache{
+		maxEntries: modelAliasCacheMaxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+func (c *modelAliasCache) get(storeID, alias string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[storeID+"|"+alias]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*modelAliasCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(elem)
+		return "", false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.modelID, true
+}
+
+func (c *modelAliasCache) set(storeID, alias, modelID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := storeID + "|" + alias
+	expiresAt := time.Now().Add(modelAliasCacheTTL)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*modelAliasCacheEntry)
+		entry.modelID, entry.expiresAt = modelID, expiresAt
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&modelAliasCacheEntry{key: key, modelID: modelID, expiresAt: expiresAt})
+	if c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *modelAliasCache) invalidate(storeID, alias string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[storeID+"|"+alias]; ok {
+		c.remove(elem)
+	}
+}
+
+// invalidateStore invalidates the entries of all the aliases of the store.
+func (c *modelAliasCache) invalidateStore(storeID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key, storeID+"|") {
+			c.remove(elem)
+		}
+	}
+}
+
+// remove removes the entry of elem. The caller must hold mu.
+func (c *modelAliasCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*modelAliasCacheEntry).key)
+}
+
+// resolveModelAlias returns the ID of the model that the alias of the AuthorizationModelAliasHeader
+// metadata points at, or an empty string if the request has no alias.
+func (s *Server) resolveModelAlias(ctx context.Context, storeID string) (string, error) {
+	values := metadata.ValueFromIncomingContext(ctx, AuthorizationModelAliasHeader)
+	if len(values) == 0 || values[0] == "" {
+		return "", nil
+	}
+	alias := values[0]
+
+	if s.modelAliases == nil {
+		return "", serverErrors.ModelAliasesUnsupported
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("authorization_model_alias", alias))
+
+	if modelID, ok := s.modelAliasCache.get(storeID, alias); ok {
+		return modelID, nil
+	}
+
+	resolved, err := commands.NewReadModelAliasQuery(s.modelAliases,
+		commands.WithReadModelAliasQueryLogger(s.logger),
+	).Execute(ctx, storeID, alias)
+	if err != nil {
+		return "", err
+	}
+
+	s.modelAliasCache.set(storeID, alias, resolved.AuthorizationModelID)
+	return resolved.AuthorizationModelID, nil
+}
+
+// WriteModelAlias points a named alias of the store, e.g. "production", at one of its
+// authorization models. Requests that set the Openfga-Authorization-Model-Alias header and no
+// authorization model ID are evaluated against that model, so that model rollouts only require
+// moving the alias. Aliases must match `^[a-z][a-z0-9_-]{0,63}$`.
+//
+// There is no per-API authorization in this server: like WriteAuthorizationModel, this method
+// is available to every caller.
+func (s *Server) WriteModelAlias(ctx context.Context, req *commands.ModelAlias) (err error) {
+	ctx, span := tracer.Start(ctx, "WriteModelAlias", trace.WithAttributes(
+		attribute.String("store_id", req.StoreID),
+		attribute.String("authorization_model_alias", req.Alias),
+		attribute.String(authorizationModelIDKey, req.AuthorizationModelID),
+	))
+	defer span.End()
+	defer s.inFlightRequests.start("WriteModelAlias")()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, "WriteModelAlias", req, &err)
+
+	if s.readOnly {
+		return serverErrors.ServerReadOnly
+	}
+
+	if s.modelAliases == nil {
+		return serverErrors.ModelAliasesUnsupported
+	}
+
+	if req.StoreID == "" || req.AuthorizationModelID == "" {
+		return serverErrors.RequiredFieldsMissing("the store ID and authorization model ID are required", "store_id", "authorization_model_id")
+	}
+	if err := validator.ValidateStoreID(req.StoreID); err != nil {
+		return err
+	}
+	if err := validator.ValidateAuthorizationModelID(req.AuthorizationModelID); err != nil {
+		return err
+	}
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "WriteModelAlias",
+	})
+
+	err = commands.NewWriteModelAliasCommand(s.modelAliases, s.datastore,
+		commands.WithWriteModelAliasCmdLogger(s.logger),
+	).Execute(ctx, req)
+	if err != nil {
+		telemetry.TraceError(span, err)
+		return err
+	}
+
+	s.modelAliasCache.invalidate(req.StoreID, req.Alias)
+	return nil
+}
+
+// ReadModelAlias returns the authorization model that a named alias of the store points at.
+func (s *Server) ReadModelAlias(ctx context.Context, storeID, alias string) (_ *commands.ModelAlias, err error) {
+	ctx, span := tracer.Start(ctx, "ReadModelAlias", trace.WithAttributes(
+		attribute.String("store_id", storeID),
+		attribute.String("authorization_model_alias", alias),
+	))
+	defer span.End()
+	defer s.inFlightRequests.start("ReadModelAlias")()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, "ReadModelAlias", nil, &err)
+
+	if err := validator.ValidateStoreID(storeID); err != nil {
+		return nil, err
+	}
+
+	if s.modelAliases == nil {
+		return nil, serverErrors.ModelAliasesUnsupported
+	}
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "ReadModelAlias",
+	})
+
+	return commands.NewReadModelAliasQuery(s.modelAliases,
+		commands.WithReadModelAliasQueryLogger(s.logger),
+	).Execute(ctx, storeID, alias)
+}
+
+// SetModelAlias serves [Server.WriteModelAlias] as the method of the [modelalias.ServiceDesc]
+// gRPC service, and responds with the alias.
+func (s *Server) SetModelAlias(ctx context.Context, req *structpb.Struct) (_ *structpb.Struct, err error) {
+	defer s.recoverFromPanic(ctx, "SetModelAlias", req, &err)
+
+	fields, err := structStringFields(req, modelalias.StoreIDField, modelalias.AliasField, modelalias.AuthorizationModelIDField)
+	if err != nil {
+		return nil, err
+	}
+
+	alias := &commands.ModelAlias{
+		StoreID:              fields[modelalias.StoreIDField],
+		Alias:                fields[modelalias.AliasField],
+		AuthorizationModelID: fields[modelalias.AuthorizationModelIDField],
+	}
+	if err := s.WriteModelAlias(ctx, alias); err != nil {
+		return nil, err
+	}
+
+	return jsonStruct(alias, "the model alias")
+}
+
+// GetModelAlias serves [Server.ReadModelAlias] as the method of the [modelalias.ServiceDesc]
+// gRPC service.
+func (s *Server) GetModelAlias(ctx context.Context, req *structpb.Struct) (_ *structpb.Struct, err error) {
+	defer s.recoverFromPanic(ctx, "GetModelAlias", req, &err)
+
+	fields, err := structStringFields(req, modelalias.StoreIDField, modelalias.AliasField)
+	if err != nil {
+		return nil, err
+	}
+
+	alias, err := s.ReadModelAlias(ctx, fields[modelalias.StoreIDField], fields[modelalias.AliasField])
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonStruct(alias, "the model alias")
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+const (
+	modelImpactAnalysisWarn   = "warn"
+	modelImpactAnalysisStrict = "strict"
+)
+
+// modelImpactAnalysisFromMetadata returns whether the WriteModelImpactAnalysisHeader metadata
+// enables the impact analysis of a WriteAuthorizationModel, and whether the analysis is strict.
+// Without the metadata the analysis does not run.
+func modelImpactAnalysisFromMetadata(ctx context.Context) (enabled bool, strict bool, err error) {
+	values := metadata.ValueFromIncomingContext(ctx, WriteModelImpactAnalysisHeader)
+	if len(values) == 0 {
+		return false, false, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(values[0])) {
+	case modelImpactAnalysisWarn:
+		return true, false, nil
+	case modelImpactAnalysisStrict:
+		return true, true, nil
+	default:
+		return false, false, serverErrors.InvalidHeaderValue(WriteModelImpactAnalysisHeader, values[0],
+			fmt.Errorf("invalid '%s' header value '%s': expected '%s' or '%s'", WriteModelImpactAnalysisHeader, values[0], modelImpactAnalysisWarn, modelImpactAnalysisStrict))
+	}
+}
@@ -0,0 +1,3 @@
+// Package modelalias describes the gRPC service that sets and reads the named aliases of the
+// authorization models of a store, which the OpenFGA API does not define.
+package modelalias
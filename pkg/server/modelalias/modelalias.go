@@ -0,0 +1,93 @@
+package modelalias
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// ServiceName is the name of the gRPC service of SetModelAlias and GetModelAlias. It is not
+	// part of the openfga.v1 package of the OpenFGA API.
+	ServiceName = "openfga.server.modelalias.v1.ModelAliasService"
+
+	// SetModelAliasFullMethodName is the full gRPC method name of SetModelAlias.
+	SetModelAliasFullMethodName = "/" + ServiceName + "/SetModelAlias"
+
+	// GetModelAliasFullMethodName is the full gRPC method name of GetModelAlias.
+	GetModelAliasFullMethodName = "/" + ServiceName + "/GetModelAlias"
+
+	// ModelAliasHTTPPath is the path of the alias of a store in the HTTP API: PUT sets it with the
+	// AuthorizationModelIDField of the JSON body, and GET reads it.
+	ModelAliasHTTPPath = "/stores/{store_id}/model-aliases/{alias}"
+)
+
+// The fields of the requests of SetModelAlias and GetModelAlias. GetModelAlias has no
+// AuthorizationModelIDField.
+const (
+	StoreIDField              = "store_id"
+	AliasField                = "alias"
+	AuthorizationModelIDField = "authorization_model_id"
+)
+
+// ServiceDesc describes the gRPC service of SetModelAlias and GetModelAlias. As the OpenFGA API
+// has no messages for it, the requests are Structs with the string fields above, and the
+// responses are Structs with the fields of the JSON form of the alias, i.e.
+// {"store_id": "...", "alias": "production", "authorization_model_id": "..."}.
+var ServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*ModelAliasServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SetModelAlias",
+			Handler:    setModelAliasHandler,
+		},
+		{
+			MethodName: "GetModelAlias",
+			Handler:    getModelAliasHandler,
+		},
+	},
+}
+
+// ModelAliasServer is the server API of the service of ServiceDesc.
+type ModelAliasServer interface {
+	SetModelAlias(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	GetModelAlias(context.Context, *structpb.Struct) (*structpb.Struct, error)
+}
+
+func setModelAliasHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModelAliasServer).SetModelAlias(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SetModelAliasFullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModelAliasServer).SetModelAlias(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func getModelAliasHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModelAliasServer).GetModelAlias(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetModelAliasFullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModelAliasServer).GetModelAlias(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc/metadata"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage/storagewrappers"
+)
+
+// pointInTimeFromMetadata returns the point in time of a Check request from the CheckAtHeader
+// metadata, or the zero time if the header is not set.
+func pointInTimeFromMetadata(ctx context.Context) (time.Time, error) {
+	values := metadata.ValueFromIncomingContext(ctx, CheckAtHeader)
+	if len(values) == 0 || values[0] == "" {
+		return time.Time{}, nil
+	}
+
+	if at, err := time.Parse(time.RFC3339Nano, values[0]); err == nil {
+		return at, nil
+	}
+	if id, err := ulid.ParseStrict(values[0]); err == nil {
+		return ulid.Time(id.Time()), nil
+	}
+	return time.Time{}, serverErrors.InvalidHeaderValue(CheckAtHeader, values[0],
+		fmt.Errorf("invalid '%s' header value '%s', must be an RFC 3339 timestamp or a ULID", CheckAtHeader, values[0]))
+}
+
+// pointInTimeTupleReader returns a reader of the tuples of the store as they were at the point
+// in time of the Check request, or nil if the request has no point in time.
+//
+// There is no per-API authorization in this server, so only loopback callers are allowed to read
+// past tuples, see [isLoopbackCaller].
+func (s *Server) pointInTimeTupleReader(ctx context.Context, storeID string) (*storagewrappers.PointInTimeTupleReader, time.Time, error) {
+	at, err := pointInTimeFromMetadata(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if at.IsZero() {
+		return nil, time.Time{}, nil
+	}
+
+	if !s.experimentallyEnabled(ctx, ExperimentalPointInTimeCheck) {
+		return nil, time.Time{}, serverErrors.ExperimentalFlagRequired(CheckAtHeader, string(ExperimentalPointInTimeCheck))
+	}
+	if !isLoopbackCaller(ctx) {
+		return nil, time.Time{}, serverErrors.LoopbackCallerRequired(openfgav1.OpenFGAService_Check_FullMethodName, "point-in-time checks can only be evaluated from a loopback address")
+	}
+
+	now := time.Now()
+	if at.After(now) {
+		return nil, time.Time{}, serverErrors.PointInTimeInFuture(at)
+	}
+	if s.pointInTimeCheckHorizon > 0 && at.Before(now.Add(-s.pointInTimeCheckHorizon)) {
+		return nil, time.Time{}, serverErrors.PointInTimeBeyondHorizon(at, s.pointInTimeCheckHorizon)
+	}
+
+	reader, err := storagewrappers.NewPointInTimeTupleReader(ctx, s.datastore, storeID, at, s.pointInTimeCheckMaxChanges)
+	if err != nil {
+		if errors.Is(err, storagewrappers.ErrPointInTimeUnavailable) {
+			return nil, time.Time{}, serverErrors.PointInTimeUnavailable(err)
+		}
+		return nil, time.Time{}, serverErrors.HandleError("", err)
+	}
+	return reader, at, nil
+}
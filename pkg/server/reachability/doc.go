@@ -0,0 +1,3 @@
+// Package reachability describes the gRPC service that lists the relations the users of a type
+// can hold under an authorization model, which the OpenFGA API does not define.
+package reachability
@@ -0,0 +1,67 @@
+package reachability
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// ServiceName is the name of the gRPC service of ListReachableRelations. It is not part of the
+	// openfga.v1 package of the OpenFGA API.
+	ServiceName = "openfga.server.reachability.v1.ReachableRelationsService"
+
+	// ListReachableRelationsFullMethodName is the full gRPC method name of ListReachableRelations.
+	ListReachableRelationsFullMethodName = "/" + ServiceName + "/ListReachableRelations"
+
+	// ListReachableRelationsHTTPPath is the path of ListReachableRelations in the HTTP API, with the
+	// user_type and the optional authorization_model_id as query parameters.
+	ListReachableRelationsHTTPPath = "/stores/{store_id}/reachable-relations"
+)
+
+// The fields of the request of ListReachableRelations.
+const (
+	StoreIDField              = "store_id"
+	AuthorizationModelIDField = "authorization_model_id"
+	UserTypeField             = "user_type"
+)
+
+// ServiceDesc describes the gRPC service of ListReachableRelations. As the OpenFGA API has no
+// messages for it, the request is a Struct with the string fields StoreIDField,
+// AuthorizationModelIDField and UserTypeField, and the response is a Struct with the fields of
+// the JSON form of the relations, e.g.
+// {"authorization_model_id": "...", "user_type": "user", "relations": [...]}.
+var ServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*ReachableRelationsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListReachableRelations",
+			Handler:    listReachableRelationsHandler,
+		},
+	},
+}
+
+// ReachableRelationsServer is the server API of the service of ServiceDesc.
+type ReachableRelationsServer interface {
+	ListReachableRelations(context.Context, *structpb.Struct) (*structpb.Struct, error)
+}
+
+func listReachableRelationsHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReachableRelationsServer).ListReachableRelations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ListReachableRelationsFullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReachableRelationsServer).ListReachableRelations(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/middleware/validator"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/server/reachability"
+	"github.com/openfga/openfga/pkg/telemetry"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// ReachableRelations are the relations that the users of a type can hold under an
+// authorization model, see [Server.GetReachableRelations].
+type ReachableRelations struct {
+	AuthorizationModelID string                         `json:"authorization_model_id"`
+	UserType             string                         `json:"user_type"`
+	Relations            []typesystem.ReachableRelation `json:"relations"`
+}
+
+// GetReachableRelations returns the relations that the users of the given type can ever hold
+// under an authorization model of a store, or its latest model if authorizationModelID is
+// empty, and whether each path to them is direct, via a userset or via a tuple to userset
+// rewrite, e.g. to generate typed permission helpers. See
+// [typesystem.TypeSystem.GetReachableRelations].
+//
+// It only reads the model, which any client can read with ReadAuthorizationModel, so it is
+// allowed for every caller.
+func (s *Server) GetReachableRelations(ctx context.Context, storeID, authorizationModelID, userType string) (_ *ReachableRelations, err error) {
+	ctx, span := tracer.Start(ctx, "GetReachableRelations", trace.WithAttributes(
+		attribute.String("store_id", storeID),
+		attribute.String("user_type", userType),
+	))
+	defer span.End()
+	defer s.inFlightRequests.start("GetReachableRelations")()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, "GetReachableRelations", storeID, &err)
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "GetReachableRelations",
+	})
+
+	if userType == "" {
+		return nil, serverErrors.RequiredFieldsMissing("the user type is required", "user_type")
+	}
+	if err := validator.ValidateStoreID(storeID); err != nil {
+		return nil, err
+	}
+	if authorizationModelID != "" {
+		if err := validator.ValidateAuthorizationModelID(authorizationModelID); err != nil {
+			return nil, err
+		}
+	}
+
+	typesys, err := s.resolveTypesystem(ctx, storeID, authorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := typesys.GetTypeDefinition(userType); !ok {
+		return nil, serverErrors.TypeNotFound(userType)
+	}
+
+	relations := typesys.GetReachableRelations(userType)
+	span.SetAttributes(
+		attribute.String(authorizationModelIDKey, typesys.GetAuthorizationModelID()),
+		attribute.Int("reachable_relations", len(relations)),
+	)
+
+	return &ReachableRelations{
+		AuthorizationModelID: typesys.GetAuthorizationModelID(),
+		UserType:             userType,
+		Relations:            relations,
+	}, nil
+}
+
+// ListReachableRelations serves [Server.GetReachableRelations] as the method of the
+// [reachability.ServiceDesc] gRPC service, and through it the
+// [reachability.ListReachableRelationsHTTPPath] route of the gateway (see [NewGatewayMux]).
+func (s *Server) ListReachableRelations(ctx context.Context, req *structpb.Struct) (_ *structpb.Struct, err error) {
+	defer s.recoverFromPanic(ctx, "ListReachableRelations", req, &err)
+
+	fields, err := structStringFields(req, reachability.StoreIDField, reachability.AuthorizationModelIDField, reachability.UserTypeField)
+	if err != nil {
+		return nil, err
+	}
+
+	relations, err := s.GetReachableRelations(ctx,
+		fields[reachability.StoreIDField],
+		fields[reachability.AuthorizationModelIDField],
+		fields[reachability.UserTypeField],
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonStruct(relations, "the reachable relations")
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// readConditionFilterFromMetadata returns the condition filter of a Read request
+// from the ReadConditionNameHeader and ReadHasConditionHeader metadata.
+func readConditionFilterFromMetadata(ctx context.Context) (storage.ReadConditionFilter, error) {
+	var filter storage.ReadConditionFilter
+
+	if values := metadata.ValueFromIncomingContext(ctx, ReadConditionNameHeader); len(values) > 0 {
+		filter.Name = values[0]
+	}
+
+	if values := metadata.ValueFromIncomingContext(ctx, ReadHasConditionHeader); len(values) > 0 {
+		hasCondition, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return storage.ReadConditionFilter{}, fmt.Errorf("invalid '%s' header value '%s', must be 'true' or 'false'", ReadHasConditionHeader, values[0])
+		}
+		filter.HasCondition = &hasCondition
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"time"
+
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/pkg/middleware/validator"
+	"github.com/openfga/openfga/pkg/server/commands"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/server/watch"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+// StreamedReadChanges tails the changelog of a store, optionally filtered by object type, to drive
+// cache invalidation or downstream sync without calling ReadChanges in a loop. It starts at the
+// continuation token of the request or, without one, at the start time of the
+// ReadChangesStartTimeHeader metadata or at the beginning of the changelog. It sends the changes
+// page by page as ReadChanges returns them, withheld by the changelog horizon offset, and then
+// reads the changelog for new changes every poll interval (see [WithReadChangesWatchPollInterval]).
+//
+// Every response carries the continuation token after its changes, to resume the stream from. When
+// no changes are sent for a heartbeat interval (see [WithReadChangesWatchHeartbeatInterval]), an
+// empty response with the current continuation token is sent, so that proxies do not close the
+// idle stream. The stream ends without an error when its context is canceled or when it reaches the
+// maximum stream duration (see [WithMaxStreamDuration]), with the continuation token of the last
+// page of changes it read in the ReadChangesContinuationTokenHeader trailer. Streams over the
+// maximum number of streams of the server (see [WithReadChangesWatchMaxStreams]) are rejected.
+func (s *Server) StreamedReadChanges(req *openfgav1.ReadChangesRequest, srv watch.StreamedReadChangesServer) (err error) {
+	const methodName = "StreamedReadChanges"
+
+	ctx := srv.Context()
+	ctx, span := tracer.Start(ctx, "StreamedReadChanges", trace.WithAttributes(
+		attribute.String("store_id", req.GetStoreId()),
+		attribute.KeyValue{Key: "type", Value: attribute.StringValue(req.GetType())},
+	))
+	defer span.End()
+	defer s.inFlightRequests.start(watch.StreamedReadChangesFullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, watch.StreamedReadChangesFullMethodName, req, &err)
+
+	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return err
+		}
+		if err := req.Validate(); err != nil {
+			return serverErrors.RequestValidationError(err)
+		}
+	}
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  methodName,
+	})
+
+	streams := s.readChangesWatchStreams.Add(1)
+	defer s.readChangesWatchStreams.Add(-1)
+	if s.readChangesWatchMaxStreams > 0 && streams > int64(s.readChangesWatchMaxStreams) {
+		return serverErrors.ReadChangesWatchMaxStreamsExceeded(s.readChangesWatchMaxStreams)
+	}
+
+	startTime, err := readChangesStartTimeFromMetadata(ctx)
+	if err != nil {
+		return err
+	}
+	if !startTime.IsZero() {
+		span.SetAttributes(attribute.String("start_time", startTime.UTC().Format(time.RFC3339Nano)))
+	}
+
+	var changesSent int
+	defer func() {
+		span.SetAttributes(attribute.Int("changes_sent", changesSent))
+	}()
+
+	ticker := time.NewTicker(s.readChangesWatchPollInterval)
+	defer ticker.Stop()
+
+	// a nil channel never fires, so that streams have no maximum duration by default
+	var maxDurationReached <-chan time.Time
+	if s.maxStreamDuration > 0 {
+		timer := time.NewTimer(s.maxStreamDuration)
+		defer timer.Stop()
+		maxDurationReached = timer.C
+	}
+
+	continuationToken := req.GetContinuationToken()
+	defer func() {
+		if err == nil && continuationToken != "" {
+			srv.SetTrailer(metadata.Pairs(ReadChangesContinuationTokenHeader, continuationToken))
+		}
+	}()
+
+	truncate := func() error {
+		const truncationCause = "max_stream_duration"
+		grpc_ctxtags.Extract(ctx).Set("truncation_cause", truncationCause)
+		span.SetAttributes(attribute.String("truncation_cause", truncationCause))
+		truncatedRequestCounter.WithLabelValues(s.serviceName, methodName, truncationCause).Inc()
+		srv.SetTrailer(metadata.Pairs(StreamTruncatedHeader, truncationCause))
+		return nil
+	}
+
+	lastSent := time.Now()
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+		select {
+		case <-maxDurationReached:
+			return truncate()
+		default:
+		}
+
+		opts := []commands.ReadChangesQueryOption{
+			commands.WithReadChangesQueryLogger(s.logger),
+			commands.WithReadChangesQueryEncoder(s.encoder),
+			commands.WithReadChangeQueryHorizonOffset(s.changelogHorizonOffset),
+			commands.WithReadChangesQueryMaxResponseSizeInBytes(s.maxReadResponseSizeInBytes),
+		}
+		// the start time only applies until the stream has a continuation token
+		if continuationToken == "" {
+			opts = append(opts, commands.WithReadChangesQueryStartTime(startTime))
+		}
+
+		resp, err := commands.NewReadChangesQuery(s.datastore, opts...).Execute(ctx, &openfgav1.ReadChangesRequest{
+			StoreId:           req.GetStoreId(),
+			Type:              req.GetType(),
+			PageSize:          req.GetPageSize(),
+			ContinuationToken: continuationToken,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		continuationToken = resp.GetContinuationToken()
+
+		changes := len(resp.GetChanges())
+		if changes > 0 || (s.readChangesWatchHeartbeatInterval > 0 && time.Since(lastSent) >= s.readChangesWatchHeartbeatInterval) {
+			if err := srv.Send(resp); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return serverErrors.HandleError("", err)
+			}
+			lastSent = time.Now()
+			changesSent += changes
+		}
+
+		// the next page is read right away until the stream has caught up with the changelog
+		if changes > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-maxDurationReached:
+			return truncate()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/pkg/middleware/recovery"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// recoverFromPanic converts a panic in the handler of the given gRPC method into an
+// Internal error, so that servers embedded without the recovery middleware stay up.
+// It must be deferred by the handler, which sets err through its named result.
+func (s *Server) recoverFromPanic(ctx context.Context, method string, req any, err *error) {
+	p := recover()
+	if p == nil {
+		return
+	}
+
+	var fields []zap.Field
+	if r, ok := req.(interface{ GetStoreId() string }); ok {
+		fields = append(fields, zap.String("store_id", r.GetStoreId()))
+	}
+	if tk := panicTupleKey(req); tk != "" {
+		fields = append(fields, zap.String("tuple_key", tk))
+	}
+
+	*err = recovery.RecoverPanic(ctx, s.logger, method, p, fields...)
+}
+
+// panicTupleKey returns the tuple key of the requests that have one.
+func panicTupleKey(req any) string {
+	switch r := req.(type) {
+	case *openfgav1.CheckRequest:
+		return tuple.TupleKeyToString(r.GetTupleKey())
+	case *openfgav1.ExpandRequest:
+		return tuple.ToObjectRelationString(r.GetTupleKey().GetObject(), r.GetTupleKey().GetRelation())
+	case *openfgav1.ReadRequest:
+		return tuple.TupleKeyToString(r.GetTupleKey())
+	case *openfgav1.ListObjectsRequest:
+		return tuple.ToObjectRelationString(r.GetType(), r.GetRelation()) + "@" + r.GetUser()
+	case *openfgav1.StreamedListObjectsRequest:
+		return tuple.ToObjectRelationString(r.GetType(), r.GetRelation()) + "@" + r.GetUser()
+	case *openfgav1.ListUsersRequest:
+		return tuple.ToObjectRelationString(tuple.ObjectKey(r.GetObject()), r.GetRelation())
+	default:
+		return ""
+	}
+}
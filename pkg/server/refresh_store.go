@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/oklog/ulid/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/telemetry"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// RefreshStore makes the server re-read the latest authorization model of a store from the
+// datastore, and drop the models of the store that it cached, e.g. after a migration wrote models
+// to the datastore outside the server. It returns the ID of the latest model.
+//
+// The caches keep serving their entries until the latest model is read and validated, and the
+// models missing from them are read from the datastore, so the requests that run concurrently
+// resolve a model throughout the refresh. Only the caches of this server are refreshed.
+//
+// Only loopback callers are allowed, see [isLoopbackCaller].
+func (s *Server) RefreshStore(ctx context.Context, storeID string) (string, error) {
+	ctx, span := tracer.Start(ctx, "RefreshStore", trace.WithAttributes(
+		attribute.String("store_id", storeID),
+	))
+	defer span.End()
+
+	if !isLoopbackCaller(ctx) {
+		span.SetAttributes(attribute.Bool("permission_denied", true))
+		return "", serverErrors.LoopbackCallerRequired("RefreshStore", "stores can only be refreshed from a loopback address")
+	}
+
+	if _, err := ulid.Parse(storeID); err != nil {
+		return "", serverErrors.InvalidStoreID
+	}
+
+	// the resolver reads the latest model through the model cache, so the cache goes first
+	if _, err := s.modelCache.RefreshStore(ctx, storeID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return "", serverErrors.LatestAuthorizationModelNotFound(storeID)
+		}
+		telemetry.TraceError(span, err)
+		return "", serverErrors.HandleError("", err)
+	}
+
+	typesys, err := s.memoizedTypesystemResolver.Refresh(ctx, storeID)
+	if err != nil {
+		switch {
+		case errors.Is(err, typesystem.ErrStoreNotFound):
+			return "", serverErrors.StoreIDNotFound
+		case errors.Is(err, typesystem.ErrModelNotFound):
+			return "", serverErrors.LatestAuthorizationModelNotFound(storeID)
+		case errors.Is(err, typesystem.ErrInvalidModel):
+			return "", serverErrors.ValidationError(err)
+		}
+		telemetry.TraceError(span, err)
+		return "", serverErrors.HandleError("", err)
+	}
+	s.modelAliasCache.invalidateStore(storeID)
+
+	modelID := typesys.GetAuthorizationModelID()
+	span.SetAttributes(attribute.String(authorizationModelIDKey, modelID))
+	s.logger.InfoWithContext(ctx, "refreshed the cached authorization models of a store",
+		zap.String("store_id", storeID),
+		zap.String(authorizationModelIDKey, modelID),
+	)
+
+	return modelID, nil
+}
@@ -0,0 +1,389 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
+	"github.com/openfga/openfga/pkg/middleware/validator"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/server/health"
+	"github.com/openfga/openfga/pkg/server/indexadvice"
+	"github.com/openfga/openfga/pkg/server/modelalias"
+	"github.com/openfga/openfga/pkg/server/reachability"
+	"github.com/openfga/openfga/pkg/server/relationstats"
+	"github.com/openfga/openfga/pkg/server/watch"
+)
+
+// GatewayRouteMethods are the gRPC methods of the gateway routes that accept a request body, by
+// route name (see [httpmiddleware.RouteName]), e.g. to limit the size of the gRPC messages of a
+// method like the size of the HTTP bodies of its route.
+var GatewayRouteMethods = map[string]string{
+	"read":                  openfgav1.OpenFGAService_Read_FullMethodName,
+	"write":                 openfgav1.OpenFGAService_Write_FullMethodName,
+	"check":                 openfgav1.OpenFGAService_Check_FullMethodName,
+	"expand":                openfgav1.OpenFGAService_Expand_FullMethodName,
+	"authorization-models":  openfgav1.OpenFGAService_WriteAuthorizationModel_FullMethodName,
+	"assertions":            openfgav1.OpenFGAService_WriteAssertions_FullMethodName,
+	"stores":                openfgav1.OpenFGAService_CreateStore_FullMethodName,
+	"list-objects":          openfgav1.OpenFGAService_ListObjects_FullMethodName,
+	"streamed-list-objects": openfgav1.OpenFGAService_StreamedListObjects_FullMethodName,
+	"list-users":            openfgav1.OpenFGAService_ListUsers_FullMethodName,
+}
+
+type registerConfig struct {
+	reflection      bool
+	health          bool
+	incomingHeaders []string
+
+	maxBodySize       int64
+	routeMaxBodySizes map[string]int64
+
+	strictJSON bool
+}
+
+// RegisterOption configures RegisterGRPC and NewGatewayMux.
+type RegisterOption func(*registerConfig)
+
+// WithReflection enables the gRPC server reflection service. It is disabled by default.
+func WithReflection(enabled bool) RegisterOption {
+	return func(c *registerConfig) {
+		c.reflection = enabled
+	}
+}
+
+// WithHealth enables the gRPC health service, and the /healthz endpoint of the gateway.
+// It is enabled by default.
+func WithHealth(enabled bool) RegisterOption {
+	return func(c *registerConfig) {
+		c.health = enabled
+	}
+}
+
+// WithGatewayIncomingHeaders sets additional HTTP request headers that the gateway forwards
+// to the gRPC server as metadata (e.g. the Accept-Language header), besides the headers with the
+// [requestcontext.HeaderPrefix].
+func WithGatewayIncomingHeaders(headers ...string) RegisterOption {
+	return func(c *registerConfig) {
+		c.incomingHeaders = append(c.incomingHeaders, headers...)
+	}
+}
+
+// WithGatewayMaxBodySize limits the size of the request bodies accepted by the gateway to
+// maxBodySize bytes, or to the limit of their route in routeMaxBodySizes, e.g. to accept larger
+// Write requests than Check requests. Routes are named by the last literal segment of their path,
+// e.g. "check", "write", "list-objects" or "authorization-models" (see [httpmiddleware.RouteName]).
+// Larger requests fail with a 413 status. A limit of 0 disables the check, which is the default.
+func WithGatewayMaxBodySize(maxBodySize int64, routeMaxBodySizes map[string]int64) RegisterOption {
+	return func(c *registerConfig) {
+		c.maxBodySize = maxBodySize
+		c.routeMaxBodySizes = routeMaxBodySizes
+	}
+}
+
+// WithGatewayStrictJSON makes the gateway reject the bodies of Write, WriteAuthorizationModel and
+// WriteAssertions requests that have unknown fields with a 400 status naming the field and its
+// JSON path, instead of ignoring the fields, e.g. "delete" instead of "deletes". The bodies of the
+// other requests ignore unknown fields either way. It is disabled by default.
+func WithGatewayStrictJSON(enabled bool) RegisterOption {
+	return func(c *registerConfig) {
+		c.strictJSON = enabled
+	}
+}
+
+func newRegisterConfig(opts ...RegisterOption) *registerConfig {
+	c := &registerConfig{
+		health: true,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// RegisterGRPC registers s as the OpenFGA service of grpcServer, as the [watch.ServiceDesc]
+// service of StreamedReadChanges, as the [reachability.ServiceDesc] service of
+// ListReachableRelations, as the [indexadvice.ServiceDesc] service of GetIndexAdvice, as the
+// [modelalias.ServiceDesc] service of SetModelAlias and GetModelAlias and as the
+// [relationstats.ServiceDesc] service of ReadRelationStatistics, with the request validator
+// middleware installed for all of their methods. Depending on the options, it
+// also registers the gRPC health and reflection services.
+//
+// For the response headers (such as the HTTP status code used by the gateway) to reach the
+// client, s must be constructed with a gRPC transport, e.g.
+// WithTransport(gateway.NewRPCTransport(logger)). RegisterGRPC does not change s.
+func RegisterGRPC(grpcServer *grpc.Server, s *Server, opts ...RegisterOption) {
+	cfg := newRegisterConfig(opts...)
+
+	desc := withServiceInterceptors(
+		openfgav1.OpenFGAService_ServiceDesc,
+		validator.UnaryServerInterceptor(),
+		validator.StreamServerInterceptor(),
+	)
+	grpcServer.RegisterService(&desc, s)
+
+	watchDesc := withServiceInterceptors(
+		watch.ServiceDesc,
+		validator.UnaryServerInterceptor(),
+		validator.StreamServerInterceptor(),
+	)
+	grpcServer.RegisterService(&watchDesc, s)
+
+	reachabilityDesc := withServiceInterceptors(
+		reachability.ServiceDesc,
+		validator.UnaryServerInterceptor(),
+		validator.StreamServerInterceptor(),
+	)
+	grpcServer.RegisterService(&reachabilityDesc, s)
+
+	indexAdviceDesc := withServiceInterceptors(
+		indexadvice.ServiceDesc,
+		validator.UnaryServerInterceptor(),
+		validator.StreamServerInterceptor(),
+	)
+	grpcServer.RegisterService(&indexAdviceDesc, s)
+
+	modelAliasDesc := withServiceInterceptors(
+		modelalias.ServiceDesc,
+		validator.UnaryServerInterceptor(),
+		validator.StreamServerInterceptor(),
+	)
+	grpcServer.RegisterService(&modelAliasDesc, s)
+
+	relationStatsDesc := withServiceInterceptors(
+		relationstats.ServiceDesc,
+		validator.UnaryServerInterceptor(),
+		validator.StreamServerInterceptor(),
+	)
+	grpcServer.RegisterService(&relationStatsDesc, s)
+
+	if cfg.health {
+		healthv1pb.RegisterHealthServer(grpcServer, &health.Checker{
+			TargetService:     s,
+			TargetServiceName: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+		})
+	}
+
+	if cfg.reflection {
+		reflection.Register(grpcServer)
+	}
+}
+
+// NewGatewayMux returns an HTTP handler for the OpenFGA HTTP API that proxies requests to the
+// gRPC server behind conn. It maps the status code set by the server (see [httpmiddleware.XHttpCode])
+// and OpenFGA errors to their HTTP representation, and forwards all response headers. The request
+// headers with the [requestcontext.HeaderPrefix] are forwarded as is, including the ones unknown to
+// the server, so that the requestoptions middleware can reject them in strict mode. So is the
+// If-None-Match request header, for the ETags of authorization models (see [ETagHeader]).
+//
+// Besides the routes of the OpenFGA service, it serves the
+// [reachability.ListReachableRelationsHTTPPath] route of ListReachableRelations and the
+// [modelalias.ModelAliasHTTPPath] route of SetModelAlias (PUT) and GetModelAlias (GET).
+func NewGatewayMux(ctx context.Context, conn *grpc.ClientConn, opts ...RegisterOption) (*runtime.ServeMux, error) {
+	cfg := newRegisterConfig(opts...)
+
+	muxOpts := []runtime.ServeMuxOption{
+		runtime.WithForwardResponseOption(httpmiddleware.HTTPResponseModifier),
+		runtime.WithErrorHandler(func(c context.Context, sr *runtime.ServeMux, mm runtime.Marshaler, w http.ResponseWriter, r *http.Request, e error) {
+			intCode := serverErrors.ConvertToEncodedErrorCode(status.Convert(e))
+			httpmiddleware.CustomHTTPErrorHandler(c, w, r, serverErrors.NewEncodedError(intCode, e.Error()))
+		}),
+		runtime.WithStreamErrorHandler(func(ctx context.Context, e error) *status.Status {
+			intCode := serverErrors.ConvertToEncodedErrorCode(status.Convert(e))
+			encodedErr := serverErrors.NewEncodedError(intCode, e.Error())
+			return status.Convert(encodedErr)
+		}),
+		runtime.WithOutgoingHeaderMatcher(func(s string) (string, bool) { return s, true }),
+		runtime.WithIncomingHeaderMatcher(func(s string) (string, bool) {
+			if strings.EqualFold(s, IfNoneMatchHeader) || hasHeaderPrefix(s, requestcontext.HeaderPrefix) {
+				return s, true
+			}
+			for _, header := range cfg.incomingHeaders {
+				if strings.EqualFold(s, header) {
+					return s, true
+				}
+			}
+			return runtime.DefaultHeaderMatcher(s)
+		}),
+	}
+
+	if cfg.maxBodySize > 0 || len(cfg.routeMaxBodySizes) > 0 {
+		muxOpts = append(muxOpts, runtime.WithMiddlewares(
+			httpmiddleware.MaxBodySizeMiddleware(cfg.maxBodySize, cfg.routeMaxBodySizes),
+		))
+	}
+
+	if cfg.strictJSON {
+		muxOpts = append(muxOpts, runtime.WithMarshalerOption(runtime.MIMEWildcard, httpmiddleware.NewStrictJSONMarshaler(
+			&openfgav1.WriteRequest{},
+			&openfgav1.WriteAuthorizationModelRequest{},
+			&openfgav1.WriteAssertionsRequest{},
+		)))
+	}
+
+	if cfg.health {
+		muxOpts = append(muxOpts, runtime.WithHealthzEndpoint(healthv1pb.NewHealthClient(conn)))
+	}
+
+	mux := runtime.NewServeMux(muxOpts...)
+	if err := openfgav1.RegisterOpenFGAServiceHandler(ctx, mux, conn); err != nil {
+		return nil, err
+	}
+	if err := mux.HandlePath(http.MethodGet, reachability.ListReachableRelationsHTTPPath, structGatewayHandler(mux, conn,
+		reachability.ListReachableRelationsFullMethodName, reachability.ListReachableRelationsHTTPPath, listReachableRelationsFields)); err != nil {
+		return nil, err
+	}
+	if err := mux.HandlePath(http.MethodGet, modelalias.ModelAliasHTTPPath, structGatewayHandler(mux, conn,
+		modelalias.GetModelAliasFullMethodName, modelalias.ModelAliasHTTPPath, getModelAliasFields)); err != nil {
+		return nil, err
+	}
+	if err := mux.HandlePath(http.MethodPut, modelalias.ModelAliasHTTPPath, structGatewayHandler(mux, conn,
+		modelalias.SetModelAliasFullMethodName, modelalias.ModelAliasHTTPPath, setModelAliasFields)); err != nil {
+		return nil, err
+	}
+
+	return mux, nil
+}
+
+// structGatewayHandler returns the handler of a route of the gateway that calls a method of
+// conn whose request is the Struct of the fields returned by fields, like the generated handlers
+// of the OpenFGA service call its methods.
+func structGatewayHandler(
+	mux *runtime.ServeMux,
+	conn *grpc.ClientConn,
+	fullMethodName, httpPath string,
+	fields func(r *http.Request, pathParams map[string]string) (map[string]interface{}, error),
+) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		_, outboundMarshaler := runtime.MarshalerForRequest(mux, r)
+
+		ctx, err := runtime.AnnotateContext(ctx, mux, r, fullMethodName, runtime.WithHTTPPathPattern(httpPath))
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outboundMarshaler, w, r, err)
+			return
+		}
+
+		reqFields, err := fields(r, pathParams)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outboundMarshaler, w, r, err)
+			return
+		}
+		req, err := structpb.NewStruct(reqFields)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outboundMarshaler, w, r, err)
+			return
+		}
+
+		var header, trailer metadata.MD
+		resp := &structpb.Struct{}
+		err = conn.Invoke(ctx, fullMethodName, req, resp, grpc.Header(&header), grpc.Trailer(&trailer))
+		ctx = runtime.NewServerMetadataContext(ctx, runtime.ServerMetadata{HeaderMD: header, TrailerMD: trailer})
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outboundMarshaler, w, r, err)
+			return
+		}
+
+		runtime.ForwardResponseMessage(ctx, mux, outboundMarshaler, w, r, resp)
+	}
+}
+
+// listReachableRelationsFields returns the fields of the request of ListReachableRelations for
+// the [reachability.ListReachableRelationsHTTPPath] route.
+func listReachableRelationsFields(r *http.Request, pathParams map[string]string) (map[string]interface{}, error) {
+	query := r.URL.Query()
+	return map[string]interface{}{
+		reachability.StoreIDField:              pathParams["store_id"],
+		reachability.AuthorizationModelIDField: query.Get(reachability.AuthorizationModelIDField),
+		reachability.UserTypeField:             query.Get(reachability.UserTypeField),
+	}, nil
+}
+
+// getModelAliasFields returns the fields of the request of GetModelAlias for a GET of the
+// [modelalias.ModelAliasHTTPPath] route.
+func getModelAliasFields(_ *http.Request, pathParams map[string]string) (map[string]interface{}, error) {
+	return map[string]interface{}{
+		modelalias.StoreIDField: pathParams["store_id"],
+		modelalias.AliasField:   pathParams["alias"],
+	}, nil
+}
+
+// setModelAliasFields returns the fields of the request of SetModelAlias for a PUT of the
+// [modelalias.ModelAliasHTTPPath] route, whose JSON body has the authorization model ID.
+func setModelAliasFields(r *http.Request, pathParams map[string]string) (map[string]interface{}, error) {
+	var body struct {
+		AuthorizationModelID string `json:"authorization_model_id"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		return nil, serverErrors.ValidationError(fmt.Errorf("invalid request body: %w", err))
+	}
+	return map[string]interface{}{
+		modelalias.StoreIDField:              pathParams["store_id"],
+		modelalias.AliasField:                pathParams["alias"],
+		modelalias.AuthorizationModelIDField: body.AuthorizationModelID,
+	}, nil
+}
+
+// withServiceInterceptors returns a copy of desc whose methods run the given interceptors
+// after the interceptors of the gRPC server.
+func withServiceInterceptors(
+	desc grpc.ServiceDesc,
+	unary grpc.UnaryServerInterceptor,
+	stream grpc.StreamServerInterceptor,
+) grpc.ServiceDesc {
+	methods := make([]grpc.MethodDesc, len(desc.Methods))
+	for i, method := range desc.Methods {
+		handler := method.Handler
+		method.Handler = func(srv interface{}, ctx context.Context, dec func(interface{}) error, outer grpc.UnaryServerInterceptor) (interface{}, error) {
+			if outer == nil {
+				return handler(srv, ctx, dec, unary)
+			}
+
+			return handler(srv, ctx, dec, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (interface{}, error) {
+				return outer(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return unary(ctx, req, info, next)
+				})
+			})
+		}
+		methods[i] = method
+	}
+
+	streams := make([]grpc.StreamDesc, len(desc.Streams))
+	for i, streamDesc := range desc.Streams {
+		handler := streamDesc.Handler
+		info := &grpc.StreamServerInfo{
+			FullMethod:     "/" + desc.ServiceName + "/" + streamDesc.StreamName,
+			IsClientStream: streamDesc.ClientStreams,
+			IsServerStream: streamDesc.ServerStreams,
+		}
+		streamDesc.Handler = func(srv interface{}, ss grpc.ServerStream) error {
+			return stream(srv, ss, info, handler)
+		}
+		streams[i] = streamDesc
+	}
+
+	desc.Methods = methods
+	desc.Streams = streams
+	return desc
+}
+
+func hasHeaderPrefix(header, prefix string) bool {
+	return len(header) >= len(prefix) && strings.EqualFold(header[:len(prefix)], prefix)
+}
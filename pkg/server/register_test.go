@@ -0,0 +1,730 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/openfga/openfga/pkg/gateway"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/middleware/requestoptions"
+	"github.com/openfga/openfga/pkg/requestcontext"
+	"github.com/openfga/openfga/pkg/server/commands"
+	"github.com/openfga/openfga/pkg/server/modelalias"
+	"github.com/openfga/openfga/pkg/server/reachability"
+	"github.com/openfga/openfga/pkg/server/relationstats"
+	"github.com/openfga/openfga/pkg/server/watch"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+func TestRegisterGRPCAndGateway(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithTransport(gateway.NewRPCTransport(logger.NewNoopLogger())))
+	t.Cleanup(s.Close)
+
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		requestoptions.NewUnaryInterceptor(requestoptions.WithStrictHeaders(RequestHeaders...)),
+	))
+	RegisterGRPC(grpcServer, s, WithReflection(true))
+
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	client := openfgav1.NewOpenFGAServiceClient(conn)
+
+	mux, err := NewGatewayMux(ctx, conn, WithGatewayMaxBodySize(4*1024, map[string]int64{"write": 64 * 1024}))
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(mux)
+	t.Cleanup(httpServer.Close)
+
+	createStoreResp, err := client.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "embedded"})
+	require.NoError(t, err)
+	storeID := createStoreResp.GetId()
+
+	writeModelResp, err := client.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       storeID,
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: []*openfgav1.TypeDefinition{
+			{Type: "user"},
+			{
+				Type: "document",
+				Relations: map[string]*openfgav1.Userset{
+					"viewer": typesystem.This(),
+				},
+				Metadata: &openfgav1.Metadata{
+					Relations: map[string]*openfgav1.RelationMetadata{
+						"viewer": {
+							DirectlyRelatedUserTypes: []*openfgav1.RelationReference{
+								typesystem.DirectRelationReference("user", ""),
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = client.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("check_over_grpc", func(t *testing.T) {
+		resp, err := client.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+	})
+
+	t.Run("streamed_read_changes_over_grpc", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stream, err := conn.NewStream(ctx, &watch.ServiceDesc.Streams[0], watch.StreamedReadChangesFullMethodName)
+		require.NoError(t, err)
+		require.NoError(t, stream.SendMsg(&openfgav1.ReadChangesRequest{StoreId: storeID}))
+		require.NoError(t, stream.CloseSend())
+
+		var resp openfgav1.ReadChangesResponse
+		require.NoError(t, stream.RecvMsg(&resp))
+		require.Len(t, resp.GetChanges(), 1)
+		require.Equal(t, "document:1#viewer@user:anne", tuple.TupleKeyToString(resp.GetChanges()[0].GetTupleKey()))
+	})
+
+	t.Run("invalid_streamed_read_changes_over_grpc_is_rejected_by_validator", func(t *testing.T) {
+		stream, err := conn.NewStream(ctx, &watch.ServiceDesc.Streams[0], watch.StreamedReadChangesFullMethodName)
+		require.NoError(t, err)
+		require.NoError(t, stream.SendMsg(&openfgav1.ReadChangesRequest{StoreId: "invalid-store-id"}))
+		require.NoError(t, stream.CloseSend())
+
+		err = stream.RecvMsg(&openfgav1.ReadChangesResponse{})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("list_reachable_relations_over_grpc", func(t *testing.T) {
+		req, err := structpb.NewStruct(map[string]interface{}{
+			reachability.StoreIDField:  storeID,
+			reachability.UserTypeField: "user",
+		})
+		require.NoError(t, err)
+
+		var resp structpb.Struct
+		require.NoError(t, conn.Invoke(ctx, reachability.ListReachableRelationsFullMethodName, req, &resp))
+
+		b, err := protojson.Marshal(&resp)
+		require.NoError(t, err)
+		var relations ReachableRelations
+		require.NoError(t, json.Unmarshal(b, &relations))
+		require.Equal(t, "user", relations.UserType)
+		require.Equal(t, []typesystem.ReachableRelation{
+			{ObjectType: "document", Relation: "viewer", Paths: []typesystem.RelationPath{{Kind: typesystem.RelationPathDirect}}},
+		}, relations.Relations)
+
+		for name, fields := range map[string]map[string]interface{}{
+			"unknown_field":     {reachability.StoreIDField: storeID, reachability.UserTypeField: "user", "usertype": "user"},
+			"non_string_field":  {reachability.StoreIDField: storeID, reachability.UserTypeField: 1},
+			"missing_user_type": {reachability.StoreIDField: storeID},
+			"invalid_store_id":  {reachability.StoreIDField: "invalid-store-id", reachability.UserTypeField: "user"},
+			"invalid_model_id":  {reachability.StoreIDField: storeID, reachability.AuthorizationModelIDField: "invalid", reachability.UserTypeField: "user"},
+		} {
+			req, err := structpb.NewStruct(fields)
+			require.NoError(t, err)
+			err = conn.Invoke(ctx, reachability.ListReachableRelationsFullMethodName, req, &structpb.Struct{})
+			require.Equal(t, codes.InvalidArgument, status.Code(err), name)
+		}
+	})
+
+	t.Run("read_relation_statistics_over_grpc", func(t *testing.T) {
+		req, err := structpb.NewStruct(map[string]interface{}{
+			relationstats.StoreIDField:    storeID,
+			relationstats.ObjectTypeField: "document",
+			relationstats.RelationField:   "viewer",
+		})
+		require.NoError(t, err)
+
+		// the bufconn address of the client is not a loopback address
+		err = conn.Invoke(ctx, relationstats.ReadRelationStatisticsFullMethodName, req, &structpb.Struct{})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("model_alias_over_grpc", func(t *testing.T) {
+		req, err := structpb.NewStruct(map[string]interface{}{
+			modelalias.StoreIDField:              storeID,
+			modelalias.AliasField:                "grpc",
+			modelalias.AuthorizationModelIDField: writeModelResp.GetAuthorizationModelId(),
+		})
+		require.NoError(t, err)
+		require.NoError(t, conn.Invoke(ctx, modelalias.SetModelAliasFullMethodName, req, &structpb.Struct{}))
+
+		req, err = structpb.NewStruct(map[string]interface{}{
+			modelalias.StoreIDField: storeID,
+			modelalias.AliasField:   "grpc",
+		})
+		require.NoError(t, err)
+		var resp structpb.Struct
+		require.NoError(t, conn.Invoke(ctx, modelalias.GetModelAliasFullMethodName, req, &resp))
+		require.Equal(t, writeModelResp.GetAuthorizationModelId(), resp.GetFields()[modelalias.AuthorizationModelIDField].GetStringValue())
+
+		req, err = structpb.NewStruct(map[string]interface{}{
+			modelalias.StoreIDField:              storeID,
+			modelalias.AliasField:                "grpc",
+			modelalias.AuthorizationModelIDField: 1,
+		})
+		require.NoError(t, err)
+		err = conn.Invoke(ctx, modelalias.SetModelAliasFullMethodName, req, &structpb.Struct{})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("model_alias_over_gateway", func(t *testing.T) {
+		url := httpServer.URL + "/stores/" + storeID + "/model-aliases/gateway"
+		body := `{"authorization_model_id": "` + writeModelResp.GetAuthorizationModelId() + `"}`
+		req, err := http.NewRequest(http.MethodPut, url, strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = http.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var alias commands.ModelAlias
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&alias))
+		require.Equal(t, commands.ModelAlias{StoreID: storeID, Alias: "gateway", AuthorizationModelID: writeModelResp.GetAuthorizationModelId()}, alias)
+
+		req, err = http.NewRequest(http.MethodPut, url, strings.NewReader(`{"model_id": "x"}`))
+		require.NoError(t, err)
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, err = http.Get(httpServer.URL + "/stores/" + storeID + "/model-aliases/unknown")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("list_reachable_relations_over_gateway", func(t *testing.T) {
+		resp, err := http.Get(httpServer.URL + "/stores/" + storeID + "/reachable-relations?user_type=user")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var relations ReachableRelations
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&relations))
+		require.Equal(t, "user", relations.UserType)
+		require.NotEmpty(t, relations.AuthorizationModelID)
+		require.Len(t, relations.Relations, 1)
+
+		resp, err = http.Get(httpServer.URL + "/stores/" + storeID + "/reachable-relations")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("invalid_request_over_grpc_is_rejected_by_validator", func(t *testing.T) {
+		_, err := client.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  "invalid-store-id",
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("check_over_gateway", func(t *testing.T) {
+		body := `{"tuple_key": {"object": "document:1", "relation": "viewer", "user": "user:anne"}}`
+		resp, err := http.Post(httpServer.URL+"/stores/"+storeID+"/check", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var checkResp struct {
+			Allowed bool `json:"allowed"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&checkResp))
+		require.True(t, checkResp.Allowed)
+	})
+
+	t.Run("gateway_forwards_option_headers", func(t *testing.T) {
+		check := func(header, value string) *http.Response {
+			body := `{"tuple_key": {"object": "document:1", "relation": "viewer", "user": "user:anne"}}`
+			req, err := http.NewRequest(http.MethodPost, httpServer.URL+"/stores/"+storeID+"/check", strings.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set(header, value)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_ = resp.Body.Close()
+			})
+			return resp
+		}
+
+		resp := check(requestcontext.ResolveNodeLimitHeader, "10")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// unknown option headers reach the gRPC server, which rejects them in strict mode
+		resp = check("Openfga-Resolve-Limit", "10")
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Contains(t, string(body), "unknown request headers: openfga-resolve-limit")
+	})
+
+	t.Run("gateway_maps_http_status_code_header", func(t *testing.T) {
+		resp, err := http.Post(httpServer.URL+"/stores", "application/json", strings.NewReader(`{"name": "other"}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("health_over_grpc_and_gateway", func(t *testing.T) {
+		healthResp, err := healthv1pb.NewHealthClient(conn).Check(ctx, &healthv1pb.HealthCheckRequest{})
+		require.NoError(t, err)
+		require.Equal(t, healthv1pb.HealthCheckResponse_SERVING, healthResp.GetStatus())
+
+		resp, err := http.Get(httpServer.URL + "/healthz")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("gateway_limits_body_size_per_route", func(t *testing.T) {
+		// a check request body of about 8 KB, within the write limit but above the default limit
+		checkBody := `{"tuple_key": {"object": "document:1", "relation": "viewer", "user": "user:anne"}, "context": {"padding": "` + strings.Repeat("a", 8*1024) + `"}}`
+		resp, err := http.Post(httpServer.URL+"/stores/"+storeID+"/check", "application/json", strings.NewReader(checkBody))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+		var errResp struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		require.Equal(t, "request_body_too_large", errResp.Code)
+		require.Equal(t, "the request body exceeds the limit of 4096 bytes", errResp.Message)
+
+		writeBody := `{"writes": {"tuple_keys": [{"object": "document:2", "relation": "viewer", "user": "user:` + strings.Repeat("a", 8*1024) + `"}]}}`
+		resp, err = http.Post(httpServer.URL+"/stores/"+storeID+"/write", "application/json", strings.NewReader(writeBody))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		// the body is forwarded, and the request is rejected by the server for its user length
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		writeBody = `{"writes": {"tuple_keys": [{"object": "document:2", "relation": "viewer", "user": "user:` + strings.Repeat("a", 64*1024) + `"}]}}`
+		resp, err = http.Post(httpServer.URL+"/stores/"+storeID+"/write", "application/json", strings.NewReader(writeBody))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+	t.Run("gateway_rejects_unknown_fields_of_write_requests", func(t *testing.T) {
+		strictMux, err := NewGatewayMux(ctx, conn, WithGatewayStrictJSON(true))
+		require.NoError(t, err)
+		strictServer := httptest.NewServer(strictMux)
+		t.Cleanup(strictServer.Close)
+
+		modelID := func() string {
+			resp, err := client.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{StoreId: storeID})
+			require.NoError(t, err)
+			return resp.GetAuthorizationModels()[0].GetId()
+		}()
+
+		tests := map[string]struct {
+			method  string
+			path    string
+			body    string
+			message string
+		}{
+			"write": {
+				method:  http.MethodPost,
+				path:    "/stores/" + storeID + "/write",
+				body:    `{"delete": {"tuple_keys": [{"object": "document:1", "relation": "viewer", "user": "user:anne"}]}}`,
+				message: `unknown field "delete" at "delete"`,
+			},
+			"write_nested": {
+				method:  http.MethodPost,
+				path:    "/stores/" + storeID + "/write",
+				body:    `{"writes": {"tuple_keys": [{"object": "document:1", "relation": "viewer", "user": "user:bob", "conditon": {"name": "x"}}]}}`,
+				message: `unknown field "conditon" at "writes.tuple_keys[0].conditon"`,
+			},
+			"write_authorization_model": {
+				method:  http.MethodPost,
+				path:    "/stores/" + storeID + "/authorization-models",
+				body:    `{"schema_version": "1.1", "type_definitions": [{"type": "user", "relation": {}}]}`,
+				message: `unknown field "relation" at "type_definitions[0].relation"`,
+			},
+			"write_assertions": {
+				method:  http.MethodPut,
+				path:    "/stores/" + storeID + "/assertions/" + modelID,
+				body:    `{"assertions": [{"tuple_key": {"object": "document:1", "relation": "viewer", "user": "user:anne"}, "expected": true, "expectation": true}]}`,
+				message: `unknown field "expected" at "assertions[0].expected"`,
+			},
+		}
+
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				req, err := http.NewRequest(test.method, strictServer.URL+test.path, strings.NewReader(test.body))
+				require.NoError(t, err)
+				req.Header.Set("Content-Type", "application/json")
+				resp, err := http.DefaultClient.Do(req)
+				require.NoError(t, err)
+				defer resp.Body.Close()
+
+				require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+				var errResp struct {
+					Code    string `json:"code"`
+					Message string `json:"message"`
+				}
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+				require.Equal(t, "validation_error", errResp.Code)
+				require.Equal(t, test.message, errResp.Message)
+			})
+		}
+
+		t.Run("reads_ignore_unknown_fields", func(t *testing.T) {
+			body := `{"tuple_key": {"object": "document:1", "relation": "viewer", "user": "user:anne"}, "consistensy": "HIGHER_CONSISTENCY"}`
+			resp, err := http.Post(strictServer.URL+"/stores/"+storeID+"/check", "application/json", strings.NewReader(body))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		})
+
+		t.Run("known_fields_are_accepted", func(t *testing.T) {
+			body := `{"writes": {"tuple_keys": [{"object": "document:3", "relation": "viewer", "user": "user:anne"}]}}`
+			resp, err := http.Post(strictServer.URL+"/stores/"+storeID+"/write", "application/json", strings.NewReader(body))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		})
+	})
+}
+
+func TestAuthorizationModelETag(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithTransport(gateway.NewRPCTransport(logger.NewNoopLogger())))
+	t.Cleanup(s.Close)
+
+	grpcServer := grpc.NewServer()
+	RegisterGRPC(grpcServer, s)
+
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	client := openfgav1.NewOpenFGAServiceClient(conn)
+
+	mux, err := NewGatewayMux(ctx, conn)
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(mux)
+	t.Cleanup(httpServer.Close)
+
+	createStoreResp, err := client.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "etag"})
+	require.NoError(t, err)
+	storeID := createStoreResp.GetId()
+
+	writeModel := func(t *testing.T) string {
+		resp, err := client.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: []*openfgav1.TypeDefinition{{Type: "user"}},
+		})
+		require.NoError(t, err)
+		return resp.GetAuthorizationModelId()
+	}
+	modelID := writeModel(t)
+	etag := `"` + modelID + `"`
+
+	t.Run("grpc", func(t *testing.T) {
+		var header metadata.MD
+		resp, err := client.ReadAuthorizationModel(ctx, &openfgav1.ReadAuthorizationModelRequest{StoreId: storeID, Id: modelID}, grpc.Header(&header))
+		require.NoError(t, err)
+		require.Equal(t, modelID, resp.GetAuthorizationModel().GetId())
+		require.Equal(t, []string{etag}, header.Get(ETagHeader))
+
+		notModifiedCtx := metadata.AppendToOutgoingContext(ctx, IfNoneMatchHeader, etag)
+		resp, err = client.ReadAuthorizationModel(notModifiedCtx, &openfgav1.ReadAuthorizationModelRequest{StoreId: storeID, Id: modelID}, grpc.Header(&header))
+		require.NoError(t, err)
+		require.Nil(t, resp.GetAuthorizationModel())
+		require.Equal(t, []string{etag}, header.Get(ETagHeader))
+
+		otherCtx := metadata.AppendToOutgoingContext(ctx, IfNoneMatchHeader, `"01HXXXXXXXXXXXXXXXXXXXXXXX"`)
+		resp, err = client.ReadAuthorizationModel(otherCtx, &openfgav1.ReadAuthorizationModelRequest{StoreId: storeID, Id: modelID})
+		require.NoError(t, err)
+		require.Equal(t, modelID, resp.GetAuthorizationModel().GetId())
+
+		_, err = client.ReadAuthorizationModel(notModifiedCtx, &openfgav1.ReadAuthorizationModelRequest{StoreId: storeID, Id: ulid.Make().String()})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_authorization_model_not_found), status.Code(err))
+	})
+
+	t.Run("gateway", func(t *testing.T) {
+		get := func(t *testing.T, path, ifNoneMatch string) *http.Response {
+			req, err := http.NewRequest(http.MethodGet, httpServer.URL+path, nil)
+			require.NoError(t, err)
+			if ifNoneMatch != "" {
+				req.Header.Set(IfNoneMatchHeader, ifNoneMatch)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_ = resp.Body.Close()
+			})
+			return resp
+		}
+
+		path := "/stores/" + storeID + "/authorization-models/" + modelID
+		resp := get(t, path, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, etag, resp.Header.Get(ETagHeader))
+
+		resp = get(t, path, `W/"other", `+etag)
+		require.Equal(t, http.StatusNotModified, resp.StatusCode)
+		require.Equal(t, etag, resp.Header.Get(ETagHeader))
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Empty(t, body)
+	})
+
+	t.Run("latest_model", func(t *testing.T) {
+		readLatest := func(t *testing.T, ifNoneMatch string) (*openfgav1.ReadAuthorizationModelsResponse, string) {
+			var header metadata.MD
+			ctx := ctx
+			if ifNoneMatch != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, IfNoneMatchHeader, ifNoneMatch)
+			}
+			resp, err := client.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{
+				StoreId:  storeID,
+				PageSize: wrapperspb.Int32(1),
+			}, grpc.Header(&header))
+			require.NoError(t, err)
+			return resp, strings.Join(header.Get(ETagHeader), ",")
+		}
+
+		resp, latestETag := readLatest(t, "")
+		require.Len(t, resp.GetAuthorizationModels(), 1)
+		require.Equal(t, modelsPageETag(modelID, 1, ""), latestETag)
+
+		resp, _ = readLatest(t, latestETag)
+		require.Empty(t, resp.GetAuthorizationModels())
+
+		// the ETag of the model is not the ETag of the page
+		resp, _ = readLatest(t, etag)
+		require.Len(t, resp.GetAuthorizationModels(), 1)
+
+		oldETag := latestETag
+		newModelID := writeModel(t)
+		resp, latestETag = readLatest(t, oldETag)
+		require.Len(t, resp.GetAuthorizationModels(), 1)
+		require.Equal(t, newModelID, resp.GetAuthorizationModels()[0].GetId())
+		require.Equal(t, modelsPageETag(newModelID, 1, ""), latestETag)
+
+		t.Run("pages_of_other_sizes_and_tokens", func(t *testing.T) {
+			var header metadata.MD
+			resp, err := client.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{
+				StoreId:  storeID,
+				PageSize: wrapperspb.Int32(2),
+			}, grpc.Header(&header))
+			require.NoError(t, err)
+			require.Len(t, resp.GetAuthorizationModels(), 2)
+			require.Equal(t, []string{modelsPageETag(newModelID, 2, "")}, header.Get(ETagHeader))
+
+			first, err := client.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{
+				StoreId:  storeID,
+				PageSize: wrapperspb.Int32(1),
+			})
+			require.NoError(t, err)
+			token := first.GetContinuationToken()
+			require.NotEmpty(t, token)
+
+			resp, err = client.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{
+				StoreId:           storeID,
+				PageSize:          wrapperspb.Int32(1),
+				ContinuationToken: token,
+			}, grpc.Header(&header))
+			require.NoError(t, err)
+			require.Equal(t, modelID, resp.GetAuthorizationModels()[0].GetId())
+			pageETag := modelsPageETag(modelID, 1, token)
+			require.Equal(t, []string{pageETag}, header.Get(ETagHeader))
+
+			resp, err = client.ReadAuthorizationModels(metadata.AppendToOutgoingContext(ctx, IfNoneMatchHeader, pageETag), &openfgav1.ReadAuthorizationModelsRequest{
+				StoreId:           storeID,
+				PageSize:          wrapperspb.Int32(1),
+				ContinuationToken: token,
+			})
+			require.NoError(t, err)
+			require.Empty(t, resp.GetAuthorizationModels())
+		})
+
+		httpResp, err := http.Get(httpServer.URL + "/stores/" + storeID + "/authorization-models?page_size=1")
+		require.NoError(t, err)
+		defer httpResp.Body.Close()
+		require.Equal(t, http.StatusOK, httpResp.StatusCode)
+		require.Equal(t, latestETag, httpResp.Header.Get(ETagHeader))
+	})
+}
+
+func TestPointInTimeCheckOverGateway(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithTransport(gateway.NewRPCTransport(logger.NewNoopLogger())),
+		WithExperimentals(ExperimentalPointInTimeCheck),
+		WithPointInTimeCheckLimits(time.Hour, 0),
+	)
+	t.Cleanup(s.Close)
+
+	grpcServer := grpc.NewServer()
+	RegisterGRPC(grpcServer, s)
+
+	// like the gateway of 'openfga run', connect over a loopback address
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	mux, err := NewGatewayMux(ctx, conn)
+	require.NoError(t, err)
+
+	createStoreResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "point-in-time"})
+	require.NoError(t, err)
+	storeID := createStoreResp.GetId()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	check := func(t *testing.T, remoteAddr, forwardedFor string) int {
+		body := `{"tuple_key": {"object": "document:1", "relation": "viewer", "user": "user:anne"}}`
+		req := httptest.NewRequest(http.MethodPost, "/stores/"+storeID+"/check", strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(CheckAtHeader, time.Now().Add(-time.Second).Format(time.RFC3339Nano))
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("remote_client", func(t *testing.T) {
+		require.Equal(t, http.StatusForbidden, check(t, "10.0.0.1:1234", ""))
+	})
+
+	t.Run("remote_client_with_forwarded_for_header", func(t *testing.T) {
+		require.Equal(t, http.StatusForbidden, check(t, "10.0.0.1:1234", "127.0.0.1"))
+	})
+
+	t.Run("loopback_client", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, check(t, "127.0.0.1:1234", ""))
+	})
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/middleware/validator"
+	"github.com/openfga/openfga/pkg/server/commands"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/server/relationstats"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+// GetRelationStatistics estimates the number of tuples, distinct objects and distinct users of
+// a relation, and the histogram of the number of users per object, e.g. to tune the userset
+// batch size or the dispatch throttling thresholds. The relation must be defined in the latest
+// authorization model of the store.
+//
+// The counts are exact if the datastore implements [storage.RelationCounter], which the
+// built-in datastores do. The histogram is built from a sample of at most the number of tuples
+// set by [WithRelationStatisticsLimits], read within its deadline from a random object of the
+// relation. If the counting times out, the counts of the sample are returned instead, and if
+// the sampling does, the counts are returned without a histogram.
+//
+// Only loopback callers are allowed, see [isLoopbackCaller].
+func (s *Server) GetRelationStatistics(ctx context.Context, req *commands.RelationStatisticsRequest) (_ *commands.RelationStatistics, err error) {
+	ctx, span := tracer.Start(ctx, "GetRelationStatistics", trace.WithAttributes(
+		attribute.String("store_id", req.StoreID),
+		attribute.String("object_type", req.ObjectType),
+		attribute.String("relation", req.Relation),
+	))
+	defer span.End()
+	defer s.inFlightRequests.start("GetRelationStatistics")()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, "GetRelationStatistics", req, &err)
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "GetRelationStatistics",
+	})
+
+	if !isLoopbackCaller(ctx) {
+		span.SetAttributes(attribute.Bool("permission_denied", true))
+		return nil, serverErrors.LoopbackCallerRequired("GetRelationStatistics", "relation statistics can only be read from a loopback address")
+	}
+
+	if req.StoreID == "" || req.ObjectType == "" || req.Relation == "" {
+		return nil, serverErrors.RequiredFieldsMissing("the store ID, object type and relation are required", "store_id", "object_type", "relation")
+	}
+	if err := validator.ValidateStoreID(req.StoreID); err != nil {
+		return nil, err
+	}
+
+	typesys, err := s.resolveTypesystem(ctx, req.StoreID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := typesys.GetRelation(req.ObjectType, req.Relation); err != nil {
+		return nil, serverErrors.RelationNotFound(req.Relation, req.ObjectType, nil)
+	}
+
+	if s.relationStatisticsDeadline != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.relationStatisticsDeadline)
+		defer cancel()
+	}
+
+	opts := []commands.RelationStatisticsQueryOption{
+		commands.WithRelationStatisticsQueryLogger(s.logger),
+		commands.WithRelationStatisticsMaxSampleSize(s.relationStatisticsMaxSampleSize),
+	}
+	if s.relationCounter != nil {
+		opts = append(opts, commands.WithRelationStatisticsCounter(s.relationCounter))
+	}
+
+	stats, err := commands.NewRelationStatisticsQuery(s.datastore, opts...).Execute(ctx, req)
+	if err != nil {
+		telemetry.TraceError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.Int("sample_size", stats.SampleSize),
+		attribute.Bool("exact", stats.Exact),
+	)
+
+	return stats, nil
+}
+
+// ReadRelationStatistics serves [Server.GetRelationStatistics] as the method of the
+// [relationstats.ServiceDesc] gRPC service.
+func (s *Server) ReadRelationStatistics(ctx context.Context, req *structpb.Struct) (_ *structpb.Struct, err error) {
+	defer s.recoverFromPanic(ctx, "ReadRelationStatistics", req, &err)
+
+	fields, err := structStringFields(req, relationstats.StoreIDField, relationstats.ObjectTypeField, relationstats.RelationField)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.GetRelationStatistics(ctx, &commands.RelationStatisticsRequest{
+		StoreID:    fields[relationstats.StoreIDField],
+		ObjectType: fields[relationstats.ObjectTypeField],
+		Relation:   fields[relationstats.RelationField],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonStruct(stats, "the relation statistics")
+}
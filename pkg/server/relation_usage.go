@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/openfga/openfga/pkg/middleware/validator"
+	"github.com/openfga/openfga/pkg/server/commands"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+// GetRelationUsage reports, for each relation of the latest authorization model of a store,
+// whether tuples of the store reference it and how many, and lists the relations that tuples
+// reference but the model does not define, i.e. the orphan tuples left behind by the removal of
+// a type or relation from the model. It answers whether a relation can be removed from the model.
+//
+// The counts are exact if the datastore implements [storage.RelationUsageCounter], which the
+// built-in datastores do with one grouped query. Otherwise the tuples of the store are read
+// within the deadline set by [WithRelationUsageDeadline], and the counts are those of the tuples
+// read so far if it is exceeded.
+//
+// Only loopback callers are allowed, see [isLoopbackCaller].
+func (s *Server) GetRelationUsage(ctx context.Context, storeID string) (_ *commands.RelationUsageReport, err error) {
+	ctx, span := tracer.Start(ctx, "GetRelationUsage", trace.WithAttributes(
+		attribute.String("store_id", storeID),
+	))
+	defer span.End()
+	defer s.inFlightRequests.start("GetRelationUsage")()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, "GetRelationUsage", storeID, &err)
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "GetRelationUsage",
+	})
+
+	if !isLoopbackCaller(ctx) {
+		span.SetAttributes(attribute.Bool("permission_denied", true))
+		return nil, serverErrors.LoopbackCallerRequired("GetRelationUsage", "relation usage can only be read from a loopback address")
+	}
+
+	if err := validator.ValidateStoreID(storeID); err != nil {
+		return nil, err
+	}
+
+	typesys, err := s.resolveTypesystem(ctx, storeID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if s.relationUsageDeadline != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.relationUsageDeadline)
+		defer cancel()
+	}
+
+	opts := []commands.RelationUsageQueryOption{
+		commands.WithRelationUsageQueryLogger(s.logger),
+	}
+	if s.relationUsageCounter != nil {
+		opts = append(opts, commands.WithRelationUsageCounter(s.relationUsageCounter))
+	}
+
+	report, err := commands.NewRelationUsageQuery(s.datastore, opts...).Execute(ctx, storeID, typesys)
+	if err != nil {
+		telemetry.TraceError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.String(authorizationModelIDKey, report.AuthorizationModelID),
+		attribute.Int("orphans", len(report.Orphans)),
+		attribute.Bool("exact", report.Exact),
+	)
+
+	return report, nil
+}
@@ -0,0 +1,3 @@
+// Package relationstats describes the gRPC service that reads the statistics of a relation of a
+// store, which the OpenFGA API does not define.
+package relationstats
@@ -0,0 +1,62 @@
+package relationstats
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// ServiceName is the name of the gRPC service of ReadRelationStatistics. It is not part of the
+	// openfga.v1 package of the OpenFGA API.
+	ServiceName = "openfga.server.relationstats.v1.RelationStatisticsService"
+
+	// ReadRelationStatisticsFullMethodName is the full gRPC method name of ReadRelationStatistics.
+	ReadRelationStatisticsFullMethodName = "/" + ServiceName + "/ReadRelationStatistics"
+)
+
+// The fields of the request of ReadRelationStatistics.
+const (
+	StoreIDField    = "store_id"
+	ObjectTypeField = "object_type"
+	RelationField   = "relation"
+)
+
+// ServiceDesc describes the gRPC service of ReadRelationStatistics. As the OpenFGA API has no
+// messages for it, the request is a Struct with the string fields StoreIDField, ObjectTypeField
+// and RelationField, and the response is a Struct with the fields of the JSON form of the
+// statistics, e.g. {"tuple_count": 6, "distinct_objects": 3, "users_per_object": [...], ...}.
+var ServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*RelationStatisticsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ReadRelationStatistics",
+			Handler:    readRelationStatisticsHandler,
+		},
+	},
+}
+
+// RelationStatisticsServer is the server API of the service of ServiceDesc.
+type RelationStatisticsServer interface {
+	ReadRelationStatistics(context.Context, *structpb.Struct) (*structpb.Struct, error)
+}
+
+func readRelationStatisticsHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationStatisticsServer).ReadRelationStatistics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReadRelationStatisticsFullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationStatisticsServer).ReadRelationStatistics(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
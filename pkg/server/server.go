@@ -3,13 +3,20 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"runtime"
 	"slices"
 	"sort"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/openfga/openfga/internal/graph"
 
@@ -26,20 +33,25 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/internal/concurrency"
 	"github.com/openfga/openfga/internal/condition"
 	serverconfig "github.com/openfga/openfga/internal/server/config"
 	"github.com/openfga/openfga/internal/utils"
+	"github.com/openfga/openfga/pkg/dispatch"
 	"github.com/openfga/openfga/pkg/encoder"
 	"github.com/openfga/openfga/pkg/gateway"
+	"github.com/openfga/openfga/pkg/id"
 	"github.com/openfga/openfga/pkg/logger"
 	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
 	"github.com/openfga/openfga/pkg/middleware/validator"
 	"github.com/openfga/openfga/pkg/server/commands"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/membershipindex"
 	"github.com/openfga/openfga/pkg/storage/storagewrappers"
 	"github.com/openfga/openfga/pkg/telemetry"
 	"github.com/openfga/openfga/pkg/typesystem"
@@ -48,11 +60,125 @@ import (
 type ExperimentalFeatureFlag string
 
 const (
-	AuthorizationModelIDHeader = "Openfga-Authorization-Model-Id"
-	authorizationModelIDKey    = "authorization_model_id"
-	allowedLabel               = "allowed"
+	// ExperimentalCheckDenialReasons reports why Check denied a request and how many
+	// objects ListObjects excluded because of unmet conditions. There is no per-API
+	// authorization in this server, so the experimental flag is the only gate.
+	ExperimentalCheckDenialReasons ExperimentalFeatureFlag = "enable-check-denial-reasons"
+	// ExperimentalPointInTimeCheck evaluates Check requests with the CheckAtHeader against the
+	// tuples as they were at a point in time, reconstructed from the changelog.
+	ExperimentalPointInTimeCheck ExperimentalFeatureFlag = "enable-point-in-time-check"
 )
 
+const (
+	AuthorizationModelIDHeader       = "Openfga-Authorization-Model-Id"
+	AuthorizationModelWarningsHeader = "Openfga-Authorization-Model-Warnings"
+	ListUsersTruncatedHeader         = "Openfga-ListUsers-Truncated"
+	ExpandTruncatedHeader            = "Openfga-Expand-Truncated"
+	// ExpandCompactTreeHeader is the response header of Expand with the compact userset tree
+	// requested with the ExpandFormatHeader, as JSON whose non-ASCII characters are escaped.
+	ExpandCompactTreeHeader = "Openfga-Expand-Compact-Tree"
+	// AuthorizationModelStoredSizeHeader is the response header of WriteAuthorizationModel with
+	// the size in bytes of the model as stored, the size checked against
+	// [WithMaxAuthorizationModelSizeInBytes] with the size of the request.
+	AuthorizationModelStoredSizeHeader = "Openfga-Authorization-Model-Stored-Size"
+	// StreamTruncatedHeader is the trailer of the streams that ended before all their results
+	// were sent, with the cause of the truncation, e.g. "max_stream_duration" (see
+	// [WithMaxStreamDuration]).
+	StreamTruncatedHeader = "Openfga-Stream-Truncated"
+
+	// AuthorizationModelAliasHeader is the request header (or gRPC metadata key) that selects
+	// the authorization model by alias (see [Server.WriteModelAlias]) when the request has no
+	// authorization model ID.
+	AuthorizationModelAliasHeader = "Openfga-Authorization-Model-Alias"
+
+	// ReadConditionNameHeader is the request header (or gRPC metadata key) that restricts
+	// Read to the tuples with the condition of the given name.
+	ReadConditionNameHeader = "Openfga-Read-Condition-Name"
+	// ReadHasConditionHeader is the request header (or gRPC metadata key) that restricts
+	// Read to the tuples with ("true") or without ("false") a condition.
+	ReadHasConditionHeader = "Openfga-Read-Has-Condition"
+	// WriteAllowStaleModelHeader is the request header (or gRPC metadata key) that lets a Write
+	// evaluated with an authorization model other than the latest through when the server
+	// rejects them (see [WithRejectWritesOnStaleModel]), if set to "true".
+	WriteAllowStaleModelHeader = "Openfga-Write-Allow-Stale-Model"
+	// CheckAtHeader is the request header (or gRPC metadata key) that evaluates a Check against
+	// the tuples as they were at a point in time, given as an RFC 3339 timestamp or as a ULID,
+	// whose millisecond timestamp is used (see [ExperimentalPointInTimeCheck]).
+	CheckAtHeader = "Openfga-Check-At"
+	// ReadChangesStartTimeHeader is the request header (or gRPC metadata key) that makes
+	// ReadChanges start at the first change that occurred at or after a time, given as an RFC 3339
+	// timestamp. It cannot be combined with a continuation token.
+	ReadChangesStartTimeHeader = "Openfga-ReadChanges-Start-Time"
+	// ExpandFormatHeader is the request header (or gRPC metadata key) that makes Expand also
+	// return the userset tree in its compact adjacency list representation (see
+	// [Server.ExpandCompact]), as JSON in the ExpandCompactTreeHeader, if set to "compact".
+	ExpandFormatHeader = "Openfga-Expand-Format"
+	// ListStoresIncludeTotalCountHeader is the request header (or gRPC metadata key) that makes
+	// ListStores return the number of stores in the ListStoresTotalCountHeader, if set to "true".
+	ListStoresIncludeTotalCountHeader = "Openfga-ListStores-Include-Total-Count"
+	// ListStoresNameHeader is the request header (or gRPC metadata key) that makes ListStores
+	// return only the stores with exactly the given name.
+	ListStoresNameHeader = "Openfga-ListStores-Name"
+	// WriteModelImpactAnalysisHeader is the request header (or gRPC metadata key) that makes
+	// WriteAuthorizationModel look for the existing tuples of the store that the new model makes
+	// invalid before writing it, and report them as warnings in the
+	// AuthorizationModelWarningsHeader ("warn") or reject the model ("strict"). See
+	// [WithModelImpactAnalysisMaxProbes].
+	WriteModelImpactAnalysisHeader = "Openfga-Write-Model-Impact-Analysis"
+	// AcceptLanguageHeader is the request header (or gRPC metadata key) with the locale of the
+	// messages of the errors, passed to the message catalog (see [WithErrorMessageCatalog]).
+	AcceptLanguageHeader = "Accept-Language"
+	// ListStoresTotalCountHeader is the response header with the number of stores, and
+	// ListStoresTotalCountEstimatedHeader is set to "true" if it is an estimate (see
+	// [WithListStoresExactTotalCount]).
+	ListStoresTotalCountHeader          = "Openfga-ListStores-Total-Count"
+	ListStoresTotalCountEstimatedHeader = "Openfga-ListStores-Total-Count-Estimated"
+	// ReadChangesHorizonHeader is the response header with the changelog horizon of ReadChanges,
+	// and ReadChangesNewestWithheldChangeHeader the time of the newest change withheld by it,
+	// both in RFC 3339 format (see [WithReadChangesHorizonHeaders]).
+	ReadChangesHorizonHeader               = "Openfga-ReadChanges-Horizon"
+	ReadChangesNewestWithheldChangeHeader  = "Openfga-ReadChanges-Newest-Withheld-Change"
+	CheckDenialReasonHeader                = "Openfga-Check-Denial-Reason"
+	CheckMissingContextHeader              = "Openfga-Check-Missing-Context"
+	ListObjectsConditionalExclusionsHeader = "Openfga-ListObjects-Conditional-Exclusions"
+	// ReadChangesContinuationTokenHeader is the trailer of the streams of StreamedReadChanges with
+	// the continuation token of the last page of changes they read, to resume the stream from.
+	ReadChangesContinuationTokenHeader = "Openfga-ReadChanges-Continuation-Token"
+	// CheckCacheHeader is the response header that reports whether the result of a Check was
+	// served from the check query cache (see [WithCheckQueryCacheResponseHeader]).
+	CheckCacheHeader = "Openfga-Check-Cache"
+	// DispatchCountHeader and DatastoreQueryCountHeader are the response headers of Check and
+	// ListObjects, and the trailers of StreamedListObjects, with the number of dispatches and
+	// datastore queries of the request (see [WithCheckResponseMetadataHeaders]).
+	DispatchCountHeader       = "Openfga-Dispatch-Count"
+	DatastoreQueryCountHeader = "Openfga-Datastore-Query-Count"
+	// ETagHeader is the response header with the entity tag of the authorization models returned
+	// by ReadAuthorizationModel and of the pages of ReadAuthorizationModels. Requests with
+	// an IfNoneMatchHeader that matches it get a response without models, with a 304 status over
+	// HTTP, so that clients polling for model changes only download them when they change.
+	ETagHeader        = "ETag"
+	IfNoneMatchHeader = "If-None-Match"
+
+	authorizationModelIDKey = "authorization_model_id"
+	allowedLabel            = "allowed"
+)
+
+// RequestHeaders are the request headers (or gRPC metadata keys) read by the handlers of the
+// server, besides the request options of the requestcontext package.
+var RequestHeaders = []string{
+	AuthorizationModelAliasHeader,
+	ReadConditionNameHeader,
+	ReadHasConditionHeader,
+	WriteAllowStaleModelHeader,
+	CheckAtHeader,
+	ReadChangesStartTimeHeader,
+	ExpandFormatHeader,
+	ListStoresIncludeTotalCountHeader,
+	ListStoresNameHeader,
+	WriteModelImpactAnalysisHeader,
+	AcceptLanguageHeader,
+}
+
 var tracer = otel.Tracer("openfga/pkg/server")
 
 var (
@@ -80,6 +206,16 @@ var (
 		NativeHistogramMinResetDuration: time.Hour,
 	}, []string{"grpc_service", "grpc_method"})
 
+	datastoreQueryCountByKindHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:                       build.ProjectName,
+		Name:                            "datastore_query_count_by_kind",
+		Help:                            "The number of tuple queries of each kind (read, read_user_tuple, read_userset_tuples or read_starting_with_user) required to resolve a query (e.g. Check, ListObjects or ListUsers).",
+		Buckets:                         []float64{1, 5, 20, 50, 100, 150, 225, 400, 500, 750, 1000},
+		NativeHistogramBucketFactor:     1.1,
+		NativeHistogramMaxBucketNumber:  100,
+		NativeHistogramMinResetDuration: time.Hour,
+	}, []string{"grpc_service", "grpc_method", "query_kind"})
+
 	requestDurationHistogramName = "request_duration_ms"
 
 	requestDurationHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
@@ -96,7 +232,43 @@ var (
 		Namespace: build.ProjectName,
 		Name:      "throttled_requests_count",
 		Help:      "The total number of requests that have been throttled.",
-	}, []string{"grpc_service", "grpc_method"})
+	}, []string{"grpc_service", "grpc_method", "priority"})
+
+	truncatedRequestCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "truncated_requests_count",
+		Help:      "The total number of requests that returned partial results, labeled by the cause of the truncation.",
+	}, []string{"grpc_service", "grpc_method", "cause"})
+
+	tuplesWrittenCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "tuples_written_count",
+		Help:      "The total number of tuples written by successful Write requests, labeled by store ID for the stores allowlisted by WithPerStoreWriteMetrics and 'other' for the rest.",
+	}, []string{"store_id"})
+
+	staleModelWritesRejectedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "stale_model_writes_rejected_count",
+		Help:      "The total number of Write requests rejected because they were evaluated with an authorization model other than the latest, labeled by store ID for the stores allowlisted by WithPerStoreWriteMetrics and 'other' for the rest.",
+	}, []string{"store_id"})
+
+	tuplesDeletedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "tuples_deleted_count",
+		Help:      "The total number of tuples deleted by successful Write requests, labeled by store ID for the stores allowlisted by WithPerStoreWriteMetrics and 'other' for the rest.",
+	}, []string{"store_id"})
+
+	authorizationModelComplexityGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "authorization_model_complexity_score",
+		Help:      "The complexity score of the last authorization model written to each store allowlisted by WithPerStoreModelComplexityMetrics.",
+	}, []string{"store_id"})
+
+	buildInfoGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "build_info",
+		Help:      "Always 1, labeled by the build version, commit and Go version of the server, by the version of the schema of the root authorization model its FGA-on-FGA access control expects, and by its enabled experimental features, comma separated and sorted.",
+	}, []string{"version", "commit", "go_version", "authz_model_schema_version", "experimentals"})
 
 	checkResultCounterName = "check_result_count"
 	checkResultCounter     = promauto.NewCounterVec(prometheus.CounterOpts{
@@ -111,36 +283,93 @@ var (
 type Server struct {
 	openfgav1.UnimplementedOpenFGAServiceServer
 
+	// createdAt and inFlightRequests are reported by Stats
+	createdAt        time.Time
+	inFlightRequests inFlightRequests
+
+	// the commands below hold no state of a request, so they are constructed once and shared
+	listObjectsQuery *commands.ListObjectsQuery
+	readQuery        *commands.ReadQuery
+	expandQuery      *commands.ExpandQuery
+
 	logger                           logger.Logger
 	datastore                        storage.OpenFGADatastore
 	checkDatastore                   storage.OpenFGADatastore
+	expandTupleReader                storage.RelationshipTupleReader
+	readTupleReader                  storage.RelationshipTupleReader
 	encoder                          encoder.Encoder
+	idGenerator                      id.Generator
 	transport                        gateway.Transport
 	resolveNodeLimit                 uint32
 	resolveNodeBreadthLimit          uint32
+	resolveNodeBreadthLimitShadow    uint32
+	resolveNodeLimitShadow           uint32
 	usersetBatchSize                 uint32
 	changelogHorizonOffset           int
 	listObjectsDeadline              time.Duration
 	listObjectsMaxResults            uint32
+	listObjectsMaxCandidates         uint32
+	listObjectsStrategy              commands.ListObjectsStrategy
+	listObjectsStreamMaxModelLag     uint32
+	listObjectsStreamCheckInterval   time.Duration
+	maxStreamDuration                time.Duration
+	expandMaxLeafUsers               uint32
+	expandMaxNodes                   uint32
+	expandStrictResultLimit          bool
+	relationStatisticsMaxSampleSize  uint32
+	relationStatisticsDeadline       time.Duration
+	relationUsageDeadline            time.Duration
+	deleteTuplesByFilterMaxTuples    uint32
+	deleteTuplesByFilterMaxScanned   uint32
+	pointInTimeCheckHorizon          time.Duration
+	pointInTimeCheckMaxChanges       uint32
+	errorReasonDetails               bool
+	errorMessageCatalog              serverErrors.MessageCatalog
+	relationCounter                  storage.RelationCounter
+	relationUsageCounter             storage.RelationUsageCounter
+	indexAdvisor                     storage.IndexAdvisor
+	modelAliases                     storage.ModelAliasBackend
+	modelAliasCache                  *modelAliasCache
 	listUsersDeadline                time.Duration
 	listUsersMaxResults              uint32
 	maxConcurrentReadsForListObjects uint32
 	maxConcurrentReadsForCheck       uint32
+	snapshotReadsForCheck            bool
+	snapshotBeginner                 storage.SnapshotBeginner
+	maxChecksPerBatchCheck           uint32
 	maxConcurrentReadsForListUsers   uint32
+	maxConcurrentReadsForExpand      uint32
+	maxConcurrentReadsForRead        uint32
+	maxReadResponseSizeInBytes       int
+	datastoreHedgingEnabled          bool
+	datastoreHedgingMinDelay         time.Duration
+	datastoreHedgingPercentile       float64
+	maxDatastoreHedgesPerRequest     uint32
+	// datastoreHedgingPolicy is shared by the requests hedging their reads, if hedging is enabled.
+	datastoreHedgingPolicy           *storagewrappers.HedgingPolicy
 	maxAuthorizationModelCacheSize   int
 	maxAuthorizationModelSizeInBytes int
 	experimentals                    []ExperimentalFeatureFlag
+	experimentalFeatures             map[ExperimentalFeatureFlag]ExperimentalFeature
+	deprecatedExperimentalLogs       experimentalLogLimiter
 	serviceName                      string
 
 	// NOTE don't use this directly, use function resolveTypesystem. See https://github.com/openfga/openfga/issues/1527
-	typesystemResolver     typesystem.TypesystemResolverFunc
-	typesystemResolverStop func()
+	typesystemResolver         typesystem.TypesystemResolverFunc
+	memoizedTypesystemResolver *typesystem.MemoizedTypesystemResolver
+	// modelCache is the datastore wrapper that caches the models, refreshed by RefreshStore.
+	modelCache storagewrappers.ModelCacheRefresher
 
 	cacheLimit uint32
-	cache      storage.InMemoryCache[any]
+	cache      *countingCache
+
+	checkQueryCacheEnabled        bool
+	checkQueryCacheTTL            time.Duration
+	checkQueryCacheResponseHeader bool
+
+	checkResponseMetadataHeaders bool
 
-	checkQueryCacheEnabled bool
-	checkQueryCacheTTL     time.Duration
+	checkDeduplicationEnabled bool
 
 	checkIteratorCacheEnabled    bool
 	checkIteratorCacheMaxResults uint32
@@ -148,6 +377,10 @@ type Server struct {
 	checkResolver       graph.CheckResolver
 	checkResolverCloser func()
 
+	resolverWorkerPoolEnabled bool
+	resolverWorkerPoolSize    int
+	resolverWorkerPool        *concurrency.WorkerPool
+
 	requestDurationByQueryHistogramBuckets         []uint
 	requestDurationByDispatchCountHistogramBuckets []uint
 
@@ -169,6 +402,79 @@ type Server struct {
 	listObjectsDispatchThrottler throttler.Throttler
 	listUsersDispatchThrottler   throttler.Throttler
 
+	// checkDispatchThrottler is closed by the check resolver, it is kept to report its queue depth
+	checkDispatchThrottler throttler.Throttler
+
+	requestPriorityEnabled             bool
+	requestPriorityMaxDeprioritization uint32
+
+	shadowModelEvaluationSampleRate float64
+	shadowModelEvaluator            *shadowModelEvaluator
+
+	perStoreWriteMetricsAllowlist map[string]struct{}
+
+	writeReferentialCheckEnabled bool
+
+	rejectWritesOnStaleModel          bool
+	rejectWritesOnStaleModelOverrides map[string]bool
+
+	storeCounter              storage.StoreCounter
+	listStoresExactTotalCount bool
+
+	uniqueStoreNamesEnabled bool
+	uniqueStoreNames        storage.UniqueStoreNamesBackend
+
+	readChangesHorizonHeaders bool
+
+	readChangesWatchPollInterval      time.Duration
+	readChangesWatchHeartbeatInterval time.Duration
+	readChangesWatchMaxStreams        uint32
+	// readChangesWatchStreams is the number of streams StreamedReadChanges serves
+	readChangesWatchStreams atomic.Int64
+
+	tupleCounter        storage.TupleCounter
+	tupleQuota          commands.TupleQuota
+	tupleQuotaOverrides map[string]commands.TupleQuota
+
+	strictConditionParameters bool
+
+	maxAuthorizationModelComplexity         int
+	maxAuthorizationModelTypes              int
+	maxAuthorizationModelRelationsPerType   int
+	maxAuthorizationModelRewriteDepth       int
+	authorizationModelValidationTimeout     time.Duration
+	authorizationModelValidationMaxVisits   int
+	modelImpactAnalysisMaxProbes            int
+	perStoreModelComplexityMetricsAllowlist map[string]struct{}
+
+	changelogMetricsInterval          time.Duration
+	perStoreChangelogMetricsAllowlist map[string]struct{}
+	changelogMetricsReporter          *changelogMetricsReporter
+
+	readOnly bool
+
+	sessionAffinityEnabled     bool
+	sessionAffinityWindow      time.Duration
+	sessionAffinityMaxSessions uint32
+	// sessionAffinity is the datastore wrapper that tracks the writes of the clients, if enabled.
+	sessionAffinity *storagewrappers.SessionAffinityDatastore
+
+	membershipIndexRelations    []string
+	membershipIndexPollInterval time.Duration
+	membershipIndexMaxStaleness time.Duration
+	membershipIndexMaxStores    int
+	membershipIndexIdleTimeout  time.Duration
+	// membershipIndex is consulted by Check for the members of the indexed relations, if any.
+	membershipIndex storage.MembershipIndex
+	// ownsMembershipIndex is true if the server created the membership index, and so closes it.
+	ownsMembershipIndex bool
+
+	checkFairReadSharing             bool
+	checkStoreReadWeights            map[string]uint32
+	perStoreReadWaitMetricsAllowlist []string
+	// checkReadLimiter shares the concurrent reads of Check among the stores, if enabled.
+	checkReadLimiter *storagewrappers.FairShareLimiter
+
 	ctx context.Context
 }
 
@@ -208,6 +514,14 @@ func WithTokenEncoder(encoder encoder.Encoder) OpenFGAServiceV1Option {
 	}
 }
 
+// WithIDGenerator sets the generator of new store and authorization model IDs.
+// Generated IDs must be valid ULIDs, see [id.Generator]. NewServerWithOpts fails if it is nil.
+func WithIDGenerator(g id.Generator) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.idGenerator = g
+	}
+}
+
 // WithTransport sets the connection transport.
 func WithTransport(t gateway.Transport) OpenFGAServiceV1Option {
 	return func(s *Server) {
@@ -237,6 +551,45 @@ func WithResolveNodeBreadthLimit(limit uint32) OpenFGAServiceV1Option {
 	}
 }
 
+// WithResolveNodeBreadthLimitShadow sets a candidate resolve node breadth limit that is measured
+// but not enforced, to evaluate a lower WithResolveNodeBreadthLimit before setting it.
+// Every Check resolution in which a node evaluates more subproblems than the shadow limit
+// increments the check_resolve_node_shadow_limit_exceeded_count metric, and a sample of them is
+// logged with the store and the relation of the node. 0, the default, disables it.
+func WithResolveNodeBreadthLimitShadow(limit uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.resolveNodeBreadthLimitShadow = limit
+	}
+}
+
+// WithResolveNodeLimitShadow is the WithResolveNodeBreadthLimitShadow counterpart of
+// WithResolveNodeLimit: it records the Check resolutions deeper than the shadow limit.
+// 0, the default, disables it.
+func WithResolveNodeLimitShadow(limit uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.resolveNodeLimitShadow = limit
+	}
+}
+
+// WithResolverWorkerPoolEnabled makes Check resolution and the checks of ListObjects run their
+// concurrent subproblems on a pool of long-lived goroutines, instead of starting a goroutine per
+// subproblem. When every worker is busy, subproblems run in the goroutine that submits them.
+// It is disabled by default.
+func WithResolverWorkerPoolEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.resolverWorkerPoolEnabled = enabled
+	}
+}
+
+// WithResolverWorkerPoolSize sets the number of goroutines of the resolver worker pool, see
+// [WithResolverWorkerPoolEnabled]. 0, the default, sizes the pool to GOMAXPROCS times the resolve
+// node breadth limit.
+func WithResolverWorkerPoolSize(size int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.resolverWorkerPoolSize = size
+	}
+}
+
 // WithUsersetBatchSize in Check requests, configures how many usersets are collected
 // before we start processing them.
 //
@@ -270,8 +623,35 @@ func WithChangelogHorizonOffset(offset int) OpenFGAServiceV1Option {
 	}
 }
 
+// WithReadChangesWatchPollInterval sets how often the streams of StreamedReadChanges read the
+// changelog for new changes once they have read all of them. Non-positive intervals are rejected.
+func WithReadChangesWatchPollInterval(interval time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.readChangesWatchPollInterval = interval
+	}
+}
+
+// WithReadChangesWatchHeartbeatInterval sets how long the streams of StreamedReadChanges can go
+// without sending a response before they send an empty one, with the continuation token of the
+// stream, so that proxies do not close idle streams. 0 disables the heartbeats.
+func WithReadChangesWatchHeartbeatInterval(interval time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.readChangesWatchHeartbeatInterval = interval
+	}
+}
+
+// WithReadChangesWatchMaxStreams sets the maximum number of streams of StreamedReadChanges the
+// server serves at once. Streams over the limit are rejected with a ResourceExhausted error.
+// 0 means no limit.
+func WithReadChangesWatchMaxStreams(maxStreams uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.readChangesWatchMaxStreams = maxStreams
+	}
+}
+
 // WithListObjectsDeadline affect the ListObjects API and Streamed ListObjects API only.
 // It sets the maximum amount of time that the server will spend gathering results.
+// If it's zero, there is no deadline. Negative deadlines are rejected.
 func WithListObjectsDeadline(deadline time.Duration) OpenFGAServiceV1Option {
 	return func(s *Server) {
 		s.listObjectsDeadline = deadline
@@ -280,6 +660,7 @@ func WithListObjectsDeadline(deadline time.Duration) OpenFGAServiceV1Option {
 
 // WithListObjectsMaxResults affects the ListObjects API only.
 // It sets the maximum number of results that this API will return.
+// If it's zero, all results will be attempted to be returned.
 func WithListObjectsMaxResults(limit uint32) OpenFGAServiceV1Option {
 	return func(s *Server) {
 		s.listObjectsMaxResults = limit
@@ -288,6 +669,7 @@ func WithListObjectsMaxResults(limit uint32) OpenFGAServiceV1Option {
 
 // WithListUsersDeadline affect the ListUsers API only.
 // It sets the maximum amount of time that the server will spend gathering results.
+// If it's zero, there is no deadline. Negative deadlines are rejected.
 func WithListUsersDeadline(deadline time.Duration) OpenFGAServiceV1Option {
 	return func(s *Server) {
 		s.listUsersDeadline = deadline
@@ -303,6 +685,129 @@ func WithListUsersMaxResults(limit uint32) OpenFGAServiceV1Option {
 	}
 }
 
+// WithListObjectsMaxCandidates sets the maximum number of distinct candidate objects that
+// a single ListObjects or StreamedListObjects call may hold in memory while expanding the
+// relationship graph. If exceeded, the call fails with a ResourceExhausted error instead of
+// exhausting the memory of the server. 0 means no limit.
+func WithListObjectsMaxCandidates(limit uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listObjectsMaxCandidates = limit
+	}
+}
+
+// WithListObjectsStrategy sets how ListObjects and StreamedListObjects find the objects of a
+// request. With [commands.ListObjectsStrategyAuto], the default, the strategy is picked from the
+// shape of the requested relation: directly assigned relations are read with a single
+// ReadStartingWithUser per relation, relations made of several tuple to usersets check the
+// objects of their tuplesets, exclusions and intersections of computed relations check only the
+// excluded or intersected relations on the objects of their base, and other relations are
+// reverse expanded. The strategy is recorded in the list_objects_strategy span attribute and the
+// list_objects_strategy_count metric.
+func WithListObjectsStrategy(strategy commands.ListObjectsStrategy) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listObjectsStrategy = strategy
+	}
+}
+
+// WithStreamedListObjectsMaxModelLag ends StreamedListObjects streams that are evaluated with the
+// latest authorization model of the store with an Aborted error when, checked every
+// checkInterval, more than maxVersions models were written since the stream started, so that
+// consumers that require fresh results can restart it. 0 disables the check.
+func WithStreamedListObjectsMaxModelLag(maxVersions uint32, checkInterval time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listObjectsStreamMaxModelLag = maxVersions
+		s.listObjectsStreamCheckInterval = checkInterval
+	}
+}
+
+// WithMaxStreamDuration ends the streams of StreamedListObjects and StreamedReadChanges that last
+// longer than maxDuration, between two objects or pages of changes, without an error and with the
+// reason of the truncation in the StreamTruncatedHeader trailer, so that clients behind proxies
+// that reset long-lived streams can tell an incomplete stream from a broken one. 0 means no limit.
+func WithMaxStreamDuration(maxDuration time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxStreamDuration = maxDuration
+	}
+}
+
+// WithExpandResultLimit sets the maximum number of leaf users (including the computed
+// usersets of tuple-to-userset leaves) and of nodes in the tree returned by Expand.
+// When a limit is exceeded, the rest of the tree is replaced by marker nodes named
+// "truncated" and the Openfga-Expand-Truncated response header is set, or, if strict is
+// true, the call fails with a ResourceExhausted error. 0 means no limit.
+func WithExpandResultLimit(maxLeafUsers, maxNodes uint32, strict bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.expandMaxLeafUsers = maxLeafUsers
+		s.expandMaxNodes = maxNodes
+		s.expandStrictResultLimit = strict
+	}
+}
+
+// WithRelationStatisticsLimits sets the maximum number of tuples read by, and the timeout
+// deadline of, a single GetRelationStatistics call.
+func WithRelationStatisticsLimits(maxSampleSize uint32, deadline time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.relationStatisticsMaxSampleSize = maxSampleSize
+		s.relationStatisticsDeadline = deadline
+	}
+}
+
+// WithRelationUsageDeadline sets the timeout deadline of a single GetRelationUsage call. 0 means
+// no deadline.
+func WithRelationUsageDeadline(deadline time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.relationUsageDeadline = deadline
+	}
+}
+
+// WithDeleteTuplesByFilterMaxTuples sets the maximum number of tuples deleted by a single
+// DeleteTuplesByFilter call. 0 means no limit.
+func WithDeleteTuplesByFilterMaxTuples(limit uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.deleteTuplesByFilterMaxTuples = limit
+	}
+}
+
+// WithDeleteTuplesByFilterMaxScanned sets the maximum number of tuples read by a single
+// DeleteTuplesByFilter call to find the ones selected by its filter. 0 means no limit.
+func WithDeleteTuplesByFilterMaxScanned(limit uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.deleteTuplesByFilterMaxScanned = limit
+	}
+}
+
+// WithPointInTimeCheckLimits sets how far back a point-in-time Check can be evaluated, and the
+// maximum number of changelog entries read to evaluate one (see [ExperimentalPointInTimeCheck]).
+// 0 means no limit.
+func WithPointInTimeCheckLimits(horizon time.Duration, maxChanges uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.pointInTimeCheckHorizon = horizon
+		s.pointInTimeCheckMaxChanges = maxChanges
+	}
+}
+
+// WithErrorReasonDetails adds to the errors of the Write, Check, ListObjects and ListUsers APIs
+// an ErrorInfo detail whose reason tells the errors caused by the authorization model
+// (MODEL_INVALID and MODEL_NOT_FOUND) from those caused by the tuples (TUPLE_INVALID) or the
+// condition context (CONDITION_CONTEXT_INVALID) of the request, and from the limits of the
+// server (LIMIT_EXCEEDED). See [serverErrors.WithErrorReason].
+func WithErrorReasonDetails(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.errorReasonDetails = enabled
+	}
+}
+
+// WithErrorMessageCatalog sets the catalog of the messages of the errors returned by the
+// server, e.g. to translate them to the locale of the AcceptLanguageHeader of each request, which
+// the catalog receives as the [serverErrors.LocaleParam] param. The catalog is called with the
+// reason and the params of the ErrorInfo details of the error, and only replaces its message:
+// the codes and details of the errors are the same with or without catalog.
+func WithErrorMessageCatalog(catalog func(code string, params map[string]string) string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.errorMessageCatalog = catalog
+	}
+}
+
 // WithMaxConcurrentReadsForListObjects sets a limit on the number of datastore reads that can be in flight for a given ListObjects call.
 // This number should be set depending on the RPS expected for Check and ListObjects APIs, the number of OpenFGA replicas running,
 // and the number of connections the datastore allows.
@@ -329,6 +834,60 @@ func WithMaxConcurrentReadsForCheck(max uint32) OpenFGAServiceV1Option {
 	}
 }
 
+// WithCheckFairReadSharing makes the limit set by [WithMaxConcurrentReadsForCheck] a limit of
+// the concurrent reads of all the Check requests of the server, instead of each request, shared
+// fairly among the stores: a store may use the reads that other stores leave unused, but a burst
+// of Check requests on one store does not hold back the reads of the other stores behind it.
+// See [WithCheckStoreReadWeights] to give some stores a larger share.
+func WithCheckFairReadSharing(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkFairReadSharing = enabled
+	}
+}
+
+// WithCheckStoreReadWeights sets the weights of stores in the sharing of the concurrent reads of
+// Check, if enabled by [WithCheckFairReadSharing]. Each store that is reading gets a share of the
+// reads proportional to its weight. Stores without a weight have a weight of 1.
+func WithCheckStoreReadWeights(weights map[string]uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkStoreReadWeights = weights
+	}
+}
+
+// WithPerStoreReadWaitMetrics sets the store IDs whose Check reads waiting for their share of
+// the concurrent reads are reported by the datastore_store_read_wait_ms histogram with their own
+// store_id label, to keep the cardinality of the metric bounded. The others are reported under
+// 'other'.
+func WithPerStoreReadWaitMetrics(allowlist []string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.perStoreReadWaitMetricsAllowlist = allowlist
+	}
+}
+
+// WithSnapshotReadsForCheck evaluates each Check request against one consistent snapshot of the
+// tuples, so that a tuple written while it is evaluated is either read by all of its reads or by
+// none, e.g. a Check does not see a tuple deleted on one path and not yet written on another.
+// It needs a datastore that implements storage.SnapshotBeginner, which the built-in ones do.
+//
+// It is disabled by default, as the SQL datastores hold read-only transactions, and so
+// connections, for each Check request: PostgreSQL shares the snapshot between up to
+// WithMaxConcurrentReadsForCheck transactions, while MySQL and SQLite run the reads of a request
+// one at a time. The snapshot reads bypass
+// the Check iterator cache. Point-in-time checks read their own reconstruction of the tuples.
+func WithSnapshotReadsForCheck(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.snapshotReadsForCheck = enabled
+	}
+}
+
+// WithMaxChecksPerBatchCheck sets the maximum number of checks of a BatchCheck request. Larger
+// batches are rejected with InvalidArgument.
+func WithMaxChecksPerBatchCheck(maxChecks uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxChecksPerBatchCheck = maxChecks
+	}
+}
+
 // WithMaxConcurrentReadsForListUsers sets a limit on the number of datastore reads that can be in flight for a given ListUsers call.
 // This number should be set depending on the RPS expected for all query APIs, the number of OpenFGA replicas running,
 // and the number of connections the datastore allows.
@@ -342,12 +901,88 @@ func WithMaxConcurrentReadsForListUsers(max uint32) OpenFGAServiceV1Option {
 	}
 }
 
+// WithMaxConcurrentReadsForExpand sets a limit on the number of datastore reads that can be in flight for all the Expand calls
+// of the server together. Unlike the limits of Check, ListObjects and ListUsers, which apply to each call, it is shared by
+// the calls, so that a burst of Expand calls on big usersets cannot take all the datastore connections needed by other APIs.
+// Unbounded by default.
+func WithMaxConcurrentReadsForExpand(max uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxConcurrentReadsForExpand = max
+	}
+}
+
+// WithMaxConcurrentReadsForRead sets a limit on the number of datastore reads that can be in flight for all the Read calls
+// of the server together. Like WithMaxConcurrentReadsForExpand, it is shared by the calls. Unbounded by default.
+func WithMaxConcurrentReadsForRead(max uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxConcurrentReadsForRead = max
+	}
+}
+
+// WithMaxReadResponseSizeInBytes sets the approximate maximum size of the responses of Read and
+// ReadChanges, to keep them under the message size limits of the transport. A page that would
+// exceed it ends early, with a continuation token at the first tuple or change left out. 0
+// disables the limit. Defaults to serverconfig.DefaultMaxReadResponseSizeInBytes.
+func WithMaxReadResponseSizeInBytes(size int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxReadResponseSizeInBytes = size
+	}
+}
+
+// WithDatastoreHedgingEnabled enables hedged datastore reads for Check, ListObjects and ListUsers: a
+// read that has not returned within the hedging delay is issued a second time, and the first result
+// to come back is used. This trims the tail latency caused by slow datastore replicas, at the cost of
+// extra load on the datastore. The hedged reads take their share of the concurrent reads of the
+// request. Reads with HIGHER_CONSISTENCY are never hedged. Disabled by default.
+// See also WithDatastoreHedgingMinDelay, WithDatastoreHedgingPercentile and
+// WithDatastoreHedgingMaxPerRequest.
+func WithDatastoreHedgingEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.datastoreHedgingEnabled = enabled
+	}
+}
+
+// WithDatastoreHedgingMinDelay sets the minimum hedging delay, which applies until enough reads were
+// observed to compute the percentile. Needs WithDatastoreHedgingEnabled set to true.
+func WithDatastoreHedgingMinDelay(delay time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.datastoreHedgingMinDelay = delay
+	}
+}
+
+// WithDatastoreHedgingPercentile sets the percentile of the latency of the latest datastore reads
+// used as the hedging delay, e.g. 95. Needs WithDatastoreHedgingEnabled set to true.
+func WithDatastoreHedgingPercentile(percentile float64) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.datastoreHedgingPercentile = percentile
+	}
+}
+
+// WithDatastoreHedgingMaxPerRequest sets the maximum number of hedged reads per request, bounding
+// the extra load of one request on the datastore. Needs WithDatastoreHedgingEnabled set to true.
+func WithDatastoreHedgingMaxPerRequest(maxPerRequest uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxDatastoreHedgesPerRequest = maxPerRequest
+	}
+}
+
 func WithExperimentals(experimentals ...ExperimentalFeatureFlag) OpenFGAServiceV1Option {
 	return func(s *Server) {
 		s.experimentals = experimentals
 	}
 }
 
+// WithExperimentalFeatures registers the lifecycle metadata of experimental flags in the server,
+// besides the flags it knows (see [ExperimentalFeatures]), e.g. to deprecate a flag of an
+// application that embeds the server. A feature replaces the one of the same flag.
+func WithExperimentalFeatures(features ...ExperimentalFeature) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		for _, feature := range features {
+			s.experimentalFeatures[feature.Flag] = feature
+		}
+	}
+}
+
 // WithCheckQueryCacheEnabled enables caching of Check results for the Check and List Objects APIs.
 // This cache is shared for all requests.
 // See also WithCheckQueryCacheLimit and WithCheckQueryCacheTTL.
@@ -372,6 +1007,36 @@ func WithCheckQueryCacheTTL(ttl time.Duration) OpenFGAServiceV1Option {
 	}
 }
 
+// WithCheckQueryCacheResponseHeader makes Check return whether its result was served from the
+// check query cache, and how old the cached result was, in the CheckCacheHeader, e.g.
+// "hit; age=1.2s" or "miss". Needs WithCheckQueryCacheEnabled set to true.
+func WithCheckQueryCacheResponseHeader(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkQueryCacheResponseHeader = enabled
+	}
+}
+
+// WithCheckResponseMetadataHeaders makes Check and ListObjects return the number of dispatches and
+// datastore queries of the request in the DispatchCountHeader and DatastoreQueryCountHeader.
+// StreamedListObjects returns the counts in trailers, since its headers are sent before the
+// first object. Whether the result of a Check was served from the check query cache is
+// returned in the CheckCacheHeader, see [WithCheckQueryCacheResponseHeader].
+func WithCheckResponseMetadataHeaders(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkResponseMetadataHeaders = enabled
+	}
+}
+
+// WithCheckDeduplicationEnabled makes identical concurrent Check requests share a single
+// evaluation: a request with the same store, model, tuple key, contextual tuples, context and
+// consistency preference as a request being evaluated waits for its result instead of being
+// evaluated. The shared evaluation is only canceled once every request waiting for it is.
+func WithCheckDeduplicationEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkDeduplicationEnabled = enabled
+	}
+}
+
 // WithCheckIteratorCacheEnabled enables caching of iterators produced within Check for subsequent requests.
 func WithCheckIteratorCacheEnabled(enabled bool) OpenFGAServiceV1Option {
 	return func(s *Server) {
@@ -403,6 +1068,8 @@ func WithRequestDurationByDispatchCountHistogramBuckets(buckets []uint) OpenFGAS
 	}
 }
 
+// WithMaxAuthorizationModelSizeInBytes rejects the models whose size in the
+// WriteAuthorizationModel request, or as stored by the datastore, is above the given size.
 func WithMaxAuthorizationModelSizeInBytes(size int) OpenFGAServiceV1Option {
 	return func(s *Server) {
 		s.maxAuthorizationModelSizeInBytes = size
@@ -447,121 +1114,499 @@ func WithDispatchThrottlingCheckResolverMaxThreshold(maxThreshold uint32) OpenFG
 	}
 }
 
-// MustNewServerWithOpts see NewServerWithOpts.
-func MustNewServerWithOpts(opts ...OpenFGAServiceV1Option) *Server {
-	s, err := NewServerWithOpts(opts...)
-	if err != nil {
-		panic(fmt.Errorf("failed to construct the OpenFGA server: %w", err))
+// WithRequestPriorityEnabled sets whether the priority of a request (see [dispatch.RequestPriority]) is honored.
+// When enabled, low priority requests are throttled with a lower dispatch threshold, and their datastore reads
+// wait for the reads of normal priority requests.
+func WithRequestPriorityEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.requestPriorityEnabled = enabled
 	}
+}
 
-	return s
+// WithRequestPriorityMaxDeprioritization sets the percentage (0-100) by which the dispatch throttling
+// thresholds are lowered for low priority requests.
+// Needs WithRequestPriorityEnabled set to true.
+func WithRequestPriorityMaxDeprioritization(percentage uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.requestPriorityMaxDeprioritization = percentage
+	}
 }
 
-func (s *Server) IsExperimentallyEnabled(flag ExperimentalFeatureFlag) bool {
-	return slices.Contains(s.experimentals, flag)
+// WithShadowModelEvaluation sets the fraction (0-1) of Check requests that pinned an authorization
+// model other than the latest, which are evaluated again against the latest model of the store.
+// Shadow evaluations run asynchronously in a bounded worker pool and never affect the response.
+// Agreements and divergences are counted, and divergences are logged with the tuple key.
+// A sample rate of 0 (the default) disables shadow evaluation.
+func WithShadowModelEvaluation(sampleRate float64) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.shadowModelEvaluationSampleRate = sampleRate
+	}
 }
 
-// WithListObjectsDispatchThrottlingEnabled sets whether dispatch throttling is enabled for List Objects requests.
-// Enabling this feature will prioritize dispatched requests requiring less than the configured dispatch
-// threshold over requests whose dispatch count exceeds the configured threshold.
-func WithListObjectsDispatchThrottlingEnabled(enabled bool) OpenFGAServiceV1Option {
+// WithPerStoreWriteMetrics sets the store IDs whose tuple write and delete counts are reported
+// with their own store_id label. To keep the cardinality of the metrics bounded, writes to all
+// other stores are reported under the "other" label.
+func WithPerStoreWriteMetrics(allowlist []string) OpenFGAServiceV1Option {
 	return func(s *Server) {
-		s.listObjectsDispatchThrottlingEnabled = enabled
+		s.perStoreWriteMetricsAllowlist = make(map[string]struct{}, len(allowlist))
+		for _, storeID := range allowlist {
+			s.perStoreWriteMetricsAllowlist[storeID] = struct{}{}
+		}
 	}
 }
 
-// WithListObjectsDispatchThrottlingFrequency defines how frequent dispatch throttling
-// will be evaluated for List Objects requests.
-// Frequency controls how frequently throttled dispatch requests are evaluated to determine whether
-// it can be processed.
-// This value should not be too small (i.e., in the ns ranges) as i) there are limitation in timer resolution
-// and ii) very small value will result in a higher frequency of processing dispatches,
-// which diminishes the value of the throttling.
-func WithListObjectsDispatchThrottlingFrequency(frequency time.Duration) OpenFGAServiceV1Option {
+// WithWriteReferentialCheck enables rejecting Write requests that write a tuple of a tupleset
+// relation (e.g. `document:readme#parent@folder:x`) when the referenced object (folder:x) is not
+// the object of any stored tuple that the request does not delete nor of a tuple written in the
+// same request. It costs one datastore read per distinct referenced object, so it is disabled by
+// default.
+// Rejections are counted in the write_referential_check_rejections_count metric.
+func WithWriteReferentialCheck(enabled bool) OpenFGAServiceV1Option {
 	return func(s *Server) {
-		s.listObjectsDispatchThrottlingFrequency = frequency
+		s.writeReferentialCheckEnabled = enabled
 	}
 }
 
-// WithListObjectsDispatchThrottlingThreshold define the number of dispatches to be throttled
-// for List Objects requests.
-func WithListObjectsDispatchThrottlingThreshold(threshold uint32) OpenFGAServiceV1Option {
+// WithRejectWritesOnStaleModel makes Write reject, with a FailedPrecondition error carrying both
+// model IDs, the requests evaluated with an authorization model other than the latest of the
+// store, e.g. pinned by an old deploy, unless they set the WriteAllowStaleModelHeader to "true".
+// Requests without an authorization model ID or alias are evaluated with the latest model.
+func WithRejectWritesOnStaleModel(reject bool) OpenFGAServiceV1Option {
 	return func(s *Server) {
-		s.listObjectsDispatchDefaultThreshold = threshold
+		s.rejectWritesOnStaleModel = reject
 	}
 }
 
-// WithListObjectsDispatchThrottlingMaxThreshold define the maximum threshold values allowed
-// It will ensure listObjectsDispatchThrottlingMaxThreshold will never be smaller than threshold.
-func WithListObjectsDispatchThrottlingMaxThreshold(maxThreshold uint32) OpenFGAServiceV1Option {
+// WithRejectWritesOnStaleModelOverrides overrides the setting of [WithRejectWritesOnStaleModel]
+// for the given store IDs.
+func WithRejectWritesOnStaleModelOverrides(overrides map[string]bool) OpenFGAServiceV1Option {
 	return func(s *Server) {
-		s.listObjectsDispatchThrottlingMaxThreshold = maxThreshold
+		s.rejectWritesOnStaleModelOverrides = overrides
 	}
 }
 
-// WithListUsersDispatchThrottlingEnabled sets whether dispatch throttling is enabled for ListUsers requests.
-// Enabling this feature will prioritize dispatched requests requiring less than the configured dispatch
-// threshold over requests whose dispatch count exceeds the configured threshold.
-func WithListUsersDispatchThrottlingEnabled(enabled bool) OpenFGAServiceV1Option {
+// WithListStoresExactTotalCount makes ListStores return the exact number of stores when a request
+// asks for it with the ListStoresIncludeTotalCountHeader. Otherwise the MySQL and Postgres
+// datastores return an estimate read from the statistics of the store table, which is cheaper
+// with many stores but lags behind the stores created and deleted recently. The datastore must
+// implement [storage.StoreCounter], which the built-in datastores do.
+func WithListStoresExactTotalCount(exact bool) OpenFGAServiceV1Option {
 	return func(s *Server) {
-		s.listUsersDispatchThrottlingEnabled = enabled
+		s.listStoresExactTotalCount = exact
 	}
 }
 
-// WithListUsersDispatchThrottlingFrequency defines how frequent dispatch throttling
-// will be evaluated for ListUsers requests.
-// Frequency controls how frequently throttled dispatch requests are evaluated to determine whether
-// it can be processed.
-// This value should not be too small (i.e., in the ns ranges) as i) there are limitation in timer resolution
-// and ii) very small value will result in a higher frequency of processing dispatches,
-// which diminishes the value of the throttling.
-func WithListUsersDispatchThrottlingFrequency(frequency time.Duration) OpenFGAServiceV1Option {
+// WithUniqueStoreNames makes CreateStore fail with an AlreadyExists error, with the ID of the
+// conflicting store, when a store that was not deleted already has the name of the new store. The
+// stores created before it was enabled may share names. It is disabled by default. The datastore
+// must implement [storage.UniqueStoreNamesBackend], which the built-in datastores do.
+func WithUniqueStoreNames(enabled bool) OpenFGAServiceV1Option {
 	return func(s *Server) {
-		s.listUsersDispatchThrottlingFrequency = frequency
+		s.uniqueStoreNamesEnabled = enabled
 	}
 }
 
-// WithListUsersDispatchThrottlingThreshold define the number of dispatches to be throttled
-// for ListUsers requests.
-func WithListUsersDispatchThrottlingThreshold(threshold uint32) OpenFGAServiceV1Option {
+// WithReadChangesHorizonHeaders makes ReadChanges return the changelog horizon, the time
+// before which changes are returned (see [WithChangelogHorizonOffset]), in the
+// ReadChangesHorizonHeader, and the time of the newest change withheld by the horizon, if any, in
+// the ReadChangesNewestWithheldChangeHeader. Consumers can then tell "no changes" from "changes
+// pending behind the horizon". With a horizon offset, it costs one more read of the changelog.
+func WithReadChangesHorizonHeaders(enabled bool) OpenFGAServiceV1Option {
 	return func(s *Server) {
-		s.listUsersDispatchDefaultThreshold = threshold
+		s.readChangesHorizonHeaders = enabled
 	}
 }
 
-// WithListUsersDispatchThrottlingMaxThreshold define the maximum threshold values allowed
-// It will ensure listUsersDispatchThrottlingMaxThreshold will never be smaller than threshold.
-func WithListUsersDispatchThrottlingMaxThreshold(maxThreshold uint32) OpenFGAServiceV1Option {
+// WithTupleQuota limits the number of tuples of every store, in total and per object type. Write
+// rejects, with a ResourceExhausted error carrying the quota and the current usage, the requests
+// that would bring a store or one of its object types over its quota. The quota is enforced with
+// the tuple counters maintained by the datastore, which must implement [storage.TupleCounter];
+// the counters of the tuples written before an upgrade are backfilled with the
+// backfill-tuple-counts command. By default the number of tuples is not limited.
+func WithTupleQuota(quota commands.TupleQuota) OpenFGAServiceV1Option {
 	return func(s *Server) {
-		s.listUsersDispatchThrottlingMaxThreshold = maxThreshold
+		s.tupleQuota = quota
 	}
 }
 
-// NewServerWithOpts returns a new server.
-// You must call Close on it after you are done using it.
-func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
+// WithTupleQuotaOverrides overrides the quota of [WithTupleQuota] for the given store IDs.
+func WithTupleQuotaOverrides(overrides map[string]commands.TupleQuota) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.tupleQuotaOverrides = overrides
+	}
+}
+
+// WithStrictConditionParameters makes WriteAuthorizationModel reject models with conditions
+// that declare parameters their expression does not use. By default such models are written
+// and the unused parameters are reported in the Openfga-Authorization-Model-Warnings header.
+func WithStrictConditionParameters(strict bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.strictConditionParameters = strict
+	}
+}
+
+// WithMaxAuthorizationModelComplexity makes WriteAuthorizationModel reject models whose
+// complexity score (see [typesystem.TypeSystem.Complexity]) is above the given score, with an
+// error listing the relations contributing the most to it. 0, the default, accepts every model.
+func WithMaxAuthorizationModelComplexity(score int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxAuthorizationModelComplexity = score
+	}
+}
+
+// WithMaxAuthorizationModelTypes makes WriteAuthorizationModel reject models with more than the
+// given number of types, with an error stating the limit and the number of types. It applies in
+// addition to the limit of the datastore, see [storage.OpenFGADatastore].MaxTypesPerAuthorizationModel.
+// 0, the default, accepts every model.
+func WithMaxAuthorizationModelTypes(limit int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxAuthorizationModelTypes = limit
+	}
+}
+
+// WithMaxAuthorizationModelRelationsPerType makes WriteAuthorizationModel reject models with a
+// type of more than the given number of relations, with an error naming the type. 0, the
+// default, accepts every model.
+func WithMaxAuthorizationModelRelationsPerType(limit int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxAuthorizationModelRelationsPerType = limit
+	}
+}
+
+// WithMaxAuthorizationModelRewriteDepth makes WriteAuthorizationModel reject models with a
+// relation whose rewrite is nested deeper than the given depth (see [typesystem.RewriteDepth]),
+// with an error naming the relation. 0, the default, accepts every model.
+func WithMaxAuthorizationModelRewriteDepth(limit int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxAuthorizationModelRewriteDepth = limit
+	}
+}
+
+// WithAuthorizationModelValidationTimeout sets the maximum time that WriteAuthorizationModel
+// spends validating a model, in addition to the limit of rewrite nodes that the validation visits
+// (see [WithAuthorizationModelValidationMaxVisits]). Models that exceed either are rejected as too
+// complex to validate, before they are written. 0 disables the time limit. Defaults to
+// serverconfig.DefaultAuthorizationModelValidationTimeout.
+func WithAuthorizationModelValidationTimeout(timeout time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.authorizationModelValidationTimeout = timeout
+	}
+}
+
+// WithAuthorizationModelValidationMaxVisits sets the maximum number of rewrite nodes that the
+// validation of a model by WriteAuthorizationModel visits (see
+// [typesystem.WithValidationMaxVisits]) before rejecting it as too complex to validate. The
+// models already written are loaded without the limit. 0 disables the limit. Defaults to
+// serverconfig.DefaultAuthorizationModelValidationMaxVisits.
+func WithAuthorizationModelValidationMaxVisits(maxVisits int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.authorizationModelValidationMaxVisits = maxVisits
+	}
+}
+
+// WithModelImpactAnalysisMaxProbes sets the maximum number of tuple reads of the impact analysis
+// of a WriteAuthorizationModel request with the WriteModelImpactAnalysisHeader, i.e. the number
+// of changed relations whose tuples are probed. The relations beyond it are reported as not
+// checked.
+func WithModelImpactAnalysisMaxProbes(maxProbes int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.modelImpactAnalysisMaxProbes = maxProbes
+	}
+}
+
+// WithPerStoreModelComplexityMetrics sets the store IDs whose authorization model complexity
+// score is reported by the authorization_model_complexity_score gauge. The scores of the models
+// of other stores are only logged, to keep the cardinality of the metric bounded.
+func WithPerStoreModelComplexityMetrics(allowlist []string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.perStoreModelComplexityMetricsAllowlist = make(map[string]struct{}, len(allowlist))
+		for _, storeID := range allowlist {
+			s.perStoreModelComplexityMetricsAllowlist[storeID] = struct{}{}
+		}
+	}
+}
+
+// WithChangelogMetricsInterval sets how often the changelog_rows and
+// changelog_newest_change_age_seconds gauges are computed from the datastore. 0, the default,
+// disables the gauges, as do datastores that do not implement [storage.ChangelogStatsReader].
+func WithChangelogMetricsInterval(interval time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.changelogMetricsInterval = interval
+	}
+}
+
+// WithPerStoreChangelogMetrics sets the store IDs whose newest change age is reported by the
+// changelog_newest_change_age_seconds gauge, to keep the cardinality of the metric bounded.
+func WithPerStoreChangelogMetrics(allowlist []string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.perStoreChangelogMetricsAllowlist = make(map[string]struct{}, len(allowlist))
+		for _, storeID := range allowlist {
+			s.perStoreChangelogMetricsAllowlist[storeID] = struct{}{}
+		}
+	}
+}
+
+// WithReadOnlyMode makes the server serve only the read APIs, e.g. when its datastore is a
+// read replica of the database. Write, WriteAuthorizationModel, WriteAssertions, CreateStore
+// and DeleteStore fail with a FailedPrecondition error without reaching the datastore.
+func WithReadOnlyMode(readOnly bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.readOnly = readOnly
+	}
+}
+
+// WithSessionAffinityEnabled gives clients read-your-writes consistency: after a client writes
+// tuples to a store, its Check, Read and ListObjects requests on the store, and their datastore
+// reads, are upgraded to HIGHER_CONSISTENCY for the session affinity window, bypassing the caches
+// of the server and letting the datastore read from its primary database. Clients are identified
+// by their authenticated subject, or else by their connection.
+// See also WithSessionAffinityWindow and WithSessionAffinityMaxSessions.
+func WithSessionAffinityEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.sessionAffinityEnabled = enabled
+	}
+}
+
+// WithSessionAffinityWindow sets how long after a write the requests of the client are upgraded
+// to HIGHER_CONSISTENCY. It should exceed the replication delay of the datastore.
+// Needs WithSessionAffinityEnabled set to true.
+func WithSessionAffinityWindow(window time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.sessionAffinityWindow = window
+	}
+}
+
+// WithSessionAffinityMaxSessions sets the maximum number of pairs of client and store whose
+// writes are remembered. Needs WithSessionAffinityEnabled set to true.
+func WithSessionAffinityMaxSessions(maxSessions uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.sessionAffinityMaxSessions = maxSessions
+	}
+}
+
+// WithMembershipIndex makes Check consult the given index for the members of its relations
+// before resolving them, see [storage.MembershipIndex]. The caller owns the index and must close
+// it after the server. It takes precedence over WithMembershipIndexRelations.
+func WithMembershipIndex(index storage.MembershipIndex) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.membershipIndex = index
+	}
+}
+
+// WithMembershipIndexRelations makes Check consult a [membershipindex.ChangelogIndex] of the given
+// relations, as `objectType#relation`, e.g. `group#member`, for their members before resolving
+// them. The index flattens the nested usersets of the relations, and is updated from the
+// changelog of the datastore, so it is eventually consistent: Check does not consult it for
+// HIGHER_CONSISTENCY requests, and only for the relations that the model defines as directly
+// related user types without conditions. It is disabled by default.
+// See also WithMembershipIndexPollInterval, WithMembershipIndexMaxStaleness,
+// WithMembershipIndexMaxStores and WithMembershipIndexIdleTimeout.
+func WithMembershipIndexRelations(relations []string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.membershipIndexRelations = relations
+	}
+}
+
+// WithMembershipIndexPollInterval sets how often the membership index reads the new changes of
+// the indexed stores. Needs WithMembershipIndexRelations.
+func WithMembershipIndexPollInterval(interval time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.membershipIndexPollInterval = interval
+	}
+}
+
+// WithMembershipIndexMaxStaleness sets how long after it last caught up with the changelog of a
+// store the membership index still answers the Check sub-problems of the store. It must exceed
+// the changelog horizon offset. Needs WithMembershipIndexRelations.
+func WithMembershipIndexMaxStaleness(maxStaleness time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.membershipIndexMaxStaleness = maxStaleness
+	}
+}
+
+// WithMembershipIndexMaxStores sets how many stores the membership index keeps. The least
+// recently looked up store is dropped to index another one. Needs WithMembershipIndexRelations.
+func WithMembershipIndexMaxStores(maxStores int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.membershipIndexMaxStores = maxStores
+	}
+}
+
+// WithMembershipIndexIdleTimeout sets how long after its last lookup for a store the membership
+// index drops the store. Needs WithMembershipIndexRelations.
+func WithMembershipIndexIdleTimeout(timeout time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.membershipIndexIdleTimeout = timeout
+	}
+}
+
+// warnAboutListLimits warns about the deadlines and max results of ListObjects and ListUsers that
+// are valid but likely misconfigured: requests without any bound, and deadlines that expire
+// before a throttled dispatch is released.
+func (s *Server) warnAboutListLimits() {
+	for _, limits := range []struct {
+		api                 string
+		deadline            time.Duration
+		maxResults          uint32
+		throttlingEnabled   bool
+		throttlingFrequency time.Duration
+	}{
+		{"ListObjects", s.listObjectsDeadline, s.listObjectsMaxResults, s.listObjectsDispatchThrottlingEnabled, s.listObjectsDispatchThrottlingFrequency},
+		{"ListUsers", s.listUsersDeadline, s.listUsersMaxResults, s.listUsersDispatchThrottlingEnabled, s.listUsersDispatchThrottlingFrequency},
+	} {
+		if limits.deadline == 0 && limits.maxResults == 0 {
+			s.logger.Warn(fmt.Sprintf("%s has neither a deadline nor max results, so its requests are only bounded by the request timeout", limits.api))
+		}
+		if limits.throttlingEnabled && limits.deadline > 0 && limits.deadline < limits.throttlingFrequency {
+			s.logger.Warn(fmt.Sprintf("%s deadline is shorter than its dispatch throttling frequency, so throttled requests return partial results", limits.api),
+				zap.Duration("deadline", limits.deadline),
+				zap.Duration("throttling_frequency", limits.throttlingFrequency))
+		}
+	}
+}
+
+// MustNewServerWithOpts see NewServerWithOpts.
+func MustNewServerWithOpts(opts ...OpenFGAServiceV1Option) *Server {
+	s, err := NewServerWithOpts(opts...)
+	if err != nil {
+		panic(fmt.Errorf("failed to construct the OpenFGA server: %w", err))
+	}
+
+	return s
+}
+
+func (s *Server) IsExperimentallyEnabled(flag ExperimentalFeatureFlag) bool {
+	return slices.Contains(s.experimentals, flag)
+}
+
+// WithListObjectsDispatchThrottlingEnabled sets whether dispatch throttling is enabled for List Objects requests.
+// Enabling this feature will prioritize dispatched requests requiring less than the configured dispatch
+// threshold over requests whose dispatch count exceeds the configured threshold.
+func WithListObjectsDispatchThrottlingEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listObjectsDispatchThrottlingEnabled = enabled
+	}
+}
+
+// WithListObjectsDispatchThrottlingFrequency defines how frequent dispatch throttling
+// will be evaluated for List Objects requests.
+// Frequency controls how frequently throttled dispatch requests are evaluated to determine whether
+// it can be processed.
+// This value should not be too small (i.e., in the ns ranges) as i) there are limitation in timer resolution
+// and ii) very small value will result in a higher frequency of processing dispatches,
+// which diminishes the value of the throttling.
+func WithListObjectsDispatchThrottlingFrequency(frequency time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listObjectsDispatchThrottlingFrequency = frequency
+	}
+}
+
+// WithListObjectsDispatchThrottlingThreshold define the number of dispatches to be throttled
+// for List Objects requests.
+func WithListObjectsDispatchThrottlingThreshold(threshold uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listObjectsDispatchDefaultThreshold = threshold
+	}
+}
+
+// WithListObjectsDispatchThrottlingMaxThreshold define the maximum threshold values allowed
+// It will ensure listObjectsDispatchThrottlingMaxThreshold will never be smaller than threshold.
+func WithListObjectsDispatchThrottlingMaxThreshold(maxThreshold uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listObjectsDispatchThrottlingMaxThreshold = maxThreshold
+	}
+}
+
+// WithListUsersDispatchThrottlingEnabled sets whether dispatch throttling is enabled for ListUsers requests.
+// Enabling this feature will prioritize dispatched requests requiring less than the configured dispatch
+// threshold over requests whose dispatch count exceeds the configured threshold.
+func WithListUsersDispatchThrottlingEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listUsersDispatchThrottlingEnabled = enabled
+	}
+}
+
+// WithListUsersDispatchThrottlingFrequency defines how frequent dispatch throttling
+// will be evaluated for ListUsers requests.
+// Frequency controls how frequently throttled dispatch requests are evaluated to determine whether
+// it can be processed.
+// This value should not be too small (i.e., in the ns ranges) as i) there are limitation in timer resolution
+// and ii) very small value will result in a higher frequency of processing dispatches,
+// which diminishes the value of the throttling.
+func WithListUsersDispatchThrottlingFrequency(frequency time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listUsersDispatchThrottlingFrequency = frequency
+	}
+}
+
+// WithListUsersDispatchThrottlingThreshold define the number of dispatches to be throttled
+// for ListUsers requests.
+func WithListUsersDispatchThrottlingThreshold(threshold uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listUsersDispatchDefaultThreshold = threshold
+	}
+}
+
+// WithListUsersDispatchThrottlingMaxThreshold define the maximum threshold values allowed
+// It will ensure listUsersDispatchThrottlingMaxThreshold will never be smaller than threshold.
+func WithListUsersDispatchThrottlingMaxThreshold(maxThreshold uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listUsersDispatchThrottlingMaxThreshold = maxThreshold
+	}
+}
+
+// NewServerWithOpts returns a new server.
+// You must call Close on it after you are done using it.
+func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
 	s := &Server{
+		createdAt:                        time.Now(),
+		experimentalFeatures:             maps.Clone(defaultExperimentalFeatures),
 		logger:                           logger.NewNoopLogger(),
 		encoder:                          encoder.NewBase64Encoder(),
+		idGenerator:                      id.NewULIDGenerator(),
 		transport:                        gateway.NewNoopTransport(),
 		changelogHorizonOffset:           serverconfig.DefaultChangelogHorizonOffset,
 		resolveNodeLimit:                 serverconfig.DefaultResolveNodeLimit,
 		resolveNodeBreadthLimit:          serverconfig.DefaultResolveNodeBreadthLimit,
 		listObjectsDeadline:              serverconfig.DefaultListObjectsDeadline,
 		listObjectsMaxResults:            serverconfig.DefaultListObjectsMaxResults,
+		listObjectsMaxCandidates:         serverconfig.DefaultListObjectsMaxCandidates,
+		listObjectsStrategy:              commands.ListObjectsStrategyAuto,
+		listObjectsStreamMaxModelLag:     serverconfig.DefaultListObjectsStreamMaxModelLag,
+		listObjectsStreamCheckInterval:   serverconfig.DefaultListObjectsStreamModelCheckInterval,
+		maxStreamDuration:                serverconfig.DefaultMaxStreamDuration,
+		expandMaxLeafUsers:               serverconfig.DefaultExpandMaxLeafUsers,
+		expandMaxNodes:                   serverconfig.DefaultExpandMaxNodes,
+		relationStatisticsMaxSampleSize:  serverconfig.DefaultRelationStatisticsMaxSampleSize,
+		relationStatisticsDeadline:       serverconfig.DefaultRelationStatisticsDeadline,
+		relationUsageDeadline:            serverconfig.DefaultRelationUsageDeadline,
+		deleteTuplesByFilterMaxTuples:    serverconfig.DefaultDeleteTuplesByFilterMaxTuples,
+		deleteTuplesByFilterMaxScanned:   serverconfig.DefaultDeleteTuplesByFilterMaxScanned,
+		pointInTimeCheckHorizon:          serverconfig.DefaultPointInTimeCheckHorizon,
+		pointInTimeCheckMaxChanges:       serverconfig.DefaultPointInTimeCheckMaxChanges,
 		listUsersDeadline:                serverconfig.DefaultListUsersDeadline,
 		listUsersMaxResults:              serverconfig.DefaultListUsersMaxResults,
 		maxConcurrentReadsForCheck:       serverconfig.DefaultMaxConcurrentReadsForCheck,
+		snapshotReadsForCheck:            serverconfig.DefaultSnapshotReadsForCheck,
+		maxChecksPerBatchCheck:           serverconfig.DefaultMaxChecksPerBatchCheck,
 		maxConcurrentReadsForListObjects: serverconfig.DefaultMaxConcurrentReadsForListObjects,
 		maxConcurrentReadsForListUsers:   serverconfig.DefaultMaxConcurrentReadsForListUsers,
+		maxConcurrentReadsForExpand:      serverconfig.DefaultMaxConcurrentReadsForExpand,
+		maxConcurrentReadsForRead:        serverconfig.DefaultMaxConcurrentReadsForRead,
+		maxReadResponseSizeInBytes:       serverconfig.DefaultMaxReadResponseSizeInBytes,
 		maxAuthorizationModelSizeInBytes: serverconfig.DefaultMaxAuthorizationModelSizeInBytes,
 		maxAuthorizationModelCacheSize:   serverconfig.DefaultMaxAuthorizationModelCacheSize,
-		experimentals:                    make([]ExperimentalFeatureFlag, 0, 10),
+
+		authorizationModelValidationTimeout:   serverconfig.DefaultAuthorizationModelValidationTimeout,
+		authorizationModelValidationMaxVisits: serverconfig.DefaultAuthorizationModelValidationMaxVisits,
+
+		experimentals: make([]ExperimentalFeatureFlag, 0, 10),
 
 		cacheLimit: serverconfig.DefaultCacheLimit,
 
-		checkQueryCacheEnabled: serverconfig.DefaultCheckQueryCacheEnabled,
-		checkQueryCacheTTL:     serverconfig.DefaultCheckQueryCacheTTL,
+		checkQueryCacheEnabled:        serverconfig.DefaultCheckQueryCacheEnabled,
+		checkQueryCacheTTL:            serverconfig.DefaultCheckQueryCacheTTL,
+		checkQueryCacheResponseHeader: serverconfig.DefaultCheckQueryCacheResponseHeader,
 
 		checkIteratorCacheEnabled:    serverconfig.DefaultCheckIteratorCacheEnabled,
 		checkIteratorCacheMaxResults: serverconfig.DefaultCheckIteratorCacheMaxResults,
@@ -585,6 +1630,31 @@ func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
 		listUsersDispatchThrottlingFrequency:    serverconfig.DefaultListUsersDispatchThrottlingFrequency,
 		listUsersDispatchDefaultThreshold:       serverconfig.DefaultListUsersDispatchThrottlingDefaultThreshold,
 		listUsersDispatchThrottlingMaxThreshold: serverconfig.DefaultListUsersDispatchThrottlingMaxThreshold,
+
+		requestPriorityEnabled:             serverconfig.DefaultRequestPriorityEnabled,
+		requestPriorityMaxDeprioritization: serverconfig.DefaultRequestPriorityMaxDeprioritization,
+
+		sessionAffinityEnabled:     serverconfig.DefaultSessionAffinityEnabled,
+		sessionAffinityWindow:      serverconfig.DefaultSessionAffinityWindow,
+		sessionAffinityMaxSessions: serverconfig.DefaultSessionAffinityMaxSessions,
+
+		datastoreHedgingEnabled:      serverconfig.DefaultDatastoreHedgingEnabled,
+		datastoreHedgingMinDelay:     serverconfig.DefaultDatastoreHedgingMinDelay,
+		datastoreHedgingPercentile:   serverconfig.DefaultDatastoreHedgingPercentile,
+		maxDatastoreHedgesPerRequest: serverconfig.DefaultDatastoreHedgingMaxPerRequest,
+
+		membershipIndexPollInterval: serverconfig.DefaultMembershipIndexPollInterval,
+		membershipIndexMaxStaleness: serverconfig.DefaultMembershipIndexMaxStaleness,
+		membershipIndexMaxStores:    serverconfig.DefaultMembershipIndexMaxStores,
+		membershipIndexIdleTimeout:  serverconfig.DefaultMembershipIndexIdleTimeout,
+
+		changelogMetricsInterval: serverconfig.DefaultChangelogMetricsInterval,
+
+		modelImpactAnalysisMaxProbes: serverconfig.DefaultModelImpactAnalysisMaxProbes,
+
+		readChangesWatchPollInterval:      serverconfig.DefaultReadChangesWatchPollInterval,
+		readChangesWatchHeartbeatInterval: serverconfig.DefaultReadChangesWatchHeartbeatInterval,
+		readChangesWatchMaxStreams:        serverconfig.DefaultReadChangesWatchMaxStreams,
 	}
 
 	for _, opt := range opts {
@@ -595,6 +1665,10 @@ func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
 		return nil, fmt.Errorf("a datastore option must be provided")
 	}
 
+	if s.idGenerator == nil {
+		return nil, fmt.Errorf("the id generator must not be nil")
+	}
+
 	if len(s.requestDurationByQueryHistogramBuckets) == 0 {
 		return nil, fmt.Errorf("request duration datastore count buckets must not be empty")
 	}
@@ -614,25 +1688,126 @@ func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
 		return nil, fmt.Errorf("ListUsers default dispatch throttling threshold must be equal or smaller than max dispatch threshold for ListUsers")
 	}
 
+	if s.maxAuthorizationModelTypes < 0 || s.maxAuthorizationModelRelationsPerType < 0 || s.maxAuthorizationModelRewriteDepth < 0 || s.authorizationModelValidationTimeout < 0 || s.authorizationModelValidationMaxVisits < 0 {
+		return nil, fmt.Errorf("authorization model limits cannot be negative")
+	}
+
+	if s.modelImpactAnalysisMaxProbes <= 0 {
+		return nil, fmt.Errorf("model impact analysis max probes must be greater than 0")
+	}
+
+	if s.maxConcurrentReadsForExpand == 0 {
+		return nil, fmt.Errorf("max concurrent reads for Expand must be greater than 0")
+	}
+
+	if s.maxConcurrentReadsForRead == 0 {
+		return nil, fmt.Errorf("max concurrent reads for Read must be greater than 0")
+	}
+
+	if s.resolverWorkerPoolSize < 0 {
+		return nil, fmt.Errorf("resolver worker pool size must not be negative")
+	}
+
+	if s.maxChecksPerBatchCheck == 0 {
+		return nil, fmt.Errorf("max checks per BatchCheck must be greater than 0")
+	}
+
+	if s.maxReadResponseSizeInBytes < 0 {
+		return nil, fmt.Errorf("max read response size in bytes cannot be negative")
+	}
+
+	if s.requestPriorityMaxDeprioritization > 100 {
+		return nil, fmt.Errorf("request priority max deprioritization must be a percentage between 0 and 100")
+	}
+
+	if s.shadowModelEvaluationSampleRate < 0 || s.shadowModelEvaluationSampleRate > 1 {
+		return nil, fmt.Errorf("shadow model evaluation sample rate must be between 0 and 1")
+	}
+
+	if s.sessionAffinityEnabled && (s.sessionAffinityWindow <= 0 || s.sessionAffinityMaxSessions == 0) {
+		return nil, fmt.Errorf("session affinity window and max sessions must be greater than 0")
+	}
+
+	if s.datastoreHedgingEnabled {
+		if s.datastoreHedgingMinDelay < 0 || s.datastoreHedgingPercentile <= 0 || s.datastoreHedgingPercentile > 100 || s.maxDatastoreHedgesPerRequest == 0 {
+			return nil, fmt.Errorf("datastore hedging min delay must not be negative, percentile must be between 0 and 100, and max per request must be greater than 0")
+		}
+		s.datastoreHedgingPolicy = storagewrappers.NewHedgingPolicy(s.datastoreHedgingMinDelay, s.datastoreHedgingPercentile, s.maxDatastoreHedgesPerRequest)
+	}
+
+	if s.changelogMetricsInterval < 0 {
+		return nil, fmt.Errorf("changelog metrics interval must not be negative, use 0 to disable the changelog metrics")
+	}
+
+	if s.listObjectsDeadline < 0 {
+		return nil, fmt.Errorf("ListObjects deadline must not be negative, use 0 to disable it")
+	}
+
+	if s.listUsersDeadline < 0 {
+		return nil, fmt.Errorf("ListUsers deadline must not be negative, use 0 to disable it")
+	}
+
+	if s.readChangesWatchPollInterval <= 0 {
+		return nil, fmt.Errorf("StreamedReadChanges poll interval must be greater than 0")
+	}
+
+	if s.readChangesWatchHeartbeatInterval < 0 {
+		return nil, fmt.Errorf("StreamedReadChanges heartbeat interval must not be negative, use 0 to disable the heartbeats")
+	}
+
+	if s.snapshotReadsForCheck {
+		var ok bool
+		if s.snapshotBeginner, ok = s.datastore.(storage.SnapshotBeginner); !ok {
+			return nil, fmt.Errorf("snapshot reads for Check require a datastore that implements storage.SnapshotBeginner")
+		}
+	}
+
+	if s.membershipIndex == nil && len(s.membershipIndexRelations) > 0 {
+		// the index reads the changelog of the datastore directly, below the caching wrappers
+		index, err := membershipindex.NewChangelogIndex(s.datastore, s.membershipIndexRelations,
+			membershipindex.WithPollInterval(s.membershipIndexPollInterval),
+			membershipindex.WithMaxStaleness(s.membershipIndexMaxStaleness),
+			membershipindex.WithMaxStores(s.membershipIndexMaxStores),
+			membershipindex.WithIdleTimeout(s.membershipIndexIdleTimeout),
+			membershipindex.WithHorizonOffset(time.Duration(s.changelogHorizonOffset)*time.Minute),
+			membershipindex.WithLogger(s.logger),
+		)
+		if err != nil {
+			return nil, err
+		}
+		s.membershipIndex = index
+		s.ownsMembershipIndex = true
+	}
+
+	for storeID, weight := range s.checkStoreReadWeights {
+		if weight == 0 {
+			return nil, fmt.Errorf("the Check read weight of store '%s' must be greater than 0", storeID)
+		}
+	}
+
+	s.warnAboutListLimits()
+	s.warnAboutExperimentals()
+
 	// below this point, don't throw errors, or we may leak resources in tests
 
 	checkDispatchThrottlingOptions := []graph.DispatchThrottlingCheckResolverOpt{}
 	if s.checkDispatchThrottlingEnabled {
+		s.checkDispatchThrottler = throttler.NewConstantRateThrottler(s.checkDispatchThrottlingFrequency, "check_dispatch_throttle")
 		checkDispatchThrottlingOptions = []graph.DispatchThrottlingCheckResolverOpt{
 			graph.WithDispatchThrottlingCheckResolverConfig(graph.DispatchThrottlingCheckResolverConfig{
-				DefaultThreshold: s.checkDispatchThrottlingDefaultThreshold,
-				MaxThreshold:     s.checkDispatchThrottlingMaxThreshold,
+				DefaultThreshold:            s.checkDispatchThrottlingDefaultThreshold,
+				MaxThreshold:                s.checkDispatchThrottlingMaxThreshold,
+				LowPriorityDeprioritization: s.lowPriorityDeprioritization(),
 			}),
 			// only create the throttler if the feature is enabled, so that we can clean it afterward
-			graph.WithThrottler(throttler.NewConstantRateThrottler(s.checkDispatchThrottlingFrequency,
-				"check_dispatch_throttle")),
+			graph.WithThrottler(s.checkDispatchThrottler),
 		}
 	}
 
 	if s.cacheLimit > 0 && (s.checkQueryCacheEnabled || s.checkIteratorCacheEnabled) {
-		s.cache = storage.NewInMemoryLRUCache([]storage.InMemoryLRUCacheOpt[any]{
+		s.cache = newCountingCache(storage.NewInMemoryLRUCache([]storage.InMemoryLRUCacheOpt[any]{
 			storage.WithMaxCacheSize[any](int64(s.cacheLimit)),
-		}...)
+		}...))
 	}
 
 	var checkCacheOptions []graph.CachedCheckResolverOpt
@@ -644,11 +1819,24 @@ func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
 		)
 	}
 
+	if s.resolverWorkerPoolEnabled {
+		if s.resolverWorkerPoolSize == 0 {
+			s.resolverWorkerPoolSize = runtime.GOMAXPROCS(0) * int(s.resolveNodeBreadthLimit)
+		}
+		s.resolverWorkerPool = concurrency.NewWorkerPool(s.resolverWorkerPoolSize)
+	}
+
 	s.checkResolver, s.checkResolverCloser = graph.NewOrderedCheckResolvers([]graph.CheckResolverOrderedBuilderOpt{
+		graph.WithMembershipIndexCheckResolverOpts(s.membershipIndex != nil, graph.WithMembershipIndex(s.membershipIndex)),
 		graph.WithLocalCheckerOpts([]graph.LocalCheckerOption{
 			graph.WithResolveNodeBreadthLimit(s.resolveNodeBreadthLimit),
+			graph.WithResolverWorkerPool(s.resolverWorkerPool),
+			graph.WithResolveNodeBreadthLimitShadow(s.resolveNodeBreadthLimitShadow),
+			graph.WithResolveNodeLimitShadow(s.resolveNodeLimitShadow),
+			graph.WithLocalCheckerLogger(s.logger),
 		}...),
 		graph.WithCachedCheckResolverOpts(s.checkQueryCacheEnabled, checkCacheOptions...),
+		graph.WithSingleflightCheckResolverEnabled(s.checkDeduplicationEnabled),
 		graph.WithDispatchThrottlingCheckResolverOpts(s.checkDispatchThrottlingEnabled, checkDispatchThrottlingOptions...),
 	}...).Build()
 
@@ -660,20 +1848,145 @@ func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
 		s.listUsersDispatchThrottler = throttler.NewConstantRateThrottler(s.listUsersDispatchThrottlingFrequency, "list_users_dispatch_throttle")
 	}
 
-	s.datastore = storagewrappers.NewCachedOpenFGADatastore(storagewrappers.NewContextWrapper(s.datastore), s.maxAuthorizationModelCacheSize)
+	// the wrappers below hide the optional interfaces of the datastore
+	s.relationCounter, _ = s.datastore.(storage.RelationCounter)
+	s.relationUsageCounter, _ = s.datastore.(storage.RelationUsageCounter)
+	s.indexAdvisor, _ = s.datastore.(storage.IndexAdvisor)
+	s.modelAliases, _ = s.datastore.(storage.ModelAliasBackend)
+	s.tupleCounter, _ = s.datastore.(storage.TupleCounter)
+	s.storeCounter, _ = s.datastore.(storage.StoreCounter)
+	changelogStats, _ := s.datastore.(storage.ChangelogStatsReader)
+	if s.tupleCounter == nil && (!s.tupleQuota.IsZero() || len(s.tupleQuotaOverrides) > 0) {
+		return nil, fmt.Errorf("tuple quotas require a datastore that implements storage.TupleCounter")
+	}
+	if s.uniqueStoreNamesEnabled {
+		var ok bool
+		if s.uniqueStoreNames, ok = s.datastore.(storage.UniqueStoreNamesBackend); !ok {
+			return nil, fmt.Errorf("unique store names require a datastore that implements storage.UniqueStoreNamesBackend")
+		}
+	}
+	s.modelAliasCache = newModelAliasCache()
+
+	if s.readOnly {
+		// the caching wrappers below only invalidate their entries after a successful write
+		s.datastore = storagewrappers.NewReadOnlyDatastore(s.datastore)
+	}
+
+	cachedDatastore := storagewrappers.NewCachedOpenFGADatastore(storagewrappers.NewContextWrapper(s.datastore), s.maxAuthorizationModelCacheSize)
+	s.modelCache = cachedDatastore
+	s.datastore = cachedDatastore
+	if s.sessionAffinityEnabled {
+		// above the context wrapper, which drops the client of the request from the context
+		s.sessionAffinity = storagewrappers.NewSessionAffinityDatastore(s.datastore, s.sessionAffinityWindow, int(s.sessionAffinityMaxSessions))
+		s.datastore = s.sessionAffinity
+	}
 	s.checkDatastore = s.datastore
+	if s.checkFairReadSharing {
+		s.checkReadLimiter = storagewrappers.NewFairShareLimiter(s.maxConcurrentReadsForCheck,
+			storagewrappers.WithStoreWeights(s.checkStoreReadWeights),
+			storagewrappers.WithStoreWaitMetrics(s.perStoreReadWaitMetricsAllowlist),
+		)
+	}
+	s.expandTupleReader = storagewrappers.NewBoundedConcurrencyTupleReader(s.datastore, s.maxConcurrentReadsForExpand)
+	s.readTupleReader = storagewrappers.NewBoundedConcurrencyTupleReader(s.datastore, s.maxConcurrentReadsForRead)
+
+	var err error
+	s.listObjectsQuery, err = commands.NewListObjectsQuery(
+		s.datastore,
+		s.checkResolver,
+		commands.WithLogger(s.logger),
+		commands.WithListObjectsDeadline(s.listObjectsDeadline),
+		commands.WithListObjectsMaxResults(s.listObjectsMaxResults),
+		commands.WithDispatchThrottlerConfig(threshold.Config{
+			Throttler:                   s.listObjectsDispatchThrottler,
+			Enabled:                     s.listObjectsDispatchThrottlingEnabled,
+			Threshold:                   s.listObjectsDispatchDefaultThreshold,
+			MaxThreshold:                s.listObjectsDispatchThrottlingMaxThreshold,
+			LowPriorityDeprioritization: s.lowPriorityDeprioritization(),
+		}),
+		commands.WithResolveNodeLimit(s.resolveNodeLimit),
+		commands.WithResolveNodeBreadthLimit(s.resolveNodeBreadthLimit),
+		commands.WithMaxConcurrentReads(s.maxConcurrentReadsForListObjects),
+		commands.WithDatastoreHedging(s.datastoreHedgingPolicy),
+		commands.WithListObjectsMaxCandidates(s.listObjectsMaxCandidates),
+		commands.WithListObjectsStrategy(s.listObjectsStrategy),
+		commands.WithListObjectsWorkerPool(s.resolverWorkerPool),
+		commands.WithStreamedListObjectsMaxDuration(s.maxStreamDuration),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.readQuery = commands.NewReadQuery(s.datastore,
+		commands.WithReadQueryLogger(s.logger),
+		commands.WithReadQueryEncoder(s.encoder),
+		commands.WithReadQueryTupleReader(s.readTupleReader),
+		commands.WithReadQueryMaxResponseSizeInBytes(s.maxReadResponseSizeInBytes),
+	)
+
+	s.expandQuery = commands.NewExpandQuery(s.datastore,
+		commands.WithExpandQueryLogger(s.logger),
+		commands.WithExpandResultLimit(s.expandMaxLeafUsers, s.expandMaxNodes, s.expandStrictResultLimit),
+		commands.WithExpandQueryTupleReader(s.expandTupleReader),
+	)
 
 	if s.cache != nil && s.checkIteratorCacheEnabled {
 		s.checkDatastore = graph.NewCachedDatastore(s.datastore, s.cache, int(s.checkIteratorCacheMaxResults), s.checkQueryCacheTTL)
 	}
 
-	s.typesystemResolver, s.typesystemResolverStop = typesystem.MemoizedTypesystemResolverFunc(s.datastore)
+	s.memoizedTypesystemResolver = typesystem.NewMemoizedTypesystemResolver(s.datastore)
+	s.typesystemResolver = s.memoizedTypesystemResolver.Resolve
+
+	if s.shadowModelEvaluationSampleRate > 0 {
+		s.shadowModelEvaluator = newShadowModelEvaluator(s, s.shadowModelEvaluationSampleRate)
+	}
+
+	changelogHorizonOffsetGauge.Set((time.Duration(s.changelogHorizonOffset) * time.Minute).Seconds())
+	if s.changelogMetricsInterval > 0 {
+		if changelogStats != nil {
+			s.changelogMetricsReporter = newChangelogMetricsReporter(s, changelogStats, s.changelogMetricsInterval)
+		} else {
+			s.logger.Info("the datastore does not implement storage.ChangelogStatsReader, so the changelog metrics are disabled")
+		}
+	}
+
+	s.reportBuildInfo()
 
 	return s, nil
 }
 
+// reportBuildInfo sets the build info metric to the build and the enabled experimental features
+// of this server, replacing the labels set by any server created before it in the process.
+func (s *Server) reportBuildInfo() {
+	experimentals := make([]string, 0, len(s.experimentals))
+	for _, flag := range s.experimentals {
+		experimentals = append(experimentals, string(flag))
+	}
+	sort.Strings(experimentals)
+
+	buildInfoGauge.Reset()
+	buildInfoGauge.WithLabelValues(build.Version, build.Commit, runtime.Version(), build.AuthzModelSchemaVersion, strings.Join(slices.Compact(experimentals), ",")).Set(1)
+}
+
+// lowPriorityDeprioritization returns the percentage by which dispatch throttling thresholds
+// are lowered for low priority requests, or zero if request priorities are disabled.
+func (s *Server) lowPriorityDeprioritization() uint32 {
+	if !s.requestPriorityEnabled {
+		return 0
+	}
+	return s.requestPriorityMaxDeprioritization
+}
+
 // Close releases the server resources.
 func (s *Server) Close() {
+	if s.shadowModelEvaluator != nil {
+		s.shadowModelEvaluator.Close()
+	}
+
+	if s.changelogMetricsReporter != nil {
+		s.changelogMetricsReporter.Close()
+	}
+
 	if s.listObjectsDispatchThrottler != nil {
 		s.listObjectsDispatchThrottler.Close()
 	}
@@ -683,15 +1996,23 @@ func (s *Server) Close() {
 
 	s.checkResolverCloser()
 
+	if s.ownsMembershipIndex {
+		s.membershipIndex.Close()
+	}
+
+	if s.resolverWorkerPool != nil {
+		s.resolverWorkerPool.Close()
+	}
+
 	if s.cache != nil {
 		s.cache.Stop()
 	}
 	s.datastore.Close()
 
-	s.typesystemResolverStop()
+	s.memoizedTypesystemResolver.Stop()
 }
 
-func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequest) (*openfgav1.ListObjectsResponse, error) {
+func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequest) (_ *openfgav1.ListObjectsResponse, err error) {
 	start := time.Now()
 
 	targetObjectType := req.GetType()
@@ -704,10 +2025,17 @@ func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequ
 		attribute.String("consistency", req.GetConsistency().String()),
 	))
 	defer span.End()
+	defer s.setErrorReason(&err)
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_ListObjects_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_ListObjects_FullMethodName, req, &err)
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return nil, err
+		}
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -719,33 +2047,14 @@ func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequ
 	})
 
 	storeID := req.GetStoreId()
+	consistency := s.sessionConsistency(ctx, storeID, req.GetConsistency())
 
 	typesys, err := s.resolveTypesystem(ctx, storeID, req.GetAuthorizationModelId())
 	if err != nil {
 		return nil, err
 	}
 
-	q, err := commands.NewListObjectsQuery(
-		s.datastore,
-		s.checkResolver,
-		commands.WithLogger(s.logger),
-		commands.WithListObjectsDeadline(s.listObjectsDeadline),
-		commands.WithListObjectsMaxResults(s.listObjectsMaxResults),
-		commands.WithDispatchThrottlerConfig(threshold.Config{
-			Throttler:    s.listObjectsDispatchThrottler,
-			Enabled:      s.listObjectsDispatchThrottlingEnabled,
-			Threshold:    s.listObjectsDispatchDefaultThreshold,
-			MaxThreshold: s.listObjectsDispatchThrottlingMaxThreshold,
-		}),
-		commands.WithResolveNodeLimit(s.resolveNodeLimit),
-		commands.WithResolveNodeBreadthLimit(s.resolveNodeBreadthLimit),
-		commands.WithMaxConcurrentReads(s.maxConcurrentReadsForListObjects),
-	)
-	if err != nil {
-		return nil, serverErrors.NewInternalError("", err)
-	}
-
-	result, err := q.Execute(
+	result, err := s.listObjectsQuery.Execute(
 		typesystem.ContextWithTypesystem(ctx, typesys),
 		&openfgav1.ListObjectsRequest{
 			StoreId:              storeID,
@@ -755,7 +2064,7 @@ func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequ
 			Relation:             req.GetRelation(),
 			User:                 req.GetUser(),
 			Context:              req.GetContext(),
-			Consistency:          req.GetConsistency(),
+			Consistency:          consistency,
 		},
 	)
 	if err != nil {
@@ -766,35 +2075,13 @@ func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequ
 
 		return nil, err
 	}
-	datastoreQueryCount := float64(*result.ResolutionMetadata.DatastoreQueryCount)
-
-	grpc_ctxtags.Extract(ctx).Set(datastoreQueryCountHistogramName, datastoreQueryCount)
-	span.SetAttributes(attribute.Float64(datastoreQueryCountHistogramName, datastoreQueryCount))
-	datastoreQueryCountHistogram.WithLabelValues(
-		s.serviceName,
-		methodName,
-	).Observe(datastoreQueryCount)
-
-	dispatchCount := float64(result.ResolutionMetadata.DispatchCounter.Load())
-
-	grpc_ctxtags.Extract(ctx).Set(dispatchCountHistogramName, dispatchCount)
-	span.SetAttributes(attribute.Float64(dispatchCountHistogramName, dispatchCount))
-	dispatchCountHistogram.WithLabelValues(
-		s.serviceName,
-		methodName,
-	).Observe(dispatchCount)
-
-	requestDurationHistogram.WithLabelValues(
-		s.serviceName,
-		methodName,
-		utils.Bucketize(uint(*result.ResolutionMetadata.DatastoreQueryCount), s.requestDurationByQueryHistogramBuckets),
-		utils.Bucketize(uint(result.ResolutionMetadata.DispatchCounter.Load()), s.requestDurationByDispatchCountHistogramBuckets),
-		req.GetConsistency().String(),
-	).Observe(float64(time.Since(start).Milliseconds()))
+	s.observeResolutionMetadata(ctx, methodName, consistency, start, result.ResolutionMetadata)
+	if s.checkResponseMetadataHeaders {
+		s.setResolutionMetadataHeaders(ctx, result.ResolutionMetadata)
+	}
 
-	wasRequestThrottled := result.ResolutionMetadata.WasThrottled.Load()
-	if wasRequestThrottled {
-		throttledRequestCounter.WithLabelValues(s.serviceName, methodName).Inc()
+	if s.experimentallyEnabled(ctx, ExperimentalCheckDenialReasons) {
+		s.setListObjectsConditionalExclusionsHeader(ctx, result.ResolutionMetadata.ConditionalExclusionCount.Load())
 	}
 
 	return &openfgav1.ListObjectsResponse{
@@ -802,7 +2089,7 @@ func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequ
 	}, nil
 }
 
-func (s *Server) StreamedListObjects(req *openfgav1.StreamedListObjectsRequest, srv openfgav1.OpenFGAService_StreamedListObjectsServer) error {
+func (s *Server) StreamedListObjects(req *openfgav1.StreamedListObjectsRequest, srv openfgav1.OpenFGAService_StreamedListObjectsServer) (err error) {
 	start := time.Now()
 
 	ctx := srv.Context()
@@ -814,10 +2101,17 @@ func (s *Server) StreamedListObjects(req *openfgav1.StreamedListObjectsRequest,
 		attribute.String("consistency", req.GetConsistency().String()),
 	))
 	defer span.End()
+	defer s.setErrorReason(&err)
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_StreamedListObjects_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_StreamedListObjects_FullMethodName, req, &err)
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return err
+		}
 		if err := req.Validate(); err != nil {
-			return status.Error(codes.InvalidArgument, err.Error())
+			return serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -835,72 +2129,52 @@ func (s *Server) StreamedListObjects(req *openfgav1.StreamedListObjectsRequest,
 		return err
 	}
 
-	q, err := commands.NewListObjectsQuery(
-		s.datastore,
-		s.checkResolver,
-		commands.WithLogger(s.logger),
-		commands.WithListObjectsDeadline(s.listObjectsDeadline),
-		commands.WithDispatchThrottlerConfig(threshold.Config{
-			Throttler:    s.listObjectsDispatchThrottler,
-			Enabled:      s.listObjectsDispatchThrottlingEnabled,
-			Threshold:    s.listObjectsDispatchDefaultThreshold,
-			MaxThreshold: s.listObjectsDispatchThrottlingMaxThreshold,
-		}),
-		commands.WithListObjectsMaxResults(s.listObjectsMaxResults),
-		commands.WithResolveNodeLimit(s.resolveNodeLimit),
-		commands.WithResolveNodeBreadthLimit(s.resolveNodeBreadthLimit),
-		commands.WithMaxConcurrentReads(s.maxConcurrentReadsForListObjects),
-	)
-	if err != nil {
-		return serverErrors.NewInternalError("", err)
+	// send the headers, including the resolved model ID, before the first object
+	if err := srv.SendHeader(metadata.MD{}); err != nil {
+		return serverErrors.HandleError("", err)
+	}
+
+	// only streams of the latest model are expected to follow it
+	var streamCheck func(context.Context) error
+	if s.listObjectsStreamMaxModelLag > 0 && req.GetAuthorizationModelId() == "" &&
+		len(metadata.ValueFromIncomingContext(ctx, AuthorizationModelAliasHeader)) == 0 {
+		streamCheck = s.streamModelLagCheck(storeID, typesys.GetAuthorizationModelID())
 	}
 
 	req.AuthorizationModelId = typesys.GetAuthorizationModelID() // the resolved model id
+	req.Consistency = s.sessionConsistency(ctx, storeID, req.GetConsistency())
 
-	resolutionMetadata, err := q.ExecuteStreamed(
+	resolutionMetadata, err := s.listObjectsQuery.ExecuteStreamedWithCheck(
 		typesystem.ContextWithTypesystem(ctx, typesys),
 		req,
 		srv,
+		s.listObjectsStreamCheckInterval,
+		streamCheck,
 	)
-	if err != nil {
-		telemetry.TraceError(span, err)
-		return err
-	}
-	datastoreQueryCount := float64(*resolutionMetadata.DatastoreQueryCount)
-
-	grpc_ctxtags.Extract(ctx).Set(datastoreQueryCountHistogramName, datastoreQueryCount)
-	span.SetAttributes(attribute.Float64(datastoreQueryCountHistogramName, datastoreQueryCount))
-	datastoreQueryCountHistogram.WithLabelValues(
-		s.serviceName,
-		methodName,
-	).Observe(datastoreQueryCount)
-
-	dispatchCount := float64(resolutionMetadata.DispatchCounter.Load())
-
-	grpc_ctxtags.Extract(ctx).Set(dispatchCountHistogramName, dispatchCount)
-	span.SetAttributes(attribute.Float64(dispatchCountHistogramName, dispatchCount))
-	dispatchCountHistogram.WithLabelValues(
-		s.serviceName,
-		methodName,
-	).Observe(dispatchCount)
-
-	requestDurationHistogram.WithLabelValues(
-		s.serviceName,
-		methodName,
-		utils.Bucketize(uint(*resolutionMetadata.DatastoreQueryCount), s.requestDurationByQueryHistogramBuckets),
-		utils.Bucketize(uint(resolutionMetadata.DispatchCounter.Load()), s.requestDurationByDispatchCountHistogramBuckets),
-		req.GetConsistency().String(),
-	).Observe(float64(time.Since(start).Milliseconds()))
-
-	wasRequestThrottled := resolutionMetadata.WasThrottled.Load()
-	if wasRequestThrottled {
-		throttledRequestCounter.WithLabelValues(s.serviceName, methodName).Inc()
+	if errors.Is(err, commands.ErrStreamMaxDurationReached) {
+		const truncationCause = "max_stream_duration"
+		grpc_ctxtags.Extract(ctx).Set("truncation_cause", truncationCause)
+		span.SetAttributes(attribute.String("truncation_cause", truncationCause))
+		truncatedRequestCounter.WithLabelValues(s.serviceName, methodName, truncationCause).Inc()
+		srv.SetTrailer(metadata.Pairs(StreamTruncatedHeader, truncationCause))
+		err = nil
+	}
+	if err != nil {
+		telemetry.TraceError(span, err)
+		return err
+	}
+	s.observeResolutionMetadata(ctx, methodName, req.GetConsistency(), start, resolutionMetadata)
+	if s.checkResponseMetadataHeaders {
+		srv.SetTrailer(metadata.Pairs(
+			DispatchCountHeader, strconv.FormatUint(uint64(resolutionMetadata.DispatchCount.Load()), 10),
+			DatastoreQueryCountHeader, strconv.FormatUint(uint64(resolutionMetadata.DatastoreQueryCount.Load()), 10),
+		))
 	}
 
 	return nil
 }
 
-func (s *Server) Read(ctx context.Context, req *openfgav1.ReadRequest) (*openfgav1.ReadResponse, error) {
+func (s *Server) Read(ctx context.Context, req *openfgav1.ReadRequest) (_ *openfgav1.ReadResponse, err error) {
 	tk := req.GetTupleKey()
 	ctx, span := tracer.Start(ctx, "Read", trace.WithAttributes(
 		attribute.String("store_id", req.GetStoreId()),
@@ -910,10 +2184,16 @@ func (s *Server) Read(ctx context.Context, req *openfgav1.ReadRequest) (*openfga
 		attribute.KeyValue{Key: "consistency", Value: attribute.StringValue(req.GetConsistency().String())},
 	))
 	defer span.End()
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_Read_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_Read_FullMethodName, req, &err)
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return nil, err
+		}
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -922,28 +2202,40 @@ func (s *Server) Read(ctx context.Context, req *openfgav1.ReadRequest) (*openfga
 		Method:  "Read",
 	})
 
-	q := commands.NewReadQuery(s.datastore,
-		commands.WithReadQueryLogger(s.logger),
-		commands.WithReadQueryEncoder(s.encoder),
-	)
-	return q.Execute(ctx, &openfgav1.ReadRequest{
+	conditionFilter, err := readConditionFilterFromMetadata(ctx)
+	if err != nil {
+		return nil, serverErrors.ValidationError(err)
+	}
+
+	return s.readQuery.ExecuteWithConditionFilter(ctx, &openfgav1.ReadRequest{
 		StoreId:           req.GetStoreId(),
 		TupleKey:          tk,
 		PageSize:          req.GetPageSize(),
 		ContinuationToken: req.GetContinuationToken(),
-		Consistency:       req.GetConsistency(),
-	})
+		Consistency:       s.sessionConsistency(ctx, req.GetStoreId(), req.GetConsistency()),
+	}, conditionFilter)
 }
 
-func (s *Server) Write(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteResponse, error) {
+func (s *Server) Write(ctx context.Context, req *openfgav1.WriteRequest) (_ *openfgav1.WriteResponse, err error) {
 	ctx, span := tracer.Start(ctx, "Write", trace.WithAttributes(
 		attribute.String("store_id", req.GetStoreId()),
 	))
 	defer span.End()
+	defer s.setErrorReason(&err)
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_Write_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_Write_FullMethodName, req, &err)
+
+	if s.readOnly {
+		return nil, serverErrors.ServerReadOnly
+	}
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return nil, err
+		}
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -959,19 +2251,42 @@ func (s *Server) Write(ctx context.Context, req *openfgav1.WriteRequest) (*openf
 		return nil, err
 	}
 
+	if err := s.checkWriteModelIsLatest(ctx, req, typesys); err != nil {
+		return nil, err
+	}
+
+	// writes go through the check datastore so that they invalidate the cached usersets they touch
 	cmd := commands.NewWriteCommand(
-		s.datastore,
+		s.checkDatastore,
 		commands.WithWriteCmdLogger(s.logger),
+		commands.WithReferentialCheck(s.writeReferentialCheckEnabled),
+		commands.WithTupleQuota(s.tupleCounter, s.tupleQuotaForStore(storeID)),
 	)
-	return cmd.Execute(ctx, &openfgav1.WriteRequest{
+	resp, err := cmd.Execute(ctx, &openfgav1.WriteRequest{
 		StoreId:              storeID,
 		AuthorizationModelId: typesys.GetAuthorizationModelID(), // the resolved model id
 		Writes:               req.GetWrites(),
 		Deletes:              req.GetDeletes(),
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	writes := len(req.GetWrites().GetTupleKeys())
+	deletes := len(req.GetDeletes().GetTupleKeys())
+	span.SetAttributes(
+		attribute.Int("tuples_written_count", writes),
+		attribute.Int("tuples_deleted_count", deletes),
+	)
+
+	storeLabel := s.perStoreWriteMetricsLabel(storeID)
+	tuplesWrittenCounter.WithLabelValues(storeLabel).Add(float64(writes))
+	tuplesDeletedCounter.WithLabelValues(storeLabel).Add(float64(deletes))
+
+	return resp, nil
 }
 
-func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openfgav1.CheckResponse, error) {
+func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (_ *openfgav1.CheckResponse, err error) {
 	start := time.Now()
 
 	tk := req.GetTupleKey()
@@ -983,6 +2298,16 @@ func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openf
 		attribute.KeyValue{Key: "consistency", Value: attribute.StringValue(req.GetConsistency().String())},
 	))
 	defer span.End()
+	defer s.setErrorReason(&err)
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_Check_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_Check_FullMethodName, req, &err)
+
+	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return nil, err
+		}
+	}
 
 	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
 		Service: s.serviceName,
@@ -996,19 +2321,36 @@ func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openf
 		return nil, err
 	}
 
+	ctx, checkDatastore, pointInTime, release, err := s.checkTupleReader(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	req.Consistency = s.sessionConsistency(ctx, storeID, req.GetConsistency())
+
 	const methodName = "check"
-	resp, checkRequestMetadata, err := commands.NewCheckCommand(
-		s.checkDatastore,
+	resp, resolutionMetadata, err := commands.NewCheckCommand(
+		checkDatastore,
 		s.checkResolver,
 		typesys,
 		commands.WithCheckCommandLogger(s.logger),
 		commands.WithCheckCommandMaxConcurrentReads(s.maxConcurrentReadsForCheck),
+		commands.WithCheckCommandDatastoreHedging(s.datastoreHedgingPolicy),
 		commands.WithCheckCommandResolveNodeLimit(s.resolveNodeLimit),
+		commands.WithCheckCommandPointInTime(pointInTime),
 	).Execute(ctx, req)
 	if err != nil {
 		telemetry.TraceError(span, err)
 		if errors.Is(err, serverErrors.ThrottledTimeout) {
-			throttledRequestCounter.WithLabelValues(s.serviceName, methodName).Inc()
+			throttledRequestCounter.WithLabelValues(s.serviceName, methodName, dispatch.RequestPriorityFromContext(ctx).String()).Inc()
+		}
+		// A condition that cannot be evaluated fails the request, but the caller
+		// still benefits from knowing which context parameters were missing.
+		if status.Code(err) == codes.Code(openfgav1.ErrorCode_validation_error) && s.experimentallyEnabled(ctx, ExperimentalCheckDenialReasons) {
+			if missing := serverErrors.MissingConditionParameters(err); len(missing) > 0 {
+				s.setCheckDenialHeaders(ctx, DenialReasonConditionMissingContext, missing)
+			}
 		}
 		// should we define all metrics in one place that is accessible from everywhere (including LocalChecker!)
 		// and add a wrapper helper that automatically injects the service name tag?
@@ -1018,25 +2360,7 @@ func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openf
 	span.SetAttributes(
 		attribute.Bool("cycle_detected", resp.GetCycleDetected()),
 		attribute.Bool("allowed", resp.GetAllowed()))
-
-	queryCount := float64(resp.GetResolutionMetadata().DatastoreQueryCount)
-
-	grpc_ctxtags.Extract(ctx).Set(datastoreQueryCountHistogramName, queryCount)
-	span.SetAttributes(attribute.Float64(datastoreQueryCountHistogramName, queryCount))
-	datastoreQueryCountHistogram.WithLabelValues(
-		s.serviceName,
-		methodName,
-	).Observe(queryCount)
-
-	rawDispatchCount := checkRequestMetadata.DispatchCounter.Load()
-	dispatchCount := float64(rawDispatchCount)
-
-	grpc_ctxtags.Extract(ctx).Set(dispatchCountHistogramName, dispatchCount)
-	span.SetAttributes(attribute.Float64(dispatchCountHistogramName, dispatchCount))
-	dispatchCountHistogram.WithLabelValues(
-		s.serviceName,
-		methodName,
-	).Observe(dispatchCount)
+	s.observeCheckCache(ctx, resp)
 
 	res := &openfgav1.CheckResponse{
 		Allowed: resp.Allowed,
@@ -1044,23 +2368,104 @@ func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openf
 
 	checkResultCounter.With(prometheus.Labels{allowedLabel: strconv.FormatBool(resp.GetAllowed())}).Inc()
 
-	requestDurationHistogram.WithLabelValues(
-		s.serviceName,
-		methodName,
-		utils.Bucketize(uint(resp.GetResolutionMetadata().DatastoreQueryCount), s.requestDurationByQueryHistogramBuckets),
-		utils.Bucketize(uint(rawDispatchCount), s.requestDurationByDispatchCountHistogramBuckets),
-		req.GetConsistency().String(),
-	).Observe(float64(time.Since(start).Milliseconds()))
+	s.observeResolutionMetadata(ctx, methodName, req.GetConsistency(), start, resolutionMetadata)
+	if s.checkResponseMetadataHeaders {
+		s.setResolutionMetadataHeaders(ctx, resolutionMetadata)
+	}
 
-	wasRequestThrottled := checkRequestMetadata.WasThrottled.Load()
-	if wasRequestThrottled {
-		throttledRequestCounter.WithLabelValues(s.serviceName, methodName).Inc()
+	// the shadow model is evaluated against the current tuples
+	if s.shadowModelEvaluator != nil && pointInTime.IsZero() {
+		s.shadowModelEvaluator.maybeEnqueue(req, resp.GetAllowed())
+	}
+
+	if !resp.GetAllowed() && s.experimentallyEnabled(ctx, ExperimentalCheckDenialReasons) {
+		s.setCheckDenialHeaders(ctx, checkDenialReason(resp), nil)
 	}
 
 	return res, nil
 }
 
-func (s *Server) Expand(ctx context.Context, req *openfgav1.ExpandRequest) (*openfgav1.ExpandResponse, error) {
+// checkTupleReader returns the reader of the tuples of the Check requests on a store: the
+// reconstructed past tuples of a point-in-time check instead of the cached current ones, else a
+// snapshot of the tuples if snapshot reads are enabled, else the current tuples. Its reads hold a
+// permit of the fair share limiter of Check, if enabled. release must be called once the requests
+// are resolved.
+//
+// The returned context marks a snapshot reader as scoped to the request, so that its evaluations
+// are not shared with other requests that would outlive the snapshot (see
+// [graph.SingleflightCheckResolver]).
+func (s *Server) checkTupleReader(ctx context.Context, storeID string) (_ context.Context, _ storage.RelationshipTupleReader, pointInTime time.Time, release func(), err error) {
+	reader, pointInTime, release, err := s.consistentCheckTupleReader(ctx, storeID)
+	if err != nil {
+		return nil, nil, time.Time{}, nil, err
+	}
+	if _, ok := reader.(storage.SnapshotTupleReader); ok {
+		ctx = graph.ContextWithRequestScopedTupleReader(ctx)
+	}
+	if s.checkReadLimiter != nil {
+		reader = storagewrappers.NewFairShareTupleReader(reader, s.checkReadLimiter)
+	}
+	return ctx, reader, pointInTime, release, nil
+}
+
+// consistentCheckTupleReader returns the reader of checkTupleReader, before the fair sharing of
+// its reads.
+func (s *Server) consistentCheckTupleReader(ctx context.Context, storeID string) (_ storage.RelationshipTupleReader, pointInTime time.Time, release func(), err error) {
+	pointInTimeReader, pointInTime, err := s.pointInTimeTupleReader(ctx, storeID)
+	if err != nil {
+		return nil, time.Time{}, nil, err
+	}
+	if pointInTimeReader != nil {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("point_in_time", pointInTime.Format(time.RFC3339Nano)))
+		return pointInTimeReader, pointInTime, func() {}, nil
+	}
+
+	if s.snapshotBeginner != nil {
+		snapshot, err := s.snapshotBeginner.BeginSnapshot(ctx, storage.SnapshotOptions{MaxConcurrentReads: s.maxConcurrentReadsForCheck})
+		if err != nil {
+			return nil, time.Time{}, nil, serverErrors.HandleError("", err)
+		}
+		return snapshot, time.Time{}, snapshot.Release, nil
+	}
+
+	return s.checkDatastore, time.Time{}, func() {}, nil
+}
+
+// sessionConsistency returns the consistency preference of a request of the client of ctx on a
+// store, upgraded to HIGHER_CONSISTENCY if the client wrote to the store within the session
+// affinity window, so that the caches of the server are bypassed (see [WithSessionAffinityEnabled]).
+func (s *Server) sessionConsistency(ctx context.Context, storeID string, preference openfgav1.ConsistencyPreference) openfgav1.ConsistencyPreference {
+	if s.sessionAffinity == nil {
+		return preference
+	}
+	return s.sessionAffinity.Consistency(ctx, storeID, preference)
+}
+
+// setErrorReason adds its ErrorInfo reason to *err if enabled, see [WithErrorReasonDetails]. It
+// is deferred before recoverFromPanic, so that it runs after it. The errors of the interceptors
+// get their reason from the errorreason middleware.
+func (s *Server) setErrorReason(err *error) {
+	if s.errorReasonDetails && *err != nil {
+		*err = serverErrors.WithErrorReason(*err)
+	}
+}
+
+// localizeError replaces the message of *err with the message of the catalog in the locale of
+// the request, if a catalog is set, see [WithErrorMessageCatalog]. It is deferred before
+// recoverFromPanic and after setErrorReason, so that it runs between them.
+func (s *Server) localizeError(ctx context.Context, err *error) {
+	if s.errorMessageCatalog == nil || *err == nil {
+		return
+	}
+
+	var locale string
+	if values := metadata.ValueFromIncomingContext(ctx, AcceptLanguageHeader); len(values) > 0 {
+		locale = values[0]
+	}
+	*err = serverErrors.Localize(*err, s.errorMessageCatalog, locale)
+}
+
+func (s *Server) Expand(ctx context.Context, req *openfgav1.ExpandRequest) (_ *openfgav1.ExpandResponse, err error) {
 	tk := req.GetTupleKey()
 	ctx, span := tracer.Start(ctx, "Expand", trace.WithAttributes(
 		attribute.KeyValue{Key: "store_id", Value: attribute.StringValue(req.GetStoreId())},
@@ -1069,10 +2474,16 @@ func (s *Server) Expand(ctx context.Context, req *openfgav1.ExpandRequest) (*ope
 		attribute.KeyValue{Key: "consistency", Value: attribute.StringValue(req.GetConsistency().String())},
 	))
 	defer span.End()
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_Expand_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_Expand_FullMethodName, req, &err)
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return nil, err
+		}
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -1083,30 +2494,103 @@ func (s *Server) Expand(ctx context.Context, req *openfgav1.ExpandRequest) (*ope
 
 	storeID := req.GetStoreId()
 
+	compact, err := expandCompactFromMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	typesys, err := s.resolveTypesystem(ctx, storeID, req.GetAuthorizationModelId())
 	if err != nil {
 		return nil, err
 	}
 
-	q := commands.NewExpandQuery(s.datastore, commands.WithExpandQueryLogger(s.logger))
-	return q.Execute(ctx, &openfgav1.ExpandRequest{
+	resp, truncationCause, err := s.expandQuery.ExecuteWithTruncationCause(ctx, &openfgav1.ExpandRequest{
 		StoreId:              storeID,
 		AuthorizationModelId: typesys.GetAuthorizationModelID(), // the resolved model id
 		TupleKey:             tk,
 		Consistency:          req.GetConsistency(),
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if truncationCause != commands.ExpandTruncationCauseNone {
+		span.SetAttributes(attribute.String("truncation_cause", string(truncationCause)))
+		s.transport.SetHeader(ctx, ExpandTruncatedHeader, string(truncationCause))
+	}
+
+	if compact {
+		tree, err := json.Marshal(commands.NewCompactUsersetTree(resp.GetTree()))
+		if err != nil {
+			return nil, serverErrors.HandleError("", err)
+		}
+		s.transport.SetHeader(ctx, ExpandCompactTreeHeader, escapeNonASCII(string(tree)))
+	}
+
+	return resp, nil
+}
+
+// ExpandCompact is Expand with the userset tree in its compact adjacency list representation,
+// see [commands.CompactUsersetTree], e.g. for clients that render it as a graph. The tree is
+// computed as for Expand, and is truncated the same way by the limits of
+// [WithExpandResultLimit]. The ExpandRequest of the API has no field for the format of the
+// response, so API clients request the compact representation with the ExpandFormatHeader.
+func (s *Server) ExpandCompact(ctx context.Context, req *openfgav1.ExpandRequest) (_ *commands.CompactUsersetTree, err error) {
+	defer s.recoverFromPanic(ctx, "ExpandCompact", req, &err)
+
+	resp, err := s.Expand(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return commands.NewCompactUsersetTree(resp.GetTree()), nil
+}
+
+// expandCompactFromMetadata returns whether the ExpandFormatHeader metadata asks for the compact
+// userset tree.
+func expandCompactFromMetadata(ctx context.Context) (bool, error) {
+	values := metadata.ValueFromIncomingContext(ctx, ExpandFormatHeader)
+	if len(values) == 0 || values[0] == "" {
+		return false, nil
+	}
+	if values[0] != "compact" {
+		return false, serverErrors.InvalidHeaderValue(ExpandFormatHeader, values[0],
+			fmt.Errorf("invalid '%s' header value '%s', must be 'compact'", ExpandFormatHeader, values[0]))
+	}
+	return true, nil
+}
+
+// escapeNonASCII escapes the non-ASCII characters of a JSON document as \u sequences, since the
+// values of response headers, and of gRPC metadata in particular, must be ASCII.
+func escapeNonASCII(doc string) string {
+	var b strings.Builder
+	for _, r := range doc {
+		if r < utf8.RuneSelf {
+			b.WriteRune(r)
+			continue
+		}
+		for _, c := range utf16.Encode([]rune{r}) {
+			fmt.Fprintf(&b, "\\u%04x", c)
+		}
+	}
+	return b.String()
 }
 
-func (s *Server) ReadAuthorizationModel(ctx context.Context, req *openfgav1.ReadAuthorizationModelRequest) (*openfgav1.ReadAuthorizationModelResponse, error) {
+func (s *Server) ReadAuthorizationModel(ctx context.Context, req *openfgav1.ReadAuthorizationModelRequest) (_ *openfgav1.ReadAuthorizationModelResponse, err error) {
 	ctx, span := tracer.Start(ctx, "ReadAuthorizationModel", trace.WithAttributes(
 		attribute.String("store_id", req.GetStoreId()),
 		attribute.KeyValue{Key: authorizationModelIDKey, Value: attribute.StringValue(req.GetId())},
 	))
 	defer span.End()
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_ReadAuthorizationModel_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_ReadAuthorizationModel_FullMethodName, req, &err)
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return nil, err
+		}
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -1115,19 +2599,48 @@ func (s *Server) ReadAuthorizationModel(ctx context.Context, req *openfgav1.Read
 		Method:  "ReadAuthorizationModels",
 	})
 
+	// the entity tag of a model is its ID, so a request whose If-None-Match names it is answered
+	// without reading the model: the client got it from the store before, and models are
+	// immutable
+	etag := modelETag(req.GetId())
+	if ifNoneMatch(ctx, etag, false) {
+		s.setNotModified(ctx, etag)
+		return &openfgav1.ReadAuthorizationModelResponse{}, nil
+	}
+
 	q := commands.NewReadAuthorizationModelQuery(s.datastore, commands.WithReadAuthModelQueryLogger(s.logger))
-	return q.Execute(ctx, req)
+	resp, err := q.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ifNoneMatch(ctx, etag, true) {
+		s.setNotModified(ctx, etag)
+		return &openfgav1.ReadAuthorizationModelResponse{}, nil
+	}
+	s.transport.SetHeader(ctx, ETagHeader, etag)
+	return resp, nil
 }
 
-func (s *Server) WriteAuthorizationModel(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (*openfgav1.WriteAuthorizationModelResponse, error) {
+func (s *Server) WriteAuthorizationModel(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (_ *openfgav1.WriteAuthorizationModelResponse, err error) {
 	ctx, span := tracer.Start(ctx, "WriteAuthorizationModel", trace.WithAttributes(
 		attribute.String("store_id", req.GetStoreId()),
 	))
 	defer span.End()
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_WriteAuthorizationModel_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_WriteAuthorizationModel_FullMethodName, req, &err)
+
+	if s.readOnly {
+		return nil, serverErrors.ServerReadOnly
+	}
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return nil, err
+		}
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -1136,29 +2649,79 @@ func (s *Server) WriteAuthorizationModel(ctx context.Context, req *openfgav1.Wri
 		Method:  "WriteAuthorizationModel",
 	})
 
-	c := commands.NewWriteAuthorizationModelCommand(s.datastore,
+	impactAnalysis, strictImpactAnalysis, err := modelImpactAnalysisFromMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []commands.WriteAuthModelOption{
 		commands.WithWriteAuthModelLogger(s.logger),
 		commands.WithWriteAuthModelMaxSizeInBytes(s.maxAuthorizationModelSizeInBytes),
-	)
-	res, err := c.Execute(ctx, req)
+		commands.WithWriteAuthModelIDGenerator(s.idGenerator),
+		commands.WithWriteAuthModelStrictConditionParameters(s.strictConditionParameters),
+		commands.WithWriteAuthModelMaxComplexity(s.maxAuthorizationModelComplexity),
+		commands.WithWriteAuthModelMaxTypes(s.maxAuthorizationModelTypes),
+		commands.WithWriteAuthModelMaxRelationsPerType(s.maxAuthorizationModelRelationsPerType),
+		commands.WithWriteAuthModelMaxRewriteDepth(s.maxAuthorizationModelRewriteDepth),
+		commands.WithWriteAuthModelValidationTimeout(s.authorizationModelValidationTimeout),
+		commands.WithWriteAuthModelValidationMaxVisits(s.authorizationModelValidationMaxVisits),
+	}
+	if impactAnalysis {
+		opts = append(opts, commands.WithWriteAuthModelImpactAnalysis(s.datastore, strictImpactAnalysis, s.modelImpactAnalysisMaxProbes))
+	}
+	c := commands.NewWriteAuthorizationModelCommand(s.datastore, opts...)
+	res, details, err := c.ExecuteWithDetails(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
+	complexity := details.Complexity
+	span.SetAttributes(
+		attribute.Int("complexity_score", complexity.Score),
+		attribute.Int("stored_size", details.StoredSize),
+	)
+	s.logger.InfoWithContext(ctx, "authorization model written",
+		zap.String("store_id", req.GetStoreId()),
+		zap.String("authorization_model_id", res.GetAuthorizationModelId()),
+		zap.Int("complexity_score", complexity.Score),
+		zap.Int("types", complexity.Types),
+		zap.Int("relations", complexity.Relations),
+		zap.Int("tuple_to_usersets", complexity.TupleToUsersets),
+		zap.Int("conditions", complexity.Conditions),
+		zap.Int("max_rewrite_depth", complexity.MaxRewriteDepth),
+		zap.Int("max_rewrite_width", complexity.MaxRewriteWidth),
+		zap.Stringers("top_contributors", complexity.TopContributors),
+	)
+	if _, ok := s.perStoreModelComplexityMetricsAllowlist[req.GetStoreId()]; ok {
+		authorizationModelComplexityGauge.WithLabelValues(req.GetStoreId()).Set(float64(complexity.Score))
+	}
+
+	if warnings := details.Warnings; len(warnings) > 0 {
+		span.SetAttributes(attribute.StringSlice("warnings", warnings))
+		s.transport.SetHeader(ctx, AuthorizationModelWarningsHeader, strings.Join(warnings, "; "))
+	}
+	s.transport.SetHeader(ctx, AuthorizationModelStoredSizeHeader, strconv.Itoa(details.StoredSize))
+
 	s.transport.SetHeader(ctx, httpmiddleware.XHttpCode, strconv.Itoa(http.StatusCreated))
 
 	return res, nil
 }
 
-func (s *Server) ReadAuthorizationModels(ctx context.Context, req *openfgav1.ReadAuthorizationModelsRequest) (*openfgav1.ReadAuthorizationModelsResponse, error) {
+func (s *Server) ReadAuthorizationModels(ctx context.Context, req *openfgav1.ReadAuthorizationModelsRequest) (_ *openfgav1.ReadAuthorizationModelsResponse, err error) {
 	ctx, span := tracer.Start(ctx, "ReadAuthorizationModels", trace.WithAttributes(
 		attribute.String("store_id", req.GetStoreId()),
 	))
 	defer span.End()
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_ReadAuthorizationModels_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_ReadAuthorizationModels_FullMethodName, req, &err)
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return nil, err
+		}
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -1171,18 +2734,44 @@ func (s *Server) ReadAuthorizationModels(ctx context.Context, req *openfgav1.Rea
 		commands.WithReadAuthModelsQueryLogger(s.logger),
 		commands.WithReadAuthModelsQueryEncoder(s.encoder),
 	)
-	return c.Execute(ctx, req)
+	resp, err := c.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// models are immutable and only deleted with their store, so a page, e.g. the latest model
+	// with a page size of 1, only changes when a model is written
+	if len(resp.GetAuthorizationModels()) == 0 {
+		return resp, nil
+	}
+	etag := modelsPageETag(resp.GetAuthorizationModels()[0].GetId(), req.GetPageSize().GetValue(), req.GetContinuationToken())
+	if ifNoneMatch(ctx, etag, true) {
+		s.setNotModified(ctx, etag)
+		return &openfgav1.ReadAuthorizationModelsResponse{}, nil
+	}
+	s.transport.SetHeader(ctx, ETagHeader, etag)
+	return resp, nil
 }
 
-func (s *Server) WriteAssertions(ctx context.Context, req *openfgav1.WriteAssertionsRequest) (*openfgav1.WriteAssertionsResponse, error) {
+func (s *Server) WriteAssertions(ctx context.Context, req *openfgav1.WriteAssertionsRequest) (_ *openfgav1.WriteAssertionsResponse, err error) {
 	ctx, span := tracer.Start(ctx, "WriteAssertions", trace.WithAttributes(
 		attribute.String("store_id", req.GetStoreId()),
 	))
 	defer span.End()
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_WriteAssertions_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_WriteAssertions_FullMethodName, req, &err)
+
+	if s.readOnly {
+		return nil, serverErrors.ServerReadOnly
+	}
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return nil, err
+		}
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -1213,15 +2802,21 @@ func (s *Server) WriteAssertions(ctx context.Context, req *openfgav1.WriteAssert
 	return res, nil
 }
 
-func (s *Server) ReadAssertions(ctx context.Context, req *openfgav1.ReadAssertionsRequest) (*openfgav1.ReadAssertionsResponse, error) {
+func (s *Server) ReadAssertions(ctx context.Context, req *openfgav1.ReadAssertionsRequest) (_ *openfgav1.ReadAssertionsResponse, err error) {
 	ctx, span := tracer.Start(ctx, "ReadAssertions", trace.WithAttributes(
 		attribute.String("store_id", req.GetStoreId()),
 	))
 	defer span.End()
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_ReadAssertions_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_ReadAssertions_FullMethodName, req, &err)
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return nil, err
+		}
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -1236,19 +2831,84 @@ func (s *Server) ReadAssertions(ctx context.Context, req *openfgav1.ReadAssertio
 	}
 
 	q := commands.NewReadAssertionsQuery(s.datastore, commands.WithReadAssertionsQueryLogger(s.logger))
-	return q.Execute(ctx, req.GetStoreId(), typesys.GetAuthorizationModelID())
+	resp, err := q.Execute(ctx, &commands.ReadAssertionsRequest{
+		StoreID:              req.GetStoreId(),
+		AuthorizationModelID: typesys.GetAuthorizationModelID(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &openfgav1.ReadAssertionsResponse{
+		AuthorizationModelId: resp.AuthorizationModelID,
+		Assertions:           resp.Assertions,
+	}, nil
+}
+
+// ReadAssertionsPage reads the assertions of an authorization model like ReadAssertions,
+// optionally filtered by the object type and relation of their tuple key and paginated with
+// the token encoder of the server (see [WithTokenEncoder]). An empty authorization model ID
+// reads the assertions of the latest model of the store.
+func (s *Server) ReadAssertionsPage(ctx context.Context, req *commands.ReadAssertionsRequest) (_ *commands.ReadAssertionsResponse, err error) {
+	ctx, span := tracer.Start(ctx, "ReadAssertionsPage", trace.WithAttributes(
+		attribute.String("store_id", req.StoreID),
+		attribute.String("object_type", req.ObjectType),
+		attribute.String("relation", req.Relation),
+	))
+	defer span.End()
+	defer s.inFlightRequests.start("ReadAssertionsPage")()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, "ReadAssertionsPage", nil, &err)
+
+	if err := validator.ValidateStoreID(req.StoreID); err != nil {
+		return nil, err
+	}
+	if req.AuthorizationModelID != "" {
+		if err := validator.ValidateAuthorizationModelID(req.AuthorizationModelID); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "ReadAssertionsPage",
+	})
+
+	typesys, err := s.resolveTypesystem(ctx, req.StoreID, req.AuthorizationModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := commands.NewReadAssertionsQuery(s.datastore,
+		commands.WithReadAssertionsQueryLogger(s.logger),
+		commands.WithReadAssertionsQueryEncoder(s.encoder),
+	)
+	return q.Execute(ctx, &commands.ReadAssertionsRequest{
+		StoreID:              req.StoreID,
+		AuthorizationModelID: typesys.GetAuthorizationModelID(),
+		ObjectType:           req.ObjectType,
+		Relation:             req.Relation,
+		PageSize:             req.PageSize,
+		ContinuationToken:    req.ContinuationToken,
+	})
 }
 
-func (s *Server) ReadChanges(ctx context.Context, req *openfgav1.ReadChangesRequest) (*openfgav1.ReadChangesResponse, error) {
+func (s *Server) ReadChanges(ctx context.Context, req *openfgav1.ReadChangesRequest) (_ *openfgav1.ReadChangesResponse, err error) {
 	ctx, span := tracer.Start(ctx, "ReadChangesQuery", trace.WithAttributes(
 		attribute.String("store_id", req.GetStoreId()),
 		attribute.KeyValue{Key: "type", Value: attribute.StringValue(req.GetType())},
 	))
 	defer span.End()
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_ReadChanges_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_ReadChanges_FullMethodName, req, &err)
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return nil, err
+		}
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -1257,21 +2917,68 @@ func (s *Server) ReadChanges(ctx context.Context, req *openfgav1.ReadChangesRequ
 		Method:  "ReadChanges",
 	})
 
+	startTime, err := readChangesStartTimeFromMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !startTime.IsZero() {
+		span.SetAttributes(attribute.String("start_time", startTime.UTC().Format(time.RFC3339Nano)))
+	}
+
 	q := commands.NewReadChangesQuery(s.datastore,
 		commands.WithReadChangesQueryLogger(s.logger),
 		commands.WithReadChangesQueryEncoder(s.encoder),
 		commands.WithReadChangeQueryHorizonOffset(s.changelogHorizonOffset),
+		commands.WithReadChangesQueryHorizonInfo(s.readChangesHorizonHeaders),
+		commands.WithReadChangesQueryMaxResponseSizeInBytes(s.maxReadResponseSizeInBytes),
+		commands.WithReadChangesQueryStartTime(startTime),
 	)
-	return q.Execute(ctx, req)
+	resp, horizon, err := q.ExecuteWithHorizon(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if horizon != nil {
+		s.transport.SetHeader(ctx, ReadChangesHorizonHeader, horizon.Horizon.UTC().Format(time.RFC3339Nano))
+		if !horizon.NewestWithheldChange.IsZero() {
+			span.SetAttributes(attribute.Bool("changes_withheld", true))
+			s.transport.SetHeader(ctx, ReadChangesNewestWithheldChangeHeader, horizon.NewestWithheldChange.UTC().Format(time.RFC3339Nano))
+		}
+	}
+
+	return resp, nil
+}
+
+// readChangesStartTimeFromMetadata returns the start time of a ReadChanges request from the
+// ReadChangesStartTimeHeader metadata, or the zero time if the header is not set.
+func readChangesStartTimeFromMetadata(ctx context.Context) (time.Time, error) {
+	values := metadata.ValueFromIncomingContext(ctx, ReadChangesStartTimeHeader)
+	if len(values) == 0 || values[0] == "" {
+		return time.Time{}, nil
+	}
+
+	startTime, err := time.Parse(time.RFC3339Nano, values[0])
+	if err != nil {
+		return time.Time{}, serverErrors.InvalidHeaderValue(ReadChangesStartTimeHeader, values[0],
+			fmt.Errorf("invalid '%s' header value '%s', must be an RFC 3339 timestamp", ReadChangesStartTimeHeader, values[0]))
+	}
+	return startTime, nil
 }
 
-func (s *Server) CreateStore(ctx context.Context, req *openfgav1.CreateStoreRequest) (*openfgav1.CreateStoreResponse, error) {
+func (s *Server) CreateStore(ctx context.Context, req *openfgav1.CreateStoreRequest) (_ *openfgav1.CreateStoreResponse, err error) {
 	ctx, span := tracer.Start(ctx, "CreateStore")
 	defer span.End()
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_CreateStore_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_CreateStore_FullMethodName, req, &err)
+
+	if s.readOnly {
+		return nil, serverErrors.ServerReadOnly
+	}
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -1280,7 +2987,15 @@ func (s *Server) CreateStore(ctx context.Context, req *openfgav1.CreateStoreRequ
 		Method:  "CreateStore",
 	})
 
-	c := commands.NewCreateStoreCommand(s.datastore, commands.WithCreateStoreCmdLogger(s.logger))
+	opts := []commands.CreateStoreCmdOption{
+		commands.WithCreateStoreCmdLogger(s.logger),
+		commands.WithCreateStoreCmdIDGenerator(s.idGenerator),
+	}
+	if s.uniqueStoreNames != nil {
+		opts = append(opts, commands.WithCreateStoreCmdUniqueNames(s.uniqueStoreNames))
+	}
+
+	c := commands.NewCreateStoreCommand(s.datastore, opts...)
 	res, err := c.Execute(ctx, req)
 	if err != nil {
 		return nil, err
@@ -1291,15 +3006,25 @@ func (s *Server) CreateStore(ctx context.Context, req *openfgav1.CreateStoreRequ
 	return res, nil
 }
 
-func (s *Server) DeleteStore(ctx context.Context, req *openfgav1.DeleteStoreRequest) (*openfgav1.DeleteStoreResponse, error) {
+func (s *Server) DeleteStore(ctx context.Context, req *openfgav1.DeleteStoreRequest) (_ *openfgav1.DeleteStoreResponse, err error) {
 	ctx, span := tracer.Start(ctx, "DeleteStore", trace.WithAttributes(
 		attribute.String("store_id", req.GetStoreId()),
 	))
 	defer span.End()
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_DeleteStore_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_DeleteStore_FullMethodName, req, &err)
+
+	if s.readOnly {
+		return nil, serverErrors.ServerReadOnly
+	}
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return nil, err
+		}
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -1314,20 +3039,29 @@ func (s *Server) DeleteStore(ctx context.Context, req *openfgav1.DeleteStoreRequ
 		return nil, err
 	}
 
+	// the model cache of the datastore is invalidated by its DeleteStore
+	s.memoizedTypesystemResolver.DeleteStore(req.GetStoreId())
+
 	s.transport.SetHeader(ctx, httpmiddleware.XHttpCode, strconv.Itoa(http.StatusNoContent))
 
 	return res, nil
 }
 
-func (s *Server) GetStore(ctx context.Context, req *openfgav1.GetStoreRequest) (*openfgav1.GetStoreResponse, error) {
+func (s *Server) GetStore(ctx context.Context, req *openfgav1.GetStoreRequest) (_ *openfgav1.GetStoreResponse, err error) {
 	ctx, span := tracer.Start(ctx, "GetStore", trace.WithAttributes(
 		attribute.String("store_id", req.GetStoreId()),
 	))
 	defer span.End()
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_GetStore_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_GetStore_FullMethodName, req, &err)
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := validator.ValidateIDs(req); err != nil {
+			return nil, err
+		}
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -1340,13 +3074,16 @@ func (s *Server) GetStore(ctx context.Context, req *openfgav1.GetStoreRequest) (
 	return q.Execute(ctx, req)
 }
 
-func (s *Server) ListStores(ctx context.Context, req *openfgav1.ListStoresRequest) (*openfgav1.ListStoresResponse, error) {
+func (s *Server) ListStores(ctx context.Context, req *openfgav1.ListStoresRequest) (_ *openfgav1.ListStoresResponse, err error) {
 	ctx, span := tracer.Start(ctx, "ListStores")
 	defer span.End()
+	defer s.inFlightRequests.start(openfgav1.OpenFGAService_ListStores_FullMethodName)()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, openfgav1.OpenFGAService_ListStores_FullMethodName, req, &err)
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
 		if err := req.Validate(); err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, serverErrors.RequestValidationError(err)
 		}
 	}
 
@@ -1355,11 +3092,44 @@ func (s *Server) ListStores(ctx context.Context, req *openfgav1.ListStoresReques
 		Method:  "ListStores",
 	})
 
-	q := commands.NewListStoresQuery(s.datastore,
+	opts := []commands.ListStoresQueryOption{
 		commands.WithListStoresQueryLogger(s.logger),
 		commands.WithListStoresQueryEncoder(s.encoder),
-	)
-	return q.Execute(ctx, req)
+	}
+	if includeTotalCountFromMetadata(ctx) {
+		if s.storeCounter == nil {
+			return nil, serverErrors.StoreCountsUnsupported
+		}
+		opts = append(opts, commands.WithListStoresQueryTotalCount(s.storeCounter, s.listStoresExactTotalCount))
+	}
+	if values := metadata.ValueFromIncomingContext(ctx, ListStoresNameHeader); len(values) > 0 && values[0] != "" {
+		span.SetAttributes(attribute.String("name", values[0]))
+		opts = append(opts, commands.WithListStoresQueryName(values[0]))
+	}
+
+	q := commands.NewListStoresQuery(s.datastore, opts...)
+	resp, count, err := q.ExecuteWithTotalCount(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if count != nil {
+		span.SetAttributes(attribute.Int64("total_count", count.Count))
+		s.transport.SetHeader(ctx, ListStoresTotalCountHeader, strconv.FormatInt(count.Count, 10))
+		s.transport.SetHeader(ctx, ListStoresTotalCountEstimatedHeader, strconv.FormatBool(count.Estimated))
+	}
+
+	return resp, nil
+}
+
+// includeTotalCountFromMetadata returns true if the ListStoresIncludeTotalCountHeader metadata is "true".
+func includeTotalCountFromMetadata(ctx context.Context) bool {
+	values := metadata.ValueFromIncomingContext(ctx, ListStoresIncludeTotalCountHeader)
+	if len(values) == 0 {
+		return false
+	}
+	include, err := strconv.ParseBool(values[0])
+	return err == nil && include
 }
 
 // IsReady reports whether the datastore is ready. Please see the implementation of [[storage.OpenFGADatastore.IsReady]]
@@ -1367,7 +3137,8 @@ func (s *Server) ListStores(ctx context.Context, req *openfgav1.ListStoresReques
 func (s *Server) IsReady(ctx context.Context) (bool, error) {
 	// for now we only depend on the datastore being ready, but in the future
 	// server readiness may also depend on other criteria in addition to the
-	// datastore being ready.
+	// datastore being ready. Datastores only check that they can be read, so
+	// a server in read-only mode is ready with a read replica.
 
 	status, err := s.datastore.IsReady(ctx)
 	if err != nil {
@@ -1382,12 +3153,108 @@ func (s *Server) IsReady(ctx context.Context) (bool, error) {
 	return false, nil
 }
 
+// observeResolutionMetadata records the counters of the resolution of a Check, ListObjects or
+// ListUsers request: in the datastore_query_count, datastore_query_count_by_kind, dispatch_count,
+// request_duration_ms and throttled_requests_count metrics, and as attributes of the request span and fields of its logs.
+func (s *Server) observeResolutionMetadata(ctx context.Context, methodName string, consistency openfgav1.ConsistencyPreference, start time.Time, metadata *graph.ResolutionMetadata) {
+	span := trace.SpanFromContext(ctx)
+
+	datastoreQueryCount := metadata.DatastoreQueryCount.Load()
+	grpc_ctxtags.Extract(ctx).Set(datastoreQueryCountHistogramName, float64(datastoreQueryCount))
+	span.SetAttributes(attribute.Float64(datastoreQueryCountHistogramName, float64(datastoreQueryCount)))
+	datastoreQueryCountHistogram.WithLabelValues(
+		s.serviceName,
+		methodName,
+	).Observe(float64(datastoreQueryCount))
+
+	// the breakdown tells cheap point reads from expensive scans and reverse reads
+	for _, kind := range storagewrappers.QueryKinds {
+		queryCount := metadata.QueryCount(kind)
+		span.SetAttributes(attribute.Int(datastoreQueryCountHistogramName+"."+kind.String(), int(queryCount)))
+		datastoreQueryCountByKindHistogram.WithLabelValues(
+			s.serviceName,
+			methodName,
+			kind.String(),
+		).Observe(float64(queryCount))
+	}
+
+	dispatchCount := metadata.DispatchCount.Load()
+	grpc_ctxtags.Extract(ctx).Set(dispatchCountHistogramName, float64(dispatchCount))
+	span.SetAttributes(attribute.Float64(dispatchCountHistogramName, float64(dispatchCount)))
+	dispatchCountHistogram.WithLabelValues(
+		s.serviceName,
+		methodName,
+	).Observe(float64(dispatchCount))
+
+	span.SetAttributes(
+		attribute.Int("throttle_count", int(metadata.ThrottleCount.Load())),
+		attribute.Int("cache_hit_count", int(metadata.CacheHitCount.Load())),
+	)
+
+	requestDurationHistogram.WithLabelValues(
+		s.serviceName,
+		methodName,
+		utils.Bucketize(uint(datastoreQueryCount), s.requestDurationByQueryHistogramBuckets),
+		utils.Bucketize(uint(dispatchCount), s.requestDurationByDispatchCountHistogramBuckets),
+		consistency.String(),
+	).Observe(float64(time.Since(start).Milliseconds()))
+
+	if metadata.WasThrottled() {
+		throttledRequestCounter.WithLabelValues(s.serviceName, methodName, dispatch.RequestPriorityFromContext(ctx).String()).Inc()
+	}
+}
+
+// observeCheckCache records whether the result of a Check was served from the check query cache,
+// e.g. "hit; age=1.2s" or "miss": as the check_cache_hit and check_cache_entry_age_ms attributes
+// of the request span, as the check_cache field of its logs and, if enabled, in the
+// CheckCacheHeader.
+func (s *Server) observeCheckCache(ctx context.Context, resp *graph.ResolveCheckResponse) {
+	if !s.checkQueryCacheEnabled {
+		return
+	}
+
+	metadata := resp.GetResolutionMetadata()
+	hit := metadata != nil && metadata.CacheHit
+	value := "miss"
+	if hit {
+		value = "hit; age=" + strconv.FormatFloat(metadata.CacheEntryAge.Seconds(), 'f', 1, 64) + "s"
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("check_cache_entry_age_ms", metadata.CacheEntryAge.Milliseconds()))
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("check_cache_hit", hit))
+	grpc_ctxtags.Extract(ctx).Set("check_cache", value)
+
+	if s.checkQueryCacheResponseHeader {
+		s.transport.SetHeader(ctx, CheckCacheHeader, value)
+	}
+}
+
+// setResolutionMetadataHeaders sets the DispatchCountHeader and the DatastoreQueryCountHeader of
+// the response.
+func (s *Server) setResolutionMetadataHeaders(ctx context.Context, metadata *graph.ResolutionMetadata) {
+	s.transport.SetHeader(ctx, DispatchCountHeader, strconv.FormatUint(uint64(metadata.DispatchCount.Load()), 10))
+	s.transport.SetHeader(ctx, DatastoreQueryCountHeader, strconv.FormatUint(uint64(metadata.DatastoreQueryCount.Load()), 10))
+}
+
 // resolveTypesystem resolves the underlying TypeSystem given the storeID and modelID and
-// it sets some response metadata based on the model resolution.
+// it sets some response metadata based on the model resolution. If modelID is empty, the
+// model of the alias in the AuthorizationModelAliasHeader metadata is used, or else the latest model.
 func (s *Server) resolveTypesystem(ctx context.Context, storeID, modelID string) (*typesystem.TypeSystem, error) {
 	parentSpan := trace.SpanFromContext(ctx)
+
+	if modelID == "" {
+		aliasModelID, err := s.resolveModelAlias(ctx, storeID)
+		if err != nil {
+			return nil, err
+		}
+		modelID = aliasModelID
+	}
+
 	typesys, err := s.typesystemResolver(ctx, storeID, modelID)
 	if err != nil {
+		if errors.Is(err, typesystem.ErrStoreNotFound) {
+			return nil, serverErrors.StoreIDNotFound
+		}
+
 		if errors.Is(err, typesystem.ErrModelNotFound) {
 			if modelID == "" {
 				return nil, serverErrors.LatestAuthorizationModelNotFound(storeID)
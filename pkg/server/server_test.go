@@ -1,27 +1,50 @@
 package server
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"crypto/rand"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"runtime"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"github.com/openfga/openfga/cmd/migrate"
 	"github.com/openfga/openfga/cmd/util"
@@ -29,8 +52,11 @@ import (
 	"github.com/openfga/openfga/internal/graph"
 	mockstorage "github.com/openfga/openfga/internal/mocks"
 	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/middleware/validator"
 	"github.com/openfga/openfga/pkg/server/commands"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/server/relationstats"
 	"github.com/openfga/openfga/pkg/server/test"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/memory"
@@ -40,6 +66,7 @@ import (
 	"github.com/openfga/openfga/pkg/storage/sqlite"
 	"github.com/openfga/openfga/pkg/storage/storagewrappers"
 	storageTest "github.com/openfga/openfga/pkg/storage/test"
+	"github.com/openfga/openfga/pkg/telemetry"
 	storagefixtures "github.com/openfga/openfga/pkg/testfixtures/storage"
 	"github.com/openfga/openfga/pkg/testutils"
 	"github.com/openfga/openfga/pkg/tuple"
@@ -181,6 +208,19 @@ func TestServerPanicIfValidationsFail(t *testing.T) {
 			)
 		})
 	})
+
+	t.Run("invalid_request_priority_max_deprioritization", func(t *testing.T) {
+		require.PanicsWithError(t, "failed to construct the OpenFGA server: request priority max deprioritization must be a percentage between 0 and 100", func() {
+			mockController := gomock.NewController(t)
+			defer mockController.Finish()
+			mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+			_ = MustNewServerWithOpts(
+				WithDatastore(mockDatastore),
+				WithRequestPriorityEnabled(true),
+				WithRequestPriorityMaxDeprioritization(101),
+			)
+		})
+	})
 }
 
 func TestServerNotReadyDueToDatastoreRevision(t *testing.T) {
@@ -1286,18 +1326,102 @@ func TestReadAssertionModelDSError(t *testing.T) {
 
 	mockDSBadReadAssertions := mockstorage.NewMockOpenFGADatastore(mockController)
 	mockDSBadReadAssertions.EXPECT().
-		ReadAssertions(gomock.Any(), storeID, modelID).
+		ReadAssertions(gomock.Any(), storeID, modelID, storage.ReadAssertionsOptions{}).
 		AnyTimes().
-		Return(nil, fmt.Errorf("unable to read"))
+		Return(nil, nil, fmt.Errorf("unable to read"))
 
 	readAssertionQuery := commands.NewReadAssertionsQuery(mockDSBadReadAssertions)
-	_, err := readAssertionQuery.Execute(ctx, storeID, modelID)
+	_, err := readAssertionQuery.Execute(ctx, &commands.ReadAssertionsRequest{StoreID: storeID, AuthorizationModelID: modelID})
 	expectedError := serverErrors.NewInternalError(
 		"", fmt.Errorf("unable to read"),
 	)
 	require.EqualError(t, err, expectedError.Error())
 }
 
+func TestReadAssertionsPage(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	createResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "assertions"})
+	require.NoError(t, err)
+	storeID := createResp.GetId()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type folder
+			relations
+				define viewer: [user]
+		type document
+			relations
+				define editor: [user]
+				define viewer: [user]`)
+	writeModelResp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+	modelID := writeModelResp.GetAuthorizationModelId()
+
+	_, err = s.WriteAssertions(ctx, &openfgav1.WriteAssertionsRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: modelID,
+		Assertions: []*openfgav1.Assertion{
+			{TupleKey: tuple.NewAssertionTupleKey("document:1", "viewer", "user:anne"), Expectation: true},
+			{TupleKey: tuple.NewAssertionTupleKey("document:1", "editor", "user:anne"), Expectation: false},
+			{TupleKey: tuple.NewAssertionTupleKey("folder:1", "viewer", "user:anne"), Expectation: true},
+			{TupleKey: tuple.NewAssertionTupleKey("document:2", "viewer", "user:bob"), Expectation: false},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("filtered_pages_of_the_latest_model", func(t *testing.T) {
+		req := &commands.ReadAssertionsRequest{StoreID: storeID, ObjectType: "document", Relation: "viewer", PageSize: 1}
+
+		var objects []string
+		for {
+			resp, err := s.ReadAssertionsPage(ctx, req)
+			require.NoError(t, err)
+			require.Equal(t, modelID, resp.AuthorizationModelID)
+			for _, assertion := range resp.Assertions {
+				objects = append(objects, assertion.GetTupleKey().GetObject())
+			}
+
+			if resp.ContinuationToken == "" {
+				break
+			}
+			req.ContinuationToken = resp.ContinuationToken
+		}
+		require.Equal(t, []string{"document:1", "document:2"}, objects)
+	})
+
+	t.Run("read_assertions_returns_every_assertion", func(t *testing.T) {
+		resp, err := s.ReadAssertions(ctx, &openfgav1.ReadAssertionsRequest{StoreId: storeID, AuthorizationModelId: modelID})
+		require.NoError(t, err)
+		require.Len(t, resp.GetAssertions(), 4)
+	})
+
+	t.Run("invalid_continuation_token", func(t *testing.T) {
+		_, err := s.ReadAssertionsPage(ctx, &commands.ReadAssertionsRequest{StoreID: storeID, PageSize: 1, ContinuationToken: "not-a-token"})
+		require.ErrorIs(t, err, serverErrors.InvalidContinuationToken)
+	})
+
+	t.Run("invalid_model_id", func(t *testing.T) {
+		_, err := s.ReadAssertionsPage(ctx, &commands.ReadAssertionsRequest{StoreID: storeID, AuthorizationModelID: "invalid"})
+		require.ErrorIs(t, err, serverErrors.InvalidAuthorizationModelID)
+	})
+}
+
 func TestResolveAuthorizationModel(t *testing.T) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
@@ -1382,6 +1506,7 @@ func TestResolveAuthorizationModel(t *testing.T) {
 
 type mockStreamServer struct {
 	grpc.ServerStream
+	trailer metadata.MD
 }
 
 func NewMockStreamServer() *mockStreamServer {
@@ -1396,6 +1521,235 @@ func (m *mockStreamServer) Send(*openfgav1.StreamedListObjectsResponse) error {
 	return nil
 }
 
+func (m *mockStreamServer) SendHeader(metadata.MD) error {
+	return nil
+}
+
+func (m *mockStreamServer) SetTrailer(md metadata.MD) {
+	m.trailer = metadata.Join(m.trailer, md)
+}
+
+// blockingReadDatastore blocks ReadStartingWithUser until released.
+type blockingReadDatastore struct {
+	storage.OpenFGADatastore
+	reading chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingReadDatastore) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter, options storage.ReadStartingWithUserOptions) (storage.TupleIterator, error) {
+	select {
+	case b.reading <- struct{}{}:
+	default:
+	}
+
+	<-b.release
+	return b.OpenFGADatastore.ReadStartingWithUser(ctx, store, filter, options)
+}
+
+func TestStreamedListObjectsMaxModelLag(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	model := &openfgav1.WriteAuthorizationModelRequest{
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: language.MustTransformDSLToProto(`
+			model
+				schema 1.1
+
+			type user
+
+			type document
+				relations
+					define viewer: [user]`).GetTypeDefinitions(),
+	}
+
+	setup := func(t *testing.T, maxVersions uint32) (*Server, *blockingReadDatastore, string) {
+		ds := &blockingReadDatastore{
+			OpenFGADatastore: memory.New(),
+			reading:          make(chan struct{}, 1),
+			release:          make(chan struct{}),
+		}
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithStreamedListObjectsMaxModelLag(maxVersions, time.Millisecond),
+		)
+		t.Cleanup(s.Close)
+
+		store := ulid.Make().String()
+		model.StoreId = store
+		_, err := s.WriteAuthorizationModel(ctx, model)
+		require.NoError(t, err)
+
+		_, err = s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: store,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+			},
+		})
+		require.NoError(t, err)
+
+		return s, ds, store
+	}
+
+	stream := func(s *Server, store, modelID string) chan error {
+		done := make(chan error, 1)
+		go func() {
+			done <- s.StreamedListObjects(&openfgav1.StreamedListObjectsRequest{
+				StoreId:              store,
+				AuthorizationModelId: modelID,
+				Type:                 "document",
+				Relation:             "viewer",
+				User:                 "user:anne",
+			}, NewMockStreamServer())
+		}()
+		return done
+	}
+
+	t.Run("aborted_when_the_lag_is_exceeded", func(t *testing.T) {
+		s, ds, store := setup(t, 1)
+
+		done := stream(s, store, "")
+		<-ds.reading
+
+		for i := 0; i < 2; i++ {
+			_, err := s.WriteAuthorizationModel(ctx, model)
+			require.NoError(t, err)
+		}
+
+		err := <-done
+		require.Equal(t, codes.Aborted, status.Code(err))
+
+		// datastore queries are not canceled with the request
+		close(ds.release)
+	})
+
+	t.Run("not_aborted_within_the_lag", func(t *testing.T) {
+		s, ds, store := setup(t, 1)
+
+		done := stream(s, store, "")
+		<-ds.reading
+
+		_, err := s.WriteAuthorizationModel(ctx, model)
+		require.NoError(t, err)
+
+		// let the stream check the latest model a few times
+		time.Sleep(20 * time.Millisecond)
+		close(ds.release)
+
+		require.NoError(t, <-done)
+	})
+
+	t.Run("not_aborted_with_a_model_id", func(t *testing.T) {
+		s, ds, store := setup(t, 1)
+
+		resp, err := s.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{StoreId: store})
+		require.NoError(t, err)
+
+		done := stream(s, store, resp.GetAuthorizationModels()[0].GetId())
+		<-ds.reading
+
+		for i := 0; i < 2; i++ {
+			_, err := s.WriteAuthorizationModel(ctx, model)
+			require.NoError(t, err)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		close(ds.release)
+
+		require.NoError(t, <-done)
+	})
+}
+
+func TestStreamedListObjectsMaxStreamDuration(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	setup := func(t *testing.T, maxDuration time.Duration) (*Server, *blockingReadDatastore, string) {
+		ds := &blockingReadDatastore{
+			OpenFGADatastore: memory.New(),
+			reading:          make(chan struct{}, 1),
+			release:          make(chan struct{}),
+		}
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithMaxStreamDuration(maxDuration),
+		)
+		t.Cleanup(s.Close)
+
+		store := ulid.Make().String()
+		_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:       store,
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: language.MustTransformDSLToProto(`
+				model
+					schema 1.1
+
+				type user
+
+				type document
+					relations
+						define viewer: [user]`).GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+
+		_, err = s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: store,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+			},
+		})
+		require.NoError(t, err)
+
+		return s, ds, store
+	}
+
+	stream := func(s *Server, store string) (*collectingStreamServer, chan error) {
+		srv := &collectingStreamServer{}
+		done := make(chan error, 1)
+		go func() {
+			done <- s.StreamedListObjects(&openfgav1.StreamedListObjectsRequest{
+				StoreId:  store,
+				Type:     "document",
+				Relation: "viewer",
+				User:     "user:anne",
+			}, srv)
+		}()
+		return srv, done
+	}
+
+	t.Run("ends_gracefully_at_the_limit", func(t *testing.T) {
+		s, ds, store := setup(t, 10*time.Millisecond)
+
+		srv, done := stream(s, store)
+		<-ds.reading
+
+		require.NoError(t, <-done)
+		require.Empty(t, srv.objects)
+		require.Equal(t, []string{"max_stream_duration"}, srv.trailer.Get(StreamTruncatedHeader))
+
+		// datastore queries are not canceled with the request
+		close(ds.release)
+	})
+
+	t.Run("not_truncated_within_the_limit", func(t *testing.T) {
+		s, ds, store := setup(t, time.Minute)
+
+		srv, done := stream(s, store)
+		<-ds.reading
+		close(ds.release)
+
+		require.NoError(t, <-done)
+		require.Equal(t, []string{"document:1"}, srv.objects)
+		require.Empty(t, srv.trailer.Get(StreamTruncatedHeader))
+	})
+}
+
 // This runs ListObjects and StreamedListObjects many times over to ensure no race conditions (see https://github.com/openfga/openfga/pull/762)
 func BenchmarkListObjectsNoRaceCondition(b *testing.B) {
 	b.Cleanup(func() {
@@ -1744,6 +2098,8 @@ func TestDefaultMaxConcurrentReadSettings(t *testing.T) {
 	require.EqualValues(t, math.MaxUint32, cfg.MaxConcurrentReadsForCheck)
 	require.EqualValues(t, math.MaxUint32, cfg.MaxConcurrentReadsForListObjects)
 	require.EqualValues(t, math.MaxUint32, cfg.MaxConcurrentReadsForListUsers)
+	require.EqualValues(t, math.MaxUint32, cfg.MaxConcurrentReadsForExpand)
+	require.EqualValues(t, math.MaxUint32, cfg.MaxConcurrentReadsForRead)
 
 	s := MustNewServerWithOpts(
 		WithDatastore(memory.New()),
@@ -1752,6 +2108,136 @@ func TestDefaultMaxConcurrentReadSettings(t *testing.T) {
 	require.EqualValues(t, math.MaxUint32, s.maxConcurrentReadsForCheck)
 	require.EqualValues(t, math.MaxUint32, s.maxConcurrentReadsForListObjects)
 	require.EqualValues(t, math.MaxUint32, s.maxConcurrentReadsForListUsers)
+	require.EqualValues(t, math.MaxUint32, s.maxConcurrentReadsForExpand)
+	require.EqualValues(t, math.MaxUint32, s.maxConcurrentReadsForRead)
+
+	t.Run("zero_is_rejected", func(t *testing.T) {
+		_, err := NewServerWithOpts(WithDatastore(memory.New()), WithMaxConcurrentReadsForExpand(0))
+		require.EqualError(t, err, "max concurrent reads for Expand must be greater than 0")
+
+		_, err = NewServerWithOpts(WithDatastore(memory.New()), WithMaxConcurrentReadsForRead(0))
+		require.EqualError(t, err, "max concurrent reads for Read must be greater than 0")
+
+		_, err = NewServerWithOpts(WithDatastore(memory.New()), WithMaxChecksPerBatchCheck(0))
+		require.EqualError(t, err, "max checks per BatchCheck must be greater than 0")
+
+		_, err = NewServerWithOpts(WithDatastore(memory.New()), WithCheckStoreReadWeights(map[string]uint32{"store": 0}))
+		require.EqualError(t, err, "the Check read weight of store 'store' must be greater than 0")
+	})
+
+	t.Run("negative_authorization_model_limits_are_rejected", func(t *testing.T) {
+		_, err := NewServerWithOpts(WithDatastore(memory.New()), WithMaxAuthorizationModelTypes(-1))
+		require.EqualError(t, err, "authorization model limits cannot be negative")
+
+		_, err = NewServerWithOpts(WithDatastore(memory.New()), WithMaxAuthorizationModelRelationsPerType(-1))
+		require.EqualError(t, err, "authorization model limits cannot be negative")
+
+		_, err = NewServerWithOpts(WithDatastore(memory.New()), WithMaxAuthorizationModelRewriteDepth(-1))
+		require.EqualError(t, err, "authorization model limits cannot be negative")
+	})
+}
+
+func TestResolverWorkerPool(t *testing.T) {
+	t.Run("disabled_by_default", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(memory.New()))
+		t.Cleanup(s.Close)
+
+		require.False(t, s.resolverWorkerPoolEnabled)
+		require.Nil(t, s.resolverWorkerPool)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		s := MustNewServerWithOpts(
+			WithDatastore(memory.New()),
+			WithResolverWorkerPoolEnabled(true),
+			WithResolveNodeBreadthLimit(10),
+		)
+		t.Cleanup(s.Close)
+
+		require.NotNil(t, s.resolverWorkerPool)
+		require.Equal(t, runtime.GOMAXPROCS(0)*10, s.resolverWorkerPoolSize)
+	})
+
+	t.Run("negative_size_is_rejected", func(t *testing.T) {
+		_, err := NewServerWithOpts(
+			WithDatastore(memory.New()),
+			WithResolverWorkerPoolEnabled(true),
+			WithResolverWorkerPoolSize(-1),
+		)
+		require.EqualError(t, err, "resolver worker pool size must not be negative")
+	})
+}
+
+func TestMaxConcurrentReadsForExpandAndRead(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	const readDelay = 100 * time.Millisecond
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+	storeID, model := storageTest.BootstrapFGAStore(t, ds, `
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`,
+		[]string{"document:1#viewer@user:anne"},
+	)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(mockstorage.NewMockSlowDataStorage(ds, readDelay)),
+		WithMaxConcurrentReadsForExpand(1),
+		WithMaxConcurrentReadsForRead(1),
+	)
+	t.Cleanup(s.Close)
+
+	// the limits are shared by the calls, so 3 concurrent calls read the datastore one at a time
+	const calls = 3
+	runConcurrently := func(t *testing.T, call func() error) time.Duration {
+		var wg sync.WaitGroup
+		errs := make(chan error, calls)
+		start := time.Now()
+		for i := 0; i < calls; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- call()
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+		return time.Since(start)
+	}
+
+	t.Run("expand", func(t *testing.T) {
+		elapsed := runConcurrently(t, func() error {
+			_, err := s.Expand(ctx, &openfgav1.ExpandRequest{
+				StoreId:              storeID,
+				AuthorizationModelId: model.GetId(),
+				TupleKey:             &openfgav1.ExpandRequestTupleKey{Object: "document:1", Relation: "viewer"},
+			})
+			return err
+		})
+		require.GreaterOrEqual(t, elapsed, calls*readDelay)
+	})
+
+	t.Run("read", func(t *testing.T) {
+		elapsed := runConcurrently(t, func() error {
+			_, err := s.Read(ctx, &openfgav1.ReadRequest{
+				StoreId:  storeID,
+				TupleKey: &openfgav1.ReadRequestTupleKey{Object: "document:1"},
+			})
+			return err
+		})
+		require.GreaterOrEqual(t, elapsed, calls*readDelay)
+	})
 }
 
 func TestDelegateCheckResolver(t *testing.T) {
@@ -1971,17 +2457,143 @@ func TestWriteAuthorizationModelWithSchema12(t *testing.T) {
 	})
 }
 
-func TestIsExperimentallyEnabled(t *testing.T) {
-	t.Cleanup(func() {
-		goleak.VerifyNone(t)
-	})
-	ds := memory.New() // Datastore required for server instantiation
-	someExperimentalFlag := ExperimentalFeatureFlag("some-experimental-feature-to-enable")
-	t.Cleanup(ds.Close)
+// regionIDGenerator embeds a region code in the first entropy byte of the generated ULIDs.
+type regionIDGenerator struct {
+	region byte
+}
 
-	t.Run("returns_false_if_experimentals_is_empty", func(t *testing.T) {
-		s := MustNewServerWithOpts(WithDatastore(ds))
-		t.Cleanup(s.Close)
+func (g regionIDGenerator) newID() string {
+	entropy := make([]byte, 10)
+	_, _ = rand.Read(entropy)
+	entropy[0] = g.region
+	return ulid.MustNew(ulid.Now(), bytes.NewReader(entropy)).String()
+}
+
+func (g regionIDGenerator) NewStoreID() string {
+	return g.newID()
+}
+
+func (g regionIDGenerator) NewModelID() string {
+	return g.newID()
+}
+
+type invalidIDGenerator struct{}
+
+func (invalidIDGenerator) NewStoreID() string {
+	return "us-east-1-store"
+}
+
+func (invalidIDGenerator) NewModelID() string {
+	return "us-east-1-model"
+}
+
+func TestWithIDGenerator(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	t.Run("custom_generator_end_to_end", func(t *testing.T) {
+		const region = byte(42)
+
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithIDGenerator(regionIDGenerator{region: region}),
+		)
+		t.Cleanup(s.Close)
+
+		createStoreResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "regional"})
+		require.NoError(t, err)
+		storeID := createStoreResp.GetId()
+		require.Equal(t, region, ulid.MustParse(storeID).Entropy()[0])
+
+		getStoreResp, err := s.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Equal(t, "regional", getStoreResp.GetName())
+
+		model := testutils.MustTransformDSLToProtoWithID(`
+			model
+				schema 1.1
+			type user
+			type document
+				relations
+					define viewer: [user]`)
+
+		writeModelResp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+		modelID := writeModelResp.GetAuthorizationModelId()
+		require.Equal(t, region, ulid.MustParse(modelID).Entropy()[0])
+
+		_, err = s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{
+					tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		checkResp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.True(t, checkResp.GetAllowed())
+
+		readModelsResp, err := s.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, readModelsResp.GetAuthorizationModels(), 1)
+		require.Equal(t, modelID, readModelsResp.GetAuthorizationModels()[0].GetId())
+	})
+
+	t.Run("generator_with_invalid_ids", func(t *testing.T) {
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithIDGenerator(invalidIDGenerator{}),
+		)
+		t.Cleanup(s.Close)
+
+		_, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "regional"})
+		require.Equal(t, codes.Code(openfgav1.InternalErrorCode_internal_error), status.Code(err))
+
+		_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:       ulid.Make().String(),
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: []*openfgav1.TypeDefinition{
+				{Type: "user"},
+			},
+		})
+		require.Equal(t, codes.Code(openfgav1.InternalErrorCode_internal_error), status.Code(err))
+	})
+
+	t.Run("nil_generator", func(t *testing.T) {
+		_, err := NewServerWithOpts(
+			WithDatastore(ds),
+			WithIDGenerator(nil),
+		)
+		require.EqualError(t, err, "the id generator must not be nil")
+	})
+}
+
+func TestIsExperimentallyEnabled(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ds := memory.New() // Datastore required for server instantiation
+	someExperimentalFlag := ExperimentalFeatureFlag("some-experimental-feature-to-enable")
+	t.Cleanup(ds.Close)
+
+	t.Run("returns_false_if_experimentals_is_empty", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(s.Close)
 		require.False(t, s.IsExperimentallyEnabled(someExperimentalFlag))
 	})
 
@@ -2013,6 +2625,119 @@ func TestIsExperimentallyEnabled(t *testing.T) {
 	})
 }
 
+func TestDeprecatedExperimentalFlag(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	deprecatedFlag := ExperimentalFeatureFlag("some-deprecated-feature")
+	deprecatedAt := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	deprecatedFeature := WithExperimentalFeatures(ExperimentalFeature{
+		Flag:           deprecatedFlag,
+		Stage:          ExperimentalStageDeprecated,
+		DeprecatedAt:   deprecatedAt,
+		RemovalVersion: "v2.0.0",
+		Sunset:         sunset,
+	})
+
+	t.Run("sets_the_deprecation_headers_when_enabled", func(t *testing.T) {
+		observerLogger, logs := observer.New(zap.WarnLevel)
+		transport := &headerRecordingTransport{}
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithTransport(transport),
+			deprecatedFeature,
+			WithLogger(&logger.ZapLogger{Logger: zap.New(observerLogger)}),
+			WithExperimentals(deprecatedFlag, ExperimentalFeatureFlag("some-unknown-feature")),
+		)
+		t.Cleanup(s.Close)
+
+		require.Equal(t, 1, logs.FilterMessage("deprecated experimental flag enabled").FilterField(zap.String("removal_version", "v2.0.0")).Len())
+		require.Equal(t, 1, logs.FilterMessage("unknown experimental flag, it has no effect").Len())
+
+		ctx := telemetry.ContextWithRPCInfo(context.Background(), telemetry.RPCInfo{Method: "Check", Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName})
+		counter := deprecatedExperimentalUseCounter.WithLabelValues(string(deprecatedFlag), openfgav1.OpenFGAService_ServiceDesc.ServiceName, "Check")
+		before := testutil.ToFloat64(counter)
+		require.True(t, s.experimentallyEnabled(ctx, deprecatedFlag))
+		require.True(t, s.experimentallyEnabled(ctx, deprecatedFlag))
+
+		deprecation, ok := transport.header(DeprecationHeader)
+		require.True(t, ok)
+		require.Equal(t, "@"+strconv.FormatInt(deprecatedAt.Unix(), 10), deprecation)
+		sunsetHeader, ok := transport.header(SunsetHeader)
+		require.True(t, ok)
+		require.Equal(t, "Tue, 01 Jul 2025 00:00:00 GMT", sunsetHeader)
+
+		require.InDelta(t, before+2, testutil.ToFloat64(counter), 0)
+		// the warnings about the use of the flag are rate limited
+		require.Equal(t, 1, logs.FilterMessage("a request depended on a deprecated experimental flag").Len())
+	})
+
+	t.Run("sets_no_headers_when_disabled", func(t *testing.T) {
+		transport := &headerRecordingTransport{}
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithTransport(transport),
+			deprecatedFeature,
+		)
+		t.Cleanup(s.Close)
+
+		require.False(t, s.experimentallyEnabled(context.Background(), deprecatedFlag))
+		_, ok := transport.header(DeprecationHeader)
+		require.False(t, ok)
+	})
+
+	t.Run("the_features_are_registered_per_server", func(t *testing.T) {
+		transport := &headerRecordingTransport{}
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithTransport(transport),
+			WithExperimentals(deprecatedFlag),
+		)
+		t.Cleanup(s.Close)
+
+		require.True(t, s.experimentallyEnabled(context.Background(), deprecatedFlag))
+		_, ok := transport.header(DeprecationHeader)
+		require.False(t, ok)
+		_, ok = LookupExperimentalFeature(deprecatedFlag)
+		require.False(t, ok)
+	})
+
+	t.Run("sets_no_headers_for_flags_that_are_not_deprecated", func(t *testing.T) {
+		transport := &headerRecordingTransport{}
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithTransport(transport),
+			deprecatedFeature,
+			WithExperimentals(ExperimentalCheckDenialReasons),
+		)
+		t.Cleanup(s.Close)
+
+		require.True(t, s.experimentallyEnabled(context.Background(), ExperimentalCheckDenialReasons))
+		_, ok := transport.header(DeprecationHeader)
+		require.False(t, ok)
+		_, ok = transport.header(SunsetHeader)
+		require.False(t, ok)
+	})
+}
+
+func TestExperimentalFeatures(t *testing.T) {
+	features := ExperimentalFeatures()
+	require.True(t, slices.IsSortedFunc(features, func(a, b ExperimentalFeature) int {
+		return cmp.Compare(a.Flag, b.Flag)
+	}))
+	for _, feature := range features {
+		require.Contains(t, []ExperimentalFeatureStage{ExperimentalStageExperimental, ExperimentalStageBeta, ExperimentalStageDeprecated}, feature.Stage, feature.Flag)
+		if feature.Stage == ExperimentalStageDeprecated {
+			require.False(t, feature.DeprecatedAt.IsZero(), feature.Flag)
+			require.NotEmpty(t, feature.RemovalVersion, feature.Flag)
+		}
+	}
+}
+
 func TestServer_ThrottleUntilDeadline(t *testing.T) {
 	ds := memory.New()
 	t.Cleanup(ds.Close)
@@ -2254,3 +2979,4409 @@ func TestCheckWithCachedIterator(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, checkResponse.GetAllowed())
 }
+
+func TestCheckAfterDeleteStore(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithCheckQueryCacheEnabled(true),
+		WithCacheLimit(10),
+		WithCheckQueryCacheTTL(1*time.Minute),
+	)
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "deleted"})
+	require.NoError(t, err)
+	model, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId: store.GetId(),
+		TypeDefinitions: language.MustTransformDSLToProto(`
+			model
+				schema 1.1
+			type user
+			type document
+				relations
+					define viewer: [user]`).GetTypeDefinitions(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+	})
+	require.NoError(t, err)
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: store.GetId(),
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:jon")},
+		},
+	})
+	require.NoError(t, err)
+
+	requests := []*openfgav1.CheckRequest{
+		{
+			StoreId:  store.GetId(),
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:jon"),
+		},
+		{
+			StoreId:              store.GetId(),
+			AuthorizationModelId: model.GetAuthorizationModelId(),
+			TupleKey:             tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:jon"),
+		},
+	}
+	for _, req := range requests {
+		resp, err := s.Check(ctx, req)
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+	}
+
+	_, err = s.DeleteStore(ctx, &openfgav1.DeleteStoreRequest{StoreId: store.GetId()})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		for _, req := range requests {
+			_, err := s.Check(ctx, req)
+			require.ErrorIs(t, err, serverErrors.StoreIDNotFound)
+		}
+	}
+}
+
+func TestWritePerStoreMetrics(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	createStore := func(name string) string {
+		resp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: name})
+		require.NoError(t, err)
+
+		model := testutils.MustTransformDSLToProtoWithID(`
+			model
+				schema 1.1
+			type user
+			type document
+				relations
+					define viewer: [user]`)
+		_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         resp.GetId(),
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+		return resp.GetId()
+	}
+
+	allowlistedStoreID := createStore("allowlisted")
+	otherStoreID := createStore("other")
+	WithPerStoreWriteMetrics([]string{allowlistedStoreID})(s)
+
+	writtenAllowlisted := tuplesWrittenCounter.WithLabelValues(allowlistedStoreID)
+	deletedAllowlisted := tuplesDeletedCounter.WithLabelValues(allowlistedStoreID)
+	writtenOther := tuplesWrittenCounter.WithLabelValues("other")
+	initialWrittenOther := testutil.ToFloat64(writtenOther)
+
+	_, err := s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: allowlistedStoreID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+				tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.InDelta(t, 2, testutil.ToFloat64(writtenAllowlisted), 0)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: allowlistedStoreID,
+		Deletes: &openfgav1.WriteRequestDeletes{
+			TupleKeys: []*openfgav1.TupleKeyWithoutCondition{
+				tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("document:1", "viewer", "user:anne")),
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.InDelta(t, 1, testutil.ToFloat64(deletedAllowlisted), 0)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: otherStoreID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.InDelta(t, initialWrittenOther+1, testutil.ToFloat64(writtenOther), 0)
+
+	t.Run("failed_writes_are_not_counted", func(t *testing.T) {
+		_, err := s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: allowlistedStoreID,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{
+					tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+				},
+			},
+		})
+		require.Error(t, err)
+		require.InDelta(t, 2, testutil.ToFloat64(writtenAllowlisted), 0)
+	})
+}
+
+func TestAuthorizationModelComplexity(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	// The score is 2 types + 2 direct relations + all (2 + 2*1 + 2*1) = 10.
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define editor: [user]
+				define viewer: [user]
+				define all: editor and viewer`)
+
+	createStore := func(s *Server) string {
+		resp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "complexity"})
+		require.NoError(t, err)
+		return resp.GetId()
+	}
+	writeModel := func(s *Server, storeID string) error {
+		_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		return err
+	}
+
+	t.Run("reported_for_allowlisted_stores", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(s.Close)
+
+		allowlistedStoreID := createStore(s)
+		otherStoreID := createStore(s)
+		WithPerStoreModelComplexityMetrics([]string{allowlistedStoreID})(s)
+
+		require.NoError(t, writeModel(s, allowlistedStoreID))
+		require.NoError(t, writeModel(s, otherStoreID))
+
+		require.InDelta(t, 10, testutil.ToFloat64(authorizationModelComplexityGauge.WithLabelValues(allowlistedStoreID)), 0)
+		require.Equal(t, 1, testutil.CollectAndCount(authorizationModelComplexityGauge))
+	})
+
+	t.Run("rejected_above_the_limit", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds), WithMaxAuthorizationModelComplexity(9))
+		t.Cleanup(s.Close)
+
+		err := writeModel(s, createStore(s))
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_authorization_model), status.Code(err))
+		require.ErrorContains(t, err, "the complexity score of the model is 10, above the limit of 9; the relations contributing the most are document#all (6)")
+	})
+}
+
+func TestRejectWritesOnStaleModel(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithRejectWritesOnStaleModel(true))
+	t.Cleanup(s.Close)
+
+	resp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "stale"})
+	require.NoError(t, err)
+	storeID := resp.GetId()
+
+	writeModel := func() string {
+		model := testutils.MustTransformDSLToProtoWithID(`
+			model
+				schema 1.1
+			type user
+			type document
+				relations
+					define viewer: [user]`)
+		resp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+		return resp.GetAuthorizationModelId()
+	}
+	staleModelID := writeModel()
+	latestModelID := writeModel()
+
+	write := func(ctx context.Context, storeID, modelID, object string) error {
+		_, err := s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: modelID,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey(object, "viewer", "user:anne")},
+			},
+		})
+		return err
+	}
+
+	t.Run("pinned_latest", func(t *testing.T) {
+		require.NoError(t, write(ctx, storeID, latestModelID, "document:1"))
+	})
+
+	t.Run("unpinned", func(t *testing.T) {
+		require.NoError(t, write(ctx, storeID, "", "document:2"))
+	})
+
+	t.Run("pinned_stale", func(t *testing.T) {
+		rejected := staleModelWritesRejectedCounter.WithLabelValues("other")
+		initialRejected := testutil.ToFloat64(rejected)
+
+		err := write(ctx, storeID, staleModelID, "document:3")
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.FailedPrecondition, st.Code())
+
+		require.Len(t, st.Details(), 1)
+		info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		require.Equal(t, "STALE_AUTHORIZATION_MODEL", info.GetReason())
+		require.Equal(t, map[string]string{
+			"authorization_model_id":        staleModelID,
+			"latest_authorization_model_id": latestModelID,
+		}, info.GetMetadata())
+
+		require.InDelta(t, initialRejected+1, testutil.ToFloat64(rejected), 0)
+	})
+
+	t.Run("pinned_stale_by_alias", func(t *testing.T) {
+		err := s.WriteModelAlias(ctx, &commands.ModelAlias{StoreID: storeID, Alias: "old", AuthorizationModelID: staleModelID})
+		require.NoError(t, err)
+
+		aliasCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(AuthorizationModelAliasHeader, "old"))
+		err = write(aliasCtx, storeID, "", "document:4")
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("pinned_stale_allowed_by_header", func(t *testing.T) {
+		allowCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(WriteAllowStaleModelHeader, "true"))
+		require.NoError(t, write(allowCtx, storeID, staleModelID, "document:5"))
+	})
+
+	t.Run("pinned_stale_allowed_by_store_override", func(t *testing.T) {
+		WithRejectWritesOnStaleModelOverrides(map[string]bool{storeID: false})(s)
+		t.Cleanup(func() {
+			WithRejectWritesOnStaleModelOverrides(nil)(s)
+		})
+
+		require.NoError(t, write(ctx, storeID, staleModelID, "document:6"))
+	})
+
+	t.Run("allowed_by_default", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(s.Close)
+
+		_, err := s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: staleModelID,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:7", "viewer", "user:anne")},
+			},
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestDatastoreHedging(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	// a delay this short hedges every read up to the budget
+	s := MustNewServerWithOpts(WithDatastore(ds), WithDatastoreHedgingEnabled(true), WithDatastoreHedgingMinDelay(time.Nanosecond), WithDatastoreHedgingMaxPerRequest(10))
+	t.Cleanup(s.Close)
+
+	resp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "hedging"})
+	require.NoError(t, err)
+	storeID := resp.GetId()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type group
+			relations
+				define member: [user]
+		type document
+			relations
+				define viewer: [user, group#member]`)
+	modelResp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+	modelID := modelResp.GetAuthorizationModelId()
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: modelID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("group:eng", "member", "user:anne"),
+				tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	checkResp, err := s.Check(ctx, &openfgav1.CheckRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: modelID,
+		TupleKey:             tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+	})
+	require.NoError(t, err)
+	require.True(t, checkResp.GetAllowed())
+
+	listObjectsResp, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: modelID,
+		Type:                 "document",
+		Relation:             "viewer",
+		User:                 "user:anne",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"document:1"}, listObjectsResp.GetObjects())
+
+	listUsersResp, err := s.ListUsers(ctx, &openfgav1.ListUsersRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: modelID,
+		Object:               &openfgav1.Object{Type: "document", Id: "1"},
+		Relation:             "viewer",
+		UserFilters:          []*openfgav1.UserTypeFilter{{Type: "user"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, listUsersResp.GetUsers(), 1)
+	require.Equal(t, "anne", listUsersResp.GetUsers()[0].GetObject().GetId())
+
+	_, err = NewServerWithOpts(WithDatastore(ds), WithDatastoreHedgingEnabled(true), WithDatastoreHedgingPercentile(101))
+	require.EqualError(t, err, "datastore hedging min delay must not be negative, percentile must be between 0 and 100, and max per request must be greater than 0")
+}
+
+func TestTupleQuota(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithTupleQuota(commands.TupleQuota{MaxTuples: 1}))
+	t.Cleanup(s.Close)
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`)
+
+	createStore := func() string {
+		resp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "quota"})
+		require.NoError(t, err)
+
+		_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         resp.GetId(),
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+		return resp.GetId()
+	}
+	write := func(storeID, object string) error {
+		_, err := s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey(object, "viewer", "user:anne")},
+			},
+		})
+		return err
+	}
+
+	t.Run("over_quota", func(t *testing.T) {
+		storeID := createStore()
+		require.NoError(t, write(storeID, "document:1"))
+
+		err := write(storeID, "document:2")
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.ResourceExhausted, st.Code())
+		require.Len(t, st.Details(), 1)
+		info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		require.Equal(t, "TUPLE_QUOTA_EXCEEDED", info.GetReason())
+		require.Equal(t, map[string]string{"quota": "1", "usage": "1"}, info.GetMetadata())
+
+		stats, err := s.GetStoreStatistics(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), stats.TupleCount)
+		require.Equal(t, map[string]int64{"document": 1}, stats.TupleCountByObjectType)
+		require.Equal(t, commands.TupleQuota{MaxTuples: 1}, stats.TupleQuota)
+	})
+
+	t.Run("store_override", func(t *testing.T) {
+		storeID := createStore()
+		WithTupleQuotaOverrides(map[string]commands.TupleQuota{storeID: {MaxTuples: 2}})(s)
+		t.Cleanup(func() {
+			WithTupleQuotaOverrides(nil)(s)
+		})
+
+		require.NoError(t, write(storeID, "document:1"))
+		require.NoError(t, write(storeID, "document:2"))
+		require.Equal(t, codes.ResourceExhausted, status.Code(write(storeID, "document:3")))
+
+		stats, err := s.GetStoreStatistics(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), stats.TupleCount)
+		require.Equal(t, commands.TupleQuota{MaxTuples: 2}, stats.TupleQuota)
+	})
+
+	t.Run("unknown_store_statistics", func(t *testing.T) {
+		_, err := s.GetStoreStatistics(ctx, ulid.Make().String())
+		require.ErrorIs(t, err, serverErrors.StoreIDNotFound)
+	})
+
+	t.Run("admin_handler", func(t *testing.T) {
+		storeID := createStore()
+		require.NoError(t, write(storeID, "document:1"))
+
+		r := httptest.NewRequest(http.MethodGet, "/admin/stores/"+storeID+"/statistics", nil)
+		r.RemoteAddr = "127.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		NewAdminHandler(s).ServeHTTP(rec, r)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var stats commands.StoreStatistics
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+		require.Equal(t, int64(1), stats.TupleCount)
+		require.Equal(t, commands.TupleQuota{MaxTuples: 1}, stats.TupleQuota)
+	})
+
+	t.Run("admin_handler_remote_caller", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/admin/stores/"+createStore()+"/statistics", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		NewAdminHandler(s).ServeHTTP(rec, r)
+		require.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
+
+func TestResolutionMetadataMetrics(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	resp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "metrics"})
+	require.NoError(t, err)
+	storeID := resp.GetId()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type group
+			relations
+				define member: [user]
+		type document
+			relations
+				define viewer: [user, group#member]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("group:eng", "member", "user:anne"),
+				tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	sampleCount := func(histogram *prometheus.HistogramVec, methodName string) uint64 {
+		m := &dto.Metric{}
+		require.NoError(t, histogram.WithLabelValues(s.serviceName, methodName).(prometheus.Metric).Write(m))
+		return m.GetHistogram().GetSampleCount()
+	}
+
+	methods := map[string]func() error{
+		"check": func() error {
+			_, err := s.Check(ctx, &openfgav1.CheckRequest{
+				StoreId:  storeID,
+				TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+			})
+			return err
+		},
+		"listobjects": func() error {
+			_, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+				StoreId:  storeID,
+				Type:     "document",
+				Relation: "viewer",
+				User:     "user:anne",
+			})
+			return err
+		},
+		"listusers": func() error {
+			_, err := s.ListUsers(ctx, &openfgav1.ListUsersRequest{
+				StoreId:     storeID,
+				Object:      &openfgav1.Object{Type: "document", Id: "1"},
+				Relation:    "viewer",
+				UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+			})
+			return err
+		},
+	}
+
+	for methodName, call := range methods {
+		t.Run(methodName, func(t *testing.T) {
+			initialQueries := sampleCount(datastoreQueryCountHistogram, methodName)
+			initialDispatches := sampleCount(dispatchCountHistogram, methodName)
+
+			require.NoError(t, call())
+
+			require.Equal(t, initialQueries+1, sampleCount(datastoreQueryCountHistogram, methodName))
+			require.Equal(t, initialDispatches+1, sampleCount(dispatchCountHistogram, methodName))
+		})
+	}
+
+	for _, metric := range []string{
+		"openfga_datastore_query_count",
+		"openfga_dispatch_count",
+		"openfga_request_duration_ms",
+	} {
+		count, err := testutil.GatherAndCount(prometheus.DefaultGatherer, metric)
+		require.NoError(t, err)
+		require.Positive(t, count, metric)
+	}
+}
+
+func TestCheckDenialReasons(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	setup := func(t *testing.T, experimentals ...ExperimentalFeatureFlag) (*Server, *headerRecordingTransport, string) {
+		transport := &headerRecordingTransport{}
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithTransport(transport),
+			WithExperimentals(experimentals...),
+		)
+		t.Cleanup(s.Close)
+
+		createResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "denial-reasons"})
+		require.NoError(t, err)
+		storeID := createResp.GetId()
+
+		model := testutils.MustTransformDSLToProtoWithID(`
+			model
+				schema 1.1
+			type user
+			type group
+				relations
+					define member: [user with in_region]
+			type document
+				relations
+					define viewer: [user with in_region, group#member]
+
+			condition in_region(region: string) {
+				region == "eu"
+			}`)
+		_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+			Conditions:      model.GetConditions(),
+		})
+		require.NoError(t, err)
+
+		_, err = s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{
+					tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:anne", "in_region", nil),
+					tuple.NewTupleKeyWithCondition("document:2", "viewer", "user:anne", "in_region", nil),
+					tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+					tuple.NewTupleKeyWithCondition("group:eng", "member", "user:carl", "in_region", nil),
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		return s, transport, storeID
+	}
+
+	tests := []struct {
+		name             string
+		user             string
+		context          map[string]interface{}
+		contextualTuples []*openfgav1.TupleKey
+		expectedReason   DenialReason
+		expectedMissing  string
+	}{
+		{
+			name:           "no_relationship",
+			user:           "user:bob",
+			context:        map[string]interface{}{"region": "eu"},
+			expectedReason: DenialReasonDenied,
+		},
+		{
+			name:           "condition_false",
+			user:           "user:anne",
+			context:        map[string]interface{}{"region": "us"},
+			expectedReason: DenialReasonConditionFalse,
+		},
+		{
+			name:            "condition_missing_context",
+			user:            "user:anne",
+			expectedReason:  DenialReasonConditionMissingContext,
+			expectedMissing: "region",
+		},
+		{
+			name:           "condition_false_through_userset",
+			user:           "user:carl",
+			context:        map[string]interface{}{"region": "us"},
+			expectedReason: DenialReasonConditionFalse,
+		},
+		{
+			name:    "condition_false_on_contextual_tuple",
+			user:    "user:dave",
+			context: map[string]interface{}{"region": "us"},
+			contextualTuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:dave", "in_region", nil),
+			},
+			expectedReason: DenialReasonConditionFalse,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, transport, storeID := setup(t, ExperimentalCheckDenialReasons)
+
+			resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+				StoreId:          storeID,
+				TupleKey:         tuple.NewCheckRequestTupleKey("document:1", "viewer", test.user),
+				Context:          testutils.MustNewStruct(t, test.context),
+				ContextualTuples: &openfgav1.ContextualTupleKeys{TupleKeys: test.contextualTuples},
+			})
+			if test.expectedReason == DenialReasonConditionMissingContext {
+				require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+			} else {
+				require.NoError(t, err)
+				require.False(t, resp.GetAllowed())
+			}
+
+			reason, ok := transport.header(CheckDenialReasonHeader)
+			require.True(t, ok)
+			require.Equal(t, string(test.expectedReason), reason)
+
+			missing, ok := transport.header(CheckMissingContextHeader)
+			require.Equal(t, test.expectedMissing != "", ok)
+			require.Equal(t, test.expectedMissing, missing)
+		})
+	}
+
+	t.Run("list_objects_reports_conditional_exclusions", func(t *testing.T) {
+		s, transport, storeID := setup(t, ExperimentalCheckDenialReasons)
+
+		resp, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+			StoreId:  storeID,
+			Type:     "document",
+			Relation: "viewer",
+			User:     "user:anne",
+			Context:  testutils.MustNewStruct(t, map[string]interface{}{"region": "us"}),
+		})
+		require.NoError(t, err)
+		require.Empty(t, resp.GetObjects())
+
+		exclusions, ok := transport.header(ListObjectsConditionalExclusionsHeader)
+		require.True(t, ok)
+		require.Equal(t, "2", exclusions)
+	})
+
+	t.Run("headers_not_set_without_experimental_flag", func(t *testing.T) {
+		s, transport, storeID := setup(t)
+
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+			Context:  testutils.MustNewStruct(t, map[string]interface{}{"region": "us"}),
+		})
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+
+		_, ok := transport.header(CheckDenialReasonHeader)
+		require.False(t, ok)
+	})
+}
+
+func TestCheckCacheHeader(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	setup := func(t *testing.T, opts ...OpenFGAServiceV1Option) (*Server, *headerRecordingTransport, string) {
+		transport := &headerRecordingTransport{}
+		s := MustNewServerWithOpts(append([]OpenFGAServiceV1Option{
+			WithDatastore(ds),
+			WithTransport(transport),
+			WithCheckQueryCacheEnabled(true),
+		}, opts...)...)
+		t.Cleanup(s.Close)
+
+		createResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "check-cache"})
+		require.NoError(t, err)
+		storeID := createResp.GetId()
+
+		model := testutils.MustTransformDSLToProtoWithID(`
+			model
+				schema 1.1
+			type user
+			type document
+				relations
+					define viewer: [user]`)
+		_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+
+		_, err = s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+			},
+		})
+		require.NoError(t, err)
+
+		return s, transport, storeID
+	}
+
+	t.Run("miss_then_hit", func(t *testing.T) {
+		s, transport, storeID := setup(t, WithCheckQueryCacheResponseHeader(true))
+
+		check := func() {
+			resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+				StoreId:  storeID,
+				TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+			})
+			require.NoError(t, err)
+			require.True(t, resp.GetAllowed())
+		}
+
+		check()
+		value, ok := transport.header(CheckCacheHeader)
+		require.True(t, ok)
+		require.Equal(t, "miss", value)
+
+		time.Sleep(100 * time.Millisecond)
+		check()
+		value, ok = transport.header(CheckCacheHeader)
+		require.True(t, ok)
+		require.True(t, strings.HasPrefix(value, "hit; age="), value)
+		age, err := time.ParseDuration(strings.TrimPrefix(value, "hit; age="))
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, age, 100*time.Millisecond)
+		require.Less(t, age, serverconfig.DefaultCheckQueryCacheTTL)
+	})
+
+	t.Run("header_not_set_by_default", func(t *testing.T) {
+		s, transport, storeID := setup(t)
+
+		_, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+
+		_, ok := transport.header(CheckCacheHeader)
+		require.False(t, ok)
+		_, ok = transport.header(DispatchCountHeader)
+		require.False(t, ok)
+	})
+
+	t.Run("response_metadata_headers", func(t *testing.T) {
+		s, transport, storeID := setup(t, WithCheckResponseMetadataHeaders(true), WithCheckQueryCacheResponseHeader(true))
+
+		check := func() {
+			_, err := s.Check(ctx, &openfgav1.CheckRequest{
+				StoreId:  storeID,
+				TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+			})
+			require.NoError(t, err)
+		}
+
+		check()
+		value, _ := transport.header(CheckCacheHeader)
+		require.Equal(t, "miss", value)
+		value, _ = transport.header(DatastoreQueryCountHeader)
+		require.Equal(t, "1", value)
+		value, _ = transport.header(DispatchCountHeader)
+		require.Equal(t, "0", value)
+
+		check()
+		value, _ = transport.header(CheckCacheHeader)
+		require.True(t, strings.HasPrefix(value, "hit; age="), value)
+		value, _ = transport.header(DatastoreQueryCountHeader)
+		require.Equal(t, "0", value)
+
+		_, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+			StoreId:  storeID,
+			Type:     "document",
+			Relation: "viewer",
+			User:     "user:anne",
+		})
+		require.NoError(t, err)
+		value, ok := transport.header(DatastoreQueryCountHeader)
+		require.True(t, ok)
+		require.NotEqual(t, "0", value)
+		_, ok = transport.header(DispatchCountHeader)
+		require.True(t, ok)
+	})
+}
+
+func TestExpandResultLimit(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	createResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "expand-limit"})
+	require.NoError(t, err)
+	storeID := createResp.GetId()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define editor: [user]
+				define viewer: [user] or editor`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	const members = 10_000
+	var writes []*openfgav1.TupleKey
+	for i := 0; i < members; i++ {
+		writes = append(writes, tuple.NewTupleKey("document:1", "viewer", "user:"+strconv.Itoa(i)))
+		if len(writes) == ds.MaxTuplesPerWrite() {
+			require.NoError(t, ds.Write(ctx, storeID, nil, writes))
+			writes = nil
+		}
+	}
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "editor", "user:editor")}))
+
+	expand := func(t *testing.T, opts ...OpenFGAServiceV1Option) (*openfgav1.ExpandResponse, *headerRecordingTransport, error) {
+		transport := &headerRecordingTransport{}
+		s := MustNewServerWithOpts(append([]OpenFGAServiceV1Option{WithDatastore(ds), WithTransport(transport)}, opts...)...)
+		t.Cleanup(s.Close)
+
+		resp, err := s.Expand(ctx, &openfgav1.ExpandRequest{
+			StoreId:  storeID,
+			TupleKey: &openfgav1.ExpandRequestTupleKey{Object: "document:1", Relation: "viewer"},
+		})
+		return resp, transport, err
+	}
+
+	t.Run("no_limit", func(t *testing.T) {
+		resp, transport, err := expand(t)
+		require.NoError(t, err)
+		nodes := resp.GetTree().GetRoot().GetUnion().GetNodes()
+		require.Len(t, nodes, 2)
+		require.Len(t, nodes[0].GetLeaf().GetUsers().GetUsers(), members)
+		_, ok := transport.header(ExpandTruncatedHeader)
+		require.False(t, ok)
+	})
+
+	t.Run("truncates_leaf_users", func(t *testing.T) {
+		resp, transport, err := expand(t, WithExpandResultLimit(100, 0, false))
+		require.NoError(t, err)
+
+		var users int
+		var markers int
+		var walk func(node *openfgav1.UsersetTree_Node)
+		walk = func(node *openfgav1.UsersetTree_Node) {
+			if node.GetName() == commands.ExpandTruncatedNodeName {
+				markers++
+			}
+			users += len(node.GetLeaf().GetUsers().GetUsers())
+			for _, child := range node.GetUnion().GetNodes() {
+				walk(child)
+			}
+		}
+		walk(resp.GetTree().GetRoot())
+		require.Equal(t, 100, users)
+		require.Positive(t, markers)
+
+		cause, ok := transport.header(ExpandTruncatedHeader)
+		require.True(t, ok)
+		require.Equal(t, string(commands.ExpandTruncationCauseMaxLeafUsers), cause)
+	})
+
+	t.Run("truncates_nodes", func(t *testing.T) {
+		resp, transport, err := expand(t, WithExpandResultLimit(0, 1, false))
+		require.NoError(t, err)
+
+		nodes := resp.GetTree().GetRoot().GetUnion().GetNodes()
+		require.Len(t, nodes, 2)
+		for _, node := range nodes {
+			require.Equal(t, commands.ExpandTruncatedNodeName, node.GetName())
+			require.Empty(t, node.GetLeaf().GetUsers().GetUsers())
+		}
+
+		cause, ok := transport.header(ExpandTruncatedHeader)
+		require.True(t, ok)
+		require.Equal(t, string(commands.ExpandTruncationCauseMaxNodes), cause)
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		_, transport, err := expand(t, WithExpandResultLimit(100, 0, true))
+		require.Error(t, err)
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		_, ok := transport.header(ExpandTruncatedHeader)
+		require.False(t, ok)
+	})
+
+	t.Run("compact", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds), WithExpandResultLimit(100, 0, false))
+		t.Cleanup(s.Close)
+
+		compact, err := s.ExpandCompact(ctx, &openfgav1.ExpandRequest{
+			StoreId:  storeID,
+			TupleKey: &openfgav1.ExpandRequestTupleKey{Object: "document:1", Relation: "viewer"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, commands.CompactUsersetNodeUnion, compact.Nodes[0].Kind)
+		require.Len(t, compact.Users, 100)
+
+		tree, err := compact.UsersetTree()
+		require.NoError(t, err)
+		require.Len(t, tree.GetRoot().GetUnion().GetNodes(), 2)
+	})
+
+	t.Run("compact_format_header", func(t *testing.T) {
+		transport := &headerRecordingTransport{}
+		s := MustNewServerWithOpts(WithDatastore(ds), WithTransport(transport), WithExpandResultLimit(100, 0, false))
+		t.Cleanup(s.Close)
+
+		req := &openfgav1.ExpandRequest{
+			StoreId:  storeID,
+			TupleKey: &openfgav1.ExpandRequestTupleKey{Object: "document:1", Relation: "viewer"},
+		}
+		resp, err := s.Expand(metadata.NewIncomingContext(ctx, metadata.Pairs(ExpandFormatHeader, "compact")), req)
+		require.NoError(t, err)
+
+		value, ok := transport.header(ExpandCompactTreeHeader)
+		require.True(t, ok)
+		var compact commands.CompactUsersetTree
+		require.NoError(t, json.Unmarshal([]byte(value), &compact))
+		require.Equal(t, commands.NewCompactUsersetTree(resp.GetTree()), &compact)
+
+		_, err = s.Expand(metadata.NewIncomingContext(ctx, metadata.Pairs(ExpandFormatHeader, "graph")), req)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("compact_tree_header_is_ascii", func(t *testing.T) {
+		doc, err := json.Marshal(map[string]string{"user": "user:zoë 😀"})
+		require.NoError(t, err)
+
+		escaped := escapeNonASCII(string(doc))
+		require.Equal(t, `{"user":"user:zo\u00eb \ud83d\ude00"}`, escaped)
+		var decoded map[string]string
+		require.NoError(t, json.Unmarshal([]byte(escaped), &decoded))
+		require.Equal(t, "user:zoë 😀", decoded["user"])
+	})
+}
+
+func TestHandlerPanicRecovery(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	createResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "panic-recovery"})
+	require.NoError(t, err)
+	storeID := createResp.GetId()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockCheckResolver := graph.NewMockCheckResolver(mockController)
+	mockCheckResolver.EXPECT().
+		ResolveCheck(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, *graph.ResolveCheckRequest) (*graph.ResolveCheckResponse, error) {
+			panic("injected panic")
+		}).
+		Times(2)
+
+	originalCheckResolver := s.checkResolver
+	s.checkResolver = mockCheckResolver
+	defer func() { s.checkResolver = originalCheckResolver }()
+
+	checkReq := &openfgav1.CheckRequest{
+		StoreId:  storeID,
+		TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err = s.Check(ctx, checkReq)
+		require.Error(t, err)
+		require.Equal(t, codes.Internal, status.Code(err))
+	}
+
+	// The server keeps serving the requests that do not panic.
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+		},
+	})
+	require.NoError(t, err)
+
+	readResp, err := s.Read(ctx, &openfgav1.ReadRequest{StoreId: storeID})
+	require.NoError(t, err)
+	require.Len(t, readResp.GetTuples(), 1)
+
+	s.checkResolver = originalCheckResolver
+	checkResp, err := s.Check(ctx, checkReq)
+	require.NoError(t, err)
+	require.True(t, checkResp.GetAllowed())
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	writable := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(writable.Close)
+
+	createResp, err := writable.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "read-only"})
+	require.NoError(t, err)
+	storeID := createResp.GetId()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`)
+	writeModelResp, err := writable.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+	modelID := writeModelResp.GetAuthorizationModelId()
+
+	_, err = writable.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = writable.WriteAssertions(ctx, &openfgav1.WriteAssertionsRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: modelID,
+		Assertions: []*openfgav1.Assertion{{
+			TupleKey:    tuple.NewAssertionTupleKey("document:1", "viewer", "user:anne"),
+			Expectation: true,
+		}},
+	})
+	require.NoError(t, err)
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithReadOnlyMode(true))
+	t.Cleanup(s.Close)
+
+	t.Run("serves_reads", func(t *testing.T) {
+		ready, err := s.IsReady(ctx)
+		require.NoError(t, err)
+		require.True(t, ready)
+
+		checkResp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.True(t, checkResp.GetAllowed())
+
+		readResp, err := s.Read(ctx, &openfgav1.ReadRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, readResp.GetTuples(), 1)
+
+		expandResp, err := s.Expand(ctx, &openfgav1.ExpandRequest{
+			StoreId:  storeID,
+			TupleKey: &openfgav1.ExpandRequestTupleKey{Object: "document:1", Relation: "viewer"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"user:anne"}, expandResp.GetTree().GetRoot().GetLeaf().GetUsers().GetUsers())
+
+		listObjectsResp, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+			StoreId:  storeID,
+			Type:     "document",
+			Relation: "viewer",
+			User:     "user:anne",
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"document:1"}, listObjectsResp.GetObjects())
+
+		listUsersResp, err := s.ListUsers(ctx, &openfgav1.ListUsersRequest{
+			StoreId:     storeID,
+			Object:      &openfgav1.Object{Type: "document", Id: "1"},
+			Relation:    "viewer",
+			UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, listUsersResp.GetUsers(), 1)
+
+		changesResp, err := s.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, changesResp.GetChanges(), 1)
+
+		modelResp, err := s.ReadAuthorizationModel(ctx, &openfgav1.ReadAuthorizationModelRequest{StoreId: storeID, Id: modelID})
+		require.NoError(t, err)
+		require.Equal(t, modelID, modelResp.GetAuthorizationModel().GetId())
+
+		modelsResp, err := s.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, modelsResp.GetAuthorizationModels(), 1)
+
+		assertionsResp, err := s.ReadAssertions(ctx, &openfgav1.ReadAssertionsRequest{StoreId: storeID, AuthorizationModelId: modelID})
+		require.NoError(t, err)
+		require.Len(t, assertionsResp.GetAssertions(), 1)
+
+		_, err = s.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: storeID})
+		require.NoError(t, err)
+
+		storesResp, err := s.ListStores(ctx, &openfgav1.ListStoresRequest{})
+		require.NoError(t, err)
+		require.Len(t, storesResp.GetStores(), 1)
+	})
+
+	t.Run("rejects_writes", func(t *testing.T) {
+		_, err := s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:2", "viewer", "user:anne")},
+			},
+		})
+		require.ErrorIs(t, err, serverErrors.ServerReadOnly)
+
+		_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		require.ErrorIs(t, err, serverErrors.ServerReadOnly)
+
+		_, err = s.WriteAssertions(ctx, &openfgav1.WriteAssertionsRequest{StoreId: storeID, AuthorizationModelId: modelID})
+		require.ErrorIs(t, err, serverErrors.ServerReadOnly)
+
+		_, err = s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "another"})
+		require.ErrorIs(t, err, serverErrors.ServerReadOnly)
+
+		_, err = s.DeleteStore(ctx, &openfgav1.DeleteStoreRequest{StoreId: storeID})
+		require.ErrorIs(t, err, serverErrors.ServerReadOnly)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		readResp, err := s.Read(ctx, &openfgav1.ReadRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, readResp.GetTuples(), 1)
+
+		storesResp, err := s.ListStores(ctx, &openfgav1.ListStoresRequest{})
+		require.NoError(t, err)
+		require.Len(t, storesResp.GetStores(), 1)
+	})
+}
+
+func TestListStoresTotalCount(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+	includeCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(ListStoresIncludeTotalCountHeader, "true"))
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	transport := &headerRecordingTransport{}
+	s := MustNewServerWithOpts(WithDatastore(ds), WithTransport(transport))
+	t.Cleanup(s.Close)
+
+	for _, name := range []string{"one", "two", "three"} {
+		_, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: name})
+		require.NoError(t, err)
+	}
+
+	t.Run("not_included_by_default", func(t *testing.T) {
+		resp, err := s.ListStores(ctx, &openfgav1.ListStoresRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.GetStores(), 3)
+
+		_, ok := transport.header(ListStoresTotalCountHeader)
+		require.False(t, ok)
+	})
+
+	t.Run("counts_all_the_pages", func(t *testing.T) {
+		resp, err := s.ListStores(includeCtx, &openfgav1.ListStoresRequest{PageSize: wrapperspb.Int32(1)})
+		require.NoError(t, err)
+		require.Len(t, resp.GetStores(), 1)
+		require.NotEmpty(t, resp.GetContinuationToken())
+
+		count, ok := transport.header(ListStoresTotalCountHeader)
+		require.True(t, ok)
+		require.Equal(t, "3", count)
+		estimated, ok := transport.header(ListStoresTotalCountEstimatedHeader)
+		require.True(t, ok)
+		require.Equal(t, "false", estimated)
+	})
+
+	t.Run("unsupported_datastore", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().Close().AnyTimes()
+		s := MustNewServerWithOpts(WithDatastore(mockDatastore))
+		t.Cleanup(s.Close)
+
+		_, err := s.ListStores(includeCtx, &openfgav1.ListStoresRequest{})
+		require.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
+
+func TestReadChangesHorizonHeaders(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")}))
+
+	transport := &headerRecordingTransport{}
+	s := MustNewServerWithOpts(WithDatastore(ds), WithTransport(transport), WithChangelogHorizonOffset(5), WithReadChangesHorizonHeaders(true))
+	t.Cleanup(s.Close)
+
+	resp, err := s.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+	require.NoError(t, err)
+	require.Empty(t, resp.GetChanges())
+
+	horizon, ok := transport.header(ReadChangesHorizonHeader)
+	require.True(t, ok)
+	_, err = time.Parse(time.RFC3339Nano, horizon)
+	require.NoError(t, err)
+
+	withheld, ok := transport.header(ReadChangesNewestWithheldChangeHeader)
+	require.True(t, ok)
+	_, err = time.Parse(time.RFC3339Nano, withheld)
+	require.NoError(t, err)
+}
+
+// readChangesWatchStream records the responses and the trailer of a StreamedReadChanges stream.
+type readChangesWatchStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses chan *openfgav1.ReadChangesResponse
+
+	mu      sync.Mutex
+	trailer metadata.MD
+}
+
+func (x *readChangesWatchStream) SetTrailer(md metadata.MD) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.trailer = metadata.Join(x.trailer, md)
+}
+
+func (x *readChangesWatchStream) trailerValue(key string) string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if values := x.trailer.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (x *readChangesWatchStream) Context() context.Context {
+	return x.ctx
+}
+
+func (x *readChangesWatchStream) Send(m *openfgav1.ReadChangesResponse) error {
+	select {
+	case x.responses <- m:
+		return nil
+	case <-x.ctx.Done():
+		return x.ctx.Err()
+	}
+}
+
+func TestStreamedReadChanges(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds),
+		WithReadChangesWatchPollInterval(10*time.Millisecond),
+		WithReadChangesWatchHeartbeatInterval(50*time.Millisecond),
+	)
+	t.Cleanup(s.Close)
+
+	watchWith := func(s *Server, ctx context.Context, req *openfgav1.ReadChangesRequest) (*readChangesWatchStream, chan error) {
+		stream := &readChangesWatchStream{ctx: ctx, responses: make(chan *openfgav1.ReadChangesResponse)}
+		done := make(chan error, 1)
+		go func() {
+			done <- s.StreamedReadChanges(req, stream)
+		}()
+		return stream, done
+	}
+
+	watch := func(ctx context.Context, req *openfgav1.ReadChangesRequest) (chan *openfgav1.ReadChangesResponse, chan error) {
+		stream, done := watchWith(s, ctx, req)
+		return stream.responses, done
+	}
+
+	// nextChanges skips the heartbeats until a response with changes
+	nextChanges := func(t *testing.T, responses chan *openfgav1.ReadChangesResponse) *openfgav1.ReadChangesResponse {
+		for {
+			select {
+			case resp := <-responses:
+				if len(resp.GetChanges()) > 0 {
+					return resp
+				}
+			case <-time.After(5 * time.Second):
+				require.FailNow(t, "no changes received")
+			}
+		}
+	}
+
+	keys := func(resp *openfgav1.ReadChangesResponse) []string {
+		var keys []string
+		for _, change := range resp.GetChanges() {
+			keys = append(keys, tuple.TupleKeyToString(change.GetTupleKey()))
+		}
+		return keys
+	}
+
+	t.Run("tails_the_changes_of_a_type", func(t *testing.T) {
+		storeID := ulid.Make().String()
+		require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+			tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+		}))
+
+		ctx, cancel := context.WithCancel(ctx)
+		responses, done := watch(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID, Type: "document"})
+
+		require.Equal(t, []string{"document:1#viewer@user:anne"}, keys(nextChanges(t, responses)))
+
+		require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("folder:2", "viewer", "user:bob"),
+			tuple.NewTupleKey("document:2", "viewer", "user:bob"),
+		}))
+		resp := nextChanges(t, responses)
+		require.Equal(t, []string{"document:2#viewer@user:bob"}, keys(resp))
+
+		cancel()
+		require.NoError(t, <-done)
+
+		t.Run("resumes_from_the_continuation_token_with_heartbeats", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			responses, done := watch(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID, Type: "document", ContinuationToken: resp.GetContinuationToken()})
+
+			heartbeat := <-responses
+			require.Empty(t, heartbeat.GetChanges())
+			require.Equal(t, resp.GetContinuationToken(), heartbeat.GetContinuationToken())
+
+			cancel()
+			require.NoError(t, <-done)
+		})
+	})
+
+	t.Run("start_time", func(t *testing.T) {
+		storeID := ulid.Make().String()
+		require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")}))
+		time.Sleep(2 * time.Millisecond)
+		startTime := time.Now()
+		require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:2", "viewer", "user:anne")}))
+
+		ctx, cancel := context.WithCancel(metadata.NewIncomingContext(ctx, metadata.Pairs(ReadChangesStartTimeHeader, startTime.Format(time.RFC3339Nano))))
+		defer cancel()
+		responses, done := watch(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+		require.Equal(t, []string{"document:2#viewer@user:anne"}, keys(nextChanges(t, responses)))
+
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	t.Run("sends_the_continuation_token_in_the_trailer", func(t *testing.T) {
+		storeID := ulid.Make().String()
+		require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")}))
+
+		ctx, cancel := context.WithCancel(ctx)
+		stream, done := watchWith(s, ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+		resp := nextChanges(t, stream.responses)
+
+		cancel()
+		require.NoError(t, <-done)
+		require.Equal(t, resp.GetContinuationToken(), stream.trailerValue(ReadChangesContinuationTokenHeader))
+	})
+
+	t.Run("max_stream_duration", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds),
+			WithReadChangesWatchPollInterval(10*time.Millisecond),
+			WithMaxStreamDuration(100*time.Millisecond),
+		)
+		t.Cleanup(s.Close)
+
+		storeID := ulid.Make().String()
+		require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")}))
+
+		stream, done := watchWith(s, ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+		resp := nextChanges(t, stream.responses)
+
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "the stream did not end at its maximum duration")
+		}
+		require.Equal(t, "max_stream_duration", stream.trailerValue(StreamTruncatedHeader))
+		require.Equal(t, resp.GetContinuationToken(), stream.trailerValue(ReadChangesContinuationTokenHeader))
+	})
+
+	t.Run("max_streams", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds),
+			WithReadChangesWatchPollInterval(10*time.Millisecond),
+			WithReadChangesWatchMaxStreams(1),
+		)
+		t.Cleanup(s.Close)
+
+		storeID := ulid.Make().String()
+		require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")}))
+
+		ctx, cancel := context.WithCancel(ctx)
+		stream, done := watchWith(s, ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+		nextChanges(t, stream.responses)
+
+		_, rejected := watchWith(s, ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+		require.Equal(t, codes.ResourceExhausted, status.Code(<-rejected))
+
+		cancel()
+		require.NoError(t, <-done)
+
+		// the slot of the ended stream is free again
+		ctx, cancel = context.WithCancel(context.Background())
+		stream, done = watchWith(s, ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+		nextChanges(t, stream.responses)
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	t.Run("invalid_store_id", func(t *testing.T) {
+		_, done := watch(ctx, &openfgav1.ReadChangesRequest{StoreId: "invalid"})
+		require.Equal(t, codes.InvalidArgument, status.Code(<-done))
+	})
+
+	t.Run("invalid_poll_interval", func(t *testing.T) {
+		_, err := NewServerWithOpts(WithDatastore(ds), WithReadChangesWatchPollInterval(0))
+		require.ErrorContains(t, err, "StreamedReadChanges poll interval must be greater than 0")
+	})
+}
+
+func TestGetRelationStatistics(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithRelationStatisticsLimits(4, time.Second))
+	t.Cleanup(s.Close)
+
+	createResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "relation-statistics"})
+	require.NoError(t, err)
+	storeID := createResp.GetId()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define owner: [user]
+				define viewer: [user]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	// document:1 has 1 viewer, document:2 has 2 and document:3 has 3
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+				tuple.NewTupleKey("document:1", "owner", "user:anne"),
+				tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+				tuple.NewTupleKey("document:2", "viewer", "user:bob"),
+				tuple.NewTupleKey("document:3", "viewer", "user:anne"),
+				tuple.NewTupleKey("document:3", "viewer", "user:bob"),
+				tuple.NewTupleKey("document:3", "viewer", "user:charlie"),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("counts_are_exact_beyond_the_sample", func(t *testing.T) {
+		stats, err := s.GetRelationStatistics(ctx, &commands.RelationStatisticsRequest{
+			StoreID:    storeID,
+			ObjectType: "document",
+			Relation:   "viewer",
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, 6, stats.TupleCount)
+		require.EqualValues(t, 3, stats.DistinctObjects)
+		require.EqualValues(t, 3, stats.DistinctUsers)
+		require.True(t, stats.Exact)
+		require.Equal(t, 4, stats.SampleSize)
+
+		// the sample holds the first 4 tuples: the viewers of document:1 and document:2, and
+		// one of the viewers of document:3
+		require.Equal(t, []commands.UsersPerObjectBucket{
+			{Min: 1, Max: 1, Objects: 2},
+			{Min: 2, Max: 3, Objects: 1},
+		}, stats.UsersPerObject)
+	})
+
+	t.Run("sample_without_counter", func(t *testing.T) {
+		q := commands.NewRelationStatisticsQuery(ds, commands.WithRelationStatisticsMaxSampleSize(100))
+		stats, err := q.Execute(ctx, &commands.RelationStatisticsRequest{
+			StoreID:    storeID,
+			ObjectType: "document",
+			Relation:   "viewer",
+		})
+		require.NoError(t, err)
+		require.Equal(t, &commands.RelationStatistics{
+			TupleCount:      6,
+			DistinctObjects: 3,
+			DistinctUsers:   3,
+			Exact:           true,
+			UsersPerObject: []commands.UsersPerObjectBucket{
+				{Min: 1, Max: 1, Objects: 1},
+				{Min: 2, Max: 3, Objects: 2},
+			},
+			SampleSize: 6,
+		}, stats)
+
+		q = commands.NewRelationStatisticsQuery(ds, commands.WithRelationStatisticsMaxSampleSize(2))
+		stats, err = q.Execute(ctx, &commands.RelationStatisticsRequest{
+			StoreID:    storeID,
+			ObjectType: "document",
+			Relation:   "viewer",
+		})
+		require.NoError(t, err)
+		require.False(t, stats.Exact)
+		require.EqualValues(t, 2, stats.TupleCount)
+		require.Equal(t, 2, stats.SampleSize)
+	})
+
+	t.Run("unknown_relation", func(t *testing.T) {
+		_, err := s.GetRelationStatistics(ctx, &commands.RelationStatisticsRequest{
+			StoreID:    storeID,
+			ObjectType: "document",
+			Relation:   "editor",
+		})
+		require.Error(t, err)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_relation_not_found), status.Code(err))
+	})
+
+	t.Run("remote_caller", func(t *testing.T) {
+		remoteCtx := peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+		_, err := s.GetRelationStatistics(remoteCtx, &commands.RelationStatisticsRequest{
+			StoreID:    storeID,
+			ObjectType: "document",
+			Relation:   "viewer",
+		})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("grpc_method", func(t *testing.T) {
+		req, err := structpb.NewStruct(map[string]interface{}{
+			relationstats.StoreIDField:    storeID,
+			relationstats.ObjectTypeField: "document",
+			relationstats.RelationField:   "viewer",
+		})
+		require.NoError(t, err)
+
+		resp, err := s.ReadRelationStatistics(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, float64(6), resp.GetFields()["tuple_count"].GetNumberValue())
+		require.True(t, resp.GetFields()["exact"].GetBoolValue())
+
+		req.Fields["objecttype"] = structpb.NewStringValue("document")
+		_, err = s.ReadRelationStatistics(ctx, req)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("admin_handler", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/admin/stores/"+storeID+"/relation-statistics?object_type=document&relation=viewer", nil)
+		r.RemoteAddr = "127.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		NewAdminHandler(s).ServeHTTP(rec, r)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var stats commands.RelationStatistics
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+		require.EqualValues(t, 6, stats.TupleCount)
+		require.Equal(t, 4, stats.SampleSize)
+	})
+
+	t.Run("admin_handler_remote_caller", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/admin/stores/"+storeID+"/relation-statistics?object_type=document&relation=viewer", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		NewAdminHandler(s).ServeHTTP(rec, r)
+		require.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
+
+func TestGetRelationUsage(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	createResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "relation-usage"})
+	require.NoError(t, err)
+	storeID := createResp.GetId()
+
+	writeModel := func(dsl string) string {
+		model := testutils.MustTransformDSLToProtoWithID(dsl)
+		resp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+		return resp.GetAuthorizationModelId()
+	}
+
+	writeModel(`
+		model
+			schema 1.1
+		type user
+		type folder
+			relations
+				define viewer: [user]
+		type document
+			relations
+				define editor: [user]
+				define owner: [user]
+				define viewer: [user]`)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+				tuple.NewTupleKey("document:1", "editor", "user:anne"),
+				tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+				tuple.NewTupleKey("document:2", "viewer", "user:bob"),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	// the latest model drops the folder type and the editor relation
+	modelID := writeModel(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define owner: [user]
+				define viewer: [user]`)
+
+	expected := &commands.RelationUsageReport{
+		AuthorizationModelID: modelID,
+		Relations: []commands.RelationUsage{
+			{ObjectType: "document", Relation: "owner"},
+			{ObjectType: "document", Relation: "viewer", HasTuples: true, TupleCount: 2},
+		},
+		Orphans: []commands.RelationUsage{
+			{ObjectType: "document", Relation: "editor", HasTuples: true, TupleCount: 1},
+			{ObjectType: "folder", Relation: "viewer", HasTuples: true, TupleCount: 1},
+		},
+		Exact: true,
+	}
+
+	t.Run("counts_and_orphans", func(t *testing.T) {
+		report, err := s.GetRelationUsage(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, expected, report)
+	})
+
+	t.Run("read_without_counter", func(t *testing.T) {
+		typesys, err := s.resolveTypesystem(ctx, storeID, "")
+		require.NoError(t, err)
+
+		report, err := commands.NewRelationUsageQuery(ds).Execute(ctx, storeID, typesys)
+		require.NoError(t, err)
+		require.Equal(t, expected, report)
+	})
+
+	t.Run("invalid_store_id", func(t *testing.T) {
+		_, err := s.GetRelationUsage(ctx, "invalid")
+		require.ErrorIs(t, err, serverErrors.InvalidStoreID)
+	})
+
+	t.Run("remote_caller", func(t *testing.T) {
+		remoteCtx := peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+		_, err := s.GetRelationUsage(remoteCtx, storeID)
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("admin_handler", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/admin/stores/"+storeID+"/relation-usage", nil)
+		r.RemoteAddr = "127.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		NewAdminHandler(s).ServeHTTP(rec, r)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var report commands.RelationUsageReport
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		require.Equal(t, expected, &report)
+	})
+
+	t.Run("admin_handler_remote_caller", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/admin/stores/"+storeID+"/relation-usage", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		NewAdminHandler(s).ServeHTTP(rec, r)
+		require.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
+
+func TestAdviseIndexes(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	t.Run("datastore_without_advisor", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		s := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(s.Close)
+
+		_, err := s.AdviseIndexes(ctx)
+		require.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+
+	t.Run("sampling_disabled", func(t *testing.T) {
+		testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+		ds, err := sqlite.New(testDatastore.GetConnectionURI(true), sqlcommon.NewConfig())
+		require.NoError(t, err)
+		t.Cleanup(ds.Close)
+
+		s := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(s.Close)
+
+		_, err = s.AdviseIndexes(ctx)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("sampling_enabled", func(t *testing.T) {
+		testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+		ds, err := sqlite.New(testDatastore.GetConnectionURI(true), sqlcommon.NewConfig(sqlcommon.WithQueryShapeSampling(true)))
+		require.NoError(t, err)
+		t.Cleanup(ds.Close)
+
+		s := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(s.Close)
+
+		for i := 0; i < sqlcommon.MinIndexAdviceQueries; i++ {
+			iter, err := ds.ReadUsersetTuples(ctx, ulid.Make().String(), storage.ReadUsersetTuplesFilter{
+				Object:                      "document:1",
+				Relation:                    "viewer",
+				AllowedUserTypeRestrictions: []*openfgav1.RelationReference{typesystem.DirectRelationReference("group", "member")},
+			}, storage.ReadUsersetTuplesOptions{})
+			require.NoError(t, err)
+			iter.Stop()
+		}
+
+		advice, err := s.AdviseIndexes(ctx)
+		require.NoError(t, err)
+		require.Len(t, advice.Shapes, 1)
+		require.Len(t, advice.Recommendations, 1)
+		require.Equal(t, "idx_tuple_userset_type", advice.Recommendations[0].Name)
+
+		t.Run("remote_caller", func(t *testing.T) {
+			remoteCtx := peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+			_, err := s.AdviseIndexes(remoteCtx)
+			require.Equal(t, codes.PermissionDenied, status.Code(err))
+		})
+
+		t.Run("admin_handler", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/index-advice", nil)
+			req.RemoteAddr = "127.0.0.1:1234"
+			rec := httptest.NewRecorder()
+			NewAdminHandler(s).ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var resp storage.IndexAdvice
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.Equal(t, advice.Recommendations, resp.Recommendations)
+			require.Equal(t, advice.Shapes, resp.Shapes)
+		})
+
+		t.Run("admin_handler_remote_caller", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/index-advice", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			rec := httptest.NewRecorder()
+			NewAdminHandler(s).ServeHTTP(rec, req)
+			require.Equal(t, http.StatusForbidden, rec.Code)
+		})
+
+		t.Run("grpc_method", func(t *testing.T) {
+			resp, err := s.GetIndexAdvice(ctx, &emptypb.Empty{})
+			require.NoError(t, err)
+			require.Equal(t, "idx_tuple_userset_type",
+				resp.GetFields()["recommendations"].GetListValue().GetValues()[0].GetStructValue().GetFields()["name"].GetStringValue())
+		})
+	})
+}
+
+func TestDeleteTuplesByFilter(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	setup := func(t *testing.T, opts ...OpenFGAServiceV1Option) (*Server, storage.OpenFGADatastore, string) {
+		ds := memory.New(memory.WithMaxTuplesPerWrite(2))
+		t.Cleanup(ds.Close)
+
+		s := MustNewServerWithOpts(append([]OpenFGAServiceV1Option{WithDatastore(ds)}, opts...)...)
+		t.Cleanup(s.Close)
+
+		store, err := ds.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: "repair"})
+		require.NoError(t, err)
+
+		err = ds.Write(ctx, store.GetId(), nil, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "editor", "service:old-bot-1"),
+			tuple.NewTupleKey("document:2", "editor", "service:old-bot-1"),
+			tuple.NewTupleKey("document:3", "editor", "service:old-bot-2"),
+			tuple.NewTupleKey("folder:1", "editor", "service:old-bot-2"),
+			tuple.NewTupleKey("document:1", "viewer", "service:old-bot-1"),
+			tuple.NewTupleKey("document:1", "editor", "user:anne"),
+		})
+		require.NoError(t, err)
+		return s, ds, store.GetId()
+	}
+
+	remaining := func(t *testing.T, ds storage.OpenFGADatastore, storeID string) []string {
+		tuples, _, err := ds.ReadPage(ctx, storeID, &openfgav1.TupleKey{}, storage.ReadPageOptions{
+			Pagination: storage.NewPaginationOptions(100, ""),
+		})
+		require.NoError(t, err)
+
+		keys := make([]string, 0, len(tuples))
+		for _, t := range tuples {
+			keys = append(keys, tuple.TupleKeyToString(t.GetKey()))
+		}
+		slices.Sort(keys)
+		return keys
+	}
+
+	req := func(storeID string) *commands.DeleteTuplesByFilterRequest {
+		return &commands.DeleteTuplesByFilterRequest{
+			StoreID:    storeID,
+			TupleKey:   &openfgav1.ReadRequestTupleKey{Relation: "editor"},
+			UserPrefix: "service:old-bot",
+		}
+	}
+
+	t.Run("deletes_in_batches_through_the_changelog", func(t *testing.T) {
+		s, ds, storeID := setup(t)
+
+		var progress []commands.DeleteTuplesByFilterResponse
+		r := req(storeID)
+		r.Progress = func(resp commands.DeleteTuplesByFilterResponse) {
+			progress = append(progress, resp)
+		}
+		resp, err := s.DeleteTuplesByFilter(ctx, r)
+		require.NoError(t, err)
+		require.Equal(t, &commands.DeleteTuplesByFilterResponse{Matched: 4, Deleted: 4, Scanned: 6}, resp)
+		require.Equal(t, []commands.DeleteTuplesByFilterResponse{
+			{Matched: 2, Deleted: 2, Scanned: 4},
+			{Matched: 4, Deleted: 4, Scanned: 6},
+		}, progress)
+
+		require.Equal(t, []string{
+			"document:1#editor@user:anne",
+			"document:1#viewer@service:old-bot-1",
+		}, remaining(t, ds, storeID))
+
+		changes, _, err := ds.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.ReadChangesOptions{})
+		require.NoError(t, err)
+		var deletes []string
+		for _, change := range changes {
+			if change.GetOperation() == openfgav1.TupleOperation_TUPLE_OPERATION_DELETE {
+				deletes = append(deletes, tuple.TupleKeyToString(change.GetTupleKey()))
+			}
+		}
+		slices.Sort(deletes)
+		require.Equal(t, []string{
+			"document:1#editor@service:old-bot-1",
+			"document:2#editor@service:old-bot-1",
+			"document:3#editor@service:old-bot-2",
+			"folder:1#editor@service:old-bot-2",
+		}, deletes)
+	})
+
+	t.Run("dry_run", func(t *testing.T) {
+		s, ds, storeID := setup(t, WithReadOnlyMode(true))
+
+		r := req(storeID)
+		r.DryRun = true
+		resp, err := s.DeleteTuplesByFilter(ctx, r)
+		require.NoError(t, err)
+		require.Equal(t, &commands.DeleteTuplesByFilterResponse{Matched: 4, Scanned: 6}, resp)
+		require.Len(t, remaining(t, ds, storeID), 6)
+
+		_, err = s.DeleteTuplesByFilter(ctx, req(storeID))
+		require.ErrorIs(t, err, serverErrors.ServerReadOnly)
+	})
+
+	t.Run("max_tuples", func(t *testing.T) {
+		s, ds, storeID := setup(t, WithDeleteTuplesByFilterMaxTuples(3))
+
+		resp, err := s.DeleteTuplesByFilter(ctx, req(storeID))
+		require.NoError(t, err)
+		require.Equal(t, &commands.DeleteTuplesByFilterResponse{Matched: 3, Deleted: 3, Scanned: 6, Truncated: true}, resp)
+		require.Len(t, remaining(t, ds, storeID), 3)
+
+		resp, err = s.DeleteTuplesByFilter(ctx, req(storeID))
+		require.NoError(t, err)
+		require.Equal(t, &commands.DeleteTuplesByFilterResponse{Matched: 1, Deleted: 1, Scanned: 3}, resp)
+		require.Len(t, remaining(t, ds, storeID), 2)
+	})
+
+	t.Run("object_type_filter", func(t *testing.T) {
+		s, ds, storeID := setup(t)
+
+		resp, err := s.DeleteTuplesByFilter(ctx, &commands.DeleteTuplesByFilterRequest{
+			StoreID:  storeID,
+			TupleKey: &openfgav1.ReadRequestTupleKey{Object: "document:", User: "service:old-bot-1"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, resp.Deleted)
+		// only the tuples of the object type and the user are read
+		require.Equal(t, 3, resp.Scanned)
+		require.Len(t, remaining(t, ds, storeID), 3)
+	})
+
+	t.Run("max_scanned", func(t *testing.T) {
+		s, ds, storeID := setup(t, WithDeleteTuplesByFilterMaxScanned(3))
+
+		resp, err := s.DeleteTuplesByFilter(ctx, req(storeID))
+		require.NoError(t, err)
+		require.Equal(t, 4, resp.Scanned)
+		require.True(t, resp.Truncated)
+		require.NotEmpty(t, resp.ContinuationToken)
+
+		r := req(storeID)
+		r.ContinuationToken = resp.ContinuationToken
+		resp, err = s.DeleteTuplesByFilter(ctx, r)
+		require.NoError(t, err)
+		require.Equal(t, 2, resp.Scanned)
+		require.False(t, resp.Truncated)
+		require.Empty(t, resp.ContinuationToken)
+
+		require.Equal(t, []string{
+			"document:1#editor@user:anne",
+			"document:1#viewer@service:old-bot-1",
+		}, remaining(t, ds, storeID))
+	})
+
+	t.Run("empty_filter", func(t *testing.T) {
+		s, _, storeID := setup(t)
+
+		_, err := s.DeleteTuplesByFilter(ctx, &commands.DeleteTuplesByFilterRequest{StoreID: storeID})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+	})
+
+	t.Run("remote_caller", func(t *testing.T) {
+		s, _, storeID := setup(t)
+
+		remoteCtx := peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+		_, err := s.DeleteTuplesByFilter(remoteCtx, req(storeID))
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("admin_handler", func(t *testing.T) {
+		s, ds, storeID := setup(t)
+		handler := NewAdminHandler(s, WithAdminToken("secret"))
+
+		newRequest := func(body string) *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "/admin/stores/"+storeID+"/delete-tuples", strings.NewReader(body))
+			r.RemoteAddr = "127.0.0.1:1234"
+			r.Header.Set("Authorization", "Bearer secret")
+			return r
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newRequest(`{"tuple_key": {"relation": "editor"}, "user_prefix": "service:old-bot", "dry_run": true}`))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp commands.DeleteTuplesByFilterResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, 4, resp.Matched)
+		require.Zero(t, resp.Deleted)
+		require.Len(t, remaining(t, ds, storeID), 6)
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, newRequest("{"))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("admin_handler_requires_the_token", func(t *testing.T) {
+		s, ds, storeID := setup(t)
+		body := `{"tuple_key": {"relation": "editor"}, "user_prefix": "service:old-bot"}`
+
+		for name, test := range map[string]struct {
+			handler       http.Handler
+			authorization string
+		}{
+			"no_token_configured": {handler: NewAdminHandler(s), authorization: "Bearer "},
+			"missing_token":       {handler: NewAdminHandler(s, WithAdminToken("secret"))},
+			"wrong_token":         {handler: NewAdminHandler(s, WithAdminToken("secret")), authorization: "Bearer other"},
+		} {
+			r := httptest.NewRequest(http.MethodPost, "/admin/stores/"+storeID+"/delete-tuples", strings.NewReader(body))
+			r.RemoteAddr = "127.0.0.1:1234"
+			if test.authorization != "" {
+				r.Header.Set("Authorization", test.authorization)
+			}
+			rec := httptest.NewRecorder()
+			test.handler.ServeHTTP(rec, r)
+			require.Equal(t, http.StatusForbidden, rec.Code, name)
+		}
+		require.Len(t, remaining(t, ds, storeID), 6)
+	})
+
+	t.Run("admin_handler_remote_caller", func(t *testing.T) {
+		s, ds, storeID := setup(t)
+
+		r := httptest.NewRequest(http.MethodPost, "/admin/stores/"+storeID+"/delete-tuples", strings.NewReader(`{"tuple_key": {"relation": "editor"}}`))
+		r.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		NewAdminHandler(s, WithAdminToken("secret")).ServeHTTP(rec, r)
+		require.Equal(t, http.StatusForbidden, rec.Code)
+		require.Len(t, remaining(t, ds, storeID), 6)
+	})
+}
+
+func TestGetReachableRelations(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	createResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "reachable-relations"})
+	require.NoError(t, err)
+	storeID := createResp.GetId()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type group
+			relations
+				define member: [user]
+		type document
+			relations
+				define viewer: [user:*, group#member]`)
+	writeResp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+	modelID := writeResp.GetAuthorizationModelId()
+
+	expected := &ReachableRelations{
+		AuthorizationModelID: modelID,
+		UserType:             "user",
+		Relations: []typesystem.ReachableRelation{
+			{ObjectType: "document", Relation: "viewer", Paths: []typesystem.RelationPath{
+				{Kind: typesystem.RelationPathDirect, Wildcard: true},
+				{Kind: typesystem.RelationPathUserset, Via: "group#member"},
+			}},
+			{ObjectType: "group", Relation: "member", Paths: []typesystem.RelationPath{
+				{Kind: typesystem.RelationPathDirect},
+			}},
+		},
+	}
+
+	t.Run("latest_model", func(t *testing.T) {
+		relations, err := s.GetReachableRelations(ctx, storeID, "", "user")
+		require.NoError(t, err)
+		require.Equal(t, expected, relations)
+	})
+
+	t.Run("given_model", func(t *testing.T) {
+		relations, err := s.GetReachableRelations(ctx, storeID, modelID, "user")
+		require.NoError(t, err)
+		require.Equal(t, expected, relations)
+	})
+
+	t.Run("unknown_user_type", func(t *testing.T) {
+		_, err := s.GetReachableRelations(ctx, storeID, "", "team")
+		require.ErrorIs(t, err, serverErrors.TypeNotFound("team"))
+	})
+
+	t.Run("missing_user_type", func(t *testing.T) {
+		_, err := s.GetReachableRelations(ctx, storeID, "", "")
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("invalid_model_id", func(t *testing.T) {
+		_, err := s.GetReachableRelations(ctx, storeID, "invalid", "user")
+		require.ErrorIs(t, err, serverErrors.InvalidAuthorizationModelID)
+	})
+
+	t.Run("admin_handler", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewAdminHandler(s).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/stores/"+storeID+"/reachable-relations?user_type=user", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var relations ReachableRelations
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &relations))
+		require.Equal(t, expected, &relations)
+	})
+}
+
+func TestModelAlias(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	transport := &headerRecordingTransport{}
+	s := MustNewServerWithOpts(WithDatastore(ds), WithTransport(transport))
+	t.Cleanup(s.Close)
+
+	createResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "model-alias"})
+	require.NoError(t, err)
+	storeID := createResp.GetId()
+
+	writeModel := func(dsl string) string {
+		model := testutils.MustTransformDSLToProtoWithID(dsl)
+		resp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+		return resp.GetAuthorizationModelId()
+	}
+
+	productionModelID := writeModel(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`)
+	latestModelID := writeModel(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define editor: [user]
+				define viewer: [user] or editor`)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+		},
+	})
+	require.NoError(t, err)
+
+	err = s.WriteModelAlias(ctx, &commands.ModelAlias{StoreID: storeID, Alias: "production", AuthorizationModelID: productionModelID})
+	require.NoError(t, err)
+
+	alias, err := s.ReadModelAlias(ctx, storeID, "production")
+	require.NoError(t, err)
+	require.Equal(t, productionModelID, alias.AuthorizationModelID)
+
+	aliasCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(AuthorizationModelAliasHeader, "production"))
+	checkWithAlias := func() {
+		_, err := s.Check(aliasCtx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("requests_resolve_the_alias", func(t *testing.T) {
+		checkWithAlias()
+		modelID, _ := transport.header(AuthorizationModelIDHeader)
+		require.Equal(t, productionModelID, modelID)
+
+		_, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		modelID, _ = transport.header(AuthorizationModelIDHeader)
+		require.Equal(t, latestModelID, modelID)
+	})
+
+	t.Run("model_id_takes_precedence", func(t *testing.T) {
+		_, err := s.Check(aliasCtx, &openfgav1.CheckRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: latestModelID,
+			TupleKey:             tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		modelID, _ := transport.header(AuthorizationModelIDHeader)
+		require.Equal(t, latestModelID, modelID)
+	})
+
+	t.Run("update_invalidates_the_cached_alias", func(t *testing.T) {
+		err := s.WriteModelAlias(ctx, &commands.ModelAlias{StoreID: storeID, Alias: "production", AuthorizationModelID: latestModelID})
+		require.NoError(t, err)
+
+		checkWithAlias()
+		modelID, _ := transport.header(AuthorizationModelIDHeader)
+		require.Equal(t, latestModelID, modelID)
+	})
+
+	t.Run("unknown_alias", func(t *testing.T) {
+		_, err := s.Check(metadata.NewIncomingContext(ctx, metadata.Pairs(AuthorizationModelAliasHeader, "staging")), &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("invalid_alias", func(t *testing.T) {
+		err := s.WriteModelAlias(ctx, &commands.ModelAlias{StoreID: storeID, Alias: "Production", AuthorizationModelID: latestModelID})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+	})
+
+	t.Run("unknown_model", func(t *testing.T) {
+		err := s.WriteModelAlias(ctx, &commands.ModelAlias{StoreID: storeID, Alias: "staging", AuthorizationModelID: ulid.Make().String()})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_authorization_model_not_found), status.Code(err))
+	})
+
+	t.Run("read_only", func(t *testing.T) {
+		readOnly := MustNewServerWithOpts(WithDatastore(ds), WithReadOnlyMode(true))
+		t.Cleanup(readOnly.Close)
+
+		err := readOnly.WriteModelAlias(ctx, &commands.ModelAlias{StoreID: storeID, Alias: "production", AuthorizationModelID: productionModelID})
+		require.ErrorIs(t, err, serverErrors.ServerReadOnly)
+	})
+}
+
+func TestModelAliasCache(t *testing.T) {
+	t.Run("evicts_the_least_recently_used_alias", func(t *testing.T) {
+		c := newModelAliasCache()
+		c.maxEntries = 2
+
+		c.set("store", "a", "model-a")
+		c.set("store", "b", "model-b")
+		_, ok := c.get("store", "a")
+		require.True(t, ok)
+
+		c.set("store", "c", "model-c")
+		_, ok = c.get("store", "b")
+		require.False(t, ok)
+		modelID, ok := c.get("store", "a")
+		require.True(t, ok)
+		require.Equal(t, "model-a", modelID)
+		modelID, ok = c.get("store", "c")
+		require.True(t, ok)
+		require.Equal(t, "model-c", modelID)
+		require.Len(t, c.entries, 2)
+	})
+
+	t.Run("expired_alias", func(t *testing.T) {
+		c := newModelAliasCache()
+		c.set("store", "a", "model-a")
+		c.entries["store|a"].Value.(*modelAliasCacheEntry).expiresAt = time.Now().Add(-time.Second)
+
+		_, ok := c.get("store", "a")
+		require.False(t, ok)
+		require.Empty(t, c.entries)
+		require.Zero(t, c.lru.Len())
+	})
+
+	t.Run("invalidate_store", func(t *testing.T) {
+		c := newModelAliasCache()
+		c.set("store", "a", "model-a")
+		c.set("other", "a", "model-b")
+
+		c.invalidateStore("store")
+		_, ok := c.get("store", "a")
+		require.False(t, ok)
+		_, ok = c.get("other", "a")
+		require.True(t, ok)
+	})
+}
+
+func FuzzHandlersRejectMalformedIDs(f *testing.F) {
+	f.Add(ulid.Make().String(), "")
+	f.Add(ulid.Make().String(), ulid.Make().String())
+	f.Add("01hvmmbcmgzncsvy6y0rdq2z4h", "01hvmmbcmgzncsvy6y0rdq2z4h")
+	f.Add("01HVMMBCMGZNCSVY6Y0RDQ2Z4", "01HVMMBCMGZNCSVY6Y0RDQ2Z4HH")
+	f.Add("01HVMMBCMGZNCSVY6Y0RDQ2ZIL", "01HVMMBCMGZNCSVY6Y0RDQ2ZOU")
+	f.Add("5c3a0b5c-9a1b-4e0e-8d8c-1f2b3c4d5e6f", "")
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	f.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	f.Cleanup(s.Close)
+
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+
+	f.Fuzz(func(t *testing.T, storeID, modelID string) {
+		handlers := map[string]struct {
+			withModelID bool
+			call        func() error
+		}{
+			"Check": {
+				withModelID: true,
+				call: func() error {
+					_, err := s.Check(ctx, &openfgav1.CheckRequest{StoreId: storeID, AuthorizationModelId: modelID, TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne")})
+					return err
+				},
+			},
+			"Expand": {
+				withModelID: true,
+				call: func() error {
+					_, err := s.Expand(ctx, &openfgav1.ExpandRequest{StoreId: storeID, AuthorizationModelId: modelID, TupleKey: tuple.NewExpandRequestTupleKey("document:1", "viewer")})
+					return err
+				},
+			},
+			"ListObjects": {
+				withModelID: true,
+				call: func() error {
+					_, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{StoreId: storeID, AuthorizationModelId: modelID, Type: "document", Relation: "viewer", User: "user:anne"})
+					return err
+				},
+			},
+			"StreamedListObjects": {
+				withModelID: true,
+				call: func() error {
+					return s.StreamedListObjects(&openfgav1.StreamedListObjectsRequest{StoreId: storeID, AuthorizationModelId: modelID, Type: "document", Relation: "viewer", User: "user:anne"}, NewMockStreamServer())
+				},
+			},
+			"ListUsers": {
+				withModelID: true,
+				call: func() error {
+					_, err := s.ListUsers(ctx, &openfgav1.ListUsersRequest{
+						StoreId:              storeID,
+						AuthorizationModelId: modelID,
+						Object:               &openfgav1.Object{Type: "document", Id: "1"},
+						Relation:             "viewer",
+						UserFilters:          []*openfgav1.UserTypeFilter{{Type: "user"}},
+					})
+					return err
+				},
+			},
+			"Read": {
+				withModelID: false,
+				call: func() error {
+					_, err := s.Read(ctx, &openfgav1.ReadRequest{StoreId: storeID})
+					return err
+				},
+			},
+			"Write": {
+				withModelID: true,
+				call: func() error {
+					_, err := s.Write(ctx, &openfgav1.WriteRequest{StoreId: storeID, AuthorizationModelId: modelID, Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{tk}}})
+					return err
+				},
+			},
+			"ReadChanges": {
+				withModelID: false,
+				call: func() error {
+					_, err := s.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+					return err
+				},
+			},
+			"ReadAuthorizationModel": {
+				withModelID: true,
+				call: func() error {
+					_, err := s.ReadAuthorizationModel(ctx, &openfgav1.ReadAuthorizationModelRequest{StoreId: storeID, Id: modelID})
+					return err
+				},
+			},
+			"ReadAuthorizationModels": {
+				withModelID: false,
+				call: func() error {
+					_, err := s.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{StoreId: storeID})
+					return err
+				},
+			},
+			"ReadAssertions": {
+				withModelID: true,
+				call: func() error {
+					_, err := s.ReadAssertions(ctx, &openfgav1.ReadAssertionsRequest{StoreId: storeID, AuthorizationModelId: modelID})
+					return err
+				},
+			},
+			"GetStore": {
+				withModelID: false,
+				call: func() error {
+					_, err := s.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: storeID})
+					return err
+				},
+			},
+			"DeleteStore": {
+				withModelID: false,
+				call: func() error {
+					_, err := s.DeleteStore(ctx, &openfgav1.DeleteStoreRequest{StoreId: storeID})
+					return err
+				},
+			},
+			"GetRelationStatistics": {
+				withModelID: false,
+				call: func() error {
+					_, err := s.GetRelationStatistics(ctx, &commands.RelationStatisticsRequest{StoreID: storeID, ObjectType: "document", Relation: "viewer"})
+					return err
+				},
+			},
+			"ReadModelAlias": {
+				withModelID: false,
+				call: func() error {
+					_, err := s.ReadModelAlias(ctx, storeID, "production")
+					return err
+				},
+			},
+		}
+
+		storeIDMalformed := validator.ValidateStoreID(storeID) != nil
+		modelIDMalformed := modelID != "" && validator.ValidateAuthorizationModelID(modelID) != nil
+
+		for name, handler := range handlers {
+			err := handler.call()
+			require.NotEqual(t, codes.Internal, status.Code(err), "%s: %v", name, err)
+			if storeIDMalformed || (handler.withModelID && modelIDMalformed) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err), "%s: %v", name, err)
+			}
+		}
+	})
+}
+
+func TestMigrateAuthorizationModelSchema(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	legacyModel := &openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_0,
+		TypeDefinitions: []*openfgav1.TypeDefinition{
+			{Type: "user"},
+			{
+				Type: "document",
+				Relations: map[string]*openfgav1.Userset{
+					"owner":  typesystem.This(),
+					"viewer": typesystem.Union(typesystem.This(), typesystem.ComputedUserset("owner")),
+				},
+			},
+		},
+	}
+
+	createStore := func(tuples ...*openfgav1.TupleKey) string {
+		resp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "legacy"})
+		require.NoError(t, err)
+
+		err = ds.WriteAuthorizationModel(ctx, resp.GetId(), legacyModel)
+		require.NoError(t, err)
+		err = ds.Write(ctx, resp.GetId(), nil, tuples)
+		require.NoError(t, err)
+		return resp.GetId()
+	}
+
+	t.Run("migrates_the_latest_model", func(t *testing.T) {
+		storeID := createStore(
+			tuple.NewTupleKey("document:1", "owner", "user:anne"),
+			tuple.NewTupleKey("document:1", "viewer", "user:*"),
+		)
+
+		resp, err := s.MigrateAuthorizationModelSchema(ctx, &commands.MigrateAuthorizationModelSchemaRequest{StoreID: storeID})
+		require.NoError(t, err)
+		require.Equal(t, legacyModel.GetId(), resp.SourceAuthorizationModelID)
+		require.NotEmpty(t, resp.AuthorizationModelID)
+		require.Equal(t, 2, resp.TuplesRead)
+		require.Empty(t, resp.NeedsAttention)
+		require.Len(t, resp.Transformations, 3)
+
+		model, err := ds.FindLatestAuthorizationModel(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, resp.AuthorizationModelID, model.GetId())
+		require.Equal(t, typesystem.SchemaVersion1_1, model.GetSchemaVersion())
+
+		checkResp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:bob"),
+		})
+		require.NoError(t, err)
+		require.True(t, checkResp.GetAllowed())
+	})
+
+	t.Run("dry_run_does_not_write", func(t *testing.T) {
+		storeID := createStore(
+			tuple.NewTupleKey("document:1", "owner", "user:anne"),
+			tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		)
+
+		resp, err := s.MigrateAuthorizationModelSchema(ctx, &commands.MigrateAuthorizationModelSchemaRequest{
+			StoreID:              storeID,
+			AuthorizationModelID: legacyModel.GetId(),
+			DryRun:               true,
+		})
+		require.NoError(t, err)
+		require.Empty(t, resp.AuthorizationModelID)
+		require.Empty(t, resp.NeedsAttention)
+
+		model, err := ds.FindLatestAuthorizationModel(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, legacyModel.GetId(), model.GetId())
+	})
+
+	t.Run("relations_that_need_attention_are_not_written", func(t *testing.T) {
+		storeID := createStore(tuple.NewTupleKey("document:1", "owner", "anne"))
+
+		resp, err := s.MigrateAuthorizationModelSchema(ctx, &commands.MigrateAuthorizationModelSchemaRequest{StoreID: storeID})
+		require.NoError(t, err)
+		require.Empty(t, resp.AuthorizationModelID)
+		require.Equal(t, []typesystem.SchemaMigrationNote{
+			{Type: "document", Relation: "owner", Message: "1 tuple(s) have a user with no type, such as the wildcard '*', which schema 1.1 cannot represent: rewrite them with typed users, e.g. 'user:*'"},
+			{Type: "document", Relation: "owner", Message: "the relation is directly assignable but no tuple with a typed user was written to it: add its type restrictions by hand, or remove its direct assignment"},
+			{Type: "document", Relation: "viewer", Message: "the relation is directly assignable but no tuple with a typed user was written to it: add its type restrictions by hand, or remove its direct assignment"},
+		}, resp.NeedsAttention)
+
+		model, err := ds.FindLatestAuthorizationModel(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, legacyModel.GetId(), model.GetId())
+	})
+
+	t.Run("rejects_models_of_other_schema_versions", func(t *testing.T) {
+		storeID := createStore(
+			tuple.NewTupleKey("document:1", "owner", "user:anne"),
+			tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		)
+		_, err := s.MigrateAuthorizationModelSchema(ctx, &commands.MigrateAuthorizationModelSchemaRequest{StoreID: storeID})
+		require.NoError(t, err)
+
+		_, err = s.MigrateAuthorizationModelSchema(ctx, &commands.MigrateAuthorizationModelSchemaRequest{StoreID: storeID})
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("unknown_model", func(t *testing.T) {
+		storeID := createStore()
+		_, err := s.MigrateAuthorizationModelSchema(ctx, &commands.MigrateAuthorizationModelSchemaRequest{
+			StoreID:              storeID,
+			AuthorizationModelID: ulid.Make().String(),
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_authorization_model_not_found), status.Code(err))
+	})
+
+	t.Run("remote_caller", func(t *testing.T) {
+		remoteCtx := peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+		_, err := s.MigrateAuthorizationModelSchema(remoteCtx, &commands.MigrateAuthorizationModelSchemaRequest{StoreID: createStore()})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("admin_handler", func(t *testing.T) {
+		storeID := createStore(
+			tuple.NewTupleKey("document:1", "owner", "user:anne"),
+			tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		)
+
+		r := httptest.NewRequest(http.MethodPost, "/admin/stores/"+storeID+"/migrate-model-schema", strings.NewReader(`{"dry_run": true}`))
+		r.RemoteAddr = "127.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		NewAdminHandler(s).ServeHTTP(rec, r)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp commands.MigrateAuthorizationModelSchemaResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, legacyModel.GetId(), resp.SourceAuthorizationModelID)
+		require.Empty(t, resp.AuthorizationModelID)
+		require.Equal(t, 2, resp.TuplesRead)
+
+		model, err := ds.FindLatestAuthorizationModel(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, legacyModel.GetId(), model.GetId())
+	})
+
+	t.Run("admin_handler_remote_caller", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/admin/stores/"+createStore()+"/migrate-model-schema", strings.NewReader(`{}`))
+		r.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		NewAdminHandler(s).ServeHTTP(rec, r)
+		require.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
+
+func TestBuildInfoMetric(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithExperimentals(ExperimentalCheckDenialReasons, "enable-access-control", ExperimentalCheckDenialReasons),
+	)
+	t.Cleanup(s.Close)
+
+	require.Equal(t, 1, testutil.CollectAndCount(buildInfoGauge))
+	require.InDelta(t, 1, testutil.ToFloat64(buildInfoGauge.WithLabelValues(
+		build.Version, build.Commit, runtime.Version(), build.AuthzModelSchemaVersion, "enable-access-control,enable-check-denial-reasons",
+	)), 0)
+
+	t.Run("replaced_by_later_servers", func(t *testing.T) {
+		other := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(other.Close)
+
+		require.Equal(t, 1, testutil.CollectAndCount(buildInfoGauge))
+		require.InDelta(t, 1, testutil.ToFloat64(buildInfoGauge.WithLabelValues(
+			build.Version, build.Commit, runtime.Version(), build.AuthzModelSchemaVersion, "",
+		)), 0)
+	})
+}
+
+func TestPointInTimeCheck(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithExperimentals(ExperimentalPointInTimeCheck),
+		WithCheckQueryCacheEnabled(true),
+		WithPointInTimeCheckLimits(time.Hour, 0),
+	)
+	t.Cleanup(s.Close)
+
+	createResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "point-in-time"})
+	require.NoError(t, err)
+	storeID := createResp.GetId()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+		},
+	})
+	require.NoError(t, err)
+
+	time.Sleep(time.Millisecond)
+	before := time.Now()
+	time.Sleep(time.Millisecond)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Deletes: &openfgav1.WriteRequestDeletes{
+			TupleKeys: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("document:1", "viewer", "user:anne"))},
+		},
+	})
+	require.NoError(t, err)
+
+	check := func(ctx context.Context) (bool, error) {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		return resp.GetAllowed(), err
+	}
+	checkAt := func(at string) (bool, error) {
+		return check(metadata.NewIncomingContext(ctx, metadata.Pairs(CheckAtHeader, at)))
+	}
+
+	allowed, err := check(ctx)
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	t.Run("rfc3339_timestamp", func(t *testing.T) {
+		allowed, err := checkAt(before.Format(time.RFC3339Nano))
+		require.NoError(t, err)
+		require.True(t, allowed)
+
+		// the current result is not replaced in the cache by the past one
+		allowed, err = check(ctx)
+		require.NoError(t, err)
+		require.False(t, allowed)
+	})
+
+	t.Run("ulid", func(t *testing.T) {
+		allowed, err := checkAt(ulid.MustNew(ulid.Timestamp(before), nil).String())
+		require.NoError(t, err)
+		require.True(t, allowed)
+	})
+
+	t.Run("invalid_bound", func(t *testing.T) {
+		_, err := checkAt("yesterday")
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("in_the_future", func(t *testing.T) {
+		_, err := checkAt(time.Now().Add(time.Minute).Format(time.RFC3339Nano))
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("older_than_the_horizon", func(t *testing.T) {
+		_, err := checkAt(time.Now().Add(-2 * time.Hour).Format(time.RFC3339Nano))
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("remote_caller", func(t *testing.T) {
+		remoteCtx := peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+		_, err := check(metadata.NewIncomingContext(remoteCtx, metadata.Pairs(CheckAtHeader, before.Format(time.RFC3339Nano))))
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("remote_caller_over_gateway", func(t *testing.T) {
+		gatewayCtx := peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 1234}})
+		_, err := check(metadata.NewIncomingContext(gatewayCtx, metadata.Pairs(
+			CheckAtHeader, before.Format(time.RFC3339Nano),
+			"x-forwarded-for", "127.0.0.1, 10.0.0.1",
+		)))
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+		allowed, err := check(metadata.NewIncomingContext(gatewayCtx, metadata.Pairs(
+			CheckAtHeader, before.Format(time.RFC3339Nano),
+			"x-forwarded-for", "127.0.0.1",
+		)))
+		require.NoError(t, err)
+		require.True(t, allowed)
+	})
+
+	t.Run("experimental_flag_disabled", func(t *testing.T) {
+		other := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(other.Close)
+
+		_, err := other.Check(metadata.NewIncomingContext(ctx, metadata.Pairs(CheckAtHeader, before.Format(time.RFC3339Nano))), &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
+
+// interleavedWriteDatastore writes between the reads of a Check: the first read of the viewer
+// relation is followed by the write, and the reads of the editor relation wait for it.
+type interleavedWriteDatastore struct {
+	storage.OpenFGADatastore
+	write   func()
+	once    sync.Once
+	written chan struct{}
+}
+
+func (d *interleavedWriteDatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadUserTupleOptions) (*openfgav1.Tuple, error) {
+	return d.interleave(ctx, d.OpenFGADatastore, store, tupleKey, options)
+}
+
+func (d *interleavedWriteDatastore) BeginSnapshot(ctx context.Context, options storage.SnapshotOptions) (storage.SnapshotTupleReader, error) {
+	snapshot, err := d.OpenFGADatastore.(storage.SnapshotBeginner).BeginSnapshot(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return &interleavedWriteSnapshot{SnapshotTupleReader: snapshot, datastore: d}, nil
+}
+
+func (d *interleavedWriteDatastore) interleave(ctx context.Context, reader storage.RelationshipTupleReader, store string, tupleKey *openfgav1.TupleKey, options storage.ReadUserTupleOptions) (*openfgav1.Tuple, error) {
+	if tupleKey.GetRelation() == "editor" {
+		<-d.written
+	}
+	t, err := reader.ReadUserTuple(ctx, store, tupleKey, options)
+	if tupleKey.GetRelation() == "viewer" {
+		d.once.Do(func() {
+			d.write()
+			close(d.written)
+		})
+	}
+	return t, err
+}
+
+type interleavedWriteSnapshot struct {
+	storage.SnapshotTupleReader
+	datastore *interleavedWriteDatastore
+}
+
+func (s *interleavedWriteSnapshot) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadUserTupleOptions) (*openfgav1.Tuple, error) {
+	return s.datastore.interleave(ctx, s.SnapshotTupleReader, store, tupleKey, options)
+}
+
+func TestSnapshotReadsForCheck(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define editor: [user]
+				define viewer: [user]
+				define can_read: viewer or editor`)
+
+	// anne is moved from editor to viewer by one write, so that she can read document:1 before
+	// and after it. The Check reads viewer before the write and editor after it.
+	testCheck := func(t *testing.T, ds storage.OpenFGADatastore, snapshotReads bool) bool {
+		ctx := context.Background()
+		storeID := ulid.Make().String()
+
+		_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: "snapshot"})
+		require.NoError(t, err)
+		require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+		require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "editor", "user:anne")}))
+
+		interleaved := &interleavedWriteDatastore{
+			OpenFGADatastore: ds,
+			written:          make(chan struct{}),
+			write: func() {
+				err := ds.Write(ctx, storeID,
+					[]*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("document:1", "editor", "user:anne"))},
+					[]*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+				)
+				require.NoError(t, err)
+			},
+		}
+
+		s := MustNewServerWithOpts(
+			WithDatastore(interleaved),
+			WithSnapshotReadsForCheck(snapshotReads),
+		)
+		t.Cleanup(s.Close)
+
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "can_read", "user:anne"),
+		})
+		require.NoError(t, err)
+
+		// the write happened during the Check
+		_, err = ds.ReadUserTuple(ctx, storeID, tuple.NewTupleKey("document:1", "viewer", "user:anne"), storage.ReadUserTupleOptions{})
+		require.NoError(t, err)
+
+		return resp.GetAllowed()
+	}
+
+	datastores := map[string]func(t *testing.T) storage.OpenFGADatastore{
+		"memory": func(t *testing.T) storage.OpenFGADatastore {
+			ds := memory.New()
+			t.Cleanup(ds.Close)
+			return ds
+		},
+		"sqlite": func(t *testing.T) storage.OpenFGADatastore {
+			testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+			ds, err := sqlite.New(testDatastore.GetConnectionURI(true), sqlcommon.NewConfig())
+			require.NoError(t, err)
+			t.Cleanup(ds.Close)
+			return ds
+		},
+	}
+
+	for name, newDatastore := range datastores {
+		t.Run(name, func(t *testing.T) {
+			t.Run("without_snapshot_reads_the_check_misses_both_tuples", func(t *testing.T) {
+				require.False(t, testCheck(t, newDatastore(t), false))
+			})
+
+			t.Run("with_snapshot_reads_the_check_reads_the_tuples_before_the_write", func(t *testing.T) {
+				require.True(t, testCheck(t, newDatastore(t), true))
+			})
+		})
+	}
+
+	t.Run("requires_a_snapshot_beginner", func(t *testing.T) {
+		_, err := NewServerWithOpts(
+			WithDatastore(storagewrappers.NewReadOnlyDatastore(memory.New())),
+			WithSnapshotReadsForCheck(true),
+		)
+		require.ErrorContains(t, err, "storage.SnapshotBeginner")
+	})
+}
+
+func TestMembershipIndex(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type group
+			relations
+				define member: [user, user:*, group#member]
+		type document
+			relations
+				define viewer: [user, group#member]
+				define can_read: viewer`)
+
+	storeID := ulid.Make().String()
+	_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: "membership-index"})
+	require.NoError(t, err)
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("group:eng", "member", "user:anne"),
+		tuple.NewTupleKey("group:eng", "member", "group:sre#member"),
+		tuple.NewTupleKey("group:sre", "member", "user:bob"),
+		tuple.NewTupleKey("group:sre", "member", "group:eng#member"),
+		tuple.NewTupleKey("group:all", "member", "user:*"),
+		tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+		tuple.NewTupleKey("document:2", "viewer", "group:all#member"),
+		tuple.NewTupleKey("document:3", "viewer", "user:carl"),
+	}))
+
+	withoutIndex := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(withoutIndex.Close)
+
+	withIndex := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithMembershipIndexRelations([]string{"group#member"}),
+		WithMembershipIndexPollInterval(10*time.Millisecond),
+	)
+	t.Cleanup(withIndex.Close)
+
+	check := func(t *testing.T, s *Server, object, relation, user string, consistency openfgav1.ConsistencyPreference) bool {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:     storeID,
+			TupleKey:    tuple.NewCheckRequestTupleKey(object, relation, user),
+			Consistency: consistency,
+		})
+		require.NoError(t, err)
+		return resp.GetAllowed()
+	}
+
+	// the index answers the sub-problems of Check once it caught up with the writes
+	waitForIndex := func(t *testing.T, object, user string, member bool) {
+		require.Eventually(t, func() bool {
+			check(t, withIndex, object, "member", user, openfgav1.ConsistencyPreference_MINIMIZE_LATENCY)
+			isMember, ok := withIndex.membershipIndex.Lookup(ctx, storeID, object, "member", user)
+			return ok && isMember == member
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	requireSameAnswers := func(t *testing.T) {
+		for _, user := range []string{"user:anne", "user:bob", "user:carl", "user:dave"} {
+			for _, object := range []string{"group:eng", "group:sre", "group:all"} {
+				require.Equal(t,
+					check(t, withoutIndex, object, "member", user, openfgav1.ConsistencyPreference_MINIMIZE_LATENCY),
+					check(t, withIndex, object, "member", user, openfgav1.ConsistencyPreference_MINIMIZE_LATENCY),
+					"%s#member@%s", object, user)
+			}
+			for _, object := range []string{"document:1", "document:2", "document:3"} {
+				require.Equal(t,
+					check(t, withoutIndex, object, "can_read", user, openfgav1.ConsistencyPreference_MINIMIZE_LATENCY),
+					check(t, withIndex, object, "can_read", user, openfgav1.ConsistencyPreference_MINIMIZE_LATENCY),
+					"%s#can_read@%s", object, user)
+			}
+		}
+	}
+
+	indexHits := func(t *testing.T) float64 {
+		families, err := prometheus.DefaultGatherer.Gather()
+		require.NoError(t, err)
+		for _, family := range families {
+			if family.GetName() == "openfga_membership_index_hit_count" {
+				return family.GetMetric()[0].GetCounter().GetValue()
+			}
+		}
+		return 0
+	}
+
+	t.Run("same_answers_as_without_the_index", func(t *testing.T) {
+		waitForIndex(t, "group:eng", "user:bob", true)
+
+		hits := indexHits(t)
+		requireSameAnswers(t)
+		require.Greater(t, indexHits(t), hits)
+	})
+
+	t.Run("same_answers_after_the_index_catches_up_with_writes", func(t *testing.T) {
+		require.NoError(t, ds.Write(ctx, storeID,
+			[]*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("group:sre", "member", "group:eng#member"))},
+			[]*openfgav1.TupleKey{tuple.NewTupleKey("group:sre", "member", "user:dave")},
+		))
+
+		waitForIndex(t, "group:eng", "user:dave", true)
+		waitForIndex(t, "group:sre", "user:anne", false)
+		requireSameAnswers(t)
+	})
+
+	t.Run("higher_consistency_bypasses_the_index", func(t *testing.T) {
+		require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("group:eng", "member", "user:erin")}))
+
+		require.True(t, check(t, withIndex, "document:1", "can_read", "user:erin", openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY))
+	})
+
+	t.Run("invalid_relations", func(t *testing.T) {
+		_, err := NewServerWithOpts(
+			WithDatastore(ds),
+			WithMembershipIndexRelations([]string{"group:eng#member"}),
+		)
+		require.ErrorContains(t, err, "invalid membership index relation 'group:eng#member'")
+	})
+}
+
+func TestBatchCheck(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type group
+			relations
+				define member: [user]
+		type document
+			relations
+				define viewer: [user, group#member]`)
+
+	storeID := ulid.Make().String()
+	_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: "batch-check"})
+	require.NoError(t, err)
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("group:eng", "member", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+	}))
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithMaxChecksPerBatchCheck(3),
+	)
+	t.Cleanup(s.Close)
+
+	batchCheckSamples := func(t *testing.T) uint64 {
+		families, err := prometheus.DefaultGatherer.Gather()
+		require.NoError(t, err)
+		for _, family := range families {
+			if family.GetName() != "openfga_datastore_query_count" {
+				continue
+			}
+			for _, metric := range family.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "grpc_method" && label.GetValue() == "batchcheck" {
+						return metric.GetHistogram().GetSampleCount()
+					}
+				}
+			}
+		}
+		return 0
+	}
+
+	t.Run("results_by_correlation_id", func(t *testing.T) {
+		samples := batchCheckSamples(t)
+
+		resp, err := s.BatchCheck(ctx, &BatchCheckRequest{
+			StoreID: storeID,
+			Checks: []*BatchCheckItem{
+				{CorrelationID: "anne", TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne")},
+				{CorrelationID: "bob", TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:bob")},
+				{
+					CorrelationID:    "carl",
+					TupleKey:         tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:carl"),
+					ContextualTuples: []*openfgav1.TupleKey{tuple.NewTupleKey("group:eng", "member", "user:carl")},
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, model.GetId(), resp.AuthorizationModelID)
+		require.Len(t, resp.Results, 3)
+		require.True(t, resp.Results["anne"].Allowed)
+		require.False(t, resp.Results["bob"].Allowed)
+		require.True(t, resp.Results["carl"].Allowed)
+		for _, result := range resp.Results {
+			require.NoError(t, result.Error)
+		}
+
+		// the metrics of the batch are recorded once
+		require.Equal(t, samples+1, batchCheckSamples(t))
+	})
+
+	t.Run("an_invalid_check_does_not_fail_the_batch", func(t *testing.T) {
+		resp, err := s.BatchCheck(ctx, &BatchCheckRequest{
+			StoreID: storeID,
+			Checks: []*BatchCheckItem{
+				{CorrelationID: "valid", TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne")},
+				{CorrelationID: "invalid", TupleKey: tuple.NewCheckRequestTupleKey("document:1", "editor", "user:anne")},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, resp.Results["valid"].Allowed)
+		require.NoError(t, resp.Results["valid"].Error)
+		require.False(t, resp.Results["invalid"].Allowed)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(resp.Results["invalid"].Error))
+	})
+
+	t.Run("invalid_batches", func(t *testing.T) {
+		check := func(correlationID string) *BatchCheckItem {
+			return &BatchCheckItem{CorrelationID: correlationID, TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne")}
+		}
+
+		tests := map[string][]*BatchCheckItem{
+			`empty`:                    nil,
+			`too_many_checks`:          {check("1"), check("2"), check("3"), check("4")},
+			`missing_correlation_id`:   {check("1"), check("")},
+			`duplicate_correlation_id`: {check("1"), check("1")},
+		}
+		for name, checks := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := s.BatchCheck(ctx, &BatchCheckRequest{StoreID: storeID, Checks: checks})
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			})
+		}
+	})
+}
+
+func TestCheckFairReadSharing(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	const readDuration = 100 * time.Millisecond
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+	slowDatastore := mockstorage.NewMockSlowDataStorage(ds, readDuration)
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`)
+
+	busyStoreID := ulid.Make().String()
+	otherStoreID := ulid.Make().String()
+	for _, storeID := range []string{busyStoreID, otherStoreID} {
+		require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+		require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")}))
+	}
+
+	s := MustNewServerWithOpts(
+		WithDatastore(slowDatastore),
+		WithMaxConcurrentReadsForCheck(1),
+		WithCheckFairReadSharing(true),
+	)
+	t.Cleanup(s.Close)
+
+	check := func(storeID string) error {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: model.GetId(),
+			TupleKey:             tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		if err == nil && !resp.GetAllowed() {
+			err = fmt.Errorf("anne cannot view document:1 of store %s", storeID)
+		}
+		return err
+	}
+
+	// the Check requests of the busy store share one read at a time
+	start := time.Now()
+	var busyChecks errgroup.Group
+	for i := 0; i < 10; i++ {
+		busyChecks.Go(func() error {
+			return check(busyStoreID)
+		})
+	}
+	time.Sleep(readDuration / 2)
+
+	// the Check of the other store gets the next read, ahead of the waiting reads of the busy store
+	otherStart := time.Now()
+	require.NoError(t, check(otherStoreID))
+	require.Less(t, time.Since(otherStart), 3*readDuration)
+
+	require.NoError(t, busyChecks.Wait())
+	require.GreaterOrEqual(t, time.Since(start), 10*readDuration)
+}
+
+func TestErrorReasonDetails(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithErrorReasonDetails(true))
+	t.Cleanup(s.Close)
+
+	createResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "error-reasons"})
+	require.NoError(t, err)
+	storeID := createResp.GetId()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user, user with in_office]
+		condition in_office(ip: ipaddress) {
+			ip.in_cidr("192.168.0.0/24")
+		}`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+		Conditions:      model.GetConditions(),
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:anne", "in_office", nil)},
+		},
+	})
+	require.NoError(t, err)
+
+	requireReason := func(t *testing.T, err error, reason string) {
+		t.Helper()
+		require.Error(t, err)
+		details := status.Convert(err).Details()
+		require.Len(t, details, 1)
+		info, ok := details[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		require.Equal(t, reason, info.GetReason())
+	}
+
+	t.Run("write_tuple_invalid", func(t *testing.T) {
+		_, err := s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "editor", "user:anne")},
+			},
+		})
+		requireReason(t, err, "TUPLE_INVALID")
+	})
+
+	t.Run("check_model_not_found", func(t *testing.T) {
+		_, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: ulid.Make().String(),
+			TupleKey:             tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		requireReason(t, err, "MODEL_NOT_FOUND")
+	})
+
+	t.Run("check_condition_context_invalid", func(t *testing.T) {
+		_, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		requireReason(t, err, "CONDITION_CONTEXT_INVALID")
+	})
+
+	t.Run("list_objects_tuple_invalid", func(t *testing.T) {
+		_, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+			StoreId:  storeID,
+			Type:     "folder",
+			Relation: "viewer",
+			User:     "user:anne",
+		})
+		requireReason(t, err, "TUPLE_INVALID")
+	})
+
+	t.Run("list_users_model_not_found", func(t *testing.T) {
+		_, err := s.ListUsers(ctx, &openfgav1.ListUsersRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: ulid.Make().String(),
+			Object:               &openfgav1.Object{Type: "document", Id: "1"},
+			Relation:             "viewer",
+			UserFilters:          []*openfgav1.UserTypeFilter{{Type: "user"}},
+		})
+		requireReason(t, err, "MODEL_NOT_FOUND")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		other := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(other.Close)
+
+		_, err := other.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: ulid.Make().String(),
+			TupleKey:             tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_authorization_model_not_found), status.Code(err))
+
+		// the error keeps the details of its own reason only
+		details := status.Convert(err).Details()
+		require.Len(t, details, 1)
+		info, ok := details[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		require.Equal(t, "AUTHORIZATION_MODEL_NOT_FOUND", info.GetReason())
+		require.NotContains(t, info.GetMetadata(), "detail_reason")
+	})
+}
+
+func TestErrorMessageCatalog(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	catalog := func(code string, params map[string]string) string {
+		if params[serverErrors.LocaleParam] != "ja" {
+			return ""
+		}
+		switch code {
+		case "AUTHORIZATION_MODEL_NOT_FOUND":
+			return fmt.Sprintf("認可モデル '%s' が見つかりません", params["authorization_model_id"])
+		case "TYPE_NOT_FOUND":
+			return fmt.Sprintf("タプル '%s' の型 '%s' が見つかりません", params["tuple"], params["object_type"])
+		}
+		return ""
+	}
+
+	plain := MustNewServerWithOpts(WithDatastore(ds), WithErrorReasonDetails(true))
+	t.Cleanup(plain.Close)
+	localized := MustNewServerWithOpts(WithDatastore(ds), WithErrorReasonDetails(true), WithErrorMessageCatalog(catalog))
+	t.Cleanup(localized.Close)
+
+	createResp, err := plain.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "error-messages"})
+	require.NoError(t, err)
+	storeID := createResp.GetId()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`)
+	_, err = plain.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	modelID := ulid.Make().String()
+	check := func(s *Server, ctx context.Context) error {
+		_, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: modelID,
+			TupleKey:             tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		return err
+	}
+	write := func(s *Server, ctx context.Context) error {
+		_, err := s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("folder:1", "viewer", "user:anne")},
+			},
+		})
+		return err
+	}
+
+	jaCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(AcceptLanguageHeader, "ja"))
+
+	tests := map[string]struct {
+		call            func(s *Server, ctx context.Context) error
+		expectedMessage string
+	}{
+		"check": {
+			call:            check,
+			expectedMessage: fmt.Sprintf("認可モデル '%s' が見つかりません", modelID),
+		},
+		"write": {
+			call:            write,
+			expectedMessage: "タプル 'folder:1#viewer@user:anne' の型 'folder' が見つかりません",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			expected := status.Convert(test.call(plain, jaCtx))
+			actual := status.Convert(test.call(localized, jaCtx))
+			require.Equal(t, test.expectedMessage, actual.Message())
+
+			// the codes and details are the same regardless of the catalog
+			require.Equal(t, expected.Code(), actual.Code())
+			require.Len(t, actual.Proto().GetDetails(), len(expected.Proto().GetDetails()))
+			for i, detail := range expected.Proto().GetDetails() {
+				require.True(t, proto.Equal(detail, actual.Proto().GetDetails()[i]))
+			}
+
+			// other locales keep the default message
+			require.Equal(t, expected.Message(), status.Convert(test.call(localized, ctx)).Message())
+		})
+	}
+}
+
+// collectingStreamServer collects the objects of a StreamedListObjects stream.
+type collectingStreamServer struct {
+	mockStreamServer
+	objects []string
+}
+
+func (c *collectingStreamServer) Send(resp *openfgav1.StreamedListObjectsResponse) error {
+	c.objects = append(c.objects, resp.GetObject())
+	return nil
+}
+
+// sameElements reports whether actual has the elements of expected, which must be sorted, in any order.
+func sameElements(expected, actual []string) bool {
+	actual = slices.Clone(actual)
+	slices.Sort(actual)
+	return slices.Equal(expected, actual)
+}
+
+func TestCommandsSharedByConcurrentRequests(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithMaxConcurrentReadsForListObjects(1),
+		WithMaxConcurrentReadsForRead(1),
+		WithMaxConcurrentReadsForExpand(1),
+	)
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "shared"})
+	require.NoError(t, err)
+	storeID := store.GetId()
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId: storeID,
+		TypeDefinitions: language.MustTransformDSLToProto(`
+			model
+				schema 1.1
+			type user
+			type document
+				relations
+					define owner: [user]
+					define viewer: [user] or owner`).GetTypeDefinitions(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+	})
+	require.NoError(t, err)
+
+	const users = 10
+	var tuples []*openfgav1.TupleKey
+	for i := 0; i < users; i++ {
+		tuples = append(tuples,
+			tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "viewer", fmt.Sprintf("user:%d", i)),
+			tuple.NewTupleKey(fmt.Sprintf("document:owned-%d", i), "owner", fmt.Sprintf("user:%d", i)),
+		)
+	}
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes:  &openfgav1.WriteRequestWrites{TupleKeys: tuples},
+	})
+	require.NoError(t, err)
+
+	const repeats = 10
+	errs := make(chan error, repeats*users)
+	var wg sync.WaitGroup
+	for i := 0; i < repeats*users; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- func() error {
+				user := fmt.Sprintf("user:%d", i%users)
+				expected := []string{fmt.Sprintf("document:%d", i%users), fmt.Sprintf("document:owned-%d", i%users)}
+
+				listObjectsResp, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+					StoreId:  storeID,
+					Type:     "document",
+					Relation: "viewer",
+					User:     user,
+				})
+				if err != nil {
+					return err
+				}
+				if !sameElements(expected, listObjectsResp.GetObjects()) {
+					return fmt.Errorf("unexpected ListObjects objects of %s", user)
+				}
+
+				stream := &collectingStreamServer{}
+				err = s.StreamedListObjects(&openfgav1.StreamedListObjectsRequest{
+					StoreId:  storeID,
+					Type:     "document",
+					Relation: "viewer",
+					User:     user,
+				}, stream)
+				if err != nil {
+					return err
+				}
+				if !sameElements(expected, stream.objects) {
+					return fmt.Errorf("unexpected StreamedListObjects objects of %s", user)
+				}
+
+				readResp, err := s.Read(ctx, &openfgav1.ReadRequest{
+					StoreId:  storeID,
+					TupleKey: &openfgav1.ReadRequestTupleKey{Object: "document:", User: user},
+				})
+				if err != nil {
+					return err
+				}
+				if len(readResp.GetTuples()) != 2 {
+					return fmt.Errorf("unexpected Read tuples of %s: %v", user, readResp.GetTuples())
+				}
+
+				expandResp, err := s.Expand(ctx, &openfgav1.ExpandRequest{
+					StoreId:  storeID,
+					TupleKey: &openfgav1.ExpandRequestTupleKey{Object: expected[0], Relation: "viewer"},
+				})
+				if err != nil {
+					return err
+				}
+				if expandResp.GetTree().GetRoot().GetName() != expected[0]+"#viewer" {
+					return fmt.Errorf("unexpected Expand tree of %s", expected[0])
+				}
+				return nil
+			}()
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
+
+func TestSessionAffinity(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	t.Run("reads_of_the_writer_bypass_the_check_cache", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithCheckQueryCacheEnabled(true),
+			WithCheckQueryCacheTTL(time.Minute),
+			WithSessionAffinityEnabled(true),
+			WithSessionAffinityWindow(time.Minute),
+		)
+		t.Cleanup(s.Close)
+
+		writer := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1000}})
+		reader := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 1000}})
+
+		createResp, err := s.CreateStore(writer, &openfgav1.CreateStoreRequest{Name: "session-affinity"})
+		require.NoError(t, err)
+		storeID := createResp.GetId()
+
+		model := testutils.MustTransformDSLToProtoWithID(`
+			model
+				schema 1.1
+			type user
+			type document
+				relations
+					define viewer: [user]`)
+		_, err = s.WriteAuthorizationModel(writer, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+
+		check := func(ctx context.Context) bool {
+			resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+				StoreId:  storeID,
+				TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+			})
+			require.NoError(t, err)
+			return resp.GetAllowed()
+		}
+
+		// caches the denial
+		require.False(t, check(reader))
+
+		_, err = s.Write(writer, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+			},
+		})
+		require.NoError(t, err)
+
+		require.True(t, check(writer))
+		require.Equal(t, openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY, s.sessionConsistency(writer, storeID, openfgav1.ConsistencyPreference_UNSPECIFIED))
+		require.Equal(t, openfgav1.ConsistencyPreference_UNSPECIFIED, s.sessionConsistency(reader, storeID, openfgav1.ConsistencyPreference_UNSPECIFIED))
+	})
+
+	t.Run("zero_window_is_rejected", func(t *testing.T) {
+		_, err := NewServerWithOpts(WithDatastore(memory.New()), WithSessionAffinityEnabled(true), WithSessionAffinityWindow(0))
+		require.EqualError(t, err, "session affinity window and max sessions must be greater than 0")
+	})
+}
+
+func TestListLimits(t *testing.T) {
+	t.Run("negative_deadlines_are_rejected", func(t *testing.T) {
+		_, err := NewServerWithOpts(WithDatastore(memory.New()), WithListObjectsDeadline(-time.Second))
+		require.EqualError(t, err, "ListObjects deadline must not be negative, use 0 to disable it")
+
+		_, err = NewServerWithOpts(WithDatastore(memory.New()), WithListUsersDeadline(-time.Second))
+		require.EqualError(t, err, "ListUsers deadline must not be negative, use 0 to disable it")
+	})
+
+	t.Run("suspicious_limits_are_logged", func(t *testing.T) {
+		observerLogger, logs := observer.New(zap.WarnLevel)
+
+		s := MustNewServerWithOpts(
+			WithDatastore(memory.New()),
+			WithLogger(&logger.ZapLogger{Logger: zap.New(observerLogger)}),
+			WithListObjectsDeadline(0),
+			WithListObjectsMaxResults(0),
+			WithListUsersDeadline(10*time.Millisecond),
+			WithListUsersDispatchThrottlingEnabled(true),
+			WithListUsersDispatchThrottlingFrequency(time.Second),
+		)
+		t.Cleanup(s.Close)
+
+		messages := make([]string, 0, logs.Len())
+		for _, entry := range logs.All() {
+			messages = append(messages, entry.Message)
+		}
+		require.ElementsMatch(t, []string{
+			"ListObjects has neither a deadline nor max results, so its requests are only bounded by the request timeout",
+			"ListUsers deadline is shorter than its dispatch throttling frequency, so throttled requests return partial results",
+		}, messages)
+	})
+
+	t.Run("default_limits_are_not_logged", func(t *testing.T) {
+		observerLogger, logs := observer.New(zap.WarnLevel)
+
+		s := MustNewServerWithOpts(
+			WithDatastore(memory.New()),
+			WithLogger(&logger.ZapLogger{Logger: zap.New(observerLogger)}),
+		)
+		t.Cleanup(s.Close)
+
+		require.Zero(t, logs.Len())
+	})
+}
+
+func TestChangelogMetrics(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	ds := memory.New()
+	storeID := ulid.Make().String()
+	otherStoreID := ulid.Make().String()
+	for _, store := range []string{storeID, otherStoreID} {
+		err := ds.Write(ctx, store, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")})
+		require.NoError(t, err)
+	}
+
+	t.Run("negative_interval_is_rejected", func(t *testing.T) {
+		_, err := NewServerWithOpts(WithDatastore(ds), WithChangelogMetricsInterval(-time.Second))
+		require.EqualError(t, err, "changelog metrics interval must not be negative, use 0 to disable the changelog metrics")
+	})
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithChangelogHorizonOffset(2),
+		WithChangelogMetricsInterval(10*time.Millisecond),
+		WithPerStoreChangelogMetrics([]string{storeID}),
+	)
+	t.Cleanup(s.Close)
+
+	require.InDelta(t, 120, testutil.ToFloat64(changelogHorizonOffsetGauge), 0)
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(changelogRowsGauge) == 2
+	}, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return testutil.CollectAndCount(changelogNewestChangeAgeGauge) == 1
+	}, time.Second, 10*time.Millisecond)
+	require.GreaterOrEqual(t, testutil.ToFloat64(changelogNewestChangeAgeGauge.WithLabelValues(storeID)), float64(0))
+
+	t.Run("disabled_by_default", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(s.Close)
+		require.Nil(t, s.changelogMetricsReporter)
+	})
+
+	t.Run("closing_a_server_keeps_the_series_of_the_others", func(t *testing.T) {
+		other := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithChangelogMetricsInterval(10*time.Millisecond),
+			WithPerStoreChangelogMetrics([]string{otherStoreID}),
+		)
+		require.Eventually(t, func() bool {
+			return testutil.CollectAndCount(changelogNewestChangeAgeGauge) == 2
+		}, time.Second, 10*time.Millisecond)
+
+		other.Close()
+		require.Equal(t, 1, testutil.CollectAndCount(changelogNewestChangeAgeGauge))
+		require.GreaterOrEqual(t, testutil.ToFloat64(changelogNewestChangeAgeGauge.WithLabelValues(storeID)), float64(0))
+	})
+}
+
+func TestWriteAuthorizationModelImpactAnalysis(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	ds := memory.New()
+	transport := &headerRecordingTransport{}
+	s := MustNewServerWithOpts(WithDatastore(ds), WithTransport(transport))
+	t.Cleanup(s.Close)
+
+	t.Run("max_probes_must_be_positive", func(t *testing.T) {
+		_, err := NewServerWithOpts(WithDatastore(ds), WithModelImpactAnalysisMaxProbes(0))
+		require.EqualError(t, err, "model impact analysis max probes must be greater than 0")
+	})
+
+	createStoreResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "impact"})
+	require.NoError(t, err)
+	storeID := createStoreResp.GetId()
+
+	writeModel := func(ctx context.Context, dsl string) error {
+		model := testutils.MustTransformDSLToProtoWithID(dsl)
+		_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		return err
+	}
+	withImpactAnalysis := func(mode string) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(WriteModelImpactAnalysisHeader, mode))
+	}
+
+	require.NoError(t, writeModel(ctx, `
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`))
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+		},
+	})
+	require.NoError(t, err)
+
+	withoutViewer := `
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define editor: [user]`
+
+	t.Run("invalid_header", func(t *testing.T) {
+		err := writeModel(withImpactAnalysis("loud"), withoutViewer)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		err := writeModel(withImpactAnalysis("strict"), withoutViewer)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+		require.ErrorContains(t, err, "document#viewer: the relation was removed, but the store has tuples of it")
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		require.NoError(t, writeModel(withImpactAnalysis("warn"), withoutViewer))
+		warnings, ok := transport.header(AuthorizationModelWarningsHeader)
+		require.True(t, ok)
+		require.Equal(t, "document#viewer: the relation was removed, but the store has tuples of it, e.g. 'document:1#viewer@user:anne'", warnings)
+	})
+
+	t.Run("not_run_without_header", func(t *testing.T) {
+		transport := &headerRecordingTransport{}
+		s := MustNewServerWithOpts(WithDatastore(ds), WithTransport(transport))
+		t.Cleanup(s.Close)
+
+		model := testutils.MustTransformDSLToProtoWithID(`
+			model
+				schema 1.1
+			type user`)
+		_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+		_, ok := transport.header(AuthorizationModelWarningsHeader)
+		require.False(t, ok)
+
+		// the model has an ID of the same size as the generated one
+		storedSize, ok := transport.header(AuthorizationModelStoredSizeHeader)
+		require.True(t, ok)
+		require.Equal(t, strconv.Itoa(proto.Size(model)), storedSize)
+	})
+}
+
+func TestRefreshStore(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	ds := memory.New()
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	createStoreResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "refresh"})
+	require.NoError(t, err)
+	storeID := createStoreResp.GetId()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`)
+	writeModelResp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+	modelID := writeModelResp.GetAuthorizationModelId()
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+		},
+	})
+	require.NoError(t, err)
+
+	checkCanView := func(ctx context.Context, modelID string) (*openfgav1.CheckResponse, error) {
+		return s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: modelID,
+			TupleKey:             tuple.NewCheckRequestTupleKey("document:1", "can_view", "user:anne"),
+		})
+	}
+
+	// caches the model
+	_, err = checkCanView(ctx, modelID)
+	require.Error(t, err)
+
+	// a migration rewrites the model and writes a new latest model behind the caches
+	rewritten := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]
+				define can_view: viewer`)
+	rewritten.Id = modelID
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, rewritten))
+	latest := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]
+				define can_view: viewer`)
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, latest))
+
+	_, err = checkCanView(ctx, modelID)
+	require.Error(t, err, "the cached model is served until the store is refreshed")
+
+	t.Run("concurrent_requests_resolve_a_model", func(t *testing.T) {
+		var wg sync.WaitGroup
+		stop := make(chan struct{})
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					_, err := s.Check(ctx, &openfgav1.CheckRequest{
+						StoreId:  storeID,
+						TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+					})
+					if err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}()
+		}
+
+		for i := 0; i < 20; i++ {
+			refreshedModelID, err := s.RefreshStore(ctx, storeID)
+			require.NoError(t, err)
+			require.Equal(t, latest.GetId(), refreshedModelID)
+		}
+		close(stop)
+		wg.Wait()
+	})
+
+	checkResp, err := checkCanView(ctx, modelID)
+	require.NoError(t, err)
+	require.True(t, checkResp.GetAllowed())
+
+	t.Run("invalid_store_id", func(t *testing.T) {
+		_, err := s.RefreshStore(ctx, "invalid")
+		require.ErrorIs(t, err, serverErrors.InvalidStoreID)
+	})
+
+	t.Run("store_without_models", func(t *testing.T) {
+		_, err := s.RefreshStore(ctx, ulid.Make().String())
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_latest_authorization_model_not_found), status.Code(err))
+	})
+
+	t.Run("remote_caller", func(t *testing.T) {
+		remoteCtx := peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+		_, err := s.RefreshStore(remoteCtx, storeID)
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("admin_handler", func(t *testing.T) {
+		handler := NewAdminHandler(s)
+		newRequest := func(method, target string) *http.Request {
+			r := httptest.NewRequest(method, target, nil)
+			r.RemoteAddr = "127.0.0.1:1234"
+			return r
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newRequest(http.MethodPost, "/admin/stores/"+storeID+"/refresh"))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body struct {
+			AuthorizationModelID string `json:"authorization_model_id"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		require.Equal(t, latest.GetId(), body.AuthorizationModelID)
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, newRequest(http.MethodPost, "/admin/stores/invalid/refresh"))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, newRequest(http.MethodGet, "/admin/stores/"+storeID+"/refresh"))
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+
+	t.Run("admin_handler_remote_caller", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/admin/stores/"+storeID+"/refresh", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		NewAdminHandler(s).ServeHTTP(rec, r)
+		require.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
+
+func TestUniqueStoreNames(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	t.Run("disabled_by_default", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(s.Close)
+
+		for i := 0; i < 2; i++ {
+			_, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "shared"})
+			require.NoError(t, err)
+		}
+	})
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithUniqueStoreNames(true))
+	t.Cleanup(s.Close)
+
+	t.Run("rejects_a_taken_name", func(t *testing.T) {
+		created, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "unique"})
+		require.NoError(t, err)
+
+		_, err = s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "unique"})
+		require.Equal(t, codes.AlreadyExists, status.Code(err))
+		require.ErrorContains(t, err, created.GetId())
+
+		_, err = s.DeleteStore(ctx, &openfgav1.DeleteStoreRequest{StoreId: created.GetId()})
+		require.NoError(t, err)
+		_, err = s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "unique"})
+		require.NoError(t, err)
+	})
+
+	t.Run("concurrent_creates", func(t *testing.T) {
+		var wg sync.WaitGroup
+		var created atomic.Int32
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "concurrent"})
+				if err == nil {
+					created.Add(1)
+					return
+				}
+				if status.Code(err) != codes.AlreadyExists {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		require.EqualValues(t, 1, created.Load())
+	})
+
+	t.Run("list_stores_by_name", func(t *testing.T) {
+		resp, err := s.ListStores(metadata.NewIncomingContext(ctx, metadata.Pairs(ListStoresNameHeader, "shared")), &openfgav1.ListStoresRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.GetStores(), 2)
+		for _, store := range resp.GetStores() {
+			require.Equal(t, "shared", store.GetName())
+		}
+	})
+}
+
+func TestServerWithRoutingDatastore(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	eu := memory.New()
+	us := memory.New()
+	ds, err := storagewrappers.NewRoutingDatastore(map[string]storage.OpenFGADatastore{"eu": eu, "us": us}, "us",
+		storagewrappers.WithCreateStorePolicy(func(_ context.Context, store *openfgav1.Store) (string, error) {
+			if strings.HasPrefix(store.GetName(), "eu-") {
+				return "eu", nil
+			}
+			return "us", nil
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`)
+
+	stores := map[string]storage.OpenFGADatastore{}
+	var storeIDs []string
+	for name, partition := range map[string]storage.OpenFGADatastore{"eu-tenant": eu, "us-tenant": us} {
+		store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: name})
+		require.NoError(t, err)
+		stores[store.GetId()] = partition
+		storeIDs = append(storeIDs, store.GetId())
+	}
+
+	for storeID, partition := range stores {
+		writeModelResp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			TypeDefinitions: model.GetTypeDefinitions(),
+			SchemaVersion:   model.GetSchemaVersion(),
+		})
+		require.NoError(t, err)
+		modelID := writeModelResp.GetAuthorizationModelId()
+
+		_, err = s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+			},
+		})
+		require.NoError(t, err)
+
+		// the tuples of the store are only in its partition
+		for name, candidate := range map[string]storage.OpenFGADatastore{"eu": eu, "us": us} {
+			_, err := candidate.ReadUserTuple(ctx, storeID, tuple.NewTupleKey("document:1", "viewer", "user:anne"), storage.ReadUserTupleOptions{})
+			if candidate == partition {
+				require.NoError(t, err, name)
+			} else {
+				require.ErrorIs(t, err, storage.ErrNotFound, name)
+			}
+		}
+
+		getStoreResp, err := s.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Equal(t, storeID, getStoreResp.GetId())
+
+		_, err = s.ReadAuthorizationModel(ctx, &openfgav1.ReadAuthorizationModelRequest{StoreId: storeID, Id: modelID})
+		require.NoError(t, err)
+		readModelsResp, err := s.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, readModelsResp.GetAuthorizationModels(), 1)
+
+		checkResp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.True(t, checkResp.GetAllowed())
+
+		listObjectsResp, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+			StoreId:  storeID,
+			Type:     "document",
+			Relation: "viewer",
+			User:     "user:anne",
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"document:1"}, listObjectsResp.GetObjects())
+
+		listUsersResp, err := s.ListUsers(ctx, &openfgav1.ListUsersRequest{
+			StoreId:     storeID,
+			Object:      &openfgav1.Object{Type: "document", Id: "1"},
+			Relation:    "viewer",
+			UserFilters: []*openfgav1.UserTypeFilter{{Type: "user"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, listUsersResp.GetUsers(), 1)
+
+		expandResp, err := s.Expand(ctx, &openfgav1.ExpandRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewExpandRequestTupleKey("document:1", "viewer"),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, expandResp.GetTree())
+
+		readResp, err := s.Read(ctx, &openfgav1.ReadRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, readResp.GetTuples(), 1)
+
+		readChangesResp, err := s.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, readChangesResp.GetChanges(), 1)
+
+		_, err = s.WriteAssertions(ctx, &openfgav1.WriteAssertionsRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: modelID,
+			Assertions: []*openfgav1.Assertion{{
+				TupleKey:    tuple.NewAssertionTupleKey("document:1", "viewer", "user:anne"),
+				Expectation: true,
+			}},
+		})
+		require.NoError(t, err)
+		readAssertionsResp, err := s.ReadAssertions(ctx, &openfgav1.ReadAssertionsRequest{StoreId: storeID, AuthorizationModelId: modelID})
+		require.NoError(t, err)
+		require.Len(t, readAssertionsResp.GetAssertions(), 1)
+	}
+
+	var listed []string
+	var continuationToken string
+	for {
+		listStoresResp, err := s.ListStores(ctx, &openfgav1.ListStoresRequest{PageSize: wrapperspb.Int32(1), ContinuationToken: continuationToken})
+		require.NoError(t, err)
+		for _, store := range listStoresResp.GetStores() {
+			listed = append(listed, store.GetId())
+		}
+		continuationToken = listStoresResp.GetContinuationToken()
+		if continuationToken == "" {
+			break
+		}
+	}
+	require.ElementsMatch(t, storeIDs, listed)
+
+	for storeID := range stores {
+		_, err := s.DeleteStore(ctx, &openfgav1.DeleteStoreRequest{StoreId: storeID})
+		require.NoError(t, err)
+		_, err = s.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: storeID})
+		require.Equal(t, codes.Code(openfgav1.NotFoundErrorCode_store_id_not_found), status.Code(err))
+	}
+}
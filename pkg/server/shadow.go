@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/server/commands"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+const (
+	// shadowModelEvaluationWorkers is the number of goroutines evaluating shadow checks.
+	shadowModelEvaluationWorkers = 4
+	// shadowModelEvaluationQueueSize is the number of shadow checks that may wait for a worker.
+	// Checks sampled while the queue is full are dropped.
+	shadowModelEvaluationQueueSize = 100
+	// shadowModelEvaluationTimeout bounds the duration of a single shadow check.
+	shadowModelEvaluationTimeout = 3 * time.Second
+
+	shadowResultAgreement  = "agreement"
+	shadowResultDivergence = "divergence"
+	shadowResultError      = "error"
+	shadowResultDropped    = "dropped"
+)
+
+var shadowModelEvaluationCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "shadow_model_evaluation_count",
+	Help:      "The total number of Check requests evaluated again against the latest authorization model, labeled by result (agreement, divergence, error or dropped).",
+}, []string{"result"})
+
+// shadowCheck is a Check request that was answered using a pinned model, to be evaluated against the latest model.
+type shadowCheck struct {
+	req     *openfgav1.CheckRequest
+	allowed bool
+}
+
+// shadowModelEvaluator evaluates sampled Check requests again against the latest authorization
+// model of the store, and records whether the result agrees with the one of the pinned model.
+// Evaluations run in a bounded worker pool and never affect the original request.
+type shadowModelEvaluator struct {
+	server     *Server
+	sampleRate float64
+	queue      chan shadowCheck
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+func newShadowModelEvaluator(s *Server, sampleRate float64) *shadowModelEvaluator {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	e := &shadowModelEvaluator{
+		server:     s,
+		sampleRate: sampleRate,
+		queue:      make(chan shadowCheck, shadowModelEvaluationQueueSize),
+		cancel:     cancel,
+	}
+
+	for i := 0; i < shadowModelEvaluationWorkers; i++ {
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case check := <-e.queue:
+					e.evaluate(ctx, check)
+				}
+			}
+		}()
+	}
+
+	return e
+}
+
+// maybeEnqueue samples Check requests that pinned a model, and queues them for shadow evaluation.
+// It never blocks.
+func (e *shadowModelEvaluator) maybeEnqueue(req *openfgav1.CheckRequest, allowed bool) {
+	if req.GetAuthorizationModelId() == "" || rand.Float64() >= e.sampleRate {
+		return
+	}
+
+	select {
+	case e.queue <- shadowCheck{req: proto.Clone(req).(*openfgav1.CheckRequest), allowed: allowed}:
+	default:
+		shadowModelEvaluationCounter.WithLabelValues(shadowResultDropped).Inc()
+	}
+}
+
+func (e *shadowModelEvaluator) evaluate(ctx context.Context, check shadowCheck) {
+	ctx, cancel := context.WithTimeout(ctx, shadowModelEvaluationTimeout)
+	defer cancel()
+
+	s := e.server
+	req := check.req
+	pinnedModelID := req.GetAuthorizationModelId()
+
+	typesys, err := s.typesystemResolver(ctx, req.GetStoreId(), "")
+	if err != nil {
+		shadowModelEvaluationCounter.WithLabelValues(shadowResultError).Inc()
+		return
+	}
+
+	latestModelID := typesys.GetAuthorizationModelID()
+	if latestModelID == pinnedModelID {
+		// the pinned model is the latest, there is nothing to compare
+		return
+	}
+
+	req.AuthorizationModelId = latestModelID
+	resp, _, err := commands.NewCheckCommand(
+		s.checkDatastore,
+		s.checkResolver,
+		typesys,
+		commands.WithCheckCommandLogger(s.logger),
+		commands.WithCheckCommandMaxConcurrentReads(s.maxConcurrentReadsForCheck),
+		commands.WithCheckCommandResolveNodeLimit(s.resolveNodeLimit),
+	).Execute(ctx, req)
+	if err != nil {
+		shadowModelEvaluationCounter.WithLabelValues(shadowResultError).Inc()
+		return
+	}
+
+	if resp.GetAllowed() == check.allowed {
+		shadowModelEvaluationCounter.WithLabelValues(shadowResultAgreement).Inc()
+		return
+	}
+
+	shadowModelEvaluationCounter.WithLabelValues(shadowResultDivergence).Inc()
+	s.logger.Warn("shadow model evaluation diverged",
+		zap.String("store_id", req.GetStoreId()),
+		zap.String("tuple_key", tuple.TupleKeyToString(req.GetTupleKey())),
+		zap.String("authorization_model_id", pinnedModelID),
+		zap.String("latest_authorization_model_id", latestModelID),
+		zap.Bool("allowed", check.allowed),
+		zap.Bool("latest_allowed", resp.GetAllowed()),
+	)
+}
+
+// Close stops the workers. Queued checks are discarded.
+func (e *shadowModelEvaluator) Close() {
+	e.cancel()
+	e.wg.Wait()
+}
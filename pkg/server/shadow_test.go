@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestShadowModelEvaluation(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	observerLogger, logs := observer.New(zap.WarnLevel)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithLogger(&logger.ZapLogger{Logger: zap.New(observerLogger)}),
+		WithShadowModelEvaluation(1),
+	)
+	t.Cleanup(s.Close)
+
+	createStoreResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "shadow"})
+	require.NoError(t, err)
+	storeID := createStoreResp.GetId()
+
+	writeModel := func(dsl string) string {
+		model := testutils.MustTransformDSLToProtoWithID(dsl)
+		resp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+		return resp.GetAuthorizationModelId()
+	}
+
+	oldModelID := writeModel(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define blocked: [user]
+				define viewer: [user]`)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+				tuple.NewTupleKey("document:1", "blocked", "user:anne"),
+				tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	// the latest model denies access to blocked users
+	writeModel(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define blocked: [user]
+				define viewer: [user] but not blocked`)
+
+	agreements := shadowModelEvaluationCounter.WithLabelValues(shadowResultAgreement)
+	divergences := shadowModelEvaluationCounter.WithLabelValues(shadowResultDivergence)
+	initialAgreements := testutil.ToFloat64(agreements)
+	initialDivergences := testutil.ToFloat64(divergences)
+
+	t.Run("divergence_is_counted_and_logged", func(t *testing.T) {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: oldModelID,
+			TupleKey:             tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed()) // the response always uses the pinned model
+
+		require.Eventually(t, func() bool {
+			return testutil.ToFloat64(divergences) == initialDivergences+1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		entries := logs.FilterMessage("shadow model evaluation diverged").All()
+		require.Len(t, entries, 1)
+		require.Equal(t, "document:1#viewer@user:anne", entries[0].ContextMap()["tuple_key"])
+		require.Equal(t, oldModelID, entries[0].ContextMap()["authorization_model_id"])
+	})
+
+	t.Run("agreement_is_counted", func(t *testing.T) {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: oldModelID,
+			TupleKey:             tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:bob"),
+		})
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+
+		require.Eventually(t, func() bool {
+			return testutil.ToFloat64(agreements) == initialAgreements+1
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("requests_without_pinned_model_are_not_sampled", func(t *testing.T) {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+		require.Empty(t, s.shadowModelEvaluator.queue)
+	})
+}
+
+func TestShadowModelEvaluationSampleRateValidation(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	_, err := NewServerWithOpts(WithDatastore(ds), WithShadowModelEvaluation(1.5))
+	require.ErrorContains(t, err, "shadow model evaluation sample rate must be between 0 and 1")
+}
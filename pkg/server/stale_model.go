@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"strconv"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// checkWriteModelIsLatest returns a WriteOnStaleAuthorizationModel error if the server rejects
+// writes on stale models for the store of the request and the request was evaluated with a
+// model, selected by ID or alias, other than the latest of the store.
+func (s *Server) checkWriteModelIsLatest(ctx context.Context, req *openfgav1.WriteRequest, typesys *typesystem.TypeSystem) error {
+	storeID := req.GetStoreId()
+
+	reject, ok := s.rejectWritesOnStaleModelOverrides[storeID]
+	if !ok {
+		reject = s.rejectWritesOnStaleModel
+	}
+	if !reject || allowStaleModelFromMetadata(ctx) {
+		return nil
+	}
+
+	// without a model ID or alias, the request is evaluated with the latest model
+	if req.GetAuthorizationModelId() == "" && len(metadata.ValueFromIncomingContext(ctx, AuthorizationModelAliasHeader)) == 0 {
+		return nil
+	}
+
+	latest, err := s.typesystemResolver(ctx, storeID, "")
+	if err != nil {
+		return serverErrors.HandleError("", err)
+	}
+
+	modelID := typesys.GetAuthorizationModelID()
+	if latestModelID := latest.GetAuthorizationModelID(); modelID != latestModelID {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("stale_model_rejected", true))
+		staleModelWritesRejectedCounter.WithLabelValues(s.perStoreWriteMetricsLabel(storeID)).Inc()
+		return serverErrors.WriteOnStaleAuthorizationModel(modelID, latestModelID)
+	}
+
+	return nil
+}
+
+// allowStaleModelFromMetadata returns true if the WriteAllowStaleModelHeader metadata is "true".
+func allowStaleModelFromMetadata(ctx context.Context) bool {
+	values := metadata.ValueFromIncomingContext(ctx, WriteAllowStaleModelHeader)
+	if len(values) == 0 {
+		return false
+	}
+	allow, err := strconv.ParseBool(values[0])
+	return err == nil && allow
+}
+
+// perStoreWriteMetricsLabel returns the store_id label of the write metrics of a store: its ID
+// if it is allowlisted by WithPerStoreWriteMetrics, and "other" otherwise.
+func (s *Server) perStoreWriteMetricsLabel(storeID string) string {
+	if _, ok := s.perStoreWriteMetricsAllowlist[storeID]; ok {
+		return storeID
+	}
+	return "other"
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openfga/openfga/internal/throttler"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// Stats is a snapshot of the counters maintained by a [Server], for embedders that export their
+// own metrics. See [Server.Stats].
+type Stats struct {
+	// Uptime is the time elapsed since the server was created.
+	Uptime time.Duration
+
+	// InFlightRequests is the number of requests being served, by method, e.g. "Check". Methods
+	// that were never called are absent.
+	InFlightRequests map[string]int64
+
+	// CheckCache describes the cache shared by the Check query cache and the Check iterator
+	// cache. It is zero if both are disabled.
+	CheckCache CacheStats
+
+	// TypesystemCacheSize is the number of memoized type systems of authorization models.
+	TypesystemCacheSize int
+
+	// ThrottlerQueueDepths is the number of dispatches waiting in each dispatch throttler, by
+	// throttler name: "check", "list_objects" and "list_users". Disabled throttlers are absent.
+	ThrottlerQueueDepths map[string]int64
+}
+
+// CacheStats describes a cache of a [Server].
+type CacheStats struct {
+	// Size is the number of entries in the cache, including expired ones that were not evicted yet.
+	Size int
+
+	// Hits and Misses count the lookups of the cache since the server was created. Expired
+	// entries count as misses.
+	Hits   uint64
+	Misses uint64
+}
+
+// HitRate returns the share of the lookups that were hits, or 0 if there were none.
+func (c CacheStats) HitRate() float64 {
+	lookups := c.Hits + c.Misses
+	if lookups == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(lookups)
+}
+
+// Stats returns a snapshot of the counters of the server. It is safe to call concurrently with
+// requests, and cheap enough to be called on every scrape of a metrics system: it only loads
+// atomic counters and the sizes of the caches.
+//
+// The counters are loaded one at a time while requests are served, so they are not consistent
+// with each other to the request.
+func (s *Server) Stats() Stats {
+	stats := Stats{
+		Uptime:               time.Since(s.createdAt),
+		InFlightRequests:     s.inFlightRequests.snapshot(),
+		TypesystemCacheSize:  s.memoizedTypesystemResolver.Len(),
+		ThrottlerQueueDepths: map[string]int64{},
+	}
+
+	if s.cache != nil {
+		stats.CheckCache = s.cache.stats()
+	}
+
+	for name, t := range map[string]throttler.Throttler{
+		"check":        s.checkDispatchThrottler,
+		"list_objects": s.listObjectsDispatchThrottler,
+		"list_users":   s.listUsersDispatchThrottler,
+	} {
+		if t != nil {
+			stats.ThrottlerQueueDepths[name] = throttler.QueueDepth(t)
+		}
+	}
+
+	return stats
+}
+
+// inFlightRequests counts the requests being served by method.
+type inFlightRequests struct {
+	// map: method => *atomic.Int64
+	counts sync.Map
+}
+
+// start counts a request of method as in flight, until the returned function is called. It is
+// meant to be deferred at the top of a handler: defer s.inFlightRequests.start(method)().
+func (r *inFlightRequests) start(method string) func() {
+	// the handlers name their methods either "Check" or "/openfga.v1.OpenFGAService/Check"
+	method = method[strings.LastIndex(method, "/")+1:]
+
+	count, ok := r.counts.Load(method)
+	if !ok {
+		count, _ = r.counts.LoadOrStore(method, &atomic.Int64{})
+	}
+	count.(*atomic.Int64).Add(1)
+	return func() {
+		count.(*atomic.Int64).Add(-1)
+	}
+}
+
+func (r *inFlightRequests) snapshot() map[string]int64 {
+	snapshot := map[string]int64{}
+	r.counts.Range(func(method, count any) bool {
+		snapshot[method.(string)] = count.(*atomic.Int64).Load()
+		return true
+	})
+	return snapshot
+}
+
+// countingCache counts the hits and misses of a cache.
+type countingCache struct {
+	*storage.InMemoryLRUCache[any]
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+var _ storage.InMemoryCache[any] = (*countingCache)(nil)
+
+func newCountingCache(cache *storage.InMemoryLRUCache[any]) *countingCache {
+	return &countingCache{InMemoryLRUCache: cache}
+}
+
+func (c *countingCache) Get(key string) *storage.CachedResult[any] {
+	res := c.InMemoryLRUCache.Get(key)
+	if res == nil || res.Expired {
+		c.misses.Add(1)
+	} else {
+		c.hits.Add(1)
+	}
+	return res
+}
+
+func (c *countingCache) stats() CacheStats {
+	return CacheStats{
+		Size:   c.Len(),
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+func TestServerStats(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithCheckQueryCacheEnabled(true),
+		WithCacheLimit(100),
+		WithCheckQueryCacheTTL(1*time.Minute),
+		WithDispatchThrottlingCheckResolverEnabled(true),
+		WithListObjectsDispatchThrottlingEnabled(true),
+	)
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "stats"})
+	require.NoError(t, err)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId: store.GetId(),
+		TypeDefinitions: language.MustTransformDSLToProto(`
+			model
+				schema 1.1
+			type user
+			type document
+				relations
+					define viewer: [user]`).GetTypeDefinitions(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+	})
+	require.NoError(t, err)
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: store.GetId(),
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:jon")},
+		},
+	})
+	require.NoError(t, err)
+
+	check := func() {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  store.GetId(),
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:jon"),
+		})
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+	}
+
+	// the first Check misses the cache, the next ones hit it
+	check()
+
+	// the stats are read while the requests are served
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				stats := s.Stats()
+				require.GreaterOrEqual(t, stats.InFlightRequests["Check"], int64(0))
+			}
+		}
+	}()
+
+	const checks = 10
+	for i := 1; i < checks; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			check()
+		}()
+	}
+	require.Eventually(t, func() bool {
+		return s.Stats().CheckCache.Hits+s.Stats().CheckCache.Misses >= checks
+	}, time.Second, time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	stats := s.Stats()
+	require.Positive(t, stats.Uptime)
+	require.Equal(t, map[string]int64{
+		"CreateStore":             0,
+		"WriteAuthorizationModel": 0,
+		"Write":                   0,
+		"Check":                   0,
+	}, stats.InFlightRequests)
+	require.Equal(t, 1, stats.TypesystemCacheSize)
+	require.Equal(t, map[string]int64{"check": 0, "list_objects": 0}, stats.ThrottlerQueueDepths)
+
+	require.Positive(t, stats.CheckCache.Size)
+	require.Equal(t, uint64(1), stats.CheckCache.Misses)
+	require.Equal(t, uint64(checks-1), stats.CheckCache.Hits)
+	require.InDelta(t, 0.9, stats.CheckCache.HitRate(), 1e-9)
+}
+
+func TestInFlightRequests(t *testing.T) {
+	var r inFlightRequests
+	require.Empty(t, r.snapshot())
+
+	done := r.start(openfgav1.OpenFGAService_Check_FullMethodName)
+	doneToo := r.start("Check")
+	require.Equal(t, map[string]int64{"Check": 2}, r.snapshot())
+
+	done()
+	doneToo()
+	require.Equal(t, map[string]int64{"Check": 0}, r.snapshot())
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// streamModelLagCheck returns the check that ends a StreamedListObjects stream evaluated with the
+// model modelID once the latest model of the store is more than listObjectsStreamMaxModelLag
+// versions newer. The latest model is found with the memoized typesystem resolver, and the
+// versions are only counted once it changed. Errors of the check itself are logged and do not
+// end the stream.
+func (s *Server) streamModelLagCheck(storeID, modelID string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		latest, err := s.typesystemResolver(ctx, storeID, "")
+		if err != nil {
+			s.logger.WarnWithContext(ctx, "failed to resolve the latest authorization model of the stream", zap.Error(err))
+			return nil
+		}
+
+		latestModelID := latest.GetAuthorizationModelID()
+		if latestModelID == modelID {
+			return nil
+		}
+
+		versions, err := s.countNewerModels(ctx, storeID, modelID, int(s.listObjectsStreamMaxModelLag)+1)
+		if err != nil {
+			s.logger.WarnWithContext(ctx, "failed to count the authorization models written during the stream", zap.Error(err))
+			return nil
+		}
+
+		if versions > int(s.listObjectsStreamMaxModelLag) {
+			return serverErrors.StreamedListObjectsModelChanged(modelID, latestModelID, versions)
+		}
+		return nil
+	}
+}
+
+// countNewerModels returns the number of models of the store written after modelID, counting
+// at most limit of them.
+func (s *Server) countNewerModels(ctx context.Context, storeID, modelID string, limit int) (int, error) {
+	count := 0
+	opts := storage.ReadAuthorizationModelsOptions{
+		Pagination: storage.PaginationOptions{PageSize: limit},
+	}
+	for {
+		models, contToken, err := s.datastore.ReadAuthorizationModels(ctx, storeID, opts)
+		if err != nil {
+			return 0, err
+		}
+
+		// models are read from the newest
+		for _, model := range models {
+			if model.GetId() == modelID || count == limit {
+				return count, nil
+			}
+			count++
+		}
+
+		if len(contToken) == 0 {
+			return count, nil
+		}
+		opts.Pagination.From = string(contToken)
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+// The gRPC services of the server that the OpenFGA API does not define, e.g. the service of
+// ListReachableRelations, have no messages of their own: their requests and responses are
+// Structs.
+
+// structStringFields returns the fields of req, which must be strings and among names.
+func structStringFields(req *structpb.Struct, names ...string) (map[string]string, error) {
+	fields := map[string]string{}
+	for name, value := range req.GetFields() {
+		if !slices.Contains(names, name) {
+			return nil, serverErrors.InvalidRequestField(name, "unknown field")
+		}
+		str, ok := value.GetKind().(*structpb.Value_StringValue)
+		if !ok {
+			return nil, serverErrors.InvalidRequestField(name, "must be a string")
+		}
+		fields[name] = str.StringValue
+	}
+	return fields, nil
+}
+
+// jsonStruct returns a Struct with the fields of the JSON form of v, where description names v
+// in the error.
+func jsonStruct(v any, description string) (*structpb.Struct, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, serverErrors.HandleError("", fmt.Errorf("failed to encode %s: %w", description, err))
+	}
+	resp := &structpb.Struct{}
+	if err := protojson.Unmarshal(b, resp); err != nil {
+		return nil, serverErrors.HandleError("", fmt.Errorf("failed to encode %s: %w", description, err))
+	}
+	return resp, nil
+}
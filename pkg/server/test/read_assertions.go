@@ -4,31 +4,74 @@ import (
 	"context"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
 
 	"github.com/openfga/openfga/pkg/server/commands"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
 )
 
 func TestReadAssertionQuery(t *testing.T, datastore storage.OpenFGADatastore) {
 	type readAssertionsQueryTest struct {
 		_name            string
-		request          *openfgav1.ReadAssertionsRequest
-		expectedResponse *openfgav1.ReadAssertionsResponse
+		assertions       []*openfgav1.Assertion
+		request          *commands.ReadAssertionsRequest
+		expectedResponse *commands.ReadAssertionsResponse
 		expectedError    error
 	}
 
+	docViewer := &openfgav1.Assertion{TupleKey: tuple.NewAssertionTupleKey("document:1", "viewer", "user:anne"), Expectation: true}
+	docEditor := &openfgav1.Assertion{TupleKey: tuple.NewAssertionTupleKey("document:1", "editor", "user:anne"), Expectation: false}
+	folderViewer := &openfgav1.Assertion{TupleKey: tuple.NewAssertionTupleKey("folder:1", "viewer", "user:bob"), Expectation: true}
+	allAssertions := []*openfgav1.Assertion{docViewer, docEditor, folderViewer}
+
 	var tests = []readAssertionsQueryTest{
 		{
 			_name:   "ReturnsAssertionModelNotFound",
-			request: &openfgav1.ReadAssertionsRequest{StoreId: "store", AuthorizationModelId: "test"},
-			expectedResponse: &openfgav1.ReadAssertionsResponse{
-				AuthorizationModelId: "test",
+			request: &commands.ReadAssertionsRequest{AuthorizationModelID: "test"},
+			expectedResponse: &commands.ReadAssertionsResponse{
+				AuthorizationModelID: "test",
 				Assertions:           []*openfgav1.Assertion{},
 			},
 		},
+		{
+			_name:      "FiltersByObjectType",
+			assertions: allAssertions,
+			request:    &commands.ReadAssertionsRequest{AuthorizationModelID: "test", ObjectType: "document"},
+			expectedResponse: &commands.ReadAssertionsResponse{
+				AuthorizationModelID: "test",
+				Assertions:           []*openfgav1.Assertion{docViewer, docEditor},
+			},
+		},
+		{
+			_name:      "FiltersByObjectTypeAndRelation",
+			assertions: allAssertions,
+			request:    &commands.ReadAssertionsRequest{AuthorizationModelID: "test", ObjectType: "document", Relation: "viewer"},
+			expectedResponse: &commands.ReadAssertionsResponse{
+				AuthorizationModelID: "test",
+				Assertions:           []*openfgav1.Assertion{docViewer},
+			},
+		},
+		{
+			_name:      "FiltersByRelation",
+			assertions: allAssertions,
+			request:    &commands.ReadAssertionsRequest{AuthorizationModelID: "test", Relation: "viewer"},
+			expectedResponse: &commands.ReadAssertionsResponse{
+				AuthorizationModelID: "test",
+				Assertions:           []*openfgav1.Assertion{docViewer, folderViewer},
+			},
+		},
+		{
+			_name:         "ReturnsInvalidContinuationToken",
+			assertions:    allAssertions,
+			request:       &commands.ReadAssertionsRequest{AuthorizationModelID: "test", PageSize: 1, ContinuationToken: "Zm9v"},
+			expectedError: serverErrors.InvalidContinuationToken,
+		},
 	}
 
 	ctx := context.Background()
@@ -36,17 +79,46 @@ func TestReadAssertionQuery(t *testing.T, datastore storage.OpenFGADatastore) {
 	for _, test := range tests {
 		t.Run(test._name, func(t *testing.T) {
 			store := testutils.CreateRandomString(10)
+			if test.assertions != nil {
+				err := datastore.WriteAssertions(ctx, store, test.request.AuthorizationModelID, test.assertions)
+				require.NoError(t, err)
+			}
 
 			query := commands.NewReadAssertionsQuery(datastore)
-			test.request.StoreId = store
-			actualResponse, actualError := query.Execute(ctx, test.request.GetStoreId(), test.request.GetAuthorizationModelId())
+			test.request.StoreID = store
+			actualResponse, actualError := query.Execute(ctx, test.request)
 
 			if test.expectedError != nil {
 				require.ErrorIs(t, actualError, test.expectedError)
 			} else {
 				require.NoError(t, actualError)
-				require.Equal(t, test.expectedResponse, actualResponse)
+				if diff := cmp.Diff(test.expectedResponse, actualResponse, protocmp.Transform()); diff != "" {
+					t.Errorf("response mismatch (-want +got):\n%s", diff)
+				}
 			}
 		})
 	}
+
+	t.Run("ReturnsFilteredPages", func(t *testing.T) {
+		store := testutils.CreateRandomString(10)
+		err := datastore.WriteAssertions(ctx, store, "test", allAssertions)
+		require.NoError(t, err)
+
+		query := commands.NewReadAssertionsQuery(datastore)
+		req := &commands.ReadAssertionsRequest{StoreID: store, AuthorizationModelID: "test", Relation: "viewer", PageSize: 1}
+
+		var got []string
+		for {
+			resp, err := query.Execute(ctx, req)
+			require.NoError(t, err)
+			require.Len(t, resp.Assertions, 1)
+			got = append(got, resp.Assertions[0].GetTupleKey().GetObject())
+
+			if resp.ContinuationToken == "" {
+				break
+			}
+			req.ContinuationToken = resp.ContinuationToken
+		}
+		require.Equal(t, []string{"document:1", "folder:1"}, got)
+	})
 }
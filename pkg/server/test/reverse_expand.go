@@ -1319,7 +1319,7 @@ func TestReverseExpand(t *testing.T, ds storage.OpenFGADatastore) {
 			timeoutCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
 
-			resolutionMetadata := reverseexpand.NewResolutionMetadata()
+			resolutionMetadata := graph.NewResolutionMetadata()
 
 			reverseExpandErrCh := make(chan error, 1)
 			go func() {
@@ -1344,7 +1344,7 @@ func TestReverseExpand(t *testing.T, ds storage.OpenFGADatastore) {
 						t.Log("channel closed")
 						if test.expectedError == nil {
 							require.ElementsMatch(t, test.expectedResult, results)
-							require.Equal(t, test.expectedDSQueryCount, *resolutionMetadata.DatastoreQueryCount)
+							require.Equal(t, test.expectedDSQueryCount, resolutionMetadata.DatastoreQueryCount.Load())
 						} else {
 							require.FailNow(t, "expected an error, got none")
 						}
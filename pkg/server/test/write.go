@@ -77,7 +77,7 @@ func TestWriteCommand(t *testing.T, datastore storage.OpenFGADatastore) {
 			},
 
 			// output
-			err: serverErrors.DuplicateTupleInWrite(tk),
+			err: serverErrors.DuplicateTupleInWrites(tk, 0, 1),
 		},
 		{
 			_name: "ExecuteWithWriteToIndirectUnionRelationshipReturnsError",
@@ -109,7 +109,7 @@ func TestWriteCommand(t *testing.T, datastore storage.OpenFGADatastore) {
 			// output
 			err: serverErrors.ValidationError(
 				&tuple.InvalidTupleError{
-					Cause:    fmt.Errorf("type 'user' is not an allowed type restriction for 'repo#viewer'"),
+					Cause:    &tuple.RelationNotAssignableError{TypeName: "repo", Relation: "viewer"},
 					TupleKey: tuple.NewTupleKey("repo:openfga/openfga", "viewer", "user:github|alice@openfga.com"),
 				},
 			),
@@ -144,7 +144,7 @@ func TestWriteCommand(t *testing.T, datastore storage.OpenFGADatastore) {
 			// output
 			err: serverErrors.ValidationError(
 				&tuple.InvalidTupleError{
-					Cause:    fmt.Errorf("type 'user' is not an allowed type restriction for 'repo#viewer'"),
+					Cause:    &tuple.RelationNotAssignableError{TypeName: "repo", Relation: "viewer"},
 					TupleKey: tuple.NewTupleKey("repo:openfga/openfga", "viewer", "user:github|alice@openfga.com"),
 				},
 			),
@@ -206,7 +206,7 @@ func TestWriteCommand(t *testing.T, datastore storage.OpenFGADatastore) {
 			// output
 			err: serverErrors.ValidationError(
 				&tuple.InvalidTupleError{
-					Cause:    fmt.Errorf("type 'user' is not an allowed type restriction for 'repo#viewer'"),
+					Cause:    &tuple.RelationNotAssignableError{TypeName: "repo", Relation: "viewer"},
 					TupleKey: tuple.NewTupleKey("repo:openfga/openfga", "viewer", "user:github|alice@openfga.com"),
 				},
 			),
@@ -241,7 +241,7 @@ func TestWriteCommand(t *testing.T, datastore storage.OpenFGADatastore) {
 			// output
 			err: serverErrors.ValidationError(
 				&tuple.InvalidTupleError{
-					Cause:    fmt.Errorf("type 'user' is not an allowed type restriction for 'repo#viewer'"),
+					Cause:    &tuple.RelationNotAssignableError{TypeName: "repo", Relation: "viewer"},
 					TupleKey: tuple.NewTupleKey("repo:openfga/openfga", "viewer", "user:github|alice@openfga.com"),
 				},
 			),
@@ -279,7 +279,7 @@ func TestWriteCommand(t *testing.T, datastore storage.OpenFGADatastore) {
 			// output
 			err: serverErrors.ValidationError(
 				&tuple.InvalidTupleError{
-					Cause:    fmt.Errorf("type 'user' is not an allowed type restriction for 'repo#viewer'"),
+					Cause:    &tuple.RelationNotAssignableError{TypeName: "repo", Relation: "viewer"},
 					TupleKey: tuple.NewTupleKey("repo:openfga/openfga", "viewer", "user:github|alice@openfga.com"),
 				},
 			),
@@ -306,7 +306,7 @@ func TestWriteCommand(t *testing.T, datastore storage.OpenFGADatastore) {
 				},
 			},
 			// output
-			err: serverErrors.DuplicateTupleInWrite(tk),
+			err: serverErrors.DuplicateTupleInDeletes(tk, 0, 1),
 		},
 		{
 			_name: "ExecuteWithSameTupleInWritesAndDeletesReturnsError",
@@ -333,7 +333,7 @@ func TestWriteCommand(t *testing.T, datastore storage.OpenFGADatastore) {
 				},
 			},
 			// output
-			err: serverErrors.DuplicateTupleInWrite(tk),
+			err: serverErrors.TupleInWritesAndDeletes(tk, 0, 0),
 		},
 		{
 			_name: "ExecuteDeleteTupleWhichDoesNotExistReturnsError",
@@ -915,7 +915,7 @@ func TestWriteCommand(t *testing.T, datastore storage.OpenFGADatastore) {
 			},
 			err: serverErrors.ValidationError(
 				&tuple.InvalidTupleError{
-					Cause:    fmt.Errorf("type 'user' is not an allowed type restriction for 'document#reader'"),
+					Cause:    &tuple.UserTypeNotAllowedError{TypeName: "document", Relation: "reader", UserType: "user"},
 					TupleKey: tuple.NewTupleKey("document:budget", "reader", "user:abc"),
 				},
 			),
@@ -1091,7 +1091,7 @@ func TestWriteCommand(t *testing.T, datastore storage.OpenFGADatastore) {
 			},
 			err: serverErrors.ValidationError(
 				&tuple.InvalidTupleError{
-					Cause:    fmt.Errorf("type 'user' is not an allowed type restriction for 'document#reader'"),
+					Cause:    &tuple.UserTypeNotAllowedError{TypeName: "document", Relation: "reader", UserType: "user"},
 					TupleKey: tuple.NewTupleKey("document:budget", "reader", "user:abc"),
 				},
 			),
@@ -1283,7 +1283,7 @@ func TestWriteCommand(t *testing.T, datastore storage.OpenFGADatastore) {
 			},
 			err: serverErrors.ValidationError(
 				&tuple.InvalidTupleError{
-					Cause:    fmt.Errorf("the typed wildcard 'group:*' is not an allowed type restriction for 'document#reader'"),
+					Cause:    &tuple.WildcardNotAllowedError{TypeName: "document", Relation: "reader", Wildcard: "group:*"},
 					TupleKey: tuple.NewTupleKey("document:budget", "reader", "group:*"),
 				},
 			),
@@ -1338,7 +1338,7 @@ func TestWriteCommand(t *testing.T, datastore storage.OpenFGADatastore) {
 			},
 			err: serverErrors.ValidationError(
 				&tuple.InvalidTupleError{
-					Cause:    fmt.Errorf("type 'group' is not an allowed type restriction for 'resource#writer'"),
+					Cause:    &tuple.UserTypeNotAllowedError{TypeName: "resource", Relation: "writer", UserType: "group"},
 					TupleKey: tuple.NewTupleKey("resource:bad", "writer", "group:fga"),
 				},
 			),
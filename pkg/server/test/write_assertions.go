@@ -197,7 +197,7 @@ func TestWriteAndReadAssertions(t *testing.T, datastore storage.OpenFGADatastore
 					},
 				},
 			},
-			expectErrWhenWriting: "Invalid tuple 'repo:test#can_read@user:elbuo'. Reason: type 'user' is not an allowed type restriction for 'repo#can_read'",
+			expectErrWhenWriting: "Invalid tuple 'repo:test#can_read@user:elbuo'. Reason: relation 'repo#can_read' is not assignable: it has no type restrictions, so it accepts no direct users",
 		},
 		{
 			_name:        "writing_assertion_with_contextual_tuple_fails_because_invalid_relation_in_contextual_tuple",
@@ -267,11 +267,14 @@ func TestWriteAndReadAssertions(t *testing.T, datastore storage.OpenFGADatastore
 			} else {
 				require.NoError(t, err)
 
-				actualResponse, err := commands.NewReadAssertionsQuery(datastore).Execute(context.Background(), store, test.inputModelID)
+				actualResponse, err := commands.NewReadAssertionsQuery(datastore).Execute(context.Background(), &commands.ReadAssertionsRequest{
+					StoreID:              store,
+					AuthorizationModelID: test.inputModelID,
+				})
 				require.NoError(t, err)
 
-				expectedResponse := &openfgav1.ReadAssertionsResponse{
-					AuthorizationModelId: test.inputModelID,
+				expectedResponse := &commands.ReadAssertionsResponse{
+					AuthorizationModelID: test.inputModelID,
 					Assertions:           test.assertions,
 				}
 				if diff := cmp.Diff(expectedResponse, actualResponse, protocmp.Transform()); diff != "" {
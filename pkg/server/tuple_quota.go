@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/openfga/openfga/pkg/middleware/validator"
+	"github.com/openfga/openfga/pkg/server/commands"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+// tupleQuotaForStore returns the quota of the store: its override if WithTupleQuotaOverrides sets
+// one, and the quota of WithTupleQuota otherwise.
+func (s *Server) tupleQuotaForStore(storeID string) commands.TupleQuota {
+	if quota, ok := s.tupleQuotaOverrides[storeID]; ok {
+		return quota
+	}
+	return s.tupleQuota
+}
+
+// GetStoreStatistics returns the number of tuples of a store, in total and per object type, as
+// counted by the datastore, along with the quota of the store (see [WithTupleQuota]). The
+// datastore must implement [storage.TupleCounter], which the built-in datastores do.
+//
+// Only loopback callers are allowed, see [isLoopbackCaller].
+func (s *Server) GetStoreStatistics(ctx context.Context, storeID string) (_ *commands.StoreStatistics, err error) {
+	ctx, span := tracer.Start(ctx, "GetStoreStatistics", trace.WithAttributes(
+		attribute.String("store_id", storeID),
+	))
+	defer span.End()
+	defer s.inFlightRequests.start("GetStoreStatistics")()
+	defer s.localizeError(ctx, &err)
+	defer s.recoverFromPanic(ctx, "GetStoreStatistics", storeID, &err)
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "GetStoreStatistics",
+	})
+
+	if !isLoopbackCaller(ctx) {
+		span.SetAttributes(attribute.Bool("permission_denied", true))
+		return nil, serverErrors.LoopbackCallerRequired("GetStoreStatistics", "store statistics can only be read from a loopback address")
+	}
+
+	if err := validator.ValidateStoreID(storeID); err != nil {
+		return nil, err
+	}
+
+	if s.tupleCounter == nil {
+		return nil, serverErrors.TupleCountsUnsupported
+	}
+
+	stats, err := commands.NewStoreStatisticsQuery(
+		s.datastore,
+		s.tupleCounter,
+		commands.WithStoreStatisticsQueryLogger(s.logger),
+		commands.WithStoreStatisticsTupleQuota(s.tupleQuotaForStore(storeID)),
+	).Execute(ctx, storeID)
+	if err != nil {
+		telemetry.TraceError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int64("tuple_count", stats.TupleCount))
+
+	return stats, nil
+}
@@ -0,0 +1,3 @@
+// Package watch describes the gRPC service of the streaming ReadChanges of the OpenFGA server,
+// which the OpenFGA API does not define.
+package watch
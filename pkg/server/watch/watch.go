@@ -0,0 +1,57 @@
+package watch
+
+import (
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+)
+
+const (
+	// ServiceName is the name of the gRPC service of StreamedReadChanges. It is not part of the
+	// openfga.v1 package of the OpenFGA API, which has no streaming ReadChanges.
+	ServiceName = "openfga.server.watch.v1.ReadChangesWatchService"
+
+	// StreamedReadChangesFullMethodName is the full gRPC method name of StreamedReadChanges.
+	StreamedReadChangesFullMethodName = "/" + ServiceName + "/StreamedReadChanges"
+)
+
+// ServiceDesc describes the gRPC service of StreamedReadChanges. The service reuses the messages of
+// ReadChanges: clients open a stream with this description (see [grpc.ClientConn.NewStream]), send
+// a ReadChangesRequest and receive ReadChangesResponses.
+var ServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*ReadChangesWatchServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamedReadChanges",
+			Handler:       streamedReadChangesHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+// ReadChangesWatchServer is the server API of the service of ServiceDesc.
+type ReadChangesWatchServer interface {
+	StreamedReadChanges(*openfgav1.ReadChangesRequest, StreamedReadChangesServer) error
+}
+
+// StreamedReadChangesServer is the server side of a StreamedReadChanges stream.
+type StreamedReadChangesServer interface {
+	Send(*openfgav1.ReadChangesResponse) error
+	grpc.ServerStream
+}
+
+type streamedReadChangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *streamedReadChangesServer) Send(m *openfgav1.ReadChangesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func streamedReadChangesHandler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(openfgav1.ReadChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReadChangesWatchServer).StreamedReadChanges(m, &streamedReadChangesServer{stream})
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	tupleutils "github.com/openfga/openfga/pkg/tuple"
+)
+
+// Matches returns true if the assertion passes the filter.
+func (f ReadAssertionsFilter) Matches(assertion *openfgav1.Assertion) bool {
+	tk := assertion.GetTupleKey()
+	if f.ObjectType != "" && tupleutils.GetType(tk.GetObject()) != f.ObjectType {
+		return false
+	}
+	return f.Relation == "" || tk.GetRelation() == f.Relation
+}
+
+// hash returns a hash of the filter, to tell apart the continuation tokens of different filters.
+func (f ReadAssertionsFilter) hash() string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(f.ObjectType + "#" + f.Relation))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// PageAssertions applies the options of ReadAssertions to all the assertions of a store
+// and model. Assertions are written as a whole, so the datastores read them all and
+// filter them here. The continuation token is the offset of the next page among the
+// matching assertions followed by a hash of the filter; a token that is not one, or that
+// was returned for another filter, returns [ErrInvalidContinuationToken].
+func PageAssertions(assertions []*openfgav1.Assertion, options ReadAssertionsOptions) ([]*openfgav1.Assertion, []byte, error) {
+	filterHash := options.Filter.hash()
+
+	from := 0
+	if options.Pagination.From != "" {
+		offset, tokenFilterHash, ok := strings.Cut(options.Pagination.From, ":")
+		if !ok || tokenFilterHash != filterHash {
+			return nil, nil, ErrInvalidContinuationToken
+		}
+		var err error
+		from, err = strconv.Atoi(offset)
+		if err != nil || from < 0 {
+			return nil, nil, ErrInvalidContinuationToken
+		}
+	}
+
+	matching := make([]*openfgav1.Assertion, 0, len(assertions))
+	for _, assertion := range assertions {
+		if options.Filter.Matches(assertion) {
+			matching = append(matching, assertion)
+		}
+	}
+
+	if from > len(matching) {
+		return nil, nil, ErrInvalidContinuationToken
+	}
+
+	to := len(matching)
+	if options.Pagination.PageSize > 0 && from+options.Pagination.PageSize < to {
+		to = from + options.Pagination.PageSize
+	}
+
+	var continuationToken []byte
+	if to < len(matching) {
+		continuationToken = []byte(strconv.Itoa(to) + ":" + filterHash)
+	}
+
+	return matching[from:to], continuationToken, nil
+}
@@ -71,6 +71,16 @@ func (i InMemoryLRUCache[T]) Set(key string, value T, ttl time.Duration) {
 	i.ccache.Set(key, value, ttl)
 }
 
+// Len returns the number of entries in the cache, including the expired ones not evicted yet.
+func (i InMemoryLRUCache[T]) Len() int {
+	return i.ccache.ItemCount()
+}
+
+// DeletePrefix deletes the entries whose key starts with prefix, and returns how many were deleted.
+func (i InMemoryLRUCache[T]) DeletePrefix(prefix string) int {
+	return i.ccache.DeletePrefix(prefix)
+}
+
 func (i InMemoryLRUCache[T]) Stop() {
 	i.closeOnce.Do(func() {
 		i.ccache.Stop()
@@ -20,6 +20,10 @@ var (
 	// object in the ReadChanges API and the type indicated by the continuation token.
 	ErrMismatchObjectType = errors.New("mismatched types in request and continuation token")
 
+	// ErrContinuationTokenExpired is returned by ReadChanges when changes after the continuation
+	// token were deleted from the changelog, e.g. by TrimChanges.
+	ErrContinuationTokenExpired = errors.New("continuation token expired")
+
 	// ErrInvalidWriteInput is returned when the tuple to be written
 	// already existed or the tuple to be deleted did not exist.
 	ErrInvalidWriteInput = errors.New("invalid write input")
@@ -32,8 +36,47 @@ var (
 
 	// ErrNotFound is returned when the object does not exist.
 	ErrNotFound = errors.New("not found")
+
+	// ErrStoreDeleted is returned by the reads of the authorization models of a store that was
+	// deleted, whose models the datastore may keep. It wraps ErrNotFound.
+	ErrStoreDeleted = fmt.Errorf("%w: the store was deleted", ErrNotFound)
+
+	// ErrChangelogDisabled is returned by ReadChanges when the datastore does not write a changelog.
+	ErrChangelogDisabled = errors.New("the changelog is disabled for this datastore")
+
+	// ErrReadOnly is returned by the writes to a datastore that is read-only.
+	ErrReadOnly = errors.New("the datastore is read-only")
+
+	// ErrQueryShapeSamplingDisabled is returned by AdviseIndexes when the datastore does not sample
+	// the shapes of its queries.
+	ErrQueryShapeSamplingDisabled = errors.New("the sampling of query shapes is disabled for this datastore")
+
+	// ErrTupleCountsDisabled is returned by ReadTupleCounts when the datastore does not maintain
+	// the tuple counters of the stores.
+	ErrTupleCountsDisabled = errors.New("the tuple counters are disabled for this datastore")
+
+	// ErrUnimplemented is returned by the methods of an optional interface that a datastore
+	// implements only for some of its stores, e.g. a router whose partitions do not all
+	// implement it.
+	ErrUnimplemented = errors.New("the datastore does not implement the operation")
 )
 
+// StoreNameConflictError is returned by CreateStoreWithUniqueName when another store already has
+// the name of the store to create. It wraps ErrCollision.
+type StoreNameConflictError struct {
+	// StoreID is the ID of the store that has the name.
+	StoreID string
+	Name    string
+}
+
+func (e *StoreNameConflictError) Error() string {
+	return fmt.Sprintf("store '%s' already has the name '%s': %s", e.StoreID, e.Name, ErrCollision)
+}
+
+func (e *StoreNameConflictError) Unwrap() error {
+	return ErrCollision
+}
+
 // ExceededMaxTypeDefinitionsLimitError constructs an error indicating that
 // the maximum allowed limit for type definitions has been exceeded.
 func ExceededMaxTypeDefinitionsLimitError(limit int) error {
@@ -0,0 +1,636 @@
+// Package membershipindex contains a reference implementation of [storage.MembershipIndex] that
+// maintains the flattened members of some relations from the changelog of the datastore.
+package membershipindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+const (
+	DefaultPollInterval    = time.Second
+	DefaultMaxStaleness    = 10 * time.Second
+	DefaultMaxStores       = 1000
+	DefaultIdleTimeout     = 10 * time.Minute
+	DefaultPollConcurrency = 8
+
+	pageSize = 100
+)
+
+var stalenessGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: build.ProjectName,
+	Name:      "membership_index_staleness_seconds",
+	Help:      "How long ago the changelog index of the most stale store was caught up with its changelog, as of the last poll of the membership index. Stores that are not built yet are not counted.",
+})
+
+// Datastore is the datastore the index reads the tuples and the changes of the stores from.
+type Datastore interface {
+	storage.RelationshipTupleReader
+	storage.ChangelogBackend
+}
+
+// ChangelogIndex is a [storage.MembershipIndex] that keeps the tuples of the indexed relations
+// of each store in memory, along with the flattened members of the usersets that were looked up,
+// through the usersets of the indexed relations, which it recomputes as their tuples change.
+//
+// A store is indexed from its first lookup: the index reads the tuples of the indexed relations,
+// then replays the changes since it started reading them, and then polls the changelog for new
+// changes. It answers lookups only once the store is built and as long as it was caught up with
+// the changelog within the max staleness, so it is eventually consistent. The first lookup of a
+// userset is not answered either: the next poll flattens its members. The stores that are not
+// looked up for the idle timeout are dropped, as is the least recently looked up store when a
+// store beyond the max stores is looked up.
+type ChangelogIndex struct {
+	datastore       Datastore
+	relations       map[string]struct{}
+	pollInterval    time.Duration
+	maxStaleness    time.Duration
+	horizonOffset   time.Duration
+	maxStores       int
+	idleTimeout     time.Duration
+	pollConcurrency int
+	logger          logger.Logger
+
+	mu     sync.RWMutex
+	stores map[string]*storeIndex
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ storage.MembershipIndex = (*ChangelogIndex)(nil)
+
+// ChangelogIndexOpt defines an option that can be used to change the behavior of a ChangelogIndex.
+type ChangelogIndexOpt func(*ChangelogIndex)
+
+// WithPollInterval sets how often the index reads the new changes of the indexed stores.
+func WithPollInterval(interval time.Duration) ChangelogIndexOpt {
+	return func(c *ChangelogIndex) {
+		c.pollInterval = interval
+	}
+}
+
+// WithMaxStaleness sets how long after it was last caught up with the changelog of a store the
+// index still answers the lookups for the store.
+func WithMaxStaleness(maxStaleness time.Duration) ChangelogIndexOpt {
+	return func(c *ChangelogIndex) {
+		c.maxStaleness = maxStaleness
+	}
+}
+
+// WithHorizonOffset makes the index only read the changes older than the offset, e.g. the
+// changelog horizon offset of the server, for datastores that can commit a change after a
+// more recent one is read. The index is then at least that stale, so the max staleness must
+// exceed it.
+func WithHorizonOffset(offset time.Duration) ChangelogIndexOpt {
+	return func(c *ChangelogIndex) {
+		c.horizonOffset = offset
+	}
+}
+
+// WithMaxStores sets how many stores the index keeps. Looking up a store beyond it drops the
+// least recently looked up store.
+func WithMaxStores(maxStores int) ChangelogIndexOpt {
+	return func(c *ChangelogIndex) {
+		c.maxStores = maxStores
+	}
+}
+
+// WithIdleTimeout sets how long after its last lookup the index drops a store.
+func WithIdleTimeout(timeout time.Duration) ChangelogIndexOpt {
+	return func(c *ChangelogIndex) {
+		c.idleTimeout = timeout
+	}
+}
+
+// WithPollConcurrency sets how many stores a poll updates at a time.
+func WithPollConcurrency(concurrency int) ChangelogIndexOpt {
+	return func(c *ChangelogIndex) {
+		c.pollConcurrency = concurrency
+	}
+}
+
+// WithLogger sets the logger of the index.
+func WithLogger(logger logger.Logger) ChangelogIndexOpt {
+	return func(c *ChangelogIndex) {
+		c.logger = logger
+	}
+}
+
+// NewChangelogIndex returns a ChangelogIndex of the given relations, as `objectType#relation`,
+// and starts polling the changelog. It must be closed.
+func NewChangelogIndex(datastore Datastore, relations []string, opts ...ChangelogIndexOpt) (*ChangelogIndex, error) {
+	c := &ChangelogIndex{
+		datastore:       datastore,
+		relations:       make(map[string]struct{}, len(relations)),
+		pollInterval:    DefaultPollInterval,
+		maxStaleness:    DefaultMaxStaleness,
+		maxStores:       DefaultMaxStores,
+		idleTimeout:     DefaultIdleTimeout,
+		pollConcurrency: DefaultPollConcurrency,
+		logger:          logger.NewNoopLogger(),
+		stores:          map[string]*storeIndex{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if len(relations) == 0 {
+		return nil, errors.New("the membership index needs at least one relation")
+	}
+	for _, relation := range relations {
+		objectType, name := tuple.SplitObjectRelation(relation)
+		if objectType == "" || name == "" || strings.Contains(objectType, ":") {
+			return nil, fmt.Errorf("invalid membership index relation '%s', it must be 'objectType#relation'", relation)
+		}
+		c.relations[relation] = struct{}{}
+	}
+	if c.pollInterval <= 0 {
+		return nil, errors.New("the membership index poll interval must be greater than 0")
+	}
+	if c.maxStaleness <= c.horizonOffset {
+		return nil, errors.New("the membership index max staleness must be greater than its horizon offset")
+	}
+	if c.maxStores <= 0 {
+		return nil, errors.New("the membership index max stores must be greater than 0")
+	}
+	if c.idleTimeout <= 0 {
+		return nil, errors.New("the membership index idle timeout must be greater than 0")
+	}
+	if c.pollConcurrency <= 0 {
+		return nil, errors.New("the membership index poll concurrency must be greater than 0")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		ticker := time.NewTicker(c.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.poll(ctx)
+			}
+		}
+	}()
+
+	return c, nil
+}
+
+// Relations see [storage.MembershipIndex].Relations.
+func (c *ChangelogIndex) Relations() []string {
+	relations := make([]string, 0, len(c.relations))
+	for relation := range c.relations {
+		relations = append(relations, relation)
+	}
+	return relations
+}
+
+// Lookup see [storage.MembershipIndex].Lookup. The first lookup of a store starts indexing it.
+func (c *ChangelogIndex) Lookup(_ context.Context, store, object, relation, user string) (bool, bool) {
+	if _, ok := c.relations[tuple.ToObjectRelationString(tuple.GetType(object), relation)]; !ok {
+		return false, false
+	}
+	if tuple.IsObjectRelation(user) {
+		return false, false
+	}
+
+	c.mu.RLock()
+	idx, ok := c.stores[store]
+	c.mu.RUnlock()
+	if !ok {
+		c.add(store)
+		return false, false
+	}
+
+	return idx.lookup(tuple.ToObjectRelationString(object, relation), user, c.maxStaleness)
+}
+
+// Close see [storage.MembershipIndex].Close. It waits for the running poll, if any.
+func (c *ChangelogIndex) Close() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+// add starts indexing a store, dropping the least recently looked up store if the index keeps
+// the max stores already.
+func (c *ChangelogIndex) add(storeID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.stores[storeID]; ok {
+		return
+	}
+
+	if len(c.stores) >= c.maxStores {
+		var lru string
+		var lruAt int64
+		for id, idx := range c.stores {
+			if lookedUpAt := idx.lastLookup.Load(); lru == "" || lookedUpAt < lruAt {
+				lru, lruAt = id, lookedUpAt
+			}
+		}
+		delete(c.stores, lru)
+	}
+
+	idx := newStoreIndex(c.relations)
+	idx.lastLookup.Store(time.Now().UnixNano())
+	c.stores[storeID] = idx
+}
+
+// poll drops the idle stores, then builds the stores looked up since the last poll and catches
+// the others up with their changelog, a few stores at a time.
+func (c *ChangelogIndex) poll(ctx context.Context) {
+	idleSince := time.Now().Add(-c.idleTimeout).UnixNano()
+
+	c.mu.Lock()
+	for storeID, idx := range c.stores {
+		if idx.lastLookup.Load() < idleSince {
+			delete(c.stores, storeID)
+		}
+	}
+	stores := maps.Clone(c.stores)
+	c.mu.Unlock()
+
+	var g errgroup.Group
+	g.SetLimit(c.pollConcurrency)
+	for storeID, idx := range stores {
+		g.Go(func() error {
+			c.update(ctx, storeID, idx)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+
+	var staleness time.Duration
+	for _, idx := range stores {
+		if caughtUpAt := idx.lastCaughtUp(); !caughtUpAt.IsZero() && time.Since(caughtUpAt) > staleness {
+			staleness = time.Since(caughtUpAt)
+		}
+	}
+	stalenessGauge.Set(staleness.Seconds())
+}
+
+// update builds a store, or catches it up with its changelog, and flattens the members of the
+// usersets that were looked up for the first time or went through tuples that changed.
+func (c *ChangelogIndex) update(ctx context.Context, storeID string, idx *storeIndex) {
+	var err error
+	if idx.built() {
+		err = c.catchUp(ctx, storeID, idx)
+	} else {
+		err = c.build(ctx, storeID, idx)
+	}
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+
+	if errors.Is(err, storage.ErrContinuationTokenExpired) {
+		// the changes since the last poll were trimmed, so the store is built again
+		c.logger.Info("the membership index of a store is rebuilt as its changelog was trimmed", zap.String("store_id", storeID))
+		idx.reset()
+	} else {
+		c.logger.Warn("failed to update the membership index of a store", zap.String("store_id", storeID), zap.Error(err))
+	}
+}
+
+// build reads the tuples of the indexed relations of a store into a new index, which replaces
+// the one of the store. The changes read after the tuples cover the writes and deletes that
+// occurred while they were read, and replaying them in order leaves each tuple as it ends up.
+// They are sought from the time the tuples started to be read, minus the horizon offset, so that
+// the older changes of the changelog are not read.
+func (c *ChangelogIndex) build(ctx context.Context, storeID string, idx *storeIndex) error {
+	start := time.Now().Add(-c.horizonOffset)
+
+	built := newStoreIndex(c.relations)
+	for relation := range c.relations {
+		objectType, name := tuple.SplitObjectRelation(relation)
+		from := ""
+		for {
+			tuples, next, err := c.datastore.ReadPage(ctx, storeID, tuple.NewTupleKey(objectType+":", name, ""), storage.ReadPageOptions{
+				Pagination: storage.NewPaginationOptions(pageSize, from),
+			})
+			if err != nil {
+				return err
+			}
+			for _, t := range tuples {
+				built.write(t.GetKey())
+			}
+			if len(next) == 0 {
+				break
+			}
+			from = string(next)
+		}
+	}
+
+	readAt := time.Now()
+	token, err := c.readChanges(ctx, storeID, "", start, built.apply)
+	if err != nil {
+		return err
+	}
+
+	idx.replace(built, token, start, readAt.Add(-c.horizonOffset))
+	return nil
+}
+
+// catchUp applies the new changes of a store to its index.
+func (c *ChangelogIndex) catchUp(ctx context.Context, storeID string, idx *storeIndex) error {
+	readAt := time.Now()
+	token, err := c.readChanges(ctx, storeID, idx.token, idx.start, idx.apply)
+	if err != nil {
+		return err
+	}
+	idx.caughtUp(token, readAt.Add(-c.horizonOffset))
+	return nil
+}
+
+// readChanges reads the changes of a store that occurred at or after start, from the given
+// continuation token to the end of its changelog, passes them to apply page by page, and
+// returns the continuation token to read the following changes from.
+func (c *ChangelogIndex) readChanges(ctx context.Context, storeID, from string, start time.Time, apply func([]*openfgav1.TupleChange)) (string, error) {
+	for {
+		changes, next, err := c.datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{
+			HorizonOffset: c.horizonOffset,
+			StartTime:     start,
+		}, storage.ReadChangesOptions{
+			Pagination: storage.NewPaginationOptions(pageSize, from),
+		})
+		if errors.Is(err, storage.ErrNotFound) {
+			return from, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		apply(changes)
+		if len(next) > 0 {
+			from = string(next)
+		}
+		if len(changes) < pageSize {
+			return from, nil
+		}
+	}
+}
+
+// storeIndex is the index of one store. Only the poll goroutine changes it and reads its tuples,
+// so the tuples are not guarded by the lock, which only guards what the lookups read.
+type storeIndex struct {
+	relations map[string]struct{}
+	token     string
+	// start is the time the changes are sought from while there is no continuation token yet
+	start time.Time
+	// members are the users of the tuples by `object#relation`, with the name of their condition
+	members map[string]map[string]string
+	// parents are the `object#relation` whose tuples have each userset as their user
+	parents map[string]map[string]struct{}
+	// changed are the usersets whose closures go through tuples that changed since the last poll
+	changed map[string]struct{}
+
+	// lastLookup is the time of the last lookup of the store, in Unix nanoseconds
+	lastLookup atomic.Int64
+
+	requestedMu sync.Mutex
+	// requested are the usersets looked up without a closure, which the next poll flattens
+	requested map[string]struct{}
+
+	mu sync.RWMutex
+	// caughtUpAt is zero until the store is built
+	caughtUpAt time.Time
+	// closures are the flattened members of the usersets that were looked up
+	closures map[string]*closure
+}
+
+// closure are the flattened members of a userset.
+type closure struct {
+	users map[string]struct{}
+	// complete is false if a tuple on the way has a condition, or a userset of a relation that
+	// is not indexed, so that the members of the userset cannot be known from the index
+	complete bool
+}
+
+func newStoreIndex(relations map[string]struct{}) *storeIndex {
+	return &storeIndex{
+		relations: relations,
+		members:   map[string]map[string]string{},
+		parents:   map[string]map[string]struct{}{},
+		changed:   map[string]struct{}{},
+		requested: map[string]struct{}{},
+		closures:  map[string]*closure{},
+	}
+}
+
+func (s *storeIndex) built() bool {
+	return !s.lastCaughtUp().IsZero()
+}
+
+func (s *storeIndex) lastCaughtUp() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.caughtUpAt
+}
+
+func (s *storeIndex) lookup(userset, user string, maxStaleness time.Duration) (bool, bool) {
+	s.lastLookup.Store(time.Now().UnixNano())
+
+	s.mu.RLock()
+	if s.caughtUpAt.IsZero() || time.Since(s.caughtUpAt) > maxStaleness {
+		s.mu.RUnlock()
+		return false, false
+	}
+	members, ok := s.closures[userset]
+	s.mu.RUnlock()
+
+	if !ok {
+		s.requestedMu.Lock()
+		s.requested[userset] = struct{}{}
+		s.requestedMu.Unlock()
+		return false, false
+	}
+
+	if !members.complete {
+		return false, false
+	}
+	if _, ok := members.users[user]; ok {
+		return true, true
+	}
+	_, ok = members.users[tuple.TypedPublicWildcard(tuple.GetType(user))]
+	return ok, true
+}
+
+// closure returns the flattened members of the userset.
+func (s *storeIndex) closure(userset string) *closure {
+	members := &closure{users: map[string]struct{}{}, complete: true}
+	visited := map[string]struct{}{userset: {}}
+	for queue := []string{userset}; len(queue) > 0; queue = queue[1:] {
+		for user, condition := range s.members[queue[0]] {
+			if condition != "" {
+				members.complete = false
+			}
+			if !tuple.IsObjectRelation(user) {
+				members.users[user] = struct{}{}
+				continue
+			}
+			object, relation := tuple.SplitObjectRelation(user)
+			if _, ok := s.relations[tuple.ToObjectRelationString(tuple.GetType(object), relation)]; !ok {
+				members.complete = false
+				continue
+			}
+			if _, ok := visited[user]; !ok {
+				visited[user] = struct{}{}
+				queue = append(queue, user)
+			}
+		}
+	}
+	return members
+}
+
+// flatten computes the closures of the given usersets and of the usersets requested by the
+// lookups since the last poll, from the tuples of the store.
+func (s *storeIndex) flatten(usersets map[string]struct{}) map[string]*closure {
+	s.requestedMu.Lock()
+	requested := s.requested
+	s.requested = map[string]struct{}{}
+	s.requestedMu.Unlock()
+
+	closures := make(map[string]*closure, len(usersets)+len(requested))
+	for userset := range usersets {
+		closures[userset] = s.closure(userset)
+	}
+	for userset := range requested {
+		if _, ok := closures[userset]; !ok {
+			closures[userset] = s.closure(userset)
+		}
+	}
+	return closures
+}
+
+// apply applies the changes of the indexed relations.
+func (s *storeIndex) apply(changes []*openfgav1.TupleChange) {
+	for _, change := range changes {
+		tk := change.GetTupleKey()
+		if _, ok := s.relations[tuple.ToObjectRelationString(tuple.GetType(tk.GetObject()), tk.GetRelation())]; !ok {
+			continue
+		}
+		if change.GetOperation() == openfgav1.TupleOperation_TUPLE_OPERATION_DELETE {
+			s.delete(tk)
+		} else {
+			s.write(tk)
+		}
+	}
+}
+
+func (s *storeIndex) write(tk *openfgav1.TupleKey) {
+	userset := tuple.ToObjectRelationString(tk.GetObject(), tk.GetRelation())
+	if s.members[userset] == nil {
+		s.members[userset] = map[string]string{}
+	}
+	s.members[userset][tk.GetUser()] = tk.GetCondition().GetName()
+	if tuple.IsObjectRelation(tk.GetUser()) {
+		if s.parents[tk.GetUser()] == nil {
+			s.parents[tk.GetUser()] = map[string]struct{}{}
+		}
+		s.parents[tk.GetUser()][userset] = struct{}{}
+	}
+	s.markChanged(userset)
+}
+
+func (s *storeIndex) delete(tk *openfgav1.TupleKey) {
+	userset := tuple.ToObjectRelationString(tk.GetObject(), tk.GetRelation())
+	delete(s.members[userset], tk.GetUser())
+	if len(s.members[userset]) == 0 {
+		delete(s.members, userset)
+	}
+	if tuple.IsObjectRelation(tk.GetUser()) {
+		delete(s.parents[tk.GetUser()], userset)
+		if len(s.parents[tk.GetUser()]) == 0 {
+			delete(s.parents, tk.GetUser())
+		}
+	}
+	s.markChanged(userset)
+}
+
+// markChanged records that the closures of the userset and of the usersets that go through it
+// must be recomputed. The lookups only read the closures, so it reads them without the lock.
+func (s *storeIndex) markChanged(userset string) {
+	visited := map[string]struct{}{userset: {}}
+	for queue := []string{userset}; len(queue) > 0; queue = queue[1:] {
+		if _, ok := s.closures[queue[0]]; ok {
+			s.changed[queue[0]] = struct{}{}
+		}
+		for parent := range s.parents[queue[0]] {
+			if _, ok := visited[parent]; !ok {
+				visited[parent] = struct{}{}
+				queue = append(queue, parent)
+			}
+		}
+	}
+}
+
+// replace replaces the index with a built one, and flattens the usersets that were looked up.
+func (s *storeIndex) replace(built *storeIndex, token string, start, caughtUpAt time.Time) {
+	s.members = built.members
+	s.parents = built.parents
+	s.changed = map[string]struct{}{}
+	s.token = token
+	s.start = start
+	lookedUp := make(map[string]struct{}, len(s.closures))
+	for userset := range s.closures {
+		lookedUp[userset] = struct{}{}
+	}
+	closures := s.flatten(lookedUp)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.caughtUpAt = caughtUpAt
+	s.closures = closures
+}
+
+// caughtUp records the progress through the changelog, and recomputes the closures that changed
+// along with the ones requested since the last poll.
+func (s *storeIndex) caughtUp(token string, caughtUpAt time.Time) {
+	s.token = token
+	updated := s.flatten(s.changed)
+	s.changed = map[string]struct{}{}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.caughtUpAt = caughtUpAt
+	maps.Copy(s.closures, updated)
+}
+
+// reset makes the store be built again by the next poll. The usersets that were looked up are
+// flattened again once it is built.
+func (s *storeIndex) reset() {
+	s.token = ""
+	s.start = time.Time{}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.caughtUpAt = time.Time{}
+}
@@ -0,0 +1,267 @@
+package membershipindex
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestNewChangelogIndexValidation(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	tests := map[string]struct {
+		relations []string
+		opts      []ChangelogIndexOpt
+		err       string
+	}{
+		`no_relations`: {
+			err: "the membership index needs at least one relation",
+		},
+		`relation_without_type`: {
+			relations: []string{"member"},
+			err:       "invalid membership index relation 'member', it must be 'objectType#relation'",
+		},
+		`relation_of_an_object`: {
+			relations: []string{"group:eng#member"},
+			err:       "invalid membership index relation 'group:eng#member', it must be 'objectType#relation'",
+		},
+		`poll_interval`: {
+			relations: []string{"group#member"},
+			opts:      []ChangelogIndexOpt{WithPollInterval(0)},
+			err:       "the membership index poll interval must be greater than 0",
+		},
+		`max_staleness_within_the_horizon_offset`: {
+			relations: []string{"group#member"},
+			opts:      []ChangelogIndexOpt{WithMaxStaleness(time.Minute), WithHorizonOffset(time.Minute)},
+			err:       "the membership index max staleness must be greater than its horizon offset",
+		},
+		`max_stores`: {
+			relations: []string{"group#member"},
+			opts:      []ChangelogIndexOpt{WithMaxStores(0)},
+			err:       "the membership index max stores must be greater than 0",
+		},
+		`idle_timeout`: {
+			relations: []string{"group#member"},
+			opts:      []ChangelogIndexOpt{WithIdleTimeout(0)},
+			err:       "the membership index idle timeout must be greater than 0",
+		},
+		`poll_concurrency`: {
+			relations: []string{"group#member"},
+			opts:      []ChangelogIndexOpt{WithPollConcurrency(0)},
+			err:       "the membership index poll concurrency must be greater than 0",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewChangelogIndex(ds, test.relations, test.opts...)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestChangelogIndex(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	storeID := "01JCZ6AN3K4V7RDRG9XW8PQ5MN"
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	write := func(t *testing.T, deletes []*openfgav1.TupleKey, writes ...*openfgav1.TupleKey) {
+		var keys []*openfgav1.TupleKeyWithoutCondition
+		for _, tk := range deletes {
+			keys = append(keys, tuple.TupleKeyToTupleKeyWithoutCondition(tk))
+		}
+		require.NoError(t, ds.Write(ctx, storeID, keys, writes))
+	}
+
+	write(t, nil,
+		tuple.NewTupleKey("group:eng", "member", "user:anne"),
+		tuple.NewTupleKey("group:eng", "member", "group:sre#member"),
+		tuple.NewTupleKey("group:sre", "member", "user:bob"),
+		tuple.NewTupleKey("group:sre", "member", "group:eng#member"),
+		tuple.NewTupleKey("group:all", "member", "user:*"),
+		tuple.NewTupleKey("group:ext", "member", "team:x#member"),
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+	)
+
+	index, err := NewChangelogIndex(ds, []string{"group#member"}, WithPollInterval(10*time.Millisecond), WithMaxStaleness(time.Second))
+	require.NoError(t, err)
+	t.Cleanup(index.Close)
+
+	require.Equal(t, []string{"group#member"}, index.Relations())
+
+	// the first lookup of the store starts indexing it
+	_, ok := index.Lookup(ctx, storeID, "group:eng", "member", "user:anne")
+	require.False(t, ok)
+
+	requireLookup := func(t *testing.T, object, user string, member bool) {
+		require.Eventually(t, func() bool {
+			isMember, ok := index.Lookup(ctx, storeID, object, "member", user)
+			return ok && isMember == member
+		}, 5*time.Second, 10*time.Millisecond, "%s#member@%s", object, user)
+	}
+
+	// write a conditional tuple after the store is built
+	requireLookup(t, "group:eng", "user:anne", true)
+	write(t, nil, tuple.NewTupleKeyWithCondition("group:cond", "member", "user:carl", "in_office_hours", nil))
+
+	t.Run("nested_and_cyclic_usersets", func(t *testing.T) {
+		requireLookup(t, "group:eng", "user:bob", true)
+		requireLookup(t, "group:sre", "user:anne", true)
+		requireLookup(t, "group:sre", "user:carl", false)
+	})
+
+	t.Run("wildcards", func(t *testing.T) {
+		requireLookup(t, "group:all", "user:carl", true)
+		requireLookup(t, "group:all", "user:*", true)
+		requireLookup(t, "group:eng", "user:*", false)
+	})
+
+	t.Run("unanswerable_lookups", func(t *testing.T) {
+		requireLookup(t, "group:eng", "user:anne", true)
+
+		for _, lookup := range []struct{ object, relation, user string }{
+			{"group:cond", "member", "user:carl"},       // the tuple has a condition
+			{"group:ext", "member", "user:dave"},        // team#member is not indexed
+			{"group:eng", "member", "group:sre#member"}, // the user is a userset
+			{"document:1", "viewer", "user:anne"},       // document#viewer is not indexed
+		} {
+			_, ok := index.Lookup(ctx, storeID, lookup.object, lookup.relation, lookup.user)
+			require.False(t, ok, lookup)
+		}
+	})
+
+	t.Run("writes_and_deletes", func(t *testing.T) {
+		write(t,
+			[]*openfgav1.TupleKey{tuple.NewTupleKey("group:sre", "member", "user:bob")},
+			tuple.NewTupleKey("group:sre", "member", "user:dave"),
+		)
+		requireLookup(t, "group:eng", "user:bob", false)
+		requireLookup(t, "group:eng", "user:dave", true)
+
+		write(t, []*openfgav1.TupleKey{tuple.NewTupleKeyWithCondition("group:cond", "member", "user:carl", "in_office_hours", nil)})
+		requireLookup(t, "group:cond", "user:carl", false)
+	})
+
+	t.Run("rebuilt_after_the_changelog_is_trimmed", func(t *testing.T) {
+		write(t, nil, tuple.NewTupleKey("group:sre", "member", "user:erin"))
+		_, err := ds.(storage.ChangelogTrimmer).TrimChanges(ctx, storeID, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		write(t, nil, tuple.NewTupleKey("group:sre", "member", "user:frank"))
+
+		requireLookup(t, "group:eng", "user:erin", true)
+		requireLookup(t, "group:eng", "user:frank", true)
+		requireLookup(t, "group:eng", "user:anne", true)
+	})
+
+	t.Run("not_answered_when_stale", func(t *testing.T) {
+		index.Close()
+
+		require.Eventually(t, func() bool {
+			_, ok := index.Lookup(ctx, storeID, "group:eng", "member", "user:anne")
+			return !ok
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+// changesRecorder records the filters of the ReadChanges calls.
+type changesRecorder struct {
+	storage.OpenFGADatastore
+
+	mu      sync.Mutex
+	filters []storage.ReadChangesFilter
+}
+
+func (r *changesRecorder) ReadChanges(ctx context.Context, store string, filter storage.ReadChangesFilter, options storage.ReadChangesOptions) ([]*openfgav1.TupleChange, []byte, error) {
+	r.mu.Lock()
+	r.filters = append(r.filters, filter)
+	r.mu.Unlock()
+	return r.OpenFGADatastore.ReadChanges(ctx, store, filter, options)
+}
+
+func TestChangelogIndexBuildSeeksTheChangelog(t *testing.T) {
+	ctx := context.Background()
+	storeID := "01JCZ6AN3K4V7RDRG9XW8PQ5MN"
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("group:eng", "member", "user:anne")}))
+
+	recorder := &changesRecorder{OpenFGADatastore: ds}
+	index, err := NewChangelogIndex(recorder, []string{"group#member"}, WithPollInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	t.Cleanup(index.Close)
+
+	beforeBuild := time.Now()
+	require.Eventually(t, func() bool {
+		member, ok := index.Lookup(ctx, storeID, "group:eng", "member", "user:anne")
+		return ok && member
+	}, 5*time.Second, 10*time.Millisecond)
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	require.NotEmpty(t, recorder.filters)
+	// the changes written before the build are not read, only the tuples
+	require.False(t, recorder.filters[0].StartTime.Before(beforeBuild))
+}
+
+func TestChangelogIndexEviction(t *testing.T) {
+	ctx := context.Background()
+	stores := []string{"01JCZ6AN3K4V7RDRG9XW8PQ5M1", "01JCZ6AN3K4V7RDRG9XW8PQ5M2", "01JCZ6AN3K4V7RDRG9XW8PQ5M3"}
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+	for _, storeID := range stores {
+		require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("group:eng", "member", "user:anne")}))
+	}
+
+	indexed := func(index *ChangelogIndex, storeID string) bool {
+		index.mu.RLock()
+		defer index.mu.RUnlock()
+		_, ok := index.stores[storeID]
+		return ok
+	}
+
+	t.Run("least_recently_looked_up_store", func(t *testing.T) {
+		index, err := NewChangelogIndex(ds, []string{"group#member"}, WithPollInterval(time.Hour), WithMaxStores(2))
+		require.NoError(t, err)
+		t.Cleanup(index.Close)
+
+		for _, storeID := range []string{stores[0], stores[1], stores[0], stores[2]} {
+			index.Lookup(ctx, storeID, "group:eng", "member", "user:anne")
+			time.Sleep(time.Millisecond)
+		}
+
+		require.True(t, indexed(index, stores[0]))
+		require.False(t, indexed(index, stores[1]))
+		require.True(t, indexed(index, stores[2]))
+	})
+
+	t.Run("idle_store", func(t *testing.T) {
+		index, err := NewChangelogIndex(ds, []string{"group#member"}, WithPollInterval(10*time.Millisecond), WithIdleTimeout(50*time.Millisecond))
+		require.NoError(t, err)
+		t.Cleanup(index.Close)
+
+		index.Lookup(ctx, stores[0], "group:eng", "member", "user:anne")
+		require.True(t, indexed(index, stores[0]))
+
+		require.Eventually(t, func() bool {
+			return !indexed(index, stores[0])
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
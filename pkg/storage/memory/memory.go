@@ -3,6 +3,7 @@ package memory
 import (
 	"context"
 	"fmt"
+	"maps"
 	"slices"
 	"sort"
 	"strconv"
@@ -120,6 +121,7 @@ const (
 type MemoryBackend struct {
 	maxTuplesPerWrite             int
 	maxTypesPerAuthorizationModel int
+	writeHook                     storage.WriteHook
 
 	// TupleBackend
 	// map: store => set of tuples
@@ -130,22 +132,47 @@ type MemoryBackend struct {
 	// map: store => set of changes
 	changes map[string][]*openfgav1.TupleChange // GUARDED_BY(mutexTuples).
 
+	// ChangelogTrimmer
+	// map: store => object type ("" for all types) => number of trimmed changes
+	trimmedChanges map[string]map[string]int64 // GUARDED_BY(mutexTuples).
+
+	// TupleCounter
+	// map: store => object type => number of tuples
+	tupleCounts map[string]map[string]int64 // GUARDED_BY(mutexTuples).
+
 	// AuthorizationModelBackend
 	// map: store = > map: type definition id => type definition
 	authorizationModels map[string]map[string]*AuthorizationModelEntry // GUARDED_BY(mutexModels).
 	mutexModels         sync.RWMutex
 
 	// map: store id => store data
-	stores      map[string]*openfgav1.Store // GUARDED_BY(mutexStores).
-	mutexStores sync.RWMutex
+	stores map[string]*openfgav1.Store // GUARDED_BY(mutexStores).
+	// the IDs of the deleted stores, whose models are kept
+	deletedStores map[string]struct{} // GUARDED_BY(mutexStores).
+	mutexStores   sync.RWMutex
 
 	// map: store id | authz model id => assertions
 	assertions      map[string][]*openfgav1.Assertion // GUARDED_BY(mutexAssertions).
 	mutexAssertions sync.RWMutex
+
+	// map: store id | alias => authz model id
+	modelAliases      map[string]string // GUARDED_BY(mutexModelAliases).
+	mutexModelAliases sync.RWMutex
 }
 
-// Ensures that [MemoryBackend] implements the [storage.OpenFGADatastore] interface.
+// Ensures that [MemoryBackend] implements the [storage.OpenFGADatastore], [storage.RelationCounter],
+// [storage.RelationUsageCounter], [storage.SnapshotBeginner], [storage.TupleCounter], [storage.ModelAliasBackend], [storage.StoreCounter], [storage.ChangelogTrimmer],
+// [storage.ChangelogStatsReader] and [storage.UniqueStoreNamesBackend] interfaces.
 var _ storage.OpenFGADatastore = (*MemoryBackend)(nil)
+var _ storage.RelationCounter = (*MemoryBackend)(nil)
+var _ storage.RelationUsageCounter = (*MemoryBackend)(nil)
+var _ storage.SnapshotBeginner = (*MemoryBackend)(nil)
+var _ storage.TupleCounter = (*MemoryBackend)(nil)
+var _ storage.ModelAliasBackend = (*MemoryBackend)(nil)
+var _ storage.StoreCounter = (*MemoryBackend)(nil)
+var _ storage.ChangelogTrimmer = (*MemoryBackend)(nil)
+var _ storage.ChangelogStatsReader = (*MemoryBackend)(nil)
+var _ storage.UniqueStoreNamesBackend = (*MemoryBackend)(nil)
 
 // AuthorizationModelEntry represents an entry in a storage system
 // that holds information about an authorization model.
@@ -161,9 +188,13 @@ func New(opts ...StorageOption) storage.OpenFGADatastore {
 		maxTypesPerAuthorizationModel: defaultMaxTypesPerAuthorizationModel,
 		tuples:                        make(map[string][]*storage.TupleRecord, 0),
 		changes:                       make(map[string][]*openfgav1.TupleChange, 0),
+		trimmedChanges:                make(map[string]map[string]int64),
+		tupleCounts:                   make(map[string]map[string]int64),
 		authorizationModels:           make(map[string]map[string]*AuthorizationModelEntry),
 		stores:                        make(map[string]*openfgav1.Store, 0),
+		deletedStores:                 make(map[string]struct{}),
 		assertions:                    make(map[string][]*openfgav1.Assertion, 0),
+		modelAliases:                  make(map[string]string),
 	}
 
 	for _, opt := range opts {
@@ -187,6 +218,12 @@ func WithMaxTypesPerAuthorizationModel(n int) StorageOption {
 	return func(ds *MemoryBackend) { ds.maxTypesPerAuthorizationModel = n }
 }
 
+// WithWriteHook returns a [StorageOption] that calls the hook on every Write, while the
+// datastore is locked and before the tuples are changed. See [storage.WriteHook].
+func WithWriteHook(h storage.WriteHook) StorageOption {
+	return func(ds *MemoryBackend) { ds.writeHook = h }
+}
+
 // Close does not do anything for [MemoryBackend].
 func (s *MemoryBackend) Close() {}
 
@@ -256,31 +293,120 @@ func (s *MemoryBackend) ReadChanges(ctx context.Context, store string, filter st
 		return nil, nil, storage.ErrNotFound
 	}
 
+	// the changes are in the order they occurred, so the first change at or after the start time
+	// is sought with a binary search. As with the ULIDs of the SQL datastores, the times are
+	// compared to the millisecond.
+	var start int
+	if !filter.StartTime.IsZero() {
+		startTime := filter.StartTime.Truncate(time.Millisecond)
+		start = sort.Search(len(allChanges), func(i int) bool {
+			return !allChanges[i].GetTimestamp().AsTime().Truncate(time.Millisecond).Before(startTime)
+		})
+	}
+
 	pageSize := storage.DefaultPageSize
 	if options.Pagination.PageSize > 0 {
 		pageSize = options.Pagination.PageSize
 	}
+	// Ascending tokens are offsets from the first change ever written, so that they keep pointing
+	// to the same change after the changelog is trimmed. Descending tokens are offsets from the
+	// newest change, which trimming doesn't move.
+	var trimmed int64
 	if options.SortDesc {
+		allChanges = allChanges[start:]
 		slices.Reverse(allChanges)
+	} else {
+		trimmed = s.trimmedChanges[store][objectType]
+		if options.Pagination.From != "" {
+			// the token is the offset of the next change to read, so it only expires if that
+			// change was trimmed, not if the consumer read every trimmed change
+			if from < trimmed {
+				return nil, nil, storage.ErrContinuationTokenExpired
+			}
+			from -= trimmed
+		}
+		from = max(from, int64(start))
+	}
+	if int(from) >= len(allChanges) {
+		return nil, nil, storage.ErrNotFound
 	}
 	to := int(from) + pageSize
 	if len(allChanges) < to {
 		to = len(allChanges)
 	}
 	res := allChanges[from:to]
-	if len(res) == 0 {
-		return nil, nil, storage.ErrNotFound
-	}
 
-	continuationToken = strconv.Itoa(len(allChanges))
+	continuationToken = strconv.FormatInt(int64(len(allChanges))+trimmed, 10)
 	if to != len(allChanges) {
-		continuationToken = strconv.Itoa(to)
+		continuationToken = strconv.FormatInt(int64(to)+trimmed, 10)
 	}
 	continuationToken += fmt.Sprintf("|%s", objectType)
 
 	return res, []byte(continuationToken), nil
 }
 
+// TrimChanges see [storage.ChangelogTrimmer].TrimChanges.
+func (s *MemoryBackend) TrimChanges(ctx context.Context, store string, before time.Time) (int64, error) {
+	_, span := tracer.Start(ctx, "memory.TrimChanges")
+	defer span.End()
+
+	s.mutexTuples.Lock()
+	defer s.mutexTuples.Unlock()
+
+	changes := s.changes[store]
+	n := 0
+	for n < len(changes) && changes[n].GetTimestamp().AsTime().Before(before) {
+		n++
+	}
+	if n == 0 {
+		return 0, nil
+	}
+
+	counts, ok := s.trimmedChanges[store]
+	if !ok {
+		counts = make(map[string]int64)
+		s.trimmedChanges[store] = counts
+	}
+	for _, change := range changes[:n] {
+		objectType, _ := tupleUtils.SplitObject(change.GetTupleKey().GetObject())
+		counts[objectType]++
+	}
+	counts[""] += int64(n)
+	s.changes[store] = changes[n:]
+
+	return int64(n), nil
+}
+
+// GetLatestChange see [storage.ChangelogStatsReader].GetLatestChange.
+func (s *MemoryBackend) GetLatestChange(ctx context.Context, store string) (time.Time, error) {
+	_, span := tracer.Start(ctx, "memory.GetLatestChange")
+	defer span.End()
+
+	s.mutexTuples.RLock()
+	defer s.mutexTuples.RUnlock()
+
+	changes := s.changes[store]
+	if len(changes) == 0 {
+		return time.Time{}, storage.ErrNotFound
+	}
+	return changes[len(changes)-1].GetTimestamp().AsTime(), nil
+}
+
+// CountChanges see [storage.ChangelogStatsReader].CountChanges. The count is exact.
+func (s *MemoryBackend) CountChanges(ctx context.Context) (storage.ChangeCount, error) {
+	_, span := tracer.Start(ctx, "memory.CountChanges")
+	defer span.End()
+
+	s.mutexTuples.RLock()
+	defer s.mutexTuples.RUnlock()
+
+	var count storage.ChangeCount
+	for _, changes := range s.changes {
+		count.Count += int64(len(changes))
+	}
+	return count, nil
+}
+
 // read returns an iterator of a store's tuples with a given tuple as filter.
 // A nil paginationOptions input means the returned iterator will iterate through all values.
 func (s *MemoryBackend) read(ctx context.Context, store string, tk *openfgav1.TupleKey, options *storage.ReadPageOptions) (*staticIterator, error) {
@@ -302,26 +428,41 @@ func (s *MemoryBackend) read(ctx context.Context, store string, tk *openfgav1.Tu
 		}
 	}
 
-	var err error
-	var from int
-	if options != nil && options.Pagination.From != "" {
-		from, err = strconv.Atoi(options.Pagination.From)
+	if options != nil && !options.Condition.IsEmpty() {
+		filtered := matches[:0]
+		for _, t := range matches {
+			if options.Condition.Matches(t.ConditionName) {
+				filtered = append(filtered, t)
+			}
+		}
+		matches = filtered
+	}
+
+	if options == nil {
+		return &staticIterator{records: matches}, nil
+	}
+
+	slices.SortFunc(matches, storage.CompareReadOrder)
+
+	if options.Pagination.From != "" {
+		cursor, err := storage.UnmarshalReadCursor(options.Pagination.From)
 		if err != nil {
 			telemetry.TraceError(span, err)
 			return nil, err
 		}
-	}
-
-	if from <= len(matches) {
+		from, _ := slices.BinarySearchFunc(matches, cursor, func(t *storage.TupleRecord, c storage.ReadCursor) int {
+			return c.Compare(t)
+		})
 		matches = matches[from:]
 	}
 
-	to := 0 // fetch everything
-	if options != nil {
-		to = options.Pagination.PageSize
-	}
+	to := options.Pagination.PageSize
 	if to != 0 && to < len(matches) {
-		return &staticIterator{records: matches[:to], continuationToken: []byte(strconv.Itoa(from + to))}, nil
+		continuationToken, err := storage.NewReadCursor(matches[to]).Marshal()
+		if err != nil {
+			return nil, err
+		}
+		return &staticIterator{records: matches[:to], continuationToken: continuationToken}, nil
 	}
 
 	return &staticIterator{records: matches}, nil
@@ -341,6 +482,12 @@ func (s *MemoryBackend) Write(ctx context.Context, store string, deletes storage
 		return err
 	}
 
+	if s.writeHook != nil {
+		if err := s.writeHook.BeforeCommit(ctx, nil, store, deletes, writes); err != nil {
+			return err
+		}
+	}
+
 	var records []*storage.TupleRecord
 Delete:
 	for _, tr := range s.tuples[store] {
@@ -406,6 +553,15 @@ Write:
 		})
 	}
 	s.tuples[store] = records
+
+	deltas := storage.TupleCountDeltas(deletes, writes)
+	if len(deltas) > 0 && s.tupleCounts[store] == nil {
+		s.tupleCounts[store] = make(map[string]int64)
+	}
+	for objectType, delta := range deltas {
+		s.tupleCounts[store][objectType] += delta
+	}
+
 	return nil
 }
 
@@ -455,6 +611,116 @@ func (s *MemoryBackend) ReadUserTuple(ctx context.Context, store string, key *op
 	return nil, storage.ErrNotFound
 }
 
+// CountRelation see [storage.RelationCounter].CountRelation.
+func (s *MemoryBackend) CountRelation(ctx context.Context, store, objectType, relation string) (storage.RelationCounts, error) {
+	_, span := tracer.Start(ctx, "memory.CountRelation")
+	defer span.End()
+
+	s.mutexTuples.RLock()
+	defer s.mutexTuples.RUnlock()
+
+	var counts storage.RelationCounts
+	objects := map[string]struct{}{}
+	users := map[string]struct{}{}
+	for _, t := range s.tuples[store] {
+		if t.ObjectType != objectType || t.Relation != relation {
+			continue
+		}
+		counts.Tuples++
+		objects[t.ObjectID] = struct{}{}
+		users[t.User] = struct{}{}
+	}
+	counts.DistinctObjects = int64(len(objects))
+	counts.DistinctUsers = int64(len(users))
+
+	return counts, nil
+}
+
+// CountRelationUsage see [storage.RelationUsageCounter].CountRelationUsage.
+func (s *MemoryBackend) CountRelationUsage(ctx context.Context, store string) ([]storage.RelationUsage, error) {
+	_, span := tracer.Start(ctx, "memory.CountRelationUsage")
+	defer span.End()
+
+	s.mutexTuples.RLock()
+	defer s.mutexTuples.RUnlock()
+
+	type key struct{ objectType, relation string }
+	counts := map[key]int64{}
+	for _, t := range s.tuples[store] {
+		counts[key{t.ObjectType, t.Relation}]++
+	}
+
+	usage := make([]storage.RelationUsage, 0, len(counts))
+	for k, n := range counts {
+		usage = append(usage, storage.RelationUsage{ObjectType: k.objectType, Relation: k.relation, Tuples: n})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].ObjectType != usage[j].ObjectType {
+			return usage[i].ObjectType < usage[j].ObjectType
+		}
+		return usage[i].Relation < usage[j].Relation
+	})
+
+	return usage, nil
+}
+
+// BeginSnapshot see [storage.SnapshotBeginner].BeginSnapshot. Write replaces the tuples of a
+// store rather than changing them, so the snapshot is a copy-on-write view that keeps the tuples
+// of each store as they were when it began.
+func (s *MemoryBackend) BeginSnapshot(ctx context.Context, _ storage.SnapshotOptions) (storage.SnapshotTupleReader, error) {
+	_, span := tracer.Start(ctx, "memory.BeginSnapshot")
+	defer span.End()
+
+	s.mutexTuples.RLock()
+	defer s.mutexTuples.RUnlock()
+
+	return &memorySnapshot{
+		RelationshipTupleReader: &MemoryBackend{tuples: maps.Clone(s.tuples)},
+	}, nil
+}
+
+// memorySnapshot reads the tuples of a [MemoryBackend] as they were when the snapshot began.
+type memorySnapshot struct {
+	storage.RelationshipTupleReader
+}
+
+// Release does not do anything for [MemoryBackend].
+func (m *memorySnapshot) Release() {}
+
+// ReadTupleCounts see [storage.TupleCounter].ReadTupleCounts.
+func (s *MemoryBackend) ReadTupleCounts(ctx context.Context, store string) (storage.TupleCounts, error) {
+	_, span := tracer.Start(ctx, "memory.ReadTupleCounts")
+	defer span.End()
+
+	s.mutexTuples.RLock()
+	defer s.mutexTuples.RUnlock()
+
+	counts := storage.TupleCounts{ByObjectType: make(map[string]int64, len(s.tupleCounts[store]))}
+	for objectType, tuples := range s.tupleCounts[store] {
+		counts.ByObjectType[objectType] = tuples
+		counts.Total += tuples
+	}
+
+	return counts, nil
+}
+
+// BackfillTupleCounts see [storage.TupleCounter].BackfillTupleCounts.
+func (s *MemoryBackend) BackfillTupleCounts(ctx context.Context, store string) error {
+	_, span := tracer.Start(ctx, "memory.BackfillTupleCounts")
+	defer span.End()
+
+	s.mutexTuples.Lock()
+	defer s.mutexTuples.Unlock()
+
+	counts := make(map[string]int64)
+	for _, t := range s.tuples[store] {
+		counts[t.ObjectType]++
+	}
+	s.tupleCounts[store] = counts
+
+	return nil
+}
+
 // ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
 func (s *MemoryBackend) ReadUsersetTuples(
 	ctx context.Context,
@@ -567,6 +833,11 @@ func (s *MemoryBackend) ReadAuthorizationModel(
 	_, span := tracer.Start(ctx, "memory.ReadAuthorizationModel")
 	defer span.End()
 
+	if s.storeDeleted(store) {
+		telemetry.TraceError(span, storage.ErrStoreDeleted)
+		return nil, storage.ErrStoreDeleted
+	}
+
 	s.mutexModels.RLock()
 	defer s.mutexModels.RUnlock()
 
@@ -639,6 +910,11 @@ func (s *MemoryBackend) FindLatestAuthorizationModel(ctx context.Context, store
 	_, span := tracer.Start(ctx, "memory.FindLatestAuthorizationModel")
 	defer span.End()
 
+	if s.storeDeleted(store) {
+		telemetry.TraceError(span, storage.ErrStoreDeleted)
+		return nil, storage.ErrStoreDeleted
+	}
+
 	s.mutexModels.RLock()
 	defer s.mutexModels.RUnlock()
 
@@ -689,9 +965,56 @@ func (s *MemoryBackend) CreateStore(ctx context.Context, newStore *openfgav1.Sto
 	s.mutexStores.Lock()
 	defer s.mutexStores.Unlock()
 
+	return s.createStore(newStore)
+}
+
+// CreateStoreWithUniqueName see [storage.UniqueStoreNamesBackend].CreateStoreWithUniqueName.
+func (s *MemoryBackend) CreateStoreWithUniqueName(ctx context.Context, newStore *openfgav1.Store) (*openfgav1.Store, error) {
+	_, span := tracer.Start(ctx, "memory.CreateStoreWithUniqueName")
+	defer span.End()
+
+	s.mutexStores.Lock()
+	defer s.mutexStores.Unlock()
+
+	if existing := s.storeByName(newStore.GetName()); existing != nil {
+		return nil, &storage.StoreNameConflictError{StoreID: existing.GetId(), Name: newStore.GetName()}
+	}
+	return s.createStore(newStore)
+}
+
+// GetStoreByName see [storage.UniqueStoreNamesBackend].GetStoreByName.
+func (s *MemoryBackend) GetStoreByName(ctx context.Context, name string) (*openfgav1.Store, error) {
+	_, span := tracer.Start(ctx, "memory.GetStoreByName")
+	defer span.End()
+
+	s.mutexStores.RLock()
+	defer s.mutexStores.RUnlock()
+
+	store := s.storeByName(name)
+	if store == nil {
+		return nil, storage.ErrNotFound
+	}
+	return store, nil
+}
+
+// storeByName returns the oldest store with the name, or nil. It must be called with
+// mutexStores held.
+func (s *MemoryBackend) storeByName(name string) *openfgav1.Store {
+	var oldest *openfgav1.Store
+	for _, store := range s.stores {
+		if store.GetName() == name && (oldest == nil || store.GetId() < oldest.GetId()) {
+			oldest = store
+		}
+	}
+	return oldest
+}
+
+// createStore adds the store. It must be called with mutexStores held.
+func (s *MemoryBackend) createStore(newStore *openfgav1.Store) (*openfgav1.Store, error) {
 	if _, ok := s.stores[newStore.GetId()]; ok {
 		return nil, storage.ErrCollision
 	}
+	delete(s.deletedStores, newStore.GetId())
 
 	now := timestamppb.New(time.Now().UTC())
 	s.stores[newStore.GetId()] = &openfgav1.Store{
@@ -712,10 +1035,22 @@ func (s *MemoryBackend) DeleteStore(ctx context.Context, id string) error {
 	s.mutexStores.Lock()
 	defer s.mutexStores.Unlock()
 
+	if _, ok := s.stores[id]; ok {
+		s.deletedStores[id] = struct{}{}
+	}
 	delete(s.stores, id)
 	return nil
 }
 
+// storeDeleted returns whether the store was deleted.
+func (s *MemoryBackend) storeDeleted(id string) bool {
+	s.mutexStores.RLock()
+	defer s.mutexStores.RUnlock()
+
+	_, ok := s.deletedStores[id]
+	return ok
+}
+
 // WriteAssertions see [storage.AssertionsBackend].WriteAssertions.
 func (s *MemoryBackend) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
 	_, span := tracer.Start(ctx, "memory.WriteAssertions")
@@ -731,7 +1066,7 @@ func (s *MemoryBackend) WriteAssertions(ctx context.Context, store, modelID stri
 }
 
 // ReadAssertions see [storage.AssertionsBackend].ReadAssertions.
-func (s *MemoryBackend) ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error) {
+func (s *MemoryBackend) ReadAssertions(ctx context.Context, store, modelID string, options storage.ReadAssertionsOptions) ([]*openfgav1.Assertion, []byte, error) {
 	_, span := tracer.Start(ctx, "memory.ReadAssertions")
 	defer span.End()
 
@@ -739,11 +1074,35 @@ func (s *MemoryBackend) ReadAssertions(ctx context.Context, store, modelID strin
 	defer s.mutexAssertions.RUnlock()
 
 	assertionsID := fmt.Sprintf("%s|%s", store, modelID)
-	assertions, ok := s.assertions[assertionsID]
+	return storage.PageAssertions(s.assertions[assertionsID], options)
+}
+
+// WriteModelAlias see [storage.ModelAliasBackend].WriteModelAlias.
+func (s *MemoryBackend) WriteModelAlias(ctx context.Context, store, alias, modelID string) error {
+	_, span := tracer.Start(ctx, "memory.WriteModelAlias")
+	defer span.End()
+
+	s.mutexModelAliases.Lock()
+	defer s.mutexModelAliases.Unlock()
+
+	s.modelAliases[fmt.Sprintf("%s|%s", store, alias)] = modelID
+
+	return nil
+}
+
+// ReadModelAlias see [storage.ModelAliasBackend].ReadModelAlias.
+func (s *MemoryBackend) ReadModelAlias(ctx context.Context, store, alias string) (string, error) {
+	_, span := tracer.Start(ctx, "memory.ReadModelAlias")
+	defer span.End()
+
+	s.mutexModelAliases.RLock()
+	defer s.mutexModelAliases.RUnlock()
+
+	modelID, ok := s.modelAliases[fmt.Sprintf("%s|%s", store, alias)]
 	if !ok {
-		return []*openfgav1.Assertion{}, nil
+		return "", storage.ErrNotFound
 	}
-	return assertions, nil
+	return modelID, nil
 }
 
 // MaxTuplesPerWrite see [storage.RelationshipTupleWriter].MaxTuplesPerWrite.
@@ -781,6 +1140,9 @@ func (s *MemoryBackend) ListStores(ctx context.Context, options storage.ListStor
 
 	stores := make([]*openfgav1.Store, 0, len(s.stores))
 	for _, t := range s.stores {
+		if options.Name != "" && t.GetName() != options.Name {
+			continue
+		}
 		stores = append(stores, t)
 	}
 
@@ -818,6 +1180,27 @@ func (s *MemoryBackend) ListStores(ctx context.Context, options storage.ListStor
 	return res, []byte(continuationToken), nil
 }
 
+// CountStores see [storage.StoreCounter].CountStores. The count is always exact.
+func (s *MemoryBackend) CountStores(ctx context.Context, options storage.CountStoresOptions) (storage.StoreCount, error) {
+	_, span := tracer.Start(ctx, "memory.CountStores")
+	defer span.End()
+
+	s.mutexStores.RLock()
+	defer s.mutexStores.RUnlock()
+
+	if options.Name == "" {
+		return storage.StoreCount{Count: int64(len(s.stores))}, nil
+	}
+
+	var count int64
+	for _, store := range s.stores {
+		if store.GetName() == options.Name {
+			count++
+		}
+	}
+	return storage.StoreCount{Count: count}, nil
+}
+
 // IsReady see [storage.OpenFGADatastore].IsReady.
 func (s *MemoryBackend) IsReady(context.Context) (storage.ReadinessStatus, error) {
 	return storage.ReadinessStatus{IsReady: true}, nil
@@ -2,6 +2,7 @@ package memory
 
 import (
 	"context"
+	"errors"
 	"strconv"
 	"testing"
 	"time"
@@ -347,3 +348,45 @@ func TestFindTupleKey(t *testing.T) {
 		})
 	}
 }
+
+func TestMemdbWriteHook(t *testing.T) {
+	ctx := context.Background()
+	tk := tuple.NewTupleKey("doc:1", "viewer", "user:anne")
+
+	t.Run("called_with_the_write", func(t *testing.T) {
+		var gotStore string
+		var gotWrites storage.Writes
+		ds := New(WithWriteHook(storage.WriteHookFunc(func(_ context.Context, tx storage.WriteTx, store string, _ storage.Deletes, writes storage.Writes) error {
+			require.Nil(t, tx)
+			gotStore = store
+			gotWrites = writes
+			return nil
+		})))
+		defer ds.Close()
+
+		err := ds.Write(ctx, "store", nil, []*openfgav1.TupleKey{tk})
+		require.NoError(t, err)
+		require.Equal(t, "store", gotStore)
+		require.Len(t, gotWrites, 1)
+	})
+
+	t.Run("error_leaves_tuples_unchanged", func(t *testing.T) {
+		hookErr := errors.New("outbox unavailable")
+		ds := New(WithWriteHook(storage.WriteHookFunc(func(context.Context, storage.WriteTx, string, storage.Deletes, storage.Writes) error {
+			return hookErr
+		})))
+		defer ds.Close()
+
+		err := ds.Write(ctx, "store", nil, []*openfgav1.TupleKey{tk})
+		require.ErrorIs(t, err, hookErr)
+
+		_, err = ds.ReadUserTuple(ctx, "store", tk, storage.ReadUserTupleOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+}
+
+func BenchmarkMemdbStorageReadUsersetTuples(b *testing.B) {
+	ds := New()
+	defer ds.Close()
+	test.ReadUsersetTuplesBenchmark(b, ds)
+}
@@ -2,7 +2,9 @@ package mysql
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -30,10 +32,24 @@ import (
 
 var tracer = otel.Tracer("openfga/pkg/storage/mysql")
 
+// storeNameLockTimeoutSeconds bounds the wait of CreateStoreWithUniqueName for the lock on the
+// name of the store.
+const storeNameLockTimeoutSeconds = 10
+
 func startTrace(ctx context.Context, name string) (context.Context, trace.Span) {
 	return tracer.Start(ctx, "mysql."+name)
 }
 
+// readOrderColumns are the columns of the ReadPage order, compared byte-wise like the cursor
+// instead of with the collation of the table, which is case-insensitive by default. The
+// idx_tuple_read_order index is on the same expressions.
+var readOrderColumns = []string{
+	"CAST(object_type AS BINARY)",
+	"CAST(object_id AS BINARY)",
+	"CAST(relation AS BINARY)",
+	"CAST(_user AS BINARY)",
+}
+
 // Datastore provides a MySQL based implementation of [storage.OpenFGADatastore].
 type Datastore struct {
 	stbl                   sq.StatementBuilderType
@@ -41,12 +57,31 @@ type Datastore struct {
 	dbInfo                 *sqlcommon.DBInfo
 	logger                 logger.Logger
 	dbStatsCollector       prometheus.Collector
+	poolMonitor            *sqlcommon.PoolMonitor
+	queryShapes            *sqlcommon.QueryShapeSampler
+	tupleCounts            bool
+	changelog              *sqlcommon.ChangelogWriter
 	maxTuplesPerWriteField int
 	maxTypesPerModelField  int
 }
 
-// Ensures that Datastore implements the OpenFGADatastore interface.
+// Ensures that Datastore implements the OpenFGADatastore, RelationCounter, RelationUsageCounter, SnapshotBeginner,
+// TupleCounter, ModelAliasBackend, StorePurger, StoreCounter, ChangelogTrimmer, ChangelogStatsReader, UniqueStoreNamesBackend and IndexAdvisor interfaces.
 var _ storage.OpenFGADatastore = (*Datastore)(nil)
+var _ storage.RelationCounter = (*Datastore)(nil)
+var _ storage.RelationUsageCounter = (*Datastore)(nil)
+var _ storage.SnapshotBeginner = (*Datastore)(nil)
+var _ storage.TupleCounter = (*Datastore)(nil)
+var _ storage.ModelAliasBackend = (*Datastore)(nil)
+var _ storage.StorePurger = (*Datastore)(nil)
+var _ storage.StoreCounter = (*Datastore)(nil)
+var _ storage.ChangelogTrimmer = (*Datastore)(nil)
+var _ storage.ChangelogStatsReader = (*Datastore)(nil)
+var _ storage.UniqueStoreNamesBackend = (*Datastore)(nil)
+var _ storage.IndexAdvisor = (*Datastore)(nil)
+
+// tupleCountUpsert adds to the existing tuple counter of a store and object type.
+const tupleCountUpsert = "ON DUPLICATE KEY UPDATE tuples = tuples + VALUES(tuples)"
 
 // New creates a new [Datastore] storage.
 func New(uri string, cfg *sqlcommon.Config) (*Datastore, error) {
@@ -116,7 +151,22 @@ func NewWithDB(db *sql.DB, cfg *sqlcommon.Config) (*Datastore, error) {
 	}
 
 	stbl := sq.StatementBuilder.RunWith(db)
-	dbInfo := sqlcommon.NewDBInfo(db, stbl, HandleSQLError)
+	changelog := sqlcommon.NewChangelogWriter(db, stbl, sqlcommon.ChangelogTable{
+		Columns:  sqlcommon.ChangelogColumns,
+		Now:      "NOW()",
+		LockRows: true,
+	}, cfg)
+	dbInfo := sqlcommon.NewDBInfo(db, stbl, HandleSQLError).
+		WithChangelogWriter(changelog).
+		WithWriteHook(cfg.WriteHook)
+	if cfg.TupleCounts {
+		dbInfo = dbInfo.WithTupleCounts(tupleCountUpsert)
+	}
+
+	var queryShapes *sqlcommon.QueryShapeSampler
+	if cfg.QueryShapeSampling {
+		queryShapes = sqlcommon.NewQueryShapeSampler()
+	}
 
 	return &Datastore{
 		stbl:                   stbl,
@@ -124,6 +174,10 @@ func NewWithDB(db *sql.DB, cfg *sqlcommon.Config) (*Datastore, error) {
 		dbInfo:                 dbInfo,
 		logger:                 cfg.Logger,
 		dbStatsCollector:       collector,
+		changelog:              changelog,
+		poolMonitor:            sqlcommon.NewPoolMonitor(db, cfg),
+		queryShapes:            queryShapes,
+		tupleCounts:            cfg.TupleCounts,
 		maxTuplesPerWriteField: cfg.MaxTuplesPerWriteField,
 		maxTypesPerModelField:  cfg.MaxTypesPerModelField,
 	}, nil
@@ -131,6 +185,8 @@ func NewWithDB(db *sql.DB, cfg *sqlcommon.Config) (*Datastore, error) {
 
 // Close see [storage.OpenFGADatastore].Close.
 func (s *Datastore) Close() {
+	s.poolMonitor.Stop()
+	s.changelog.Close()
 	if s.dbStatsCollector != nil {
 		prometheus.Unregister(s.dbStatsCollector)
 	}
@@ -147,7 +203,12 @@ func (s *Datastore) Read(
 	ctx, span := startTrace(ctx, "Read")
 	defer span.End()
 
-	return s.read(ctx, store, tupleKey, nil)
+	iter, err := s.read(ctx, store, tupleKey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queryShapes.RecordIterator(sqlcommon.QueryMethodRead, sqlcommon.TupleKeyFilter(tupleKey), iter), nil
 }
 
 // ReadPage see [storage.RelationshipTupleReader].ReadPage.
@@ -166,7 +227,13 @@ func (s *Datastore) ReadPage(
 	}
 	defer iter.Stop()
 
-	return iter.ToArray(options.Pagination)
+	tuples, contToken, err := iter.ToArray(options.Pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.queryShapes.Record(sqlcommon.QueryMethodReadPage, sqlcommon.ReadPageFilter(tupleKey, options), len(tuples))
+	return tuples, contToken, nil
 }
 
 func (s *Datastore) read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options *storage.ReadPageOptions) (*sqlcommon.SQLTupleIterator, error) {
@@ -182,7 +249,7 @@ func (s *Datastore) read(ctx context.Context, store string, tupleKey *openfgav1.
 		From("tuple").
 		Where(sq.Eq{"store": store})
 	if options != nil {
-		sb = sb.OrderBy("ulid")
+		sb = sb.OrderBy(readOrderColumns...)
 	}
 
 	objectType, objectID := tupleUtils.SplitObject(tupleKey.GetObject())
@@ -198,12 +265,16 @@ func (s *Datastore) read(ctx context.Context, store string, tupleKey *openfgav1.
 	if tupleKey.GetUser() != "" {
 		sb = sb.Where(sq.Eq{"_user": tupleKey.GetUser()})
 	}
+	if options != nil {
+		sb = sqlcommon.WhereConditionFilter(sb, options.Condition)
+	}
 	if options != nil && options.Pagination.From != "" {
-		token, err := sqlcommon.UnmarshallContToken(options.Pagination.From)
+		cursor, err := storage.UnmarshalReadCursor(options.Pagination.From)
 		if err != nil {
 			return nil, err
 		}
-		sb = sb.Where(sq.GtOrEq{"ulid": token.Ulid})
+		sb = sb.Where(sq.Expr("("+strings.Join(readOrderColumns, ", ")+") >= (?, ?, ?, ?)",
+			cursor.ObjectType, cursor.ObjectID, cursor.Relation, cursor.User))
 	}
 	if options != nil && options.Pagination.PageSize != 0 {
 		sb = sb.Limit(uint64(options.Pagination.PageSize + 1)) // + 1 is used to determine whether to return a continuation token.
@@ -271,8 +342,10 @@ func (s *Datastore) ReadUserTuple(ctx context.Context, store string, tupleKey *o
 			&conditionContext,
 		)
 	if err != nil {
+		s.queryShapes.Record(sqlcommon.QueryMethodReadUserTuple, sqlcommon.TupleKeyFilter(tupleKey), 0)
 		return nil, HandleSQLError(err)
 	}
+	s.queryShapes.Record(sqlcommon.QueryMethodReadUserTuple, sqlcommon.TupleKeyFilter(tupleKey), 1)
 
 	if conditionName.String != "" {
 		record.ConditionName = conditionName.String
@@ -289,6 +362,97 @@ func (s *Datastore) ReadUserTuple(ctx context.Context, store string, tupleKey *o
 	return record.AsTuple(), nil
 }
 
+// BeginSnapshot see [storage.SnapshotBeginner].BeginSnapshot. The tuples are read within
+// a read-only repeatable-read transaction, whose snapshot is taken by its first read. MySQL cannot
+// share the snapshot with other transactions, so the reads run one at a time.
+func (s *Datastore) BeginSnapshot(ctx context.Context, _ storage.SnapshotOptions) (storage.SnapshotTupleReader, error) {
+	ctx, span := startTrace(ctx, "BeginSnapshot")
+	defer span.End()
+
+	// the transaction ends with Release rather than with the request
+	txn, err := s.db.BeginTx(context.WithoutCancel(ctx), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	snapshot := *s
+	snapshot.stbl = s.stbl.RunWith(txn)
+	return sqlcommon.NewSnapshotTupleReader(&sqlcommon.SnapshotConn{Reader: &snapshot, Txn: txn}, nil, 1), nil
+}
+
+// CountRelation see [storage.RelationCounter].CountRelation.
+func (s *Datastore) CountRelation(ctx context.Context, store, objectType, relation string) (storage.RelationCounts, error) {
+	ctx, span := startTrace(ctx, "CountRelation")
+	defer span.End()
+
+	counts, err := sqlcommon.CountRelation(ctx, s.stbl, store, objectType, relation, "_user")
+	if err != nil {
+		return storage.RelationCounts{}, HandleSQLError(err)
+	}
+
+	return counts, nil
+}
+
+// CountRelationUsage see [storage.RelationUsageCounter].CountRelationUsage.
+func (s *Datastore) CountRelationUsage(ctx context.Context, store string) ([]storage.RelationUsage, error) {
+	ctx, span := startTrace(ctx, "CountRelationUsage")
+	defer span.End()
+
+	usage, err := sqlcommon.CountRelationUsage(ctx, s.stbl, store)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return usage, nil
+}
+
+// AdviseIndexes see [storage.IndexAdvisor].AdviseIndexes. The query shapes are only sampled
+// if the datastore was created with [sqlcommon.WithQueryShapeSampling].
+func (s *Datastore) AdviseIndexes(ctx context.Context) (storage.IndexAdvice, error) {
+	_, span := startTrace(ctx, "AdviseIndexes")
+	defer span.End()
+
+	return s.queryShapes.Advise("mysql")
+}
+
+// ReadTupleCounts see [storage.TupleCounter].ReadTupleCounts. The counters are only maintained
+// if the datastore was created with [sqlcommon.WithTupleCounts].
+func (s *Datastore) ReadTupleCounts(ctx context.Context, store string) (storage.TupleCounts, error) {
+	ctx, span := startTrace(ctx, "ReadTupleCounts")
+	defer span.End()
+
+	if !s.tupleCounts {
+		return storage.TupleCounts{}, storage.ErrTupleCountsDisabled
+	}
+
+	counts, err := sqlcommon.ReadTupleCounts(ctx, s.stbl, store)
+	if err != nil {
+		return storage.TupleCounts{}, HandleSQLError(err)
+	}
+
+	return counts, nil
+}
+
+// BackfillTupleCounts see [storage.TupleCounter].BackfillTupleCounts.
+func (s *Datastore) BackfillTupleCounts(ctx context.Context, store string) error {
+	ctx, span := startTrace(ctx, "BackfillTupleCounts")
+	defer span.End()
+
+	// under REPEATABLE READ, the shared next-key locks of the tuples of the store also block
+	// the inserts of new tuples of the store until the backfill commits
+	lockTuples := s.stbl.
+		Select("COUNT(*)").
+		From("tuple").
+		Where(sq.Eq{"store": store}).
+		Suffix("LOCK IN SHARE MODE")
+
+	if err := sqlcommon.BackfillTupleCounts(ctx, s.db, s.stbl, store, lockTuples); err != nil {
+		return HandleSQLError(err)
+	}
+
+	return nil
+}
+
 // ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
 func (s *Datastore) ReadUsersetTuples(
 	ctx context.Context,
@@ -320,27 +484,14 @@ func (s *Datastore) ReadUsersetTuples(
 		sb = sb.Where(sq.Eq{"relation": filter.Relation})
 	}
 	if len(filter.AllowedUserTypeRestrictions) > 0 {
-		orConditions := sq.Or{}
-		for _, userset := range filter.AllowedUserTypeRestrictions {
-			if _, ok := userset.GetRelationOrWildcard().(*openfgav1.RelationReference_Relation); ok {
-				orConditions = append(orConditions, sq.Like{
-					"_user": userset.GetType() + ":%#" + userset.GetRelation(),
-				})
-			}
-			if _, ok := userset.GetRelationOrWildcard().(*openfgav1.RelationReference_Wildcard); ok {
-				orConditions = append(orConditions, sq.Eq{
-					"_user": userset.GetType() + ":*",
-				})
-			}
-		}
-		sb = sb.Where(orConditions)
+		sb = sb.Where(sqlcommon.UsersetTypeConditions(filter.AllowedUserTypeRestrictions))
 	}
 	rows, err := sb.QueryContext(ctx)
 	if err != nil {
 		return nil, HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return s.queryShapes.RecordIterator(sqlcommon.QueryMethodReadUsersetTuples, sqlcommon.ReadUsersetTuplesFilter(filter), sqlcommon.NewSQLTupleIterator(rows)), nil
 }
 
 // ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
@@ -385,7 +536,7 @@ func (s *Datastore) ReadStartingWithUser(
 		return nil, HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return s.queryShapes.RecordIterator(sqlcommon.QueryMethodReadStartingWithUser, sqlcommon.ReadStartingWithUserFilter(filter), sqlcommon.NewSQLTupleIterator(rows)), nil
 }
 
 // MaxTuplesPerWrite see [storage.RelationshipTupleWriter].MaxTuplesPerWrite.
@@ -507,9 +658,6 @@ func (s *Datastore) CreateStore(ctx context.Context, store *openfgav1.Store) (*o
 	ctx, span := startTrace(ctx, "CreateStore")
 	defer span.End()
 
-	var id, name string
-	var createdAt, updatedAt time.Time
-
 	txn, err := s.db.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
 		return nil, HandleSQLError(err)
@@ -518,7 +666,86 @@ func (s *Datastore) CreateStore(ctx context.Context, store *openfgav1.Store) (*o
 		_ = txn.Rollback()
 	}()
 
-	_, err = s.stbl.
+	created, err := s.createStore(ctx, txn, store)
+	if err != nil {
+		return nil, err
+	}
+
+	err = txn.Commit()
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return created, nil
+}
+
+// CreateStoreWithUniqueName see [storage.UniqueStoreNamesBackend].CreateStoreWithUniqueName.
+// The creates of a name are serialized by a named lock on the hash of the name, held by the
+// connection of the transaction until it commits, so that two of them cannot both find the name
+// available.
+func (s *Datastore) CreateStoreWithUniqueName(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	ctx, span := startTrace(ctx, "CreateStoreWithUniqueName")
+	defer span.End()
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+	defer conn.Close()
+
+	// named locks are limited to 64 characters. The name is compared with the collation of the
+	// column, which is case-insensitive by default, so the names that differ in case share a lock.
+	hash := sha256.Sum256([]byte(strings.ToLower(store.GetName())))
+	lockName := "openfga_store_name_" + hex.EncodeToString(hash[:16])
+
+	var locked sql.NullInt64
+	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, storeNameLockTimeoutSeconds).Scan(&locked)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+	if locked.Int64 != 1 {
+		return nil, fmt.Errorf("timed out waiting for the lock on the store name '%s'", store.GetName())
+	}
+	defer func() {
+		// released even if the request is canceled, since the connection returns to the pool
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), "SELECT RELEASE_LOCK(?)", lockName)
+	}()
+
+	txn, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	if err := sqlcommon.CheckStoreNameAvailable(ctx, s.stbl.RunWith(txn), store.GetName()); err != nil {
+		var conflict *storage.StoreNameConflictError
+		if errors.As(err, &conflict) {
+			return nil, err
+		}
+		return nil, HandleSQLError(err)
+	}
+
+	created, err := s.createStore(ctx, txn, store)
+	if err != nil {
+		return nil, err
+	}
+
+	err = txn.Commit()
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return created, nil
+}
+
+// createStore inserts the store in the transaction and reads it back.
+func (s *Datastore) createStore(ctx context.Context, txn *sql.Tx, store *openfgav1.Store) (*openfgav1.Store, error) {
+	var id, name string
+	var createdAt, updatedAt time.Time
+
+	_, err := s.stbl.
 		Insert("store").
 		Columns("id", "name", "created_at", "updated_at").
 		Values(store.GetId(), store.GetName(), sq.Expr("NOW()"), sq.Expr("NOW()")).
@@ -539,11 +766,6 @@ func (s *Datastore) CreateStore(ctx context.Context, store *openfgav1.Store) (*o
 		return nil, HandleSQLError(err)
 	}
 
-	err = txn.Commit()
-	if err != nil {
-		return nil, HandleSQLError(err)
-	}
-
 	return &openfgav1.Store{
 		Id:        id,
 		Name:      name,
@@ -552,6 +774,19 @@ func (s *Datastore) CreateStore(ctx context.Context, store *openfgav1.Store) (*o
 	}, nil
 }
 
+// GetStoreByName see [storage.UniqueStoreNamesBackend].GetStoreByName.
+func (s *Datastore) GetStoreByName(ctx context.Context, name string) (*openfgav1.Store, error) {
+	ctx, span := startTrace(ctx, "GetStoreByName")
+	defer span.End()
+
+	store, err := sqlcommon.GetStoreByName(ctx, s.stbl, name)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return store, nil
+}
+
 // GetStore retrieves the details of a specific store using its storeID.
 func (s *Datastore) GetStore(ctx context.Context, id string) (*openfgav1.Store, error) {
 	ctx, span := startTrace(ctx, "GetStore")
@@ -595,6 +830,9 @@ func (s *Datastore) ListStores(ctx context.Context, options storage.ListStoresOp
 		Where(sq.Eq{"deleted_at": nil}).
 		OrderBy("id")
 
+	if options.Name != "" {
+		sb = sb.Where(sq.Eq{"name": options.Name})
+	}
 	if options.Pagination.From != "" {
 		token, err := sqlcommon.UnmarshallContToken(options.Pagination.From)
 		if err != nil {
@@ -645,6 +883,26 @@ func (s *Datastore) ListStores(ctx context.Context, options storage.ListStoresOp
 	return stores, nil, nil
 }
 
+// CountStores see [storage.StoreCounter].CountStores.
+func (s *Datastore) CountStores(ctx context.Context, options storage.CountStoresOptions) (storage.StoreCount, error) {
+	ctx, span := startTrace(ctx, "CountStores")
+	defer span.End()
+
+	// TABLE_ROWS is the estimate of the number of rows of InnoDB tables, less the deleted stores,
+	// which are few and counted with idx_store_deleted_at
+	estimate := s.stbl.
+		Select("CAST(TABLE_ROWS AS SIGNED) - (SELECT COUNT(*) FROM store WHERE deleted_at IS NOT NULL)").
+		From("information_schema.TABLES").
+		Where("TABLE_SCHEMA = DATABASE()").
+		Where(sq.Eq{"TABLE_NAME": "store"})
+	count, err := sqlcommon.CountStores(ctx, s.stbl, options, &estimate)
+	if err != nil {
+		return storage.StoreCount{}, HandleSQLError(err)
+	}
+
+	return count, nil
+}
+
 // DeleteStore removes a store from storage.
 func (s *Datastore) DeleteStore(ctx context.Context, id string) error {
 	ctx, span := startTrace(ctx, "DeleteStore")
@@ -662,6 +920,37 @@ func (s *Datastore) DeleteStore(ctx context.Context, id string) error {
 	return nil
 }
 
+// ListDeletedStores see [storage.StorePurger].ListDeletedStores.
+func (s *Datastore) ListDeletedStores(ctx context.Context) ([]string, error) {
+	ctx, span := startTrace(ctx, "ListDeletedStores")
+	defer span.End()
+
+	stores, err := sqlcommon.ListDeletedStores(ctx, s.stbl)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return stores, nil
+}
+
+// PurgeStore see [storage.StorePurger].PurgeStore.
+func (s *Datastore) PurgeStore(ctx context.Context, store string, batchSize int) (int64, error) {
+	ctx, span := startTrace(ctx, "PurgeStore")
+	defer span.End()
+
+	deleted, err := sqlcommon.PurgeStore(ctx, s.stbl, store, batchSize, func(table, store string, limit int) sq.DeleteBuilder {
+		return s.stbl.
+			Delete(table).
+			Where(sq.Eq{"store": store}).
+			Limit(uint64(limit))
+	})
+	if err != nil {
+		return deleted, HandleSQLError(err)
+	}
+
+	return deleted, nil
+}
+
 // WriteAssertions see [storage.AssertionsBackend].WriteAssertions.
 func (s *Datastore) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
 	ctx, span := startTrace(ctx, "WriteAssertions")
@@ -686,7 +975,7 @@ func (s *Datastore) WriteAssertions(ctx context.Context, store, modelID string,
 }
 
 // ReadAssertions see [storage.AssertionsBackend].ReadAssertions.
-func (s *Datastore) ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error) {
+func (s *Datastore) ReadAssertions(ctx context.Context, store, modelID string, options storage.ReadAssertionsOptions) ([]*openfgav1.Assertion, []byte, error) {
 	ctx, span := startTrace(ctx, "ReadAssertions")
 	defer span.End()
 
@@ -702,18 +991,58 @@ func (s *Datastore) ReadAssertions(ctx context.Context, store, modelID string) (
 		Scan(&marshalledAssertions)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return []*openfgav1.Assertion{}, nil
+			return storage.PageAssertions(nil, options)
 		}
-		return nil, HandleSQLError(err)
+		return nil, nil, HandleSQLError(err)
 	}
 
 	var assertions openfgav1.Assertions
 	err = proto.Unmarshal(marshalledAssertions, &assertions)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	return storage.PageAssertions(assertions.GetAssertions(), options)
+}
+
+// WriteModelAlias see [storage.ModelAliasBackend].WriteModelAlias.
+func (s *Datastore) WriteModelAlias(ctx context.Context, store, alias, modelID string) error {
+	ctx, span := startTrace(ctx, "WriteModelAlias")
+	defer span.End()
+
+	_, err := s.stbl.
+		Insert("model_alias").
+		Columns("store", "alias", "authorization_model_id", "updated_at").
+		Values(store, alias, modelID, sq.Expr("NOW()")).
+		Suffix("ON DUPLICATE KEY UPDATE authorization_model_id = ?, updated_at = NOW()", modelID).
+		ExecContext(ctx)
+	if err != nil {
+		return HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadModelAlias see [storage.ModelAliasBackend].ReadModelAlias.
+func (s *Datastore) ReadModelAlias(ctx context.Context, store, alias string) (string, error) {
+	ctx, span := startTrace(ctx, "ReadModelAlias")
+	defer span.End()
+
+	var modelID string
+	err := s.stbl.
+		Select("authorization_model_id").
+		From("model_alias").
+		Where(sq.Eq{
+			"store": store,
+			"alias": alias,
+		}).
+		QueryRowContext(ctx).
+		Scan(&modelID)
+	if err != nil {
+		return "", HandleSQLError(err)
 	}
 
-	return assertions.GetAssertions(), nil
+	return modelID, nil
 }
 
 // ReadChanges see [storage.ChangelogBackend].ReadChanges.
@@ -726,6 +1055,10 @@ func (s *Datastore) ReadChanges(
 	ctx, span := startTrace(ctx, "ReadChanges")
 	defer span.End()
 
+	if s.changelog.Mode() == sqlcommon.ChangelogModeDisabled {
+		return nil, nil, storage.ErrChangelogDisabled
+	}
+
 	objectTypeFilter := filter.ObjectType
 	horizonOffset := filter.HorizonOffset
 
@@ -749,6 +1082,13 @@ func (s *Datastore) ReadChanges(
 	if objectTypeFilter != "" {
 		sb = sb.Where(sq.Eq{"object_type": objectTypeFilter})
 	}
+	if !filter.StartTime.IsZero() {
+		startULID, err := sqlcommon.ChangesStartULID(filter.StartTime)
+		if err != nil {
+			return nil, nil, err
+		}
+		sb = sb.Where(sq.GtOrEq{"ulid": startULID})
+	}
 	if options.Pagination.From != "" {
 		token, err := sqlcommon.UnmarshallContToken(options.Pagination.From)
 		if err != nil {
@@ -761,6 +1101,12 @@ func (s *Datastore) ReadChanges(
 		if options.SortDesc {
 			sb = sb.Where(sq.Lt{"ulid": token.Ulid})
 		} else {
+			if err := sqlcommon.CheckChangesContinuationToken(ctx, s.stbl, store, token.Ulid); err != nil {
+				if errors.Is(err, storage.ErrContinuationTokenExpired) {
+					return nil, nil, err
+				}
+				return nil, nil, HandleSQLError(err)
+			}
 			sb = sb.Where(sq.Gt{"ulid": token.Ulid})
 		}
 	}
@@ -834,9 +1180,59 @@ func (s *Datastore) ReadChanges(
 	return changes, contToken, nil
 }
 
+// TrimChanges see [storage.ChangelogTrimmer].TrimChanges.
+func (s *Datastore) TrimChanges(ctx context.Context, store string, before time.Time) (int64, error) {
+	ctx, span := startTrace(ctx, "TrimChanges")
+	defer span.End()
+
+	trimmed, err := sqlcommon.TrimChanges(ctx, s.db, s.stbl, store, before)
+	if err != nil {
+		return 0, HandleSQLError(err)
+	}
+
+	return trimmed, nil
+}
+
+// GetLatestChange see [storage.ChangelogStatsReader].GetLatestChange.
+func (s *Datastore) GetLatestChange(ctx context.Context, store string) (time.Time, error) {
+	ctx, span := startTrace(ctx, "GetLatestChange")
+	defer span.End()
+
+	latest, err := sqlcommon.GetLatestChange(ctx, s.stbl, store)
+	if err != nil {
+		return time.Time{}, HandleSQLError(err)
+	}
+
+	return latest, nil
+}
+
+// CountChanges see [storage.ChangelogStatsReader].CountChanges.
+func (s *Datastore) CountChanges(ctx context.Context) (storage.ChangeCount, error) {
+	ctx, span := startTrace(ctx, "CountChanges")
+	defer span.End()
+
+	// TABLE_ROWS is the estimate of the number of rows of InnoDB tables, less the deleted stores,
+	// which are few and counted with idx_store_deleted_at
+	estimate := s.stbl.
+		Select("CAST(TABLE_ROWS AS SIGNED) - (SELECT COUNT(*) FROM store WHERE deleted_at IS NOT NULL)").
+		From("information_schema.TABLES").
+		Where("TABLE_SCHEMA = DATABASE()").
+		Where(sq.Eq{"TABLE_NAME": "changelog"})
+	count, err := sqlcommon.CountChanges(ctx, s.stbl, &estimate)
+	if err != nil {
+		return storage.ChangeCount{}, HandleSQLError(err)
+	}
+
+	return count, nil
+}
+
 // IsReady see [sqlcommon.IsReady].
 func (s *Datastore) IsReady(ctx context.Context) (storage.ReadinessStatus, error) {
-	return sqlcommon.IsReady(ctx, s.db)
+	status, err := sqlcommon.IsReady(ctx, s.db)
+	if err != nil {
+		return status, err
+	}
+	return s.poolMonitor.AnnotateReadiness(status), nil
 }
 
 // HandleSQLError processes an SQL error and converts it into a more
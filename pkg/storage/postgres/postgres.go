@@ -42,12 +42,31 @@ type Datastore struct {
 	dbInfo                 *sqlcommon.DBInfo
 	logger                 logger.Logger
 	dbStatsCollector       prometheus.Collector
+	poolMonitor            *sqlcommon.PoolMonitor
+	queryShapes            *sqlcommon.QueryShapeSampler
+	tupleCounts            bool
+	changelog              *sqlcommon.ChangelogWriter
 	maxTuplesPerWriteField int
 	maxTypesPerModelField  int
 }
 
-// Ensures that Datastore implements the OpenFGADatastore interface.
+// Ensures that Datastore implements the OpenFGADatastore, RelationCounter, RelationUsageCounter, SnapshotBeginner,
+// TupleCounter, ModelAliasBackend, StorePurger, StoreCounter, ChangelogTrimmer, ChangelogStatsReader, UniqueStoreNamesBackend and IndexAdvisor interfaces.
 var _ storage.OpenFGADatastore = (*Datastore)(nil)
+var _ storage.RelationCounter = (*Datastore)(nil)
+var _ storage.RelationUsageCounter = (*Datastore)(nil)
+var _ storage.SnapshotBeginner = (*Datastore)(nil)
+var _ storage.TupleCounter = (*Datastore)(nil)
+var _ storage.ModelAliasBackend = (*Datastore)(nil)
+var _ storage.StorePurger = (*Datastore)(nil)
+var _ storage.StoreCounter = (*Datastore)(nil)
+var _ storage.ChangelogTrimmer = (*Datastore)(nil)
+var _ storage.ChangelogStatsReader = (*Datastore)(nil)
+var _ storage.UniqueStoreNamesBackend = (*Datastore)(nil)
+var _ storage.IndexAdvisor = (*Datastore)(nil)
+
+// tupleCountUpsert adds to the existing tuple counter of a store and object type.
+const tupleCountUpsert = "ON CONFLICT (store, object_type) DO UPDATE SET tuples = tuple_count.tuples + excluded.tuples"
 
 // New creates a new [Datastore] storage.
 func New(uri string, cfg *sqlcommon.Config) (*Datastore, error) {
@@ -130,7 +149,22 @@ func NewWithDB(db *sql.DB, cfg *sqlcommon.Config) (*Datastore, error) {
 	}
 
 	stbl := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).RunWith(db)
-	dbInfo := sqlcommon.NewDBInfo(db, stbl, HandleSQLError)
+	changelog := sqlcommon.NewChangelogWriter(db, stbl, sqlcommon.ChangelogTable{
+		Columns:  sqlcommon.ChangelogColumns,
+		Now:      "NOW()",
+		LockRows: true,
+	}, cfg)
+	dbInfo := sqlcommon.NewDBInfo(db, stbl, HandleSQLError).
+		WithChangelogWriter(changelog).
+		WithWriteHook(cfg.WriteHook)
+	if cfg.TupleCounts {
+		dbInfo = dbInfo.WithTupleCounts(tupleCountUpsert)
+	}
+
+	var queryShapes *sqlcommon.QueryShapeSampler
+	if cfg.QueryShapeSampling {
+		queryShapes = sqlcommon.NewQueryShapeSampler()
+	}
 
 	return &Datastore{
 		stbl:                   stbl,
@@ -138,6 +172,10 @@ func NewWithDB(db *sql.DB, cfg *sqlcommon.Config) (*Datastore, error) {
 		dbInfo:                 dbInfo,
 		logger:                 cfg.Logger,
 		dbStatsCollector:       collector,
+		changelog:              changelog,
+		poolMonitor:            sqlcommon.NewPoolMonitor(db, cfg),
+		queryShapes:            queryShapes,
+		tupleCounts:            cfg.TupleCounts,
 		maxTuplesPerWriteField: cfg.MaxTuplesPerWriteField,
 		maxTypesPerModelField:  cfg.MaxTypesPerModelField,
 	}, nil
@@ -145,6 +183,8 @@ func NewWithDB(db *sql.DB, cfg *sqlcommon.Config) (*Datastore, error) {
 
 // Close see [storage.OpenFGADatastore].Close.
 func (s *Datastore) Close() {
+	s.poolMonitor.Stop()
+	s.changelog.Close()
 	if s.dbStatsCollector != nil {
 		prometheus.Unregister(s.dbStatsCollector)
 	}
@@ -161,7 +201,12 @@ func (s *Datastore) Read(
 	ctx, span := startTrace(ctx, "Read")
 	defer span.End()
 
-	return s.read(ctx, store, tupleKey, nil)
+	iter, err := s.read(ctx, store, tupleKey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queryShapes.RecordIterator(sqlcommon.QueryMethodRead, sqlcommon.TupleKeyFilter(tupleKey), iter), nil
 }
 
 // ReadPage see [storage.RelationshipTupleReader].ReadPage.
@@ -180,7 +225,13 @@ func (s *Datastore) ReadPage(
 	}
 	defer iter.Stop()
 
-	return iter.ToArray(options.Pagination)
+	tuples, contToken, err := iter.ToArray(options.Pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.queryShapes.Record(sqlcommon.QueryMethodReadPage, sqlcommon.ReadPageFilter(tupleKey, options), len(tuples))
+	return tuples, contToken, nil
 }
 
 func (s *Datastore) read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options *storage.ReadPageOptions) (*sqlcommon.SQLTupleIterator, error) {
@@ -196,7 +247,8 @@ func (s *Datastore) read(ctx context.Context, store string, tupleKey *openfgav1.
 		From("tuple").
 		Where(sq.Eq{"store": store})
 	if options != nil {
-		sb = sb.OrderBy("ulid")
+		// compare bytes, as the other datastores do. The idx_tuple_read_order index serves this order.
+		sb = sb.OrderBy(`object_type COLLATE "C"`, `object_id COLLATE "C"`, `relation COLLATE "C"`, `_user COLLATE "C"`)
 	}
 
 	objectType, objectID := tupleUtils.SplitObject(tupleKey.GetObject())
@@ -212,12 +264,16 @@ func (s *Datastore) read(ctx context.Context, store string, tupleKey *openfgav1.
 	if tupleKey.GetUser() != "" {
 		sb = sb.Where(sq.Eq{"_user": tupleKey.GetUser()})
 	}
+	if options != nil {
+		sb = sqlcommon.WhereConditionFilter(sb, options.Condition)
+	}
 	if options != nil && options.Pagination.From != "" {
-		token, err := sqlcommon.UnmarshallContToken(options.Pagination.From)
+		cursor, err := storage.UnmarshalReadCursor(options.Pagination.From)
 		if err != nil {
 			return nil, err
 		}
-		sb = sb.Where(sq.GtOrEq{"ulid": token.Ulid})
+		sb = sb.Where(sq.Expr(`(object_type COLLATE "C", object_id COLLATE "C", relation COLLATE "C", _user COLLATE "C") >= (?, ?, ?, ?)`,
+			cursor.ObjectType, cursor.ObjectID, cursor.Relation, cursor.User))
 	}
 	if options != nil && options.Pagination.PageSize != 0 {
 		sb = sb.Limit(uint64(options.Pagination.PageSize + 1)) // + 1 is used to determine whether to return a continuation token.
@@ -285,8 +341,10 @@ func (s *Datastore) ReadUserTuple(ctx context.Context, store string, tupleKey *o
 			&conditionContext,
 		)
 	if err != nil {
+		s.queryShapes.Record(sqlcommon.QueryMethodReadUserTuple, sqlcommon.TupleKeyFilter(tupleKey), 0)
 		return nil, HandleSQLError(err)
 	}
+	s.queryShapes.Record(sqlcommon.QueryMethodReadUserTuple, sqlcommon.TupleKeyFilter(tupleKey), 1)
 
 	if conditionName.String != "" {
 		record.ConditionName = conditionName.String
@@ -303,6 +361,120 @@ func (s *Datastore) ReadUserTuple(ctx context.Context, store string, tupleKey *o
 	return record.AsTuple(), nil
 }
 
+// BeginSnapshot see [storage.SnapshotBeginner].BeginSnapshot. The tuples are read within
+// read-only repeatable-read transactions, the first one exporting its snapshot for the others.
+func (s *Datastore) BeginSnapshot(ctx context.Context, options storage.SnapshotOptions) (storage.SnapshotTupleReader, error) {
+	ctx, span := startTrace(ctx, "BeginSnapshot")
+	defer span.End()
+
+	// the transactions end with Release rather than with the request
+	ctx = context.WithoutCancel(ctx)
+	first, err := s.beginSnapshotConn(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshotID string
+	if err := first.Txn.QueryRowContext(ctx, "SELECT pg_export_snapshot()").Scan(&snapshotID); err != nil {
+		_ = first.Txn.Rollback()
+		return nil, HandleSQLError(err)
+	}
+
+	return sqlcommon.NewSnapshotTupleReader(first, func(ctx context.Context) (*sqlcommon.SnapshotConn, error) {
+		return s.beginSnapshotConn(ctx, snapshotID)
+	}, options.MaxConcurrentReads), nil
+}
+
+// beginSnapshotConn begins a read-only repeatable-read transaction, which reads the exported
+// snapshot snapshotID if set.
+func (s *Datastore) beginSnapshotConn(ctx context.Context, snapshotID string) (*sqlcommon.SnapshotConn, error) {
+	txn, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	if snapshotID != "" {
+		// SET TRANSACTION SNAPSHOT takes no parameters, the identifier is generated by PostgreSQL
+		if _, err := txn.ExecContext(ctx, "SET TRANSACTION SNAPSHOT '"+strings.ReplaceAll(snapshotID, "'", "''")+"'"); err != nil {
+			_ = txn.Rollback()
+			return nil, HandleSQLError(err)
+		}
+	}
+
+	snapshot := *s
+	snapshot.stbl = s.stbl.RunWith(txn)
+	return &sqlcommon.SnapshotConn{Reader: &snapshot, Txn: txn}, nil
+}
+
+// CountRelation see [storage.RelationCounter].CountRelation.
+func (s *Datastore) CountRelation(ctx context.Context, store, objectType, relation string) (storage.RelationCounts, error) {
+	ctx, span := startTrace(ctx, "CountRelation")
+	defer span.End()
+
+	counts, err := sqlcommon.CountRelation(ctx, s.stbl, store, objectType, relation, "_user")
+	if err != nil {
+		return storage.RelationCounts{}, HandleSQLError(err)
+	}
+
+	return counts, nil
+}
+
+// CountRelationUsage see [storage.RelationUsageCounter].CountRelationUsage.
+func (s *Datastore) CountRelationUsage(ctx context.Context, store string) ([]storage.RelationUsage, error) {
+	ctx, span := startTrace(ctx, "CountRelationUsage")
+	defer span.End()
+
+	usage, err := sqlcommon.CountRelationUsage(ctx, s.stbl, store)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return usage, nil
+}
+
+// AdviseIndexes see [storage.IndexAdvisor].AdviseIndexes. The query shapes are only sampled
+// if the datastore was created with [sqlcommon.WithQueryShapeSampling].
+func (s *Datastore) AdviseIndexes(ctx context.Context) (storage.IndexAdvice, error) {
+	_, span := startTrace(ctx, "AdviseIndexes")
+	defer span.End()
+
+	return s.queryShapes.Advise("postgres")
+}
+
+// ReadTupleCounts see [storage.TupleCounter].ReadTupleCounts. The counters are only maintained
+// if the datastore was created with [sqlcommon.WithTupleCounts].
+func (s *Datastore) ReadTupleCounts(ctx context.Context, store string) (storage.TupleCounts, error) {
+	ctx, span := startTrace(ctx, "ReadTupleCounts")
+	defer span.End()
+
+	if !s.tupleCounts {
+		return storage.TupleCounts{}, storage.ErrTupleCountsDisabled
+	}
+
+	counts, err := sqlcommon.ReadTupleCounts(ctx, s.stbl, store)
+	if err != nil {
+		return storage.TupleCounts{}, HandleSQLError(err)
+	}
+
+	return counts, nil
+}
+
+// BackfillTupleCounts see [storage.TupleCounter].BackfillTupleCounts.
+func (s *Datastore) BackfillTupleCounts(ctx context.Context, store string) error {
+	ctx, span := startTrace(ctx, "BackfillTupleCounts")
+	defer span.End()
+
+	// SHARE conflicts with the ROW EXCLUSIVE lock of the writes, so the writes of every store
+	// wait while the tuples of the store are counted
+	lockTuples := sq.Expr("LOCK TABLE tuple IN SHARE MODE")
+
+	if err := sqlcommon.BackfillTupleCounts(ctx, s.db, s.stbl, store, lockTuples); err != nil {
+		return HandleSQLError(err)
+	}
+
+	return nil
+}
+
 // ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
 func (s *Datastore) ReadUsersetTuples(
 	ctx context.Context,
@@ -334,27 +506,14 @@ func (s *Datastore) ReadUsersetTuples(
 		sb = sb.Where(sq.Eq{"relation": filter.Relation})
 	}
 	if len(filter.AllowedUserTypeRestrictions) > 0 {
-		orConditions := sq.Or{}
-		for _, userset := range filter.AllowedUserTypeRestrictions {
-			if _, ok := userset.GetRelationOrWildcard().(*openfgav1.RelationReference_Relation); ok {
-				orConditions = append(orConditions, sq.Like{
-					"_user": userset.GetType() + ":%#" + userset.GetRelation(),
-				})
-			}
-			if _, ok := userset.GetRelationOrWildcard().(*openfgav1.RelationReference_Wildcard); ok {
-				orConditions = append(orConditions, sq.Eq{
-					"_user": userset.GetType() + ":*",
-				})
-			}
-		}
-		sb = sb.Where(orConditions)
+		sb = sb.Where(sqlcommon.UsersetTypeConditions(filter.AllowedUserTypeRestrictions))
 	}
 	rows, err := sb.QueryContext(ctx)
 	if err != nil {
 		return nil, HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return s.queryShapes.RecordIterator(sqlcommon.QueryMethodReadUsersetTuples, sqlcommon.ReadUsersetTuplesFilter(filter), sqlcommon.NewSQLTupleIterator(rows)), nil
 }
 
 // ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
@@ -399,7 +558,7 @@ func (s *Datastore) ReadStartingWithUser(
 		return nil, HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return s.queryShapes.RecordIterator(sqlcommon.QueryMethodReadStartingWithUser, sqlcommon.ReadStartingWithUserFilter(filter), sqlcommon.NewSQLTupleIterator(rows)), nil
 }
 
 // MaxTuplesPerWrite see [storage.RelationshipTupleWriter].MaxTuplesPerWrite.
@@ -521,10 +680,54 @@ func (s *Datastore) CreateStore(ctx context.Context, store *openfgav1.Store) (*o
 	ctx, span := startTrace(ctx, "CreateStore")
 	defer span.End()
 
+	return createStore(ctx, s.stbl, store)
+}
+
+// CreateStoreWithUniqueName see [storage.UniqueStoreNamesBackend].CreateStoreWithUniqueName.
+// The creates of a name are serialized by a transaction-level advisory lock on the hash of the
+// name, so that two of them cannot both find the name available.
+func (s *Datastore) CreateStoreWithUniqueName(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	ctx, span := startTrace(ctx, "CreateStoreWithUniqueName")
+	defer span.End()
+
+	txn, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	if _, err := txn.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", "openfga.store_name:"+store.GetName()); err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	stbl := s.stbl.RunWith(txn)
+	if err := sqlcommon.CheckStoreNameAvailable(ctx, stbl, store.GetName()); err != nil {
+		var conflict *storage.StoreNameConflictError
+		if errors.As(err, &conflict) {
+			return nil, err
+		}
+		return nil, HandleSQLError(err)
+	}
+
+	created, err := createStore(ctx, stbl, store)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := txn.Commit(); err != nil {
+		return nil, HandleSQLError(err)
+	}
+	return created, nil
+}
+
+// createStore inserts the store with stbl, which may run in a transaction.
+func createStore(ctx context.Context, stbl sq.StatementBuilderType, store *openfgav1.Store) (*openfgav1.Store, error) {
 	var id, name string
 	var createdAt, updatedAt time.Time
 
-	err := s.stbl.
+	err := stbl.
 		Insert("store").
 		Columns("id", "name", "created_at", "updated_at").
 		Values(store.GetId(), store.GetName(), sq.Expr("NOW()"), sq.Expr("NOW()")).
@@ -575,6 +778,19 @@ func (s *Datastore) GetStore(ctx context.Context, id string) (*openfgav1.Store,
 	}, nil
 }
 
+// GetStoreByName see [storage.UniqueStoreNamesBackend].GetStoreByName.
+func (s *Datastore) GetStoreByName(ctx context.Context, name string) (*openfgav1.Store, error) {
+	ctx, span := startTrace(ctx, "GetStoreByName")
+	defer span.End()
+
+	store, err := sqlcommon.GetStoreByName(ctx, s.stbl, name)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return store, nil
+}
+
 // ListStores provides a paginated list of all stores present in the storage.
 func (s *Datastore) ListStores(ctx context.Context, options storage.ListStoresOptions) ([]*openfgav1.Store, []byte, error) {
 	ctx, span := startTrace(ctx, "ListStores")
@@ -586,6 +802,9 @@ func (s *Datastore) ListStores(ctx context.Context, options storage.ListStoresOp
 		Where(sq.Eq{"deleted_at": nil}).
 		OrderBy("id")
 
+	if options.Name != "" {
+		sb = sb.Where(sq.Eq{"name": options.Name})
+	}
 	if options.Pagination.From != "" {
 		token, err := sqlcommon.UnmarshallContToken(options.Pagination.From)
 		if err != nil {
@@ -636,6 +855,27 @@ func (s *Datastore) ListStores(ctx context.Context, options storage.ListStoresOp
 	return stores, nil, nil
 }
 
+// CountStores see [storage.StoreCounter].CountStores.
+func (s *Datastore) CountStores(ctx context.Context, options storage.CountStoresOptions) (storage.StoreCount, error) {
+	ctx, span := startTrace(ctx, "CountStores")
+	defer span.End()
+
+	// reltuples is the estimate of the number of rows maintained by VACUUM and ANALYZE, and the
+	// null_frac of deleted_at the estimate of the fraction of them that were not deleted
+	estimate := s.stbl.
+		Select("(c.reltuples * s.null_frac)::bigint").
+		From("pg_class c").
+		Join("pg_namespace n ON n.oid = c.relnamespace").
+		Join("pg_stats s ON s.schemaname = n.nspname AND s.tablename = c.relname AND s.attname = 'deleted_at'").
+		Where("c.oid = 'store'::regclass")
+	count, err := sqlcommon.CountStores(ctx, s.stbl, options, &estimate)
+	if err != nil {
+		return storage.StoreCount{}, HandleSQLError(err)
+	}
+
+	return count, nil
+}
+
 // DeleteStore removes a store from storage.
 func (s *Datastore) DeleteStore(ctx context.Context, id string) error {
 	ctx, span := startTrace(ctx, "DeleteStore")
@@ -653,6 +893,37 @@ func (s *Datastore) DeleteStore(ctx context.Context, id string) error {
 	return nil
 }
 
+// ListDeletedStores see [storage.StorePurger].ListDeletedStores.
+func (s *Datastore) ListDeletedStores(ctx context.Context) ([]string, error) {
+	ctx, span := startTrace(ctx, "ListDeletedStores")
+	defer span.End()
+
+	stores, err := sqlcommon.ListDeletedStores(ctx, s.stbl)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return stores, nil
+}
+
+// PurgeStore see [storage.StorePurger].PurgeStore.
+// PostgreSQL has no DELETE ... LIMIT, so the rows of each batch are selected by ctid.
+func (s *Datastore) PurgeStore(ctx context.Context, store string, batchSize int) (int64, error) {
+	ctx, span := startTrace(ctx, "PurgeStore")
+	defer span.End()
+
+	deleted, err := sqlcommon.PurgeStore(ctx, s.stbl, store, batchSize, func(table, store string, limit int) sq.DeleteBuilder {
+		return s.stbl.
+			Delete(table).
+			Where(sq.Expr("ctid IN (SELECT ctid FROM "+table+" WHERE store = ? LIMIT ?)", store, limit))
+	})
+	if err != nil {
+		return deleted, HandleSQLError(err)
+	}
+
+	return deleted, nil
+}
+
 // WriteAssertions see [storage.AssertionsBackend].WriteAssertions.
 func (s *Datastore) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
 	ctx, span := startTrace(ctx, "WriteAssertions")
@@ -677,7 +948,7 @@ func (s *Datastore) WriteAssertions(ctx context.Context, store, modelID string,
 }
 
 // ReadAssertions see [storage.AssertionsBackend].ReadAssertions.
-func (s *Datastore) ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error) {
+func (s *Datastore) ReadAssertions(ctx context.Context, store, modelID string, options storage.ReadAssertionsOptions) ([]*openfgav1.Assertion, []byte, error) {
 	ctx, span := startTrace(ctx, "ReadAssertions")
 	defer span.End()
 
@@ -693,18 +964,58 @@ func (s *Datastore) ReadAssertions(ctx context.Context, store, modelID string) (
 		Scan(&marshalledAssertions)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return []*openfgav1.Assertion{}, nil
+			return storage.PageAssertions(nil, options)
 		}
-		return nil, HandleSQLError(err)
+		return nil, nil, HandleSQLError(err)
 	}
 
 	var assertions openfgav1.Assertions
 	err = proto.Unmarshal(marshalledAssertions, &assertions)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	return storage.PageAssertions(assertions.GetAssertions(), options)
+}
+
+// WriteModelAlias see [storage.ModelAliasBackend].WriteModelAlias.
+func (s *Datastore) WriteModelAlias(ctx context.Context, store, alias, modelID string) error {
+	ctx, span := startTrace(ctx, "WriteModelAlias")
+	defer span.End()
+
+	_, err := s.stbl.
+		Insert("model_alias").
+		Columns("store", "alias", "authorization_model_id", "updated_at").
+		Values(store, alias, modelID, sq.Expr("NOW()")).
+		Suffix("ON CONFLICT (store, alias) DO UPDATE SET authorization_model_id = ?, updated_at = NOW()", modelID).
+		ExecContext(ctx)
+	if err != nil {
+		return HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadModelAlias see [storage.ModelAliasBackend].ReadModelAlias.
+func (s *Datastore) ReadModelAlias(ctx context.Context, store, alias string) (string, error) {
+	ctx, span := startTrace(ctx, "ReadModelAlias")
+	defer span.End()
+
+	var modelID string
+	err := s.stbl.
+		Select("authorization_model_id").
+		From("model_alias").
+		Where(sq.Eq{
+			"store": store,
+			"alias": alias,
+		}).
+		QueryRowContext(ctx).
+		Scan(&modelID)
+	if err != nil {
+		return "", HandleSQLError(err)
 	}
 
-	return assertions.GetAssertions(), nil
+	return modelID, nil
 }
 
 // ReadChanges see [storage.ChangelogBackend].ReadChanges.
@@ -717,6 +1028,10 @@ func (s *Datastore) ReadChanges(
 	ctx, span := startTrace(ctx, "ReadChanges")
 	defer span.End()
 
+	if s.changelog.Mode() == sqlcommon.ChangelogModeDisabled {
+		return nil, nil, storage.ErrChangelogDisabled
+	}
+
 	objectTypeFilter := filter.ObjectType
 	horizonOffset := filter.HorizonOffset
 
@@ -740,6 +1055,13 @@ func (s *Datastore) ReadChanges(
 	if objectTypeFilter != "" {
 		sb = sb.Where(sq.Eq{"object_type": objectTypeFilter})
 	}
+	if !filter.StartTime.IsZero() {
+		startULID, err := sqlcommon.ChangesStartULID(filter.StartTime)
+		if err != nil {
+			return nil, nil, err
+		}
+		sb = sb.Where(sq.GtOrEq{"ulid": startULID})
+	}
 	if options.Pagination.From != "" {
 		token, err := sqlcommon.UnmarshallContToken(options.Pagination.From)
 		if err != nil {
@@ -752,6 +1074,12 @@ func (s *Datastore) ReadChanges(
 		if options.SortDesc {
 			sb = sb.Where(sq.Lt{"ulid": token.Ulid})
 		} else {
+			if err := sqlcommon.CheckChangesContinuationToken(ctx, s.stbl, store, token.Ulid); err != nil {
+				if errors.Is(err, storage.ErrContinuationTokenExpired) {
+					return nil, nil, err
+				}
+				return nil, nil, HandleSQLError(err)
+			}
 			sb = sb.Where(sq.Gt{"ulid": token.Ulid})
 		}
 	}
@@ -825,9 +1153,57 @@ func (s *Datastore) ReadChanges(
 	return changes, contToken, nil
 }
 
+// TrimChanges see [storage.ChangelogTrimmer].TrimChanges.
+func (s *Datastore) TrimChanges(ctx context.Context, store string, before time.Time) (int64, error) {
+	ctx, span := startTrace(ctx, "TrimChanges")
+	defer span.End()
+
+	trimmed, err := sqlcommon.TrimChanges(ctx, s.db, s.stbl, store, before)
+	if err != nil {
+		return 0, HandleSQLError(err)
+	}
+
+	return trimmed, nil
+}
+
+// GetLatestChange see [storage.ChangelogStatsReader].GetLatestChange.
+func (s *Datastore) GetLatestChange(ctx context.Context, store string) (time.Time, error) {
+	ctx, span := startTrace(ctx, "GetLatestChange")
+	defer span.End()
+
+	latest, err := sqlcommon.GetLatestChange(ctx, s.stbl, store)
+	if err != nil {
+		return time.Time{}, HandleSQLError(err)
+	}
+
+	return latest, nil
+}
+
+// CountChanges see [storage.ChangelogStatsReader].CountChanges.
+func (s *Datastore) CountChanges(ctx context.Context) (storage.ChangeCount, error) {
+	ctx, span := startTrace(ctx, "CountChanges")
+	defer span.End()
+
+	// reltuples is the estimate of the number of rows maintained by VACUUM and ANALYZE
+	estimate := s.stbl.
+		Select("reltuples::bigint").
+		From("pg_class").
+		Where("oid = 'changelog'::regclass")
+	count, err := sqlcommon.CountChanges(ctx, s.stbl, &estimate)
+	if err != nil {
+		return storage.ChangeCount{}, HandleSQLError(err)
+	}
+
+	return count, nil
+}
+
 // IsReady see [sqlcommon.IsReady].
 func (s *Datastore) IsReady(ctx context.Context) (storage.ReadinessStatus, error) {
-	return sqlcommon.IsReady(ctx, s.db)
+	status, err := sqlcommon.IsReady(ctx, s.db)
+	if err != nil {
+		return status, err
+	}
+	return s.poolMonitor.AnnotateReadiness(status), nil
 }
 
 // HandleSQLError processes an SQL error and converts it into a more
@@ -26,7 +26,7 @@ func TestPostgresDatastore(t *testing.T) {
 	testDatastore := storagefixtures.RunDatastoreTestContainer(t, "postgres")
 
 	uri := testDatastore.GetConnectionURI(true)
-	ds, err := New(uri, sqlcommon.NewConfig())
+	ds, err := New(uri, sqlcommon.NewConfig(sqlcommon.WithTupleCounts(true)))
 	require.NoError(t, err)
 	defer ds.Close()
 	test.RunAllTests(t, ds)
@@ -44,6 +44,32 @@ func TestPostgresDatastoreAfterCloseIsNotReady(t *testing.T) {
 	require.False(t, status.IsReady)
 }
 
+func TestCountStoresEstimateExcludesDeletedStores(t *testing.T) {
+	testDatastore := storagefixtures.RunDatastoreTestContainer(t, "postgres")
+
+	uri := testDatastore.GetConnectionURI(true)
+	ds, err := New(uri, sqlcommon.NewConfig())
+	require.NoError(t, err)
+	defer ds.Close()
+
+	ctx := context.Background()
+	var storeIDs []string
+	for i := 0; i < 4; i++ {
+		store, err := ds.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: "store"})
+		require.NoError(t, err)
+		storeIDs = append(storeIDs, store.GetId())
+	}
+	require.NoError(t, ds.DeleteStore(ctx, storeIDs[0]))
+
+	// the statistics of a table this small are computed from all of its rows
+	_, err = ds.db.ExecContext(ctx, "ANALYZE store")
+	require.NoError(t, err)
+
+	count, err := ds.CountStores(ctx, storage.CountStoresOptions{})
+	require.NoError(t, err)
+	require.Equal(t, storage.StoreCount{Count: 3, Estimated: true}, count)
+}
+
 // TestReadEnsureNoOrder asserts that the read response is not ordered by ulid.
 func TestReadEnsureNoOrder(t *testing.T) {
 	tests := []struct {
@@ -220,7 +246,7 @@ func TestCtxCancel(t *testing.T) {
 	}
 }
 
-// TestReadPageEnsureNoOrder asserts that the read page is ordered by ulid.
+// TestReadPageEnsureOrder asserts that the read page is ordered by object, relation and user, not by ulid.
 func TestReadPageEnsureOrder(t *testing.T) {
 	testDatastore := storagefixtures.RunDatastoreTestContainer(t, "postgres")
 
@@ -262,9 +288,9 @@ func TestReadPageEnsureOrder(t *testing.T) {
 	require.NoError(t, err)
 
 	require.Len(t, tuples, 2)
-	// We expect that objectID2 will return first because it has a smaller ulid.
-	require.Equal(t, secondTuple, tuples[0].GetKey())
-	require.Equal(t, firstTuple, tuples[1].GetKey())
+	// We expect that objectID1 will return first even though objectID2 has a smaller ulid.
+	require.Equal(t, firstTuple, tuples[0].GetKey())
+	require.Equal(t, secondTuple, tuples[1].GetKey())
 }
 
 func TestReadAuthorizationModelUnmarshallError(t *testing.T) {
@@ -499,6 +525,54 @@ func TestAllowNullCondition(t *testing.T) {
 	require.Equal(t, tk, changes[1].GetTupleKey())
 }
 
+// TestReadUsersetTuplesWithoutUserTypeColumns tests that ReadUsersetTuples finds the tuples
+// written without the user_object_type and user_relation columns, e.g. by a server still running
+// the version before the migration that added them.
+func TestReadUsersetTuplesWithoutUserTypeColumns(t *testing.T) {
+	ctx := context.Background()
+	testDatastore := storagefixtures.RunDatastoreTestContainer(t, "postgres")
+
+	uri := testDatastore.GetConnectionURI(true)
+	ds, err := New(uri, sqlcommon.NewConfig())
+	require.NoError(t, err)
+	defer ds.Close()
+
+	storeID := ulid.Make().String()
+	stmt := `
+		INSERT INTO tuple (
+			store, object_type, object_id, relation, _user, user_type, ulid, inserted_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW());
+	`
+	for _, user := range []string{"group:eng#can_view", "group:eng#canxview", "group:*", "user:*"} {
+		_, err = ds.db.ExecContext(ctx, stmt, storeID, "document", "1", "viewer", user, tuple.UserSet, ulid.Make().String())
+		require.NoError(t, err)
+	}
+	err = ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "group:ops#can_view")})
+	require.NoError(t, err)
+
+	iter, err := ds.ReadUsersetTuples(ctx, storeID, storage.ReadUsersetTuplesFilter{
+		Object:   "document:1",
+		Relation: "viewer",
+		AllowedUserTypeRestrictions: []*openfgav1.RelationReference{
+			typesystem.DirectRelationReference("group", "can_view"),
+			typesystem.WildcardRelationReference("group"),
+		},
+	}, storage.ReadUsersetTuplesOptions{})
+	require.NoError(t, err)
+	defer iter.Stop()
+
+	var users []string
+	for {
+		tp, err := iter.Next(ctx)
+		if errors.Is(err, storage.ErrIteratorDone) {
+			break
+		}
+		require.NoError(t, err)
+		users = append(users, tp.GetKey().GetUser())
+	}
+	require.ElementsMatch(t, []string{"group:eng#can_view", "group:*", "group:ops#can_view"}, users)
+}
+
 // TestMarshalledAssertions tests that previously persisted marshalled
 // assertions can be read back. In any case where the Assertions proto model
 // needs to change, we'll likely need to introduce a series of data migrations.
@@ -520,7 +594,7 @@ func TestMarshalledAssertions(t *testing.T) {
 	_, err = ds.db.ExecContext(ctx, stmt, "store", "model")
 	require.NoError(t, err)
 
-	assertions, err := ds.ReadAssertions(ctx, "store", "model")
+	assertions, _, err := ds.ReadAssertions(ctx, "store", "model", storage.ReadAssertionsOptions{})
 	require.NoError(t, err)
 
 	expectedAssertions := []*openfgav1.Assertion{
@@ -557,3 +631,13 @@ func TestHandleSQLError(t *testing.T) {
 		require.ErrorIs(t, err, storage.ErrNotFound)
 	})
 }
+
+func BenchmarkPostgresDatastoreReadUsersetTuples(b *testing.B) {
+	testDatastore := storagefixtures.RunDatastoreTestContainer(b, "postgres")
+
+	uri := testDatastore.GetConnectionURI(true)
+	ds, err := New(uri, sqlcommon.NewConfig())
+	require.NoError(b, err)
+	defer ds.Close()
+	test.ReadUsersetTuplesBenchmark(b, ds)
+}
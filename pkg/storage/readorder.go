@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"cmp"
+	"encoding/json"
+
+	tupleutils "github.com/openfga/openfga/pkg/tuple"
+)
+
+// ReadCursor is the continuation token of [RelationshipTupleReader].ReadPage. Pages are
+// ordered by object type, object ID, relation and user (see [CompareReadOrder]), and the
+// cursor holds that key for the first tuple of the next page. The next page is then read
+// with a keyset condition on the key, instead of skipping the tuples of the previous pages.
+type ReadCursor struct {
+	ObjectType string `json:"object_type"`
+	ObjectID   string `json:"object_id"`
+	Relation   string `json:"relation"`
+	User       string `json:"user"`
+}
+
+// NewReadCursor returns the cursor that resumes ReadPage at the given tuple.
+func NewReadCursor(t *TupleRecord) ReadCursor {
+	return ReadCursor{
+		ObjectType: t.ObjectType,
+		ObjectID:   t.ObjectID,
+		Relation:   t.Relation,
+		User:       readOrderUser(t),
+	}
+}
+
+// Marshal encodes the cursor as a continuation token.
+func (c ReadCursor) Marshal() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// UnmarshalReadCursor decodes a continuation token returned by ReadPage. It returns
+// [ErrInvalidContinuationToken] for anything else, including the tokens of previous
+// versions, which were offsets or ULIDs.
+func UnmarshalReadCursor(from string) (ReadCursor, error) {
+	var c ReadCursor
+	if err := json.Unmarshal([]byte(from), &c); err != nil || c.ObjectType == "" || c.ObjectID == "" || c.Relation == "" || c.User == "" {
+		return ReadCursor{}, ErrInvalidContinuationToken
+	}
+	return c, nil
+}
+
+// Compare returns -1, 0 or +1 depending on whether the tuple comes before, at or after
+// the cursor in the ReadPage order.
+func (c ReadCursor) Compare(t *TupleRecord) int {
+	return cmp.Or(
+		cmp.Compare(t.ObjectType, c.ObjectType),
+		cmp.Compare(t.ObjectID, c.ObjectID),
+		cmp.Compare(t.Relation, c.Relation),
+		cmp.Compare(readOrderUser(t), c.User),
+	)
+}
+
+// CompareReadOrder defines the order of the tuples returned by ReadPage: by object type,
+// object ID, relation and user (e.g. `group:eng#member`), each compared byte-wise.
+// It returns -1, 0 or +1 depending on whether a comes before, at or after b.
+func CompareReadOrder(a, b *TupleRecord) int {
+	return NewReadCursor(b).Compare(a)
+}
+
+func readOrderUser(t *TupleRecord) string {
+	if t.User != "" {
+		return t.User
+	}
+	return tupleutils.FromUserParts(t.UserObjectType, t.UserObjectID, t.UserRelation)
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func FuzzUnmarshalReadCursor(f *testing.F) {
+	token, err := NewReadCursor(&TupleRecord{
+		ObjectType: "document",
+		ObjectID:   "1",
+		Relation:   "viewer",
+		User:       "group:eng#member",
+	}).Marshal()
+	require.NoError(f, err)
+	f.Add(string(token))
+	f.Add(`{"object_type":"document"}`)
+	f.Add(`{"object_type":1,"object_id":"1","relation":"viewer","user":"user:jon"}`)
+	f.Add(`01HVMMBCMGZNCSVY6Y0RDQ2Z4H`)
+	f.Add(`100`)
+	f.Add(``)
+
+	f.Fuzz(func(t *testing.T, token string) {
+		cursor, err := UnmarshalReadCursor(token)
+		if err != nil {
+			require.ErrorIs(t, err, ErrInvalidContinuationToken)
+			require.Equal(t, ReadCursor{}, cursor)
+			return
+		}
+
+		// a decoded cursor resumes at its own key
+		marshalled, err := cursor.Marshal()
+		require.NoError(t, err)
+		remarshalled, err := UnmarshalReadCursor(string(marshalled))
+		require.NoError(t, err)
+		require.Equal(t, cursor, remarshalled)
+	})
+}
@@ -0,0 +1,354 @@
+package sqlcommon
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/pkg/logger"
+)
+
+// ChangelogMode controls how a SQL datastore writes changelog entries.
+type ChangelogMode string
+
+const (
+	// ChangelogModeSync inserts changelog entries in the transaction that writes the tuples.
+	ChangelogModeSync ChangelogMode = "sync"
+
+	// ChangelogModeAsync inserts changelog entries into the changelog_outbox table in the
+	// transaction that writes the tuples, and a background worker moves them to the changelog
+	// in batches. The ULID of an entry is assigned when it is moved, above the ULIDs of the
+	// changelog of its store, so an entry never lands behind a continuation token that was
+	// already returned. ReadChanges only returns an entry once it is moved, which lags behind
+	// the Write by up to the flush interval, and entries left in the outbox by a crash are
+	// moved once a server in async mode starts again.
+	ChangelogModeAsync ChangelogMode = "async"
+
+	// ChangelogModeDisabled does not write changelog entries. ReadChanges
+	// returns [storage.ErrChangelogDisabled].
+	ChangelogModeDisabled ChangelogMode = "disabled"
+)
+
+const (
+	DefaultChangelogBatchSize     = 500
+	DefaultChangelogFlushInterval = 100 * time.Millisecond
+
+	// DefaultChangelogFlushTimeout bounds the retries of a batch. Entries of a batch that
+	// keeps failing stay in the outbox and are retried on the next flush.
+	DefaultChangelogFlushTimeout = 10 * time.Second
+)
+
+// ChangelogOutboxTable is the table that holds the changelog entries of committed writes
+// until they are moved to the changelog, in async mode.
+const ChangelogOutboxTable = "changelog_outbox"
+
+// ChangelogTable describes the changelog table of a dialect.
+type ChangelogTable struct {
+	// Columns are the columns of a changelog row, including ulid and inserted_at.
+	Columns []string
+
+	// Now is the SQL expression of the current time, which inserted_at is set to
+	// when entries are moved from the outbox.
+	Now string
+
+	// LockRows makes the flush select the outbox rows FOR UPDATE, so that the
+	// flushes of several servers sharing the datastore run one at a time.
+	LockRows bool
+}
+
+// ChangelogWriter writes changelog rows according to a [ChangelogMode].
+// Each row holds the values of the columns of its [ChangelogTable].
+type ChangelogWriter struct {
+	mode          ChangelogMode
+	db            *sql.DB
+	stbl          sq.StatementBuilderType
+	table         ChangelogTable
+	outboxColumns []string
+	logger        logger.Logger
+	batchSize     int
+	flushInterval time.Duration
+	flushTimeout  time.Duration
+
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewChangelogWriter creates a ChangelogWriter for cfg.ChangelogMode. In async mode
+// it starts the background worker that moves entries from the outbox to the changelog,
+// which Close flushes and stops.
+func NewChangelogWriter(db *sql.DB, stbl sq.StatementBuilderType, table ChangelogTable, cfg *Config) *ChangelogWriter {
+	mode := cfg.ChangelogMode
+	if mode == "" {
+		mode = ChangelogModeSync
+	}
+
+	l := cfg.Logger
+	if l == nil {
+		l = logger.NewNoopLogger()
+	}
+
+	w := &ChangelogWriter{
+		mode:  mode,
+		db:    db,
+		stbl:  stbl,
+		table: table,
+		outboxColumns: slices.DeleteFunc(slices.Clone(table.Columns), func(column string) bool {
+			return column == "ulid" || column == "inserted_at"
+		}),
+		logger:        l,
+		batchSize:     DefaultChangelogBatchSize,
+		flushInterval: DefaultChangelogFlushInterval,
+		flushTimeout:  DefaultChangelogFlushTimeout,
+		done:          make(chan struct{}),
+	}
+
+	if mode == ChangelogModeAsync {
+		w.wg.Add(1)
+		go w.run()
+	}
+
+	return w
+}
+
+// Mode returns the ChangelogMode of the writer. A nil writer is in sync mode.
+func (w *ChangelogWriter) Mode() ChangelogMode {
+	if w == nil {
+		return ChangelogModeSync
+	}
+	return w.mode
+}
+
+// InsertBuilder returns the statement that inserts the given rows, to be run in the
+// transaction that writes the tuples: into the changelog in sync mode, and into the
+// outbox, without their ulid and inserted_at, in async mode.
+func (w *ChangelogWriter) InsertBuilder(rows [][]interface{}) sq.InsertBuilder {
+	if w.mode != ChangelogModeAsync {
+		builder := w.stbl.Insert("changelog").Columns(w.table.Columns...)
+		for _, row := range rows {
+			builder = builder.Values(row...)
+		}
+		return builder
+	}
+
+	builder := w.stbl.Insert(ChangelogOutboxTable).Columns(w.outboxColumns...)
+	for _, row := range rows {
+		values := make([]interface{}, 0, len(w.outboxColumns))
+		for i, column := range w.table.Columns {
+			if column != "ulid" && column != "inserted_at" {
+				values = append(values, row[i])
+			}
+		}
+		builder = builder.Values(values...)
+	}
+	return builder
+}
+
+func (w *ChangelogWriter) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.flush()
+		case <-w.done:
+			w.flush()
+			return
+		}
+	}
+}
+
+// flush moves the entries of the outbox to the changelog, one batch at a time, until the
+// outbox is drained or a batch fails for longer than the flush timeout.
+func (w *ChangelogWriter) flush() {
+	for {
+		var moved int
+		policy := backoff.NewExponentialBackOff()
+		policy.MaxElapsedTime = w.flushTimeout
+
+		err := backoff.Retry(func() error {
+			var err error
+			moved, err = w.flushBatch(context.Background())
+			return err
+		}, policy)
+		if err != nil {
+			w.logger.Error("failed to flush changelog entries, they stay in the outbox until the next flush", zap.Error(err))
+			return
+		}
+
+		if moved < w.batchSize {
+			return
+		}
+	}
+}
+
+// flushBatch moves the oldest batch of the outbox to the changelog in one transaction and
+// returns the number of entries moved. The entries of a store get increasing ULIDs above
+// the newest ULID of its changelog, in the order they were written to the outbox.
+func (w *ChangelogWriter) flushBatch(ctx context.Context) (int, error) {
+	txn, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	sb := w.stbl.
+		Select(append([]string{"id"}, w.outboxColumns...)...).
+		From(ChangelogOutboxTable).
+		OrderBy("id").
+		Limit(uint64(w.batchSize))
+	if w.table.LockRows {
+		sb = sb.Suffix("FOR UPDATE")
+	}
+
+	rows, err := sb.RunWith(txn).QueryContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []int64
+	var entries [][]interface{}
+	for rows.Next() {
+		var id int64
+		values := make([]interface{}, len(w.outboxColumns))
+		dest := []interface{}{&id}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+		entries = append(entries, values)
+	}
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	storeColumn := slices.Index(w.outboxColumns, "store")
+	ulids := make(map[string]func() string)
+
+	builder := w.stbl.Insert("changelog").Columns(w.table.Columns...)
+	for _, entry := range entries {
+		store := outboxString(entry[storeColumn])
+
+		next, ok := ulids[store]
+		if !ok {
+			next, err = w.nextULIDs(ctx, txn, store)
+			if err != nil {
+				return 0, err
+			}
+			ulids[store] = next
+		}
+
+		values := make([]interface{}, 0, len(w.table.Columns))
+		i := 0
+		for _, column := range w.table.Columns {
+			switch column {
+			case "ulid":
+				values = append(values, next())
+			case "inserted_at":
+				values = append(values, sq.Expr(w.table.Now))
+			default:
+				values = append(values, entry[i])
+				i++
+			}
+		}
+		builder = builder.Values(values...)
+	}
+
+	if _, err := builder.RunWith(txn).ExecContext(ctx); err != nil {
+		return 0, err
+	}
+
+	if _, err := w.stbl.
+		Delete(ChangelogOutboxTable).
+		Where(sq.Eq{"id": ids}).
+		RunWith(txn).
+		ExecContext(ctx); err != nil {
+		return 0, err
+	}
+
+	if err := txn.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(entries), nil
+}
+
+// nextULIDs returns a generator of increasing ULIDs above the newest ULID of the
+// changelog of the store, even if the clock of this server is behind.
+func (w *ChangelogWriter) nextULIDs(ctx context.Context, txn *sql.Tx, store string) (func() string, error) {
+	ms := ulid.Timestamp(time.Now())
+
+	var newest string
+	err := w.stbl.
+		Select("ulid").
+		From("changelog").
+		Where(sq.Eq{"store": store}).
+		OrderBy("ulid desc").
+		Limit(1).
+		RunWith(txn).
+		QueryRowContext(ctx).
+		Scan(&newest)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, err
+	default:
+		parsed, err := ulid.Parse(newest)
+		if err != nil {
+			return nil, err
+		}
+		if parsed.Time() >= ms {
+			ms = parsed.Time() + 1
+		}
+	}
+
+	entropy := ulid.Monotonic(rand.Reader, 0)
+	return func() string {
+		return ulid.MustNew(ms, entropy).String()
+	}, nil
+}
+
+// outboxString returns the string a driver scanned a text column of the outbox into.
+func outboxString(v interface{}) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	s, _ := v.(string)
+	return s
+}
+
+// Close flushes the outbox and stops the background worker.
+func (w *ChangelogWriter) Close() {
+	if w == nil {
+		return
+	}
+	w.stopOnce.Do(func() {
+		close(w.done)
+	})
+	w.wg.Wait()
+}
@@ -0,0 +1,214 @@
+package sqlcommon
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+const (
+	// MinIndexAdviceQueries is the number of sampled queries below which [AdviseIndexes]
+	// recommends no index, as the sample does not describe the workload yet.
+	MinIndexAdviceQueries = 1000
+
+	// MinIndexAdviceShare is the share of the sampled queries that the queries served by an index
+	// must reach for [AdviseIndexes] to recommend it.
+	MinIndexAdviceShare = 0.1
+
+	// MaxIndexAdviceRowsPerQuery is the average number of rows per query above which
+	// [AdviseIndexes] recommends no index for the queries. Such queries spend their time reading
+	// rows rather than finding them, and an index does not make them faster.
+	MaxIndexAdviceRowsPerQuery = 1000
+)
+
+// indexHeuristic recommends an index of the tuple table for the query shapes it matches.
+type indexHeuristic struct {
+	name string
+	// columns are the columns of the index, where "user" stands for the column or columns
+	// that store the user in the dialect.
+	columns []string
+	// userset indexes only cover the tuples whose user is a userset.
+	userset bool
+	// defaults are the dialects whose migrations create the index.
+	defaults []string
+	reason   string
+	matches  func(shape storage.QueryShape) bool
+}
+
+// indexHeuristics are the heuristics of AdviseIndexes:
+//
+//   - Reverse lookups, i.e. ReadStartingWithUser, and the Read and ReadPage queries that filter
+//     on the object type, relation and user but not on the object ID, call for an index on
+//     (store, object_type, relation, user).
+//   - ReadUsersetTuples queries with user type restrictions call for an index on
+//     (store, object_type, object_id, relation, user_object_type, user_relation) of the tuples
+//     whose user is a userset.
+//   - Read and ReadPage queries that filter on the object type and relation only, e.g. to list
+//     the tuples of a relation, call for an index on (store, object_type, relation).
+//   - Read and ReadPage queries that filter on the object type and user only call for an index
+//     on (store, object_type, user).
+//   - Read and ReadPage queries that filter on the user but not on the object type call for an
+//     index on (store, user).
+//
+// The primary key of the tuple table serves the other queries, which filter on a prefix of
+// (store, object_type, object_id, relation, user).
+var indexHeuristics = []indexHeuristic{
+	{
+		name:     "idx_reverse_lookup_user",
+		columns:  []string{"store", "object_type", "relation", "user"},
+		defaults: []string{"postgres", "mysql", "sqlite"},
+		reason:   "reverse lookups (ReadStartingWithUser, and reads by object type, relation and user) filter on the object type, relation and user",
+		matches: func(shape storage.QueryShape) bool {
+			if shape.Method == QueryMethodReadStartingWithUser.String() {
+				return true
+			}
+			return isTupleKeyRead(shape) &&
+				hasColumns(shape, "object_type", "relation", "user") && !hasColumns(shape, "object_id")
+		},
+	},
+	{
+		name:     "idx_tuple_userset_type",
+		columns:  []string{"store", "object_type", "object_id", "relation", "user_object_type", "user_relation"},
+		userset:  true,
+		defaults: []string{"postgres", "mysql"},
+		reason:   "ReadUsersetTuples queries filter the usersets of an object and relation on their type and relation",
+		matches: func(shape storage.QueryShape) bool {
+			return shape.Method == QueryMethodReadUsersetTuples.String() && hasColumns(shape, "user_type")
+		},
+	},
+	{
+		name:     "idx_tuple_relation_usage",
+		columns:  []string{"store", "object_type", "relation"},
+		defaults: []string{"postgres", "mysql", "sqlite"},
+		reason:   "reads filter on the object type and relation but not on the object or user",
+		matches: func(shape storage.QueryShape) bool {
+			return isTupleKeyRead(shape) &&
+				hasColumns(shape, "object_type", "relation") && !hasColumns(shape, "object_id") && !hasColumns(shape, "user")
+		},
+	},
+	{
+		name:    "idx_tuple_object_type_user",
+		columns: []string{"store", "object_type", "user"},
+		reason:  "reads filter on the object type and user but not on the object or relation",
+		matches: func(shape storage.QueryShape) bool {
+			return isTupleKeyRead(shape) &&
+				hasColumns(shape, "object_type", "user") && !hasColumns(shape, "object_id") && !hasColumns(shape, "relation")
+		},
+	},
+	{
+		name:    "idx_tuple_user",
+		columns: []string{"store", "user"},
+		reason:  "reads filter on the user but not on the object type",
+		matches: func(shape storage.QueryShape) bool {
+			return isTupleKeyRead(shape) && hasColumns(shape, "user") && !hasColumns(shape, "object_type")
+		},
+	},
+}
+
+func isTupleKeyRead(shape storage.QueryShape) bool {
+	return shape.Method == QueryMethodRead.String() || shape.Method == QueryMethodReadPage.String()
+}
+
+func hasColumns(shape storage.QueryShape, columns ...string) bool {
+	for _, column := range columns {
+		if !slices.Contains(shape.Columns, column) {
+			return false
+		}
+	}
+	return true
+}
+
+// AdviseIndexes returns the indexes of the tuple table of the dialect ("postgres", "mysql" or
+// "sqlite") that the sampled query shapes call for, sorted by decreasing number of queries
+// served. An index is recommended when the queries it serves are at least
+// [MinIndexAdviceShare] of at least [MinIndexAdviceQueries] sampled queries, and return at most
+// [MaxIndexAdviceRowsPerQuery] rows on average. The heuristics are documented on indexHeuristics.
+//
+// The recommendations include the indexes that the migrations of the datastore create (see
+// [storage.IndexRecommendation].Default), which need no change unless they were dropped. The
+// DDL of PostgreSQL creates the indexes concurrently, and MySQL, without partial indexes, indexes
+// the type of the user instead.
+func AdviseIndexes(dialect string, shapes []storage.QueryShape) []storage.IndexRecommendation {
+	if !slices.Contains([]string{"postgres", "mysql", "sqlite"}, dialect) {
+		return nil
+	}
+
+	var total int64
+	for _, shape := range shapes {
+		total += shape.Queries
+	}
+	if total < MinIndexAdviceQueries {
+		return nil
+	}
+
+	var recommendations []storage.IndexRecommendation
+	for _, heuristic := range indexHeuristics {
+		var queries, rows int64
+		for _, shape := range shapes {
+			if heuristic.matches(shape) {
+				queries += shape.Queries
+				rows += shape.Rows
+			}
+		}
+
+		share := float64(queries) / float64(total)
+		if queries == 0 || share < MinIndexAdviceShare || rows > queries*MaxIndexAdviceRowsPerQuery {
+			continue
+		}
+
+		columns := indexColumns(dialect, heuristic)
+		recommendations = append(recommendations, storage.IndexRecommendation{
+			Name:    heuristic.name,
+			Columns: columns,
+			DDL:     indexDDL(dialect, heuristic.name, columns, heuristic.userset),
+			Reason:  fmt.Sprintf("%s: %.0f%% of the sampled queries, %.1f rows per query", heuristic.reason, share*100, float64(rows)/float64(queries)),
+			Queries: queries,
+			Share:   share,
+			Default: slices.Contains(heuristic.defaults, dialect),
+		})
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Queries > recommendations[j].Queries
+	})
+	return recommendations
+}
+
+// indexColumns returns the columns of the index of the heuristic in the dialect.
+func indexColumns(dialect string, heuristic indexHeuristic) []string {
+	columns := make([]string, 0, len(heuristic.columns)+2)
+	for _, column := range heuristic.columns {
+		switch {
+		case column == "user" && dialect == "sqlite":
+			columns = append(columns, "user_object_type", "user_object_id", "user_relation")
+		case column == "user":
+			columns = append(columns, "_user")
+		default:
+			columns = append(columns, column)
+		}
+		if column == "relation" && heuristic.userset && dialect == "mysql" {
+			columns = append(columns, "user_type")
+		}
+	}
+	return columns
+}
+
+// indexDDL returns the statement that creates the index in the dialect.
+func indexDDL(dialect, name string, columns []string, userset bool) string {
+	var where string
+	if userset && dialect != "mysql" {
+		where = " WHERE user_type = 'userset'"
+	}
+
+	switch dialect {
+	case "postgres":
+		return fmt.Sprintf("CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON tuple (%s)%s;", name, strings.Join(columns, ", "), where)
+	case "sqlite":
+		return fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON tuple (%s)%s;", name, strings.Join(columns, ", "), where)
+	default:
+		return fmt.Sprintf("CREATE INDEX %s ON tuple (%s);", name, strings.Join(columns, ", "))
+	}
+}
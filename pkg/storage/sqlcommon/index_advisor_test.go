@@ -0,0 +1,202 @@
+package sqlcommon
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+func TestQueryShapeSampler(t *testing.T) {
+	t.Run("nil_sampler_records_nothing", func(t *testing.T) {
+		var s *QueryShapeSampler
+		s.Record(QueryMethodRead, FilterObjectType, 1)
+
+		iter := storage.NewStaticTupleIterator(nil)
+		require.Equal(t, iter, s.RecordIterator(QueryMethodRead, FilterObjectType, iter))
+		require.Empty(t, s.Shapes())
+
+		_, err := s.Advise("postgres")
+		require.ErrorIs(t, err, storage.ErrQueryShapeSamplingDisabled)
+	})
+
+	t.Run("records_shapes_without_values", func(t *testing.T) {
+		s := NewQueryShapeSampler()
+		s.Record(QueryMethodReadUserTuple, TupleKeyFilter(tuple.NewTupleKey("document:1", "viewer", "user:anne")), 1)
+		s.Record(QueryMethodReadUserTuple, TupleKeyFilter(tuple.NewTupleKey("document:2", "editor", "user:bob")), 0)
+
+		iter := s.RecordIterator(QueryMethodReadStartingWithUser, ReadStartingWithUserFilter(storage.ReadStartingWithUserFilter{
+			ObjectType: "document",
+			Relation:   "viewer",
+			UserFilter: []*openfgav1.ObjectRelation{{Object: "user:anne"}},
+		}), storage.NewStaticTupleIterator([]*openfgav1.Tuple{
+			{Key: tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+			{Key: tuple.NewTupleKey("document:2", "viewer", "user:anne")},
+		}))
+		for {
+			if _, err := iter.Next(context.Background()); err != nil {
+				require.ErrorIs(t, err, storage.ErrIteratorDone)
+				break
+			}
+		}
+		iter.Stop()
+
+		require.Equal(t, []storage.QueryShape{
+			{Method: "ReadUserTuple", Columns: []string{"object_type", "object_id", "relation", "user"}, Queries: 2, Rows: 1},
+			{Method: "ReadStartingWithUser", Columns: []string{"object_type", "relation", "user"}, Queries: 1, Rows: 2},
+		}, s.Shapes())
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		require.Equal(t, FilterObjectType|FilterRelation, TupleKeyFilter(&openfgav1.TupleKey{Object: "document:", Relation: "viewer"}))
+		require.Equal(t, FilterUser, TupleKeyFilter(&openfgav1.TupleKey{User: "user:anne"}))
+
+		hasCondition := true
+		require.Equal(t, FilterObjectType|FilterCondition, ReadPageFilter(&openfgav1.TupleKey{Object: "document:"}, storage.ReadPageOptions{
+			Condition: storage.ReadConditionFilter{HasCondition: &hasCondition},
+		}))
+
+		require.Equal(t, FilterObjectType|FilterObjectID|FilterRelation|FilterUserType, ReadUsersetTuplesFilter(storage.ReadUsersetTuplesFilter{
+			Object:                      "document:1",
+			Relation:                    "viewer",
+			AllowedUserTypeRestrictions: []*openfgav1.RelationReference{typesystem.DirectRelationReference("group", "member")},
+		}))
+
+		objectIDs := storage.NewSortedSet()
+		objectIDs.Add("1")
+		require.Equal(t, FilterObjectType|FilterObjectID|FilterRelation|FilterUser, ReadStartingWithUserFilter(storage.ReadStartingWithUserFilter{
+			ObjectType: "document",
+			Relation:   "viewer",
+			UserFilter: []*openfgav1.ObjectRelation{{Object: "user:anne"}},
+			ObjectIDs:  objectIDs,
+		}))
+	})
+
+	t.Run("concurrent_records", func(t *testing.T) {
+		s := NewQueryShapeSampler()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					s.Record(QueryMethodRead, FilterObjectType|FilterRelation, 2)
+				}
+			}()
+		}
+		wg.Wait()
+
+		require.Equal(t, []storage.QueryShape{
+			{Method: "Read", Columns: []string{"object_type", "relation"}, Queries: 5000, Rows: 10000},
+		}, s.Shapes())
+	})
+}
+
+func TestAdviseIndexes(t *testing.T) {
+	reverseLookups := storage.QueryShape{Method: "ReadStartingWithUser", Columns: []string{"object_type", "relation", "user"}, Queries: 800, Rows: 4000}
+	usersets := storage.QueryShape{Method: "ReadUsersetTuples", Columns: []string{"object_type", "object_id", "relation", "user_type"}, Queries: 800, Rows: 800}
+	userTuples := storage.QueryShape{Method: "ReadUserTuple", Columns: []string{"object_type", "object_id", "relation", "user"}, Queries: 8000, Rows: 4000}
+
+	t.Run("too_few_queries", func(t *testing.T) {
+		require.Empty(t, AdviseIndexes("postgres", []storage.QueryShape{
+			{Method: "ReadStartingWithUser", Columns: []string{"object_type", "relation", "user"}, Queries: MinIndexAdviceQueries - 1},
+		}))
+	})
+
+	t.Run("unknown_dialect", func(t *testing.T) {
+		require.Empty(t, AdviseIndexes("memory", []storage.QueryShape{reverseLookups, userTuples}))
+	})
+
+	t.Run("primary_key_lookups_only", func(t *testing.T) {
+		require.Empty(t, AdviseIndexes("postgres", []storage.QueryShape{
+			userTuples,
+			{Method: "Read", Columns: []string{"object_type", "object_id", "relation"}, Queries: 5000, Rows: 20000},
+		}))
+	})
+
+	t.Run("reverse_reads_dominate", func(t *testing.T) {
+		shapes := []storage.QueryShape{
+			reverseLookups,
+			{Method: "ReadPage", Columns: []string{"object_type", "relation", "user"}, Queries: 200, Rows: 200},
+			usersets,
+			{Method: "ReadUserTuple", Columns: []string{"object_type", "object_id", "relation", "user"}, Queries: 1000, Rows: 500},
+		}
+
+		recommendations := AdviseIndexes("postgres", shapes)
+		require.Len(t, recommendations, 2)
+
+		require.Equal(t, "idx_reverse_lookup_user", recommendations[0].Name)
+		require.Equal(t, []string{"store", "object_type", "relation", "_user"}, recommendations[0].Columns)
+		require.Equal(t, "CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_reverse_lookup_user ON tuple (store, object_type, relation, _user);", recommendations[0].DDL)
+		require.Equal(t, int64(1000), recommendations[0].Queries)
+		require.InDelta(t, 0.357, recommendations[0].Share, 0.001)
+		require.True(t, recommendations[0].Default)
+		require.Contains(t, recommendations[0].Reason, "36% of the sampled queries, 4.2 rows per query")
+
+		require.Equal(t, "idx_tuple_userset_type", recommendations[1].Name)
+		require.Equal(t, "CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_tuple_userset_type ON tuple (store, object_type, object_id, relation, user_object_type, user_relation) WHERE user_type = 'userset';", recommendations[1].DDL)
+		require.True(t, recommendations[1].Default)
+	})
+
+	t.Run("dialects", func(t *testing.T) {
+		shapes := []storage.QueryShape{reverseLookups, usersets}
+
+		mysql := AdviseIndexes("mysql", shapes)
+		require.Len(t, mysql, 2)
+		require.Equal(t, "CREATE INDEX idx_reverse_lookup_user ON tuple (store, object_type, relation, _user);", mysql[0].DDL)
+		require.Equal(t, "CREATE INDEX idx_tuple_userset_type ON tuple (store, object_type, object_id, relation, user_type, user_object_type, user_relation);", mysql[1].DDL)
+		require.True(t, mysql[1].Default)
+
+		sqlite := AdviseIndexes("sqlite", shapes)
+		require.Len(t, sqlite, 2)
+		require.Equal(t, "CREATE INDEX IF NOT EXISTS idx_reverse_lookup_user ON tuple (store, object_type, relation, user_object_type, user_object_id, user_relation);", sqlite[0].DDL)
+		require.True(t, sqlite[0].Default)
+		require.Equal(t, "CREATE INDEX IF NOT EXISTS idx_tuple_userset_type ON tuple (store, object_type, object_id, relation, user_object_type, user_relation) WHERE user_type = 'userset';", sqlite[1].DDL)
+		require.False(t, sqlite[1].Default)
+	})
+
+	t.Run("reads_by_user", func(t *testing.T) {
+		recommendations := AdviseIndexes("postgres", []storage.QueryShape{
+			{Method: "Read", Columns: []string{"user"}, Queries: 3000, Rows: 6000},
+			{Method: "ReadPage", Columns: []string{"object_type", "user"}, Queries: 2000, Rows: 2000},
+			{Method: "Read", Columns: []string{"object_type", "relation"}, Queries: 1500, Rows: 7500},
+			{Method: "ReadUserTuple", Columns: []string{"object_type", "object_id", "relation", "user"}, Queries: 4000, Rows: 2000},
+		})
+		require.Len(t, recommendations, 3)
+
+		require.Equal(t, "idx_tuple_user", recommendations[0].Name)
+		require.Equal(t, "CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_tuple_user ON tuple (store, _user);", recommendations[0].DDL)
+		require.False(t, recommendations[0].Default)
+
+		require.Equal(t, "idx_tuple_object_type_user", recommendations[1].Name)
+		require.Equal(t, []string{"store", "object_type", "_user"}, recommendations[1].Columns)
+		require.False(t, recommendations[1].Default)
+
+		require.Equal(t, "idx_tuple_relation_usage", recommendations[2].Name)
+		require.True(t, recommendations[2].Default)
+	})
+
+	t.Run("below_the_share", func(t *testing.T) {
+		recommendations := AdviseIndexes("postgres", []storage.QueryShape{
+			userTuples,
+			{Method: "ReadStartingWithUser", Columns: []string{"object_type", "relation", "user"}, Queries: 800, Rows: 800},
+		})
+		require.Empty(t, recommendations)
+	})
+
+	t.Run("unselective_queries", func(t *testing.T) {
+		recommendations := AdviseIndexes("postgres", []storage.QueryShape{
+			{Method: "ReadStartingWithUser", Columns: []string{"object_type", "relation", "user"}, Queries: 1000, Rows: 1000 * (MaxIndexAdviceRowsPerQuery + 1)},
+			usersets,
+		})
+		require.Len(t, recommendations, 1)
+		require.Equal(t, "idx_tuple_userset_type", recommendations[0].Name)
+	})
+}
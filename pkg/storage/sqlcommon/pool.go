@@ -0,0 +1,136 @@
+package sqlcommon
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+const (
+	DefaultPoolStatsInterval           = 10 * time.Second
+	DefaultPoolSaturationWaitThreshold = 10
+	DefaultPoolSaturationPeriod        = time.Minute
+)
+
+// PoolMonitor periodically samples the [sql.DBStats] of a connection pool and
+// tracks whether the pool is saturated. The statistics themselves are exported
+// by the DB stats collector of the datastore.
+type PoolMonitor struct {
+	db            *sql.DB
+	waitThreshold int64
+	periodSamples int
+
+	mu            sync.Mutex
+	lastStats     sql.DBStats
+	busySamples   int
+	lastWaitCount int64
+
+	saturated atomic.Bool
+	done      chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewPoolMonitor starts sampling the pool statistics of db every cfg.PoolStatsInterval.
+// Call Stop to end the sampling.
+func NewPoolMonitor(db *sql.DB, cfg *Config) *PoolMonitor {
+	interval := cfg.PoolStatsInterval
+	if interval <= 0 {
+		interval = DefaultPoolStatsInterval
+	}
+
+	periodSamples := int(cfg.PoolSaturationPeriod / interval)
+	if periodSamples < 1 {
+		periodSamples = 1
+	}
+
+	m := &PoolMonitor{
+		db:            db,
+		waitThreshold: cfg.PoolSaturationWaitThreshold,
+		periodSamples: periodSamples,
+		lastStats:     db.Stats(),
+		done:          make(chan struct{}),
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.done:
+				return
+			case <-ticker.C:
+				m.sample()
+			}
+		}
+	}()
+
+	return m
+}
+
+func (m *PoolMonitor) sample() {
+	stats := m.db.Stats()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	waiting := stats.WaitCount - m.lastStats.WaitCount
+	m.lastStats = stats
+	m.lastWaitCount = waiting
+
+	if m.waitThreshold > 0 && waiting > m.waitThreshold {
+		m.busySamples++
+	} else {
+		m.busySamples = 0
+	}
+
+	m.saturated.Store(m.busySamples >= m.periodSamples)
+}
+
+// Saturated reports whether the number of requests waiting for a connection
+// exceeded the threshold in every sample of the saturation period.
+func (m *PoolMonitor) Saturated() bool {
+	if m == nil {
+		return false
+	}
+	return m.saturated.Load()
+}
+
+// AnnotateReadiness adds a warning to the readiness status when the pool is saturated.
+// A saturated pool does not make the datastore not ready.
+func (m *PoolMonitor) AnnotateReadiness(status storage.ReadinessStatus) storage.ReadinessStatus {
+	if !m.Saturated() {
+		return status
+	}
+
+	m.mu.Lock()
+	warning := fmt.Sprintf("pool saturated: %d requests waited for a connection in the last sample (max open connections: %d)",
+		m.lastWaitCount, m.lastStats.MaxOpenConnections)
+	m.mu.Unlock()
+
+	if status.Message != "" {
+		status.Message += "; " + warning
+	} else {
+		status.Message = warning
+	}
+	return status
+}
+
+// Stop ends the sampling of the pool statistics.
+func (m *PoolMonitor) Stop() {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+	m.wg.Wait()
+}
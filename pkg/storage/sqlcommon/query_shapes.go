@@ -0,0 +1,224 @@
+package sqlcommon
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/storage"
+	tupleUtils "github.com/openfga/openfga/pkg/tuple"
+)
+
+// QueryMethod is a datastore method that reads tuples, as sampled by [QueryShapeSampler].
+type QueryMethod int
+
+const (
+	QueryMethodRead QueryMethod = iota
+	QueryMethodReadPage
+	QueryMethodReadUserTuple
+	QueryMethodReadUsersetTuples
+	QueryMethodReadStartingWithUser
+
+	numQueryMethods
+)
+
+var queryMethodNames = [numQueryMethods]string{
+	QueryMethodRead:                 "Read",
+	QueryMethodReadPage:             "ReadPage",
+	QueryMethodReadUserTuple:        "ReadUserTuple",
+	QueryMethodReadUsersetTuples:    "ReadUsersetTuples",
+	QueryMethodReadStartingWithUser: "ReadStartingWithUser",
+}
+
+// String returns the name of the datastore method.
+func (m QueryMethod) String() string {
+	return queryMethodNames[m]
+}
+
+// QueryFilter is the set of columns of the tuple table that a query filters on, besides the store.
+type QueryFilter uint8
+
+const (
+	FilterObjectType QueryFilter = 1 << iota
+	FilterObjectID
+	FilterRelation
+	FilterUser
+	// FilterUserType is set by the user type restrictions of ReadUsersetTuples, which filter on
+	// the type and relation of the user.
+	FilterUserType
+	FilterCondition
+
+	numQueryFilters = iota
+)
+
+var queryFilterColumns = [numQueryFilters]string{
+	"object_type", "object_id", "relation", "user", "user_type", "condition",
+}
+
+// Columns returns the names of the columns of the filter, in the order of the constants. The
+// user is named "user" whether the datastore stores it in one column or in several.
+func (f QueryFilter) Columns() []string {
+	columns := make([]string, 0, numQueryFilters)
+	for i, column := range queryFilterColumns {
+		if f&(1<<i) != 0 {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// TupleKeyFilter returns the filter of a query that reads the tuples matching tupleKey, as Read
+// and ReadPage do.
+func TupleKeyFilter(tupleKey *openfgav1.TupleKey) QueryFilter {
+	var f QueryFilter
+	objectType, objectID := tupleUtils.SplitObject(tupleKey.GetObject())
+	if objectType != "" {
+		f |= FilterObjectType
+	}
+	if objectID != "" {
+		f |= FilterObjectID
+	}
+	if tupleKey.GetRelation() != "" {
+		f |= FilterRelation
+	}
+	if tupleKey.GetUser() != "" {
+		f |= FilterUser
+	}
+	return f
+}
+
+// ReadPageFilter returns the filter of a ReadPage query of the tuples matching tupleKey.
+func ReadPageFilter(tupleKey *openfgav1.TupleKey, options storage.ReadPageOptions) QueryFilter {
+	f := TupleKeyFilter(tupleKey)
+	if !options.Condition.IsEmpty() {
+		f |= FilterCondition
+	}
+	return f
+}
+
+// ReadUsersetTuplesFilter returns the filter of a ReadUsersetTuples query.
+func ReadUsersetTuplesFilter(filter storage.ReadUsersetTuplesFilter) QueryFilter {
+	f := TupleKeyFilter(&openfgav1.TupleKey{Object: filter.Object, Relation: filter.Relation})
+	if len(filter.AllowedUserTypeRestrictions) > 0 {
+		f |= FilterUserType
+	}
+	return f
+}
+
+// ReadStartingWithUserFilter returns the filter of a ReadStartingWithUser query.
+func ReadStartingWithUserFilter(filter storage.ReadStartingWithUserFilter) QueryFilter {
+	f := FilterObjectType | FilterRelation | FilterUser
+	if filter.ObjectIDs != nil && filter.ObjectIDs.Size() > 0 {
+		f |= FilterObjectID
+	}
+	return f
+}
+
+type queryShapeCounters struct {
+	queries atomic.Int64
+	rows    atomic.Int64
+}
+
+// QueryShapeSampler counts the queries of the tuple reads of a datastore and the rows they
+// return, by method and by the set of columns they filter on, i.e. by query shape. The values of
+// the filters are never recorded. There is a fixed number of shapes, so the sampler does not grow
+// with the workload, and recording a query costs two atomic additions.
+//
+// A nil sampler records nothing, so that datastores can call it whether sampling is enabled or
+// not (see [WithQueryShapeSampling]).
+type QueryShapeSampler struct {
+	since  time.Time
+	shapes [numQueryMethods][1 << numQueryFilters]queryShapeCounters
+}
+
+// NewQueryShapeSampler returns a sampler without any recorded query.
+func NewQueryShapeSampler() *QueryShapeSampler {
+	return &QueryShapeSampler{since: time.Now()}
+}
+
+// Record records a query of the method with the filter, that returned the given number of rows.
+func (s *QueryShapeSampler) Record(method QueryMethod, filter QueryFilter, rows int) {
+	if s == nil {
+		return
+	}
+	counters := &s.shapes[method][filter]
+	counters.queries.Add(1)
+	if rows > 0 {
+		counters.rows.Add(int64(rows))
+	}
+}
+
+// RecordIterator records a query of the method with the filter, whose rows are counted as the
+// returned iterator returns them.
+func (s *QueryShapeSampler) RecordIterator(method QueryMethod, filter QueryFilter, iter storage.TupleIterator) storage.TupleIterator {
+	if s == nil {
+		return iter
+	}
+	counters := &s.shapes[method][filter]
+	counters.queries.Add(1)
+	return &rowCountingIterator{TupleIterator: iter, rows: &counters.rows}
+}
+
+// Shapes returns the shapes of the queries recorded so far, sorted by decreasing number of
+// queries.
+func (s *QueryShapeSampler) Shapes() []storage.QueryShape {
+	if s == nil {
+		return nil
+	}
+
+	var shapes []storage.QueryShape
+	for method := range s.shapes {
+		for filter := range s.shapes[method] {
+			counters := &s.shapes[method][filter]
+			queries := counters.queries.Load()
+			if queries == 0 {
+				continue
+			}
+			shapes = append(shapes, storage.QueryShape{
+				Method:  QueryMethod(method).String(),
+				Columns: QueryFilter(filter).Columns(),
+				Queries: queries,
+				Rows:    counters.rows.Load(),
+			})
+		}
+	}
+
+	sort.SliceStable(shapes, func(i, j int) bool {
+		return shapes[i].Queries > shapes[j].Queries
+	})
+	return shapes
+}
+
+// Advise returns the query shapes recorded so far and the indexes of the tuple table of the
+// dialect that they call for (see [AdviseIndexes]). It returns
+// [storage.ErrQueryShapeSamplingDisabled] if s is nil.
+func (s *QueryShapeSampler) Advise(dialect string) (storage.IndexAdvice, error) {
+	if s == nil {
+		return storage.IndexAdvice{}, storage.ErrQueryShapeSamplingDisabled
+	}
+
+	shapes := s.Shapes()
+	return storage.IndexAdvice{
+		Datastore:       dialect,
+		SampledSince:    s.since,
+		Shapes:          shapes,
+		Recommendations: AdviseIndexes(dialect, shapes),
+	}, nil
+}
+
+// rowCountingIterator counts the tuples returned by Next.
+type rowCountingIterator struct {
+	storage.TupleIterator
+	rows *atomic.Int64
+}
+
+func (i *rowCountingIterator) Next(ctx context.Context) (*openfgav1.Tuple, error) {
+	t, err := i.TupleIterator.Next(ctx)
+	if err == nil {
+		i.rows.Add(1)
+	}
+	return t, err
+}
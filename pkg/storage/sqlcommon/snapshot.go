@@ -0,0 +1,386 @@
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// snapshotReadAhead is the number of tuples a snapshot read reads before it returns. The
+// connection of a read with no more tuples is free for the other reads right away, while a read
+// with more tuples streams the rest from its connection.
+const snapshotReadAhead = 100
+
+// SnapshotConn is a transaction reading the snapshot of a [SnapshotTupleReader], along with the
+// reader running its queries within the transaction, e.g. with a statement builder that runs with
+// it.
+type SnapshotConn struct {
+	Reader storage.RelationshipTupleReader
+	Txn    *sql.Tx
+}
+
+// SnapshotConnBeginner begins another transaction reading the same snapshot as the first
+// transaction of a [SnapshotTupleReader].
+type SnapshotConnBeginner func(ctx context.Context) (*SnapshotConn, error)
+
+// SnapshotTupleReader is a [storage.SnapshotTupleReader] that runs the reads of a datastore in
+// read-only transactions that all read the same snapshot. A transaction holds one connection,
+// which runs one query at a time, so the reads run concurrently on up to the maximum number of
+// transactions, and wait for a free transaction otherwise.
+//
+// A read reads ahead a few tuples, and streams the others from its transaction until its iterator
+// is stopped. When another read waits for a transaction, and none is free, the rows left of a
+// streaming read are read into memory, so that the reads of a request cannot wait for each other.
+type SnapshotTupleReader struct {
+	begin    SnapshotConnBeginner
+	maxConns int
+
+	// inUse counts the transactions running a query or streaming rows, which Release waits for.
+	inUse sync.WaitGroup
+
+	mu sync.Mutex
+	// opened counts the transactions that are open or being opened
+	opened    int
+	conns     []*SnapshotConn
+	idle      []*SnapshotConn
+	streaming []*snapshotIterator
+	// returned is closed, and replaced, when a transaction becomes free
+	returned chan struct{}
+	released bool
+}
+
+var _ storage.SnapshotTupleReader = (*SnapshotTupleReader)(nil)
+
+// NewSnapshotTupleReader returns a [SnapshotTupleReader] that reads with first, and with up to
+// maxConns-1 other transactions begun by begin, which may be nil if the snapshot cannot be shared
+// with other transactions. Release rolls the transactions back.
+func NewSnapshotTupleReader(first *SnapshotConn, begin SnapshotConnBeginner, maxConns uint32) *SnapshotTupleReader {
+	if begin == nil || maxConns == 0 {
+		maxConns = 1
+	}
+	return &SnapshotTupleReader{
+		begin:    begin,
+		maxConns: int(maxConns),
+		opened:   1,
+		conns:    []*SnapshotConn{first},
+		idle:     []*SnapshotConn{first},
+		returned: make(chan struct{}),
+	}
+}
+
+// acquire returns a free transaction, begins another one if the maximum allows it, or else waits
+// for one. If a transaction cannot be begun, the reads make do with the ones already open.
+func (s *SnapshotTupleReader) acquire(ctx context.Context) (*SnapshotConn, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		if s.released {
+			s.mu.Unlock()
+			return nil, sql.ErrTxDone
+		}
+
+		if n := len(s.idle); n > 0 {
+			conn := s.idle[n-1]
+			s.idle = s.idle[:n-1]
+			s.inUse.Add(1)
+			s.mu.Unlock()
+			return conn, nil
+		}
+
+		if s.opened < s.maxConns {
+			s.opened++
+			s.inUse.Add(1)
+			s.mu.Unlock()
+
+			conn, err := s.begin(ctx)
+
+			s.mu.Lock()
+			if err != nil {
+				s.opened--
+				s.maxConns = s.opened
+				s.inUse.Done()
+				s.mu.Unlock()
+				continue
+			}
+			if s.released {
+				s.mu.Unlock()
+				_ = conn.Txn.Rollback()
+				s.inUse.Done()
+				return nil, sql.ErrTxDone
+			}
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+			return conn, nil
+		}
+
+		if len(s.streaming) > 0 {
+			iter := s.streaming[0]
+			s.streaming = s.streaming[1:]
+			s.mu.Unlock()
+			iter.spill()
+			continue
+		}
+
+		returned := s.returned
+		s.mu.Unlock()
+		select {
+		case <-returned:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// put frees a transaction returned by acquire.
+func (s *SnapshotTupleReader) put(conn *SnapshotConn) {
+	s.mu.Lock()
+	if !s.released {
+		s.idle = append(s.idle, conn)
+		close(s.returned)
+		s.returned = make(chan struct{})
+	}
+	s.mu.Unlock()
+	s.inUse.Done()
+}
+
+// unstream removes iter from the streaming reads, if it is one.
+func (s *SnapshotTupleReader) unstream(iter *snapshotIterator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, streaming := range s.streaming {
+		if streaming == iter {
+			s.streaming = append(s.streaming[:i], s.streaming[i+1:]...)
+			return
+		}
+	}
+}
+
+// stream runs read with a free transaction. Like the context wrapper of the server, the query
+// itself runs without the cancellation of ctx, as a cancelled query can close the connection of
+// the transaction, which the other reads of the request still need.
+func (s *SnapshotTupleReader) stream(ctx context.Context, read func(context.Context, storage.RelationshipTupleReader) (storage.TupleIterator, error)) (storage.TupleIterator, error) {
+	conn, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	queryCtx := context.WithoutCancel(ctx)
+	iter, err := read(queryCtx, conn.Reader)
+	if err != nil {
+		s.put(conn)
+		return nil, err
+	}
+
+	buffer := make([]*openfgav1.Tuple, 0, snapshotReadAhead)
+	for len(buffer) < snapshotReadAhead {
+		t, err := iter.Next(queryCtx)
+		if err != nil {
+			iter.Stop()
+			s.put(conn)
+			if errors.Is(err, storage.ErrIteratorDone) {
+				return storage.NewStaticTupleIterator(buffer), nil
+			}
+			return nil, err
+		}
+		buffer = append(buffer, t)
+	}
+
+	streaming := &snapshotIterator{reader: s, buffer: buffer, iter: iter, conn: conn}
+	s.mu.Lock()
+	if s.released {
+		s.mu.Unlock()
+		iter.Stop()
+		s.put(conn)
+		return nil, sql.ErrTxDone
+	}
+	s.streaming = append(s.streaming, streaming)
+	s.mu.Unlock()
+	return streaming, nil
+}
+
+// Read see [storage.RelationshipTupleReader].Read.
+func (s *SnapshotTupleReader) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	return s.stream(ctx, func(ctx context.Context, reader storage.RelationshipTupleReader) (storage.TupleIterator, error) {
+		return reader.Read(ctx, store, tupleKey, options)
+	})
+}
+
+// ReadPage see [storage.RelationshipTupleReader].ReadPage.
+func (s *SnapshotTupleReader) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadPageOptions) ([]*openfgav1.Tuple, []byte, error) {
+	conn, err := s.acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer s.put(conn)
+
+	return conn.Reader.ReadPage(context.WithoutCancel(ctx), store, tupleKey, options)
+}
+
+// ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
+func (s *SnapshotTupleReader) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadUserTupleOptions) (*openfgav1.Tuple, error) {
+	conn, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.put(conn)
+
+	return conn.Reader.ReadUserTuple(context.WithoutCancel(ctx), store, tupleKey, options)
+}
+
+// ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
+func (s *SnapshotTupleReader) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, options storage.ReadUsersetTuplesOptions) (storage.TupleIterator, error) {
+	return s.stream(ctx, func(ctx context.Context, reader storage.RelationshipTupleReader) (storage.TupleIterator, error) {
+		return reader.ReadUsersetTuples(ctx, store, filter, options)
+	})
+}
+
+// ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
+func (s *SnapshotTupleReader) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter, options storage.ReadStartingWithUserOptions) (storage.TupleIterator, error) {
+	return s.stream(ctx, func(ctx context.Context, reader storage.RelationshipTupleReader) (storage.TupleIterator, error) {
+		return reader.ReadStartingWithUser(ctx, store, filter, options)
+	})
+}
+
+// Release see [storage.SnapshotTupleReader].Release. It stops the streaming reads, and waits for
+// the running ones, if any.
+func (s *SnapshotTupleReader) Release() {
+	s.mu.Lock()
+	if s.released {
+		s.mu.Unlock()
+		return
+	}
+	s.released = true
+	streaming := s.streaming
+	s.streaming = nil
+	s.mu.Unlock()
+
+	for _, iter := range streaming {
+		iter.Stop()
+	}
+	s.inUse.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		_ = conn.Txn.Rollback()
+	}
+}
+
+// snapshotIterator returns the tuples read ahead by a snapshot read, then streams the others from
+// its transaction, which it frees once they are read or it is stopped.
+type snapshotIterator struct {
+	reader *SnapshotTupleReader
+
+	mu     sync.Mutex
+	buffer []*openfgav1.Tuple
+	// iter and conn are nil once the rows are read or the iterator is stopped
+	iter storage.TupleIterator
+	conn *SnapshotConn
+	// err is the error of reading the rows into memory, returned after the tuples read before it
+	err error
+}
+
+var _ storage.TupleIterator = (*snapshotIterator)(nil)
+
+// Next see [storage.Iterator].Next.
+func (i *snapshotIterator) Next(ctx context.Context) (*openfgav1.Tuple, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if len(i.buffer) > 0 {
+		t := i.buffer[0]
+		i.buffer[0] = nil
+		i.buffer = i.buffer[1:]
+		return t, nil
+	}
+	return i.readLocked(ctx)
+}
+
+// Head see [storage.Iterator].Head.
+func (i *snapshotIterator) Head(ctx context.Context) (*openfgav1.Tuple, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if len(i.buffer) > 0 {
+		return i.buffer[0], nil
+	}
+	t, err := i.readLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	i.buffer = append(i.buffer, t)
+	return t, nil
+}
+
+// readLocked reads the next row of the transaction.
+func (i *snapshotIterator) readLocked(ctx context.Context) (*openfgav1.Tuple, error) {
+	if i.iter == nil {
+		if i.err != nil {
+			return nil, i.err
+		}
+		return nil, storage.ErrIteratorDone
+	}
+
+	t, err := i.iter.Next(context.WithoutCancel(ctx))
+	if err != nil {
+		i.finishLocked()
+		return nil, err
+	}
+	return t, nil
+}
+
+// Stop see [storage.Iterator].Stop.
+func (i *snapshotIterator) Stop() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.buffer = nil
+	if i.iter != nil {
+		i.finishLocked()
+	}
+}
+
+// spill reads the rows left into memory, to free the transaction for another read.
+func (i *snapshotIterator) spill() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.iter == nil {
+		return
+	}
+	for {
+		t, err := i.iter.Next(context.Background())
+		if err != nil {
+			if !errors.Is(err, storage.ErrIteratorDone) {
+				i.err = err
+			}
+			break
+		}
+		i.buffer = append(i.buffer, t)
+	}
+	i.finishLocked()
+}
+
+func (i *snapshotIterator) finishLocked() {
+	i.iter.Stop()
+	i.iter = nil
+	i.reader.unstream(i)
+	i.reader.put(i.conn)
+	i.conn = nil
+}
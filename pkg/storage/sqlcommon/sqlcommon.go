@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,6 +17,7 @@ import (
 	"github.com/pressly/goose/v3"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/openfga/openfga/internal/build"
 	"github.com/openfga/openfga/pkg/logger"
@@ -37,6 +40,18 @@ type Config struct {
 	ConnMaxLifetime time.Duration
 
 	ExportMetrics bool
+
+	PoolStatsInterval           time.Duration
+	PoolSaturationWaitThreshold int64
+	PoolSaturationPeriod        time.Duration
+
+	ChangelogMode ChangelogMode
+
+	WriteHook storage.WriteHook
+
+	QueryShapeSampling bool
+
+	TupleCounts bool
 }
 
 // DatastoreOption defines a function type
@@ -120,6 +135,56 @@ func WithMetrics() DatastoreOption {
 	}
 }
 
+// WithPoolStatsInterval returns a DatastoreOption that sets how often
+// the connection pool statistics are sampled.
+func WithPoolStatsInterval(d time.Duration) DatastoreOption {
+	return func(cfg *Config) {
+		cfg.PoolStatsInterval = d
+	}
+}
+
+// WithPoolSaturation returns a DatastoreOption that reports the connection pool
+// as saturated in the readiness status once more than waitThreshold requests
+// waited for a connection in every sample taken over the given period.
+func WithPoolSaturation(waitThreshold int64, period time.Duration) DatastoreOption {
+	return func(cfg *Config) {
+		cfg.PoolSaturationWaitThreshold = waitThreshold
+		cfg.PoolSaturationPeriod = period
+	}
+}
+
+// WithChangelogMode returns a DatastoreOption that sets
+// how changelog entries are written. See [ChangelogMode].
+func WithChangelogMode(mode ChangelogMode) DatastoreOption {
+	return func(cfg *Config) {
+		cfg.ChangelogMode = mode
+	}
+}
+
+// WithWriteHook returns a DatastoreOption that calls the hook inside
+// the transaction of every Write. See [storage.WriteHook].
+func WithWriteHook(h storage.WriteHook) DatastoreOption {
+	return func(cfg *Config) {
+		cfg.WriteHook = h
+	}
+}
+
+// WithQueryShapeSampling returns a DatastoreOption that makes the datastore
+// count its tuple queries by shape, to recommend indexes. See [QueryShapeSampler].
+func WithQueryShapeSampling(enabled bool) DatastoreOption {
+	return func(cfg *Config) {
+		cfg.QueryShapeSampling = enabled
+	}
+}
+
+// WithTupleCounts returns a DatastoreOption that makes the datastore maintain
+// the tuple counters of the stores in every Write. See [storage.TupleCounter].
+func WithTupleCounts(enabled bool) DatastoreOption {
+	return func(cfg *Config) {
+		cfg.TupleCounts = enabled
+	}
+}
+
 // NewConfig creates a new Config instance with default values
 // and applies any provided DatastoreOption modifications.
 func NewConfig(opts ...DatastoreOption) *Config {
@@ -141,9 +206,42 @@ func NewConfig(opts ...DatastoreOption) *Config {
 		cfg.MaxTypesPerModelField = storage.DefaultMaxTypesPerAuthorizationModel
 	}
 
+	if cfg.ChangelogMode == "" {
+		cfg.ChangelogMode = ChangelogModeSync
+	}
+
+	if cfg.PoolStatsInterval == 0 {
+		cfg.PoolStatsInterval = DefaultPoolStatsInterval
+	}
+
+	if cfg.PoolSaturationWaitThreshold == 0 {
+		cfg.PoolSaturationWaitThreshold = DefaultPoolSaturationWaitThreshold
+	}
+
+	if cfg.PoolSaturationPeriod == 0 {
+		cfg.PoolSaturationPeriod = DefaultPoolSaturationPeriod
+	}
+
 	return cfg
 }
 
+// WhereConditionFilter restricts a select on the tuple table to the tuples that pass the filter.
+func WhereConditionFilter(sb sq.SelectBuilder, filter storage.ReadConditionFilter) sq.SelectBuilder {
+	if filter.Name != "" {
+		sb = sb.Where(sq.Eq{"condition_name": filter.Name})
+	}
+
+	if filter.HasCondition != nil {
+		if *filter.HasCondition {
+			sb = sb.Where(sq.And{sq.NotEq{"condition_name": nil}, sq.NotEq{"condition_name": ""}})
+		} else {
+			sb = sb.Where(sq.Or{sq.Eq{"condition_name": nil}, sq.Eq{"condition_name": ""}})
+		}
+	}
+
+	return sb
+}
+
 // ContToken represents a continuation token structure used in pagination.
 type ContToken struct {
 	Ulid       string `json:"ulid"`
@@ -339,7 +437,7 @@ func (t *SQLTupleIterator) ToArray(
 		return nil, nil, err
 	}
 
-	contToken, err := json.Marshal(NewContToken(tupleRecord.Ulid, ""))
+	contToken, err := storage.NewReadCursor(tupleRecord).Marshal()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -385,6 +483,11 @@ type DBInfo struct {
 	db             *sql.DB
 	stbl           sq.StatementBuilderType
 	HandleSQLError errorHandlerFn
+	changelog      *ChangelogWriter
+	writeHook      storage.WriteHook
+
+	// tupleCountUpsert is the suffix of the INSERT that adds to a tuple counter. See [UpdateTupleCounts].
+	tupleCountUpsert string
 }
 
 type errorHandlerFn func(error, ...interface{}) error
@@ -395,9 +498,76 @@ func NewDBInfo(db *sql.DB, stbl sq.StatementBuilderType, errorHandler errorHandl
 		db:             db,
 		stbl:           stbl,
 		HandleSQLError: errorHandler,
+		changelog: &ChangelogWriter{
+			mode:  ChangelogModeSync,
+			stbl:  stbl,
+			table: ChangelogTable{Columns: ChangelogColumns, Now: "NOW()"},
+		},
 	}
 }
 
+// WithChangelogWriter sets the writer of the changelog entries of [Write].
+// Without one, changelog entries are inserted in the write transaction.
+func (d *DBInfo) WithChangelogWriter(w *ChangelogWriter) *DBInfo {
+	d.changelog = w
+	return d
+}
+
+// WithWriteHook sets the hook that [Write] calls before committing.
+func (d *DBInfo) WithWriteHook(h storage.WriteHook) *DBInfo {
+	d.writeHook = h
+	return d
+}
+
+// WithTupleCounts makes [Write] maintain the tuple counters of the stores, with the given
+// dialect-specific suffix of the INSERT adding to a counter. See [UpdateTupleCounts].
+func (d *DBInfo) WithTupleCounts(upsertSuffix string) *DBInfo {
+	d.tupleCountUpsert = upsertSuffix
+	return d
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern with the escape character of
+// [UsersetTypeConditions]. An explicit escape character is portable across MySQL, whose
+// NO_BACKSLASH_ESCAPES mode disables the default one, and Postgres.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// UsersetTypeConditions returns the condition of ReadUsersetTuples on the users of the tuples
+// whose type and relation are allowed by the type restrictions. The users are matched on the
+// user_object_type and user_relation columns written by [Write], backed by the
+// idx_tuple_userset_type index, or on the user itself for the tuples whose columns are empty,
+// such as the tuples written by servers still running the version before the migration that
+// added the columns.
+func UsersetTypeConditions(restrictions []*openfgav1.RelationReference) sq.Or {
+	conditions := sq.Or{}
+	for _, userset := range restrictions {
+		if _, ok := userset.GetRelationOrWildcard().(*openfgav1.RelationReference_Relation); ok {
+			conditions = append(conditions,
+				sq.Eq{
+					"user_object_type": userset.GetType(),
+					"user_relation":    userset.GetRelation(),
+				},
+				sq.And{
+					sq.Eq{"user_object_type": ""},
+					sq.Expr("_user LIKE ? ESCAPE '!'", likeEscaper.Replace(userset.GetType())+":%#"+likeEscaper.Replace(userset.GetRelation())),
+				},
+			)
+		}
+		if _, ok := userset.GetRelationOrWildcard().(*openfgav1.RelationReference_Wildcard); ok {
+			conditions = append(conditions, sq.Eq{
+				"user_object_type": []string{userset.GetType(), ""},
+				"_user":            userset.GetType() + ":*",
+			})
+		}
+	}
+	return conditions
+}
+
+// ChangelogColumns are the columns of the changelog table written by [Write].
+var ChangelogColumns = []string{
+	"store", "object_type", "object_id", "relation", "_user",
+	"condition_name", "condition_context", "operation", "ulid", "inserted_at",
+}
+
 // Write provides the common method for writing to database across sql storage.
 func Write(
 	ctx context.Context,
@@ -415,12 +585,7 @@ func Write(
 		_ = txn.Rollback()
 	}()
 
-	changelogBuilder := dbInfo.stbl.
-		Insert("changelog").
-		Columns(
-			"store", "object_type", "object_id", "relation", "_user",
-			"condition_name", "condition_context", "operation", "ulid", "inserted_at",
-		)
+	var changelogRows [][]interface{}
 
 	deleteBuilder := dbInfo.stbl.Delete("tuple")
 
@@ -455,19 +620,20 @@ func Write(
 			)
 		}
 
-		changelogBuilder = changelogBuilder.Values(
+		changelogRows = append(changelogRows, []interface{}{
 			store, objectType, objectID,
 			tk.GetRelation(), tk.GetUser(),
 			"", nil, // Redact condition info for deletes since we only need the base triplet (object, relation, user).
 			openfgav1.TupleOperation_TUPLE_OPERATION_DELETE,
 			id, sq.Expr("NOW()"),
-		)
+		})
 	}
 
 	insertBuilder := dbInfo.stbl.
 		Insert("tuple").
 		Columns(
 			"store", "object_type", "object_id", "relation", "_user", "user_type",
+			"user_object_type", "user_relation",
 			"condition_name", "condition_context", "ulid", "inserted_at",
 		)
 
@@ -475,6 +641,9 @@ func Write(
 		id := ulid.MustNew(ulid.Timestamp(now), ulid.DefaultEntropy()).String()
 		objectType, objectID := tupleUtils.SplitObject(tk.GetObject())
 
+		// the type and relation of the user back the index used by ReadUsersetTuples
+		userObjectType, _, userRelation := tupleUtils.ToUserParts(tk.GetUser())
+
 		conditionName, conditionContext, err := MarshalRelationshipCondition(tk.GetCondition())
 		if err != nil {
 			return err
@@ -488,6 +657,8 @@ func Write(
 				tk.GetRelation(),
 				tk.GetUser(),
 				tupleUtils.GetUserTypeFromUser(tk.GetUser()),
+				userObjectType,
+				userRelation,
 				conditionName,
 				conditionContext,
 				id,
@@ -499,7 +670,7 @@ func Write(
 			return dbInfo.HandleSQLError(err, tk)
 		}
 
-		changelogBuilder = changelogBuilder.Values(
+		changelogRows = append(changelogRows, []interface{}{
 			store,
 			objectType,
 			objectID,
@@ -510,16 +681,29 @@ func Write(
 			openfgav1.TupleOperation_TUPLE_OPERATION_WRITE,
 			id,
 			sq.Expr("NOW()"),
-		)
+		})
+	}
+
+	if len(changelogRows) > 0 && dbInfo.changelog.Mode() != ChangelogModeDisabled {
+		_, err := dbInfo.changelog.InsertBuilder(changelogRows).RunWith(txn).ExecContext(ctx) // Part of a txn.
+		if err != nil {
+			return dbInfo.HandleSQLError(err)
+		}
 	}
 
-	if len(writes) > 0 || len(deletes) > 0 {
-		_, err := changelogBuilder.RunWith(txn).ExecContext(ctx) // Part of a txn.
+	if dbInfo.tupleCountUpsert != "" {
+		err := UpdateTupleCounts(ctx, txn, dbInfo.stbl, store, storage.TupleCountDeltas(deletes, writes), dbInfo.tupleCountUpsert)
 		if err != nil {
 			return dbInfo.HandleSQLError(err)
 		}
 	}
 
+	if dbInfo.writeHook != nil {
+		if err := dbInfo.writeHook.BeforeCommit(ctx, txn, store, deletes, writes); err != nil {
+			return err
+		}
+	}
+
 	if err := txn.Commit(); err != nil {
 		return dbInfo.HandleSQLError(err)
 	}
@@ -628,6 +812,34 @@ func constructAuthorizationModelFromSQLRows(rows *sql.Rows) (*openfgav1.Authoriz
 	}, nil
 }
 
+// LiveStoreModels filters the rows of the authorization_model table on the stores that were not
+// deleted. The models without a store row, e.g. written directly to the datastore, are kept.
+var LiveStoreModels = sq.Expr("NOT EXISTS (SELECT 1 FROM store WHERE store.id = authorization_model.store AND store.deleted_at IS NOT NULL)")
+
+// ModelNotFoundError returns [storage.ErrStoreDeleted] if the authorization model was not found
+// because its store was deleted, and else err.
+func ModelNotFoundError(ctx context.Context, stbl sq.StatementBuilderType, store string, err error) error {
+	if !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+
+	var one int
+	switch scanErr := stbl.
+		Select("1").
+		From("store").
+		Where(sq.Eq{"id": store}).
+		Where(sq.NotEq{"deleted_at": nil}).
+		QueryRowContext(ctx).
+		Scan(&one); {
+	case scanErr == nil:
+		return storage.ErrStoreDeleted
+	case errors.Is(scanErr, sql.ErrNoRows):
+		return err
+	default:
+		return scanErr
+	}
+}
+
 // FindLatestAuthorizationModel reads the latest authorization model corresponding to the store.
 func FindLatestAuthorizationModel(
 	ctx context.Context,
@@ -638,6 +850,7 @@ func FindLatestAuthorizationModel(
 		Select("authorization_model_id", "schema_version", "type", "type_definition", "serialized_protobuf").
 		From("authorization_model").
 		Where(sq.Eq{"store": store}).
+		Where(LiveStoreModels).
 		OrderBy("authorization_model_id desc").
 		QueryContext(ctx)
 	if err != nil {
@@ -646,7 +859,7 @@ func FindLatestAuthorizationModel(
 	defer rows.Close()
 	ret, err := constructAuthorizationModelFromSQLRows(rows)
 	if err != nil {
-		return nil, dbInfo.HandleSQLError(err)
+		return nil, ModelNotFoundError(ctx, dbInfo.stbl, store, dbInfo.HandleSQLError(err))
 	}
 
 	return ret, nil
@@ -665,6 +878,7 @@ func ReadAuthorizationModel(
 			"store":                  store,
 			"authorization_model_id": modelID,
 		}).
+		Where(LiveStoreModels).
 		QueryContext(ctx)
 	if err != nil {
 		return nil, dbInfo.HandleSQLError(err)
@@ -672,12 +886,524 @@ func ReadAuthorizationModel(
 	defer rows.Close()
 	ret, err := constructAuthorizationModelFromSQLRows(rows)
 	if err != nil {
-		return nil, dbInfo.HandleSQLError(err)
+		return nil, ModelNotFoundError(ctx, dbInfo.stbl, store, dbInfo.HandleSQLError(err))
 	}
 
 	return ret, nil
 }
 
+// CountRelation counts the tuples of a relation with a single aggregate query.
+// userColumn is the column, or expression, of the user string. See [storage.RelationCounter].
+func CountRelation(ctx context.Context, stbl sq.StatementBuilderType, store, objectType, relation, userColumn string) (storage.RelationCounts, error) {
+	var counts storage.RelationCounts
+	err := stbl.
+		Select("COUNT(*)", "COUNT(DISTINCT object_id)", "COUNT(DISTINCT "+userColumn+")").
+		From("tuple").
+		Where(sq.Eq{
+			"store":       store,
+			"object_type": objectType,
+			"relation":    relation,
+		}).
+		QueryRowContext(ctx).
+		Scan(&counts.Tuples, &counts.DistinctObjects, &counts.DistinctUsers)
+	if err != nil {
+		return storage.RelationCounts{}, err
+	}
+
+	return counts, nil
+}
+
+// CountRelationUsage counts the tuples of every object type and relation of a store with a single
+// grouped aggregate query. See [storage.RelationUsageCounter].
+func CountRelationUsage(ctx context.Context, stbl sq.StatementBuilderType, store string) ([]storage.RelationUsage, error) {
+	rows, err := stbl.
+		Select("object_type", "relation", "COUNT(*)").
+		From("tuple").
+		Where(sq.Eq{"store": store}).
+		GroupBy("object_type", "relation").
+		OrderBy("object_type", "relation").
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []storage.RelationUsage
+	for rows.Next() {
+		var u storage.RelationUsage
+		if err := rows.Scan(&u.ObjectType, &u.Relation, &u.Tuples); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+// UpdateTupleCounts adds the deltas to the tuple counters of the store in the transaction of a
+// Write. The counters are updated in the order of their object type, so that concurrent writes
+// lock them in the same order. upsertSuffix is the suffix of the INSERT that adds to an existing
+// counter instead, since the dialects differ on how to upsert. See [storage.TupleCounter].
+func UpdateTupleCounts(ctx context.Context, txn *sql.Tx, stbl sq.StatementBuilderType, store string, deltas map[string]int64, upsertSuffix string) error {
+	objectTypes := make([]string, 0, len(deltas))
+	for objectType := range deltas {
+		objectTypes = append(objectTypes, objectType)
+	}
+	sort.Strings(objectTypes)
+
+	for _, objectType := range objectTypes {
+		_, err := stbl.
+			Insert("tuple_count").
+			Columns("store", "object_type", "tuples").
+			Values(store, objectType, deltas[objectType]).
+			Suffix(upsertSuffix).
+			RunWith(txn). // Part of a txn.
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadTupleCounts reads the tuple counters of a store. See [storage.TupleCounter].
+func ReadTupleCounts(ctx context.Context, stbl sq.StatementBuilderType, store string) (storage.TupleCounts, error) {
+	rows, err := stbl.
+		Select("object_type", "tuples").
+		From("tuple_count").
+		Where(sq.Eq{"store": store}).
+		QueryContext(ctx)
+	if err != nil {
+		return storage.TupleCounts{}, err
+	}
+	defer rows.Close()
+
+	counts := storage.TupleCounts{ByObjectType: make(map[string]int64)}
+	for rows.Next() {
+		var objectType string
+		var tuples int64
+		if err := rows.Scan(&objectType, &tuples); err != nil {
+			return storage.TupleCounts{}, err
+		}
+		counts.ByObjectType[objectType] = tuples
+		counts.Total += tuples
+	}
+	if err := rows.Err(); err != nil {
+		return storage.TupleCounts{}, err
+	}
+
+	return counts, nil
+}
+
+// BackfillTupleCounts replaces the tuple counters of a store with counts of its tuples, in one
+// transaction. lockTuples is the dialect-specific statement that makes the writes of the tuples
+// of the store wait until the transaction commits, run before they are counted, so that a
+// concurrent Write neither adds to the counters a tuple that is also counted nor has its delta
+// dropped; it is nil if the datastore runs one write transaction at a time. See
+// [storage.TupleCounter].
+func BackfillTupleCounts(ctx context.Context, db *sql.DB, stbl sq.StatementBuilderType, store string, lockTuples sq.Sqlizer) error {
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	if lockTuples != nil {
+		query, args, err := lockTuples.ToSql()
+		if err != nil {
+			return err
+		}
+		rows, err := txn.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+	}
+
+	_, err = stbl.
+		Delete("tuple_count").
+		Where(sq.Eq{"store": store}).
+		RunWith(txn). // Part of a txn.
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = stbl.
+		Insert("tuple_count").
+		Columns("store", "object_type", "tuples").
+		Select(sq.
+				Select("store", "object_type", "COUNT(*)").
+				From("tuple").
+				Where(sq.Eq{"store": store}).
+				GroupBy("store", "object_type")).
+		RunWith(txn). // Part of a txn.
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return txn.Commit()
+}
+
+// CountStores counts the stores that were not deleted. Unless the options require the exact
+// count or filter on a name, it returns the estimate of the number of stores that were not
+// deleted read by estimate instead, if the dialect has one (estimate is not nil) and the
+// statistics it reads are available. See [storage.StoreCounter].
+func CountStores(ctx context.Context, stbl sq.StatementBuilderType, options storage.CountStoresOptions, estimate *sq.SelectBuilder) (storage.StoreCount, error) {
+	if !options.Exact && options.Name == "" {
+		rows, ok, err := estimateRows(ctx, estimate)
+		if err != nil {
+			return storage.StoreCount{}, err
+		}
+		if ok {
+			return storage.StoreCount{Count: rows, Estimated: true}, nil
+		}
+	}
+
+	sb := stbl.
+		Select("COUNT(*)").
+		From("store").
+		Where(sq.Eq{"deleted_at": nil})
+	if options.Name != "" {
+		sb = sb.Where(sq.Eq{"name": options.Name})
+	}
+
+	var count storage.StoreCount
+	err := sb.QueryRowContext(ctx).Scan(&count.Count)
+	if err != nil {
+		return storage.StoreCount{}, err
+	}
+
+	return count, nil
+}
+
+// GetStoreByName returns the oldest store with exactly the name that was not deleted, or
+// storage.ErrNotFound. Run stbl with a transaction to read the store in it. See
+// [storage.UniqueStoreNamesBackend].
+func GetStoreByName(ctx context.Context, stbl sq.StatementBuilderType, name string) (*openfgav1.Store, error) {
+	var id, storeName string
+	var createdAt, updatedAt time.Time
+	err := stbl.
+		Select("id", "name", "created_at", "updated_at").
+		From("store").
+		Where(sq.Eq{
+			"name":       name,
+			"deleted_at": nil,
+		}).
+		OrderBy("id").
+		Limit(1).
+		QueryRowContext(ctx).
+		Scan(&id, &storeName, &createdAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &openfgav1.Store{
+		Id:        id,
+		Name:      storeName,
+		CreatedAt: timestamppb.New(createdAt),
+		UpdatedAt: timestamppb.New(updatedAt),
+	}, nil
+}
+
+// CheckStoreNameAvailable returns a [*storage.StoreNameConflictError] if a store that was not
+// deleted has the name. The caller must serialize it with the creation of the store, e.g. with a
+// lock on the name held until the transaction that creates the store commits.
+func CheckStoreNameAvailable(ctx context.Context, stbl sq.StatementBuilderType, name string) error {
+	existing, err := GetStoreByName(ctx, stbl, name)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return &storage.StoreNameConflictError{StoreID: existing.GetId(), Name: name}
+}
+
+// estimateRows returns the estimate of the number of rows of a table read by estimate, and
+// whether it is available: estimate is nil for dialects without one.
+func estimateRows(ctx context.Context, estimate *sq.SelectBuilder) (int64, bool, error) {
+	if estimate == nil {
+		return 0, false, nil
+	}
+
+	var rows sql.NullInt64
+	err := estimate.QueryRowContext(ctx).Scan(&rows)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+	// the statistics are missing until the table is first analyzed
+	if !rows.Valid || rows.Int64 < 0 {
+		return 0, false, nil
+	}
+	return rows.Int64, true, nil
+}
+
+// GetLatestChange returns the time of the newest change of the store, read from the ULID of the
+// primary key of the changelog. See [storage.ChangelogStatsReader].
+func GetLatestChange(ctx context.Context, stbl sq.StatementBuilderType, store string) (time.Time, error) {
+	var latest string
+	err := stbl.
+		Select("ulid").
+		From("changelog").
+		Where(sq.Eq{"store": store}).
+		OrderBy("ulid DESC").
+		Limit(1).
+		QueryRowContext(ctx).
+		Scan(&latest)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, storage.ErrNotFound
+		}
+		return time.Time{}, err
+	}
+
+	id, err := ulid.Parse(latest)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ulid.Time(id.Time()), nil
+}
+
+// CountChanges counts the changes of all stores. It returns the estimate of the number of rows of
+// the changelog table read by estimate instead, if the dialect has one (estimate is not nil) and
+// the statistics it reads are available. See [storage.ChangelogStatsReader].
+func CountChanges(ctx context.Context, stbl sq.StatementBuilderType, estimate *sq.SelectBuilder) (storage.ChangeCount, error) {
+	rows, ok, err := estimateRows(ctx, estimate)
+	if err != nil {
+		return storage.ChangeCount{}, err
+	}
+	if ok {
+		return storage.ChangeCount{Count: rows, Estimated: true}, nil
+	}
+
+	var count storage.ChangeCount
+	err = stbl.
+		Select("COUNT(*)").
+		From("changelog").
+		QueryRowContext(ctx).
+		Scan(&count.Count)
+	if err != nil {
+		return storage.ChangeCount{}, err
+	}
+
+	return count, nil
+}
+
+// CheckChangesContinuationToken returns [storage.ErrContinuationTokenExpired] if changes of the
+// store newer than the change of the continuation token of an ascending ReadChanges were trimmed,
+// i.e. if the consumer would miss them. A consumer that read every trimmed change keeps its token.
+// The newest trimmed change is recorded in the changelog_trim table by [TrimChanges]. See
+// [storage.ChangelogTrimmer].
+func CheckChangesContinuationToken(ctx context.Context, stbl sq.StatementBuilderType, store, tokenULID string) error {
+	var newestTrimmed string
+	err := stbl.
+		Select("ulid").
+		From("changelog_trim").
+		Where(sq.Eq{"store": store}).
+		QueryRowContext(ctx).
+		Scan(&newestTrimmed)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if tokenULID < newestTrimmed {
+		return storage.ErrContinuationTokenExpired
+	}
+	return nil
+}
+
+// ChangesStartULID returns the smallest ULID of the changes that occurred at or after the start
+// time, to seek the changes of a [storage.ReadChangesFilter] with a StartTime by the primary key
+// of the changelog. Start times before the Unix epoch return the smallest ULID.
+func ChangesStartULID(start time.Time) (string, error) {
+	var startULID ulid.ULID
+	if start.Before(time.Unix(0, 0)) {
+		return startULID.String(), nil
+	}
+	if err := startULID.SetTime(ulid.Timestamp(start)); err != nil {
+		return "", err
+	}
+	return startULID.String(), nil
+}
+
+// TrimChanges deletes the changes of the store whose ULID is older than the given time, and
+// records the ULID of the newest change deleted in the changelog_trim table, in one transaction,
+// for [CheckChangesContinuationToken]. See [storage.ChangelogTrimmer].
+func TrimChanges(ctx context.Context, db *sql.DB, stbl sq.StatementBuilderType, store string, before time.Time) (int64, error) {
+	var oldestRetained ulid.ULID
+	if err := oldestRetained.SetTime(ulid.Timestamp(before)); err != nil {
+		return 0, err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	var newestTrimmed sql.NullString
+	err = stbl.
+		Select("MAX(ulid)").
+		From("changelog").
+		Where(sq.Eq{"store": store}).
+		Where(sq.Lt{"ulid": oldestRetained.String()}).
+		RunWith(txn). // Part of a txn.
+		QueryRowContext(ctx).
+		Scan(&newestTrimmed)
+	if err != nil {
+		return 0, err
+	}
+	if !newestTrimmed.Valid {
+		return 0, nil
+	}
+
+	res, err := stbl.
+		Delete("changelog").
+		Where(sq.Eq{"store": store}).
+		Where(sq.LtOrEq{"ulid": newestTrimmed.String}).
+		RunWith(txn). // Part of a txn.
+		ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	trimmed, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	var recorded string
+	err = stbl.
+		Select("ulid").
+		From("changelog_trim").
+		Where(sq.Eq{"store": store}).
+		RunWith(txn). // Part of a txn.
+		QueryRowContext(ctx).
+		Scan(&recorded)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		_, err = stbl.
+			Insert("changelog_trim").
+			Columns("store", "ulid").
+			Values(store, newestTrimmed.String).
+			RunWith(txn). // Part of a txn.
+			ExecContext(ctx)
+	case err != nil:
+	case recorded < newestTrimmed.String:
+		_, err = stbl.
+			Update("changelog_trim").
+			Set("ulid", newestTrimmed.String).
+			Where(sq.Eq{"store": store}).
+			RunWith(txn). // Part of a txn.
+			ExecContext(ctx)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := txn.Commit(); err != nil {
+		return 0, err
+	}
+	return trimmed, nil
+}
+
+// purgeStoreTables are the tables of the rows of a store, in the order they are purged.
+var purgeStoreTables = []string{"tuple", "tuple_count", "changelog", ChangelogOutboxTable, "changelog_trim", "assertion", "model_alias", "authorization_model"}
+
+// ListDeletedStores returns the IDs of the stores whose deleted_at is set. See [storage.StorePurger].
+func ListDeletedStores(ctx context.Context, stbl sq.StatementBuilderType) ([]string, error) {
+	rows, err := stbl.
+		Select("id").
+		From("store").
+		Where(sq.NotEq{"deleted_at": nil}).
+		OrderBy("id").
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stores []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		stores = append(stores, id)
+	}
+	return stores, rows.Err()
+}
+
+// PurgeStore deletes at most batchSize rows of a deleted store, across its tables, and deletes
+// the store once no rows are left. deleteBatch returns the statement that deletes at most limit
+// rows of the store from a table, since the dialects differ on how to bound a DELETE.
+// See [storage.StorePurger].
+func PurgeStore(
+	ctx context.Context,
+	stbl sq.StatementBuilderType,
+	store string,
+	batchSize int,
+	deleteBatch func(table, store string, limit int) sq.DeleteBuilder,
+) (int64, error) {
+	var found int
+	err := stbl.
+		Select("1").
+		From("store").
+		Where(sq.Eq{"id": store}).
+		Where(sq.NotEq{"deleted_at": nil}).
+		QueryRowContext(ctx).
+		Scan(&found)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, table := range purgeStoreTables {
+		if deleted >= int64(batchSize) {
+			break
+		}
+
+		res, err := deleteBatch(table, store, batchSize-int(deleted)).ExecContext(ctx)
+		if err != nil {
+			return deleted, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+
+	if deleted == 0 {
+		_, err := stbl.
+			Delete("store").
+			Where(sq.Eq{"id": store}).
+			ExecContext(ctx)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return deleted, nil
+}
+
 // IsReady returns true if the connection to the datastore is successful
 // and the datastore has the latest migration applied.
 func IsReady(ctx context.Context, db *sql.DB) (storage.ReadinessStatus, error) {
@@ -38,18 +38,59 @@ func startTrace(ctx context.Context, name string) (context.Context, trace.Span)
 	return tracer.Start(ctx, "sqlite."+name)
 }
 
+// changelogColumns are the columns of the changelog table written by [Datastore.Write].
+var changelogColumns = []string{
+	"store",
+	"object_type",
+	"object_id",
+	"relation",
+	"user_object_type",
+	"user_object_id",
+	"user_relation",
+	"condition_name",
+	"condition_context",
+	"operation",
+	"ulid",
+	"inserted_at",
+}
+
+// userExpr rebuilds the user string (e.g. `group:eng#member`) from its columns, so that
+// ReadPage orders users the way the other datastores do. The idx_tuple_read_order index is on
+// the same expression, so that it serves the ReadPage order; they must not diverge.
+const userExpr = "user_object_type || ':' || user_object_id || CASE WHEN user_relation = '' THEN '' ELSE '#' || user_relation END"
+
 // Datastore provides a SQLite based implementation of [storage.OpenFGADatastore].
 type Datastore struct {
 	stbl                   sq.StatementBuilderType
 	db                     *sql.DB
 	logger                 logger.Logger
 	dbStatsCollector       prometheus.Collector
+	poolMonitor            *sqlcommon.PoolMonitor
+	queryShapes            *sqlcommon.QueryShapeSampler
+	tupleCounts            bool
+	changelog              *sqlcommon.ChangelogWriter
+	writeHook              storage.WriteHook
 	maxTuplesPerWriteField int
 	maxTypesPerModelField  int
 }
 
-// Ensures that SQLite implements the OpenFGADatastore interface.
+// Ensures that SQLite implements the OpenFGADatastore, RelationCounter, RelationUsageCounter, SnapshotBeginner,
+// TupleCounter, ModelAliasBackend, StorePurger, StoreCounter, ChangelogTrimmer, ChangelogStatsReader, UniqueStoreNamesBackend and IndexAdvisor interfaces.
 var _ storage.OpenFGADatastore = (*Datastore)(nil)
+var _ storage.RelationCounter = (*Datastore)(nil)
+var _ storage.RelationUsageCounter = (*Datastore)(nil)
+var _ storage.SnapshotBeginner = (*Datastore)(nil)
+var _ storage.TupleCounter = (*Datastore)(nil)
+var _ storage.ModelAliasBackend = (*Datastore)(nil)
+var _ storage.StorePurger = (*Datastore)(nil)
+var _ storage.StoreCounter = (*Datastore)(nil)
+var _ storage.ChangelogTrimmer = (*Datastore)(nil)
+var _ storage.ChangelogStatsReader = (*Datastore)(nil)
+var _ storage.UniqueStoreNamesBackend = (*Datastore)(nil)
+var _ storage.IndexAdvisor = (*Datastore)(nil)
+
+// tupleCountUpsert adds to the existing tuple counter of a store and object type.
+const tupleCountUpsert = "ON CONFLICT (store, object_type) DO UPDATE SET tuples = tuple_count.tuples + excluded.tuples"
 
 // Prepare a raw DSN from config for use with SQLite, specifying defaults for journal mode and busy timeout.
 func PrepareDSN(uri string) (string, error) {
@@ -115,11 +156,24 @@ func New(uri string, cfg *sqlcommon.Config) (*Datastore, error) {
 
 	stbl := sq.StatementBuilder.RunWith(db)
 
+	var queryShapes *sqlcommon.QueryShapeSampler
+	if cfg.QueryShapeSampling {
+		queryShapes = sqlcommon.NewQueryShapeSampler()
+	}
+
 	return &Datastore{
-		stbl:                   stbl,
-		db:                     db,
-		logger:                 cfg.Logger,
-		dbStatsCollector:       collector,
+		stbl:             stbl,
+		db:               db,
+		logger:           cfg.Logger,
+		dbStatsCollector: collector,
+		changelog: sqlcommon.NewChangelogWriter(db, stbl, sqlcommon.ChangelogTable{
+			Columns: changelogColumns,
+			Now:     "datetime('subsec')",
+		}, cfg),
+		writeHook:              cfg.WriteHook,
+		poolMonitor:            sqlcommon.NewPoolMonitor(db, cfg),
+		queryShapes:            queryShapes,
+		tupleCounts:            cfg.TupleCounts,
 		maxTuplesPerWriteField: cfg.MaxTuplesPerWriteField,
 		maxTypesPerModelField:  cfg.MaxTypesPerModelField,
 	}, nil
@@ -127,6 +181,8 @@ func New(uri string, cfg *sqlcommon.Config) (*Datastore, error) {
 
 // Close see [storage.OpenFGADatastore].Close.
 func (s *Datastore) Close() {
+	s.poolMonitor.Stop()
+	s.changelog.Close()
 	if s.dbStatsCollector != nil {
 		prometheus.Unregister(s.dbStatsCollector)
 	}
@@ -143,7 +199,12 @@ func (s *Datastore) Read(
 	ctx, span := startTrace(ctx, "Read")
 	defer span.End()
 
-	return s.read(ctx, store, tupleKey, nil)
+	iter, err := s.read(ctx, store, tupleKey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queryShapes.RecordIterator(sqlcommon.QueryMethodRead, sqlcommon.TupleKeyFilter(tupleKey), iter), nil
 }
 
 // ReadPage see [storage.RelationshipTupleReader].ReadPage.
@@ -162,7 +223,13 @@ func (s *Datastore) ReadPage(
 	}
 	defer iter.Stop()
 
-	return iter.ToArray(options.Pagination)
+	tuples, contToken, err := iter.ToArray(options.Pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.queryShapes.Record(sqlcommon.QueryMethodReadPage, sqlcommon.ReadPageFilter(tupleKey, options), len(tuples))
+	return tuples, contToken, nil
 }
 
 func (s *Datastore) read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options *storage.ReadPageOptions) (*SQLTupleIterator, error) {
@@ -178,7 +245,7 @@ func (s *Datastore) read(ctx context.Context, store string, tupleKey *openfgav1.
 		From("tuple").
 		Where(sq.Eq{"store": store})
 	if options != nil {
-		sb = sb.OrderBy("ulid")
+		sb = sb.OrderBy("object_type", "object_id", "relation", userExpr)
 	}
 
 	objectType, objectID := tupleUtils.SplitObject(tupleKey.GetObject())
@@ -199,12 +266,16 @@ func (s *Datastore) read(ctx context.Context, store string, tupleKey *openfgav1.
 			"user_relation":    userRelation,
 		})
 	}
+	if options != nil {
+		sb = sqlcommon.WhereConditionFilter(sb, options.Condition)
+	}
 	if options != nil && options.Pagination.From != "" {
-		token, err := sqlcommon.UnmarshallContToken(options.Pagination.From)
+		cursor, err := storage.UnmarshalReadCursor(options.Pagination.From)
 		if err != nil {
 			return nil, err
 		}
-		sb = sb.Where(sq.GtOrEq{"ulid": token.Ulid})
+		sb = sb.Where(sq.Expr("(object_type, object_id, relation, "+userExpr+") >= (?, ?, ?, ?)",
+			cursor.ObjectType, cursor.ObjectID, cursor.Relation, cursor.User))
 	}
 	if options != nil && options.Pagination.PageSize != 0 {
 		sb = sb.Limit(uint64(options.Pagination.PageSize + 1)) // + 1 is used to determine whether to return a continuation token.
@@ -256,22 +327,7 @@ func (s *Datastore) write(
 		_ = txn.Rollback()
 	}()
 
-	changelogBuilder := s.stbl.
-		Insert("changelog").
-		Columns(
-			"store",
-			"object_type",
-			"object_id",
-			"relation",
-			"user_object_type",
-			"user_object_id",
-			"user_relation",
-			"condition_name",
-			"condition_context",
-			"operation",
-			"ulid",
-			"inserted_at",
-		)
+	var changelogRows [][]interface{}
 
 	deleteBuilder := s.stbl.Delete("tuple")
 
@@ -314,7 +370,7 @@ func (s *Datastore) write(
 			)
 		}
 
-		changelogBuilder = changelogBuilder.Values(
+		changelogRows = append(changelogRows, []interface{}{
 			store,
 			objectType,
 			objectID,
@@ -327,7 +383,7 @@ func (s *Datastore) write(
 			openfgav1.TupleOperation_TUPLE_OPERATION_DELETE,
 			id,
 			sq.Expr("datetime('subsec')"),
-		)
+		})
 	}
 
 	insertBuilder := s.stbl.
@@ -381,7 +437,7 @@ func (s *Datastore) write(
 			return HandleSQLError(err, tk)
 		}
 
-		changelogBuilder = changelogBuilder.Values(
+		changelogRows = append(changelogRows, []interface{}{
 			store,
 			objectType,
 			objectID,
@@ -394,10 +450,11 @@ func (s *Datastore) write(
 			openfgav1.TupleOperation_TUPLE_OPERATION_WRITE,
 			id,
 			sq.Expr("datetime('subsec')"),
-		)
+		})
 	}
 
-	if len(writes) > 0 || len(deletes) > 0 {
+	if len(changelogRows) > 0 && s.changelog.Mode() != sqlcommon.ChangelogModeDisabled {
+		changelogBuilder := s.changelog.InsertBuilder(changelogRows)
 		err := busyRetry(func() error {
 			_, err := changelogBuilder.RunWith(txn).ExecContext(ctx) // Part of a txn.
 			return err
@@ -407,6 +464,21 @@ func (s *Datastore) write(
 		}
 	}
 
+	if s.tupleCounts {
+		err = busyRetry(func() error {
+			return sqlcommon.UpdateTupleCounts(ctx, txn, s.stbl, store, storage.TupleCountDeltas(deletes, writes), tupleCountUpsert)
+		})
+		if err != nil {
+			return HandleSQLError(err)
+		}
+	}
+
+	if s.writeHook != nil {
+		if err := s.writeHook.BeforeCommit(ctx, txn, store, deletes, writes); err != nil {
+			return err
+		}
+	}
+
 	err = busyRetry(func() error {
 		return txn.Commit()
 	})
@@ -459,8 +531,10 @@ func (s *Datastore) ReadUserTuple(ctx context.Context, store string, tupleKey *o
 			&conditionContext,
 		)
 	if err != nil {
+		s.queryShapes.Record(sqlcommon.QueryMethodReadUserTuple, sqlcommon.TupleKeyFilter(tupleKey), 0)
 		return nil, HandleSQLError(err)
 	}
+	s.queryShapes.Record(sqlcommon.QueryMethodReadUserTuple, sqlcommon.TupleKeyFilter(tupleKey), 1)
 
 	if conditionName.String != "" {
 		record.ConditionName = conditionName.String
@@ -477,6 +551,93 @@ func (s *Datastore) ReadUserTuple(ctx context.Context, store string, tupleKey *o
 	return record.AsTuple(), nil
 }
 
+// BeginSnapshot see [storage.SnapshotBeginner].BeginSnapshot. The tuples are read within
+// a read-only transaction, whose snapshot is taken by its first read, as SQLite transactions are serializable.
+// The reads run one at a time.
+func (s *Datastore) BeginSnapshot(ctx context.Context, _ storage.SnapshotOptions) (storage.SnapshotTupleReader, error) {
+	ctx, span := startTrace(ctx, "BeginSnapshot")
+	defer span.End()
+
+	// the transaction ends with Release rather than with the request
+	txn, err := s.db.BeginTx(context.WithoutCancel(ctx), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	snapshot := *s
+	snapshot.stbl = s.stbl.RunWith(txn)
+	return sqlcommon.NewSnapshotTupleReader(&sqlcommon.SnapshotConn{Reader: &snapshot, Txn: txn}, nil, 1), nil
+}
+
+// CountRelation see [storage.RelationCounter].CountRelation.
+func (s *Datastore) CountRelation(ctx context.Context, store, objectType, relation string) (storage.RelationCounts, error) {
+	ctx, span := startTrace(ctx, "CountRelation")
+	defer span.End()
+
+	counts, err := sqlcommon.CountRelation(ctx, s.stbl, store, objectType, relation, userExpr)
+	if err != nil {
+		return storage.RelationCounts{}, HandleSQLError(err)
+	}
+
+	return counts, nil
+}
+
+// CountRelationUsage see [storage.RelationUsageCounter].CountRelationUsage.
+func (s *Datastore) CountRelationUsage(ctx context.Context, store string) ([]storage.RelationUsage, error) {
+	ctx, span := startTrace(ctx, "CountRelationUsage")
+	defer span.End()
+
+	usage, err := sqlcommon.CountRelationUsage(ctx, s.stbl, store)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return usage, nil
+}
+
+// AdviseIndexes see [storage.IndexAdvisor].AdviseIndexes. The query shapes are only sampled
+// if the datastore was created with [sqlcommon.WithQueryShapeSampling].
+func (s *Datastore) AdviseIndexes(ctx context.Context) (storage.IndexAdvice, error) {
+	_, span := startTrace(ctx, "AdviseIndexes")
+	defer span.End()
+
+	return s.queryShapes.Advise("sqlite")
+}
+
+// ReadTupleCounts see [storage.TupleCounter].ReadTupleCounts. The counters are only maintained
+// if the datastore was created with [sqlcommon.WithTupleCounts].
+func (s *Datastore) ReadTupleCounts(ctx context.Context, store string) (storage.TupleCounts, error) {
+	ctx, span := startTrace(ctx, "ReadTupleCounts")
+	defer span.End()
+
+	if !s.tupleCounts {
+		return storage.TupleCounts{}, storage.ErrTupleCountsDisabled
+	}
+
+	counts, err := sqlcommon.ReadTupleCounts(ctx, s.stbl, store)
+	if err != nil {
+		return storage.TupleCounts{}, HandleSQLError(err)
+	}
+
+	return counts, nil
+}
+
+// BackfillTupleCounts see [storage.TupleCounter].BackfillTupleCounts.
+func (s *Datastore) BackfillTupleCounts(ctx context.Context, store string) error {
+	ctx, span := startTrace(ctx, "BackfillTupleCounts")
+	defer span.End()
+
+	err := busyRetry(func() error {
+		// write transactions already run one at a time
+		return sqlcommon.BackfillTupleCounts(ctx, s.db, s.stbl, store, nil)
+	})
+	if err != nil {
+		return HandleSQLError(err)
+	}
+
+	return nil
+}
+
 // ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
 func (s *Datastore) ReadUsersetTuples(
 	ctx context.Context,
@@ -530,7 +691,7 @@ func (s *Datastore) ReadUsersetTuples(
 		return nil, HandleSQLError(err)
 	}
 
-	return NewSQLTupleIterator(rows), nil
+	return s.queryShapes.RecordIterator(sqlcommon.QueryMethodReadUsersetTuples, sqlcommon.ReadUsersetTuplesFilter(filter), NewSQLTupleIterator(rows)), nil
 }
 
 // ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
@@ -579,7 +740,7 @@ func (s *Datastore) ReadStartingWithUser(
 		return nil, HandleSQLError(err)
 	}
 
-	return NewSQLTupleIterator(rows), nil
+	return s.queryShapes.RecordIterator(sqlcommon.QueryMethodReadStartingWithUser, sqlcommon.ReadStartingWithUserFilter(filter), NewSQLTupleIterator(rows)), nil
 }
 
 // MaxTuplesPerWrite see [storage.RelationshipTupleWriter].MaxTuplesPerWrite.
@@ -625,13 +786,18 @@ func (s *Datastore) ReadAuthorizationModel(ctx context.Context, store string, mo
 			"store":                  store,
 			"authorization_model_id": modelID,
 		}).
+		Where(sqlcommon.LiveStoreModels).
 		QueryContext(ctx)
 	if err != nil {
 		return nil, HandleSQLError(err)
 	}
 	defer rows.Close()
 
-	return constructAuthorizationModelFromSQLRows(rows)
+	model, err := constructAuthorizationModelFromSQLRows(rows)
+	if err != nil {
+		return nil, sqlcommon.ModelNotFoundError(ctx, s.stbl, store, err)
+	}
+	return model, nil
 }
 
 // ReadAuthorizationModels see [storage.AuthorizationModelReadBackend].ReadAuthorizationModels.
@@ -712,6 +878,7 @@ func (s *Datastore) FindLatestAuthorizationModel(ctx context.Context, store stri
 		Select("authorization_model_id", "schema_version", "serialized_protobuf").
 		From("authorization_model").
 		Where(sq.Eq{"store": store}).
+		Where(sqlcommon.LiveStoreModels).
 		OrderBy("authorization_model_id desc").
 		Limit(1).
 		QueryContext(ctx)
@@ -720,7 +887,11 @@ func (s *Datastore) FindLatestAuthorizationModel(ctx context.Context, store stri
 	}
 	defer rows.Close()
 
-	return constructAuthorizationModelFromSQLRows(rows)
+	model, err := constructAuthorizationModelFromSQLRows(rows)
+	if err != nil {
+		return nil, sqlcommon.ModelNotFoundError(ctx, s.stbl, store, err)
+	}
+	return model, nil
 }
 
 // MaxTypesPerAuthorizationModel see [storage.TypeDefinitionWriteBackend].MaxTypesPerAuthorizationModel.
@@ -769,11 +940,56 @@ func (s *Datastore) CreateStore(ctx context.Context, store *openfgav1.Store) (*o
 	ctx, span := startTrace(ctx, "CreateStore")
 	defer span.End()
 
+	return createStore(ctx, s.stbl, store)
+}
+
+// CreateStoreWithUniqueName see [storage.UniqueStoreNamesBackend].CreateStoreWithUniqueName.
+// The transactions of the datastore take the write lock of the database when they begin, so two
+// creates of a name cannot both find it available.
+func (s *Datastore) CreateStoreWithUniqueName(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	ctx, span := startTrace(ctx, "CreateStoreWithUniqueName")
+	defer span.End()
+
+	var txn *sql.Tx
+	err := busyRetry(func() error {
+		var err error
+		txn, err = s.db.BeginTx(ctx, nil)
+		return err
+	})
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	stbl := s.stbl.RunWith(txn)
+	if err := sqlcommon.CheckStoreNameAvailable(ctx, stbl, store.GetName()); err != nil {
+		var conflict *storage.StoreNameConflictError
+		if errors.As(err, &conflict) {
+			return nil, err
+		}
+		return nil, HandleSQLError(err)
+	}
+
+	created, err := createStore(ctx, stbl, store)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := txn.Commit(); err != nil {
+		return nil, HandleSQLError(err)
+	}
+	return created, nil
+}
+
+// createStore inserts the store with stbl, which may run in a transaction.
+func createStore(ctx context.Context, stbl sq.StatementBuilderType, store *openfgav1.Store) (*openfgav1.Store, error) {
 	var id, name string
 	var createdAt, updatedAt time.Time
 
 	err := busyRetry(func() error {
-		return s.stbl.
+		return stbl.
 			Insert("store").
 			Columns("id", "name", "created_at", "updated_at").
 			Values(store.GetId(), store.GetName(), sq.Expr("datetime('subsec')"), sq.Expr("datetime('subsec')")).
@@ -825,6 +1041,19 @@ func (s *Datastore) GetStore(ctx context.Context, id string) (*openfgav1.Store,
 	}, nil
 }
 
+// GetStoreByName see [storage.UniqueStoreNamesBackend].GetStoreByName.
+func (s *Datastore) GetStoreByName(ctx context.Context, name string) (*openfgav1.Store, error) {
+	ctx, span := startTrace(ctx, "GetStoreByName")
+	defer span.End()
+
+	store, err := sqlcommon.GetStoreByName(ctx, s.stbl, name)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return store, nil
+}
+
 // ListStores provides a paginated list of all stores present in the storage.
 func (s *Datastore) ListStores(ctx context.Context, options storage.ListStoresOptions) ([]*openfgav1.Store, []byte, error) {
 	ctx, span := startTrace(ctx, "ListStores")
@@ -836,6 +1065,9 @@ func (s *Datastore) ListStores(ctx context.Context, options storage.ListStoresOp
 		Where(sq.Eq{"deleted_at": nil}).
 		OrderBy("id")
 
+	if options.Name != "" {
+		sb = sb.Where(sq.Eq{"name": options.Name})
+	}
 	if options.Pagination.From != "" {
 		token, err := sqlcommon.UnmarshallContToken(options.Pagination.From)
 		if err != nil {
@@ -886,6 +1118,20 @@ func (s *Datastore) ListStores(ctx context.Context, options storage.ListStoresOp
 	return stores, nil, nil
 }
 
+// CountStores see [storage.StoreCounter].CountStores.
+func (s *Datastore) CountStores(ctx context.Context, options storage.CountStoresOptions) (storage.StoreCount, error) {
+	ctx, span := startTrace(ctx, "CountStores")
+	defer span.End()
+
+	// SQLite keeps no estimate of the number of rows of a table
+	count, err := sqlcommon.CountStores(ctx, s.stbl, options, nil)
+	if err != nil {
+		return storage.StoreCount{}, HandleSQLError(err)
+	}
+
+	return count, nil
+}
+
 // DeleteStore removes a store from storage.
 func (s *Datastore) DeleteStore(ctx context.Context, id string) error {
 	ctx, span := startTrace(ctx, "DeleteStore")
@@ -903,6 +1149,42 @@ func (s *Datastore) DeleteStore(ctx context.Context, id string) error {
 	return nil
 }
 
+// ListDeletedStores see [storage.StorePurger].ListDeletedStores.
+func (s *Datastore) ListDeletedStores(ctx context.Context) ([]string, error) {
+	ctx, span := startTrace(ctx, "ListDeletedStores")
+	defer span.End()
+
+	stores, err := sqlcommon.ListDeletedStores(ctx, s.stbl)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return stores, nil
+}
+
+// PurgeStore see [storage.StorePurger].PurgeStore.
+// The rows of each batch are selected by rowid, since DELETE ... LIMIT is a compile-time option of SQLite.
+func (s *Datastore) PurgeStore(ctx context.Context, store string, batchSize int) (int64, error) {
+	ctx, span := startTrace(ctx, "PurgeStore")
+	defer span.End()
+
+	var deleted int64
+	err := busyRetry(func() error {
+		var err error
+		deleted, err = sqlcommon.PurgeStore(ctx, s.stbl, store, batchSize, func(table, store string, limit int) sq.DeleteBuilder {
+			return s.stbl.
+				Delete(table).
+				Where(sq.Expr("rowid IN (SELECT rowid FROM "+table+" WHERE store = ? LIMIT ?)", store, limit))
+		})
+		return err
+	})
+	if err != nil {
+		return deleted, HandleSQLError(err)
+	}
+
+	return deleted, nil
+}
+
 // WriteAssertions see [storage.AssertionsBackend].WriteAssertions.
 func (s *Datastore) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
 	ctx, span := startTrace(ctx, "WriteAssertions")
@@ -930,7 +1212,7 @@ func (s *Datastore) WriteAssertions(ctx context.Context, store, modelID string,
 }
 
 // ReadAssertions see [storage.AssertionsBackend].ReadAssertions.
-func (s *Datastore) ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error) {
+func (s *Datastore) ReadAssertions(ctx context.Context, store, modelID string, options storage.ReadAssertionsOptions) ([]*openfgav1.Assertion, []byte, error) {
 	ctx, span := startTrace(ctx, "ReadAssertions")
 	defer span.End()
 
@@ -946,18 +1228,61 @@ func (s *Datastore) ReadAssertions(ctx context.Context, store, modelID string) (
 		Scan(&marshalledAssertions)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return []*openfgav1.Assertion{}, nil
+			return storage.PageAssertions(nil, options)
 		}
-		return nil, HandleSQLError(err)
+		return nil, nil, HandleSQLError(err)
 	}
 
 	var assertions openfgav1.Assertions
 	err = proto.Unmarshal(marshalledAssertions, &assertions)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return assertions.GetAssertions(), nil
+	return storage.PageAssertions(assertions.GetAssertions(), options)
+}
+
+// WriteModelAlias see [storage.ModelAliasBackend].WriteModelAlias.
+func (s *Datastore) WriteModelAlias(ctx context.Context, store, alias, modelID string) error {
+	ctx, span := startTrace(ctx, "WriteModelAlias")
+	defer span.End()
+
+	err := busyRetry(func() error {
+		_, err := s.stbl.
+			Insert("model_alias").
+			Columns("store", "alias", "authorization_model_id", "updated_at").
+			Values(store, alias, modelID, sq.Expr("datetime('subsec')")).
+			Suffix("ON CONFLICT (store, alias) DO UPDATE SET authorization_model_id = ?, updated_at = datetime('subsec')", modelID).
+			ExecContext(ctx)
+		return err
+	})
+	if err != nil {
+		return HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadModelAlias see [storage.ModelAliasBackend].ReadModelAlias.
+func (s *Datastore) ReadModelAlias(ctx context.Context, store, alias string) (string, error) {
+	ctx, span := startTrace(ctx, "ReadModelAlias")
+	defer span.End()
+
+	var modelID string
+	err := s.stbl.
+		Select("authorization_model_id").
+		From("model_alias").
+		Where(sq.Eq{
+			"store": store,
+			"alias": alias,
+		}).
+		QueryRowContext(ctx).
+		Scan(&modelID)
+	if err != nil {
+		return "", HandleSQLError(err)
+	}
+
+	return modelID, nil
 }
 
 // ReadChanges see [storage.ChangelogBackend].ReadChanges.
@@ -970,6 +1295,10 @@ func (s *Datastore) ReadChanges(
 	ctx, span := startTrace(ctx, "ReadChanges")
 	defer span.End()
 
+	if s.changelog.Mode() == sqlcommon.ChangelogModeDisabled {
+		return nil, nil, storage.ErrChangelogDisabled
+	}
+
 	objectTypeFilter := filter.ObjectType
 	horizonOffset := filter.HorizonOffset
 
@@ -993,6 +1322,13 @@ func (s *Datastore) ReadChanges(
 	if objectTypeFilter != "" {
 		sb = sb.Where(sq.Eq{"object_type": objectTypeFilter})
 	}
+	if !filter.StartTime.IsZero() {
+		startULID, err := sqlcommon.ChangesStartULID(filter.StartTime)
+		if err != nil {
+			return nil, nil, err
+		}
+		sb = sb.Where(sq.GtOrEq{"ulid": startULID})
+	}
 	if options.Pagination.From != "" {
 		token, err := sqlcommon.UnmarshallContToken(options.Pagination.From)
 		if err != nil {
@@ -1005,6 +1341,12 @@ func (s *Datastore) ReadChanges(
 		if options.SortDesc {
 			sb = sb.Where(sq.Lt{"ulid": token.Ulid})
 		} else {
+			if err := sqlcommon.CheckChangesContinuationToken(ctx, s.stbl, store, token.Ulid); err != nil {
+				if errors.Is(err, storage.ErrContinuationTokenExpired) {
+					return nil, nil, err
+				}
+				return nil, nil, HandleSQLError(err)
+			}
 			sb = sb.Where(sq.Gt{"ulid": token.Ulid})
 		}
 	}
@@ -1080,9 +1422,59 @@ func (s *Datastore) ReadChanges(
 	return changes, contToken, nil
 }
 
+// TrimChanges see [storage.ChangelogTrimmer].TrimChanges.
+func (s *Datastore) TrimChanges(ctx context.Context, store string, before time.Time) (int64, error) {
+	ctx, span := startTrace(ctx, "TrimChanges")
+	defer span.End()
+
+	trimmed, err := sqlcommon.TrimChanges(ctx, s.db, s.stbl, store, before)
+	if err != nil {
+		return 0, HandleSQLError(err)
+	}
+
+	return trimmed, nil
+}
+
+// GetLatestChange see [storage.ChangelogStatsReader].GetLatestChange.
+func (s *Datastore) GetLatestChange(ctx context.Context, store string) (time.Time, error) {
+	ctx, span := startTrace(ctx, "GetLatestChange")
+	defer span.End()
+
+	latest, err := sqlcommon.GetLatestChange(ctx, s.stbl, store)
+	if err != nil {
+		return time.Time{}, HandleSQLError(err)
+	}
+
+	return latest, nil
+}
+
+// CountChanges see [storage.ChangelogStatsReader].CountChanges.
+func (s *Datastore) CountChanges(ctx context.Context) (storage.ChangeCount, error) {
+	ctx, span := startTrace(ctx, "CountChanges")
+	defer span.End()
+
+	// SQLite keeps no estimate of the number of rows of a table, but the changelog is a rowid
+	// table whose smallest and largest rowids are read from the ends of its b-tree, so their
+	// range estimates the number of rows without scanning them. It overestimates the rows of
+	// changelogs that were trimmed other than from their oldest changes.
+	estimate := s.stbl.
+		Select("MAX(rowid) - MIN(rowid) + 1").
+		From("changelog")
+	count, err := sqlcommon.CountChanges(ctx, s.stbl, &estimate)
+	if err != nil {
+		return storage.ChangeCount{}, HandleSQLError(err)
+	}
+
+	return count, nil
+}
+
 // IsReady see [sqlcommon.IsReady].
 func (s *Datastore) IsReady(ctx context.Context) (storage.ReadinessStatus, error) {
-	return sqlcommon.IsReady(ctx, s.db)
+	status, err := sqlcommon.IsReady(ctx, s.db)
+	if err != nil {
+		return status, err
+	}
+	return s.poolMonitor.AnnotateReadiness(status), nil
 }
 
 // HandleSQLError processes an SQL error and converts it into a more
@@ -2,6 +2,9 @@ package sqlite
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,7 +22,7 @@ func TestSQLiteDatastore(t *testing.T) {
 	testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
 
 	uri := testDatastore.GetConnectionURI(true)
-	ds, err := New(uri, sqlcommon.NewConfig())
+	ds, err := New(uri, sqlcommon.NewConfig(sqlcommon.WithTupleCounts(true)))
 	require.NoError(t, err)
 	defer ds.Close()
 	test.RunAllTests(t, ds)
@@ -140,7 +143,7 @@ func TestReadEnsureNoOrder(t *testing.T) {
 	}
 }
 
-// TestReadPageEnsureNoOrder asserts that the read page is ordered by ulid.
+// TestReadPageEnsureOrder asserts that the read page is ordered by object, relation and user, not by ulid.
 func TestReadPageEnsureOrder(t *testing.T) {
 	testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
 
@@ -180,7 +183,361 @@ func TestReadPageEnsureOrder(t *testing.T) {
 	require.NoError(t, err)
 
 	require.Len(t, tuples, 2)
-	// We expect that objectID2 will return first because it has a smaller ulid.
-	require.Equal(t, secondTuple, tuples[0].GetKey())
-	require.Equal(t, firstTuple, tuples[1].GetKey())
+	// We expect that objectID1 will return first even though objectID2 has a smaller ulid.
+	require.Equal(t, firstTuple, tuples[0].GetKey())
+	require.Equal(t, secondTuple, tuples[1].GetKey())
+}
+
+func TestSQLiteDatastorePoolSaturation(t *testing.T) {
+	testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+
+	uri := testDatastore.GetConnectionURI(true)
+	ds, err := New(uri, sqlcommon.NewConfig(
+		sqlcommon.WithPoolStatsInterval(10*time.Millisecond),
+		sqlcommon.WithPoolSaturation(1, 30*time.Millisecond),
+	))
+	require.NoError(t, err)
+	defer ds.Close()
+
+	ctx := context.Background()
+
+	// A pool of a single connection that is mostly held forces the other queries to wait.
+	ds.db.SetMaxOpenConns(1)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			conn, err := ds.db.Conn(ctx)
+			if err != nil {
+				continue
+			}
+			time.Sleep(5 * time.Millisecond)
+			_ = conn.Close()
+		}
+	}()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				queryCtx, cancel := context.WithTimeout(ctx, time.Millisecond)
+				_, _ = ds.db.ExecContext(queryCtx, "SELECT 1")
+				cancel()
+			}
+		}()
+	}
+
+	require.Eventually(t, func() bool {
+		status, err := ds.IsReady(ctx)
+		require.NoError(t, err)
+		require.True(t, status.IsReady)
+		return strings.Contains(status.Message, "pool saturated")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	close(done)
+	wg.Wait()
+
+	require.Eventually(t, func() bool {
+		status, err := ds.IsReady(ctx)
+		require.NoError(t, err)
+		return status.Message == ""
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSQLiteDatastoreChangelogModes(t *testing.T) {
+	ctx := context.Background()
+	tk := tuple.NewTupleKey("doc:1", "viewer", "user:anne")
+
+	t.Run("async", func(t *testing.T) {
+		testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+
+		uri := testDatastore.GetConnectionURI(true)
+		ds, err := New(uri, sqlcommon.NewConfig(sqlcommon.WithChangelogMode(sqlcommon.ChangelogModeAsync)))
+		require.NoError(t, err)
+		defer ds.Close()
+
+		err = ds.Write(ctx, "store", nil, []*openfgav1.TupleKey{tk})
+		require.NoError(t, err)
+
+		_, err = ds.ReadUserTuple(ctx, "store", tk, storage.ReadUserTupleOptions{})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			changes, _, err := ds.ReadChanges(ctx, "store", storage.ReadChangesFilter{}, storage.ReadChangesOptions{})
+			return err == nil && len(changes) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("async_flushes_on_close", func(t *testing.T) {
+		testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+
+		uri := testDatastore.GetConnectionURI(true)
+		ds, err := New(uri, sqlcommon.NewConfig(sqlcommon.WithChangelogMode(sqlcommon.ChangelogModeAsync)))
+		require.NoError(t, err)
+
+		err = ds.Write(ctx, "store", nil, []*openfgav1.TupleKey{tk})
+		require.NoError(t, err)
+		ds.changelog.Close()
+
+		changes, _, err := ds.ReadChanges(ctx, "store", storage.ReadChangesFilter{}, storage.ReadChangesOptions{})
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		ds.Close()
+	})
+
+	t.Run("async_entries_follow_issued_tokens", func(t *testing.T) {
+		testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+
+		uri := testDatastore.GetConnectionURI(true)
+		ds, err := New(uri, sqlcommon.NewConfig(sqlcommon.WithChangelogMode(sqlcommon.ChangelogModeAsync)))
+		require.NoError(t, err)
+		defer ds.Close()
+
+		err = ds.Write(ctx, "store", nil, []*openfgav1.TupleKey{tk})
+		require.NoError(t, err)
+
+		var token []byte
+		require.Eventually(t, func() bool {
+			var changes []*openfgav1.TupleChange
+			changes, token, err = ds.ReadChanges(ctx, "store", storage.ReadChangesFilter{}, storage.ReadChangesOptions{})
+			return err == nil && len(changes) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		err = ds.Write(ctx, "store", []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tk)}, nil)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			changes, _, err := ds.ReadChanges(ctx, "store", storage.ReadChangesFilter{}, storage.ReadChangesOptions{
+				Pagination: storage.PaginationOptions{From: string(token)},
+			})
+			return err == nil && len(changes) == 1 && changes[0].GetOperation() == openfgav1.TupleOperation_TUPLE_OPERATION_DELETE
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("async_recovers_entries_left_in_the_outbox", func(t *testing.T) {
+		testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+
+		uri := testDatastore.GetConnectionURI(true)
+		ds, err := New(uri, sqlcommon.NewConfig())
+		require.NoError(t, err)
+
+		_, err = ds.stbl.
+			Insert(sqlcommon.ChangelogOutboxTable).
+			Columns("store", "object_type", "object_id", "relation", "user_object_type", "user_object_id", "user_relation", "operation").
+			Values("store", "doc", "1", "viewer", "user", "anne", "", openfgav1.TupleOperation_TUPLE_OPERATION_WRITE).
+			ExecContext(ctx)
+		require.NoError(t, err)
+		ds.Close()
+
+		ds, err = New(uri, sqlcommon.NewConfig(sqlcommon.WithChangelogMode(sqlcommon.ChangelogModeAsync)))
+		require.NoError(t, err)
+		defer ds.Close()
+
+		require.Eventually(t, func() bool {
+			changes, _, err := ds.ReadChanges(ctx, "store", storage.ReadChangesFilter{}, storage.ReadChangesOptions{})
+			return err == nil && len(changes) == 1 && changes[0].GetTupleKey().GetUser() == "user:anne"
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+
+		uri := testDatastore.GetConnectionURI(true)
+		ds, err := New(uri, sqlcommon.NewConfig(sqlcommon.WithChangelogMode(sqlcommon.ChangelogModeDisabled)))
+		require.NoError(t, err)
+		defer ds.Close()
+
+		err = ds.Write(ctx, "store", nil, []*openfgav1.TupleKey{tk})
+		require.NoError(t, err)
+
+		_, err = ds.ReadUserTuple(ctx, "store", tk, storage.ReadUserTupleOptions{})
+		require.NoError(t, err)
+
+		_, _, err = ds.ReadChanges(ctx, "store", storage.ReadChangesFilter{}, storage.ReadChangesOptions{})
+		require.ErrorIs(t, err, storage.ErrChangelogDisabled)
+
+		var count int
+		err = ds.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM changelog").Scan(&count)
+		require.NoError(t, err)
+		require.Zero(t, count)
+	})
+}
+
+func TestSQLiteDatastoreWriteHook(t *testing.T) {
+	ctx := context.Background()
+	tk := tuple.NewTupleKey("doc:1", "viewer", "user:anne")
+
+	t.Run("commits_with_the_write", func(t *testing.T) {
+		testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+
+		hook := storage.WriteHookFunc(func(ctx context.Context, tx storage.WriteTx, store string, deletes storage.Deletes, writes storage.Writes) error {
+			_, err := tx.ExecContext(ctx, "INSERT INTO outbox (store, writes) VALUES (?, ?)", store, len(writes))
+			return err
+		})
+
+		uri := testDatastore.GetConnectionURI(true)
+		ds, err := New(uri, sqlcommon.NewConfig(sqlcommon.WithWriteHook(hook)))
+		require.NoError(t, err)
+		defer ds.Close()
+
+		_, err = ds.db.ExecContext(ctx, "CREATE TABLE outbox (store TEXT NOT NULL, writes INTEGER NOT NULL)")
+		require.NoError(t, err)
+
+		err = ds.Write(ctx, "store", nil, []*openfgav1.TupleKey{tk})
+		require.NoError(t, err)
+
+		var store string
+		var writes int
+		err = ds.db.QueryRowContext(ctx, "SELECT store, writes FROM outbox").Scan(&store, &writes)
+		require.NoError(t, err)
+		require.Equal(t, "store", store)
+		require.Equal(t, 1, writes)
+	})
+
+	t.Run("error_rolls_back_the_write", func(t *testing.T) {
+		testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+
+		hookErr := errors.New("outbox unavailable")
+		hook := storage.WriteHookFunc(func(context.Context, storage.WriteTx, string, storage.Deletes, storage.Writes) error {
+			return hookErr
+		})
+
+		uri := testDatastore.GetConnectionURI(true)
+		ds, err := New(uri, sqlcommon.NewConfig(sqlcommon.WithWriteHook(hook)))
+		require.NoError(t, err)
+		defer ds.Close()
+
+		err = ds.Write(ctx, "store", nil, []*openfgav1.TupleKey{tk})
+		require.ErrorIs(t, err, hookErr)
+
+		_, err = ds.ReadUserTuple(ctx, "store", tk, storage.ReadUserTupleOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		changes, _, err := ds.ReadChanges(ctx, "store", storage.ReadChangesFilter{}, storage.ReadChangesOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+		require.Empty(t, changes)
+	})
+}
+
+func TestSQLiteDatastoreQueryShapeSampling(t *testing.T) {
+	ctx := context.Background()
+	tk := tuple.NewTupleKey("doc:1", "viewer", "user:anne")
+
+	t.Run("disabled_by_default", func(t *testing.T) {
+		testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+
+		uri := testDatastore.GetConnectionURI(true)
+		ds, err := New(uri, sqlcommon.NewConfig())
+		require.NoError(t, err)
+		defer ds.Close()
+
+		_, err = ds.AdviseIndexes(ctx)
+		require.ErrorIs(t, err, storage.ErrQueryShapeSamplingDisabled)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+
+		uri := testDatastore.GetConnectionURI(true)
+		ds, err := New(uri, sqlcommon.NewConfig(sqlcommon.WithQueryShapeSampling(true)))
+		require.NoError(t, err)
+		defer ds.Close()
+
+		err = ds.Write(ctx, "store", nil, []*openfgav1.TupleKey{tk})
+		require.NoError(t, err)
+
+		_, err = ds.ReadUserTuple(ctx, "store", tk, storage.ReadUserTupleOptions{})
+		require.NoError(t, err)
+
+		for i := 0; i < sqlcommon.MinIndexAdviceQueries; i++ {
+			iter, err := ds.ReadStartingWithUser(ctx, "store", storage.ReadStartingWithUserFilter{
+				ObjectType: "doc",
+				Relation:   "viewer",
+				UserFilter: []*openfgav1.ObjectRelation{{Object: "user:anne"}},
+			}, storage.ReadStartingWithUserOptions{})
+			require.NoError(t, err)
+			_, err = iter.Next(ctx)
+			require.NoError(t, err)
+			iter.Stop()
+		}
+
+		advice, err := ds.AdviseIndexes(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "sqlite", advice.Datastore)
+		require.Equal(t, []storage.QueryShape{
+			{Method: "ReadStartingWithUser", Columns: []string{"object_type", "relation", "user"}, Queries: sqlcommon.MinIndexAdviceQueries, Rows: sqlcommon.MinIndexAdviceQueries},
+			{Method: "ReadUserTuple", Columns: []string{"object_type", "object_id", "relation", "user"}, Queries: 1, Rows: 1},
+		}, advice.Shapes)
+
+		require.Len(t, advice.Recommendations, 1)
+		require.Equal(t, "idx_reverse_lookup_user", advice.Recommendations[0].Name)
+		require.True(t, advice.Recommendations[0].Default)
+
+		// the DDL is valid, and the index already exists
+		_, err = ds.db.ExecContext(ctx, advice.Recommendations[0].DDL)
+		require.NoError(t, err)
+	})
+}
+
+func TestSQLiteDatastoreTupleCountsDisabledByDefault(t *testing.T) {
+	ctx := context.Background()
+	testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+
+	uri := testDatastore.GetConnectionURI(true)
+	ds, err := New(uri, sqlcommon.NewConfig())
+	require.NoError(t, err)
+	defer ds.Close()
+
+	err = ds.Write(ctx, "store", nil, []*openfgav1.TupleKey{tuple.NewTupleKey("doc:1", "viewer", "user:anne")})
+	require.NoError(t, err)
+
+	_, err = ds.ReadTupleCounts(ctx, "store")
+	require.ErrorIs(t, err, storage.ErrTupleCountsDisabled)
+
+	var counters int
+	err = ds.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tuple_count").Scan(&counters)
+	require.NoError(t, err)
+	require.Zero(t, counters)
+}
+
+func TestSQLiteDatastoreCountChangesIsEstimated(t *testing.T) {
+	ctx := context.Background()
+	testDatastore := storagefixtures.RunDatastoreTestContainer(t, "sqlite")
+
+	uri := testDatastore.GetConnectionURI(true)
+	ds, err := New(uri, sqlcommon.NewConfig())
+	require.NoError(t, err)
+	defer ds.Close()
+
+	for _, object := range []string{"doc:1", "doc:2", "doc:3"} {
+		err = ds.Write(ctx, "store", nil, []*openfgav1.TupleKey{tuple.NewTupleKey(object, "viewer", "user:anne")})
+		require.NoError(t, err)
+	}
+
+	count, err := ds.CountChanges(ctx)
+	require.NoError(t, err)
+	require.Equal(t, storage.ChangeCount{Count: 3, Estimated: true}, count)
+}
+
+func BenchmarkSQLiteDatastoreReadUsersetTuples(b *testing.B) {
+	testDatastore := storagefixtures.RunDatastoreTestContainer(b, "sqlite")
+
+	uri := testDatastore.GetConnectionURI(true)
+	ds, err := New(uri, sqlcommon.NewConfig())
+	require.NoError(b, err)
+	defer ds.Close()
+	test.ReadUsersetTuplesBenchmark(b, ds)
 }
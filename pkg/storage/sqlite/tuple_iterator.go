@@ -3,7 +3,6 @@ package sqlite
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"sync"
 
@@ -12,7 +11,6 @@ import (
 	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/openfga/openfga/pkg/storage"
-	"github.com/openfga/openfga/pkg/storage/sqlcommon"
 )
 
 // SQLTupleIterator is a struct that implements the storage.TupleIterator
@@ -189,7 +187,7 @@ func (t *SQLTupleIterator) ToArray(
 		return nil, nil, err
 	}
 
-	contToken, err := json.Marshal(sqlcommon.NewContToken(tupleRecord.Ulid, ""))
+	contToken, err := storage.NewReadCursor(tupleRecord).Marshal()
 	if err != nil {
 		return nil, nil, err
 	}
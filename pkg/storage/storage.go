@@ -4,9 +4,14 @@ package storage
 
 import (
 	"context"
+	"database/sql"
+	"strconv"
+	"strings"
 	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/tuple"
 )
 
 type ctxKey string
@@ -84,6 +89,8 @@ type ReadAuthorizationModelsOptions struct {
 // be used with the ListStores method.
 type ListStoresOptions struct {
 	Pagination PaginationOptions
+	// Name, if not empty, restricts the stores to those with exactly this name.
+	Name string
 }
 
 // ReadChangesOptions represents the options that can
@@ -98,6 +105,64 @@ type ReadChangesOptions struct {
 type ReadPageOptions struct {
 	Pagination  PaginationOptions
 	Consistency ConsistencyOptions
+	Condition   ReadConditionFilter
+}
+
+// ReadAssertionsOptions represents the options that can
+// be used with the ReadAssertions method. A zero page size returns every
+// assertion that matches the filter.
+type ReadAssertionsOptions struct {
+	Filter     ReadAssertionsFilter
+	Pagination PaginationOptions
+}
+
+// ReadAssertionsFilter narrows the assertions returned by ReadAssertions by the
+// object type and relation of their tuple key. The zero value matches every assertion.
+type ReadAssertionsFilter struct {
+	ObjectType string
+	Relation   string
+}
+
+// ReadConditionFilter narrows the tuples returned by ReadPage by their condition.
+// The zero value matches every tuple.
+type ReadConditionFilter struct {
+	// Name, when not empty, only matches tuples with the condition of that name.
+	Name string
+
+	// HasCondition, when not nil, only matches tuples with a condition (true)
+	// or without one (false).
+	HasCondition *bool
+}
+
+// IsEmpty returns true if the filter matches every tuple.
+func (f ReadConditionFilter) IsEmpty() bool {
+	return f.Name == "" && f.HasCondition == nil
+}
+
+// Matches returns true if a tuple with the given condition name passes the filter.
+// Tuples without a condition have an empty condition name.
+func (f ReadConditionFilter) Matches(conditionName string) bool {
+	if f.Name != "" && f.Name != conditionName {
+		return false
+	}
+
+	if f.HasCondition != nil && *f.HasCondition != (conditionName != "") {
+		return false
+	}
+
+	return true
+}
+
+// String returns a canonical representation of the filter.
+func (f ReadConditionFilter) String() string {
+	var parts []string
+	if f.Name != "" {
+		parts = append(parts, "condition_name="+f.Name)
+	}
+	if f.HasCondition != nil {
+		parts = append(parts, "has_condition="+strconv.FormatBool(*f.HasCondition))
+	}
+	return strings.Join(parts, "&")
 }
 
 // ConsistencyOptions represents the options that can
@@ -136,6 +201,33 @@ type Writes = []*openfgav1.TupleKey
 // Deletes is a typesafe alias for Delete arguments.
 type Deletes = []*openfgav1.TupleKeyWithoutCondition
 
+// WriteTx is the part of the transaction of a Write that a [WriteHook] can use.
+// The SQL datastores pass the [*sql.Tx] of the Write.
+type WriteTx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// WriteHook lets embedders take part in the transaction of every tuple Write, e.g. to
+// record an event in a transactional outbox table. A hook runs while the transaction
+// holds its locks (or, in the memory datastore, while the datastore is locked against
+// other writes), so it must be fast.
+type WriteHook interface {
+	// BeforeCommit is called after the tuples are written and before the transaction
+	// commits. Returning an error rolls back the whole Write, which then fails with
+	// that error. The memory datastore has no transaction and passes a nil tx.
+	BeforeCommit(ctx context.Context, tx WriteTx, store string, deletes Deletes, writes Writes) error
+}
+
+// WriteHookFunc is an adapter to use an ordinary function as a [WriteHook].
+type WriteHookFunc func(ctx context.Context, tx WriteTx, store string, deletes Deletes, writes Writes) error
+
+// BeforeCommit calls f(ctx, tx, store, deletes, writes).
+func (f WriteHookFunc) BeforeCommit(ctx context.Context, tx WriteTx, store string, deletes Deletes, writes Writes) error {
+	return f(ctx, tx, store, deletes, writes)
+}
+
 // A TupleBackend provides a read/write interface for managing tuples.
 type TupleBackend interface {
 	RelationshipTupleReader
@@ -157,7 +249,7 @@ type RelationshipTupleReader interface {
 	// ReadPage functions similarly to Read but includes support for pagination. It takes
 	// mandatory ReadPageOptions options. PageSize will always be greater than zero.
 	// It returns a slice of tuples along with a continuation token. This token can be used for retrieving subsequent pages of data.
-	// There is NO guarantee on the order of the tuples in one page.
+	// Tuples are returned in the order defined by [CompareReadOrder], and the continuation token is a [ReadCursor].
 	ReadPage(
 		ctx context.Context,
 		store string,
@@ -252,14 +344,15 @@ type ReadUsersetTuplesFilter struct {
 // AuthorizationModelReadBackend provides a read interface for managing type definitions.
 type AuthorizationModelReadBackend interface {
 	// ReadAuthorizationModel reads the model corresponding to store and model ID.
-	// If it's not found, it must return ErrNotFound.
+	// If it's not found, it must return ErrNotFound, or ErrStoreDeleted if the store was deleted.
 	ReadAuthorizationModel(ctx context.Context, store string, id string) (*openfgav1.AuthorizationModel, error)
 
 	// ReadAuthorizationModels reads all models for the supplied store and returns them in descending order of ULID (from newest to oldest).
 	ReadAuthorizationModels(ctx context.Context, store string, options ReadAuthorizationModelsOptions) ([]*openfgav1.AuthorizationModel, []byte, error)
 
 	// FindLatestAuthorizationModel returns the last model for the store.
-	// If none were ever written, it must return ErrNotFound.
+	// If none were ever written, it must return ErrNotFound, or ErrStoreDeleted if the store was
+	// deleted.
 	FindLatestAuthorizationModel(ctx context.Context, store string) (*openfgav1.AuthorizationModel, error)
 }
 
@@ -292,14 +385,20 @@ type AssertionsBackend interface {
 	// WriteAssertions overwrites the assertions for a store and modelID.
 	WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error
 
-	// ReadAssertions returns the assertions for a store and modelID.
-	// If no assertions were ever written, it must return an empty list.
-	ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error)
+	// ReadAssertions returns the assertions for a store and modelID that match the filter
+	// of the options, in the order they were written, and the continuation token of the
+	// next page (empty on the last page). If no assertions were ever written, it must
+	// return an empty list.
+	ReadAssertions(ctx context.Context, store, modelID string, options ReadAssertionsOptions) ([]*openfgav1.Assertion, []byte, error)
 }
 
 type ReadChangesFilter struct {
 	ObjectType    string
 	HorizonOffset time.Duration
+	// StartTime, if not zero, restricts the changes to those that occurred at or after it. The
+	// changes are sought by the millisecond timestamp of their ULIDs, so that reading from a
+	// start time does not scan the older changes.
+	StartTime time.Time
 }
 
 // ChangelogBackend is an interface for interacting with and managing changelogs.
@@ -314,6 +413,266 @@ type ChangelogBackend interface {
 	ReadChanges(ctx context.Context, store string, filter ReadChangesFilter, options ReadChangesOptions) ([]*openfgav1.TupleChange, []byte, error)
 }
 
+// ChangelogTrimmer is implemented by datastores that can delete the old changes of a store, e.g.
+// to bound the size of the changelog. Once changes are trimmed, ReadChanges returns
+// ErrContinuationTokenExpired for the continuation tokens of ascending reads that come before a
+// trimmed change, instead of skipping the trimmed changes. The tokens of consumers that read
+// every trimmed change keep working. It is optional and not part of [OpenFGADatastore].
+type ChangelogTrimmer interface {
+	// TrimChanges deletes the changes of the store that occurred before the given time, and
+	// returns the number of changes deleted.
+	TrimChanges(ctx context.Context, store string, before time.Time) (int64, error)
+}
+
+// ChangeCount is the number of changes in the changelog of a datastore.
+type ChangeCount struct {
+	Count int64
+	// Estimated is true if Count is an estimate, e.g. read from the statistics of the database.
+	Estimated bool
+}
+
+// ChangelogStatsReader is implemented by datastores that can describe their changelog without
+// reading it, e.g. to export metrics periodically. It is optional and not part of [OpenFGADatastore].
+type ChangelogStatsReader interface {
+	// GetLatestChange returns the time of the newest change of the store. If the store has no
+	// changes, it must return ErrNotFound.
+	GetLatestChange(ctx context.Context, store string) (time.Time, error)
+
+	// CountChanges returns the number of changes of all stores, which may be an estimate.
+	CountChanges(ctx context.Context) (ChangeCount, error)
+}
+
+// RelationCounts are the exact counts of the tuples of one object type and relation in a store.
+type RelationCounts struct {
+	Tuples          int64
+	DistinctObjects int64
+	DistinctUsers   int64
+}
+
+// RelationCounter is implemented by datastores that can count the tuples of a relation
+// without reading them, e.g. with aggregate queries. It is optional and not part of [OpenFGADatastore].
+type RelationCounter interface {
+	// CountRelation returns the counts of the tuples whose object is of the given type and
+	// whose relation is the given relation.
+	CountRelation(ctx context.Context, store, objectType, relation string) (RelationCounts, error)
+}
+
+// RelationUsage is the number of tuples of one object type and relation in a store.
+type RelationUsage struct {
+	ObjectType string
+	Relation   string
+	Tuples     int64
+}
+
+// RelationUsageCounter is implemented by datastores that can count the tuples of every object
+// type and relation of a store at once, e.g. with a grouped aggregate query. It is optional and
+// not part of [OpenFGADatastore].
+type RelationUsageCounter interface {
+	// CountRelationUsage returns the counts of the object types and relations that have tuples in
+	// the store, sorted by object type and relation. Relations without tuples are omitted.
+	CountRelationUsage(ctx context.Context, store string) ([]RelationUsage, error)
+}
+
+// QueryShape is the number of queries of a datastore method that filtered the tuples on the same
+// columns, and the number of rows they returned, which measures the selectivity of the filter.
+type QueryShape struct {
+	// Method is the datastore method, e.g. "ReadStartingWithUser".
+	Method string `json:"method"`
+	// Columns are the columns that the queries filtered on besides the store, e.g. "object_type",
+	// "relation" and "user".
+	Columns []string `json:"columns"`
+	Queries int64    `json:"queries"`
+	Rows    int64    `json:"rows"`
+}
+
+// IndexRecommendation is an index of the tuple table that a workload calls for.
+type IndexRecommendation struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	// DDL is the statement that creates the index in the datastore.
+	DDL string `json:"ddl"`
+	// Reason describes the queries that the index serves.
+	Reason string `json:"reason"`
+	// Queries is the number of sampled queries that the index serves, and Share their share of
+	// all the sampled queries.
+	Queries int64   `json:"queries"`
+	Share   float64 `json:"share"`
+	// Default is true if the migrations of the datastore create the index, in which case it is
+	// only missing if it was dropped.
+	Default bool `json:"default"`
+}
+
+// IndexAdvice is the distribution of the query shapes of a datastore, and the indexes it calls for.
+type IndexAdvice struct {
+	Datastore string `json:"datastore"`
+	// SampledSince is the time at which the sampling of the query shapes started.
+	SampledSince    time.Time             `json:"sampled_since"`
+	Shapes          []QueryShape          `json:"shapes"`
+	Recommendations []IndexRecommendation `json:"recommendations"`
+}
+
+// IndexAdvisor is implemented by datastores that can sample the shapes of their tuple queries and
+// recommend indexes for them. It is optional and not part of [OpenFGADatastore].
+type IndexAdvisor interface {
+	// AdviseIndexes returns the query shapes sampled so far and the indexes they call for. If the
+	// sampling is disabled, it must return ErrQueryShapeSamplingDisabled.
+	AdviseIndexes(ctx context.Context) (IndexAdvice, error)
+}
+
+// SnapshotTupleReader reads the tuples of a datastore from one snapshot, so that its reads
+// agree with each other whatever is written meanwhile. See [SnapshotBeginner].
+type SnapshotTupleReader interface {
+	RelationshipTupleReader
+
+	// Release ends the snapshot, e.g. the transaction that holds it. The reader must not be used
+	// afterward. Release can be called more than once.
+	Release()
+}
+
+// SnapshotOptions represents the options that can be used with the BeginSnapshot method.
+type SnapshotOptions struct {
+	// MaxConcurrentReads bounds the number of reads of the snapshot that run concurrently, e.g. the
+	// number of transactions that read it. Datastores that cannot share a snapshot between
+	// transactions run its reads one at a time. 0 means 1.
+	MaxConcurrentReads uint32
+}
+
+// SnapshotBeginner is implemented by datastores that can read tuples from a consistent snapshot,
+// e.g. within a read-only repeatable-read transaction. It is optional and not part of
+// [OpenFGADatastore].
+type SnapshotBeginner interface {
+	// BeginSnapshot returns a reader of the tuples as they are when it begins, or at the latest
+	// when its first read runs. It holds resources, e.g. connections, until it is released.
+	BeginSnapshot(ctx context.Context, options SnapshotOptions) (SnapshotTupleReader, error)
+}
+
+// MembershipIndex is a secondary index of the members of some relations, e.g. a flattened
+// membership table of `group#member`, that Check consults before resolving them from the
+// tuples. It answers from the tuples written to the indexed relations, following the usersets
+// of indexed relations, so Check only consults it for relations that the model defines as
+// directly related user types without conditions. It may lag behind the writes, so Check does
+// not consult it for HIGHER_CONSISTENCY requests.
+type MembershipIndex interface {
+	// Relations returns the indexed relations, as `objectType#relation`.
+	Relations() []string
+
+	// Lookup reports whether the user, an object or a wildcard, is a member of the relation of
+	// the object in the store, directly, via its type wildcard or via the usersets of indexed
+	// relations. ok is false if the index cannot answer, e.g. it has not caught up with the
+	// store yet or a tuple on the way has a condition, and Check then resolves it as usual.
+	Lookup(ctx context.Context, store, object, relation, user string) (member, ok bool)
+
+	// Close stops the index. It must not be used afterward.
+	Close()
+}
+
+// TupleCounts are the number of tuples of a store, in total and by object type.
+type TupleCounts struct {
+	Total        int64
+	ByObjectType map[string]int64
+}
+
+// TupleCounter is implemented by datastores that can maintain counters of the tuples of each
+// store and object type, updated in the transaction of every Write, so that they can be read
+// without counting the tuples. It is optional and not part of [OpenFGADatastore]. Since every
+// Write then updates the counter rows of its object types, which serializes the concurrent
+// writes of an object type, the SQL datastores only maintain them when created with
+// sqlcommon.WithTupleCounts, e.g. to enforce tuple quotas.
+type TupleCounter interface {
+	// ReadTupleCounts returns the counters of the store. Object types without tuples may be
+	// omitted. The counters of the tuples written before the datastore maintained them are
+	// missing until BackfillTupleCounts is called. It returns [ErrTupleCountsDisabled] if the
+	// datastore does not maintain the counters.
+	ReadTupleCounts(ctx context.Context, store string) (TupleCounts, error)
+
+	// BackfillTupleCounts recomputes the counters of the store from its tuples. The writes of
+	// the tuples of the store wait until it is done, so that none is counted twice or missed.
+	BackfillTupleCounts(ctx context.Context, store string) error
+}
+
+// TupleCountDeltas returns the change of the number of tuples of each object type made by a
+// Write of the given deletes and writes, omitting the object types whose number does not change.
+func TupleCountDeltas(deletes Deletes, writes Writes) map[string]int64 {
+	deltas := make(map[string]int64)
+	for _, tk := range deletes {
+		deltas[tuple.GetType(tk.GetObject())]--
+	}
+	for _, tk := range writes {
+		deltas[tuple.GetType(tk.GetObject())]++
+	}
+	for objectType, delta := range deltas {
+		if delta == 0 {
+			delete(deltas, objectType)
+		}
+	}
+	return deltas
+}
+
+// CountStoresOptions represents the options that can be used with the CountStores method.
+type CountStoresOptions struct {
+	// Exact requires the exact number of stores. Otherwise, a datastore may return an estimate
+	// that is cheaper to compute, e.g. read from the statistics of its tables.
+	Exact bool
+	// Name, if not empty, restricts the count to the stores with exactly this name, like
+	// [ListStoresOptions].Name. The count of a name is always exact.
+	Name string
+}
+
+// StoreCount is the number of stores of a datastore.
+type StoreCount struct {
+	Count int64
+	// Estimated is true if Count is an estimate, which may lag behind the stores created or
+	// deleted recently.
+	Estimated bool
+}
+
+// StoreCounter is implemented by datastores that can count their stores without listing them.
+// It is optional and not part of [OpenFGADatastore].
+type StoreCounter interface {
+	// CountStores returns the number of stores that were not deleted.
+	CountStores(ctx context.Context, options CountStoresOptions) (StoreCount, error)
+}
+
+// StorePurger is implemented by datastores that keep the data of the stores deleted with
+// DeleteStore, and can delete it in bounded batches, e.g. to not hold a long transaction or
+// write-ahead log spike for large stores. It is optional and not part of [OpenFGADatastore].
+type StorePurger interface {
+	// ListDeletedStores returns the IDs of the stores that were deleted and are not purged yet.
+	ListDeletedStores(ctx context.Context) ([]string, error)
+
+	// PurgeStore deletes at most batchSize rows of the tuples, changelog, authorization models,
+	// assertions and model aliases of a deleted store, and returns the number of rows deleted.
+	// Once none are left, it deletes the store itself and returns 0. Purging is resumable:
+	// every call deletes some of the remaining rows. If the store does not exist or is not
+	// deleted, it must return ErrNotFound.
+	PurgeStore(ctx context.Context, store string, batchSize int) (int64, error)
+}
+
+// UniqueStoreNamesBackend is implemented by datastores that can look up the stores by name and
+// create stores with a name that no other store has. It is optional and not part of
+// [OpenFGADatastore].
+type UniqueStoreNamesBackend interface {
+	// GetStoreByName returns the oldest store with exactly the name that was not deleted. If
+	// there is none, it must return ErrNotFound.
+	GetStoreByName(ctx context.Context, name string) (*openfgav1.Store, error)
+
+	// CreateStoreWithUniqueName creates the store like CreateStore, unless a store that was not
+	// deleted already has its name, in which case it must return a [*StoreNameConflictError].
+	// Concurrent calls for the same name must create at most one store.
+	CreateStoreWithUniqueName(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error)
+}
+
+// ModelAliasBackend is implemented by datastores that store named aliases of the
+// authorization models of a store, e.g. "production". It is optional and not part of [OpenFGADatastore].
+type ModelAliasBackend interface {
+	// WriteModelAlias points the alias at the given authorization model, creating or replacing it.
+	WriteModelAlias(ctx context.Context, store, alias, modelID string) error
+
+	// ReadModelAlias returns the ID of the authorization model the alias points at.
+	// If the alias does not exist, it must return ErrNotFound.
+	ReadModelAlias(ctx context.Context, store, alias string) (string, error)
+}
+
 // OpenFGADatastore is an interface that defines a set of methods for interacting
 // with and managing data in an OpenFGA (Fine-Grained Authorization) system.
 type OpenFGADatastore interface {
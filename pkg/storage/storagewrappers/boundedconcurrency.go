@@ -2,6 +2,7 @@ package storagewrappers
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
@@ -11,11 +12,18 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/dispatch"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/telemetry"
 )
 
-const timeWaitingSpanAttribute = "time_waiting"
+const (
+	timeWaitingSpanAttribute = "time_waiting"
+
+	// lowPriorityYieldInterval is how long a low priority read waits before trying again to acquire
+	// the limiter while normal priority reads are waiting for it.
+	lowPriorityYieldInterval = time.Millisecond
+)
 
 var _ storage.RelationshipTupleReader = (*BoundedConcurrencyTupleReader)(nil)
 
@@ -28,17 +36,22 @@ var (
 		NativeHistogramBucketFactor:     1.1,
 		NativeHistogramMaxBucketNumber:  100,
 		NativeHistogramMinResetDuration: time.Hour,
-	}, []string{"grpc_service", "grpc_method"})
+	}, []string{"grpc_service", "grpc_method", "priority"})
 )
 
 type BoundedConcurrencyTupleReader struct {
 	storage.RelationshipTupleReader
 	limiter chan struct{}
+
+	// normalPriorityWaiters is the number of normal priority reads waiting for the limiter.
+	// Low priority reads only acquire the limiter when there are none.
+	normalPriorityWaiters atomic.Int64
 }
 
 // NewBoundedConcurrencyTupleReader returns a wrapper over a datastore that makes sure that there are, at most,
-// "concurrency" concurrent calls to Read, ReadUserTuple and ReadUsersetTuples.
+// "concurrency" concurrent calls to Read, ReadPage, ReadUserTuple, ReadUsersetTuples and ReadStartingWithUser.
 // Consumers can then rest assured that one client will not hoard all the database connections available.
+// Reads whose context has dispatch.RequestPriorityLow acquire a slot only after all waiting normal priority reads.
 func NewBoundedConcurrencyTupleReader(wrapped storage.RelationshipTupleReader, concurrency uint32) *BoundedConcurrencyTupleReader {
 	return &BoundedConcurrencyTupleReader{
 		RelationshipTupleReader: wrapped,
@@ -79,6 +92,25 @@ func (b *BoundedConcurrencyTupleReader) Read(ctx context.Context, store string,
 	return b.RelationshipTupleReader.Read(ctx, store, tupleKey, options)
 }
 
+// ReadPage the set of tuples associated with `store` and `TupleKey`, which may be nil or partially filled.
+func (b *BoundedConcurrencyTupleReader) ReadPage(
+	ctx context.Context,
+	store string,
+	tupleKey *openfgav1.TupleKey,
+	options storage.ReadPageOptions,
+) ([]*openfgav1.Tuple, []byte, error) {
+	err := b.waitForLimiter(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer func() {
+		<-b.limiter
+	}()
+
+	return b.RelationshipTupleReader.ReadPage(ctx, store, tupleKey, options)
+}
+
 // ReadUsersetTuples returns all userset tuples for a specified object and relation.
 func (b *BoundedConcurrencyTupleReader) ReadUsersetTuples(
 	ctx context.Context,
@@ -121,6 +153,7 @@ func (b *BoundedConcurrencyTupleReader) ReadStartingWithUser(
 // waitForLimiter respects context errors and returns an error only if it couldn't send an item to the channel.
 func (b *BoundedConcurrencyTupleReader) waitForLimiter(ctx context.Context) error {
 	start := time.Now()
+	priority := dispatch.RequestPriorityFromContext(ctx)
 	defer func() {
 		timeWaiting := time.Since(start).Milliseconds()
 
@@ -128,19 +161,37 @@ func (b *BoundedConcurrencyTupleReader) waitForLimiter(ctx context.Context) erro
 		boundedReadDelayMsHistogram.WithLabelValues(
 			rpcInfo.Service,
 			rpcInfo.Method,
+			priority.String(),
 		).Observe(float64(timeWaiting))
 
 		span := trace.SpanFromContext(ctx)
 		span.SetAttributes(attribute.Int64(timeWaitingSpanAttribute, timeWaiting))
 	}()
 
-	select {
-	// Note: if both cases can proceed, one will be selected at random
-	case <-ctx.Done():
-		return ctx.Err()
-	case b.limiter <- struct{}{}:
-		break
+	if priority != dispatch.RequestPriorityLow {
+		b.normalPriorityWaiters.Add(1)
+		defer b.normalPriorityWaiters.Add(-1)
 	}
 
-	return nil
+	for {
+		select {
+		// Note: if both cases can proceed, one will be selected at random
+		case <-ctx.Done():
+			return ctx.Err()
+		case b.limiter <- struct{}{}:
+		}
+
+		if priority != dispatch.RequestPriorityLow || b.normalPriorityWaiters.Load() == 0 {
+			return nil
+		}
+
+		// give the slot back to the normal priority reads that are waiting
+		<-b.limiter
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lowPriorityYieldInterval):
+		}
+	}
 }
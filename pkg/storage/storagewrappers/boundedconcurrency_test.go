@@ -13,6 +13,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/openfga/openfga/internal/mocks"
+	"github.com/openfga/openfga/pkg/dispatch"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/memory"
 	"github.com/openfga/openfga/pkg/tuple"
@@ -33,8 +34,8 @@ func TestBoundedConcurrencyWrapper(t *testing.T) {
 	// Create a limited tuple reader that allows 1 concurrent read a time.
 	limitedTupleReader := NewBoundedConcurrencyTupleReader(slowBackend, 1)
 
-	// Do reads from 4 goroutines - each should be run serially. Should be >4 seconds.
-	const numRoutine = 4
+	// Do reads from 5 goroutines - each should be run serially. Should be >5 seconds.
+	const numRoutine = 5
 
 	var wg errgroup.Group
 
@@ -58,6 +59,11 @@ func TestBoundedConcurrencyWrapper(t *testing.T) {
 		return err
 	})
 
+	wg.Go(func() error {
+		_, _, err := limitedTupleReader.ReadPage(context.Background(), store, nil, storage.ReadPageOptions{})
+		return err
+	})
+
 	wg.Go(func() error {
 		_, err := limitedTupleReader.ReadStartingWithUser(
 			context.Background(),
@@ -80,6 +86,44 @@ func TestBoundedConcurrencyWrapper(t *testing.T) {
 	require.GreaterOrEqual(t, end.Sub(start), numRoutine*time.Second)
 }
 
+func TestBoundedConcurrencyWrapper_Low_Priority_Acquires_After_Normal_Priority(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	store := ulid.Make().String()
+	slowBackend := mocks.NewMockSlowDataStorage(memory.New(), 200*time.Millisecond)
+
+	limitedTupleReader := NewBoundedConcurrencyTupleReader(slowBackend, 1)
+	lowPriorityCtx := dispatch.ContextWithRequestPriority(context.Background(), dispatch.RequestPriorityLow)
+
+	completed := make(chan string, 3)
+	read := func(ctx context.Context, name string) func() error {
+		return func() error {
+			_, err := limitedTupleReader.Read(ctx, store, nil, storage.ReadOptions{})
+			completed <- name
+			return err
+		}
+	}
+
+	var wg errgroup.Group
+
+	// holds the only slot while the other reads start waiting
+	wg.Go(read(context.Background(), "first"))
+	time.Sleep(50 * time.Millisecond)
+	wg.Go(read(lowPriorityCtx, "low"))
+	time.Sleep(50 * time.Millisecond)
+	wg.Go(read(context.Background(), "normal"))
+
+	require.NoError(t, wg.Wait())
+	close(completed)
+
+	var order []string
+	for name := range completed {
+		order = append(order, name)
+	}
+	require.Equal(t, []string{"first", "normal", "low"}, order)
+}
+
 func TestBoundedConcurrencyWrapper_Exits_Early_If_Context_Error(t *testing.T) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
@@ -8,17 +8,30 @@ import (
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"golang.org/x/sync/singleflight"
 
+	"github.com/openfga/openfga/pkg/requestcontext"
 	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
 )
 
 const ttl = time.Hour * 168
 
-var _ storage.OpenFGADatastore = (*cachedOpenFGADatastore)(nil)
+var (
+	_ storage.OpenFGADatastore = (*cachedOpenFGADatastore)(nil)
+	_ ModelCacheRefresher      = (*cachedOpenFGADatastore)(nil)
+)
+
+// ModelCacheRefresher is a datastore wrapper that caches models and can refresh the cached
+// models of a store, e.g. after they were written to the datastore outside the server.
+type ModelCacheRefresher interface {
+	// RefreshStore evicts the cached models of the store and caches its latest model, as read
+	// from the datastore, and returns it.
+	RefreshStore(ctx context.Context, storeID string) (*openfgav1.AuthorizationModel, error)
+}
 
 type cachedOpenFGADatastore struct {
 	storage.OpenFGADatastore
 	lookupGroup singleflight.Group
-	cache       storage.InMemoryCache[*openfgav1.AuthorizationModel]
+	cache       *storage.InMemoryLRUCache[*openfgav1.AuthorizationModel]
 }
 
 // NewCachedOpenFGADatastore returns a wrapper over a datastore that caches up to maxSize
@@ -28,13 +41,18 @@ func NewCachedOpenFGADatastore(inner storage.OpenFGADatastore, maxSize int) *cac
 	cache := storage.NewInMemoryLRUCache[*openfgav1.AuthorizationModel](storage.WithMaxCacheSize[*openfgav1.AuthorizationModel](int64(maxSize)))
 	return &cachedOpenFGADatastore{
 		OpenFGADatastore: inner,
-		cache:            *cache,
+		cache:            cache,
 	}
 }
 
-// ReadAuthorizationModel reads the model corresponding to store and model ID.
+// ReadAuthorizationModel reads the model corresponding to store and model ID. Requests with the
+// NoModelCache option read it from the datastore without caching it.
 func (c *cachedOpenFGADatastore) ReadAuthorizationModel(ctx context.Context, storeID, modelID string) (*openfgav1.AuthorizationModel, error) {
-	cacheKey := fmt.Sprintf("%s:%s", storeID, modelID)
+	if requestcontext.FromContext(ctx).NoModelCache {
+		return c.OpenFGADatastore.ReadAuthorizationModel(ctx, storeID, modelID)
+	}
+
+	cacheKey := tuple.CanonicalKey(storeID, modelID)
 	cachedEntry := c.cache.Get(cacheKey)
 
 	if cachedEntry != nil {
@@ -62,6 +80,32 @@ func (c *cachedOpenFGADatastore) FindLatestAuthorizationModel(ctx context.Contex
 	return v.(*openfgav1.AuthorizationModel), nil
 }
 
+// DeleteStore deletes the store and evicts its cached models, so that they are not served for
+// the deleted store.
+func (c *cachedOpenFGADatastore) DeleteStore(ctx context.Context, id string) error {
+	if err := c.OpenFGADatastore.DeleteStore(ctx, id); err != nil {
+		return err
+	}
+
+	c.cache.DeletePrefix(tuple.CanonicalKey(id))
+	return nil
+}
+
+// RefreshStore see [ModelCacheRefresher].RefreshStore. The latest model is read before any model
+// is evicted, and a model missing from the cache is read from the datastore, so requests resolve
+// a model throughout the refresh.
+func (c *cachedOpenFGADatastore) RefreshStore(ctx context.Context, storeID string) (*openfgav1.AuthorizationModel, error) {
+	// not deduplicated with the lookups in flight, which may have started before the write
+	model, err := c.OpenFGADatastore.FindLatestAuthorizationModel(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.DeletePrefix(tuple.CanonicalKey(storeID))
+	c.cache.Set(tuple.CanonicalKey(storeID, model.GetId()), model, ttl)
+	return model, nil
+}
+
 // Close closes the datastore and cleans up any residual resources.
 func (c *cachedOpenFGADatastore) Close() {
 	c.cache.Stop()
@@ -2,7 +2,6 @@ package storagewrappers
 
 import (
 	"context"
-	"fmt"
 	"testing"
 	"time"
 
@@ -14,6 +13,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/openfga/openfga/internal/mocks"
+	"github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
 )
 
@@ -57,7 +57,7 @@ func TestReadAuthorizationModel(t *testing.T) {
 	require.Equal(t, model, gotModel)
 
 	// Check what's stored inside the cache.
-	modelKey := fmt.Sprintf("%s:%s", storeID, model.GetId())
+	modelKey := tuple.CanonicalKey(storeID, model.GetId())
 	cachedModel := cachingBackend.cache.Get(modelKey)
 	require.NotNil(t, cachedModel)
 	require.Equal(t, model, cachedModel.Value)
@@ -123,3 +123,80 @@ func TestSingleFlightFindLatestAuthorizationModel(t *testing.T) {
 	err := wg.Wait()
 	require.NoError(t, err)
 }
+
+func TestDeleteStoreEvictsCachedModels(t *testing.T) {
+	ctx := context.Background()
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	mockController := gomock.NewController(t)
+	mockController.Finish()
+
+	mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+	cachingBackend := NewCachedOpenFGADatastore(mockDatastore, 5)
+	t.Cleanup(cachingBackend.Close)
+	model := &openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+	}
+	storeID := ulid.Make().String()
+	otherStoreID := ulid.Make().String()
+	gomock.InOrder(
+		mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), storeID, model.GetId()).Times(1).Return(model, nil),
+		mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), otherStoreID, model.GetId()).Times(1).Return(model, nil),
+		mockDatastore.EXPECT().DeleteStore(gomock.Any(), storeID).Times(1).Return(nil),
+		mockDatastore.EXPECT().Close().Times(1),
+	)
+
+	_, err := cachingBackend.ReadAuthorizationModel(ctx, storeID, model.GetId())
+	require.NoError(t, err)
+	_, err = cachingBackend.ReadAuthorizationModel(ctx, otherStoreID, model.GetId())
+	require.NoError(t, err)
+
+	err = cachingBackend.DeleteStore(ctx, storeID)
+	require.NoError(t, err)
+
+	require.Nil(t, cachingBackend.cache.Get(tuple.CanonicalKey(storeID, model.GetId())))
+	require.NotNil(t, cachingBackend.cache.Get(tuple.CanonicalKey(otherStoreID, model.GetId())))
+}
+
+func TestRefreshStoreReplacesCachedModels(t *testing.T) {
+	ctx := context.Background()
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	mockController := gomock.NewController(t)
+
+	mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+	cachingBackend := NewCachedOpenFGADatastore(mockDatastore, 5)
+	t.Cleanup(cachingBackend.Close)
+	model := &openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+	}
+	latest := &openfgav1.AuthorizationModel{
+		Id:            ulid.Make().String(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+	}
+	storeID := ulid.Make().String()
+	otherStoreID := ulid.Make().String()
+	gomock.InOrder(
+		mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), storeID, model.GetId()).Times(1).Return(model, nil),
+		mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), otherStoreID, model.GetId()).Times(1).Return(model, nil),
+		mockDatastore.EXPECT().FindLatestAuthorizationModel(gomock.Any(), storeID).Times(1).Return(latest, nil),
+		mockDatastore.EXPECT().Close().Times(1),
+	)
+
+	_, err := cachingBackend.ReadAuthorizationModel(ctx, storeID, model.GetId())
+	require.NoError(t, err)
+	_, err = cachingBackend.ReadAuthorizationModel(ctx, otherStoreID, model.GetId())
+	require.NoError(t, err)
+
+	refreshed, err := cachingBackend.RefreshStore(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, latest, refreshed)
+
+	require.Nil(t, cachingBackend.cache.Get(tuple.CanonicalKey(storeID, model.GetId())))
+	require.NotNil(t, cachingBackend.cache.Get(tuple.CanonicalKey(storeID, latest.GetId())))
+	require.NotNil(t, cachingBackend.cache.Get(tuple.CanonicalKey(otherStoreID, model.GetId())))
+}
@@ -14,35 +14,30 @@ import (
 func NewCombinedTupleReader(
 	ds storage.RelationshipTupleReader,
 	contextualTuples []*openfgav1.TupleKey,
+) storage.RelationshipTupleReader {
+	return NewCombinedTupleReaderWithOverlay(ds, NewContextualTupleOverlay(contextualTuples))
+}
+
+// NewCombinedTupleReaderWithOverlay is like [NewCombinedTupleReader], with the contextual tuples
+// of the request indexed already, so that the overlay is shared with the other readers of the
+// request.
+func NewCombinedTupleReaderWithOverlay(
+	ds storage.RelationshipTupleReader,
+	overlay *ContextualTupleOverlay,
 ) storage.RelationshipTupleReader {
 	return &CombinedTupleReader{
 		RelationshipTupleReader: ds,
-		contextualTuples:        contextualTuples,
+		overlay:                 overlay,
 	}
 }
 
 type CombinedTupleReader struct {
 	storage.RelationshipTupleReader
-	contextualTuples []*openfgav1.TupleKey
+	overlay *ContextualTupleOverlay
 }
 
 var _ storage.RelationshipTupleReader = (*CombinedTupleReader)(nil)
 
-// filterTuples filters out the tuples in the provided slice by removing any tuples in the slice
-// that don't match the object and relation provided in the filterKey.
-func filterTuples(tuples []*openfgav1.TupleKey, targetObject, targetRelation string) []*openfgav1.Tuple {
-	var filtered []*openfgav1.Tuple
-	for _, tk := range tuples {
-		if tk.GetObject() == targetObject && tk.GetRelation() == targetRelation {
-			filtered = append(filtered, &openfgav1.Tuple{
-				Key: tk,
-			})
-		}
-	}
-
-	return filtered
-}
-
 // Read see [storage.RelationshipTupleReader.ReadUserTuple].
 func (c *CombinedTupleReader) Read(
 	ctx context.Context,
@@ -50,7 +45,7 @@ func (c *CombinedTupleReader) Read(
 	tk *openfgav1.TupleKey,
 	options storage.ReadOptions,
 ) (storage.TupleIterator, error) {
-	iter1 := storage.NewStaticTupleIterator(filterTuples(c.contextualTuples, tk.GetObject(), tk.GetRelation()))
+	iter1 := storage.NewStaticTupleIterator(c.overlay.objectRelation(tk.GetObject(), tk.GetRelation()))
 
 	iter2, err := c.RelationshipTupleReader.Read(ctx, storeID, tk, options)
 	if err != nil {
@@ -73,12 +68,8 @@ func (c *CombinedTupleReader) ReadUserTuple(
 	tk *openfgav1.TupleKey,
 	options storage.ReadUserTupleOptions,
 ) (*openfgav1.Tuple, error) {
-	filteredContextualTuples := filterTuples(c.contextualTuples, tk.GetObject(), tk.GetRelation())
-
-	for _, t := range filteredContextualTuples {
-		if t.GetKey().GetUser() == tk.GetUser() {
-			return t, nil
-		}
+	if t := c.overlay.tupleKey(tk); t != nil {
+		return t, nil
 	}
 
 	return c.RelationshipTupleReader.ReadUserTuple(ctx, store, tk, options)
@@ -91,15 +82,7 @@ func (c *CombinedTupleReader) ReadUsersetTuples(
 	filter storage.ReadUsersetTuplesFilter,
 	options storage.ReadUsersetTuplesOptions,
 ) (storage.TupleIterator, error) {
-	var usersetTuples []*openfgav1.Tuple
-
-	for _, t := range filterTuples(c.contextualTuples, filter.Object, filter.Relation) {
-		if tuple.GetUserTypeFromUser(t.GetKey().GetUser()) == tuple.UserSet {
-			usersetTuples = append(usersetTuples, t)
-		}
-	}
-
-	iter1 := storage.NewStaticTupleIterator(usersetTuples)
+	iter1 := storage.NewStaticTupleIterator(c.overlay.usersets(filter.Object, filter.Relation))
 
 	iter2, err := c.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter, options)
 	if err != nil {
@@ -117,26 +100,20 @@ func (c *CombinedTupleReader) ReadStartingWithUser(
 	options storage.ReadStartingWithUserOptions,
 ) (storage.TupleIterator, error) {
 	var filteredTuples []*openfgav1.Tuple
-	for _, t := range c.contextualTuples {
-		if tuple.GetType(t.GetObject()) != filter.ObjectType {
-			continue
+	for _, u := range filter.UserFilter {
+		targetUser := u.GetObject()
+		if u.GetRelation() != "" {
+			targetUser = tuple.ToObjectRelationString(targetUser, u.GetRelation())
 		}
 
-		if t.GetRelation() != filter.Relation {
-			continue
-		}
-
-		for _, u := range filter.UserFilter {
-			targetUser := u.GetObject()
-			if u.GetRelation() != "" {
-				targetUser = tuple.ToObjectRelationString(targetUser, u.GetRelation())
-			}
-
-			if t.GetUser() == targetUser {
-				filteredTuples = append(filteredTuples, &openfgav1.Tuple{
-					Key: t,
-				})
+		for _, t := range c.overlay.startingWithUser(filter.ObjectType, filter.Relation, targetUser) {
+			// like the datastores, only the objects of the filter are read, if any
+			if filter.ObjectIDs != nil {
+				if _, objectID := tuple.SplitObject(t.GetKey().GetObject()); !filter.ObjectIDs.Exists(objectID) {
+					continue
+				}
 			}
+			filteredTuples = append(filteredTuples, t)
 		}
 	}
 
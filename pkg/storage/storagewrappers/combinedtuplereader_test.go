@@ -440,6 +440,52 @@ func Test_combinedTupleReader_ReadStartingWithUser(t *testing.T) {
 			wantErr: nil,
 		},
 
+		{
+			name: "Test_combinedTupleReader_ReadStartingWithUser_OK_object_ids",
+			fields: fields{
+				RelationshipTupleReader: mockRelationshipTupleReader,
+				contextualTuples: []*openfgav1.TupleKey{
+					testTuples["group:1#member@user:11"].GetKey(),
+					testTuples["group:1#member@user:12"].GetKey(),
+					testTuples["group:2#member@user:21"].GetKey(),
+					testTuples["group:3#member@user:11"].GetKey(),
+				},
+			},
+			args: args{
+				ctx:   context.Background(),
+				store: "",
+				filter: storage.ReadStartingWithUserFilter{
+					ObjectType: "group",
+					Relation:   "member",
+					UserFilter: []*openfgav1.ObjectRelation{
+						{
+							Object: "user:11",
+						},
+						{
+							Object: "user:21",
+						},
+					},
+					ObjectIDs: func() storage.SortedSet {
+						objectIDs := storage.NewSortedSet()
+						objectIDs.Add("2")
+						objectIDs.Add("3")
+						return objectIDs
+					}(),
+				},
+				options: storage.ReadStartingWithUserOptions{},
+			},
+			setups: func() {
+				mockRelationshipTupleReader.EXPECT().
+					ReadStartingWithUser(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(storage.NewStaticTupleIterator(nil), nil)
+			},
+			want: []*openfgav1.Tuple{
+				testTuples["group:3#member@user:11"],
+				testTuples["group:2#member@user:21"],
+			},
+			wantErr: nil,
+		},
+
 		{
 			name: "Test_combinedTupleReader_ReadStartingWithUser_error_relationship_tuple_reader_error",
 			fields: fields{
@@ -727,7 +773,7 @@ func Test_combinedTupleReader_ReadUsersetTuples(t *testing.T) {
 	}
 }
 
-func Test_filterTuples(t *testing.T) {
+func Test_contextualTupleOverlay_objectRelation(t *testing.T) {
 	type args struct {
 		tuples         []*openfgav1.TupleKey
 		targetObject   string
@@ -794,7 +840,7 @@ func Test_filterTuples(t *testing.T) {
 		want []*openfgav1.Tuple
 	}{
 		{
-			name: "Test_filterTuples_OK",
+			name: "Test_contextualTupleOverlay_objectRelation_OK",
 			args: args{
 				tuples:         okTuples,
 				targetObject:   "group:1",
@@ -807,7 +853,7 @@ func Test_filterTuples(t *testing.T) {
 			},
 		},
 		{
-			name: "Test_filterTuples_with_incomplete_testTuples",
+			name: "Test_contextualTupleOverlay_objectRelation_with_incomplete_testTuples",
 			args: args{
 				tuples:         incompleteTuples,
 				targetObject:   "group:1",
@@ -823,7 +869,7 @@ func Test_filterTuples(t *testing.T) {
 			},
 		},
 		{
-			name: "Test_filterTuples_with_incomplete_testTuples_only_relation",
+			name: "Test_contextualTupleOverlay_objectRelation_with_incomplete_testTuples_only_relation",
 			args: args{
 				tuples:         incompleteTuples,
 				targetRelation: "member",
@@ -843,7 +889,7 @@ func Test_filterTuples(t *testing.T) {
 			},
 		},
 		{
-			name: "Test_filterTuples_with_incomplete_testTuples_only_object",
+			name: "Test_contextualTupleOverlay_objectRelation_with_incomplete_testTuples_only_object",
 			args: args{
 				tuples:       incompleteTuples,
 				targetObject: "group:1",
@@ -859,8 +905,8 @@ func Test_filterTuples(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := filterTuples(tt.args.tuples, tt.args.targetObject, tt.args.targetRelation); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("filterTuples() = %v, want %v", got, tt.want)
+			if got := NewContextualTupleOverlay(tt.args.tuples).objectRelation(tt.args.targetObject, tt.args.targetRelation); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("objectRelation() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -0,0 +1,88 @@
+package storagewrappers
+
+import (
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// ContextualTupleOverlay is an index of the contextual tuples of a request, built once per
+// request so that the reads of its internal checks and reverse expansions look the contextual
+// tuples up instead of scanning them on every read. It is immutable once built, so it is shared
+// by the goroutines of the request without locking. The tuples it returns must not be modified.
+type ContextualTupleOverlay struct {
+	count int
+	// byObjectRelation holds the tuples by object and relation, in the order of the request.
+	byObjectRelation map[contextualTupleKey][]*openfgav1.Tuple
+	// usersetsByObjectRelation holds the tuples of byObjectRelation whose user is a userset or
+	// a wildcard.
+	usersetsByObjectRelation map[contextualTupleKey][]*openfgav1.Tuple
+	// byTupleKey holds the first tuple of each object, relation and user.
+	byTupleKey map[contextualTupleKey]*openfgav1.Tuple
+	// byUser holds the tuples by object type, relation and user, for the reads starting with a
+	// user.
+	byUser map[contextualTupleKey][]*openfgav1.Tuple
+}
+
+// contextualTupleKey is the key of the indexes of a [ContextualTupleOverlay]. object is an
+// object type in the keys of byUser, and user is empty in the keys of byObjectRelation.
+type contextualTupleKey struct {
+	object   string
+	relation string
+	user     string
+}
+
+// NewContextualTupleOverlay indexes the contextual tuples of a request.
+func NewContextualTupleOverlay(contextualTuples []*openfgav1.TupleKey) *ContextualTupleOverlay {
+	o := &ContextualTupleOverlay{
+		count:                    len(contextualTuples),
+		byObjectRelation:         make(map[contextualTupleKey][]*openfgav1.Tuple, len(contextualTuples)),
+		usersetsByObjectRelation: map[contextualTupleKey][]*openfgav1.Tuple{},
+		byTupleKey:               make(map[contextualTupleKey]*openfgav1.Tuple, len(contextualTuples)),
+		byUser:                   make(map[contextualTupleKey][]*openfgav1.Tuple, len(contextualTuples)),
+	}
+
+	for _, tk := range contextualTuples {
+		t := &openfgav1.Tuple{Key: tk}
+
+		objectRelation := contextualTupleKey{object: tk.GetObject(), relation: tk.GetRelation()}
+		o.byObjectRelation[objectRelation] = append(o.byObjectRelation[objectRelation], t)
+		if tuple.GetUserTypeFromUser(tk.GetUser()) == tuple.UserSet {
+			o.usersetsByObjectRelation[objectRelation] = append(o.usersetsByObjectRelation[objectRelation], t)
+		}
+
+		key := contextualTupleKey{object: tk.GetObject(), relation: tk.GetRelation(), user: tk.GetUser()}
+		if _, ok := o.byTupleKey[key]; !ok {
+			o.byTupleKey[key] = t
+		}
+
+		userKey := contextualTupleKey{object: tuple.GetType(tk.GetObject()), relation: tk.GetRelation(), user: tk.GetUser()}
+		o.byUser[userKey] = append(o.byUser[userKey], t)
+	}
+	return o
+}
+
+// Len returns the number of contextual tuples.
+func (o *ContextualTupleOverlay) Len() int {
+	return o.count
+}
+
+// objectRelation returns the tuples of the object and relation.
+func (o *ContextualTupleOverlay) objectRelation(object, relation string) []*openfgav1.Tuple {
+	return o.byObjectRelation[contextualTupleKey{object: object, relation: relation}]
+}
+
+// usersets returns the tuples of the object and relation whose user is a userset or a wildcard.
+func (o *ContextualTupleOverlay) usersets(object, relation string) []*openfgav1.Tuple {
+	return o.usersetsByObjectRelation[contextualTupleKey{object: object, relation: relation}]
+}
+
+// tupleKey returns the first tuple of the object, relation and user of the key, or nil.
+func (o *ContextualTupleOverlay) tupleKey(tk *openfgav1.TupleKey) *openfgav1.Tuple {
+	return o.byTupleKey[contextualTupleKey{object: tk.GetObject(), relation: tk.GetRelation(), user: tk.GetUser()}]
+}
+
+// startingWithUser returns the tuples of the objects of the type with the relation and the user.
+func (o *ContextualTupleOverlay) startingWithUser(objectType, relation, user string) []*openfgav1.Tuple {
+	return o.byUser[contextualTupleKey{object: objectType, relation: relation, user: user}]
+}
@@ -0,0 +1,97 @@
+package storagewrappers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestContextualTupleOverlay(t *testing.T) {
+	overlay := NewContextualTupleOverlay(tuple.MustParseTupleStrings(
+		"document:1#viewer@user:jon",
+		"document:1#viewer@group:eng#member",
+		"document:1#viewer@user:*",
+		"document:2#viewer@user:jon",
+		"document:1#viewer@user:jon",
+		"folder:1#viewer@user:jon",
+	))
+	require.Equal(t, 6, overlay.Len())
+
+	keys := func(tuples []*openfgav1.Tuple) []string {
+		var keys []string
+		for _, t := range tuples {
+			keys = append(keys, tuple.TupleKeyToString(t.GetKey()))
+		}
+		return keys
+	}
+
+	require.Equal(t, []string{
+		"document:1#viewer@user:jon",
+		"document:1#viewer@group:eng#member",
+		"document:1#viewer@user:*",
+		"document:1#viewer@user:jon",
+	}, keys(overlay.objectRelation("document:1", "viewer")))
+	require.Empty(t, overlay.objectRelation("document:1", "editor"))
+
+	require.Equal(t, []string{
+		"document:1#viewer@group:eng#member",
+		"document:1#viewer@user:*",
+	}, keys(overlay.usersets("document:1", "viewer")))
+
+	require.Equal(t, "document:2#viewer@user:jon", tuple.TupleKeyToString(overlay.tupleKey(tuple.NewTupleKey("document:2", "viewer", "user:jon")).GetKey()))
+	require.Nil(t, overlay.tupleKey(tuple.NewTupleKey("document:2", "viewer", "user:maria")))
+
+	require.Equal(t, []string{
+		"document:1#viewer@user:jon",
+		"document:2#viewer@user:jon",
+		"document:1#viewer@user:jon",
+	}, keys(overlay.startingWithUser("document", "viewer", "user:jon")))
+
+	t.Run("shared_by_concurrent_readers", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		contextualTuples := make([]*openfgav1.TupleKey, 0, 100)
+		for i := 0; i < 100; i++ {
+			contextualTuples = append(contextualTuples, tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "viewer", "user:jon"))
+		}
+		overlay := NewContextualTupleOverlay(contextualTuples)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				reader := NewCombinedTupleReaderWithOverlay(ds, overlay)
+				iter, err := reader.ReadStartingWithUser(context.Background(), "store", storage.ReadStartingWithUserFilter{
+					ObjectType: "document",
+					Relation:   "viewer",
+					UserFilter: []*openfgav1.ObjectRelation{{Object: "user:jon"}},
+				}, storage.ReadStartingWithUserOptions{})
+				if !assert.NoError(t, err) {
+					return
+				}
+				defer iter.Stop()
+
+				var count int
+				for ; ; count++ {
+					if _, err := iter.Next(context.Background()); err != nil {
+						break
+					}
+				}
+				assert.Equal(t, 100, count)
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,386 @@
+package storagewrappers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/dispatch"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+// otherStoresLabel is the store_id label of the stores that are not allowlisted.
+const otherStoresLabel = "other"
+
+var _ storage.RelationshipTupleReader = (*FairShareTupleReader)(nil)
+
+var storeReadWaitMsHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace:                       build.ProjectName,
+	Name:                            "datastore_store_read_wait_ms",
+	Help:                            "Time spent by the reads of a store waiting for their share of the concurrent datastore reads, labeled by store ID for the allowlisted stores and 'other' for the rest.",
+	Buckets:                         []float64{1, 3, 5, 10, 25, 50, 100, 1000, 5000},
+	NativeHistogramBucketFactor:     1.1,
+	NativeHistogramMaxBucketNumber:  100,
+	NativeHistogramMinResetDuration: time.Hour,
+}, []string{"store_id"})
+
+// FairShareLimiter limits the concurrent reads of all the stores to a total number of permits,
+// and shares the permits among the stores by weight. A store may use the permits that the other
+// stores leave unused, but when a permit is released and reads of several stores are waiting, it
+// goes to the store that holds the fewest permits relative to its weight. A burst of reads of one
+// store thus delays the reads of the other stores by at most one read, instead of until the
+// burst is over.
+//
+// As in [BoundedConcurrencyTupleReader], a read whose context has dispatch.RequestPriorityLow
+// only acquires a permit when no normal priority read is waiting.
+type FairShareLimiter struct {
+	total   uint32
+	weights map[string]uint32
+
+	// metricsAllowlist are the stores whose wait times are reported under their own label.
+	metricsAllowlist map[string]struct{}
+
+	mu      sync.Mutex
+	inUse   uint32
+	waiting int
+	// normalPriorityWaiting is the number of waiting normal priority reads.
+	normalPriorityWaiting int
+	// seq orders the waiting reads.
+	seq uint64
+	// grants counts the permits granted, to order the stores by their last permit.
+	grants uint64
+	stores map[string]*fairShareStore
+}
+
+type fairShareStore struct {
+	inUse     uint32
+	lastGrant uint64
+	waiters   []*fairShareWaiter
+}
+
+type fairShareWaiter struct {
+	seq      uint64
+	priority dispatch.RequestPriority
+	ready    chan struct{}
+	granted  bool
+}
+
+// FairShareLimiterOpt defines an option that can be used to change the behavior of a
+// FairShareLimiter.
+type FairShareLimiterOpt func(*FairShareLimiter)
+
+// WithStoreWeights sets the weights of stores. The share of the permits of a store is
+// proportional to its weight among the stores that are reading. Stores without a weight, or with
+// a weight of 0, have a weight of 1.
+func WithStoreWeights(weights map[string]uint32) FairShareLimiterOpt {
+	return func(l *FairShareLimiter) {
+		for storeID, weight := range weights {
+			if weight > 0 {
+				l.weights[storeID] = weight
+			}
+		}
+	}
+}
+
+// WithStoreWaitMetrics sets the stores whose wait times are reported with their own store_id
+// label by the datastore_store_read_wait_ms histogram. The others are reported under 'other'.
+func WithStoreWaitMetrics(allowlist []string) FairShareLimiterOpt {
+	return func(l *FairShareLimiter) {
+		for _, storeID := range allowlist {
+			l.metricsAllowlist[storeID] = struct{}{}
+		}
+	}
+}
+
+// NewFairShareLimiter returns a FairShareLimiter of total permits.
+func NewFairShareLimiter(total uint32, opts ...FairShareLimiterOpt) *FairShareLimiter {
+	l := &FairShareLimiter{
+		total:            total,
+		weights:          map[string]uint32{},
+		metricsAllowlist: map[string]struct{}{},
+		stores:           map[string]*fairShareStore{},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Acquire waits for a permit for a read of the store. It returns the error of ctx if ctx is
+// done first. Every successful Acquire must be followed by a Release of the same store.
+func (l *FairShareLimiter) Acquire(ctx context.Context, storeID string) error {
+	start := time.Now()
+	priority := dispatch.RequestPriorityFromContext(ctx)
+	defer func() {
+		timeWaiting := time.Since(start).Milliseconds()
+
+		rpcInfo := telemetry.RPCInfoFromContext(ctx)
+		boundedReadDelayMsHistogram.WithLabelValues(
+			rpcInfo.Service,
+			rpcInfo.Method,
+			priority.String(),
+		).Observe(float64(timeWaiting))
+		storeReadWaitMsHistogram.WithLabelValues(l.metricsLabel(storeID)).Observe(float64(timeWaiting))
+
+		span := trace.SpanFromContext(ctx)
+		span.SetAttributes(attribute.Int64(timeWaitingSpanAttribute, timeWaiting))
+	}()
+
+	l.mu.Lock()
+	store := l.store(storeID)
+	if l.inUse < l.total && l.waiting == 0 {
+		l.take(store)
+		l.mu.Unlock()
+		return nil
+	}
+
+	l.seq++
+	waiter := &fairShareWaiter{seq: l.seq, priority: priority, ready: make(chan struct{})}
+	store.enqueue(waiter)
+	l.waiting++
+	if priority != dispatch.RequestPriorityLow {
+		l.normalPriorityWaiting++
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-waiter.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	if waiter.granted {
+		// the permit was granted as ctx was done, give it to the next read
+		l.release(storeID)
+	} else {
+		store.remove(waiter)
+		l.waiting--
+		if priority != dispatch.RequestPriorityLow {
+			l.normalPriorityWaiting--
+		}
+		l.forget(storeID, store)
+	}
+	l.mu.Unlock()
+
+	return ctx.Err()
+}
+
+// Release gives back a permit of the store.
+func (l *FairShareLimiter) Release(storeID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.release(storeID)
+}
+
+func (l *FairShareLimiter) release(storeID string) {
+	store := l.stores[storeID]
+	store.inUse--
+	l.inUse--
+	l.forget(storeID, store)
+	l.grant()
+}
+
+// grant gives the free permits to the waiting reads, each to the first waiting read of the store
+// with the fewest permits relative to its weight.
+func (l *FairShareLimiter) grant() {
+	for l.inUse < l.total && l.waiting > 0 {
+		var nextID string
+		var next *fairShareStore
+		for storeID, store := range l.stores {
+			if len(store.waiters) == 0 {
+				continue
+			}
+			// low priority reads only get a permit when no normal priority read is waiting
+			if l.normalPriorityWaiting > 0 && store.waiters[0].priority == dispatch.RequestPriorityLow {
+				continue
+			}
+			if next == nil || l.before(storeID, store, nextID, next) {
+				nextID, next = storeID, store
+			}
+		}
+
+		waiter := next.waiters[0]
+		next.waiters = next.waiters[1:]
+		l.waiting--
+		if waiter.priority != dispatch.RequestPriorityLow {
+			l.normalPriorityWaiting--
+		}
+		l.take(next)
+		waiter.granted = true
+		close(waiter.ready)
+	}
+}
+
+func (l *FairShareLimiter) take(store *fairShareStore) {
+	l.inUse++
+	l.grants++
+	store.inUse++
+	store.lastGrant = l.grants
+}
+
+// before returns true if the first waiting read of store a should get a permit before the first
+// waiting read of store b: if a holds fewer permits relative to its weight, or as many but got
+// its last permit earlier, so that stores with the same share take turns.
+func (l *FairShareLimiter) before(aID string, a *fairShareStore, bID string, b *fairShareStore) bool {
+	// compare a.inUse/weight(a) and b.inUse/weight(b) without dividing
+	aShare := uint64(a.inUse) * uint64(l.weight(bID))
+	bShare := uint64(b.inUse) * uint64(l.weight(aID))
+	if aShare != bShare {
+		return aShare < bShare
+	}
+	if a.lastGrant != b.lastGrant {
+		return a.lastGrant < b.lastGrant
+	}
+	return a.waiters[0].seq < b.waiters[0].seq
+}
+
+func (l *FairShareLimiter) weight(storeID string) uint32 {
+	if weight, ok := l.weights[storeID]; ok {
+		return weight
+	}
+	return 1
+}
+
+// store returns the state of a store, which is kept while it has permits or waiting reads.
+func (l *FairShareLimiter) store(storeID string) *fairShareStore {
+	store, ok := l.stores[storeID]
+	if !ok {
+		store = &fairShareStore{}
+		l.stores[storeID] = store
+	}
+	return store
+}
+
+func (l *FairShareLimiter) forget(storeID string, store *fairShareStore) {
+	if store.inUse == 0 && len(store.waiters) == 0 {
+		delete(l.stores, storeID)
+	}
+}
+
+func (l *FairShareLimiter) metricsLabel(storeID string) string {
+	if _, ok := l.metricsAllowlist[storeID]; ok {
+		return storeID
+	}
+	return otherStoresLabel
+}
+
+// enqueue adds a waiting read after the other reads of its priority, normal priority reads
+// being served before low priority ones.
+func (s *fairShareStore) enqueue(waiter *fairShareWaiter) {
+	i := len(s.waiters)
+	if waiter.priority != dispatch.RequestPriorityLow {
+		for i > 0 && s.waiters[i-1].priority == dispatch.RequestPriorityLow {
+			i--
+		}
+	}
+	s.waiters = append(s.waiters, nil)
+	copy(s.waiters[i+1:], s.waiters[i:])
+	s.waiters[i] = waiter
+}
+
+func (s *fairShareStore) remove(waiter *fairShareWaiter) {
+	for i, w := range s.waiters {
+		if w == waiter {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// FairShareTupleReader is a wrapper over a datastore whose calls to Read, ReadPage,
+// ReadUserTuple, ReadUsersetTuples and ReadStartingWithUser hold a permit of a
+// [FairShareLimiter] shared with other readers, so that the reads of each store get their share
+// of the concurrent reads of all the stores.
+type FairShareTupleReader struct {
+	storage.RelationshipTupleReader
+	limiter *FairShareLimiter
+}
+
+// NewFairShareTupleReader returns a wrapper over a datastore whose reads hold a permit of the
+// limiter.
+func NewFairShareTupleReader(wrapped storage.RelationshipTupleReader, limiter *FairShareLimiter) *FairShareTupleReader {
+	return &FairShareTupleReader{
+		RelationshipTupleReader: wrapped,
+		limiter:                 limiter,
+	}
+}
+
+// ReadUserTuple tries to return one tuple that matches the provided key exactly.
+func (f *FairShareTupleReader) ReadUserTuple(
+	ctx context.Context,
+	store string,
+	tupleKey *openfgav1.TupleKey,
+	options storage.ReadUserTupleOptions,
+) (*openfgav1.Tuple, error) {
+	if err := f.limiter.Acquire(ctx, store); err != nil {
+		return nil, err
+	}
+	defer f.limiter.Release(store)
+
+	return f.RelationshipTupleReader.ReadUserTuple(ctx, store, tupleKey, options)
+}
+
+// Read the set of tuples associated with `store` and `TupleKey`, which may be nil or partially filled.
+func (f *FairShareTupleReader) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	if err := f.limiter.Acquire(ctx, store); err != nil {
+		return nil, err
+	}
+	defer f.limiter.Release(store)
+
+	return f.RelationshipTupleReader.Read(ctx, store, tupleKey, options)
+}
+
+// ReadPage the set of tuples associated with `store` and `TupleKey`, which may be nil or partially filled.
+func (f *FairShareTupleReader) ReadPage(
+	ctx context.Context,
+	store string,
+	tupleKey *openfgav1.TupleKey,
+	options storage.ReadPageOptions,
+) ([]*openfgav1.Tuple, []byte, error) {
+	if err := f.limiter.Acquire(ctx, store); err != nil {
+		return nil, nil, err
+	}
+	defer f.limiter.Release(store)
+
+	return f.RelationshipTupleReader.ReadPage(ctx, store, tupleKey, options)
+}
+
+// ReadUsersetTuples returns all userset tuples for a specified object and relation.
+func (f *FairShareTupleReader) ReadUsersetTuples(
+	ctx context.Context,
+	store string,
+	filter storage.ReadUsersetTuplesFilter,
+	options storage.ReadUsersetTuplesOptions,
+) (storage.TupleIterator, error) {
+	if err := f.limiter.Acquire(ctx, store); err != nil {
+		return nil, err
+	}
+	defer f.limiter.Release(store)
+
+	return f.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter, options)
+}
+
+// ReadStartingWithUser performs a reverse read of relationship tuples starting at one or
+// more user(s) or userset(s) and filtered by object type and relation.
+func (f *FairShareTupleReader) ReadStartingWithUser(
+	ctx context.Context,
+	store string,
+	filter storage.ReadStartingWithUserFilter,
+	options storage.ReadStartingWithUserOptions,
+) (storage.TupleIterator, error) {
+	if err := f.limiter.Acquire(ctx, store); err != nil {
+		return nil, err
+	}
+	defer f.limiter.Release(store)
+
+	return f.RelationshipTupleReader.ReadStartingWithUser(ctx, store, filter, options)
+}
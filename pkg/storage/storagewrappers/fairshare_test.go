@@ -0,0 +1,192 @@
+package storagewrappers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/openfga/openfga/internal/mocks"
+	"github.com/openfga/openfga/pkg/dispatch"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestFairShareTupleReader(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	busyStore := ulid.Make().String()
+	otherStore := ulid.Make().String()
+
+	const readDuration = 200 * time.Millisecond
+	slowBackend := mocks.NewMockSlowDataStorage(memory.New(), readDuration)
+	for _, store := range []string{busyStore, otherStore} {
+		err := slowBackend.Write(context.Background(), store, nil, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("obj:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+	}
+
+	limiter := NewFairShareLimiter(2, WithStoreWaitMetrics([]string{otherStore}))
+	reader := NewFairShareTupleReader(slowBackend, limiter)
+	read := func(store string) error {
+		_, err := reader.ReadUserTuple(context.Background(), store, tuple.NewTupleKey("obj:1", "viewer", "user:anne"), storage.ReadUserTupleOptions{})
+		return err
+	}
+
+	// the busy store saturates the permits with 5 reads per permit
+	var busyReads errgroup.Group
+	for i := 0; i < 10; i++ {
+		busyReads.Go(func() error {
+			return read(busyStore)
+		})
+	}
+	require.Eventually(t, func() bool {
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		return limiter.waiting == 8
+	}, time.Second, time.Millisecond)
+
+	waits := testutil.CollectAndCount(storeReadWaitMsHistogram)
+
+	// the read of the other store gets the next permit, instead of waiting for the 8 reads
+	// of the busy store waiting before it
+	start := time.Now()
+	require.NoError(t, read(otherStore))
+	require.Less(t, time.Since(start), 3*readDuration)
+
+	require.NoError(t, busyReads.Wait())
+
+	// the wait of the allowlisted store is reported under its own label
+	require.Equal(t, waits+1, testutil.CollectAndCount(storeReadWaitMsHistogram))
+	require.Empty(t, limiter.stores)
+}
+
+func TestFairShareLimiter(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+
+	// acquire waits for a permit in the background, and returns the channel of the result
+	acquire := func(ctx context.Context, t *testing.T, limiter *FairShareLimiter, storeID string) <-chan error {
+		done := make(chan error, 1)
+		limiter.mu.Lock()
+		waiting := limiter.waiting
+		limiter.mu.Unlock()
+
+		go func() {
+			done <- limiter.Acquire(ctx, storeID)
+		}()
+
+		// wait until it is queued, so that the order of the waiting reads is known
+		require.Eventually(t, func() bool {
+			if len(done) > 0 {
+				return true
+			}
+			limiter.mu.Lock()
+			defer limiter.mu.Unlock()
+			return limiter.waiting > waiting
+		}, time.Second, time.Millisecond)
+		return done
+	}
+
+	inUse := func(limiter *FairShareLimiter, storeID string) uint32 {
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		if store, ok := limiter.stores[storeID]; ok {
+			return store.inUse
+		}
+		return 0
+	}
+
+	t.Run("a_store_borrows_the_permits_of_idle_stores", func(t *testing.T) {
+		limiter := NewFairShareLimiter(3)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, limiter.Acquire(ctx, "a"))
+		}
+		require.Equal(t, uint32(3), inUse(limiter, "a"))
+
+		for i := 0; i < 3; i++ {
+			limiter.Release("a")
+		}
+		require.Empty(t, limiter.stores)
+	})
+
+	t.Run("permits_are_shared_by_weight", func(t *testing.T) {
+		limiter := NewFairShareLimiter(4, WithStoreWeights(map[string]uint32{"a": 3}))
+		for i := 0; i < 4; i++ {
+			require.NoError(t, limiter.Acquire(ctx, "b"))
+		}
+
+		var waiting []<-chan error
+		for i := 0; i < 4; i++ {
+			waiting = append(waiting, acquire(ctx, t, limiter, "a"))
+		}
+		for i := 0; i < 4; i++ {
+			waiting = append(waiting, acquire(ctx, t, limiter, "b"))
+		}
+
+		// as the permits of b are released, a gets 3 of them for each one of b
+		for i := 0; i < 4; i++ {
+			limiter.Release("b")
+		}
+		require.Equal(t, uint32(3), inUse(limiter, "a"))
+		require.Equal(t, uint32(1), inUse(limiter, "b"))
+
+		for i := 0; i < 3; i++ {
+			limiter.Release("a")
+		}
+		limiter.Release("b")
+		for _, done := range waiting {
+			require.NoError(t, <-done)
+		}
+		require.Equal(t, uint32(1), inUse(limiter, "a"))
+		require.Equal(t, uint32(3), inUse(limiter, "b"))
+
+		limiter.Release("a")
+		for i := 0; i < 3; i++ {
+			limiter.Release("b")
+		}
+		require.Empty(t, limiter.stores)
+	})
+
+	t.Run("low_priority_reads_wait_for_normal_priority_reads", func(t *testing.T) {
+		limiter := NewFairShareLimiter(1)
+		require.NoError(t, limiter.Acquire(ctx, "a"))
+
+		low := acquire(dispatch.ContextWithRequestPriority(ctx, dispatch.RequestPriorityLow), t, limiter, "b")
+		normal := acquire(ctx, t, limiter, "a")
+
+		limiter.Release("a")
+		require.NoError(t, <-normal)
+		require.Empty(t, low)
+
+		limiter.Release("a")
+		require.NoError(t, <-low)
+		limiter.Release("b")
+		require.Empty(t, limiter.stores)
+	})
+
+	t.Run("a_read_stops_waiting_when_its_context_is_done", func(t *testing.T) {
+		limiter := NewFairShareLimiter(1)
+		require.NoError(t, limiter.Acquire(ctx, "a"))
+
+		cancelCtx, cancel := context.WithCancel(ctx)
+		done := acquire(cancelCtx, t, limiter, "b")
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+
+		limiter.Release("a")
+		require.Empty(t, limiter.stores)
+		require.Zero(t, limiter.waiting)
+	})
+}
@@ -0,0 +1,305 @@
+package storagewrappers
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+var _ storage.RelationshipTupleReader = (*HedgedTupleReader)(nil)
+
+var (
+	hedgedReadsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "datastore_hedged_reads_count",
+		Help:      "The total number of second attempts issued for Read, ReadUserTuple, ReadUsersetTuples and ReadStartingWithUser calls to the datastore that did not return within the hedging delay.",
+	}, []string{"grpc_service", "grpc_method"})
+
+	hedgeWinsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "datastore_hedge_wins_count",
+		Help:      "The total number of hedged datastore reads whose second attempt returned first.",
+	}, []string{"grpc_service", "grpc_method"})
+)
+
+const (
+	// hedgingLatencySamples is the number of the latest read latencies the hedging delay is
+	// computed from.
+	hedgingLatencySamples = 1000
+
+	// hedgingDelayRefreshSamples is the number of read latencies observed between two computations
+	// of the hedging delay. The minimum delay applies until that many latencies were observed.
+	hedgingDelayRefreshSamples = 100
+)
+
+// HedgingPolicy decides when the reads of the HedgedTupleReader wrappers using it are hedged. It is
+// meant to be shared by the requests of a server: it learns the hedging delay from a percentile of
+// the latency of the reads they observe.
+type HedgingPolicy struct {
+	minDelay   time.Duration
+	percentile float64
+	maxHedges  uint32
+
+	mu sync.Mutex
+	// latencies is a ring buffer of the latest successful read latencies, next the index of the
+	// oldest one
+	latencies []time.Duration
+	next      int
+	// observed is the number of latencies observed since the delay was last computed
+	observed int
+
+	// delay is the latest computed percentile of the latencies, in nanoseconds
+	delay atomic.Int64
+}
+
+// NewHedgingPolicy returns a policy hedging the reads not returned within the given percentile
+// (e.g. 95) of the latency of the latest reads, and never before minDelay. At most
+// maxHedgesPerRequest reads are hedged per HedgedTupleReader, bounding the extra load of one request
+// on the database.
+func NewHedgingPolicy(minDelay time.Duration, percentile float64, maxHedgesPerRequest uint32) *HedgingPolicy {
+	return &HedgingPolicy{
+		minDelay:   minDelay,
+		percentile: percentile,
+		maxHedges:  maxHedgesPerRequest,
+		latencies:  make([]time.Duration, 0, hedgingLatencySamples),
+	}
+}
+
+// Delay returns how long a read runs before it is hedged.
+func (p *HedgingPolicy) Delay() time.Duration {
+	return max(p.minDelay, time.Duration(p.delay.Load()))
+}
+
+// observe records the latency of a successful read, and recomputes the delay every
+// hedgingDelayRefreshSamples latencies.
+func (p *HedgingPolicy) observe(latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.latencies) < hedgingLatencySamples {
+		p.latencies = append(p.latencies, latency)
+	} else {
+		p.latencies[p.next] = latency
+		p.next = (p.next + 1) % hedgingLatencySamples
+	}
+
+	p.observed++
+	if p.observed < hedgingDelayRefreshSamples {
+		return
+	}
+	p.observed = 0
+
+	sorted := slices.Clone(p.latencies)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p.percentile/100*float64(len(sorted)))) - 1
+	p.delay.Store(int64(sorted[min(max(rank, 0), len(sorted)-1)]))
+}
+
+// HedgedTupleReader is a wrapper over a datastore that hedges its reads: if a call to Read,
+// ReadUserTuple, ReadUsersetTuples or ReadStartingWithUser has not returned within the delay of its
+// HedgingPolicy, it issues a second, identical call and returns the result of whichever succeeds
+// first, cancelling the other. Reads with HIGHER_CONSISTENCY are not hedged, to keep their load off
+// the primary database.
+type HedgedTupleReader struct {
+	storage.RelationshipTupleReader
+	policy *HedgingPolicy
+
+	// hedges is the number of second attempts issued so far.
+	hedges atomic.Uint32
+}
+
+// NewHedgedTupleReader returns a wrapper over a datastore that hedges its reads according to policy.
+// At most the maximum number of hedges per request of the policy are issued by a wrapper, so a
+// wrapper is meant to be created for each request. It is best to wrap the reader bounding the
+// concurrent reads of the request, so that the hedged reads are bounded too.
+func NewHedgedTupleReader(wrapped storage.RelationshipTupleReader, policy *HedgingPolicy) *HedgedTupleReader {
+	return &HedgedTupleReader{
+		RelationshipTupleReader: wrapped,
+		policy:                  policy,
+	}
+}
+
+// ReadUserTuple tries to return one tuple that matches the provided key exactly.
+func (h *HedgedTupleReader) ReadUserTuple(
+	ctx context.Context,
+	store string,
+	tupleKey *openfgav1.TupleKey,
+	options storage.ReadUserTupleOptions,
+) (*openfgav1.Tuple, error) {
+	if options.Consistency.Preference == openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY {
+		return h.RelationshipTupleReader.ReadUserTuple(ctx, store, tupleKey, options)
+	}
+
+	tuple, cancel, err := hedge(ctx, h, func(ctx context.Context) (*openfgav1.Tuple, error) {
+		return h.RelationshipTupleReader.ReadUserTuple(ctx, store, tupleKey, options)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	cancel()
+	return tuple, nil
+}
+
+// Read the set of tuples associated with `store` and `TupleKey`, which may be nil or partially filled.
+func (h *HedgedTupleReader) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	if options.Consistency.Preference == openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY {
+		return h.RelationshipTupleReader.Read(ctx, store, tupleKey, options)
+	}
+
+	return hedgeIterator(ctx, h, func(ctx context.Context) (storage.TupleIterator, error) {
+		return h.RelationshipTupleReader.Read(ctx, store, tupleKey, options)
+	})
+}
+
+// ReadUsersetTuples returns all userset tuples for a specified object and relation.
+func (h *HedgedTupleReader) ReadUsersetTuples(
+	ctx context.Context,
+	store string,
+	filter storage.ReadUsersetTuplesFilter,
+	options storage.ReadUsersetTuplesOptions,
+) (storage.TupleIterator, error) {
+	if options.Consistency.Preference == openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY {
+		return h.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter, options)
+	}
+
+	return hedgeIterator(ctx, h, func(ctx context.Context) (storage.TupleIterator, error) {
+		return h.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter, options)
+	})
+}
+
+// ReadStartingWithUser performs a reverse read of relationship tuples starting at one or
+// more user(s) or userset(s) and filtered by object type and relation.
+func (h *HedgedTupleReader) ReadStartingWithUser(
+	ctx context.Context,
+	store string,
+	filter storage.ReadStartingWithUserFilter,
+	options storage.ReadStartingWithUserOptions,
+) (storage.TupleIterator, error) {
+	if options.Consistency.Preference == openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY {
+		return h.RelationshipTupleReader.ReadStartingWithUser(ctx, store, filter, options)
+	}
+
+	return hedgeIterator(ctx, h, func(ctx context.Context) (storage.TupleIterator, error) {
+		return h.RelationshipTupleReader.ReadStartingWithUser(ctx, store, filter, options)
+	})
+}
+
+// hedgedIterator cancels the context of the read that returned it when it is stopped.
+type hedgedIterator struct {
+	storage.TupleIterator
+	cancel context.CancelFunc
+}
+
+func (i *hedgedIterator) Stop() {
+	i.TupleIterator.Stop()
+	i.cancel()
+}
+
+func hedgeIterator(ctx context.Context, h *HedgedTupleReader, read func(context.Context) (storage.TupleIterator, error)) (storage.TupleIterator, error) {
+	iter, cancel, err := hedge(ctx, h, read, func(iter storage.TupleIterator) {
+		iter.Stop()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &hedgedIterator{TupleIterator: iter, cancel: cancel}, nil
+}
+
+type hedgeAttempt[T any] struct {
+	value   T
+	err     error
+	hedged  bool
+	latency time.Duration
+	cancel  context.CancelFunc
+}
+
+// hedge calls read and, if it has not returned within the delay and the budget allows it, calls it
+// again. It returns the first successful result, along with the function cancelling the context of
+// the read that returned it, or the last error if every read fails. The other read is cancelled,
+// and its result released once it returns. The latency of the successful read is observed by the
+// policy.
+func hedge[T any](ctx context.Context, h *HedgedTupleReader, read func(context.Context) (T, error), release func(T)) (T, context.CancelFunc, error) {
+	attempts := make(chan hedgeAttempt[T], 2)
+	// cancels holds the cancel functions of the attempts, the first one and the hedged one if issued
+	cancels := make([]context.CancelFunc, 0, 2)
+	start := func(hedged bool) {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		go func() {
+			started := time.Now()
+			value, err := read(attemptCtx)
+			attempts <- hedgeAttempt[T]{value: value, err: err, hedged: hedged, latency: time.Since(started), cancel: cancel}
+		}()
+	}
+
+	start(false)
+	pending := 1
+
+	timer := time.NewTimer(h.policy.Delay())
+	defer timer.Stop()
+
+	var err error
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if h.hedges.Add(1) > h.policy.maxHedges {
+				continue
+			}
+
+			rpcInfo := telemetry.RPCInfoFromContext(ctx)
+			hedgedReadsCounter.WithLabelValues(rpcInfo.Service, rpcInfo.Method).Inc()
+			trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("hedged_read", true))
+
+			start(true)
+			pending++
+		case attempt := <-attempts:
+			pending--
+			if attempt.err != nil {
+				attempt.cancel()
+				err = attempt.err
+				continue
+			}
+
+			h.policy.observe(attempt.latency)
+			if attempt.hedged {
+				rpcInfo := telemetry.RPCInfoFromContext(ctx)
+				hedgeWinsCounter.WithLabelValues(rpcInfo.Service, rpcInfo.Method).Inc()
+			}
+			if pending > 0 {
+				// the other attempt is still running: cancel it, and release its result once it returns
+				loser := cancels[1]
+				if attempt.hedged {
+					loser = cancels[0]
+				}
+				loser()
+				go discardHedgeAttempt(attempts, release)
+			}
+			return attempt.value, attempt.cancel, nil
+		}
+	}
+
+	var zero T
+	return zero, nil, err
+}
+
+// discardHedgeAttempt waits for the cancelled read that lost the race and releases its result.
+func discardHedgeAttempt[T any](attempts <-chan hedgeAttempt[T], release func(T)) {
+	attempt := <-attempts
+	attempt.cancel()
+	if attempt.err == nil && release != nil {
+		release(attempt.value)
+	}
+}
@@ -0,0 +1,197 @@
+package storagewrappers
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/telemetry"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// stallingTupleReader stalls the calls for which stall returns true until their context is
+// cancelled, or until stallTimeout.
+type stallingTupleReader struct {
+	storage.RelationshipTupleReader
+	stall        func(call uint32) bool
+	stallTimeout time.Duration
+
+	calls     atomic.Uint32
+	cancelled atomic.Uint32
+}
+
+func (r *stallingTupleReader) wait(ctx context.Context) error {
+	if !r.stall(r.calls.Add(1)) {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		r.cancelled.Add(1)
+		return ctx.Err()
+	case <-time.After(r.stallTimeout):
+		return nil
+	}
+}
+
+func (r *stallingTupleReader) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadUserTupleOptions) (*openfgav1.Tuple, error) {
+	if err := r.wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.RelationshipTupleReader.ReadUserTuple(ctx, store, tupleKey, options)
+}
+
+func (r *stallingTupleReader) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	if err := r.wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.RelationshipTupleReader.Read(ctx, store, tupleKey, options)
+}
+
+func TestHedgedTupleReader(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := telemetry.ContextWithRPCInfo(context.Background(), telemetry.RPCInfo{Service: "openfga.v1.OpenFGAService", Method: "Check"})
+	store := ulid.Make().String()
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+	err := ds.Write(ctx, store, nil, []*openfgav1.TupleKey{tk})
+	require.NoError(t, err)
+
+	hedged := hedgedReadsCounter.WithLabelValues("openfga.v1.OpenFGAService", "Check")
+	wins := hedgeWinsCounter.WithLabelValues("openfga.v1.OpenFGAService", "Check")
+
+	newReader := func(stall func(call uint32) bool) *stallingTupleReader {
+		return &stallingTupleReader{RelationshipTupleReader: ds, stall: stall, stallTimeout: time.Second}
+	}
+	stallFirstCall := func(call uint32) bool { return call == 1 }
+
+	t.Run("fast_read_is_not_hedged", func(t *testing.T) {
+		initialHedged := testutil.ToFloat64(hedged)
+		reader := newReader(func(uint32) bool { return false })
+
+		tp, err := NewHedgedTupleReader(reader, NewHedgingPolicy(50*time.Millisecond, 95, 1)).ReadUserTuple(ctx, store, tk, storage.ReadUserTupleOptions{})
+		require.NoError(t, err)
+		require.Equal(t, tk.GetObject(), tp.GetKey().GetObject())
+
+		require.Equal(t, uint32(1), reader.calls.Load())
+		require.InDelta(t, initialHedged, testutil.ToFloat64(hedged), 0)
+	})
+
+	t.Run("stalled_read_is_hedged_and_cancelled", func(t *testing.T) {
+		initialHedged := testutil.ToFloat64(hedged)
+		initialWins := testutil.ToFloat64(wins)
+		reader := newReader(stallFirstCall)
+
+		start := time.Now()
+		tp, err := NewHedgedTupleReader(reader, NewHedgingPolicy(10*time.Millisecond, 95, 1)).ReadUserTuple(ctx, store, tk, storage.ReadUserTupleOptions{})
+		require.NoError(t, err)
+		require.Equal(t, tk.GetObject(), tp.GetKey().GetObject())
+		require.Less(t, time.Since(start), reader.stallTimeout)
+
+		require.Equal(t, uint32(2), reader.calls.Load())
+		require.Eventually(t, func() bool {
+			return reader.cancelled.Load() == 1
+		}, time.Second, time.Millisecond)
+		require.InDelta(t, initialHedged+1, testutil.ToFloat64(hedged), 0)
+		require.InDelta(t, initialWins+1, testutil.ToFloat64(wins), 0)
+	})
+
+	t.Run("hedged_iterator_is_usable_until_stopped", func(t *testing.T) {
+		reader := newReader(stallFirstCall)
+
+		iter, err := NewHedgedTupleReader(reader, NewHedgingPolicy(10*time.Millisecond, 95, 1)).Read(ctx, store, tk, storage.ReadOptions{})
+		require.NoError(t, err)
+		defer iter.Stop()
+
+		tp, err := iter.Next(ctx)
+		require.NoError(t, err)
+		require.Equal(t, tk.GetUser(), tp.GetKey().GetUser())
+	})
+
+	t.Run("hedges_are_bounded_by_the_budget", func(t *testing.T) {
+		initialHedged := testutil.ToFloat64(hedged)
+		reader := newReader(func(call uint32) bool { return call != 2 })
+		reader.stallTimeout = 50 * time.Millisecond
+
+		hedgedReader := NewHedgedTupleReader(reader, NewHedgingPolicy(10*time.Millisecond, 95, 1))
+		for i := 0; i < 2; i++ {
+			_, err := hedgedReader.ReadUserTuple(ctx, store, tk, storage.ReadUserTupleOptions{})
+			require.NoError(t, err)
+		}
+
+		// the first read was hedged by the second call, the second read was not hedged
+		require.Equal(t, uint32(3), reader.calls.Load())
+		require.InDelta(t, initialHedged+1, testutil.ToFloat64(hedged), 0)
+	})
+
+	t.Run("higher_consistency_read_is_not_hedged", func(t *testing.T) {
+		reader := newReader(stallFirstCall)
+		reader.stallTimeout = 50 * time.Millisecond
+
+		_, err := NewHedgedTupleReader(reader, NewHedgingPolicy(10*time.Millisecond, 95, 1)).ReadUserTuple(ctx, store, tk, storage.ReadUserTupleOptions{
+			Consistency: storage.ConsistencyOptions{Preference: openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY},
+		})
+		require.NoError(t, err)
+		require.Equal(t, uint32(1), reader.calls.Load())
+	})
+
+	t.Run("error_is_returned_when_every_attempt_fails", func(t *testing.T) {
+		reader := newReader(func(uint32) bool { return true })
+
+		cancelCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		_, err := NewHedgedTupleReader(reader, NewHedgingPolicy(10*time.Millisecond, 95, 1)).ReadUserTuple(cancelCtx, store, tk, storage.ReadUserTupleOptions{})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, uint32(2), reader.calls.Load())
+	})
+}
+
+func TestHedgingPolicy(t *testing.T) {
+	t.Run("min_delay_applies_until_enough_latencies_are_observed", func(t *testing.T) {
+		policy := NewHedgingPolicy(time.Millisecond, 95, 1)
+		for i := 0; i < hedgingDelayRefreshSamples-1; i++ {
+			policy.observe(time.Second)
+		}
+		require.Equal(t, time.Millisecond, policy.Delay())
+
+		policy.observe(time.Second)
+		require.Equal(t, time.Second, policy.Delay())
+	})
+
+	t.Run("delay_is_the_percentile_of_the_latest_latencies", func(t *testing.T) {
+		policy := NewHedgingPolicy(0, 95, 1)
+		for i := 1; i <= hedgingLatencySamples; i++ {
+			policy.observe(time.Duration(i) * time.Millisecond)
+		}
+		require.Equal(t, 950*time.Millisecond, policy.Delay())
+
+		// the oldest latencies are replaced by the new ones
+		for i := 0; i < hedgingLatencySamples; i++ {
+			policy.observe(10 * time.Millisecond)
+		}
+		require.Equal(t, 10*time.Millisecond, policy.Delay())
+	})
+
+	t.Run("delay_is_never_below_the_min_delay", func(t *testing.T) {
+		policy := NewHedgingPolicy(50*time.Millisecond, 95, 1)
+		for i := 0; i < hedgingDelayRefreshSamples; i++ {
+			policy.observe(time.Millisecond)
+		}
+		require.Equal(t, 50*time.Millisecond, policy.Delay())
+	})
+}
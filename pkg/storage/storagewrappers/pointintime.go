@@ -0,0 +1,317 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+const pointInTimeChangelogPageSize = 100
+
+// ErrPointInTimeUnavailable is returned when the tuples of a store cannot be reconstructed as
+// they were at a point in time, e.g. because the changelog does not go back far enough.
+var ErrPointInTimeUnavailable = errors.New("the tuples cannot be reconstructed at this point in time")
+
+var _ storage.RelationshipTupleReader = (*PointInTimeTupleReader)(nil)
+
+// PointInTimeTupleReader reads the tuples of one store as they were at a point in time. Every
+// write and delete is recorded in the changelog, so the state of a tuple at that point in time
+// is given by its first change after it: a tuple first written afterward did not exist yet,
+// and a tuple first deleted afterward existed, with the condition of its last write. The
+// tuples of the other stores must not be read with it.
+//
+// The changelog is read once, from the newest change back to the point in time, plus as many
+// older changes as needed to find the last write of the tuples deleted since, so building a
+// PointInTimeTupleReader costs a changelog read per 100 changes, and memory for the tuples
+// changed since. Each read then costs a read of the wrapped datastore plus a scan of the
+// tuples that existed at the point in time and were changed since, except ReadUserTuple,
+// which costs a map lookup instead. See BenchmarkPointInTimeTupleReader.
+type PointInTimeTupleReader struct {
+	storage.RelationshipTupleReader
+
+	// changed holds the canonical keys of the tuples written or deleted since the point in time
+	changed map[string]struct{}
+	// restored holds the tuples that existed at the point in time and were changed since, by canonical key
+	restored map[string]*openfgav1.Tuple
+}
+
+// NewPointInTimeTupleReader reads the changelog of the store back to the given point in time,
+// and returns a reader of its tuples as they were then. It returns an error wrapping
+// [ErrPointInTimeUnavailable] if more than maxChanges changes must be read, unless maxChanges
+// is 0, or if the changelog does not hold the last write of a tuple deleted since.
+func NewPointInTimeTupleReader(
+	ctx context.Context,
+	ds storage.OpenFGADatastore,
+	store string,
+	at time.Time,
+	maxChanges uint32,
+) (*PointInTimeTupleReader, error) {
+	// the first change of each tuple since the point in time, as the changelog is read from the newest change
+	firstChanges := map[string]*openfgav1.TupleChange{}
+	// the tuples deleted since the point in time whose last write is not read yet
+	var pending map[string]struct{}
+	restored := map[string]*openfgav1.Tuple{}
+
+	reachPointInTime := func() {
+		pending = map[string]struct{}{}
+		for key, change := range firstChanges {
+			if change.GetOperation() == openfgav1.TupleOperation_TUPLE_OPERATION_DELETE {
+				pending[key] = struct{}{}
+			}
+		}
+	}
+
+	read := uint32(0)
+	opts := storage.ReadChangesOptions{
+		Pagination: storage.PaginationOptions{PageSize: pointInTimeChangelogPageSize},
+		SortDesc:   true,
+	}
+
+ReadLoop:
+	for {
+		changes, contToken, err := ds.ReadChanges(ctx, store, storage.ReadChangesFilter{}, opts)
+		if errors.Is(err, storage.ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, change := range changes {
+			read++
+			if maxChanges > 0 && read > maxChanges {
+				return nil, fmt.Errorf("%w: more than %d changes must be read", ErrPointInTimeUnavailable, maxChanges)
+			}
+
+			key := tuple.CanonicalTupleKey(change.GetTupleKey())
+			if change.GetTimestamp().AsTime().After(at) {
+				firstChanges[key] = change
+				continue
+			}
+
+			if pending == nil {
+				reachPointInTime()
+			}
+			if len(pending) == 0 {
+				break ReadLoop
+			}
+
+			if _, ok := pending[key]; ok && change.GetOperation() == openfgav1.TupleOperation_TUPLE_OPERATION_WRITE {
+				restored[key] = &openfgav1.Tuple{
+					Key:       change.GetTupleKey(),
+					Timestamp: change.GetTimestamp(),
+				}
+				delete(pending, key)
+			}
+		}
+
+		if len(contToken) == 0 {
+			break
+		}
+		opts.Pagination.From = string(contToken)
+	}
+
+	if pending == nil {
+		reachPointInTime()
+	}
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("%w: the changelog does not hold the last write of %d tuple(s) deleted since", ErrPointInTimeUnavailable, len(pending))
+	}
+
+	changed := make(map[string]struct{}, len(firstChanges))
+	for key := range firstChanges {
+		changed[key] = struct{}{}
+	}
+
+	return &PointInTimeTupleReader{
+		RelationshipTupleReader: ds,
+		changed:                 changed,
+		restored:                restored,
+	}, nil
+}
+
+// Read see [storage.RelationshipTupleReader].Read.
+func (p *PointInTimeTupleReader) Read(
+	ctx context.Context,
+	store string,
+	tupleKey *openfgav1.TupleKey,
+	options storage.ReadOptions,
+) (storage.TupleIterator, error) {
+	iter, err := p.RelationshipTupleReader.Read(ctx, store, tupleKey, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.correct(iter, func(tk *openfgav1.TupleKey) bool {
+		if object := tupleKey.GetObject(); object != "" {
+			objectType, objectID := tuple.SplitObject(object)
+			if objectID == "" && tuple.GetType(tk.GetObject()) != objectType {
+				return false
+			}
+			if objectID != "" && tk.GetObject() != object {
+				return false
+			}
+		}
+		return (tupleKey.GetRelation() == "" || tk.GetRelation() == tupleKey.GetRelation()) &&
+			(tupleKey.GetUser() == "" || tk.GetUser() == tupleKey.GetUser())
+	}), nil
+}
+
+// ReadPage is not supported, as a page cannot be corrected without changing the pages after it.
+func (p *PointInTimeTupleReader) ReadPage(
+	context.Context,
+	string,
+	*openfgav1.TupleKey,
+	storage.ReadPageOptions,
+) ([]*openfgav1.Tuple, []byte, error) {
+	return nil, nil, fmt.Errorf("%w: tuples cannot be read by page", ErrPointInTimeUnavailable)
+}
+
+// ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
+func (p *PointInTimeTupleReader) ReadUserTuple(
+	ctx context.Context,
+	store string,
+	tupleKey *openfgav1.TupleKey,
+	options storage.ReadUserTupleOptions,
+) (*openfgav1.Tuple, error) {
+	key := tuple.CanonicalTupleKey(tupleKey)
+	if _, ok := p.changed[key]; !ok {
+		return p.RelationshipTupleReader.ReadUserTuple(ctx, store, tupleKey, options)
+	}
+
+	if t, ok := p.restored[key]; ok {
+		return t, nil
+	}
+	return nil, storage.ErrNotFound
+}
+
+// ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
+func (p *PointInTimeTupleReader) ReadUsersetTuples(
+	ctx context.Context,
+	store string,
+	filter storage.ReadUsersetTuplesFilter,
+	options storage.ReadUsersetTuplesOptions,
+) (storage.TupleIterator, error) {
+	iter, err := p.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.correct(iter, func(tk *openfgav1.TupleKey) bool {
+		if tk.GetObject() != filter.Object || tk.GetRelation() != filter.Relation ||
+			tuple.GetUserTypeFromUser(tk.GetUser()) != tuple.UserSet {
+			return false
+		}
+		if len(filter.AllowedUserTypeRestrictions) == 0 {
+			return true
+		}
+
+		userType := tuple.GetType(tk.GetUser())
+		_, userRelation := tuple.SplitObjectRelation(tk.GetUser())
+		for _, allowed := range filter.AllowedUserTypeRestrictions {
+			if allowed.GetType() == userType && allowed.GetRelation() == userRelation {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
+func (p *PointInTimeTupleReader) ReadStartingWithUser(
+	ctx context.Context,
+	store string,
+	filter storage.ReadStartingWithUserFilter,
+	options storage.ReadStartingWithUserOptions,
+) (storage.TupleIterator, error) {
+	iter, err := p.RelationshipTupleReader.ReadStartingWithUser(ctx, store, filter, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.correct(iter, func(tk *openfgav1.TupleKey) bool {
+		objectType, objectID := tuple.SplitObject(tk.GetObject())
+		if objectType != filter.ObjectType || tk.GetRelation() != filter.Relation {
+			return false
+		}
+		if filter.ObjectIDs != nil && !filter.ObjectIDs.Exists(objectID) {
+			return false
+		}
+
+		for _, userFilter := range filter.UserFilter {
+			if tuple.GetObjectRelationAsString(userFilter) == tk.GetUser() {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// correct returns the tuples of iter that were not changed since the point in time, followed by
+// the tuples that existed at that point in time and were changed since, if they match the filter
+// of the read.
+func (p *PointInTimeTupleReader) correct(iter storage.TupleIterator, match func(*openfgav1.TupleKey) bool) storage.TupleIterator {
+	var restored []*openfgav1.Tuple
+	for _, t := range p.restored {
+		if match(t.GetKey()) {
+			restored = append(restored, t)
+		}
+	}
+
+	return storage.NewCombinedIterator[*openfgav1.Tuple](
+		&unchangedTupleIterator{iter: iter, changed: p.changed},
+		storage.NewStaticTupleIterator(restored),
+	)
+}
+
+// unchangedTupleIterator skips the tuples of iter that were changed since the point in time.
+type unchangedTupleIterator struct {
+	iter    storage.TupleIterator
+	changed map[string]struct{}
+	mu      sync.Mutex
+}
+
+var _ storage.TupleIterator = (*unchangedTupleIterator)(nil)
+
+// Next see [storage.Iterator].Next.
+func (u *unchangedTupleIterator) Next(ctx context.Context) (*openfgav1.Tuple, error) {
+	for {
+		t, err := u.iter.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := u.changed[tuple.CanonicalTupleKey(t.GetKey())]; !ok {
+			return t, nil
+		}
+	}
+}
+
+// Head see [storage.Iterator].Head.
+func (u *unchangedTupleIterator) Head(ctx context.Context) (*openfgav1.Tuple, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for {
+		t, err := u.iter.Head(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := u.changed[tuple.CanonicalTupleKey(t.GetKey())]; !ok {
+			return t, nil
+		}
+		if _, err := u.iter.Next(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Stop see [storage.Iterator].Stop.
+func (u *unchangedTupleIterator) Stop() {
+	u.iter.Stop()
+}
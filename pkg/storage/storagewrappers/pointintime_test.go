@@ -0,0 +1,196 @@
+package storagewrappers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// writeThenMark writes and deletes the tuples and returns a point in time after the change.
+func writeThenMark(t testing.TB, ds storage.OpenFGADatastore, store string, deletes []*openfgav1.TupleKeyWithoutCondition, writes []*openfgav1.TupleKey) time.Time {
+	t.Helper()
+	require.NoError(t, ds.Write(context.Background(), store, deletes, writes))
+	time.Sleep(time.Millisecond)
+	at := time.Now()
+	time.Sleep(time.Millisecond)
+	return at
+}
+
+func readAll(t *testing.T, iter storage.TupleIterator) []string {
+	t.Helper()
+	defer iter.Stop()
+
+	var keys []string
+	for {
+		tup, err := iter.Next(context.Background())
+		if err != nil {
+			require.ErrorIs(t, err, storage.ErrIteratorDone)
+			return keys
+		}
+		keys = append(keys, tuple.TupleKeyToString(tup.GetKey()))
+	}
+}
+
+func TestPointInTimeTupleReader(t *testing.T) {
+	ctx := context.Background()
+	const store = "store"
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	anne := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	bob := tuple.NewTupleKey("document:1", "viewer", "user:bob")
+	carl := tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:carl", "in_office", nil)
+	dan := tuple.NewTupleKey("document:1", "viewer", "user:dan")
+	eng := tuple.NewTupleKey("document:1", "viewer", "group:eng#member")
+
+	// before the point in time: anne and carl are written, dan is written then deleted
+	writeThenMark(t, ds, store, nil, []*openfgav1.TupleKey{anne, carl, dan, eng})
+	at := writeThenMark(t, ds, store, []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(dan)}, nil)
+
+	// after the point in time: bob is written, carl is deleted then written without a condition, eng is deleted
+	writeThenMark(t, ds, store, nil, []*openfgav1.TupleKey{bob})
+	writeThenMark(t, ds, store, []*openfgav1.TupleKeyWithoutCondition{
+		tuple.TupleKeyToTupleKeyWithoutCondition(carl),
+		tuple.TupleKeyToTupleKeyWithoutCondition(eng),
+	}, nil)
+	writeThenMark(t, ds, store, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:carl")})
+
+	reader, err := NewPointInTimeTupleReader(ctx, ds, store, at, 0)
+	require.NoError(t, err)
+
+	t.Run("read", func(t *testing.T) {
+		iter, err := reader.Read(ctx, store, tuple.NewTupleKey("document:1", "", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{
+			"document:1#viewer@user:anne",
+			"document:1#viewer@user:carl",
+			"document:1#viewer@group:eng#member",
+		}, readAll(t, iter))
+
+		iter, err = reader.Read(ctx, store, tuple.NewTupleKey("document:", "viewer", "user:carl"), storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"document:1#viewer@user:carl"}, readAll(t, iter))
+	})
+
+	t.Run("read_user_tuple", func(t *testing.T) {
+		tup, err := reader.ReadUserTuple(ctx, store, anne, storage.ReadUserTupleOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "user:anne", tup.GetKey().GetUser())
+
+		// carl had the condition of his last write before the point in time
+		tup, err = reader.ReadUserTuple(ctx, store, tuple.NewTupleKey("document:1", "viewer", "user:carl"), storage.ReadUserTupleOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "in_office", tup.GetKey().GetCondition().GetName())
+
+		_, err = reader.ReadUserTuple(ctx, store, bob, storage.ReadUserTupleOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		_, err = reader.ReadUserTuple(ctx, store, dan, storage.ReadUserTupleOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("read_userset_tuples", func(t *testing.T) {
+		iter, err := reader.ReadUsersetTuples(ctx, store, storage.ReadUsersetTuplesFilter{
+			Object:   "document:1",
+			Relation: "viewer",
+			AllowedUserTypeRestrictions: []*openfgav1.RelationReference{
+				{Type: "group", RelationOrWildcard: &openfgav1.RelationReference_Relation{Relation: "member"}},
+			},
+		}, storage.ReadUsersetTuplesOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"document:1#viewer@group:eng#member"}, readAll(t, iter))
+	})
+
+	t.Run("read_starting_with_user", func(t *testing.T) {
+		iter, err := reader.ReadStartingWithUser(ctx, store, storage.ReadStartingWithUserFilter{
+			ObjectType: "document",
+			Relation:   "viewer",
+			UserFilter: []*openfgav1.ObjectRelation{{Object: "user:carl"}, {Object: "user:bob"}},
+		}, storage.ReadStartingWithUserOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"document:1#viewer@user:carl"}, readAll(t, iter))
+	})
+
+	t.Run("read_page_is_not_supported", func(t *testing.T) {
+		_, _, err := reader.ReadPage(ctx, store, tuple.NewTupleKey("document:1", "", ""), storage.ReadPageOptions{})
+		require.ErrorIs(t, err, ErrPointInTimeUnavailable)
+	})
+
+	t.Run("before_every_change", func(t *testing.T) {
+		reader, err := NewPointInTimeTupleReader(ctx, ds, store, time.Unix(0, 0), 0)
+		require.NoError(t, err)
+
+		iter, err := reader.Read(ctx, store, tuple.NewTupleKey("document:1", "", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Empty(t, readAll(t, iter))
+	})
+
+	t.Run("empty_changelog", func(t *testing.T) {
+		reader, err := NewPointInTimeTupleReader(ctx, ds, "another", at, 0)
+		require.NoError(t, err)
+
+		iter, err := reader.Read(ctx, "another", tuple.NewTupleKey("document:1", "", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		require.Empty(t, readAll(t, iter))
+	})
+
+	t.Run("too_many_changes", func(t *testing.T) {
+		_, err := NewPointInTimeTupleReader(ctx, ds, store, at, 3)
+		require.ErrorIs(t, err, ErrPointInTimeUnavailable)
+	})
+}
+
+// BenchmarkPointInTimeTupleReader measures the cost of building a PointInTimeTupleReader, which
+// grows with the number of changes since the point in time, and of reading through it, which
+// grows with the number of tuples changed since.
+func BenchmarkPointInTimeTupleReader(b *testing.B) {
+	ctx := context.Background()
+	const store = "store"
+
+	for _, changesSince := range []int{100, 1000, 10000} {
+		ds := memory.New()
+
+		var writes []*openfgav1.TupleKey
+		for i := 0; i < 1000; i++ {
+			writes = append(writes, tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "viewer", "user:anne"))
+		}
+		at := writeThenMark(b, ds, store, nil, writes)
+
+		for i := 0; i < changesSince; i += 100 {
+			var deletes []*openfgav1.TupleKeyWithoutCondition
+			for j := i; j < i+100; j++ {
+				deletes = append(deletes, tuple.TupleKeyToTupleKeyWithoutCondition(writes[j%len(writes)]))
+			}
+			require.NoError(b, ds.Write(ctx, store, deletes, nil))
+			require.NoError(b, ds.Write(ctx, store, nil, writes[i%len(writes):i%len(writes)+100]))
+		}
+
+		b.Run(fmt.Sprintf("build_with_%d_changes_since", 2*changesSince), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				_, err := NewPointInTimeTupleReader(ctx, ds, store, at, 0)
+				require.NoError(b, err)
+			}
+		})
+
+		reader, err := NewPointInTimeTupleReader(ctx, ds, store, at, 0)
+		require.NoError(b, err)
+
+		b.Run(fmt.Sprintf("read_user_tuple_with_%d_changes_since", 2*changesSince), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				_, err := reader.ReadUserTuple(ctx, store, writes[n%len(writes)], storage.ReadUserTupleOptions{})
+				require.NoError(b, err)
+			}
+		})
+
+		ds.Close()
+	}
+}
@@ -0,0 +1,112 @@
+package storagewrappers
+
+import (
+	"context"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// QueryKind is the kind of a tuple query, which hints at its cost in the datastore: ReadUserTuple
+// is a point read, while Read, ReadUsersetTuples and ReadStartingWithUser scan ranges of tuples,
+// the reverse reads of ReadStartingWithUser being the most expensive.
+type QueryKind int
+
+const (
+	// ReadQuery counts the Read and ReadPage calls.
+	ReadQuery QueryKind = iota
+	ReadUserTupleQuery
+	ReadUsersetTuplesQuery
+	ReadStartingWithUserQuery
+)
+
+// QueryKinds are all the kinds of tuple queries, in the order of their values.
+var QueryKinds = [...]QueryKind{ReadQuery, ReadUserTupleQuery, ReadUsersetTuplesQuery, ReadStartingWithUserQuery}
+
+func (k QueryKind) String() string {
+	switch k {
+	case ReadQuery:
+		return "read"
+	case ReadUserTupleQuery:
+		return "read_user_tuple"
+	case ReadUsersetTuplesQuery:
+		return "read_userset_tuples"
+	case ReadStartingWithUserQuery:
+		return "read_starting_with_user"
+	default:
+		return "unknown"
+	}
+}
+
+// QueryCounter counts the tuple queries of a request by kind. It must be safe for concurrent use.
+type QueryCounter interface {
+	AddQuery(kind QueryKind)
+}
+
+var _ storage.RelationshipTupleReader = (*QueryCountingTupleReader)(nil)
+
+type QueryCountingTupleReader struct {
+	storage.RelationshipTupleReader
+	counter QueryCounter
+}
+
+// NewQueryCountingTupleReader returns a wrapper over a datastore that counts its calls to Read,
+// ReadPage, ReadUserTuple, ReadUsersetTuples and ReadStartingWithUser in counter, by [QueryKind].
+// Calls that fail are counted too.
+func NewQueryCountingTupleReader(wrapped storage.RelationshipTupleReader, counter QueryCounter) *QueryCountingTupleReader {
+	return &QueryCountingTupleReader{
+		RelationshipTupleReader: wrapped,
+		counter:                 counter,
+	}
+}
+
+// Read see [storage.RelationshipTupleReader].Read.
+func (q *QueryCountingTupleReader) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	q.counter.AddQuery(ReadQuery)
+	return q.RelationshipTupleReader.Read(ctx, store, tupleKey, options)
+}
+
+// ReadPage see [storage.RelationshipTupleReader].ReadPage.
+func (q *QueryCountingTupleReader) ReadPage(
+	ctx context.Context,
+	store string,
+	tupleKey *openfgav1.TupleKey,
+	options storage.ReadPageOptions,
+) ([]*openfgav1.Tuple, []byte, error) {
+	q.counter.AddQuery(ReadQuery)
+	return q.RelationshipTupleReader.ReadPage(ctx, store, tupleKey, options)
+}
+
+// ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
+func (q *QueryCountingTupleReader) ReadUserTuple(
+	ctx context.Context,
+	store string,
+	tupleKey *openfgav1.TupleKey,
+	options storage.ReadUserTupleOptions,
+) (*openfgav1.Tuple, error) {
+	q.counter.AddQuery(ReadUserTupleQuery)
+	return q.RelationshipTupleReader.ReadUserTuple(ctx, store, tupleKey, options)
+}
+
+// ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
+func (q *QueryCountingTupleReader) ReadUsersetTuples(
+	ctx context.Context,
+	store string,
+	filter storage.ReadUsersetTuplesFilter,
+	options storage.ReadUsersetTuplesOptions,
+) (storage.TupleIterator, error) {
+	q.counter.AddQuery(ReadUsersetTuplesQuery)
+	return q.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter, options)
+}
+
+// ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
+func (q *QueryCountingTupleReader) ReadStartingWithUser(
+	ctx context.Context,
+	store string,
+	filter storage.ReadStartingWithUserFilter,
+	options storage.ReadStartingWithUserOptions,
+) (storage.TupleIterator, error) {
+	q.counter.AddQuery(ReadStartingWithUserQuery)
+	return q.RelationshipTupleReader.ReadStartingWithUser(ctx, store, filter, options)
+}
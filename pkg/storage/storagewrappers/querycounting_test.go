@@ -0,0 +1,76 @@
+package storagewrappers
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+type queryCounts struct {
+	mu     sync.Mutex
+	counts map[QueryKind]int
+}
+
+func (c *queryCounts) AddQuery(kind QueryKind) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[kind]++
+}
+
+func TestQueryCountingTupleReader(t *testing.T) {
+	ctx := context.Background()
+
+	mds := memory.New()
+	t.Cleanup(mds.Close)
+
+	tk := tuple.NewTupleKey("doc:1", "viewer", "user:anne")
+	require.NoError(t, mds.Write(ctx, "store", nil, []*openfgav1.TupleKey{tk}))
+
+	counter := &queryCounts{counts: map[QueryKind]int{}}
+	ds := NewQueryCountingTupleReader(mds, counter)
+
+	_, err := ds.ReadUserTuple(ctx, "store", tk, storage.ReadUserTupleOptions{})
+	require.NoError(t, err)
+	_, err = ds.ReadUserTuple(ctx, "store", tuple.NewTupleKey("doc:1", "viewer", "user:bob"), storage.ReadUserTupleOptions{})
+	require.ErrorIs(t, err, storage.ErrNotFound)
+
+	iter, err := ds.Read(ctx, "store", tuple.NewTupleKey("doc:1", "viewer", ""), storage.ReadOptions{})
+	require.NoError(t, err)
+	iter.Stop()
+	_, _, err = ds.ReadPage(ctx, "store", nil, storage.ReadPageOptions{Pagination: storage.NewPaginationOptions(1, "")})
+	require.NoError(t, err)
+
+	iter, err = ds.ReadUsersetTuples(ctx, "store", storage.ReadUsersetTuplesFilter{Object: "doc:1", Relation: "viewer"}, storage.ReadUsersetTuplesOptions{})
+	require.NoError(t, err)
+	iter.Stop()
+
+	iter, err = ds.ReadStartingWithUser(ctx, "store", storage.ReadStartingWithUserFilter{
+		ObjectType: "doc",
+		Relation:   "viewer",
+		UserFilter: []*openfgav1.ObjectRelation{{Object: "user:anne"}},
+	}, storage.ReadStartingWithUserOptions{})
+	require.NoError(t, err)
+	iter.Stop()
+
+	require.Equal(t, map[QueryKind]int{
+		ReadQuery:                 2,
+		ReadUserTupleQuery:        2,
+		ReadUsersetTuplesQuery:    1,
+		ReadStartingWithUserQuery: 1,
+	}, counter.counts)
+}
+
+func TestQueryKindString(t *testing.T) {
+	names := make([]string, 0, len(QueryKinds))
+	for _, kind := range QueryKinds {
+		names = append(names, kind.String())
+	}
+	require.Equal(t, []string{"read", "read_user_tuple", "read_userset_tuples", "read_starting_with_user"}, names)
+}
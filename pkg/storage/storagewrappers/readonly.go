@@ -0,0 +1,48 @@
+package storagewrappers
+
+import (
+	"context"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// ReadOnlyDatastore is a wrapper for a datastore that rejects every write with
+// [storage.ErrReadOnly] without calling the underlying datastore, e.g. when it is a
+// read replica of the database.
+type ReadOnlyDatastore struct {
+	storage.OpenFGADatastore
+}
+
+var _ storage.OpenFGADatastore = (*ReadOnlyDatastore)(nil)
+
+// NewReadOnlyDatastore creates a new instance of [ReadOnlyDatastore], wrapping the specified datastore.
+func NewReadOnlyDatastore(inner storage.OpenFGADatastore) *ReadOnlyDatastore {
+	return &ReadOnlyDatastore{inner}
+}
+
+// Write see [storage.RelationshipTupleWriter].Write.
+func (r *ReadOnlyDatastore) Write(context.Context, string, storage.Deletes, storage.Writes) error {
+	return storage.ErrReadOnly
+}
+
+// WriteAuthorizationModel see [storage.TypeDefinitionWriteBackend].WriteAuthorizationModel.
+func (r *ReadOnlyDatastore) WriteAuthorizationModel(context.Context, string, *openfgav1.AuthorizationModel) error {
+	return storage.ErrReadOnly
+}
+
+// CreateStore see [storage.StoresBackend].CreateStore.
+func (r *ReadOnlyDatastore) CreateStore(context.Context, *openfgav1.Store) (*openfgav1.Store, error) {
+	return nil, storage.ErrReadOnly
+}
+
+// DeleteStore see [storage.StoresBackend].DeleteStore.
+func (r *ReadOnlyDatastore) DeleteStore(context.Context, string) error {
+	return storage.ErrReadOnly
+}
+
+// WriteAssertions see [storage.AssertionsBackend].WriteAssertions.
+func (r *ReadOnlyDatastore) WriteAssertions(context.Context, string, string, []*openfgav1.Assertion) error {
+	return storage.ErrReadOnly
+}
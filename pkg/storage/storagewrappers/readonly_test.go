@@ -0,0 +1,46 @@
+package storagewrappers
+
+import (
+	"context"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestReadOnlyDatastore(t *testing.T) {
+	ctx := context.Background()
+
+	mds := memory.New()
+	t.Cleanup(mds.Close)
+
+	tk := tuple.NewTupleKey("doc:1", "viewer", "user:anne")
+	require.NoError(t, mds.Write(ctx, "store", nil, []*openfgav1.TupleKey{tk}))
+
+	ds := NewReadOnlyDatastore(mds)
+
+	_, err := ds.ReadUserTuple(ctx, "store", tk, storage.ReadUserTupleOptions{})
+	require.NoError(t, err)
+
+	err = ds.Write(ctx, "store", []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tk)}, nil)
+	require.ErrorIs(t, err, storage.ErrReadOnly)
+
+	err = ds.WriteAuthorizationModel(ctx, "store", &openfgav1.AuthorizationModel{Id: "model"})
+	require.ErrorIs(t, err, storage.ErrReadOnly)
+
+	_, err = ds.CreateStore(ctx, &openfgav1.Store{Id: "another"})
+	require.ErrorIs(t, err, storage.ErrReadOnly)
+
+	err = ds.DeleteStore(ctx, "store")
+	require.ErrorIs(t, err, storage.ErrReadOnly)
+
+	err = ds.WriteAssertions(ctx, "store", "model", nil)
+	require.ErrorIs(t, err, storage.ErrReadOnly)
+
+	_, err = mds.ReadUserTuple(ctx, "store", tk, storage.ReadUserTupleOptions{})
+	require.NoError(t, err)
+}
@@ -0,0 +1,928 @@
+package storagewrappers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+var (
+	_ storage.OpenFGADatastore        = (*RoutingDatastore)(nil)
+	_ storage.SnapshotBeginner        = (*RoutingDatastore)(nil)
+	_ storage.RelationCounter         = (*RoutingDatastore)(nil)
+	_ storage.RelationUsageCounter    = (*RoutingDatastore)(nil)
+	_ storage.IndexAdvisor            = (*RoutingDatastore)(nil)
+	_ storage.ModelAliasBackend       = (*RoutingDatastore)(nil)
+	_ storage.TupleCounter            = (*RoutingDatastore)(nil)
+	_ storage.StoreCounter            = (*RoutingDatastore)(nil)
+	_ storage.StorePurger             = (*RoutingDatastore)(nil)
+	_ storage.ChangelogTrimmer        = (*RoutingDatastore)(nil)
+	_ storage.ChangelogStatsReader    = (*RoutingDatastore)(nil)
+	_ storage.UniqueStoreNamesBackend = (*RoutingDatastore)(nil)
+)
+
+const (
+	// DefaultUnknownStoreTTL is how long a [RoutingDatastore] remembers that a store ID was not
+	// found in any partition by default.
+	DefaultUnknownStoreTTL = 30 * time.Second
+
+	// unknownStoresCacheSize is the number of store IDs not found in any partition that a
+	// [RoutingDatastore] remembers.
+	unknownStoresCacheSize = 10000
+)
+
+// CreateStorePolicy returns the name of the partition of a [RoutingDatastore] that a new store
+// is created in, e.g. based on the caller in the context or on the name of the store.
+type CreateStorePolicy func(ctx context.Context, store *openfgav1.Store) (string, error)
+
+// RoutingDatastoreOption configures a [RoutingDatastore].
+type RoutingDatastoreOption func(*RoutingDatastore)
+
+// WithStoreRoute routes the store with the ID to the partition.
+func WithStoreRoute(storeID, partition string) RoutingDatastoreOption {
+	return func(r *RoutingDatastore) {
+		r.storeRoutes[storeID] = partition
+	}
+}
+
+// WithStorePrefixRoute routes the stores whose ID starts with the prefix to the partition. When
+// several prefixes match a store ID, the longest one wins.
+func WithStorePrefixRoute(prefix, partition string) RoutingDatastoreOption {
+	return func(r *RoutingDatastore) {
+		r.prefixRoutes = append(r.prefixRoutes, prefixRoute{prefix: prefix, partition: partition})
+	}
+}
+
+// WithCreateStorePolicy sets the policy that chooses the partition of the new stores whose ID
+// has no route. By default they are created in the default partition.
+func WithCreateStorePolicy(policy CreateStorePolicy) RoutingDatastoreOption {
+	return func(r *RoutingDatastore) {
+		r.createStorePolicy = policy
+	}
+}
+
+// WithUnknownStoreTTL sets how long the router remembers that a store ID was not found in any
+// partition, during which the calls for the store go to the default partition without looking
+// it up again. See [DefaultUnknownStoreTTL].
+func WithUnknownStoreTTL(ttl time.Duration) RoutingDatastoreOption {
+	return func(r *RoutingDatastore) {
+		r.unknownStoreTTL = ttl
+	}
+}
+
+type prefixRoute struct {
+	prefix    string
+	partition string
+}
+
+// RoutingDatastore is a datastore that keeps each store in one of several partitions, e.g. to keep
+// the tuples of the stores of EU tenants in an EU database, and delegates each call to the
+// partition of its store. A store is routed, in order:
+//
+//   - by its route (see [WithStoreRoute]) or longest prefix route (see [WithStorePrefixRoute]);
+//   - to the partition it was created in through the router;
+//   - to the partition in which GetStore finds it, for the stores created before the router
+//     started;
+//   - to the default partition.
+//
+// ListStores lists the stores of the partitions one after the other, in the order of their
+// names, with a continuation token that carries the partition and its own token.
+//
+// The router implements the optional interfaces of the datastores, e.g. [storage.TupleCounter],
+// by delegating to the partition of the store, or to every partition for the calls that are
+// not about one store. If the partition does not implement the interface, the call returns
+// [storage.ErrUnimplemented].
+type RoutingDatastore struct {
+	partitions       map[string]storage.OpenFGADatastore
+	partitionNames   []string
+	defaultPartition string
+
+	storeRoutes       map[string]string
+	prefixRoutes      []prefixRoute
+	createStorePolicy CreateStorePolicy
+
+	// located are the partitions of the stores found without a route, by store ID.
+	located sync.Map
+	// unknown are the store IDs that were not found in any partition, until the TTL expires.
+	unknown         *storage.InMemoryLRUCache[struct{}]
+	unknownStoreTTL time.Duration
+	lookups         singleflight.Group
+
+	// uniqueNamesMu serializes the creation of the stores with unique names, whose name is
+	// looked up in the other partitions first.
+	uniqueNamesMu sync.Mutex
+}
+
+// NewRoutingDatastore returns a datastore that routes the stores to the partitions, by name, and
+// to the default partition when they have no route and are not found in any partition. It fails
+// if a route or the default partition is not one of the partitions.
+func NewRoutingDatastore(partitions map[string]storage.OpenFGADatastore, defaultPartition string, opts ...RoutingDatastoreOption) (*RoutingDatastore, error) {
+	r := &RoutingDatastore{
+		partitions:       partitions,
+		defaultPartition: defaultPartition,
+		storeRoutes:      make(map[string]string),
+		unknownStoreTTL:  DefaultUnknownStoreTTL,
+	}
+	r.createStorePolicy = func(context.Context, *openfgav1.Store) (string, error) {
+		return r.defaultPartition, nil
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	for name := range partitions {
+		r.partitionNames = append(r.partitionNames, name)
+	}
+	slices.Sort(r.partitionNames)
+
+	if _, ok := partitions[defaultPartition]; !ok {
+		return nil, fmt.Errorf("the default partition '%s' is not one of the partitions", defaultPartition)
+	}
+	for storeID, partition := range r.storeRoutes {
+		if _, ok := partitions[partition]; !ok {
+			return nil, fmt.Errorf("the route of the store '%s' is to the unknown partition '%s'", storeID, partition)
+		}
+	}
+	for _, route := range r.prefixRoutes {
+		if _, ok := partitions[route.partition]; !ok {
+			return nil, fmt.Errorf("the route of the prefix '%s' is to the unknown partition '%s'", route.prefix, route.partition)
+		}
+	}
+	// the longest prefix is found first
+	slices.SortStableFunc(r.prefixRoutes, func(a, b prefixRoute) int {
+		return len(b.prefix) - len(a.prefix)
+	})
+	r.unknown = storage.NewInMemoryLRUCache(storage.WithMaxCacheSize[struct{}](unknownStoresCacheSize))
+
+	return r, nil
+}
+
+// route returns the name of the partition of the store.
+func (r *RoutingDatastore) route(storeID string) (string, bool) {
+	if partition, ok := r.storeRoutes[storeID]; ok {
+		return partition, true
+	}
+	for _, route := range r.prefixRoutes {
+		if strings.HasPrefix(storeID, route.prefix) {
+			return route.partition, true
+		}
+	}
+	return "", false
+}
+
+// partition returns the partition of the store, looking it up in the partitions if it has no
+// route.
+func (r *RoutingDatastore) partition(ctx context.Context, storeID string) (storage.OpenFGADatastore, error) {
+	name, err := r.partitionName(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	return r.partitions[name], nil
+}
+
+// partitionName returns the name of the partition of the store. A store without a route is
+// looked up once in all the partitions at the same time. If no partition has it, the store is
+// routed to the default partition, which is remembered for the unknown store TTL.
+func (r *RoutingDatastore) partitionName(ctx context.Context, storeID string) (string, error) {
+	if partition, ok := r.route(storeID); ok {
+		return partition, nil
+	}
+	if partition, ok := r.located.Load(storeID); ok {
+		return partition.(string), nil
+	}
+	if cached := r.unknown.Get(storeID); cached != nil && !cached.Expired {
+		return r.defaultPartition, nil
+	}
+
+	name, err, _ := r.lookups.Do(storeID, func() (interface{}, error) {
+		return r.locate(ctx, storeID)
+	})
+	if err != nil {
+		return "", err
+	}
+	return name.(string), nil
+}
+
+// locate looks the store up in all the partitions.
+func (r *RoutingDatastore) locate(ctx context.Context, storeID string) (string, error) {
+	found := make([]bool, len(r.partitionNames))
+	g, gctx := errgroup.WithContext(ctx)
+	for i, name := range r.partitionNames {
+		g.Go(func() error {
+			_, err := r.partitions[name].GetStore(gctx, storeID)
+			if err == nil {
+				found[i] = true
+				return nil
+			}
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil
+			}
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return "", err
+	}
+
+	for i, name := range r.partitionNames {
+		if found[i] {
+			r.located.Store(storeID, name)
+			return name, nil
+		}
+	}
+
+	// the store does not exist, so any partition answers the same
+	r.unknown.Set(storeID, struct{}{}, r.unknownStoreTTL)
+	return r.defaultPartition, nil
+}
+
+// Read see [storage.RelationshipTupleReader].Read.
+func (r *RoutingDatastore) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	partition, err := r.partition(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return partition.Read(ctx, store, tupleKey, options)
+}
+
+// ReadPage see [storage.RelationshipTupleReader].ReadPage.
+func (r *RoutingDatastore) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadPageOptions) ([]*openfgav1.Tuple, []byte, error) {
+	partition, err := r.partition(ctx, store)
+	if err != nil {
+		return nil, nil, err
+	}
+	return partition.ReadPage(ctx, store, tupleKey, options)
+}
+
+// ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
+func (r *RoutingDatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadUserTupleOptions) (*openfgav1.Tuple, error) {
+	partition, err := r.partition(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return partition.ReadUserTuple(ctx, store, tupleKey, options)
+}
+
+// ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
+func (r *RoutingDatastore) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, options storage.ReadUsersetTuplesOptions) (storage.TupleIterator, error) {
+	partition, err := r.partition(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return partition.ReadUsersetTuples(ctx, store, filter, options)
+}
+
+// ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
+func (r *RoutingDatastore) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter, options storage.ReadStartingWithUserOptions) (storage.TupleIterator, error) {
+	partition, err := r.partition(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return partition.ReadStartingWithUser(ctx, store, filter, options)
+}
+
+// Write see [storage.RelationshipTupleWriter].Write.
+func (r *RoutingDatastore) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
+	partition, err := r.partition(ctx, store)
+	if err != nil {
+		return err
+	}
+	return partition.Write(ctx, store, deletes, writes)
+}
+
+// MaxTuplesPerWrite see [storage.RelationshipTupleWriter].MaxTuplesPerWrite. It is the lowest of
+// the partitions.
+func (r *RoutingDatastore) MaxTuplesPerWrite() int {
+	return r.lowest(storage.OpenFGADatastore.MaxTuplesPerWrite)
+}
+
+// ReadAuthorizationModel see [storage.AuthorizationModelReadBackend].ReadAuthorizationModel.
+func (r *RoutingDatastore) ReadAuthorizationModel(ctx context.Context, store string, id string) (*openfgav1.AuthorizationModel, error) {
+	partition, err := r.partition(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return partition.ReadAuthorizationModel(ctx, store, id)
+}
+
+// ReadAuthorizationModels see [storage.AuthorizationModelReadBackend].ReadAuthorizationModels.
+func (r *RoutingDatastore) ReadAuthorizationModels(ctx context.Context, store string, options storage.ReadAuthorizationModelsOptions) ([]*openfgav1.AuthorizationModel, []byte, error) {
+	partition, err := r.partition(ctx, store)
+	if err != nil {
+		return nil, nil, err
+	}
+	return partition.ReadAuthorizationModels(ctx, store, options)
+}
+
+// FindLatestAuthorizationModel see [storage.AuthorizationModelReadBackend].FindLatestAuthorizationModel.
+func (r *RoutingDatastore) FindLatestAuthorizationModel(ctx context.Context, store string) (*openfgav1.AuthorizationModel, error) {
+	partition, err := r.partition(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return partition.FindLatestAuthorizationModel(ctx, store)
+}
+
+// MaxTypesPerAuthorizationModel see [storage.TypeDefinitionWriteBackend].MaxTypesPerAuthorizationModel.
+// It is the lowest of the partitions.
+func (r *RoutingDatastore) MaxTypesPerAuthorizationModel() int {
+	return r.lowest(storage.OpenFGADatastore.MaxTypesPerAuthorizationModel)
+}
+
+// WriteAuthorizationModel see [storage.TypeDefinitionWriteBackend].WriteAuthorizationModel.
+func (r *RoutingDatastore) WriteAuthorizationModel(ctx context.Context, store string, model *openfgav1.AuthorizationModel) error {
+	partition, err := r.partition(ctx, store)
+	if err != nil {
+		return err
+	}
+	return partition.WriteAuthorizationModel(ctx, store, model)
+}
+
+// CreateStore see [storage.StoresBackend].CreateStore. The store is created in the partition of
+// its route if it has one, and else in the partition chosen by the [CreateStorePolicy].
+func (r *RoutingDatastore) CreateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	name, err := r.createStorePartition(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := r.partitions[name].CreateStore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	r.located.Store(created.GetId(), name)
+	return created, nil
+}
+
+// createStorePartition returns the name of the partition that the store is created in.
+func (r *RoutingDatastore) createStorePartition(ctx context.Context, store *openfgav1.Store) (string, error) {
+	if name, ok := r.route(store.GetId()); ok {
+		return name, nil
+	}
+	name, err := r.createStorePolicy(ctx, store)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := r.partitions[name]; !ok {
+		return "", fmt.Errorf("the create store policy chose the unknown partition '%s'", name)
+	}
+	return name, nil
+}
+
+// DeleteStore see [storage.StoresBackend].DeleteStore.
+func (r *RoutingDatastore) DeleteStore(ctx context.Context, id string) error {
+	partition, err := r.partition(ctx, id)
+	if err != nil {
+		return err
+	}
+	return partition.DeleteStore(ctx, id)
+}
+
+// GetStore see [storage.StoresBackend].GetStore.
+func (r *RoutingDatastore) GetStore(ctx context.Context, id string) (*openfgav1.Store, error) {
+	partition, err := r.partition(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return partition.GetStore(ctx, id)
+}
+
+// routingContinuationToken is the continuation token of [RoutingDatastore.ListStores].
+type routingContinuationToken struct {
+	// Partition is the partition that the next page starts in.
+	Partition string `json:"partition"`
+	// Token is the continuation token of the partition.
+	Token string `json:"token,omitempty"`
+}
+
+// ListStores see [storage.StoresBackend].ListStores. It lists the stores of the partitions one
+// after the other, filling each page from the next partitions when a partition runs out of
+// stores.
+func (r *RoutingDatastore) ListStores(ctx context.Context, options storage.ListStoresOptions) ([]*openfgav1.Store, []byte, error) {
+	next := routingContinuationToken{Partition: r.partitionNames[0]}
+	if options.Pagination.From != "" {
+		if err := json.Unmarshal([]byte(options.Pagination.From), &next); err != nil {
+			return nil, nil, storage.ErrInvalidContinuationToken
+		}
+	}
+	i := slices.Index(r.partitionNames, next.Partition)
+	if i < 0 {
+		return nil, nil, storage.ErrInvalidContinuationToken
+	}
+
+	pageSize := storage.DefaultPageSize
+	if options.Pagination.PageSize > 0 {
+		pageSize = options.Pagination.PageSize
+	}
+
+	var stores []*openfgav1.Store
+	token := next.Token
+	for ; i < len(r.partitionNames); i++ {
+		page, contToken, err := r.partitions[r.partitionNames[i]].ListStores(ctx, storage.ListStoresOptions{
+			Pagination: storage.PaginationOptions{PageSize: pageSize - len(stores), From: token},
+			Name:       options.Name,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		stores = append(stores, page...)
+
+		token = string(contToken)
+		if token != "" {
+			// the partition has more stores than fit in the page
+			return r.listStoresPage(stores, routingContinuationToken{Partition: r.partitionNames[i], Token: token})
+		}
+		if len(stores) == pageSize {
+			break
+		}
+	}
+
+	if i+1 >= len(r.partitionNames) {
+		return stores, nil, nil
+	}
+	return r.listStoresPage(stores, routingContinuationToken{Partition: r.partitionNames[i+1]})
+}
+
+func (r *RoutingDatastore) listStoresPage(stores []*openfgav1.Store, next routingContinuationToken) ([]*openfgav1.Store, []byte, error) {
+	contToken, err := json.Marshal(next)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stores, contToken, nil
+}
+
+// WriteAssertions see [storage.AssertionsBackend].WriteAssertions.
+func (r *RoutingDatastore) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
+	partition, err := r.partition(ctx, store)
+	if err != nil {
+		return err
+	}
+	return partition.WriteAssertions(ctx, store, modelID, assertions)
+}
+
+// ReadAssertions see [storage.AssertionsBackend].ReadAssertions.
+func (r *RoutingDatastore) ReadAssertions(ctx context.Context, store, modelID string, options storage.ReadAssertionsOptions) ([]*openfgav1.Assertion, []byte, error) {
+	partition, err := r.partition(ctx, store)
+	if err != nil {
+		return nil, nil, err
+	}
+	return partition.ReadAssertions(ctx, store, modelID, options)
+}
+
+// ReadChanges see [storage.ChangelogBackend].ReadChanges.
+func (r *RoutingDatastore) ReadChanges(ctx context.Context, store string, filter storage.ReadChangesFilter, options storage.ReadChangesOptions) ([]*openfgav1.TupleChange, []byte, error) {
+	partition, err := r.partition(ctx, store)
+	if err != nil {
+		return nil, nil, err
+	}
+	return partition.ReadChanges(ctx, store, filter, options)
+}
+
+// IsReady see [storage.OpenFGADatastore].IsReady. The router is ready when all its partitions are.
+func (r *RoutingDatastore) IsReady(ctx context.Context) (storage.ReadinessStatus, error) {
+	for _, name := range r.partitionNames {
+		status, err := r.partitions[name].IsReady(ctx)
+		if err != nil {
+			return storage.ReadinessStatus{}, fmt.Errorf("partition '%s': %w", name, err)
+		}
+		if !status.IsReady {
+			status.Message = fmt.Sprintf("partition '%s': %s", name, status.Message)
+			return status, nil
+		}
+	}
+	return storage.ReadinessStatus{IsReady: true}, nil
+}
+
+// Close see [storage.OpenFGADatastore].Close. It closes all the partitions.
+func (r *RoutingDatastore) Close() {
+	r.unknown.Stop()
+	for _, name := range r.partitionNames {
+		r.partitions[name].Close()
+	}
+}
+
+// lowest returns the lowest value of the limit across the partitions.
+func (r *RoutingDatastore) lowest(limit func(storage.OpenFGADatastore) int) int {
+	lowest := 0
+	for i, name := range r.partitionNames {
+		if value := limit(r.partitions[name]); i == 0 || value < lowest {
+			lowest = value
+		}
+	}
+	return lowest
+}
+
+// unimplemented returns the error of a call to a partition that does not implement the
+// optional interface.
+func unimplemented(partition, iface string) error {
+	return fmt.Errorf("%w: partition '%s' does not implement %s", storage.ErrUnimplemented, partition, iface)
+}
+
+// partitionFor returns the partition of the store as the optional interface T.
+func partitionFor[T any](ctx context.Context, r *RoutingDatastore, storeID, iface string) (T, error) {
+	var zero T
+	name, err := r.partitionName(ctx, storeID)
+	if err != nil {
+		return zero, err
+	}
+	partition, ok := r.partitions[name].(T)
+	if !ok {
+		return zero, unimplemented(name, iface)
+	}
+	return partition, nil
+}
+
+// allPartitions calls f with every partition as the optional interface T, in the order of
+// their names.
+func allPartitions[T any](r *RoutingDatastore, iface string, f func(name string, partition T) error) error {
+	for _, name := range r.partitionNames {
+		partition, ok := r.partitions[name].(T)
+		if !ok {
+			return unimplemented(name, iface)
+		}
+		if err := f(name, partition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BeginSnapshot see [storage.SnapshotBeginner].BeginSnapshot. The snapshot of each partition
+// begins when the first read of one of its stores runs.
+func (r *RoutingDatastore) BeginSnapshot(ctx context.Context, options storage.SnapshotOptions) (storage.SnapshotTupleReader, error) {
+	return &routingSnapshot{router: r, options: options, snapshots: make(map[string]storage.SnapshotTupleReader)}, nil
+}
+
+// routingSnapshot reads the tuples of each partition of a [RoutingDatastore] from a snapshot of
+// the partition.
+type routingSnapshot struct {
+	router  *RoutingDatastore
+	options storage.SnapshotOptions
+
+	mu        sync.Mutex
+	snapshots map[string]storage.SnapshotTupleReader
+	released  bool
+}
+
+var _ storage.SnapshotTupleReader = (*routingSnapshot)(nil)
+
+// snapshot returns the snapshot of the partition of the store, beginning it if needed.
+func (s *routingSnapshot) snapshot(ctx context.Context, storeID string) (storage.SnapshotTupleReader, error) {
+	name, err := s.router.partitionName(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.released {
+		return nil, errors.New("the snapshot was released")
+	}
+	if snapshot, ok := s.snapshots[name]; ok {
+		return snapshot, nil
+	}
+	beginner, ok := s.router.partitions[name].(storage.SnapshotBeginner)
+	if !ok {
+		return nil, unimplemented(name, "storage.SnapshotBeginner")
+	}
+	snapshot, err := beginner.BeginSnapshot(ctx, s.options)
+	if err != nil {
+		return nil, err
+	}
+	s.snapshots[name] = snapshot
+	return snapshot, nil
+}
+
+// Read see [storage.RelationshipTupleReader].Read.
+func (s *routingSnapshot) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	snapshot, err := s.snapshot(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.Read(ctx, store, tupleKey, options)
+}
+
+// ReadPage see [storage.RelationshipTupleReader].ReadPage.
+func (s *routingSnapshot) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadPageOptions) ([]*openfgav1.Tuple, []byte, error) {
+	snapshot, err := s.snapshot(ctx, store)
+	if err != nil {
+		return nil, nil, err
+	}
+	return snapshot.ReadPage(ctx, store, tupleKey, options)
+}
+
+// ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
+func (s *routingSnapshot) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadUserTupleOptions) (*openfgav1.Tuple, error) {
+	snapshot, err := s.snapshot(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.ReadUserTuple(ctx, store, tupleKey, options)
+}
+
+// ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
+func (s *routingSnapshot) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, options storage.ReadUsersetTuplesOptions) (storage.TupleIterator, error) {
+	snapshot, err := s.snapshot(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.ReadUsersetTuples(ctx, store, filter, options)
+}
+
+// ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
+func (s *routingSnapshot) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter, options storage.ReadStartingWithUserOptions) (storage.TupleIterator, error) {
+	snapshot, err := s.snapshot(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.ReadStartingWithUser(ctx, store, filter, options)
+}
+
+// Release releases the snapshots of the partitions.
+func (s *routingSnapshot) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.released = true
+	for name, snapshot := range s.snapshots {
+		snapshot.Release()
+		delete(s.snapshots, name)
+	}
+}
+
+// CountRelation see [storage.RelationCounter].CountRelation.
+func (r *RoutingDatastore) CountRelation(ctx context.Context, store, objectType, relation string) (storage.RelationCounts, error) {
+	counter, err := partitionFor[storage.RelationCounter](ctx, r, store, "storage.RelationCounter")
+	if err != nil {
+		return storage.RelationCounts{}, err
+	}
+	return counter.CountRelation(ctx, store, objectType, relation)
+}
+
+// CountRelationUsage see [storage.RelationUsageCounter].CountRelationUsage.
+func (r *RoutingDatastore) CountRelationUsage(ctx context.Context, store string) ([]storage.RelationUsage, error) {
+	counter, err := partitionFor[storage.RelationUsageCounter](ctx, r, store, "storage.RelationUsageCounter")
+	if err != nil {
+		return nil, err
+	}
+	return counter.CountRelationUsage(ctx, store)
+}
+
+// AdviseIndexes see [storage.IndexAdvisor].AdviseIndexes. It combines the advice of the
+// partitions that sample their queries, prefixing the datastore and the reasons of the
+// recommendations with the name of the partition.
+func (r *RoutingDatastore) AdviseIndexes(ctx context.Context) (storage.IndexAdvice, error) {
+	var advice storage.IndexAdvice
+	var datastores []string
+	err := allPartitions(r, "storage.IndexAdvisor", func(name string, advisor storage.IndexAdvisor) error {
+		partitionAdvice, err := advisor.AdviseIndexes(ctx)
+		if errors.Is(err, storage.ErrQueryShapeSamplingDisabled) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		datastores = append(datastores, fmt.Sprintf("%s: %s", name, partitionAdvice.Datastore))
+		if advice.SampledSince.IsZero() || partitionAdvice.SampledSince.Before(advice.SampledSince) {
+			advice.SampledSince = partitionAdvice.SampledSince
+		}
+		advice.Shapes = append(advice.Shapes, partitionAdvice.Shapes...)
+		for _, recommendation := range partitionAdvice.Recommendations {
+			recommendation.Reason = fmt.Sprintf("partition '%s': %s", name, recommendation.Reason)
+			advice.Recommendations = append(advice.Recommendations, recommendation)
+		}
+		return nil
+	})
+	if err != nil {
+		return storage.IndexAdvice{}, err
+	}
+	if len(datastores) == 0 {
+		return storage.IndexAdvice{}, storage.ErrQueryShapeSamplingDisabled
+	}
+	advice.Datastore = strings.Join(datastores, ", ")
+	return advice, nil
+}
+
+// WriteModelAlias see [storage.ModelAliasBackend].WriteModelAlias.
+func (r *RoutingDatastore) WriteModelAlias(ctx context.Context, store, alias, modelID string) error {
+	backend, err := partitionFor[storage.ModelAliasBackend](ctx, r, store, "storage.ModelAliasBackend")
+	if err != nil {
+		return err
+	}
+	return backend.WriteModelAlias(ctx, store, alias, modelID)
+}
+
+// ReadModelAlias see [storage.ModelAliasBackend].ReadModelAlias.
+func (r *RoutingDatastore) ReadModelAlias(ctx context.Context, store, alias string) (string, error) {
+	backend, err := partitionFor[storage.ModelAliasBackend](ctx, r, store, "storage.ModelAliasBackend")
+	if err != nil {
+		return "", err
+	}
+	return backend.ReadModelAlias(ctx, store, alias)
+}
+
+// ReadTupleCounts see [storage.TupleCounter].ReadTupleCounts.
+func (r *RoutingDatastore) ReadTupleCounts(ctx context.Context, store string) (storage.TupleCounts, error) {
+	counter, err := partitionFor[storage.TupleCounter](ctx, r, store, "storage.TupleCounter")
+	if err != nil {
+		return storage.TupleCounts{}, err
+	}
+	return counter.ReadTupleCounts(ctx, store)
+}
+
+// BackfillTupleCounts see [storage.TupleCounter].BackfillTupleCounts.
+func (r *RoutingDatastore) BackfillTupleCounts(ctx context.Context, store string) error {
+	counter, err := partitionFor[storage.TupleCounter](ctx, r, store, "storage.TupleCounter")
+	if err != nil {
+		return err
+	}
+	return counter.BackfillTupleCounts(ctx, store)
+}
+
+// CountStores see [storage.StoreCounter].CountStores. It is the sum of the partitions, and is
+// estimated if the count of any partition is.
+func (r *RoutingDatastore) CountStores(ctx context.Context, options storage.CountStoresOptions) (storage.StoreCount, error) {
+	var count storage.StoreCount
+	err := allPartitions(r, "storage.StoreCounter", func(_ string, counter storage.StoreCounter) error {
+		partitionCount, err := counter.CountStores(ctx, options)
+		if err != nil {
+			return err
+		}
+		count.Count += partitionCount.Count
+		count.Estimated = count.Estimated || partitionCount.Estimated
+		return nil
+	})
+	if err != nil {
+		return storage.StoreCount{}, err
+	}
+	return count, nil
+}
+
+// ListDeletedStores see [storage.StorePurger].ListDeletedStores. It lists the deleted stores of
+// all the partitions.
+func (r *RoutingDatastore) ListDeletedStores(ctx context.Context) ([]string, error) {
+	var storeIDs []string
+	err := allPartitions(r, "storage.StorePurger", func(_ string, purger storage.StorePurger) error {
+		deleted, err := purger.ListDeletedStores(ctx)
+		if err != nil {
+			return err
+		}
+		storeIDs = append(storeIDs, deleted...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return storeIDs, nil
+}
+
+// PurgeStore see [storage.StorePurger].PurgeStore. A deleted store without a route is purged
+// from the partition it is found deleted in, since GetStore does not find it.
+func (r *RoutingDatastore) PurgeStore(ctx context.Context, store string, batchSize int) (int64, error) {
+	if name, ok := r.route(store); ok {
+		purger, ok := r.partitions[name].(storage.StorePurger)
+		if !ok {
+			return 0, unimplemented(name, "storage.StorePurger")
+		}
+		return purger.PurgeStore(ctx, store, batchSize)
+	}
+
+	var purged int64
+	found := false
+	err := allPartitions(r, "storage.StorePurger", func(_ string, purger storage.StorePurger) error {
+		if found {
+			return nil
+		}
+		var err error
+		purged, err = purger.PurgeStore(ctx, store, batchSize)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		found = true
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, storage.ErrNotFound
+	}
+	return purged, nil
+}
+
+// TrimChanges see [storage.ChangelogTrimmer].TrimChanges.
+func (r *RoutingDatastore) TrimChanges(ctx context.Context, store string, before time.Time) (int64, error) {
+	trimmer, err := partitionFor[storage.ChangelogTrimmer](ctx, r, store, "storage.ChangelogTrimmer")
+	if err != nil {
+		return 0, err
+	}
+	return trimmer.TrimChanges(ctx, store, before)
+}
+
+// GetLatestChange see [storage.ChangelogStatsReader].GetLatestChange.
+func (r *RoutingDatastore) GetLatestChange(ctx context.Context, store string) (time.Time, error) {
+	reader, err := partitionFor[storage.ChangelogStatsReader](ctx, r, store, "storage.ChangelogStatsReader")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return reader.GetLatestChange(ctx, store)
+}
+
+// CountChanges see [storage.ChangelogStatsReader].CountChanges. It is the sum of the partitions,
+// and is estimated if the count of any partition is.
+func (r *RoutingDatastore) CountChanges(ctx context.Context) (storage.ChangeCount, error) {
+	var count storage.ChangeCount
+	err := allPartitions(r, "storage.ChangelogStatsReader", func(_ string, reader storage.ChangelogStatsReader) error {
+		partitionCount, err := reader.CountChanges(ctx)
+		if err != nil {
+			return err
+		}
+		count.Count += partitionCount.Count
+		count.Estimated = count.Estimated || partitionCount.Estimated
+		return nil
+	})
+	if err != nil {
+		return storage.ChangeCount{}, err
+	}
+	return count, nil
+}
+
+// GetStoreByName see [storage.UniqueStoreNamesBackend].GetStoreByName. It returns the oldest
+// store with the name across the partitions.
+func (r *RoutingDatastore) GetStoreByName(ctx context.Context, name string) (*openfgav1.Store, error) {
+	var oldest *openfgav1.Store
+	err := allPartitions(r, "storage.UniqueStoreNamesBackend", func(partition string, backend storage.UniqueStoreNamesBackend) error {
+		store, err := backend.GetStoreByName(ctx, name)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		r.located.Store(store.GetId(), partition)
+		if oldest == nil || store.GetId() < oldest.GetId() {
+			oldest = store
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if oldest == nil {
+		return nil, storage.ErrNotFound
+	}
+	return oldest, nil
+}
+
+// CreateStoreWithUniqueName see [storage.UniqueStoreNamesBackend].CreateStoreWithUniqueName. The
+// store is created in the partition that CreateStore would choose, after looking its name up
+// in the other partitions. The names are unique across the partitions for the stores created
+// through one router; the partitions only guarantee it among their own stores otherwise.
+func (r *RoutingDatastore) CreateStoreWithUniqueName(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	name, err := r.createStorePartition(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	backend, ok := r.partitions[name].(storage.UniqueStoreNamesBackend)
+	if !ok {
+		return nil, unimplemented(name, "storage.UniqueStoreNamesBackend")
+	}
+
+	r.uniqueNamesMu.Lock()
+	defer r.uniqueNamesMu.Unlock()
+
+	err = allPartitions(r, "storage.UniqueStoreNamesBackend", func(partition string, other storage.UniqueStoreNamesBackend) error {
+		if partition == name {
+			return nil
+		}
+		existing, err := other.GetStoreByName(ctx, store.GetName())
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return &storage.StoreNameConflictError{StoreID: existing.GetId(), Name: store.GetName()}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := backend.CreateStoreWithUniqueName(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	r.located.Store(created.GetId(), name)
+	return created, nil
+}
@@ -0,0 +1,247 @@
+package storagewrappers
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/storage/test"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// newTestRoutingDatastore returns a router over two memory partitions, "eu" and "us", that
+// creates the stores in turn in each of them.
+func newTestRoutingDatastore(t *testing.T, opts ...RoutingDatastoreOption) (*RoutingDatastore, storage.OpenFGADatastore, storage.OpenFGADatastore) {
+	eu := memory.New()
+	us := memory.New()
+
+	var created atomic.Uint32
+	opts = append([]RoutingDatastoreOption{
+		WithCreateStorePolicy(func(context.Context, *openfgav1.Store) (string, error) {
+			if created.Add(1)%2 == 0 {
+				return "eu", nil
+			}
+			return "us", nil
+		}),
+	}, opts...)
+
+	ds, err := NewRoutingDatastore(map[string]storage.OpenFGADatastore{"eu": eu, "us": us}, "us", opts...)
+	require.NoError(t, err)
+	t.Cleanup(ds.Close)
+	return ds, eu, us
+}
+
+func TestRoutingDatastore(t *testing.T) {
+	ds, _, _ := newTestRoutingDatastore(t)
+	test.RunAllTests(t, ds)
+}
+
+func TestRoutingDatastoreRoutes(t *testing.T) {
+	ctx := context.Background()
+
+	euStoreID := ulid.Make().String()
+	ds, eu, us := newTestRoutingDatastore(t,
+		WithStoreRoute(euStoreID, "eu"),
+		WithStorePrefixRoute("01", "us"),
+		WithStorePrefixRoute("01EU", "eu"),
+	)
+
+	for _, storeID := range []string{euStoreID, "01EU" + ulid.Make().String()[4:], "01US" + ulid.Make().String()[4:]} {
+		_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: "store"})
+		require.NoError(t, err)
+
+		tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+		require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk}))
+
+		partition, other := us, eu
+		if storeID == euStoreID || storeID[:4] == "01EU" {
+			partition, other = eu, us
+		}
+		_, err = partition.ReadUserTuple(ctx, storeID, tk, storage.ReadUserTupleOptions{})
+		require.NoError(t, err, storeID)
+		_, err = other.ReadUserTuple(ctx, storeID, tk, storage.ReadUserTupleOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound, storeID)
+		_, err = other.GetStore(ctx, storeID)
+		require.ErrorIs(t, err, storage.ErrNotFound, storeID)
+	}
+}
+
+func TestRoutingDatastoreLocatesStores(t *testing.T) {
+	ctx := context.Background()
+
+	eu := memory.New()
+	t.Cleanup(eu.Close)
+	us := memory.New()
+	t.Cleanup(us.Close)
+
+	// a store created in the partition before the router started
+	storeID := ulid.Make().String()
+	_, err := eu.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: "store"})
+	require.NoError(t, err)
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	require.NoError(t, eu.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk}))
+
+	ds, err := NewRoutingDatastore(map[string]storage.OpenFGADatastore{"eu": eu, "us": us}, "us")
+	require.NoError(t, err)
+
+	_, err = ds.ReadUserTuple(ctx, storeID, tk, storage.ReadUserTupleOptions{})
+	require.NoError(t, err)
+
+	// the stores that do not exist are in the default partition
+	_, err = ds.GetStore(ctx, ulid.Make().String())
+	require.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+// getStoreCounter counts the calls to GetStore of a datastore, and hides its optional interfaces.
+type getStoreCounter struct {
+	storage.OpenFGADatastore
+	calls atomic.Int32
+}
+
+func (c *getStoreCounter) GetStore(ctx context.Context, id string) (*openfgav1.Store, error) {
+	c.calls.Add(1)
+	return c.OpenFGADatastore.GetStore(ctx, id)
+}
+
+func TestRoutingDatastoreRemembersUnknownStores(t *testing.T) {
+	ctx := context.Background()
+
+	eu := &getStoreCounter{OpenFGADatastore: memory.New()}
+	t.Cleanup(eu.Close)
+	us := memory.New()
+	t.Cleanup(us.Close)
+
+	ds, err := NewRoutingDatastore(map[string]storage.OpenFGADatastore{"eu": eu, "us": us}, "us", WithUnknownStoreTTL(time.Hour))
+	require.NoError(t, err)
+
+	storeID := ulid.Make().String()
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	for range 3 {
+		_, err := ds.ReadUserTuple(ctx, storeID, tk, storage.ReadUserTupleOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	}
+	require.Equal(t, int32(1), eu.calls.Load())
+
+	// the stores created through the router are found although they were unknown
+	_, err = ds.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: "store"})
+	require.NoError(t, err)
+	_, err = ds.GetStore(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), eu.calls.Load())
+}
+
+func TestRoutingDatastoreForwardsOptionalInterfaces(t *testing.T) {
+	ctx := context.Background()
+	ds, eu, us := newTestRoutingDatastore(t)
+
+	usStore, err := ds.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: "us"})
+	require.NoError(t, err)
+	euStore, err := ds.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: "eu"})
+	require.NoError(t, err)
+	_, err = eu.GetStore(ctx, euStore.GetId())
+	require.NoError(t, err)
+
+	require.NoError(t, ds.Write(ctx, euStore.GetId(), nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+	}))
+	counts, err := ds.ReadTupleCounts(ctx, euStore.GetId())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), counts.Total)
+
+	stores, err := ds.CountStores(ctx, storage.CountStoresOptions{Exact: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), stores.Count)
+
+	t.Run("unique_names_across_partitions", func(t *testing.T) {
+		// the next store is created in the "us" partition, which does not have the name
+		_, err := ds.CreateStoreWithUniqueName(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: "eu"})
+		var conflict *storage.StoreNameConflictError
+		require.ErrorAs(t, err, &conflict)
+		require.Equal(t, euStore.GetId(), conflict.StoreID)
+
+		found, err := ds.GetStoreByName(ctx, "us")
+		require.NoError(t, err)
+		require.Equal(t, usStore.GetId(), found.GetId())
+	})
+
+	t.Run("partition_without_the_interface", func(t *testing.T) {
+		ds, err := NewRoutingDatastore(map[string]storage.OpenFGADatastore{
+			"eu": &getStoreCounter{OpenFGADatastore: eu},
+			"us": us,
+		}, "us")
+		require.NoError(t, err)
+
+		_, err = ds.ReadTupleCounts(ctx, euStore.GetId())
+		require.ErrorIs(t, err, storage.ErrUnimplemented)
+		_, err = ds.ReadTupleCounts(ctx, usStore.GetId())
+		require.NoError(t, err)
+		_, err = ds.CountStores(ctx, storage.CountStoresOptions{})
+		require.ErrorIs(t, err, storage.ErrUnimplemented)
+	})
+}
+
+func TestRoutingDatastoreListStores(t *testing.T) {
+	ctx := context.Background()
+	ds, eu, us := newTestRoutingDatastore(t)
+
+	var storeIDs []string
+	for range 5 {
+		store, err := ds.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: "store", CreatedAt: timestamppb.Now()})
+		require.NoError(t, err)
+		storeIDs = append(storeIDs, store.GetId())
+	}
+
+	euStores, _, err := eu.ListStores(ctx, storage.ListStoresOptions{})
+	require.NoError(t, err)
+	require.Len(t, euStores, 2)
+	usStores, _, err := us.ListStores(ctx, storage.ListStoresOptions{})
+	require.NoError(t, err)
+	require.Len(t, usStores, 3)
+
+	for _, pageSize := range []int{1, 2, 3, 5, 10} {
+		var listed []string
+		var from string
+		for {
+			stores, contToken, err := ds.ListStores(ctx, storage.ListStoresOptions{
+				Pagination: storage.NewPaginationOptions(int32(pageSize), from),
+			})
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(stores), pageSize)
+			for _, store := range stores {
+				listed = append(listed, store.GetId())
+			}
+			if len(contToken) == 0 {
+				break
+			}
+			from = string(contToken)
+		}
+		require.ElementsMatch(t, storeIDs, listed, pageSize)
+	}
+
+	_, _, err = ds.ListStores(ctx, storage.ListStoresOptions{
+		Pagination: storage.NewPaginationOptions(1, `{"partition":"ap"}`),
+	})
+	require.ErrorIs(t, err, storage.ErrInvalidContinuationToken)
+}
+
+func TestNewRoutingDatastoreRejectsUnknownPartitions(t *testing.T) {
+	partitions := map[string]storage.OpenFGADatastore{"eu": memory.New()}
+	t.Cleanup(partitions["eu"].Close)
+
+	_, err := NewRoutingDatastore(partitions, "us")
+	require.Error(t, err)
+
+	_, err = NewRoutingDatastore(partitions, "eu", WithStoreRoute(ulid.Make().String(), "us"))
+	require.Error(t, err)
+
+	_, err = NewRoutingDatastore(partitions, "eu", WithStorePrefixRoute("01", "us"))
+	require.Error(t, err)
+}
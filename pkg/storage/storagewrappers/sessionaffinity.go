@@ -0,0 +1,140 @@
+package storagewrappers
+
+import (
+	"context"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/peer"
+
+	"github.com/openfga/openfga/internal/authn"
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+var _ storage.OpenFGADatastore = (*SessionAffinityDatastore)(nil)
+
+var sessionAffinityForcedReadsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "session_affinity_forced_consistency_count",
+	Help:      "The total number of requests and datastore reads upgraded to HIGHER_CONSISTENCY because their client wrote to the store within the session affinity window.",
+}, []string{"grpc_service", "grpc_method"})
+
+// SessionAffinityDatastore is a wrapper over a datastore that gives clients read-your-writes
+// consistency within a window: after a client writes tuples to a store, its reads of the store
+// are upgraded to HIGHER_CONSISTENCY until the window elapses, so that a datastore that reads
+// from replicas serves them from the primary database.
+//
+// A client is identified by its authenticated subject or, if it has none, by its connection. The
+// clients of the HTTP gateway share the connection of the gateway, so without authentication a
+// write of any of them upgrades the reads of all of them.
+//
+// Caches above the wrapper are not bypassed by the reads it upgrades. Handlers that cache their
+// results upgrade the consistency of their requests with [SessionAffinityDatastore.Consistency].
+type SessionAffinityDatastore struct {
+	storage.OpenFGADatastore
+	window time.Duration
+
+	// sessions holds the sessions that wrote to a store within the window, keyed by session
+	// and store ID, and is bounded to evict the least recently written ones.
+	sessions *storage.InMemoryLRUCache[struct{}]
+}
+
+// NewSessionAffinityDatastore returns a wrapper over a datastore that upgrades the reads of the
+// clients that wrote to a store in the last window to HIGHER_CONSISTENCY. It remembers the writes
+// of at most maxSessions pairs of client and store.
+func NewSessionAffinityDatastore(inner storage.OpenFGADatastore, window time.Duration, maxSessions int) *SessionAffinityDatastore {
+	return &SessionAffinityDatastore{
+		OpenFGADatastore: inner,
+		window:           window,
+		sessions:         storage.NewInMemoryLRUCache[struct{}](storage.WithMaxCacheSize[struct{}](int64(maxSessions))),
+	}
+}
+
+// Write see [storage.RelationshipTupleWriter].Write. Successful writes start the window of the
+// client for the store.
+func (s *SessionAffinityDatastore) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
+	if err := s.OpenFGADatastore.Write(ctx, store, deletes, writes); err != nil {
+		return err
+	}
+
+	if session := sessionFromContext(ctx); session != "" {
+		s.sessions.Set(sessionKey(session, store), struct{}{}, s.window)
+	}
+	return nil
+}
+
+// Consistency returns HIGHER_CONSISTENCY if the client of ctx wrote to the store within the
+// window, and preference otherwise.
+func (s *SessionAffinityDatastore) Consistency(ctx context.Context, store string, preference openfgav1.ConsistencyPreference) openfgav1.ConsistencyPreference {
+	if preference == openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY {
+		return preference
+	}
+
+	session := sessionFromContext(ctx)
+	if session == "" {
+		return preference
+	}
+	if res := s.sessions.Get(sessionKey(session, store)); res == nil || res.Expired {
+		return preference
+	}
+
+	rpcInfo := telemetry.RPCInfoFromContext(ctx)
+	sessionAffinityForcedReadsCounter.WithLabelValues(rpcInfo.Service, rpcInfo.Method).Inc()
+	return openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY
+}
+
+// Read see [storage.RelationshipTupleReader].Read.
+func (s *SessionAffinityDatastore) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadOptions) (storage.TupleIterator, error) {
+	options.Consistency.Preference = s.Consistency(ctx, store, options.Consistency.Preference)
+	return s.OpenFGADatastore.Read(ctx, store, tupleKey, options)
+}
+
+// ReadPage see [storage.RelationshipTupleReader].ReadPage.
+func (s *SessionAffinityDatastore) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadPageOptions) ([]*openfgav1.Tuple, []byte, error) {
+	options.Consistency.Preference = s.Consistency(ctx, store, options.Consistency.Preference)
+	return s.OpenFGADatastore.ReadPage(ctx, store, tupleKey, options)
+}
+
+// ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
+func (s *SessionAffinityDatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, options storage.ReadUserTupleOptions) (*openfgav1.Tuple, error) {
+	options.Consistency.Preference = s.Consistency(ctx, store, options.Consistency.Preference)
+	return s.OpenFGADatastore.ReadUserTuple(ctx, store, tupleKey, options)
+}
+
+// ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
+func (s *SessionAffinityDatastore) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter, options storage.ReadUsersetTuplesOptions) (storage.TupleIterator, error) {
+	options.Consistency.Preference = s.Consistency(ctx, store, options.Consistency.Preference)
+	return s.OpenFGADatastore.ReadUsersetTuples(ctx, store, filter, options)
+}
+
+// ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
+func (s *SessionAffinityDatastore) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter, options storage.ReadStartingWithUserOptions) (storage.TupleIterator, error) {
+	options.Consistency.Preference = s.Consistency(ctx, store, options.Consistency.Preference)
+	return s.OpenFGADatastore.ReadStartingWithUser(ctx, store, filter, options)
+}
+
+// Close closes the datastore and cleans up the sessions.
+func (s *SessionAffinityDatastore) Close() {
+	s.sessions.Stop()
+	s.OpenFGADatastore.Close()
+}
+
+// sessionFromContext returns the session of the client of a request: its authenticated subject,
+// or else its connection. It returns an empty string for in-process calls without either.
+func sessionFromContext(ctx context.Context) string {
+	if claims, ok := authn.AuthClaimsFromContext(ctx); ok && claims.Subject != "" {
+		return "subject:" + claims.Subject
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return "peer:" + p.Addr.String()
+	}
+	return ""
+}
+
+func sessionKey(session, store string) string {
+	return session + "|" + store
+}
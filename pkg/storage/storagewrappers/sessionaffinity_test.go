@@ -0,0 +1,107 @@
+package storagewrappers
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/peer"
+
+	"github.com/openfga/openfga/internal/authn"
+	"github.com/openfga/openfga/internal/mocks"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestSessionAffinityDatastore(t *testing.T) {
+	tk := tuple.NewTupleKey("doc:1", "viewer", "user:anne")
+	writes := []*openfgav1.TupleKey{tk}
+
+	client := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1000}})
+	otherConnection := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 2000}})
+	anne := authn.ContextWithAuthClaims(client, &authn.AuthClaims{Subject: "anne"})
+	anneOnOtherConnection := authn.ContextWithAuthClaims(otherConnection, &authn.AuthClaims{Subject: "anne"})
+
+	higher := openfgav1.ConsistencyPreference_HIGHER_CONSISTENCY
+	unspecified := openfgav1.ConsistencyPreference_UNSPECIFIED
+
+	t.Run("reads_after_a_write_of_the_client_are_upgraded", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().Write(gomock.Any(), "store", gomock.Any(), gomock.Any()).Return(nil)
+		mockDatastore.EXPECT().Close()
+
+		ds := NewSessionAffinityDatastore(mockDatastore, time.Minute, 10)
+		t.Cleanup(ds.Close)
+
+		require.Equal(t, unspecified, ds.Consistency(client, "store", unspecified))
+		require.NoError(t, ds.Write(client, "store", nil, writes))
+
+		require.Equal(t, higher, ds.Consistency(client, "store", unspecified))
+		require.Equal(t, higher, ds.Consistency(client, "store", openfgav1.ConsistencyPreference_MINIMIZE_LATENCY))
+		require.Equal(t, unspecified, ds.Consistency(client, "other-store", unspecified))
+		require.Equal(t, unspecified, ds.Consistency(otherConnection, "store", unspecified))
+		require.Equal(t, unspecified, ds.Consistency(context.Background(), "store", unspecified))
+
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), "store", tk, storage.ReadUserTupleOptions{
+			Consistency: storage.ConsistencyOptions{Preference: higher},
+		}).Return(&openfgav1.Tuple{Key: tk}, nil)
+		_, err := ds.ReadUserTuple(client, "store", tk, storage.ReadUserTupleOptions{})
+		require.NoError(t, err)
+
+		mockDatastore.EXPECT().ReadPage(gomock.Any(), "store", tk, storage.ReadPageOptions{
+			Consistency: storage.ConsistencyOptions{Preference: unspecified},
+		}).Return(nil, nil, nil)
+		_, _, err = ds.ReadPage(otherConnection, "store", tk, storage.ReadPageOptions{})
+		require.NoError(t, err)
+	})
+
+	t.Run("authenticated_clients_are_identified_by_subject", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().Write(gomock.Any(), "store", gomock.Any(), gomock.Any()).Return(nil)
+		mockDatastore.EXPECT().Close()
+
+		ds := NewSessionAffinityDatastore(mockDatastore, time.Minute, 10)
+		t.Cleanup(ds.Close)
+
+		require.NoError(t, ds.Write(anne, "store", nil, writes))
+
+		require.Equal(t, higher, ds.Consistency(anneOnOtherConnection, "store", unspecified))
+		require.Equal(t, unspecified, ds.Consistency(client, "store", unspecified))
+	})
+
+	t.Run("failed_writes_are_ignored", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().Write(gomock.Any(), "store", gomock.Any(), gomock.Any()).Return(storage.ErrTransactionalWriteFailed)
+		mockDatastore.EXPECT().Close()
+
+		ds := NewSessionAffinityDatastore(mockDatastore, time.Minute, 10)
+		t.Cleanup(ds.Close)
+
+		require.ErrorIs(t, ds.Write(client, "store", nil, writes), storage.ErrTransactionalWriteFailed)
+		require.Equal(t, unspecified, ds.Consistency(client, "store", unspecified))
+	})
+
+	t.Run("window_elapses", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().Write(gomock.Any(), "store", gomock.Any(), gomock.Any()).Return(nil)
+		mockDatastore.EXPECT().Close()
+
+		ds := NewSessionAffinityDatastore(mockDatastore, 10*time.Millisecond, 10)
+		t.Cleanup(ds.Close)
+
+		require.NoError(t, ds.Write(client, "store", nil, writes))
+		require.Equal(t, higher, ds.Consistency(client, "store", unspecified))
+
+		require.Eventually(t, func() bool {
+			return ds.Consistency(client, "store", unspecified) == unspecified
+		}, time.Second, 10*time.Millisecond)
+	})
+}
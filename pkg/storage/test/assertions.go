@@ -36,7 +36,7 @@ func AssertionsTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		err := datastore.WriteAssertions(ctx, store, modelID, assertions)
 		require.NoError(t, err)
 
-		gotAssertions, err := datastore.ReadAssertions(ctx, store, modelID)
+		gotAssertions, _, err := datastore.ReadAssertions(ctx, store, modelID, storage.ReadAssertionsOptions{})
 		require.NoError(t, err)
 
 		if diff := cmp.Diff(assertions, gotAssertions, cmpOpts...); diff != "" {
@@ -77,7 +77,7 @@ func AssertionsTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		err := datastore.WriteAssertions(ctx, storeID, modelID, assertions)
 		require.NoError(t, err)
 
-		gotAssertions, err := datastore.ReadAssertions(ctx, storeID, modelID)
+		gotAssertions, _, err := datastore.ReadAssertions(ctx, storeID, modelID, storage.ReadAssertionsOptions{})
 		require.NoError(t, err)
 		require.Len(t, gotAssertions, len(assertions))
 	})
@@ -98,7 +98,7 @@ func AssertionsTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		err = datastore.WriteAssertions(ctx, store, modelID, assertions)
 		require.NoError(t, err)
 
-		gotAssertions, err := datastore.ReadAssertions(ctx, store, modelID)
+		gotAssertions, _, err := datastore.ReadAssertions(ctx, store, modelID, storage.ReadAssertionsOptions{})
 		require.NoError(t, err)
 
 		if diff := cmp.Diff(assertions, gotAssertions, cmpOpts...); diff != "" {
@@ -120,9 +120,74 @@ func AssertionsTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		err := datastore.WriteAssertions(ctx, store, oldModelID, assertions)
 		require.NoError(t, err)
 
-		gotAssertions, err := datastore.ReadAssertions(ctx, store, newModelID)
+		gotAssertions, _, err := datastore.ReadAssertions(ctx, store, newModelID, storage.ReadAssertionsOptions{})
 		require.NoError(t, err)
 
 		require.Empty(t, gotAssertions)
 	})
+
+	t.Run("reading_filtered_assertions_in_pages", func(t *testing.T) {
+		store := ulid.Make().String()
+		modelID := ulid.Make().String()
+		assertions := []*openfgav1.Assertion{
+			{
+				TupleKey:    tupleUtils.NewAssertionTupleKey("doc:readme", "viewer", "user:anne"),
+				Expectation: true,
+			},
+			{
+				TupleKey:    tupleUtils.NewAssertionTupleKey("folder:x", "viewer", "user:anne"),
+				Expectation: true,
+			},
+			{
+				TupleKey:    tupleUtils.NewAssertionTupleKey("doc:readme", "owner", "user:bob"),
+				Expectation: false,
+			},
+			{
+				TupleKey:    tupleUtils.NewAssertionTupleKey("doc:other", "viewer", "user:bob"),
+				Expectation: false,
+			},
+		}
+
+		err := datastore.WriteAssertions(ctx, store, modelID, assertions)
+		require.NoError(t, err)
+
+		gotAssertions, contToken, err := datastore.ReadAssertions(ctx, store, modelID, storage.ReadAssertionsOptions{
+			Filter: storage.ReadAssertionsFilter{ObjectType: "doc"},
+		})
+		require.NoError(t, err)
+		require.Empty(t, contToken)
+		if diff := cmp.Diff([]*openfgav1.Assertion{assertions[0], assertions[2], assertions[3]}, gotAssertions, cmpOpts...); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+
+		options := storage.ReadAssertionsOptions{
+			Filter:     storage.ReadAssertionsFilter{ObjectType: "doc", Relation: "viewer"},
+			Pagination: storage.PaginationOptions{PageSize: 1},
+		}
+		gotAssertions, contToken, err = datastore.ReadAssertions(ctx, store, modelID, options)
+		require.NoError(t, err)
+		require.NotEmpty(t, contToken)
+		if diff := cmp.Diff([]*openfgav1.Assertion{assertions[0]}, gotAssertions, cmpOpts...); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+
+		_, _, err = datastore.ReadAssertions(ctx, store, modelID, storage.ReadAssertionsOptions{
+			Filter:     storage.ReadAssertionsFilter{ObjectType: "folder"},
+			Pagination: storage.PaginationOptions{PageSize: 1, From: string(contToken)},
+		})
+		require.ErrorIs(t, err, storage.ErrInvalidContinuationToken)
+
+		options.Pagination.From = string(contToken)
+		gotAssertions, contToken, err = datastore.ReadAssertions(ctx, store, modelID, options)
+		require.NoError(t, err)
+		require.Empty(t, contToken)
+		if diff := cmp.Diff([]*openfgav1.Assertion{assertions[3]}, gotAssertions, cmpOpts...); diff != "" {
+			t.Fatalf("mismatch (-want +got):\n%s", diff)
+		}
+
+		_, _, err = datastore.ReadAssertions(ctx, store, modelID, storage.ReadAssertionsOptions{
+			Pagination: storage.PaginationOptions{PageSize: 1, From: "invalid"},
+		})
+		require.ErrorIs(t, err, storage.ErrInvalidContinuationToken)
+	})
 }
@@ -40,6 +40,25 @@ func WriteAndReadAuthorizationModelTest(t *testing.T, datastore storage.OpenFGAD
 		_, err := datastore.ReadAuthorizationModel(ctx, storeID, ulid.Make().String())
 		require.ErrorIs(t, err, storage.ErrNotFound)
 	})
+
+	t.Run("models_of_a_deleted_store_return_store_deleted", func(t *testing.T) {
+		store, err := datastore.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: "deleted"})
+		require.NoError(t, err)
+		model := &openfgav1.AuthorizationModel{
+			Id:              ulid.Make().String(),
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: []*openfgav1.TypeDefinition{{Type: "folder"}},
+		}
+		require.NoError(t, datastore.WriteAuthorizationModel(ctx, store.GetId(), model))
+
+		require.NoError(t, datastore.DeleteStore(ctx, store.GetId()))
+
+		_, err = datastore.ReadAuthorizationModel(ctx, store.GetId(), model.GetId())
+		require.ErrorIs(t, err, storage.ErrStoreDeleted)
+		_, err = datastore.FindLatestAuthorizationModel(ctx, store.GetId())
+		require.ErrorIs(t, err, storage.ErrStoreDeleted)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
 }
 
 func ReadAuthorizationModelsTest(t *testing.T, datastore storage.OpenFGADatastore) {
@@ -229,3 +248,39 @@ func FindLatestAuthorizationModelTest(t *testing.T, datastore storage.OpenFGADat
 		}
 	})
 }
+
+func ModelAliasTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	backend, ok := datastore.(storage.ModelAliasBackend)
+	if !ok {
+		t.Skip("the datastore does not implement storage.ModelAliasBackend")
+	}
+
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+	otherStoreID := ulid.Make().String()
+	modelID := ulid.Make().String()
+	nextModelID := ulid.Make().String()
+
+	_, err := backend.ReadModelAlias(ctx, storeID, "production")
+	require.ErrorIs(t, err, storage.ErrNotFound)
+
+	err = backend.WriteModelAlias(ctx, storeID, "production", modelID)
+	require.NoError(t, err)
+
+	got, err := backend.ReadModelAlias(ctx, storeID, "production")
+	require.NoError(t, err)
+	require.Equal(t, modelID, got)
+
+	err = backend.WriteModelAlias(ctx, storeID, "production", nextModelID)
+	require.NoError(t, err)
+
+	got, err = backend.ReadModelAlias(ctx, storeID, "production")
+	require.NoError(t, err)
+	require.Equal(t, nextModelID, got)
+
+	_, err = backend.ReadModelAlias(ctx, otherStoreID, "production")
+	require.ErrorIs(t, err, storage.ErrNotFound)
+
+	_, err = backend.ReadModelAlias(ctx, storeID, "staging")
+	require.ErrorIs(t, err, storage.ErrNotFound)
+}
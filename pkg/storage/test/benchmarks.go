@@ -0,0 +1,82 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// ReadUsersetTuplesBenchmark reads the userset tuples of one type out of an object with many
+// direct members of mixed types, as Check does for a relation that allows them. It reports the
+// queries and the tuples read per operation: with the filter pushed down to the datastore, only
+// the tuples of the allowed userset type are read.
+func ReadUsersetTuplesBenchmark(b *testing.B, ds storage.OpenFGADatastore) {
+	ctx := context.Background()
+
+	const (
+		users          = 10_000
+		usersetsByType = 100
+	)
+	userTypes := []string{"group", "team", "org"}
+
+	storeID := ulid.Make().String()
+	var tks []*openfgav1.TupleKey
+	write := func(tk *openfgav1.TupleKey) {
+		tks = append(tks, tk)
+		if len(tks) == ds.MaxTuplesPerWrite() {
+			require.NoError(b, ds.Write(ctx, storeID, nil, tks))
+			tks = nil
+		}
+	}
+	for i := 0; i < users; i++ {
+		write(tuple.NewTupleKey("document:1", "viewer", fmt.Sprintf("user:%d", i)))
+	}
+	for _, userType := range userTypes {
+		for i := 0; i < usersetsByType; i++ {
+			write(tuple.NewTupleKey("document:1", "viewer", fmt.Sprintf("%s:%d#member", userType, i)))
+		}
+	}
+	if len(tks) > 0 {
+		require.NoError(b, ds.Write(ctx, storeID, nil, tks))
+	}
+
+	filter := storage.ReadUsersetTuplesFilter{
+		Object:   "document:1",
+		Relation: "viewer",
+		AllowedUserTypeRestrictions: []*openfgav1.RelationReference{
+			typesystem.DirectRelationReference("group", "member"),
+		},
+	}
+
+	var queries, read int
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		iter, err := ds.ReadUsersetTuples(ctx, storeID, filter, storage.ReadUsersetTuplesOptions{})
+		require.NoError(b, err)
+		queries++
+
+		for {
+			_, err := iter.Next(ctx)
+			if errors.Is(err, storage.ErrIteratorDone) {
+				break
+			}
+			require.NoError(b, err)
+			read++
+		}
+		iter.Stop()
+	}
+	b.StopTimer()
+
+	require.Equal(b, usersetsByType*b.N, read)
+	b.ReportMetric(float64(queries)/float64(b.N), "queries/op")
+	b.ReportMetric(float64(read)/float64(b.N), "tuples/op")
+}
@@ -37,17 +37,29 @@ func RunAllTests(t *testing.T, ds storage.OpenFGADatastore) {
 	t.Run("TestReadChanges", func(t *testing.T) { ReadChangesTest(t, ds) })
 	t.Run("TestReadStartingWithUser", func(t *testing.T) { ReadStartingWithUserTest(t, ds) })
 	t.Run("TestReadAndReadPages", func(t *testing.T) { ReadAndReadPageTest(t, ds) })
+	t.Run("TestReadPageWithConditionFilter", func(t *testing.T) { ReadPageWithConditionFilterTest(t, ds) })
+	t.Run("TestReadPageOrder", func(t *testing.T) { ReadPageOrderTest(t, ds) })
+	t.Run("TestCountRelation", func(t *testing.T) { CountRelationTest(t, ds) })
+	t.Run("TestCountRelationUsage", func(t *testing.T) { CountRelationUsageTest(t, ds) })
+	t.Run("TestSnapshot", func(t *testing.T) { SnapshotTest(t, ds) })
+	t.Run("TestTupleCounter", func(t *testing.T) { TupleCounterTest(t, ds) })
+	t.Run("TestChangelogTrimmer", func(t *testing.T) { ChangelogTrimmerTest(t, ds) })
+	t.Run("TestChangelogStatsReader", func(t *testing.T) { ChangelogStatsReaderTest(t, ds) })
 
 	// Authorization models.
 	t.Run("TestWriteAndReadAuthorizationModel", func(t *testing.T) { WriteAndReadAuthorizationModelTest(t, ds) })
 	t.Run("TestReadAuthorizationModels", func(t *testing.T) { ReadAuthorizationModelsTest(t, ds) })
 	t.Run("TestFindLatestAuthorizationModel", func(t *testing.T) { FindLatestAuthorizationModelTest(t, ds) })
+	t.Run("TestModelAlias", func(t *testing.T) { ModelAliasTest(t, ds) })
 
 	// Assertions.
 	t.Run("TestWriteAndReadAssertions", func(t *testing.T) { AssertionsTest(t, ds) })
 
 	// Stores.
 	t.Run("TestStore", func(t *testing.T) { StoreTest(t, ds) })
+	t.Run("TestStoreCounter", func(t *testing.T) { StoreCounterTest(t, ds) })
+	t.Run("TestUniqueStoreNames", func(t *testing.T) { UniqueStoreNamesTest(t, ds) })
+	t.Run("TestPurgeStore", func(t *testing.T) { PurgeStoreTest(t, ds) })
 }
 
 // BootstrapFGAStore is a utility to write an FGA model and relationship tuples to a datastore.
@@ -2,6 +2,8 @@ package test
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -58,6 +60,38 @@ func StoreTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		require.Empty(t, ct)
 	})
 
+	t.Run("list_stores_by_name", func(t *testing.T) {
+		name := testutils.CreateRandomString(10)
+		var storeIDs []string
+		for i := 0; i < 3; i++ {
+			store, err := datastore.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: name})
+			require.NoError(t, err)
+			storeIDs = append(storeIDs, store.GetId())
+		}
+
+		gotStores, ct, err := datastore.ListStores(ctx, storage.ListStoresOptions{
+			Pagination: storage.NewPaginationOptions(2, ""),
+			Name:       name,
+		})
+		require.NoError(t, err)
+		require.Len(t, gotStores, 2)
+		require.NotEmpty(t, ct)
+
+		lastStores, ct, err := datastore.ListStores(ctx, storage.ListStoresOptions{
+			Pagination: storage.NewPaginationOptions(2, string(ct)),
+			Name:       name,
+		})
+		require.NoError(t, err)
+		require.Empty(t, ct)
+
+		var gotIDs []string
+		for _, store := range append(gotStores, lastStores...) {
+			require.Equal(t, name, store.GetName())
+			gotIDs = append(gotIDs, store.GetId())
+		}
+		require.ElementsMatch(t, storeIDs, gotIDs)
+	})
+
 	t.Run("get_store_succeeds", func(t *testing.T) {
 		store := stores[0]
 		gotStore, err := datastore.GetStore(ctx, store.GetId())
@@ -98,3 +132,199 @@ func StoreTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		}
 	})
 }
+
+func StoreCounterTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	counter, ok := datastore.(storage.StoreCounter)
+	if !ok {
+		t.Skip("the datastore does not implement storage.StoreCounter")
+	}
+
+	ctx := context.Background()
+
+	before, err := counter.CountStores(ctx, storage.CountStoresOptions{Exact: true})
+	require.NoError(t, err)
+	require.False(t, before.Estimated)
+
+	name := testutils.CreateRandomString(10)
+	var storeIDs []string
+	for i := 0; i < 3; i++ {
+		store, err := datastore.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: name})
+		require.NoError(t, err)
+		storeIDs = append(storeIDs, store.GetId())
+	}
+	require.NoError(t, datastore.DeleteStore(ctx, storeIDs[0]))
+
+	after, err := counter.CountStores(ctx, storage.CountStoresOptions{Exact: true})
+	require.NoError(t, err)
+	require.False(t, after.Estimated)
+	require.Equal(t, before.Count+2, after.Count)
+
+	named, err := counter.CountStores(ctx, storage.CountStoresOptions{Name: name})
+	require.NoError(t, err)
+	require.False(t, named.Estimated)
+	require.Equal(t, int64(2), named.Count)
+
+	estimate, err := counter.CountStores(ctx, storage.CountStoresOptions{})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, estimate.Count, int64(0))
+	if !estimate.Estimated {
+		require.Equal(t, after.Count, estimate.Count)
+	}
+}
+
+func UniqueStoreNamesTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	backend, ok := datastore.(storage.UniqueStoreNamesBackend)
+	if !ok {
+		t.Skip("the datastore does not implement storage.UniqueStoreNamesBackend")
+	}
+
+	ctx := context.Background()
+
+	t.Run("get_store_by_name", func(t *testing.T) {
+		name := testutils.CreateRandomString(10)
+		_, err := backend.GetStoreByName(ctx, name)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		first, err := datastore.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: name})
+		require.NoError(t, err)
+		_, err = datastore.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: name})
+		require.NoError(t, err)
+
+		got, err := backend.GetStoreByName(ctx, name)
+		require.NoError(t, err)
+		require.Equal(t, first.GetId(), got.GetId())
+		require.Equal(t, name, got.GetName())
+
+		require.NoError(t, datastore.DeleteStore(ctx, first.GetId()))
+		got, err = backend.GetStoreByName(ctx, name)
+		require.NoError(t, err)
+		require.NotEqual(t, first.GetId(), got.GetId())
+	})
+
+	t.Run("create_store_with_unique_name", func(t *testing.T) {
+		name := testutils.CreateRandomString(10)
+		created, err := backend.CreateStoreWithUniqueName(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: name})
+		require.NoError(t, err)
+		require.Equal(t, name, created.GetName())
+
+		_, err = backend.CreateStoreWithUniqueName(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: name})
+		require.ErrorIs(t, err, storage.ErrCollision)
+		var conflict *storage.StoreNameConflictError
+		require.ErrorAs(t, err, &conflict)
+		require.Equal(t, created.GetId(), conflict.StoreID)
+
+		// the name of a deleted store is available
+		require.NoError(t, datastore.DeleteStore(ctx, created.GetId()))
+		_, err = backend.CreateStoreWithUniqueName(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: name})
+		require.NoError(t, err)
+	})
+
+	t.Run("concurrent_creates_of_a_name_create_one_store", func(t *testing.T) {
+		name := testutils.CreateRandomString(10)
+
+		const creates = 8
+		errs := make(chan error, creates)
+		var wg sync.WaitGroup
+		for i := 0; i < creates; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := backend.CreateStoreWithUniqueName(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: name})
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		var created int
+		for err := range errs {
+			if err == nil {
+				created++
+				continue
+			}
+			require.ErrorIs(t, err, storage.ErrCollision)
+		}
+		require.Equal(t, 1, created)
+
+		stores, _, err := datastore.ListStores(ctx, storage.ListStoresOptions{
+			Pagination: storage.NewPaginationOptions(storage.DefaultPageSize, ""),
+			Name:       name,
+		})
+		require.NoError(t, err)
+		require.Len(t, stores, 1)
+	})
+}
+
+func PurgeStoreTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	purger, ok := datastore.(storage.StorePurger)
+	if !ok {
+		t.Skip("the datastore does not implement storage.StorePurger")
+	}
+
+	ctx := context.Background()
+	if _, err := purger.ListDeletedStores(ctx); errors.Is(err, storage.ErrUnimplemented) {
+		t.Skip("the datastore does not implement storage.StorePurger for all its stores")
+	}
+
+	storeID, _ := BootstrapFGAStore(t, datastore, `
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`,
+		[]string{"document:1#viewer@user:anne", "document:2#viewer@user:anne", "document:3#viewer@user:bob"},
+	)
+	otherStoreID, _ := BootstrapFGAStore(t, datastore, `
+		model
+			schema 1.1
+		type user`,
+		nil,
+	)
+
+	for _, id := range []string{storeID, otherStoreID} {
+		_, err := datastore.CreateStore(ctx, &openfgav1.Store{Id: id, Name: "purge"})
+		require.NoError(t, err)
+	}
+
+	_, err := purger.PurgeStore(ctx, storeID, 2)
+	require.ErrorIs(t, err, storage.ErrNotFound)
+
+	require.NoError(t, datastore.DeleteStore(ctx, storeID))
+
+	deletedStores, err := purger.ListDeletedStores(ctx)
+	require.NoError(t, err)
+	require.Contains(t, deletedStores, storeID)
+	require.NotContains(t, deletedStores, otherStoreID)
+
+	// 3 tuples, 1 tuple counter, 3 changelog entries and 1 model
+	var batches []int64
+	for {
+		deleted, err := purger.PurgeStore(ctx, storeID, 2)
+		require.NoError(t, err)
+		if deleted == 0 {
+			break
+		}
+		batches = append(batches, deleted)
+	}
+	require.Equal(t, []int64{2, 2, 2, 2}, batches)
+
+	tuples, _, err := datastore.ReadPage(ctx, storeID, nil, storage.ReadPageOptions{
+		Pagination: storage.NewPaginationOptions(100, ""),
+	})
+	require.NoError(t, err)
+	require.Empty(t, tuples)
+
+	_, err = datastore.FindLatestAuthorizationModel(ctx, storeID)
+	require.ErrorIs(t, err, storage.ErrNotFound)
+
+	deletedStores, err = purger.ListDeletedStores(ctx)
+	require.NoError(t, err)
+	require.NotContains(t, deletedStores, storeID)
+
+	_, err = purger.PurgeStore(ctx, storeID, 2)
+	require.ErrorIs(t, err, storage.ErrNotFound)
+
+	_, err = datastore.FindLatestAuthorizationModel(ctx, otherStoreID)
+	require.NoError(t, err)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"testing"
 	"time"
@@ -593,6 +594,64 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		_, _, err = datastore.ReadChanges(context.Background(), storeID, storage.ReadChangesFilter{ObjectType: "folder"}, opts)
 		require.ErrorIs(t, err, storage.ErrNotFound)
 	})
+
+	t.Run("read_changes_from_start_time", func(t *testing.T) {
+		storeID := ulid.Make().String()
+
+		tk1 := tuple.NewTupleKey("folder:1", "viewer", "user:bob")
+		tk2 := tuple.NewTupleKey("document:1", "viewer", "user:bill")
+		tk3 := tuple.NewTupleKey("folder:1", "viewer", "user:josh")
+
+		err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk1})
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+		startTime := time.Now()
+		time.Sleep(10 * time.Millisecond)
+		err = datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk2})
+		require.NoError(t, err)
+		err = datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk3})
+		require.NoError(t, err)
+
+		users := func(changes []*openfgav1.TupleChange) []string {
+			var users []string
+			for _, change := range changes {
+				users = append(users, change.GetTupleKey().GetUser())
+			}
+			return users
+		}
+
+		filter := storage.ReadChangesFilter{StartTime: startTime}
+		changes, token, err := datastore.ReadChanges(ctx, storeID, filter, storage.ReadChangesOptions{
+			Pagination: storage.NewPaginationOptions(1, ""),
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{tk2.GetUser()}, users(changes))
+
+		// the continuation token goes on from the start time without it
+		changes, _, err = datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.ReadChangesOptions{
+			Pagination: storage.NewPaginationOptions(storage.DefaultPageSize, string(token)),
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{tk3.GetUser()}, users(changes))
+
+		changes, _, err = datastore.ReadChanges(ctx, storeID, filter, storage.ReadChangesOptions{
+			Pagination: storage.NewPaginationOptions(storage.DefaultPageSize, ""),
+			SortDesc:   true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{tk3.GetUser(), tk2.GetUser()}, users(changes))
+
+		changes, _, err = datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{ObjectType: "folder", StartTime: startTime}, storage.ReadChangesOptions{
+			Pagination: storage.NewPaginationOptions(storage.DefaultPageSize, ""),
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{tk3.GetUser()}, users(changes))
+
+		_, _, err = datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{StartTime: time.Now().Add(time.Minute)}, storage.ReadChangesOptions{
+			Pagination: storage.NewPaginationOptions(storage.DefaultPageSize, ""),
+		})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
 }
 
 func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore) {
@@ -1083,6 +1142,43 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 		require.ErrorIs(t, err, storage.ErrIteratorDone)
 	})
 
+	t.Run("reading_userset_tuples_with_filter_matches_type_and_relation_exactly", func(t *testing.T) {
+		storeID := ulid.Make().String()
+		tks := []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "viewer", "group:eng#can_view"),
+			tuple.NewTupleKey("document:1", "viewer", "group:eng#canxview"),
+			tuple.NewTupleKey("document:1", "viewer", "group:eng#owner"),
+			tuple.NewTupleKey("document:1", "viewer", "groups:eng#can_view"),
+			tuple.NewTupleKey("document:1", "viewer", "group:*"),
+		}
+
+		err := datastore.Write(ctx, storeID, nil, tks)
+		require.NoError(t, err)
+
+		gotTuples, err := datastore.ReadUsersetTuples(ctx, storeID, storage.ReadUsersetTuplesFilter{
+			Object:   "document:1",
+			Relation: "viewer",
+			AllowedUserTypeRestrictions: []*openfgav1.RelationReference{
+				typesystem.DirectRelationReference("group", "can_view"),
+			},
+		}, storage.ReadUsersetTuplesOptions{})
+		require.NoError(t, err)
+
+		iter := storage.NewTupleKeyIteratorFromTupleIterator(gotTuples)
+		defer iter.Stop()
+
+		gotTk, err := iter.Next(ctx)
+		require.NoError(t, err)
+
+		expected := tuple.NewTupleKey("document:1", "viewer", "group:eng#can_view")
+		if diff := cmp.Diff(expected, gotTk, cmpOpts...); diff != "" {
+			require.FailNowf(t, "mismatch (-want +got):\n%s", diff)
+		}
+
+		_, err = iter.Next(ctx)
+		require.ErrorIs(t, err, storage.ErrIteratorDone)
+	})
+
 	t.Run("tuples_with_nil_condition", func(t *testing.T) {
 		// This test ensures we don't normalize nil conditions to an empty value.
 		storeID := ulid.Make().String()
@@ -1741,3 +1837,558 @@ func writeTuplesConcurrently(t *testing.T, store string, datastore storage.OpenF
 
 	wg.Wait()
 }
+
+func ReadPageWithConditionFilterTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	tuples := []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:bob", "ip_allowlist", nil),
+		tuple.NewTupleKeyWithCondition("document:2", "viewer", "user:anne", "ip_allowlist", nil),
+		tuple.NewTupleKeyWithCondition("document:2", "editor", "user:anne", "in_region", nil),
+		tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+	}
+	err := datastore.Write(ctx, storeID, nil, tuples)
+	require.NoError(t, err)
+
+	hasCondition := true
+	hasNoCondition := false
+
+	tests := []struct {
+		name     string
+		tupleKey *openfgav1.TupleKey
+		filter   storage.ReadConditionFilter
+		expected []*openfgav1.TupleKey
+	}{
+		{
+			name:     "condition_name",
+			filter:   storage.ReadConditionFilter{Name: "ip_allowlist"},
+			expected: []*openfgav1.TupleKey{tuples[1], tuples[2]},
+		},
+		{
+			name:     "has_condition",
+			filter:   storage.ReadConditionFilter{HasCondition: &hasCondition},
+			expected: []*openfgav1.TupleKey{tuples[1], tuples[2], tuples[3]},
+		},
+		{
+			name:     "has_no_condition",
+			filter:   storage.ReadConditionFilter{HasCondition: &hasNoCondition},
+			expected: []*openfgav1.TupleKey{tuples[0], tuples[4]},
+		},
+		{
+			name:     "condition_name_and_object",
+			tupleKey: tuple.NewTupleKey("document:2", "", ""),
+			filter:   storage.ReadConditionFilter{Name: "ip_allowlist"},
+			expected: []*openfgav1.TupleKey{tuples[2]},
+		},
+		{
+			name:     "has_condition_and_user",
+			tupleKey: tuple.NewTupleKey("document:", "", "user:anne"),
+			filter:   storage.ReadConditionFilter{HasCondition: &hasCondition},
+			expected: []*openfgav1.TupleKey{tuples[2], tuples[3]},
+		},
+		{
+			name:     "condition_name_and_has_condition",
+			tupleKey: tuple.NewTupleKey("document:2", "editor", ""),
+			filter:   storage.ReadConditionFilter{Name: "in_region", HasCondition: &hasCondition},
+			expected: []*openfgav1.TupleKey{tuples[3]},
+		},
+		{
+			name:   "no_match",
+			filter: storage.ReadConditionFilter{Name: "unknown"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tupleKey := test.tupleKey
+			if tupleKey == nil {
+				tupleKey = &openfgav1.TupleKey{}
+			}
+
+			var got []*openfgav1.TupleKey
+			opts := storage.ReadPageOptions{
+				Pagination: storage.NewPaginationOptions(1, ""),
+				Condition:  test.filter,
+			}
+			for {
+				page, contToken, err := datastore.ReadPage(ctx, storeID, tupleKey, opts)
+				require.NoError(t, err)
+				for _, tp := range page {
+					got = append(got, tp.GetKey())
+				}
+				if len(contToken) == 0 {
+					break
+				}
+				opts.Pagination.From = string(contToken)
+			}
+
+			if diff := cmp.Diff(test.expected, got, cmpOpts...); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func ReadPageOrderTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	// Written out of order, with users that sort differently as strings than
+	// as (type, id, relation) parts.
+	tuples := []*openfgav1.TupleKey{
+		tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "team:a#member"),
+		tuple.NewTupleKey("document:1", "viewer", "team-a:x"),
+		tuple.NewTupleKey("document:1", "editor", "user:bob"),
+		tuple.NewTupleKey("document:1", "viewer", "team:a"),
+		tuple.NewTupleKey("document:1", "viewer", "user:*"),
+		tuple.NewTupleKey("document:10", "viewer", "user:anne"),
+	}
+	err := datastore.Write(ctx, storeID, nil, tuples)
+	require.NoError(t, err)
+
+	expected := slices.Clone(tuples)
+	slices.SortFunc(expected, func(a, b *openfgav1.TupleKey) int {
+		return storage.CompareReadOrder(readOrderRecord(a), readOrderRecord(b))
+	})
+
+	for _, pageSize := range []int32{1, 3, 100} {
+		t.Run(fmt.Sprintf("page_size_%d", pageSize), func(t *testing.T) {
+			var got []*openfgav1.TupleKey
+			opts := storage.ReadPageOptions{Pagination: storage.NewPaginationOptions(pageSize, "")}
+			for {
+				page, contToken, err := datastore.ReadPage(ctx, storeID, &openfgav1.TupleKey{}, opts)
+				require.NoError(t, err)
+				for _, tp := range page {
+					got = append(got, tp.GetKey())
+				}
+				if len(contToken) == 0 {
+					break
+				}
+				opts.Pagination.From = string(contToken)
+			}
+
+			if diff := cmp.Diff(expected, got, cmpOpts...); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("legacy_token_rejected", func(t *testing.T) {
+		opts := storage.ReadPageOptions{Pagination: storage.NewPaginationOptions(1, "5")}
+		_, _, err := datastore.ReadPage(ctx, storeID, &openfgav1.TupleKey{}, opts)
+		require.ErrorIs(t, err, storage.ErrInvalidContinuationToken)
+	})
+}
+
+func readOrderRecord(tk *openfgav1.TupleKey) *storage.TupleRecord {
+	objectType, objectID := tuple.SplitObject(tk.GetObject())
+	return &storage.TupleRecord{
+		ObjectType: objectType,
+		ObjectID:   objectID,
+		Relation:   tk.GetRelation(),
+		User:       tk.GetUser(),
+	}
+}
+
+func CountRelationTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	counter, ok := datastore.(storage.RelationCounter)
+	if !ok {
+		t.Skip("the datastore does not implement storage.RelationCounter")
+	}
+
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+		tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:2", "editor", "user:bob"),
+		tuple.NewTupleKey("folder:1", "viewer", "user:charlie"),
+	})
+	require.NoError(t, err)
+
+	counts, err := counter.CountRelation(ctx, storeID, "document", "viewer")
+	require.NoError(t, err)
+	require.Equal(t, storage.RelationCounts{Tuples: 3, DistinctObjects: 2, DistinctUsers: 2}, counts)
+
+	counts, err = counter.CountRelation(ctx, storeID, "document", "owner")
+	require.NoError(t, err)
+	require.Equal(t, storage.RelationCounts{}, counts)
+}
+
+func CountRelationUsageTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	counter, ok := datastore.(storage.RelationUsageCounter)
+	if !ok {
+		t.Skip("the datastore does not implement storage.RelationUsageCounter")
+	}
+
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	usage, err := counter.CountRelationUsage(ctx, storeID)
+	require.NoError(t, err)
+	require.Empty(t, usage)
+
+	err = datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("folder:1", "viewer", "user:charlie"),
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+		tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:2", "editor", "user:bob"),
+	})
+	require.NoError(t, err)
+
+	// the tuples of other stores are not counted
+	err = datastore.Write(ctx, ulid.Make().String(), nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "owner", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	usage, err = counter.CountRelationUsage(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, []storage.RelationUsage{
+		{ObjectType: "document", Relation: "editor", Tuples: 1},
+		{ObjectType: "document", Relation: "viewer", Tuples: 3},
+		{ObjectType: "folder", Relation: "viewer", Tuples: 1},
+	}, usage)
+}
+
+func SnapshotTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	beginner, ok := datastore.(storage.SnapshotBeginner)
+	if !ok {
+		t.Skip("the datastore does not implement storage.SnapshotBeginner")
+	}
+
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+	})
+	require.NoError(t, err)
+
+	// more tuples than a read reads ahead, so that the reads stream them
+	const manyUsers = 250
+	var many []*openfgav1.TupleKey
+	var manyUserIDs []string
+	for i := 0; i < manyUsers; i++ {
+		many = append(many, tuple.NewTupleKey("document:many", "viewer", fmt.Sprintf("user:%d", i)))
+		manyUserIDs = append(manyUserIDs, fmt.Sprintf("user:%d", i))
+	}
+	for i := 0; i < len(many); i += 100 {
+		err = datastore.Write(ctx, storeID, nil, many[i:min(i+100, len(many))])
+		require.NoError(t, err)
+	}
+
+	snapshot, err := beginner.BeginSnapshot(ctx, storage.SnapshotOptions{MaxConcurrentReads: 2})
+	require.NoError(t, err)
+	defer snapshot.Release()
+
+	// the first read takes the snapshot of the transactional datastores
+	_, err = snapshot.ReadUserTuple(ctx, storeID, tuple.NewTupleKey("document:1", "viewer", "user:anne"), storage.ReadUserTupleOptions{})
+	require.NoError(t, err)
+
+	err = datastore.Write(ctx, storeID,
+		[]*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("document:1", "viewer", "user:anne"))},
+		[]*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+			tuple.NewTupleKey("document:1", "viewer", "group:sales#member"),
+		},
+	)
+	require.NoError(t, err)
+
+	t.Run("reads_the_tuples_of_the_snapshot", func(t *testing.T) {
+		iter, err := snapshot.Read(ctx, storeID, tuple.NewTupleKey("document:1", "viewer", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		// a read while the iterator of another one is open
+		usersets, err := snapshot.ReadUsersetTuples(ctx, storeID, storage.ReadUsersetTuplesFilter{Object: "document:1", Relation: "viewer"}, storage.ReadUsersetTuplesOptions{})
+		require.NoError(t, err)
+
+		require.ElementsMatch(t, []string{"user:anne", "group:eng#member"}, readUsers(t, iter))
+		require.ElementsMatch(t, []string{"group:eng#member"}, readUsers(t, usersets))
+
+		_, err = snapshot.ReadUserTuple(ctx, storeID, tuple.NewTupleKey("document:1", "viewer", "user:anne"), storage.ReadUserTupleOptions{})
+		require.NoError(t, err)
+		_, err = snapshot.ReadUserTuple(ctx, storeID, tuple.NewTupleKey("document:1", "viewer", "user:bob"), storage.ReadUserTupleOptions{})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		iter, err = snapshot.ReadStartingWithUser(ctx, storeID, storage.ReadStartingWithUserFilter{
+			ObjectType: "document",
+			Relation:   "viewer",
+			UserFilter: []*openfgav1.ObjectRelation{{Object: "user:anne"}, {Object: "user:bob"}},
+		}, storage.ReadStartingWithUserOptions{})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"user:anne"}, readUsers(t, iter))
+	})
+
+	t.Run("streamed_reads_do_not_wait_for_each_other", func(t *testing.T) {
+		first, err := snapshot.Read(ctx, storeID, tuple.NewTupleKey("document:many", "viewer", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		head, err := first.Head(ctx)
+		require.NoError(t, err)
+
+		second, err := snapshot.Read(ctx, storeID, tuple.NewTupleKey("document:many", "viewer", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		// a read while both iterators are open and have tuples left
+		_, err = snapshot.ReadUserTuple(ctx, storeID, tuple.NewTupleKey("document:many", "viewer", "user:0"), storage.ReadUserTupleOptions{})
+		require.NoError(t, err)
+
+		firstUsers := readUsers(t, first)
+		require.ElementsMatch(t, manyUserIDs, firstUsers)
+		require.Equal(t, head.GetKey().GetUser(), firstUsers[0])
+		require.ElementsMatch(t, manyUserIDs, readUsers(t, second))
+	})
+
+	t.Run("stopped_streamed_read_frees_its_transaction", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			iter, err := snapshot.ReadStartingWithUser(ctx, storeID, storage.ReadStartingWithUserFilter{
+				ObjectType: "document",
+				Relation:   "viewer",
+				UserFilter: []*openfgav1.ObjectRelation{{Object: "user:1"}, {Object: "user:2"}},
+			}, storage.ReadStartingWithUserOptions{})
+			require.NoError(t, err)
+			iter.Stop()
+
+			iter, err = snapshot.Read(ctx, storeID, tuple.NewTupleKey("document:many", "viewer", ""), storage.ReadOptions{})
+			require.NoError(t, err)
+			_, err = iter.Next(ctx)
+			require.NoError(t, err)
+			iter.Stop()
+		}
+	})
+
+	t.Run("the_datastore_reads_the_current_tuples", func(t *testing.T) {
+		iter, err := datastore.Read(ctx, storeID, tuple.NewTupleKey("document:1", "viewer", ""), storage.ReadOptions{})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"user:bob", "group:eng#member", "group:sales#member"}, readUsers(t, iter))
+	})
+
+	t.Run("release_can_be_called_more_than_once", func(t *testing.T) {
+		snapshot.Release()
+		snapshot.Release()
+	})
+}
+
+// readUsers returns the users of the tuples of iter and stops it.
+func readUsers(t *testing.T, iter storage.TupleIterator) []string {
+	t.Helper()
+	defer iter.Stop()
+
+	var users []string
+	for {
+		tk, err := iter.Next(context.Background())
+		if errors.Is(err, storage.ErrIteratorDone) {
+			return users
+		}
+		require.NoError(t, err)
+		users = append(users, tk.GetKey().GetUser())
+	}
+}
+
+func TupleCounterTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	counter, ok := datastore.(storage.TupleCounter)
+	if !ok {
+		t.Skip("the datastore does not implement storage.TupleCounter")
+	}
+
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	counts, err := counter.ReadTupleCounts(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), counts.Total)
+
+	err = datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+		tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	err = datastore.Write(ctx, storeID, []*openfgav1.TupleKeyWithoutCondition{
+		tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("document:1", "viewer", "user:anne")),
+		tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("folder:1", "viewer", "user:anne")),
+	}, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:3", "viewer", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	counts, err = counter.ReadTupleCounts(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), counts.Total)
+	require.Equal(t, int64(2), counts.ByObjectType["document"])
+	require.Zero(t, counts.ByObjectType["folder"])
+
+	t.Run("failed_write_does_not_change_the_counts", func(t *testing.T) {
+		err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:4", "viewer", "user:anne"),
+			tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+		})
+		require.Error(t, err)
+
+		counts, err := counter.ReadTupleCounts(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), counts.Total)
+	})
+
+	t.Run("backfill", func(t *testing.T) {
+		require.NoError(t, counter.BackfillTupleCounts(ctx, storeID))
+
+		counts, err := counter.ReadTupleCounts(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), counts.Total)
+		require.Equal(t, int64(2), counts.ByObjectType["document"])
+		require.Zero(t, counts.ByObjectType["folder"])
+	})
+}
+
+func ChangelogTrimmerTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	trimmer, ok := datastore.(storage.ChangelogTrimmer)
+	if !ok {
+		t.Skip("the datastore does not implement storage.ChangelogTrimmer")
+	}
+
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	write := func(objects ...string) {
+		for _, object := range objects {
+			err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey(object, "viewer", "user:anne")})
+			require.NoError(t, err)
+		}
+	}
+
+	write("document:1", "document:2")
+	time.Sleep(5 * time.Millisecond)
+	before := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	write("document:3", "document:4")
+
+	readChanges := func(pageSize int, token string, sortDesc bool) ([]*openfgav1.TupleChange, string, error) {
+		opts := storage.ReadChangesOptions{
+			Pagination: storage.PaginationOptions{PageSize: pageSize, From: token},
+			SortDesc:   sortDesc,
+		}
+		changes, contToken, err := datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, opts)
+		return changes, string(contToken), err
+	}
+
+	_, behindToken, err := readChanges(1, "", false)
+	require.NoError(t, err)
+	_, caughtUpWithTrimmedToken, err := readChanges(2, "", false)
+	require.NoError(t, err)
+	_, retainedToken, err := readChanges(3, "", false)
+	require.NoError(t, err)
+
+	trimmed, err := trimmer.TrimChanges(ctx, storeID, before)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), trimmed)
+
+	t.Run("token_before_a_trimmed_change_expires", func(t *testing.T) {
+		_, _, err := readChanges(1, behindToken, false)
+		require.ErrorIs(t, err, storage.ErrContinuationTokenExpired)
+	})
+
+	t.Run("token_of_the_newest_trimmed_change_keeps_working", func(t *testing.T) {
+		changes, _, err := readChanges(1, caughtUpWithTrimmedToken, false)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, "document:3", changes[0].GetTupleKey().GetObject())
+	})
+
+	t.Run("token_of_a_retained_change_keeps_working", func(t *testing.T) {
+		changes, _, err := readChanges(1, retainedToken, false)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, "document:4", changes[0].GetTupleKey().GetObject())
+	})
+
+	t.Run("reading_from_the_beginning_starts_at_the_oldest_retained_change", func(t *testing.T) {
+		changes, _, err := readChanges(10, "", false)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		require.Equal(t, "document:3", changes[0].GetTupleKey().GetObject())
+	})
+
+	t.Run("descending_reads_are_not_affected", func(t *testing.T) {
+		changes, token, err := readChanges(1, "", true)
+		require.NoError(t, err)
+		require.Equal(t, "document:4", changes[0].GetTupleKey().GetObject())
+
+		changes, _, err = readChanges(1, token, true)
+		require.NoError(t, err)
+		require.Equal(t, "document:3", changes[0].GetTupleKey().GetObject())
+	})
+
+	t.Run("trimming_again_deletes_nothing", func(t *testing.T) {
+		trimmed, err := trimmer.TrimChanges(ctx, storeID, before)
+		require.NoError(t, err)
+		require.Zero(t, trimmed)
+	})
+
+	t.Run("caught_up_token_keeps_working_after_every_change_is_trimmed", func(t *testing.T) {
+		_, caughtUpToken, err := readChanges(10, "", false)
+		require.NoError(t, err)
+
+		time.Sleep(5 * time.Millisecond)
+		trimmed, err := trimmer.TrimChanges(ctx, storeID, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, int64(2), trimmed)
+		time.Sleep(5 * time.Millisecond)
+		write("document:5")
+
+		changes, _, err := readChanges(10, caughtUpToken, false)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, "document:5", changes[0].GetTupleKey().GetObject())
+	})
+}
+
+func ChangelogStatsReaderTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	reader, ok := datastore.(storage.ChangelogStatsReader)
+	if !ok {
+		t.Skip("the datastore does not implement storage.ChangelogStatsReader")
+	}
+
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	t.Run("store_without_changes", func(t *testing.T) {
+		_, err := reader.GetLatestChange(ctx, storeID)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	before, err := reader.CountChanges(ctx)
+	require.NoError(t, err)
+
+	err = datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")})
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	// changelog times may be truncated to the millisecond
+	written := time.Now().Truncate(time.Millisecond)
+	err = datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:2", "viewer", "user:anne")})
+	require.NoError(t, err)
+	after := time.Now()
+
+	t.Run("latest_change_is_the_newest_write", func(t *testing.T) {
+		latest, err := reader.GetLatestChange(ctx, storeID)
+		require.NoError(t, err)
+		require.False(t, latest.Before(written))
+		require.False(t, latest.After(after))
+	})
+
+	t.Run("count_includes_the_new_changes", func(t *testing.T) {
+		count, err := reader.CountChanges(ctx)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, count.Count, int64(0))
+		if !before.Estimated && !count.Estimated {
+			require.Equal(t, before.Count+2, count.Count)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package loadgen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage/memory"
+)
+
+func TestGeneratorIsDeterministic(t *testing.T) {
+	first, err := NewGenerator(42).Instance(DefaultModelShape, DefaultTupleShape)
+	require.NoError(t, err)
+	second, err := NewGenerator(42).Instance(DefaultModelShape, DefaultTupleShape)
+	require.NoError(t, err)
+
+	require.Equal(t, first.Model.DSL, second.Model.DSL)
+	require.Equal(t, []string{"t0", "t1", "t2"}, first.Model.ObjectTypes())
+	require.Len(t, second.Tuples, len(first.Tuples))
+	for i := range first.Tuples {
+		require.Equal(t, first.Tuples[i].String(), second.Tuples[i].String())
+	}
+
+	other, err := NewGenerator(43).Instance(DefaultModelShape, DefaultTupleShape)
+	require.NoError(t, err)
+	require.NotEqual(t, first.Model.DSL+first.Tuples[0].String(), other.Model.DSL+other.Tuples[0].String())
+}
+
+func TestGeneratorRejectsEmptyShapes(t *testing.T) {
+	_, err := NewGenerator(1).Model(ModelShape{})
+	require.Error(t, err)
+}
+
+func TestRunnerMatchesTheReference(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+	srv := server.MustNewServerWithOpts(server.WithDatastore(ds))
+	t.Cleanup(srv.Close)
+
+	for _, seed := range []int64{1, 2, 3, 4, 5} {
+		instance, err := NewGenerator(seed).Instance(DefaultModelShape, DefaultTupleShape)
+		require.NoError(t, err)
+
+		runner, err := NewRunner(context.Background(), srv, instance)
+		require.NoError(t, err, instance.Model.DSL)
+
+		report, err := runner.Run(context.Background(), Workload{
+			Seed:              seed,
+			Operations:        200,
+			CheckWeight:       6,
+			ListObjectsWeight: 2,
+			WriteWeight:       2,
+			Verify:            true,
+		})
+		require.NoError(t, err)
+		require.Empty(t, report.Mismatches, instance.Model.DSL)
+
+		checks := report.Stats[CheckOperation]
+		require.Positive(t, checks.Count)
+		require.Zero(t, checks.Errors)
+		require.Positive(t, checks.DatastoreQueryCount)
+		require.Positive(t, checks.Percentile(50))
+		require.Zero(t, report.Stats[ListObjectsOperation].Errors)
+		require.Zero(t, report.Stats[WriteOperation].Errors)
+	}
+}
+
+func TestRunnerRunsConcurrently(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+	srv := server.MustNewServerWithOpts(server.WithDatastore(ds))
+	t.Cleanup(srv.Close)
+
+	instance, err := NewGenerator(7).Instance(DefaultModelShape, DefaultTupleShape)
+	require.NoError(t, err)
+	runner, err := NewRunner(context.Background(), srv, instance)
+	require.NoError(t, err)
+
+	report, err := runner.Run(context.Background(), Workload{
+		Seed:        7,
+		Operations:  100,
+		Concurrency: 8,
+		CheckWeight: 1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 100, report.Stats[CheckOperation].Count)
+	require.Zero(t, report.Stats[CheckOperation].Errors)
+	require.Contains(t, report.String(), "check: count=100 errors=0")
+}
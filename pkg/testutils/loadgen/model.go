@@ -0,0 +1,221 @@
+// Package loadgen generates authorization models and tuples of a configurable shape, and runs
+// mixed Check, ListObjects and Write workloads against a [server.Server], recording their latency
+// and resolution counters. On small instances, it can cross-check the answers of the server
+// against a naive reference evaluator.
+//
+// Everything is derived from a seed, so that the same seed and shapes reproduce the same model,
+// tuples and operations, e.g. to reproduce a performance report or to run regression benchmarks.
+package loadgen
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	parser "github.com/openfga/language/pkg/go/transformer"
+)
+
+const (
+	// userType is the type of the users of the generated tuples.
+	userType = "user"
+
+	// memberRelation is the relation of each generated type that combines its other relations.
+	memberRelation = "member"
+
+	// parentRelation is the tupleset relation of the generated types below the first level.
+	parentRelation = "parent"
+
+	// conditionName is the condition of the conditional relations. It is met if the "allowed"
+	// parameter of the tuple context is true, so that the reference evaluator doesn't need CEL.
+	conditionName = "is_allowed"
+)
+
+// ModelShape configures the authorization models generated by a [Generator].
+//
+// A model has Depth levels of object types, named t0 to t{Depth-1}. The types of every level have
+// DirectRelations directly assignable relations d0 to d{DirectRelations-1}, and a member relation
+// that is the union of Branching operands. Every type below the first level has a parent relation
+// to the type of the previous level.
+type ModelShape struct {
+	// Depth is the number of levels of object types.
+	Depth int
+
+	// Branching is the number of operands of the member relation of each type.
+	Branching int
+
+	// DirectRelations is the number of directly assignable relations of each type.
+	DirectRelations int
+
+	// TTUDensity is the probability that an operand of member is a tuple to userset rewrite
+	// through the parent relation.
+	TTUDensity float64
+
+	// UsersetDensity is the probability that a directly assignable relation also accepts the
+	// member usersets of the type of the previous level.
+	UsersetDensity float64
+
+	// SetOperationDensity is the probability that an operand of member is the intersection or the
+	// exclusion of two directly assignable relations.
+	SetOperationDensity float64
+
+	// ConditionDensity is the probability that a directly assignable relation also accepts
+	// conditional users.
+	ConditionDensity float64
+}
+
+// DefaultModelShape is a small shape that exercises every kind of rewrite.
+var DefaultModelShape = ModelShape{
+	Depth:               3,
+	Branching:           3,
+	DirectRelations:     2,
+	TTUDensity:          0.5,
+	UsersetDensity:      0.3,
+	SetOperationDensity: 0.2,
+	ConditionDensity:    0.3,
+}
+
+// Model is a generated authorization model.
+type Model struct {
+	// DSL is the model in the DSL, e.g. to reproduce an issue with the CLI.
+	DSL string
+
+	// Proto is the model, without ID.
+	Proto *openfgav1.AuthorizationModel
+
+	levels []level
+}
+
+// level describes the type of a level of a generated model, to generate tuples and requests.
+type level struct {
+	objectType      string
+	directRelations []directRelation
+}
+
+type directRelation struct {
+	name string
+
+	// usersetType is the type whose member usersets the relation accepts, if any.
+	usersetType string
+
+	// conditional is true if the relation accepts conditional users.
+	conditional bool
+}
+
+// ObjectTypes returns the object types of the model, from the first level to the last one.
+func (m *Model) ObjectTypes() []string {
+	objectTypes := make([]string, 0, len(m.levels))
+	for _, l := range m.levels {
+		objectTypes = append(objectTypes, l.objectType)
+	}
+	return objectTypes
+}
+
+// relations returns the relations of the given level that can be checked.
+func (l level) relations() []string {
+	relations := []string{memberRelation}
+	for _, r := range l.directRelations {
+		relations = append(relations, r.name)
+	}
+	return relations
+}
+
+// Generator generates models, tuples and workloads from a seed. It is not safe for concurrent use.
+type Generator struct {
+	rng *rand.Rand
+}
+
+// NewGenerator returns a [Generator] seeded with seed.
+func NewGenerator(seed int64) *Generator {
+	//nolint:gosec // reproducibility matters here, not unpredictability
+	return &Generator{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Model generates an authorization model of the given shape.
+func (g *Generator) Model(shape ModelShape) (*Model, error) {
+	if shape.Depth < 1 || shape.Branching < 1 || shape.DirectRelations < 1 {
+		return nil, fmt.Errorf("depth, branching and direct relations must be at least 1, got %d, %d and %d",
+			shape.Depth, shape.Branching, shape.DirectRelations)
+	}
+
+	model := &Model{}
+	var dsl strings.Builder
+	dsl.WriteString("model\n  schema 1.1\n\ntype " + userType + "\n")
+
+	usesCondition := false
+	for depth := 0; depth < shape.Depth; depth++ {
+		l := level{objectType: fmt.Sprintf("t%d", depth)}
+		var previous *level
+		if depth > 0 {
+			previous = &model.levels[depth-1]
+		}
+
+		fmt.Fprintf(&dsl, "\ntype %s\n  relations\n", l.objectType)
+		if previous != nil {
+			fmt.Fprintf(&dsl, "    define %s: [%s]\n", parentRelation, previous.objectType)
+		}
+
+		for i := 0; i < shape.DirectRelations; i++ {
+			r := directRelation{name: fmt.Sprintf("d%d", i)}
+			restrictions := []string{userType}
+			if g.rng.Float64() < shape.ConditionDensity {
+				r.conditional = true
+				usesCondition = true
+				restrictions = append(restrictions, userType+" with "+conditionName)
+			}
+			if previous != nil && g.rng.Float64() < shape.UsersetDensity {
+				r.usersetType = previous.objectType
+				restrictions = append(restrictions, previous.objectType+"#"+memberRelation)
+			}
+			l.directRelations = append(l.directRelations, r)
+			fmt.Fprintf(&dsl, "    define %s: [%s]\n", r.name, strings.Join(restrictions, ", "))
+		}
+
+		// duplicate operands are dropped, so member may have fewer than Branching operands
+		operands := make([]string, 0, shape.Branching)
+		for i := 0; i < shape.Branching; i++ {
+			if operand := g.operand(shape, l, previous); !slices.Contains(operands, operand) {
+				operands = append(operands, operand)
+			}
+		}
+		fmt.Fprintf(&dsl, "    define %s: %s\n", memberRelation, strings.Join(operands, " or "))
+
+		model.levels = append(model.levels, l)
+	}
+
+	if usesCondition {
+		fmt.Fprintf(&dsl, "\ncondition %s(allowed: bool) {\n  allowed\n}\n", conditionName)
+	}
+
+	proto, err := parser.TransformDSLToProto(dsl.String())
+	if err != nil {
+		return nil, fmt.Errorf("generated an invalid model: %w", err)
+	}
+
+	model.DSL = dsl.String()
+	model.Proto = proto
+	return model, nil
+}
+
+// operand generates an operand of the member relation of l.
+func (g *Generator) operand(shape ModelShape, l level, previous *level) string {
+	if previous != nil && g.rng.Float64() < shape.TTUDensity {
+		relations := previous.relations()
+		return relations[g.rng.Intn(len(relations))] + " from " + parentRelation
+	}
+
+	first := l.directRelations[g.rng.Intn(len(l.directRelations))].name
+	if len(l.directRelations) > 1 && g.rng.Float64() < shape.SetOperationDensity {
+		second := l.directRelations[g.rng.Intn(len(l.directRelations))].name
+		for second == first {
+			second = l.directRelations[g.rng.Intn(len(l.directRelations))].name
+		}
+		if g.rng.Intn(2) == 0 {
+			return "(" + first + " and " + second + ")"
+		}
+		return "(" + first + " but not " + second + ")"
+	}
+
+	return first
+}
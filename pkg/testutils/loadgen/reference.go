@@ -0,0 +1,180 @@
+package loadgen
+
+import (
+	"fmt"
+	"sort"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// Reference is a naive evaluator of Check and ListObjects over an in-memory set of tuples. It
+// walks the rewrites of the model without any of the optimizations of the server, so that its
+// answers can be trusted on small instances, and it only understands the conditions of generated
+// models. It is not safe for concurrent use.
+type Reference struct {
+	// map: object type => relation => rewrite
+	rewrites map[string]map[string]*openfgav1.Userset
+
+	// map: object#relation => tuples
+	tuples map[string][]*openfgav1.TupleKey
+
+	// map: tuple key without condition => struct{}
+	keys map[string]struct{}
+}
+
+// NewReference returns a [Reference] for the given model and tuples.
+func NewReference(model *openfgav1.AuthorizationModel, tuples []*openfgav1.TupleKey) *Reference {
+	r := &Reference{
+		rewrites: make(map[string]map[string]*openfgav1.Userset),
+		tuples:   make(map[string][]*openfgav1.TupleKey),
+		keys:     make(map[string]struct{}),
+	}
+	for _, typeDef := range model.GetTypeDefinitions() {
+		r.rewrites[typeDef.GetType()] = typeDef.GetRelations()
+	}
+	for _, tk := range tuples {
+		r.Add(tk)
+	}
+	return r
+}
+
+// Add adds a tuple, e.g. after writing it to the store. It reports whether the tuple was new.
+func (r *Reference) Add(tk *openfgav1.TupleKey) bool {
+	key := tuple.TupleKeyToString(tk)
+	if _, ok := r.keys[key]; ok {
+		return false
+	}
+	r.keys[key] = struct{}{}
+
+	objectRelation := tuple.ToObjectRelationString(tk.GetObject(), tk.GetRelation())
+	r.tuples[objectRelation] = append(r.tuples[objectRelation], tk)
+	return true
+}
+
+// Contains reports whether the tuple, ignoring its condition, was added.
+func (r *Reference) Contains(tk *openfgav1.TupleKey) bool {
+	_, ok := r.keys[tuple.TupleKeyToString(tk)]
+	return ok
+}
+
+// Check returns whether user has relation with object.
+func (r *Reference) Check(object, relation, user string) (bool, error) {
+	return r.check(object, relation, user, make(map[string]struct{}))
+}
+
+// ListObjects returns the objects of objectType that user has relation with, sorted. The
+// candidates are the objects of objectType that appear in a tuple.
+func (r *Reference) ListObjects(objectType, relation, user string) ([]string, error) {
+	candidates := make(map[string]struct{})
+	for _, tuples := range r.tuples {
+		for _, tk := range tuples {
+			if tuple.GetType(tk.GetObject()) == objectType {
+				candidates[tk.GetObject()] = struct{}{}
+			}
+		}
+	}
+
+	var objects []string
+	for object := range candidates {
+		allowed, err := r.Check(object, relation, user)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			objects = append(objects, object)
+		}
+	}
+	sort.Strings(objects)
+	return objects, nil
+}
+
+func (r *Reference) check(object, relation, user string, visited map[string]struct{}) (bool, error) {
+	objectRelation := tuple.ToObjectRelationString(object, relation)
+	if _, ok := visited[objectRelation]; ok {
+		return false, nil
+	}
+	visited[objectRelation] = struct{}{}
+	defer delete(visited, objectRelation)
+
+	rewrite, ok := r.rewrites[tuple.GetType(object)][relation]
+	if !ok {
+		return false, fmt.Errorf("relation '%s' is not defined", objectRelation)
+	}
+	return r.rewrite(object, relation, rewrite, user, visited)
+}
+
+func (r *Reference) rewrite(object, relation string, rewrite *openfgav1.Userset, user string, visited map[string]struct{}) (bool, error) {
+	switch rw := rewrite.GetUserset().(type) {
+	case *openfgav1.Userset_This:
+		for _, tk := range r.tuples[tuple.ToObjectRelationString(object, relation)] {
+			if !conditionMet(tk) {
+				continue
+			}
+			if tk.GetUser() == user {
+				return true, nil
+			}
+			if tuple.IsObjectRelation(tk.GetUser()) {
+				usersetObject, usersetRelation := tuple.SplitObjectRelation(tk.GetUser())
+				allowed, err := r.check(usersetObject, usersetRelation, user, visited)
+				if err != nil || allowed {
+					return allowed, err
+				}
+			}
+		}
+		return false, nil
+	case *openfgav1.Userset_ComputedUserset:
+		return r.check(object, rw.ComputedUserset.GetRelation(), user, visited)
+	case *openfgav1.Userset_TupleToUserset:
+		tupleset := rw.TupleToUserset.GetTupleset().GetRelation()
+		computed := rw.TupleToUserset.GetComputedUserset().GetRelation()
+		for _, tk := range r.tuples[tuple.ToObjectRelationString(object, tupleset)] {
+			if !conditionMet(tk) {
+				continue
+			}
+			allowed, err := r.check(tk.GetUser(), computed, user, visited)
+			if err != nil || allowed {
+				return allowed, err
+			}
+		}
+		return false, nil
+	case *openfgav1.Userset_Union:
+		for _, child := range rw.Union.GetChild() {
+			allowed, err := r.rewrite(object, relation, child, user, visited)
+			if err != nil || allowed {
+				return allowed, err
+			}
+		}
+		return false, nil
+	case *openfgav1.Userset_Intersection:
+		for _, child := range rw.Intersection.GetChild() {
+			allowed, err := r.rewrite(object, relation, child, user, visited)
+			if err != nil || !allowed {
+				return false, err
+			}
+		}
+		return true, nil
+	case *openfgav1.Userset_Difference:
+		allowed, err := r.rewrite(object, relation, rw.Difference.GetBase(), user, visited)
+		if err != nil || !allowed {
+			return false, err
+		}
+		denied, err := r.rewrite(object, relation, rw.Difference.GetSubtract(), user, visited)
+		if err != nil {
+			return false, err
+		}
+		return !denied, nil
+	default:
+		return false, fmt.Errorf("unsupported rewrite of relation '%s'", relation)
+	}
+}
+
+// conditionMet evaluates the condition of a generated tuple, which is met if the "allowed"
+// parameter of its context is true.
+func conditionMet(tk *openfgav1.TupleKey) bool {
+	if tk.GetCondition() == nil {
+		return true
+	}
+	return tk.GetCondition().GetContext().GetFields()["allowed"].GetBoolValue()
+}
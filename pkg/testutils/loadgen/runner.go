@@ -0,0 +1,344 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// OperationKind is the kind of an operation of a workload.
+type OperationKind string
+
+// The kinds of operations of a workload.
+const (
+	CheckOperation       OperationKind = "check"
+	ListObjectsOperation OperationKind = "list_objects"
+	WriteOperation       OperationKind = "write"
+)
+
+// the tags set by the server with the resolution counters of Check and ListObjects
+const (
+	datastoreQueryCountTag = "datastore_query_count"
+	dispatchCountTag       = "dispatch_count"
+)
+
+// Workload configures the operations run by a [Runner].
+type Workload struct {
+	// Seed seeds the generation of the operations.
+	Seed int64
+
+	// Operations is the number of operations to run.
+	Operations int
+
+	// Concurrency is the number of operations run concurrently. It is ignored when Verify is set.
+	Concurrency int
+
+	// CheckWeight, ListObjectsWeight and WriteWeight are the relative frequencies of each kind of
+	// operation.
+	CheckWeight       int
+	ListObjectsWeight int
+	WriteWeight       int
+
+	// Verify cross-checks every Check and ListObjects answer against a [Reference], and runs the
+	// operations one at a time so that the reference sees the writes in order. Use it on small
+	// instances only.
+	Verify bool
+}
+
+// Stats are the measurements of the operations of one kind.
+type Stats struct {
+	Count  int
+	Errors int
+
+	// DatastoreQueryCount and DispatchCount are the sums of the resolution counters reported by
+	// the server.
+	DatastoreQueryCount uint64
+	DispatchCount       uint64
+
+	latencies []time.Duration
+}
+
+// Percentile returns the latency below which p percent of the successful operations completed,
+// e.g. Percentile(99) for the p99.
+func (s *Stats) Percentile(p float64) time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+	i := int(float64(len(s.latencies)-1) * p / 100)
+	return s.latencies[min(max(i, 0), len(s.latencies)-1)]
+}
+
+// Mismatch is a Check or ListObjects answer of the server that differs from the reference.
+type Mismatch struct {
+	Operation OperationKind
+	Request   string
+	Expected  string
+	Actual    string
+}
+
+// Report is the outcome of a workload.
+type Report struct {
+	Duration   time.Duration
+	Stats      map[OperationKind]*Stats
+	Mismatches []Mismatch
+}
+
+// String summarizes the report, one line per kind of operation.
+func (r *Report) String() string {
+	kinds := make([]string, 0, len(r.Stats))
+	for kind := range r.Stats {
+		kinds = append(kinds, string(kind))
+	}
+	sort.Strings(kinds)
+
+	summary := fmt.Sprintf("duration=%s mismatches=%d\n", r.Duration, len(r.Mismatches))
+	for _, kind := range kinds {
+		s := r.Stats[OperationKind(kind)]
+		summary += fmt.Sprintf("%s: count=%d errors=%d p50=%s p95=%s p99=%s datastore_queries=%d dispatches=%d\n",
+			kind, s.Count, s.Errors, s.Percentile(50), s.Percentile(95), s.Percentile(99), s.DatastoreQueryCount, s.DispatchCount)
+	}
+	return summary
+}
+
+// Runner runs workloads against the store of an [Instance] on a [server.Server].
+type Runner struct {
+	srv       *server.Server
+	instance  *Instance
+	storeID   string
+	modelID   string
+	reference *Reference
+}
+
+// NewRunner creates a store on srv, and writes the model and the tuples of instance to it.
+func NewRunner(ctx context.Context, srv *server.Server, instance *Instance) (*Runner, error) {
+	store, err := srv.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "loadgen"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the store: %w", err)
+	}
+
+	model, err := srv.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store.GetId(),
+		TypeDefinitions: instance.Model.Proto.GetTypeDefinitions(),
+		SchemaVersion:   instance.Model.Proto.GetSchemaVersion(),
+		Conditions:      instance.Model.Proto.GetConditions(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write the model: %w\n%s", err, instance.Model.DSL)
+	}
+
+	for i := 0; i < len(instance.Tuples); i += serverconfig.DefaultMaxTuplesPerWrite {
+		batch := instance.Tuples[i:min(i+serverconfig.DefaultMaxTuplesPerWrite, len(instance.Tuples))]
+		_, err := srv.Write(ctx, &openfgav1.WriteRequest{
+			StoreId:              store.GetId(),
+			AuthorizationModelId: model.GetAuthorizationModelId(),
+			Writes:               &openfgav1.WriteRequestWrites{TupleKeys: batch},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to write the tuples: %w", err)
+		}
+	}
+
+	return &Runner{
+		srv:       srv,
+		instance:  instance,
+		storeID:   store.GetId(),
+		modelID:   model.GetAuthorizationModelId(),
+		reference: NewReference(instance.Model.Proto, instance.Tuples),
+	}, nil
+}
+
+// StoreID returns the ID of the store of the runner.
+func (r *Runner) StoreID() string {
+	return r.storeID
+}
+
+// operation is an operation of a workload. Only the fields of its kind are set.
+type operation struct {
+	kind     OperationKind
+	object   string
+	relation string
+	user     string
+	write    *openfgav1.TupleKey
+}
+
+// Run runs a workload and reports its measurements. Errors returned by the server are counted,
+// not returned.
+func (r *Runner) Run(ctx context.Context, workload Workload) (*Report, error) {
+	operations, err := r.operations(workload)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Stats: map[OperationKind]*Stats{
+		CheckOperation:       {},
+		ListObjectsOperation: {},
+		WriteOperation:       {},
+	}}
+	var mu sync.Mutex
+
+	concurrency := max(workload.Concurrency, 1)
+	if workload.Verify {
+		concurrency = 1
+	}
+
+	start := time.Now()
+	next := make(chan operation)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for op := range next {
+				r.run(ctx, op, workload.Verify, report, &mu)
+			}
+		}()
+	}
+	for _, op := range operations {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- op
+	}
+	close(next)
+	wg.Wait()
+	report.Duration = time.Since(start)
+
+	for _, stats := range report.Stats {
+		slices.Sort(stats.latencies)
+	}
+	return report, ctx.Err()
+}
+
+// operations generates the operations of a workload.
+func (r *Runner) operations(workload Workload) ([]operation, error) {
+	total := workload.CheckWeight + workload.ListObjectsWeight + workload.WriteWeight
+	if total <= 0 {
+		return nil, fmt.Errorf("the workload must have a positive weight")
+	}
+
+	g := NewGenerator(workload.Seed)
+	model := r.instance.Model
+	operations := make([]operation, 0, workload.Operations)
+	for i := 0; i < workload.Operations; i++ {
+		l := model.levels[g.rng.Intn(len(model.levels))]
+		relations := l.relations()
+		relation := relations[g.rng.Intn(len(relations))]
+		user := g.user(r.instance.Shape)
+
+		switch n := g.rng.Intn(total); {
+		case n < workload.CheckWeight:
+			operations = append(operations, operation{kind: CheckOperation, object: g.object(l, r.instance.Shape), relation: relation, user: user})
+		case n < workload.CheckWeight+workload.ListObjectsWeight:
+			operations = append(operations, operation{kind: ListObjectsOperation, object: l.objectType, relation: relation, user: user})
+		default:
+			operations = append(operations, operation{kind: WriteOperation, write: g.writeTuple(model, r.instance.Shape)})
+		}
+	}
+	return operations, nil
+}
+
+func (r *Runner) run(ctx context.Context, op operation, verify bool, report *Report, mu *sync.Mutex) {
+	tags := grpc_ctxtags.NewTags()
+	ctx = grpc_ctxtags.SetInContext(ctx, tags)
+
+	var mismatch *Mismatch
+	var latency time.Duration
+	start := time.Now()
+	err := func() error {
+		switch op.kind {
+		case CheckOperation:
+			resp, err := r.srv.Check(ctx, &openfgav1.CheckRequest{
+				StoreId:              r.storeID,
+				AuthorizationModelId: r.modelID,
+				TupleKey:             tuple.NewCheckRequestTupleKey(op.object, op.relation, op.user),
+			})
+			latency = time.Since(start)
+			if err != nil || !verify {
+				return err
+			}
+			expected, err := r.reference.Check(op.object, op.relation, op.user)
+			if err != nil {
+				return err
+			}
+			if expected != resp.GetAllowed() {
+				mismatch = &Mismatch{
+					Request:  tuple.ToObjectRelationString(op.object, op.relation) + "@" + op.user,
+					Expected: fmt.Sprint(expected),
+					Actual:   fmt.Sprint(resp.GetAllowed()),
+				}
+			}
+		case ListObjectsOperation:
+			resp, err := r.srv.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+				StoreId:              r.storeID,
+				AuthorizationModelId: r.modelID,
+				Type:                 op.object,
+				Relation:             op.relation,
+				User:                 op.user,
+			})
+			latency = time.Since(start)
+			if err != nil || !verify {
+				return err
+			}
+			expected, err := r.reference.ListObjects(op.object, op.relation, op.user)
+			if err != nil {
+				return err
+			}
+			actual := slices.Clone(resp.GetObjects())
+			sort.Strings(actual)
+			if !slices.Equal(expected, actual) {
+				mismatch = &Mismatch{
+					Request:  op.object + "#" + op.relation + "@" + op.user,
+					Expected: fmt.Sprint(expected),
+					Actual:   fmt.Sprint(actual),
+				}
+			}
+		case WriteOperation:
+			if verify && r.reference.Contains(op.write) {
+				// the write would fail as a duplicate
+				return nil
+			}
+			_, err := r.srv.Write(ctx, &openfgav1.WriteRequest{
+				StoreId:              r.storeID,
+				AuthorizationModelId: r.modelID,
+				Writes:               &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{op.write}},
+			})
+			latency = time.Since(start)
+			if err != nil || !verify {
+				return err
+			}
+			r.reference.Add(op.write)
+		}
+		return nil
+	}()
+
+	mu.Lock()
+	defer mu.Unlock()
+	stats := report.Stats[op.kind]
+	stats.Count++
+	if err != nil {
+		stats.Errors++
+		return
+	}
+	stats.latencies = append(stats.latencies, latency)
+	values := tags.Values()
+	if count, ok := values[datastoreQueryCountTag].(float64); ok {
+		stats.DatastoreQueryCount += uint64(count)
+	}
+	if count, ok := values[dispatchCountTag].(float64); ok {
+		stats.DispatchCount += uint64(count)
+	}
+	if mismatch != nil {
+		mismatch.Operation = op.kind
+		report.Mismatches = append(report.Mismatches, *mismatch)
+	}
+}
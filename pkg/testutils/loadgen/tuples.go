@@ -0,0 +1,125 @@
+package loadgen
+
+import (
+	"fmt"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// TupleShape configures the tuples generated by a [Generator] for a [Model].
+type TupleShape struct {
+	// ObjectsPerType is the number of objects of each object type.
+	ObjectsPerType int
+
+	// Users is the number of users, named user:u0 to user:u{Users-1}.
+	Users int
+
+	// UsersPerRelation is the number of users assigned to each directly assignable relation of
+	// each object.
+	UsersPerRelation int
+
+	// UsersetsPerRelation is the number of usersets assigned to each directly assignable relation
+	// of each object, for the relations that accept usersets.
+	UsersetsPerRelation int
+}
+
+// DefaultTupleShape is a shape small enough for the reference evaluator.
+var DefaultTupleShape = TupleShape{
+	ObjectsPerType:      10,
+	Users:               10,
+	UsersPerRelation:    2,
+	UsersetsPerRelation: 1,
+}
+
+// Instance is a generated model with its tuples.
+type Instance struct {
+	Model  *Model
+	Tuples []*openfgav1.TupleKey
+	Shape  TupleShape
+}
+
+// Instance generates a model of the given shape and its tuples.
+func (g *Generator) Instance(modelShape ModelShape, tupleShape TupleShape) (*Instance, error) {
+	model, err := g.Model(modelShape)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Instance{
+		Model:  model,
+		Tuples: g.Tuples(model, tupleShape),
+		Shape:  tupleShape,
+	}, nil
+}
+
+// Tuples generates the tuples of a model. Every object below the first level gets a parent, and
+// every directly assignable relation of every object gets users and, if it accepts them, usersets.
+// Conditional relations get conditional users half of the time, whose condition is met half of
+// the time.
+func (g *Generator) Tuples(model *Model, shape TupleShape) []*openfgav1.TupleKey {
+	seen := make(map[string]struct{})
+	var tuples []*openfgav1.TupleKey
+	add := func(tk *openfgav1.TupleKey) {
+		key := tuple.TupleKeyToString(tk)
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		tuples = append(tuples, tk)
+	}
+
+	for depth, l := range model.levels {
+		for i := 0; i < shape.ObjectsPerType; i++ {
+			object := tuple.BuildObject(l.objectType, fmt.Sprintf("o%d", i))
+
+			if depth > 0 {
+				add(tuple.NewTupleKey(object, parentRelation, g.object(model.levels[depth-1], shape)))
+			}
+
+			for _, r := range l.directRelations {
+				for j := 0; j < shape.UsersPerRelation; j++ {
+					add(g.userTuple(object, r, shape))
+				}
+				if r.usersetType == "" {
+					continue
+				}
+				for j := 0; j < shape.UsersetsPerRelation; j++ {
+					userset := tuple.ToObjectRelationString(g.object(model.levels[depth-1], shape), memberRelation)
+					add(tuple.NewTupleKey(object, r.name, userset))
+				}
+			}
+		}
+	}
+
+	return tuples
+}
+
+// writeTuple generates a tuple that can be written to the store of an instance, e.g. by the
+// Write operations of a workload.
+func (g *Generator) writeTuple(model *Model, shape TupleShape) *openfgav1.TupleKey {
+	l := model.levels[g.rng.Intn(len(model.levels))]
+	r := l.directRelations[g.rng.Intn(len(l.directRelations))]
+	return g.userTuple(g.object(l, shape), r, shape)
+}
+
+// userTuple generates a tuple assigning a random user to the relation r of object.
+func (g *Generator) userTuple(object string, r directRelation, shape TupleShape) *openfgav1.TupleKey {
+	user := g.user(shape)
+	if !r.conditional || g.rng.Intn(2) == 0 {
+		return tuple.NewTupleKey(object, r.name, user)
+	}
+
+	context, _ := structpb.NewStruct(map[string]interface{}{"allowed": g.rng.Intn(2) == 0})
+	return tuple.NewTupleKeyWithCondition(object, r.name, user, conditionName, context)
+}
+
+func (g *Generator) object(l level, shape TupleShape) string {
+	return tuple.BuildObject(l.objectType, fmt.Sprintf("o%d", g.rng.Intn(shape.ObjectsPerType)))
+}
+
+func (g *Generator) user(shape TupleShape) string {
+	return tuple.BuildObject(userType, fmt.Sprintf("u%d", g.rng.Intn(shape.Users)))
+}
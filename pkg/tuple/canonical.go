@@ -0,0 +1,58 @@
+package tuple
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CanonicalKey encodes the fields as one string, prefixing each field with its length, e.g.
+// CanonicalKey("document:1", "viewer") is "10:document:16:viewer". Two lists of fields have
+// the same key if and only if they are equal, whatever characters the fields contain, so
+// unlike a concatenation with separators, the key of a crafted field, e.g. a user
+// 'user:x#member', cannot collide with the key of other fields, e.g. a userset. Use it for the
+// keys of caches, and of maps and singleflight groups that deduplicate work.
+func CanonicalKey(fields ...string) string {
+	var b strings.Builder
+	for _, field := range fields {
+		AppendCanonicalField(&b, field)
+	}
+	return b.String()
+}
+
+// AppendCanonicalField appends the field to b as [CanonicalKey] encodes it, so that a
+// canonical key can be built incrementally.
+func AppendCanonicalField(b *strings.Builder, field string) {
+	b.WriteString(strconv.Itoa(len(field)))
+	b.WriteByte(':')
+	b.WriteString(field)
+}
+
+// CanonicalTupleKey returns the canonical key of the object, relation and user of the tuple
+// key, see [CanonicalKey]. It is lenient: every tuple key has a key, including tuple keys with
+// empty or invalid fields, e.g. the filters of a Read or tuple keys that were not validated yet.
+func CanonicalTupleKey(tk TupleWithoutCondition) string {
+	return CanonicalKey(tk.GetObject(), tk.GetRelation(), tk.GetUser())
+}
+
+// StrictCanonicalTupleKey returns the readable form 'object#relation@user' of the tuple key,
+// see [TupleKeyToString], which is canonical for valid tuple keys only: objects and relations
+// cannot contain '#' and relations cannot contain '@'. It returns an [InvalidTupleError] for a
+// tuple key with an invalid object, relation or user, whose readable form could collide with
+// the form of another tuple key. Use it where keys are also read by humans, e.g. logs.
+func StrictCanonicalTupleKey(tk TupleWithoutCondition) (string, error) {
+	var cause error
+	switch {
+	case !IsValidObject(tk.GetObject()):
+		cause = fmt.Errorf("invalid object '%s'", tk.GetObject())
+	case !IsValidRelation(tk.GetRelation()):
+		cause = fmt.Errorf("invalid relation '%s'", tk.GetRelation())
+	case !IsValidUser(tk.GetUser()):
+		cause = fmt.Errorf("invalid user '%s'", tk.GetUser())
+	}
+	if cause != nil {
+		return "", &InvalidTupleError{Cause: cause, TupleKey: tk}
+	}
+
+	return TupleKeyToString(tk), nil
+}
@@ -0,0 +1,91 @@
+package tuple
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalKey(t *testing.T) {
+	require.Equal(t, "", CanonicalKey())
+	require.Equal(t, "0:", CanonicalKey(""))
+	require.Equal(t, "10:document:16:viewer", CanonicalKey("document:1", "viewer"))
+
+	// separators in the fields do not make keys collide
+	require.NotEqual(t, CanonicalKey("document:1#viewer", ""), CanonicalKey("document:1", "viewer"))
+	require.NotEqual(t, CanonicalKey("a", "bc"), CanonicalKey("ab", "c"))
+
+	var b strings.Builder
+	AppendCanonicalField(&b, "document:1")
+	AppendCanonicalField(&b, "viewer")
+	require.Equal(t, CanonicalKey("document:1", "viewer"), b.String())
+}
+
+func TestCanonicalTupleKey(t *testing.T) {
+	require.Equal(t, "10:document:16:viewer16:group:fga#member", CanonicalTupleKey(NewTupleKey("document:1", "viewer", "group:fga#member")))
+	require.Equal(t, "0:0:0:", CanonicalTupleKey(NewTupleKey("", "", "")))
+
+	// a crafted user cannot take the key of a userset, or of another relation
+	require.NotEqual(t,
+		CanonicalTupleKey(NewTupleKey("document:1", "viewer", "group:eng#member")),
+		CanonicalTupleKey(NewTupleKey("document:1", "viewer#member", "group:eng")),
+	)
+	require.NotEqual(t,
+		CanonicalTupleKey(NewTupleKey("document:1", "viewer", "user:anne@document:2")),
+		CanonicalTupleKey(NewTupleKey("document:1", "viewer@user:anne", "document:2")),
+	)
+}
+
+func TestStrictCanonicalTupleKey(t *testing.T) {
+	t.Run("valid_tuple_keys", func(t *testing.T) {
+		for _, tk := range []struct{ object, relation, user string }{
+			{"document:1", "viewer", "user:anne"},
+			{"document:1", "viewer", "group:fga#member"},
+			{"document:1", "viewer", "user:*"},
+			{"document:1", "viewer", "*"},
+			{"document:1", "viewer", "anne"},
+		} {
+			key, err := StrictCanonicalTupleKey(NewTupleKey(tk.object, tk.relation, tk.user))
+			require.NoError(t, err)
+			require.Equal(t, tk.object+"#"+tk.relation+"@"+tk.user, key)
+		}
+	})
+
+	t.Run("invalid_tuple_keys", func(t *testing.T) {
+		for _, tk := range []struct{ object, relation, user, cause string }{
+			{"document", "viewer", "user:anne", "invalid object 'document'"},
+			{"document:1#viewer", "member", "user:anne", "invalid object 'document:1#viewer'"},
+			{"document:1", "viewer@user:anne", "user:bob", "invalid relation 'viewer@user:anne'"},
+			{"document:1", "", "user:anne", "invalid relation ''"},
+			{"document:1", "viewer", "group:fga#member#member", "invalid user 'group:fga#member#member'"},
+			{"document:1", "viewer", "", "invalid user ''"},
+		} {
+			_, err := StrictCanonicalTupleKey(NewTupleKey(tk.object, tk.relation, tk.user))
+			require.ErrorIs(t, err, &InvalidTupleError{})
+			require.ErrorContains(t, err, tk.cause)
+		}
+	})
+}
+
+func FuzzCanonicalTupleKey(f *testing.F) {
+	f.Add("document:1", "viewer", "group:eng#member", "document:1", "viewer#member", "group:eng")
+	f.Add("document:1", "viewer", "user:anne@document:2", "document:1", "viewer@user:anne", "document:2")
+	f.Add("a", "bc", "", "ab", "c", "")
+	f.Add("1:a", "", "", "", "1:a", "")
+
+	f.Fuzz(func(t *testing.T, object1, relation1, user1, object2, relation2, user2 string) {
+		tk1 := NewTupleKey(object1, relation1, user1)
+		tk2 := NewTupleKey(object2, relation2, user2)
+		sameTuple := object1 == object2 && relation1 == relation2 && user1 == user2
+
+		require.Equal(t, sameTuple, CanonicalTupleKey(tk1) == CanonicalTupleKey(tk2))
+
+		// the strict keys of valid tuple keys do not collide either
+		strict1, err1 := StrictCanonicalTupleKey(tk1)
+		strict2, err2 := StrictCanonicalTupleKey(tk2)
+		if err1 == nil && err2 == nil {
+			require.Equal(t, sameTuple, strict1 == strict2)
+		}
+	})
+}
@@ -21,6 +21,10 @@ func (i *InvalidConditionalTupleError) Is(target error) bool {
 	return ok
 }
 
+func (i *InvalidConditionalTupleError) Unwrap() error {
+	return i.Cause
+}
+
 // InvalidTupleError is returned if the tuple is invalid.
 type InvalidTupleError struct {
 	Cause    error
@@ -36,6 +40,10 @@ func (i *InvalidTupleError) Is(target error) bool {
 	return ok
 }
 
+func (i *InvalidTupleError) Unwrap() error {
+	return i.Cause
+}
+
 type TypeNotFoundError struct {
 	TypeName string
 }
@@ -68,3 +76,59 @@ func (i *RelationNotFoundError) Is(target error) bool {
 	_, ok := target.(*RelationNotFoundError)
 	return ok
 }
+
+// RelationNotAssignableError is returned when a tuple assigns a user to a relation that exists
+// on the type but has no type restrictions, e.g. `define viewer: editor`, whose users are only
+// computed from other relations.
+type RelationNotAssignableError struct {
+	TypeName string
+	Relation string
+}
+
+func (i *RelationNotAssignableError) Error() string {
+	return fmt.Sprintf("relation '%s#%s' is not assignable: it has no type restrictions, so it accepts no direct users", i.TypeName, i.Relation)
+}
+
+func (i *RelationNotAssignableError) Is(target error) bool {
+	_, ok := target.(*RelationNotAssignableError)
+	return ok
+}
+
+// UserTypeNotAllowedError is returned when the type of the user of a tuple, e.g. 'user', or
+// its type and relation if it is a userset, e.g. 'group#member', is not one of the type
+// restrictions of the relation.
+type UserTypeNotAllowedError struct {
+	TypeName     string
+	Relation     string
+	UserType     string
+	UserRelation string
+}
+
+func (i *UserTypeNotAllowedError) Error() string {
+	if i.UserRelation != "" {
+		return fmt.Sprintf("'%s#%s' is not an allowed type restriction for '%s#%s'", i.UserType, i.UserRelation, i.TypeName, i.Relation)
+	}
+	return fmt.Sprintf("type '%s' is not an allowed type restriction for '%s#%s'", i.UserType, i.TypeName, i.Relation)
+}
+
+func (i *UserTypeNotAllowedError) Is(target error) bool {
+	_, ok := target.(*UserTypeNotAllowedError)
+	return ok
+}
+
+// WildcardNotAllowedError is returned when the user of a tuple is a typed wildcard, e.g.
+// 'user:*', that is not one of the type restrictions of the relation.
+type WildcardNotAllowedError struct {
+	TypeName string
+	Relation string
+	Wildcard string
+}
+
+func (i *WildcardNotAllowedError) Error() string {
+	return fmt.Sprintf("the typed wildcard '%s' is not an allowed type restriction for '%s#%s'", i.Wildcard, i.TypeName, i.Relation)
+}
+
+func (i *WildcardNotAllowedError) Is(target error) bool {
+	_, ok := target.(*WildcardNotAllowedError)
+	return ok
+}
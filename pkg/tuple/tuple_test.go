@@ -609,3 +609,60 @@ func TestToUserPartsFromObjectRelation(t *testing.T) {
 	require.Equal(t, "*", userObjectID)
 	require.Equal(t, "", userRelation)
 }
+
+func FuzzParseTupleString(f *testing.F) {
+	f.Add("document:1#viewer@user:jon")
+	f.Add("document:1#viewer@group:eng#member")
+	f.Add("document:1#viewer@user:*")
+	f.Add("document:1#viewer@*")
+	f.Add("document:1#viewer@")
+	f.Add("document:1#viewer#member@user:jon")
+	f.Add("document#viewer@user:jon")
+	f.Add("document:1#viewer@group:eng#")
+	f.Add("#@")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		tk, err := ParseTupleString(s)
+		if err != nil {
+			require.Nil(t, tk)
+			return
+		}
+
+		require.True(t, IsValidObject(tk.GetObject()))
+		require.True(t, IsValidRelation(tk.GetRelation()))
+		require.True(t, IsValidUser(tk.GetUser()))
+		require.Equal(t, s, TupleKeyToString(tk))
+	})
+}
+
+func FuzzSplitObject(f *testing.F) {
+	f.Add("group:eng")
+	f.Add("group:eng#member")
+	f.Add("group#member:eng")
+	f.Add("user:*")
+	f.Add("*")
+	f.Add("group:")
+	f.Add("group:eng#")
+	f.Add(":#")
+	f.Add("anne")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		objectType, objectID := SplitObject(s)
+		if objectType != "" || objectID != "" || s != "" {
+			require.Contains(t, []string{objectID, BuildObject(objectType, objectID)}, s)
+		}
+
+		object, relation := SplitObjectRelation(s)
+		if relation != "" {
+			require.Equal(t, s, ToObjectRelationString(object, relation))
+		}
+
+		// the parts of a valid user are the parts of its proto
+		userObjectType, userObjectID, userRelation := ToUserParts(s)
+		if IsValidUser(s) && userObjectType != "" && userObjectID != "" {
+			require.Equal(t, s, FromUserParts(userObjectType, userObjectID, userRelation))
+			require.Equal(t, s, UserProtoToString(StringToUserProto(s)))
+		}
+		_ = GetUserTypeFromUser(s)
+	})
+}
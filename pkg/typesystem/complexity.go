@@ -0,0 +1,158 @@
+package typesystem
+
+import (
+	"fmt"
+	"sort"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+)
+
+const (
+	// maxComplexityContributors is the number of relations reported by Complexity as the top
+	// contributors to the score.
+	maxComplexityContributors = 5
+
+	complexityWeightTupleToUserset = 3
+	complexityWeightDifference     = 2
+	complexityWeightCondition      = 2
+)
+
+// Complexity summarizes the size and shape of an authorization model, as computed by
+// [TypeSystem.Complexity].
+type Complexity struct {
+	// Score is the complexity score of the model. See [TypeSystem.Complexity].
+	Score int
+
+	Types           int
+	Relations       int
+	TupleToUsersets int
+	Conditions      int
+
+	// MaxRewriteDepth is the depth of the deepest rewrite tree of the relations, 1 for a
+	// rewrite without set operations.
+	MaxRewriteDepth int
+	// MaxRewriteWidth is the largest number of children of a union or intersection.
+	MaxRewriteWidth int
+
+	// TopContributors are the relations with the highest scores, highest first.
+	TopContributors []ComplexityContributor
+}
+
+// ComplexityContributor is the complexity score of one relation of a model.
+type ComplexityContributor struct {
+	ObjectType string
+	Relation   string
+	Score      int
+}
+
+// String returns e.g. `document#viewer (12)`.
+func (c ComplexityContributor) String() string {
+	return fmt.Sprintf("%s#%s (%d)", c.ObjectType, c.Relation, c.Score)
+}
+
+// Complexity computes the complexity score of the model, a rough estimate of the cost of
+// resolving its relations. The score of a relation is the sum of the weights of the nodes of
+// its rewrite tree, each multiplied by the depth of the node (1 for the root):
+//
+//   - a direct assignment weighs 1, plus 1 for each directly related userset type (e.g. `group#member`);
+//   - a computed relation weighs 1;
+//   - a tuple to userset weighs 3;
+//   - a union or intersection weighs the number of its children, and an exclusion 2.
+//
+// The score of the model is the number of types, plus the scores of the relations, plus 2
+// for each condition.
+func (t *TypeSystem) Complexity() Complexity {
+	c := Complexity{
+		Types:      len(t.typeDefinitions),
+		Conditions: len(t.conditions),
+	}
+	c.Score = c.Types + complexityWeightCondition*c.Conditions
+
+	contributors := make([]ComplexityContributor, 0)
+	for objectType, relations := range t.relations {
+		for relationName, relation := range relations {
+			w := &complexityWalker{directlyRelated: relation.GetTypeInfo().GetDirectlyRelatedUserTypes()}
+			w.walk(relation.GetRewrite(), 1)
+
+			c.Relations++
+			c.TupleToUsersets += w.tupleToUsersets
+			c.MaxRewriteDepth = max(c.MaxRewriteDepth, w.depth)
+			c.MaxRewriteWidth = max(c.MaxRewriteWidth, w.width)
+			c.Score += w.score
+
+			contributors = append(contributors, ComplexityContributor{
+				ObjectType: objectType,
+				Relation:   relationName,
+				Score:      w.score,
+			})
+		}
+	}
+
+	sort.Slice(contributors, func(i, j int) bool {
+		if contributors[i].Score != contributors[j].Score {
+			return contributors[i].Score > contributors[j].Score
+		}
+		if contributors[i].ObjectType != contributors[j].ObjectType {
+			return contributors[i].ObjectType < contributors[j].ObjectType
+		}
+		return contributors[i].Relation < contributors[j].Relation
+	})
+	c.TopContributors = contributors[:min(len(contributors), maxComplexityContributors)]
+
+	return c
+}
+
+// RewriteDepth returns the depth of the rewrite tree of a relation, 1 for a rewrite without set
+// operations, as counted by [Complexity.MaxRewriteDepth].
+func RewriteDepth(rewrite *openfgav1.Userset) int {
+	w := &complexityWalker{}
+	w.walk(rewrite, 1)
+	return w.depth
+}
+
+// complexityWalker accumulates the complexity of the rewrite tree of one relation.
+type complexityWalker struct {
+	directlyRelated []*openfgav1.RelationReference
+
+	score           int
+	tupleToUsersets int
+	depth           int
+	width           int
+}
+
+func (w *complexityWalker) walk(rewrite *openfgav1.Userset, depth int) {
+	w.depth = max(w.depth, depth)
+
+	var weight int
+	var children []*openfgav1.Userset
+	switch rw := rewrite.GetUserset().(type) {
+	case *openfgav1.Userset_This:
+		weight = 1
+		for _, ref := range w.directlyRelated {
+			if ref.GetRelation() != "" {
+				weight++
+			}
+		}
+	case *openfgav1.Userset_ComputedUserset:
+		weight = 1
+	case *openfgav1.Userset_TupleToUserset:
+		weight = complexityWeightTupleToUserset
+		w.tupleToUsersets++
+	case *openfgav1.Userset_Union:
+		children = rw.Union.GetChild()
+		weight = len(children)
+		w.width = max(w.width, len(children))
+	case *openfgav1.Userset_Intersection:
+		children = rw.Intersection.GetChild()
+		weight = len(children)
+		w.width = max(w.width, len(children))
+	case *openfgav1.Userset_Difference:
+		children = []*openfgav1.Userset{rw.Difference.GetBase(), rw.Difference.GetSubtract()}
+		weight = complexityWeightDifference
+	}
+
+	w.score += weight * depth
+	for _, child := range children {
+		w.walk(child, depth+1)
+	}
+}
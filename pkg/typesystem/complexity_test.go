@@ -0,0 +1,146 @@
+package typesystem
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/testutils"
+)
+
+func TestComplexity(t *testing.T) {
+	tests := map[string]struct {
+		model    string
+		expected Complexity
+	}{
+		`direct_relation`: {
+			model: `
+				model
+					schema 1.1
+				type user
+				type document
+					relations
+						define viewer: [user]`,
+			expected: Complexity{
+				Score:           3,
+				Types:           2,
+				Relations:       1,
+				MaxRewriteDepth: 1,
+				TopContributors: []ComplexityContributor{
+					{ObjectType: "document", Relation: "viewer", Score: 1},
+				},
+			},
+		},
+		`usersets_and_unions`: {
+			model: `
+				model
+					schema 1.1
+				type user
+				type team
+					relations
+						define member: [user, team#member]
+				type repo
+					relations
+						define owner: [user]
+						define admin: [user, team#member] or owner
+						define reader: [user] or admin`,
+			expected: Complexity{
+				// 3 types + member 2 + owner 1 + admin (2 + 2*2 + 2*1) + reader (2 + 2*1 + 2*1)
+				Score:           20,
+				Types:           3,
+				Relations:       4,
+				MaxRewriteDepth: 2,
+				MaxRewriteWidth: 2,
+				TopContributors: []ComplexityContributor{
+					{ObjectType: "repo", Relation: "admin", Score: 8},
+					{ObjectType: "repo", Relation: "reader", Score: 6},
+					{ObjectType: "team", Relation: "member", Score: 2},
+					{ObjectType: "repo", Relation: "owner", Score: 1},
+				},
+			},
+		},
+		`tuple_to_userset_exclusion_and_condition`: {
+			model: `
+				model
+					schema 1.1
+				type user
+				type folder
+					relations
+						define viewer: [user]
+				type document
+					relations
+						define parent: [folder]
+						define blocked: [user]
+						define viewer: ([user with non_expired] or viewer from parent) but not blocked
+
+				condition non_expired(current_time: timestamp, expiration: timestamp) {
+					current_time < expiration
+				}`,
+			expected: Complexity{
+				// 3 types + 2 for the condition + 3 direct relations + viewer (2 + 2*2 + 3*1 + 3*3 + 2*1)
+				Score:           28,
+				Types:           3,
+				Relations:       4,
+				TupleToUsersets: 1,
+				Conditions:      1,
+				MaxRewriteDepth: 3,
+				MaxRewriteWidth: 2,
+				TopContributors: []ComplexityContributor{
+					{ObjectType: "document", Relation: "viewer", Score: 20},
+					{ObjectType: "document", Relation: "blocked", Score: 1},
+					{ObjectType: "document", Relation: "parent", Score: 1},
+					{ObjectType: "folder", Relation: "viewer", Score: 1},
+				},
+			},
+		},
+		`wide_intersection_and_top_contributors`: {
+			model: `
+				model
+					schema 1.1
+				type user
+				type document
+					relations
+						define a: [user]
+						define b: [user]
+						define c: [user]
+						define d: [user]
+						define e: [user]
+						define all: a and b and c`,
+			expected: Complexity{
+				// 2 types + 5 direct relations + all (3 + 3*2)
+				Score:           16,
+				Types:           2,
+				Relations:       6,
+				MaxRewriteDepth: 2,
+				MaxRewriteWidth: 3,
+				TopContributors: []ComplexityContributor{
+					{ObjectType: "document", Relation: "all", Score: 9},
+					{ObjectType: "document", Relation: "a", Score: 1},
+					{ObjectType: "document", Relation: "b", Score: 1},
+					{ObjectType: "document", Relation: "c", Score: 1},
+					{ObjectType: "document", Relation: "d", Score: 1},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			typesys, err := New(testutils.MustTransformDSLToProtoWithID(test.model))
+			require.NoError(t, err)
+
+			require.Equal(t, test.expected, typesys.Complexity())
+		})
+	}
+}
+
+func TestComplexityContributorString(t *testing.T) {
+	require.Equal(t, "document#viewer (12)", ComplexityContributor{ObjectType: "document", Relation: "viewer", Score: 12}.String())
+}
+
+func TestRewriteDepth(t *testing.T) {
+	require.Equal(t, 1, RewriteDepth(This()))
+	require.Equal(t, 1, RewriteDepth(TupleToUserset("parent", "viewer")))
+	require.Equal(t, 2, RewriteDepth(Union(This(), ComputedUserset("owner"))))
+	require.Equal(t, 3, RewriteDepth(Difference(Union(This(), ComputedUserset("owner")), ComputedUserset("blocked"))))
+}
@@ -11,6 +11,10 @@ var (
 	// ErrModelNotFound is returned when an authorization model is not found.
 	ErrModelNotFound = errors.New("authorization model not found")
 
+	// ErrStoreNotFound is returned by a [MemoizedTypesystemResolver] for the stores that the
+	// datastore reports deleted (see [storage.ErrStoreDeleted]).
+	ErrStoreNotFound = errors.New("store not found")
+
 	// ErrDuplicateTypes is returned when an authorization model contains duplicate types.
 	ErrDuplicateTypes = errors.New("an authorization model cannot contain duplicate types")
 
@@ -47,6 +51,10 @@ var (
 
 	// ErrNoConditionForRelation is returned when no condition is defined for a relation in the authorization model.
 	ErrNoConditionForRelation = errors.New("no condition defined for relation")
+
+	// ErrModelTooComplex is returned by NewAndValidate when the validation of a model exceeds its
+	// work limits, see [ValidationOption].
+	ErrModelTooComplex = errors.New("model too complex to validate")
 )
 
 // InvalidTypeError represents an error indicating an invalid object type.
@@ -0,0 +1,202 @@
+package typesystem
+
+import (
+	"sort"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// RelationPathKind is the kind of the first hop of a path by which a user can hold a relation.
+type RelationPathKind string
+
+const (
+	// RelationPathDirect is a tuple with the user, or with the wildcard of its type, e.g.
+	// `document:1#viewer@user:anne`.
+	RelationPathDirect RelationPathKind = "direct"
+	// RelationPathUserset is a tuple with a userset that the user can be a member of, e.g.
+	// `document:1#viewer@group:eng#member`.
+	RelationPathUserset RelationPathKind = "userset"
+	// RelationPathTupleToUserset is a tuple to userset rewrite, e.g. `viewer from parent`,
+	// through an object on which the user can hold the computed relation.
+	RelationPathTupleToUserset RelationPathKind = "tuple_to_userset"
+)
+
+// RelationPath is one way for the users of a type to hold a relation. The computed usersets,
+// unions, intersections and exclusions of the rewrite of the relation keep the paths of the
+// relations they rewrite from, e.g. the paths of `can_read: viewer` are those of viewer.
+type RelationPath struct {
+	Kind RelationPathKind `json:"kind"`
+	// Via is the userset of a userset path, e.g. `group#member`, or the object type and
+	// computed relation of a tuple to userset path, e.g. `folder#viewer`.
+	Via string `json:"via,omitempty"`
+	// Tupleset is the tupleset relation of a tuple to userset path, e.g. `parent`.
+	Tupleset string `json:"tupleset,omitempty"`
+	// Wildcard is true if the tuple of a direct path is the wildcard of the user type, e.g. `user:*`.
+	Wildcard bool `json:"wildcard,omitempty"`
+	// Condition is the name of the condition the tuple of the first hop must be written with, if any.
+	Condition string `json:"condition,omitempty"`
+}
+
+// ReachableRelation is a relation that the users of a type can hold, and the paths by which
+// they can hold it.
+type ReachableRelation struct {
+	ObjectType string         `json:"object_type"`
+	Relation   string         `json:"relation"`
+	Paths      []RelationPath `json:"paths"`
+}
+
+// GetReachableRelations returns the relations that the users of the given type can ever hold
+// under the model, sorted by object type and relation, e.g. that a `user` can be a `viewer` of
+// a `document` directly or via `group#member`. A relation is reachable if a path of tuples
+// allowed by the type restrictions of the model leads from the user to it, whatever tuples are
+// written: an intersection is reachable if all its operands are, and an exclusion if its base is.
+//
+// The result is computed once per user type and cached, so it must not be modified.
+func (t *TypeSystem) GetReachableRelations(userType string) []ReachableRelation {
+	if cached, ok := t.reachableRelations.Load(userType); ok {
+		return cached.([]ReachableRelation)
+	}
+
+	r := &reachability{typesys: t, userType: userType, paths: map[string]map[RelationPath]struct{}{}}
+	r.compute()
+
+	relations := make([]ReachableRelation, 0, len(r.paths))
+	for objectType, typeRelations := range t.relations {
+		for relation := range typeRelations {
+			paths := r.paths[tuple.ToObjectRelationString(objectType, relation)]
+			if len(paths) == 0 {
+				continue
+			}
+			relations = append(relations, ReachableRelation{
+				ObjectType: objectType,
+				Relation:   relation,
+				Paths:      sortedRelationPaths(paths),
+			})
+		}
+	}
+	sort.Slice(relations, func(i, j int) bool {
+		if relations[i].ObjectType != relations[j].ObjectType {
+			return relations[i].ObjectType < relations[j].ObjectType
+		}
+		return relations[i].Relation < relations[j].Relation
+	})
+
+	cached, _ := t.reachableRelations.LoadOrStore(userType, relations)
+	return cached.([]ReachableRelation)
+}
+
+// reachability computes the paths of the relations reachable by a user type. The paths of a
+// relation depend on the paths of the relations its rewrite refers to, which can refer back to
+// it, so they are recomputed until none changes. The number of paths is bounded by the model,
+// so the computation terminates.
+type reachability struct {
+	typesys  *TypeSystem
+	userType string
+	// paths of the reachable relations by `objectType#relation`
+	paths map[string]map[RelationPath]struct{}
+}
+
+func (r *reachability) compute() {
+	for changed := true; changed; {
+		changed = false
+		for objectType, relations := range r.typesys.relations {
+			for relationName, relation := range relations {
+				key := tuple.ToObjectRelationString(objectType, relationName)
+				paths := r.rewritePaths(objectType, relationName, relation.GetRewrite())
+				if len(paths) > len(r.paths[key]) {
+					r.paths[key] = paths
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// rewritePaths returns the paths of a rewrite of a relation, given the paths computed so far.
+// They only grow from one round to the next, as the paths of the relations the rewrite refers
+// to do.
+func (r *reachability) rewritePaths(objectType, relation string, rewrite *openfgav1.Userset) map[RelationPath]struct{} {
+	paths := map[RelationPath]struct{}{}
+
+	switch rw := rewrite.GetUserset().(type) {
+	case *openfgav1.Userset_This:
+		directlyRelated, _ := r.typesys.GetDirectlyRelatedUserTypes(objectType, relation)
+		for _, ref := range directlyRelated {
+			if ref.GetType() == r.userType && ref.GetRelation() == "" {
+				paths[RelationPath{Kind: RelationPathDirect, Wildcard: ref.GetWildcard() != nil, Condition: ref.GetCondition()}] = struct{}{}
+				continue
+			}
+			if ref.GetRelation() == "" {
+				continue
+			}
+			via := tuple.ToObjectRelationString(ref.GetType(), ref.GetRelation())
+			if len(r.paths[via]) > 0 {
+				paths[RelationPath{Kind: RelationPathUserset, Via: via, Condition: ref.GetCondition()}] = struct{}{}
+			}
+		}
+	case *openfgav1.Userset_ComputedUserset:
+		for path := range r.paths[tuple.ToObjectRelationString(objectType, rw.ComputedUserset.GetRelation())] {
+			paths[path] = struct{}{}
+		}
+	case *openfgav1.Userset_TupleToUserset:
+		tupleset := rw.TupleToUserset.GetTupleset().GetRelation()
+		computed := rw.TupleToUserset.GetComputedUserset().GetRelation()
+		tuplesetTypes, _ := r.typesys.GetDirectlyRelatedUserTypes(objectType, tupleset)
+		for _, ref := range tuplesetTypes {
+			// the tupleset is followed through the objects of its tuples, not through usersets
+			if ref.GetRelation() != "" || ref.GetWildcard() != nil {
+				continue
+			}
+			via := tuple.ToObjectRelationString(ref.GetType(), computed)
+			if len(r.paths[via]) > 0 {
+				paths[RelationPath{Kind: RelationPathTupleToUserset, Via: via, Tupleset: tupleset, Condition: ref.GetCondition()}] = struct{}{}
+			}
+		}
+	case *openfgav1.Userset_Union:
+		for _, child := range rw.Union.GetChild() {
+			for path := range r.rewritePaths(objectType, relation, child) {
+				paths[path] = struct{}{}
+			}
+		}
+	case *openfgav1.Userset_Intersection:
+		for _, child := range rw.Intersection.GetChild() {
+			childPaths := r.rewritePaths(objectType, relation, child)
+			if len(childPaths) == 0 {
+				return map[RelationPath]struct{}{}
+			}
+			for path := range childPaths {
+				paths[path] = struct{}{}
+			}
+		}
+	case *openfgav1.Userset_Difference:
+		return r.rewritePaths(objectType, relation, rw.Difference.GetBase())
+	}
+
+	return paths
+}
+
+func sortedRelationPaths(paths map[RelationPath]struct{}) []RelationPath {
+	sorted := make([]RelationPath, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Via != b.Via {
+			return a.Via < b.Via
+		}
+		if a.Tupleset != b.Tupleset {
+			return a.Tupleset < b.Tupleset
+		}
+		if a.Wildcard != b.Wildcard {
+			return !a.Wildcard
+		}
+		return a.Condition < b.Condition
+	})
+	return sorted
+}
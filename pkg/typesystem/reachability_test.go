@@ -0,0 +1,193 @@
+package typesystem
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/testutils"
+)
+
+func TestGetReachableRelations(t *testing.T) {
+	tests := map[string]struct {
+		model    string
+		userType string
+		expected []ReachableRelation
+	}{
+		`direct_and_userset`: {
+			model: `
+				model
+					schema 1.1
+				type user
+				type group
+					relations
+						define member: [user, group#member]
+				type document
+					relations
+						define viewer: [user, group#member]
+						define can_read: viewer`,
+			userType: "user",
+			expected: []ReachableRelation{
+				{ObjectType: "document", Relation: "can_read", Paths: []RelationPath{
+					{Kind: RelationPathDirect},
+					{Kind: RelationPathUserset, Via: "group#member"},
+				}},
+				{ObjectType: "document", Relation: "viewer", Paths: []RelationPath{
+					{Kind: RelationPathDirect},
+					{Kind: RelationPathUserset, Via: "group#member"},
+				}},
+				{ObjectType: "group", Relation: "member", Paths: []RelationPath{
+					{Kind: RelationPathDirect},
+					{Kind: RelationPathUserset, Via: "group#member"},
+				}},
+			},
+		},
+		`other_user_type`: {
+			model: `
+				model
+					schema 1.1
+				type user
+				type employee
+				type group
+					relations
+						define member: [employee]
+				type document
+					relations
+						define viewer: [user, group#member]
+						define owner: [group]`,
+			userType: "group",
+			expected: []ReachableRelation{
+				{ObjectType: "document", Relation: "owner", Paths: []RelationPath{
+					{Kind: RelationPathDirect},
+				}},
+			},
+		},
+		`tuple_to_userset`: {
+			model: `
+				model
+					schema 1.1
+				type user
+				type folder
+					relations
+						define parent: [folder]
+						define viewer: [user] or viewer from parent
+				type document
+					relations
+						define parent: [folder]
+						define viewer: viewer from parent`,
+			userType: "user",
+			expected: []ReachableRelation{
+				{ObjectType: "document", Relation: "viewer", Paths: []RelationPath{
+					{Kind: RelationPathTupleToUserset, Via: "folder#viewer", Tupleset: "parent"},
+				}},
+				{ObjectType: "folder", Relation: "viewer", Paths: []RelationPath{
+					{Kind: RelationPathDirect},
+					{Kind: RelationPathTupleToUserset, Via: "folder#viewer", Tupleset: "parent"},
+				}},
+			},
+		},
+		`wildcards_and_conditions`: {
+			model: `
+				model
+					schema 1.1
+				type user
+				type document
+					relations
+						define viewer: [user, user:*, user with in_office_hours]
+						define editor: [user:* with in_office_hours]
+				condition in_office_hours(hour: int) {
+					hour >= 9 && hour <= 17
+				}`,
+			userType: "user",
+			expected: []ReachableRelation{
+				{ObjectType: "document", Relation: "editor", Paths: []RelationPath{
+					{Kind: RelationPathDirect, Wildcard: true, Condition: "in_office_hours"},
+				}},
+				{ObjectType: "document", Relation: "viewer", Paths: []RelationPath{
+					{Kind: RelationPathDirect},
+					{Kind: RelationPathDirect, Condition: "in_office_hours"},
+					{Kind: RelationPathDirect, Wildcard: true},
+				}},
+			},
+		},
+		`intersection_and_exclusion`: {
+			model: `
+				model
+					schema 1.1
+				type user
+				type employee
+				type document
+					relations
+						define allowed: [user]
+						define blocked: [user]
+						define staff: [employee]
+						define viewer: [user] and allowed
+						define editor: [user] and staff
+						define commenter: [user] but not staff`,
+			userType: "user",
+			expected: []ReachableRelation{
+				{ObjectType: "document", Relation: "allowed", Paths: []RelationPath{{Kind: RelationPathDirect}}},
+				{ObjectType: "document", Relation: "blocked", Paths: []RelationPath{{Kind: RelationPathDirect}}},
+				// editor is not reachable, as a user cannot be staff
+				{ObjectType: "document", Relation: "commenter", Paths: []RelationPath{{Kind: RelationPathDirect}}},
+				{ObjectType: "document", Relation: "viewer", Paths: []RelationPath{{Kind: RelationPathDirect}}},
+			},
+		},
+		`conditional_userset_and_tupleset`: {
+			model: `
+				model
+					schema 1.1
+				type user
+				type group
+					relations
+						define member: [user]
+				type folder
+					relations
+						define viewer: [group#member with is_active]
+				type document
+					relations
+						define parent: [folder with is_active]
+						define viewer: viewer from parent
+				condition is_active(active: bool) {
+					active
+				}`,
+			userType: "user",
+			expected: []ReachableRelation{
+				{ObjectType: "document", Relation: "viewer", Paths: []RelationPath{
+					{Kind: RelationPathTupleToUserset, Via: "folder#viewer", Tupleset: "parent", Condition: "is_active"},
+				}},
+				{ObjectType: "folder", Relation: "viewer", Paths: []RelationPath{
+					{Kind: RelationPathUserset, Via: "group#member", Condition: "is_active"},
+				}},
+				{ObjectType: "group", Relation: "member", Paths: []RelationPath{{Kind: RelationPathDirect}}},
+			},
+		},
+		`unknown_user_type`: {
+			model: `
+				model
+					schema 1.1
+				type user
+				type document
+					relations
+						define viewer: [user]`,
+			userType: "team",
+			expected: []ReachableRelation{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			typesys, err := New(testutils.MustTransformDSLToProtoWithID(test.model))
+			require.NoError(t, err)
+
+			relations := typesys.GetReachableRelations(test.userType)
+			require.Equal(t, test.expected, relations)
+
+			// the computation is cached
+			require.Equal(t, relations, typesys.GetReachableRelations(test.userType))
+			cached, ok := typesys.reachableRelations.Load(test.userType)
+			require.True(t, ok)
+			require.Equal(t, relations, cached)
+		})
+	}
+}
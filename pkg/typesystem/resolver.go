@@ -12,6 +12,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/singleflight"
 
+	"github.com/openfga/openfga/pkg/requestcontext"
 	"github.com/openfga/openfga/pkg/storage"
 )
 
@@ -31,74 +32,146 @@ type TypesystemResolverFunc func(ctx context.Context, storeID, modelID string) (
 // If not given a model ID: fetches the latest model ID from the datastore, then sees if the model ID is in the cache.
 // If it is, returns it. Else, validates it and returns it.
 func MemoizedTypesystemResolverFunc(datastore storage.AuthorizationModelReadBackend) (TypesystemResolverFunc, func()) {
-	lookupGroup := singleflight.Group{}
+	r := NewMemoizedTypesystemResolver(datastore)
+	return r.Resolve, r.Stop
+}
+
+// MemoizedTypesystemResolver resolves and caches the validated type systems of authorization
+// models, as described in [MemoizedTypesystemResolverFunc]. Unlike the function, it can be told
+// about deleted stores.
+type MemoizedTypesystemResolver struct {
+	datastore   storage.AuthorizationModelReadBackend
+	lookupGroup singleflight.Group
 
 	// cache holds models that have already been validated.
-	cache := storage.NewInMemoryLRUCache[*TypeSystem]()
-
-	return func(ctx context.Context, storeID, modelID string) (*TypeSystem, error) {
-		ctx, span := tracer.Start(ctx, "resolveTypesystem", trace.WithAttributes(
-			attribute.String("store_id", storeID),
-		))
-		defer func() {
-			span.SetAttributes(attribute.String("authorization_model_id", modelID))
-			span.End()
-		}()
-
-		var err error
-
-		if modelID != "" {
-			if _, err := ulid.Parse(modelID); err != nil {
-				return nil, ErrModelNotFound
-			}
-		}
+	cache *storage.InMemoryLRUCache[*TypeSystem]
+}
 
-		var model *openfgav1.AuthorizationModel
-		var key string
-		if modelID == "" {
-			v, err, _ := lookupGroup.Do(fmt.Sprintf("FindLatestAuthorizationModel:%s", storeID), func() (interface{}, error) {
-				return datastore.FindLatestAuthorizationModel(ctx, storeID)
-			})
-			if err != nil {
-				if errors.Is(err, storage.ErrNotFound) {
-					return nil, ErrModelNotFound
-				}
-
-				return nil, fmt.Errorf("failed to FindLatestAuthorizationModel: %w", err)
-			}
-
-			model = v.(*openfgav1.AuthorizationModel)
-			modelID = model.GetId()
-		}
+// NewMemoizedTypesystemResolver returns a [MemoizedTypesystemResolver] reading models from datastore.
+// Call Stop to release its caches.
+func NewMemoizedTypesystemResolver(datastore storage.AuthorizationModelReadBackend) *MemoizedTypesystemResolver {
+	return &MemoizedTypesystemResolver{
+		datastore: datastore,
+		cache:     storage.NewInMemoryLRUCache[*TypeSystem](),
+	}
+}
 
-		key = fmt.Sprintf("%s/%s", storeID, modelID)
-		item := cache.Get(key)
-		if item != nil {
-			return item.Value, nil
+// Resolve is a [TypesystemResolverFunc].
+func (r *MemoizedTypesystemResolver) Resolve(ctx context.Context, storeID, modelID string) (*TypeSystem, error) {
+	ctx, span := tracer.Start(ctx, "resolveTypesystem", trace.WithAttributes(
+		attribute.String("store_id", storeID),
+	))
+	defer func() {
+		span.SetAttributes(attribute.String("authorization_model_id", modelID))
+		span.End()
+	}()
+
+	var err error
+
+	if modelID != "" {
+		if _, err := ulid.Parse(modelID); err != nil {
+			return nil, ErrModelNotFound
+		}
+	}
+
+	var model *openfgav1.AuthorizationModel
+	var key string
+	if modelID == "" {
+		v, err, _ := r.lookupGroup.Do(fmt.Sprintf("FindLatestAuthorizationModel:%s", storeID), func() (interface{}, error) {
+			return r.datastore.FindLatestAuthorizationModel(ctx, storeID)
+		})
+		if err != nil {
+			return nil, modelLookupError("FindLatestAuthorizationModel", err)
 		}
 
-		if model == nil {
-			v, err, _ := lookupGroup.Do(fmt.Sprintf("ReadAuthorizationModel:%s/%s", storeID, modelID), func() (interface{}, error) {
-				return datastore.ReadAuthorizationModel(ctx, storeID, modelID)
-			})
-			if err != nil {
-				if errors.Is(err, storage.ErrNotFound) {
-					return nil, ErrModelNotFound
-				}
+		model = v.(*openfgav1.AuthorizationModel)
+		modelID = model.GetId()
+	}
 
-				return nil, fmt.Errorf("failed to ReadAuthorizationModel: %w", err)
-			}
+	// requests may bypass the cache, e.g. to check a model that was fixed in the datastore
+	noCache := requestcontext.FromContext(ctx).NoModelCache
 
-			model = v.(*openfgav1.AuthorizationModel)
+	key = typesystemCacheKey(storeID, modelID)
+	if !noCache {
+		if item := r.cache.Get(key); item != nil {
+			return item.Value, nil
 		}
+	}
 
-		typesys, err := NewAndValidate(ctx, model)
+	if model == nil {
+		v, err, _ := r.lookupGroup.Do(fmt.Sprintf("ReadAuthorizationModel:%s/%s", storeID, modelID), func() (interface{}, error) {
+			return r.datastore.ReadAuthorizationModel(ctx, storeID, modelID)
+		})
 		if err != nil {
-			return nil, fmt.Errorf("%w: %v", ErrInvalidModel, err)
+			return nil, modelLookupError("ReadAuthorizationModel", err)
 		}
 
-		cache.Set(key, typesys, typesystemCacheTTL)
+		model = v.(*openfgav1.AuthorizationModel)
+	}
+
+	typesys, err := NewAndValidate(ctx, model)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidModel, err)
+	}
+
+	if !noCache {
+		r.cache.Set(key, typesys, typesystemCacheTTL)
+	}
+
+	return typesys, nil
+}
+
+// DeleteStore evicts the type systems of a deleted store. Resolve then reads its models from
+// the datastore, which fails with [storage.ErrStoreDeleted] for them, so Resolve fails with
+// [ErrStoreNotFound].
+func (r *MemoizedTypesystemResolver) DeleteStore(storeID string) {
+	r.cache.DeletePrefix(storeID + "/")
+}
+
+// Refresh re-reads the latest model of a store from the datastore and replaces the memoized type
+// systems of the store with it, e.g. after models were written to the datastore outside the
+// server. The model is validated before any type system is evicted, and Resolve reads the type
+// systems missing from the cache from the datastore, so requests resolve a model throughout the
+// refresh.
+func (r *MemoizedTypesystemResolver) Refresh(ctx context.Context, storeID string) (*TypeSystem, error) {
+	// not deduplicated with the lookups in flight, which may have started before the write
+	model, err := r.datastore.FindLatestAuthorizationModel(ctx, storeID)
+	if err != nil {
+		return nil, modelLookupError("FindLatestAuthorizationModel", err)
+	}
+
+	typesys, err := NewAndValidate(ctx, model)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidModel, err)
+	}
+
+	r.cache.DeletePrefix(storeID + "/")
+	r.cache.Set(typesystemCacheKey(storeID, model.GetId()), typesys, typesystemCacheTTL)
+	return typesys, nil
+}
+
+// Len returns the number of memoized type systems.
+func (r *MemoizedTypesystemResolver) Len() int {
+	return r.cache.Len()
+}
+
+// Stop releases the caches of the resolver.
+func (r *MemoizedTypesystemResolver) Stop() {
+	r.cache.Stop()
+}
+
+// modelLookupError returns the error of a failed read of the models of a store.
+func modelLookupError(method string, err error) error {
+	switch {
+	case errors.Is(err, storage.ErrStoreDeleted):
+		return ErrStoreNotFound
+	case errors.Is(err, storage.ErrNotFound):
+		return ErrModelNotFound
+	default:
+		return fmt.Errorf("failed to %s: %w", method, err)
+	}
+}
 
-		return typesys, nil
-	}, cache.Stop
+func typesystemCacheKey(storeID, modelID string) string {
+	return fmt.Sprintf("%s/%s", storeID, modelID)
 }
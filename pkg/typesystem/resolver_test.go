@@ -11,6 +11,7 @@ import (
 	"go.uber.org/mock/gomock"
 
 	mockstorage "github.com/openfga/openfga/internal/mocks"
+	"github.com/openfga/openfga/pkg/requestcontext"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/testutils"
 )
@@ -193,6 +194,43 @@ func TestMemoizedTypesystemResolverFunc(t *testing.T) {
 		require.Equal(t, modelID, typesys.GetAuthorizationModelID())
 	})
 
+	t.Run("no_model_cache_option_bypasses_the_cache", func(t *testing.T) {
+		store := ulid.Make().String()
+		modelID := ulid.Make().String()
+
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mockstorage.NewMockAuthorizationModelReadBackend(mockController)
+		mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), store, modelID).
+			Return(
+				&openfgav1.AuthorizationModel{
+					Id:            modelID,
+					SchemaVersion: SchemaVersion1_1,
+				},
+				nil,
+			).
+			Times(3)
+
+		resolver, resolverStop := MemoizedTypesystemResolverFunc(
+			mockDatastore,
+		)
+		defer resolverStop()
+
+		ctx := requestcontext.ContextWithOptions(context.Background(), requestcontext.Options{NoModelCache: true})
+
+		// the calls with the option neither read nor fill the cache, asserted by the Times(3) above
+		for i := 0; i < 2; i++ {
+			typesys, err := resolver(ctx, store, modelID)
+			require.NoError(t, err)
+			require.Equal(t, modelID, typesys.GetAuthorizationModelID())
+		}
+
+		typesys, err := resolver(context.Background(), store, modelID)
+		require.NoError(t, err)
+		require.Equal(t, modelID, typesys.GetAuthorizationModelID())
+	})
+
 	t.Run("two_calls_without_model_id_returns_second_from_cache", func(t *testing.T) {
 		store := ulid.Make().String()
 		model := testutils.MustTransformDSLToProtoWithID(`
@@ -265,4 +303,52 @@ func TestMemoizedTypesystemResolverFunc(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, modelTwo.GetId(), typesys.GetAuthorizationModelID())
 	})
+
+	t.Run("deleted_store_returns_store_not_found", func(t *testing.T) {
+		store := ulid.Make().String()
+		otherStore := ulid.Make().String()
+		modelID := ulid.Make().String()
+
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mockstorage.NewMockAuthorizationModelReadBackend(mockController)
+		mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), gomock.Any(), modelID).
+			Return(
+				&openfgav1.AuthorizationModel{
+					Id:            modelID,
+					SchemaVersion: SchemaVersion1_1,
+				},
+				nil,
+			).
+			Times(2)
+
+		resolver := NewMemoizedTypesystemResolver(mockDatastore)
+		defer resolver.Stop()
+
+		_, err := resolver.Resolve(context.Background(), store, modelID)
+		require.NoError(t, err)
+		_, err = resolver.Resolve(context.Background(), otherStore, modelID)
+		require.NoError(t, err)
+
+		// the datastore keeps the tombstone of the store
+		mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), store, modelID).
+			Return(nil, storage.ErrStoreDeleted).
+			Times(2)
+		mockDatastore.EXPECT().FindLatestAuthorizationModel(gomock.Any(), store).
+			Return(nil, storage.ErrStoreDeleted).
+			Times(2)
+
+		resolver.DeleteStore(store)
+		for i := 0; i < 2; i++ {
+			_, err = resolver.Resolve(context.Background(), store, modelID)
+			require.ErrorIs(t, err, ErrStoreNotFound)
+			_, err = resolver.Resolve(context.Background(), store, "")
+			require.ErrorIs(t, err, ErrStoreNotFound)
+		}
+
+		// other stores are served from the cache, as asserted by the Times(2) above
+		_, err = resolver.Resolve(context.Background(), otherStore, modelID)
+		require.NoError(t, err)
+	})
 }
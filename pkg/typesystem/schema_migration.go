@@ -0,0 +1,296 @@
+package typesystem
+
+import (
+	"fmt"
+	"sort"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// SchemaUsage records the users of the tuples written to each relation of a store, from which
+// [MigrateSchema1_0To1_1] synthesizes the type restrictions of the relations.
+type SchemaUsage struct {
+	// references maps an object type and a relation to the references of the users of their
+	// tuples, keyed by their string form, e.g. `user`, `group#member` or `user:*`.
+	references map[string]map[string]map[string]*openfgav1.RelationReference
+
+	// untyped maps an object type and a relation to the number of their tuples whose user has
+	// no type, e.g. `anne` or the schema 1.0 wildcard `*`.
+	untyped map[string]map[string]int
+}
+
+func NewSchemaUsage() *SchemaUsage {
+	return &SchemaUsage{
+		references: map[string]map[string]map[string]*openfgav1.RelationReference{},
+		untyped:    map[string]map[string]int{},
+	}
+}
+
+// AddTuple records the user of the tuple.
+func (u *SchemaUsage) AddTuple(tk *openfgav1.TupleKey) {
+	objectType := tuple.GetType(tk.GetObject())
+	relation := tk.GetRelation()
+
+	userObject, userRelation := tuple.SplitObjectRelation(tk.GetUser())
+	userType, userID := tuple.SplitObject(userObject)
+
+	if userType == "" {
+		if u.untyped[objectType] == nil {
+			u.untyped[objectType] = map[string]int{}
+		}
+		u.untyped[objectType][relation]++
+		return
+	}
+
+	var ref *openfgav1.RelationReference
+	if userID == tuple.Wildcard && userRelation == "" {
+		ref = WildcardRelationReference(userType)
+	} else {
+		ref = DirectRelationReference(userType, userRelation)
+	}
+
+	if u.references[objectType] == nil {
+		u.references[objectType] = map[string]map[string]*openfgav1.RelationReference{}
+	}
+	if u.references[objectType][relation] == nil {
+		u.references[objectType][relation] = map[string]*openfgav1.RelationReference{}
+	}
+	u.references[objectType][relation][relationReferenceString(ref)] = ref
+}
+
+// relationReferenceString returns `user`, `group#member` or `user:*`.
+func relationReferenceString(ref *openfgav1.RelationReference) string {
+	if ref.GetRelation() == "" && ref.GetWildcard() == nil {
+		return ref.GetType()
+	}
+	return GetRelationReferenceAsString(ref)
+}
+
+// SchemaMigrationNote is a transformation applied by [MigrateSchema1_0To1_1], or a problem that
+// it could not solve. Type and Relation are empty if the note is about the whole model.
+type SchemaMigrationNote struct {
+	Type     string `json:"type,omitempty"`
+	Relation string `json:"relation,omitempty"`
+	Message  string `json:"message"`
+}
+
+// String returns e.g. `document#viewer: <message>`.
+func (n SchemaMigrationNote) String() string {
+	switch {
+	case n.Type == "":
+		return n.Message
+	case n.Relation == "":
+		return fmt.Sprintf("%s: %s", n.Type, n.Message)
+	default:
+		return fmt.Sprintf("%s#%s: %s", n.Type, n.Relation, n.Message)
+	}
+}
+
+// SchemaMigration is the result of [MigrateSchema1_0To1_1].
+type SchemaMigration struct {
+	// Model is the migrated model, with schema version 1.1 and no ID. It is only valid if
+	// NeedsAttention is empty.
+	Model *openfgav1.AuthorizationModel
+
+	// Transformations are the changes made to the model, in the order of the types and relations.
+	Transformations []SchemaMigrationNote
+
+	// NeedsAttention are the relations that the migration could not carry over unchanged, and
+	// that must be fixed by hand.
+	NeedsAttention []SchemaMigrationNote
+}
+
+// MigrateSchema1_0To1_1 transforms a model of schema version 1.0 into a model of schema version
+// 1.1, following the documented migration rules:
+//
+//   - the schema version becomes 1.1;
+//   - every directly assignable relation, i.e. every relation whose rewrite contains `this`, is
+//     given the type restrictions of the users of the tuples written to it, as recorded in usage;
+//   - relations that are not directly assignable are given no type restrictions.
+//
+// The relations that cannot be migrated this way are reported in NeedsAttention: directly
+// assignable relations with no typed users to synthesize their type restrictions from, users
+// whose type or relation is not defined in the model, users with no type (including the schema
+// 1.0 wildcard `*`, which must be rewritten as a typed wildcard such as `user:*`), and tupleset
+// relations with usersets or wildcards as users. Any other change the rules of schema 1.1
+// require is left to the validation of the migrated model.
+//
+// The input model is not modified.
+func MigrateSchema1_0To1_1(model *openfgav1.AuthorizationModel, usage *SchemaUsage) (*SchemaMigration, error) {
+	if model.GetSchemaVersion() != SchemaVersion1_0 {
+		return nil, fmt.Errorf("%w: expected %s, got '%s'", ErrInvalidSchemaVersion, SchemaVersion1_0, model.GetSchemaVersion())
+	}
+	if usage == nil {
+		usage = NewSchemaUsage()
+	}
+
+	migrated := &openfgav1.AuthorizationModel{
+		SchemaVersion: SchemaVersion1_1,
+		Conditions:    model.GetConditions(),
+	}
+	migration := &SchemaMigration{Model: migrated}
+	migration.Transformations = append(migration.Transformations, SchemaMigrationNote{
+		Message: fmt.Sprintf("schema version changed from %s to %s", SchemaVersion1_0, SchemaVersion1_1),
+	})
+
+	typeDefinitions := make(map[string]*openfgav1.TypeDefinition, len(model.GetTypeDefinitions()))
+	for _, td := range model.GetTypeDefinitions() {
+		typeDefinitions[td.GetType()] = td
+	}
+
+	for _, td := range model.GetTypeDefinitions() {
+		typeName := td.GetType()
+		tuplesets := tuplesetRelations(td)
+
+		migratedTypedef := &openfgav1.TypeDefinition{Type: typeName}
+		migrated.TypeDefinitions = append(migrated.TypeDefinitions, migratedTypedef)
+		if td.GetMetadata() != nil {
+			migratedTypedef.Metadata = proto.Clone(td.GetMetadata()).(*openfgav1.Metadata)
+		}
+		if len(td.GetRelations()) == 0 {
+			continue
+		}
+
+		// as in the models written in the DSL, every relation has metadata
+		if migratedTypedef.Metadata == nil {
+			migratedTypedef.Metadata = &openfgav1.Metadata{}
+		}
+		migratedTypedef.Metadata.Relations = make(map[string]*openfgav1.RelationMetadata, len(td.GetRelations()))
+		migratedTypedef.Relations = make(map[string]*openfgav1.Userset, len(td.GetRelations()))
+
+		relationNames := make([]string, 0, len(td.GetRelations()))
+		for relationName := range td.GetRelations() {
+			relationNames = append(relationNames, relationName)
+		}
+		sort.Strings(relationNames)
+
+		for _, relationName := range relationNames {
+			rewrite := td.GetRelations()[relationName]
+			migratedTypedef.Relations[relationName] = normalizeThis(proto.Clone(rewrite).(*openfgav1.Userset))
+
+			relationMetadata := &openfgav1.RelationMetadata{}
+			if previous := td.GetMetadata().GetRelations()[relationName]; previous != nil {
+				relationMetadata = proto.Clone(previous).(*openfgav1.RelationMetadata)
+			}
+			migratedTypedef.Metadata.Relations[relationName] = relationMetadata
+
+			if !RewriteContainsSelf(rewrite) {
+				relationMetadata.DirectlyRelatedUserTypes = nil
+				continue
+			}
+
+			_, isTupleset := tuplesets[relationName]
+			relatedTypes, notes := synthesizeTypeRestrictions(typeDefinitions, usage, typeName, relationName, isTupleset)
+			migration.NeedsAttention = append(migration.NeedsAttention, notes...)
+
+			if n := usage.untyped[typeName][relationName]; n > 0 {
+				migration.NeedsAttention = append(migration.NeedsAttention, SchemaMigrationNote{
+					Type:     typeName,
+					Relation: relationName,
+					Message:  fmt.Sprintf("%d tuple(s) have a user with no type, such as the wildcard '*', which schema %s cannot represent: rewrite them with typed users, e.g. 'user:*'", n, SchemaVersion1_1),
+				})
+			}
+
+			relationMetadata.DirectlyRelatedUserTypes = relatedTypes
+			if len(relatedTypes) == 0 {
+				migration.NeedsAttention = append(migration.NeedsAttention, SchemaMigrationNote{
+					Type:     typeName,
+					Relation: relationName,
+					Message:  "the relation is directly assignable but no tuple with a typed user was written to it: add its type restrictions by hand, or remove its direct assignment",
+				})
+				continue
+			}
+
+			refs := make([]string, 0, len(relatedTypes))
+			for _, ref := range relatedTypes {
+				refs = append(refs, relationReferenceString(ref))
+			}
+			migration.Transformations = append(migration.Transformations, SchemaMigrationNote{
+				Type:     typeName,
+				Relation: relationName,
+				Message:  fmt.Sprintf("type restrictions %v synthesized from the users of its tuples", refs),
+			})
+		}
+	}
+
+	return migration, nil
+}
+
+// synthesizeTypeRestrictions returns the type restrictions of a directly assignable relation,
+// sorted, from the recorded users of its tuples, along with the notes on the users that cannot
+// be type restrictions.
+func synthesizeTypeRestrictions(
+	typeDefinitions map[string]*openfgav1.TypeDefinition,
+	usage *SchemaUsage,
+	typeName, relationName string,
+	isTupleset bool,
+) ([]*openfgav1.RelationReference, []SchemaMigrationNote) {
+	used := usage.references[typeName][relationName]
+	keys := make([]string, 0, len(used))
+	for key := range used {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var (
+		relatedTypes []*openfgav1.RelationReference
+		notes        []SchemaMigrationNote
+	)
+	for _, key := range keys {
+		ref := used[key]
+
+		var problem string
+		userTypedef, ok := typeDefinitions[ref.GetType()]
+		switch {
+		case !ok:
+			problem = fmt.Sprintf("the type '%s' is not defined", ref.GetType())
+		case ref.GetRelation() != "" && userTypedef.GetRelations()[ref.GetRelation()] == nil:
+			problem = fmt.Sprintf("the relation '%s' is not defined", key)
+		case isTupleset && (ref.GetRelation() != "" || ref.GetWildcard() != nil):
+			problem = "the relation is a tupleset, whose users can only be objects"
+		}
+		if problem != "" {
+			notes = append(notes, SchemaMigrationNote{
+				Type:     typeName,
+				Relation: relationName,
+				Message:  fmt.Sprintf("tuples with users of '%s' cannot be carried over, %s: delete or rewrite them", key, problem),
+			})
+			continue
+		}
+
+		relatedTypes = append(relatedTypes, ref)
+	}
+
+	return relatedTypes, notes
+}
+
+// tuplesetRelations returns the relations of the type that are the tupleset of a tuple to
+// userset rewrite.
+func tuplesetRelations(td *openfgav1.TypeDefinition) map[string]struct{} {
+	tuplesets := map[string]struct{}{}
+	for _, rewrite := range td.GetRelations() {
+		_, _ = WalkUsersetRewrite(rewrite, func(r *openfgav1.Userset) interface{} {
+			if ttu := r.GetTupleToUserset(); ttu != nil {
+				tuplesets[ttu.GetTupleset().GetRelation()] = struct{}{}
+			}
+			return nil
+		})
+	}
+	return tuplesets
+}
+
+// normalizeThis sets the direct assignments of the rewrite that have no value, as some schema 1.0
+// models do, to an empty value, as in the models written in the DSL, which does not render them
+// otherwise.
+func normalizeThis(rewrite *openfgav1.Userset) *openfgav1.Userset {
+	_, _ = WalkUsersetRewrite(rewrite, func(r *openfgav1.Userset) interface{} {
+		if this, ok := r.GetUserset().(*openfgav1.Userset_This); ok && this.This == nil {
+			this.This = &openfgav1.DirectUserset{}
+		}
+		return nil
+	})
+	return rewrite
+}
@@ -0,0 +1,285 @@
+package typesystem
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	parser "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestMigrateSchema1_0To1_1(t *testing.T) {
+	tests := map[string]struct {
+		typeDefinitions         []*openfgav1.TypeDefinition
+		tuples                  []*openfgav1.TupleKey
+		expected                string
+		expectedTransformations []string
+		expectedNeedsAttention  []string
+	}{
+		`direct_relations`: {
+			typeDefinitions: []*openfgav1.TypeDefinition{
+				{Type: "user"},
+				{
+					Type: "document",
+					Relations: map[string]*openfgav1.Userset{
+						"owner":  This(),
+						"viewer": This(),
+					},
+				},
+			},
+			tuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "owner", "user:anne"),
+				tuple.NewTupleKey("document:2", "owner", "user:bob"),
+				tuple.NewTupleKey("document:1", "viewer", "user:*"),
+				tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+			},
+			expected: `
+				model
+					schema 1.1
+				type user
+				type document
+					relations
+						define owner: [user]
+						define viewer: [user, user:*]`,
+			expectedTransformations: []string{
+				"schema version changed from 1.0 to 1.1",
+				"document#owner: type restrictions [user] synthesized from the users of its tuples",
+				"document#viewer: type restrictions [user user:*] synthesized from the users of its tuples",
+			},
+		},
+		`usersets_and_computed_relations`: {
+			typeDefinitions: []*openfgav1.TypeDefinition{
+				{Type: "user"},
+				{
+					Type: "group",
+					Relations: map[string]*openfgav1.Userset{
+						"member": This(),
+					},
+				},
+				{
+					Type: "document",
+					Relations: map[string]*openfgav1.Userset{
+						"editor":   This(),
+						"viewer":   Union(This(), ComputedUserset("editor")),
+						"can_view": ComputedUserset("viewer"),
+					},
+				},
+			},
+			tuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("group:eng", "member", "user:anne"),
+				tuple.NewTupleKey("group:eng", "member", "group:fga#member"),
+				tuple.NewTupleKey("document:1", "editor", "group:eng#member"),
+				tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+			},
+			expected: `
+				model
+					schema 1.1
+				type user
+				type group
+					relations
+						define member: [group#member, user]
+				type document
+					relations
+						define can_view: viewer
+						define editor: [group#member]
+						define viewer: [user] or editor`,
+			expectedTransformations: []string{
+				"schema version changed from 1.0 to 1.1",
+				"group#member: type restrictions [group#member user] synthesized from the users of its tuples",
+				"document#editor: type restrictions [group#member] synthesized from the users of its tuples",
+				"document#viewer: type restrictions [user] synthesized from the users of its tuples",
+			},
+		},
+		`tuple_to_userset`: {
+			typeDefinitions: []*openfgav1.TypeDefinition{
+				{Type: "user"},
+				{
+					Type: "folder",
+					Relations: map[string]*openfgav1.Userset{
+						"viewer": This(),
+					},
+				},
+				{
+					Type: "document",
+					Relations: map[string]*openfgav1.Userset{
+						"parent": This(),
+						"viewer": Union(This(), TupleToUserset("parent", "viewer")),
+					},
+				},
+			},
+			tuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("folder:x", "viewer", "user:anne"),
+				tuple.NewTupleKey("document:1", "parent", "folder:x"),
+				tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+			},
+			expected: `
+				model
+					schema 1.1
+				type user
+				type folder
+					relations
+						define viewer: [user]
+				type document
+					relations
+						define parent: [folder]
+						define viewer: [user] or viewer from parent`,
+			expectedTransformations: []string{
+				"schema version changed from 1.0 to 1.1",
+				"folder#viewer: type restrictions [user] synthesized from the users of its tuples",
+				"document#parent: type restrictions [folder] synthesized from the users of its tuples",
+				"document#viewer: type restrictions [user] synthesized from the users of its tuples",
+			},
+		},
+		`intersection_and_exclusion`: {
+			typeDefinitions: []*openfgav1.TypeDefinition{
+				{Type: "user"},
+				{
+					Type: "document",
+					Relations: map[string]*openfgav1.Userset{
+						"allowed": This(),
+						"blocked": This(),
+						"viewer":  Intersection(This(), ComputedUserset("allowed")),
+						"editor":  Difference(This(), ComputedUserset("blocked")),
+					},
+				},
+			},
+			tuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "allowed", "user:anne"),
+				tuple.NewTupleKey("document:1", "blocked", "user:bob"),
+				tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+				tuple.NewTupleKey("document:1", "editor", "user:*"),
+			},
+			expected: `
+				model
+					schema 1.1
+				type user
+				type document
+					relations
+						define allowed: [user]
+						define blocked: [user]
+						define editor: [user:*] but not blocked
+						define viewer: [user] and allowed`,
+			expectedTransformations: []string{
+				"schema version changed from 1.0 to 1.1",
+				"document#allowed: type restrictions [user] synthesized from the users of its tuples",
+				"document#blocked: type restrictions [user] synthesized from the users of its tuples",
+				"document#editor: type restrictions [user:*] synthesized from the users of its tuples",
+				"document#viewer: type restrictions [user] synthesized from the users of its tuples",
+			},
+		},
+		`relations_that_need_attention`: {
+			typeDefinitions: []*openfgav1.TypeDefinition{
+				{Type: "user"},
+				{
+					Type: "group",
+					Relations: map[string]*openfgav1.Userset{
+						"member": This(),
+					},
+				},
+				{
+					Type: "folder",
+					Relations: map[string]*openfgav1.Userset{
+						"viewer": This(),
+					},
+				},
+				{
+					Type: "document",
+					Relations: map[string]*openfgav1.Userset{
+						"owner":  This(),
+						"parent": This(),
+						"viewer": Union(This(), TupleToUserset("parent", "viewer")),
+					},
+				},
+			},
+			tuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("group:eng", "member", "user:anne"),
+				tuple.NewTupleKey("group:eng", "member", "team:fga"),
+				tuple.NewTupleKey("group:eng", "member", "group:fga#admin"),
+				tuple.NewTupleKey("document:1", "parent", "folder:x"),
+				tuple.NewTupleKey("document:1", "parent", "folder:y#viewer"),
+				tuple.NewTupleKey("document:1", "viewer", "anne"),
+				tuple.NewTupleKey("document:1", "viewer", "*"),
+			},
+			expectedTransformations: []string{
+				"schema version changed from 1.0 to 1.1",
+				"group#member: type restrictions [user] synthesized from the users of its tuples",
+				"document#parent: type restrictions [folder] synthesized from the users of its tuples",
+			},
+			expectedNeedsAttention: []string{
+				"group#member: tuples with users of 'group#admin' cannot be carried over, the relation 'group#admin' is not defined: delete or rewrite them",
+				"group#member: tuples with users of 'team' cannot be carried over, the type 'team' is not defined: delete or rewrite them",
+				"folder#viewer: the relation is directly assignable but no tuple with a typed user was written to it: add its type restrictions by hand, or remove its direct assignment",
+				"document#owner: the relation is directly assignable but no tuple with a typed user was written to it: add its type restrictions by hand, or remove its direct assignment",
+				"document#parent: tuples with users of 'folder#viewer' cannot be carried over, the relation is a tupleset, whose users can only be objects: delete or rewrite them",
+				"document#viewer: 2 tuple(s) have a user with no type, such as the wildcard '*', which schema 1.1 cannot represent: rewrite them with typed users, e.g. 'user:*'",
+				"document#viewer: the relation is directly assignable but no tuple with a typed user was written to it: add its type restrictions by hand, or remove its direct assignment",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			model := &openfgav1.AuthorizationModel{
+				SchemaVersion:   SchemaVersion1_0,
+				TypeDefinitions: test.typeDefinitions,
+			}
+			original := proto.Clone(model)
+
+			usage := NewSchemaUsage()
+			for _, tk := range test.tuples {
+				usage.AddTuple(tk)
+			}
+
+			migration, err := MigrateSchema1_0To1_1(model, usage)
+			require.NoError(t, err)
+			require.True(t, proto.Equal(original, model), "the input model was modified")
+
+			transformations := make([]string, 0, len(migration.Transformations))
+			for _, note := range migration.Transformations {
+				transformations = append(transformations, note.String())
+			}
+			require.Equal(t, test.expectedTransformations, transformations)
+
+			var needsAttention []string
+			for _, note := range migration.NeedsAttention {
+				needsAttention = append(needsAttention, note.String())
+			}
+			require.Equal(t, test.expectedNeedsAttention, needsAttention)
+
+			_, err = NewAndValidate(context.Background(), migration.Model)
+			if len(test.expectedNeedsAttention) > 0 {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			expected := parser.MustTransformDSLToProto(test.expected)
+			if diff := cmp.Diff(expected.GetTypeDefinitions(), migration.Model.GetTypeDefinitions(), protocmp.Transform(), cmpopts.EquateEmpty()); diff != "" {
+				t.Fatalf("migrated type definitions mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("rejects_other_schema_versions", func(t *testing.T) {
+		_, err := MigrateSchema1_0To1_1(&openfgav1.AuthorizationModel{SchemaVersion: SchemaVersion1_1}, NewSchemaUsage())
+		require.ErrorIs(t, err, ErrInvalidSchemaVersion)
+	})
+
+	t.Run("keeps_type_definitions_without_usage", func(t *testing.T) {
+		migration, err := MigrateSchema1_0To1_1(&openfgav1.AuthorizationModel{
+			SchemaVersion:   SchemaVersion1_0,
+			TypeDefinitions: []*openfgav1.TypeDefinition{{Type: "user"}},
+		}, nil)
+		require.NoError(t, err)
+		require.Empty(t, migration.NeedsAttention)
+		require.Len(t, migration.Model.GetTypeDefinitions(), 1)
+		require.Equal(t, SchemaVersion1_1, migration.Model.GetSchemaVersion())
+	})
+}
@@ -168,6 +168,8 @@ type TypeSystem struct {
 	ttuRelations map[string]map[string][]*openfgav1.TupleToUserset
 
 	computedRelations sync.Map
+	// [userType] => []ReachableRelation, see GetReachableRelations.
+	reachableRelations sync.Map
 
 	modelID                 string
 	schemaVersion           string
@@ -781,7 +783,12 @@ func hasEntrypoints(
 	typeName, relationName string,
 	rewrite *openfgav1.Userset,
 	visitedRelations map[string]map[string]bool,
+	budget *validationBudget,
 ) (bool, bool, error) {
+	if err := budget.visit(); err != nil {
+		return false, false, err
+	}
+
 	v := maps.Clone(visitedRelations)
 
 	// Presence of a key represents that we've visited that object and relation. We keep track of this to avoid stack overflows.
@@ -820,7 +827,7 @@ func hasEntrypoints(
 				continue
 			}
 
-			hasEntrypoint, _, err := hasEntrypoints(typedefs, assignableTypeName, assignableRelationName, assignableRelation.GetRewrite(), v)
+			hasEntrypoint, _, err := hasEntrypoints(typedefs, assignableTypeName, assignableRelationName, assignableRelation.GetRewrite(), v, budget)
 			if err != nil {
 				return false, false, err
 			}
@@ -843,7 +850,7 @@ func hasEntrypoints(
 			return hasEntrypoint, true, nil
 		}
 
-		hasEntrypoint, loop, err := hasEntrypoints(typedefs, typeName, computedRelationName, computedRelation.GetRewrite(), v)
+		hasEntrypoint, loop, err := hasEntrypoints(typedefs, typeName, computedRelationName, computedRelation.GetRewrite(), v, budget)
 		if err != nil {
 			return false, false, err
 		}
@@ -870,7 +877,7 @@ func hasEntrypoints(
 					continue
 				}
 
-				hasEntrypoint, _, err := hasEntrypoints(typedefs, assignableTypeName, computedRelationName, assignableRelation.GetRewrite(), v)
+				hasEntrypoint, _, err := hasEntrypoints(typedefs, assignableTypeName, computedRelationName, assignableRelation.GetRewrite(), v, budget)
 				if err != nil {
 					return false, false, err
 				}
@@ -887,7 +894,7 @@ func hasEntrypoints(
 		// At least one type must have an entrypoint.
 		loop := false
 		for _, child := range rw.Union.GetChild() {
-			hasEntrypoints, childLoop, err := hasEntrypoints(typedefs, typeName, relationName, child, visitedRelations)
+			hasEntrypoints, childLoop, err := hasEntrypoints(typedefs, typeName, relationName, child, visitedRelations, budget)
 			if err != nil {
 				return false, false, err
 			}
@@ -903,7 +910,7 @@ func hasEntrypoints(
 
 		for _, child := range rw.Intersection.GetChild() {
 			// All the children must have an entrypoint.
-			hasEntrypoints, childLoop, err := hasEntrypoints(typedefs, typeName, relationName, child, visitedRelations)
+			hasEntrypoints, childLoop, err := hasEntrypoints(typedefs, typeName, relationName, child, visitedRelations, budget)
 			if err != nil {
 				return false, false, err
 			}
@@ -916,7 +923,7 @@ func hasEntrypoints(
 		return true, false, nil
 	case *openfgav1.Userset_Difference:
 		// All the children must have an entrypoint.
-		hasEntrypoint, loop, err := hasEntrypoints(typedefs, typeName, relationName, rw.Difference.GetBase(), visitedRelations)
+		hasEntrypoint, loop, err := hasEntrypoints(typedefs, typeName, relationName, rw.Difference.GetBase(), visitedRelations, budget)
 		if err != nil {
 			return false, false, err
 		}
@@ -925,7 +932,7 @@ func hasEntrypoints(
 			return false, loop, nil
 		}
 
-		hasEntrypoint, loop, err = hasEntrypoints(typedefs, typeName, relationName, rw.Difference.GetSubtract(), visitedRelations)
+		hasEntrypoint, loop, err = hasEntrypoints(typedefs, typeName, relationName, rw.Difference.GetSubtract(), visitedRelations, budget)
 		if err != nil {
 			return false, false, err
 		}
@@ -954,10 +961,16 @@ func hasEntrypoints(
 //     a) For a type (e.g. user) this means checking that this type is in the *TypeSystem
 //     b) For a type#relation this means checking that this type with this relation is in the *TypeSystem
 //  4. Check that a relation is assignable if and only if it has a non-zero list of types
-func NewAndValidate(ctx context.Context, model *openfgav1.AuthorizationModel) (*TypeSystem, error) {
+//
+// The work of the validation can be limited (see [ValidationOption]), so that adversarial models
+// whose rewrites make it visit exponentially many nodes fail fast with ErrModelTooComplex. It is
+// unlimited by default, so that the models already written are always loaded.
+func NewAndValidate(ctx context.Context, model *openfgav1.AuthorizationModel, opts ...ValidationOption) (*TypeSystem, error) {
 	_, span := tracer.Start(ctx, "typesystem.NewAndValidate")
 	defer span.End()
 
+	budget := newValidationBudget(ctx, opts...)
+
 	t, err := New(model)
 	if err != nil {
 		return nil, err
@@ -999,7 +1012,7 @@ func NewAndValidate(ctx context.Context, model *openfgav1.AuthorizationModel) (*
 		sort.Strings(relationNames)
 
 		for _, relationName := range relationNames {
-			err := t.validateRelation(typeName, relationName, relationMap)
+			err := t.validateRelation(typeName, relationName, relationMap, budget)
 			if err != nil {
 				return nil, err
 			}
@@ -1016,7 +1029,7 @@ func NewAndValidate(ctx context.Context, model *openfgav1.AuthorizationModel) (*
 // validateRelation applies all the validation rules to a relation definition in a model. A relation
 // must meet all the rewrite validation, type restriction validation, and entrypoint validation criteria
 // for it to be valid. Otherwise, an error is returned.
-func (t *TypeSystem) validateRelation(typeName, relationName string, relationMap map[string]*openfgav1.Userset) error {
+func (t *TypeSystem) validateRelation(typeName, relationName string, relationMap map[string]*openfgav1.Userset, budget *validationBudget) error {
 	rewrite := relationMap[relationName]
 
 	err := t.isUsersetRewriteValid(typeName, relationName, rewrite)
@@ -1031,7 +1044,7 @@ func (t *TypeSystem) validateRelation(typeName, relationName string, relationMap
 
 	visitedRelations := map[string]map[string]bool{}
 
-	hasEntrypoints, loop, err := hasEntrypoints(t.relations, typeName, relationName, rewrite, visitedRelations)
+	hasEntrypoints, loop, err := hasEntrypoints(t.relations, typeName, relationName, rewrite, visitedRelations, budget)
 	if err != nil {
 		return err
 	}
@@ -1048,7 +1061,7 @@ func (t *TypeSystem) validateRelation(typeName, relationName string, relationMap
 		}
 	}
 
-	hasCycle, err := t.HasCycle(typeName, relationName)
+	hasCycle, err := t.hasCycle(typeName, relationName, rewrite, map[string]struct{}{}, budget)
 	if err != nil {
 		return err
 	}
@@ -1255,6 +1268,30 @@ func (t *TypeSystem) validateConditions() error {
 	return nil
 }
 
+// ConditionParameterWarnings returns a warning for every parameter that a condition
+// of the model declares but never references in its expression, ordered by condition name.
+func (t *TypeSystem) ConditionParameterWarnings() []string {
+	conditionNames := make([]string, 0, len(t.conditions))
+	for name := range t.conditions {
+		conditionNames = append(conditionNames, name)
+	}
+	sort.Strings(conditionNames)
+
+	var warnings []string
+	for _, name := range conditionNames {
+		unused, err := t.conditions[name].UnusedParameters()
+		if err != nil {
+			continue
+		}
+
+		for _, paramName := range unused {
+			warnings = append(warnings, fmt.Sprintf("condition '%s' declares parameter '%s' which its expression does not use", name, paramName))
+		}
+	}
+
+	return warnings
+}
+
 func (t *TypeSystem) IsDirectlyAssignable(relation *openfgav1.Relation) bool {
 	return RewriteContainsSelf(relation.GetRewrite())
 }
@@ -1280,7 +1317,12 @@ func (t *TypeSystem) hasCycle(
 	objectType, relationName string,
 	rewrite *openfgav1.Userset,
 	visited map[string]struct{},
+	budget *validationBudget,
 ) (bool, error) {
+	if err := budget.visit(); err != nil {
+		return false, err
+	}
+
 	visited[fmt.Sprintf("%s#%s", objectType, relationName)] = struct{}{}
 
 	visitedCopy := maps.Clone(visited)
@@ -1302,7 +1344,7 @@ func (t *TypeSystem) hasCycle(
 			return false, err
 		}
 
-		return t.hasCycle(objectType, rewrittenRelation, rewrittenRewrite.GetRewrite(), visitedCopy)
+		return t.hasCycle(objectType, rewrittenRelation, rewrittenRewrite.GetRewrite(), visitedCopy, budget)
 	case *openfgav1.Userset_Union:
 		children = append(children, rw.Union.GetChild()...)
 	case *openfgav1.Userset_Intersection:
@@ -1312,7 +1354,7 @@ func (t *TypeSystem) hasCycle(
 	}
 
 	for _, child := range children {
-		hasCycle, err := t.hasCycle(objectType, relationName, child, visitedCopy)
+		hasCycle, err := t.hasCycle(objectType, relationName, child, visitedCopy, budget)
 		if err != nil {
 			return false, err
 		}
@@ -1335,7 +1377,7 @@ func (t *TypeSystem) HasCycle(objectType, relationName string) (bool, error) {
 		return false, err
 	}
 
-	return t.hasCycle(objectType, relationName, relation.GetRewrite(), visited)
+	return t.hasCycle(objectType, relationName, relation.GetRewrite(), visited, nil)
 }
 
 // IsTuplesetRelation returns a boolean indicating if the provided relation is defined under a
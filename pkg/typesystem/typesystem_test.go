@@ -908,7 +908,7 @@ func TestHasEntrypoints(t *testing.T) {
 			inputRelation, _ := ts.GetRelation(test.inputType, test.inputRelation)
 
 			rewrite := inputRelation.GetRewrite()
-			hasEntrypoints, hasCycle, err := hasEntrypoints(ts.GetAllRelations(), test.inputType, test.inputRelation, rewrite, map[string]map[string]bool{})
+			hasEntrypoints, hasCycle, err := hasEntrypoints(ts.GetAllRelations(), test.inputType, test.inputRelation, rewrite, map[string]map[string]bool{}, nil)
 
 			if test.expectError != "" {
 				require.ErrorContains(t, err, test.expectError)
@@ -0,0 +1,76 @@
+package typesystem
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// validationTimeCheckInterval is the number of visits between two checks of the wall-clock
+// budget and the context of a validation.
+const validationTimeCheckInterval = 1_024
+
+// ValidationOption sets a limit of the work done by [NewAndValidate], which has none by default.
+type ValidationOption func(*validationBudget)
+
+// WithValidationMaxVisits sets the number of rewrite nodes that the validation of a model may
+// visit before failing with ErrModelTooComplex. 0 disables the limit.
+func WithValidationMaxVisits(maxVisits int) ValidationOption {
+	return func(b *validationBudget) {
+		b.maxVisits = maxVisits
+	}
+}
+
+// WithValidationTimeout sets the time that the validation of a model may take before failing
+// with ErrModelTooComplex. 0 disables the limit. The validation stops when its context is done
+// regardless.
+func WithValidationTimeout(timeout time.Duration) ValidationOption {
+	return func(b *validationBudget) {
+		b.timeout = timeout
+	}
+}
+
+// validationBudget bounds the work of the validation of a model. Some self-referential rewrites
+// make the walks of the validation visit exponentially many nodes before any depth limit
+// applies, so each visit is counted, and the elapsed time is checked every
+// validationTimeCheckInterval visits. A nil budget is unlimited.
+type validationBudget struct {
+	ctx       context.Context
+	maxVisits int
+	timeout   time.Duration
+	deadline  time.Time
+	visits    int
+}
+
+func newValidationBudget(ctx context.Context, opts ...ValidationOption) *validationBudget {
+	b := &validationBudget{ctx: ctx}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.timeout > 0 {
+		b.deadline = time.Now().Add(b.timeout)
+	}
+	return b
+}
+
+// visit counts the visit of a rewrite node, and fails if the budget is exceeded.
+func (b *validationBudget) visit() error {
+	if b == nil {
+		return nil
+	}
+
+	b.visits++
+	if b.maxVisits > 0 && b.visits > b.maxVisits {
+		return fmt.Errorf("%w: its validation visited more than %d rewrite nodes", ErrModelTooComplex, b.maxVisits)
+	}
+
+	if b.visits%validationTimeCheckInterval == 0 {
+		if err := b.ctx.Err(); err != nil {
+			return err
+		}
+		if !b.deadline.IsZero() && time.Now().After(b.deadline) {
+			return fmt.Errorf("%w: its validation took longer than %s", ErrModelTooComplex, b.timeout)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package typesystem
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/testutils"
+)
+
+// duplicatedComputedChainModel defines relations that each rewrite to the next one twice, so
+// that the cycle detection walks 2^n paths.
+func duplicatedComputedChainModel(n int) string {
+	var b strings.Builder
+	b.WriteString("model\n  schema 1.1\ntype user\ntype document\n  relations\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "    define r%d: r%d or r%d\n", i, i+1, i+1)
+	}
+	fmt.Fprintf(&b, "    define r%d: [user]\n", n)
+	return b.String()
+}
+
+// intersectedUsersetChainModel defines types whose relation intersects two usersets of the next
+// type, so that the entrypoint detection walks 2^n paths.
+func intersectedUsersetChainModel(n int) string {
+	var b strings.Builder
+	b.WriteString("model\n  schema 1.1\ntype user\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "type t%d\n  relations\n    define x: [t%d#r]\n    define y: [t%d#r]\n    define r: x and y\n", i, i+1, i+1)
+	}
+	fmt.Fprintf(&b, "type t%d\n  relations\n    define r: [user]\n", n)
+	return b.String()
+}
+
+func TestNewAndValidateBudget(t *testing.T) {
+	adversarial := map[string]string{
+		`duplicated_computed_chain`: duplicatedComputedChainModel(24),
+		`intersected_userset_chain`: intersectedUsersetChainModel(24),
+	}
+
+	for name, dsl := range adversarial {
+		t.Run(name, func(t *testing.T) {
+			model := testutils.MustTransformDSLToProtoWithID(dsl)
+
+			start := time.Now()
+			_, err := NewAndValidate(context.Background(), model, WithValidationMaxVisits(100_000))
+			require.ErrorIs(t, err, ErrModelTooComplex)
+			require.Less(t, time.Since(start), 5*time.Second)
+
+			_, err = NewAndValidate(context.Background(), model, WithValidationMaxVisits(0), WithValidationTimeout(50*time.Millisecond))
+			require.ErrorIs(t, err, ErrModelTooComplex)
+			require.ErrorContains(t, err, "its validation took longer than 50ms")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err = NewAndValidate(ctx, model, WithValidationMaxVisits(0))
+			require.ErrorIs(t, err, context.Canceled)
+		})
+	}
+
+	t.Run("small_models_within_the_budget", func(t *testing.T) {
+		for _, dsl := range []string{duplicatedComputedChainModel(6), intersectedUsersetChainModel(6)} {
+			_, err := NewAndValidate(context.Background(), testutils.MustTransformDSLToProtoWithID(dsl))
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("unlimited_by_default", func(t *testing.T) {
+		model := testutils.MustTransformDSLToProtoWithID(duplicatedComputedChainModel(12))
+		_, err := NewAndValidate(context.Background(), model)
+		require.NoError(t, err)
+
+		_, err = NewAndValidate(context.Background(), model, WithValidationMaxVisits(1_000))
+		require.ErrorIs(t, err, ErrModelTooComplex)
+	})
+
+	t.Run("max_visits", func(t *testing.T) {
+		model := testutils.MustTransformDSLToProtoWithID(duplicatedComputedChainModel(6))
+		_, err := NewAndValidate(context.Background(), model, WithValidationMaxVisits(10))
+		require.ErrorIs(t, err, ErrModelTooComplex)
+		require.ErrorContains(t, err, "its validation visited more than 10 rewrite nodes")
+	})
+}
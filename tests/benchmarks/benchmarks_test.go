@@ -0,0 +1,108 @@
+package benchmarks
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+var summary = flag.Bool("summary", false, "print a markdown summary of the results of BenchmarkScenarios")
+
+func TestScenarios(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	for _, scenario := range DefaultScenarios() {
+		t.Run(scenario.Name, func(t *testing.T) {
+			for _, config := range DefaultConfigurations() {
+				t.Run(config.Name, func(t *testing.T) {
+					h, err := NewHarness(ctx, scenario, config)
+					require.NoError(t, err)
+					defer h.Close()
+
+					for _, check := range scenario.Checks {
+						sample, err := h.Check(ctx, check)
+						require.NoError(t, err)
+						require.Equal(t, check.Allowed, sample.Allowed, check.TupleKey)
+						require.Positive(t, sample.DatastoreQueries)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	configs := Matrix(ResolveNodeBreadthLimits(), CheckCaches())
+	names := make([]string, 0, len(configs))
+	for _, config := range configs {
+		names = append(names, config.Name)
+	}
+	require.Equal(t, []string{
+		"breadth_default/cache_off",
+		"breadth_default/cache_on",
+		"breadth_10/cache_off",
+		"breadth_10/cache_on",
+	}, names)
+	require.Len(t, configs[3].Options, 3)
+
+	require.Len(t, DefaultConfigurations(), 16)
+}
+
+func TestSummarize(t *testing.T) {
+	result := NewResult("wide_union_50", "breadth_default", []Sample{
+		{Latency: 3 * time.Millisecond, Dispatches: 1, DatastoreQueries: 4},
+		{Latency: time.Millisecond, Dispatches: 0, DatastoreQueries: 2},
+		{Latency: 2 * time.Millisecond, Dispatches: 2, DatastoreQueries: 3},
+	})
+	require.Equal(t, 2*time.Millisecond, result.P50)
+	require.Equal(t, 2*time.Millisecond, result.P99)
+	require.InDelta(t, 1.0, result.MeanDispatches, 0.001)
+	require.InDelta(t, 3.0, result.MeanDatastoreQueries, 0.001)
+
+	var out bytes.Buffer
+	require.NoError(t, Summarize(&out, []Result{result}))
+	require.Equal(t, `| scenario | configuration | checks | p50 | p99 | dispatches/check | queries/check |
+|---|---|---:|---:|---:|---:|---:|
+| wide_union_50 | breadth_default | 3 | 2ms | 2ms | 1.0 | 3.0 |
+`, out.String())
+}
+
+func BenchmarkScenarios(b *testing.B) {
+	b.Cleanup(func() {
+		goleak.VerifyNone(b,
+			// https://github.com/uber-go/goleak/discussions/89
+			goleak.IgnoreTopFunction("testing.(*B).run1"),
+			goleak.IgnoreTopFunction("testing.(*B).doBench"),
+		)
+	})
+
+	var results []Result
+	for _, scenario := range DefaultScenarios() {
+		b.Run(scenario.Name, func(b *testing.B) {
+			for _, config := range DefaultConfigurations() {
+				b.Run(config.Name, func(b *testing.B) {
+					result := Run(b, scenario, config)
+					// the benchmark runs with increasing b.N, the last run is the one reported
+					if len(results) > 0 && results[len(results)-1].Scenario == scenario.Name && results[len(results)-1].Configuration == config.Name {
+						results[len(results)-1] = result
+					} else {
+						results = append(results, result)
+					}
+				})
+			}
+		})
+	}
+
+	if *summary {
+		require.NoError(b, Summarize(os.Stdout, results))
+	}
+}
@@ -0,0 +1,91 @@
+package benchmarks
+
+import (
+	"strings"
+	"time"
+
+	"github.com/openfga/openfga/pkg/server"
+)
+
+// Configuration is a named set of options of the server that a [Scenario] runs against.
+type Configuration struct {
+	Name    string
+	Options []server.OpenFGAServiceV1Option
+}
+
+// Matrix returns the configurations that combine one configuration of each axis, in order, for
+// each combination of them. Their names are the names of the combined configurations, separated
+// by "/".
+func Matrix(axes ...[]Configuration) []Configuration {
+	configs := []Configuration{{}}
+	for _, axis := range axes {
+		combined := make([]Configuration, 0, len(configs)*len(axis))
+		for _, config := range configs {
+			for _, value := range axis {
+				options := make([]server.OpenFGAServiceV1Option, 0, len(config.Options)+len(value.Options))
+				options = append(options, config.Options...)
+				options = append(options, value.Options...)
+
+				names := make([]string, 0, 2)
+				if config.Name != "" {
+					names = append(names, config.Name)
+				}
+				combined = append(combined, Configuration{
+					Name:    strings.Join(append(names, value.Name), "/"),
+					Options: options,
+				})
+			}
+		}
+		configs = combined
+	}
+	return configs
+}
+
+// ResolveNodeBreadthLimits is an axis of the resolve node breadth limit, see
+// [server.WithResolveNodeBreadthLimit].
+func ResolveNodeBreadthLimits() []Configuration {
+	return []Configuration{
+		{Name: "breadth_default"},
+		{Name: "breadth_10", Options: []server.OpenFGAServiceV1Option{server.WithResolveNodeBreadthLimit(10)}},
+	}
+}
+
+// UsersetBatchSizes is an axis of the userset batch size, see [server.WithUsersetBatchSize].
+func UsersetBatchSizes() []Configuration {
+	return []Configuration{
+		{Name: "batch_default"},
+		{Name: "batch_10", Options: []server.OpenFGAServiceV1Option{server.WithUsersetBatchSize(10)}},
+	}
+}
+
+// CheckCaches is an axis of the check query and iterator caches, see
+// [server.WithCheckQueryCacheEnabled] and [server.WithCheckIteratorCacheEnabled].
+func CheckCaches() []Configuration {
+	return []Configuration{
+		{Name: "cache_off"},
+		{Name: "cache_on", Options: []server.OpenFGAServiceV1Option{
+			server.WithCheckQueryCacheEnabled(true),
+			server.WithCheckIteratorCacheEnabled(true),
+		}},
+	}
+}
+
+// DispatchThrottling is an axis of the dispatch throttling of Check, see
+// [server.WithDispatchThrottlingCheckResolverEnabled]. The throttled configuration throttles
+// the dispatches of a request past the 10th.
+func DispatchThrottling() []Configuration {
+	return []Configuration{
+		{Name: "throttling_off"},
+		{Name: "throttling_on", Options: []server.OpenFGAServiceV1Option{
+			server.WithDispatchThrottlingCheckResolverEnabled(true),
+			server.WithDispatchThrottlingCheckResolverFrequency(100 * time.Microsecond),
+			server.WithDispatchThrottlingCheckResolverThreshold(10),
+			server.WithDispatchThrottlingCheckResolverMaxThreshold(10),
+		}},
+	}
+}
+
+// DefaultConfigurations returns the matrix of all the axes of this package.
+func DefaultConfigurations() []Configuration {
+	return Matrix(ResolveNodeBreadthLimits(), UsersetBatchSizes(), CheckCaches(), DispatchThrottling())
+}
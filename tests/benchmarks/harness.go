@@ -0,0 +1,219 @@
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	parser "github.com/openfga/language/pkg/go/transformer"
+
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+)
+
+const (
+	// dispatchCountTag and datastoreQueryCountTag are the tags that the server sets on the
+	// context of a Check with the number of its dispatches and datastore queries.
+	dispatchCountTag       = "dispatch_count"
+	datastoreQueryCountTag = "datastore_query_count"
+)
+
+// Harness is a server on the memory datastore, with a store that holds the model and the tuples
+// of a [Scenario].
+type Harness struct {
+	datastore storage.OpenFGADatastore
+	server    *server.Server
+	storeID   string
+	modelID   string
+}
+
+// Sample is the outcome of a check run by a [Harness].
+type Sample struct {
+	Allowed          bool
+	Latency          time.Duration
+	Dispatches       float64
+	DatastoreQueries float64
+}
+
+// NewHarness starts a server with the options of the configuration, and writes the model and the
+// tuples of the scenario to a new store. Close must be called once the harness is no longer used.
+func NewHarness(ctx context.Context, scenario Scenario, config Configuration) (*Harness, error) {
+	model, err := parser.TransformDSLToProto(scenario.Model)
+	if err != nil {
+		return nil, fmt.Errorf("scenario '%s' has an invalid model: %w", scenario.Name, err)
+	}
+
+	ds := memory.New()
+	opts := append([]server.OpenFGAServiceV1Option{
+		server.WithDatastore(ds),
+		server.WithLogger(logger.NewNoopLogger()),
+	}, config.Options...)
+	s, err := server.NewServerWithOpts(opts...)
+	if err != nil {
+		ds.Close()
+		return nil, fmt.Errorf("configuration '%s' is invalid: %w", config.Name, err)
+	}
+	h := &Harness{datastore: ds, server: s}
+
+	if err := h.setup(ctx, scenario, model); err != nil {
+		h.Close()
+		return nil, fmt.Errorf("failed to set up scenario '%s': %w", scenario.Name, err)
+	}
+	return h, nil
+}
+
+func (h *Harness) setup(ctx context.Context, scenario Scenario, model *openfgav1.AuthorizationModel) error {
+	store, err := h.server.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: scenario.Name})
+	if err != nil {
+		return err
+	}
+	h.storeID = store.GetId()
+
+	written, err := h.server.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         h.storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+		Conditions:      model.GetConditions(),
+	})
+	if err != nil {
+		return err
+	}
+	h.modelID = written.GetAuthorizationModelId()
+
+	batchSize := h.datastore.MaxTuplesPerWrite()
+	for start := 0; start < len(scenario.Tuples); start += batchSize {
+		end := min(start+batchSize, len(scenario.Tuples))
+		_, err := h.server.Write(ctx, &openfgav1.WriteRequest{
+			StoreId:              h.storeID,
+			AuthorizationModelId: h.modelID,
+			Writes:               &openfgav1.WriteRequestWrites{TupleKeys: scenario.Tuples[start:end]},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check runs a check against the store of the harness.
+func (h *Harness) Check(ctx context.Context, check Check) (Sample, error) {
+	tags := grpc_ctxtags.NewTags()
+	ctx = grpc_ctxtags.SetInContext(ctx, tags)
+
+	start := time.Now()
+	resp, err := h.server.Check(ctx, &openfgav1.CheckRequest{
+		StoreId:              h.storeID,
+		AuthorizationModelId: h.modelID,
+		TupleKey:             check.TupleKey,
+	})
+	if err != nil {
+		return Sample{}, err
+	}
+	sample := Sample{Allowed: resp.GetAllowed(), Latency: time.Since(start)}
+
+	values := tags.Values()
+	sample.Dispatches, _ = values[dispatchCountTag].(float64)
+	sample.DatastoreQueries, _ = values[datastoreQueryCountTag].(float64)
+	return sample, nil
+}
+
+// Close stops the server of the harness.
+func (h *Harness) Close() {
+	h.server.Close()
+	h.datastore.Close()
+}
+
+// Run benchmarks the checks of the scenario, in turn, against a harness with the configuration.
+// It fails the benchmark if a check fails or has an unexpected result, and reports the mean
+// dispatches and datastore queries, and the 99th percentile of the latency, of the checks.
+func Run(b *testing.B, scenario Scenario, config Configuration) Result {
+	b.Helper()
+
+	ctx := context.Background()
+	h, err := NewHarness(ctx, scenario, config)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer h.Close()
+
+	samples := make([]Sample, 0, b.N)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		check := scenario.Checks[i%len(scenario.Checks)]
+		sample, err := h.Check(ctx, check)
+		if err != nil {
+			b.Fatalf("check %s failed: %v", check.TupleKey, err)
+		}
+		if sample.Allowed != check.Allowed {
+			b.Fatalf("check %s returned allowed=%t, expected %t", check.TupleKey, sample.Allowed, check.Allowed)
+		}
+		samples = append(samples, sample)
+	}
+	b.StopTimer()
+
+	result := NewResult(scenario.Name, config.Name, samples)
+	b.ReportMetric(result.MeanDispatches, "dispatches/op")
+	b.ReportMetric(result.MeanDatastoreQueries, "queries/op")
+	b.ReportMetric(float64(result.P99.Nanoseconds()), "p99-ns/op")
+	return result
+}
+
+// Result is the summary of the samples of a [Scenario] on a [Configuration].
+type Result struct {
+	Scenario      string
+	Configuration string
+	Checks        int
+
+	P50 time.Duration
+	P99 time.Duration
+
+	MeanDispatches       float64
+	MeanDatastoreQueries float64
+}
+
+// NewResult summarizes the samples of a scenario on a configuration.
+func NewResult(scenario, configuration string, samples []Sample) Result {
+	result := Result{Scenario: scenario, Configuration: configuration, Checks: len(samples)}
+	if len(samples) == 0 {
+		return result
+	}
+
+	latencies := make([]time.Duration, 0, len(samples))
+	for _, sample := range samples {
+		latencies = append(latencies, sample.Latency)
+		result.MeanDispatches += sample.Dispatches
+		result.MeanDatastoreQueries += sample.DatastoreQueries
+	}
+	result.MeanDispatches /= float64(len(samples))
+	result.MeanDatastoreQueries /= float64(len(samples))
+
+	slices.Sort(latencies)
+	result.P50 = latencies[(len(latencies)-1)*50/100]
+	result.P99 = latencies[(len(latencies)-1)*99/100]
+	return result
+}
+
+// Summarize writes the results as a markdown table, in order.
+func Summarize(w io.Writer, results []Result) error {
+	if _, err := fmt.Fprintln(w, "| scenario | configuration | checks | p50 | p99 | dispatches/check | queries/check |"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "|---|---|---:|---:|---:|---:|---:|"); err != nil {
+		return err
+	}
+	for _, r := range results {
+		_, err := fmt.Fprintf(w, "| %s | %s | %d | %s | %s | %.1f | %.1f |\n",
+			r.Scenario, r.Configuration, r.Checks, r.P50, r.P99, r.MeanDispatches, r.MeanDatastoreQueries)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
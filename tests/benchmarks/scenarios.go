@@ -0,0 +1,199 @@
+// Package benchmarks runs Check against a server on the memory datastore through scenarios
+// that stress the graph resolution in different ways, across configurations of the resolver,
+// to compare their latency, dispatches and datastore queries. See [Run] and [Summarize].
+//
+// To evaluate a model of your own, write a [Scenario] with its model, tuples and checks, and run
+// it across the configurations that you want to compare, e.g. in a benchmark like the ones of
+// this package:
+//
+//	func BenchmarkMyModel(b *testing.B) {
+//		for _, config := range benchmarks.DefaultConfigurations() {
+//			b.Run(config.Name, func(b *testing.B) {
+//				benchmarks.Run(b, myScenario, config)
+//			})
+//		}
+//	}
+package benchmarks
+
+import (
+	"fmt"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// Scenario is a model, the tuples written to a store with it, and the checks run against them.
+type Scenario struct {
+	Name   string
+	Model  string
+	Tuples []*openfgav1.TupleKey
+	Checks []Check
+}
+
+// Check is a check of a [Scenario] and its expected result.
+type Check struct {
+	TupleKey *openfgav1.CheckRequestTupleKey
+	Allowed  bool
+}
+
+// DefaultScenarios returns the scenarios of the benchmarks of this package, at sizes that take
+// a few milliseconds at most to resolve on the default configuration.
+func DefaultScenarios() []Scenario {
+	return []Scenario{
+		DeepHierarchy(20),
+		WideUnion(50),
+		TupleToUsersetChain(20),
+		DirectUsersets(1_000),
+		Exclusion(500),
+	}
+}
+
+// DeepHierarchy returns groups nested depth levels deep, where the member of the innermost
+// group is a member of the outermost one through depth usersets.
+func DeepHierarchy(depth int) Scenario {
+	tuples := make([]*openfgav1.TupleKey, 0, depth+1)
+	for i := 0; i < depth; i++ {
+		tuples = append(tuples, tuple.NewTupleKey(fmt.Sprintf("group:%d", i), "member", fmt.Sprintf("group:%d#member", i+1)))
+	}
+	tuples = append(tuples, tuple.NewTupleKey(fmt.Sprintf("group:%d", depth), "member", "user:inner"))
+
+	return Scenario{
+		Name: fmt.Sprintf("deep_hierarchy_%d", depth),
+		Model: `
+			model
+				schema 1.1
+			type user
+			type group
+				relations
+					define member: [user, group#member]`,
+		Tuples: tuples,
+		Checks: []Check{
+			{TupleKey: tuple.NewCheckRequestTupleKey("group:0", "member", "user:inner"), Allowed: true},
+			{TupleKey: tuple.NewCheckRequestTupleKey("group:0", "member", "user:outsider"), Allowed: false},
+		},
+	}
+}
+
+// WideUnion returns a relation that is the union of width relations, of which only the last one
+// has a tuple.
+func WideUnion(width int) Scenario {
+	var model strings.Builder
+	model.WriteString("model\n  schema 1.1\ntype user\ntype document\n  relations\n")
+	operands := make([]string, 0, width)
+	for i := 0; i < width; i++ {
+		fmt.Fprintf(&model, "    define r%d: [user]\n", i)
+		operands = append(operands, fmt.Sprintf("r%d", i))
+	}
+	fmt.Fprintf(&model, "    define viewer: %s\n", strings.Join(operands, " or "))
+
+	return Scenario{
+		Name:   fmt.Sprintf("wide_union_%d", width),
+		Model:  model.String(),
+		Tuples: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", fmt.Sprintf("r%d", width-1), "user:last")},
+		Checks: []Check{
+			{TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:last"), Allowed: true},
+			{TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:outsider"), Allowed: false},
+		},
+	}
+}
+
+// TupleToUsersetChain returns a document in a chain of length nested folders, whose viewers are
+// the viewers of their parent, and a viewer of the outermost folder.
+func TupleToUsersetChain(length int) Scenario {
+	tuples := make([]*openfgav1.TupleKey, 0, length+1)
+	tuples = append(tuples, tuple.NewTupleKey("document:1", "parent", "folder:0"))
+	for i := 0; i < length-1; i++ {
+		tuples = append(tuples, tuple.NewTupleKey(fmt.Sprintf("folder:%d", i), "parent", fmt.Sprintf("folder:%d", i+1)))
+	}
+	tuples = append(tuples, tuple.NewTupleKey(fmt.Sprintf("folder:%d", length-1), "viewer", "user:root"))
+
+	return Scenario{
+		Name: fmt.Sprintf("ttu_chain_%d", length),
+		Model: `
+			model
+				schema 1.1
+			type user
+			type folder
+				relations
+					define parent: [folder]
+					define viewer: [user] or viewer from parent
+			type document
+				relations
+					define parent: [folder]
+					define viewer: [user] or viewer from parent`,
+		Tuples: tuples,
+		Checks: []Check{
+			{TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:root"), Allowed: true},
+			{TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:outsider"), Allowed: false},
+		},
+	}
+}
+
+// DirectUsersets returns a document shared with count groups, of which only the last one has a
+// member.
+func DirectUsersets(count int) Scenario {
+	tuples := make([]*openfgav1.TupleKey, 0, count+1)
+	for i := 0; i < count; i++ {
+		tuples = append(tuples, tuple.NewTupleKey("document:1", "viewer", fmt.Sprintf("group:%d#member", i)))
+	}
+	tuples = append(tuples, tuple.NewTupleKey(fmt.Sprintf("group:%d", count-1), "member", "user:last"))
+
+	return Scenario{
+		Name: fmt.Sprintf("direct_usersets_%d", count),
+		Model: `
+			model
+				schema 1.1
+			type user
+			type group
+				relations
+					define member: [user]
+			type document
+				relations
+					define viewer: [user, group#member]`,
+		Tuples: tuples,
+		Checks: []Check{
+			{TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:last"), Allowed: true},
+			{TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:outsider"), Allowed: false},
+		},
+	}
+}
+
+// Exclusion returns a document allowed to count groups and blocked to count other groups, and a
+// member of the last allowed group who is a member of the last blocked group or not.
+func Exclusion(count int) Scenario {
+	tuples := make([]*openfgav1.TupleKey, 0, 2*count+3)
+	for i := 0; i < count; i++ {
+		tuples = append(tuples,
+			tuple.NewTupleKey("document:1", "allowed", fmt.Sprintf("group:allowed%d#member", i)),
+			tuple.NewTupleKey("document:1", "blocked", fmt.Sprintf("group:blocked%d#member", i)),
+		)
+	}
+	tuples = append(tuples,
+		tuple.NewTupleKey(fmt.Sprintf("group:allowed%d", count-1), "member", "user:allowed"),
+		tuple.NewTupleKey(fmt.Sprintf("group:allowed%d", count-1), "member", "user:blocked"),
+		tuple.NewTupleKey(fmt.Sprintf("group:blocked%d", count-1), "member", "user:blocked"),
+	)
+
+	return Scenario{
+		Name: fmt.Sprintf("exclusion_%d", count),
+		Model: `
+			model
+				schema 1.1
+			type user
+			type group
+				relations
+					define member: [user]
+			type document
+				relations
+					define allowed: [user, group#member]
+					define blocked: [user, group#member]
+					define viewer: allowed but not blocked`,
+		Tuples: tuples,
+		Checks: []Check{
+			{TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:allowed"), Allowed: true},
+			{TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:blocked"), Allowed: false},
+		},
+	}
+}
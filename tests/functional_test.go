@@ -464,7 +464,7 @@ func GRPCWriteTest(t *testing.T, client openfgav1.OpenFGAServiceClient) {
 			},
 			output: output{
 				errorCode:    codes.InvalidArgument,
-				errorMessage: `value does not match regex pattern "^[ABCDEFGHJKMNPQRSTVWXYZ0-9]{26}$`,
+				errorMessage: "store ID must be a valid ULID",
 			},
 		},
 		{
@@ -476,7 +476,7 @@ func GRPCWriteTest(t *testing.T, client openfgav1.OpenFGAServiceClient) {
 			},
 			output: output{
 				errorCode:    codes.InvalidArgument,
-				errorMessage: `value does not match regex pattern "^[ABCDEFGHJKMNPQRSTVWXYZ0-9]{26}$`,
+				errorMessage: "authorization model ID must be a valid ULID",
 			},
 		},
 		{
@@ -28,13 +28,28 @@ func TestListObjectsSQLite(t *testing.T) {
 	testRunAll(t, "sqlite")
 }
 
+// TestListObjectsStrategies runs the tests with every strategy forced, so that the strategies
+// that the planner picks for some relations are validated against every relation.
+func TestListObjectsStrategies(t *testing.T) {
+	for _, strategy := range []string{"direct", "reverse_expand", "check_candidates", "filtered_base"} {
+		t.Run(strategy, func(t *testing.T) {
+			testRunAllWithStrategy(t, "memory", strategy)
+		})
+	}
+}
+
 func testRunAll(t *testing.T, engine string) {
+	testRunAllWithStrategy(t, engine, config.DefaultListObjectsStrategy)
+}
+
+func testRunAllWithStrategy(t *testing.T, engine, strategy string) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
 	})
 	cfg := config.MustDefaultConfig()
 	cfg.Log.Level = "error"
 	cfg.Datastore.Engine = engine
+	cfg.ListObjectsStrategy = strategy
 	cfg.ListObjectsDeadline = 0 // no deadline
 	// extend the timeout for the tests, coverage makes them slower
 	cfg.RequestTimeout = 10 * time.Second